@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/tests/load"
+)
+
+// Loadtest binary: runs concurrent signup, login, upload, list, and metrics journeys against a running server
+// and exits non-zero when an endpoint's p95 latency or error rate is over budget, e.g.
+//
+//	loadtest -server http://localhost:8080 -users 50 -iterations 4
+//	loadtest -server https://staging.example.com -users 20 -budget-scale 2
+//
+// Every journey creates an account, so point it at a demo or staging server, never production.
+// Metrics are only timed when the server analyzes uploads or gives new accounts sample reports (DEMO_MODE).
+func main() {
+	server := flag.String("server", "http://localhost:8080", "API base URL")
+	users := flag.Int("users", 10, "virtual users running journeys at once")
+	iterations := flag.Int("iterations", 5, "journeys each virtual user runs")
+	timeout := flag.Duration("timeout", 30*time.Second, "timeout for each request")
+	budgetScale := flag.Float64("budget-scale", 1, "multiply every latency budget, e.g. 2 for a slower staging machine")
+	maxErrorRate := flag.Float64("max-error-rate", 0.01, "share of an endpoint's requests allowed to fail")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	report, err := load.Run(ctx, load.Options{
+		BaseURL:    *server,
+		Users:      *users,
+		Iterations: *iterations,
+		Timeout:    *timeout,
+	})
+	if err != nil {
+		log.Fatalf("Load test stopped: %v", err)
+	}
+	fmt.Print(report)
+
+	budgets := make(map[string]time.Duration, len(load.DefaultBudgets))
+	for endpoint, budget := range load.DefaultBudgets {
+		budgets[endpoint] = time.Duration(float64(budget) * *budgetScale)
+	}
+	violations := report.Check(budgets, *maxErrorRate)
+	for _, violation := range violations {
+		fmt.Println("OVER BUDGET", violation)
+	}
+	if len(violations) > 0 {
+		os.Exit(1)
+	}
+}
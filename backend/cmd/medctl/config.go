@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// defaultServer is used when neither a flag, MEDCTL_SERVER, nor the config file names a server
+const defaultServer = "http://localhost:8080"
+
+// Config is what medctl remembers between runs
+type Config struct {
+	Server string `json:"server,omitempty"`
+	APIKey string `json:"api_key,omitempty"`
+	Token  string `json:"token,omitempty"` // Session token from medctl login; the API key wins when both are set
+	Email  string `json:"email,omitempty"` // Who the token belongs to, shown by medctl whoami and as the login default
+
+	RefreshToken   string     `json:"refresh_token,omitempty"`    // Renews the session token once it expires
+	TokenExpiresAt *time.Time `json:"token_expires_at,omitempty"` // When the session token expires
+}
+
+// configPath returns where the config file lives: the -config flag, MEDCTL_CONFIG, or the user config directory
+func configPath(flagValue string) (string, error) {
+	if flagValue != "" {
+		return flagValue, nil
+	}
+	if env := os.Getenv("MEDCTL_CONFIG"); env != "" {
+		return env, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot locate a config directory, pass -config: %w", err)
+	}
+	return filepath.Join(dir, "medctl", "config.json"), nil
+}
+
+// loadConfig reads the config file; a missing file is an empty config
+func loadConfig(path string) (*Config, error) {
+	cfg := &Config{}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// saveConfig writes the config file readable only by its owner, since it holds credentials
+func saveConfig(path string, cfg *Config) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Decision: Write beside the file and rename, so an interrupted save never leaves a half-written config
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".config-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// resolve applies flags and environment over the config file: flags > MEDCTL_SERVER/MEDCTL_API_KEY > file
+func (c *Config) resolve(server, apiKey string) (resolvedServer, credential string) {
+	resolvedServer = firstNonEmpty(server, os.Getenv("MEDCTL_SERVER"), c.Server, defaultServer)
+	credential = firstNonEmpty(apiKey, os.Getenv("MEDCTL_API_KEY"), c.APIKey, c.Token)
+	return strings.TrimRight(resolvedServer, "/"), credential
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,587 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/client"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// Medctl binary: the API from a terminal or a script, e.g.
+//
+//	medctl login -email dr.rao@example.com
+//	medctl upload -wait cbc.pdf
+//	medctl list -limit 5
+//	medctl -json metrics 42 | jq '.metrics[] | select(.status != "normal")'
+//	MEDCTL_API_KEY=mk_... medctl summary 42
+//
+// Credentials come from -api-key, then MEDCTL_API_KEY, then the config file's API key, then the token saved by login.
+// The server comes from -server, then MEDCTL_SERVER, then the config file, then http://localhost:8080.
+func main() {
+	server := flag.String("server", "", "API base URL (default from MEDCTL_SERVER or the config file)")
+	apiKey := flag.String("api-key", "", "API key to authenticate with (default from MEDCTL_API_KEY or the config file)")
+	configFlag := flag.String("config", "", "config file (default $MEDCTL_CONFIG or <user config dir>/medctl/config.json)")
+	jsonOutput := flag.Bool("json", false, "print the API's JSON instead of text")
+	timeout := flag.Duration("timeout", client.DefaultTimeout, "timeout for each request")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	path, err := configPath(*configFlag)
+	if err != nil {
+		fail(err)
+	}
+	cfg, err := loadConfig(path)
+	if err != nil {
+		fail(err)
+	}
+	resolvedServer, credential := cfg.resolve(*server, *apiKey)
+
+	app := &app{
+		ctx:        context.Background(),
+		configPath: path,
+		config:     cfg,
+		api:        newAPIClient(resolvedServer, credential, *timeout),
+		timeout:    *timeout,
+		json:       *jsonOutput,
+		out:        os.Stdout,
+	}
+
+	command, args := flag.Arg(0), flag.Args()[1:]
+	run, ok := commands[command]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "medctl: unknown command %q\n\n", command)
+		usage()
+		os.Exit(2)
+	}
+	if credential == cfg.Token && command != "login" && command != "logout" {
+		if err := app.renewSession(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not renew the session: %v\n", err)
+		}
+	}
+	if err := run(app, args); err != nil {
+		if client.IsStatus(err, http.StatusUnauthorized) {
+			err = fmt.Errorf("%w (run medctl login, or set -api-key or MEDCTL_API_KEY)", err)
+		}
+		fail(err)
+	}
+}
+
+// commands maps each subcommand to its implementation
+var commands = map[string]func(*app, []string) error{
+	"login":     (*app).login,
+	"logout":    (*app).logout,
+	"configure": (*app).configure,
+	"whoami":    (*app).whoami,
+	"upload":    (*app).upload,
+	"list":      (*app).list,
+	"summary":   (*app).summary,
+	"metrics":   (*app).metrics,
+	"chat":      (*app).chat,
+	"keys":      (*app).keys,
+}
+
+func usage() {
+	fmt.Fprint(os.Stderr, `Usage: medctl [flags] <command> [arguments]
+
+Commands:
+  login [-email E] [-password-stdin]       sign in and save the session token
+  logout                                   end the session and forget its token
+  configure [-server URL] [-api-key KEY]   save the server or an API key to the config file
+  whoami                                   show the signed-in account
+  upload [-wait] [-reading-level L] FILE   upload a report; -wait follows it until analyzed
+  list [-limit N] [-offset N]              list your reports
+  summary ID                               print a report's summary
+  metrics ID                               print a report's health metrics
+  chat ID [-voice FILE] [-format F]        print the report's conversation, or ask a recorded question
+  keys create NAME | keys list | keys revoke ID
+                                           manage API keys (requires a password login)
+
+Flags:
+`)
+	flag.PrintDefaults()
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "medctl: %v\n", err)
+	os.Exit(1)
+}
+
+func newAPIClient(server, credential string, timeout time.Duration) *client.Client {
+	api := client.New(server, credential)
+	api.SetHTTPClient(&http.Client{Timeout: timeout})
+	api.SetUserAgent("medctl")
+	return api
+}
+
+// app is the state every command shares
+type app struct {
+	ctx        context.Context
+	configPath string
+	config     *Config
+	api        *client.Client
+	timeout    time.Duration
+	json       bool
+	out        io.Writer
+}
+
+// printJSON prints a response under -json
+func (a *app) printJSON(value any) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = a.out.Write(append(data, '\n'))
+	return err
+}
+
+// login exchanges an email and password for a session token and saves it
+func (a *app) login(args []string) error {
+	flags := flag.NewFlagSet("login", flag.ExitOnError)
+	email := flags.String("email", a.config.Email, "account email")
+	passwordStdin := flags.Bool("password-stdin", false, "read the password from stdin without prompting")
+	flags.Parse(args)
+
+	stdin := bufio.NewReader(os.Stdin)
+	if *email == "" {
+		fmt.Fprint(os.Stderr, "Email: ")
+		line, err := stdin.ReadString('\n')
+		if err != nil && line == "" {
+			return fmt.Errorf("no email given")
+		}
+		*email = strings.TrimSpace(line)
+	}
+	if !*passwordStdin {
+		// Decision: No terminal raw mode without a dependency, so the prompt warns that input is visible
+		fmt.Fprint(os.Stderr, "Password (input is shown; use -password-stdin to pipe it): ")
+	}
+	password, err := stdin.ReadString('\n')
+	if err != nil && password == "" {
+		return fmt.Errorf("no password given")
+	}
+	password = strings.TrimRight(password, "\r\n")
+
+	response, err := a.api.Login(a.ctx, *email, password)
+	if err != nil {
+		return err
+	}
+
+	a.config.Token = response.Token
+	a.config.RefreshToken = response.RefreshToken
+	a.config.TokenExpiresAt = &response.ExpiresAt
+	a.config.Email = response.User.Email
+	if a.config.Server == "" {
+		a.config.Server = a.api.BaseURL()
+	}
+	if err := saveConfig(a.configPath, a.config); err != nil {
+		return fmt.Errorf("signed in but could not save the token: %w", err)
+	}
+	fmt.Fprintf(a.out, "Signed in as %s\n", response.User.Email)
+	if a.config.APIKey != "" {
+		fmt.Fprintln(os.Stderr, "Note: the config file also holds an API key, which is used instead of this session")
+	}
+	return nil
+}
+
+// logout revokes the saved session token and removes it from the config file
+func (a *app) logout(args []string) error {
+	if a.config.Token == "" {
+		fmt.Fprintln(a.out, "Not signed in")
+		return nil
+	}
+	if err := a.session().Logout(a.ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: the server did not end the session: %v\n", err)
+	}
+	a.config.Token, a.config.RefreshToken, a.config.TokenExpiresAt = "", "", nil
+	if err := saveConfig(a.configPath, a.config); err != nil {
+		return err
+	}
+	fmt.Fprintln(a.out, "Signed out")
+	return nil
+}
+
+// session returns a client using the token saved by login, falling back to the configured credential
+// Decision: Key management prefers the session even when an API key is configured, since keys can't manage keys
+func (a *app) session() *client.Client {
+	if a.config.Token == "" {
+		return a.api
+	}
+	session := newAPIClient(a.api.BaseURL(), a.config.Token, a.timeout)
+	session.SetRefreshToken(a.config.RefreshToken)
+	return session
+}
+
+// renewSession exchanges the saved refresh token for new tokens once the session token is about to expire
+// Decision: The refresh token is spent by the exchange, so the new one is saved before the command runs; a
+// run that renewed without saving would sign the next run out
+func (a *app) renewSession() error {
+	if a.config.RefreshToken == "" || a.config.TokenExpiresAt == nil || time.Until(*a.config.TokenExpiresAt) > time.Minute {
+		return nil
+	}
+	session := a.session()
+	response, err := session.Refresh(a.ctx)
+	if err != nil {
+		return err
+	}
+	a.config.Token, a.config.RefreshToken, a.config.TokenExpiresAt = response.Token, response.RefreshToken, &response.ExpiresAt
+	if err := saveConfig(a.configPath, a.config); err != nil {
+		return fmt.Errorf("renewed but could not save the tokens: %w", err)
+	}
+	a.api.SetToken(response.Token)
+	return nil
+}
+
+// configure stores the server and API key, so later runs need no flags
+func (a *app) configure(args []string) error {
+	flags := flag.NewFlagSet("configure", flag.ExitOnError)
+	server := flags.String("server", "", "API base URL to save")
+	apiKey := flags.String("api-key", "", "API key to save; \"-\" reads it from stdin, \"none\" removes it")
+	flags.Parse(args)
+
+	if *server == "" && *apiKey == "" {
+		fmt.Fprintf(a.out, "Config file: %s\nServer:      %s\nAPI key:     %s\nSigned in:   %s\n",
+			a.configPath, firstNonEmpty(a.config.Server, defaultServer), maskKey(a.config.APIKey),
+			firstNonEmpty(a.config.Email, "no"))
+		return nil
+	}
+	if *server != "" {
+		if parsed, err := url.Parse(*server); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("server must be an http or https URL")
+		}
+		a.config.Server = strings.TrimRight(*server, "/")
+	}
+	switch *apiKey {
+	case "":
+	case "none":
+		a.config.APIKey = ""
+	case "-":
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return fmt.Errorf("no API key on stdin")
+		}
+		a.config.APIKey = strings.TrimSpace(line)
+	default:
+		a.config.APIKey = *apiKey
+	}
+	if err := saveConfig(a.configPath, a.config); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "Saved %s\n", a.configPath)
+	return nil
+}
+
+// whoami shows whose credentials are in use
+func (a *app) whoami(args []string) error {
+	user, err := a.api.Me(a.ctx)
+	if err != nil {
+		return err
+	}
+	if a.json {
+		return a.printJSON(user)
+	}
+	fmt.Fprintf(a.out, "%s (%s), %s plan\n", user.Email, firstNonEmpty(user.FullName, "no name"), user.Plan)
+	return nil
+}
+
+// upload sends a report file and, with -wait, polls until its analysis finishes
+func (a *app) upload(args []string) error {
+	flags := flag.NewFlagSet("upload", flag.ExitOnError)
+	readingLevel := flags.String("reading-level", "", "child, standard, or clinical (default: your account setting)")
+	wait := flags.Bool("wait", false, "wait until the report is analyzed, then print its summary")
+	waitTimeout := flags.Duration("wait-timeout", 10*time.Minute, "how long -wait waits")
+	files := parseInterspersed(flags, args)
+	if len(files) != 1 {
+		return fmt.Errorf("usage: medctl upload [-wait] [-reading-level L] FILE")
+	}
+
+	file, err := os.Open(files[0])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	response, err := a.api.UploadReport(a.ctx, filepath.Base(files[0]), file, client.UploadOptions{ReadingLevel: *readingLevel})
+	if err != nil {
+		return err
+	}
+	if !*wait {
+		if a.json {
+			return a.printJSON(response)
+		}
+		fmt.Fprintf(a.out, "Uploaded report %d\n", response.ReportID)
+		if response.MergeCandidate != nil {
+			fmt.Fprintf(a.out, "This looks like another page of report %d (%s); merge with POST %s\n",
+				response.MergeCandidate.ReportID, response.MergeCandidate.OriginalFilename, response.MergeCandidate.MergeURL)
+		}
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "Uploaded report %d, waiting for analysis...\n", response.ReportID)
+	ctx, cancel := context.WithTimeout(a.ctx, *waitTimeout)
+	defer cancel()
+	status, err := a.api.WaitForReport(ctx, response.ReportID, time.Second)
+	if err == context.DeadlineExceeded && status != nil {
+		return fmt.Errorf("report %d is still %s after %s", response.ReportID, status.Status, *waitTimeout)
+	}
+	if err != nil {
+		return err
+	}
+	if status.Status != "completed" {
+		return fmt.Errorf("report %d %s: %s", response.ReportID, status.Status, firstNonEmpty(status.ErrorDetail, status.ErrorCode))
+	}
+	return a.summary([]string{strconv.Itoa(response.ReportID)})
+}
+
+// list prints a page of the user's reports
+func (a *app) list(args []string) error {
+	flags := flag.NewFlagSet("list", flag.ExitOnError)
+	limit := flags.Int("limit", 20, "reports per page")
+	offset := flags.Int("offset", 0, "reports to skip")
+	flags.Parse(args)
+
+	response, err := a.api.ListReports(a.ctx, *limit, *offset)
+	if err != nil {
+		return err
+	}
+	if a.json {
+		return a.printJSON(response)
+	}
+	if len(response.Reports) == 0 {
+		fmt.Fprintln(a.out, "No reports")
+		return nil
+	}
+	table := tabwriter.NewWriter(a.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "ID\tUPLOADED\tTITLE")
+	for _, report := range response.Reports {
+		fmt.Fprintf(table, "%d\t%s\t%s\n", report.ID, report.UploadDate.Local().Format("2006-01-02 15:04"),
+			firstNonEmpty(report.Title, report.OriginalFilename))
+	}
+	if err := table.Flush(); err != nil {
+		return err
+	}
+	if shown := *offset + len(response.Reports); shown < response.Total {
+		fmt.Fprintf(a.out, "%d of %d; next page: medctl list -offset %d\n", shown, response.Total, shown)
+	}
+	return nil
+}
+
+// summary prints a report's summary in the account's language
+func (a *app) summary(args []string) error {
+	reportID, err := reportArg("summary", args)
+	if err != nil {
+		return err
+	}
+	response, err := a.api.GetReportSummary(a.ctx, reportID, "")
+	if err != nil {
+		return err
+	}
+	if a.json {
+		return a.printJSON(response)
+	}
+	fmt.Fprintf(a.out, "%s\n\n%s\n", firstNonEmpty(response.Report.Title, response.Report.OriginalFilename), response.Summary)
+	return nil
+}
+
+// metrics prints a report's metrics as a table, out-of-range results first
+func (a *app) metrics(args []string) error {
+	reportID, err := reportArg("metrics", args)
+	if err != nil {
+		return err
+	}
+	response, err := a.api.GetHealthMetrics(a.ctx, reportID, "")
+	if err != nil {
+		return err
+	}
+	if a.json {
+		return a.printJSON(response)
+	}
+
+	rank := func(status string) int {
+		switch status {
+		case "critical":
+			return 0
+		case "warning":
+			return 1
+		}
+		return 2
+	}
+	metrics := response.Metrics
+	sort.SliceStable(metrics, func(i, j int) bool { return rank(metrics[i].Status) < rank(metrics[j].Status) })
+
+	table := tabwriter.NewWriter(a.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "METRIC\tVALUE\tRANGE\tSTATUS")
+	for _, m := range metrics {
+		valueRange := ""
+		if m.RangeMin != 0 || m.RangeMax != 0 {
+			valueRange = fmt.Sprintf("%g-%g", m.RangeMin, m.RangeMax)
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\n", m.Name, strings.TrimSpace(fmt.Sprintf("%v %s", m.Value, m.Unit)), valueRange, m.Status)
+	}
+	return table.Flush()
+}
+
+// chat prints a report's conversation, or asks a recorded question with -voice
+func (a *app) chat(args []string) error {
+	flags := flag.NewFlagSet("chat", flag.ExitOnError)
+	voice := flags.String("voice", "", "audio file with a question to ask (webm, ogg, m4a, mp3, or wav)")
+	language := flags.String("language", "", "language spoken in -voice, e.g. hi (default: detected)")
+	format := flags.String("format", "markdown", "transcript format: markdown or json")
+	reportID, err := reportArg("chat", parseInterspersed(flags, args))
+	if err != nil {
+		return err
+	}
+
+	if *voice == "" {
+		if a.json {
+			*format = "json"
+		}
+		body, err := a.api.ExportChat(a.ctx, reportID, *format)
+		if err != nil {
+			return err
+		}
+		_, err = a.out.Write(body)
+		return err
+	}
+
+	audio, err := os.Open(*voice)
+	if err != nil {
+		return err
+	}
+	defer audio.Close()
+	message, err := a.api.AskByVoice(a.ctx, reportID, filepath.Base(*voice), audio, client.VoiceOptions{Language: *language})
+	if err != nil {
+		return err
+	}
+	if a.json {
+		return a.printJSON(message)
+	}
+	fmt.Fprintf(a.out, "You: %s\n\n%s\n", message.UserMessage, message.AIResponse)
+	return nil
+}
+
+// keys manages the account's API keys; the server only allows this with a password session
+func (a *app) keys(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: medctl keys create NAME | keys list | keys revoke ID")
+	}
+	session := a.session()
+	switch args[0] {
+	case "create":
+		flags := flag.NewFlagSet("keys create", flag.ExitOnError)
+		save := flags.Bool("save", false, "store the new key in the config file")
+		rest := parseInterspersed(flags, args[1:])
+		if len(rest) != 1 {
+			return fmt.Errorf("usage: medctl keys create [-save] NAME")
+		}
+		created, err := session.CreateAPIKey(a.ctx, rest[0])
+		if err != nil {
+			return err
+		}
+		if *save {
+			a.config.APIKey = created.Key
+			if err := saveConfig(a.configPath, a.config); err != nil {
+				return err
+			}
+		}
+		if a.json {
+			return a.printJSON(created)
+		}
+		fmt.Fprintf(a.out, "Created key %d (%s). It is shown only once:\n%s\n", created.ID, created.Name, created.Key)
+		return nil
+
+	case "list":
+		keys, err := session.ListAPIKeys(a.ctx)
+		if err != nil {
+			return err
+		}
+		if a.json {
+			return a.printJSON(types.APIKeysResponse{Keys: keys})
+		}
+		table := tabwriter.NewWriter(a.out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(table, "ID\tNAME\tPREFIX\tCREATED\tLAST USED\tREVOKED")
+		for _, key := range keys {
+			fmt.Fprintf(table, "%d\t%s\t%s…\t%s\t%s\t%s\n", key.ID, key.Name, key.Prefix,
+				key.CreatedAt.Local().Format("2006-01-02"), formatOptionalTime(key.LastUsedAt), formatOptionalTime(key.RevokedAt))
+		}
+		return table.Flush()
+
+	case "revoke":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: medctl keys revoke ID")
+		}
+		id, err := strconv.Atoi(args[1])
+		if err != nil || id < 1 {
+			return fmt.Errorf("invalid key ID %q", args[1])
+		}
+		if err := session.RevokeAPIKey(a.ctx, id); err != nil {
+			return err
+		}
+		fmt.Fprintf(a.out, "Revoked key %d\n", id)
+		return nil
+
+	default:
+		return fmt.Errorf("unknown keys command %q (expected create, list, or revoke)", args[0])
+	}
+}
+
+// reportArg reads the single report ID a command takes
+func reportArg(command string, args []string) (int, error) {
+	if len(args) != 1 {
+		return 0, fmt.Errorf("usage: medctl %s REPORT_ID", command)
+	}
+	reportID, err := strconv.Atoi(args[0])
+	if err != nil || reportID < 1 {
+		return 0, fmt.Errorf("invalid report ID %q", args[0])
+	}
+	return reportID, nil
+}
+
+// parseInterspersed parses flags given before or after positional arguments, returning the positionals
+// Decision: The flag package stops at the first positional, but "medctl chat 42 -voice q.m4a" reads naturally
+func parseInterspersed(flags *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		flags.Parse(args)
+		args = flags.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+// maskKey shows enough of a key to recognize it
+func maskKey(key string) string {
+	if key == "" {
+		return "none"
+	}
+	if len(key) <= 8 {
+		return "set"
+	}
+	return key[:8] + "…"
+}
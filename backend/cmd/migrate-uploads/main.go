@@ -0,0 +1,139 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/joho/godotenv"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/logging"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+)
+
+// Migrate-uploads binary: moves files from the old flat uploads directory into
+// the per-user {userHash}/{uuid}.ext layout and updates each report's file_path,
+// then applies UPLOAD_FILENAME_POLICY to each report's original filename, e.g.
+//
+//	migrate-uploads -dry-run
+//	migrate-uploads
+//
+// Safe to re-run: reports already in the new layout or with a conforming name are skipped.
+func main() {
+	dryRun := flag.Bool("dry-run", false, "list files that would move without changing anything")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Printf("Warning: Could not load .env file: %v", err)
+	}
+
+	cfg := config.Load()
+	if _, err := logging.Setup(cfg.Log); err != nil {
+		log.Fatalf("Invalid logging configuration: %v", err)
+	}
+	if _, err := services.LoadSecrets(cfg); err != nil {
+		log.Fatalf("Failed to load secrets: %v", err)
+	}
+
+	db, err := database.Setup(cfg)
+	if err != nil {
+		log.Fatalf("Failed to setup database: %v", err)
+	}
+	defer db.Close()
+
+	reportRepo := models.NewReportRepository(db.GetDB())
+	fileStorage := services.NewFileStorage(cfg.Upload.UploadPath, cfg.Upload.DirSecret)
+	if err := fileStorage.SetFilenamePolicy(cfg.Upload.FilenamePolicy); err != nil {
+		log.Fatalf("Invalid upload configuration: %v", err)
+	}
+
+	reports, err := reportRepo.ListByFilter(models.ReportFilter{})
+	if err != nil {
+		log.Fatalf("Failed to list reports: %v", err)
+	}
+
+	var moved, skipped, failed int
+	for _, report := range reports {
+		if fileStorage.IsUserPath(report.UserID, report.FilePath) {
+			skipped++
+			continue
+		}
+
+		// Decision: Never move files we can't prove live under the upload directory
+		oldPath, err := fileStorage.Resolve(report.FilePath)
+		if err != nil {
+			log.Printf("Report %d: skipping %q: %v", report.ID, report.FilePath, err)
+			failed++
+			continue
+		}
+
+		if _, err := os.Stat(oldPath); err != nil {
+			log.Printf("Report %d: skipping missing file %q", report.ID, report.FilePath)
+			failed++
+			continue
+		}
+
+		if *dryRun {
+			fmt.Printf("Report %d: would move %s\n", report.ID, report.FilePath)
+			moved++
+			continue
+		}
+
+		newPath, err := fileStorage.NewFilePath(report.UserID, report.OriginalFilename)
+		if err != nil {
+			log.Printf("Report %d: %v", report.ID, err)
+			failed++
+			continue
+		}
+
+		if err := os.Rename(oldPath, newPath); err != nil {
+			log.Printf("Report %d: failed to move file: %v", report.ID, err)
+			failed++
+			continue
+		}
+
+		// Decision: Put the file back if the database can't be updated so the row stays valid
+		if err := reportRepo.UpdateFilePath(report.ID, newPath); err != nil {
+			log.Printf("Report %d: failed to update file path: %v", report.ID, err)
+			if err := os.Rename(newPath, oldPath); err != nil {
+				log.Printf("Report %d: failed to restore %s: %v", report.ID, oldPath, err)
+			}
+			failed++
+			continue
+		}
+
+		fmt.Printf("Report %d: %s -> %s\n", report.ID, report.FilePath, newPath)
+		moved++
+	}
+
+	fmt.Printf("Moved %d, already migrated %d, failed %d\n", moved, skipped, failed)
+
+	// Decision: Names are only display data, so they are rewritten in place; the name as uploaded
+	// is not kept anywhere, since extension_only exists to drop it
+	var renamed, renameFailed int
+	for _, report := range reports {
+		name := fileStorage.OriginalFilename(report.OriginalFilename)
+		if name == report.OriginalFilename {
+			continue
+		}
+
+		if *dryRun {
+			fmt.Printf("Report %d: would rename %q to %q\n", report.ID, report.OriginalFilename, name)
+			renamed++
+			continue
+		}
+
+		if err := reportRepo.UpdateOriginalFilename(report.ID, name); err != nil {
+			log.Printf("Report %d: failed to update original filename: %v", report.ID, err)
+			renameFailed++
+			continue
+		}
+		fmt.Printf("Report %d: %q -> %q\n", report.ID, report.OriginalFilename, name)
+		renamed++
+	}
+
+	fmt.Printf("Renamed %d under the %s filename policy, failed %d\n", renamed, cfg.Upload.FilenamePolicy, renameFailed)
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/logging"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+)
+
+// Reprocess binary: re-analyzes reports matching filters, e.g.
+//
+//	reprocess -status failed -since 2025-01-01
+//	reprocess -user 42 -requeue
+//	reprocess -status completed -upgrade-schema
+func main() {
+	status := flag.String("status", "", "only reports with this processing status (pending|processing|completed|failed)")
+	since := flag.String("since", "", "only reports uploaded on or after this date (YYYY-MM-DD)")
+	until := flag.String("until", "", "only reports uploaded before this date (YYYY-MM-DD)")
+	userID := flag.Int("user", 0, "only reports owned by this user ID")
+	limit := flag.Int("limit", 0, "maximum number of reports to reprocess (0 = no limit)")
+	requeue := flag.Bool("requeue", false, "reset matches to pending for cmd/worker instead of processing here")
+	dryRun := flag.Bool("dry-run", false, "list matching reports without changing them")
+	upgradeSchema := flag.Bool("upgrade-schema", false, "migrate stored analyses to the current schema version without calling the AI")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Printf("Warning: Could not load .env file: %v", err)
+	}
+
+	filter := models.ReportFilter{
+		Status: *status,
+		UserID: *userID,
+		Limit:  *limit,
+	}
+	if *since != "" {
+		t, err := time.Parse("2006-01-02", *since)
+		if err != nil {
+			log.Fatalf("Invalid -since date: %v", err)
+		}
+		filter.Since = &t
+	}
+	if *until != "" {
+		t, err := time.Parse("2006-01-02", *until)
+		if err != nil {
+			log.Fatalf("Invalid -until date: %v", err)
+		}
+		filter.Until = &t
+	}
+
+	cfg := config.Load()
+	if _, err := logging.Setup(cfg.Log); err != nil {
+		log.Fatalf("Invalid logging configuration: %v", err)
+	}
+	if _, err := services.LoadSecrets(cfg); err != nil {
+		log.Fatalf("Failed to load secrets: %v", err)
+	}
+
+	db, err := database.Setup(cfg)
+	if err != nil {
+		log.Fatalf("Failed to setup database: %v", err)
+	}
+	defer db.Close()
+
+	reportRepo := models.NewReportRepository(db.GetDB())
+	reports, err := reportRepo.ListByFilter(filter)
+	if err != nil {
+		log.Fatalf("Failed to list reports: %v", err)
+	}
+
+	fmt.Printf("%d report(s) match\n", len(reports))
+	if *dryRun {
+		for _, report := range reports {
+			fmt.Printf("  #%d user=%d status=%s uploaded=%s %s\n", report.ID, report.UserID,
+				report.ProcessingStatus, report.UploadDate.Format(time.RFC3339), report.OriginalFilename)
+		}
+		return
+	}
+
+	// Decision: Schema upgrades are pure data migrations - no AI calls, status untouched
+	if *upgradeSchema {
+		var upgradedCount int
+		for _, report := range reports {
+			if report.ProcessingStatus != "completed" {
+				continue
+			}
+			upgraded, changed, err := services.UpgradeAnalysisJSON(report.SimplifiedSummary)
+			if err != nil {
+				log.Printf("Report %d: cannot upgrade analysis: %v", report.ID, err)
+				continue
+			}
+			if !changed {
+				continue
+			}
+			if err := reportRepo.UpdateSummary(report.ID, upgraded); err != nil {
+				log.Printf("Report %d: failed to store upgraded analysis: %v", report.ID, err)
+				continue
+			}
+			upgradedCount++
+		}
+		fmt.Printf("Upgraded %d analysis blob(s) to schema version %d\n", upgradedCount, services.CurrentAnalysisSchemaVersion)
+		return
+	}
+
+	// Decision: Requeue mode leaves the AI work to the worker fleet
+	if *requeue {
+		for _, report := range reports {
+			if err := reportRepo.UpdateProcessingStatus(report.ID, "pending", ""); err != nil {
+				log.Printf("Failed to requeue report %d: %v", report.ID, err)
+			}
+		}
+		fmt.Printf("Requeued %d report(s)\n", len(reports))
+		return
+	}
+
+	aiService, err := services.NewAIService(cfg.AI)
+	if err != nil {
+		log.Fatalf("Failed to initialize AI service: %v", err)
+	}
+	defer aiService.Close()
+
+	aiCallRecorder, err := services.NewAICallRecorder(models.NewAICallRepository(db.GetDB()), cfg.AI.CallLog)
+	if err != nil {
+		log.Fatalf("Invalid AI call log configuration: %v", err)
+	}
+	if aiCallRecorder != nil {
+		aiService.SetCallRecorder(aiCallRecorder)
+	}
+
+	processor := services.NewReportProcessor(reportRepo, models.NewJobRepository(db.GetDB()), models.NewAnalysisReviewRepository(db.GetDB()),
+		models.NewHealthProfileRepository(db.GetDB()), aiService, services.NewFileStorage(cfg.Upload.UploadPath, cfg.Upload.DirSecret))
+	processor.SetPartRepository(models.NewReportPartRepository(db.GetDB()))
+
+	var failed, skipped int
+	for _, report := range reports {
+		err := processor.ProcessReport(report)
+		if errors.Is(err, services.ErrReportClaimed) {
+			skipped++
+			log.Printf("Report %d skipped: another process is analyzing it", report.ID)
+			continue
+		}
+		if errors.Is(err, models.ErrLegalHold) {
+			skipped++
+			log.Printf("Report %d skipped: its owner's data is under a legal hold", report.ID)
+			continue
+		}
+		if err != nil {
+			failed++
+			log.Printf("Report %d failed: %v", report.ID, err)
+			continue
+		}
+		log.Printf("Report %d reprocessed", report.ID)
+	}
+
+	fmt.Printf("Reprocessed %d report(s), %d failed, %d skipped\n", len(reports)-failed-skipped, failed, skipped)
+}
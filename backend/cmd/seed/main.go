@@ -0,0 +1,108 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/joho/godotenv"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/logging"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+)
+
+// Seed binary: populates the configured database with demo users, analyzed
+// sample reports, and chat history, e.g.
+//
+//	seed -users 3 -password password123
+//
+// Pending migrations are applied on connecting unless DB_AUTO_MIGRATE=false; existing seed users are skipped.
+func main() {
+	userCount := flag.Int("users", 3, "number of demo users to create")
+	password := flag.String("password", "password123", "password for every demo user")
+	emailDomain := flag.String("domain", "example.com", "email domain for demo users")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Printf("Warning: Could not load .env file: %v", err)
+	}
+
+	cfg := config.Load()
+	if _, err := logging.Setup(cfg.Log); err != nil {
+		log.Fatalf("Invalid logging configuration: %v", err)
+	}
+	if _, err := services.LoadSecrets(cfg); err != nil {
+		log.Fatalf("Failed to load secrets: %v", err)
+	}
+
+	db, err := database.Setup(cfg)
+	if err != nil {
+		log.Fatalf("Failed to setup database: %v", err)
+	}
+	defer db.Close()
+
+	userRepo := models.NewUserRepository(db.GetDB())
+	reportRepo := models.NewReportRepository(db.GetDB())
+	chatRepo := models.NewChatMessageRepository(db.GetDB())
+	passwordService := services.NewPasswordService()
+	demoService := services.NewDemoService(reportRepo)
+
+	// Decision: Hash once - every seed user shares the password
+	passwordHash, err := passwordService.HashPassword(*password)
+	if err != nil {
+		log.Fatalf("Failed to hash password: %v", err)
+	}
+
+	var created int
+	for i := 1; i <= *userCount; i++ {
+		email := fmt.Sprintf("demo%d@%s", i, *emailDomain)
+
+		// Decision: Skip existing users so seeding twice doesn't duplicate reports
+		existing, err := userRepo.GetByEmail(email)
+		if err != nil {
+			log.Fatalf("Failed to look up %s: %v", email, err)
+		}
+		if existing != nil {
+			log.Printf("Skipping %s: already exists", email)
+			continue
+		}
+
+		user := &models.User{
+			Email:         email,
+			PasswordHash:  passwordHash,
+			FullName:      fmt.Sprintf("Demo User %d", i),
+			EmailVerified: true,
+			IsActive:      true,
+		}
+		if err := userRepo.Create(user); err != nil {
+			log.Fatalf("Failed to create %s: %v", email, err)
+		}
+
+		reports, err := demoService.ProvisionSampleReports(user.ID)
+		if err != nil {
+			log.Fatalf("Failed to create reports for %s: %v", email, err)
+		}
+
+		var messages int
+		for idx, report := range reports {
+			for _, exchange := range services.DemoSamples[idx].Chat {
+				message := &models.ChatMessage{
+					ReportID:    report.ID,
+					UserMessage: exchange.Question,
+					AIResponse:  exchange.Answer,
+				}
+				if err := chatRepo.Create(message); err != nil {
+					log.Fatalf("Failed to create chat history for report %d: %v", report.ID, err)
+				}
+				messages++
+			}
+		}
+
+		fmt.Printf("Created %s with %d report(s) and %d chat message(s)\n", email, len(reports), messages)
+		created++
+	}
+
+	fmt.Printf("Seeded %d user(s); log in with password %q\n", created, *password)
+}
@@ -1,52 +1,149 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"strings"
+	"time"
+	_ "time/tzdata" // Decision: Embed zone data so user timezones resolve in minimal containers
 
 	"github.com/joho/godotenv"
 	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
 	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
 	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/handlers"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/logging"
 	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
 	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
 	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/router"
 	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/worker"
 )
 
 func main() {
+	selfTest := flag.Bool("selftest", false, "check the database, schema, upload directory, and AI credentials, then exit")
+	migrationsDir := flag.String("migrations", "migrations", "migration directory -selftest compares the schema with")
+	flag.Parse()
+
 	// Decision: Load environment variables from .env file
-	if err := godotenv.Load(); err != nil {
-		log.Printf("Warning: Could not load .env file: %v", err)
-		log.Printf("Using system environment variables")
-	}
+	envErr := godotenv.Load()
 
 	// Decision: Load configuration from environment
 	cfg := config.Load()
-	log.Printf("Starting Medical Report Backend on %s:%s", cfg.Server.Host, cfg.Server.Port)
+	// Decision: Set up logging before anything logs, so every record is leveled and redacted
+	if _, err := logging.Setup(cfg.Log); err != nil {
+		log.Fatalf("Invalid logging configuration: %v", err)
+	}
+	if envErr != nil {
+		slog.Info("Could not load .env file, using system environment variables", "error", envErr)
+	}
+	// Decision: Secrets from a secrets manager replace the environment's before anything reads them
+	secretStore, err := services.LoadSecrets(cfg)
+	if err != nil {
+		logging.Fatal("Failed to load secrets", "error", err)
+	}
+	if *selfTest {
+		os.Exit(runSelfTest(cfg, *migrationsDir))
+	}
+	slog.Info("Starting Medical Report Backend", "host", cfg.Server.Host, "port", cfg.Server.Port)
 
 	// Decision: Initialize database connection
 	db, err := database.Setup(cfg)
 	if err != nil {
-		log.Fatalf("Failed to setup database: %v", err)
+		logging.Fatal("Failed to setup database", "error", err)
 	}
 	defer db.Close()
 
+	// Decision: Monitor database health in the background and reconnect with backoff
+	dbMonitor := database.NewHealthMonitor(db, cfg.Database.HealthCheckInterval, cfg.Database.ReconnectMaxBackoff)
+	dbMonitor.Start()
+	defer dbMonitor.Stop()
+
 	// Decision: Initialize repositories (data layer)
-	userRepo := models.NewUserRepository(db.GetDB())
-	reportRepo := models.NewReportRepository(db.GetDB())
+	var userRepo models.UserRepository = models.NewUserRepository(db.GetDB())
+	reportRepo := models.NewReportRepositoryWithReplica(db.GetDB(), db.GetReadDB())
+	transferRepo := models.NewReportTransferRepository(db.GetDB())
+	chatRepo := models.NewChatMessageRepository(db.GetDB())
+	auditRepo := models.NewAuditLogRepository(db.GetDB())
+	usageRepo := models.NewAPIUsageRepository(db.GetDB())
+	notificationRepo := models.NewNotificationRepository(db.GetDB())
+
+	// Decision: Side effects of uploads, analyses, and chats subscribe here instead of living in the code that publishes them
+	// With QUEUE_BACKEND=nats they run on whichever server receives each event, and workers consume uploads
+	eventBus, err := services.NewEventBus(cfg.Queue, "server")
+	if err != nil {
+		logging.Fatal("Failed to start event bus", "error", err)
+	}
+	defer eventBus.Close()
+	sharedQueue := strings.EqualFold(cfg.Queue.Backend, "nats")
+	services.SubscribeNotifications(eventBus, notificationRepo, reportRepo)
+	services.SubscribeAuditLog(eventBus, auditRepo)
+
+	// Decision: Refuse to start with push credentials we cannot load, like the other providers
+	pushSenders, err := services.NewPushSenders(cfg.Push)
+	if err != nil {
+		logging.Fatal("Invalid push notification configuration", "error", err)
+	}
+	pushService := services.NewPushService(models.NewPushDeviceRepository(db.GetDB()), pushSenders, cfg.Push.MaxDevices)
+	if len(pushSenders) == 0 {
+		slog.Info("Push notifications disabled - devices can register but receive nothing")
+	} else {
+		slog.Info("Push notifications enabled", "platforms", strings.Join(pushService.Platforms(), ","))
+		services.SubscribePush(eventBus, pushService, reportRepo)
+	}
+	deviceHandler := handlers.NewDeviceHandler(pushService)
+
+	// Decision: Cache user lookups so every authenticated request doesn't hit the users table
+	metricsHandler := handlers.NewMetricsHandler()
+	metricsHandler.Register("database", func() any { return dbMonitor.Status() })
+	if cfg.Cache.UserTTL > 0 {
+		cachedUserRepo := models.NewCachedUserRepository(userRepo, cfg.Cache.UserTTL)
+		metricsHandler.Register("user_cache", func() any { return cachedUserRepo.Stats() })
+		userRepo = cachedUserRepo
+	}
 
 	// Decision: Initialize services (business logic layer)
 	passwordService := services.NewPasswordService()
 	jwtService := services.NewJWTService(cfg.JWT.Secret, cfg.JWT.Expiration)
+	if secretStore != nil {
+		secretStore.OnRotate("JWT_SECRET", jwtService.Rotate)
+		secretsCtx, stopSecrets := context.WithCancel(context.Background())
+		defer stopSecrets()
+		go secretStore.Run(secretsCtx)
+	}
 	authService := services.NewAuthService(userRepo, passwordService, jwtService)
+	authService.SetTokenRevocation(models.NewRevokedTokenRepository(db.GetDB()))
+	authService.SetRefreshTokens(models.NewRefreshTokenRepository(db.GetDB()), cfg.JWT.RefreshExpiration)
+	var sessionService *services.SessionService
+	if cfg.JWT.MaxSessions > 0 {
+		sessionService = services.NewSessionService(models.NewSessionRepository(db.GetDB()), cfg.JWT.MaxSessions)
+		authService.SetSessionService(sessionService)
+		slog.Info("Session limit enabled", "sessions_per_user", cfg.JWT.MaxSessions)
+	}
+	transferService := services.NewTransferService(transferRepo, reportRepo, userRepo)
+	impersonationService := services.NewImpersonationService(userRepo, auditRepo, notificationRepo, jwtService,
+		cfg.Admin.ImpersonationTTL, cfg.Admin.Emails)
 
-	// Initialize AI service for Gemini integration
-	aiService, err := services.NewAIService(cfg.AI.GeminiAPIKey)
+	// Initialize AI service (Gemini or a self-hosted model, per AI_PROVIDER)
+	// Decision: Demo mode never calls a model, even when one is configured
+	var aiService *services.AIService
+	if !cfg.Demo.Enabled {
+		aiService, err = services.NewAIService(cfg.AI)
+	}
 	if err != nil {
-		log.Printf("Warning: AI service initialization failed: %v", err)
-		log.Printf("Report analysis will not be available")
+		slog.Warn("AI service initialization failed", "error", err)
+		if cfg.AI.BasicFallback {
+			slog.Warn("Reports will get a basic extraction only")
+		} else {
+			slog.Warn("Report analysis will not be available")
+		}
+	} else if aiService != nil {
+		slog.Info("AI provider ready", "provider", aiService.ProviderName())
+		metricsHandler.Register("ai_rate_limiter", func() any { return aiService.LimiterStats() })
 	}
 	defer func() {
 		if aiService != nil {
@@ -54,40 +151,435 @@ func main() {
 		}
 	}()
 
+	// Decision: Every analyzing process registers the canary and asks whether it is still live; only the
+	// server compares it with the stable prompt and rolls it back
+	var canaryService *services.PromptCanaryService
+	if aiService != nil {
+		canaryService = services.NewPromptCanaryService(models.NewPromptCanaryRepository(db.GetDB()), reportRepo, auditRepo, cfg.AI)
+	}
+	if canaryService != nil {
+		canary, err := canaryService.Start()
+		if err != nil {
+			logging.Fatal("Failed to start prompt canary", "error", err)
+		}
+		slog.Info("Prompt canary serving new reports", "version", canary.Version, "status", canary.Status, "percent", canary.Percent)
+		aiService.SetCanaryGate(canaryService.Live)
+		canaryCtx, stopCanary := context.WithCancel(context.Background())
+		defer stopCanary()
+		go canaryService.Run(canaryCtx)
+	}
+
+	// Decision: Keep each model call's prompt and response, encrypted, so wrong results can be traced;
+	// the server purges expired calls for every process writing to the same database
+	aiCallRecorder, err := services.NewAICallRecorder(models.NewAICallRepository(db.GetDB()), cfg.AI.CallLog)
+	if err != nil {
+		logging.Fatal("Invalid AI call log configuration", "error", err)
+	}
+	if aiCallRecorder != nil {
+		slog.Info("AI call log enabled", "retention", aiCallRecorder.Retention())
+		if aiService != nil {
+			aiService.SetCallRecorder(aiCallRecorder)
+		}
+		callLogCtx, stopCallLog := context.WithCancel(context.Background())
+		defer stopCallLog()
+		go aiCallRecorder.Run(callLogCtx)
+	}
+
+	// Decision: Every process meters the clinic tenants' usage it causes; the server measures their storage
+	organizationUsage := services.NewOrganizationUsageService(models.NewOrganizationUsageRepository(db.GetDB()))
+	if aiService != nil {
+		aiService.SetUsageMeter(organizationUsage)
+	}
+	usageMeterCtx, stopUsageMeter := context.WithCancel(context.Background())
+	defer stopUsageMeter()
+	go organizationUsage.Run(usageMeterCtx)
+
+	fileStorage := services.NewFileStorage(cfg.Upload.UploadPath, cfg.Upload.DirSecret)
+	if err := fileStorage.SetFilenamePolicy(cfg.Upload.FilenamePolicy); err != nil {
+		logging.Fatal("Invalid upload configuration", "error", err)
+	}
+
+	// Decision: Remove files of bulk-deleted reports in the background
+	janitorCtx, stopJanitor := context.WithCancel(context.Background())
+	defer stopJanitor()
+	janitor := worker.NewJanitor(models.NewFileCleanupRepository(db.GetDB()), fileStorage, cfg.Worker.JanitorInterval)
+	go janitor.Run(janitorCtx)
+
+	// Decision: Without inline processing, uploads stay pending for cmd/worker to pick up
+	jobRepo := models.NewJobRepository(db.GetDB())
+	reviewRepo := models.NewAnalysisReviewRepository(db.GetDB())
+	profileRepo := models.NewHealthProfileRepository(db.GetDB())
+	var reportProcessor *services.ReportProcessor
+	if cfg.Demo.Enabled {
+		reportProcessor = services.NewReportProcessorWithAnalyzer(reportRepo, jobRepo, reviewRepo, profileRepo, services.NewDemoAnalyzer(), fileStorage)
+	} else if sharedQueue {
+		slog.Info("Inline processing disabled - uploads are queued on NATS for cmd/worker")
+	} else if cfg.Worker.ProcessInline {
+		reportProcessor = services.NewReportProcessor(reportRepo, jobRepo, reviewRepo, profileRepo, aiService, fileStorage)
+	} else {
+		slog.Info("Inline processing disabled - run cmd/worker to process uploaded reports")
+	}
+	partRepo := models.NewReportPartRepository(db.GetDB())
+	if reportProcessor != nil {
+		reportProcessor.SetEventBus(eventBus)
+		reportProcessor.SetPartRepository(partRepo)
+		reportProcessor.SetUsageMeter(organizationUsage)
+		// Decision: Demo analyses never fail, so they need no fallback
+		if cfg.AI.BasicFallback && !cfg.Demo.Enabled {
+			reportProcessor.SetFallbackAnalyzer(services.NewBasicAnalyzer(cfg.AI.Extraction))
+		}
+		reportProcessor.SetRetryPolicy(services.RetryPolicy{MaxAttempts: cfg.Worker.MaxAttempts,
+			Backoff: cfg.Worker.RetryBackoff, MaxBackoff: cfg.Worker.RetryMaxBackoff})
+
+		// Decision: Inline processing runs the same worker as cmd/worker, so uploads share its concurrency limit,
+		// retries, and crash recovery; they reach it through this server's event bus
+		workerCtx, stopWorker := context.WithCancel(context.Background())
+		defer stopWorker()
+		inlineWorker := worker.NewWorker(reportRepo, reportProcessor, cfg.Worker.PollInterval, cfg.Worker.BatchSize,
+			cfg.Worker.Concurrency, cfg.Worker.StuckAfter)
+		inlineWorker.Consume(workerCtx, eventBus)
+		go inlineWorker.Run(workerCtx)
+	}
+
+	// Decision: Chat answers come from the same backend as analysis; no responder means chat returns 503
+	var chatResponder services.ChatResponder
+	if cfg.Demo.Enabled {
+		chatResponder = services.NewDemoAnalyzer()
+	} else if aiService != nil {
+		chatResponder = aiService
+	}
+
+	// Decision: Gemini transcription reuses the analysis key unless a separate one is set
+	if strings.EqualFold(cfg.Speech.Provider, "gemini") && cfg.Speech.APIKey == "" {
+		cfg.Speech.APIKey = cfg.AI.GeminiAPIKey
+	}
+	transcriber, err := services.NewTranscriber(cfg.Speech)
+	if err != nil {
+		logging.Fatal("Invalid transcription configuration", "error", err)
+	}
+	if transcriber == nil {
+		slog.Info("Transcription disabled - voice chat questions return 503")
+	}
+	chatSafety, err := services.NewSafetyFilter(cfg.AI.ChatSafety)
+	if err != nil {
+		logging.Fatal("Invalid chat safety configuration", "error", err)
+	}
+	slog.Info("Chat safety filter ready", "level", chatSafety.Level())
+	summaryRepo := models.NewChatSummaryRepository(db.GetDB())
+	chatService := services.NewChatService(chatRepo, summaryRepo, reportRepo, profileRepo, chatResponder, transcriber, cfg.AI)
+	chatService.SetEventBus(eventBus)
+	safetyRepo := models.NewSafetyInterventionRepository(db.GetDB())
+	chatService.SetSafetyLog(safetyRepo)
+	crisisRepo := models.NewCrisisFlagRepository(db.GetDB())
+	crisisService, err := services.NewCrisisService(crisisRepo, userRepo, cfg.AI.ChatSafety)
+	if err != nil {
+		logging.Fatal("Invalid crisis contact configuration", "error", err)
+	}
+	chatService.SetCrisisService(crisisService)
+
+	// Decision: Glossary definitions come from the same backend as chat; without one only built-in terms resolve
+	var glossaryDefiner services.GlossaryDefiner
+	if cfg.Demo.Enabled {
+		glossaryDefiner = services.NewDemoAnalyzer()
+	} else if aiService != nil {
+		glossaryDefiner = aiService
+	}
+	glossaryService := services.NewGlossaryService(models.NewGlossaryRepository(db.GetDB()), glossaryDefiner)
+
+	// Decision: The llm translation provider uses the same backend as chat
+	var textTranslator services.TextTranslator
+	if cfg.Demo.Enabled {
+		textTranslator = services.NewDemoAnalyzer()
+	} else if aiService != nil {
+		textTranslator = aiService
+	}
+	translator, err := services.NewTranslator(cfg.Translate, textTranslator)
+	if err != nil {
+		logging.Fatal("Invalid translation configuration", "error", err)
+	}
+	if translator == nil {
+		slog.Info("Translation disabled - summaries are served in English only")
+	}
+	translationService := services.NewTranslationService(translator, models.NewTranslationCacheRepository(db.GetDB()))
+
+	ttsProvider, err := services.NewTTSProvider(cfg.TTS)
+	if err != nil {
+		logging.Fatal("Invalid text-to-speech configuration", "error", err)
+	}
+	if ttsProvider == nil {
+		slog.Info("Text-to-speech disabled - summary audio requests return 503")
+	}
+	audioService := services.NewSummaryAudioService(ttsProvider, cfg.TTS.CacheDir)
+
+	// Decision: Demo accounts start with sample reports so there is something to show
+	if cfg.Demo.Enabled {
+		slog.Warn("DEMO MODE: AI calls are mocked and destructive actions are disabled")
+		demoService := services.NewDemoService(reportRepo)
+		authService.AddSignupHook(func(user *models.User) {
+			if _, err := demoService.ProvisionSampleReports(user.ID); err != nil {
+				slog.Error("Failed to provision demo reports", "user_id", user.ID, "error", err)
+			}
+		})
+	}
+
+	// Decision: Refuse to start with an upload policy we cannot enforce
+	fileValidator, err := services.NewFileValidator(cfg.Upload)
+	if err != nil {
+		logging.Fatal("Invalid upload configuration", "error", err)
+	}
+
+	// Decision: Refuse to start with a CAPTCHA provider we cannot verify against
+	captchaVerifier, err := services.NewCaptchaVerifier(cfg.Captcha)
+	if err != nil {
+		logging.Fatal("Invalid CAPTCHA configuration", "error", err)
+	}
+	captchaGuard := services.NewCaptchaGuard(captchaVerifier, cfg.Captcha)
+	if captchaGuard.Enabled() {
+		slog.Info("CAPTCHA required on signup and after failed logins", "provider", cfg.Captcha.Provider, "failed_logins", cfg.Captcha.LoginFailureThreshold)
+	}
+
 	// Decision: Initialize handlers (HTTP layer)
-	authHandler := handlers.NewAuthHandler(authService)
-	reportHandler := handlers.NewReportHandler(reportRepo, authService, aiService, cfg.Upload.UploadPath, cfg.Upload.MaxFileSize)
+	apiKeyService := services.NewAPIKeyService(models.NewAPIKeyRepository(db.GetDB()), userRepo)
+	authHandler := handlers.NewAuthHandler(authService, captchaGuard)
+	authHandler.SetAPIKeyService(apiKeyService)
+	authHandler.SetSessionService(sessionService)
+	reportHandler := handlers.NewReportHandler(reportRepo, authService, aiService, fileValidator, fileStorage, cfg.Upload.MaxFileSize, cfg.Upload.ExposeFilePaths)
+	reportHandler.SetEventBus(eventBus)
+	reportHandler.SetTranslationService(translationService)
+	// Decision: The stream polls report history, so it also follows reports analyzed by cmd/worker; events on
+	// this server's bus only deliver changes sooner
+	reportStream := services.NewReportStreamService(reportRepo, 0)
+	reportStream.Subscribe(eventBus)
+	reportHandler.SetReportStream(reportStream)
+	jobService := services.NewJobService(reportRepo, jobRepo, auditRepo, cfg.Worker.StuckAfter)
+	reportHandler.SetJobService(jobService)
+	reportHandler.SetPartService(services.NewReportPartService(reportRepo, partRepo, cfg.Upload.MultipartWindow))
+	reportHandler.SetClaimPackageService(services.NewClaimPackageService(reportRepo, partRepo,
+		models.NewClaimPackageRepository(db.GetDB()), fileStorage))
+	// Decision: Imported analyses don't need a model, so import works even when none is configured
+	analysisImportService := services.NewAnalysisImportService(reportRepo, auditRepo)
+	analysisImportService.SetEventBus(eventBus)
+	reportHandler.SetAnalysisImportService(analysisImportService)
+	reportHandler.SetSyncService(services.NewSyncService(reportRepo, chatRepo, models.NewTombstoneRepository(db.GetDB())))
+	adminHandler := handlers.NewAdminHandler(reportRepo, auditRepo, usageRepo, safetyRepo, crisisRepo, impersonationService, jobService,
+		services.NewReviewService(reportRepo, reviewRepo, auditRepo))
+	provenanceService := services.NewProvenanceService(reportRepo, auditRepo, aiCallRecorder)
+	adminHandler.SetProvenanceService(provenanceService)
+	adminHandler.SetAPIKeyService(apiKeyService)
+	adminHandler.SetPromptCanaryService(canaryService)
+
+	// Decision: Runs target the prompt and model this server analyzes with; every process that analyzes
+	// reports, this one included, works through them
+	reanalysisRepo := models.NewReanalysisRepository(db.GetDB())
+	if cfg.Demo.Enabled {
+		adminHandler.SetReanalysisService(services.NewReanalysisService(reanalysisRepo, reportRepo, auditRepo, services.DemoPromptVersion, reportProcessor.ModelName()))
+	} else if aiService != nil {
+		adminHandler.SetReanalysisService(services.NewReanalysisService(reanalysisRepo, reportRepo, auditRepo, cfg.AI.PromptVersion, aiService.ModelName()))
+	}
+	if reportProcessor != nil && (cfg.Demo.Enabled || aiService != nil) {
+		reanalysisCtx, stopReanalysis := context.WithCancel(context.Background())
+		defer stopReanalysis()
+		go services.NewReanalysisRunner(reanalysisRepo, reportRepo, reportProcessor, cfg.Worker.ReanalysisPerMinute).Run(reanalysisCtx)
+	}
+	transferHandler := handlers.NewTransferHandler(transferService)
+	orgRepo := models.NewOrganizationRepository(db.GetDB())
+	brandingService := services.NewBrandingService(orgRepo, userRepo)
+	reportHandler.SetReportAccess(services.NewReportAccessService(orgRepo, reportRepo))
+	planService := services.NewPlanService(userRepo, chatRepo, auditRepo, cfg.AI.Plans)
+	chatHandler := handlers.NewChatHandler(chatService, brandingService, planService, cfg.Speech.MaxAudioBytes)
+	moderationService := services.NewModerationService(models.NewModerationRepository(db.GetDB()), chatRepo, reportRepo,
+		summaryRepo, userRepo, auditRepo, cfg.Admin.Emails)
+	chatHandler.SetModerationService(moderationService)
+	adminHandler.SetModerationService(moderationService)
+	adminHandler.SetLegalHoldService(services.NewLegalHoldService(models.NewLegalHoldRepository(db.GetDB()), auditRepo))
+	notificationHandler := handlers.NewNotificationHandler(notificationRepo)
+	glossaryHandler := handlers.NewGlossaryHandler(glossaryService)
+	audioHandler := handlers.NewSummaryAudioHandler(reportRepo, audioService)
+	shareHandler := handlers.NewShareHandler(services.NewShareService(models.NewShareLinkRepository(db.GetDB()),
+		reportRepo, notificationRepo, passwordService, cfg.Share))
+	orgHandler := handlers.NewOrganizationHandler(brandingService, organizationUsage)
+
+	// Decision: Merged analyses and annual reviews come from the same backend as analysis;
+	// without one only stored ones are readable
+	var combinedAnalyzer services.CombinedAnalyzer
+	var annualReviewWriter services.AnnualReviewWriter
+	if cfg.Demo.Enabled {
+		combinedAnalyzer = services.NewDemoAnalyzer()
+		annualReviewWriter = services.NewDemoAnalyzer()
+	} else if aiService != nil {
+		combinedAnalyzer = aiService
+		annualReviewWriter = aiService
+	}
+	analysisHandler := handlers.NewAnalysisHandler(
+		services.NewMergedAnalysisService(models.NewMergedAnalysisRepository(db.GetDB()), reportRepo, combinedAnalyzer),
+		services.NewAnnualReviewService(models.NewAnnualReviewRepository(db.GetDB()), reportRepo, annualReviewWriter),
+		planService)
+
+	calculatorHandler := handlers.NewCalculatorHandler(services.NewCalculatorService(reportRepo, profileRepo))
+	profileHandler := handlers.NewHealthProfileHandler(services.NewHealthProfileService(profileRepo))
+
+	// Decision: Statistics are rebuilt by the API server for every process sharing the database
+	populationService, err := services.NewPopulationService(models.NewPopulationRepository(db.GetDB()), reportRepo, profileRepo, cfg.Insights)
+	if err != nil {
+		logging.Fatal("Invalid population insights configuration", "error", err)
+	}
+	populationCtx, stopPopulation := context.WithCancel(context.Background())
+	defer stopPopulation()
+	go populationService.Run(populationCtx)
+	insightsHandler := handlers.NewInsightsHandler(populationService)
+
+	directory, err := services.NewDoctorDirectory(cfg.Directory)
+	if err != nil {
+		logging.Fatal("Invalid doctor directory configuration", "error", err)
+	}
+	if directory == nil {
+		slog.Info("Doctor directory disabled - referrals suggest specialties without nearby doctors")
+	}
+	referralHandler := handlers.NewReferralHandler(services.NewReferralService(reportRepo, directory))
+
+	scheduler, err := services.NewScheduler(cfg.Schedule)
+	if err != nil {
+		logging.Fatal("Invalid scheduling configuration", "error", err)
+	}
+	if scheduler == nil {
+		slog.Info("Scheduling disabled - follow-up booking unavailable")
+	}
+	appointmentHandler := handlers.NewAppointmentHandler(services.NewAppointmentService(models.NewAppointmentRepository(db.GetDB()),
+		reportRepo, notificationRepo, scheduler, cfg.Schedule.Secret))
+	conditionRepo := models.NewUserConditionRepository(db.GetDB())
+	conditionHandler := handlers.NewConditionHandler(services.NewConditionService(conditionRepo, reportRepo))
+	emergencyCardHandler := handlers.NewEmergencyCardHandler(services.NewEmergencyCardService(userRepo, profileRepo, conditionRepo,
+		reportRepo, models.NewEmergencyCardLinkRepository(db.GetDB())))
+
+	// Decision: Gemini prescription reading reuses the analysis key unless a separate one is set
+	if strings.EqualFold(cfg.Rx.Provider, "gemini") && cfg.Rx.APIKey == "" {
+		cfg.Rx.APIKey = cfg.AI.GeminiAPIKey
+	}
+	var prescriptionReader services.PrescriptionReader
+	if cfg.Demo.Enabled {
+		prescriptionReader = services.NewDemoAnalyzer()
+	} else if prescriptionReader, err = services.NewPrescriptionReader(cfg.Rx); err != nil {
+		logging.Fatal("Invalid prescription reader configuration", "error", err)
+	}
+	if prescriptionReader == nil {
+		slog.Info("Prescription reading disabled - prescription uploads return 503")
+	}
+	prescriptionHandler := handlers.NewPrescriptionHandler(services.NewPrescriptionService(models.NewPrescriptionRepository(db.GetDB()),
+		prescriptionReader, fileStorage, cfg.Rx.LowConfidence), cfg.Rx.MaxImageBytes)
+
+	widgetHandler := handlers.NewWidgetHandler(services.NewWidgetService(cfg.JWT.Secret, reportRepo, userRepo, cfg.Widget))
+
+	planHandler := handlers.NewPlanHandler(planService)
+	free := services.PlanLimits(cfg.AI.Plans, models.PlanFree)
+	slog.Info("Free plan limits (0 = unlimited)", "summary_words", free.SummaryWords, "findings", free.MaxFindings,
+		"chat_questions_per_day", free.ChatMessagesPerDay)
+
+	// Decision: Refuse to start with a payment provider we cannot call or verify webhooks from
+	paymentProvider, err := services.NewPaymentProvider(cfg.Payment)
+	if err != nil {
+		logging.Fatal("Invalid payment configuration", "error", err)
+	}
+	if paymentProvider == nil {
+		slog.Info("Payments disabled - premium is granted by administrators only")
+	} else {
+		slog.Info("Premium subscriptions enabled, webhooks at /api/billing/webhook", "provider", paymentProvider.Name())
+	}
+	billingHandler := handlers.NewBillingHandler(services.NewBillingService(paymentProvider,
+		models.NewSubscriptionRepository(db.GetDB()), userRepo, auditRepo), paymentProvider)
 
 	// Decision: Initialize middleware
-	authMiddleware := middleware.NewAuthMiddleware(authService)
+	authMiddleware := middleware.NewAuthMiddleware(authService, cfg.Admin.Emails, auditRepo)
+	authMiddleware.SetAPIKeyService(apiKeyService)
+	authMiddleware.SetTokenRenewal(cfg.JWT.RefreshWindow, cfg.JWT.SlidingExpiration)
+	if cfg.JWT.SlidingExpiration {
+		slog.Info("Sliding token expiration enabled")
+	}
+
+	// Decision: Per-route usage counts are flushed once a minute; the admin usage report reads them back
+	usageCtx, stopUsage := context.WithCancel(context.Background())
+	defer stopUsage()
+	usageTracker := middleware.NewUsageTracker(usageRepo, time.Minute)
+	go usageTracker.Run(usageCtx)
 
 	// Decision: Setup router with all dependencies
-	rt := router.NewRouter(authHandler, reportHandler, authMiddleware)
-	httpRouter := rt.SetupRoutes()
+	rt := router.NewRouter(authHandler, reportHandler, adminHandler, transferHandler, chatHandler, notificationHandler, glossaryHandler, audioHandler, shareHandler, orgHandler, analysisHandler, calculatorHandler, profileHandler, conditionHandler, emergencyCardHandler, prescriptionHandler, widgetHandler, planHandler, billingHandler, insightsHandler, referralHandler, appointmentHandler, deviceHandler, handlers.NewAILogHandler(provenanceService), authMiddleware, dbMonitor, metricsHandler, usageTracker)
+	routes := rt.SetupRoutes()
+
+	// Decision: Serve the built frontend from the same binary when configured; registered last so every API route wins
+	if cfg.Server.FrontendDir != "" {
+		staticHandler, err := handlers.NewStaticHandler(cfg.Server.FrontendDir)
+		if err != nil {
+			logging.Fatal("Invalid frontend configuration", "error", err)
+		}
+		router.ServeFrontend(routes, staticHandler)
+		slog.Info("Serving frontend", "dir", cfg.Server.FrontendDir)
+	}
+
+	var httpHandler http.Handler = routes
+	if cfg.Demo.Enabled {
+		httpHandler = middleware.DisableDestructiveActions(httpHandler)
+	}
 
 	// Decision: Configure HTTP server with timeouts
 	server := &http.Server{
 		Addr:         fmt.Sprintf("%s:%s", cfg.Server.Host, cfg.Server.Port),
-		Handler:      httpRouter,
+		Handler:      httpHandler,
 		ReadTimeout:  cfg.Server.ReadTimeout,
 		WriteTimeout: cfg.Server.WriteTimeout,
 	}
 
 	// Decision: Log available endpoints for development
-	log.Println("Available endpoints:")
-	log.Println("  GET  /health                    - Health check")
-	log.Println("  POST /api/auth/signup           - User registration")
-	log.Println("  POST /api/auth/login            - User login")
-	log.Println("  POST /api/auth/logout           - User logout")
-	log.Println("  GET  /api/auth/me               - Get current user (requires auth)")
-	log.Println("  POST /api/auth/refresh          - Refresh JWT token (requires auth)")
-	log.Println("  GET  /api/reports               - Get user's reports (requires auth)")
-	log.Println("  POST /api/reports               - Upload medical report (requires auth)")
-	log.Println("  GET  /api/reports/{id}          - Get specific report (requires auth)")
-	log.Println("  DELETE /api/reports/{id}        - Delete report (requires auth)")
-	log.Println("  GET  /api/reports/{id}/summary  - Get AI analysis summary (requires auth)")
-	log.Println("  GET  /api/reports/{id}/metrics  - Get health metrics for speedometer (requires auth)")
-
-	log.Printf("Server ready and listening on %s", server.Addr)
-	log.Fatal(server.ListenAndServe())
+	slog.Debug("Available endpoints:")
+	slog.Debug("  GET  /health                    - Health check")
+	slog.Debug("  GET  /metrics                   - Runtime metrics (cache, database pool)")
+	slog.Debug("  POST /api/auth/signup           - User registration")
+	slog.Debug("  POST /api/auth/login            - User login")
+	slog.Debug("  GET  /api/auth/captcha          - CAPTCHA widget settings")
+	slog.Debug("  POST /api/auth/logout           - User logout")
+	slog.Debug("  GET  /api/auth/me               - Get current user (requires auth)")
+	slog.Debug("  PATCH /api/auth/me             - Update name, timezone, or reading level (requires auth)")
+	slog.Debug("  POST /api/auth/refresh          - Exchange a refresh token for new tokens")
+	slog.Debug("  GET  /api/reports               - Get user's reports (requires auth)")
+	slog.Debug("  POST /api/reports               - Upload medical report (requires auth)")
+	slog.Debug("  GET  /api/reports/{id}          - Get specific report (requires auth)")
+	slog.Debug("  GET  /api/reports/{id}/file     - Download original file (requires auth)")
+	slog.Debug("  PATCH /api/reports/{id}         - Update report title, date, or notes (requires auth)")
+	slog.Debug("  DELETE /api/reports/{id}        - Delete report (requires auth)")
+	slog.Debug("  POST /api/reports/bulk-delete   - Delete many reports (requires auth)")
+	slog.Debug("  POST /api/reports/bulk-archive  - Archive many reports (requires auth)")
+	slog.Debug("  GET  /api/reports/{id}/summary  - Get AI analysis summary (requires auth)")
+	slog.Debug("  GET  /api/reports/{id}/summary/audio - Spoken summary as MP3, ?lang=hi-IN (requires auth)")
+	slog.Debug("  GET  /api/reports/{id}/metrics  - Get health metrics for speedometer (requires auth)")
+	slog.Debug("  POST /api/reports/{id}/analysis - Attach an externally produced analysis (requires admin or analysis:import key)")
+	slog.Debug("  POST /api/reports/{id}/feedback - Rate the AI analysis 1-5 (requires auth)")
+	slog.Debug("  POST /api/reports/{id}/transfer - Offer report to another user (requires auth)")
+	slog.Debug("  GET  /api/reports/{id}/transfers - Report ownership history (requires auth)")
+	slog.Debug("  GET  /api/transfers             - Incoming transfer offers (requires auth)")
+	slog.Debug("  POST /api/transfers/{id}/accept - Accept, decline, or cancel a transfer (requires auth)")
+	slog.Debug("  GET  /api/reports/{id}/chat/export - Download conversation as json, markdown, or pdf (requires auth)")
+	slog.Debug("  PUT  /api/chat/{id}             - Edit a chat question and re-answer (requires auth)")
+	slog.Debug("  POST /api/chat/{id}/regenerate  - Regenerate a chat answer (requires auth)")
+	slog.Debug("  GET  /api/chat/{id}/versions    - Earlier versions of a chat message (requires auth)")
+	slog.Debug("  GET  /api/admin/prompts/stats   - Compare prompt variants (requires admin)")
+	slog.Debug("  POST /api/admin/impersonate/{id} - Act as a user for support (requires admin)")
+	slog.Debug("  GET  /api/admin/audit           - Audit log of impersonated actions (requires admin)")
+	slog.Debug("  GET  /api/admin/usage           - Who calls which routes, ?deprecated=true for routes being retired (requires admin)")
+	slog.Debug("  GET  /api/notifications         - In-app notifications (requires auth)")
+	slog.Debug("  POST /api/devices               - Register a phone for push notifications (requires auth)")
+	slog.Debug("  GET  /api/glossary?term=HDL     - Plain-language definition of a medical term (requires auth)")
+	slog.Debug("  POST /api/analyses/merge        - Combined analysis of several reports (requires auth)")
+	slog.Debug("  GET  /api/analyses              - Merged analyses (requires auth)")
+	slog.Debug("  GET  /api/analyses/annual       - Year-in-review overview (requires auth)")
+	slog.Debug("  GET  /api/calculators/{name}    - ASCVD, Framingham, eGFR, or BMI (requires auth)")
+	slog.Debug("  PUT  /api/health-profile        - Age, sex, and conditions for personalized analysis (requires auth)")
+	slog.Debug("  GET  /api/conditions/{key}/overview - Metrics and findings for a tracked condition (requires auth)")
+	slog.Debug("  GET  /api/users/me/emergency-card - Emergency summary card as JSON or PDF (requires auth)")
+	slog.Debug("  GET  /api/emergency-card/{token} - Public emergency card, when the owner enabled sharing")
+	slog.Debug("  POST /api/prescriptions         - Read medications from a prescription photo (requires auth)")
+	slog.Debug("  PATCH /api/prescriptions/{id}/medications/{index} - Correct a medication line (requires auth)")
+	slog.Debug("  POST /api/widgets/token         - Short-lived token for an embedded report widget (requires auth)")
+	slog.Debug("  GET  /api/widgets/report        - Widget data, authorized by a widget token")
+
+	slog.Info("Server ready and listening", "addr", server.Addr)
+	logging.Fatal("Server stopped", "error", server.ListenAndServe())
 }
\ No newline at end of file
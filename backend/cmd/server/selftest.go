@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/selftest"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+)
+
+// selfTestTimeout bounds each check, so an unreachable dependency fails instead of hanging a pipeline
+const selfTestTimeout = 10 * time.Second
+
+// runSelfTest checks every external dependency the server needs, prints a table, and returns the exit code
+// Decision: Dependencies that can't even be set up become failed rows rather than a fatal log,
+// so the table always shows the whole picture
+func runSelfTest(cfg *config.Config, migrationsDir string) int {
+	var checks []selftest.Check
+
+	db, err := database.Setup(cfg)
+	if err != nil {
+		checks = append(checks, selftest.Failing("database", err), selftest.Skipped("schema", "no database connection"))
+	} else {
+		defer db.Close()
+		checks = append(checks, selftest.DatabaseCheck(db.GetDB()), selftest.SchemaCheck(db.GetDB(), migrationsDir))
+	}
+
+	checks = append(checks, selftest.UploadDirCheck(cfg.Upload.UploadPath))
+
+	if cfg.Demo.Enabled {
+		checks = append(checks, selftest.Skipped("ai", "demo mode uses canned analyses"))
+	} else if aiService, err := services.NewAIService(cfg.AI); err != nil {
+		checks = append(checks, selftest.Failing("ai", err))
+	} else {
+		defer aiService.Close()
+		checks = append(checks, selftest.PingCheck("ai", aiService.ProviderName(), aiService.Ping))
+	}
+
+	if strings.EqualFold(cfg.Queue.Backend, "nats") {
+		checks = append(checks, selftest.Check{Name: "queue", Run: func(ctx context.Context) (string, error) {
+			bus, err := services.NewEventBus(cfg.Queue, "selftest")
+			if err != nil {
+				return "", err
+			}
+			bus.Close()
+			return "connected to " + cfg.Queue.NATSURL, nil
+		}})
+	} else {
+		checks = append(checks, selftest.Skipped("queue", "in-process event bus"))
+	}
+
+	// Decision: Listed so pipelines expecting an SMTP row see why there is nothing to verify
+	checks = append(checks, selftest.Skipped("smtp", "no SMTP configured; the server sends no email"))
+
+	results := selftest.Run(context.Background(), checks, selfTestTimeout)
+	selftest.Print(os.Stdout, results)
+	if selftest.Failed(results) {
+		fmt.Println("Self-test failed")
+		return 1
+	}
+	fmt.Println("Self-test passed")
+	return 0
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/joho/godotenv"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/logging"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/worker"
+)
+
+// Worker binary: runs only the report processing queue so it can scale
+// independently of the API servers (set PROCESS_REPORTS_INLINE=false on those)
+func main() {
+	envErr := godotenv.Load()
+
+	cfg := config.Load()
+	if _, err := logging.Setup(cfg.Log); err != nil {
+		log.Fatalf("Invalid logging configuration: %v", err)
+	}
+	if envErr != nil {
+		slog.Info("Could not load .env file, using system environment variables", "error", envErr)
+	}
+	if _, err := services.LoadSecrets(cfg); err != nil {
+		logging.Fatal("Failed to load secrets", "error", err)
+	}
+
+	db, err := database.Setup(cfg)
+	if err != nil {
+		logging.Fatal("Failed to setup database", "error", err)
+	}
+	defer db.Close()
+
+	// Decision: A worker without AI would just fail every report, so refuse to start
+	aiService, err := services.NewAIService(cfg.AI)
+	if err != nil {
+		logging.Fatal("Failed to initialize AI service", "error", err)
+	}
+	defer aiService.Close()
+
+	aiCallRecorder, err := services.NewAICallRecorder(models.NewAICallRepository(db.GetDB()), cfg.AI.CallLog)
+	if err != nil {
+		logging.Fatal("Invalid AI call log configuration", "error", err)
+	}
+	if aiCallRecorder != nil {
+		aiService.SetCallRecorder(aiCallRecorder)
+	}
+	organizationUsage := services.NewOrganizationUsageService(models.NewOrganizationUsageRepository(db.GetDB()))
+	aiService.SetUsageMeter(organizationUsage)
+	slog.Info("AI provider ready", "provider", aiService.ProviderName())
+
+	reportRepo := models.NewReportRepository(db.GetDB())
+	canaryService := services.NewPromptCanaryService(models.NewPromptCanaryRepository(db.GetDB()), reportRepo,
+		models.NewAuditLogRepository(db.GetDB()), cfg.AI)
+	if canaryService != nil {
+		canary, err := canaryService.Start()
+		if err != nil {
+			logging.Fatal("Failed to start prompt canary", "error", err)
+		}
+		slog.Info("Prompt canary serving new reports", "version", canary.Version, "status", canary.Status, "percent", canary.Percent)
+		aiService.SetCanaryGate(canaryService.Live)
+	}
+	processor := services.NewReportProcessor(reportRepo, models.NewJobRepository(db.GetDB()), models.NewAnalysisReviewRepository(db.GetDB()),
+		models.NewHealthProfileRepository(db.GetDB()), aiService, services.NewFileStorage(cfg.Upload.UploadPath, cfg.Upload.DirSecret))
+
+	eventBus, err := services.NewEventBus(cfg.Queue, "worker")
+	if err != nil {
+		logging.Fatal("Failed to start event bus", "error", err)
+	}
+	defer eventBus.Close()
+	processor.SetEventBus(eventBus)
+	processor.SetPartRepository(models.NewReportPartRepository(db.GetDB()))
+	processor.SetUsageMeter(organizationUsage)
+	if cfg.AI.BasicFallback {
+		processor.SetFallbackAnalyzer(services.NewBasicAnalyzer(cfg.AI.Extraction))
+	}
+	processor.SetRetryPolicy(services.RetryPolicy{MaxAttempts: cfg.Worker.MaxAttempts,
+		Backoff: cfg.Worker.RetryBackoff, MaxBackoff: cfg.Worker.RetryMaxBackoff})
+
+	// Decision: A local bus only carries this worker's own events, so it runs the server's subscribers itself;
+	// on NATS the servers run them and the worker only consumes uploads
+	sharedQueue := strings.EqualFold(cfg.Queue.Backend, "nats")
+	if !sharedQueue {
+		services.SubscribeNotifications(eventBus, models.NewNotificationRepository(db.GetDB()), reportRepo)
+		services.SubscribeAuditLog(eventBus, models.NewAuditLogRepository(db.GetDB()))
+		pushSenders, err := services.NewPushSenders(cfg.Push)
+		if err != nil {
+			logging.Fatal("Invalid push notification configuration", "error", err)
+		}
+		if len(pushSenders) > 0 {
+			services.SubscribePush(eventBus, services.NewPushService(models.NewPushDeviceRepository(db.GetDB()), pushSenders, cfg.Push.MaxDevices), reportRepo)
+		}
+	}
+	w := worker.NewWorker(reportRepo, processor, cfg.Worker.PollInterval, cfg.Worker.BatchSize, cfg.Worker.Concurrency, cfg.Worker.StuckAfter)
+
+	// Decision: Finish the current report and exit cleanly on SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if sharedQueue {
+		w.Consume(ctx, eventBus)
+		slog.Info("Consuming uploads from NATS", "url", cfg.Queue.NATSURL)
+	}
+	go services.NewReanalysisRunner(models.NewReanalysisRepository(db.GetDB()), reportRepo, processor, cfg.Worker.ReanalysisPerMinute).Run(ctx)
+	w.Run(ctx)
+}
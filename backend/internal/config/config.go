@@ -2,16 +2,37 @@ package config
 
 import (
 	"os"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 )
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
-	Upload   UploadConfig
-	AI       AIConfig
+	Server    ServerConfig
+	Database  DatabaseConfig
+	JWT       JWTConfig
+	Upload    UploadConfig
+	AI        AIConfig
+	Cache     CacheConfig
+	Worker    WorkerConfig
+	Admin     AdminConfig
+	Demo      DemoConfig
+	Captcha   CaptchaConfig
+	TTS       TTSConfig
+	Speech    TranscriptionConfig
+	Translate TranslationConfig
+	Share     ShareConfig
+	Rx        PrescriptionConfig
+	Widget    WidgetConfig
+	Queue     QueueConfig
+	Payment   PaymentConfig
+	Insights  InsightsConfig
+	Directory DirectoryConfig
+	Schedule  SchedulingConfig
+	Push      PushConfig
+	Secrets   SecretsConfig
+	Log       LogConfig
 }
 
 type ServerConfig struct {
@@ -19,28 +40,346 @@ type ServerConfig struct {
 	Host         string
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
+	FrontendDir  string // Built frontend served at /; empty when the frontend is hosted separately
 }
 
 type DatabaseConfig struct {
-	Driver string
-	DSN    string
+	Driver              string
+	DSN                 string
+	ReadDSN             string // Optional read-only replica using the same driver
+	AutoMigrate         bool   // Apply pending schema migrations when connecting
+	HealthCheckInterval time.Duration
+	ReconnectMaxBackoff time.Duration
 }
 
 type JWTConfig struct {
-	Secret     string
-	Expiration time.Duration
+	Secret            string
+	Expiration        time.Duration // Lifetime of access tokens
+	RefreshExpiration time.Duration // How long an unused refresh token stays valid; each refresh starts it again
+	MaxSessions       int           // Sessions a user may have signed in at once; 0 leaves sessions untracked
+	RefreshWindow     time.Duration // How close to expiry clients are told to refresh; 0 means the last fifth of the token's lifetime
+	SlidingExpiration bool          // Renew tokens inside the refresh window on any request rather than waiting for /refresh
 }
 
 type UploadConfig struct {
-	MaxFileSize int64
-	UploadPath  string
-	AllowedTypes []string
+	MaxFileSize       int64
+	UploadPath        string
+	AllowedExtensions []string      // e.g. .pdf,.txt,.docx,.png,.jpg
+	AllowedTypes      []string      // MIME types; empty derives them from AllowedExtensions
+	DirSecret         string        // Keys the per-user directory hash; changing it only affects new uploads
+	ExposeFilePaths   bool          // v1 compatibility: include server file_path in report responses
+	FilenamePolicy    string        // preserve or extension_only; how the uploaded name is stored
+	MultipartWindow   time.Duration // Uploads this close together with sibling names are offered as one report; 0 disables
 }
 
 type AIConfig struct {
+	Provider     string // gemini or ollama
 	GeminiAPIKey string
+	GeminiModel  string
 	MaxTokens    int32
 	Temperature  float32
+
+	// Self-hosted model server (Ollama or llama.cpp) used when Provider is ollama
+	OllamaURL     string
+	OllamaModel   string
+	OllamaTimeout time.Duration
+
+	// Provider quota shared by analysis and chat in this process; 0 disables a limit
+	RequestsPerMinute int
+	TokensPerMinute   int
+	RateLimitRetries  int           // Retries after the provider answers 429
+	RateLimitBackoff  time.Duration // First retry delay when the provider gives no Retry-After
+
+	// Prompt A/B testing: variant B is served to PromptBPercent% of analyses
+	PromptPath     string
+	PromptVersion  string
+	PromptBPath    string
+	PromptBVersion string
+	PromptBPercent int
+
+	// Canary deployment of a new prompt or model, rolled back automatically if its analyses are worse
+	Canary PromptCanaryConfig
+
+	// Chat context: history beyond ChatHistoryTokens is summarized, keeping ChatRecentTurns verbatim
+	ChatHistoryTokens int
+	ChatRecentTurns   int
+
+	// Chat safety: answers are screened for dosing instructions, diagnoses, and emergency advice
+	ChatSafety ChatSafetyConfig
+
+	// Debug log of every model call's prompt and response, encrypted at rest
+	CallLog AICallLogConfig
+
+	Persona    PersonaConfig
+	Extraction ExtractionConfig
+
+	// BasicFallback completes reports with a keyword-based extraction when the model is missing or keeps
+	// failing, instead of failing them
+	BasicFallback bool
+
+	// Limits per plan name (models.Plan*); users on a plan missing here get the free plan's limits
+	Plans map[string]PlanConfig
+}
+
+// PromptCanaryConfig serves a candidate prompt or model to a share of new reports and compares it with the stable one
+type PromptCanaryConfig struct {
+	Version                 string        // Label stored with the canary's analyses; empty disables the canary
+	PromptPath              string        // Empty uses the stable prompt
+	Model                   string        // Gemini or Ollama model name; empty uses the stable model
+	Percent                 int           // Share of new reports analyzed by the canary
+	MinSamples              int           // Analyses each side needs before the canary is judged
+	MaxParseFailureIncrease float64       // Parse failure rate points the canary may exceed the stable rate by
+	MaxLengthChange         float64       // Relative change in median summary length the canary may show
+	CheckInterval           time.Duration // How often the comparison runs
+}
+
+// ExtractionConfig tunes how report text is read and when a report counts as unreadable
+type ExtractionConfig struct {
+	OCRCommand        string // Path to the tesseract binary; empty disables OCR of scans and photos
+	OCRLanguages      string // Tesseract language codes, e.g. eng or eng+hin
+	OCRDetectScript   bool   // Detect each page's script and add the Hindi, Kannada, or Tamil pack it needs
+	PDFRasterizer     string // pdftoppm binary used to render scanned PDF pages for OCR
+	OCRTimeout        time.Duration
+	MinCharsPerPage   float64 // PDFs with less text per page are treated as scans
+	MaxGibberishRatio float64 // Share of garbled words above which text is rejected
+}
+
+// PlanConfig sets how much analysis and chat one plan gets; 0 leaves a limit off
+type PlanConfig struct {
+	MaxOutputTokens    int32    // Cap on the model's answer for one analysis; 0 uses MaxTokens
+	SummaryWords       int      // Longest simple_summary the model is asked for
+	MaxFindings        int      // Most key findings, and most recommendations, kept in an analysis
+	ChatMessagesPerDay int      // New chat questions allowed in any 24 hours
+	Features           []string // Premium features the plan includes, e.g. cross_report and pdf_export
+}
+
+// AICallLogConfig controls the ai_calls log kept for investigating wrong results
+type AICallLogConfig struct {
+	Enabled       bool
+	EncryptionKey string        // Base64-encoded 32-byte AES key; required when enabled
+	Retention     time.Duration // Calls older than this are deleted
+}
+
+// ChatSafetyConfig sets how strictly chat answers are screened
+type ChatSafetyConfig struct {
+	Level           string // off, standard, or strict
+	EmergencyNumber string // Number the emergency guidance tells users to call
+	DefaultCountry  string // Crisis contacts for users whose time zone maps to no known country; empty uses EmergencyNumber
+}
+
+// PersonaConfig customizes how the assistant presents itself in every analysis and chat
+type PersonaConfig struct {
+	Name         string // How the assistant refers to itself
+	Organization string // Hospital or clinic name for branded deployments; empty for the consumer app
+	Tone         string // Free-text style guidance, e.g. "warm and reassuring" or "concise and clinical"
+	Disclaimer   string // Shown on exported conversations and restated by the assistant when relevant
+	PromptPath   string // Optional file of extra deployment-specific instructions
+}
+
+type CacheConfig struct {
+	UserTTL time.Duration // Auth user lookup cache; 0 disables caching
+}
+
+type WorkerConfig struct {
+	ProcessInline bool // Process uploads inside the API server; disable when running cmd/worker
+	PollInterval  time.Duration
+	BatchSize     int
+	Concurrency   int           // Reports of one batch analyzed in parallel; the AI rate limiter paces them
+	StuckAfter    time.Duration // A report processing this long is shown as stuck and may be retried or cancelled; workers requeue it
+
+	MaxAttempts     int           // Analyses a report gets in all when they time out or hit the provider's quota
+	RetryBackoff    time.Duration // Wait before the second analysis, doubled for each one after
+	RetryMaxBackoff time.Duration // Longest wait between analyses
+
+	JanitorInterval time.Duration // How often queued files of deleted reports are removed
+
+	ReanalysisPerMinute int // Reports each process re-analyzes a minute during an operator-started re-analysis
+}
+
+// InsightsConfig controls the anonymized population statistics users opt in to
+type InsightsConfig struct {
+	MinCohort       int           // k-anonymity floor: distributions from fewer users are never published
+	RefreshInterval time.Duration // How often the statistics are rebuilt from the users currently opted in
+}
+
+// DirectoryConfig selects the provider directory that finds doctors near the user for referral suggestions
+type DirectoryConfig struct {
+	Provider string // http (a directory API speaking the contract in docs/ARCHITECTURE.md) or none
+	URL      string // The directory's search endpoint
+	APIKey   string // Sent as a bearer token
+	Timeout  time.Duration
+}
+
+// SchedulingConfig selects the external scheduling system follow-up appointments are booked through
+type SchedulingConfig struct {
+	Provider string // webhook (a scheduling adapter speaking the contract in docs/ARCHITECTURE.md) or none
+	URL      string // Where booking requests are posted
+	Secret   string // Signs booking requests and verifies the scheduling system's status callbacks
+	Timeout  time.Duration
+}
+
+// PushConfig holds the credentials for sending push notifications to registered devices
+type PushConfig struct {
+	FCMCredentialsFile string // Firebase service account JSON; empty disables Android and web pushes
+	APNsKeyFile        string // .p8 token signing key from the Apple developer account; empty disables iOS pushes
+	APNsKeyID          string
+	APNsTeamID         string
+	APNsTopic          string // The iOS app's bundle ID
+	APNsSandbox        bool   // Send through the development gateway, for builds signed with a development profile
+	MaxDevices         int    // Per user; registering another forgets the least recently seen
+	Timeout            time.Duration
+}
+
+// QueueConfig selects where events travel between the API servers and the workers
+type QueueConfig struct {
+	Backend       string // local (in-process, each process on its own) or nats (shared by every process)
+	NATSURL       string // nats://[user:pass@]host[:port]
+	SubjectPrefix string // Prefixes subjects and queue groups, so deployments can share a NATS server
+}
+
+type AdminConfig struct {
+	Emails           []string      // Users allowed to call /api/admin endpoints
+	ImpersonationTTL time.Duration // Lifetime of support impersonation tokens; they can't be refreshed
+}
+
+type DemoConfig struct {
+	Enabled bool // Mock AI, seed sample reports on signup, and block destructive actions
+}
+
+// CaptchaConfig enables bot protection on signup and after repeated failed logins
+// Decision: Off by default so local and test environments need no provider account
+type CaptchaConfig struct {
+	Provider              string // hcaptcha, recaptcha, or none
+	SiteKey               string // Public key the frontend renders the widget with
+	SecretKey             string
+	VerifyURL             string // Overrides the provider's siteverify endpoint, e.g. for a proxy
+	Timeout               time.Duration
+	LoginFailureThreshold int           // Failed logins per email or IP before a CAPTCHA is required
+	FailureWindow         time.Duration // How long failed logins are remembered
+}
+
+// TTSConfig selects the text-to-speech provider for summary audio
+type TTSConfig struct {
+	Provider string // google or none
+	APIKey   string
+	URL      string // Overrides the provider's synthesize endpoint, e.g. for a proxy
+	Timeout  time.Duration
+	CacheDir string // Generated MP3s, named by a hash of text and language
+}
+
+// TranscriptionConfig selects the speech-to-text provider for voice chat questions
+type TranscriptionConfig struct {
+	Provider      string // whisper (any OpenAI-compatible transcription API), gemini, or none
+	APIKey        string // Falls back to GEMINI_API_KEY for the gemini provider
+	URL           string // Overrides the whisper endpoint, e.g. a self-hosted faster-whisper server
+	Model         string
+	Timeout       time.Duration
+	MaxAudioBytes int64 // Largest accepted recording
+}
+
+// TranslationConfig selects the service that translates stored summaries on request
+type TranslationConfig struct {
+	Provider string // google (Cloud Translation), llm (the configured AI provider), or none
+	APIKey   string // Google Cloud Translation key
+	URL      string // Overrides the Cloud Translation endpoint, e.g. for a proxy
+	Timeout  time.Duration
+}
+
+// PrescriptionConfig selects the vision model that reads photographed prescriptions
+type PrescriptionConfig struct {
+	Provider      string // gemini or none
+	APIKey        string // Falls back to GEMINI_API_KEY
+	Model         string
+	Timeout       time.Duration
+	MaxImageBytes int64   // Largest accepted photo
+	LowConfidence float64 // Lines the reader is less sure of than this are flagged for the user to check
+}
+
+// ShareConfig governs read-only report links for people without an account
+type ShareConfig struct {
+	DefaultTTL     time.Duration // Link lifetime when the owner doesn't choose one
+	MaxTTL         time.Duration
+	MaxPINAttempts int // Wrong PINs before a link is locked for good
+}
+
+// PaymentConfig selects the payment provider that sells the premium plan
+type PaymentConfig struct {
+	Provider       string // stripe, razorpay, or none
+	KeyID          string // Razorpay key id; unused by Stripe
+	SecretKey      string // Stripe secret key or Razorpay key secret
+	WebhookSecret  string // Signs the provider's webhook calls
+	PremiumPriceID string // Stripe price or Razorpay plan billed for premium
+	SuccessURL     string // Where Stripe checkout returns after paying
+	CancelURL      string // Where Stripe checkout returns when abandoned
+	APIURL         string // Overrides the provider's API base URL, e.g. for a proxy
+	Timeout        time.Duration
+}
+
+// WidgetConfig governs tokens that let another site embed a report's metrics
+type WidgetConfig struct {
+	DefaultTTL time.Duration // Token lifetime when the owner doesn't choose one
+	MaxTTL     time.Duration
+}
+
+// SecretsConfig selects where secrets are read from when they shouldn't sit in the environment
+// Decision: The provider holds one secret whose value is a JSON object keyed by the environment variable
+// names it replaces (JWT_SECRET, GEMINI_API_KEY, ...), so a single read loads them all
+type LogConfig struct {
+	Level  string // debug, info, warn, or error
+	Format string // text or json
+}
+
+type SecretsConfig struct {
+	Provider        string // env, vault, aws, or gcp
+	URL             string // Vault address; overrides the AWS or GCP endpoint, e.g. for a proxy
+	Path            string // Vault KV v2 path (secret/data/app), AWS secret id, or GCP secret name
+	VaultToken      string // Vault token
+	AWSRegion       string // Region of the AWS secret
+	AWSAccessKeyID  string // Static credentials; instance and task roles aren't read
+	AWSSecretKey    string
+	AWSSessionToken string        // Only for temporary credentials
+	GCPProject      string        // Project of the GCP secret
+	GCPAccessToken  string        // OAuth token for Secret Manager; empty asks the GCE metadata server
+	RefreshInterval time.Duration // How long read secrets are cached before they are read again; 0 reads once
+	Timeout         time.Duration
+}
+
+// managedSecrets maps the names a secrets provider may supply to the configuration they set
+func (c *Config) managedSecrets() map[string]*string {
+	return map[string]*string{
+		"JWT_SECRET":               &c.JWT.Secret,
+		"UPLOAD_DIR_SECRET":        &c.Upload.DirSecret,
+		"GEMINI_API_KEY":           &c.AI.GeminiAPIKey,
+		"AI_CALL_LOG_KEY":          &c.AI.CallLog.EncryptionKey,
+		"CAPTCHA_SECRET_KEY":       &c.Captcha.SecretKey,
+		"TTS_API_KEY":              &c.TTS.APIKey,
+		"TRANSCRIBE_API_KEY":       &c.Speech.APIKey,
+		"TRANSLATE_API_KEY":        &c.Translate.APIKey,
+		"PRESCRIPTION_API_KEY":     &c.Rx.APIKey,
+		"DOCTOR_DIRECTORY_API_KEY": &c.Directory.APIKey,
+		"SCHEDULING_SECRET":        &c.Schedule.Secret,
+		"PAYMENT_SECRET_KEY":       &c.Payment.SecretKey,
+		"PAYMENT_WEBHOOK_SECRET":   &c.Payment.WebhookSecret,
+	}
+}
+
+// ApplySecrets replaces configured secrets with the values lookup finds, keeping the environment's for the rest,
+// and returns the names it replaced
+func (c *Config) ApplySecrets(lookup func(name string) (string, bool)) []string {
+	var applied []string
+	for name, field := range c.managedSecrets() {
+		if value, ok := lookup(name); ok && value != "" {
+			*field = value
+			applied = append(applied, name)
+		}
+	}
+
+	// Decision: The upload directory secret follows JWT_SECRET unless set on its own, wherever JWT_SECRET came from
+	if _, ok := lookup("UPLOAD_DIR_SECRET"); !ok && os.Getenv("UPLOAD_DIR_SECRET") == "" {
+		c.Upload.DirSecret = c.JWT.Secret
+	}
+	sort.Strings(applied)
+	return applied
 }
 
 func Load() *Config {
@@ -50,24 +389,250 @@ func Load() *Config {
 			Host:         getEnv("HOST", "localhost"),
 			ReadTimeout:  getDurationEnv("READ_TIMEOUT", 15*time.Second),
 			WriteTimeout: getDurationEnv("WRITE_TIMEOUT", 15*time.Second),
+			FrontendDir:  getEnv("FRONTEND_DIR", ""),
 		},
 		Database: DatabaseConfig{
-			Driver: getEnv("DB_DRIVER", "sqlite3"),
-			DSN:    getEnv("DB_DSN", "./medical_reports.db"),
+			Driver:              getEnv("DB_DRIVER", "sqlite3"),
+			DSN:                 getEnv("DB_DSN", "./medical_reports.db"),
+			ReadDSN:             getEnv("DB_READ_DSN", ""),
+			AutoMigrate:         getBoolEnv("DB_AUTO_MIGRATE", true),
+			HealthCheckInterval: getDurationEnv("DB_HEALTH_CHECK_INTERVAL", 30*time.Second),
+			ReconnectMaxBackoff: getDurationEnv("DB_RECONNECT_MAX_BACKOFF", time.Minute),
 		},
 		JWT: JWTConfig{
-			Secret:     getEnv("JWT_SECRET", "your-secret-key-change-in-production"),
-			Expiration: getDurationEnv("JWT_EXPIRATION", 24*time.Hour),
+			Secret:            getEnv("JWT_SECRET", "your-secret-key-change-in-production"),
+			Expiration:        getDurationEnv("JWT_EXPIRATION", 15*time.Minute),
+			RefreshExpiration: getDurationEnv("JWT_REFRESH_EXPIRATION", 30*24*time.Hour),
+			MaxSessions:       getIntEnv("MAX_SESSIONS_PER_USER", 0),
+			RefreshWindow:     getDurationEnv("JWT_REFRESH_WINDOW", 0),
+			SlidingExpiration: getBoolEnv("JWT_SLIDING_EXPIRATION", false),
 		},
 		Upload: UploadConfig{
-			MaxFileSize: getInt64Env("MAX_FILE_SIZE", 20*1024*1024), // 20MB default
-			UploadPath:  getEnv("UPLOAD_PATH", "./uploads"),
-			AllowedTypes: []string{"application/pdf", "text/plain", "application/vnd.openxmlformats-officedocument.wordprocessingml.document", "application/msword"},
+			MaxFileSize:       getInt64Env("MAX_FILE_SIZE", 20*1024*1024), // 20MB default
+			UploadPath:        getEnv("UPLOAD_PATH", "./uploads"),
+			AllowedExtensions: getListEnv("ALLOWED_FILE_EXTENSIONS", []string{".pdf", ".txt", ".docx"}),
+			AllowedTypes:      getListEnv("ALLOWED_FILE_TYPES", nil),
+			DirSecret:         getEnv("UPLOAD_DIR_SECRET", getEnv("JWT_SECRET", "your-secret-key-change-in-production")),
+			ExposeFilePaths:   getBoolEnv("EXPOSE_FILE_PATHS", false),
+			FilenamePolicy:    getEnv("UPLOAD_FILENAME_POLICY", "preserve"),
+			MultipartWindow:   getDurationEnv("MULTIPART_WINDOW", 10*time.Minute),
 		},
 		AI: AIConfig{
+			Provider:     getEnv("AI_PROVIDER", "gemini"),
 			GeminiAPIKey: getEnv("GEMINI_API_KEY", ""),
+			GeminiModel:  getEnv("AI_GEMINI_MODEL", "gemini-1.5-flash"),
 			MaxTokens:    getInt32Env("AI_MAX_TOKENS", 2048),
 			Temperature:  getFloat32Env("AI_TEMPERATURE", 0.3),
+
+			OllamaURL:     getEnv("OLLAMA_URL", "http://localhost:11434"),
+			OllamaModel:   getEnv("OLLAMA_MODEL", "llama3.1"),
+			OllamaTimeout: getDurationEnv("OLLAMA_TIMEOUT", 5*time.Minute),
+
+			RequestsPerMinute: getIntEnv("AI_REQUESTS_PER_MINUTE", 15),
+			TokensPerMinute:   getIntEnv("AI_TOKENS_PER_MINUTE", 1000000),
+			RateLimitRetries:  getIntEnv("AI_RATE_LIMIT_RETRIES", 3),
+			RateLimitBackoff:  getDurationEnv("AI_RATE_LIMIT_BACKOFF", 2*time.Second),
+
+			PromptPath:     getEnv("AI_PROMPT_PATH", "prompts/medical_analysis_prompt.txt"),
+			PromptVersion:  getEnv("AI_PROMPT_VERSION", "v1"),
+			PromptBPath:    getEnv("AI_PROMPT_B_PATH", ""),
+			PromptBVersion: getEnv("AI_PROMPT_B_VERSION", "v2"),
+			PromptBPercent: getIntEnv("AI_PROMPT_B_PERCENT", 0),
+
+			Canary: PromptCanaryConfig{
+				Version:                 getEnv("AI_CANARY_VERSION", ""),
+				PromptPath:              getEnv("AI_CANARY_PROMPT_PATH", ""),
+				Model:                   getEnv("AI_CANARY_MODEL", ""),
+				Percent:                 getIntEnv("AI_CANARY_PERCENT", 5),
+				MinSamples:              getIntEnv("AI_CANARY_MIN_SAMPLES", 20),
+				MaxParseFailureIncrease: getFloat64Env("AI_CANARY_MAX_PARSE_FAILURE_INCREASE", 0.05),
+				MaxLengthChange:         getFloat64Env("AI_CANARY_MAX_LENGTH_CHANGE", 0.25),
+				CheckInterval:           getDurationEnv("AI_CANARY_CHECK_INTERVAL", 10*time.Minute),
+			},
+
+			ChatHistoryTokens: getIntEnv("AI_CHAT_HISTORY_TOKENS", 4000),
+			ChatRecentTurns:   getIntEnv("AI_CHAT_RECENT_TURNS", 6),
+
+			ChatSafety: ChatSafetyConfig{
+				Level:           getEnv("AI_CHAT_SAFETY_LEVEL", "standard"),
+				EmergencyNumber: getEnv("AI_CHAT_EMERGENCY_NUMBER", "112"),
+				DefaultCountry:  getEnv("AI_CHAT_DEFAULT_COUNTRY", "IN"),
+			},
+
+			CallLog: AICallLogConfig{
+				Enabled:       getBoolEnv("AI_CALL_LOG_ENABLED", false),
+				EncryptionKey: getEnv("AI_CALL_LOG_KEY", ""),
+				Retention:     getDurationEnv("AI_CALL_LOG_RETENTION", 30*24*time.Hour),
+			},
+
+			Persona: PersonaConfig{
+				Name:         getEnv("AI_PERSONA_NAME", "MedSimple Assistant"),
+				Organization: getEnv("AI_PERSONA_ORGANIZATION", ""),
+				Tone:         getEnv("AI_PERSONA_TONE", "warm, plain-spoken, and reassuring without downplaying real concerns"),
+				Disclaimer: getEnv("AI_DISCLAIMER", "Answers are generated by an AI assistant to help explain a medical report. "+
+					"They are not a diagnosis or medical advice."),
+				PromptPath: getEnv("AI_PERSONA_PROMPT_PATH", ""),
+			},
+
+			Extraction: ExtractionConfig{
+				OCRCommand:        getEnv("OCR_COMMAND", ""),
+				OCRLanguages:      getEnv("OCR_LANGUAGES", "eng"),
+				OCRDetectScript:   getBoolEnv("OCR_DETECT_SCRIPT", true),
+				PDFRasterizer:     getEnv("OCR_PDF_RASTERIZER", "pdftoppm"),
+				OCRTimeout:        getDurationEnv("OCR_TIMEOUT", 2*time.Minute),
+				MinCharsPerPage:   getFloat64Env("EXTRACTION_MIN_CHARS_PER_PAGE", 200),
+				MaxGibberishRatio: getFloat64Env("EXTRACTION_MAX_GIBBERISH_RATIO", 0.3),
+			},
+			BasicFallback: getBoolEnv("AI_BASIC_FALLBACK", true),
+
+			Plans: map[string]PlanConfig{
+				"free": {
+					MaxOutputTokens:    getInt32Env("PLAN_FREE_MAX_TOKENS", 2048),
+					SummaryWords:       getIntEnv("PLAN_FREE_SUMMARY_WORDS", 80),
+					MaxFindings:        getIntEnv("PLAN_FREE_MAX_FINDINGS", 3),
+					ChatMessagesPerDay: getIntEnv("PLAN_FREE_CHAT_PER_DAY", 20),
+					Features:           getListEnv("PLAN_FREE_FEATURES", nil),
+				},
+				"premium": {
+					MaxOutputTokens:    getInt32Env("PLAN_PREMIUM_MAX_TOKENS", 4096),
+					SummaryWords:       getIntEnv("PLAN_PREMIUM_SUMMARY_WORDS", 200),
+					MaxFindings:        getIntEnv("PLAN_PREMIUM_MAX_FINDINGS", 0),
+					ChatMessagesPerDay: getIntEnv("PLAN_PREMIUM_CHAT_PER_DAY", 0),
+					Features:           getListEnv("PLAN_PREMIUM_FEATURES", []string{"cross_report", "pdf_export"}),
+				},
+			},
+		},
+		Cache: CacheConfig{
+			UserTTL: getDurationEnv("USER_CACHE_TTL", 30*time.Second),
+		},
+		Worker: WorkerConfig{
+			ProcessInline: getBoolEnv("PROCESS_REPORTS_INLINE", true),
+			PollInterval:  getDurationEnv("WORKER_POLL_INTERVAL", 5*time.Second),
+			BatchSize:     getIntEnv("WORKER_BATCH_SIZE", 10),
+			Concurrency:   getIntEnv("WORKER_CONCURRENCY", 4),
+			StuckAfter:    getDurationEnv("JOB_STUCK_AFTER", 15*time.Minute),
+
+			MaxAttempts:     getIntEnv("WORKER_MAX_ATTEMPTS", 3),
+			RetryBackoff:    getDurationEnv("WORKER_RETRY_BACKOFF", 30*time.Second),
+			RetryMaxBackoff: getDurationEnv("WORKER_RETRY_MAX_BACKOFF", 10*time.Minute),
+
+			JanitorInterval: getDurationEnv("JANITOR_INTERVAL", time.Minute),
+
+			ReanalysisPerMinute: getIntEnv("REANALYSIS_PER_MINUTE", 6),
+		},
+		Insights: InsightsConfig{
+			MinCohort:       getIntEnv("POPULATION_MIN_COHORT", 20),
+			RefreshInterval: getDurationEnv("POPULATION_REFRESH_INTERVAL", time.Hour),
+		},
+		Admin: AdminConfig{
+			Emails:           getListEnv("ADMIN_EMAILS", nil),
+			ImpersonationTTL: getDurationEnv("ADMIN_IMPERSONATION_TTL", 15*time.Minute),
+		},
+		Demo: DemoConfig{
+			Enabled: getBoolEnv("DEMO_MODE", false),
+		},
+		Captcha: CaptchaConfig{
+			Provider:              getEnv("CAPTCHA_PROVIDER", "none"),
+			SiteKey:               getEnv("CAPTCHA_SITE_KEY", ""),
+			SecretKey:             getEnv("CAPTCHA_SECRET_KEY", ""),
+			VerifyURL:             getEnv("CAPTCHA_VERIFY_URL", ""),
+			Timeout:               getDurationEnv("CAPTCHA_TIMEOUT", 5*time.Second),
+			LoginFailureThreshold: getIntEnv("CAPTCHA_LOGIN_FAILURE_THRESHOLD", 3),
+			FailureWindow:         getDurationEnv("CAPTCHA_FAILURE_WINDOW", 15*time.Minute),
+		},
+		TTS: TTSConfig{
+			Provider: getEnv("TTS_PROVIDER", "none"),
+			APIKey:   getEnv("TTS_API_KEY", ""),
+			URL:      getEnv("TTS_URL", ""),
+			Timeout:  getDurationEnv("TTS_TIMEOUT", 30*time.Second),
+			CacheDir: getEnv("TTS_CACHE_DIR", "./uploads/tts_cache"),
+		},
+		Speech: TranscriptionConfig{
+			Provider:      getEnv("TRANSCRIBE_PROVIDER", "none"),
+			APIKey:        getEnv("TRANSCRIBE_API_KEY", ""),
+			URL:           getEnv("TRANSCRIBE_URL", ""),
+			Model:         getEnv("TRANSCRIBE_MODEL", ""),
+			Timeout:       getDurationEnv("TRANSCRIBE_TIMEOUT", 60*time.Second),
+			MaxAudioBytes: getInt64Env("TRANSCRIBE_MAX_AUDIO_SIZE", 10*1024*1024), // 10MB, about 10 minutes of compressed speech
+		},
+		Translate: TranslationConfig{
+			Provider: getEnv("TRANSLATE_PROVIDER", "none"),
+			APIKey:   getEnv("TRANSLATE_API_KEY", ""),
+			URL:      getEnv("TRANSLATE_URL", ""),
+			Timeout:  getDurationEnv("TRANSLATE_TIMEOUT", 30*time.Second),
+		},
+		Share: ShareConfig{
+			DefaultTTL:     getDurationEnv("SHARE_LINK_TTL", 7*24*time.Hour),
+			MaxTTL:         getDurationEnv("SHARE_LINK_MAX_TTL", 30*24*time.Hour),
+			MaxPINAttempts: getIntEnv("SHARE_PIN_MAX_ATTEMPTS", 5),
+		},
+		Widget: WidgetConfig{
+			DefaultTTL: getDurationEnv("WIDGET_TOKEN_TTL", 15*time.Minute),
+			MaxTTL:     getDurationEnv("WIDGET_TOKEN_MAX_TTL", time.Hour),
+		},
+		Queue: QueueConfig{
+			Backend:       getEnv("QUEUE_BACKEND", "local"),
+			NATSURL:       getEnv("NATS_URL", "nats://127.0.0.1:4222"),
+			SubjectPrefix: getEnv("QUEUE_SUBJECT_PREFIX", "medreport"),
+		},
+		Directory: DirectoryConfig{
+			Provider: getEnv("DOCTOR_DIRECTORY_PROVIDER", "none"),
+			URL:      getEnv("DOCTOR_DIRECTORY_URL", ""),
+			APIKey:   getEnv("DOCTOR_DIRECTORY_API_KEY", ""),
+			Timeout:  getDurationEnv("DOCTOR_DIRECTORY_TIMEOUT", 10*time.Second),
+		},
+		Schedule: SchedulingConfig{
+			Provider: getEnv("SCHEDULING_PROVIDER", "none"),
+			URL:      getEnv("SCHEDULING_URL", ""),
+			Secret:   getEnv("SCHEDULING_SECRET", ""),
+			Timeout:  getDurationEnv("SCHEDULING_TIMEOUT", 15*time.Second),
+		},
+		Push: PushConfig{
+			FCMCredentialsFile: getEnv("PUSH_FCM_CREDENTIALS_FILE", ""),
+			APNsKeyFile:        getEnv("PUSH_APNS_KEY_FILE", ""),
+			APNsKeyID:          getEnv("PUSH_APNS_KEY_ID", ""),
+			APNsTeamID:         getEnv("PUSH_APNS_TEAM_ID", ""),
+			APNsTopic:          getEnv("PUSH_APNS_TOPIC", ""),
+			APNsSandbox:        getBoolEnv("PUSH_APNS_SANDBOX", false),
+			MaxDevices:         getIntEnv("PUSH_MAX_DEVICES", 10),
+			Timeout:            getDurationEnv("PUSH_TIMEOUT", 10*time.Second),
+		},
+		Rx: PrescriptionConfig{
+			Provider:      getEnv("PRESCRIPTION_PROVIDER", "none"),
+			APIKey:        getEnv("PRESCRIPTION_API_KEY", ""),
+			Model:         getEnv("PRESCRIPTION_MODEL", ""),
+			Timeout:       getDurationEnv("PRESCRIPTION_TIMEOUT", 60*time.Second),
+			MaxImageBytes: getInt64Env("PRESCRIPTION_MAX_IMAGE_SIZE", 8*1024*1024), // 8MB, a full-resolution phone photo
+			LowConfidence: getFloat64Env("PRESCRIPTION_LOW_CONFIDENCE", 0.7),
+		},
+		Payment: PaymentConfig{
+			Provider:       getEnv("PAYMENT_PROVIDER", "none"),
+			KeyID:          getEnv("PAYMENT_KEY_ID", ""),
+			SecretKey:      getEnv("PAYMENT_SECRET_KEY", ""),
+			WebhookSecret:  getEnv("PAYMENT_WEBHOOK_SECRET", ""),
+			PremiumPriceID: getEnv("PAYMENT_PREMIUM_PRICE_ID", ""),
+			SuccessURL:     getEnv("PAYMENT_SUCCESS_URL", "http://localhost:8080/?checkout=success"),
+			CancelURL:      getEnv("PAYMENT_CANCEL_URL", "http://localhost:8080/?checkout=cancelled"),
+			APIURL:         getEnv("PAYMENT_API_URL", ""),
+			Timeout:        getDurationEnv("PAYMENT_TIMEOUT", 15*time.Second),
+		},
+		Secrets: SecretsConfig{
+			Provider:        getEnv("SECRETS_PROVIDER", "env"),
+			URL:             getEnv("SECRETS_URL", getEnv("VAULT_ADDR", "")),
+			Path:            getEnv("SECRETS_PATH", ""),
+			VaultToken:      getEnv("VAULT_TOKEN", ""),
+			AWSRegion:       getEnv("SECRETS_AWS_REGION", getEnv("AWS_REGION", "")),
+			AWSAccessKeyID:  getEnv("AWS_ACCESS_KEY_ID", ""),
+			AWSSecretKey:    getEnv("AWS_SECRET_ACCESS_KEY", ""),
+			AWSSessionToken: getEnv("AWS_SESSION_TOKEN", ""),
+			GCPProject:      getEnv("SECRETS_GCP_PROJECT", getEnv("GOOGLE_CLOUD_PROJECT", "")),
+			GCPAccessToken:  getEnv("SECRETS_GCP_ACCESS_TOKEN", ""),
+			RefreshInterval: getDurationEnv("SECRETS_REFRESH_INTERVAL", 5*time.Minute),
+			Timeout:         getDurationEnv("SECRETS_TIMEOUT", 10*time.Second),
+		},
+		Log: LogConfig{
+			Level:  getEnv("LOG_LEVEL", "info"),
+			Format: getEnv("LOG_FORMAT", "text"),
 		},
 	}
 }
@@ -79,6 +644,37 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+func getListEnv(key string, defaultValue []string) []string {
+	if value := os.Getenv(key); value != "" {
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items
+	}
+	return defaultValue
+}
+
+func getBoolEnv(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolVal, err := strconv.ParseBool(value); err == nil {
+			return boolVal
+		}
+	}
+	return defaultValue
+}
+
+func getIntEnv(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intVal, err := strconv.Atoi(value); err == nil {
+			return intVal
+		}
+	}
+	return defaultValue
+}
+
 func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
 		if duration, err := time.ParseDuration(value); err == nil {
@@ -106,6 +702,15 @@ func getInt32Env(key string, defaultValue int32) int32 {
 	return defaultValue
 }
 
+func getFloat64Env(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatVal
+		}
+	}
+	return defaultValue
+}
+
 func getFloat32Env(key string, defaultValue float32) float32 {
 	if value := os.Getenv(key); value != "" {
 		if floatVal, err := strconv.ParseFloat(value, 32); err == nil {
@@ -113,4 +718,4 @@ func getFloat32Env(key string, defaultValue float32) float32 {
 		}
 	}
 	return defaultValue
-}
\ No newline at end of file
+}
@@ -3,14 +3,16 @@ package database
 import (
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
 
 	_ "github.com/mattn/go-sqlite3" // Import SQLite driver
 )
 
 // DB holds our database connection
+// Decision: Optional replica is kept alongside the primary so repositories can split reads
 type DB struct {
 	*sql.DB
+	replica *sql.DB
 }
 
 // NewConnection creates a new database connection
@@ -36,16 +38,33 @@ func NewConnection(driverName, dataSourceName string) (*DB, error) {
 	// Max idle connections - keeps connections ready for reuse
 	db.SetMaxIdleConns(25)
 
-	log.Printf("Database connection established successfully")
+	slog.Debug("Database connection established")
 
-	return &DB{db}, nil
+	return &DB{DB: db}, nil
 }
 
 // Close closes the database connection
 func (db *DB) Close() error {
+	if db.replica != nil {
+		db.replica.Close()
+	}
 	return db.DB.Close()
 }
 
+// AttachReplica registers a read-only replica connection for read-heavy queries
+func (db *DB) AttachReplica(replica *DB) {
+	db.replica = replica.DB
+}
+
+// GetReadDB returns the replica connection if configured, otherwise the primary
+// Decision: Callers never need to know whether a replica exists
+func (db *DB) GetReadDB() *sql.DB {
+	if db.replica != nil {
+		return db.replica
+	}
+	return db.DB
+}
+
 // GetDB returns the underlying sql.DB for advanced operations
 // Decision: Expose underlying DB for migrations and complex queries
 func (db *DB) GetDB() *sql.DB {
@@ -0,0 +1,196 @@
+package database
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// PoolStats is a snapshot of the connection pool for health reporting
+// Decision: Flatten sql.DBStats into JSON-friendly fields the /health endpoint can expose
+type PoolStats struct {
+	OpenConnections     int   `json:"open_connections"`
+	InUse               int   `json:"in_use"`
+	Idle                int   `json:"idle"`
+	MaxOpenConnections  int   `json:"max_open_connections"`
+	WaitCount           int64 `json:"wait_count"`
+	WaitDurationMs      int64 `json:"wait_duration_ms"`
+	Exhausted           bool  `json:"exhausted"`
+	ConsecutiveFailures int   `json:"consecutive_failures"`
+}
+
+// HealthStatus describes the current database health as seen by the monitor
+type HealthStatus struct {
+	Healthy   bool      `json:"healthy"`
+	LastCheck time.Time `json:"last_check"`
+	LastError string    `json:"last_error,omitempty"`
+	Pool      PoolStats `json:"pool"`
+}
+
+// HealthMonitor periodically pings the database and reconnects with backoff
+// Decision: sql.DB already re-dials broken connections lazily, so "reconnect" means
+// actively pinging until the pool can hand out a working connection again
+type HealthMonitor struct {
+	db          *DB
+	interval    time.Duration
+	pingTimeout time.Duration
+	maxBackoff  time.Duration
+
+	mu                  sync.RWMutex
+	healthy             bool
+	lastCheck           time.Time
+	lastErr             error
+	consecutiveFailures int
+	lastWaitCount       int64
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewHealthMonitor creates a health monitor for the given database
+func NewHealthMonitor(db *DB, interval, maxBackoff time.Duration) *HealthMonitor {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = time.Minute
+	}
+
+	return &HealthMonitor{
+		db:          db,
+		interval:    interval,
+		pingTimeout: 5 * time.Second,
+		maxBackoff:  maxBackoff,
+		healthy:     true, // Decision: Setup already pinged successfully before the monitor exists
+		lastCheck:   time.Now(),
+	}
+}
+
+// Start runs the monitor loop in the background until Stop is called
+func (hm *HealthMonitor) Start() {
+	hm.stop = make(chan struct{})
+	hm.done = make(chan struct{})
+
+	go hm.run()
+}
+
+// Stop halts the monitor loop and waits for it to exit
+func (hm *HealthMonitor) Stop() {
+	if hm.stop == nil {
+		return
+	}
+	close(hm.stop)
+	<-hm.done
+}
+
+// run checks the database on every tick and enters reconnect mode on failure
+func (hm *HealthMonitor) run() {
+	defer close(hm.done)
+
+	ticker := time.NewTicker(hm.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-hm.stop:
+			return
+		case <-ticker.C:
+			if err := hm.Check(); err != nil {
+				slog.Error("Database health check failed", "error", err)
+				hm.reconnect()
+			}
+		}
+	}
+}
+
+// reconnect pings with exponential backoff until the database answers or the monitor stops
+func (hm *HealthMonitor) reconnect() {
+	backoff := time.Second
+
+	for {
+		select {
+		case <-hm.stop:
+			return
+		case <-time.After(backoff):
+		}
+
+		if err := hm.Check(); err == nil {
+			slog.Info("Database connection restored")
+			return
+		}
+
+		// Decision: Double the wait each attempt, capped to avoid hammering a recovering database
+		backoff *= 2
+		if backoff > hm.maxBackoff {
+			backoff = hm.maxBackoff
+		}
+		slog.Warn("Database still unavailable", "retry_in", backoff)
+	}
+}
+
+// Check pings the database once and records the outcome
+func (hm *HealthMonitor) Check() error {
+	ctx, cancel := context.WithTimeout(context.Background(), hm.pingTimeout)
+	defer cancel()
+
+	err := hm.db.PingContext(ctx)
+
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+
+	hm.lastCheck = time.Now()
+	hm.lastErr = err
+	if err != nil {
+		hm.healthy = false
+		hm.consecutiveFailures++
+		return err
+	}
+
+	hm.healthy = true
+	hm.consecutiveFailures = 0
+
+	// Decision: Log when callers had to wait for a connection since the last check
+	stats := hm.db.Stats()
+	if stats.WaitCount > hm.lastWaitCount {
+		slog.Warn("Database pool exhausted", "waited", stats.WaitCount-hm.lastWaitCount, "in_use", stats.InUse,
+			"max_open", stats.MaxOpenConnections)
+	}
+	hm.lastWaitCount = stats.WaitCount
+
+	return nil
+}
+
+// IsHealthy reports whether the last check succeeded
+func (hm *HealthMonitor) IsHealthy() bool {
+	hm.mu.RLock()
+	defer hm.mu.RUnlock()
+	return hm.healthy
+}
+
+// Status returns the latest health status with current pool statistics
+func (hm *HealthMonitor) Status() HealthStatus {
+	hm.mu.RLock()
+	defer hm.mu.RUnlock()
+
+	stats := hm.db.Stats()
+	status := HealthStatus{
+		Healthy:   hm.healthy,
+		LastCheck: hm.lastCheck,
+		Pool: PoolStats{
+			OpenConnections:     stats.OpenConnections,
+			InUse:               stats.InUse,
+			Idle:                stats.Idle,
+			MaxOpenConnections:  stats.MaxOpenConnections,
+			WaitCount:           stats.WaitCount,
+			WaitDurationMs:      stats.WaitDuration.Milliseconds(),
+			Exhausted:           stats.MaxOpenConnections > 0 && stats.InUse >= stats.MaxOpenConnections,
+			ConsecutiveFailures: hm.consecutiveFailures,
+		},
+	}
+	if hm.lastErr != nil {
+		status.LastError = hm.lastErr.Error()
+	}
+
+	return status
+}
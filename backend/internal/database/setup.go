@@ -1,17 +1,19 @@
 package database
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
 
 	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/migrations"
 )
 
 // Setup initializes the database connection and returns a DB instance
 // Decision: Centralized database setup function for consistent initialization
 func Setup(cfg *config.Config) (*DB, error) {
-	// Decision: Log connection attempt for debugging
-	log.Printf("Connecting to database: driver=%s, dsn=%s", cfg.Database.Driver, cfg.Database.DSN)
+	// Decision: Log connection attempt for debugging; the DSN is left out since it can hold credentials
+	slog.Info("Connecting to database", "driver", cfg.Database.Driver)
 
 	db, err := NewConnection(cfg.Database.Driver, cfg.Database.DSN)
 	if err != nil {
@@ -24,9 +26,36 @@ func Setup(cfg *config.Config) (*DB, error) {
 		if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
 			return nil, fmt.Errorf("failed to enable foreign keys: %w", err)
 		}
-		log.Println("Foreign key constraints enabled")
+		slog.Debug("Foreign key constraints enabled")
 	}
 
-	log.Println("Database setup completed successfully")
+	// Decision: Migrate before the replica is attached; the replica receives the schema from the primary
+	if cfg.Database.AutoMigrate {
+		if cfg.Database.Driver != "sqlite3" {
+			db.Close()
+			return nil, fmt.Errorf("automatic migrations support sqlite3 only; set DB_AUTO_MIGRATE=false and migrate %s yourself", cfg.Database.Driver)
+		}
+		applied, err := migrations.Apply(context.Background(), db.DB)
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to migrate database: %w", err)
+		}
+		if applied > 0 {
+			slog.Info("Applied database migrations", "count", applied)
+		}
+	}
+
+	// Decision: Read replica is optional; writes always go to the primary
+	if cfg.Database.ReadDSN != "" {
+		slog.Info("Connecting to read replica", "driver", cfg.Database.Driver)
+		replica, err := NewConnection(cfg.Database.Driver, cfg.Database.ReadDSN)
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to connect to read replica: %w", err)
+		}
+		db.AttachReplica(replica)
+	}
+
+	slog.Info("Database setup completed")
 	return db, nil
 }
\ No newline at end of file
@@ -0,0 +1,346 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// AdminHandler handles operator-only HTTP requests
+// Decision: Routes are protected by RequireAuth + RequireAdmin in the router
+type AdminHandler struct {
+	reportRepo           models.ReportRepository
+	auditRepo            models.AuditLogRepository
+	usageRepo            models.APIUsageRepository
+	safetyRepo           models.SafetyInterventionRepository
+	crisisRepo           models.CrisisFlagRepository
+	impersonationService *services.ImpersonationService
+	jobService           *services.JobService
+	reviewService        *services.ReviewService
+	provenanceService    *services.ProvenanceService   // Optional; nil answers AI call lookups with 503
+	reanalysisService    *services.ReanalysisService   // Optional; nil answers re-analysis requests with 503
+	apiKeys              *services.APIKeyService       // Optional; nil answers scoped key requests with 503
+	moderationService    *services.ModerationService   // Optional; nil answers moderation and ban requests with 503
+	canaryService        *services.PromptCanaryService // Optional; nil answers canary requests with 503
+	legalHolds           *services.LegalHoldService    // Optional; nil answers legal hold requests with 503
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(
+	reportRepo models.ReportRepository,
+	auditRepo models.AuditLogRepository,
+	usageRepo models.APIUsageRepository,
+	safetyRepo models.SafetyInterventionRepository,
+	crisisRepo models.CrisisFlagRepository,
+	impersonationService *services.ImpersonationService,
+	jobService *services.JobService,
+	reviewService *services.ReviewService,
+) *AdminHandler {
+	return &AdminHandler{
+		reportRepo:           reportRepo,
+		auditRepo:            auditRepo,
+		usageRepo:            usageRepo,
+		safetyRepo:           safetyRepo,
+		crisisRepo:           crisisRepo,
+		impersonationService: impersonationService,
+		jobService:           jobService,
+		reviewService:        reviewService,
+	}
+}
+
+// SetProvenanceService enables the AI call log lookup
+func (ah *AdminHandler) SetProvenanceService(provenanceService *services.ProvenanceService) {
+	ah.provenanceService = provenanceService
+}
+
+// SetReanalysisService enables re-analysis of historical reports
+func (ah *AdminHandler) SetReanalysisService(reanalysisService *services.ReanalysisService) {
+	ah.reanalysisService = reanalysisService
+}
+
+// SetPromptCanaryService enables the prompt canary's status, rollback, and promotion
+func (ah *AdminHandler) SetPromptCanaryService(canaryService *services.PromptCanaryService) {
+	ah.canaryService = canaryService
+}
+
+// GetPromptStatsHandler compares parse failures and user feedback per prompt version
+// GET /api/admin/prompts/stats
+func (ah *AdminHandler) GetPromptStatsHandler(w http.ResponseWriter, r *http.Request) {
+	stats, err := ah.reportRepo.GetPromptVariantStats()
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve prompt statistics")
+		return
+	}
+
+	variants := make([]types.PromptVariantStats, len(stats))
+	for i, s := range stats {
+		variants[i] = types.PromptVariantStats{
+			PromptVersion: s.PromptVersion,
+			Total:         s.Total,
+			ParseFailures: s.ParseFailures,
+			FeedbackCount: s.FeedbackCount,
+			AverageRating: s.AverageRating,
+		}
+		if s.Total > 0 {
+			variants[i].ParseFailureRate = float64(s.ParseFailures) / float64(s.Total)
+		}
+	}
+
+	writeJSONResponse(w, http.StatusOK, types.PromptStatsResponse{Variants: variants})
+}
+
+// ImpersonateHandler issues a short-lived token for acting as a user
+// POST /api/admin/impersonate/{userId}
+func (ah *AdminHandler) ImpersonateHandler(w http.ResponseWriter, r *http.Request) {
+	admin, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	userID, err := strconv.Atoi(mux.Vars(r)["userId"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	var req types.ImpersonateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	response, err := ah.impersonationService.Start(admin, userID, req.Reason)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusCreated, response)
+}
+
+// GetAuditLogHandler lists audit entries, optionally for one user or actor
+// GET /api/admin/audit?user_id=&actor_id=
+func (ah *AdminHandler) GetAuditLogHandler(w http.ResponseWriter, r *http.Request) {
+	limit, offset := parsePaginationParams(r)
+	filter := models.AuditLogFilter{Limit: limit, Offset: offset}
+
+	query := r.URL.Query()
+	if v := query.Get("user_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, "Invalid user_id")
+			return
+		}
+		filter.UserID = id
+	}
+	if v := query.Get("actor_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, "Invalid actor_id")
+			return
+		}
+		filter.ActorID = id
+	}
+
+	entries, err := ah.auditRepo.List(filter)
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve audit log")
+		return
+	}
+
+	response := make([]types.AuditLogEntry, len(entries))
+	for i, e := range entries {
+		response[i] = types.AuditLogEntry{
+			ID:           e.ID,
+			ActorID:      e.ActorID,
+			UserID:       e.UserID,
+			Action:       e.Action,
+			Method:       e.Method,
+			Path:         e.Path,
+			StatusCode:   e.StatusCode,
+			Impersonated: e.Impersonated,
+			Details:      e.Details,
+			CreatedAt:    e.CreatedAt,
+		}
+	}
+
+	meta := &types.Meta{Pagination: &types.Pagination{Limit: limit, Offset: offset, Count: len(response)}}
+	writeJSONResponseWithMeta(w, http.StatusOK, response, meta)
+}
+
+// GetAPIUsageHandler reports who called which routes, most calls first
+// GET /api/admin/usage?deprecated=true&user_id=&days=30
+// Decision: deprecated=true narrows the report to routes being retired, listing the callers to contact before the sunset
+func (ah *AdminHandler) GetAPIUsageHandler(w http.ResponseWriter, r *http.Request) {
+	limit, offset := parsePaginationParams(r)
+	filter := models.APIUsageFilter{Limit: limit, Offset: offset}
+
+	query := r.URL.Query()
+	days := 30
+	if v := query.Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 366 {
+			writeErrorResponse(w, http.StatusBadRequest, "days must be between 1 and 366")
+			return
+		}
+		days = n
+	}
+	filter.Since = time.Now().UTC().AddDate(0, 0, 1-days).Format("2006-01-02")
+
+	if v := query.Get("user_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, "Invalid user_id")
+			return
+		}
+		filter.UserID = id
+	}
+
+	deprecations := make(map[string]middleware.Deprecation, len(middleware.DeprecatedRoutes))
+	for _, d := range middleware.DeprecatedRoutes {
+		deprecations[d.Key()] = d
+	}
+	if query.Get("deprecated") == "true" {
+		if len(deprecations) == 0 {
+			writeJSONResponseWithMeta(w, http.StatusOK, []types.APIUsageEntry{},
+				&types.Meta{Pagination: &types.Pagination{Limit: limit, Offset: offset}})
+			return
+		}
+		for key := range deprecations {
+			filter.Routes = append(filter.Routes, key)
+		}
+	}
+
+	summaries, err := ah.usageRepo.Summarize(filter)
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve API usage")
+		return
+	}
+
+	response := make([]types.APIUsageEntry, len(summaries))
+	for i, s := range summaries {
+		response[i] = types.APIUsageEntry{
+			Method:       s.Method,
+			Route:        s.Route,
+			UserID:       s.UserID,
+			Email:        s.Email,
+			Calls:        s.Calls,
+			Days:         s.Days,
+			LastCalledAt: s.LastCalledAt,
+		}
+		if d, ok := deprecations[s.Method+" "+s.Route]; ok {
+			sunset := d.Sunset
+			response[i].Deprecated = true
+			response[i].Sunset = &sunset
+			response[i].Successor = d.Successor
+		}
+	}
+
+	meta := &types.Meta{Pagination: &types.Pagination{Limit: limit, Offset: offset, Count: len(response)}}
+	writeJSONResponseWithMeta(w, http.StatusOK, response, meta)
+}
+
+// GetSafetyInterventionsHandler lists chat answers the safety filter changed, newest first
+// GET /api/admin/safety?category=&user_id=
+func (ah *AdminHandler) GetSafetyInterventionsHandler(w http.ResponseWriter, r *http.Request) {
+	limit, offset := parsePaginationParams(r)
+	filter := models.SafetyInterventionFilter{Limit: limit, Offset: offset}
+
+	query := r.URL.Query()
+	if v := query.Get("category"); v != "" {
+		if !services.IsValidSafetyCategory(v) {
+			writeErrorResponse(w, http.StatusBadRequest, "category must be dosage, diagnosis, or emergency")
+			return
+		}
+		filter.Category = v
+	}
+	if v := query.Get("user_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, "Invalid user_id")
+			return
+		}
+		filter.UserID = id
+	}
+
+	interventions, err := ah.safetyRepo.List(filter)
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve safety interventions")
+		return
+	}
+
+	response := make([]types.SafetyInterventionEntry, len(interventions))
+	for i, in := range interventions {
+		response[i] = types.SafetyInterventionEntry{
+			ID:             in.ID,
+			UserID:         in.UserID,
+			ReportID:       in.ReportID,
+			MessageID:      in.MessageID,
+			Level:          in.Level,
+			Categories:     in.Categories,
+			Removed:        in.Removed,
+			OriginalAnswer: in.OriginalAnswer,
+			CreatedAt:      in.CreatedAt,
+		}
+	}
+
+	meta := &types.Meta{Pagination: &types.Pagination{Limit: limit, Offset: offset, Count: len(response)}}
+	writeJSONResponseWithMeta(w, http.StatusOK, response, meta)
+}
+
+// GetCrisisFlagsHandler lists chat questions that matched crisis keywords, newest first
+// GET /api/admin/crisis?category=&user_id=
+func (ah *AdminHandler) GetCrisisFlagsHandler(w http.ResponseWriter, r *http.Request) {
+	limit, offset := parsePaginationParams(r)
+	filter := models.CrisisFlagFilter{Limit: limit, Offset: offset}
+
+	query := r.URL.Query()
+	if v := query.Get("category"); v != "" {
+		if !services.IsValidCrisisCategory(v) {
+			writeErrorResponse(w, http.StatusBadRequest, "category must be self_harm or emergency")
+			return
+		}
+		filter.Category = v
+	}
+	if v := query.Get("user_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, "Invalid user_id")
+			return
+		}
+		filter.UserID = id
+	}
+
+	flags, err := ah.crisisRepo.List(filter)
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve crisis flags")
+		return
+	}
+
+	response := make([]types.CrisisFlagEntry, len(flags))
+	for i, flag := range flags {
+		response[i] = types.CrisisFlagEntry{
+			ID:        flag.ID,
+			UserID:    flag.UserID,
+			ReportID:  flag.ReportID,
+			MessageID: flag.MessageID,
+			Category:  flag.Category,
+			Keyword:   flag.Keyword,
+			Question:  flag.Question,
+			InputMode: flag.InputMode,
+			Country:   flag.Country,
+			CreatedAt: flag.CreatedAt,
+		}
+	}
+
+	meta := &types.Meta{Pagination: &types.Pagination{Limit: limit, Offset: offset, Count: len(response)}}
+	writeJSONResponseWithMeta(w, http.StatusOK, response, meta)
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// GetPromptCanaryHandler compares the deployed canary prompt with the stable one
+// GET /api/admin/prompts/canary
+func (ah *AdminHandler) GetPromptCanaryHandler(w http.ResponseWriter, r *http.Request) {
+	if ah.canaryService == nil {
+		handleServiceError(w, errors.ErrPromptCanaryDisabled)
+		return
+	}
+
+	comparison, err := ah.canaryService.Status()
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+	writeJSONResponse(w, http.StatusOK, toPromptCanaryResponse(comparison))
+}
+
+// RollbackPromptCanaryHandler stops serving the canary to new reports
+// POST /api/admin/prompts/canary/rollback
+func (ah *AdminHandler) RollbackPromptCanaryHandler(w http.ResponseWriter, r *http.Request) {
+	ah.endPromptCanary(w, r, false)
+}
+
+// PromotePromptCanaryHandler marks the canary as ready to replace the stable prompt
+// POST /api/admin/prompts/canary/promote
+func (ah *AdminHandler) PromotePromptCanaryHandler(w http.ResponseWriter, r *http.Request) {
+	ah.endPromptCanary(w, r, true)
+}
+
+// endPromptCanary promotes or rolls back the canary with the admin's reason and answers with its final comparison
+func (ah *AdminHandler) endPromptCanary(w http.ResponseWriter, r *http.Request, promote bool) {
+	admin, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+	if ah.canaryService == nil {
+		handleServiceError(w, errors.ErrPromptCanaryDisabled)
+		return
+	}
+
+	var req types.EndPromptCanaryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	end := ah.canaryService.Rollback
+	if promote {
+		end = ah.canaryService.Promote
+	}
+	if _, err := end(admin, req.Reason); err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	comparison, err := ah.canaryService.Status()
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+	writeJSONResponse(w, http.StatusOK, toPromptCanaryResponse(comparison))
+}
+
+func toPromptCanaryResponse(comparison *services.CanaryComparison) types.PromptCanaryResponse {
+	canary := comparison.Canary
+	return types.PromptCanaryResponse{
+		ID:         canary.ID,
+		Version:    canary.Version,
+		PromptPath: canary.PromptPath,
+		Model:      canary.Model,
+		Percent:    canary.Percent,
+		Status:     canary.Status,
+		Reason:     canary.Reason,
+		StartedAt:  canary.StartedAt,
+		EndedAt:    canary.EndedAt,
+		EndedBy:    canary.EndedBy,
+		Canary:     toPromptCanaryArm(comparison.Arm),
+		Stable:     toPromptCanaryArm(comparison.Stable),
+		Verdict:    comparison.Verdict,
+		Finding:    comparison.Reason,
+	}
+}
+
+func toPromptCanaryArm(arm services.CanaryArm) types.PromptCanaryArm {
+	return types.PromptCanaryArm{
+		Versions:           arm.Versions,
+		Analyses:           arm.Analyses,
+		ParseFailures:      arm.ParseFailures,
+		ParseFailureRate:   arm.ParseFailureRate,
+		MedianSummaryWords: arm.MedianSummaryWords,
+		P90SummaryWords:    arm.P90SummaryWords,
+	}
+}
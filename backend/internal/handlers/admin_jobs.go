@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// GetQueueHandler lists queued, processing, and failed jobs with the queue's pause state
+// GET /api/admin/jobs?status=pending,processing,failed&limit=
+func (ah *AdminHandler) GetQueueHandler(w http.ResponseWriter, r *http.Request) {
+	limit, _ := parsePaginationParams(r)
+
+	var statuses []string
+	if v := r.URL.Query().Get("status"); v != "" {
+		statuses = strings.Split(v, ",")
+	}
+
+	overview, err := ah.jobService.Overview(statuses, limit)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	response := types.QueueOverviewResponse{
+		Queue: types.QueueState{
+			Paused:   overview.State.Paused,
+			Reason:   overview.State.Reason,
+			PausedBy: overview.State.PausedBy,
+			PausedAt: overview.State.PausedAt,
+			Drained:  overview.Drained,
+		},
+		Counts:        overview.Counts,
+		FailureCauses: overview.FailureCauses,
+		Jobs:          make([]types.QueueJob, len(overview.Jobs)),
+	}
+	for i, job := range overview.Jobs {
+		response.Jobs[i] = types.QueueJob{
+			ReportID:         job.ReportID,
+			UserID:           job.UserID,
+			OriginalFilename: job.OriginalFilename,
+			Status:           job.Status,
+			Stuck:            job.Stuck,
+			Attempts:         job.Attempts,
+			ErrorCode:        job.ErrorCode,
+			LastError:        job.LastError,
+			UploadDate:       job.UploadDate,
+			UpdatedAt:        job.UpdatedAt,
+		}
+	}
+
+	writeJSONResponse(w, http.StatusOK, response)
+}
+
+// GetJobHandler shows a report's processing attempts and last error
+// GET /api/admin/jobs/{reportId}
+func (ah *AdminHandler) GetJobHandler(w http.ResponseWriter, r *http.Request) {
+	reportID, err := strconv.Atoi(mux.Vars(r)["reportId"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid report ID")
+		return
+	}
+
+	detail, err := ah.jobService.Detail(reportID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	response := types.JobDetailResponse{
+		ReportID:         detail.Report.ID,
+		UserID:           detail.Report.UserID,
+		OriginalFilename: detail.Report.OriginalFilename,
+		Status:           detail.Report.ProcessingStatus,
+		Stuck:            detail.Stuck,
+		ErrorCode:        detail.Report.ErrorCode,
+		LastError:        detail.LastError,
+		Attempts:         make([]types.ProcessingAttempt, len(detail.Attempts)),
+	}
+	for i, attempt := range detail.Attempts {
+		response.Attempts[i] = types.ProcessingAttempt{
+			ID:         attempt.ID,
+			Status:     attempt.Status,
+			Model:      attempt.Model,
+			Error:      attempt.Error,
+			StartedAt:  attempt.StartedAt,
+			FinishedAt: attempt.FinishedAt,
+		}
+	}
+
+	writeJSONResponse(w, http.StatusOK, response)
+}
+
+// RetryJobHandler requeues a failed or stuck report
+// POST /api/admin/jobs/{reportId}/retry
+func (ah *AdminHandler) RetryJobHandler(w http.ResponseWriter, r *http.Request) {
+	admin, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	reportID, err := strconv.Atoi(mux.Vars(r)["reportId"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid report ID")
+		return
+	}
+
+	if err := ah.jobService.Retry(admin, reportID); err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, types.MessageResponse{Message: "Job requeued"})
+}
+
+// CancelJobHandler takes a pending or stuck report out of the queue
+// POST /api/admin/jobs/{reportId}/cancel
+func (ah *AdminHandler) CancelJobHandler(w http.ResponseWriter, r *http.Request) {
+	admin, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	reportID, err := strconv.Atoi(mux.Vars(r)["reportId"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid report ID")
+		return
+	}
+
+	// Decision: The body is optional; a cancel without a reason is still recorded
+	var req types.QueueActionRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+			return
+		}
+	}
+
+	if err := ah.jobService.Cancel(admin, reportID, req.Reason); err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, types.MessageResponse{Message: "Job cancelled"})
+}
+
+// PauseQueueHandler stops new jobs from starting so the queue drains for maintenance
+// POST /api/admin/jobs/pause
+func (ah *AdminHandler) PauseQueueHandler(w http.ResponseWriter, r *http.Request) {
+	admin, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	var req types.QueueActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	if err := ah.jobService.Pause(admin, req.Reason); err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, types.MessageResponse{Message: "Queue paused; poll GET /api/admin/jobs until drained is true"})
+}
+
+// ResumeQueueHandler lets jobs start again
+// POST /api/admin/jobs/resume
+func (ah *AdminHandler) ResumeQueueHandler(w http.ResponseWriter, r *http.Request) {
+	admin, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	if err := ah.jobService.Resume(admin); err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, types.MessageResponse{Message: "Queue resumed"})
+}
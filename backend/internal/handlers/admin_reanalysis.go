@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// StartReanalysisHandler re-analyzes completed reports produced by an older prompt or model
+// POST /api/admin/reanalysis
+func (ah *AdminHandler) StartReanalysisHandler(w http.ResponseWriter, r *http.Request) {
+	admin, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+	if ah.reanalysisService == nil {
+		handleServiceError(w, errors.ErrAIUnavailable)
+		return
+	}
+
+	var req types.StartReanalysisRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+	selection := services.ReanalysisRequest{
+		Reason:    req.Reason,
+		UserID:    req.UserID,
+		Since:     req.Since,
+		Until:     req.Until,
+		ReportIDs: req.ReportIDs,
+		Limit:     req.Limit,
+	}
+
+	// Decision: A dry run lets operators check the selection before spending model quota on it
+	if req.DryRun {
+		reportIDs, err := ah.reanalysisService.Preview(selection)
+		if err != nil {
+			handleServiceError(w, err)
+			return
+		}
+		promptVersion, model := ah.reanalysisService.Target()
+		if reportIDs == nil {
+			reportIDs = []int{}
+		}
+		writeJSONResponse(w, http.StatusOK, types.ReanalysisPreviewResponse{PromptVersion: promptVersion, Model: model, ReportIDs: reportIDs})
+		return
+	}
+
+	run, err := ah.reanalysisService.Start(admin, selection)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+	writeJSONResponse(w, http.StatusAccepted, toReanalysisRunResponse(run))
+}
+
+// ListReanalysisHandler lists the most recent re-analysis runs
+// GET /api/admin/reanalysis?limit=
+func (ah *AdminHandler) ListReanalysisHandler(w http.ResponseWriter, r *http.Request) {
+	if ah.reanalysisService == nil {
+		handleServiceError(w, errors.ErrAIUnavailable)
+		return
+	}
+	limit, _ := parsePaginationParams(r)
+
+	runs, err := ah.reanalysisService.Runs(limit)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	response := types.ReanalysisRunsResponse{Runs: make([]types.ReanalysisRun, len(runs))}
+	for i, run := range runs {
+		response.Runs[i] = toReanalysisRunResponse(run)
+	}
+	writeJSONResponse(w, http.StatusOK, response)
+}
+
+// GetReanalysisHandler shows a run's progress report by report
+// GET /api/admin/reanalysis/{runId}
+func (ah *AdminHandler) GetReanalysisHandler(w http.ResponseWriter, r *http.Request) {
+	if ah.reanalysisService == nil {
+		handleServiceError(w, errors.ErrAIUnavailable)
+		return
+	}
+
+	runID, err := strconv.Atoi(mux.Vars(r)["runId"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid run ID")
+		return
+	}
+
+	detail, err := ah.reanalysisService.Run(runID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	response := types.ReanalysisRunResponse{
+		Run:     toReanalysisRunResponse(detail.Run),
+		Reports: make([]types.ReanalysisItem, len(detail.Items)),
+	}
+	for i, item := range detail.Items {
+		response.Reports[i] = toReanalysisItemResponse(item)
+	}
+	writeJSONResponse(w, http.StatusOK, response)
+}
+
+// CancelReanalysisHandler stops a run; reports already re-analyzed keep their new analysis
+// POST /api/admin/reanalysis/{runId}/cancel
+func (ah *AdminHandler) CancelReanalysisHandler(w http.ResponseWriter, r *http.Request) {
+	admin, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+	if ah.reanalysisService == nil {
+		handleServiceError(w, errors.ErrAIUnavailable)
+		return
+	}
+
+	runID, err := strconv.Atoi(mux.Vars(r)["runId"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid run ID")
+		return
+	}
+
+	if err := ah.reanalysisService.Cancel(admin, runID); err != nil {
+		handleServiceError(w, err)
+		return
+	}
+	writeJSONResponse(w, http.StatusOK, types.MessageResponse{Message: "Re-analysis cancelled"})
+}
+
+// GetReanalysisDiffHandler compares a report's analysis before and after a run
+// GET /api/admin/reanalysis/{runId}/reports/{reportId}
+func (ah *AdminHandler) GetReanalysisDiffHandler(w http.ResponseWriter, r *http.Request) {
+	admin, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+	if ah.reanalysisService == nil {
+		handleServiceError(w, errors.ErrAIUnavailable)
+		return
+	}
+
+	runID, err := strconv.Atoi(mux.Vars(r)["runId"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid run ID")
+		return
+	}
+	reportID, err := strconv.Atoi(mux.Vars(r)["reportId"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid report ID")
+		return
+	}
+
+	diff, err := ah.reanalysisService.Diff(admin, runID, reportID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	response := types.ReanalysisDiffResponse{
+		ReanalysisItem:  toReanalysisItemResponse(diff.Item),
+		Metrics:         make([]types.MetricChange, len(diff.Metrics)),
+		KeyFindings:     types.ListChange{Added: diff.KeyFindings.Added, Removed: diff.KeyFindings.Removed},
+		Recommendations: types.ListChange{Added: diff.Recommendations.Added, Removed: diff.Recommendations.Removed},
+	}
+	if diff.RiskLevel != nil {
+		response.RiskLevel = &types.ValueChange{Old: diff.RiskLevel.Old, New: diff.RiskLevel.New}
+	}
+	if diff.SimpleSummary != nil {
+		response.SimpleSummary = &types.ValueChange{Old: diff.SimpleSummary.Old, New: diff.SimpleSummary.New}
+	}
+	for i, change := range diff.Metrics {
+		response.Metrics[i] = types.MetricChange{
+			Name:      change.Name,
+			Change:    change.Change,
+			OldValue:  change.OldValue,
+			NewValue:  change.NewValue,
+			OldStatus: change.OldStatus,
+			NewStatus: change.NewStatus,
+		}
+	}
+	if json.Valid([]byte(diff.Item.OldAnalysis)) {
+		response.OldAnalysis = json.RawMessage(diff.Item.OldAnalysis)
+	}
+	if json.Valid([]byte(diff.Item.NewAnalysis)) {
+		response.NewAnalysis = json.RawMessage(diff.Item.NewAnalysis)
+	}
+	writeJSONResponse(w, http.StatusOK, response)
+}
+
+func toReanalysisRunResponse(run *models.ReanalysisRun) types.ReanalysisRun {
+	return types.ReanalysisRun{
+		ID:            run.ID,
+		CreatedBy:     run.CreatedBy,
+		Reason:        run.Reason,
+		PromptVersion: run.PromptVersion,
+		Model:         run.Model,
+		Status:        run.Status,
+		Counts:        run.Counts,
+		CreatedAt:     run.CreatedAt,
+		FinishedAt:    run.FinishedAt,
+	}
+}
+
+func toReanalysisItemResponse(item *models.ReanalysisItem) types.ReanalysisItem {
+	return types.ReanalysisItem{
+		ReportID:         item.ReportID,
+		Status:           item.Status,
+		OldPromptVersion: item.OldPromptVersion,
+		NewPromptVersion: item.NewPromptVersion,
+		Error:            item.Error,
+		FinishedAt:       item.FinishedAt,
+	}
+}
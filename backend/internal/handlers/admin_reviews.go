@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// ListAnalysisReviewsHandler lists reports whose analysis couldn't be parsed
+// GET /api/admin/reviews
+func (ah *AdminHandler) ListAnalysisReviewsHandler(w http.ResponseWriter, r *http.Request) {
+	limit, offset := parsePaginationParams(r)
+
+	reviews, err := ah.reviewService.ListOpen(limit, offset)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	response := make([]types.AnalysisReview, len(reviews))
+	for i, review := range reviews {
+		response[i] = toAnalysisReviewResponse(review)
+	}
+
+	meta := &types.Meta{Pagination: &types.Pagination{Limit: limit, Offset: offset, Count: len(response)}}
+	writeJSONResponseWithMeta(w, http.StatusOK, response, meta)
+}
+
+// GetAnalysisReviewHandler shows the raw model output quarantined for a report
+// GET /api/admin/reviews/{reportId}
+func (ah *AdminHandler) GetAnalysisReviewHandler(w http.ResponseWriter, r *http.Request) {
+	admin, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	reportID, err := strconv.Atoi(mux.Vars(r)["reportId"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid report ID")
+		return
+	}
+
+	review, err := ah.reviewService.Get(admin, reportID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	response := toAnalysisReviewResponse(review)
+	response.RawOutput = review.RawOutput
+	writeJSONResponse(w, http.StatusOK, response)
+}
+
+// ReparseAnalysisHandler parses the stored or hand-corrected output and completes the report
+// POST /api/admin/reviews/{reportId}/reparse
+func (ah *AdminHandler) ReparseAnalysisHandler(w http.ResponseWriter, r *http.Request) {
+	admin, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	reportID, err := strconv.Atoi(mux.Vars(r)["reportId"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid report ID")
+		return
+	}
+
+	// Decision: The body is optional; without one the stored output is re-parsed as-is
+	var req types.ReparseAnalysisRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+			return
+		}
+	}
+
+	analysis, err := ah.reviewService.Reparse(admin, reportID, req.RawOutput)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, analysis)
+}
+
+func toAnalysisReviewResponse(review *models.AnalysisReview) types.AnalysisReview {
+	return types.AnalysisReview{
+		ReportID:         review.ReportID,
+		UserID:           review.UserID,
+		OriginalFilename: review.OriginalFilename,
+		PromptVersion:    review.PromptVersion,
+		ParseError:       review.ParseError,
+		CreatedAt:        review.CreatedAt,
+		ResolvedAt:       review.ResolvedAt,
+	}
+}
+
+// GetAICallsHandler shows the exact prompts, settings, and responses behind a report's analysis
+// GET /api/admin/reports/{reportId}/ai-calls
+func (ah *AdminHandler) GetAICallsHandler(w http.ResponseWriter, r *http.Request) {
+	admin, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+	if ah.provenanceService == nil {
+		handleServiceError(w, errors.ErrAICallLogDisabled)
+		return
+	}
+
+	reportID, err := strconv.Atoi(mux.Vars(r)["reportId"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid report ID")
+		return
+	}
+
+	calls, err := ah.provenanceService.Calls(admin, reportID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	response := make([]types.AICallEntry, len(calls))
+	for i, call := range calls {
+		response[i] = types.AICallEntry{
+			ID:         call.ID,
+			ReportID:   call.ReportID,
+			Purpose:    call.Purpose,
+			Provider:   call.Provider,
+			Model:      call.Model,
+			Parameters: json.RawMessage(call.Parameters),
+			Prompt:     call.Prompt,
+			Response:   call.Response,
+			Error:      call.Error,
+			DurationMs: call.DurationMs,
+			CreatedAt:  call.CreatedAt,
+		}
+	}
+	writeJSONResponse(w, http.StatusOK, response)
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// AILogHandler shows users the AI operations run on their data
+type AILogHandler struct {
+	provenanceService *services.ProvenanceService
+}
+
+// NewAILogHandler creates a new AI usage log handler
+func NewAILogHandler(provenanceService *services.ProvenanceService) *AILogHandler {
+	return &AILogHandler{
+		provenanceService: provenanceService,
+	}
+}
+
+// ListAILogHandler lists the model calls made with the caller's data, newest first
+// GET /api/users/me/ai-log
+// Decision: Only what was run, with which model, and when; errors are reduced to whether the call succeeded,
+// since provider messages can echo the prompt
+func (lh *AILogHandler) ListAILogHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	limit, offset := parsePaginationParams(r)
+	calls, err := lh.provenanceService.UsageLog(user.ID, limit, offset)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	response := make([]types.AIUsageEntry, len(calls))
+	for i, call := range calls {
+		response[i] = types.AIUsageEntry{
+			ID:         call.ID,
+			Purpose:    call.Purpose,
+			Provider:   call.Provider,
+			Model:      call.Model,
+			Succeeded:  call.Error == "",
+			DurationMs: call.DurationMs,
+			CreatedAt:  call.CreatedAt.In(user.Location()),
+		}
+		if call.ReportID != 0 {
+			reportID := call.ReportID
+			response[i].ReportID = &reportID
+		}
+	}
+
+	meta := &types.Meta{Pagination: &types.Pagination{Limit: limit, Offset: offset, Count: len(response)}}
+	writeJSONResponseWithMeta(w, http.StatusOK, response, meta)
+}
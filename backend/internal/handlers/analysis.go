@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// AnalysisHandler handles analyses that span more than one report
+type AnalysisHandler struct {
+	mergedService *services.MergedAnalysisService
+	annualService *services.AnnualReviewService
+	planService   *services.PlanService
+}
+
+// NewAnalysisHandler creates a new analysis handler
+func NewAnalysisHandler(mergedService *services.MergedAnalysisService, annualService *services.AnnualReviewService, planService *services.PlanService) *AnalysisHandler {
+	return &AnalysisHandler{
+		mergedService: mergedService,
+		annualService: annualService,
+		planService:   planService,
+	}
+}
+
+// MergeAnalysesHandler writes one combined assessment of several completed reports
+// POST /api/analyses/merge
+func (ah *AnalysisHandler) MergeAnalysesHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+	if err := ah.planService.Require(user, services.FeatureCrossReport); err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	var req types.MergeAnalysisRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	readingLevel := req.ReadingLevel
+	if readingLevel == "" {
+		readingLevel = user.ReadingLevel
+	}
+
+	merged, err := ah.mergedService.Merge(user.ID, req.ReportIDs, req.Title, readingLevel, user.Plan)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusCreated, toMergedAnalysisResponse(merged, user.Location()))
+}
+
+// ListMergedAnalysesHandler returns the user's merged analyses, newest first
+// GET /api/analyses
+func (ah *AnalysisHandler) ListMergedAnalysesHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	limit, offset := parsePaginationParams(r)
+	analyses, err := ah.mergedService.List(user.ID, limit, offset)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	response := types.MergedAnalysisListResponse{Analyses: make([]types.MergedAnalysis, len(analyses))}
+	for i, merged := range analyses {
+		response.Analyses[i] = toMergedAnalysisResponse(merged, user.Location())
+	}
+	writeJSONResponse(w, http.StatusOK, response)
+}
+
+// GetMergedAnalysisHandler returns one merged analysis with links to its source reports
+// GET /api/analyses/{id}
+func (ah *AnalysisHandler) GetMergedAnalysisHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid analysis ID")
+		return
+	}
+
+	merged, err := ah.mergedService.Get(user.ID, id)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, toMergedAnalysisResponse(merged, user.Location()))
+}
+
+// DeleteMergedAnalysisHandler removes a merged analysis; the source reports are kept
+// DELETE /api/analyses/{id}
+func (ah *AnalysisHandler) DeleteMergedAnalysisHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid analysis ID")
+		return
+	}
+
+	if err := ah.mergedService.Delete(user.ID, id); err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, map[string]string{"message": "Analysis deleted"})
+}
+
+// AnnualReviewHandler returns an overview of the user's year, written once and cached until the year's reports change
+// GET /api/analyses/annual?year=2024&reading_level=standard
+func (ah *AnalysisHandler) AnnualReviewHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	year := time.Now().In(user.Location()).Year()
+	if yearStr := r.URL.Query().Get("year"); yearStr != "" {
+		parsed, err := strconv.Atoi(yearStr)
+		if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, "Invalid year")
+			return
+		}
+		year = parsed
+	}
+
+	readingLevel := r.URL.Query().Get("reading_level")
+	if readingLevel == "" {
+		readingLevel = user.ReadingLevel
+	}
+
+	review, err := ah.annualService.Get(user, year, readingLevel)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	response := types.AnnualReview{
+		Year:                      review.Year,
+		ReportCount:               review.ReportCount,
+		Overview:                  review.Overview,
+		Improvements:              review.Improvements,
+		Deteriorations:            review.Deteriorations,
+		UnresolvedRecommendations: review.UnresolvedRecommendations,
+		Trends:                    make([]types.MetricTrend, len(review.Trends)),
+		ReadingLevel:              review.ReadingLevel,
+		GeneratedAt:               review.GeneratedAt.In(user.Location()),
+	}
+	for i, trend := range review.Trends {
+		response.Trends[i] = types.MetricTrend{
+			Name:         trend.Name,
+			Unit:         trend.Unit,
+			Measurements: trend.Measurements,
+			FirstValue:   trend.FirstValue,
+			FirstStatus:  trend.FirstStatus,
+			FirstDate:    trend.FirstDate.Format(reportDateLayout),
+			LastValue:    trend.LastValue,
+			LastStatus:   trend.LastStatus,
+			LastDate:     trend.LastDate.Format(reportDateLayout),
+			Direction:    trend.Direction,
+		}
+	}
+	writeJSONResponse(w, http.StatusOK, response)
+}
+
+func toMergedAnalysisResponse(merged *models.MergedAnalysis, loc *time.Location) types.MergedAnalysis {
+	response := types.MergedAnalysis{
+		ID:            merged.ID,
+		Title:         merged.Title,
+		ReadingLevel:  merged.ReadingLevel,
+		PromptVersion: merged.PromptVersion,
+		CreatedAt:     merged.CreatedAt.In(loc),
+		Sources:       make([]types.MergedAnalysisSource, len(merged.Sources)),
+		Analysis:      json.RawMessage(merged.Analysis),
+	}
+
+	for i, source := range merged.Sources {
+		response.Sources[i] = types.MergedAnalysisSource{ReportID: source.ReportID, Label: source.Label}
+		if source.ReportDate != nil {
+			date := source.ReportDate.Format(reportDateLayout)
+			response.Sources[i].ReportDate = &date
+		}
+	}
+
+	return response
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// maxImportBody bounds an imported analysis; the model's analyses are a few kilobytes
+const maxImportBody = 1 << 20
+
+// SetAnalysisImportService lets trusted pipelines attach their own analyses to reports
+func (rh *ReportHandler) SetAnalysisImportService(imports *services.AnalysisImportService) {
+	rh.imports = imports
+}
+
+// ImportAnalysisHandler makes an externally produced analysis the report's analysis, bypassing the model
+// Only admins, or admins' API keys with the analysis:import scope, reach it; the report may belong to anyone
+// POST /api/reports/{id}/analysis
+func (rh *ReportHandler) ImportAnalysisHandler(w http.ResponseWriter, r *http.Request) {
+	actor, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+	if rh.imports == nil {
+		writeErrorResponse(w, http.StatusServiceUnavailable, "Analysis import is not available")
+		return
+	}
+
+	reportID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid report ID")
+		return
+	}
+
+	var req types.ImportAnalysisRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxImportBody)).Decode(&req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+	if len(req.Analysis) == 0 {
+		writeErrorResponse(w, http.StatusBadRequest, "analysis is required")
+		return
+	}
+
+	analysis, err := rh.imports.Import(actor, reportID, req.Analysis, req.Source)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, analysis)
+}
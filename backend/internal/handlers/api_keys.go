@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// SetAPIKeyService enables the API key management endpoints
+func (ah *AuthHandler) SetAPIKeyService(apiKeys *services.APIKeyService) {
+	ah.apiKeys = apiKeys
+}
+
+// CreateAPIKeyHandler issues an API key for scripts and the medctl CLI
+// POST /api/auth/api-keys
+func (ah *AuthHandler) CreateAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := ah.apiKeyManager(w, r)
+	if !ok {
+		return
+	}
+
+	var req types.CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	if len(req.Scopes) > 0 {
+		handleServiceError(w, errors.ErrScopeRequiresAdmin)
+		return
+	}
+
+	key, secret, err := ah.apiKeys.Create(user.ID, req.Name, nil)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusCreated, types.CreateAPIKeyResponse{
+		APIKey: toAPIKeyResponse(key, user.Location()),
+		Key:    secret,
+	})
+}
+
+// ListAPIKeysHandler lists the caller's API keys, newest first
+// GET /api/auth/api-keys
+func (ah *AuthHandler) ListAPIKeysHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := ah.apiKeyManager(w, r)
+	if !ok {
+		return
+	}
+
+	keys, err := ah.apiKeys.List(user.ID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	response := types.APIKeysResponse{Keys: make([]types.APIKey, len(keys))}
+	for i, key := range keys {
+		response.Keys[i] = toAPIKeyResponse(key, user.Location())
+	}
+	writeJSONResponse(w, http.StatusOK, response)
+}
+
+// RevokeAPIKeyHandler stops one of the caller's API keys from working
+// DELETE /api/auth/api-keys/{id}
+func (ah *AuthHandler) RevokeAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := ah.apiKeyManager(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid API key ID")
+		return
+	}
+
+	if err := ah.apiKeys.Revoke(user.ID, id); err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, map[string]string{"message": "API key revoked"})
+}
+
+// SetAPIKeyService lets admins issue scoped keys for trusted integrations
+func (ah *AdminHandler) SetAPIKeyService(apiKeys *services.APIKeyService) {
+	ah.apiKeys = apiKeys
+}
+
+// CreateScopedAPIKeyHandler issues the admin a key with scopes, for an integration such as a hospital pipeline
+// The key is listed and revoked with the admin's other keys under /api/auth/api-keys
+// POST /api/admin/api-keys
+func (ah *AdminHandler) CreateScopedAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	admin, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+	if ah.apiKeys == nil {
+		writeErrorResponse(w, http.StatusServiceUnavailable, "API keys are not available")
+		return
+	}
+
+	var req types.CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+	if len(req.Scopes) == 0 {
+		writeErrorResponse(w, http.StatusBadRequest, "scopes is required; create unscoped keys under /api/auth/api-keys")
+		return
+	}
+
+	key, secret, err := ah.apiKeys.Create(admin.ID, req.Name, req.Scopes)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusCreated, types.CreateAPIKeyResponse{
+		APIKey: toAPIKeyResponse(key, admin.Location()),
+		Key:    secret,
+	})
+}
+
+// apiKeyManager returns the caller if they may manage API keys, writing the error response otherwise
+// Decision: Only a password session may manage keys; a leaked key or an admin's impersonation token
+// must not be able to mint a credential that outlives it
+func (ah *AuthHandler) apiKeyManager(w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return nil, false
+	}
+	if ah.apiKeys == nil {
+		writeErrorResponse(w, http.StatusServiceUnavailable, "API keys are not available")
+		return nil, false
+	}
+	if _, impersonated := middleware.GetImpersonatorID(r); impersonated || middleware.UsesAPIKey(r) {
+		handleServiceError(w, errors.ErrSessionRequired)
+		return nil, false
+	}
+	return user, true
+}
+
+func toAPIKeyResponse(key *models.APIKey, loc *time.Location) types.APIKey {
+	return types.APIKey{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.ScopeList(),
+		LastUsedAt: inZone(key.LastUsedAt, loc),
+		RevokedAt:  inZone(key.RevokedAt, loc),
+		CreatedAt:  key.CreatedAt.In(loc),
+	}
+}
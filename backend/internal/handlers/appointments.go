@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// AppointmentHandler handles follow-up bookings and scheduling system callbacks
+type AppointmentHandler struct {
+	appointmentService *services.AppointmentService
+}
+
+// NewAppointmentHandler creates a new appointment handler
+func NewAppointmentHandler(appointmentService *services.AppointmentService) *AppointmentHandler {
+	return &AppointmentHandler{
+		appointmentService: appointmentService,
+	}
+}
+
+// BookFollowUpHandler books a follow-up for one of a report's recommendations
+// POST /api/reports/{id}/recommendations/{index}/bookings
+func (ah *AppointmentHandler) BookFollowUpHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	vars := mux.Vars(r)
+	reportID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid report ID")
+		return
+	}
+	index, err := strconv.Atoi(vars["index"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid recommendation index")
+		return
+	}
+
+	var req types.BookFollowUpRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+			return
+		}
+	}
+
+	booking, err := ah.appointmentService.Book(r.Context(), user, reportID, index, services.BookingInput{
+		Specialty:     req.Specialty,
+		PreferredTime: req.PreferredTime,
+		Notes:         req.Notes,
+	})
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusCreated, toAppointmentBookingResponse(booking, user.Location()))
+}
+
+// ListBookingsHandler lists the follow-ups booked for a report
+// GET /api/reports/{id}/bookings
+func (ah *AppointmentHandler) ListBookingsHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	reportID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid report ID")
+		return
+	}
+
+	bookings, err := ah.appointmentService.List(user.ID, reportID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	response := types.AppointmentBookingsResponse{ReportID: reportID, Bookings: make([]types.AppointmentBooking, len(bookings))}
+	for i, booking := range bookings {
+		response.Bookings[i] = toAppointmentBookingResponse(booking, user.Location())
+	}
+	writeJSONResponse(w, http.StatusOK, response)
+}
+
+// CallbackHandler applies a scheduling system's status update for a booking
+// POST /api/scheduling/webhook
+// Decision: Unauthenticated; the X-Signature header over the raw body is the authentication
+func (ah *AppointmentHandler) CallbackHandler(w http.ResponseWriter, r *http.Request) {
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+	if err != nil {
+		writeErrorResponse(w, http.StatusRequestEntityTooLarge, "Webhook payload too large")
+		return
+	}
+
+	if err := ah.appointmentService.HandleCallback(payload, r.Header.Get("X-Signature")); err != nil {
+		slog.WarnContext(r.Context(), "Rejected scheduling callback", "error", err)
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, map[string]string{"message": "Callback received"})
+}
+
+func toAppointmentBookingResponse(booking *models.AppointmentBooking, loc *time.Location) types.AppointmentBooking {
+	return types.AppointmentBooking{
+		ID:                  booking.ID,
+		ReportID:            booking.ReportID,
+		RecommendationIndex: booking.RecommendationIndex,
+		Recommendation:      booking.Recommendation,
+		Specialty:           booking.Specialty,
+		PreferredTime:       inZone(booking.PreferredTime, loc),
+		Status:              booking.Status,
+		Reference:           booking.ExternalRef,
+		BookingURL:          booking.BookingURL,
+		ScheduledAt:         inZone(booking.ScheduledAt, loc),
+		Error:               booking.Error,
+		CreatedAt:           booking.CreatedAt.In(loc),
+		UpdatedAt:           booking.UpdatedAt.In(loc),
+	}
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+// SummaryAudioHandler serves spoken report summaries
+type SummaryAudioHandler struct {
+	reportRepo   models.ReportRepository
+	audioService *services.SummaryAudioService
+}
+
+// NewSummaryAudioHandler creates a new summary audio handler
+func NewSummaryAudioHandler(reportRepo models.ReportRepository, audioService *services.SummaryAudioService) *SummaryAudioHandler {
+	return &SummaryAudioHandler{
+		reportRepo:   reportRepo,
+		audioService: audioService,
+	}
+}
+
+// GetSummaryAudioHandler streams an MP3 of the report's simple summary
+// GET /api/reports/{id}/summary/audio?lang=hi-IN (defaults to the Accept-Language header)
+func (ah *SummaryAudioHandler) GetSummaryAudioHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	reportID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid report ID")
+		return
+	}
+
+	languageTag, err := services.SpeechLanguage(r.URL.Query().Get("lang"), r.Header.Get("Accept-Language"))
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	report, err := ah.reportRepo.GetByID(reportID)
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve report")
+		return
+	}
+	if report == nil {
+		writeErrorResponse(w, http.StatusNotFound, "Report not found")
+		return
+	}
+	if report.UserID != user.ID {
+		writeErrorResponse(w, http.StatusForbidden, "Access denied")
+		return
+	}
+
+	path, err := ah.audioService.SummaryAudio(r.Context(), report, languageTag)
+	if err != nil {
+		if _, ok := err.(*errors.AppError); !ok {
+			slog.ErrorContext(r.Context(), "Summary audio failed", "report_id", report.ID, "error", err)
+			err = errors.ErrAIProcessingFailed
+		}
+		handleServiceError(w, err)
+		return
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to read summary audio")
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to read summary audio")
+		return
+	}
+
+	// Decision: The content hash in the filename is a stable ETag; ServeContent handles ranges for audio players
+	w.Header().Set("Content-Type", "audio/mpeg")
+	w.Header().Set("Content-Language", languageTag)
+	w.Header().Set("ETag", `"`+strings.TrimSuffix(filepath.Base(path), ".mp3")+`"`)
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	http.ServeContent(w, r, "", info.ModTime(), file)
+}
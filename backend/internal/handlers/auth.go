@@ -2,9 +2,13 @@ package handlers
 
 import (
 	"encoding/json"
+	"io"
+	"net"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
 	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
 	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
 	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
@@ -14,24 +18,22 @@ import (
 // Decision: Use struct to group related handlers and inject dependencies
 type AuthHandler struct {
 	authService *services.AuthService
+	captcha     *services.CaptchaGuard   // nil disables CAPTCHA checks
+	apiKeys     *services.APIKeyService  // nil disables API key management
+	sessions    *services.SessionService // nil while sessions are untracked
 }
 
 // NewAuthHandler creates a new authentication handler
-func NewAuthHandler(authService *services.AuthService) *AuthHandler {
+func NewAuthHandler(authService *services.AuthService, captcha *services.CaptchaGuard) *AuthHandler {
 	return &AuthHandler{
 		authService: authService,
+		captcha:     captcha,
 	}
 }
 
 // SignupHandler handles user registration requests
 // POST /api/auth/signup
 func (ah *AuthHandler) SignupHandler(w http.ResponseWriter, r *http.Request) {
-	// Decision: Only allow POST method for signup
-	if r.Method != http.MethodPost {
-		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
-		return
-	}
-
 	// Decision: Parse JSON request body
 	var req types.SignupRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -39,7 +41,14 @@ func (ah *AuthHandler) SignupHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Decision: Verify the CAPTCHA before touching the database so bots can't probe for taken emails
+	if err := ah.captcha.CheckSignup(req.CaptchaToken, clientIP(r)); err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
 	// Decision: Call authentication service for business logic
+	req.UserAgent, req.IPAddress = r.UserAgent(), clientIP(r)
 	response, err := ah.authService.SignUp(&req)
 	if err != nil {
 		handleServiceError(w, err)
@@ -53,12 +62,6 @@ func (ah *AuthHandler) SignupHandler(w http.ResponseWriter, r *http.Request) {
 // LoginHandler handles user authentication requests
 // POST /api/auth/login
 func (ah *AuthHandler) LoginHandler(w http.ResponseWriter, r *http.Request) {
-	// Decision: Only allow POST method for login
-	if r.Method != http.MethodPost {
-		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
-		return
-	}
-
 	// Decision: Parse JSON request body
 	var req types.LoginRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -66,28 +69,69 @@ func (ah *AuthHandler) LoginHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	ip := clientIP(r)
+	if err := ah.captcha.CheckLogin(req.Email, req.CaptchaToken, ip); err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
 	// Decision: Call authentication service
+	req.UserAgent, req.IPAddress = r.UserAgent(), ip
 	response, err := ah.authService.Login(&req)
 	if err != nil {
+		if err == errors.ErrInvalidCredentials {
+			ah.captcha.LoginFailed(req.Email, ip)
+		}
 		handleServiceError(w, err)
 		return
 	}
+	ah.captcha.LoginSucceeded(req.Email)
 
 	// Decision: Return 200 OK for successful login
 	writeJSONResponse(w, http.StatusOK, response)
 }
 
+// CaptchaSettingsHandler tells the frontend which CAPTCHA widget to render
+// GET /api/auth/captcha
+func (ah *AuthHandler) CaptchaSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	provider, siteKey, threshold := ah.captcha.Settings()
+	writeJSONResponse(w, http.StatusOK, types.CaptchaSettings{
+		Provider:              provider,
+		SiteKey:               siteKey,
+		RequiredForSignup:     ah.captcha.Enabled(),
+		LoginFailureThreshold: threshold,
+	})
+}
+
 // LogoutHandler handles user logout requests
 // POST /api/auth/logout
-// Decision: For now, logout is client-side (delete token). In future, could blacklist tokens.
+// Decision: The presented token and its sign-in's refresh tokens are revoked; a refresh token in the body signs
+// out its sign-in too, since the access token may already have expired. The client should still delete both
 func (ah *AuthHandler) LogoutHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
-		return
+	// Decision: The body is optional, so clients that only send the bearer token keep working
+	var req types.LogoutRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+			writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload")
+			return
+		}
+	}
+
+	if token := extractTokenFromHeader(r); token != "" {
+		if err := ah.authService.Logout(token); err != nil {
+			handleServiceError(w, err)
+			return
+		}
+	}
+	if req.RefreshToken != "" {
+		if err := ah.authService.LogoutRefreshToken(req.RefreshToken); err != nil {
+			handleServiceError(w, err)
+			return
+		}
 	}
 
 	// Decision: Return success message for logout
-	// Client should delete the token from storage
+	// Client should delete the tokens from storage
 	response := types.AuthResponse{
 		Message: "Logged out successfully",
 		Success: true,
@@ -99,57 +143,73 @@ func (ah *AuthHandler) LogoutHandler(w http.ResponseWriter, r *http.Request) {
 // MeHandler returns current user information from JWT token
 // GET /api/auth/me
 func (ah *AuthHandler) MeHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+	// Decision: Use the user RequireAuth resolved, so API keys work here as well as session tokens
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	// Decision: Return user information (password hash excluded by JSON tag)
+	setETag(w, user.Version)
+	writeJSONResponse(w, http.StatusOK, services.ToUserResponse(user))
+}
+
+// UpdateMeHandler changes the current user's name, timezone, or reading level
+// PATCH /api/auth/me
+func (ah *AuthHandler) UpdateMeHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	version, ok := parseIfMatch(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusBadRequest, "If-Match must be an ETag returned for your profile")
 		return
 	}
 
-	// Decision: Extract token from Authorization header
-	token := extractTokenFromHeader(r)
-	if token == "" {
-		writeErrorResponse(w, http.StatusUnauthorized, "Authorization token required")
+	var req types.UpdateProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
 		return
 	}
 
-	// Decision: Get user from token using auth service
-	user, err := ah.authService.GetUserFromToken(token)
+	updated, err := ah.authService.UpdateProfile(user.ID, &req, version)
+	if err == errors.ErrVersionConflict {
+		writeVersionConflict(w, updated.Version)
+		return
+	}
 	if err != nil {
 		handleServiceError(w, err)
 		return
 	}
 
-	// Decision: Return user information (password hash excluded by JSON tag)
-	writeJSONResponse(w, http.StatusOK, user)
+	setETag(w, updated.Version)
+	writeJSONResponse(w, http.StatusOK, services.ToUserResponse(updated))
 }
 
-// RefreshHandler generates a new JWT token for valid existing token
+// RefreshHandler exchanges a refresh token for a new access token and refresh token
 // POST /api/auth/refresh
+// Decision: Public, since the access token has usually expired by the time a client refreshes
 func (ah *AuthHandler) RefreshHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+	var req types.RefreshTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload")
 		return
 	}
-
-	// Decision: Extract token from Authorization header
-	token := extractTokenFromHeader(r)
-	if token == "" {
-		writeErrorResponse(w, http.StatusUnauthorized, "Authorization token required")
+	if req.RefreshToken == "" {
+		handleServiceError(w, errors.NewValidationError("refresh_token is required"))
 		return
 	}
 
-	// Decision: Generate new token
-	newToken, err := ah.authService.RefreshToken(token)
+	response, err := ah.authService.Refresh(req.RefreshToken)
 	if err != nil {
 		handleServiceError(w, err)
 		return
 	}
 
-	// Decision: Return new token in same format as login
-	response := map[string]interface{}{
-		"token":   newToken,
-		"message": "Token refreshed successfully",
-	}
-
 	writeJSONResponse(w, http.StatusOK, response)
 }
 
@@ -174,7 +234,7 @@ func extractTokenFromHeader(r *http.Request) string {
 // Decision: Map custom errors to appropriate HTTP status codes
 func handleServiceError(w http.ResponseWriter, err error) {
 	if appErr, ok := err.(*errors.AppError); ok {
-		writeErrorResponse(w, appErr.Code, appErr.Message)
+		writeEnvelope(w, appErr.Code, types.ErrorEnvelope(appErr.Code, appErr.Type, appErr.Message))
 		return
 	}
 
@@ -182,27 +242,51 @@ func handleServiceError(w http.ResponseWriter, err error) {
 	writeErrorResponse(w, http.StatusInternalServerError, "Internal server error")
 }
 
-// writeJSONResponse writes a JSON response
-// Decision: Set proper headers for JSON and CORS
-func writeJSONResponse(w http.ResponseWriter, statusCode int, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*") // Decision: Allow CORS for frontend
-	w.WriteHeader(statusCode)
+// clientIP returns the caller's address for CAPTCHA verification and failure counting
+// Decision: Use the socket address only; X-Forwarded-For is client-controlled unless a trusted proxy rewrites it
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
 
-	if err := json.NewEncoder(w).Encode(data); err != nil {
-		// Decision: Log error and write minimal error response
-		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+// inZone converts an optional timestamp to the user's zone, keeping nil as nil
+// Decision: Timestamps are stored in UTC and rendered with the caller's offset at the HTTP edge
+func inZone(t *time.Time, loc *time.Location) *time.Time {
+	if t == nil {
+		return nil
 	}
+	converted := t.In(loc)
+	return &converted
+}
+
+// writeJSONResponse writes a successful response wrapped in the standard envelope
+func writeJSONResponse(w http.ResponseWriter, statusCode int, data interface{}) {
+	writeEnvelope(w, statusCode, types.DataEnvelope(data, nil))
+}
+
+// writeJSONResponseWithMeta writes a successful response with pagination or other meta
+func writeJSONResponseWithMeta(w http.ResponseWriter, statusCode int, data interface{}, meta *types.Meta) {
+	writeEnvelope(w, statusCode, types.DataEnvelope(data, meta))
 }
 
 // writeErrorResponse writes an error response
 // Decision: Consistent error format across all endpoints
 func writeErrorResponse(w http.ResponseWriter, statusCode int, message string) {
-	errorResponse := map[string]interface{}{
-		"error":   true,
-		"message": message,
-		"status":  statusCode,
-	}
+	writeEnvelope(w, statusCode, types.ErrorEnvelope(statusCode, "", message))
+}
 
-	writeJSONResponse(w, statusCode, errorResponse)
+// writeEnvelope writes a JSON envelope
+// Decision: Set proper headers for JSON and CORS
+func writeEnvelope(w http.ResponseWriter, statusCode int, envelope types.Envelope) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*") // Decision: Allow CORS for frontend
+	w.WriteHeader(statusCode)
+
+	if err := json.NewEncoder(w).Encode(envelope); err != nil {
+		// Decision: Log error and write minimal error response
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
 }
\ No newline at end of file
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// maxWebhookBody bounds a payment provider's webhook payload; real events are a few kilobytes
+const maxWebhookBody = 1024 * 1024
+
+// BillingHandler handles premium checkout, cancellation, and payment provider webhooks
+type BillingHandler struct {
+	billingService *services.BillingService
+	providerName   string // Empty when payments are disabled
+}
+
+// NewBillingHandler creates a new billing handler
+func NewBillingHandler(billingService *services.BillingService, provider services.PaymentProvider) *BillingHandler {
+	handler := &BillingHandler{billingService: billingService}
+	if provider != nil {
+		handler.providerName = provider.Name()
+	}
+	return handler
+}
+
+// GetBillingHandler returns the caller's plan and subscription
+// GET /api/billing/subscription
+func (bh *BillingHandler) GetBillingHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	subscription, err := bh.billingService.Subscription(user)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	plan := user.Plan
+	if !models.IsValidPlan(plan) {
+		plan = models.PlanFree
+	}
+	writeJSONResponse(w, http.StatusOK, types.BillingResponse{
+		Plan:            plan,
+		PaymentsEnabled: bh.billingService.Enabled(),
+		Subscription:    toSubscriptionResponse(subscription, user),
+	})
+}
+
+// CheckoutHandler starts a premium subscription and returns the provider's payment page
+// POST /api/billing/checkout
+func (bh *BillingHandler) CheckoutHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	checkout, err := bh.billingService.Checkout(r.Context(), user)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusCreated, types.CheckoutResponse{
+		Provider:    bh.providerName,
+		CheckoutURL: checkout.URL,
+	})
+}
+
+// CancelHandler stops renewal; the caller stays premium until the paid period ends
+// POST /api/billing/cancel
+func (bh *BillingHandler) CancelHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	subscription, err := bh.billingService.Cancel(r.Context(), user)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, toSubscriptionResponse(subscription, user))
+}
+
+// WebhookHandler applies a payment provider's subscription event
+// POST /api/billing/webhook
+// Decision: Unauthenticated; the provider's signature over the raw body is the authentication
+func (bh *BillingHandler) WebhookHandler(w http.ResponseWriter, r *http.Request) {
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+	if err != nil {
+		writeErrorResponse(w, http.StatusRequestEntityTooLarge, "Webhook payload too large")
+		return
+	}
+
+	if err := bh.billingService.HandleWebhook(payload, r.Header); err != nil {
+		slog.WarnContext(r.Context(), "Rejected payment webhook", "provider", bh.providerName, "error", err)
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, map[string]string{"message": "Webhook received"})
+}
+
+func toSubscriptionResponse(subscription *models.Subscription, user *models.User) *types.Subscription {
+	if subscription == nil {
+		return nil
+	}
+	return &types.Subscription{
+		Provider:          subscription.Provider,
+		Status:            subscription.Status,
+		CurrentPeriodEnd:  inZone(subscription.CurrentPeriodEnd, user.Location()),
+		CancelAtPeriodEnd: subscription.CancelAtPeriodEnd,
+		UpdatedAt:         subscription.UpdatedAt.In(user.Location()),
+	}
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+)
+
+// CalculatorHandler handles clinical risk calculators
+type CalculatorHandler struct {
+	calculatorService *services.CalculatorService
+}
+
+// NewCalculatorHandler creates a new calculator handler
+func NewCalculatorHandler(calculatorService *services.CalculatorService) *CalculatorHandler {
+	return &CalculatorHandler{
+		calculatorService: calculatorService,
+	}
+}
+
+// CalculateHandler runs one calculator with the query's inputs and lab values from the user's newest reports
+// GET /api/calculators/{name}?age=55&sex=female&systolic_bp=120&smoker=false&diabetic=false&bp_treated=false
+func (ch *CalculatorHandler) CalculateHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	inputs, err := parseCalculatorInputs(r.URL.Query())
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := ch.calculatorService.Calculate(user.ID, mux.Vars(r)["name"], inputs)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, result)
+}
+
+// parseCalculatorInputs reads calculator inputs from query parameters; absent ones stay nil
+func parseCalculatorInputs(query url.Values) (services.CalculatorInputs, error) {
+	inputs := services.CalculatorInputs{
+		Sex:  strings.ToLower(query.Get("sex")),
+		Race: strings.ToLower(query.Get("race")),
+	}
+	if inputs.Sex != "" && inputs.Sex != "male" && inputs.Sex != "female" {
+		return inputs, fmt.Errorf("sex must be male or female")
+	}
+
+	numbers := map[string]**float64{
+		"age":               &inputs.Age,
+		"height_cm":         &inputs.HeightCm,
+		"weight_kg":         &inputs.WeightKg,
+		"systolic_bp":       &inputs.SystolicBP,
+		"total_cholesterol": &inputs.TotalCholesterol,
+		"hdl":               &inputs.HDL,
+		"creatinine":        &inputs.Creatinine,
+	}
+	for name, field := range numbers {
+		raw := query.Get(name)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil || value <= 0 {
+			return inputs, fmt.Errorf("%s must be a positive number", name)
+		}
+		*field = &value
+	}
+
+	flags := map[string]**bool{
+		"smoker":     &inputs.Smoker,
+		"diabetic":   &inputs.Diabetic,
+		"bp_treated": &inputs.BPTreated,
+	}
+	for name, field := range flags {
+		raw := query.Get(name)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return inputs, fmt.Errorf("%s must be true or false", name)
+		}
+		*field = &value
+	}
+
+	return inputs, nil
+}
@@ -0,0 +1,394 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// ChatHandler handles report Q&A HTTP requests
+type ChatHandler struct {
+	chatService       *services.ChatService
+	brandingService   *services.BrandingService // Optional; nil exports without organization branding
+	planService       *services.PlanService
+	moderationService *services.ModerationService // Optional; nil answers flag requests with 503
+	maxAudioBytes     int64
+}
+
+// NewChatHandler creates a new chat handler; maxAudioBytes bounds voice question uploads
+func NewChatHandler(chatService *services.ChatService, brandingService *services.BrandingService, planService *services.PlanService, maxAudioBytes int64) *ChatHandler {
+	if maxAudioBytes <= 0 {
+		maxAudioBytes = 10 * 1024 * 1024
+	}
+	return &ChatHandler{
+		chatService:     chatService,
+		brandingService: brandingService,
+		planService:     planService,
+		maxAudioBytes:   maxAudioBytes,
+	}
+}
+
+// AskHandler answers a typed question about a report, returning the stored question and answer
+// POST /api/reports/{id}/chat
+func (ch *ChatHandler) AskHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	reportID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid report ID")
+		return
+	}
+
+	var req types.ChatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	readingLevel := req.ReadingLevel
+	if readingLevel == "" {
+		readingLevel = user.ReadingLevel
+	}
+
+	message, err := ch.chatService.Ask(user.ID, reportID, req.Message, readingLevel, user.Plan)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusCreated, toChatMessageResponse(message, user.Location()))
+}
+
+// GetChatHistoryHandler returns a page of a report's conversation, oldest first
+// GET /api/reports/{id}/chat?limit=20&offset=0
+func (ch *ChatHandler) GetChatHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	reportID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid report ID")
+		return
+	}
+
+	limit, offset := parsePaginationParams(r)
+	messages, err := ch.chatService.GetHistory(user.ID, reportID, limit, offset)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	loc := user.Location()
+	response := make([]types.ChatMessage, len(messages))
+	for i, message := range messages {
+		response[i] = toChatMessageResponse(message, loc)
+	}
+
+	meta := &types.Meta{Pagination: &types.Pagination{Limit: limit, Offset: offset, Count: len(response)}}
+	writeJSONResponseWithMeta(w, http.StatusOK, response, meta)
+}
+
+// DeleteMessageHandler removes a question and its answer from a report's conversation
+// DELETE /api/chat/{messageId}
+func (ch *ChatHandler) DeleteMessageHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	messageID, err := strconv.Atoi(mux.Vars(r)["messageId"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid message ID")
+		return
+	}
+
+	if err := ch.chatService.DeleteMessage(user.ID, messageID); err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, types.MessageResponse{Message: "Message deleted successfully"})
+}
+
+// AskVoiceHandler answers a recorded question, returning the stored transcription and answer
+// POST /api/reports/{id}/chat/voice (multipart: audio, optional language and reading_level)
+func (ch *ChatHandler) AskVoiceHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	reportID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid report ID")
+		return
+	}
+
+	// Decision: Cap the whole body, not just the part, so an oversized upload is cut off while streaming
+	r.Body = http.MaxBytesReader(w, r.Body, ch.maxAudioBytes+64*1024)
+	if err := r.ParseMultipartForm(ch.maxAudioBytes); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Recording too large or invalid form data")
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("audio")
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "No recording provided in the audio field")
+		return
+	}
+	defer file.Close()
+
+	audio, err := io.ReadAll(io.LimitReader(file, ch.maxAudioBytes+1))
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Failed to read recording")
+		return
+	}
+	if len(audio) == 0 || int64(len(audio)) > ch.maxAudioBytes {
+		writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Recording must be between 1 byte and %d MB", ch.maxAudioBytes/(1024*1024)))
+		return
+	}
+
+	audioType, ok := services.DetectAudioType(audio, header.Header.Get("Content-Type"))
+	if !ok {
+		writeErrorResponse(w, http.StatusUnsupportedMediaType, "Unsupported recording format, use webm, ogg, mp4/m4a, mp3, or wav")
+		return
+	}
+
+	readingLevel := r.FormValue("reading_level")
+	if readingLevel == "" {
+		readingLevel = user.ReadingLevel
+	}
+
+	voice := services.VoiceQuestion{
+		Audio:     audio,
+		AudioType: audioType,
+		Language:  r.FormValue("language"),
+	}
+	message, err := ch.chatService.AskByVoice(r.Context(), user.ID, reportID, voice, readingLevel, user.Plan)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusCreated, toChatMessageResponse(message, user.Location()))
+}
+
+// EditMessageHandler replaces a question and regenerates its answer
+// PUT /api/chat/{messageId}
+func (ch *ChatHandler) EditMessageHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	messageID, err := strconv.Atoi(mux.Vars(r)["messageId"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid message ID")
+		return
+	}
+
+	var req types.ChatEditRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	// Decision: A per-request level answers one question differently without changing the saved preference
+	readingLevel := req.ReadingLevel
+	if readingLevel == "" {
+		readingLevel = user.ReadingLevel
+	}
+
+	message, err := ch.chatService.EditMessage(user.ID, messageID, req.Message, readingLevel)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, toChatMessageResponse(message, user.Location()))
+}
+
+// RegenerateMessageHandler asks the AI the same question again
+// POST /api/chat/{messageId}/regenerate?reading_level=child|standard|clinical
+func (ch *ChatHandler) RegenerateMessageHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	messageID, err := strconv.Atoi(mux.Vars(r)["messageId"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid message ID")
+		return
+	}
+
+	readingLevel := r.URL.Query().Get("reading_level")
+	if readingLevel == "" {
+		readingLevel = user.ReadingLevel
+	}
+
+	message, err := ch.chatService.RegenerateMessage(user.ID, messageID, readingLevel)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, toChatMessageResponse(message, user.Location()))
+}
+
+// GetMessageVersionsHandler returns a message and its earlier versions
+// GET /api/chat/{messageId}/versions
+func (ch *ChatHandler) GetMessageVersionsHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	messageID, err := strconv.Atoi(mux.Vars(r)["messageId"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid message ID")
+		return
+	}
+
+	message, versions, err := ch.chatService.GetVersions(user.ID, messageID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	response := types.ChatVersionsResponse{
+		Current:  toChatMessageResponse(message, user.Location()),
+		Versions: make([]types.ChatMessageVersion, len(versions)),
+	}
+	for i, version := range versions {
+		response.Versions[i] = types.ChatMessageVersion{
+			Version:     version.Version,
+			UserMessage: version.UserMessage,
+			AIResponse:  version.AIResponse,
+			CreatedAt:   version.CreatedAt.In(user.Location()),
+		}
+	}
+
+	writeJSONResponse(w, http.StatusOK, response)
+}
+
+// ExportChatHandler downloads a report's conversation as JSON, markdown, or PDF
+// GET /api/reports/{id}/chat/export?format=json|markdown|pdf
+func (ch *ChatHandler) ExportChatHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	reportID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid report ID")
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+
+	var contentType, extension string
+	switch format {
+	case "json":
+		contentType, extension = "application/json", "json"
+	case "markdown", "md":
+		contentType, extension = "text/markdown; charset=utf-8", "md"
+	case "pdf":
+		contentType, extension = "application/pdf", "pdf"
+	default:
+		writeErrorResponse(w, http.StatusBadRequest, "Unsupported format, use json, markdown, or pdf")
+		return
+	}
+	if extension == "pdf" {
+		if err := ch.planService.Require(user, services.FeaturePDFExport); err != nil {
+			handleServiceError(w, err)
+			return
+		}
+	}
+
+	transcript, err := ch.chatService.GetTranscript(user, reportID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+	if ch.brandingService != nil {
+		if transcript.Branding, err = ch.brandingService.ForUser(user.ID); err != nil {
+			handleServiceError(w, err)
+			return
+		}
+	}
+
+	var body []byte
+	switch extension {
+	case "json":
+		response := types.ChatTranscript{
+			ReportID:       transcript.Report.ID,
+			ReportFilename: transcript.Report.OriginalFilename,
+			UploadDate:     transcript.Report.UploadDate.In(user.Location()),
+			ExportedAt:     transcript.ExportedAt,
+			Disclaimer:     transcript.Disclaimer,
+			Messages:       make([]types.ChatMessage, len(transcript.Messages)),
+		}
+		for i, message := range transcript.Messages {
+			response.Messages[i] = toChatMessageResponse(message, user.Location())
+		}
+		body, err = json.MarshalIndent(response, "", "  ")
+		if err != nil {
+			writeErrorResponse(w, http.StatusInternalServerError, "Failed to export conversation")
+			return
+		}
+	case "md":
+		body = services.RenderTranscriptMarkdown(transcript)
+	case "pdf":
+		body = services.RenderTranscriptPDF(transcript)
+	}
+
+	// Decision: Always an attachment so browsers save the transcript for sharing rather than rendering it inline
+	filename := fmt.Sprintf("report-%d-chat.%s", reportID, extension)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+func toChatMessageResponse(message *models.ChatMessage, loc *time.Location) types.ChatMessage {
+	return types.ChatMessage{
+		ID:          message.ID,
+		ReportID:    message.ReportID,
+		UserMessage: message.UserMessage,
+		AIResponse:  message.AIResponse,
+		Version:     message.Version,
+		CreatedAt:   message.CreatedAt.In(loc),
+		UpdatedAt:   inZone(message.UpdatedAt, loc),
+		InputMode:   message.InputMode,
+	}
+}
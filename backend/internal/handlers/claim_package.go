@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// GenerateClaimPackageHandler bundles a processed report for an insurance claim, replacing any earlier package
+// POST /api/reports/{id}/claim-package
+func (rh *ReportHandler) GenerateClaimPackageHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	reportID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid report ID")
+		return
+	}
+
+	var req types.ClaimPackageRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+			return
+		}
+	}
+
+	if rh.claims == nil {
+		writeErrorResponse(w, http.StatusServiceUnavailable, "Claim packages are not available")
+		return
+	}
+
+	pkg, err := rh.claims.Generate(user, reportID, services.ClaimDetails{
+		Insurer:        req.Insurer,
+		PolicyNumber:   req.PolicyNumber,
+		ClaimReference: req.ClaimReference,
+	})
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusCreated, toClaimPackageResponse(pkg, user.Location()))
+}
+
+// GetClaimPackageHandler describes a report's stored claim package
+// GET /api/reports/{id}/claim-package
+func (rh *ReportHandler) GetClaimPackageHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	reportID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid report ID")
+		return
+	}
+
+	if rh.claims == nil {
+		writeErrorResponse(w, http.StatusServiceUnavailable, "Claim packages are not available")
+		return
+	}
+
+	pkg, err := rh.claims.Get(user.ID, reportID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, toClaimPackageResponse(pkg, user.Location()))
+}
+
+// DownloadClaimPackageHandler streams a report's stored claim package as a ZIP
+// GET /api/reports/{id}/claim-package/file
+func (rh *ReportHandler) DownloadClaimPackageHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	reportID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid report ID")
+		return
+	}
+
+	if rh.claims == nil {
+		writeErrorResponse(w, http.StatusServiceUnavailable, "Claim packages are not available")
+		return
+	}
+
+	pkg, file, err := rh.claims.Open(user.ID, reportID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": claimPackageFilename(pkg)}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, "", pkg.CreatedAt, file)
+}
+
+// claimPackageFilename names a package after its report
+func claimPackageFilename(pkg *models.ClaimPackage) string {
+	return fmt.Sprintf("report-%d-claim-package.zip", pkg.ReportID)
+}
+
+func toClaimPackageResponse(pkg *models.ClaimPackage, loc *time.Location) types.ClaimPackage {
+	return types.ClaimPackage{
+		ID:             pkg.ID,
+		ReportID:       pkg.ReportID,
+		Filename:       claimPackageFilename(pkg),
+		FileSize:       pkg.FileSize,
+		Checksum:       pkg.Checksum,
+		Insurer:        pkg.Insurer,
+		PolicyNumber:   pkg.PolicyNumber,
+		ClaimReference: pkg.ClaimReference,
+		DownloadURL:    fmt.Sprintf("/api/reports/%d/claim-package/file", pkg.ReportID),
+		CreatedAt:      pkg.CreatedAt.In(loc),
+	}
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// Optimistic concurrency for edits: responses carry the resource's version as a strong ETag, and an edit sent with
+// If-Match only applies while the resource is still at that version
+// Decision: Edits without If-Match still apply unconditionally, so existing clients keep working; clients opt in
+// by echoing the ETag (or the version field of the JSON body)
+
+// setETag tags the response with the version of the resource it carries
+func setETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", fmt.Sprintf(`"%d"`, version))
+}
+
+// parseIfMatch returns the version the client's edit was made against, or 0 when it sent no If-Match or "*"
+// Returns false for anything that isn't one of our ETags, which can never match
+func parseIfMatch(r *http.Request) (int, bool) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" || value == "*" {
+		return 0, true
+	}
+	// Decision: Weak tags are accepted since proxies weaken ETags they recompress; the version is the same either way
+	value = strings.Trim(strings.TrimPrefix(value, "W/"), `"`)
+	version, err := strconv.Atoi(value)
+	if err != nil || version < 1 {
+		return 0, false
+	}
+	return version, true
+}
+
+// writeVersionConflict answers an edit made against an outdated version with 409 and the current version
+func writeVersionConflict(w http.ResponseWriter, currentVersion int) {
+	setETag(w, currentVersion)
+	conflict := errors.ErrVersionConflict
+	writeEnvelope(w, conflict.Code, types.Envelope{Error: &types.APIError{
+		Status:         conflict.Code,
+		Type:           conflict.Type,
+		Message:        conflict.Message,
+		CurrentVersion: currentVersion,
+	}})
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// ConditionHandler handles tracked chronic conditions and their overviews
+type ConditionHandler struct {
+	conditionService *services.ConditionService
+}
+
+// NewConditionHandler creates a new condition handler
+func NewConditionHandler(conditionService *services.ConditionService) *ConditionHandler {
+	return &ConditionHandler{
+		conditionService: conditionService,
+	}
+}
+
+// ListConditionsHandler returns every trackable condition and whether the user tracks it
+// GET /api/conditions
+func (ch *ConditionHandler) ListConditionsHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	conditions, err := ch.conditionService.List(user.ID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	response := types.ConditionListResponse{Conditions: make([]types.TrackedCondition, len(conditions))}
+	for i, condition := range conditions {
+		response.Conditions[i] = toTrackedConditionResponse(condition, user.Location())
+	}
+	writeJSONResponse(w, http.StatusOK, response)
+}
+
+// TrackConditionHandler starts tracking a condition
+// PUT /api/conditions/{key}
+func (ch *ConditionHandler) TrackConditionHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	condition, err := ch.conditionService.Track(user.ID, mux.Vars(r)["key"])
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, toTrackedConditionResponse(*condition, user.Location()))
+}
+
+// UntrackConditionHandler stops tracking a condition
+// DELETE /api/conditions/{key}
+func (ch *ConditionHandler) UntrackConditionHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	if err := ch.conditionService.Untrack(user.ID, mux.Vars(r)["key"]); err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, map[string]string{"message": "Condition no longer tracked"})
+}
+
+// ConditionOverviewHandler returns the metrics and findings related to a tracked condition across the user's reports
+// GET /api/conditions/{key}/overview
+func (ch *ConditionHandler) ConditionOverviewHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	overview, err := ch.conditionService.Overview(user, mux.Vars(r)["key"])
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	response := types.ConditionOverview{
+		Key:         overview.Condition.Key,
+		Name:        overview.Condition.Name,
+		Since:       overview.Since.In(user.Location()),
+		ReportCount: overview.ReportCount,
+		Metrics:     make([]types.ConditionMetric, len(overview.Metrics)),
+		Findings:    make([]types.ConditionFinding, len(overview.Findings)),
+	}
+	for i, metric := range overview.Metrics {
+		readings := make([]types.ConditionReading, len(metric.Readings))
+		for j, reading := range metric.Readings {
+			readings[j] = types.ConditionReading{
+				ReportID: reading.ReportID,
+				Date:     reading.Date.Format(reportDateLayout),
+				Value:    reading.Value,
+				Unit:     reading.Unit,
+				Status:   reading.Status,
+			}
+		}
+		response.Metrics[i] = types.ConditionMetric{Name: metric.Name, LatestStatus: metric.LatestStatus, Readings: readings}
+	}
+	for i, finding := range overview.Findings {
+		response.Findings[i] = types.ConditionFinding{
+			ReportID: finding.ReportID,
+			Date:     finding.Date.Format(reportDateLayout),
+			Text:     finding.Text,
+		}
+	}
+	writeJSONResponse(w, http.StatusOK, response)
+}
+
+func toTrackedConditionResponse(condition services.TrackedCondition, loc *time.Location) types.TrackedCondition {
+	response := types.TrackedCondition{Key: condition.Key, Name: condition.Name, Tracked: condition.Tracked}
+	if condition.Since != nil {
+		since := condition.Since.In(loc)
+		response.Since = &since
+	}
+	return response
+}
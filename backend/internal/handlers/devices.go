@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// DeviceHandler handles push notification device registrations
+type DeviceHandler struct {
+	push *services.PushService
+}
+
+// NewDeviceHandler creates a new device handler
+func NewDeviceHandler(push *services.PushService) *DeviceHandler {
+	return &DeviceHandler{
+		push: push,
+	}
+}
+
+// RegisterDeviceHandler registers the app's push token for the caller
+// POST /api/devices
+func (dh *DeviceHandler) RegisterDeviceHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	var req types.RegisterDeviceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	device, err := dh.push.Register(user.ID, req.Platform, req.Token, req.Name)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusCreated, dh.toDeviceResponse(device, user.Location()))
+}
+
+// ListDevicesHandler lists the caller's registered devices, most recently seen first
+// GET /api/devices
+func (dh *DeviceHandler) ListDevicesHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	devices, err := dh.push.List(user.ID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	response := types.DevicesResponse{Devices: make([]types.Device, len(devices)), Platforms: dh.push.Platforms()}
+	for i, device := range devices {
+		response.Devices[i] = dh.toDeviceResponse(device, user.Location())
+	}
+	writeJSONResponse(w, http.StatusOK, response)
+}
+
+// UnregisterDeviceHandler stops pushes to one of the caller's devices, e.g. on sign-out
+// DELETE /api/devices/{id}
+func (dh *DeviceHandler) UnregisterDeviceHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid device ID")
+		return
+	}
+
+	if err := dh.push.Unregister(user.ID, id); err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, map[string]string{"message": "Device unregistered"})
+}
+
+func (dh *DeviceHandler) toDeviceResponse(device *models.PushDevice, loc *time.Location) types.Device {
+	return types.Device{
+		ID:          device.ID,
+		Platform:    device.Platform,
+		Name:        device.Name,
+		PushEnabled: dh.push.Enabled(device.Platform),
+		CreatedAt:   device.CreatedAt.In(loc),
+		LastSeenAt:  device.LastSeenAt.In(loc),
+	}
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// EmergencyCardHandler handles the emergency summary card and its public link
+type EmergencyCardHandler struct {
+	cardService *services.EmergencyCardService
+}
+
+// NewEmergencyCardHandler creates a new emergency card handler
+func NewEmergencyCardHandler(cardService *services.EmergencyCardService) *EmergencyCardHandler {
+	return &EmergencyCardHandler{
+		cardService: cardService,
+	}
+}
+
+// GetEmergencyCardHandler returns the user's emergency card
+// GET /api/users/me/emergency-card?format=json|pdf
+func (eh *EmergencyCardHandler) GetEmergencyCardHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	format, ok := emergencyCardFormat(w, r)
+	if !ok {
+		return
+	}
+
+	card, err := eh.cardService.Card(user)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+	if format == "pdf" {
+		writeEmergencyCardPDF(w, card)
+		return
+	}
+
+	link, err := eh.cardService.PublicLink(user.ID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+	response := toEmergencyCardResponse(card, true)
+	response.Sharing = toEmergencyCardSharingResponse(link, user.Location())
+	writeJSONResponse(w, http.StatusOK, response)
+}
+
+// UpdateEmergencyCardSharingHandler turns the public card link on or off
+// PUT /api/users/me/emergency-card/sharing
+// Decision: Turning sharing on always issues a new token, so a lost or printed card can be invalidated by re-enabling
+func (eh *EmergencyCardHandler) UpdateEmergencyCardSharingHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	var req types.EmergencyCardSharingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	if !req.Enabled {
+		if err := eh.cardService.DisablePublicLink(user.ID); err != nil {
+			handleServiceError(w, err)
+			return
+		}
+		writeJSONResponse(w, http.StatusOK, types.EmergencyCardSharing{Enabled: false})
+		return
+	}
+
+	link, token, err := eh.cardService.EnablePublicLink(user.ID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+	response := toEmergencyCardSharingResponse(link, user.Location())
+	response.Token = token
+	response.Path = "/api/emergency-card/" + token
+	writeJSONResponse(w, http.StatusOK, response)
+}
+
+// ViewPublicEmergencyCardHandler shows an emergency card to whoever holds its link, such as a first responder scanning the QR code
+// GET /api/emergency-card/{token}?format=json|pdf
+func (eh *EmergencyCardHandler) ViewPublicEmergencyCardHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+
+	format, ok := emergencyCardFormat(w, r)
+	if !ok {
+		return
+	}
+
+	card, err := eh.cardService.OpenPublicLink(mux.Vars(r)["token"])
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+	if format == "pdf" {
+		writeEmergencyCardPDF(w, card)
+		return
+	}
+	writeJSONResponse(w, http.StatusOK, toEmergencyCardResponse(card, false))
+}
+
+// emergencyCardFormat reads the format query parameter, writing a 400 for anything but json or pdf
+func emergencyCardFormat(w http.ResponseWriter, r *http.Request) (string, bool) {
+	format := r.URL.Query().Get("format")
+	switch format {
+	case "", "json":
+		return "json", true
+	case "pdf":
+		return "pdf", true
+	}
+	writeErrorResponse(w, http.StatusBadRequest, "Unsupported format, use json or pdf")
+	return "", false
+}
+
+// writeEmergencyCardPDF sends a card inline so a phone that scanned the QR code opens it directly
+func writeEmergencyCardPDF(w http.ResponseWriter, card *services.EmergencyCard) {
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": "emergency-card.pdf"}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	w.Write(services.RenderEmergencyCardPDF(card))
+}
+
+func toEmergencyCardResponse(card *services.EmergencyCard, owner bool) types.EmergencyCard {
+	response := types.EmergencyCard{
+		FullName:       card.FullName,
+		Age:            card.Age,
+		Sex:            card.Sex,
+		BloodGroup:     card.BloodGroup,
+		Conditions:     card.Conditions,
+		Allergies:      card.Allergies,
+		Medications:    card.Medications,
+		CriticalValues: make([]types.EmergencyCriticalValue, len(card.CriticalValues)),
+		GeneratedAt:    card.GeneratedAt,
+	}
+	for i, value := range card.CriticalValues {
+		response.CriticalValues[i] = types.EmergencyCriticalValue{
+			Name:  value.Name,
+			Value: value.Value,
+			Unit:  value.Unit,
+			Date:  value.Date.Format(reportDateLayout),
+		}
+		if owner {
+			reportID := value.ReportID
+			response.CriticalValues[i].ReportID = &reportID
+		}
+	}
+	return response
+}
+
+func toEmergencyCardSharingResponse(link *models.EmergencyCardLink, loc *time.Location) *types.EmergencyCardSharing {
+	if link == nil {
+		return &types.EmergencyCardSharing{Enabled: false}
+	}
+	createdAt := link.CreatedAt.In(loc)
+	return &types.EmergencyCardSharing{
+		Enabled:      true,
+		CreatedAt:    &createdAt,
+		LastViewedAt: inZone(link.LastViewedAt, loc),
+	}
+}
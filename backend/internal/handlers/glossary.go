@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// GlossaryHandler serves plain-language definitions of medical terms
+type GlossaryHandler struct {
+	glossaryService *services.GlossaryService
+}
+
+// NewGlossaryHandler creates a new glossary handler
+func NewGlossaryHandler(glossaryService *services.GlossaryService) *GlossaryHandler {
+	return &GlossaryHandler{
+		glossaryService: glossaryService,
+	}
+}
+
+// GetTermHandler returns the definition of one term, generating it on the first lookup
+// GET /api/glossary?term=HDL
+func (gh *GlossaryHandler) GetTermHandler(w http.ResponseWriter, r *http.Request) {
+	entry, err := gh.glossaryService.Lookup(r.URL.Query().Get("term"))
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	// Decision: Definitions never change once stored, so browsers may reuse them for a day
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	writeJSONResponse(w, http.StatusOK, types.GlossaryEntry{
+		Term:       entry.Term,
+		Key:        entry.Key,
+		Definition: entry.Definition,
+		Source:     entry.Source,
+	})
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// HealthProfileHandler handles the optional health details that personalize analyses and chat
+type HealthProfileHandler struct {
+	profileService *services.HealthProfileService
+}
+
+// NewHealthProfileHandler creates a new health profile handler
+func NewHealthProfileHandler(profileService *services.HealthProfileService) *HealthProfileHandler {
+	return &HealthProfileHandler{
+		profileService: profileService,
+	}
+}
+
+// GetHealthProfileHandler returns the user's health profile; empty if never saved
+// GET /api/health-profile
+func (hh *HealthProfileHandler) GetHealthProfileHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	profile, err := hh.profileService.Get(user.ID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, toHealthProfileResponse(profile, user.Location()))
+}
+
+// UpdateHealthProfileHandler replaces the user's health profile; omitted fields are cleared
+// PUT /api/health-profile
+func (hh *HealthProfileHandler) UpdateHealthProfileHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	var req types.HealthProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	profile := &models.HealthProfile{
+		Sex:         req.Sex,
+		HeightCm:    req.HeightCm,
+		WeightKg:    req.WeightKg,
+		Conditions:  req.Conditions,
+		Allergies:   req.Allergies,
+		Medications: req.Medications,
+		BloodGroup:  req.BloodGroup,
+	}
+	if req.DateOfBirth != nil && *req.DateOfBirth != "" {
+		dob, err := time.Parse(reportDateLayout, *req.DateOfBirth)
+		if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, "date_of_birth must be YYYY-MM-DD")
+			return
+		}
+		profile.DateOfBirth = &dob
+	}
+
+	profile, err := hh.profileService.Update(user.ID, profile)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, toHealthProfileResponse(profile, user.Location()))
+}
+
+// DeleteHealthProfileHandler clears the user's health profile
+// DELETE /api/health-profile
+func (hh *HealthProfileHandler) DeleteHealthProfileHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	if err := hh.profileService.Delete(user.ID); err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, map[string]string{"message": "Health profile cleared"})
+}
+
+func toHealthProfileResponse(profile *models.HealthProfile, loc *time.Location) types.HealthProfile {
+	response := types.HealthProfile{
+		Age:         profile.Age(time.Now().In(loc)),
+		Sex:         profile.Sex,
+		HeightCm:    profile.HeightCm,
+		WeightKg:    profile.WeightKg,
+		Conditions:  profile.Conditions,
+		Allergies:   profile.Allergies,
+		Medications: profile.Medications,
+		BloodGroup:  profile.BloodGroup,
+		UpdatedAt:   profile.UpdatedAt.In(loc),
+	}
+	if profile.DateOfBirth != nil {
+		dob := profile.DateOfBirth.Format(reportDateLayout)
+		response.DateOfBirth = &dob
+	}
+	return response
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// InsightsHandler handles comparisons with anonymized statistics of other users
+type InsightsHandler struct {
+	populationService *services.PopulationService
+}
+
+// NewInsightsHandler creates a new insights handler
+func NewInsightsHandler(populationService *services.PopulationService) *InsightsHandler {
+	return &InsightsHandler{
+		populationService: populationService,
+	}
+}
+
+// GetPopulationInsightsHandler compares the user's latest values with peers in their age band
+// GET /api/insights/population
+func (ih *InsightsHandler) GetPopulationInsightsHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	comparison, err := ih.populationService.Compare(user.ID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	response := types.PopulationInsightsResponse{
+		AgeBand:   comparison.AgeBand,
+		MinCohort: comparison.MinCohort,
+		Metrics:   make([]types.PopulationMetric, len(comparison.Metrics)),
+	}
+	for i, metric := range comparison.Metrics {
+		response.Metrics[i] = types.PopulationMetric{
+			Name:         metric.Stat.DisplayName,
+			Unit:         metric.Unit,
+			Value:        metric.Value,
+			ReportID:     metric.ReportID,
+			Position:     metric.Position,
+			Contributors: metric.Stat.Contributors,
+			P10:          metric.Stat.P10,
+			P25:          metric.Stat.P25,
+			P50:          metric.Stat.P50,
+			P75:          metric.Stat.P75,
+			P90:          metric.Stat.P90,
+			ComputedAt:   metric.Stat.ComputedAt,
+		}
+	}
+
+	writeJSONResponse(w, http.StatusOK, response)
+}
+
+// GetPopulationConsentHandler returns whether the user shares their values with the population statistics
+// GET /api/insights/population/consent
+func (ih *InsightsHandler) GetPopulationConsentHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	consent, err := ih.populationService.Consent(user.ID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, types.PopulationConsentResponse{OptedIn: consent.OptedIn, ConsentedAt: consent.ConsentedAt})
+}
+
+// UpdatePopulationConsentHandler opts the user in to or out of the population statistics
+// PUT /api/insights/population/consent
+func (ih *InsightsHandler) UpdatePopulationConsentHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	var req types.PopulationConsentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+	// Decision: Consent must be stated explicitly; an empty body never opts anyone in
+	if req.OptedIn == nil {
+		writeErrorResponse(w, http.StatusBadRequest, "opted_in is required")
+		return
+	}
+
+	consent, err := ih.populationService.SetConsent(user.ID, *req.OptedIn)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, types.PopulationConsentResponse{OptedIn: consent.OptedIn, ConsentedAt: consent.ConsentedAt})
+}
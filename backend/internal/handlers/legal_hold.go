@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// SetLegalHoldService enables placing and releasing legal holds
+func (ah *AdminHandler) SetLegalHoldService(legalHolds *services.LegalHoldService) {
+	ah.legalHolds = legalHolds
+}
+
+// ListLegalHoldsHandler lists the users whose data is under a legal hold, newest hold first
+// GET /api/admin/legal-holds
+func (ah *AdminHandler) ListLegalHoldsHandler(w http.ResponseWriter, r *http.Request) {
+	if ah.legalHolds == nil {
+		writeErrorResponse(w, http.StatusServiceUnavailable, "Legal holds are not available")
+		return
+	}
+
+	holds, err := ah.legalHolds.List()
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	response := types.LegalHoldListResponse{Holds: make([]types.LegalHoldResponse, len(holds))}
+	for i, hold := range holds {
+		response.Holds[i] = toLegalHoldResponse(hold)
+	}
+	writeJSONResponse(w, http.StatusOK, response)
+}
+
+// GetLegalHoldHandler returns a user's legal hold
+// GET /api/admin/users/{userId}/legal-hold
+func (ah *AdminHandler) GetLegalHoldHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := ah.heldUserID(w, r)
+	if !ok {
+		return
+	}
+
+	hold, err := ah.legalHolds.Get(userID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, toLegalHoldResponse(hold))
+}
+
+// PlaceLegalHoldHandler keeps a user's reports and related data from being deleted or overwritten
+// PUT /api/admin/users/{userId}/legal-hold
+func (ah *AdminHandler) PlaceLegalHoldHandler(w http.ResponseWriter, r *http.Request) {
+	admin, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+	userID, ok := ah.heldUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req types.PlaceLegalHoldRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	hold, err := ah.legalHolds.Place(admin, userID, req.Reason, req.Reference)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, toLegalHoldResponse(hold))
+}
+
+// ReleaseLegalHoldHandler lifts a user's legal hold
+// DELETE /api/admin/users/{userId}/legal-hold
+func (ah *AdminHandler) ReleaseLegalHoldHandler(w http.ResponseWriter, r *http.Request) {
+	admin, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+	userID, ok := ah.heldUserID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := ah.legalHolds.Release(admin, userID); err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, types.MessageResponse{Message: "Legal hold released"})
+}
+
+// heldUserID reads the user ID of a legal hold request, writing the error response if it can't be served
+func (ah *AdminHandler) heldUserID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	if ah.legalHolds == nil {
+		writeErrorResponse(w, http.StatusServiceUnavailable, "Legal holds are not available")
+		return 0, false
+	}
+	userID, err := strconv.Atoi(mux.Vars(r)["userId"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid user ID")
+		return 0, false
+	}
+	return userID, true
+}
+
+func toLegalHoldResponse(hold *models.LegalHold) types.LegalHoldResponse {
+	return types.LegalHoldResponse{
+		UserID:    hold.UserID,
+		Reason:    hold.Reason,
+		Reference: hold.Reference,
+		PlacedBy:  hold.PlacedBy,
+		PlacedAt:  hold.PlacedAt,
+	}
+}
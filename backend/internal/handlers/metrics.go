@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+	"sync"
+)
+
+// MetricsSource returns a JSON-serializable snapshot of a component's metrics
+type MetricsSource func() any
+
+// MetricsHandler exposes runtime metrics collected from registered components
+// Decision: Components register snapshot functions instead of the handler knowing every type
+type MetricsHandler struct {
+	mu      sync.RWMutex
+	sources map[string]MetricsSource
+}
+
+// NewMetricsHandler creates an empty metrics handler
+func NewMetricsHandler() *MetricsHandler {
+	return &MetricsHandler{
+		sources: make(map[string]MetricsSource),
+	}
+}
+
+// Register adds a named metrics source, replacing any existing source with that name
+func (mh *MetricsHandler) Register(name string, source MetricsSource) {
+	mh.mu.Lock()
+	defer mh.mu.Unlock()
+	mh.sources[name] = source
+}
+
+// GetMetricsHandler returns a snapshot from every registered source
+// GET /metrics
+func (mh *MetricsHandler) GetMetricsHandler(w http.ResponseWriter, r *http.Request) {
+	mh.mu.RLock()
+	response := make(map[string]any, len(mh.sources))
+	for name, source := range mh.sources {
+		response[name] = source()
+	}
+	mh.mu.RUnlock()
+
+	writeJSONResponse(w, http.StatusOK, response)
+}
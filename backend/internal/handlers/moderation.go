@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// SetModerationService lets users flag their chat exchanges for review
+func (ch *ChatHandler) SetModerationService(moderationService *services.ModerationService) {
+	ch.moderationService = moderationService
+}
+
+// FlagMessageHandler asks the moderators to review one of the caller's chat exchanges
+// POST /api/chat/{messageId}/flag
+func (ch *ChatHandler) FlagMessageHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+	if ch.moderationService == nil {
+		writeErrorResponse(w, http.StatusServiceUnavailable, "Flagging messages is not available")
+		return
+	}
+
+	messageID, err := strconv.Atoi(mux.Vars(r)["messageId"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid message ID")
+		return
+	}
+
+	var req types.FlagMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	if err := ch.moderationService.Flag(user.ID, messageID, req.Reason); err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, types.MessageResponse{Message: "Message flagged for review"})
+}
+
+// SetModerationService enables the chat moderation queue and account bans
+func (ah *AdminHandler) SetModerationService(moderationService *services.ModerationService) {
+	ah.moderationService = moderationService
+}
+
+// ListFlaggedChatHandler lists chat exchanges the safety filter, the crisis check, or users flagged, newest first
+// GET /api/admin/moderation/chat?source=user&status=open&user_id=42&limit=20&offset=0
+func (ah *AdminHandler) ListFlaggedChatHandler(w http.ResponseWriter, r *http.Request) {
+	if ah.moderationService == nil {
+		writeErrorResponse(w, http.StatusServiceUnavailable, "Chat moderation is not available")
+		return
+	}
+
+	limit, offset := parsePaginationParams(r)
+	query := r.URL.Query()
+	filter := models.ModerationFilter{Source: query.Get("source"), Status: query.Get("status"), Limit: limit, Offset: offset}
+	if filter.Source != "" && !services.IsValidModerationSource(filter.Source) {
+		writeErrorResponse(w, http.StatusBadRequest, "source must be safety, crisis, or user")
+		return
+	}
+	if filter.Status != "" && !services.IsValidModerationStatus(filter.Status) {
+		writeErrorResponse(w, http.StatusBadRequest, "status must be open or reviewed")
+		return
+	}
+	if value := query.Get("user_id"); value != "" {
+		userID, err := strconv.Atoi(value)
+		if err != nil || userID <= 0 {
+			writeErrorResponse(w, http.StatusBadRequest, "Invalid user_id")
+			return
+		}
+		filter.UserID = userID
+	}
+
+	messages, err := ah.moderationService.List(filter)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	response := make([]types.FlaggedChatEntry, len(messages))
+	for i, message := range messages {
+		response[i] = toFlaggedChatEntry(message)
+	}
+
+	meta := &types.Meta{Pagination: &types.Pagination{Limit: limit, Offset: offset, Count: len(response)}}
+	writeJSONResponseWithMeta(w, http.StatusOK, response, meta)
+}
+
+// GetFlaggedChatHandler shows a flagged exchange with the reasons users gave
+// GET /api/admin/moderation/chat/{messageId}
+func (ah *AdminHandler) GetFlaggedChatHandler(w http.ResponseWriter, r *http.Request) {
+	messageID, ok := ah.moderatedMessageID(w, r)
+	if !ok {
+		return
+	}
+
+	message, flags, err := ah.moderationService.Get(messageID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	response := types.FlaggedChatDetail{FlaggedChatEntry: toFlaggedChatEntry(message), Flags: make([]types.ChatFlagEntry, len(flags))}
+	for i, flag := range flags {
+		response.Flags[i] = types.ChatFlagEntry{ID: flag.ID, UserID: flag.UserID, Reason: flag.Reason, CreatedAt: flag.CreatedAt}
+	}
+	writeJSONResponse(w, http.StatusOK, response)
+}
+
+// DismissFlaggedChatHandler closes a flagged exchange without changing it
+// POST /api/admin/moderation/chat/{messageId}/dismiss
+func (ah *AdminHandler) DismissFlaggedChatHandler(w http.ResponseWriter, r *http.Request) {
+	admin, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+	messageID, ok := ah.moderatedMessageID(w, r)
+	if !ok {
+		return
+	}
+
+	var req types.DismissChatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	message, err := ah.moderationService.Dismiss(admin, messageID, req.Note)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, toFlaggedChatEntry(message))
+}
+
+// RedactFlaggedChatHandler removes a flagged exchange's question, answer, or both, and closes it
+// POST /api/admin/moderation/chat/{messageId}/redact
+func (ah *AdminHandler) RedactFlaggedChatHandler(w http.ResponseWriter, r *http.Request) {
+	admin, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+	messageID, ok := ah.moderatedMessageID(w, r)
+	if !ok {
+		return
+	}
+
+	var req types.RedactChatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	message, err := ah.moderationService.Redact(admin, messageID, req.Question, req.Answer, req.Note)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, toFlaggedChatEntry(message))
+}
+
+// BanUserHandler deactivates an abusive account
+// POST /api/admin/users/{userId}/ban
+func (ah *AdminHandler) BanUserHandler(w http.ResponseWriter, r *http.Request) {
+	admin, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+	userID, ok := ah.moderatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req types.BanUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	ban, err := ah.moderationService.Ban(admin, userID, req.Reason)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusCreated, types.UserBanResponse{
+		UserID:    ban.UserID,
+		Reason:    ban.Reason,
+		BannedBy:  ban.BannedBy,
+		CreatedAt: ban.CreatedAt,
+	})
+}
+
+// UnbanUserHandler reactivates a banned account
+// DELETE /api/admin/users/{userId}/ban
+func (ah *AdminHandler) UnbanUserHandler(w http.ResponseWriter, r *http.Request) {
+	admin, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+	userID, ok := ah.moderatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := ah.moderationService.Unban(admin, userID); err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, types.MessageResponse{Message: "User unbanned"})
+}
+
+// moderatedMessageID reads the message ID of a moderation request, writing the error response if it can't be served
+func (ah *AdminHandler) moderatedMessageID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	if ah.moderationService == nil {
+		writeErrorResponse(w, http.StatusServiceUnavailable, "Chat moderation is not available")
+		return 0, false
+	}
+	messageID, err := strconv.Atoi(mux.Vars(r)["messageId"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid message ID")
+		return 0, false
+	}
+	return messageID, true
+}
+
+// moderatedUserID reads the user ID of a ban request, writing the error response if it can't be served
+func (ah *AdminHandler) moderatedUserID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	if ah.moderationService == nil {
+		writeErrorResponse(w, http.StatusServiceUnavailable, "Account bans are not available")
+		return 0, false
+	}
+	userID, err := strconv.Atoi(mux.Vars(r)["userId"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid user ID")
+		return 0, false
+	}
+	return userID, true
+}
+
+func toFlaggedChatEntry(message *models.FlaggedMessage) types.FlaggedChatEntry {
+	return types.FlaggedChatEntry{
+		MessageID:   message.MessageID,
+		ReportID:    message.ReportID,
+		UserID:      message.UserID,
+		UserMessage: message.UserMessage,
+		AIResponse:  message.AIResponse,
+		InputMode:   message.InputMode,
+		IsDeleted:   message.IsDeleted,
+		Sources:     message.Sources,
+		FlagCount:   message.FlagCount,
+		Open:        message.Open,
+		Action:      message.Action,
+		Note:        message.Note,
+		ReviewedBy:  message.ReviewedBy,
+		ReviewedAt:  message.ReviewedAt,
+		CreatedAt:   message.CreatedAt,
+	}
+}
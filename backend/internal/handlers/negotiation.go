@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// Media types list endpoints can answer in, chosen by the Accept header
+const (
+	mediaTypeJSON   = "application/json"
+	mediaTypeNDJSON = "application/x-ndjson"
+	mediaTypeXML    = "application/xml"
+)
+
+// responseFormat writes a successful list response in one media type
+// Decision: Handlers build the same response whatever the format, and JSON stays the envelope every other
+// endpoint uses; the other formats are derived from it, so no response type needs format-specific tags
+type responseFormat interface {
+	ContentType() string
+	// Write renders the envelope; items are the entries of its list, for formats that write them one by one
+	Write(w io.Writer, envelope types.Envelope, items []any) error
+}
+
+// jsonFormat writes the standard envelope
+type jsonFormat struct{}
+
+func (jsonFormat) ContentType() string { return mediaTypeJSON }
+
+func (jsonFormat) Write(w io.Writer, envelope types.Envelope, items []any) error {
+	return json.NewEncoder(w).Encode(envelope)
+}
+
+// ndjsonFormat writes one JSON document per line for each entry of the list, for exports too large to
+// hold in memory; the envelope's other fields and meta are left out
+type ndjsonFormat struct{}
+
+func (ndjsonFormat) ContentType() string { return mediaTypeNDJSON }
+
+func (ndjsonFormat) Write(w io.Writer, envelope types.Envelope, items []any) error {
+	encoder := json.NewEncoder(w)
+	for _, item := range items {
+		if err := encoder.Encode(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// xmlFormat writes the envelope as XML for integrations that can't read JSON
+// Decision: The XML mirrors the JSON field for field, so its element names are the JSON keys: objects become
+// child elements, array entries become <item> elements, and null becomes an empty element
+type xmlFormat struct{}
+
+func (xmlFormat) ContentType() string { return mediaTypeXML }
+
+func (xmlFormat) Write(w io.Writer, envelope types.Envelope, items []any) error {
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+
+	io.WriteString(w, xml.Header)
+	encoder := xml.NewEncoder(w)
+	if err := writeXMLValue(encoder, decoder, "response"); err != nil {
+		return err
+	}
+	if err := encoder.Flush(); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// xmlName matches JSON keys usable as element names as they are
+var xmlName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// xmlElement names the element for a JSON key; keys that aren't valid names become <entry key="...">
+func xmlElement(key string) xml.StartElement {
+	if xmlName.MatchString(key) && !strings.HasPrefix(strings.ToLower(key), "xml") {
+		return xml.StartElement{Name: xml.Name{Local: key}}
+	}
+	return xml.StartElement{Name: xml.Name{Local: "entry"}, Attr: []xml.Attr{{Name: xml.Name{Local: "key"}, Value: key}}}
+}
+
+// writeXMLValue converts the next JSON value from decoder into an element called name
+func writeXMLValue(encoder *xml.Encoder, decoder *json.Decoder, name string) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	start := xmlElement(name)
+
+	switch value := token.(type) {
+	case json.Delim:
+		if err := encoder.EncodeToken(start); err != nil {
+			return err
+		}
+		for decoder.More() {
+			child := "item"
+			if value == '{' {
+				key, err := decoder.Token()
+				if err != nil {
+					return err
+				}
+				child = key.(string)
+			}
+			if err := writeXMLValue(encoder, decoder, child); err != nil {
+				return err
+			}
+		}
+		if _, err := decoder.Token(); err != nil { // The closing delimiter
+			return err
+		}
+		return encoder.EncodeToken(start.End())
+	case nil:
+		return encoder.EncodeElement("", start)
+	case bool:
+		return encoder.EncodeElement(strconv.FormatBool(value), start)
+	case json.Number:
+		return encoder.EncodeElement(value.String(), start)
+	default:
+		return encoder.EncodeElement(value, start)
+	}
+}
+
+// negotiateFormat picks the response format the Accept header prefers, defaulting to JSON
+// Returns false when the client accepts none of them
+func negotiateFormat(r *http.Request) (responseFormat, bool) {
+	accept := strings.TrimSpace(r.Header.Get("Accept"))
+	if accept == "" {
+		return jsonFormat{}, true
+	}
+
+	var best responseFormat
+	bestQuality := 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+
+		var format responseFormat
+		switch mediaType {
+		case mediaTypeJSON, "application/*", "*/*":
+			format = jsonFormat{}
+		case mediaTypeNDJSON, "application/ndjson", "application/jsonl":
+			format = ndjsonFormat{}
+		case mediaTypeXML, "text/xml":
+			format = xmlFormat{}
+		default:
+			continue
+		}
+		// Decision: Ties go to the earlier entry, so "application/xml, */*" means XML
+		if quality > bestQuality {
+			best, bestQuality = format, quality
+		}
+	}
+	return best, best != nil
+}
+
+// writeFormatted writes a successful list response in the negotiated format
+func writeFormatted(w http.ResponseWriter, format responseFormat, statusCode int, data any, meta *types.Meta, items []any) {
+	if _, ok := format.(jsonFormat); ok {
+		writeJSONResponseWithMeta(w, statusCode, data, meta)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Vary", "Accept")
+	w.WriteHeader(statusCode)
+	if err := format.Write(w, types.DataEnvelope(data, meta), items); err != nil {
+		slog.Warn("Failed to write response", "content_type", format.ContentType(), "error", err)
+	}
+}
+
+// streamNDJSON writes a list one page at a time, fetching the next page only once the last was sent
+// Decision: next is called once before anything is written, so a failure to read the first page is still
+// a normal error response; a later failure can only cut the stream short
+func streamNDJSON(w http.ResponseWriter, next func() ([]any, error), failureMessage string) {
+	page, err := next()
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, failureMessage)
+		return
+	}
+
+	w.Header().Set("Content-Type", mediaTypeNDJSON)
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Vary", "Accept")
+	w.WriteHeader(http.StatusOK)
+	controller := http.NewResponseController(w)
+	for len(page) > 0 {
+		if err := (ndjsonFormat{}).Write(w, types.Envelope{}, page); err != nil {
+			slog.Warn("Failed to stream NDJSON response", "error", err)
+			return
+		}
+		controller.Flush()
+
+		if page, err = next(); err != nil {
+			slog.Error("Failed to read the next page of an NDJSON export", "error", err)
+			return
+		}
+	}
+}
+
+// asItems converts a typed list to the entries a responseFormat writes
+func asItems[T any](list []T) []any {
+	items := make([]any, len(list))
+	for i := range list {
+		items[i] = list[i]
+	}
+	return items
+}
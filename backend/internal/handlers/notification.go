@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// NotificationHandler handles in-app notification HTTP requests
+type NotificationHandler struct {
+	notificationRepo models.NotificationRepository
+}
+
+// NewNotificationHandler creates a new notification handler
+func NewNotificationHandler(notificationRepo models.NotificationRepository) *NotificationHandler {
+	return &NotificationHandler{
+		notificationRepo: notificationRepo,
+	}
+}
+
+// GetNotificationsHandler lists the user's notifications, newest first
+// GET /api/notifications?unread=true
+func (nh *NotificationHandler) GetNotificationsHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	limit, offset := parsePaginationParams(r)
+	unreadOnly, _ := strconv.ParseBool(r.URL.Query().Get("unread"))
+
+	notifications, err := nh.notificationRepo.ListByUser(user.ID, unreadOnly, limit, offset)
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve notifications")
+		return
+	}
+
+	loc := user.Location()
+	response := make([]types.Notification, len(notifications))
+	for i, n := range notifications {
+		response[i] = types.Notification{
+			ID:        n.ID,
+			Kind:      n.Kind,
+			Message:   n.Message,
+			ReadAt:    inZone(n.ReadAt, loc),
+			CreatedAt: n.CreatedAt.In(loc),
+		}
+	}
+
+	meta := &types.Meta{Pagination: &types.Pagination{Limit: limit, Offset: offset, Count: len(response)}}
+	writeJSONResponseWithMeta(w, http.StatusOK, response, meta)
+}
+
+// MarkNotificationReadHandler marks one of the user's notifications as read
+// POST /api/notifications/{id}/read
+func (nh *NotificationHandler) MarkNotificationReadHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	// Decision: Support can't dismiss the notice that tells the user about their own access
+	if _, impersonated := middleware.GetImpersonatorID(r); impersonated {
+		writeErrorResponse(w, http.StatusForbidden, "Notifications can't be dismissed while impersonating")
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid notification ID")
+		return
+	}
+
+	updated, err := nh.notificationRepo.MarkRead(id, user.ID)
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to update notification")
+		return
+	}
+	if !updated {
+		writeErrorResponse(w, http.StatusNotFound, "Notification not found or already read")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, types.MessageResponse{Message: "Notification marked as read"})
+}
@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// maxLogoUpload bounds the logo request body; the service enforces the real limit with a clear message
+const maxLogoUpload = 1024 * 1024
+
+// OrganizationHandler handles organization and export branding HTTP requests
+type OrganizationHandler struct {
+	brandingService *services.BrandingService
+	usageService    *services.OrganizationUsageService
+}
+
+// NewOrganizationHandler creates a new organization handler
+func NewOrganizationHandler(brandingService *services.BrandingService, usageService *services.OrganizationUsageService) *OrganizationHandler {
+	return &OrganizationHandler{
+		brandingService: brandingService,
+		usageService:    usageService,
+	}
+}
+
+// CreateOrganizationHandler adds a clinic or hospital organization
+// POST /api/admin/organizations
+func (oh *OrganizationHandler) CreateOrganizationHandler(w http.ResponseWriter, r *http.Request) {
+	var req types.CreateOrganizationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	org, err := oh.brandingService.CreateOrganization(req.Name)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusCreated, toOrganizationBranding(org, "", time.UTC))
+}
+
+// AddOrganizationMemberHandler puts a user in an organization, as a patient, branding admin, or clinician
+// POST /api/admin/organizations/{id}/members
+func (oh *OrganizationHandler) AddOrganizationMemberHandler(w http.ResponseWriter, r *http.Request) {
+	orgID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid organization ID")
+		return
+	}
+
+	var req types.AddOrganizationMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	if err := oh.brandingService.AddMember(orgID, req.Email, req.Role, req.DoctorEmail); err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, map[string]string{"message": "Member added"})
+}
+
+// GetUsageHandler reports a month's usage per organization, as JSON or, with format=csv, as a CSV file for invoicing
+// GET /api/admin/organizations/usage?month=YYYY-MM&organization_id=&format=csv
+func (oh *OrganizationHandler) GetUsageHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	organizationID := 0
+	if v := query.Get("organization_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, "Invalid organization_id")
+			return
+		}
+		organizationID = id
+	}
+	format := query.Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		writeErrorResponse(w, http.StatusBadRequest, "format must be json or csv")
+		return
+	}
+
+	month := query.Get("month")
+	if month == "" {
+		month = oh.usageService.CurrentMonth()
+	}
+	usage, err := oh.usageService.MonthlyUsage(month, organizationID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	if format == "csv" {
+		var body bytes.Buffer
+		if err := services.WriteOrganizationUsageCSV(&body, usage); err != nil {
+			writeErrorResponse(w, http.StatusInternalServerError, "Failed to export usage")
+			return
+		}
+		filename := fmt.Sprintf("organization-usage-%s.csv", month)
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(http.StatusOK)
+		w.Write(body.Bytes())
+		return
+	}
+
+	response := types.OrganizationUsageResponse{Month: month, Organizations: make([]types.OrganizationUsage, len(usage))}
+	for i, u := range usage {
+		response.Organizations[i] = types.OrganizationUsage{
+			OrganizationID:   u.OrganizationID,
+			OrganizationName: u.OrganizationName,
+			ReportsProcessed: u.ReportsProcessed,
+			AICalls:          u.AICalls,
+			AIInputTokens:    u.AIInputTokens,
+			AIOutputTokens:   u.AIOutputTokens,
+			StorageBytes:     u.StorageBytes,
+			UpdatedAt:        u.UpdatedAt,
+		}
+	}
+	writeJSONResponse(w, http.StatusOK, response)
+}
+
+// GetBrandingHandler returns the caller's organization branding
+// GET /api/organization/branding
+func (oh *OrganizationHandler) GetBrandingHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	org, role, err := oh.brandingService.GetOrganization(user.ID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, toOrganizationBranding(org, role, user.Location()))
+}
+
+// UpdateBrandingHandler changes the footer, contact details, and export sections
+// PUT /api/organization/branding
+func (oh *OrganizationHandler) UpdateBrandingHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	var req types.UpdateBrandingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	org, err := oh.brandingService.UpdateBranding(user.ID, services.BrandingUpdate{
+		Name:        req.Name,
+		FooterText:  req.FooterText,
+		ContactInfo: req.ContactInfo,
+		Sections:    req.Sections,
+	})
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, toOrganizationBranding(org, models.OrgRoleAdmin, user.Location()))
+}
+
+// UploadLogoHandler replaces the organization's logo with the raw PNG or JPEG request body
+// PUT /api/organization/branding/logo; an empty body removes the logo
+func (oh *OrganizationHandler) UploadLogoHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxLogoUpload))
+	if err != nil {
+		writeErrorResponse(w, http.StatusRequestEntityTooLarge, "Logo is too large")
+		return
+	}
+
+	if err := oh.brandingService.SetLogo(user.ID, data); err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, map[string]string{"message": "Logo updated"})
+}
+
+// GetLogoHandler serves the organization's logo as stored for exports
+// GET /api/organization/branding/logo
+func (oh *OrganizationHandler) GetLogoHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	org, _, err := oh.brandingService.GetOrganization(user.ID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+	if len(org.Logo) == 0 {
+		writeErrorResponse(w, http.StatusNotFound, "Organization has no logo")
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/jpeg")
+	w.Header().Set("Cache-Control", "private, no-cache")
+	w.Write(org.Logo)
+}
+
+func toOrganizationBranding(org *models.Organization, role string, loc *time.Location) types.OrganizationBranding {
+	sections := org.Sections
+	if sections == nil {
+		sections = []string{}
+	}
+	return types.OrganizationBranding{
+		ID:                org.ID,
+		Name:              org.Name,
+		Role:              role,
+		FooterText:        org.FooterText,
+		ContactInfo:       org.ContactInfo,
+		HasLogo:           len(org.Logo) > 0,
+		Sections:          sections,
+		AvailableSections: services.ExportSections,
+		UpdatedAt:         org.UpdatedAt.In(loc),
+	}
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// PlanHandler handles plan HTTP requests
+type PlanHandler struct {
+	planService *services.PlanService
+}
+
+// NewPlanHandler creates a new plan handler
+func NewPlanHandler(planService *services.PlanService) *PlanHandler {
+	return &PlanHandler{
+		planService: planService,
+	}
+}
+
+// GetMyPlanHandler returns the caller's plan, its limits, and chat usage
+// GET /api/users/me/plan
+func (ph *PlanHandler) GetMyPlanHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	status, err := ph.planService.Status(user)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	response := types.PlanResponse{
+		Plan: status.Plan,
+		Limits: types.PlanLimits{
+			MaxOutputTokens:    status.Limits.MaxOutputTokens,
+			SummaryWords:       status.Limits.SummaryWords,
+			MaxFindings:        status.Limits.MaxFindings,
+			ChatMessagesPerDay: status.Limits.ChatMessagesPerDay,
+			Features:           status.Limits.Features,
+		},
+		Usage: types.PlanUsage{
+			ChatMessages: status.ChatUsed,
+			WindowStart:  status.ChatWindowFrom.In(user.Location()),
+		},
+	}
+	if response.Limits.Features == nil {
+		response.Limits.Features = []string{}
+	}
+	if limit := status.Limits.ChatMessagesPerDay; limit > 0 {
+		remaining := max(limit-status.ChatUsed, 0)
+		response.Usage.ChatRemaining = &remaining
+	}
+	writeJSONResponse(w, http.StatusOK, response)
+}
+
+// SetUserPlanHandler moves a user to another plan
+// PUT /api/admin/users/{userId}/plan
+func (ph *PlanHandler) SetUserPlanHandler(w http.ResponseWriter, r *http.Request) {
+	admin, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	userID, err := strconv.Atoi(mux.Vars(r)["userId"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	var req types.UpdatePlanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	user, err := ph.planService.SetPlan(admin, userID, req.Plan)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, services.ToUserResponse(user))
+}
@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// PrescriptionHandler handles photographed prescriptions and corrections to what was read from them
+type PrescriptionHandler struct {
+	prescriptionService *services.PrescriptionService
+	maxImageBytes       int64
+}
+
+// NewPrescriptionHandler creates a new prescription handler
+func NewPrescriptionHandler(prescriptionService *services.PrescriptionService, maxImageBytes int64) *PrescriptionHandler {
+	if maxImageBytes <= 0 {
+		maxImageBytes = 8 * 1024 * 1024
+	}
+	return &PrescriptionHandler{
+		prescriptionService: prescriptionService,
+		maxImageBytes:       maxImageBytes,
+	}
+}
+
+// UploadPrescriptionHandler reads a prescription photo sent as multipart form data in the image field
+// POST /api/prescriptions
+func (ph *PrescriptionHandler) UploadPrescriptionHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	// Decision: Cap the whole body, not just the part, so an oversized upload is cut off while streaming
+	r.Body = http.MaxBytesReader(w, r.Body, ph.maxImageBytes+64*1024)
+	if err := r.ParseMultipartForm(ph.maxImageBytes); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Photo too large or invalid form data")
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, _, err := r.FormFile("image")
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "No photo provided in the image field")
+		return
+	}
+	defer file.Close()
+
+	image, err := io.ReadAll(io.LimitReader(file, ph.maxImageBytes+1))
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Failed to read photo")
+		return
+	}
+	if len(image) == 0 || int64(len(image)) > ph.maxImageBytes {
+		writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Photo must be between 1 byte and %d MB", ph.maxImageBytes/(1024*1024)))
+		return
+	}
+
+	imageType, ok := services.DetectPrescriptionImageType(image)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnsupportedMediaType, "Unsupported photo format, use JPEG, PNG, or WebP")
+		return
+	}
+
+	prescription, err := ph.prescriptionService.Upload(r.Context(), user.ID, image, imageType)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusCreated, toPrescriptionResponse(prescription, user.Location()))
+}
+
+// ListPrescriptionsHandler returns the user's prescriptions, newest first
+// GET /api/prescriptions
+func (ph *PrescriptionHandler) ListPrescriptionsHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	limit, offset := parsePaginationParams(r)
+	prescriptions, err := ph.prescriptionService.List(user.ID, limit, offset)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	response := types.PrescriptionListResponse{Prescriptions: make([]types.Prescription, len(prescriptions))}
+	for i, prescription := range prescriptions {
+		response.Prescriptions[i] = toPrescriptionResponse(prescription, user.Location())
+	}
+	writeJSONResponse(w, http.StatusOK, response)
+}
+
+// GetPrescriptionHandler returns one prescription with its medications
+// GET /api/prescriptions/{id}
+func (ph *PrescriptionHandler) GetPrescriptionHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid prescription ID")
+		return
+	}
+
+	prescription, err := ph.prescriptionService.Get(user.ID, id)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, toPrescriptionResponse(prescription, user.Location()))
+}
+
+// GetPrescriptionImageHandler returns the original photo so doubtful lines can be checked against it
+// GET /api/prescriptions/{id}/image
+func (ph *PrescriptionHandler) GetPrescriptionImageHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid prescription ID")
+		return
+	}
+
+	prescription, file, err := ph.prescriptionService.OpenPhoto(user.ID, id)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+	defer file.Close()
+
+	image, err := io.ReadAll(file)
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to read photo")
+		return
+	}
+
+	filename := fmt.Sprintf("prescription-%d%s", prescription.ID, filepath.Ext(prescription.FilePath))
+	w.Header().Set("Content-Type", prescription.ImageType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	w.Write(image)
+}
+
+// CorrectMedicationHandler edits one medication line, addressed by its position in the list
+// PATCH /api/prescriptions/{id}/medications/{index}
+func (ph *PrescriptionHandler) CorrectMedicationHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid prescription ID")
+		return
+	}
+	index, err := strconv.Atoi(vars["index"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid medication index")
+		return
+	}
+
+	// Decision: An empty body is allowed and confirms the line as read
+	var req types.MedicationCorrectionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	prescription, err := ph.prescriptionService.CorrectMedication(user.ID, id, index, services.MedicationCorrection{
+		Name:         req.Name,
+		Dosage:       req.Dosage,
+		Frequency:    req.Frequency,
+		Duration:     req.Duration,
+		Instructions: req.Instructions,
+	})
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, toPrescriptionResponse(prescription, user.Location()))
+}
+
+// DeletePrescriptionHandler removes a prescription and its photo
+// DELETE /api/prescriptions/{id}
+func (ph *PrescriptionHandler) DeletePrescriptionHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid prescription ID")
+		return
+	}
+
+	if err := ph.prescriptionService.Delete(user.ID, id); err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, map[string]string{"message": "Prescription deleted"})
+}
+
+func toPrescriptionResponse(prescription *models.Prescription, loc *time.Location) types.Prescription {
+	response := types.Prescription{
+		ID:           prescription.ID,
+		Prescriber:   prescription.Prescriber,
+		PrescribedOn: prescription.PrescribedOn,
+		Notes:        prescription.Notes,
+		Medications:  make([]types.PrescriptionMedication, len(prescription.Medications)),
+		Reader:       prescription.Reader,
+		ImageURL:     fmt.Sprintf("/api/prescriptions/%d/image", prescription.ID),
+		CreatedAt:    prescription.CreatedAt.In(loc),
+		UpdatedAt:    prescription.UpdatedAt.In(loc),
+	}
+	for i, line := range prescription.Medications {
+		response.Medications[i] = types.PrescriptionMedication{
+			Name:          line.Name,
+			Dosage:        line.Dosage,
+			Frequency:     line.Frequency,
+			Duration:      line.Duration,
+			Instructions:  line.Instructions,
+			Confidence:    line.Confidence,
+			LowConfidence: line.LowConfidence,
+			Corrected:     line.Corrected,
+		}
+		response.NeedsReview = response.NeedsReview || line.LowConfidence
+	}
+	return response
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// referralNote accompanies every set of suggestions
+const referralNote = "Suggestions are based only on which results were out of range. " +
+	"Your doctor can tell you whether you need a specialist."
+
+// maxDoctorResults caps GET /api/doctors
+const maxDoctorResults = 20
+
+// ReferralHandler handles specialist suggestions and doctor search
+type ReferralHandler struct {
+	referralService *services.ReferralService
+}
+
+// NewReferralHandler creates a new referral handler
+func NewReferralHandler(referralService *services.ReferralService) *ReferralHandler {
+	return &ReferralHandler{
+		referralService: referralService,
+	}
+}
+
+// ListSpecialtiesHandler lists the specialties referrals can suggest
+// GET /api/specialties
+func (rh *ReferralHandler) ListSpecialtiesHandler(w http.ResponseWriter, r *http.Request) {
+	specialties := services.ListSpecialties()
+	response := types.SpecialtiesResponse{
+		Specialties:      make([]types.Specialty, len(specialties)),
+		DirectoryEnabled: rh.referralService.DirectoryEnabled(),
+	}
+	for i, specialty := range specialties {
+		response.Specialties[i] = toSpecialtyResponse(specialty)
+	}
+	writeJSONResponse(w, http.StatusOK, response)
+}
+
+// GetReferralsHandler suggests specialists for a report's out-of-range results
+// GET /api/reports/{id}/referrals?lat=12.97&lng=77.59 or ?city=Bengaluru
+func (rh *ReferralHandler) GetReferralsHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	reportID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid report ID")
+		return
+	}
+	near, err := parseDoctorLocation(r.URL.Query())
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	referrals, err := rh.referralService.Suggest(r.Context(), user.ID, reportID, near)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	response := types.ReferralsResponse{
+		ReportID:         referrals.ReportID,
+		Suggestions:      make([]types.ReferralSuggestion, len(referrals.Suggestions)),
+		DirectoryEnabled: referrals.DirectoryEnabled,
+		Note:             referralNote,
+	}
+	for i, suggestion := range referrals.Suggestions {
+		response.Suggestions[i] = types.ReferralSuggestion{
+			Specialty: toSpecialtyResponse(suggestion.Specialty),
+			Urgency:   suggestion.Urgency,
+			Reasons:   make([]types.ReferralReason, len(suggestion.Reasons)),
+			Doctors:   toDoctorResponses(suggestion.Doctors),
+		}
+		for j, reason := range suggestion.Reasons {
+			response.Suggestions[i].Reasons[j] = types.ReferralReason{
+				Metric: reason.Metric,
+				Value:  reason.Value,
+				Unit:   reason.Unit,
+				Status: reason.Status,
+			}
+		}
+	}
+	writeJSONResponse(w, http.StatusOK, response)
+}
+
+// FindDoctorsHandler searches the provider directory for doctors of one specialty
+// GET /api/doctors?specialty=nephrology&lat=12.97&lng=77.59&limit=10
+func (rh *ReferralHandler) FindDoctorsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	specialty := strings.TrimSpace(query.Get("specialty"))
+	if specialty == "" {
+		writeErrorResponse(w, http.StatusBadRequest, "specialty is required")
+		return
+	}
+	near, err := parseDoctorLocation(query)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if near == nil {
+		writeErrorResponse(w, http.StatusBadRequest, "lat and lng, or city, are required")
+		return
+	}
+	limit := 10
+	if raw := query.Get("limit"); raw != "" {
+		if limit, err = strconv.Atoi(raw); err != nil || limit < 1 || limit > maxDoctorResults {
+			writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxDoctorResults))
+			return
+		}
+	}
+
+	doctors, err := rh.referralService.FindDoctors(r.Context(), specialty, *near, limit)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+	writeJSONResponse(w, http.StatusOK, types.DoctorsResponse{Specialty: specialty, Doctors: toDoctorResponses(doctors)})
+}
+
+// parseDoctorLocation reads lat and lng, or city, from the query; nil when neither is given
+func parseDoctorLocation(query url.Values) (*services.DoctorLocation, error) {
+	near := services.DoctorLocation{City: strings.TrimSpace(query.Get("city"))}
+	rawLat, rawLng := query.Get("lat"), query.Get("lng")
+	if (rawLat == "") != (rawLng == "") {
+		return nil, fmt.Errorf("lat and lng must be given together")
+	}
+	if rawLat != "" {
+		lat, err := strconv.ParseFloat(rawLat, 64)
+		if err != nil || lat < -90 || lat > 90 {
+			return nil, fmt.Errorf("lat must be between -90 and 90")
+		}
+		lng, err := strconv.ParseFloat(rawLng, 64)
+		if err != nil || lng < -180 || lng > 180 {
+			return nil, fmt.Errorf("lng must be between -180 and 180")
+		}
+		near.Latitude, near.Longitude = &lat, &lng
+	}
+	if near.Latitude == nil && near.City == "" {
+		return nil, nil
+	}
+	return &near, nil
+}
+
+func toSpecialtyResponse(specialty services.Specialty) types.Specialty {
+	return types.Specialty{Key: specialty.Key, Name: specialty.Name, Area: specialty.Area}
+}
+
+func toDoctorResponses(doctors []services.Doctor) []types.Doctor {
+	if doctors == nil {
+		return nil
+	}
+	response := make([]types.Doctor, len(doctors))
+	for i, doctor := range doctors {
+		response[i] = types.Doctor{
+			Name:       doctor.Name,
+			Specialty:  doctor.Specialty,
+			Address:    doctor.Address,
+			Phone:      doctor.Phone,
+			URL:        doctor.URL,
+			DistanceKm: doctor.DistanceKm,
+		}
+	}
+	return response
+}
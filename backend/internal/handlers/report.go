@@ -1,15 +1,17 @@
 package handlers
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
+	"mime"
 	"mime/multipart"
 	"net/http"
 	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/gorilla/mux"
 	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
@@ -24,8 +26,19 @@ type ReportHandler struct {
 	reportRepo      models.ReportRepository
 	authService     *services.AuthService
 	aiService       *services.AIService
-	uploadDirectory string
+	fileValidator   *services.FileValidator
+	fileStorage     *services.FileStorage
 	maxFileSize     int64
+	exposeFilePaths bool
+	events          services.EventBus               // Optional; nil publishes nothing
+	translations    *services.TranslationService    // Optional; nil serves summaries in English only
+	jobs            *services.JobService            // Optional; nil disables processing history
+	parts           *services.ReportPartService     // Optional; nil disables multi-part reports
+	claims          *services.ClaimPackageService   // Optional; nil disables claim packages
+	imports         *services.AnalysisImportService // Optional; nil disables analysis import
+	sync            *services.SyncService           // Optional; nil disables delta sync
+	access          *services.ReportAccessService   // Optional; nil keeps every report private to its owner
+	stream          *services.ReportStreamService   // Optional; nil disables the event stream
 }
 
 // NewReportHandler creates a new report handler
@@ -33,26 +46,55 @@ func NewReportHandler(
 	reportRepo models.ReportRepository,
 	authService *services.AuthService,
 	aiService *services.AIService,
-	uploadDir string,
+	fileValidator *services.FileValidator,
+	fileStorage *services.FileStorage,
 	maxFileSize int64,
+	exposeFilePaths bool,
 ) *ReportHandler {
 	return &ReportHandler{
 		reportRepo:      reportRepo,
 		authService:     authService,
 		aiService:       aiService,
-		uploadDirectory: uploadDir,
+		fileValidator:   fileValidator,
+		fileStorage:     fileStorage,
 		maxFileSize:     maxFileSize,
+		exposeFilePaths: exposeFilePaths,
 	}
 }
 
+// SetEventBus publishes report.uploaded on bus for every stored upload
+func (rh *ReportHandler) SetEventBus(bus services.EventBus) {
+	rh.events = bus
+}
+
+// SetJobService serves each report's processing history to its owner
+func (rh *ReportHandler) SetJobService(jobs *services.JobService) {
+	rh.jobs = jobs
+}
+
+// SetPartService lets uploads join earlier reports as further pages
+func (rh *ReportHandler) SetPartService(parts *services.ReportPartService) {
+	rh.parts = parts
+}
+
+// SetClaimPackageService lets owners bundle reports for insurance claims
+func (rh *ReportHandler) SetClaimPackageService(claims *services.ClaimPackageService) {
+	rh.claims = claims
+}
+
+// SetTranslationService lets the summary and metrics endpoints answer ?lang= in other languages
+func (rh *ReportHandler) SetTranslationService(translations *services.TranslationService) {
+	rh.translations = translations
+}
+
+// SetReportStream streams each report's status changes to its owner
+func (rh *ReportHandler) SetReportStream(stream *services.ReportStreamService) {
+	rh.stream = stream
+}
+
 // UploadReportHandler handles file upload requests
 // POST /api/reports
 func (rh *ReportHandler) UploadReportHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
-		return
-	}
-
 	// Get user from context (set by auth middleware)
 	user, ok := middleware.GetUserFromContext(r)
 	if !ok {
@@ -67,6 +109,29 @@ func (rh *ReportHandler) UploadReportHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	// Decision: The level is fixed at upload because analysis runs later, possibly in the worker
+	readingLevel := r.FormValue("reading_level")
+	if readingLevel == "" {
+		readingLevel = user.ReadingLevel
+	}
+	if !models.IsValidReadingLevel(readingLevel) {
+		handleServiceError(w, errors.ErrInvalidReadingLevel)
+		return
+	}
+
+	// Decision: part_of adds this file as the next page of an earlier report instead of starting a new one
+	partOf := 0
+	if value := r.FormValue("part_of"); value != "" {
+		if partOf, err = strconv.Atoi(value); err != nil || partOf <= 0 {
+			writeErrorResponse(w, http.StatusBadRequest, "Invalid part_of report ID")
+			return
+		}
+		if rh.parts == nil {
+			writeErrorResponse(w, http.StatusServiceUnavailable, "Multi-part reports are not available")
+			return
+		}
+	}
+
 	// Get the uploaded file
 	file, fileHeader, err := r.FormFile("file")
 	if err != nil {
@@ -76,21 +141,18 @@ func (rh *ReportHandler) UploadReportHandler(w http.ResponseWriter, r *http.Requ
 	defer file.Close()
 
 	// Validate file type and size
-	if err := rh.validateFile(fileHeader); err != nil {
+	if err := rh.validateFile(file, fileHeader); err != nil {
 		handleServiceError(w, err)
 		return
 	}
 
-	// Create upload directory if it doesn't exist
-	if err := os.MkdirAll(rh.uploadDirectory, 0755); err != nil {
+	// Decision: Files live under a per-user directory with random names
+	filePath, err := rh.fileStorage.NewFilePath(user.ID, fileHeader.Filename)
+	if err != nil {
 		writeErrorResponse(w, http.StatusInternalServerError, "Failed to create upload directory")
 		return
 	}
 
-	// Generate unique filename
-	uniqueFilename := rh.generateUniqueFilename(fileHeader.Filename)
-	filePath := filepath.Join(rh.uploadDirectory, uniqueFilename)
-
 	// Save file to disk
 	if err := rh.saveFile(file, filePath); err != nil {
 		writeErrorResponse(w, http.StatusInternalServerError, "Failed to save file")
@@ -100,11 +162,13 @@ func (rh *ReportHandler) UploadReportHandler(w http.ResponseWriter, r *http.Requ
 	// Create report record in database
 	report := &models.Report{
 		UserID:           user.ID,
-		OriginalFilename: fileHeader.Filename,
+		OriginalFilename: rh.fileStorage.OriginalFilename(fileHeader.Filename),
 		FilePath:         filePath,
 		FileType:         fileHeader.Header.Get("Content-Type"),
 		FileSize:         fileHeader.Size,
 		ProcessingStatus: "pending",
+		ReadingLevel:     readingLevel,
+		Plan:             user.Plan,
 	}
 
 	if err := rh.reportRepo.Create(report); err != nil {
@@ -114,9 +178,6 @@ func (rh *ReportHandler) UploadReportHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	// Trigger async AI processing
-	go rh.processReportAsync(report)
-
 	// Return success response
 	response := types.UploadResponse{
 		Message:  "File uploaded successfully and queued for processing",
@@ -124,46 +185,102 @@ func (rh *ReportHandler) UploadReportHandler(w http.ResponseWriter, r *http.Requ
 		ReportID: report.ID,
 	}
 
+	if partOf != 0 {
+		target, err := rh.parts.Merge(user, partOf, report.ID)
+		if err != nil {
+			// Decision: A page that can't join its report is discarded rather than left behind as a fragment,
+			// unless a legal hold keeps it as a report of its own
+			if rh.reportRepo.Delete(report.ID) == nil {
+				rh.fileStorage.Remove(filePath)
+			}
+			handleServiceError(w, err)
+			return
+		}
+		report = target
+		response.ReportID = target.ID
+		response.Message = fmt.Sprintf("File added as a page of report %d, which is queued for processing again", target.ID)
+	} else if rh.parts != nil {
+		if candidate := rh.parts.Candidate(report); candidate != nil {
+			response.MergeCandidate = &types.MergeCandidate{
+				ReportID:         candidate.ID,
+				OriginalFilename: candidate.OriginalFilename,
+				MergeURL:         fmt.Sprintf("/api/reports/%d/parts", candidate.ID),
+			}
+		}
+	}
+
+	rh.queueForProcessing(report)
+
 	writeJSONResponse(w, http.StatusCreated, response)
 }
 
+// queueForProcessing announces a pending report; a worker, in this server or in cmd/worker, analyzes it
+// Decision: Processing never starts from the request, so uploads can't outrun the worker's concurrency limit
+// and a report whose event is lost is still found by the worker's next poll
+func (rh *ReportHandler) queueForProcessing(report *models.Report) {
+	if rh.events != nil {
+		rh.events.Publish(services.Event{Type: services.EventReportUploaded, UserID: report.UserID, ReportID: report.ID})
+	}
+}
+
 // GetReportsHandler retrieves user's reports with pagination
 // GET /api/reports
 func (rh *ReportHandler) GetReportsHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
-		return
-	}
+	rh.writeReportList(w, r, "Failed to retrieve reports")
+}
 
+// GetReportHistoryHandler retrieves user's report history with pagination
+// GET /api/reports/history
+func (rh *ReportHandler) GetReportHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	rh.writeReportList(w, r, "Failed to retrieve report history")
+}
+
+// writeReportList writes a page of the user's reports as JSON, XML, or NDJSON, per the Accept header
+func (rh *ReportHandler) writeReportList(w http.ResponseWriter, r *http.Request, failureMessage string) {
 	user, ok := middleware.GetUserFromContext(r)
 	if !ok {
 		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
 		return
 	}
 
+	format, ok := negotiateFormat(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusNotAcceptable, "Reports are available as application/json, application/xml, or application/x-ndjson")
+		return
+	}
+
+	// Decision: NDJSON without a limit exports every report, streamed a page at a time
+	if _, ndjson := format.(ndjsonFormat); ndjson && r.URL.Query().Get("limit") == "" {
+		_, offset := parsePaginationParams(r)
+		streamNDJSON(w, func() ([]any, error) {
+			reports, err := rh.reportRepo.GetByUserID(user.ID, maxPageSize, offset)
+			if err != nil {
+				return nil, err
+			}
+			offset += len(reports)
+			items := make([]any, len(reports))
+			for i, report := range reports {
+				items[i] = rh.toReportResponse(report, user.Location())
+			}
+			return items, nil
+		}, failureMessage)
+		return
+	}
+
 	// Parse pagination parameters
-	limit, offset := rh.parsePaginationParams(r)
+	limit, offset := parsePaginationParams(r)
 
 	// Get reports from database
 	reports, err := rh.reportRepo.GetByUserID(user.ID, limit, offset)
 	if err != nil {
-		writeErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve reports")
+		writeErrorResponse(w, http.StatusInternalServerError, failureMessage)
 		return
 	}
 
 	// Convert to response format
 	reportResponses := make([]types.Report, len(reports))
 	for i, report := range reports {
-		reportResponses[i] = types.Report{
-			ID:                report.ID,
-			UserID:           report.UserID,
-			OriginalFilename: report.OriginalFilename,
-			FilePath:         report.FilePath,
-			FileType:         report.FileType,
-			SimplifiedSummary: report.SimplifiedSummary,
-			UploadDate:       report.UploadDate,
-			ProcessedAt:      report.ProcessedAt,
-		}
+		reportResponses[i] = rh.toReportResponse(report, user.Location())
 	}
 
 	response := types.ReportListResponse{
@@ -171,79 +288,140 @@ func (rh *ReportHandler) GetReportsHandler(w http.ResponseWriter, r *http.Reques
 		Total:   len(reportResponses),
 	}
 
-	writeJSONResponse(w, http.StatusOK, response)
+	meta := &types.Meta{Pagination: &types.Pagination{Limit: limit, Offset: offset, Count: len(reportResponses)}}
+	writeFormatted(w, format, http.StatusOK, response, meta, asItems(reportResponses))
 }
 
-// GetReportHistoryHandler retrieves user's report history with pagination
-// GET /api/reports/history
-func (rh *ReportHandler) GetReportHistoryHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+// GetReportHandler retrieves a specific report by ID
+// GET /api/reports/{id}
+func (rh *ReportHandler) GetReportHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
 		return
 	}
 
+	// Extract report ID from URL
+	vars := mux.Vars(r)
+	reportID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid report ID")
+		return
+	}
+
+	// Get report from database
+	report, err := rh.reportRepo.GetByID(reportID)
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve report")
+		return
+	}
+
+	if report == nil {
+		writeErrorResponse(w, http.StatusNotFound, "Report not found")
+		return
+	}
+
+	// Check if user owns this report or its visibility shares it with them
+	if !rh.authorizeRead(w, user, report) {
+		return
+	}
+
+	// Convert to response format
+	reportResponse := rh.toReportResponse(report, user.Location())
+
+	setETag(w, report.Version)
+	writeJSONResponse(w, http.StatusOK, reportResponse)
+}
+
+// DownloadReportFileHandler streams the original uploaded file to its owner
+// GET /api/reports/{id}/file
+func (rh *ReportHandler) DownloadReportFileHandler(w http.ResponseWriter, r *http.Request) {
 	user, ok := middleware.GetUserFromContext(r)
 	if !ok {
 		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
 		return
 	}
 
-	// Parse pagination parameters
-	limit, offset := rh.parsePaginationParams(r)
+	// Extract report ID from URL
+	vars := mux.Vars(r)
+	reportID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid report ID")
+		return
+	}
 
-	// Get reports from database
-	reports, err := rh.reportRepo.GetByUserID(user.ID, limit, offset)
+	report, err := rh.reportRepo.GetByID(reportID)
 	if err != nil {
-		writeErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve report history")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve report")
 		return
 	}
 
-	// Convert to response format
-	reportResponses := make([]types.Report, len(reports))
-	for i, report := range reports {
-		reportResponses[i] = types.Report{
-			ID:                report.ID,
-			UserID:            report.UserID,
-			OriginalFilename:  report.OriginalFilename,
-			FilePath:          report.FilePath,
-			FileType:          report.FileType,
-			SimplifiedSummary: report.SimplifiedSummary,
-			UploadDate:        report.UploadDate,
-			ProcessedAt:       report.ProcessedAt,
-		}
+	if report == nil {
+		writeErrorResponse(w, http.StatusNotFound, "Report not found")
+		return
 	}
 
-	response := types.ReportListResponse{
-		Reports: reportResponses,
-		Total:   len(reportResponses),
+	// Check if user owns this report or its visibility shares it with them
+	if !rh.authorizeRead(w, user, report) {
+		return
 	}
 
-	writeJSONResponse(w, http.StatusOK, response)
-}
+	file, err := rh.fileStorage.Open(report.FilePath)
+	if err != nil {
+		writeErrorResponse(w, http.StatusNotFound, "Report file not found")
+		return
+	}
+	defer file.Close()
 
-// GetReportHandler retrieves a specific report by ID
-// GET /api/reports/{id}
-func (rh *ReportHandler) GetReportHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+	info, err := file.Stat()
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to read report file")
 		return
 	}
 
+	// Decision: Serve under the user's original filename; ServeContent handles ranges and caching
+	w.Header().Set("Content-Type", report.FileType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": report.OriginalFilename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, "", info.ModTime(), file)
+}
+
+// Limits on user-editable report details
+const (
+	reportDateLayout = "2006-01-02"
+	maxTitleLength   = 200
+	maxNotesLength   = 5000
+)
+
+// UpdateReportHandler changes a report's title, date, or notes
+// PATCH /api/reports/{id}
+func (rh *ReportHandler) UpdateReportHandler(w http.ResponseWriter, r *http.Request) {
 	user, ok := middleware.GetUserFromContext(r)
 	if !ok {
 		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
 		return
 	}
 
-	// Extract report ID from URL
-	vars := mux.Vars(r)
-	reportID, err := strconv.Atoi(vars["id"])
+	reportID, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
 		writeErrorResponse(w, http.StatusBadRequest, "Invalid report ID")
 		return
 	}
 
-	// Get report from database
+	version, ok := parseIfMatch(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusBadRequest, "If-Match must be an ETag returned for this report")
+		return
+	}
+
+	var req types.UpdateReportRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields() // Decision: Reject attempts to patch immutable fields instead of silently ignoring them
+	if err := decoder.Decode(&req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload; only title, report_date, and notes can be changed")
+		return
+	}
+
 	report, err := rh.reportRepo.GetByID(reportID)
 	if err != nil {
 		writeErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve report")
@@ -261,29 +439,82 @@ func (rh *ReportHandler) GetReportHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	// Convert to response format
-	reportResponse := types.Report{
-		ID:                report.ID,
-		UserID:           report.UserID,
-		OriginalFilename: report.OriginalFilename,
-		FilePath:         report.FilePath,
-		FileType:         report.FileType,
-		SimplifiedSummary: report.SimplifiedSummary,
-		UploadDate:       report.UploadDate,
-		ProcessedAt:      report.ProcessedAt,
+	if version != 0 && version != report.Version {
+		writeVersionConflict(w, report.Version)
+		return
 	}
 
-	writeJSONResponse(w, http.StatusOK, reportResponse)
+	// Decision: Merge onto the stored values so omitted fields keep their current value
+	details := models.ReportDetails{Title: report.Title, ReportDate: report.ReportDate, Notes: report.Notes}
+	if req.Title != nil {
+		details.Title = strings.TrimSpace(*req.Title)
+		if utf8.RuneCountInString(details.Title) > maxTitleLength {
+			writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Title must be at most %d characters", maxTitleLength))
+			return
+		}
+	}
+	if req.Notes != nil {
+		details.Notes = strings.TrimSpace(*req.Notes)
+		if utf8.RuneCountInString(details.Notes) > maxNotesLength {
+			writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Notes must be at most %d characters", maxNotesLength))
+			return
+		}
+	}
+	if req.ReportDate != nil {
+		details.ReportDate = nil
+		if *req.ReportDate != "" {
+			date, err := time.Parse(reportDateLayout, *req.ReportDate)
+			if err != nil {
+				writeErrorResponse(w, http.StatusBadRequest, "Report date must be formatted as YYYY-MM-DD")
+				return
+			}
+			// Decision: Allow a day of slack for users ahead of UTC
+			if date.After(time.Now().AddDate(0, 0, 1)) || date.Year() < 1900 {
+				writeErrorResponse(w, http.StatusBadRequest, "Report date must be between 1900 and today")
+				return
+			}
+			details.ReportDate = &date
+		}
+	}
+
+	applied := true
+	if version == 0 {
+		err = rh.reportRepo.UpdateDetails(reportID, details)
+	} else {
+		applied, err = rh.reportRepo.UpdateDetailsAtVersion(reportID, details, version)
+	}
+	if err == models.ErrLegalHold {
+		handleServiceError(w, errors.ErrLegalHold)
+		return
+	}
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to update report")
+		return
+	}
+
+	// Decision: Read the report back so the response and its ETag carry the stored version
+	updated, err := rh.reportRepo.GetByID(reportID)
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve report")
+		return
+	}
+	if updated == nil {
+		writeErrorResponse(w, http.StatusNotFound, "Report not found")
+		return
+	}
+	if !applied {
+		// Edited on another device between reading and writing
+		writeVersionConflict(w, updated.Version)
+		return
+	}
+
+	setETag(w, updated.Version)
+	writeJSONResponse(w, http.StatusOK, rh.toReportResponse(updated, user.Location()))
 }
 
 // DeleteReportHandler deletes a report and its file
 // DELETE /api/reports/{id}
 func (rh *ReportHandler) DeleteReportHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodDelete {
-		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
-		return
-	}
-
 	user, ok := middleware.GetUserFromContext(r)
 	if !ok {
 		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
@@ -317,87 +548,113 @@ func (rh *ReportHandler) DeleteReportHandler(w http.ResponseWriter, r *http.Requ
 	}
 
 	// Delete from database first
-	if err := rh.reportRepo.Delete(reportID); err != nil {
+	if err := rh.reportRepo.Delete(reportID); err == models.ErrLegalHold {
+		handleServiceError(w, errors.ErrLegalHold)
+		return
+	} else if err != nil {
 		writeErrorResponse(w, http.StatusInternalServerError, "Failed to delete report")
 		return
 	}
 
 	// Delete file from filesystem (ignore errors for cleanup)
-	os.Remove(report.FilePath)
+	rh.fileStorage.Remove(report.FilePath)
 
-	response := map[string]any{
-		"message": "Report deleted successfully",
-		"success": true,
-	}
+	response := types.MessageResponse{Message: "Report deleted successfully"}
 
 	writeJSONResponse(w, http.StatusOK, response)
 }
 
-// validateFile checks file type and size constraints
-func (rh *ReportHandler) validateFile(fileHeader *multipart.FileHeader) error {
-	// Check file size
-	if fileHeader.Size > rh.maxFileSize {
-		return errors.NewValidationError("File size exceeds maximum limit of 20MB")
+// maxBulkReports caps how many reports one bulk request may touch
+const maxBulkReports = 100
+
+// BulkDeleteHandler deletes many reports at once with per-item results
+// POST /api/reports/bulk-delete
+func (rh *ReportHandler) BulkDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	rh.handleBulk(w, r, rh.reportRepo.BulkDelete, models.BulkStatusDeleted)
+}
+
+// BulkArchiveHandler archives many reports at once with per-item results
+// POST /api/reports/bulk-archive
+func (rh *ReportHandler) BulkArchiveHandler(w http.ResponseWriter, r *http.Request) {
+	rh.handleBulk(w, r, rh.reportRepo.BulkArchive, models.BulkStatusArchived, models.BulkStatusAlreadyArchived)
+}
+
+// handleBulk decodes a bulk request, runs the operation, and summarizes per-item results
+func (rh *ReportHandler) handleBulk(
+	w http.ResponseWriter,
+	r *http.Request,
+	operation func(userID int, ids []int) ([]models.BulkResult, error),
+	successStatuses ...string,
+) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
 	}
 
-	// Check file extension
-	filename := strings.ToLower(fileHeader.Filename)
-	allowedExtensions := []string{".pdf", ".txt", ".docx", ".doc"}
+	var req types.BulkReportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
 
-	isAllowed := false
-	for _, ext := range allowedExtensions {
-		if strings.HasSuffix(filename, ext) {
-			isAllowed = true
-			break
+	// Decision: Drop duplicate IDs so each report gets exactly one result
+	seen := make(map[int]bool, len(req.ReportIDs))
+	var ids []int
+	for _, id := range req.ReportIDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
 		}
 	}
 
-	if !isAllowed {
-		return errors.NewValidationError("File type not supported. Please upload PDF, TXT, or DOCX files only")
+	if len(ids) == 0 || len(ids) > maxBulkReports {
+		writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Provide between 1 and %d report IDs", maxBulkReports))
+		return
 	}
 
-	// Additional content-type validation
-	contentType := fileHeader.Header.Get("Content-Type")
-	allowedTypes := []string{
-		"application/pdf",
-		"text/plain",
-		"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
-		"application/msword",
+	results, err := operation(user.ID, ids)
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "Bulk operation failed; no reports were changed")
+		return
 	}
 
-	isValidContentType := false
-	for _, allowedType := range allowedTypes {
-		if strings.Contains(contentType, allowedType) {
-			isValidContentType = true
-			break
-		}
-	}
+	response := types.BulkReportResponse{Results: make([]types.BulkResult, len(results))}
+	for i, result := range results {
+		response.Results[i] = types.BulkResult{ReportID: result.ReportID, Status: result.Status}
 
-	if !isValidContentType {
-		return errors.NewValidationError("Invalid file content type")
+		succeeded := false
+		for _, status := range successStatuses {
+			if result.Status == status {
+				succeeded = true
+				break
+			}
+		}
+		if succeeded {
+			response.Succeeded++
+		} else {
+			response.Failed++
+		}
 	}
 
-	return nil
+	writeJSONResponse(w, http.StatusOK, response)
 }
 
-// generateUniqueFilename creates a unique filename to prevent conflicts
-func (rh *ReportHandler) generateUniqueFilename(originalFilename string) string {
-	ext := filepath.Ext(originalFilename)
-	nameWithoutExt := strings.TrimSuffix(originalFilename, ext)
-
-	// Use timestamp and a portion of original name for uniqueness
-	timestamp := time.Now().Unix()
+// validateFile checks file type and size constraints
+// Decision: Allowed types come from config; the first bytes are read so spoofed extensions are caught
+func (rh *ReportHandler) validateFile(file multipart.File, fileHeader *multipart.FileHeader) error {
+	header := make([]byte, 512)
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return errors.ErrFileUploadFailed
+	}
 
-	// Sanitize filename (remove special characters)
-	safeFilename := strings.ReplaceAll(nameWithoutExt, " ", "_")
-	safeFilename = strings.Map(func(r rune) rune {
-		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' || r == '-' {
-			return r
-		}
-		return -1
-	}, safeFilename)
+	// Rewind so saveFile copies the whole upload
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return errors.ErrFileUploadFailed
+	}
 
-	return fmt.Sprintf("%d_%s%s", timestamp, safeFilename, ext)
+	return rh.fileValidator.Validate(fileHeader.Filename, fileHeader.Header.Get("Content-Type"), fileHeader.Size, header[:n])
 }
 
 // saveFile writes the uploaded file to disk
@@ -412,14 +669,56 @@ func (rh *ReportHandler) saveFile(src multipart.File, filePath string) error {
 	return err
 }
 
+// toReportResponse converts a report model to its API representation
+// Decision: Clients get an opaque file ID and download URL instead of the server path
+func (rh *ReportHandler) toReportResponse(report *models.Report, loc *time.Location) types.Report {
+	response := types.Report{
+		ID:                report.ID,
+		UserID:            report.UserID,
+		OriginalFilename:  report.OriginalFilename,
+		FileID:            rh.fileStorage.FileID(report.FilePath),
+		DownloadURL:       fmt.Sprintf("/api/reports/%d/file", report.ID),
+		FileType:          report.FileType,
+		SimplifiedSummary: report.SimplifiedSummary,
+		UploadDate:        report.UploadDate.In(loc),
+		ProcessedAt:       inZone(report.ProcessedAt, loc),
+		ArchivedAt:        inZone(report.ArchivedAt, loc),
+		Title:             report.Title,
+		Notes:             report.Notes,
+		ReadingLevel:      report.ReadingLevel,
+		ErrorCode:         report.ErrorCode,
+		ErrorDetail:       report.ErrorDetail,
+		Version:           report.Version,
+		Visibility:        report.Visibility,
+		Language:          report.Language,
+	}
+
+	if response.Title == "" {
+		response.Title = report.OriginalFilename
+	}
+	if report.ReportDate != nil {
+		date := report.ReportDate.Format(reportDateLayout)
+		response.ReportDate = &date
+	}
+
+	if rh.exposeFilePaths {
+		response.FilePath = report.FilePath
+	}
+
+	return response
+}
+
+// maxPageSize is the most entries a paginated list returns at once
+const maxPageSize = 100
+
 // parsePaginationParams extracts limit and offset from query parameters
-func (rh *ReportHandler) parsePaginationParams(r *http.Request) (limit, offset int) {
+func parsePaginationParams(r *http.Request) (limit, offset int) {
 	// Default values
 	limit = 20
 	offset = 0
 
 	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 && parsedLimit <= 100 {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 && parsedLimit <= maxPageSize {
 			limit = parsedLimit
 		}
 	}
@@ -436,11 +735,6 @@ func (rh *ReportHandler) parsePaginationParams(r *http.Request) (limit, offset i
 // GetReportSummaryHandler returns the AI-generated summary and analysis
 // GET /api/reports/{id}/summary
 func (rh *ReportHandler) GetReportSummaryHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
-		return
-	}
-
 	user, ok := middleware.GetUserFromContext(r)
 	if !ok {
 		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
@@ -467,9 +761,8 @@ func (rh *ReportHandler) GetReportSummaryHandler(w http.ResponseWriter, r *http.
 		return
 	}
 
-	// Check if user owns this report
-	if report.UserID != user.ID {
-		writeErrorResponse(w, http.StatusForbidden, "Access denied")
+	// Check if user owns this report or its visibility shares it with them
+	if !rh.authorizeRead(w, user, report) {
 		return
 	}
 
@@ -479,18 +772,35 @@ func (rh *ReportHandler) GetReportSummaryHandler(w http.ResponseWriter, r *http.
 		return
 	}
 
+	lang, err := rh.translations.ResolveLanguage(r.URL.Query().Get("lang"))
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	summary := report.SimplifiedSummary
+	if lang != "en" {
+		analysis, err := services.ParseStoredAnalysis(report.SimplifiedSummary)
+		if err != nil {
+			writeErrorResponse(w, http.StatusInternalServerError, "Failed to read analysis")
+			return
+		}
+		if err := rh.translations.TranslateAnalysis(r.Context(), analysis, lang); err != nil {
+			handleServiceError(w, err)
+			return
+		}
+		translated, err := json.Marshal(analysis)
+		if err != nil {
+			writeErrorResponse(w, http.StatusInternalServerError, "Failed to read analysis")
+			return
+		}
+		summary = string(translated)
+	}
+
 	response := types.ReportSummaryResponse{
-		Report: types.Report{
-			ID:                report.ID,
-			UserID:           report.UserID,
-			OriginalFilename: report.OriginalFilename,
-			FilePath:         report.FilePath,
-			FileType:         report.FileType,
-			SimplifiedSummary: report.SimplifiedSummary,
-			UploadDate:       report.UploadDate,
-			ProcessedAt:      report.ProcessedAt,
-		},
-		Summary: report.SimplifiedSummary,
+		Report:   rh.toReportResponse(report, user.Location()),
+		Summary:  summary,
+		Language: lang,
 	}
 
 	writeJSONResponse(w, http.StatusOK, response)
@@ -499,11 +809,100 @@ func (rh *ReportHandler) GetReportSummaryHandler(w http.ResponseWriter, r *http.
 // GetHealthMetricsHandler returns health metrics for speedometer display
 // GET /api/reports/{id}/metrics
 func (rh *ReportHandler) GetHealthMetricsHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	// Extract report ID from URL
+	vars := mux.Vars(r)
+	reportID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid report ID")
+		return
+	}
+
+	// Get report from database
+	report, err := rh.reportRepo.GetByID(reportID)
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve report")
+		return
+	}
+
+	if report == nil {
+		writeErrorResponse(w, http.StatusNotFound, "Report not found")
+		return
+	}
+
+	// Check if user owns this report or its visibility shares it with them
+	if !rh.authorizeRead(w, user, report) {
+		return
+	}
+
+	format, ok := negotiateFormat(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusNotAcceptable, "Metrics are available as application/json, application/xml, or application/x-ndjson")
+		return
+	}
+
+	// Check if report has been processed
+	if report.ProcessingStatus != "completed" {
+		writeErrorResponse(w, http.StatusBadRequest, "Report is not ready yet")
+		return
+	}
+
+	// Decision: Metrics come from the stored analysis, so no live AI service is required
+	analysis, err := services.ParseStoredAnalysis(report.SimplifiedSummary)
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to extract health metrics")
+		return
+	}
+	healthMetrics := analysis.HealthMetrics
+	completeness := analysis.Completeness
+
+	lang, err := rh.translations.ResolveLanguage(r.URL.Query().Get("lang"))
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+	if lang != "en" {
+		if err := rh.translations.TranslateMetrics(r.Context(), healthMetrics, lang); err != nil {
+			handleServiceError(w, err)
+			return
+		}
+		if completeness != nil {
+			if err := rh.translations.TranslateCompleteness(r.Context(), completeness, lang); err != nil {
+				handleServiceError(w, err)
+				return
+			}
+		}
+	}
+
+	// Decision: The dashboard shows calculators fed by this report's values; demographics come from the query
+	// like GET /api/calculators, and each result lists what is still missing
+	inputs, err := parseCalculatorInputs(r.URL.Query())
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	services.FillInputsFromMetrics(&inputs, healthMetrics, fmt.Sprintf("report %d", report.ID))
 
+	response := types.HealthMetricsResponse{
+		ReportID:     report.ID,
+		Metrics:      healthMetrics,
+		Calculators:  services.RunAllCalculators(inputs),
+		Completeness: completeness,
+		Status:       "completed",
+		Language:     lang,
+	}
+
+	writeFormatted(w, format, http.StatusOK, response, nil, asItems(healthMetrics))
+}
+
+// SubmitFeedbackHandler records the user's rating of a report analysis
+// POST /api/reports/{id}/feedback
+func (rh *ReportHandler) SubmitFeedbackHandler(w http.ResponseWriter, r *http.Request) {
 	user, ok := middleware.GetUserFromContext(r)
 	if !ok {
 		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
@@ -518,7 +917,17 @@ func (rh *ReportHandler) GetHealthMetricsHandler(w http.ResponseWriter, r *http.
 		return
 	}
 
-	// Get report from database
+	var req types.FeedbackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	if req.Rating < 1 || req.Rating > 5 {
+		writeErrorResponse(w, http.StatusBadRequest, "Rating must be between 1 and 5")
+		return
+	}
+
 	report, err := rh.reportRepo.GetByID(reportID)
 	if err != nil {
 		writeErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve report")
@@ -536,53 +945,312 @@ func (rh *ReportHandler) GetHealthMetricsHandler(w http.ResponseWriter, r *http.
 		return
 	}
 
-	// Check if report has been processed
+	// Decision: Only analyzed reports can be rated - feedback measures analysis quality
 	if report.ProcessingStatus != "completed" {
 		writeErrorResponse(w, http.StatusBadRequest, "Report is not ready yet")
 		return
 	}
 
-	// Check if AI service is available
-	if rh.aiService == nil {
-		writeErrorResponse(w, http.StatusServiceUnavailable, "AI service not available")
+	if err := rh.reportRepo.SetFeedback(reportID, req.Rating); err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to save feedback")
 		return
 	}
 
-	// Extract health metrics from AI analysis
-	healthMetrics, err := rh.aiService.GetHealthMetrics(report.SimplifiedSummary)
+	response := types.MessageResponse{Message: "Feedback recorded"}
+
+	writeJSONResponse(w, http.StatusOK, response)
+}
+
+// GetProcessingHistoryHandler lists every status a report went through and the attempts to analyze it
+// GET /api/reports/{id}/history
+func (rh *ReportHandler) GetProcessingHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	reportID, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
-		writeErrorResponse(w, http.StatusInternalServerError, "Failed to extract health metrics")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid report ID")
+		return
+	}
+
+	if rh.jobs == nil {
+		writeErrorResponse(w, http.StatusServiceUnavailable, "Processing history is not available")
+		return
+	}
+
+	history, err := rh.jobs.History(user, reportID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	loc := user.Location()
+	response := types.ReportProcessingHistoryResponse{
+		ReportID:     history.Report.ID,
+		Status:       history.Report.ProcessingStatus,
+		ErrorCode:    history.Report.ErrorCode,
+		ErrorDetail:  history.Report.ErrorDetail,
+		AttemptCount: len(history.Attempts),
+		Transitions:  make([]types.StatusTransition, len(history.Transitions)),
+		Attempts:     make([]types.ProcessingAttempt, len(history.Attempts)),
+	}
+	for i, event := range history.Transitions {
+		response.Transitions[i] = types.StatusTransition{
+			Status:      event.Status,
+			ErrorCode:   event.ErrorCode,
+			ErrorDetail: event.ErrorDetail,
+			At:          event.CreatedAt.In(loc),
+		}
+	}
+	for i, attempt := range history.Attempts {
+		response.Attempts[i] = types.ProcessingAttempt{
+			ID:         attempt.ID,
+			Status:     attempt.Status,
+			Model:      attempt.Model,
+			StartedAt:  attempt.StartedAt.In(loc),
+			FinishedAt: inZone(attempt.FinishedAt, loc),
+		}
+	}
+
+	writeJSONResponse(w, http.StatusOK, response)
+}
+
+// GetReportStatusHandler returns how far a report has got through processing, for the frontend to poll
+// GET /api/reports/{id}/status
+func (rh *ReportHandler) GetReportStatusHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
 		return
 	}
 
-	response := map[string]any{
-		"report_id": report.ID,
-		"metrics":   healthMetrics,
-		"status":    "completed",
+	reportID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid report ID")
+		return
 	}
 
+	if rh.jobs == nil {
+		writeErrorResponse(w, http.StatusServiceUnavailable, "Processing status is not available")
+		return
+	}
+
+	status, err := rh.jobs.Status(user, reportID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	loc := user.Location()
+	report := status.Report
+	response := types.ReportStatusResponse{
+		ReportID:         report.ID,
+		ProcessingStatus: report.ProcessingStatus,
+		ErrorCode:        report.ErrorCode,
+		ErrorDetail:      report.ErrorDetail,
+		RetryAt:          inZone(status.RetryAt, loc),
+		QueuePaused:      status.QueuePaused,
+		AttemptCount:     status.Attempts,
+		UploadedAt:       report.UploadDate.In(loc),
+		StartedAt:        inZone(status.StartedAt, loc),
+		ProcessedAt:      inZone(report.ProcessedAt, loc),
+		UpdatedAt:        report.UpdatedAt.In(loc),
+	}
+	if status.QueuePosition > 0 {
+		response.QueuePosition = &status.QueuePosition
+	}
+
+	// Decision: Polled responses must never be served from a cache
+	w.Header().Set("Cache-Control", "no-store")
 	writeJSONResponse(w, http.StatusOK, response)
 }
 
-// processReportAsync handles AI processing in background
-func (rh *ReportHandler) processReportAsync(report *models.Report) {
-	// Update status to processing
-	rh.reportRepo.UpdateProcessingStatus(report.ID, "processing", "")
+// reportStreamKeepalive is how often an idle event stream sends a comment, so proxies don't close it
+const reportStreamKeepalive = 15 * time.Second
+
+// GetReportEventsHandler streams a report's status changes as Server-Sent Events until it completes or fails
+// GET /api/reports/{id}/events
+func (rh *ReportHandler) GetReportEventsHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	reportID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid report ID")
+		return
+	}
+
+	if rh.stream == nil {
+		writeErrorResponse(w, http.StatusServiceUnavailable, "Report events are not available")
+		return
+	}
+
+	// Decision: A reconnecting client resumes after the last event it saw, as the SSE spec has it send
+	afterID := 0
+	if lastID := r.Header.Get("Last-Event-ID"); lastID != "" {
+		if afterID, err = strconv.Atoi(lastID); err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, "Invalid Last-Event-ID")
+			return
+		}
+	}
+
+	events, err := rh.stream.Watch(r.Context(), user, reportID, afterID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+	// Decision: 204 tells EventSource to stop reconnecting once the report has nothing more to say
+	if events == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	// Decision: The stream outlives the server's write timeout, so it lifts the deadline for this response only
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Accel-Buffering", "no") // Stop nginx holding events back
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	loc := user.Location()
+	keepalive := time.NewTicker(reportStreamKeepalive)
+	defer keepalive.Stop()
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(types.ReportStatusEvent{
+				ReportID:    event.ReportID,
+				Status:      event.Status,
+				ErrorCode:   event.ErrorCode,
+				ErrorDetail: event.ErrorDetail,
+				At:          event.CreatedAt.In(loc),
+			})
+			if err != nil {
+				return
+			}
+			fmt.Fprintf(w, "id: %d\nevent: status\ndata: %s\n\n", event.ID, data)
+		case <-keepalive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+		case <-r.Context().Done():
+			return
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// MergePartsHandler adds another of the user's reports to this one as its next pages
+// POST /api/reports/{id}/parts
+func (rh *ReportHandler) MergePartsHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	reportID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid report ID")
+		return
+	}
+
+	var req types.MergePartsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ReportID <= 0 {
+		writeErrorResponse(w, http.StatusBadRequest, "A report_id to merge is required")
+		return
+	}
 
-	// Check if AI service is available
-	if rh.aiService == nil {
-		rh.reportRepo.UpdateProcessingStatus(report.ID, "failed", "AI service not available - missing API key")
+	if rh.parts == nil {
+		writeErrorResponse(w, http.StatusServiceUnavailable, "Multi-part reports are not available")
 		return
 	}
 
-	// Extract text from file and get AI analysis
-	summary, err := rh.aiService.AnalyzeReport(report.FilePath, report.FileType)
+	report, err := rh.parts.Merge(user, reportID, req.ReportID)
 	if err != nil {
-		// Update status to failed
-		rh.reportRepo.UpdateProcessingStatus(report.ID, "failed", fmt.Sprintf("Processing failed: %v", err))
+		handleServiceError(w, err)
+		return
+	}
+	rh.queueForProcessing(report)
+
+	parts, err := rh.parts.Parts(user, report.ID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+	rh.writeParts(w, user, report, parts)
+}
+
+// GetPartsHandler lists the files a report is made of, in page order
+// GET /api/reports/{id}/parts
+func (rh *ReportHandler) GetPartsHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
 		return
 	}
 
-	// Update status to completed with summary
-	rh.reportRepo.UpdateProcessingStatus(report.ID, "completed", summary)
-}
\ No newline at end of file
+	reportID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid report ID")
+		return
+	}
+
+	if rh.parts == nil {
+		writeErrorResponse(w, http.StatusServiceUnavailable, "Multi-part reports are not available")
+		return
+	}
+
+	// Parts checks ownership before the report is loaded for the response
+	parts, err := rh.parts.Parts(user, reportID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+	report, err := rh.reportRepo.GetByID(reportID)
+	if err != nil || report == nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve report")
+		return
+	}
+
+	rh.writeParts(w, user, report, parts)
+}
+
+// writeParts responds with a report's files, the report's own file first
+func (rh *ReportHandler) writeParts(w http.ResponseWriter, user *models.User, report *models.Report, parts []*models.ReportPart) {
+	loc := user.Location()
+	response := types.ReportPartsResponse{
+		Report: rh.toReportResponse(report, loc),
+		Parts: []types.ReportPart{{
+			PartNumber:       1,
+			OriginalFilename: report.OriginalFilename,
+			FileType:         report.FileType,
+			FileSize:         report.FileSize,
+			AddedAt:          report.UploadDate.In(loc),
+		}},
+	}
+	for _, part := range parts {
+		response.Parts = append(response.Parts, types.ReportPart{
+			PartNumber:       part.PartNumber,
+			OriginalFilename: part.OriginalFilename,
+			FileType:         part.FileType,
+			FileSize:         part.FileSize,
+			AddedAt:          part.CreatedAt.In(loc),
+		})
+	}
+
+	writeJSONResponse(w, http.StatusOK, response)
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// SetSessionService enables the session endpoints, used when a session limit is configured
+func (ah *AuthHandler) SetSessionService(sessions *services.SessionService) {
+	ah.sessions = sessions
+}
+
+// ListSessionsHandler lists the devices the caller is signed in on, newest first
+// GET /api/auth/sessions
+func (ah *AuthHandler) ListSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := ah.sessionManager(w, r)
+	if !ok {
+		return
+	}
+
+	// Decision: Without a limit sessions aren't tracked, so there is nothing to list rather than an error
+	response := types.SessionsResponse{Sessions: []types.Session{}}
+	if ah.sessions == nil {
+		writeJSONResponse(w, http.StatusOK, response)
+		return
+	}
+
+	sessions, err := ah.sessions.List(user.ID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	current, _ := middleware.GetSessionTokenID(r)
+	response.Limit = ah.sessions.Limit()
+	for _, session := range sessions {
+		response.Sessions = append(response.Sessions, toSessionResponse(session, current, user))
+	}
+	writeJSONResponse(w, http.StatusOK, response)
+}
+
+// RevokeSessionHandler signs out one of the caller's devices
+// DELETE /api/auth/sessions/{id}
+func (ah *AuthHandler) RevokeSessionHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := ah.sessionManager(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid session ID")
+		return
+	}
+	if ah.sessions == nil {
+		handleServiceError(w, errors.ErrRecordNotFound)
+		return
+	}
+
+	if err := ah.sessions.Revoke(user.ID, id, models.SessionEndedRevoked); err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, map[string]string{"message": "Session signed out"})
+}
+
+// sessionManager returns the caller if they may manage sessions, writing the error response otherwise
+// Decision: As with API keys, only a password session may see or sign out the user's devices
+func (ah *AuthHandler) sessionManager(w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return nil, false
+	}
+	if _, impersonated := middleware.GetImpersonatorID(r); impersonated || middleware.UsesAPIKey(r) {
+		handleServiceError(w, errors.ErrSessionsRequirePassword)
+		return nil, false
+	}
+	return user, true
+}
+
+func toSessionResponse(session *models.Session, current string, user *models.User) types.Session {
+	loc := user.Location()
+	return types.Session{
+		ID:         session.ID,
+		UserAgent:  session.UserAgent,
+		IPAddress:  session.IPAddress,
+		CreatedAt:  session.CreatedAt.In(loc),
+		LastSeenAt: session.LastSeenAt.In(loc),
+		ExpiresAt:  session.ExpiresAt.In(loc),
+		Current:    current != "" && session.TokenID == current,
+	}
+}
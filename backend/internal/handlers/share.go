@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// ShareHandler handles read-only report share link HTTP requests
+type ShareHandler struct {
+	shareService *services.ShareService
+}
+
+// NewShareHandler creates a new share handler
+func NewShareHandler(shareService *services.ShareService) *ShareHandler {
+	return &ShareHandler{
+		shareService: shareService,
+	}
+}
+
+// CreateShareLinkHandler makes a link to a report, optionally protected by a PIN
+// POST /api/reports/{id}/shares
+func (sh *ShareHandler) CreateShareLinkHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	reportID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid report ID")
+		return
+	}
+
+	var req types.ShareLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	link, token, err := sh.shareService.CreateLink(user.ID, reportID, req.PIN, time.Duration(req.ExpiresInHours)*time.Hour)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusCreated, types.CreatedShareLink{
+		ShareLink: toShareLinkResponse(link, user.Location()),
+		Token:     token,
+		Path:      "/api/shared/" + token,
+	})
+}
+
+// ListShareLinksHandler returns a report's links and whether they were used or locked
+// GET /api/reports/{id}/shares
+func (sh *ShareHandler) ListShareLinksHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	reportID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid report ID")
+		return
+	}
+
+	links, err := sh.shareService.ListLinks(user.ID, reportID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	response := types.ShareLinkListResponse{Links: make([]types.ShareLink, len(links))}
+	for i, link := range links {
+		response.Links[i] = toShareLinkResponse(link, user.Location())
+	}
+	writeJSONResponse(w, http.StatusOK, response)
+}
+
+// RevokeShareLinkHandler disables a link
+// DELETE /api/reports/{id}/shares/{shareId}
+func (sh *ShareHandler) RevokeShareLinkHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	vars := mux.Vars(r)
+	reportID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid report ID")
+		return
+	}
+	linkID, err := strconv.Atoi(vars["shareId"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid share link ID")
+		return
+	}
+
+	if err := sh.shareService.RevokeLink(user.ID, reportID, linkID); err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, map[string]string{"message": "Share link revoked"})
+}
+
+// ViewSharedReportHandler shows a shared report's analysis to someone without an account
+// GET /api/shared/{token} with the PIN, if any, in the X-Share-PIN header
+// Decision: The PIN goes in a header, never the URL, so it stays out of access logs and browser history
+func (sh *ShareHandler) ViewSharedReportHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+
+	report, link, err := sh.shareService.OpenLink(mux.Vars(r)["token"], r.Header.Get("X-Share-PIN"))
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	analysis, _, err := services.UpgradeAnalysisJSON(report.SimplifiedSummary)
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to load the shared analysis")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, types.SharedReport{
+		Title:            report.Title,
+		OriginalFilename: report.OriginalFilename,
+		ReportDate:       report.ReportDate,
+		UploadDate:       report.UploadDate,
+		Analysis:         json.RawMessage(analysis),
+		ExpiresAt:        link.ExpiresAt,
+	})
+}
+
+func toShareLinkResponse(link *models.ShareLink, loc *time.Location) types.ShareLink {
+	return types.ShareLink{
+		ID:             link.ID,
+		ReportID:       link.ReportID,
+		PINRequired:    link.RequiresPIN(),
+		FailedAttempts: link.FailedAttempts,
+		ExpiresAt:      link.ExpiresAt.In(loc),
+		RevokedAt:      inZone(link.RevokedAt, loc),
+		LockedAt:       inZone(link.LockedAt, loc),
+		LastViewedAt:   inZone(link.LastViewedAt, loc),
+		CreatedAt:      link.CreatedAt.In(loc),
+	}
+}
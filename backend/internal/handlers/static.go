@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Cache policies for the built frontend
+// Decision: Vite fingerprints everything under /assets, so those never change; anything else
+// (index.html, favicon) keeps its name across builds and must be revalidated
+const (
+	immutableCacheControl   = "public, max-age=31536000, immutable"
+	revalidateCacheControl  = "no-cache"
+	fingerprintedAssetsPath = "/assets/"
+)
+
+// StaticHandler serves a built single-page frontend, falling back to index.html for client-side routes
+type StaticHandler struct {
+	root http.Dir
+}
+
+// NewStaticHandler creates a handler serving the frontend build in dir
+// Decision: Refuse to start without index.html rather than 404 every page of a misconfigured deployment
+func NewStaticHandler(dir string) (*StaticHandler, error) {
+	info, err := os.Stat(filepath.Join(dir, "index.html"))
+	if err != nil || info.IsDir() {
+		return nil, fmt.Errorf("%s has no index.html; build the frontend first", dir)
+	}
+	return &StaticHandler{root: http.Dir(dir)}, nil
+}
+
+// ServeHTTP serves the requested file, or index.html for paths the client-side router owns
+// GET /*
+func (sh *StaticHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Decision: Unknown API paths stay JSON 404s instead of turning into the app's HTML
+	name := path.Clean("/" + r.URL.Path)
+	if name == "/api" || strings.HasPrefix(name, "/api/") {
+		writeErrorResponse(w, http.StatusNotFound, "Endpoint not found")
+		return
+	}
+
+	if sh.serveFile(w, r, name) {
+		return
+	}
+
+	// Decision: Only extensionless paths are routes; a missing script or image is a real 404,
+	// not index.html served as JavaScript
+	if path.Ext(name) != "" {
+		http.NotFound(w, r)
+		return
+	}
+	sh.serveFile(w, r, "/index.html")
+}
+
+// serveFile writes a regular file under the root with its cache policy, reporting false if there is none
+func (sh *StaticHandler) serveFile(w http.ResponseWriter, r *http.Request, name string) bool {
+	if name == "/" {
+		name = "/index.html"
+	}
+	file, err := sh.root.Open(name)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		return false
+	}
+
+	if strings.HasPrefix(name, fingerprintedAssetsPath) {
+		w.Header().Set("Cache-Control", immutableCacheControl)
+	} else {
+		w.Header().Set("Cache-Control", revalidateCacheControl)
+	}
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, info.Name(), info.ModTime(), file)
+	return true
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// SetSyncService lets offline-first clients fetch only what changed since their last sync
+func (rh *ReportHandler) SetSyncService(sync *services.SyncService) {
+	rh.sync = sync
+}
+
+// SyncHandler returns the reports, analyses, and chat messages that changed since a checkpoint, and what was deleted
+// Without since, everything is returned; clients then send the response's checkpoint as since next time
+// GET /api/sync?since={RFC 3339 timestamp}
+func (rh *ReportHandler) SyncHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+	if rh.sync == nil {
+		writeErrorResponse(w, http.StatusServiceUnavailable, "Sync is not available")
+		return
+	}
+
+	var since *time.Time
+	if value := r.URL.Query().Get("since"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, "since must be an RFC 3339 timestamp")
+			return
+		}
+		since = &parsed
+	}
+
+	changes, err := rh.sync.Changes(user.ID, since)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	loc := user.Location()
+	response := types.SyncResponse{
+		Checkpoint:   changes.Checkpoint,
+		FullSync:     changes.FullSync,
+		Reports:      make([]types.SyncReport, 0, len(changes.Reports)),
+		ChatMessages: make([]types.ChatMessage, 0, len(changes.ChatMessages)),
+		Deleted: types.SyncDeleted{
+			Reports:      append([]int{}, changes.DeletedReports...),
+			ChatMessages: append([]int{}, changes.DeletedMessages...),
+		},
+	}
+	for _, report := range changes.Reports {
+		synced := types.SyncReport{
+			Report:           rh.toReportResponse(report, loc),
+			ProcessingStatus: report.ProcessingStatus,
+			UpdatedAt:        report.UpdatedAt.In(loc),
+		}
+		// Decision: The analysis is sent parsed instead of as the stored summary string, so clients
+		// don't have to track analysis schema versions
+		if analysis, ok := changes.Analyses[report.ID]; ok {
+			synced.Analysis = analysis
+			synced.SimplifiedSummary = ""
+		}
+		response.Reports = append(response.Reports, synced)
+	}
+	for _, message := range changes.ChatMessages {
+		response.ChatMessages = append(response.ChatMessages, toChatMessageResponse(message, loc))
+	}
+
+	writeJSONResponse(w, http.StatusOK, response)
+}
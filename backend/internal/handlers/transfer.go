@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// TransferHandler handles report ownership transfer HTTP requests
+type TransferHandler struct {
+	transferService *services.TransferService
+}
+
+// NewTransferHandler creates a new transfer handler
+func NewTransferHandler(transferService *services.TransferService) *TransferHandler {
+	return &TransferHandler{
+		transferService: transferService,
+	}
+}
+
+// RequestTransferHandler offers a report to another user
+// POST /api/reports/{id}/transfer
+func (th *TransferHandler) RequestTransferHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	reportID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid report ID")
+		return
+	}
+
+	var req types.TransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	transfer, err := th.transferService.RequestTransfer(user.ID, reportID, req.RecipientEmail)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusCreated, toTransferResponse(transfer, user.Location()))
+}
+
+// GetReportTransfersHandler returns the ownership history of a report
+// GET /api/reports/{id}/transfers
+func (th *TransferHandler) GetReportTransfersHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	reportID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid report ID")
+		return
+	}
+
+	transfers, err := th.transferService.History(user.ID, reportID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, toTransferListResponse(transfers, user.Location()))
+}
+
+// GetIncomingTransfersHandler lists transfers awaiting the user's response
+// GET /api/transfers
+func (th *TransferHandler) GetIncomingTransfersHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	transfers, err := th.transferService.ListIncoming(user.ID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, toTransferListResponse(transfers, user.Location()))
+}
+
+// AcceptTransferHandler takes ownership of an offered report
+// POST /api/transfers/{id}/accept
+func (th *TransferHandler) AcceptTransferHandler(w http.ResponseWriter, r *http.Request) {
+	th.respond(w, r, th.transferService.Accept)
+}
+
+// DeclineTransferHandler rejects an offered report
+// POST /api/transfers/{id}/decline
+func (th *TransferHandler) DeclineTransferHandler(w http.ResponseWriter, r *http.Request) {
+	th.respond(w, r, th.transferService.Decline)
+}
+
+// CancelTransferHandler withdraws an offer the user made
+// POST /api/transfers/{id}/cancel
+func (th *TransferHandler) CancelTransferHandler(w http.ResponseWriter, r *http.Request) {
+	th.respond(w, r, th.transferService.Cancel)
+}
+
+// respond runs a transfer state change for the authenticated user
+func (th *TransferHandler) respond(w http.ResponseWriter, r *http.Request, action func(userID, transferID int) (*models.ReportTransfer, error)) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	transferID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid transfer ID")
+		return
+	}
+
+	transfer, err := action(user.ID, transferID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, toTransferResponse(transfer, user.Location()))
+}
+
+func toTransferResponse(transfer *models.ReportTransfer, loc *time.Location) types.ReportTransfer {
+	return types.ReportTransfer{
+		ID:          transfer.ID,
+		ReportID:    transfer.ReportID,
+		FromUserID:  transfer.FromUserID,
+		ToUserID:    transfer.ToUserID,
+		Status:      transfer.Status,
+		CreatedAt:   transfer.CreatedAt.In(loc),
+		RespondedAt: inZone(transfer.RespondedAt, loc),
+	}
+}
+
+func toTransferListResponse(transfers []*models.ReportTransfer, loc *time.Location) types.TransferListResponse {
+	response := types.TransferListResponse{Transfers: make([]types.ReportTransfer, len(transfers))}
+	for i, transfer := range transfers {
+		response.Transfers[i] = toTransferResponse(transfer, loc)
+	}
+	return response
+}
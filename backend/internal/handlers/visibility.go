@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// SetReportAccess lets clinicians of the owner's organization read the reports shared with them
+func (rh *ReportHandler) SetReportAccess(access *services.ReportAccessService) {
+	rh.access = access
+}
+
+// authorizeRead checks that the user may read the report, writing the error response when they may not
+func (rh *ReportHandler) authorizeRead(w http.ResponseWriter, user *models.User, report *models.Report) bool {
+	if report.UserID == user.ID {
+		return true
+	}
+	if rh.access != nil {
+		allowed, err := rh.access.CanView(user.ID, report)
+		if err != nil {
+			handleServiceError(w, err)
+			return false
+		}
+		if allowed {
+			return true
+		}
+	}
+	writeErrorResponse(w, http.StatusForbidden, "Access denied")
+	return false
+}
+
+// UpdateVisibilityHandler sets who in the owner's organization may read a report
+// PATCH /api/reports/{id}/visibility
+func (rh *ReportHandler) UpdateVisibilityHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+	if rh.access == nil {
+		writeErrorResponse(w, http.StatusServiceUnavailable, "Report visibility is not available")
+		return
+	}
+
+	reportID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid report ID")
+		return
+	}
+
+	var req types.UpdateVisibilityRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	report, err := rh.access.SetVisibility(user.ID, reportID, req.Visibility)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, rh.toReportResponse(report, user.Location()))
+}
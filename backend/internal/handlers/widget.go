@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// widgetDataPath is where an embedded widget fetches its data
+const widgetDataPath = "/api/widgets/report"
+
+// WidgetHandler handles tokens that let other sites embed a report's metrics
+type WidgetHandler struct {
+	widgetService *services.WidgetService
+}
+
+// NewWidgetHandler creates a new widget handler
+func NewWidgetHandler(widgetService *services.WidgetService) *WidgetHandler {
+	return &WidgetHandler{
+		widgetService: widgetService,
+	}
+}
+
+// CreateWidgetTokenHandler issues a short-lived, read-only token for one report's widget
+// POST /api/widgets/token
+func (wh *WidgetHandler) CreateWidgetTokenHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	var req types.WidgetTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+	if req.ExpiresInMinutes < 0 {
+		writeErrorResponse(w, http.StatusBadRequest, "expires_in_minutes must not be negative")
+		return
+	}
+
+	token, claims, err := wh.widgetService.IssueToken(user.ID, req.ReportID, req.Scopes, time.Duration(req.ExpiresInMinutes)*time.Minute)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSONResponse(w, http.StatusCreated, types.WidgetToken{
+		Token:     token,
+		ReportID:  claims.ReportID,
+		Scopes:    claims.Scopes,
+		ExpiresAt: claims.ExpiresAt.In(user.Location()),
+		Path:      widgetDataPath,
+	})
+}
+
+// GetWidgetDataHandler returns the numbers a widget shows, authorized by a widget token alone
+// GET /api/widgets/report with "Authorization: Bearer <widget token>", or ?token= where headers can't be set (iframes)
+func (wh *WidgetHandler) GetWidgetDataHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	// Decision: Widgets live on other sites and the token is the only credential, so any origin may read
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	token := strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if token == "" {
+		token = r.URL.Query().Get("token")
+	}
+	if token == "" {
+		writeErrorResponse(w, http.StatusUnauthorized, "Widget token missing")
+		return
+	}
+
+	view, err := wh.widgetService.Open(token)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	response := types.WidgetData{
+		Label:   view.Label,
+		Date:    view.Date.Format(reportDateLayout),
+		Metrics: make([]types.WidgetMetric, len(view.Metrics)),
+	}
+	for i, metric := range view.Metrics {
+		response.Metrics[i] = types.WidgetMetric{
+			Name:     metric.Name,
+			Value:    metric.GetValueAsString(),
+			Unit:     metric.Unit,
+			Score:    metric.Score,
+			Status:   metric.Status,
+			RangeMin: metric.RangeMin,
+			RangeMax: metric.RangeMax,
+		}
+	}
+	for _, trend := range view.Trends {
+		readings := make([]types.WidgetReading, len(trend.Readings))
+		for i, reading := range trend.Readings {
+			readings[i] = types.WidgetReading{Date: reading.Date.Format(reportDateLayout), Value: reading.Value, Status: reading.Status}
+		}
+		response.Trends = append(response.Trends, types.WidgetTrend{Name: trend.Name, Unit: trend.Unit, Readings: readings})
+	}
+	writeJSONResponse(w, http.StatusOK, response)
+}
@@ -0,0 +1,73 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+)
+
+// RequestIDKey is the attribute naming the HTTP request a record was logged for
+const RequestIDKey = "request_id"
+
+// attrsKey holds the attributes added to a context with With
+type attrsKey struct{}
+
+// With returns a context whose records carry args, as key-value pairs or slog.Attr, after those ctx already adds
+// Decision: Attributes travel in the context rather than in a logger passed down, since the context already
+// reaches handlers, AI calls, and jobs, and *Context calls pick them up wherever they log
+func With(ctx context.Context, args ...any) context.Context {
+	added := slog.Group("", args...).Value.Group()
+	if len(added) == 0 {
+		return ctx
+	}
+	attrs := append(append([]slog.Attr{}, contextAttrs(ctx)...), added...)
+	return context.WithValue(ctx, attrsKey{}, attrs)
+}
+
+// WithRequestID returns a context whose records name the request they were logged for
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return With(ctx, RequestIDKey, requestID)
+}
+
+// RequestID returns the ID of the request ctx belongs to, or "" outside a request
+func RequestID(ctx context.Context) string {
+	for _, attr := range contextAttrs(ctx) {
+		if attr.Key == RequestIDKey {
+			return attr.Value.String()
+		}
+	}
+	return ""
+}
+
+// contextAttrs returns the attributes added to ctx
+func contextAttrs(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+	attrs, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	return attrs
+}
+
+// contextHandler adds the attributes of the context a record was logged with
+type contextHandler struct {
+	next slog.Handler
+}
+
+func (h *contextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if attrs := contextAttrs(ctx); len(attrs) > 0 {
+		record = record.Clone()
+		record.AddAttrs(attrs...)
+	}
+	return h.next.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{next: h.next.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{next: h.next.WithGroup(name)}
+}
@@ -0,0 +1,98 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// phiKeys name attributes that hold report or conversation content, redacted wherever they are logged
+// Decision: Matched by key so a record can't carry a prompt or a summary by accident; the length is kept,
+// which is usually what debugging needs
+var phiKeys = map[string]bool{
+	"prompt":     true,
+	"response":   true,
+	"text":       true,
+	"content":    true,
+	"summary":    true,
+	"question":   true,
+	"answer":     true,
+	"transcript": true,
+	"filename":   true,
+	"email":      true,
+	"full_name":  true,
+	"phone":      true,
+}
+
+// Identifiers scrubbed from every message and string attribute
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	phonePattern = regexp.MustCompile(`(?:\+\d{1,3}[\s-]?)?\b\d{5}[\s-]?\d{5}\b`)
+)
+
+// Redact replaces email addresses and phone numbers in s
+func Redact(s string) string {
+	s = emailPattern.ReplaceAllString(s, "[email]")
+	return phonePattern.ReplaceAllString(s, "[phone]")
+}
+
+// redactHandler removes PHI from records before they reach the output
+type redactHandler struct {
+	next slog.Handler
+}
+
+func (h *redactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *redactHandler) Handle(ctx context.Context, record slog.Record) error {
+	redacted := slog.NewRecord(record.Time, record.Level, Redact(record.Message), record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		redacted.AddAttrs(redactAttr(attr))
+		return true
+	})
+	return h.next.Handle(ctx, redacted)
+}
+
+func (h *redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		redacted[i] = redactAttr(attr)
+	}
+	return &redactHandler{next: h.next.WithAttrs(redacted)}
+}
+
+func (h *redactHandler) WithGroup(name string) slog.Handler {
+	return &redactHandler{next: h.next.WithGroup(name)}
+}
+
+// redactAttr returns attr with PHI removed from its value, and from the attributes of a group
+func redactAttr(attr slog.Attr) slog.Attr {
+	value := attr.Value.Resolve()
+	if phiKeys[strings.ToLower(attr.Key)] {
+		if value.Kind() == slog.KindString {
+			return slog.String(attr.Key, fmt.Sprintf("[REDACTED %d chars]", len(value.String())))
+		}
+		return slog.String(attr.Key, "[REDACTED]")
+	}
+
+	switch value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, Redact(value.String()))
+	case slog.KindGroup:
+		group := value.Group()
+		redacted := make([]slog.Attr, len(group))
+		for i, member := range group {
+			redacted[i] = redactAttr(member)
+		}
+		return slog.Attr{Key: attr.Key, Value: slog.GroupValue(redacted...)}
+	case slog.KindAny:
+		// Decision: Errors often quote what failed to parse, so they are logged by their scrubbed text
+		if err, ok := value.Any().(error); ok {
+			return slog.String(attr.Key, Redact(err.Error()))
+		}
+	}
+	return slog.Attr{Key: attr.Key, Value: value}
+}
@@ -2,11 +2,17 @@ package middleware
 
 import (
 	"context"
+	"log/slog"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/logging"
 	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
 	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
 )
 
 // UserContextKey is the key for storing user in request context
@@ -14,22 +20,53 @@ import (
 type UserContextKey string
 
 const (
-	UserKey UserContextKey = "user"
+	UserKey         UserContextKey = "user"
+	ImpersonatorKey UserContextKey = "impersonator_id"
+	APIKeyAuthKey   UserContextKey = "api_key" // The *models.APIKey the request was authenticated with
+	SessionKey      UserContextKey = "session" // The token ID of the tracked session the request belongs to
 )
 
+// ImpersonationHeader flags responses served to an impersonation token with the admin's user ID
+const ImpersonationHeader = "X-Impersonated-By"
+
 // AuthMiddleware provides JWT authentication middleware
 // Decision: Use struct to inject auth service dependency
 type AuthMiddleware struct {
 	authService *services.AuthService
+	adminEmails map[string]bool
+	auditRepo   models.AuditLogRepository // nil skips auditing impersonated requests
+	apiKeys     *services.APIKeyService   // nil accepts session tokens only
+
+	refreshWindow time.Duration // 0 suggests refreshing in the last fifth of a token's lifetime
+	sliding       bool          // Renew tokens inside the refresh window instead of only suggesting it
 }
 
 // NewAuthMiddleware creates a new authentication middleware
-func NewAuthMiddleware(authService *services.AuthService) *AuthMiddleware {
+// Decision: Admins are configured by email so no schema change is needed to bootstrap one
+func NewAuthMiddleware(authService *services.AuthService, adminEmails []string, auditRepo models.AuditLogRepository) *AuthMiddleware {
+	admins := make(map[string]bool, len(adminEmails))
+	for _, email := range adminEmails {
+		admins[strings.ToLower(strings.TrimSpace(email))] = true
+	}
+
 	return &AuthMiddleware{
 		authService: authService,
+		adminEmails: admins,
+		auditRepo:   auditRepo,
 	}
 }
 
+// SetAPIKeyService lets scripts authenticate with an API key in place of a session token
+func (am *AuthMiddleware) SetAPIKeyService(apiKeys *services.APIKeyService) {
+	am.apiKeys = apiKeys
+}
+
+// SetTokenRenewal sets how close to expiry clients are told to refresh, and whether tokens are renewed for them
+func (am *AuthMiddleware) SetTokenRenewal(refreshWindow time.Duration, sliding bool) {
+	am.refreshWindow = refreshWindow
+	am.sliding = sliding
+}
+
 // RequireAuth is middleware that requires valid JWT authentication
 // Decision: Return middleware function for flexible use with different routes
 func (am *AuthMiddleware) RequireAuth(next http.Handler) http.Handler {
@@ -40,10 +77,19 @@ func (am *AuthMiddleware) RequireAuth(next http.Handler) http.Handler {
 			writeUnauthorizedResponse(w, "Authorization token required")
 			return
 		}
+		if am.isAPIKey(token) {
+			am.serveWithAPIKey(w, r, token, next)
+			return
+		}
 
 		// Decision: Validate token and get user information
-		user, err := am.authService.GetUserFromToken(token)
+		user, claims, err := am.authService.Authenticate(token)
 		if err != nil {
+			// Decision: Say when a session was signed out, so clients can explain why the user has to sign in again
+			if appErr, ok := err.(*errors.AppError); ok && appErr.Type == errors.ErrSessionEnded.Type {
+				writeErrorEnvelope(w, appErr.Code, appErr.Type, appErr.Message)
+				return
+			}
 			writeUnauthorizedResponse(w, "Invalid or expired token")
 			return
 		}
@@ -55,20 +101,199 @@ func (am *AuthMiddleware) RequireAuth(next http.Handler) http.Handler {
 		}
 
 		// Decision: Add user to request context for handlers to use
-		ctx := context.WithValue(r.Context(), UserKey, user)
-		next.ServeHTTP(w, r.WithContext(ctx))
+		noteCaller(r, user.ID)
+		am.writeRenewalHints(w, claims)
+		ctx := context.WithValue(logging.With(r.Context(), "user_id", user.ID), UserKey, user)
+		if claims.ID != "" {
+			ctx = context.WithValue(ctx, SessionKey, claims.ID)
+		}
+		if !claims.Impersonated() {
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
+		ctx = context.WithValue(ctx, ImpersonatorKey, claims.ImpersonatorID)
+		w.Header().Set(ImpersonationHeader, strconv.Itoa(claims.ImpersonatorID))
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+		am.auditImpersonatedRequest(r, user.ID, claims.ImpersonatorID, recorder.status)
+	})
+}
+
+// writeRenewalHints tells the client when its token expires and, once expiry is near, to refresh it
+// Decision: Under sliding expiration the token is renewed here instead, so an active client never has to
+// call /refresh; renewing only inside the window keeps it from minting a token on every request
+func (am *AuthMiddleware) writeRenewalHints(w http.ResponseWriter, claims *services.JWTClaims) {
+	if claims.ExpiresAt == nil {
+		return
+	}
+	expiresAt := claims.ExpiresAt.Time
+	window := am.refreshWindow
+	if window <= 0 && claims.IssuedAt != nil {
+		window = expiresAt.Sub(claims.IssuedAt.Time) / 5
+	}
+
+	// Decision: Impersonation tokens can't be refreshed, so they only say when they run out
+	refreshDue := !claims.Impersonated() && time.Until(expiresAt) <= window
+	if refreshDue && am.sliding {
+		token, renewedExpiry, err := am.authService.RenewToken(claims)
+		if err == nil {
+			w.Header().Set(types.RefreshedTokenHeader, token)
+			expiresAt, refreshDue = renewedExpiry, false
+		} else {
+			slog.Warn("Failed to renew token", "user_id", claims.UserID, "error", err)
+		}
+	}
+
+	w.Header().Set(types.TokenExpiresInHeader, strconv.Itoa(max(int(time.Until(expiresAt).Seconds()), 0)))
+	if refreshDue {
+		w.Header().Set(types.TokenRefreshSuggestedHeader, "true")
+	}
+}
+
+// isAPIKey reports whether a bearer token is an API key rather than a JWT
+func (am *AuthMiddleware) isAPIKey(token string) bool {
+	return am.apiKeys != nil && strings.HasPrefix(token, services.APIKeyPrefix)
+}
+
+// serveWithAPIKey authenticates the request with an API key and marks it as such in the context
+func (am *AuthMiddleware) serveWithAPIKey(w http.ResponseWriter, r *http.Request, key string, next http.Handler) {
+	user, apiKey, err := am.apiKeys.Authenticate(key)
+	if err != nil {
+		writeUnauthorizedResponse(w, "Invalid or revoked API key")
+		return
+	}
+	if !user.IsActive {
+		writeUnauthorizedResponse(w, "Account is deactivated")
+		return
+	}
+
+	noteCaller(r, user.ID)
+	ctx := context.WithValue(logging.With(r.Context(), "user_id", user.ID), UserKey, user)
+	ctx = context.WithValue(ctx, APIKeyAuthKey, apiKey)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// auditImpersonatedRequest records a request an admin made while acting as a user
+// Decision: Logged after the handler so the entry carries the outcome; a failed write is logged, not surfaced
+func (am *AuthMiddleware) auditImpersonatedRequest(r *http.Request, userID, impersonatorID, status int) {
+	if am.auditRepo == nil {
+		return
+	}
+
+	entry := &models.AuditLog{
+		ActorID:      impersonatorID,
+		UserID:       userID,
+		Action:       models.AuditImpersonatedRequest,
+		Method:       r.Method,
+		Path:         r.URL.Path,
+		StatusCode:   status,
+		Impersonated: true,
+	}
+	if err := am.auditRepo.Create(entry); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to audit impersonated request", "method", r.Method, "route", routeTemplate(r),
+			"admin_id", impersonatorID, "error", err)
+	}
+}
+
+// statusRecorder captures the status code written by the wrapped handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sr *statusRecorder) WriteHeader(code int) {
+	sr.status = code
+	sr.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, so streamed responses can flush
+func (sr *statusRecorder) Unwrap() http.ResponseWriter {
+	return sr.ResponseWriter
+}
+
+// RequireAdmin is middleware that only allows configured admin users through
+// Decision: Must run after RequireAuth, which places the user in the context
+func (am *AuthMiddleware) RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := GetUserFromContext(r)
+		if !ok {
+			writeUnauthorizedResponse(w, "Authorization token required")
+			return
+		}
+
+		if !am.IsAdmin(user) {
+			writeErrorEnvelope(w, http.StatusForbidden, "", "Admin access required")
+			return
+		}
+
+		// Decision: An impersonation token never grants admin access, even while acting as an admin
+		if _, impersonated := GetImpersonatorID(r); impersonated {
+			writeErrorEnvelope(w, http.StatusForbidden, "", "Admin access is not available while impersonating")
+			return
+		}
+
+		// Decision: Nor does an API key; a key that never expires shouldn't be able to administer the site
+		if UsesAPIKey(r) {
+			writeErrorEnvelope(w, http.StatusForbidden, "", "Admin access requires signing in with a password")
+			return
+		}
+
+		next.ServeHTTP(w, r)
 	})
 }
 
+// RequireAdminOrScope lets through admins signed in with a password, and API keys of admins that were granted scope
+// Decision: Must run after RequireAuth; a scoped key lends its owner's admin rights to one operation only,
+// so a pipeline's key can't reach the rest of /api/admin, and it stops working if its owner stops being an admin
+func (am *AuthMiddleware) RequireAdminOrScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := GetUserFromContext(r)
+			if !ok {
+				writeUnauthorizedResponse(w, "Authorization token required")
+				return
+			}
+
+			if !am.IsAdmin(user) {
+				writeErrorEnvelope(w, http.StatusForbidden, "", "Admin access required")
+				return
+			}
+			if _, impersonated := GetImpersonatorID(r); impersonated {
+				writeErrorEnvelope(w, http.StatusForbidden, "", "Admin access is not available while impersonating")
+				return
+			}
+			if key, viaKey := GetAPIKey(r); viaKey && !key.HasScope(scope) {
+				writeErrorEnvelope(w, http.StatusForbidden, "", "This API key was not granted the "+scope+" scope")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// IsAdmin reports whether the user is a configured administrator
+func (am *AuthMiddleware) IsAdmin(user *models.User) bool {
+	return am.adminEmails[strings.ToLower(user.Email)]
+}
+
 // OptionalAuth is middleware that extracts user if token is present but doesn't require it
 // Decision: Useful for endpoints that behave differently for authenticated users
 func (am *AuthMiddleware) OptionalAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		token := extractBearerToken(r)
-		if token != "" {
+		if am.isAPIKey(token) {
+			if user, apiKey, err := am.apiKeys.Authenticate(token); err == nil && user.IsActive {
+				noteCaller(r, user.ID)
+				ctx := context.WithValue(logging.With(r.Context(), "user_id", user.ID), UserKey, user)
+				r = r.WithContext(context.WithValue(ctx, APIKeyAuthKey, apiKey))
+			}
+		} else if token != "" {
 			// Decision: Only add user to context if token is valid
 			if user, err := am.authService.GetUserFromToken(token); err == nil && user.IsActive {
-				ctx := context.WithValue(r.Context(), UserKey, user)
+				noteCaller(r, user.ID)
+				ctx := context.WithValue(logging.With(r.Context(), "user_id", user.ID), UserKey, user)
 				r = r.WithContext(ctx)
 			}
 		}
@@ -85,6 +310,30 @@ func GetUserFromContext(r *http.Request) (*models.User, bool) {
 	return user, ok
 }
 
+// GetImpersonatorID returns the admin acting as the user, if the request uses an impersonation token
+func GetImpersonatorID(r *http.Request) (int, bool) {
+	id, ok := r.Context().Value(ImpersonatorKey).(int)
+	return id, ok
+}
+
+// UsesAPIKey reports whether the request was authenticated with an API key rather than a session token
+func UsesAPIKey(r *http.Request) bool {
+	_, viaKey := GetAPIKey(r)
+	return viaKey
+}
+
+// GetSessionTokenID returns the token ID of the tracked session the request belongs to
+func GetSessionTokenID(r *http.Request) (string, bool) {
+	tokenID, ok := r.Context().Value(SessionKey).(string)
+	return tokenID, ok && tokenID != ""
+}
+
+// GetAPIKey returns the API key the request was authenticated with
+func GetAPIKey(r *http.Request) (*models.APIKey, bool) {
+	key, ok := r.Context().Value(APIKeyAuthKey).(*models.APIKey)
+	return key, ok && key != nil
+}
+
 // extractBearerToken extracts JWT token from Authorization header
 // Decision: Support standard "Bearer <token>" format
 func extractBearerToken(r *http.Request) string {
@@ -105,10 +354,5 @@ func extractBearerToken(r *http.Request) string {
 // writeUnauthorizedResponse writes a standardized unauthorized response
 // Decision: Consistent error format across all auth failures
 func writeUnauthorizedResponse(w http.ResponseWriter, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.WriteHeader(http.StatusUnauthorized)
-
-	response := `{"error": true, "message": "` + message + `", "status": 401}`
-	w.Write([]byte(response))
+	writeErrorEnvelope(w, http.StatusUnauthorized, "", message)
 }
\ No newline at end of file
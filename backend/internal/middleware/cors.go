@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
 )
 
 // CORSConfig holds CORS configuration
@@ -12,7 +14,8 @@ type CORSConfig struct {
 	AllowedOrigins []string
 	AllowedMethods []string
 	AllowedHeaders []string
-	MaxAge         int // Preflight cache time in seconds
+	ExposedHeaders []string // Response headers browser scripts may read
+	MaxAge         int      // Preflight cache time in seconds
 }
 
 // DefaultCORSConfig returns a development-friendly CORS configuration
@@ -32,7 +35,19 @@ func DefaultCORSConfig() *CORSConfig {
 			"Accept",
 			"Authorization",
 			"Content-Type",
+			"If-Match",
+			RequestIDHeader,
 			"X-Requested-With",
+			"X-Share-PIN",
+		},
+		// Decision: Browsers hide non-simple response headers from scripts unless exposed, and the web app
+		// needs the token renewal hints, the ETags it sends back in If-Match, and request IDs to quote in bug reports
+		ExposedHeaders: []string{
+			"ETag",
+			RequestIDHeader,
+			types.TokenExpiresInHeader,
+			types.TokenRefreshSuggestedHeader,
+			types.RefreshedTokenHeader,
 		},
 		MaxAge: 86400, // Decision: Cache preflight requests for 24 hours
 	}
@@ -65,6 +80,9 @@ func CORS(config *CORSConfig) func(http.Handler) http.Handler {
 				// Decision: Set CORS headers only for allowed origins
 				w.Header().Set("Access-Control-Allow-Methods", strings.Join(config.AllowedMethods, ", "))
 				w.Header().Set("Access-Control-Allow-Headers", strings.Join(config.AllowedHeaders, ", "))
+				if len(config.ExposedHeaders) > 0 {
+					w.Header().Set("Access-Control-Expose-Headers", strings.Join(config.ExposedHeaders, ", "))
+				}
 				w.Header().Set("Access-Control-Max-Age", strconv.Itoa(config.MaxAge))
 			}
 
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+// RequireHealthyDatabase short-circuits requests while the database is unreachable
+// Decision: Return a retryable 503 instead of letting every handler fail with a generic 500
+func RequireHealthyDatabase(monitor *database.HealthMonitor) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if monitor != nil && !monitor.IsHealthy() {
+				w.Header().Set("Retry-After", "5")
+				appErr := errors.ErrDatabaseUnavailable
+				writeErrorEnvelope(w, appErr.Code, appErr.Type, appErr.Message)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+// DisableDestructiveActions rejects DELETE requests and bulk deletes while demo mode is on
+// Decision: A shared demo instance must survive visitors deleting the sample data
+func DisableDestructiveActions(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete || strings.HasSuffix(r.URL.Path, "/bulk-delete") {
+			writeErrorEnvelope(w, http.StatusForbidden, "", "This action is disabled in demo mode")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// writeErrorEnvelope writes an error in the standard response envelope
+// Decision: Middleware can't import handlers, so it shares the envelope type rather than the helper
+func writeErrorEnvelope(w http.ResponseWriter, statusCode int, errType, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(types.ErrorEnvelope(statusCode, errType, message))
+}
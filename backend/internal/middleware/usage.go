@@ -0,0 +1,223 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+)
+
+// Deprecation marks a route that clients should stop calling
+type Deprecation struct {
+	Method    string
+	Route     string    // Route template as registered, e.g. /api/reports/history
+	Since     time.Time // When the route was deprecated
+	Sunset    time.Time // When it may be removed
+	Successor string    // Route to call instead
+}
+
+// Key identifies the route the way usage is stored, "METHOD /route/template"
+func (d Deprecation) Key() string {
+	return d.Method + " " + d.Route
+}
+
+// DeprecatedRoutes lists routes slated for change
+// Decision: Kept in code next to the routes rather than in config; removing a route is a code change anyway
+var DeprecatedRoutes = []Deprecation{
+	{
+		Method:    http.MethodGet,
+		Route:     "/api/reports/history",
+		Since:     time.Date(2026, time.October, 16, 0, 0, 0, 0, time.UTC),
+		Sunset:    time.Date(2027, time.April, 16, 0, 0, 0, 0, time.UTC),
+		Successor: "/api/reports",
+	},
+}
+
+// DeprecationHeaders announces deprecated routes with Deprecation (RFC 9745), Sunset (RFC 8594), and successor Link headers
+// Decision: Headers only; deprecated routes keep working unchanged until they are removed
+func DeprecationHeaders(deprecations []Deprecation) func(http.Handler) http.Handler {
+	byKey := make(map[string]Deprecation, len(deprecations))
+	for _, d := range deprecations {
+		byKey[d.Key()] = d
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if d, ok := byKey[r.Method+" "+routeTemplate(r)]; ok {
+				w.Header().Set("Deprecation", "@"+strconv.FormatInt(d.Since.Unix(), 10))
+				if !d.Sunset.IsZero() {
+					w.Header().Set("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+				}
+				if d.Successor != "" {
+					w.Header().Set("Link", "<"+d.Successor+">; rel=\"successor-version\"")
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// usageCallerKey holds the caller a request is counted against
+const usageCallerKey UserContextKey = "usage_caller"
+
+// usageCaller is filled in by the auth middleware, which runs after Track and can't hand back its context
+type usageCaller struct {
+	userID int
+}
+
+// noteCaller records the authenticated user for usage tracking and the request log, where they are kept
+func noteCaller(r *http.Request, userID int) {
+	if caller, ok := r.Context().Value(usageCallerKey).(*usageCaller); ok {
+		caller.userID = userID
+	}
+	if entry, ok := r.Context().Value(requestLogKey).(*requestLog); ok {
+		entry.userID = userID
+	}
+}
+
+// usageKey is one row of api_usage
+type usageKey struct {
+	method string
+	route  string
+	userID int
+	day    string
+}
+
+// usageCount accumulates calls between flushes
+type usageCount struct {
+	calls int
+	last  time.Time
+}
+
+// UsageTracker counts calls per route, caller, and day
+// Decision: Counts accumulate in memory and are written in batches, so tracking adds no query to each request;
+// calls since the last flush are lost if the process dies
+type UsageTracker struct {
+	usageRepo models.APIUsageRepository
+	interval  time.Duration
+
+	mu      sync.Mutex
+	pending map[usageKey]*usageCount
+}
+
+// NewUsageTracker creates a tracker that flushes to usageRepo every interval
+func NewUsageTracker(usageRepo models.APIUsageRepository, interval time.Duration) *UsageTracker {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	return &UsageTracker{
+		usageRepo: usageRepo,
+		interval:  interval,
+		pending:   make(map[usageKey]*usageCount),
+	}
+}
+
+// Track counts the request against its route template once the handler returns
+// Decision: Route templates, not paths, so /api/reports/1 and /api/reports/2 count as one route; preflights aren't counted
+func (ut *UsageTracker) Track(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := routeTemplate(r)
+		if r.Method == http.MethodOptions || route == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		caller := &usageCaller{}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), usageCallerKey, caller)))
+		ut.count(r.Method, route, caller.userID, time.Now())
+	})
+}
+
+// count adds one call to the pending counts
+func (ut *UsageTracker) count(method, route string, userID int, at time.Time) {
+	key := usageKey{method: method, route: route, userID: userID, day: at.UTC().Format("2006-01-02")}
+
+	ut.mu.Lock()
+	defer ut.mu.Unlock()
+	entry := ut.pending[key]
+	if entry == nil {
+		entry = &usageCount{}
+		ut.pending[key] = entry
+	}
+	entry.calls++
+	entry.last = at
+}
+
+// Flush writes the pending counts
+// Decision: A failed write puts the counts back to retry on the next flush rather than dropping them
+func (ut *UsageTracker) Flush() error {
+	ut.mu.Lock()
+	pending := ut.pending
+	ut.pending = make(map[usageKey]*usageCount)
+	ut.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	entries := make([]*models.APIUsage, 0, len(pending))
+	for key, count := range pending {
+		entries = append(entries, &models.APIUsage{
+			Method:       key.method,
+			Route:        key.route,
+			UserID:       key.userID,
+			Day:          key.day,
+			Calls:        count.calls,
+			LastCalledAt: count.last,
+		})
+	}
+
+	err := ut.usageRepo.Record(entries)
+	if err != nil {
+		ut.mu.Lock()
+		for key, count := range pending {
+			if entry := ut.pending[key]; entry != nil {
+				entry.calls += count.calls
+				continue
+			}
+			ut.pending[key] = count
+		}
+		ut.mu.Unlock()
+	}
+	return err
+}
+
+// Run flushes every interval until ctx is cancelled, then flushes once more
+func (ut *UsageTracker) Run(ctx context.Context) {
+	ticker := time.NewTicker(ut.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := ut.Flush(); err != nil {
+				slog.Error("Failed to flush API usage", "error", err)
+			}
+			return
+		case <-ticker.C:
+			if err := ut.Flush(); err != nil {
+				slog.Error("Failed to flush API usage", "error", err)
+			}
+		}
+	}
+}
+
+// routeTemplate returns the matched route's path template, or "" when no route matched
+func routeTemplate(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return ""
+	}
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return ""
+	}
+	return template
+}
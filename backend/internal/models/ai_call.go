@@ -0,0 +1,128 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// AICall records one model call; Prompt and Response hold ciphertext as stored
+type AICall struct {
+	ID         int       `json:"id" db:"id"`
+	ReportID   int       `json:"report_id" db:"report_id"`
+	UserID     int       `json:"user_id" db:"user_id"`
+	Purpose    string    `json:"purpose" db:"purpose"`
+	Provider   string    `json:"provider" db:"provider"`
+	Model      string    `json:"model" db:"model"`
+	Parameters string    `json:"parameters" db:"parameters"`
+	Prompt     string    `json:"prompt" db:"prompt"`
+	Response   string    `json:"response" db:"response"`
+	Error      string    `json:"error" db:"error"`
+	DurationMs int64     `json:"duration_ms" db:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+// AICallRepository defines the interface for AI call log database operations
+type AICallRepository interface {
+	Create(call *AICall) error
+	ListByReport(reportID int) ([]*AICall, error)
+	ListByUser(userID, limit, offset int) ([]*AICall, error)
+	DeleteBefore(cutoff time.Time) (int64, error)
+}
+
+// SQLAICallRepository implements AICallRepository using SQL database
+type SQLAICallRepository struct {
+	db *sql.DB
+}
+
+// NewAICallRepository creates a new AI call repository
+func NewAICallRepository(db *sql.DB) AICallRepository {
+	return &SQLAICallRepository{db: db}
+}
+
+// Create records a call
+func (r *SQLAICallRepository) Create(call *AICall) error {
+	if call.Parameters == "" {
+		call.Parameters = "{}"
+	}
+
+	query := `
+		INSERT INTO ai_calls (report_id, user_id, purpose, provider, model, parameters, prompt, response, error, duration_ms)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id, created_at`
+
+	row := r.db.QueryRow(query, call.ReportID, call.UserID, call.Purpose, call.Provider, call.Model, call.Parameters,
+		call.Prompt, call.Response, call.Error, call.DurationMs)
+	return row.Scan(&call.ID, &call.CreatedAt)
+}
+
+// ListByReport returns the calls made while analyzing a report, oldest first
+func (r *SQLAICallRepository) ListByReport(reportID int) ([]*AICall, error) {
+	query := `
+		SELECT id, report_id, user_id, purpose, provider, model, parameters, prompt, response, error, duration_ms, created_at
+		FROM ai_calls
+		WHERE report_id = ?
+		ORDER BY created_at ASC, id ASC`
+
+	rows, err := r.db.Query(query, reportID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var calls []*AICall
+	for rows.Next() {
+		call := &AICall{}
+		if err := rows.Scan(&call.ID, &call.ReportID, &call.UserID, &call.Purpose, &call.Provider, &call.Model, &call.Parameters,
+			&call.Prompt, &call.Response, &call.Error, &call.DurationMs, &call.CreatedAt); err != nil {
+			return nil, err
+		}
+		calls = append(calls, call)
+	}
+
+	return calls, rows.Err()
+}
+
+// ListByUser returns a page of the calls made with a user's data, newest first, without their prompts,
+// responses, or parameters
+// Decision: Calls logged before calls carried their user are matched through the report they were made for
+func (r *SQLAICallRepository) ListByUser(userID, limit, offset int) ([]*AICall, error) {
+	query := `
+		SELECT id, report_id, user_id, purpose, provider, model, error, duration_ms, created_at
+		FROM ai_calls
+		WHERE user_id = ?
+		   OR (user_id = 0 AND report_id != 0 AND report_id IN (SELECT id FROM reports WHERE user_id = ?))
+		ORDER BY created_at DESC, id DESC
+		LIMIT ? OFFSET ?`
+
+	rows, err := r.db.Query(query, userID, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var calls []*AICall
+	for rows.Next() {
+		call := &AICall{}
+		if err := rows.Scan(&call.ID, &call.ReportID, &call.UserID, &call.Purpose, &call.Provider, &call.Model,
+			&call.Error, &call.DurationMs, &call.CreatedAt); err != nil {
+			return nil, err
+		}
+		calls = append(calls, call)
+	}
+
+	return calls, rows.Err()
+}
+
+// DeleteBefore removes calls made before cutoff and returns how many were deleted; calls of users or reports under a
+// legal hold are kept until it is released
+// Decision: created_at is SQLite's CURRENT_TIMESTAMP text, so the cutoff is compared in the same UTC format
+func (r *SQLAICallRepository) DeleteBefore(cutoff time.Time) (int64, error) {
+	query := `
+		DELETE FROM ai_calls
+		WHERE created_at < ? AND user_id NOT IN (` + heldUsersQuery + `) AND report_id NOT IN (` + heldReportsQuery + `)`
+	result, err := r.db.Exec(query, cutoff.UTC().Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// AnalysisReview holds model output that couldn't be parsed, quarantined until an operator re-parses it
+// Decision: Kept apart from reports.simplified_summary so raw output is never shown to the patient as an analysis
+type AnalysisReview struct {
+	ReportID         int        `json:"report_id" db:"report_id"`
+	UserID           int        `json:"user_id" db:"user_id"`
+	OriginalFilename string     `json:"original_filename" db:"original_filename"`
+	RawOutput        string     `json:"raw_output" db:"raw_output"`
+	ParseError       string     `json:"parse_error" db:"parse_error"`
+	PromptVersion    string     `json:"prompt_version" db:"prompt_version"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+	ResolvedAt       *time.Time `json:"resolved_at" db:"resolved_at"` // Nullable
+	ResolvedBy       *int       `json:"resolved_by" db:"resolved_by"` // Nullable
+}
+
+// AnalysisReviewRepository defines the interface for analysis review database operations
+type AnalysisReviewRepository interface {
+	Quarantine(review *AnalysisReview) error
+	GetByReportID(reportID int) (*AnalysisReview, error)
+	ListOpen(limit, offset int) ([]*AnalysisReview, error)
+	Resolve(reportID, resolvedBy int) error
+}
+
+// SQLAnalysisReviewRepository implements AnalysisReviewRepository using SQL database
+type SQLAnalysisReviewRepository struct {
+	db *sql.DB
+}
+
+// NewAnalysisReviewRepository creates a new analysis review repository
+func NewAnalysisReviewRepository(db *sql.DB) AnalysisReviewRepository {
+	return &SQLAnalysisReviewRepository{db: db}
+}
+
+const analysisReviewColumns = `v.report_id, r.user_id, r.original_filename, v.raw_output, v.parse_error,
+	COALESCE(v.prompt_version, ''), v.created_at, v.resolved_at, v.resolved_by`
+
+// scanAnalysisReview reads a single review selected with analysisReviewColumns
+func scanAnalysisReview(row rowScanner) (*AnalysisReview, error) {
+	review := &AnalysisReview{}
+	err := row.Scan(&review.ReportID, &review.UserID, &review.OriginalFilename, &review.RawOutput, &review.ParseError,
+		&review.PromptVersion, &review.CreatedAt, &review.ResolvedAt, &review.ResolvedBy)
+	if err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+// Quarantine stores unparseable output for a report, replacing any earlier review of it
+func (r *SQLAnalysisReviewRepository) Quarantine(review *AnalysisReview) error {
+	query := `
+		INSERT INTO analysis_reviews (report_id, raw_output, parse_error, prompt_version)
+		VALUES (?, ?, ?, NULLIF(?, ''))
+		ON CONFLICT (report_id) DO UPDATE SET
+			raw_output = excluded.raw_output, parse_error = excluded.parse_error,
+			prompt_version = excluded.prompt_version, created_at = CURRENT_TIMESTAMP,
+			resolved_at = NULL, resolved_by = NULL
+		RETURNING created_at`
+
+	row := r.db.QueryRow(query, review.ReportID, review.RawOutput, review.ParseError, review.PromptVersion)
+	return row.Scan(&review.CreatedAt)
+}
+
+// GetByReportID retrieves the review of a report
+func (r *SQLAnalysisReviewRepository) GetByReportID(reportID int) (*AnalysisReview, error) {
+	query := `
+		SELECT ` + analysisReviewColumns + `
+		FROM analysis_reviews v
+		JOIN reports r ON r.id = v.report_id
+		WHERE v.report_id = ?`
+
+	review, err := scanAnalysisReview(r.db.QueryRow(query, reportID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return review, err
+}
+
+// ListOpen returns unresolved reviews of reports still waiting on one, oldest first
+// Decision: Raw output is left out of listings; it can be long and is fetched one report at a time
+func (r *SQLAnalysisReviewRepository) ListOpen(limit, offset int) ([]*AnalysisReview, error) {
+	query := `
+		SELECT v.report_id, r.user_id, r.original_filename, '', v.parse_error,
+			COALESCE(v.prompt_version, ''), v.created_at, v.resolved_at, v.resolved_by
+		FROM analysis_reviews v
+		JOIN reports r ON r.id = v.report_id
+		WHERE v.resolved_at IS NULL AND r.processing_status = 'needs_review'
+		ORDER BY v.created_at ASC
+		LIMIT ? OFFSET ?`
+
+	rows, err := r.db.Query(query, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reviews []*AnalysisReview
+	for rows.Next() {
+		review, err := scanAnalysisReview(rows)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, review)
+	}
+
+	return reviews, rows.Err()
+}
+
+// Resolve marks a report's review as handled
+func (r *SQLAnalysisReviewRepository) Resolve(reportID, resolvedBy int) error {
+	query := `
+		UPDATE analysis_reviews
+		SET resolved_at = CURRENT_TIMESTAMP, resolved_by = ?
+		WHERE report_id = ?`
+
+	_, err := r.db.Exec(query, resolvedBy, reportID)
+	return err
+}
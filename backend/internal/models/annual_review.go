@@ -0,0 +1,63 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// AnnualReview is a cached year-in-review overview
+type AnnualReview struct {
+	UserID       int       `json:"user_id" db:"user_id"`
+	Year         int       `json:"year" db:"year"`
+	Review       string    `json:"review" db:"review"`           // JSON written by the annual review service
+	Fingerprint  string    `json:"fingerprint" db:"fingerprint"` // Identifies the reports and their versions it covers
+	ReadingLevel string    `json:"reading_level" db:"reading_level"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// AnnualReviewRepository defines the interface for annual review cache operations
+type AnnualReviewRepository interface {
+	Get(userID, year int) (*AnnualReview, error)
+	Save(review *AnnualReview) error
+}
+
+// SQLAnnualReviewRepository implements AnnualReviewRepository using SQL database
+type SQLAnnualReviewRepository struct {
+	db *sql.DB
+}
+
+// NewAnnualReviewRepository creates a new annual review repository
+func NewAnnualReviewRepository(db *sql.DB) AnnualReviewRepository {
+	return &SQLAnnualReviewRepository{db: db}
+}
+
+// Get returns the cached review of a user's year, or nil if none was generated
+func (r *SQLAnnualReviewRepository) Get(userID, year int) (*AnnualReview, error) {
+	review := &AnnualReview{}
+	err := r.db.QueryRow(`
+		SELECT user_id, year, review, fingerprint, reading_level, created_at
+		FROM annual_reviews
+		WHERE user_id = ? AND year = ?`, userID, year).Scan(&review.UserID, &review.Year, &review.Review,
+		&review.Fingerprint, &review.ReadingLevel, &review.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+// Save stores a review, replacing the previous one for the same year
+func (r *SQLAnnualReviewRepository) Save(review *AnnualReview) error {
+	query := `
+		INSERT INTO annual_reviews (user_id, year, review, fingerprint, reading_level)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (user_id, year) DO UPDATE SET
+			review = excluded.review, fingerprint = excluded.fingerprint,
+			reading_level = excluded.reading_level, created_at = CURRENT_TIMESTAMP
+		RETURNING created_at`
+
+	row := r.db.QueryRow(query, review.UserID, review.Year, review.Review, review.Fingerprint, review.ReadingLevel)
+	return row.Scan(&review.CreatedAt)
+}
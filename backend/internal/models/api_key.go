@@ -0,0 +1,146 @@
+package models
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// API key scopes; each lets a key do one thing beyond its owner's own data
+const (
+	APIKeyScopeAnalysisImport = "analysis:import" // Attach externally produced analyses to any report
+)
+
+// APIKey is a long-lived credential a user creates for scripts and command-line tools
+// Decision: Only a hash of the key is stored, like share link tokens, so a database leak exposes no live keys
+type APIKey struct {
+	ID         int        `json:"id" db:"id"`
+	UserID     int        `json:"user_id" db:"user_id"`
+	Name       string     `json:"name" db:"name"`
+	Prefix     string     `json:"prefix" db:"prefix"`
+	KeyHash    string     `json:"-" db:"key_hash"`
+	Scopes     string     `json:"scopes" db:"scopes"`             // Comma-separated APIKeyScope* values
+	LastUsedAt *time.Time `json:"last_used_at" db:"last_used_at"` // Nullable
+	RevokedAt  *time.Time `json:"revoked_at" db:"revoked_at"`     // Nullable
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+// ScopeList returns the key's scopes
+func (k *APIKey) ScopeList() []string {
+	if k.Scopes == "" {
+		return []string{}
+	}
+	return strings.Split(k.Scopes, ",")
+}
+
+// HasScope reports whether the key was granted scope
+func (k *APIKey) HasScope(scope string) bool {
+	for _, granted := range k.ScopeList() {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKeyRepository defines the interface for API key database operations
+type APIKeyRepository interface {
+	Create(key *APIKey) error
+	GetByHash(keyHash string) (*APIKey, error)
+	ListByUser(userID int) ([]*APIKey, error)
+	CountActive(userID int) (int, error)
+	// Revoke revokes one of the user's keys, returning false if it doesn't exist or is already revoked
+	Revoke(id, userID int) (bool, error)
+	// RecordUse stamps the key's last use, at most once a minute
+	RecordUse(id int) error
+}
+
+// SQLAPIKeyRepository implements APIKeyRepository using SQL database
+type SQLAPIKeyRepository struct {
+	db *sql.DB
+}
+
+// NewAPIKeyRepository creates a new API key repository
+func NewAPIKeyRepository(db *sql.DB) APIKeyRepository {
+	return &SQLAPIKeyRepository{db: db}
+}
+
+const apiKeyColumns = `id, user_id, name, prefix, key_hash, scopes, last_used_at, revoked_at, created_at`
+
+func scanAPIKey(row rowScanner) (*APIKey, error) {
+	key := &APIKey{}
+	if err := row.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.KeyHash, &key.Scopes, &key.LastUsedAt, &key.RevokedAt, &key.CreatedAt); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Create stores a new key
+func (r *SQLAPIKeyRepository) Create(key *APIKey) error {
+	row := r.db.QueryRow(`
+		INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes)
+		VALUES (?, ?, ?, ?, ?)
+		RETURNING id, created_at`,
+		key.UserID, key.Name, key.Prefix, key.KeyHash, key.Scopes)
+	return row.Scan(&key.ID, &key.CreatedAt)
+}
+
+// GetByHash returns the key with the given hash, or nil
+func (r *SQLAPIKeyRepository) GetByHash(keyHash string) (*APIKey, error) {
+	key, err := scanAPIKey(r.db.QueryRow(`SELECT `+apiKeyColumns+` FROM api_keys WHERE key_hash = ?`, keyHash))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return key, err
+}
+
+// ListByUser returns the user's keys, newest first, including revoked ones
+func (r *SQLAPIKeyRepository) ListByUser(userID int) ([]*APIKey, error) {
+	rows, err := r.db.Query(`SELECT `+apiKeyColumns+` FROM api_keys WHERE user_id = ? ORDER BY id DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+// CountActive counts the user's keys that are not revoked
+func (r *SQLAPIKeyRepository) CountActive(userID int) (int, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM api_keys WHERE user_id = ? AND revoked_at IS NULL`, userID).Scan(&count)
+	return count, err
+}
+
+// Revoke marks the key revoked
+func (r *SQLAPIKeyRepository) Revoke(id, userID int) (bool, error) {
+	result, err := r.db.Exec(`UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP WHERE id = ? AND user_id = ? AND revoked_at IS NULL`,
+		id, userID)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+// RecordUse stamps the key's last use
+// Decision: Throttled in SQL so a script polling every second doesn't turn every read into a write
+func (r *SQLAPIKeyRepository) RecordUse(id int) error {
+	cutoff := time.Now().Add(-time.Minute).UTC().Format("2006-01-02 15:04:05")
+	_, err := r.db.Exec(`
+		UPDATE api_keys SET last_used_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND (last_used_at IS NULL OR last_used_at < ?)`, id, cutoff)
+	return err
+}
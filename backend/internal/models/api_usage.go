@@ -0,0 +1,136 @@
+package models
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// APIUsage counts one caller's calls to one route on one UTC day
+type APIUsage struct {
+	Method       string    `json:"method" db:"method"`
+	Route        string    `json:"route" db:"route"`     // Route template, not the requested path
+	UserID       int       `json:"user_id" db:"user_id"` // 0 for unauthenticated callers
+	Day          string    `json:"day" db:"day"`         // YYYY-MM-DD
+	Calls        int       `json:"calls" db:"calls"`
+	LastCalledAt time.Time `json:"last_called_at" db:"last_called_at"`
+}
+
+// APIUsageSummary totals a caller's calls to a route over the filtered days
+type APIUsageSummary struct {
+	Method       string
+	Route        string
+	UserID       int
+	Email        string // Empty for unauthenticated callers and deleted accounts
+	Calls        int
+	Days         int
+	LastCalledAt time.Time
+}
+
+// APIUsageFilter narrows a usage summary; zero values match everything
+type APIUsageFilter struct {
+	Since  string   // First day included, YYYY-MM-DD
+	Routes []string // "METHOD /route/template" keys
+	UserID int
+	Limit  int
+	Offset int
+}
+
+// APIUsageRepository defines the interface for API usage database operations
+type APIUsageRepository interface {
+	Record(entries []*APIUsage) error
+	Summarize(filter APIUsageFilter) ([]*APIUsageSummary, error)
+}
+
+// SQLAPIUsageRepository implements APIUsageRepository using SQL database
+type SQLAPIUsageRepository struct {
+	db *sql.DB
+}
+
+// NewAPIUsageRepository creates a new API usage repository
+func NewAPIUsageRepository(db *sql.DB) APIUsageRepository {
+	return &SQLAPIUsageRepository{db: db}
+}
+
+// Record adds each entry's calls to the stored count for its route, caller, and day
+// Decision: One transaction per batch, so a flush either lands completely or can be retried whole
+func (r *SQLAPIUsageRepository) Record(entries []*APIUsage) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO api_usage (method, route, user_id, day, calls, last_called_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (method, route, user_id, day) DO UPDATE SET
+			calls = calls + excluded.calls,
+			last_called_at = MAX(last_called_at, excluded.last_called_at)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, entry := range entries {
+		if _, err := stmt.Exec(entry.Method, entry.Route, entry.UserID, entry.Day, entry.Calls, entry.LastCalledAt.UTC().Truncate(time.Second)); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Summarize totals calls per route and caller, most calls first
+func (r *SQLAPIUsageRepository) Summarize(filter APIUsageFilter) ([]*APIUsageSummary, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = 50
+	}
+
+	// Decision: The bare last_called_at column takes its value from the row holding MAX(last_called_at),
+	// which keeps its DATETIME type for scanning where the aggregate would come back as text
+	query := `
+		SELECT u.method, u.route, u.user_id, COALESCE(users.email, ''), SUM(u.calls), COUNT(*),
+		       MAX(u.last_called_at), u.last_called_at
+		FROM api_usage u
+		LEFT JOIN users ON users.id = u.user_id
+		WHERE (? = '' OR u.day >= ?) AND (? = 0 OR u.user_id = ?)`
+	args := []any{filter.Since, filter.Since, filter.UserID, filter.UserID}
+
+	if len(filter.Routes) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(filter.Routes)), ", ")
+		query += ` AND u.method || ' ' || u.route IN (` + placeholders + `)`
+		for _, route := range filter.Routes {
+			args = append(args, route)
+		}
+	}
+
+	query += `
+		GROUP BY u.method, u.route, u.user_id
+		ORDER BY SUM(u.calls) DESC, u.route, u.method, u.user_id
+		LIMIT ? OFFSET ?`
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var summaries []*APIUsageSummary
+	for rows.Next() {
+		summary := &APIUsageSummary{}
+		var latest any
+		if err := rows.Scan(&summary.Method, &summary.Route, &summary.UserID, &summary.Email,
+			&summary.Calls, &summary.Days, &latest, &summary.LastCalledAt); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, rows.Err()
+}
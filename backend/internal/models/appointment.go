@@ -0,0 +1,160 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Appointment booking statuses
+const (
+	BookingRequested = "requested" // Stored, the scheduling system not yet answered
+	BookingPending   = "pending"   // Accepted; the user still picks a time at booking_url or the clinic confirms
+	BookingBooked    = "booked"
+	BookingCancelled = "cancelled"
+	BookingFailed    = "failed"
+)
+
+// AppointmentBooking is a follow-up appointment requested for one of a report's recommendations
+type AppointmentBooking struct {
+	ID                  int        `json:"id" db:"id"`
+	UserID              int        `json:"user_id" db:"user_id"`
+	ReportID            int        `json:"report_id" db:"report_id"`
+	RecommendationIndex int        `json:"recommendation_index" db:"recommendation_index"`
+	Recommendation      string     `json:"recommendation" db:"recommendation"`
+	Specialty           string     `json:"specialty" db:"specialty"`
+	PreferredTime       *time.Time `json:"preferred_time" db:"preferred_time"` // Nullable
+	Notes               string     `json:"notes" db:"notes"`
+	Provider            string     `json:"provider" db:"provider"`
+	Status              string     `json:"status" db:"status"`
+	ExternalRef         string     `json:"external_ref" db:"external_ref"` // Empty until the scheduling system answers
+	BookingURL          string     `json:"booking_url" db:"booking_url"`
+	ScheduledAt         *time.Time `json:"scheduled_at" db:"scheduled_at"` // Nullable
+	Error               string     `json:"error" db:"error"`
+	CreatedAt           time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// AppointmentRepository defines the interface for appointment booking database operations
+type AppointmentRepository interface {
+	// Create stores a requested booking unless the recommendation already has one requested, pending, or booked;
+	// it returns false in that case
+	Create(booking *AppointmentBooking) (bool, error)
+	// Update stores the booking's status, reference, link, time, and error
+	Update(booking *AppointmentBooking) error
+	GetByID(id int) (*AppointmentBooking, error)
+	GetByReference(provider, reference string) (*AppointmentBooking, error)
+	ListByReport(reportID int) ([]*AppointmentBooking, error)
+}
+
+// SQLAppointmentRepository implements AppointmentRepository using SQL database
+type SQLAppointmentRepository struct {
+	db *sql.DB
+}
+
+// NewAppointmentRepository creates a new appointment booking repository
+func NewAppointmentRepository(db *sql.DB) AppointmentRepository {
+	return &SQLAppointmentRepository{db: db}
+}
+
+const appointmentColumns = `id, user_id, report_id, recommendation_index, recommendation, specialty, preferred_time, notes,
+	provider, status, COALESCE(external_ref, ''), booking_url, scheduled_at, error, created_at, updated_at`
+
+func scanAppointment(row rowScanner) (*AppointmentBooking, error) {
+	booking := &AppointmentBooking{}
+	err := row.Scan(&booking.ID, &booking.UserID, &booking.ReportID, &booking.RecommendationIndex, &booking.Recommendation,
+		&booking.Specialty, &booking.PreferredTime, &booking.Notes, &booking.Provider, &booking.Status, &booking.ExternalRef,
+		&booking.BookingURL, &booking.ScheduledAt, &booking.Error, &booking.CreatedAt, &booking.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return booking, nil
+}
+
+// Create inserts the booking in one statement, so two simultaneous requests can't both book
+func (r *SQLAppointmentRepository) Create(booking *AppointmentBooking) (bool, error) {
+	query := `
+		INSERT INTO appointment_bookings (user_id, report_id, recommendation_index, recommendation, specialty,
+			preferred_time, notes, provider, status)
+		SELECT ?, ?, ?, ?, ?, ?, ?, ?, ?
+		WHERE NOT EXISTS (
+			SELECT 1 FROM appointment_bookings
+			WHERE report_id = ? AND recommendation_index = ? AND status IN (?, ?, ?)
+		)
+		RETURNING id, created_at, updated_at`
+
+	var preferred *time.Time
+	if booking.PreferredTime != nil {
+		t := booking.PreferredTime.UTC()
+		preferred = &t
+	}
+	err := r.db.QueryRow(query, booking.UserID, booking.ReportID, booking.RecommendationIndex, booking.Recommendation,
+		booking.Specialty, preferred, booking.Notes, booking.Provider, booking.Status,
+		booking.ReportID, booking.RecommendationIndex, BookingRequested, BookingPending, BookingBooked,
+	).Scan(&booking.ID, &booking.CreatedAt, &booking.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Update stores what the scheduling system said about the booking
+func (r *SQLAppointmentRepository) Update(booking *AppointmentBooking) error {
+	var scheduled *time.Time
+	if booking.ScheduledAt != nil {
+		t := booking.ScheduledAt.UTC()
+		scheduled = &t
+	}
+	var reference *string
+	if booking.ExternalRef != "" {
+		reference = &booking.ExternalRef
+	}
+
+	_, err := r.db.Exec(`
+		UPDATE appointment_bookings
+		SET status = ?, external_ref = ?, booking_url = ?, scheduled_at = ?, error = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`,
+		booking.Status, reference, booking.BookingURL, scheduled, booking.Error, booking.ID)
+	return err
+}
+
+// GetByID returns a booking, or nil if there is none
+func (r *SQLAppointmentRepository) GetByID(id int) (*AppointmentBooking, error) {
+	booking, err := scanAppointment(r.db.QueryRow(`SELECT `+appointmentColumns+` FROM appointment_bookings WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return booking, err
+}
+
+// GetByReference finds the booking a scheduling system's callback refers to, or nil
+func (r *SQLAppointmentRepository) GetByReference(provider, reference string) (*AppointmentBooking, error) {
+	booking, err := scanAppointment(r.db.QueryRow(`SELECT `+appointmentColumns+` FROM appointment_bookings
+		WHERE provider = ? AND external_ref = ?`, provider, reference))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return booking, err
+}
+
+// ListByReport returns a report's bookings, oldest first
+func (r *SQLAppointmentRepository) ListByReport(reportID int) ([]*AppointmentBooking, error) {
+	rows, err := r.db.Query(`SELECT `+appointmentColumns+` FROM appointment_bookings WHERE report_id = ? ORDER BY id`, reportID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bookings []*AppointmentBooking
+	for rows.Next() {
+		booking, err := scanAppointment(rows)
+		if err != nil {
+			return nil, err
+		}
+		bookings = append(bookings, booking)
+	}
+
+	return bookings, rows.Err()
+}
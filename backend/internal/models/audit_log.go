@@ -0,0 +1,117 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Audit actions
+const (
+	AuditImpersonationStarted = "impersonation.started"
+	AuditImpersonatedRequest  = "impersonation.request"
+	AuditJobRetried           = "job.retried"
+	AuditJobCancelled         = "job.cancelled"
+	AuditQueuePaused          = "queue.paused"
+	AuditQueueResumed         = "queue.resumed"
+	AuditAnalysisReviewViewed = "analysis_review.viewed"
+	AuditAnalysisReparsed     = "analysis_review.reparsed"
+	AuditAnalysisImported     = "analysis.imported"
+	AuditPlanChanged          = "plan.changed"
+	AuditAICallsViewed        = "ai_calls.viewed"
+	AuditReanalysisStarted    = "reanalysis.started"
+	AuditReanalysisCancelled  = "reanalysis.cancelled"
+	AuditReanalysisDiffViewed = "reanalysis.diff_viewed"
+	AuditChatDismissed        = "moderation.dismissed"
+	AuditChatRedacted         = "moderation.redacted"
+	AuditUserBanned           = "moderation.banned"
+	AuditUserUnbanned         = "moderation.unbanned"
+	AuditCanaryRolledBack     = "prompt_canary.rolled_back"
+	AuditCanaryPromoted       = "prompt_canary.promoted"
+	AuditLegalHoldPlaced      = "legal_hold.placed"
+	AuditLegalHoldReleased    = "legal_hold.released"
+)
+
+// AuditLog records an action taken on a user's account
+// Decision: ActorID is who acted and UserID whose data was touched; they differ only under impersonation
+type AuditLog struct {
+	ID           int       `json:"id" db:"id"`
+	ActorID      int       `json:"actor_id" db:"actor_id"`
+	UserID       int       `json:"user_id" db:"user_id"`
+	Action       string    `json:"action" db:"action"`
+	Method       string    `json:"method,omitempty" db:"method"`
+	Path         string    `json:"path,omitempty" db:"path"`
+	StatusCode   int       `json:"status_code,omitempty" db:"status_code"`
+	Impersonated bool      `json:"impersonated" db:"impersonated"`
+	Details      string    `json:"details,omitempty" db:"details"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// AuditLogFilter narrows an audit log listing; zero values match everything
+type AuditLogFilter struct {
+	UserID  int
+	ActorID int
+	Limit   int
+	Offset  int
+}
+
+// AuditLogRepository defines the interface for audit log database operations
+// Decision: Append-only; entries are never updated or deleted through the application
+type AuditLogRepository interface {
+	Create(entry *AuditLog) error
+	List(filter AuditLogFilter) ([]*AuditLog, error)
+}
+
+// SQLAuditLogRepository implements AuditLogRepository using SQL database
+type SQLAuditLogRepository struct {
+	db *sql.DB
+}
+
+// NewAuditLogRepository creates a new audit log repository
+func NewAuditLogRepository(db *sql.DB) AuditLogRepository {
+	return &SQLAuditLogRepository{db: db}
+}
+
+// Create appends an entry to the audit log
+func (r *SQLAuditLogRepository) Create(entry *AuditLog) error {
+	query := `
+		INSERT INTO audit_logs (actor_id, user_id, action, method, path, status_code, impersonated, details)
+		VALUES (?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, 0), ?, NULLIF(?, ''))
+		RETURNING id, created_at`
+
+	row := r.db.QueryRow(query, entry.ActorID, entry.UserID, entry.Action, entry.Method, entry.Path,
+		entry.StatusCode, entry.Impersonated, entry.Details)
+	return row.Scan(&entry.ID, &entry.CreatedAt)
+}
+
+// List returns matching entries, newest first
+func (r *SQLAuditLogRepository) List(filter AuditLogFilter) ([]*AuditLog, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = 50
+	}
+
+	query := `
+		SELECT id, actor_id, user_id, action, COALESCE(method, ''), COALESCE(path, ''),
+		       COALESCE(status_code, 0), impersonated, COALESCE(details, ''), created_at
+		FROM audit_logs
+		WHERE (? = 0 OR user_id = ?) AND (? = 0 OR actor_id = ?)
+		ORDER BY created_at DESC, id DESC
+		LIMIT ? OFFSET ?`
+
+	rows, err := r.db.Query(query, filter.UserID, filter.UserID, filter.ActorID, filter.ActorID, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*AuditLog
+	for rows.Next() {
+		entry := &AuditLog{}
+		if err := rows.Scan(&entry.ID, &entry.ActorID, &entry.UserID, &entry.Action, &entry.Method, &entry.Path,
+			&entry.StatusCode, &entry.Impersonated, &entry.Details, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
@@ -7,12 +7,46 @@ import (
 
 // ChatMessage represents a chat message in our system
 type ChatMessage struct {
+	ID          int        `json:"id" db:"id"`
+	ReportID    int        `json:"report_id" db:"report_id"`
+	UserMessage string     `json:"user_message" db:"user_message"`
+	AIResponse  string     `json:"ai_response" db:"ai_response"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	IsDeleted   bool       `json:"is_deleted" db:"is_deleted"`
+	Version     int        `json:"version" db:"version"`
+	UpdatedAt   *time.Time `json:"updated_at" db:"updated_at"` // Nullable; set when edited or regenerated
+	InputMode   string     `json:"input_mode" db:"input_mode"` // ChatInputText or ChatInputVoice
+}
+
+// How a question was asked
+const (
+	ChatInputText  = "text"
+	ChatInputVoice = "voice" // UserMessage is the transcription of the recording
+)
+
+// ChatMessageVersion is a superseded question/answer pair of a chat message
+type ChatMessageVersion struct {
 	ID          int       `json:"id" db:"id"`
-	ReportID    int       `json:"report_id" db:"report_id"`
+	MessageID   int       `json:"message_id" db:"message_id"`
+	Version     int       `json:"version" db:"version"`
 	UserMessage string    `json:"user_message" db:"user_message"`
 	AIResponse  string    `json:"ai_response" db:"ai_response"`
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	IsDeleted   bool      `json:"is_deleted" db:"is_deleted"`
+}
+
+// chatColumns lists the columns scanned by scanChatMessage, in order
+const chatColumns = `id, report_id, user_message, ai_response, created_at, is_deleted, version, updated_at, input_mode`
+
+// scanChatMessage reads a single chat message selected with chatColumns
+func scanChatMessage(row rowScanner) (*ChatMessage, error) {
+	message := &ChatMessage{}
+	err := row.Scan(&message.ID, &message.ReportID, &message.UserMessage,
+		&message.AIResponse, &message.CreatedAt, &message.IsDeleted,
+		&message.Version, &message.UpdatedAt, &message.InputMode)
+	if err != nil {
+		return nil, err
+	}
+	return message, nil
 }
 
 // ChatMessageRepository defines the interface for chat message database operations
@@ -24,6 +58,12 @@ type ChatMessageRepository interface {
 	SoftDelete(id int) error
 	HardDelete(id int) error
 	GetChatHistory(reportID int) ([]*ChatMessage, error)
+	Revise(message *ChatMessage) error
+	GetVersions(messageID int) ([]*ChatMessageVersion, error)
+	CountByUserSince(userID int, since time.Time) (int, error)
+	// ListChangedSince returns the live messages on the user's reports asked or revised since the given time,
+	// and all those on reports transferred to the user since then, oldest first
+	ListChangedSince(userID int, since time.Time) ([]*ChatMessage, error)
 }
 
 // SQLChatMessageRepository implements ChatMessageRepository using SQL database
@@ -39,28 +79,28 @@ func NewChatMessageRepository(db *sql.DB) ChatMessageRepository {
 // Create inserts a new chat message into the database
 func (r *SQLChatMessageRepository) Create(message *ChatMessage) error {
 	query := `
-		INSERT INTO chat_messages (report_id, user_message, ai_response)
-		VALUES (?, ?, ?)
-		RETURNING id, created_at`
+		INSERT INTO chat_messages (report_id, user_message, ai_response, input_mode)
+		VALUES (?, ?, ?, ?)
+		RETURNING id, created_at, version`
+
+	if message.InputMode == "" {
+		message.InputMode = ChatInputText
+	}
 
 	// Decision: Auto-generate timestamps and ID, is_deleted defaults to FALSE
-	row := r.db.QueryRow(query, message.ReportID, message.UserMessage, message.AIResponse)
-	return row.Scan(&message.ID, &message.CreatedAt)
+	row := r.db.QueryRow(query, message.ReportID, message.UserMessage, message.AIResponse, message.InputMode)
+	return row.Scan(&message.ID, &message.CreatedAt, &message.Version)
 }
 
 // GetByID retrieves a chat message by its ID
 func (r *SQLChatMessageRepository) GetByID(id int) (*ChatMessage, error) {
-	message := &ChatMessage{}
 	query := `
-		SELECT id, report_id, user_message, ai_response, created_at, is_deleted
+		SELECT ` + chatColumns + `
 		FROM chat_messages
 		WHERE id = ? AND is_deleted = FALSE`
 
 	// Decision: Only return non-deleted messages by default
-	row := r.db.QueryRow(query, id)
-	err := row.Scan(&message.ID, &message.ReportID, &message.UserMessage,
-		&message.AIResponse, &message.CreatedAt, &message.IsDeleted)
-
+	message, err := scanChatMessage(r.db.QueryRow(query, id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -74,7 +114,7 @@ func (r *SQLChatMessageRepository) GetByID(id int) (*ChatMessage, error) {
 // GetByReportID retrieves chat messages for a specific report with pagination
 func (r *SQLChatMessageRepository) GetByReportID(reportID int, limit, offset int) ([]*ChatMessage, error) {
 	query := `
-		SELECT id, report_id, user_message, ai_response, created_at, is_deleted
+		SELECT ` + chatColumns + `
 		FROM chat_messages
 		WHERE report_id = ? AND is_deleted = FALSE
 		ORDER BY created_at ASC
@@ -89,9 +129,7 @@ func (r *SQLChatMessageRepository) GetByReportID(reportID int, limit, offset int
 
 	var messages []*ChatMessage
 	for rows.Next() {
-		message := &ChatMessage{}
-		err := rows.Scan(&message.ID, &message.ReportID, &message.UserMessage,
-			&message.AIResponse, &message.CreatedAt, &message.IsDeleted)
+		message, err := scanChatMessage(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -109,7 +147,7 @@ func (r *SQLChatMessageRepository) GetByReportID(reportID int, limit, offset int
 func (r *SQLChatMessageRepository) Update(message *ChatMessage) error {
 	query := `
 		UPDATE chat_messages
-		SET user_message = ?, ai_response = ?
+		SET user_message = ?, ai_response = ?, updated_at = CURRENT_TIMESTAMP
 		WHERE id = ? AND is_deleted = FALSE`
 
 	// Decision: Only allow updating message content, not metadata
@@ -132,10 +170,19 @@ func (r *SQLChatMessageRepository) Update(message *ChatMessage) error {
 
 // SoftDelete marks a chat message as deleted
 func (r *SQLChatMessageRepository) SoftDelete(id int) error {
-	query := `UPDATE chat_messages SET is_deleted = TRUE WHERE id = ?`
+	query := `UPDATE chat_messages SET is_deleted = TRUE WHERE id = ? AND is_deleted = FALSE`
 
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkLegalHold(tx, chatMessageOwnerQuery, id); err != nil {
+		return err
+	}
 	// Decision: Soft delete to preserve chat history for analysis
-	result, err := r.db.Exec(query, id)
+	result, err := tx.Exec(query, id)
 	if err != nil {
 		return err
 	}
@@ -149,15 +196,32 @@ func (r *SQLChatMessageRepository) SoftDelete(id int) error {
 		return sql.ErrNoRows
 	}
 
-	return nil
+	if err := recordChatTombstone(tx, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 // HardDelete permanently removes a chat message
 func (r *SQLChatMessageRepository) HardDelete(id int) error {
 	query := `DELETE FROM chat_messages WHERE id = ?`
 
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkLegalHold(tx, chatMessageOwnerQuery, id); err != nil {
+		return err
+	}
+	if err := recordChatTombstone(tx, id); err != nil {
+		return err
+	}
+
 	// Decision: Hard delete for admin cleanup or GDPR compliance
-	result, err := r.db.Exec(query, id)
+	result, err := tx.Exec(query, id)
 	if err != nil {
 		return err
 	}
@@ -171,13 +235,13 @@ func (r *SQLChatMessageRepository) HardDelete(id int) error {
 		return sql.ErrNoRows
 	}
 
-	return nil
+	return tx.Commit()
 }
 
 // GetChatHistory retrieves all chat messages for a report (for AI context)
 func (r *SQLChatMessageRepository) GetChatHistory(reportID int) ([]*ChatMessage, error) {
 	query := `
-		SELECT id, report_id, user_message, ai_response, created_at, is_deleted
+		SELECT ` + chatColumns + `
 		FROM chat_messages
 		WHERE report_id = ? AND is_deleted = FALSE
 		ORDER BY created_at ASC`
@@ -191,9 +255,7 @@ func (r *SQLChatMessageRepository) GetChatHistory(reportID int) ([]*ChatMessage,
 
 	var messages []*ChatMessage
 	for rows.Next() {
-		message := &ChatMessage{}
-		err := rows.Scan(&message.ID, &message.ReportID, &message.UserMessage,
-			&message.AIResponse, &message.CreatedAt, &message.IsDeleted)
+		message, err := scanChatMessage(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -205,4 +267,107 @@ func (r *SQLChatMessageRepository) GetChatHistory(reportID int) ([]*ChatMessage,
 	}
 
 	return messages, nil
+}
+
+// CountByUserSince counts questions asked on any of the user's reports since the given time
+// Decision: Deleted messages still count; deleting a question doesn't refund the answer it cost
+func (r *SQLChatMessageRepository) CountByUserSince(userID int, since time.Time) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM chat_messages
+		JOIN reports ON reports.id = chat_messages.report_id
+		WHERE reports.user_id = ? AND chat_messages.created_at >= ?`
+
+	var count int
+	err := r.db.QueryRow(query, userID, since.UTC().Format("2006-01-02 15:04:05")).Scan(&count)
+	return count, err
+}
+
+// ListChangedSince returns the user's live messages changed since the given time
+// Decision: A transferred report's messages are all new to its recipient, however old they are
+func (r *SQLChatMessageRepository) ListChangedSince(userID int, since time.Time) ([]*ChatMessage, error) {
+	query := `
+		SELECT ` + chatColumns + `
+		FROM chat_messages
+		WHERE report_id IN (SELECT id FROM reports WHERE user_id = ?) AND is_deleted = FALSE
+			AND (COALESCE(updated_at, created_at) >= ?
+				OR report_id IN (SELECT report_id FROM report_transfers
+					WHERE to_user_id = ? AND status = ? AND responded_at >= ?))
+		ORDER BY created_at ASC, id ASC`
+
+	checkpoint := sqliteTimestamp(since)
+	rows, err := r.db.Query(query, userID, checkpoint, userID, TransferAccepted, checkpoint)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []*ChatMessage
+	for rows.Next() {
+		message, err := scanChatMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+
+	return messages, rows.Err()
+}
+
+// Revise replaces a message's question and answer, archiving the previous pair as a version
+// Decision: Archive and update in one transaction so no answer is ever lost
+func (r *SQLChatMessageRepository) Revise(message *ChatMessage) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO chat_message_versions (message_id, version, user_message, ai_response, created_at)
+		SELECT id, version, user_message, ai_response, COALESCE(updated_at, created_at)
+		FROM chat_messages
+		WHERE id = ? AND is_deleted = FALSE`, message.ID)
+	if err != nil {
+		return err
+	}
+
+	row := tx.QueryRow(`
+		UPDATE chat_messages
+		SET user_message = ?, ai_response = ?, version = version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND is_deleted = FALSE
+		RETURNING version, updated_at`, message.UserMessage, message.AIResponse, message.ID)
+	if err := row.Scan(&message.Version, &message.UpdatedAt); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetVersions returns the superseded versions of a message, oldest first
+func (r *SQLChatMessageRepository) GetVersions(messageID int) ([]*ChatMessageVersion, error) {
+	query := `
+		SELECT id, message_id, version, user_message, ai_response, created_at
+		FROM chat_message_versions
+		WHERE message_id = ?
+		ORDER BY version ASC`
+
+	rows, err := r.db.Query(query, messageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []*ChatMessageVersion
+	for rows.Next() {
+		version := &ChatMessageVersion{}
+		err := rows.Scan(&version.ID, &version.MessageID, &version.Version,
+			&version.UserMessage, &version.AIResponse, &version.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+
+	return versions, rows.Err()
 }
\ No newline at end of file
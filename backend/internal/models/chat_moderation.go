@@ -0,0 +1,331 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// What flagged a chat exchange for moderation
+const (
+	ModerationSourceSafety = "safety" // The safety filter changed the answer
+	ModerationSourceCrisis = "crisis" // The question matched crisis keywords
+	ModerationSourceUser   = "user"   // The user flagged it
+)
+
+// A moderator's decision on a flagged exchange
+const (
+	ModerationDismissed = "dismissed" // Reviewed and left as it is
+	ModerationRedacted  = "redacted"  // Its content was removed
+)
+
+// Moderation queue states a listing can be narrowed to
+const (
+	ModerationStatusOpen     = "open"     // Not reviewed, or flagged by a user since
+	ModerationStatusReviewed = "reviewed" // Decided and not flagged since
+)
+
+// RedactedText replaces chat content a moderator removed
+const RedactedText = "[Removed by a moderator]"
+
+// FlaggedMessage is a chat exchange in the moderation queue
+type FlaggedMessage struct {
+	MessageID   int        `json:"message_id" db:"message_id"`
+	ReportID    int        `json:"report_id" db:"report_id"`
+	UserID      int        `json:"user_id" db:"user_id"`
+	UserMessage string     `json:"user_message" db:"user_message"`
+	AIResponse  string     `json:"ai_response" db:"ai_response"`
+	InputMode   string     `json:"input_mode" db:"input_mode"`
+	IsDeleted   bool       `json:"is_deleted" db:"is_deleted"` // Deleted by the user; still moderated
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	Sources     []string   `json:"sources"`                    // ModerationSource* values, in that order
+	FlagCount   int        `json:"flag_count" db:"flag_count"` // Flags raised by users
+	Action      string     `json:"action" db:"action"`         // ModerationDismissed or ModerationRedacted; empty until reviewed
+	Note        string     `json:"note" db:"note"`
+	ReviewedBy  *int       `json:"reviewed_by" db:"reviewed_by"`
+	ReviewedAt  *time.Time `json:"reviewed_at" db:"reviewed_at"`
+	Open        bool       `json:"open"` // Not reviewed, or flagged by a user since
+}
+
+// MessageFlag is a user's flag on a chat exchange
+type MessageFlag struct {
+	ID        int       `json:"id" db:"id"`
+	MessageID int       `json:"message_id" db:"message_id"`
+	UserID    int       `json:"user_id" db:"user_id"`
+	Reason    string    `json:"reason" db:"reason"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// UserBan records an account a moderator deactivated
+type UserBan struct {
+	UserID    int       `json:"user_id" db:"user_id"`
+	Reason    string    `json:"reason" db:"reason"`
+	BannedBy  int       `json:"banned_by" db:"banned_by"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// ModerationFilter narrows the moderation queue; zero values match everything
+type ModerationFilter struct {
+	Source string // One of the ModerationSource* values
+	Status string // One of the ModerationStatus* values
+	UserID int
+	Limit  int
+	Offset int
+}
+
+// ModerationRepository defines the interface for chat moderation database operations
+type ModerationRepository interface {
+	// Flag records a user's flag on a message, replacing their earlier one, and reopens it
+	Flag(flag *MessageFlag) error
+	ListFlags(messageID int) ([]*MessageFlag, error)
+	// List returns flagged exchanges, deleted ones included, newest first
+	List(filter ModerationFilter) ([]*FlaggedMessage, error)
+	// Get returns a flagged exchange, or nil if the message doesn't exist or was never flagged
+	Get(messageID int) (*FlaggedMessage, error)
+	// Review records a moderator's decision, replacing any earlier one
+	Review(messageID, moderatorID int, action, note string) error
+	// Redact replaces the message's question, answer, or both, with RedactedText everywhere they are kept
+	Redact(messageID int, question, answer bool) error
+	Ban(ban *UserBan) error
+	// Unban removes the record of a ban, returning false if the user wasn't banned
+	Unban(userID int) (bool, error)
+	GetBan(userID int) (*UserBan, error)
+}
+
+// SQLModerationRepository implements ModerationRepository using SQL database
+type SQLModerationRepository struct {
+	db *sql.DB
+}
+
+// NewModerationRepository creates a new moderation repository
+func NewModerationRepository(db *sql.DB) ModerationRepository {
+	return &SQLModerationRepository{db: db}
+}
+
+// Flag stores a user's flag, replacing their earlier one, and drops any decision made before it
+// Decision: A new flag reopens a reviewed exchange rather than being compared with the review's time, which
+// is only kept to the second; the audit log keeps the earlier decision
+func (r *SQLModerationRepository) Flag(flag *MessageFlag) error {
+	query := `
+		INSERT INTO chat_message_flags (message_id, user_id, reason)
+		VALUES (?, ?, ?)
+		ON CONFLICT (message_id, user_id) DO UPDATE SET reason = excluded.reason, created_at = CURRENT_TIMESTAMP
+		RETURNING id, created_at`
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := tx.QueryRow(query, flag.MessageID, flag.UserID, flag.Reason).Scan(&flag.ID, &flag.CreatedAt); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM chat_moderation_reviews WHERE message_id = ?`, flag.MessageID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ListFlags returns the flags users raised on a message, oldest first
+func (r *SQLModerationRepository) ListFlags(messageID int) ([]*MessageFlag, error) {
+	rows, err := r.db.Query(`
+		SELECT id, message_id, user_id, reason, created_at
+		FROM chat_message_flags
+		WHERE message_id = ?
+		ORDER BY created_at ASC, id ASC`, messageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var flags []*MessageFlag
+	for rows.Next() {
+		flag := &MessageFlag{}
+		if err := rows.Scan(&flag.ID, &flag.MessageID, &flag.UserID, &flag.Reason, &flag.CreatedAt); err != nil {
+			return nil, err
+		}
+		flags = append(flags, flag)
+	}
+	return flags, rows.Err()
+}
+
+// flaggedSelect reads chat messages with what flagged them and any review; it is filtered by the caller
+// Decision: The queue is derived from chat_messages and the tables that flag them rather than copied into a
+// table of its own, so it can't drift from what was actually said or from the filters' own records
+const flaggedSelect = `
+	SELECT m.id, m.report_id, r.user_id, m.user_message, m.ai_response, m.input_mode, m.is_deleted, m.created_at,
+		EXISTS (SELECT 1 FROM chat_safety_interventions s WHERE s.message_id = m.id),
+		EXISTS (SELECT 1 FROM chat_crisis_flags c WHERE c.message_id = m.id),
+		(SELECT COUNT(*) FROM chat_message_flags f WHERE f.message_id = m.id),
+		COALESCE(v.action, ''), COALESCE(v.note, ''), v.reviewed_by, v.reviewed_at, v.message_id IS NULL
+	FROM chat_messages m
+	JOIN reports r ON r.id = m.report_id
+	LEFT JOIN chat_moderation_reviews v ON v.message_id = m.id`
+
+// scanFlaggedMessage reads a row selected with flaggedSelect
+func scanFlaggedMessage(row rowScanner) (*FlaggedMessage, error) {
+	message := &FlaggedMessage{}
+	var safety, crisis bool
+	var reviewedBy sql.NullInt64
+	var reviewedAt sql.NullTime
+	err := row.Scan(&message.MessageID, &message.ReportID, &message.UserID, &message.UserMessage, &message.AIResponse,
+		&message.InputMode, &message.IsDeleted, &message.CreatedAt, &safety, &crisis, &message.FlagCount,
+		&message.Action, &message.Note, &reviewedBy, &reviewedAt, &message.Open)
+	if err != nil {
+		return nil, err
+	}
+
+	message.Sources = []string{}
+	if safety {
+		message.Sources = append(message.Sources, ModerationSourceSafety)
+	}
+	if crisis {
+		message.Sources = append(message.Sources, ModerationSourceCrisis)
+	}
+	if message.FlagCount > 0 {
+		message.Sources = append(message.Sources, ModerationSourceUser)
+	}
+	if reviewedBy.Valid {
+		id := int(reviewedBy.Int64)
+		message.ReviewedBy = &id
+	}
+	if reviewedAt.Valid {
+		message.ReviewedAt = &reviewedAt.Time
+	}
+	return message, nil
+}
+
+// flaggedCondition matches messages flagged by source, or by anything when source is empty
+const flaggedCondition = `
+	((? IN ('', 'safety') AND EXISTS (SELECT 1 FROM chat_safety_interventions s WHERE s.message_id = m.id))
+	OR (? IN ('', 'crisis') AND EXISTS (SELECT 1 FROM chat_crisis_flags c WHERE c.message_id = m.id))
+	OR (? IN ('', 'user') AND EXISTS (SELECT 1 FROM chat_message_flags f WHERE f.message_id = m.id)))`
+
+// List returns a page of the moderation queue
+func (r *SQLModerationRepository) List(filter ModerationFilter) ([]*FlaggedMessage, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = 50
+	}
+
+	query := flaggedSelect + `
+		WHERE ` + flaggedCondition + ` AND (? = 0 OR r.user_id = ?)
+			AND (? = '' OR (? = 'open' AND v.message_id IS NULL) OR (? = 'reviewed' AND v.message_id IS NOT NULL))
+		ORDER BY m.created_at DESC, m.id DESC
+		LIMIT ? OFFSET ?`
+
+	rows, err := r.db.Query(query, filter.Source, filter.Source, filter.Source, filter.UserID, filter.UserID,
+		filter.Status, filter.Status, filter.Status, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []*FlaggedMessage
+	for rows.Next() {
+		message, err := scanFlaggedMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+	return messages, rows.Err()
+}
+
+// Get returns one exchange of the moderation queue
+func (r *SQLModerationRepository) Get(messageID int) (*FlaggedMessage, error) {
+	query := flaggedSelect + ` WHERE m.id = ? AND ` + flaggedCondition
+
+	message, err := scanFlaggedMessage(r.db.QueryRow(query, messageID, "", "", ""))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return message, err
+}
+
+// Review stores the decision
+func (r *SQLModerationRepository) Review(messageID, moderatorID int, action, note string) error {
+	query := `
+		INSERT INTO chat_moderation_reviews (message_id, action, note, reviewed_by, reviewed_at)
+		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT (message_id) DO UPDATE SET action = excluded.action, note = excluded.note,
+			reviewed_by = excluded.reviewed_by, reviewed_at = excluded.reviewed_at`
+
+	_, err := r.db.Exec(query, messageID, action, note, moderatorID)
+	return err
+}
+
+// Redact overwrites the content in the message, its earlier versions, and the safety and crisis records of it
+// Decision: One transaction, so removed content never survives in one copy while gone from another
+func (r *SQLModerationRepository) Redact(messageID int, question, answer bool) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkLegalHold(tx, chatMessageOwnerQuery, messageID); err != nil {
+		return err
+	}
+
+	statements := []struct {
+		apply bool
+		query string
+	}{
+		{question, `UPDATE chat_messages SET user_message = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`},
+		{answer, `UPDATE chat_messages SET ai_response = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`},
+		{question, `UPDATE chat_message_versions SET user_message = ? WHERE message_id = ?`},
+		{answer, `UPDATE chat_message_versions SET ai_response = ? WHERE message_id = ?`},
+		{question, `UPDATE chat_crisis_flags SET question = ? WHERE message_id = ?`},
+		{answer, `UPDATE chat_safety_interventions SET original_answer = ?, removed = '[]' WHERE message_id = ?`},
+	}
+	for _, statement := range statements {
+		if !statement.apply {
+			continue
+		}
+		if _, err := tx.Exec(statement.query, RedactedText, messageID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Ban records that a moderator deactivated the user, replacing any earlier record
+func (r *SQLModerationRepository) Ban(ban *UserBan) error {
+	query := `
+		INSERT INTO user_bans (user_id, reason, banned_by, created_at)
+		VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT (user_id) DO UPDATE SET reason = excluded.reason, banned_by = excluded.banned_by,
+			created_at = excluded.created_at
+		RETURNING created_at`
+
+	return r.db.QueryRow(query, ban.UserID, ban.Reason, ban.BannedBy).Scan(&ban.CreatedAt)
+}
+
+// Unban deletes the record of the user's ban
+func (r *SQLModerationRepository) Unban(userID int) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM user_bans WHERE user_id = ?`, userID)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+// GetBan returns the user's ban, or nil if they aren't banned
+func (r *SQLModerationRepository) GetBan(userID int) (*UserBan, error) {
+	ban := &UserBan{}
+	err := r.db.QueryRow(`SELECT user_id, reason, banned_by, created_at FROM user_bans WHERE user_id = ?`, userID).
+		Scan(&ban.UserID, &ban.Reason, &ban.BannedBy, &ban.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ban, nil
+}
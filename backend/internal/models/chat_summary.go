@@ -0,0 +1,72 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ChatSummary is the rolling summary of a report's older chat turns
+// Decision: ThroughMessageID marks the newest turn folded in, so summaries can be extended incrementally
+type ChatSummary struct {
+	ReportID         int       `json:"report_id" db:"report_id"`
+	Summary          string    `json:"summary" db:"summary"`
+	ThroughMessageID int       `json:"through_message_id" db:"through_message_id"`
+	UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// ChatSummaryRepository defines the interface for chat summary data operations
+type ChatSummaryRepository interface {
+	GetByReportID(reportID int) (*ChatSummary, error)
+	Save(summary *ChatSummary) error
+	Delete(reportID int) error
+}
+
+// SQLChatSummaryRepository implements ChatSummaryRepository using SQL database
+type SQLChatSummaryRepository struct {
+	db *sql.DB
+}
+
+// NewChatSummaryRepository creates a new chat summary repository
+func NewChatSummaryRepository(db *sql.DB) ChatSummaryRepository {
+	return &SQLChatSummaryRepository{db: db}
+}
+
+// GetByReportID returns the stored summary for a report, or nil if there is none
+func (r *SQLChatSummaryRepository) GetByReportID(reportID int) (*ChatSummary, error) {
+	query := `
+		SELECT report_id, summary, through_message_id, updated_at
+		FROM chat_summaries
+		WHERE report_id = ?`
+
+	summary := &ChatSummary{}
+	err := r.db.QueryRow(query, reportID).Scan(&summary.ReportID, &summary.Summary,
+		&summary.ThroughMessageID, &summary.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+// Save inserts or replaces the summary for a report
+func (r *SQLChatSummaryRepository) Save(summary *ChatSummary) error {
+	query := `
+		INSERT INTO chat_summaries (report_id, summary, through_message_id, updated_at)
+		VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT (report_id) DO UPDATE SET
+			summary = excluded.summary,
+			through_message_id = excluded.through_message_id,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING updated_at`
+
+	return r.db.QueryRow(query, summary.ReportID, summary.Summary, summary.ThroughMessageID).Scan(&summary.UpdatedAt)
+}
+
+// Delete drops a report's summary so it is rebuilt on the next question
+func (r *SQLChatSummaryRepository) Delete(reportID int) error {
+	_, err := r.db.Exec(`DELETE FROM chat_summaries WHERE report_id = ?`, reportID)
+	return err
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ClaimPackage is a stored bundle of a report prepared for an insurance reimbursement claim
+type ClaimPackage struct {
+	ID             int       `json:"id" db:"id"`
+	UserID         int       `json:"user_id" db:"user_id"`
+	ReportID       int       `json:"report_id" db:"report_id"`
+	FilePath       string    `json:"-" db:"file_path"` // Internal only - never serialize server paths
+	FileSize       int64     `json:"file_size" db:"file_size"`
+	Checksum       string    `json:"checksum" db:"checksum"`
+	Insurer        string    `json:"insurer" db:"insurer"`
+	PolicyNumber   string    `json:"policy_number" db:"policy_number"`
+	ClaimReference string    `json:"claim_reference" db:"claim_reference"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// ClaimPackageRepository defines the interface for claim package database operations
+type ClaimPackageRepository interface {
+	// Save stores the report's package, replacing any earlier one and queueing its file for the janitor
+	Save(pkg *ClaimPackage) error
+	GetByReport(reportID int) (*ClaimPackage, error)
+}
+
+// SQLClaimPackageRepository implements ClaimPackageRepository using SQL database
+type SQLClaimPackageRepository struct {
+	db *sql.DB
+}
+
+// NewClaimPackageRepository creates a new claim package repository
+func NewClaimPackageRepository(db *sql.DB) ClaimPackageRepository {
+	return &SQLClaimPackageRepository{db: db}
+}
+
+// Save replaces the report's package in one transaction
+func (r *SQLClaimPackageRepository) Save(pkg *ClaimPackage) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := dropClaimPackage(tx, pkg.ReportID); err != nil {
+		return err
+	}
+	err = tx.QueryRow(`
+		INSERT INTO claim_packages (user_id, report_id, file_path, file_size, checksum, insurer, policy_number, claim_reference)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id, created_at`,
+		pkg.UserID, pkg.ReportID, pkg.FilePath, pkg.FileSize, pkg.Checksum, pkg.Insurer, pkg.PolicyNumber, pkg.ClaimReference,
+	).Scan(&pkg.ID, &pkg.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetByReport returns the report's package, or nil if none was generated
+func (r *SQLClaimPackageRepository) GetByReport(reportID int) (*ClaimPackage, error) {
+	pkg := &ClaimPackage{}
+	err := r.db.QueryRow(`
+		SELECT id, user_id, report_id, file_path, file_size, checksum, insurer, policy_number, claim_reference, created_at
+		FROM claim_packages
+		WHERE report_id = ?`, reportID).
+		Scan(&pkg.ID, &pkg.UserID, &pkg.ReportID, &pkg.FilePath, &pkg.FileSize, &pkg.Checksum, &pkg.Insurer,
+			&pkg.PolicyNumber, &pkg.ClaimReference, &pkg.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pkg, nil
+}
+
+// dropClaimPackage removes a report's package and hands its file to the janitor
+// Decision: Called wherever a report is deleted or its contents change, so no bundle outlives what it describes
+func dropClaimPackage(tx *sql.Tx, reportID int) error {
+	if _, err := tx.Exec(`INSERT INTO file_cleanup_queue (file_path) SELECT file_path FROM claim_packages WHERE report_id = ?`, reportID); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`DELETE FROM claim_packages WHERE report_id = ?`, reportID)
+	return err
+}
@@ -0,0 +1,92 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// CrisisFlag records a chat question that matched crisis keywords
+type CrisisFlag struct {
+	ID        int       `json:"id" db:"id"`
+	UserID    int       `json:"user_id" db:"user_id"`
+	ReportID  int       `json:"report_id" db:"report_id"`
+	MessageID int       `json:"message_id" db:"message_id"`
+	Category  string    `json:"category" db:"category"`
+	Keyword   string    `json:"keyword" db:"keyword"`
+	Question  string    `json:"question" db:"question"`
+	InputMode string    `json:"input_mode" db:"input_mode"`
+	Country   string    `json:"country" db:"country"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// CrisisFlagFilter narrows a crisis flag listing; zero values match everything
+type CrisisFlagFilter struct {
+	Category string
+	UserID   int
+	Limit    int
+	Offset   int
+}
+
+// CrisisFlagRepository defines the interface for crisis flag database operations
+type CrisisFlagRepository interface {
+	Create(flag *CrisisFlag) error
+	List(filter CrisisFlagFilter) ([]*CrisisFlag, error)
+}
+
+// SQLCrisisFlagRepository implements CrisisFlagRepository using SQL database
+type SQLCrisisFlagRepository struct {
+	db *sql.DB
+}
+
+// NewCrisisFlagRepository creates a new crisis flag repository
+func NewCrisisFlagRepository(db *sql.DB) CrisisFlagRepository {
+	return &SQLCrisisFlagRepository{db: db}
+}
+
+// Create records a flag
+func (r *SQLCrisisFlagRepository) Create(flag *CrisisFlag) error {
+	if flag.InputMode == "" {
+		flag.InputMode = ChatInputText
+	}
+
+	query := `
+		INSERT INTO chat_crisis_flags (user_id, report_id, message_id, category, keyword, question, input_mode, country)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id, created_at`
+
+	row := r.db.QueryRow(query, flag.UserID, flag.ReportID, flag.MessageID, flag.Category, flag.Keyword,
+		flag.Question, flag.InputMode, flag.Country)
+	return row.Scan(&flag.ID, &flag.CreatedAt)
+}
+
+// List returns matching flags, newest first
+func (r *SQLCrisisFlagRepository) List(filter CrisisFlagFilter) ([]*CrisisFlag, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = 50
+	}
+
+	query := `
+		SELECT id, user_id, report_id, message_id, category, keyword, question, input_mode, country, created_at
+		FROM chat_crisis_flags
+		WHERE (? = '' OR category = ?) AND (? = 0 OR user_id = ?)
+		ORDER BY created_at DESC, id DESC
+		LIMIT ? OFFSET ?`
+
+	rows, err := r.db.Query(query, filter.Category, filter.Category, filter.UserID, filter.UserID, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var flags []*CrisisFlag
+	for rows.Next() {
+		flag := &CrisisFlag{}
+		if err := rows.Scan(&flag.ID, &flag.UserID, &flag.ReportID, &flag.MessageID, &flag.Category, &flag.Keyword,
+			&flag.Question, &flag.InputMode, &flag.Country, &flag.CreatedAt); err != nil {
+			return nil, err
+		}
+		flags = append(flags, flag)
+	}
+
+	return flags, rows.Err()
+}
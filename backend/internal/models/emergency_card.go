@@ -0,0 +1,81 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// EmergencyCardLink lets anyone holding its token read the user's emergency card
+// Decision: At most one per user, and only a hash of the token is stored, like share links
+type EmergencyCardLink struct {
+	UserID       int        `json:"user_id" db:"user_id"`
+	TokenHash    string     `json:"-" db:"token_hash"`
+	LastViewedAt *time.Time `json:"last_viewed_at" db:"last_viewed_at"` // Nullable
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+}
+
+// EmergencyCardLinkRepository defines the interface for emergency card link database operations
+type EmergencyCardLinkRepository interface {
+	GetByUserID(userID int) (*EmergencyCardLink, error)
+	GetByTokenHash(tokenHash string) (*EmergencyCardLink, error)
+	Replace(link *EmergencyCardLink) error
+	Delete(userID int) error
+	RecordView(userID int) error
+}
+
+// SQLEmergencyCardLinkRepository implements EmergencyCardLinkRepository using SQL database
+type SQLEmergencyCardLinkRepository struct {
+	db *sql.DB
+}
+
+// NewEmergencyCardLinkRepository creates a new emergency card link repository
+func NewEmergencyCardLinkRepository(db *sql.DB) EmergencyCardLinkRepository {
+	return &SQLEmergencyCardLinkRepository{db: db}
+}
+
+// GetByUserID returns the user's link, or nil if they have none
+func (r *SQLEmergencyCardLinkRepository) GetByUserID(userID int) (*EmergencyCardLink, error) {
+	return r.get(`SELECT user_id, token_hash, last_viewed_at, created_at FROM emergency_card_links WHERE user_id = ?`, userID)
+}
+
+// GetByTokenHash returns the link with the given token hash, or nil if there is none
+func (r *SQLEmergencyCardLinkRepository) GetByTokenHash(tokenHash string) (*EmergencyCardLink, error) {
+	return r.get(`SELECT user_id, token_hash, last_viewed_at, created_at FROM emergency_card_links WHERE token_hash = ?`, tokenHash)
+}
+
+func (r *SQLEmergencyCardLinkRepository) get(query string, arg any) (*EmergencyCardLink, error) {
+	link := &EmergencyCardLink{}
+	err := r.db.QueryRow(query, arg).Scan(&link.UserID, &link.TokenHash, &link.LastViewedAt, &link.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+// Replace stores the user's link, invalidating any earlier token
+func (r *SQLEmergencyCardLinkRepository) Replace(link *EmergencyCardLink) error {
+	query := `
+		INSERT INTO emergency_card_links (user_id, token_hash)
+		VALUES (?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET
+			token_hash = excluded.token_hash, last_viewed_at = NULL, created_at = CURRENT_TIMESTAMP
+		RETURNING created_at`
+
+	link.LastViewedAt = nil
+	return r.db.QueryRow(query, link.UserID, link.TokenHash).Scan(&link.CreatedAt)
+}
+
+// Delete removes the user's link
+func (r *SQLEmergencyCardLinkRepository) Delete(userID int) error {
+	_, err := r.db.Exec(`DELETE FROM emergency_card_links WHERE user_id = ?`, userID)
+	return err
+}
+
+// RecordView notes that the link was opened
+func (r *SQLEmergencyCardLinkRepository) RecordView(userID int) error {
+	_, err := r.db.Exec(`UPDATE emergency_card_links SET last_viewed_at = CURRENT_TIMESTAMP WHERE user_id = ?`, userID)
+	return err
+}
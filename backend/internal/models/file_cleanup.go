@@ -0,0 +1,78 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// FileCleanup is a stored file waiting to be removed from disk
+type FileCleanup struct {
+	ID        int       `json:"id" db:"id"`
+	FilePath  string    `json:"-" db:"file_path"`
+	Attempts  int       `json:"attempts" db:"attempts"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// FileCleanupRepository defines the interface for the file cleanup queue
+type FileCleanupRepository interface {
+	Enqueue(filePath string) error
+	ListPending(limit, maxAttempts int) ([]*FileCleanup, error)
+	Complete(id int) error
+	RecordFailure(id int) error
+}
+
+// SQLFileCleanupRepository implements FileCleanupRepository using SQL database
+type SQLFileCleanupRepository struct {
+	db *sql.DB
+}
+
+// NewFileCleanupRepository creates a new file cleanup repository
+func NewFileCleanupRepository(db *sql.DB) FileCleanupRepository {
+	return &SQLFileCleanupRepository{db: db}
+}
+
+// Enqueue schedules a file for removal
+func (r *SQLFileCleanupRepository) Enqueue(filePath string) error {
+	_, err := r.db.Exec(`INSERT INTO file_cleanup_queue (file_path) VALUES (?)`, filePath)
+	return err
+}
+
+// ListPending returns queued files that haven't exhausted their attempts, oldest first
+// Decision: Exhausted entries stay in the table for operators to inspect
+func (r *SQLFileCleanupRepository) ListPending(limit, maxAttempts int) ([]*FileCleanup, error) {
+	query := `
+		SELECT id, file_path, attempts, created_at
+		FROM file_cleanup_queue
+		WHERE attempts < ?
+		ORDER BY created_at ASC, id ASC
+		LIMIT ?`
+
+	rows, err := r.db.Query(query, maxAttempts, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*FileCleanup
+	for rows.Next() {
+		entry := &FileCleanup{}
+		if err := rows.Scan(&entry.ID, &entry.FilePath, &entry.Attempts, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// Complete removes an entry once its file is gone
+func (r *SQLFileCleanupRepository) Complete(id int) error {
+	_, err := r.db.Exec(`DELETE FROM file_cleanup_queue WHERE id = ?`, id)
+	return err
+}
+
+// RecordFailure bumps the attempt counter so a stuck file doesn't block the queue silently
+func (r *SQLFileCleanupRepository) RecordFailure(id int) error {
+	_, err := r.db.Exec(`UPDATE file_cleanup_queue SET attempts = attempts + 1 WHERE id = ?`, id)
+	return err
+}
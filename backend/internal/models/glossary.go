@@ -0,0 +1,76 @@
+package models
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// Glossary entry sources
+const (
+	GlossarySourceBuiltin = "builtin"
+	GlossarySourceAI      = "ai"
+)
+
+// GlossaryTerm is a plain-language definition of a medical term
+type GlossaryTerm struct {
+	ID         int       `json:"id" db:"id"`
+	Key        string    `json:"key" db:"term_key"` // Normalized lookup key, see GlossaryKey
+	Term       string    `json:"term" db:"term"`    // Spelling from the first lookup
+	Definition string    `json:"definition" db:"definition"`
+	Source     string    `json:"source" db:"source"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+// GlossaryKey normalizes a term so "HDL", " hdl " and "Hdl" share one entry
+func GlossaryKey(term string) string {
+	return strings.ToLower(strings.Join(strings.Fields(term), " "))
+}
+
+// GlossaryRepository defines the interface for glossary database operations
+type GlossaryRepository interface {
+	GetByKey(key string) (*GlossaryTerm, error)
+	Create(term *GlossaryTerm) error
+}
+
+// SQLGlossaryRepository implements GlossaryRepository using SQL database
+type SQLGlossaryRepository struct {
+	db *sql.DB
+}
+
+// NewGlossaryRepository creates a new glossary repository
+func NewGlossaryRepository(db *sql.DB) GlossaryRepository {
+	return &SQLGlossaryRepository{db: db}
+}
+
+// GetByKey returns the entry for a normalized key, or nil when none is stored
+func (r *SQLGlossaryRepository) GetByKey(key string) (*GlossaryTerm, error) {
+	term := &GlossaryTerm{}
+	query := `
+		SELECT id, term_key, term, definition, source, created_at
+		FROM glossary_terms
+		WHERE term_key = ?`
+
+	err := r.db.QueryRow(query, key).Scan(&term.ID, &term.Key, &term.Term, &term.Definition, &term.Source, &term.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return term, nil
+}
+
+// Create stores an entry; when the key already exists, term is filled with the stored entry instead
+// Decision: Two concurrent first lookups both generate a definition; the first one stored wins
+func (r *SQLGlossaryRepository) Create(term *GlossaryTerm) error {
+	query := `
+		INSERT INTO glossary_terms (term_key, term, definition, source)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(term_key) DO UPDATE SET term_key = excluded.term_key
+		RETURNING id, term, definition, source, created_at`
+
+	row := r.db.QueryRow(query, term.Key, term.Term, term.Definition, term.Source)
+	return row.Scan(&term.ID, &term.Term, &term.Definition, &term.Source, &term.CreatedAt)
+}
@@ -0,0 +1,142 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// Sexes a profile may record
+const (
+	SexMale   = "male"
+	SexFemale = "female"
+	SexOther  = "other"
+)
+
+// BloodGroups are the ABO/RhD groups a profile may record
+var BloodGroups = []string{"A+", "A-", "B+", "B-", "AB+", "AB-", "O+", "O-"}
+
+// HealthProfile holds optional health details used to personalize analyses and chat
+// Decision: A birth date rather than an age so the profile doesn't go stale
+type HealthProfile struct {
+	UserID      int        `json:"user_id" db:"user_id"`
+	DateOfBirth *time.Time `json:"date_of_birth" db:"date_of_birth"` // Nullable
+	Sex         string     `json:"sex" db:"sex"`                     // Empty, or one of the Sex* constants
+	HeightCm    *float64   `json:"height_cm" db:"height_cm"`         // Nullable
+	WeightKg    *float64   `json:"weight_kg" db:"weight_kg"`         // Nullable
+	Conditions  []string   `json:"conditions" db:"conditions"`
+	Allergies   []string   `json:"allergies" db:"allergies"`
+	Medications []string   `json:"medications" db:"medications"`
+	BloodGroup  string     `json:"blood_group" db:"blood_group"` // Empty, or one of BloodGroups
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Age returns the age in whole years at now, or nil without a birth date
+func (p *HealthProfile) Age(now time.Time) *int {
+	if p == nil || p.DateOfBirth == nil {
+		return nil
+	}
+	dob := *p.DateOfBirth
+	age := now.Year() - dob.Year()
+	if now.Month() < dob.Month() || (now.Month() == dob.Month() && now.Day() < dob.Day()) {
+		age--
+	}
+	return &age
+}
+
+// IsEmpty reports whether the profile records nothing
+func (p *HealthProfile) IsEmpty() bool {
+	return p == nil || (p.DateOfBirth == nil && p.Sex == "" && p.HeightCm == nil && p.WeightKg == nil &&
+		len(p.Conditions) == 0 && len(p.Allergies) == 0 && len(p.Medications) == 0 && p.BloodGroup == "")
+}
+
+// HealthProfileRepository defines the interface for user profile database operations
+type HealthProfileRepository interface {
+	GetByUserID(userID int) (*HealthProfile, error)
+	Upsert(profile *HealthProfile) error
+	Delete(userID int) error
+}
+
+// SQLHealthProfileRepository implements HealthProfileRepository using SQL database
+type SQLHealthProfileRepository struct {
+	db *sql.DB
+}
+
+// NewHealthProfileRepository creates a new user profile repository
+func NewHealthProfileRepository(db *sql.DB) HealthProfileRepository {
+	return &SQLHealthProfileRepository{db: db}
+}
+
+// GetByUserID returns a user's profile, or nil if none was saved
+func (r *SQLHealthProfileRepository) GetByUserID(userID int) (*HealthProfile, error) {
+	profile := &HealthProfile{}
+	var sex, bloodGroup sql.NullString
+	var conditions, allergies, medications string
+	err := r.db.QueryRow(`
+		SELECT user_id, date_of_birth, sex, height_cm, weight_kg, conditions, allergies, medications, blood_group, updated_at
+		FROM health_profiles
+		WHERE user_id = ?`, userID).Scan(&profile.UserID, &profile.DateOfBirth, &sex, &profile.HeightCm,
+		&profile.WeightKg, &conditions, &allergies, &medications, &bloodGroup, &profile.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	profile.Sex = sex.String
+	profile.BloodGroup = bloodGroup.String
+	if err := json.Unmarshal([]byte(conditions), &profile.Conditions); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(allergies), &profile.Allergies); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(medications), &profile.Medications); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// Upsert replaces a user's profile
+func (r *SQLHealthProfileRepository) Upsert(profile *HealthProfile) error {
+	conditions, err := json.Marshal(nonNilStrings(profile.Conditions))
+	if err != nil {
+		return err
+	}
+	allergies, err := json.Marshal(nonNilStrings(profile.Allergies))
+	if err != nil {
+		return err
+	}
+	medications, err := json.Marshal(nonNilStrings(profile.Medications))
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO health_profiles (user_id, date_of_birth, sex, height_cm, weight_kg, conditions, allergies,
+			medications, blood_group)
+		VALUES (?, ?, NULLIF(?, ''), ?, ?, ?, ?, ?, NULLIF(?, ''))
+		ON CONFLICT (user_id) DO UPDATE SET
+			date_of_birth = excluded.date_of_birth, sex = excluded.sex, height_cm = excluded.height_cm,
+			weight_kg = excluded.weight_kg, conditions = excluded.conditions, allergies = excluded.allergies,
+			medications = excluded.medications, blood_group = excluded.blood_group, updated_at = CURRENT_TIMESTAMP
+		RETURNING updated_at`
+
+	row := r.db.QueryRow(query, profile.UserID, profile.DateOfBirth, profile.Sex, profile.HeightCm, profile.WeightKg,
+		string(conditions), string(allergies), string(medications), profile.BloodGroup)
+	return row.Scan(&profile.UpdatedAt)
+}
+
+// Delete removes a user's profile, unless they are under a legal hold
+func (r *SQLHealthProfileRepository) Delete(userID int) error {
+	return execUnlessHeld(r.db, healthProfileOwnerQuery, `DELETE FROM health_profiles WHERE user_id = ?`, userID)
+}
+
+// nonNilStrings keeps nil slices from being stored as JSON null
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
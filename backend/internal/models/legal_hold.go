@@ -0,0 +1,153 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ErrLegalHold is returned when a change would delete or overwrite data of a user under a legal hold
+var ErrLegalHold = errors.New("data is under a legal hold")
+
+// LegalHold keeps a user's reports and related data from being deleted or altered, for a medico-legal request
+type LegalHold struct {
+	UserID    int       `json:"user_id" db:"user_id"`
+	Reason    string    `json:"reason" db:"reason"`
+	Reference string    `json:"reference" db:"reference"` // Case or request number, when there is one
+	PlacedBy  int       `json:"placed_by" db:"placed_by"`
+	PlacedAt  time.Time `json:"placed_at" db:"placed_at"`
+}
+
+// LegalHoldRepository defines the interface for legal hold database operations
+// Decision: Holds are enforced by the repositories that delete or overwrite a user's data, inside their own
+// transactions, so no service or handler path can skip the check
+type LegalHoldRepository interface {
+	// Place puts the user's data on hold, replacing the reason and reference of any earlier hold; it returns
+	// sql.ErrNoRows if there is no such user, active or not
+	Place(hold *LegalHold) error
+	// Release lifts the user's hold, reporting whether there was one
+	Release(userID int) (bool, error)
+	// Get returns the user's hold, or nil if their data isn't held
+	Get(userID int) (*LegalHold, error)
+	// List returns every hold, newest first
+	List() ([]*LegalHold, error)
+}
+
+// SQLLegalHoldRepository implements LegalHoldRepository using SQL database
+type SQLLegalHoldRepository struct {
+	db *sql.DB
+}
+
+// NewLegalHoldRepository creates a new legal hold repository
+func NewLegalHoldRepository(db *sql.DB) LegalHoldRepository {
+	return &SQLLegalHoldRepository{db: db}
+}
+
+// Place records the hold; placing it again keeps the original placed_at, since that is when the data was frozen
+func (r *SQLLegalHoldRepository) Place(hold *LegalHold) error {
+	query := `
+		INSERT INTO legal_holds (user_id, reason, reference, placed_by, placed_at)
+		SELECT id, ?, ?, ?, CURRENT_TIMESTAMP FROM users WHERE id = ?
+		ON CONFLICT (user_id) DO UPDATE SET reason = excluded.reason, reference = excluded.reference,
+			placed_by = excluded.placed_by
+		RETURNING placed_by, placed_at`
+
+	return r.db.QueryRow(query, hold.Reason, hold.Reference, hold.PlacedBy, hold.UserID).Scan(&hold.PlacedBy, &hold.PlacedAt)
+}
+
+// Release deletes the user's hold
+func (r *SQLLegalHoldRepository) Release(userID int) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM legal_holds WHERE user_id = ?`, userID)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+// Get returns the user's hold, or nil if there is none
+func (r *SQLLegalHoldRepository) Get(userID int) (*LegalHold, error) {
+	hold := &LegalHold{}
+	err := r.db.QueryRow(`SELECT user_id, reason, reference, placed_by, placed_at FROM legal_holds WHERE user_id = ?`, userID).
+		Scan(&hold.UserID, &hold.Reason, &hold.Reference, &hold.PlacedBy, &hold.PlacedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return hold, nil
+}
+
+// List returns every hold
+func (r *SQLLegalHoldRepository) List() ([]*LegalHold, error) {
+	rows, err := r.db.Query(`
+		SELECT user_id, reason, reference, placed_by, placed_at
+		FROM legal_holds
+		ORDER BY placed_at DESC, user_id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	holds := []*LegalHold{}
+	for rows.Next() {
+		hold := &LegalHold{}
+		if err := rows.Scan(&hold.UserID, &hold.Reason, &hold.Reference, &hold.PlacedBy, &hold.PlacedAt); err != nil {
+			return nil, err
+		}
+		holds = append(holds, hold)
+	}
+	return holds, rows.Err()
+}
+
+// Queries selecting the owner of a row, for checkLegalHold
+const (
+	reportOwnerQuery         = `SELECT user_id FROM reports WHERE id = ?`
+	reportAnalysisOwnerQuery = `SELECT user_id FROM reports WHERE id = ? AND COALESCE(simplified_summary, '') <> ''` // New uploads are still analyzed
+	chatMessageOwnerQuery    = `SELECT r.user_id FROM chat_messages m JOIN reports r ON r.id = m.report_id WHERE m.id = ?`
+	prescriptionOwnerQuery   = `SELECT user_id FROM prescriptions WHERE id = ?`
+	mergedAnalysisOwnerQuery = `SELECT user_id FROM merged_analyses WHERE id = ?`
+	healthProfileOwnerQuery  = `SELECT ?` // Profiles are keyed by their owner
+)
+
+// Subqueries selecting what bulk purges must leave alone
+const (
+	heldUsersQuery   = `SELECT user_id FROM legal_holds`
+	heldReportsQuery = `SELECT r.id FROM reports r JOIN legal_holds h ON h.user_id = r.user_id`
+)
+
+// checkLegalHold returns ErrLegalHold if the owner ownerQuery selects is under a legal hold; a missing row isn't held
+// Decision: Run inside the transaction making the change, so a hold placed concurrently either commits first and is
+// seen, or makes SQLite refuse the change as a write against a stale snapshot
+func checkLegalHold(tx *sql.Tx, ownerQuery string, id int) error {
+	var held bool
+	err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM legal_holds WHERE user_id IN (`+ownerQuery+`))`, id).Scan(&held)
+	if err != nil {
+		return err
+	}
+	if held {
+		return ErrLegalHold
+	}
+	return nil
+}
+
+// execUnlessHeld runs query with id in a transaction, unless the owner ownerQuery selects is under a legal hold
+func execUnlessHeld(db *sql.DB, ownerQuery, query string, id int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkLegalHold(tx, ownerQuery, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(query, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
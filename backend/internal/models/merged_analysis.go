@@ -0,0 +1,158 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// MergedAnalysis is one assessment written across several of a user's reports
+// Decision: Stored apart from reports so the sources keep their own analyses and chats
+type MergedAnalysis struct {
+	ID            int             `json:"id" db:"id"`
+	UserID        int             `json:"user_id" db:"user_id"`
+	Title         string          `json:"title" db:"title"`
+	Analysis      string          `json:"analysis" db:"analysis"` // AnalysisResult JSON
+	PromptVersion string          `json:"prompt_version" db:"prompt_version"`
+	ReadingLevel  string          `json:"reading_level" db:"reading_level"`
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+	Sources       []*MergedSource `json:"sources"`
+}
+
+// MergedSource links a merged analysis back to a report it was built from
+type MergedSource struct {
+	ReportID   *int       `json:"report_id" db:"report_id"` // Nullable; nil once the report is deleted
+	Label      string     `json:"label" db:"label"`
+	ReportDate *time.Time `json:"report_date" db:"report_date"` // Nullable
+}
+
+// MergedAnalysisRepository defines the interface for merged analysis database operations
+type MergedAnalysisRepository interface {
+	Create(analysis *MergedAnalysis) error
+	GetByID(id int) (*MergedAnalysis, error)
+	ListByUser(userID, limit, offset int) ([]*MergedAnalysis, error)
+	Delete(id int) error
+}
+
+// SQLMergedAnalysisRepository implements MergedAnalysisRepository using SQL database
+type SQLMergedAnalysisRepository struct {
+	db *sql.DB
+}
+
+// NewMergedAnalysisRepository creates a new merged analysis repository
+func NewMergedAnalysisRepository(db *sql.DB) MergedAnalysisRepository {
+	return &SQLMergedAnalysisRepository{db: db}
+}
+
+// Create stores a merged analysis together with its sources
+func (r *SQLMergedAnalysisRepository) Create(analysis *MergedAnalysis) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	row := tx.QueryRow(`
+		INSERT INTO merged_analyses (user_id, title, analysis, prompt_version, reading_level)
+		VALUES (?, ?, ?, NULLIF(?, ''), ?)
+		RETURNING id, created_at`,
+		analysis.UserID, analysis.Title, analysis.Analysis, analysis.PromptVersion, analysis.ReadingLevel)
+	if err := row.Scan(&analysis.ID, &analysis.CreatedAt); err != nil {
+		return err
+	}
+
+	for i, source := range analysis.Sources {
+		_, err := tx.Exec(`
+			INSERT INTO merged_analysis_sources (merged_analysis_id, position, report_id, label, report_date)
+			VALUES (?, ?, ?, ?, ?)`,
+			analysis.ID, i, source.ReportID, source.Label, source.ReportDate)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetByID retrieves a merged analysis with its sources
+func (r *SQLMergedAnalysisRepository) GetByID(id int) (*MergedAnalysis, error) {
+	analysis := &MergedAnalysis{}
+	err := r.db.QueryRow(`
+		SELECT id, user_id, title, analysis, COALESCE(prompt_version, ''), reading_level, created_at
+		FROM merged_analyses
+		WHERE id = ?`, id).Scan(&analysis.ID, &analysis.UserID, &analysis.Title, &analysis.Analysis,
+		&analysis.PromptVersion, &analysis.ReadingLevel, &analysis.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if analysis.Sources, err = r.getSources(id); err != nil {
+		return nil, err
+	}
+	return analysis, nil
+}
+
+// ListByUser returns a user's merged analyses, newest first, with their sources
+func (r *SQLMergedAnalysisRepository) ListByUser(userID, limit, offset int) ([]*MergedAnalysis, error) {
+	rows, err := r.db.Query(`
+		SELECT id, user_id, title, analysis, COALESCE(prompt_version, ''), reading_level, created_at
+		FROM merged_analyses
+		WHERE user_id = ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT ? OFFSET ?`, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var analyses []*MergedAnalysis
+	for rows.Next() {
+		analysis := &MergedAnalysis{}
+		if err := rows.Scan(&analysis.ID, &analysis.UserID, &analysis.Title, &analysis.Analysis,
+			&analysis.PromptVersion, &analysis.ReadingLevel, &analysis.CreatedAt); err != nil {
+			return nil, err
+		}
+		analyses = append(analyses, analysis)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, analysis := range analyses {
+		if analysis.Sources, err = r.getSources(analysis.ID); err != nil {
+			return nil, err
+		}
+	}
+	return analyses, nil
+}
+
+// Delete removes a merged analysis, unless its owner is under a legal hold; its source reports are untouched
+func (r *SQLMergedAnalysisRepository) Delete(id int) error {
+	return execUnlessHeld(r.db, mergedAnalysisOwnerQuery, `DELETE FROM merged_analyses WHERE id = ?`, id)
+}
+
+// getSources returns a merged analysis's sources in their original order
+func (r *SQLMergedAnalysisRepository) getSources(mergedAnalysisID int) ([]*MergedSource, error) {
+	rows, err := r.db.Query(`
+		SELECT report_id, label, report_date
+		FROM merged_analysis_sources
+		WHERE merged_analysis_id = ?
+		ORDER BY position ASC`, mergedAnalysisID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sources []*MergedSource
+	for rows.Next() {
+		source := &MergedSource{}
+		if err := rows.Scan(&source.ReportID, &source.Label, &source.ReportDate); err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+
+	return sources, rows.Err()
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Notification kinds
+const (
+	NotificationImpersonation   = "impersonation"
+	NotificationShareLinkLocked = "share_link_locked"
+	NotificationAnalysisReady   = "analysis_ready"
+	NotificationAppointment     = "appointment"
+)
+
+// Notification is an in-app message for a user
+type Notification struct {
+	ID        int        `json:"id" db:"id"`
+	UserID    int        `json:"user_id" db:"user_id"`
+	Kind      string     `json:"kind" db:"kind"`
+	Message   string     `json:"message" db:"message"`
+	ReadAt    *time.Time `json:"read_at" db:"read_at"` // Nullable
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// NotificationRepository defines the interface for notification database operations
+type NotificationRepository interface {
+	Create(notification *Notification) error
+	ListByUser(userID int, unreadOnly bool, limit, offset int) ([]*Notification, error)
+	MarkRead(id, userID int) (bool, error)
+}
+
+// SQLNotificationRepository implements NotificationRepository using SQL database
+type SQLNotificationRepository struct {
+	db *sql.DB
+}
+
+// NewNotificationRepository creates a new notification repository
+func NewNotificationRepository(db *sql.DB) NotificationRepository {
+	return &SQLNotificationRepository{db: db}
+}
+
+// Create stores a new unread notification
+func (r *SQLNotificationRepository) Create(notification *Notification) error {
+	query := `
+		INSERT INTO notifications (user_id, kind, message)
+		VALUES (?, ?, ?)
+		RETURNING id, created_at`
+
+	row := r.db.QueryRow(query, notification.UserID, notification.Kind, notification.Message)
+	return row.Scan(&notification.ID, &notification.CreatedAt)
+}
+
+// ListByUser returns the user's notifications, newest first
+func (r *SQLNotificationRepository) ListByUser(userID int, unreadOnly bool, limit, offset int) ([]*Notification, error) {
+	query := `
+		SELECT id, user_id, kind, message, read_at, created_at
+		FROM notifications
+		WHERE user_id = ? AND (? = FALSE OR read_at IS NULL)
+		ORDER BY created_at DESC, id DESC
+		LIMIT ? OFFSET ?`
+
+	rows, err := r.db.Query(query, userID, unreadOnly, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notifications []*Notification
+	for rows.Next() {
+		n := &Notification{}
+		if err := rows.Scan(&n.ID, &n.UserID, &n.Kind, &n.Message, &n.ReadAt, &n.CreatedAt); err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, n)
+	}
+
+	return notifications, rows.Err()
+}
+
+// MarkRead marks a notification as read, reporting false if it isn't the user's or is already read
+func (r *SQLNotificationRepository) MarkRead(id, userID int) (bool, error) {
+	query := `UPDATE notifications SET read_at = CURRENT_TIMESTAMP WHERE id = ? AND user_id = ? AND read_at IS NULL`
+
+	result, err := r.db.Exec(query, id, userID)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
@@ -0,0 +1,187 @@
+package models
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// Organization member roles
+const (
+	OrgRoleMember   = "member"
+	OrgRoleAdmin    = "admin"     // May change the organization's branding
+	OrgRoleDoctor   = "doctor"    // May be assigned patients and read the reports they share
+	OrgRoleCareTeam = "care_team" // Nurses and coordinators; read reports shared with the care team
+)
+
+// IsClinicianRole reports whether members with role may be shown patients' reports
+func IsClinicianRole(role string) bool {
+	return role == OrgRoleDoctor || role == OrgRoleCareTeam
+}
+
+// Organization is a clinic or hospital whose branding is applied to its members' exports
+type Organization struct {
+	ID          int       `json:"id" db:"id"`
+	Name        string    `json:"name" db:"name"`
+	FooterText  string    `json:"footer_text" db:"footer_text"`
+	ContactInfo string    `json:"contact_info" db:"contact_info"`
+	Logo        []byte    `json:"-" db:"logo"` // JPEG; nil when no logo was uploaded
+	LogoWidth   int       `json:"logo_width" db:"logo_width"`
+	LogoHeight  int       `json:"logo_height" db:"logo_height"`
+	Sections    []string  `json:"sections" db:"sections"` // Export sections in print order; empty uses the default
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// OrganizationMember is a user's place in their organization
+type OrganizationMember struct {
+	UserID           int    `json:"user_id" db:"user_id"`
+	OrganizationID   int    `json:"organization_id" db:"organization_id"`
+	Role             string `json:"role" db:"role"`
+	AssignedDoctorID *int   `json:"assigned_doctor_id" db:"assigned_doctor_id"` // Nullable; the patient's doctor
+}
+
+// OrganizationRepository defines the interface for organization database operations
+type OrganizationRepository interface {
+	Create(org *Organization) error
+	GetByID(id int) (*Organization, error)
+	GetForUser(userID int) (*Organization, string, error)
+	UpdateBranding(org *Organization) error
+	SetLogo(id int, logo []byte, width, height int) error
+	AddMember(orgID, userID int, role string) error
+	// GetMembership returns the user's place in their organization, or nil if they have none
+	GetMembership(userID int) (*OrganizationMember, error)
+	// AssignDoctor sets the patient's doctor; nil removes it
+	AssignDoctor(patientID int, doctorID *int) error
+}
+
+// SQLOrganizationRepository implements OrganizationRepository using SQL database
+type SQLOrganizationRepository struct {
+	db *sql.DB
+}
+
+// NewOrganizationRepository creates a new organization repository
+func NewOrganizationRepository(db *sql.DB) OrganizationRepository {
+	return &SQLOrganizationRepository{db: db}
+}
+
+const organizationColumns = `o.id, o.name, o.footer_text, o.contact_info, o.logo, o.logo_width, o.logo_height,
+	o.sections, o.created_at, o.updated_at`
+
+// scanOrganization reads an organization selected with organizationColumns, plus any extra destinations
+func scanOrganization(row rowScanner, extra ...any) (*Organization, error) {
+	org := &Organization{}
+	var sections string
+	dest := append([]any{&org.ID, &org.Name, &org.FooterText, &org.ContactInfo, &org.Logo, &org.LogoWidth,
+		&org.LogoHeight, &sections, &org.CreatedAt, &org.UpdatedAt}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	if sections != "" {
+		org.Sections = strings.Split(sections, ",")
+	}
+	return org, nil
+}
+
+// Create inserts a new organization with default branding
+func (r *SQLOrganizationRepository) Create(org *Organization) error {
+	query := `
+		INSERT INTO organizations (name, footer_text, contact_info, sections)
+		VALUES (?, ?, ?, ?)
+		RETURNING id, created_at, updated_at`
+
+	row := r.db.QueryRow(query, org.Name, org.FooterText, org.ContactInfo, strings.Join(org.Sections, ","))
+	return row.Scan(&org.ID, &org.CreatedAt, &org.UpdatedAt)
+}
+
+// GetByID retrieves an organization by its ID
+func (r *SQLOrganizationRepository) GetByID(id int) (*Organization, error) {
+	query := `SELECT ` + organizationColumns + ` FROM organizations o WHERE o.id = ?`
+
+	org, err := scanOrganization(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return org, err
+}
+
+// GetForUser returns the user's organization and their role in it, or nil if they have none
+func (r *SQLOrganizationRepository) GetForUser(userID int) (*Organization, string, error) {
+	query := `
+		SELECT ` + organizationColumns + `, m.role
+		FROM organization_members m
+		JOIN organizations o ON o.id = m.organization_id
+		WHERE m.user_id = ?`
+
+	var role string
+	org, err := scanOrganization(r.db.QueryRow(query, userID), &role)
+	if err == sql.ErrNoRows {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return org, role, nil
+}
+
+// UpdateBranding saves the organization's name, footer, contact details, and sections
+func (r *SQLOrganizationRepository) UpdateBranding(org *Organization) error {
+	query := `
+		UPDATE organizations
+		SET name = ?, footer_text = ?, contact_info = ?, sections = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+		RETURNING updated_at`
+
+	row := r.db.QueryRow(query, org.Name, org.FooterText, org.ContactInfo, strings.Join(org.Sections, ","), org.ID)
+	return row.Scan(&org.UpdatedAt)
+}
+
+// SetLogo replaces the organization's logo; a nil logo removes it
+func (r *SQLOrganizationRepository) SetLogo(id int, logo []byte, width, height int) error {
+	query := `
+		UPDATE organizations
+		SET logo = ?, logo_width = ?, logo_height = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`
+
+	_, err := r.db.Exec(query, logo, width, height, id)
+	return err
+}
+
+// AddMember puts a user in an organization, moving them from any previous one
+// Decision: A patient moving to another organization loses their assigned doctor, who belongs to the old one
+func (r *SQLOrganizationRepository) AddMember(orgID, userID int, role string) error {
+	query := `
+		INSERT INTO organization_members (user_id, organization_id, role)
+		VALUES (?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET organization_id = excluded.organization_id, role = excluded.role,
+			assigned_doctor_id = CASE WHEN organization_id = excluded.organization_id THEN assigned_doctor_id END`
+
+	_, err := r.db.Exec(query, userID, orgID, role)
+	return err
+}
+
+// GetMembership returns the user's organization, role, and assigned doctor
+func (r *SQLOrganizationRepository) GetMembership(userID int) (*OrganizationMember, error) {
+	query := `SELECT user_id, organization_id, role, assigned_doctor_id FROM organization_members WHERE user_id = ?`
+
+	member := &OrganizationMember{}
+	var doctorID sql.NullInt64
+	err := r.db.QueryRow(query, userID).Scan(&member.UserID, &member.OrganizationID, &member.Role, &doctorID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if doctorID.Valid {
+		id := int(doctorID.Int64)
+		member.AssignedDoctorID = &id
+	}
+	return member, nil
+}
+
+// AssignDoctor records the doctor looking after a patient
+func (r *SQLOrganizationRepository) AssignDoctor(patientID int, doctorID *int) error {
+	_, err := r.db.Exec(`UPDATE organization_members SET assigned_doctor_id = ? WHERE user_id = ?`, doctorID, patientID)
+	return err
+}
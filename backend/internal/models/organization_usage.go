@@ -0,0 +1,122 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// OrganizationUsageMonthLayout formats the calendar month usage is counted in
+const OrganizationUsageMonthLayout = "2006-01"
+
+// OrganizationUsage is what one organization used in one calendar month, the basis of its invoice
+type OrganizationUsage struct {
+	OrganizationID   int        `json:"organization_id" db:"organization_id"`
+	OrganizationName string     `json:"organization_name"`
+	Month            string     `json:"month" db:"month"` // YYYY-MM, UTC
+	ReportsProcessed int        `json:"reports_processed" db:"reports_processed"`
+	AICalls          int        `json:"ai_calls" db:"ai_calls"`
+	AIInputTokens    int64      `json:"ai_input_tokens" db:"ai_input_tokens"`
+	AIOutputTokens   int64      `json:"ai_output_tokens" db:"ai_output_tokens"`
+	StorageBytes     int64      `json:"storage_bytes" db:"storage_bytes"` // Peak bytes of report files held by members
+	UpdatedAt        *time.Time `json:"updated_at" db:"updated_at"`       // Nil when nothing was recorded that month
+}
+
+// OrganizationUsageDelta is usage to add to the month's totals of the organization a user belongs to
+type OrganizationUsageDelta struct {
+	ReportsProcessed int
+	AICalls          int
+	AIInputTokens    int64
+	AIOutputTokens   int64
+}
+
+// OrganizationUsageRepository defines the interface for organization usage database operations
+type OrganizationUsageRepository interface {
+	// Add counts usage against the user's organization for the month; users outside any organization count nowhere
+	Add(userID int, month string, delta OrganizationUsageDelta) error
+	// RecordStorage measures the report files every organization's members hold, keeping each month's peak
+	RecordStorage(month string) error
+	// ListMonth returns the month's usage of every organization, or of one when organizationID is set, by name;
+	// organizations without usage are listed with zeros
+	ListMonth(month string, organizationID int) ([]*OrganizationUsage, error)
+}
+
+// SQLOrganizationUsageRepository implements OrganizationUsageRepository using SQL database
+type SQLOrganizationUsageRepository struct {
+	db *sql.DB
+}
+
+// NewOrganizationUsageRepository creates a new organization usage repository
+func NewOrganizationUsageRepository(db *sql.DB) OrganizationUsageRepository {
+	return &SQLOrganizationUsageRepository{db: db}
+}
+
+// Add adds usage to the totals of the user's organization
+// Decision: The membership is resolved as the usage happens, so a patient moving to another clinic
+// doesn't take their past usage with them
+func (r *SQLOrganizationUsageRepository) Add(userID int, month string, delta OrganizationUsageDelta) error {
+	query := `
+		INSERT INTO organization_usage (organization_id, month, reports_processed, ai_calls, ai_input_tokens, ai_output_tokens)
+		SELECT organization_id, ?, ?, ?, ?, ?
+		FROM organization_members
+		WHERE user_id = ?
+		ON CONFLICT (organization_id, month) DO UPDATE SET
+			reports_processed = reports_processed + excluded.reports_processed,
+			ai_calls = ai_calls + excluded.ai_calls,
+			ai_input_tokens = ai_input_tokens + excluded.ai_input_tokens,
+			ai_output_tokens = ai_output_tokens + excluded.ai_output_tokens,
+			updated_at = CURRENT_TIMESTAMP`
+
+	_, err := r.db.Exec(query, month, delta.ReportsProcessed, delta.AICalls, delta.AIInputTokens, delta.AIOutputTokens, userID)
+	return err
+}
+
+// RecordStorage raises each organization's storage for the month to what its members hold now, if that is more
+// Decision: Multi-part reports hold part 1 on the report row and the rest in report_parts, so both are summed
+func (r *SQLOrganizationUsageRepository) RecordStorage(month string) error {
+	query := `
+		INSERT INTO organization_usage (organization_id, month, storage_bytes)
+		SELECT o.id, ?,
+			COALESCE((SELECT SUM(rp.file_size) FROM reports rp
+				JOIN organization_members m ON m.user_id = rp.user_id
+				WHERE m.organization_id = o.id), 0) +
+			COALESCE((SELECT SUM(p.file_size) FROM report_parts p
+				JOIN reports rp ON rp.id = p.report_id
+				JOIN organization_members m ON m.user_id = rp.user_id
+				WHERE m.organization_id = o.id), 0)
+		FROM organizations o
+		WHERE TRUE
+		ON CONFLICT (organization_id, month) DO UPDATE SET
+			storage_bytes = MAX(storage_bytes, excluded.storage_bytes),
+			updated_at = CASE WHEN excluded.storage_bytes > storage_bytes THEN CURRENT_TIMESTAMP ELSE updated_at END`
+
+	_, err := r.db.Exec(query, month)
+	return err
+}
+
+// ListMonth returns the month's usage per organization
+func (r *SQLOrganizationUsageRepository) ListMonth(month string, organizationID int) ([]*OrganizationUsage, error) {
+	query := `
+		SELECT o.id, o.name, COALESCE(u.reports_processed, 0), COALESCE(u.ai_calls, 0), COALESCE(u.ai_input_tokens, 0),
+		       COALESCE(u.ai_output_tokens, 0), COALESCE(u.storage_bytes, 0), u.updated_at
+		FROM organizations o
+		LEFT JOIN organization_usage u ON u.organization_id = o.id AND u.month = ?
+		WHERE (? = 0 OR o.id = ?)
+		ORDER BY o.name, o.id`
+
+	rows, err := r.db.Query(query, month, organizationID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	usage := []*OrganizationUsage{}
+	for rows.Next() {
+		u := &OrganizationUsage{Month: month}
+		if err := rows.Scan(&u.OrganizationID, &u.OrganizationName, &u.ReportsProcessed, &u.AICalls, &u.AIInputTokens,
+			&u.AIOutputTokens, &u.StorageBytes, &u.UpdatedAt); err != nil {
+			return nil, err
+		}
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
+}
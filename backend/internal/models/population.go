@@ -0,0 +1,140 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// PopulationStat is the anonymized distribution of one metric in one age band
+type PopulationStat struct {
+	MetricKey    string    `json:"metric_key" db:"metric_key"`
+	Unit         string    `json:"unit" db:"unit"`
+	AgeBand      string    `json:"age_band" db:"age_band"`
+	DisplayName  string    `json:"display_name" db:"display_name"`
+	Contributors int       `json:"contributors" db:"contributors"`
+	P10          float64   `json:"p10" db:"p10"`
+	P25          float64   `json:"p25" db:"p25"`
+	P50          float64   `json:"p50" db:"p50"`
+	P75          float64   `json:"p75" db:"p75"`
+	P90          float64   `json:"p90" db:"p90"`
+	ComputedAt   time.Time `json:"computed_at" db:"computed_at"`
+}
+
+// PopulationRepository defines the interface for population insight database operations
+type PopulationRepository interface {
+	// GetConsent returns when the user opted in, or nil when they have not
+	GetConsent(userID int) (*time.Time, error)
+	SetConsent(userID int, consented bool) error
+	ListConsentedUsers() ([]int, error)
+	// ReplaceStats swaps every stored distribution for stats in one transaction
+	ReplaceStats(stats []*PopulationStat) error
+	ListStats(ageBand string) ([]*PopulationStat, error)
+}
+
+// SQLPopulationRepository implements PopulationRepository using SQL database
+type SQLPopulationRepository struct {
+	db *sql.DB
+}
+
+// NewPopulationRepository creates a new population insight repository
+func NewPopulationRepository(db *sql.DB) PopulationRepository {
+	return &SQLPopulationRepository{db: db}
+}
+
+// GetConsent returns the time of the user's opt-in
+func (r *SQLPopulationRepository) GetConsent(userID int) (*time.Time, error) {
+	var consentedAt time.Time
+	err := r.db.QueryRow(`SELECT consented_at FROM population_consents WHERE user_id = ?`, userID).Scan(&consentedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &consentedAt, nil
+}
+
+// SetConsent records an opt-in, keeping the original time when already opted in, or removes it
+func (r *SQLPopulationRepository) SetConsent(userID int, consented bool) error {
+	query := `DELETE FROM population_consents WHERE user_id = ?`
+	if consented {
+		query = `INSERT INTO population_consents (user_id) VALUES (?) ON CONFLICT(user_id) DO NOTHING`
+	}
+
+	_, err := r.db.Exec(query, userID)
+	return err
+}
+
+// ListConsentedUsers returns the IDs of every user who opted in
+func (r *SQLPopulationRepository) ListConsentedUsers() ([]int, error) {
+	rows, err := r.db.Query(`SELECT user_id FROM population_consents ORDER BY user_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []int
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	return userIDs, rows.Err()
+}
+
+// ReplaceStats stores a fresh set of distributions
+// Decision: Cohorts that fell below the floor since the last refresh disappear with the delete
+func (r *SQLPopulationRepository) ReplaceStats(stats []*PopulationStat) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM population_metric_stats`); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO population_metric_stats (metric_key, unit, age_band, display_name, contributors, p10, p25, p50, p75, p90)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	for _, stat := range stats {
+		if _, err := tx.Exec(query, stat.MetricKey, stat.Unit, stat.AgeBand, stat.DisplayName, stat.Contributors,
+			stat.P10, stat.P25, stat.P50, stat.P75, stat.P90); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ListStats returns the distributions of an age band, by metric
+func (r *SQLPopulationRepository) ListStats(ageBand string) ([]*PopulationStat, error) {
+	query := `
+		SELECT metric_key, unit, age_band, display_name, contributors, p10, p25, p50, p75, p90, computed_at
+		FROM population_metric_stats
+		WHERE age_band = ?
+		ORDER BY metric_key, unit`
+
+	rows, err := r.db.Query(query, ageBand)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []*PopulationStat
+	for rows.Next() {
+		stat := &PopulationStat{}
+		if err := rows.Scan(&stat.MetricKey, &stat.Unit, &stat.AgeBand, &stat.DisplayName, &stat.Contributors,
+			&stat.P10, &stat.P25, &stat.P50, &stat.P75, &stat.P90, &stat.ComputedAt); err != nil {
+			return nil, err
+		}
+		stats = append(stats, stat)
+	}
+
+	return stats, rows.Err()
+}
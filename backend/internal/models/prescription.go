@@ -0,0 +1,149 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// Prescription is a photographed prescription and the medications read from it
+type Prescription struct {
+	ID           int                       `json:"id" db:"id"`
+	UserID       int                       `json:"user_id" db:"user_id"`
+	FilePath     string                    `json:"-" db:"file_path"`
+	ImageType    string                    `json:"image_type" db:"image_type"`
+	Prescriber   string                    `json:"prescriber" db:"prescriber"`
+	PrescribedOn string                    `json:"prescribed_on" db:"prescribed_on"`
+	Medications  []*PrescriptionMedication `json:"medications" db:"medications"`
+	Notes        string                    `json:"notes" db:"notes"`
+	Reader       string                    `json:"reader" db:"reader"`
+	CreatedAt    time.Time                 `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time                 `json:"updated_at" db:"updated_at"`
+}
+
+// PrescriptionMedication is one medication line of a prescription
+type PrescriptionMedication struct {
+	Name          string  `json:"name"`
+	Dosage        string  `json:"dosage"`
+	Frequency     string  `json:"frequency"`
+	Duration      string  `json:"duration"`
+	Instructions  string  `json:"instructions"`
+	Confidence    float64 `json:"confidence"`     // Reader's confidence in the line, 0-1
+	LowConfidence bool    `json:"low_confidence"` // Below the configured threshold and not yet corrected
+	Corrected     bool    `json:"corrected"`      // Edited by the user; Confidence keeps the reader's original score
+}
+
+// PrescriptionRepository defines the interface for prescription database operations
+type PrescriptionRepository interface {
+	Create(prescription *Prescription) error
+	GetByID(id int) (*Prescription, error)
+	ListByUser(userID, limit, offset int) ([]*Prescription, error)
+	UpdateMedications(prescription *Prescription) error
+	Delete(id int) error
+}
+
+// SQLPrescriptionRepository implements PrescriptionRepository using SQL database
+type SQLPrescriptionRepository struct {
+	db *sql.DB
+}
+
+// NewPrescriptionRepository creates a new prescription repository
+func NewPrescriptionRepository(db *sql.DB) PrescriptionRepository {
+	return &SQLPrescriptionRepository{db: db}
+}
+
+// Create stores a prescription
+func (r *SQLPrescriptionRepository) Create(prescription *Prescription) error {
+	medications, err := marshalMedications(prescription.Medications)
+	if err != nil {
+		return err
+	}
+
+	row := r.db.QueryRow(`
+		INSERT INTO prescriptions (user_id, file_path, image_type, prescriber, prescribed_on, medications, notes, reader)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id, created_at, updated_at`,
+		prescription.UserID, prescription.FilePath, prescription.ImageType, prescription.Prescriber,
+		prescription.PrescribedOn, medications, prescription.Notes, prescription.Reader)
+	return row.Scan(&prescription.ID, &prescription.CreatedAt, &prescription.UpdatedAt)
+}
+
+// GetByID retrieves a prescription, or nil if it does not exist
+func (r *SQLPrescriptionRepository) GetByID(id int) (*Prescription, error) {
+	prescription, err := scanPrescription(r.db.QueryRow(`
+		SELECT id, user_id, file_path, image_type, prescriber, prescribed_on, medications, notes, reader, created_at, updated_at
+		FROM prescriptions
+		WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return prescription, err
+}
+
+// ListByUser returns a user's prescriptions, newest first
+func (r *SQLPrescriptionRepository) ListByUser(userID, limit, offset int) ([]*Prescription, error) {
+	rows, err := r.db.Query(`
+		SELECT id, user_id, file_path, image_type, prescriber, prescribed_on, medications, notes, reader, created_at, updated_at
+		FROM prescriptions
+		WHERE user_id = ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT ? OFFSET ?`, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prescriptions []*Prescription
+	for rows.Next() {
+		prescription, err := scanPrescription(rows)
+		if err != nil {
+			return nil, err
+		}
+		prescriptions = append(prescriptions, prescription)
+	}
+	return prescriptions, rows.Err()
+}
+
+// UpdateMedications replaces a prescription's medication lines
+func (r *SQLPrescriptionRepository) UpdateMedications(prescription *Prescription) error {
+	medications, err := marshalMedications(prescription.Medications)
+	if err != nil {
+		return err
+	}
+
+	row := r.db.QueryRow(`
+		UPDATE prescriptions SET medications = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+		RETURNING updated_at`, medications, prescription.ID)
+	return row.Scan(&prescription.UpdatedAt)
+}
+
+// Delete removes a prescription, unless its owner is under a legal hold
+func (r *SQLPrescriptionRepository) Delete(id int) error {
+	return execUnlessHeld(r.db, prescriptionOwnerQuery, `DELETE FROM prescriptions WHERE id = ?`, id)
+}
+
+// scanPrescription reads one prescription row in the column order used by GetByID and ListByUser
+func scanPrescription(row interface{ Scan(...any) error }) (*Prescription, error) {
+	prescription := &Prescription{}
+	var medications string
+	err := row.Scan(&prescription.ID, &prescription.UserID, &prescription.FilePath, &prescription.ImageType,
+		&prescription.Prescriber, &prescription.PrescribedOn, &medications, &prescription.Notes, &prescription.Reader,
+		&prescription.CreatedAt, &prescription.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(medications), &prescription.Medications); err != nil {
+		return nil, err
+	}
+	return prescription, nil
+}
+
+// marshalMedications encodes medication lines, storing an empty list rather than JSON null
+func marshalMedications(medications []*PrescriptionMedication) (string, error) {
+	if medications == nil {
+		medications = []*PrescriptionMedication{}
+	}
+	data, err := json.Marshal(medications)
+	return string(data), err
+}
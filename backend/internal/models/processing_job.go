@@ -0,0 +1,268 @@
+package models
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// Processing attempt statuses
+const (
+	AttemptRunning   = "running"
+	AttemptSucceeded = "succeeded"
+	AttemptFailed    = "failed"
+	AttemptAbandoned = "abandoned" // The process running it died, or an operator retried or cancelled the job
+)
+
+// ProcessingAttempt is one run of the analysis pipeline on a report
+type ProcessingAttempt struct {
+	ID         int        `json:"id" db:"id"`
+	ReportID   int        `json:"report_id" db:"report_id"`
+	Status     string     `json:"status" db:"status"`
+	Model      string     `json:"model,omitempty" db:"model"` // provider/model that ran it; empty when unknown
+	Error      string     `json:"error,omitempty" db:"error"`
+	StartedAt  time.Time  `json:"started_at" db:"started_at"`
+	FinishedAt *time.Time `json:"finished_at" db:"finished_at"` // Nullable; nil while running
+}
+
+// QueueJob is a report waiting on, going through, or failed out of the processing queue
+type QueueJob struct {
+	ReportID         int       `json:"report_id"`
+	UserID           int       `json:"user_id"`
+	OriginalFilename string    `json:"original_filename"`
+	Status           string    `json:"status"`
+	Attempts         int       `json:"attempts"`
+	ErrorCode        string    `json:"error_code,omitempty"`
+	LastError        string    `json:"last_error,omitempty"`
+	UploadDate       time.Time `json:"upload_date"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// QueueState says whether workers may start new jobs
+type QueueState struct {
+	Paused   bool       `json:"paused"`
+	Reason   string     `json:"reason"`
+	PausedBy *int       `json:"paused_by"` // Nullable
+	PausedAt *time.Time `json:"paused_at"` // Nullable
+}
+
+// QueuePosition is where a pending report stands in the processing queue
+type QueuePosition struct {
+	Ahead   int        // Due reports uploaded before it, which the worker picks up first
+	RetryAt *time.Time // Nullable; set while the report waits out a retry
+}
+
+// JobRepository defines the interface for processing queue database operations
+type JobRepository interface {
+	StartAttempt(reportID int, model string) (int, error)
+	FinishAttempt(id int, status, errMsg string) error
+	AbandonRunningAttempts(reportID int, reason string) error
+	ListAttempts(reportID int) ([]*ProcessingAttempt, error)
+	ListQueue(statuses []string, limit int) ([]*QueueJob, error)
+	// GetQueuePosition returns nil unless the report is pending
+	GetQueuePosition(reportID int) (*QueuePosition, error)
+	CountByStatus() (map[string]int, error)
+	CountFailuresByCode() (map[string]int, error)
+	GetQueueState() (*QueueState, error)
+	SetQueueState(paused bool, reason string, pausedBy int) error
+}
+
+// SQLJobRepository implements JobRepository using SQL database
+type SQLJobRepository struct {
+	db *sql.DB
+}
+
+// NewJobRepository creates a new job repository
+func NewJobRepository(db *sql.DB) JobRepository {
+	return &SQLJobRepository{db: db}
+}
+
+// StartAttempt records that model started on a report and returns the attempt ID
+func (r *SQLJobRepository) StartAttempt(reportID int, model string) (int, error) {
+	var id int
+	err := r.db.QueryRow(`INSERT INTO processing_attempts (report_id, model) VALUES (?, ?) RETURNING id`, reportID, model).Scan(&id)
+	return id, err
+}
+
+// FinishAttempt records how an attempt ended
+func (r *SQLJobRepository) FinishAttempt(id int, status, errMsg string) error {
+	query := `
+		UPDATE processing_attempts
+		SET status = ?, error = NULLIF(?, ''), finished_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status = 'running'`
+
+	_, err := r.db.Exec(query, status, errMsg, id)
+	return err
+}
+
+// AbandonRunningAttempts closes attempts whose process will never report back
+func (r *SQLJobRepository) AbandonRunningAttempts(reportID int, reason string) error {
+	query := `
+		UPDATE processing_attempts
+		SET status = 'abandoned', error = NULLIF(?, ''), finished_at = CURRENT_TIMESTAMP
+		WHERE report_id = ? AND status = 'running'`
+
+	_, err := r.db.Exec(query, reason, reportID)
+	return err
+}
+
+// ListAttempts returns a report's attempts, oldest first
+func (r *SQLJobRepository) ListAttempts(reportID int) ([]*ProcessingAttempt, error) {
+	query := `
+		SELECT id, report_id, status, model, COALESCE(error, ''), started_at, finished_at
+		FROM processing_attempts
+		WHERE report_id = ?
+		ORDER BY started_at ASC, id ASC`
+
+	rows, err := r.db.Query(query, reportID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attempts []*ProcessingAttempt
+	for rows.Next() {
+		attempt := &ProcessingAttempt{}
+		if err := rows.Scan(&attempt.ID, &attempt.ReportID, &attempt.Status, &attempt.Model, &attempt.Error,
+			&attempt.StartedAt, &attempt.FinishedAt); err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, attempt)
+	}
+
+	return attempts, rows.Err()
+}
+
+// ListQueue returns reports in the given processing statuses, oldest upload first, with their attempt history summarized
+func (r *SQLJobRepository) ListQueue(statuses []string, limit int) ([]*QueueJob, error) {
+	if len(statuses) == 0 {
+		return nil, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(statuses)), ", ")
+	query := `
+		SELECT r.id, r.user_id, r.original_filename, r.processing_status,
+			(SELECT COUNT(*) FROM processing_attempts a WHERE a.report_id = r.id),
+			COALESCE((SELECT a.error FROM processing_attempts a
+				WHERE a.report_id = r.id AND a.error IS NOT NULL
+				ORDER BY a.started_at DESC, a.id DESC LIMIT 1), ''),
+			r.upload_date, r.updated_at, r.error_code
+		FROM reports r
+		WHERE r.processing_status IN (` + placeholders + `)
+		ORDER BY r.upload_date ASC
+		LIMIT ?`
+
+	args := make([]any, 0, len(statuses)+1)
+	for _, status := range statuses {
+		args = append(args, status)
+	}
+	args = append(args, limit)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []*QueueJob
+	for rows.Next() {
+		job := &QueueJob{}
+		if err := rows.Scan(&job.ReportID, &job.UserID, &job.OriginalFilename, &job.Status,
+			&job.Attempts, &job.LastError, &job.UploadDate, &job.UpdatedAt, &job.ErrorCode); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, rows.Err()
+}
+
+// GetQueuePosition counts the due reports ahead of a pending report, in the order GetPendingReports hands them out
+func (r *SQLJobRepository) GetQueuePosition(reportID int) (*QueuePosition, error) {
+	query := `
+		SELECT r.retry_at,
+			(SELECT COUNT(*) FROM reports o
+				WHERE o.processing_status = 'pending' AND (o.retry_at IS NULL OR o.retry_at <= ?)
+					AND (o.upload_date < r.upload_date OR (o.upload_date = r.upload_date AND o.id < r.id)))
+		FROM reports r
+		WHERE r.id = ? AND r.processing_status = 'pending'`
+
+	position := &QueuePosition{}
+	err := r.db.QueryRow(query, sqliteTimestamp(time.Now()), reportID).Scan(&position.RetryAt, &position.Ahead)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return position, nil
+}
+
+// CountByStatus returns how many reports are in each processing status
+func (r *SQLJobRepository) CountByStatus() (map[string]int, error) {
+	rows, err := r.db.Query(`SELECT processing_status, COUNT(*) FROM reports GROUP BY processing_status`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		counts[status] = count
+	}
+
+	return counts, rows.Err()
+}
+
+// CountFailuresByCode returns how many failed reports have each error code
+func (r *SQLJobRepository) CountFailuresByCode() (map[string]int, error) {
+	rows, err := r.db.Query(`SELECT error_code, COUNT(*) FROM reports WHERE processing_status = 'failed' GROUP BY error_code`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var code string
+		var count int
+		if err := rows.Scan(&code, &count); err != nil {
+			return nil, err
+		}
+		counts[code] = count
+	}
+
+	return counts, rows.Err()
+}
+
+// GetQueueState returns whether the queue is paused
+func (r *SQLJobRepository) GetQueueState() (*QueueState, error) {
+	state := &QueueState{}
+	err := r.db.QueryRow(`SELECT paused, reason, paused_by, paused_at FROM job_queue_state WHERE id = 1`).
+		Scan(&state.Paused, &state.Reason, &state.PausedBy, &state.PausedAt)
+	// Decision: A missing row means the migration seeded nothing yet; treat it as running
+	if err == sql.ErrNoRows {
+		return &QueueState{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// SetQueueState pauses or resumes the queue
+func (r *SQLJobRepository) SetQueueState(paused bool, reason string, pausedBy int) error {
+	query := `
+		INSERT INTO job_queue_state (id, paused, reason, paused_by, paused_at)
+		VALUES (1, ?, ?, NULLIF(?, 0), CASE WHEN ? THEN CURRENT_TIMESTAMP END)
+		ON CONFLICT (id) DO UPDATE SET
+			paused = excluded.paused, reason = excluded.reason,
+			paused_by = excluded.paused_by, paused_at = excluded.paused_at`
+
+	_, err := r.db.Exec(query, paused, reason, pausedBy, paused)
+	return err
+}
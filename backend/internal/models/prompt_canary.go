@@ -0,0 +1,112 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Prompt canary statuses
+const (
+	PromptCanaryActive     = "active"
+	PromptCanaryRolledBack = "rolled_back" // Stopped, automatically or by an admin; new reports use the stable prompt
+	PromptCanaryPromoted   = "promoted"    // Judged ready; still serving until the configuration makes it the stable prompt
+)
+
+// PromptCanary is a candidate prompt or model served to a share of new reports
+type PromptCanary struct {
+	ID         int        `json:"id" db:"id"`
+	Version    string     `json:"version" db:"version"`
+	PromptPath string     `json:"prompt_path" db:"prompt_path"`
+	Model      string     `json:"model" db:"model"` // Empty when it runs on the stable model
+	Percent    int        `json:"percent" db:"percent"`
+	Status     string     `json:"status" db:"status"`
+	Reason     string     `json:"reason" db:"reason"`
+	StartedAt  time.Time  `json:"started_at" db:"started_at"`
+	EndedAt    *time.Time `json:"ended_at" db:"ended_at"` // Nullable; nil while active
+	EndedBy    int        `json:"ended_by" db:"ended_by"` // 0 for an automatic rollback
+}
+
+// PromptCanaryRepository defines the interface for prompt canary database operations
+type PromptCanaryRepository interface {
+	// Register stores the canary unless its version was deployed before, and loads the stored one either way
+	Register(canary *PromptCanary) error
+	// GetByVersion returns the canary deployed with the version, or nil
+	GetByVersion(version string) (*PromptCanary, error)
+	// End moves an active canary to status, returning false if it had already ended
+	End(id int, status, reason string, endedBy int) (bool, error)
+}
+
+// SQLPromptCanaryRepository implements PromptCanaryRepository using SQL database
+type SQLPromptCanaryRepository struct {
+	db *sql.DB
+}
+
+// NewPromptCanaryRepository creates a new prompt canary repository
+func NewPromptCanaryRepository(db *sql.DB) PromptCanaryRepository {
+	return &SQLPromptCanaryRepository{db: db}
+}
+
+const promptCanaryColumns = `id, version, prompt_path, model, percent, status, reason, started_at, ended_at, ended_by`
+
+// scanPromptCanary reads a row selected with promptCanaryColumns
+func scanPromptCanary(row rowScanner) (*PromptCanary, error) {
+	canary := &PromptCanary{}
+	var endedAt sql.NullTime
+	err := row.Scan(&canary.ID, &canary.Version, &canary.PromptPath, &canary.Model, &canary.Percent, &canary.Status,
+		&canary.Reason, &canary.StartedAt, &endedAt, &canary.EndedBy)
+	if err != nil {
+		return nil, err
+	}
+	if endedAt.Valid {
+		canary.EndedAt = &endedAt.Time
+	}
+	return canary, nil
+}
+
+// Register inserts the canary, keeping an earlier deployment of the same version as it was
+// Decision: A version that was rolled back stays rolled back across restarts; redeploying a candidate
+// means giving it a new version, so its analyses are never compared together with the rejected ones
+func (r *SQLPromptCanaryRepository) Register(canary *PromptCanary) error {
+	query := `
+		INSERT INTO prompt_canaries (version, prompt_path, model, percent)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (version) DO NOTHING`
+
+	if _, err := r.db.Exec(query, canary.Version, canary.PromptPath, canary.Model, canary.Percent); err != nil {
+		return err
+	}
+
+	stored, err := scanPromptCanary(r.db.QueryRow(`SELECT `+promptCanaryColumns+` FROM prompt_canaries WHERE version = ?`, canary.Version))
+	if err != nil {
+		return err
+	}
+	*canary = *stored
+	return nil
+}
+
+// GetByVersion retrieves a canary by its version
+func (r *SQLPromptCanaryRepository) GetByVersion(version string) (*PromptCanary, error) {
+	canary, err := scanPromptCanary(r.db.QueryRow(`SELECT `+promptCanaryColumns+` FROM prompt_canaries WHERE version = ?`, version))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return canary, err
+}
+
+// End records how the canary ended
+func (r *SQLPromptCanaryRepository) End(id int, status, reason string, endedBy int) (bool, error) {
+	query := `
+		UPDATE prompt_canaries
+		SET status = ?, reason = ?, ended_by = ?, ended_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status = 'active'`
+
+	result, err := r.db.Exec(query, status, reason, endedBy, id)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
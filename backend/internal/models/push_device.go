@@ -0,0 +1,122 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Push platforms a device token can belong to
+const (
+	PushPlatformFCM  = "fcm"  // Firebase Cloud Messaging: Android and web
+	PushPlatformAPNs = "apns" // Apple Push Notification service: iOS
+)
+
+// PushDevice is a phone or browser registered to receive push notifications for a user
+type PushDevice struct {
+	ID         int       `json:"id" db:"id"`
+	UserID     int       `json:"user_id" db:"user_id"`
+	Platform   string    `json:"platform" db:"platform"`
+	Token      string    `json:"-" db:"token"`
+	Name       string    `json:"name" db:"name"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at" db:"last_seen_at"`
+}
+
+// PushDeviceRepository defines the interface for push device database operations
+type PushDeviceRepository interface {
+	// Upsert registers the token for device.UserID, taking it over if another user had registered it
+	Upsert(device *PushDevice) error
+	ListByUser(userID int) ([]*PushDevice, error)
+	// Delete removes one of the user's devices, returning false if it doesn't exist
+	Delete(id, userID int) (bool, error)
+	// DeleteByToken forgets a token the push service reported as no longer valid
+	DeleteByToken(platform, token string) error
+	// KeepRecent removes all but the user's keep most recently seen devices
+	KeepRecent(userID, keep int) error
+}
+
+// SQLPushDeviceRepository implements PushDeviceRepository using SQL database
+type SQLPushDeviceRepository struct {
+	db *sql.DB
+}
+
+// NewPushDeviceRepository creates a new push device repository
+func NewPushDeviceRepository(db *sql.DB) PushDeviceRepository {
+	return &SQLPushDeviceRepository{db: db}
+}
+
+const pushDeviceColumns = `id, user_id, platform, token, name, created_at, last_seen_at`
+
+func scanPushDevice(row rowScanner) (*PushDevice, error) {
+	device := &PushDevice{}
+	if err := row.Scan(&device.ID, &device.UserID, &device.Platform, &device.Token, &device.Name, &device.CreatedAt, &device.LastSeenAt); err != nil {
+		return nil, err
+	}
+	return device, nil
+}
+
+// Upsert stores the device or refreshes the existing registration of its token
+// Decision: A token identifies an app install, not a person; when someone else signs in on the
+// same phone the token moves to them so the previous user's results stop arriving there
+func (r *SQLPushDeviceRepository) Upsert(device *PushDevice) error {
+	row := r.db.QueryRow(`
+		INSERT INTO push_devices (user_id, platform, token, name)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (platform, token) DO UPDATE SET
+			user_id = excluded.user_id,
+			name = excluded.name,
+			last_seen_at = CURRENT_TIMESTAMP,
+			created_at = CASE WHEN push_devices.user_id = excluded.user_id THEN push_devices.created_at ELSE CURRENT_TIMESTAMP END
+		RETURNING id, created_at, last_seen_at`,
+		device.UserID, device.Platform, device.Token, device.Name)
+	return row.Scan(&device.ID, &device.CreatedAt, &device.LastSeenAt)
+}
+
+// ListByUser returns the user's devices, most recently seen first
+func (r *SQLPushDeviceRepository) ListByUser(userID int) ([]*PushDevice, error) {
+	rows, err := r.db.Query(`SELECT `+pushDeviceColumns+` FROM push_devices WHERE user_id = ? ORDER BY last_seen_at DESC, id DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var devices []*PushDevice
+	for rows.Next() {
+		device, err := scanPushDevice(rows)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, device)
+	}
+
+	return devices, rows.Err()
+}
+
+// Delete removes the device if it belongs to the user
+func (r *SQLPushDeviceRepository) Delete(id, userID int) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM push_devices WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+// DeleteByToken removes the token's registration, whoever it belongs to
+func (r *SQLPushDeviceRepository) DeleteByToken(platform, token string) error {
+	_, err := r.db.Exec(`DELETE FROM push_devices WHERE platform = ? AND token = ?`, platform, token)
+	return err
+}
+
+// KeepRecent removes the user's devices beyond the keep most recently seen
+func (r *SQLPushDeviceRepository) KeepRecent(userID, keep int) error {
+	_, err := r.db.Exec(`
+		DELETE FROM push_devices
+		WHERE user_id = ? AND id NOT IN (
+			SELECT id FROM push_devices WHERE user_id = ? ORDER BY last_seen_at DESC, id DESC LIMIT ?
+		)`, userID, userID, keep)
+	return err
+}
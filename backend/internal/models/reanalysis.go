@@ -0,0 +1,492 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Re-analysis run statuses
+const (
+	ReanalysisRunning   = "running"
+	ReanalysisCompleted = "completed"
+	ReanalysisCancelled = "cancelled"
+)
+
+// Re-analysis item statuses
+const (
+	ReanalysisItemPending   = "pending"
+	ReanalysisItemRunning   = "running"
+	ReanalysisItemSucceeded = "succeeded"
+	ReanalysisItemFailed    = "failed"  // The old analysis was kept
+	ReanalysisItemSkipped   = "skipped" // The run was cancelled, or the report changed while it was re-analyzed
+)
+
+// ReanalysisRun brings historical reports up to the current prompt and model
+type ReanalysisRun struct {
+	ID            int            `json:"id" db:"id"`
+	CreatedBy     int            `json:"created_by" db:"created_by"`
+	Reason        string         `json:"reason" db:"reason"`
+	PromptVersion string         `json:"prompt_version" db:"prompt_version"`
+	Model         string         `json:"model" db:"model"`
+	Status        string         `json:"status" db:"status"`
+	CreatedAt     time.Time      `json:"created_at" db:"created_at"`
+	FinishedAt    *time.Time     `json:"finished_at" db:"finished_at"` // Nullable; nil while running
+	Counts        map[string]int `json:"counts"`                       // Items per status
+}
+
+// ReanalysisItem is one report in a run
+type ReanalysisItem struct {
+	ID               int        `json:"id" db:"id"`
+	RunID            int        `json:"run_id" db:"run_id"`
+	ReportID         int        `json:"report_id" db:"report_id"`
+	Status           string     `json:"status" db:"status"`
+	OldAnalysis      string     `json:"old_analysis" db:"old_analysis"`
+	OldPromptVersion string     `json:"old_prompt_version" db:"old_prompt_version"`
+	NewAnalysis      string     `json:"new_analysis" db:"new_analysis"`
+	NewPromptVersion string     `json:"new_prompt_version" db:"new_prompt_version"`
+	Error            string     `json:"error" db:"error"`
+	FinishedAt       *time.Time `json:"finished_at" db:"finished_at"` // Nullable
+}
+
+// ReanalysisFilter selects the completed reports a run re-analyzes
+type ReanalysisFilter struct {
+	PromptVersion string // Reports analyzed with another prompt version are stale
+	Model         string // Reports last analyzed by another model are stale; empty ignores the model
+	UserID        int    // 0 means every user
+	Since         string // First upload day included, YYYY-MM-DD; empty means no bound
+	Until         string // Last upload day included, YYYY-MM-DD; empty means no bound
+	ReportIDs     []int  // Empty means every stale report
+	Limit         int
+}
+
+// ReanalysisRepository defines the interface for re-analysis run database operations
+type ReanalysisRepository interface {
+	// SelectStale returns IDs of completed reports not produced by the filter's prompt and model, oldest first,
+	// leaving out reports under a legal hold
+	SelectStale(filter ReanalysisFilter) ([]int, error)
+	// CreateRun stores a run and snapshots the current analysis of each report
+	CreateRun(run *ReanalysisRun, reportIDs []int) error
+	HasRunning() (bool, error)
+	// ClaimNext marks the oldest pending item of a running run as running; nil when there is none
+	ClaimNext() (*ReanalysisItem, error)
+	// Apply replaces the report's analysis unless it changed since the snapshot or was put under a legal hold;
+	// false marks the item skipped
+	Apply(item *ReanalysisItem, analysis, promptVersion string) (bool, error)
+	Fail(item *ReanalysisItem, errMsg string) error
+	// Release puts a claimed item back to pending
+	Release(itemID int) error
+	// ResetRunning puts items left running by a process that died back to pending
+	ResetRunning() (int64, error)
+	// Cancel stops a running run, skipping its pending items; false when it was not running
+	Cancel(runID int) (bool, error)
+	ListRuns(limit int) ([]*ReanalysisRun, error)
+	GetRun(runID int) (*ReanalysisRun, error)
+	// ListItems returns a run's items without their analyses
+	ListItems(runID int) ([]*ReanalysisItem, error)
+	GetItem(runID, reportID int) (*ReanalysisItem, error)
+}
+
+// SQLReanalysisRepository implements ReanalysisRepository using SQL database
+type SQLReanalysisRepository struct {
+	db *sql.DB
+}
+
+// NewReanalysisRepository creates a new re-analysis repository
+func NewReanalysisRepository(db *sql.DB) ReanalysisRepository {
+	return &SQLReanalysisRepository{db: db}
+}
+
+// SelectStale finds reports to re-analyze
+// Decision: A report's model is the one of its latest successful attempt; reports analyzed before
+// attempts recorded a model count as stale, since nothing says which model produced them
+func (r *SQLReanalysisRepository) SelectStale(filter ReanalysisFilter) ([]int, error) {
+	query := `
+		SELECT r.id
+		FROM reports r
+		WHERE r.processing_status = 'completed' AND r.user_id NOT IN (` + heldUsersQuery + `)
+			AND (COALESCE(r.prompt_version, '') != ?`
+	args := []any{filter.PromptVersion}
+	if filter.Model != "" {
+		query += `
+			OR COALESCE((SELECT a.model FROM processing_attempts a
+				WHERE a.report_id = r.id AND a.status = 'succeeded'
+				ORDER BY a.id DESC LIMIT 1), '') != ?`
+		args = append(args, filter.Model)
+	}
+	query += `)`
+
+	if filter.UserID != 0 {
+		query += ` AND r.user_id = ?`
+		args = append(args, filter.UserID)
+	}
+	if filter.Since != "" {
+		query += ` AND date(r.upload_date) >= ?`
+		args = append(args, filter.Since)
+	}
+	if filter.Until != "" {
+		query += ` AND date(r.upload_date) <= ?`
+		args = append(args, filter.Until)
+	}
+	if len(filter.ReportIDs) > 0 {
+		query += ` AND r.id IN (?` + strings.Repeat(", ?", len(filter.ReportIDs)-1) + `)`
+		for _, id := range filter.ReportIDs {
+			args = append(args, id)
+		}
+	}
+	query += ` ORDER BY r.id LIMIT ?`
+	args = append(args, filter.Limit)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// CreateRun stores the run and its items in one transaction
+func (r *SQLReanalysisRepository) CreateRun(run *ReanalysisRun, reportIDs []int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO reanalysis_runs (created_by, reason, prompt_version, model)
+		VALUES (?, ?, ?, ?)
+		RETURNING id, status, created_at`,
+		run.CreatedBy, run.Reason, run.PromptVersion, run.Model,
+	).Scan(&run.ID, &run.Status, &run.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	// Decision: The snapshot is what the diff compares against and what Apply checks is still current
+	query := `
+		INSERT INTO reanalysis_items (run_id, report_id, old_analysis, old_prompt_version)
+		SELECT ?, id, COALESCE(simplified_summary, ''), COALESCE(prompt_version, '')
+		FROM reports
+		WHERE id = ? AND processing_status = 'completed'`
+	run.Counts = map[string]int{}
+	for _, reportID := range reportIDs {
+		result, err := tx.Exec(query, run.ID, reportID)
+		if err != nil {
+			return err
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			run.Counts[ReanalysisItemPending]++
+		}
+	}
+
+	return tx.Commit()
+}
+
+// HasRunning reports whether a run is still in progress
+func (r *SQLReanalysisRepository) HasRunning() (bool, error) {
+	var running bool
+	err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM reanalysis_runs WHERE status = 'running')`).Scan(&running)
+	return running, err
+}
+
+// ClaimNext takes the next item
+// Decision: The conditional update lets the server and workers share a run without analyzing a report twice
+func (r *SQLReanalysisRepository) ClaimNext() (*ReanalysisItem, error) {
+	query := `
+		UPDATE reanalysis_items
+		SET status = 'running'
+		WHERE status = 'pending' AND id = (
+			SELECT i.id
+			FROM reanalysis_items i
+			JOIN reanalysis_runs run ON run.id = i.run_id
+			WHERE i.status = 'pending' AND run.status = 'running'
+			ORDER BY i.id
+			LIMIT 1
+		)
+		RETURNING id, run_id, report_id, status, old_analysis, old_prompt_version`
+
+	item := &ReanalysisItem{}
+	err := r.db.QueryRow(query).Scan(&item.ID, &item.RunID, &item.ReportID, &item.Status,
+		&item.OldAnalysis, &item.OldPromptVersion)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// Apply stores the new analysis on the report and the item together
+// Decision: A report re-uploaded, retried, or deleted while the model ran keeps what it has now, and
+// so does one whose owner was put under a legal hold after the run was started
+func (r *SQLReanalysisRepository) Apply(item *ReanalysisItem, analysis, promptVersion string) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var applied int64
+	skipReason := "The report changed while it was re-analyzed"
+	if err := checkLegalHold(tx, reportOwnerQuery, item.ReportID); err == ErrLegalHold {
+		skipReason = "The report is under a legal hold"
+	} else if err != nil {
+		return false, err
+	} else {
+		result, err := tx.Exec(`
+			UPDATE reports
+			SET simplified_summary = ?, prompt_version = ?, parse_failed = 0, updated_at = CURRENT_TIMESTAMP
+			WHERE id = ? AND processing_status = 'completed' AND COALESCE(simplified_summary, '') = ?`,
+			analysis, promptVersion, item.ReportID, item.OldAnalysis)
+		if err != nil {
+			return false, err
+		}
+		if applied, err = result.RowsAffected(); err != nil {
+			return false, err
+		}
+	}
+
+	if applied > 0 {
+		_, err = tx.Exec(`
+			UPDATE reanalysis_items
+			SET status = 'succeeded', new_analysis = ?, new_prompt_version = ?, finished_at = CURRENT_TIMESTAMP
+			WHERE id = ?`,
+			analysis, promptVersion, item.ID)
+	} else {
+		_, err = tx.Exec(`
+			UPDATE reanalysis_items
+			SET status = 'skipped', error = ?, finished_at = CURRENT_TIMESTAMP
+			WHERE id = ?`,
+			skipReason, item.ID)
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := finishRunIfDone(tx, item.RunID); err != nil {
+		return false, err
+	}
+
+	return applied > 0, tx.Commit()
+}
+
+// Fail records why an item could not be re-analyzed
+func (r *SQLReanalysisRepository) Fail(item *ReanalysisItem, errMsg string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		UPDATE reanalysis_items
+		SET status = 'failed', error = ?, finished_at = CURRENT_TIMESTAMP
+		WHERE id = ?`,
+		errMsg, item.ID); err != nil {
+		return err
+	}
+	if err := finishRunIfDone(tx, item.RunID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// finishRunIfDone completes a running run once none of its items are left
+func finishRunIfDone(tx *sql.Tx, runID int) error {
+	_, err := tx.Exec(`
+		UPDATE reanalysis_runs
+		SET status = 'completed', finished_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status = 'running' AND NOT EXISTS (
+			SELECT 1 FROM reanalysis_items WHERE run_id = ? AND status IN ('pending', 'running')
+		)`,
+		runID, runID)
+	return err
+}
+
+// Release returns an item to the queue
+func (r *SQLReanalysisRepository) Release(itemID int) error {
+	_, err := r.db.Exec(`UPDATE reanalysis_items SET status = 'pending' WHERE id = ? AND status = 'running'`, itemID)
+	return err
+}
+
+// ResetRunning requeues items a crashed process had claimed
+func (r *SQLReanalysisRepository) ResetRunning() (int64, error) {
+	result, err := r.db.Exec(`UPDATE reanalysis_items SET status = 'pending' WHERE status = 'running'`)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// Cancel stops a run; an item already being analyzed still finishes
+func (r *SQLReanalysisRepository) Cancel(runID int) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE reanalysis_runs
+		SET status = 'cancelled', finished_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status = 'running'`,
+		runID)
+	if err != nil {
+		return false, err
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE reanalysis_items
+		SET status = 'skipped', error = 'The run was cancelled', finished_at = CURRENT_TIMESTAMP
+		WHERE run_id = ? AND status = 'pending'`,
+		runID); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// ListRuns returns the most recent runs first, with their item counts
+func (r *SQLReanalysisRepository) ListRuns(limit int) ([]*ReanalysisRun, error) {
+	query := `
+		SELECT id, created_by, reason, prompt_version, model, status, created_at, finished_at
+		FROM reanalysis_runs
+		ORDER BY id DESC
+		LIMIT ?`
+
+	rows, err := r.db.Query(query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var runs []*ReanalysisRun
+	for rows.Next() {
+		run := &ReanalysisRun{}
+		if err := rows.Scan(&run.ID, &run.CreatedBy, &run.Reason, &run.PromptVersion, &run.Model,
+			&run.Status, &run.CreatedAt, &run.FinishedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, run := range runs {
+		if run.Counts, err = r.countItems(run.ID); err != nil {
+			return nil, err
+		}
+	}
+	return runs, nil
+}
+
+// GetRun retrieves a run with its item counts; nil when it does not exist
+func (r *SQLReanalysisRepository) GetRun(runID int) (*ReanalysisRun, error) {
+	query := `
+		SELECT id, created_by, reason, prompt_version, model, status, created_at, finished_at
+		FROM reanalysis_runs
+		WHERE id = ?`
+
+	run := &ReanalysisRun{}
+	err := r.db.QueryRow(query, runID).Scan(&run.ID, &run.CreatedBy, &run.Reason, &run.PromptVersion, &run.Model,
+		&run.Status, &run.CreatedAt, &run.FinishedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if run.Counts, err = r.countItems(runID); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// countItems counts a run's items per status
+func (r *SQLReanalysisRepository) countItems(runID int) (map[string]int, error) {
+	rows, err := r.db.Query(`SELECT status, COUNT(*) FROM reanalysis_items WHERE run_id = ? GROUP BY status`, runID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[string]int{}
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		counts[status] = count
+	}
+
+	return counts, rows.Err()
+}
+
+// ListItems returns a run's items in the order they are processed
+func (r *SQLReanalysisRepository) ListItems(runID int) ([]*ReanalysisItem, error) {
+	query := `
+		SELECT id, run_id, report_id, status, old_prompt_version, new_prompt_version, error, finished_at
+		FROM reanalysis_items
+		WHERE run_id = ?
+		ORDER BY id`
+
+	rows, err := r.db.Query(query, runID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []*ReanalysisItem
+	for rows.Next() {
+		item := &ReanalysisItem{}
+		if err := rows.Scan(&item.ID, &item.RunID, &item.ReportID, &item.Status, &item.OldPromptVersion,
+			&item.NewPromptVersion, &item.Error, &item.FinishedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// GetItem retrieves a report's item in a run with both analyses; nil when it does not exist
+func (r *SQLReanalysisRepository) GetItem(runID, reportID int) (*ReanalysisItem, error) {
+	query := `
+		SELECT id, run_id, report_id, status, old_analysis, old_prompt_version,
+			new_analysis, new_prompt_version, error, finished_at
+		FROM reanalysis_items
+		WHERE run_id = ? AND report_id = ?`
+
+	item := &ReanalysisItem{}
+	err := r.db.QueryRow(query, runID, reportID).Scan(&item.ID, &item.RunID, &item.ReportID, &item.Status,
+		&item.OldAnalysis, &item.OldPromptVersion, &item.NewAnalysis, &item.NewPromptVersion, &item.Error, &item.FinishedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Reasons a refresh token stopped being accepted before it expired
+const (
+	RefreshTokenRotated = "rotated" // Exchanged for a new access token and its replacement
+	RefreshTokenLogout  = "logout"  // The user signed out
+	RefreshTokenReused  = "reused"  // A rotated token of the same sign-in was presented again
+)
+
+// RefreshToken is one link in a sign-in's chain of single-use refresh tokens
+type RefreshToken struct {
+	ID            int        `json:"id" db:"id"`
+	UserID        int        `json:"user_id" db:"user_id"`
+	TokenHash     string     `json:"-" db:"token_hash"`
+	SessionID     string     `json:"-" db:"session_id"` // The jti of the sign-in's access tokens
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	ExpiresAt     time.Time  `json:"expires_at" db:"expires_at"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	RevokedReason string     `json:"revoked_reason,omitempty" db:"revoked_reason"`
+}
+
+// RefreshTokenRepository defines the interface for refresh token database operations
+type RefreshTokenRepository interface {
+	Create(token *RefreshToken) error
+	GetByHash(tokenHash string) (*RefreshToken, error)
+	// Revoke ends one token, returning false if it was already revoked, so only one caller can rotate it
+	Revoke(id int, reason string, at time.Time) (bool, error)
+	// RevokeSession ends every token of a sign-in that is still live
+	RevokeSession(sessionID, reason string, at time.Time) error
+	// DeleteExpired removes the user's tokens that expired before now
+	DeleteExpired(userID int, now time.Time) error
+}
+
+// SQLRefreshTokenRepository implements RefreshTokenRepository using SQL database
+type SQLRefreshTokenRepository struct {
+	db *sql.DB
+}
+
+// NewRefreshTokenRepository creates a new refresh token repository
+func NewRefreshTokenRepository(db *sql.DB) RefreshTokenRepository {
+	return &SQLRefreshTokenRepository{db: db}
+}
+
+const refreshTokenColumns = `id, user_id, token_hash, session_id, created_at, expires_at, revoked_at, revoked_reason`
+
+func scanRefreshToken(row rowScanner) (*RefreshToken, error) {
+	token := &RefreshToken{}
+	var revokedAt sql.NullTime
+	if err := row.Scan(&token.ID, &token.UserID, &token.TokenHash, &token.SessionID, &token.CreatedAt, &token.ExpiresAt,
+		&revokedAt, &token.RevokedReason); err != nil {
+		return nil, err
+	}
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+	return token, nil
+}
+
+// Create stores a new refresh token
+// Decision: Timestamps are written in UTC so expiry comparisons in SQL order correctly
+func (r *SQLRefreshTokenRepository) Create(token *RefreshToken) error {
+	now := time.Now().UTC()
+	result, err := r.db.Exec(`
+		INSERT INTO refresh_tokens (user_id, token_hash, session_id, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?)`,
+		token.UserID, token.TokenHash, token.SessionID, now, token.ExpiresAt.UTC())
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	token.ID = int(id)
+	token.CreatedAt = now
+	return nil
+}
+
+// GetByHash returns the token with the hash, revoked or not, or nil if there is none
+func (r *SQLRefreshTokenRepository) GetByHash(tokenHash string) (*RefreshToken, error) {
+	token, err := scanRefreshToken(r.db.QueryRow(`SELECT `+refreshTokenColumns+` FROM refresh_tokens WHERE token_hash = ?`, tokenHash))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return token, err
+}
+
+// Revoke marks the token ended if it is still live
+func (r *SQLRefreshTokenRepository) Revoke(id int, reason string, at time.Time) (bool, error) {
+	result, err := r.db.Exec(`UPDATE refresh_tokens SET revoked_at = ?, revoked_reason = ? WHERE id = ? AND revoked_at IS NULL`,
+		at.UTC(), reason, id)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+// RevokeSession marks the sign-in's live tokens ended; rotated ones keep their reason
+func (r *SQLRefreshTokenRepository) RevokeSession(sessionID, reason string, at time.Time) error {
+	_, err := r.db.Exec(`UPDATE refresh_tokens SET revoked_at = ?, revoked_reason = ? WHERE session_id = ? AND revoked_at IS NULL`,
+		at.UTC(), reason, sessionID)
+	return err
+}
+
+// DeleteExpired removes the user's expired tokens; rotated ones are kept until then so their reuse is noticed
+func (r *SQLRefreshTokenRepository) DeleteExpired(userID int, now time.Time) error {
+	_, err := r.db.Exec(`DELETE FROM refresh_tokens WHERE user_id = ? AND expires_at <= ?`, userID, now.UTC())
+	return err
+}
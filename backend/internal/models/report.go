@@ -2,6 +2,7 @@ package models
 
 import (
 	"database/sql"
+	"strings"
 	"time"
 )
 
@@ -10,7 +11,7 @@ type Report struct {
 	ID                int        `json:"id" db:"id"`
 	UserID           int        `json:"user_id" db:"user_id"`
 	OriginalFilename string     `json:"original_filename" db:"original_filename"`
-	FilePath         string     `json:"file_path" db:"file_path"`
+	FilePath         string     `json:"-" db:"file_path"` // Internal only - never serialize server paths
 	FileType         string     `json:"file_type" db:"file_type"`
 	FileSize         int64      `json:"file_size" db:"file_size"`
 	SimplifiedSummary string    `json:"simplified_summary" db:"simplified_summary"`
@@ -19,6 +20,145 @@ type Report struct {
 	ProcessedAt      *time.Time `json:"processed_at" db:"processed_at"` // Nullable
 	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt        time.Time  `json:"updated_at" db:"updated_at"`
+	PromptVersion    string     `json:"prompt_version" db:"prompt_version"`
+	ParseFailed      bool       `json:"parse_failed" db:"parse_failed"`
+	FeedbackRating   *int       `json:"feedback_rating" db:"feedback_rating"` // Nullable, 1-5
+	ArchivedAt       *time.Time `json:"archived_at" db:"archived_at"`         // Nullable; archived reports are hidden from listings
+	Title            string     `json:"title" db:"title"`                     // User-set display title; empty means use the filename
+	ReportDate       *time.Time `json:"report_date" db:"report_date"`         // Nullable; when the test was taken, as entered by the user
+	Notes            string     `json:"notes" db:"notes"`
+	ReadingLevel     string     `json:"reading_level" db:"reading_level"` // Audience the analysis was written for
+	ErrorCode        string     `json:"error_code" db:"error_code"`       // One of the ProcessingError* codes; empty unless failed
+	ErrorDetail      string     `json:"error_detail" db:"error_detail"`   // What went wrong, for the patient or operator
+	Plan             string     `json:"plan" db:"plan"`                   // Owner's plan at upload; sets analysis depth
+	Version          int        `json:"version" db:"version"`             // Incremented by every edit of the details, for optimistic concurrency
+	Visibility       string     `json:"visibility" db:"visibility"`       // One of the ReportVisibility* values
+	Language         string     `json:"language" db:"language"`           // ISO 639-1 code of the language printed in; empty until analyzed
+}
+
+// Who in the owner's organization may read a report besides the owner
+const (
+	ReportVisibilityPrivate  = "private"   // Only the owner
+	ReportVisibilityDoctor   = "doctor"    // The owner's assigned doctor
+	ReportVisibilityCareTeam = "care_team" // Every doctor and care team member of the owner's organization
+)
+
+// IsValidReportVisibility reports whether visibility is one of the ReportVisibility* values
+func IsValidReportVisibility(visibility string) bool {
+	switch visibility {
+	case ReportVisibilityPrivate, ReportVisibilityDoctor, ReportVisibilityCareTeam:
+		return true
+	}
+	return false
+}
+
+// Processing error codes stored on failed reports
+// Decision: A fixed set the frontend maps to tailored guidance and admins count by; ErrorDetail carries the specifics
+const (
+	ProcessingErrorExtractionFailed   = "extraction_failed"   // The file couldn't be opened or parsed
+	ProcessingErrorUnreadableDocument = "unreadable_document" // The file opened but held no usable text
+	ProcessingErrorAITimeout          = "ai_timeout"
+	ProcessingErrorQuotaExceeded      = "quota_exceeded" // The model provider's rate limit or quota
+	ProcessingErrorParseFailed        = "parse_failed"   // The model's answer couldn't be read
+	ProcessingErrorCancelled          = "cancelled"      // Taken out of the queue by an operator
+	ProcessingErrorInternal           = "internal"
+)
+
+// ReportDetails are the user-editable fields of a report
+type ReportDetails struct {
+	Title      string
+	ReportDate *time.Time
+	Notes      string
+}
+
+// Per-item outcomes of bulk report operations
+const (
+	BulkStatusDeleted         = "deleted"
+	BulkStatusArchived        = "archived"
+	BulkStatusAlreadyArchived = "already_archived"
+	BulkStatusNotFound        = "not_found"
+	BulkStatusForbidden       = "forbidden"
+	BulkStatusLegalHold       = "legal_hold"
+)
+
+// BulkResult is the outcome of a bulk operation for one report
+type BulkResult struct {
+	ReportID int    `json:"report_id"`
+	Status   string `json:"status"`
+}
+
+// PromptVariantStats aggregates analysis quality for one prompt version
+type PromptVariantStats struct {
+	PromptVersion string   `json:"prompt_version"`
+	Total         int      `json:"total"`
+	ParseFailures int      `json:"parse_failures"`
+	FeedbackCount int      `json:"feedback_count"`
+	AverageRating *float64 `json:"average_rating"`
+}
+
+// AnalysisSample is one analysis as a prompt comparison sees it
+type AnalysisSample struct {
+	PromptVersion     string
+	ParseFailed       bool
+	SimplifiedSummary string // Empty when parsing failed
+}
+
+// reportColumns lists the columns scanned by scanReport, in order
+// Decision: One column list keeps every SELECT in sync as the table grows;
+// COALESCE guards nullable text columns that pending reports leave empty
+const reportColumns = `id, user_id, original_filename, file_path, file_type, file_size,
+			   COALESCE(simplified_summary, ''), processing_status, upload_date, processed_at,
+			   created_at, updated_at, COALESCE(prompt_version, ''), parse_failed, feedback_rating,
+			   archived_at, COALESCE(title, ''), report_date, COALESCE(notes, ''), reading_level,
+			   error_code, error_detail, plan, version, visibility, language`
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanReport reads a single report selected with reportColumns
+func scanReport(row rowScanner) (*Report, error) {
+	report := &Report{}
+	err := row.Scan(&report.ID, &report.UserID, &report.OriginalFilename,
+		&report.FilePath, &report.FileType, &report.FileSize,
+		&report.SimplifiedSummary, &report.ProcessingStatus, &report.UploadDate,
+		&report.ProcessedAt, &report.CreatedAt, &report.UpdatedAt,
+		&report.PromptVersion, &report.ParseFailed, &report.FeedbackRating,
+		&report.ArchivedAt, &report.Title, &report.ReportDate, &report.Notes, &report.ReadingLevel,
+		&report.ErrorCode, &report.ErrorDetail, &report.Plan, &report.Version, &report.Visibility, &report.Language)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// scanReports reads all rows selected with reportColumns
+func scanReports(rows *sql.Rows) ([]*Report, error) {
+	var reports []*Report
+	for rows.Next() {
+		report, err := scanReport(rows)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return reports, nil
+}
+
+// ReportFilter narrows report queries for batch tooling
+// Decision: Zero values mean "no filter" so callers only set what they need
+type ReportFilter struct {
+	Status string
+	UserID int
+	Since  *time.Time
+	Until  *time.Time
+	Limit  int
 }
 
 // ReportRepository defines the interface for report database operations
@@ -28,50 +168,101 @@ type ReportRepository interface {
 	GetByUserID(userID int, limit, offset int) ([]*Report, error)
 	Update(report *Report) error
 	UpdateProcessingStatus(id int, status string, summary string) error
+	MarkFailed(id int, errorCode, errorDetail string) error
+	ClaimForProcessing(id int, fromStatus string) (bool, error)
+	// ScheduleRetry puts a processing report back in the queue, to be picked up no earlier than at;
+	// the error is kept on its status history
+	ScheduleRetry(id int, errorCode, errorDetail string, at time.Time) error
+	// ListStaleProcessing returns reports that have been processing since before the given time, oldest first
+	ListStaleProcessing(before time.Time, limit int) ([]*Report, error)
+	// RequeueStale puts a report processing since before the given time back in the queue; false if it moved on
+	RequeueStale(id int, before time.Time) (bool, error)
+	// FailStale is MarkFailed for a report processing since before the given time; false if it moved on
+	FailStale(id int, before time.Time, errorCode, errorDetail string) (bool, error)
+	// ListStatusEvents returns every processing status the report entered, oldest first
+	ListStatusEvents(reportID int) ([]*ReportStatusEvent, error)
+	UpdateSummary(id int, summary string) error
+	UpdateFilePath(id int, filePath string) error
+	UpdateOriginalFilename(id int, filename string) error
+	UpdateDetails(id int, details ReportDetails) error
+	// UpdateDetailsAtVersion is UpdateDetails, applied only while the report is still at version; false otherwise
+	UpdateDetailsAtVersion(id int, details ReportDetails, version int) (bool, error)
+	SetVisibility(id int, visibility string) error
 	Delete(id int) error
+	BulkDelete(userID int, ids []int) ([]BulkResult, error)
+	BulkArchive(userID int, ids []int) ([]BulkResult, error)
 	GetPendingReports(limit int) ([]*Report, error)
+	ListByFilter(filter ReportFilter) ([]*Report, error)
+	// ListChangedSince returns the user's reports, archived ones included, changed since the given time
+	ListChangedSince(userID int, since time.Time) ([]*Report, error)
+	SetAnalysisMetadata(id int, promptVersion string, parseFailed bool) error
+	// SetLanguage records the language the report was printed in, as detected before analysis
+	SetLanguage(id int, language string) error
+	SetFeedback(id int, rating int) error
+	GetPromptVariantStats() ([]*PromptVariantStats, error)
+	// ListAnalysisSamples returns the newest analyses made with any of versions of reports uploaded since the given time
+	ListAnalysisSamples(versions []string, since time.Time, limit int) ([]*AnalysisSample, error)
 }
 
 // SQLReportRepository implements ReportRepository using SQL database
 type SQLReportRepository struct {
-	db *sql.DB
+	db     *sql.DB
+	readDB *sql.DB // Decision: Listing and metrics queries go here (replica or primary); reads that must see the latest write use db
 }
 
 // NewReportRepository creates a new report repository
 func NewReportRepository(db *sql.DB) ReportRepository {
-	return &SQLReportRepository{db: db}
+	return &SQLReportRepository{db: db, readDB: db}
+}
+
+// NewReportRepositoryWithReplica creates a report repository that sends list queries to a replica
+// Decision: Ownership checks and processing queries stay on the primary to avoid replica lag
+func NewReportRepositoryWithReplica(db, readDB *sql.DB) ReportRepository {
+	return &SQLReportRepository{db: db, readDB: readDB}
 }
 
 // Create inserts a new report into the database
 func (r *SQLReportRepository) Create(report *Report) error {
 	query := `
-		INSERT INTO reports (user_id, original_filename, file_path, file_type, file_size, processing_status)
-		VALUES (?, ?, ?, ?, ?, ?)
-		RETURNING id, upload_date, created_at, updated_at`
+		INSERT INTO reports (user_id, original_filename, file_path, file_type, file_size, processing_status, reading_level, plan)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id, upload_date, created_at, updated_at, version, visibility`
+
+	if report.ReadingLevel == "" {
+		report.ReadingLevel = ReadingLevelStandard
+	}
+	if report.Plan == "" {
+		report.Plan = PlanFree
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
 	// Decision: Set processing_status to 'pending' by default, timestamps auto-generated
-	row := r.db.QueryRow(query, report.UserID, report.OriginalFilename,
-		report.FilePath, report.FileType, report.FileSize, "pending")
+	row := tx.QueryRow(query, report.UserID, report.OriginalFilename,
+		report.FilePath, report.FileType, report.FileSize, "pending", report.ReadingLevel, report.Plan)
 
-	return row.Scan(&report.ID, &report.UploadDate, &report.CreatedAt, &report.UpdatedAt)
+	if err := row.Scan(&report.ID, &report.UploadDate, &report.CreatedAt, &report.UpdatedAt, &report.Version, &report.Visibility); err != nil {
+		return err
+	}
+	if err := recordStatusEvent(tx, report.ID, "pending", "", ""); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 // GetByID retrieves a report by its ID
 func (r *SQLReportRepository) GetByID(id int) (*Report, error) {
-	report := &Report{}
 	query := `
-		SELECT id, user_id, original_filename, file_path, file_type, file_size,
-			   simplified_summary, processing_status, upload_date, processed_at,
-			   created_at, updated_at
+		SELECT ` + reportColumns + `
 		FROM reports
 		WHERE id = ?`
 
-	row := r.db.QueryRow(query, id)
-	err := row.Scan(&report.ID, &report.UserID, &report.OriginalFilename,
-		&report.FilePath, &report.FileType, &report.FileSize,
-		&report.SimplifiedSummary, &report.ProcessingStatus, &report.UploadDate,
-		&report.ProcessedAt, &report.CreatedAt, &report.UpdatedAt)
-
+	report, err := scanReport(r.db.QueryRow(query, id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -82,42 +273,23 @@ func (r *SQLReportRepository) GetByID(id int) (*Report, error) {
 	return report, nil
 }
 
-// GetByUserID retrieves reports for a specific user with pagination
+// GetByUserID retrieves a user's active (non-archived) reports with pagination
 func (r *SQLReportRepository) GetByUserID(userID int, limit, offset int) ([]*Report, error) {
 	query := `
-		SELECT id, user_id, original_filename, file_path, file_type, file_size,
-			   simplified_summary, processing_status, upload_date, processed_at,
-			   created_at, updated_at
+		SELECT ` + reportColumns + `
 		FROM reports
-		WHERE user_id = ?
+		WHERE user_id = ? AND archived_at IS NULL
 		ORDER BY upload_date DESC
 		LIMIT ? OFFSET ?`
 
 	// Decision: Order by upload_date DESC to show newest reports first
-	rows, err := r.db.Query(query, userID, limit, offset)
+	rows, err := r.readDB.Query(query, userID, limit, offset)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var reports []*Report
-	for rows.Next() {
-		report := &Report{}
-		err := rows.Scan(&report.ID, &report.UserID, &report.OriginalFilename,
-			&report.FilePath, &report.FileType, &report.FileSize,
-			&report.SimplifiedSummary, &report.ProcessingStatus, &report.UploadDate,
-			&report.ProcessedAt, &report.CreatedAt, &report.UpdatedAt)
-		if err != nil {
-			return nil, err
-		}
-		reports = append(reports, report)
-	}
-
-	if err = rows.Err(); err != nil {
-		return nil, err
-	}
-
-	return reports, nil
+	return scanReports(rows)
 }
 
 // Update modifies an existing report
@@ -129,14 +301,9 @@ func (r *SQLReportRepository) Update(report *Report) error {
 			updated_at = CURRENT_TIMESTAMP
 		WHERE id = ?`
 
-	result, err := r.db.Exec(query, report.OriginalFilename, report.FileType,
-		report.FileSize, report.SimplifiedSummary, report.ProcessingStatus,
-		report.ProcessedAt, report.ID)
-	if err != nil {
-		return err
-	}
-
-	rowsAffected, err := result.RowsAffected()
+	rowsAffected, err := r.changeStatus(report.ID, report.ProcessingStatus, "", "", query,
+		report.OriginalFilename, report.FileType, report.FileSize, report.SimplifiedSummary,
+		report.ProcessingStatus, report.ProcessedAt, report.ID)
 	if err != nil {
 		return err
 	}
@@ -148,18 +315,87 @@ func (r *SQLReportRepository) Update(report *Report) error {
 	return nil
 }
 
-// UpdateProcessingStatus updates the processing status and summary
+// UpdateProcessingStatus updates the processing status and summary, clearing any earlier failure
 // Decision: Separate method for AI processing updates to avoid race conditions
 func (r *SQLReportRepository) UpdateProcessingStatus(id int, status string, summary string) error {
 	query := `
 		UPDATE reports
 		SET processing_status = ?, simplified_summary = ?,
 			processed_at = CASE WHEN ? = 'completed' THEN CURRENT_TIMESTAMP ELSE processed_at END,
+			error_code = '', error_detail = '',
 			updated_at = CURRENT_TIMESTAMP
 		WHERE id = ?`
 
-	// Decision: Set processed_at only when status is 'completed'
-	result, err := r.db.Exec(query, status, summary, status, id)
+	// Decision: Set processed_at only when status is 'completed'; a held report's analysis isn't replaced
+	rowsAffected, err := r.changeStatusUnlessHeld(reportAnalysisOwnerQuery, id, status, "", "", query, status, summary, status, id)
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// MarkFailed fails a report with one of the ProcessingError* codes and a description
+// Decision: The failure lives in its own columns so simplified_summary only ever holds an analysis
+func (r *SQLReportRepository) MarkFailed(id int, errorCode, errorDetail string) error {
+	query := `
+		UPDATE reports
+		SET processing_status = 'failed', simplified_summary = '', error_code = ?, error_detail = ?,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`
+
+	rowsAffected, err := r.changeStatus(id, "failed", errorCode, errorDetail, query, errorCode, errorDetail, id)
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// UpdateSummary rewrites the stored analysis without touching status or processed_at
+// Decision: Used by schema upgrades, which must not look like a fresh analysis
+func (r *SQLReportRepository) UpdateSummary(id int, summary string) error {
+	query := `UPDATE reports SET simplified_summary = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkLegalHold(tx, reportOwnerQuery, id); err != nil {
+		return err
+	}
+	result, err := tx.Exec(query, summary, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return tx.Commit()
+}
+
+// UpdateFilePath points a report at a relocated file
+func (r *SQLReportRepository) UpdateFilePath(id int, filePath string) error {
+	query := `UPDATE reports SET file_path = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+
+	result, err := r.db.Exec(query, filePath, id)
 	if err != nil {
 		return err
 	}
@@ -176,13 +412,102 @@ func (r *SQLReportRepository) UpdateProcessingStatus(id int, status string, summ
 	return nil
 }
 
-// Delete removes a report from the database
+// UpdateOriginalFilename rewrites the uploaded name, e.g. when a stricter filename policy is applied to old reports
+func (r *SQLReportRepository) UpdateOriginalFilename(id int, filename string) error {
+	query := `UPDATE reports SET original_filename = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+
+	result, err := r.db.Exec(query, filename, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// UpdateDetails replaces the user-editable fields of a report
+func (r *SQLReportRepository) UpdateDetails(id int, details ReportDetails) error {
+	updated, err := r.updateDetails(id, details, 0)
+	if err == nil && !updated {
+		return sql.ErrNoRows
+	}
+	return err
+}
+
+// UpdateDetailsAtVersion replaces the user-editable fields unless they were edited since they were read at version
+func (r *SQLReportRepository) UpdateDetailsAtVersion(id int, details ReportDetails, version int) (bool, error) {
+	return r.updateDetails(id, details, version)
+}
+
+// updateDetails writes the details and bumps the version; a version of 0 applies the edit unconditionally
+// Decision: Empty strings are stored as NULL so "cleared" and "never set" look the same
+func (r *SQLReportRepository) updateDetails(id int, details ReportDetails, version int) (bool, error) {
+	query := `
+		UPDATE reports
+		SET title = NULLIF(?, ''), report_date = ?, notes = NULLIF(?, ''), version = version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND (? = 0 OR version = ?)`
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if err := checkLegalHold(tx, reportOwnerQuery, id); err != nil {
+		return false, err
+	}
+	result, err := tx.Exec(query, details.Title, details.ReportDate, details.Notes, id, version, version)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, tx.Commit()
+}
+
+// SetVisibility changes who in the owner's organization may read the report
+func (r *SQLReportRepository) SetVisibility(id int, visibility string) error {
+	_, err := r.db.Exec(`UPDATE reports SET visibility = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, visibility, id)
+	return err
+}
+
+// Delete removes a report from the database, queueing the files of any further parts for the janitor
 func (r *SQLReportRepository) Delete(id int) error {
 	query := `DELETE FROM reports WHERE id = ?`
 
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkLegalHold(tx, reportOwnerQuery, id); err != nil {
+		return err
+	}
+	if err := queuePartFiles(tx, id); err != nil {
+		return err
+	}
+	if err := dropClaimPackage(tx, id); err != nil {
+		return err
+	}
+	if err := recordReportTombstone(tx, id); err != nil {
+		return err
+	}
+
 	// Decision: Hard delete for reports since they're user-generated content
 	// Chat messages will be cascade deleted due to foreign key constraint
-	result, err := r.db.Exec(query, id)
+	result, err := tx.Exec(query, id)
 	if err != nil {
 		return err
 	}
@@ -196,43 +521,410 @@ func (r *SQLReportRepository) Delete(id int) error {
 		return sql.ErrNoRows
 	}
 
+	return tx.Commit()
+}
+
+// queuePartFiles hands the files of a report's further parts to the janitor before the report is deleted
+func queuePartFiles(tx *sql.Tx, reportID int) error {
+	_, err := tx.Exec(`INSERT INTO file_cleanup_queue (file_path) SELECT file_path FROM report_parts WHERE report_id = ?`, reportID)
+	return err
+}
+
+// BulkDelete deletes the user's reports in one transaction and queues their files for the janitor
+// Decision: Files are only removed after commit, so a rollback never leaves rows pointing at missing files
+func (r *SQLReportRepository) BulkDelete(userID int, ids []int) ([]BulkResult, error) {
+	return r.bulkApply(userID, ids, func(tx *sql.Tx, report bulkTarget) (string, error) {
+		if err := checkLegalHold(tx, reportOwnerQuery, report.id); err == ErrLegalHold {
+			return BulkStatusLegalHold, nil
+		} else if err != nil {
+			return "", err
+		}
+		if err := queuePartFiles(tx, report.id); err != nil {
+			return "", err
+		}
+		if err := dropClaimPackage(tx, report.id); err != nil {
+			return "", err
+		}
+		if err := recordReportTombstone(tx, report.id); err != nil {
+			return "", err
+		}
+		if _, err := tx.Exec(`DELETE FROM reports WHERE id = ?`, report.id); err != nil {
+			return "", err
+		}
+		if _, err := tx.Exec(`INSERT INTO file_cleanup_queue (file_path) VALUES (?)`, report.filePath); err != nil {
+			return "", err
+		}
+		return BulkStatusDeleted, nil
+	})
+}
+
+// BulkArchive hides the user's reports from listings in one transaction
+func (r *SQLReportRepository) BulkArchive(userID int, ids []int) ([]BulkResult, error) {
+	return r.bulkApply(userID, ids, func(tx *sql.Tx, report bulkTarget) (string, error) {
+		if report.archived {
+			return BulkStatusAlreadyArchived, nil
+		}
+		_, err := tx.Exec(`UPDATE reports SET archived_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, report.id)
+		if err != nil {
+			return "", err
+		}
+		return BulkStatusArchived, nil
+	})
+}
+
+// bulkTarget is the subset of a report row bulk operations need
+type bulkTarget struct {
+	id       int
+	filePath string
+	archived bool
+}
+
+// bulkApply runs apply for each owned report inside a single transaction, recording per-item results
+// Decision: Missing or foreign IDs are reported per item rather than failing the whole batch;
+// only database errors roll everything back
+func (r *SQLReportRepository) bulkApply(userID int, ids []int, apply func(tx *sql.Tx, report bulkTarget) (string, error)) ([]BulkResult, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	results := make([]BulkResult, 0, len(ids))
+	for _, id := range ids {
+		var ownerID int
+		var archivedAt *time.Time
+		target := bulkTarget{id: id}
+
+		err := tx.QueryRow(`SELECT user_id, file_path, archived_at FROM reports WHERE id = ?`, id).
+			Scan(&ownerID, &target.filePath, &archivedAt)
+		if err == sql.ErrNoRows {
+			results = append(results, BulkResult{ReportID: id, Status: BulkStatusNotFound})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if ownerID != userID {
+			results = append(results, BulkResult{ReportID: id, Status: BulkStatusForbidden})
+			continue
+		}
+
+		target.archived = archivedAt != nil
+		status, err := apply(tx, target)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, BulkResult{ReportID: id, Status: status})
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// ClaimForProcessing marks a report as processing if it is still in fromStatus, reporting whether this caller won it
+// Decision: A single conditional UPDATE is atomic on every database, so of several workers that
+// saw the same pending report exactly one claims it, without locks or extra tables. Claiming clears the
+// analysis, so a held report that has one is refused rather than re-analyzed
+func (r *SQLReportRepository) ClaimForProcessing(id int, fromStatus string) (bool, error) {
+	query := `
+		UPDATE reports
+		SET processing_status = 'processing', simplified_summary = '', error_code = '', error_detail = '',
+			retry_at = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND processing_status = ?`
+
+	rowsAffected, err := r.changeStatusUnlessHeld(reportAnalysisOwnerQuery, id, "processing", "", "", query, id, fromStatus)
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected == 1, nil
+}
+
+// ScheduleRetry puts a processing report back in the queue, to be picked up no earlier than at
+// Decision: The report reads as pending to its owner while it waits; the failure that caused the retry
+// is only kept on its status history, so it isn't shown as the reason for a report that may yet succeed
+func (r *SQLReportRepository) ScheduleRetry(id int, errorCode, errorDetail string, at time.Time) error {
+	query := `
+		UPDATE reports
+		SET processing_status = 'pending', retry_at = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND processing_status = 'processing'`
+
+	rowsAffected, err := r.changeStatus(id, "pending", errorCode, errorDetail, query, sqliteTimestamp(at), id)
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
 	return nil
 }
 
-// GetPendingReports retrieves reports that need AI processing
+// ListStaleProcessing returns reports that have been processing since before the given time, oldest first
+func (r *SQLReportRepository) ListStaleProcessing(before time.Time, limit int) ([]*Report, error) {
+	query := `
+		SELECT ` + reportColumns + `
+		FROM reports
+		WHERE processing_status = 'processing' AND updated_at < ?
+		ORDER BY updated_at ASC
+		LIMIT ?`
+
+	rows, err := r.db.Query(query, sqliteTimestamp(before), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanReports(rows)
+}
+
+// RequeueStale puts a report processing since before the given time back in the queue
+// Decision: Conditional on the status and time it was listed with, so when several workers recover
+// the same report only one requeues it, and a report that finished meanwhile is left alone
+func (r *SQLReportRepository) RequeueStale(id int, before time.Time) (bool, error) {
+	query := `
+		UPDATE reports
+		SET processing_status = 'pending', retry_at = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND processing_status = 'processing' AND updated_at < ?`
+
+	rowsAffected, err := r.changeStatus(id, "pending", "", "", query, id, sqliteTimestamp(before))
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected == 1, nil
+}
+
+// FailStale fails a report processing since before the given time, under the same condition as RequeueStale
+func (r *SQLReportRepository) FailStale(id int, before time.Time, errorCode, errorDetail string) (bool, error) {
+	query := `
+		UPDATE reports
+		SET processing_status = 'failed', simplified_summary = '', error_code = ?, error_detail = ?,
+			retry_at = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND processing_status = 'processing' AND updated_at < ?`
+
+	rowsAffected, err := r.changeStatus(id, "failed", errorCode, errorDetail, query, errorCode, errorDetail, id, sqliteTimestamp(before))
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected == 1, nil
+}
+
+// GetPendingReports retrieves reports that need AI processing, leaving out those waiting to be retried
+// Decision: Listing claims nothing; ClaimForProcessing decides which worker processes each report
 func (r *SQLReportRepository) GetPendingReports(limit int) ([]*Report, error) {
 	query := `
-		SELECT id, user_id, original_filename, file_path, file_type, file_size,
-			   simplified_summary, processing_status, upload_date, processed_at,
-			   created_at, updated_at
+		SELECT ` + reportColumns + `
 		FROM reports
-		WHERE processing_status = 'pending'
-		ORDER BY upload_date ASC
+		WHERE processing_status = 'pending' AND (retry_at IS NULL OR retry_at <= ?)
+		ORDER BY upload_date ASC, id ASC
 		LIMIT ?`
 
-	// Decision: Process oldest pending reports first (FIFO)
-	rows, err := r.db.Query(query, limit)
+	// Decision: Process oldest pending reports first (FIFO); id breaks ties within a second, as GetQueuePosition counts
+	rows, err := r.db.Query(query, sqliteTimestamp(time.Now()), limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var reports []*Report
+	return scanReports(rows)
+}
+
+// ListByFilter retrieves reports matching the given filter, oldest first
+func (r *SQLReportRepository) ListByFilter(filter ReportFilter) ([]*Report, error) {
+	query := `
+		SELECT ` + reportColumns + `
+		FROM reports
+		WHERE 1 = 1`
+	var args []any
+
+	// Decision: Build WHERE clause incrementally with placeholders only
+	if filter.Status != "" {
+		query += " AND processing_status = ?"
+		args = append(args, filter.Status)
+	}
+	if filter.UserID > 0 {
+		query += " AND user_id = ?"
+		args = append(args, filter.UserID)
+	}
+	if filter.Since != nil {
+		query += " AND upload_date >= ?"
+		args = append(args, *filter.Since)
+	}
+	if filter.Until != nil {
+		query += " AND upload_date < ?"
+		args = append(args, *filter.Until)
+	}
+	query += " ORDER BY upload_date ASC"
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := r.readDB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanReports(rows)
+}
+
+// ListChangedSince retrieves the user's reports updated at or after since, least recently updated first
+// Decision: Reads the primary rather than a replica, so a sync never misses a change made just before its checkpoint
+func (r *SQLReportRepository) ListChangedSince(userID int, since time.Time) ([]*Report, error) {
+	query := `
+		SELECT ` + reportColumns + `
+		FROM reports
+		WHERE user_id = ? AND updated_at >= ?
+		ORDER BY updated_at ASC, id ASC`
+
+	rows, err := r.db.Query(query, userID, sqliteTimestamp(since))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanReports(rows)
+}
+
+// SetAnalysisMetadata records which prompt produced the analysis and whether parsing fell back
+func (r *SQLReportRepository) SetAnalysisMetadata(id int, promptVersion string, parseFailed bool) error {
+	query := `
+		UPDATE reports
+		SET prompt_version = ?, parse_failed = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`
+
+	result, err := r.db.Exec(query, promptVersion, parseFailed, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// SetLanguage records the ISO 639-1 code of the language the report was printed in
+func (r *SQLReportRepository) SetLanguage(id int, language string) error {
+	result, err := r.db.Exec(`UPDATE reports SET language = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, language, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// SetFeedback stores the user's 1-5 rating of the analysis
+func (r *SQLReportRepository) SetFeedback(id int, rating int) error {
+	query := `UPDATE reports SET feedback_rating = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+
+	// Decision: Latest rating wins - users can change their mind
+	result, err := r.db.Exec(query, rating, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetPromptVariantStats compares parse failures and feedback across prompt versions
+func (r *SQLReportRepository) GetPromptVariantStats() ([]*PromptVariantStats, error) {
+	query := `
+		SELECT prompt_version, COUNT(*),
+			   SUM(CASE WHEN parse_failed THEN 1 ELSE 0 END),
+			   COUNT(feedback_rating), AVG(feedback_rating)
+		FROM reports
+		WHERE prompt_version IS NOT NULL AND prompt_version != ''
+		GROUP BY prompt_version
+		ORDER BY prompt_version`
+
+	rows, err := r.readDB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []*PromptVariantStats
 	for rows.Next() {
-		report := &Report{}
-		err := rows.Scan(&report.ID, &report.UserID, &report.OriginalFilename,
-			&report.FilePath, &report.FileType, &report.FileSize,
-			&report.SimplifiedSummary, &report.ProcessingStatus, &report.UploadDate,
-			&report.ProcessedAt, &report.CreatedAt, &report.UpdatedAt)
-		if err != nil {
+		s := &PromptVariantStats{}
+		var avg sql.NullFloat64
+		if err := rows.Scan(&s.PromptVersion, &s.Total, &s.ParseFailures, &s.FeedbackCount, &avg); err != nil {
 			return nil, err
 		}
-		reports = append(reports, report)
+		if avg.Valid {
+			s.AverageRating = &avg.Float64
+		}
+		stats = append(stats, s)
 	}
 
 	if err = rows.Err(); err != nil {
 		return nil, err
 	}
 
-	return reports, nil
-}
\ No newline at end of file
+	return stats, nil
+}
+
+// ListAnalysisSamples reads prompt version, parse status, and analysis of recent reports
+func (r *SQLReportRepository) ListAnalysisSamples(versions []string, since time.Time, limit int) ([]*AnalysisSample, error) {
+	if len(versions) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		SELECT prompt_version, COALESCE(parse_failed, FALSE), COALESCE(simplified_summary, '')
+		FROM reports
+		WHERE prompt_version IN (?` + strings.Repeat(", ?", len(versions)-1) + `) AND upload_date >= ?
+		ORDER BY upload_date DESC, id DESC
+		LIMIT ?`
+
+	args := make([]any, 0, len(versions)+2)
+	for _, version := range versions {
+		args = append(args, version)
+	}
+	args = append(args, sqliteTimestamp(since), limit)
+
+	rows, err := r.readDB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var samples []*AnalysisSample
+	for rows.Next() {
+		sample := &AnalysisSample{}
+		if err := rows.Scan(&sample.PromptVersion, &sample.ParseFailed, &sample.SimplifiedSummary); err != nil {
+			return nil, err
+		}
+		samples = append(samples, sample)
+	}
+	return samples, rows.Err()
+}
@@ -0,0 +1,153 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ReportPart is a further page of a multi-part report; the report itself is part 1
+type ReportPart struct {
+	ID               int       `json:"id" db:"id"`
+	ReportID         int       `json:"report_id" db:"report_id"`
+	PartNumber       int       `json:"part_number" db:"part_number"`
+	OriginalFilename string    `json:"original_filename" db:"original_filename"`
+	FilePath         string    `json:"-" db:"file_path"` // Internal only - never serialize server paths
+	FileType         string    `json:"file_type" db:"file_type"`
+	FileSize         int64     `json:"file_size" db:"file_size"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+}
+
+// ReportPartRepository defines the interface for multi-part report database operations
+type ReportPartRepository interface {
+	ListByReport(reportID int) ([]*ReportPart, error)
+	// Merge appends source and its parts to target, deletes source, and puts target back in the queue,
+	// reporting false without changes when target is being processed; it returns ErrLegalHold if either
+	// report's owner is under a legal hold
+	Merge(targetID, sourceID int) (bool, error)
+}
+
+// SQLReportPartRepository implements ReportPartRepository using SQL database
+type SQLReportPartRepository struct {
+	db *sql.DB
+}
+
+// NewReportPartRepository creates a new report part repository
+func NewReportPartRepository(db *sql.DB) ReportPartRepository {
+	return &SQLReportPartRepository{db: db}
+}
+
+// ListByReport returns a report's further parts in page order
+func (r *SQLReportPartRepository) ListByReport(reportID int) ([]*ReportPart, error) {
+	query := `
+		SELECT id, report_id, part_number, original_filename, file_path, file_type, file_size, created_at
+		FROM report_parts
+		WHERE report_id = ?
+		ORDER BY part_number ASC`
+
+	rows, err := r.db.Query(query, reportID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var parts []*ReportPart
+	for rows.Next() {
+		part := &ReportPart{}
+		if err := rows.Scan(&part.ID, &part.ReportID, &part.PartNumber, &part.OriginalFilename,
+			&part.FilePath, &part.FileType, &part.FileSize, &part.CreatedAt); err != nil {
+			return nil, err
+		}
+		parts = append(parts, part)
+	}
+
+	return parts, rows.Err()
+}
+
+// Merge moves source's files onto target in one transaction
+// Decision: The files keep their paths and only change owner row, so nothing on disk moves and a
+// rollback leaves both reports as they were; source's analysis and chat go with its row
+func (r *SQLReportPartRepository) Merge(targetID, sourceID int) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// Decision: Both ends are checked: source is deleted and target's analysis is cleared for the rerun
+	for _, id := range []int{targetID, sourceID} {
+		if err := checkLegalHold(tx, reportOwnerQuery, id); err != nil {
+			return false, err
+		}
+	}
+
+	// Claim target first, so an analysis starting now can't overwrite the merged one with a fragment
+	result, err := tx.Exec(`
+		UPDATE reports
+		SET processing_status = 'pending', simplified_summary = '', error_code = '', error_detail = '',
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND processing_status <> 'processing'`, targetID)
+	if err != nil {
+		return false, err
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
+		return false, err
+	}
+	if err := recordStatusEvent(tx, targetID, "pending", "", ""); err != nil {
+		return false, err
+	}
+
+	// Source's own file comes first, then any parts it already had
+	moved := []*ReportPart{{}}
+	if err := tx.QueryRow(`SELECT original_filename, file_path, file_type, file_size FROM reports WHERE id = ?`, sourceID).
+		Scan(&moved[0].OriginalFilename, &moved[0].FilePath, &moved[0].FileType, &moved[0].FileSize); err != nil {
+		return false, err
+	}
+	rows, err := tx.Query(`
+		SELECT original_filename, file_path, file_type, file_size
+		FROM report_parts
+		WHERE report_id = ?
+		ORDER BY part_number ASC`, sourceID)
+	if err != nil {
+		return false, err
+	}
+	for rows.Next() {
+		part := &ReportPart{}
+		if err := rows.Scan(&part.OriginalFilename, &part.FilePath, &part.FileType, &part.FileSize); err != nil {
+			rows.Close()
+			return false, err
+		}
+		moved = append(moved, part)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+
+	var last int
+	if err := tx.QueryRow(`SELECT COALESCE(MAX(part_number), 1) FROM report_parts WHERE report_id = ?`, targetID).Scan(&last); err != nil {
+		return false, err
+	}
+	for i, part := range moved {
+		if _, err := tx.Exec(`
+			INSERT INTO report_parts (report_id, part_number, original_filename, file_path, file_type, file_size)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			targetID, last+i+1, part.OriginalFilename, part.FilePath, part.FileType, part.FileSize); err != nil {
+			return false, err
+		}
+	}
+
+	// Both packages describe files and an analysis that no longer exist as they were
+	for _, id := range []int{targetID, sourceID} {
+		if err := dropClaimPackage(tx, id); err != nil {
+			return false, err
+		}
+	}
+	if err := recordReportTombstone(tx, sourceID); err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(`DELETE FROM reports WHERE id = ?`, sourceID); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ReportStatusEvent is one processing status a report entered
+type ReportStatusEvent struct {
+	ID          int       `json:"id" db:"id"`
+	ReportID    int       `json:"report_id" db:"report_id"`
+	Status      string    `json:"status" db:"status"`
+	ErrorCode   string    `json:"error_code,omitempty" db:"error_code"`     // Set when Status is failed
+	ErrorDetail string    `json:"error_detail,omitempty" db:"error_detail"` // Set when Status is failed
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// recordStatusEvent appends status to a report's history unless it is already the latest entry
+// Decision: Written in the same transaction as the status change, so the history can't miss or invent a transition
+func recordStatusEvent(tx *sql.Tx, reportID int, status, errorCode, errorDetail string) error {
+	query := `
+		INSERT INTO report_status_events (report_id, status, error_code, error_detail)
+		SELECT ?, ?, ?, ?
+		WHERE COALESCE((SELECT status FROM report_status_events WHERE report_id = ? ORDER BY id DESC LIMIT 1), '') <> ?`
+
+	_, err := tx.Exec(query, reportID, status, errorCode, errorDetail, reportID, status)
+	return err
+}
+
+// changeStatus runs update, which moves report id to status, and records the transition when a row changed
+func (r *SQLReportRepository) changeStatus(id int, status, errorCode, errorDetail, update string, args ...any) (int64, error) {
+	return r.changeStatusUnlessHeld("", id, status, errorCode, errorDetail, update, args...)
+}
+
+// changeStatusUnlessHeld is changeStatus, refused with ErrLegalHold if ownerQuery is set and selects a held owner
+func (r *SQLReportRepository) changeStatusUnlessHeld(ownerQuery string, id int, status, errorCode, errorDetail, update string, args ...any) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if ownerQuery != "" {
+		if err := checkLegalHold(tx, ownerQuery, id); err != nil {
+			return 0, err
+		}
+	}
+	result, err := tx.Exec(update, args...)
+	if err != nil {
+		return 0, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	if rowsAffected > 0 {
+		if err := recordStatusEvent(tx, id, status, errorCode, errorDetail); err != nil {
+			return 0, err
+		}
+	}
+
+	return rowsAffected, tx.Commit()
+}
+
+// ListStatusEvents returns the statuses a report went through, oldest first
+func (r *SQLReportRepository) ListStatusEvents(reportID int) ([]*ReportStatusEvent, error) {
+	query := `
+		SELECT id, report_id, status, error_code, error_detail, created_at
+		FROM report_status_events
+		WHERE report_id = ?
+		ORDER BY id ASC`
+
+	rows, err := r.db.Query(query, reportID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*ReportStatusEvent
+	for rows.Next() {
+		event := &ReportStatusEvent{}
+		if err := rows.Scan(&event.ID, &event.ReportID, &event.Status, &event.ErrorCode,
+			&event.ErrorDetail, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
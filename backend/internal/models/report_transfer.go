@@ -0,0 +1,199 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Report transfer statuses
+const (
+	TransferPending   = "pending"
+	TransferAccepted  = "accepted"
+	TransferDeclined  = "declined"
+	TransferCancelled = "cancelled"
+)
+
+// ReportTransfer is a request to move a report to another user
+// Decision: Completed rows are kept as the audit record of every ownership change
+type ReportTransfer struct {
+	ID          int        `json:"id" db:"id"`
+	ReportID    int        `json:"report_id" db:"report_id"`
+	FromUserID  int        `json:"from_user_id" db:"from_user_id"`
+	ToUserID    int        `json:"to_user_id" db:"to_user_id"`
+	Status      string     `json:"status" db:"status"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	RespondedAt *time.Time `json:"responded_at" db:"responded_at"` // Nullable
+}
+
+// ReportTransferRepository defines the interface for report transfer database operations
+type ReportTransferRepository interface {
+	Create(transfer *ReportTransfer) error
+	GetByID(id int) (*ReportTransfer, error)
+	GetPendingForReport(reportID int) (*ReportTransfer, error)
+	ListPendingForRecipient(userID int) ([]*ReportTransfer, error)
+	ListByReport(reportID int) ([]*ReportTransfer, error)
+	Accept(id int) error
+	Resolve(id int, status string) error
+}
+
+// SQLReportTransferRepository implements ReportTransferRepository using SQL database
+type SQLReportTransferRepository struct {
+	db *sql.DB
+}
+
+// NewReportTransferRepository creates a new report transfer repository
+func NewReportTransferRepository(db *sql.DB) ReportTransferRepository {
+	return &SQLReportTransferRepository{db: db}
+}
+
+const transferColumns = `id, report_id, from_user_id, to_user_id, status, created_at, responded_at`
+
+// scanTransfer reads a single transfer selected with transferColumns
+func scanTransfer(row rowScanner) (*ReportTransfer, error) {
+	transfer := &ReportTransfer{}
+	err := row.Scan(&transfer.ID, &transfer.ReportID, &transfer.FromUserID, &transfer.ToUserID,
+		&transfer.Status, &transfer.CreatedAt, &transfer.RespondedAt)
+	if err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// Create inserts a new pending transfer
+func (r *SQLReportTransferRepository) Create(transfer *ReportTransfer) error {
+	query := `
+		INSERT INTO report_transfers (report_id, from_user_id, to_user_id, status)
+		VALUES (?, ?, ?, ?)
+		RETURNING id, status, created_at`
+
+	row := r.db.QueryRow(query, transfer.ReportID, transfer.FromUserID, transfer.ToUserID, TransferPending)
+	return row.Scan(&transfer.ID, &transfer.Status, &transfer.CreatedAt)
+}
+
+// GetByID retrieves a transfer by its ID
+func (r *SQLReportTransferRepository) GetByID(id int) (*ReportTransfer, error) {
+	query := `SELECT ` + transferColumns + ` FROM report_transfers WHERE id = ?`
+
+	transfer, err := scanTransfer(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return transfer, err
+}
+
+// GetPendingForReport returns the open transfer for a report, if any
+func (r *SQLReportTransferRepository) GetPendingForReport(reportID int) (*ReportTransfer, error) {
+	query := `SELECT ` + transferColumns + ` FROM report_transfers WHERE report_id = ? AND status = ?`
+
+	transfer, err := scanTransfer(r.db.QueryRow(query, reportID, TransferPending))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return transfer, err
+}
+
+// ListPendingForRecipient returns transfers awaiting the user's response, oldest first
+func (r *SQLReportTransferRepository) ListPendingForRecipient(userID int) ([]*ReportTransfer, error) {
+	query := `
+		SELECT ` + transferColumns + `
+		FROM report_transfers
+		WHERE to_user_id = ? AND status = ?
+		ORDER BY created_at ASC`
+
+	return r.list(query, userID, TransferPending)
+}
+
+// ListByReport returns the full transfer history of a report, oldest first
+func (r *SQLReportTransferRepository) ListByReport(reportID int) ([]*ReportTransfer, error) {
+	query := `
+		SELECT ` + transferColumns + `
+		FROM report_transfers
+		WHERE report_id = ?
+		ORDER BY created_at ASC, id ASC`
+
+	return r.list(query, reportID)
+}
+
+func (r *SQLReportTransferRepository) list(query string, args ...any) ([]*ReportTransfer, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transfers []*ReportTransfer
+	for rows.Next() {
+		transfer, err := scanTransfer(rows)
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, transfer)
+	}
+
+	return transfers, rows.Err()
+}
+
+// Accept completes a pending transfer and moves the report in one transaction
+// Decision: The owner check in the UPDATE guards against the report changing hands concurrently
+func (r *SQLReportTransferRepository) Accept(id int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var reportID, fromUserID, toUserID int
+	err = tx.QueryRow(`
+		UPDATE report_transfers SET status = ?, responded_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status = ?
+		RETURNING report_id, from_user_id, to_user_id`,
+		TransferAccepted, id, TransferPending).Scan(&reportID, &fromUserID, &toUserID)
+	if err != nil {
+		return err
+	}
+
+	// Decision: A held report stays with its owner, where the hold covers it
+	if err := checkLegalHold(tx, reportOwnerQuery, reportID); err != nil {
+		return err
+	}
+
+	// The report leaves the sender's account as far as their devices are concerned
+	if err := recordReportTombstone(tx, reportID); err != nil {
+		return err
+	}
+	result, err := tx.Exec(`UPDATE reports SET user_id = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND user_id = ?`,
+		toUserID, reportID, fromUserID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return tx.Commit()
+}
+
+// Resolve closes a pending transfer without moving the report (declined or cancelled)
+func (r *SQLReportTransferRepository) Resolve(id int, status string) error {
+	query := `UPDATE report_transfers SET status = ?, responded_at = CURRENT_TIMESTAMP WHERE id = ? AND status = ?`
+
+	result, err := r.db.Exec(query, status, id, TransferPending)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// SafetyIntervention records a chat answer the safety filter changed before it was stored
+type SafetyIntervention struct {
+	ID             int       `json:"id" db:"id"`
+	UserID         int       `json:"user_id" db:"user_id"`
+	ReportID       int       `json:"report_id" db:"report_id"`
+	MessageID      int       `json:"message_id" db:"message_id"`
+	Level          string    `json:"level" db:"level"`
+	Categories     []string  `json:"categories" db:"categories"`
+	Removed        []string  `json:"removed" db:"removed"`
+	OriginalAnswer string    `json:"original_answer" db:"original_answer"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// SafetyInterventionFilter narrows an intervention listing; zero values match everything
+type SafetyInterventionFilter struct {
+	Category string
+	UserID   int
+	Limit    int
+	Offset   int
+}
+
+// SafetyInterventionRepository defines the interface for chat safety intervention database operations
+type SafetyInterventionRepository interface {
+	Create(intervention *SafetyIntervention) error
+	List(filter SafetyInterventionFilter) ([]*SafetyIntervention, error)
+}
+
+// SQLSafetyInterventionRepository implements SafetyInterventionRepository using SQL database
+type SQLSafetyInterventionRepository struct {
+	db *sql.DB
+}
+
+// NewSafetyInterventionRepository creates a new safety intervention repository
+func NewSafetyInterventionRepository(db *sql.DB) SafetyInterventionRepository {
+	return &SQLSafetyInterventionRepository{db: db}
+}
+
+// Create records an intervention
+func (r *SQLSafetyInterventionRepository) Create(intervention *SafetyIntervention) error {
+	removed, err := json.Marshal(nonNilStrings(intervention.Removed))
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO chat_safety_interventions (user_id, report_id, message_id, level, categories, removed, original_answer)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		RETURNING id, created_at`
+
+	row := r.db.QueryRow(query, intervention.UserID, intervention.ReportID, intervention.MessageID, intervention.Level,
+		strings.Join(intervention.Categories, ","), string(removed), intervention.OriginalAnswer)
+	return row.Scan(&intervention.ID, &intervention.CreatedAt)
+}
+
+// List returns matching interventions, newest first
+func (r *SQLSafetyInterventionRepository) List(filter SafetyInterventionFilter) ([]*SafetyIntervention, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = 50
+	}
+
+	query := `
+		SELECT id, user_id, report_id, message_id, level, categories, removed, original_answer, created_at
+		FROM chat_safety_interventions
+		WHERE (? = '' OR ',' || categories || ',' LIKE '%,' || ? || ',%') AND (? = 0 OR user_id = ?)
+		ORDER BY created_at DESC, id DESC
+		LIMIT ? OFFSET ?`
+
+	rows, err := r.db.Query(query, filter.Category, filter.Category, filter.UserID, filter.UserID, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var interventions []*SafetyIntervention
+	for rows.Next() {
+		intervention := &SafetyIntervention{}
+		var categories, removed string
+		if err := rows.Scan(&intervention.ID, &intervention.UserID, &intervention.ReportID, &intervention.MessageID,
+			&intervention.Level, &categories, &removed, &intervention.OriginalAnswer, &intervention.CreatedAt); err != nil {
+			return nil, err
+		}
+		intervention.Categories = strings.Split(categories, ",")
+		if err := json.Unmarshal([]byte(removed), &intervention.Removed); err != nil {
+			return nil, err
+		}
+		interventions = append(interventions, intervention)
+	}
+
+	return interventions, rows.Err()
+}
@@ -0,0 +1,152 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Reasons a session ended before its token expired
+const (
+	SessionEndedLimit   = "limit"   // Signed out because the user signed in on more devices than allowed
+	SessionEndedLogout  = "logout"  // The user signed out
+	SessionEndedRevoked = "revoked" // The user signed it out from another device
+)
+
+// Session is one sign-in, tracked while a limit on concurrent sessions is configured
+type Session struct {
+	ID            int        `json:"id" db:"id"`
+	UserID        int        `json:"user_id" db:"user_id"`
+	TokenID       string     `json:"-" db:"token_id"` // The jti of the session's tokens
+	UserAgent     string     `json:"user_agent" db:"user_agent"`
+	IPAddress     string     `json:"ip_address" db:"ip_address"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	LastSeenAt    time.Time  `json:"last_seen_at" db:"last_seen_at"`
+	ExpiresAt     time.Time  `json:"expires_at" db:"expires_at"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	RevokedReason string     `json:"revoked_reason,omitempty" db:"revoked_reason"`
+}
+
+// Active reports whether the session's tokens are still accepted at now
+func (s *Session) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// SessionRepository defines the interface for session database operations
+type SessionRepository interface {
+	Create(session *Session) error
+	GetByTokenID(tokenID string) (*Session, error)
+	// ListByUser returns the user's sessions not yet expired at now, revoked ones included, newest first
+	ListByUser(userID int, now time.Time) ([]*Session, error)
+	// Touch records a request on the session and moves its expiry when its token was refreshed
+	Touch(id int, lastSeenAt, expiresAt time.Time) error
+	// Revoke ends one of the user's active sessions, returning false if there is no such session
+	Revoke(id, userID int, reason string, at time.Time) (bool, error)
+	// DeleteExpired removes the user's sessions whose tokens expired before now
+	DeleteExpired(userID int, now time.Time) error
+}
+
+// SQLSessionRepository implements SessionRepository using SQL database
+type SQLSessionRepository struct {
+	db *sql.DB
+}
+
+// NewSessionRepository creates a new session repository
+func NewSessionRepository(db *sql.DB) SessionRepository {
+	return &SQLSessionRepository{db: db}
+}
+
+const sessionColumns = `id, user_id, token_id, user_agent, ip_address, created_at, last_seen_at, expires_at, revoked_at, revoked_reason`
+
+func scanSession(row rowScanner) (*Session, error) {
+	session := &Session{}
+	var revokedAt sql.NullTime
+	if err := row.Scan(&session.ID, &session.UserID, &session.TokenID, &session.UserAgent, &session.IPAddress,
+		&session.CreatedAt, &session.LastSeenAt, &session.ExpiresAt, &revokedAt, &session.RevokedReason); err != nil {
+		return nil, err
+	}
+	if revokedAt.Valid {
+		session.RevokedAt = &revokedAt.Time
+	}
+	return session, nil
+}
+
+// Create stores a new session
+// Decision: Timestamps are written in UTC so expiry comparisons in SQL order correctly
+func (r *SQLSessionRepository) Create(session *Session) error {
+	now := time.Now().UTC()
+	result, err := r.db.Exec(`
+		INSERT INTO sessions (user_id, token_id, user_agent, ip_address, created_at, last_seen_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		session.UserID, session.TokenID, session.UserAgent, session.IPAddress, now, now, session.ExpiresAt.UTC())
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	session.ID = int(id)
+	session.CreatedAt = now
+	session.LastSeenAt = now
+	return nil
+}
+
+// GetByTokenID returns the session its tokens name, or nil if there is none
+func (r *SQLSessionRepository) GetByTokenID(tokenID string) (*Session, error) {
+	session, err := scanSession(r.db.QueryRow(`SELECT `+sessionColumns+` FROM sessions WHERE token_id = ?`, tokenID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return session, err
+}
+
+// ListByUser returns the user's unexpired sessions, newest first
+func (r *SQLSessionRepository) ListByUser(userID int, now time.Time) ([]*Session, error) {
+	rows, err := r.db.Query(`SELECT `+sessionColumns+` FROM sessions WHERE user_id = ? AND expires_at > ?
+		ORDER BY created_at DESC, id DESC`, userID, now.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []*Session
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+// Touch updates the session's last use and expiry
+func (r *SQLSessionRepository) Touch(id int, lastSeenAt, expiresAt time.Time) error {
+	_, err := r.db.Exec(`UPDATE sessions SET last_seen_at = ?, expires_at = ? WHERE id = ?`,
+		lastSeenAt.UTC(), expiresAt.UTC(), id)
+	return err
+}
+
+// Revoke marks the session ended if it belongs to the user and is still active
+func (r *SQLSessionRepository) Revoke(id, userID int, reason string, at time.Time) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE sessions SET revoked_at = ?, revoked_reason = ?
+		WHERE id = ? AND user_id = ? AND revoked_at IS NULL AND expires_at > ?`,
+		at.UTC(), reason, id, userID, at.UTC())
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+// DeleteExpired removes the user's expired sessions; revoked ones are kept until then so their
+// tokens can be told why they stopped working
+func (r *SQLSessionRepository) DeleteExpired(userID int, now time.Time) error {
+	_, err := r.db.Exec(`DELETE FROM sessions WHERE user_id = ? AND expires_at <= ?`, userID, now.UTC())
+	return err
+}
@@ -0,0 +1,153 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ShareLink grants read-only access to a report's summary through an unguessable URL
+// Decision: Only a hash of the token is stored, like passwords, so a database leak exposes no live links
+type ShareLink struct {
+	ID             int        `json:"id" db:"id"`
+	ReportID       int        `json:"report_id" db:"report_id"`
+	CreatedBy      int        `json:"created_by" db:"created_by"`
+	TokenHash      string     `json:"-" db:"token_hash"`
+	PINHash        string     `json:"-" db:"pin_hash"` // Empty when the link needs no PIN
+	FailedAttempts int        `json:"failed_attempts" db:"failed_attempts"`
+	LockedAt       *time.Time `json:"locked_at" db:"locked_at"` // Nullable; set after too many wrong PINs
+	ExpiresAt      time.Time  `json:"expires_at" db:"expires_at"`
+	RevokedAt      *time.Time `json:"revoked_at" db:"revoked_at"`         // Nullable
+	LastViewedAt   *time.Time `json:"last_viewed_at" db:"last_viewed_at"` // Nullable
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+}
+
+// RequiresPIN reports whether viewers must enter a PIN
+func (s *ShareLink) RequiresPIN() bool {
+	return s.PINHash != ""
+}
+
+// ShareLinkRepository defines the interface for share link database operations
+type ShareLinkRepository interface {
+	Create(link *ShareLink) error
+	GetByID(id int) (*ShareLink, error)
+	GetByTokenHash(tokenHash string) (*ShareLink, error)
+	ListByReport(reportID int) ([]*ShareLink, error)
+	Revoke(id int) error
+	RecordPINAttempt(id int) (int, error)
+	Lock(id int) error
+	RecordView(id int) error
+}
+
+// SQLShareLinkRepository implements ShareLinkRepository using SQL database
+type SQLShareLinkRepository struct {
+	db *sql.DB
+}
+
+// NewShareLinkRepository creates a new share link repository
+func NewShareLinkRepository(db *sql.DB) ShareLinkRepository {
+	return &SQLShareLinkRepository{db: db}
+}
+
+const shareLinkColumns = `id, report_id, created_by, token_hash, COALESCE(pin_hash, ''), failed_attempts,
+	locked_at, expires_at, revoked_at, last_viewed_at, created_at`
+
+// scanShareLink reads a single link selected with shareLinkColumns
+func scanShareLink(row rowScanner) (*ShareLink, error) {
+	link := &ShareLink{}
+	err := row.Scan(&link.ID, &link.ReportID, &link.CreatedBy, &link.TokenHash, &link.PINHash,
+		&link.FailedAttempts, &link.LockedAt, &link.ExpiresAt, &link.RevokedAt, &link.LastViewedAt, &link.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+// Create inserts a new share link
+func (r *SQLShareLinkRepository) Create(link *ShareLink) error {
+	query := `
+		INSERT INTO report_share_links (report_id, created_by, token_hash, pin_hash, expires_at)
+		VALUES (?, ?, ?, NULLIF(?, ''), ?)
+		RETURNING id, created_at`
+
+	row := r.db.QueryRow(query, link.ReportID, link.CreatedBy, link.TokenHash, link.PINHash, link.ExpiresAt.UTC())
+	return row.Scan(&link.ID, &link.CreatedAt)
+}
+
+// GetByID retrieves a share link by its ID
+func (r *SQLShareLinkRepository) GetByID(id int) (*ShareLink, error) {
+	query := `SELECT ` + shareLinkColumns + ` FROM report_share_links WHERE id = ?`
+
+	link, err := scanShareLink(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return link, err
+}
+
+// GetByTokenHash retrieves the link a viewer's token belongs to
+func (r *SQLShareLinkRepository) GetByTokenHash(tokenHash string) (*ShareLink, error) {
+	query := `SELECT ` + shareLinkColumns + ` FROM report_share_links WHERE token_hash = ?`
+
+	link, err := scanShareLink(r.db.QueryRow(query, tokenHash))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return link, err
+}
+
+// ListByReport returns every link of a report, newest first
+func (r *SQLShareLinkRepository) ListByReport(reportID int) ([]*ShareLink, error) {
+	query := `
+		SELECT ` + shareLinkColumns + `
+		FROM report_share_links
+		WHERE report_id = ?
+		ORDER BY created_at DESC, id DESC`
+
+	rows, err := r.db.Query(query, reportID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var links []*ShareLink
+	for rows.Next() {
+		link, err := scanShareLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+
+	return links, rows.Err()
+}
+
+// Revoke disables a link; revoking twice is a no-op
+func (r *SQLShareLinkRepository) Revoke(id int) error {
+	_, err := r.db.Exec(`UPDATE report_share_links SET revoked_at = COALESCE(revoked_at, CURRENT_TIMESTAMP) WHERE id = ?`, id)
+	return err
+}
+
+// RecordPINAttempt counts a PIN attempt as failed until RecordView clears it, returning the new total
+// Decision: Count before checking and increment in SQL, so parallel guesses can't all slip under the limit
+func (r *SQLShareLinkRepository) RecordPINAttempt(id int) (int, error) {
+	var attempts int
+	err := r.db.QueryRow(`
+		UPDATE report_share_links SET failed_attempts = failed_attempts + 1
+		WHERE id = ?
+		RETURNING failed_attempts`, id).Scan(&attempts)
+	return attempts, err
+}
+
+// Lock permanently disables a link after too many wrong PINs
+func (r *SQLShareLinkRepository) Lock(id int) error {
+	_, err := r.db.Exec(`UPDATE report_share_links SET locked_at = COALESCE(locked_at, CURRENT_TIMESTAMP) WHERE id = ?`, id)
+	return err
+}
+
+// RecordView notes a successful view and clears the wrong-PIN count
+func (r *SQLShareLinkRepository) RecordView(id int) error {
+	_, err := r.db.Exec(`
+		UPDATE report_share_links SET failed_attempts = 0, last_viewed_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND locked_at IS NULL`, id)
+	return err
+}
@@ -0,0 +1,133 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Subscription statuses, normalized across payment providers
+const (
+	SubscriptionIncomplete = "incomplete" // Checkout started but not paid
+	SubscriptionActive     = "active"
+	SubscriptionPastDue    = "past_due" // A renewal failed and the provider is retrying
+	SubscriptionPaused     = "paused"
+	SubscriptionCanceled   = "canceled"
+)
+
+// Subscription is a user's premium subscription as last reported by the payment provider
+type Subscription struct {
+	UserID            int        `json:"user_id" db:"user_id"`
+	Provider          string     `json:"provider" db:"provider"`
+	CustomerID        string     `json:"customer_id" db:"customer_id"`
+	SubscriptionID    string     `json:"subscription_id" db:"subscription_id"` // Empty until the provider creates it
+	Status            string     `json:"status" db:"status"`
+	CurrentPeriodEnd  *time.Time `json:"current_period_end" db:"current_period_end"` // Nullable
+	CancelAtPeriodEnd bool       `json:"cancel_at_period_end" db:"cancel_at_period_end"`
+	EventAt           *time.Time `json:"event_at" db:"event_at"` // Newest provider event applied; nullable
+	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Entitled reports whether the subscription grants the premium plan
+// Decision: Past-due subscriptions keep premium while the provider retries the payment; the provider
+// cancels them if the retries fail
+func (s *Subscription) Entitled() bool {
+	return s != nil && (s.Status == SubscriptionActive || s.Status == SubscriptionPastDue)
+}
+
+// SubscriptionRepository defines the interface for subscription and payment event database operations
+type SubscriptionRepository interface {
+	GetByUserID(userID int) (*Subscription, error)
+	GetBySubscriptionID(provider, subscriptionID string) (*Subscription, error)
+	Upsert(subscription *Subscription) error
+	HasEvent(provider, eventID string) (bool, error)
+	RecordEvent(provider, eventID, eventType string, userID int) error
+}
+
+// SQLSubscriptionRepository implements SubscriptionRepository using SQL database
+type SQLSubscriptionRepository struct {
+	db *sql.DB
+}
+
+// NewSubscriptionRepository creates a new subscription repository
+func NewSubscriptionRepository(db *sql.DB) SubscriptionRepository {
+	return &SQLSubscriptionRepository{db: db}
+}
+
+const subscriptionColumns = `user_id, provider, customer_id, subscription_id, status, current_period_end,
+	cancel_at_period_end, event_at, created_at, updated_at`
+
+func scanSubscription(row *sql.Row) (*Subscription, error) {
+	subscription := &Subscription{}
+	err := row.Scan(&subscription.UserID, &subscription.Provider, &subscription.CustomerID, &subscription.SubscriptionID,
+		&subscription.Status, &subscription.CurrentPeriodEnd, &subscription.CancelAtPeriodEnd, &subscription.EventAt,
+		&subscription.CreatedAt, &subscription.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// GetByUserID returns a user's subscription, or nil if they never started one
+func (r *SQLSubscriptionRepository) GetByUserID(userID int) (*Subscription, error) {
+	return scanSubscription(r.db.QueryRow(`SELECT `+subscriptionColumns+` FROM subscriptions WHERE user_id = ?`, userID))
+}
+
+// GetBySubscriptionID finds the subscription a provider event refers to, or nil
+func (r *SQLSubscriptionRepository) GetBySubscriptionID(provider, subscriptionID string) (*Subscription, error) {
+	if subscriptionID == "" {
+		return nil, nil
+	}
+	return scanSubscription(r.db.QueryRow(`SELECT `+subscriptionColumns+` FROM subscriptions
+		WHERE provider = ? AND subscription_id = ?`, provider, subscriptionID))
+}
+
+// Upsert replaces a user's subscription
+func (r *SQLSubscriptionRepository) Upsert(subscription *Subscription) error {
+	var periodEnd, eventAt *time.Time
+	if subscription.CurrentPeriodEnd != nil {
+		t := subscription.CurrentPeriodEnd.UTC()
+		periodEnd = &t
+	}
+	if subscription.EventAt != nil {
+		t := subscription.EventAt.UTC()
+		eventAt = &t
+	}
+
+	_, err := r.db.Exec(`
+		INSERT INTO subscriptions (user_id, provider, customer_id, subscription_id, status, current_period_end,
+			cancel_at_period_end, event_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET
+			provider = excluded.provider,
+			customer_id = excluded.customer_id,
+			subscription_id = excluded.subscription_id,
+			status = excluded.status,
+			current_period_end = excluded.current_period_end,
+			cancel_at_period_end = excluded.cancel_at_period_end,
+			event_at = excluded.event_at,
+			updated_at = CURRENT_TIMESTAMP`,
+		subscription.UserID, subscription.Provider, subscription.CustomerID, subscription.SubscriptionID,
+		subscription.Status, periodEnd, subscription.CancelAtPeriodEnd, eventAt)
+	return err
+}
+
+// HasEvent reports whether a webhook event was already applied
+func (r *SQLSubscriptionRepository) HasEvent(provider, eventID string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM payment_events WHERE provider = ? AND event_id = ?)`,
+		provider, eventID).Scan(&exists)
+	return exists, err
+}
+
+// RecordEvent marks a webhook event as applied; recording it twice is harmless
+func (r *SQLSubscriptionRepository) RecordEvent(provider, eventID, eventType string, userID int) error {
+	_, err := r.db.Exec(`
+		INSERT INTO payment_events (provider, event_id, event_type, user_id)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (provider, event_id) DO NOTHING`, provider, eventID, eventType, userID)
+	return err
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Kinds of record a sync tombstone stands for
+const (
+	TombstoneReport      = "report"
+	TombstoneChatMessage = "chat_message"
+)
+
+// Tombstone records that a record left the user's account, so offline clients know to drop their copy
+// Decision: Reports are hard deleted, so without tombstones a delta sync could never report a deletion
+type Tombstone struct {
+	ID        int       `json:"id" db:"id"`
+	UserID    int       `json:"user_id" db:"user_id"`
+	Kind      string    `json:"kind" db:"kind"`
+	RecordID  int       `json:"record_id" db:"record_id"`
+	DeletedAt time.Time `json:"deleted_at" db:"deleted_at"`
+}
+
+// TombstoneRepository defines the interface for reading sync tombstones; they are written by the
+// repositories that delete or move records, in the same transaction
+type TombstoneRepository interface {
+	// ListSince returns the user's tombstones recorded at or after since, oldest first
+	ListSince(userID int, since time.Time) ([]*Tombstone, error)
+}
+
+// SQLTombstoneRepository implements TombstoneRepository using SQL database
+type SQLTombstoneRepository struct {
+	db *sql.DB
+}
+
+// NewTombstoneRepository creates a new tombstone repository
+func NewTombstoneRepository(db *sql.DB) TombstoneRepository {
+	return &SQLTombstoneRepository{db: db}
+}
+
+// ListSince returns the user's tombstones recorded at or after since
+func (r *SQLTombstoneRepository) ListSince(userID int, since time.Time) ([]*Tombstone, error) {
+	rows, err := r.db.Query(`
+		SELECT id, user_id, kind, record_id, deleted_at
+		FROM sync_tombstones
+		WHERE user_id = ? AND deleted_at >= ?
+		ORDER BY id ASC`, userID, sqliteTimestamp(since))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tombstones []*Tombstone
+	for rows.Next() {
+		tombstone := &Tombstone{}
+		if err := rows.Scan(&tombstone.ID, &tombstone.UserID, &tombstone.Kind, &tombstone.RecordID, &tombstone.DeletedAt); err != nil {
+			return nil, err
+		}
+		tombstones = append(tombstones, tombstone)
+	}
+
+	return tombstones, rows.Err()
+}
+
+// sqliteTimestamp formats t like CURRENT_TIMESTAMP, so it compares correctly with columns it filled
+func sqliteTimestamp(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05")
+}
+
+// recordReportTombstone records that a report is leaving its owner's account; call it before the report is
+// deleted or changes hands
+func recordReportTombstone(tx *sql.Tx, reportID int) error {
+	_, err := tx.Exec(`INSERT INTO sync_tombstones (user_id, kind, record_id) SELECT user_id, ?, id FROM reports WHERE id = ?`,
+		TombstoneReport, reportID)
+	return err
+}
+
+// recordChatTombstone records that a chat message was deleted, for the owner of its report
+func recordChatTombstone(tx *sql.Tx, messageID int) error {
+	_, err := tx.Exec(`
+		INSERT INTO sync_tombstones (user_id, kind, record_id)
+		SELECT reports.user_id, ?, chat_messages.id
+		FROM chat_messages
+		JOIN reports ON reports.id = chat_messages.report_id
+		WHERE chat_messages.id = ?`, TombstoneChatMessage, messageID)
+	return err
+}
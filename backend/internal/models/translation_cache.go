@@ -0,0 +1,92 @@
+package models
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// CachedTranslation is one translated piece of summary text
+type CachedTranslation struct {
+	ID             int       `json:"id" db:"id"`
+	SourceHash     string    `json:"source_hash" db:"source_hash"`
+	Language       string    `json:"language" db:"language"`
+	Provider       string    `json:"provider" db:"provider"`
+	TranslatedText string    `json:"translated_text" db:"translated_text"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// TranslationCacheRepository defines the interface for translation cache database operations
+type TranslationCacheRepository interface {
+	// Lookup returns the stored translations into language of the given source hashes, keyed by hash
+	Lookup(language string, sourceHashes []string) (map[string]string, error)
+	Store(translations []*CachedTranslation) error
+}
+
+// SQLTranslationCacheRepository implements TranslationCacheRepository using SQL database
+type SQLTranslationCacheRepository struct {
+	db *sql.DB
+}
+
+// NewTranslationCacheRepository creates a new translation cache repository
+func NewTranslationCacheRepository(db *sql.DB) TranslationCacheRepository {
+	return &SQLTranslationCacheRepository{db: db}
+}
+
+// Lookup returns cached translations; hashes without one are absent from the map
+func (r *SQLTranslationCacheRepository) Lookup(language string, sourceHashes []string) (map[string]string, error) {
+	translations := make(map[string]string, len(sourceHashes))
+	if len(sourceHashes) == 0 {
+		return translations, nil
+	}
+
+	args := make([]any, 0, len(sourceHashes)+1)
+	args = append(args, language)
+	for _, hash := range sourceHashes {
+		args = append(args, hash)
+	}
+	query := `
+		SELECT source_hash, translated_text
+		FROM translation_cache
+		WHERE language = ? AND source_hash IN (?` + strings.Repeat(", ?", len(sourceHashes)-1) + `)`
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var hash, text string
+		if err := rows.Scan(&hash, &text); err != nil {
+			return nil, err
+		}
+		translations[hash] = text
+	}
+
+	return translations, rows.Err()
+}
+
+// Store saves translations in one transaction
+// Decision: Two concurrent first requests may both translate; the first translation stored wins
+func (r *SQLTranslationCacheRepository) Store(translations []*CachedTranslation) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO translation_cache (source_hash, language, provider, translated_text)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(source_hash, language) DO NOTHING`
+
+	for _, translation := range translations {
+		if _, err := tx.Exec(query, translation.SourceHash, translation.Language, translation.Provider,
+			translation.TranslatedText); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -2,6 +2,8 @@ package models
 
 import (
 	"database/sql"
+	"fmt"
+	"sync"
 	"time"
 )
 
@@ -16,6 +18,82 @@ type User struct {
 	IsActive      bool      `json:"is_active" db:"is_active"`
 	CreatedAt     time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+	Timezone      string    `json:"timezone" db:"timezone"` // IANA zone name; defaults to UTC
+	ReadingLevel  string    `json:"reading_level" db:"reading_level"`
+	Plan          string    `json:"plan" db:"plan"` // One of the Plan* constants
+	Version       int       `json:"version" db:"version"` // Incremented by every update, for optimistic concurrency
+}
+
+// DefaultTimezone is used for users who haven't chosen a zone
+const DefaultTimezone = "UTC"
+
+// Reading levels set how plainly analyses and chat answers are written
+// Decision: A fixed set rather than free text so prompts stay tested and predictable
+const (
+	ReadingLevelChild    = "child"    // Short sentences, no jargon, for children or low health literacy
+	ReadingLevelStandard = "standard" // Plain language for adult patients; the default
+	ReadingLevelClinical = "clinical" // Medical terminology for clinicians
+)
+
+// IsValidReadingLevel reports whether level is one of the supported reading levels
+func IsValidReadingLevel(level string) bool {
+	switch level {
+	case ReadingLevelChild, ReadingLevelStandard, ReadingLevelClinical:
+		return true
+	}
+	return false
+}
+
+// Plans set analysis depth, summary length, and chat allowance; limits come from config
+// Decision: Only the name is stored so limits can be retuned without migrating users
+const (
+	PlanFree    = "free" // The default
+	PlanPremium = "premium"
+)
+
+// IsValidPlan reports whether plan is one of the supported plans
+func IsValidPlan(plan string) bool {
+	switch plan {
+	case PlanFree, PlanPremium:
+		return true
+	}
+	return false
+}
+
+// locations caches parsed zones; LoadLocation reads tzdata on every call
+var locations sync.Map
+
+// LoadTimezone parses an IANA zone name, rejecting "Local" so results never depend on the server's zone
+func LoadTimezone(name string) (*time.Location, error) {
+	if cached, ok := locations.Load(name); ok {
+		return cached.(*time.Location), nil
+	}
+	if name == "" || name == "Local" {
+		return nil, fmt.Errorf("invalid timezone %q", name)
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
+
+// Location returns the user's time zone, falling back to UTC for unknown names
+func (u *User) Location() *time.Location {
+	loc, err := LoadTimezone(u.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// StartOfDay returns midnight of t's calendar day in the user's time zone
+// Decision: Daily quotas and reminders follow the user's day, not the server's
+func (u *User) StartOfDay(t time.Time) time.Time {
+	local := t.In(u.Location())
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
 }
 
 // UserRepository defines the interface for user database operations
@@ -25,7 +103,11 @@ type UserRepository interface {
 	GetByID(id int) (*User, error)
 	GetByEmail(email string) (*User, error)
 	Update(user *User) error
+	// UpdateAtVersion is Update, applied only while the stored user is still at version; false otherwise
+	UpdateAtVersion(user *User, version int) (bool, error)
 	Delete(id int) error
+	// Reactivate undoes Delete
+	Reactivate(id int) error
 	List(limit, offset int) ([]*User, error)
 }
 
@@ -42,27 +124,37 @@ func NewUserRepository(db *sql.DB) UserRepository {
 // Create inserts a new user into the database
 func (r *SQLUserRepository) Create(user *User) error {
 	query := `
-		INSERT INTO users (email, password_hash, full_name, email_verified, is_active)
-		VALUES (?, ?, ?, ?, ?)
-		RETURNING id, created_at, updated_at`
+		INSERT INTO users (email, password_hash, full_name, email_verified, is_active, timezone, reading_level, plan)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id, created_at, updated_at, version`
+
+	if user.Timezone == "" {
+		user.Timezone = DefaultTimezone
+	}
+	if user.ReadingLevel == "" {
+		user.ReadingLevel = ReadingLevelStandard
+	}
+	if user.Plan == "" {
+		user.Plan = PlanFree
+	}
 
 	// Decision: Using RETURNING clause to get generated ID and timestamps
-	row := r.db.QueryRow(query, user.Email, user.PasswordHash, user.FullName, user.EmailVerified, user.IsActive)
-	return row.Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
+	row := r.db.QueryRow(query, user.Email, user.PasswordHash, user.FullName, user.EmailVerified, user.IsActive, user.Timezone, user.ReadingLevel, user.Plan)
+	return row.Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt, &user.Version)
 }
 
 // GetByID retrieves a user by their ID
 func (r *SQLUserRepository) GetByID(id int) (*User, error) {
 	user := &User{}
 	query := `
-		SELECT id, email, password_hash, full_name, email_verified, is_active, created_at, updated_at
+		SELECT id, email, password_hash, full_name, email_verified, is_active, created_at, updated_at, timezone, reading_level, plan, version
 		FROM users
 		WHERE id = ? AND is_active = TRUE`
 
 	// Decision: Only return active users in standard queries
 	row := r.db.QueryRow(query, id)
 	err := row.Scan(&user.ID, &user.Email, &user.PasswordHash, &user.FullName,
-		&user.EmailVerified, &user.IsActive, &user.CreatedAt, &user.UpdatedAt)
+		&user.EmailVerified, &user.IsActive, &user.CreatedAt, &user.UpdatedAt, &user.Timezone, &user.ReadingLevel, &user.Plan, &user.Version)
 
 	if err == sql.ErrNoRows {
 		return nil, nil // Return nil for not found, not an error
@@ -78,13 +170,13 @@ func (r *SQLUserRepository) GetByID(id int) (*User, error) {
 func (r *SQLUserRepository) GetByEmail(email string) (*User, error) {
 	user := &User{}
 	query := `
-		SELECT id, email, password_hash, full_name, email_verified, is_active, created_at, updated_at
+		SELECT id, email, password_hash, full_name, email_verified, is_active, created_at, updated_at, timezone, reading_level, plan, version
 		FROM users
 		WHERE email = ? AND is_active = TRUE`
 
 	row := r.db.QueryRow(query, email)
 	err := row.Scan(&user.ID, &user.Email, &user.PasswordHash, &user.FullName,
-		&user.EmailVerified, &user.IsActive, &user.CreatedAt, &user.UpdatedAt)
+		&user.EmailVerified, &user.IsActive, &user.CreatedAt, &user.UpdatedAt, &user.Timezone, &user.ReadingLevel, &user.Plan, &user.Version)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -98,13 +190,53 @@ func (r *SQLUserRepository) GetByEmail(email string) (*User, error) {
 
 // Update modifies an existing user
 func (r *SQLUserRepository) Update(user *User) error {
+	updated, err := r.update(user, 0)
+	if err == nil && !updated {
+		return sql.ErrNoRows // User not found or not active
+	}
+	return err
+}
+
+// UpdateAtVersion modifies an existing user unless someone else updated it since it was read at version
+func (r *SQLUserRepository) UpdateAtVersion(user *User, version int) (bool, error) {
+	return r.update(user, version)
+}
+
+// update writes the user and bumps its version; a version of 0 applies the update unconditionally
+func (r *SQLUserRepository) update(user *User, version int) (bool, error) {
 	query := `
 		UPDATE users
-		SET email = ?, full_name = ?, email_verified = ?, updated_at = CURRENT_TIMESTAMP
-		WHERE id = ? AND is_active = TRUE`
+		SET email = ?, full_name = ?, email_verified = ?, timezone = ?, reading_level = ?, plan = ?,
+			version = version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND is_active = TRUE AND (? = 0 OR version = ?)
+		RETURNING version, updated_at`
+
+	if user.Timezone == "" {
+		user.Timezone = DefaultTimezone
+	}
+	if user.ReadingLevel == "" {
+		user.ReadingLevel = ReadingLevelStandard
+	}
+	if user.Plan == "" {
+		user.Plan = PlanFree
+	}
 
 	// Decision: Not allowing password updates here - separate method for security
-	result, err := r.db.Exec(query, user.Email, user.FullName, user.EmailVerified, user.ID)
+	row := r.db.QueryRow(query, user.Email, user.FullName, user.EmailVerified, user.Timezone, user.ReadingLevel, user.Plan,
+		user.ID, version, version)
+	err := row.Scan(&user.Version, &user.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil // Not found, not active, or at another version
+	}
+	return err == nil, err
+}
+
+// Delete soft deletes a user (sets is_active to FALSE)
+func (r *SQLUserRepository) Delete(id int) error {
+	query := `UPDATE users SET is_active = FALSE, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+
+	// Decision: Soft delete to preserve data integrity with reports and chat history
+	result, err := r.db.Exec(query, id)
 	if err != nil {
 		return err
 	}
@@ -115,18 +247,15 @@ func (r *SQLUserRepository) Update(user *User) error {
 	}
 
 	if rowsAffected == 0 {
-		return sql.ErrNoRows // User not found or not active
+		return sql.ErrNoRows
 	}
 
 	return nil
 }
 
-// Delete soft deletes a user (sets is_active to FALSE)
-func (r *SQLUserRepository) Delete(id int) error {
-	query := `UPDATE users SET is_active = FALSE, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
-
-	// Decision: Soft delete to preserve data integrity with reports and chat history
-	result, err := r.db.Exec(query, id)
+// Reactivate sets is_active back to TRUE
+func (r *SQLUserRepository) Reactivate(id int) error {
+	result, err := r.db.Exec(`UPDATE users SET is_active = TRUE, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, id)
 	if err != nil {
 		return err
 	}
@@ -146,7 +275,7 @@ func (r *SQLUserRepository) Delete(id int) error {
 // List retrieves a paginated list of users
 func (r *SQLUserRepository) List(limit, offset int) ([]*User, error) {
 	query := `
-		SELECT id, email, password_hash, full_name, email_verified, is_active, created_at, updated_at
+		SELECT id, email, password_hash, full_name, email_verified, is_active, created_at, updated_at, timezone, reading_level, plan, version
 		FROM users
 		WHERE is_active = TRUE
 		ORDER BY created_at DESC
@@ -162,7 +291,7 @@ func (r *SQLUserRepository) List(limit, offset int) ([]*User, error) {
 	for rows.Next() {
 		user := &User{}
 		err := rows.Scan(&user.ID, &user.Email, &user.PasswordHash, &user.FullName,
-			&user.EmailVerified, &user.IsActive, &user.CreatedAt, &user.UpdatedAt)
+			&user.EmailVerified, &user.IsActive, &user.CreatedAt, &user.UpdatedAt, &user.Timezone, &user.ReadingLevel, &user.Plan, &user.Version)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CacheStats reports cache effectiveness for the metrics endpoint
+type CacheStats struct {
+	Hits    int64   `json:"hits"`
+	Misses  int64   `json:"misses"`
+	HitRate float64 `json:"hit_rate"`
+	Size    int     `json:"size"`
+}
+
+type cachedUser struct {
+	user      User
+	expiresAt time.Time
+}
+
+// CachedUserRepository wraps a UserRepository with a short-TTL cache for ID lookups
+// Decision: Decorating the repository means every write path invalidates automatically,
+// so auth middleware never serves a deactivated or renamed user past a write
+type CachedUserRepository struct {
+	UserRepository
+	ttl time.Duration
+
+	mu      sync.RWMutex
+	entries map[int]cachedUser
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// NewCachedUserRepository creates a caching decorator around repo
+func NewCachedUserRepository(repo UserRepository, ttl time.Duration) *CachedUserRepository {
+	return &CachedUserRepository{
+		UserRepository: repo,
+		ttl:            ttl,
+		entries:        make(map[int]cachedUser),
+	}
+}
+
+// GetByID returns a cached user when fresh, otherwise loads and caches it
+func (r *CachedUserRepository) GetByID(id int) (*User, error) {
+	r.mu.RLock()
+	entry, ok := r.entries[id]
+	r.mu.RUnlock()
+
+	if ok && time.Now().Before(entry.expiresAt) {
+		r.hits.Add(1)
+		user := entry.user // Decision: Hand out copies so callers can't mutate the cache
+		return &user, nil
+	}
+
+	r.misses.Add(1)
+	user, err := r.UserRepository.GetByID(id)
+	if err != nil || user == nil {
+		return user, err
+	}
+
+	r.mu.Lock()
+	r.entries[id] = cachedUser{user: *user, expiresAt: time.Now().Add(r.ttl)}
+	r.mu.Unlock()
+
+	return user, nil
+}
+
+// Update modifies the user and drops the cached copy
+func (r *CachedUserRepository) Update(user *User) error {
+	err := r.UserRepository.Update(user)
+	r.Invalidate(user.ID)
+	return err
+}
+
+// UpdateAtVersion modifies the user if it is still at version and drops the cached copy
+func (r *CachedUserRepository) UpdateAtVersion(user *User, version int) (bool, error) {
+	updated, err := r.UserRepository.UpdateAtVersion(user, version)
+	r.Invalidate(user.ID)
+	return updated, err
+}
+
+// Delete deactivates the user and drops the cached copy
+func (r *CachedUserRepository) Delete(id int) error {
+	err := r.UserRepository.Delete(id)
+	r.Invalidate(id)
+	return err
+}
+
+// Reactivate reactivates the user and drops the cached copy
+func (r *CachedUserRepository) Reactivate(id int) error {
+	err := r.UserRepository.Reactivate(id)
+	r.Invalidate(id)
+	return err
+}
+
+// Invalidate removes a user from the cache
+// Decision: Exported for write paths that bypass this repository (e.g. raw SQL updates)
+func (r *CachedUserRepository) Invalidate(id int) {
+	r.mu.Lock()
+	delete(r.entries, id)
+	r.mu.Unlock()
+}
+
+// Stats returns hit/miss counters and the current cache size
+func (r *CachedUserRepository) Stats() CacheStats {
+	r.mu.RLock()
+	size := len(r.entries)
+	r.mu.RUnlock()
+
+	hits, misses := r.hits.Load(), r.misses.Load()
+	stats := CacheStats{Hits: hits, Misses: misses, Size: size}
+	if total := hits + misses; total > 0 {
+		stats.HitRate = float64(hits) / float64(total)
+	}
+
+	return stats
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// UserCondition is a chronic condition a user chose to track
+type UserCondition struct {
+	UserID       int       `json:"user_id" db:"user_id"`
+	ConditionKey string    `json:"condition_key" db:"condition_key"` // Key in the services condition catalog
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// UserConditionRepository defines the interface for tracked condition database operations
+type UserConditionRepository interface {
+	ListByUser(userID int) ([]*UserCondition, error)
+	Add(condition *UserCondition) error
+	Remove(userID int, conditionKey string) error
+}
+
+// SQLUserConditionRepository implements UserConditionRepository using SQL database
+type SQLUserConditionRepository struct {
+	db *sql.DB
+}
+
+// NewUserConditionRepository creates a new tracked condition repository
+func NewUserConditionRepository(db *sql.DB) UserConditionRepository {
+	return &SQLUserConditionRepository{db: db}
+}
+
+// ListByUser returns the conditions a user tracks, in the order they were added
+func (r *SQLUserConditionRepository) ListByUser(userID int) ([]*UserCondition, error) {
+	rows, err := r.db.Query(`
+		SELECT user_id, condition_key, created_at
+		FROM user_conditions
+		WHERE user_id = ?
+		ORDER BY created_at ASC, condition_key ASC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var conditions []*UserCondition
+	for rows.Next() {
+		condition := &UserCondition{}
+		if err := rows.Scan(&condition.UserID, &condition.ConditionKey, &condition.CreatedAt); err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, condition)
+	}
+	return conditions, rows.Err()
+}
+
+// Add starts tracking a condition; adding one already tracked keeps its original date
+func (r *SQLUserConditionRepository) Add(condition *UserCondition) error {
+	_, err := r.db.Exec(`
+		INSERT INTO user_conditions (user_id, condition_key)
+		VALUES (?, ?)
+		ON CONFLICT (user_id, condition_key) DO NOTHING`, condition.UserID, condition.ConditionKey)
+	if err != nil {
+		return err
+	}
+	return r.db.QueryRow(`
+		SELECT created_at FROM user_conditions WHERE user_id = ? AND condition_key = ?`,
+		condition.UserID, condition.ConditionKey).Scan(&condition.CreatedAt)
+}
+
+// Remove stops tracking a condition
+func (r *SQLUserConditionRepository) Remove(userID int, conditionKey string) error {
+	_, err := r.db.Exec(`DELETE FROM user_conditions WHERE user_id = ? AND condition_key = ?`, userID, conditionKey)
+	return err
+}
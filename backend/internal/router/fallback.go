@@ -0,0 +1,70 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// routeMethods are the methods probed when working out which ones a path accepts
+var routeMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodOptions,
+}
+
+// setupFallbacks answers requests no route takes with the same JSON envelope as every other error
+// Decision: Routes declare their methods, so wrong methods are refused here rather than in each handler.
+// mux loses a method mismatch inside nested subrouters and reports it as not found, so both cases probe
+// which methods the path accepts; mux also skips router middleware here, so wrap applies it directly
+func setupFallbacks(r *mux.Router, wrap func(http.Handler) http.Handler) {
+	fallback := wrap(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		allowed := allowedMethods(r, req)
+		if len(allowed) == 0 {
+			writeFallbackError(w, http.StatusNotFound, "Endpoint not found")
+			return
+		}
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		writeFallbackError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}))
+	r.NotFoundHandler = fallback
+	r.MethodNotAllowedHandler = fallback
+}
+
+// allowedMethods lists the methods some route accepts for the request's path
+func allowedMethods(r *mux.Router, req *http.Request) []string {
+	var allowed []string
+	for _, method := range routeMethods {
+		probe := req.Clone(req.Context())
+		probe.Method = method
+		var match mux.RouteMatch
+		if r.Match(probe, &match) && match.MatchErr == nil {
+			allowed = append(allowed, method)
+		}
+	}
+	return allowed
+}
+
+func writeFallbackError(w http.ResponseWriter, statusCode int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(types.ErrorEnvelope(statusCode, "", message))
+}
+
+// ServeFrontend serves the built frontend for GET and HEAD requests outside /api; register it after every API route
+// Decision: API paths are excluded so an unknown endpoint stays a JSON 404, and a wrong method on one isn't
+// answered as if the frontend owned it
+func ServeFrontend(r *mux.Router, frontend http.Handler) {
+	r.PathPrefix("/").MatcherFunc(func(req *http.Request, _ *mux.RouteMatch) bool {
+		name := path.Clean("/" + req.URL.Path)
+		return name != "/api" && !strings.HasPrefix(name, "/api/")
+	}).Methods("GET", "HEAD").Handler(frontend)
+}
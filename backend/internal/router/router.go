@@ -1,31 +1,111 @@
 package router
 
 import (
+	"encoding/json"
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
 	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/handlers"
 	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
 )
 
 // Router holds all router dependencies
 // Decision: Struct to organize handlers and middleware
 type Router struct {
-	authHandler    *handlers.AuthHandler
-	reportHandler  *handlers.ReportHandler
-	authMiddleware *middleware.AuthMiddleware
+	authHandler     *handlers.AuthHandler
+	reportHandler   *handlers.ReportHandler
+	adminHandler    *handlers.AdminHandler
+	transferHandler *handlers.TransferHandler
+	chatHandler     *handlers.ChatHandler
+	notifyHandler   *handlers.NotificationHandler
+	glossaryHandler *handlers.GlossaryHandler
+	audioHandler    *handlers.SummaryAudioHandler
+	shareHandler    *handlers.ShareHandler
+	orgHandler      *handlers.OrganizationHandler
+	analysisHandler *handlers.AnalysisHandler
+	calcHandler     *handlers.CalculatorHandler
+	profileHandler  *handlers.HealthProfileHandler
+	condHandler     *handlers.ConditionHandler
+	cardHandler     *handlers.EmergencyCardHandler
+	rxHandler       *handlers.PrescriptionHandler
+	widgetHandler   *handlers.WidgetHandler
+	planHandler     *handlers.PlanHandler
+	billingHandler  *handlers.BillingHandler
+	insightsHandler *handlers.InsightsHandler
+	referralHandler *handlers.ReferralHandler
+	apptHandler     *handlers.AppointmentHandler
+	deviceHandler   *handlers.DeviceHandler
+	aiLogHandler    *handlers.AILogHandler
+	authMiddleware  *middleware.AuthMiddleware
+	dbMonitor       *database.HealthMonitor
+	metricsHandler  *handlers.MetricsHandler
+	usageTracker    *middleware.UsageTracker
 }
 
 // NewRouter creates a new router with all dependencies
+// Decision: dbMonitor, metricsHandler, and usageTracker may be nil (tests), disabling those features
 func NewRouter(
 	authHandler *handlers.AuthHandler,
 	reportHandler *handlers.ReportHandler,
+	adminHandler *handlers.AdminHandler,
+	transferHandler *handlers.TransferHandler,
+	chatHandler *handlers.ChatHandler,
+	notifyHandler *handlers.NotificationHandler,
+	glossaryHandler *handlers.GlossaryHandler,
+	audioHandler *handlers.SummaryAudioHandler,
+	shareHandler *handlers.ShareHandler,
+	orgHandler *handlers.OrganizationHandler,
+	analysisHandler *handlers.AnalysisHandler,
+	calcHandler *handlers.CalculatorHandler,
+	profileHandler *handlers.HealthProfileHandler,
+	condHandler *handlers.ConditionHandler,
+	cardHandler *handlers.EmergencyCardHandler,
+	rxHandler *handlers.PrescriptionHandler,
+	widgetHandler *handlers.WidgetHandler,
+	planHandler *handlers.PlanHandler,
+	billingHandler *handlers.BillingHandler,
+	insightsHandler *handlers.InsightsHandler,
+	referralHandler *handlers.ReferralHandler,
+	apptHandler *handlers.AppointmentHandler,
+	deviceHandler *handlers.DeviceHandler,
+	aiLogHandler *handlers.AILogHandler,
 	authMiddleware *middleware.AuthMiddleware,
+	dbMonitor *database.HealthMonitor,
+	metricsHandler *handlers.MetricsHandler,
+	usageTracker *middleware.UsageTracker,
 ) *Router {
 	return &Router{
-		authHandler:    authHandler,
-		reportHandler:  reportHandler,
-		authMiddleware: authMiddleware,
+		authHandler:     authHandler,
+		reportHandler:   reportHandler,
+		adminHandler:    adminHandler,
+		transferHandler: transferHandler,
+		chatHandler:     chatHandler,
+		notifyHandler:   notifyHandler,
+		glossaryHandler: glossaryHandler,
+		audioHandler:    audioHandler,
+		shareHandler:    shareHandler,
+		orgHandler:      orgHandler,
+		analysisHandler: analysisHandler,
+		calcHandler:     calcHandler,
+		profileHandler:  profileHandler,
+		condHandler:     condHandler,
+		cardHandler:     cardHandler,
+		rxHandler:       rxHandler,
+		widgetHandler:   widgetHandler,
+		planHandler:     planHandler,
+		billingHandler:  billingHandler,
+		insightsHandler: insightsHandler,
+		referralHandler: referralHandler,
+		apptHandler:     apptHandler,
+		deviceHandler:   deviceHandler,
+		aiLogHandler:    aiLogHandler,
+		authMiddleware:  authMiddleware,
+		dbMonitor:       dbMonitor,
+		metricsHandler:  metricsHandler,
+		usageTracker:    usageTracker,
 	}
 }
 
@@ -35,24 +115,107 @@ func (rt *Router) SetupRoutes() *mux.Router {
 	// Decision: Create main router with CORS middleware
 	r := mux.NewRouter()
 
+	// Decision: Tag and log every request first, so CORS rejections and unknown routes are logged too
+	r.Use(middleware.RequestLogger)
+
 	// Decision: Apply CORS middleware to all routes
 	corsMiddleware := middleware.CORS(middleware.DefaultCORSConfig())
 	r.Use(corsMiddleware)
+	setupFallbacks(r, func(next http.Handler) http.Handler {
+		return middleware.RequestLogger(corsMiddleware(next))
+	})
 
 	// Decision: Health check endpoint (no auth required)
 	r.HandleFunc("/health", rt.healthHandler).Methods("GET", "OPTIONS")
 
+	// Decision: Runtime metrics (cache hit rates, pool stats) for operators
+	if rt.metricsHandler != nil {
+		r.HandleFunc("/metrics", rt.metricsHandler.GetMetricsHandler).Methods("GET", "OPTIONS")
+	}
+
 	// Decision: Create API subrouter for versioning
 	api := r.PathPrefix("/api").Subrouter()
 
+	// Decision: Fail fast with 503 while the database monitor reports an outage
+	api.Use(middleware.RequireHealthyDatabase(rt.dbMonitor))
+
+	// Decision: Announce deprecated routes and count who calls each route, so removals can be planned from real usage
+	api.Use(middleware.DeprecationHeaders(middleware.DeprecatedRoutes))
+	if rt.usageTracker != nil {
+		api.Use(rt.usageTracker.Track)
+	}
+
 	// Decision: Setup authentication routes
 	rt.setupAuthRoutes(api)
 
 	// Decision: Setup report routes
 	rt.setupReportRoutes(api)
 
-	// Decision: Future route groups will be added here
-	// rt.setupChatRoutes(api)
+	// Decision: Setup routes for analyses spanning several reports
+	rt.setupAnalysisRoutes(api)
+
+	// Decision: Setup clinical risk calculator routes
+	rt.setupCalculatorRoutes(api)
+
+	// Decision: Setup health profile routes
+	rt.setupHealthProfileRoutes(api)
+
+	// Decision: Setup opt-in population insight routes
+	rt.setupInsightsRoutes(api)
+
+	// Decision: Setup specialist referral and doctor search routes
+	rt.setupReferralRoutes(api)
+
+	// Decision: Setup follow-up appointment booking routes
+	rt.setupAppointmentRoutes(api)
+
+	// Decision: Setup tracked condition routes
+	rt.setupConditionRoutes(api)
+
+	// Decision: Setup emergency card routes
+	rt.setupEmergencyCardRoutes(api)
+
+	// Decision: Setup plan routes
+	rt.setupPlanRoutes(api)
+
+	// Decision: Setup AI usage log routes
+	rt.setupAILogRoutes(api)
+
+	// Decision: Setup premium subscription routes
+	rt.setupBillingRoutes(api)
+
+	// Decision: Setup prescription routes
+	rt.setupPrescriptionRoutes(api)
+
+	// Decision: Setup report ownership transfer routes
+	rt.setupTransferRoutes(api)
+
+	// Decision: Setup read-only share link routes
+	rt.setupShareRoutes(api)
+
+	// Decision: Setup embeddable widget routes
+	rt.setupWidgetRoutes(api)
+
+	// Decision: Setup admin routes
+	rt.setupAdminRoutes(api)
+
+	// Decision: Setup organization branding routes
+	rt.setupOrganizationRoutes(api)
+
+	// Decision: Setup chat routes
+	rt.setupChatRoutes(api)
+
+	// Decision: Setup in-app notification routes
+	rt.setupNotificationRoutes(api)
+
+	// Decision: Setup push notification device routes
+	rt.setupDeviceRoutes(api)
+
+	// Decision: Setup medical glossary routes
+	rt.setupGlossaryRoutes(api)
+
+	// Decision: Setup delta sync routes for offline-first clients
+	rt.setupSyncRoutes(api)
 
 	return r
 }
@@ -65,29 +228,47 @@ func (rt *Router) setupAuthRoutes(api *mux.Router) {
 	// Decision: Public authentication endpoints (no middleware required)
 	auth.HandleFunc("/signup", rt.authHandler.SignupHandler).Methods("POST", "OPTIONS")
 	auth.HandleFunc("/login", rt.authHandler.LoginHandler).Methods("POST", "OPTIONS")
+	auth.HandleFunc("/captcha", rt.authHandler.CaptchaSettingsHandler).Methods("GET", "OPTIONS")
 	auth.HandleFunc("/logout", rt.authHandler.LogoutHandler).Methods("POST", "OPTIONS")
+	auth.HandleFunc("/refresh", rt.authHandler.RefreshHandler).Methods("POST", "OPTIONS")
 
 	// Decision: Protected authentication endpoints (require valid JWT)
 	protectedAuth := auth.PathPrefix("").Subrouter()
 	protectedAuth.Use(rt.authMiddleware.RequireAuth)
 	protectedAuth.HandleFunc("/me", rt.authHandler.MeHandler).Methods("GET", "OPTIONS")
-	protectedAuth.HandleFunc("/refresh", rt.authHandler.RefreshHandler).Methods("POST", "OPTIONS")
+	protectedAuth.HandleFunc("/me", rt.authHandler.UpdateMeHandler).Methods("PATCH", "OPTIONS")
+	protectedAuth.HandleFunc("/api-keys", rt.authHandler.ListAPIKeysHandler).Methods("GET", "OPTIONS")
+	protectedAuth.HandleFunc("/api-keys", rt.authHandler.CreateAPIKeyHandler).Methods("POST", "OPTIONS")
+	protectedAuth.HandleFunc("/api-keys/{id:[0-9]+}", rt.authHandler.RevokeAPIKeyHandler).Methods("DELETE", "OPTIONS")
+	protectedAuth.HandleFunc("/sessions", rt.authHandler.ListSessionsHandler).Methods("GET", "OPTIONS")
+	protectedAuth.HandleFunc("/sessions/{id:[0-9]+}", rt.authHandler.RevokeSessionHandler).Methods("DELETE", "OPTIONS")
 }
 
 // healthHandler provides application health status
 // Decision: Simple health check for load balancers and monitoring
 func (rt *Router) healthHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
 
 	// Decision: Include service name and status for identification
-	response := `{
-		"status": "healthy",
-		"service": "medical-report-backend",
-		"version": "1.0.0"
-	}`
+	response := types.HealthResponse{
+		Status:  "healthy",
+		Service: "medical-report-backend",
+		Version: "1.0.0",
+	}
 
-	w.Write([]byte(response))
+	// Decision: Report database and pool health so load balancers can drain unhealthy instances
+	statusCode := http.StatusOK
+	if rt.dbMonitor != nil {
+		dbStatus := rt.dbMonitor.Status()
+		response.Database = dbStatus
+		if !dbStatus.Healthy {
+			response.Status = "degraded"
+			statusCode = http.StatusServiceUnavailable
+		}
+	}
+
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(types.DataEnvelope(response, nil))
 }
 
 // setupReportRoutes configures report management endpoints
@@ -99,18 +280,315 @@ func (rt *Router) setupReportRoutes(api *mux.Router) {
 	reports.HandleFunc("", rt.reportHandler.GetReportsHandler).Methods("GET", "OPTIONS")
 	reports.HandleFunc("/history", rt.reportHandler.GetReportHistoryHandler).Methods("GET", "OPTIONS")
 	reports.HandleFunc("", rt.reportHandler.UploadReportHandler).Methods("POST", "OPTIONS")
+	reports.HandleFunc("/bulk-delete", rt.reportHandler.BulkDeleteHandler).Methods("POST", "OPTIONS")
+	reports.HandleFunc("/bulk-archive", rt.reportHandler.BulkArchiveHandler).Methods("POST", "OPTIONS")
 	reports.HandleFunc("/{id:[0-9]+}", rt.reportHandler.GetReportHandler).Methods("GET", "OPTIONS")
+	reports.HandleFunc("/{id:[0-9]+}", rt.reportHandler.UpdateReportHandler).Methods("PATCH", "OPTIONS")
 	reports.HandleFunc("/{id:[0-9]+}", rt.reportHandler.DeleteReportHandler).Methods("DELETE", "OPTIONS")
+	reports.HandleFunc("/{id:[0-9]+}/visibility", rt.reportHandler.UpdateVisibilityHandler).Methods("PATCH", "OPTIONS")
+	reports.HandleFunc("/{id:[0-9]+}/file", rt.reportHandler.DownloadReportFileHandler).Methods("GET", "OPTIONS")
 	reports.HandleFunc("/{id:[0-9]+}/summary", rt.reportHandler.GetReportSummaryHandler).Methods("GET", "OPTIONS")
 	reports.HandleFunc("/{id:[0-9]+}/metrics", rt.reportHandler.GetHealthMetricsHandler).Methods("GET", "OPTIONS")
+	reports.HandleFunc("/{id:[0-9]+}/summary/audio", rt.audioHandler.GetSummaryAudioHandler).Methods("GET", "OPTIONS")
+	reports.HandleFunc("/{id:[0-9]+}/feedback", rt.reportHandler.SubmitFeedbackHandler).Methods("POST", "OPTIONS")
+	reports.HandleFunc("/{id:[0-9]+}/history", rt.reportHandler.GetProcessingHistoryHandler).Methods("GET", "OPTIONS")
+	reports.HandleFunc("/{id:[0-9]+}/status", rt.reportHandler.GetReportStatusHandler).Methods("GET", "OPTIONS")
+	reports.HandleFunc("/{id:[0-9]+}/events", rt.reportHandler.GetReportEventsHandler).Methods("GET", "OPTIONS")
+	reports.HandleFunc("/{id:[0-9]+}/parts", rt.reportHandler.GetPartsHandler).Methods("GET", "OPTIONS")
+	reports.HandleFunc("/{id:[0-9]+}/parts", rt.reportHandler.MergePartsHandler).Methods("POST", "OPTIONS")
+	reports.HandleFunc("/{id:[0-9]+}/claim-package", rt.reportHandler.GenerateClaimPackageHandler).Methods("POST", "OPTIONS")
+	reports.HandleFunc("/{id:[0-9]+}/claim-package", rt.reportHandler.GetClaimPackageHandler).Methods("GET", "OPTIONS")
+	reports.HandleFunc("/{id:[0-9]+}/claim-package/file", rt.reportHandler.DownloadClaimPackageHandler).Methods("GET", "OPTIONS")
+
+	// Decision: Trusted pipelines attach their own analyses to anyone's report, so only admins and their scoped keys may
+	importer := rt.authMiddleware.RequireAdminOrScope(models.APIKeyScopeAnalysisImport)
+	reports.Handle("/{id:[0-9]+}/analysis", importer(http.HandlerFunc(rt.reportHandler.ImportAnalysisHandler))).Methods("POST", "OPTIONS")
+}
+
+// setupSyncRoutes configures the delta sync endpoint
+func (rt *Router) setupSyncRoutes(api *mux.Router) {
+	sync := api.PathPrefix("/sync").Subrouter()
+	sync.Use(rt.authMiddleware.RequireAuth)
+	sync.HandleFunc("", rt.reportHandler.SyncHandler).Methods("GET", "OPTIONS")
+}
+
+// setupAnalysisRoutes configures analyses that span several of the user's reports
+func (rt *Router) setupAnalysisRoutes(api *mux.Router) {
+	analyses := api.PathPrefix("/analyses").Subrouter()
+	analyses.Use(rt.authMiddleware.RequireAuth)
+	analyses.HandleFunc("", rt.analysisHandler.ListMergedAnalysesHandler).Methods("GET", "OPTIONS")
+	analyses.HandleFunc("/merge", rt.analysisHandler.MergeAnalysesHandler).Methods("POST", "OPTIONS")
+	analyses.HandleFunc("/annual", rt.analysisHandler.AnnualReviewHandler).Methods("GET", "OPTIONS")
+	analyses.HandleFunc("/{id:[0-9]+}", rt.analysisHandler.GetMergedAnalysisHandler).Methods("GET", "OPTIONS")
+	analyses.HandleFunc("/{id:[0-9]+}", rt.analysisHandler.DeleteMergedAnalysisHandler).Methods("DELETE", "OPTIONS")
+}
+
+// setupCalculatorRoutes configures deterministic risk calculators fed by report metrics
+func (rt *Router) setupCalculatorRoutes(api *mux.Router) {
+	calculators := api.PathPrefix("/calculators").Subrouter()
+	calculators.Use(rt.authMiddleware.RequireAuth)
+	calculators.HandleFunc("/{name}", rt.calcHandler.CalculateHandler).Methods("GET", "OPTIONS")
+}
+
+// setupInsightsRoutes configures comparisons with anonymized statistics of users who opted in
+func (rt *Router) setupInsightsRoutes(api *mux.Router) {
+	insights := api.PathPrefix("/insights").Subrouter()
+	insights.Use(rt.authMiddleware.RequireAuth)
+	insights.HandleFunc("/population", rt.insightsHandler.GetPopulationInsightsHandler).Methods("GET", "OPTIONS")
+	insights.HandleFunc("/population/consent", rt.insightsHandler.GetPopulationConsentHandler).Methods("GET", "OPTIONS")
+	insights.HandleFunc("/population/consent", rt.insightsHandler.UpdatePopulationConsentHandler).Methods("PUT", "OPTIONS")
+}
+
+// setupReferralRoutes configures specialist suggestions for reports and the provider directory search
+func (rt *Router) setupReferralRoutes(api *mux.Router) {
+	reports := api.PathPrefix("/reports").Subrouter()
+	reports.Use(rt.authMiddleware.RequireAuth)
+	reports.HandleFunc("/{id:[0-9]+}/referrals", rt.referralHandler.GetReferralsHandler).Methods("GET", "OPTIONS")
+
+	specialties := api.PathPrefix("/specialties").Subrouter()
+	specialties.Use(rt.authMiddleware.RequireAuth)
+	specialties.HandleFunc("", rt.referralHandler.ListSpecialtiesHandler).Methods("GET", "OPTIONS")
+
+	doctors := api.PathPrefix("/doctors").Subrouter()
+	doctors.Use(rt.authMiddleware.RequireAuth)
+	doctors.HandleFunc("", rt.referralHandler.FindDoctorsHandler).Methods("GET", "OPTIONS")
+}
+
+// setupAppointmentRoutes configures follow-up bookings and the scheduling system's callback
+func (rt *Router) setupAppointmentRoutes(api *mux.Router) {
+	reports := api.PathPrefix("/reports").Subrouter()
+	reports.Use(rt.authMiddleware.RequireAuth)
+	reports.HandleFunc("/{id:[0-9]+}/recommendations/{index:[0-9]+}/bookings", rt.apptHandler.BookFollowUpHandler).Methods("POST", "OPTIONS")
+	reports.HandleFunc("/{id:[0-9]+}/bookings", rt.apptHandler.ListBookingsHandler).Methods("GET", "OPTIONS")
+
+	// Decision: No auth middleware; the callback is signed by the scheduling system
+	api.HandleFunc("/scheduling/webhook", rt.apptHandler.CallbackHandler).Methods("POST", "OPTIONS")
+}
+
+// setupHealthProfileRoutes configures the optional details that personalize analyses and chat
+func (rt *Router) setupHealthProfileRoutes(api *mux.Router) {
+	profile := api.PathPrefix("/health-profile").Subrouter()
+	profile.Use(rt.authMiddleware.RequireAuth)
+	profile.HandleFunc("", rt.profileHandler.GetHealthProfileHandler).Methods("GET", "OPTIONS")
+	profile.HandleFunc("", rt.profileHandler.UpdateHealthProfileHandler).Methods("PUT", "OPTIONS")
+	profile.HandleFunc("", rt.profileHandler.DeleteHealthProfileHandler).Methods("DELETE", "OPTIONS")
+}
+
+// setupConditionRoutes configures tracked chronic conditions and their overviews
+func (rt *Router) setupConditionRoutes(api *mux.Router) {
+	conditions := api.PathPrefix("/conditions").Subrouter()
+	conditions.Use(rt.authMiddleware.RequireAuth)
+	conditions.HandleFunc("", rt.condHandler.ListConditionsHandler).Methods("GET", "OPTIONS")
+	conditions.HandleFunc("/{key}", rt.condHandler.TrackConditionHandler).Methods("PUT", "OPTIONS")
+	conditions.HandleFunc("/{key}", rt.condHandler.UntrackConditionHandler).Methods("DELETE", "OPTIONS")
+	conditions.HandleFunc("/{key}/overview", rt.condHandler.ConditionOverviewHandler).Methods("GET", "OPTIONS")
 }
 
-// setupChatRoutes will configure chat endpoints
-// func (rt *Router) setupChatRoutes(api *mux.Router) {
-//     chat := api.PathPrefix("/reports/{reportId}/chat").Subrouter()
-//     chat.Use(rt.authMiddleware.RequireAuth) // All chat routes require auth
-//
-//     chat.HandleFunc("", rt.chatHandler.SendMessage).Methods("POST")
-//     chat.HandleFunc("", rt.chatHandler.GetHistory).Methods("GET")
-//     chat.HandleFunc("/{messageId}", rt.chatHandler.DeleteMessage).Methods("DELETE")
-// }
\ No newline at end of file
+// setupEmergencyCardRoutes configures the emergency card and its public viewer endpoint
+// Decision: Viewing needs no account, like share links; the token exists only while the user consents
+func (rt *Router) setupEmergencyCardRoutes(api *mux.Router) {
+	card := api.PathPrefix("/users/me/emergency-card").Subrouter()
+	card.Use(rt.authMiddleware.RequireAuth)
+	card.HandleFunc("", rt.cardHandler.GetEmergencyCardHandler).Methods("GET", "OPTIONS")
+	card.HandleFunc("/sharing", rt.cardHandler.UpdateEmergencyCardSharingHandler).Methods("PUT", "OPTIONS")
+
+	api.HandleFunc("/emergency-card/{token:[A-Za-z0-9_-]+}", rt.cardHandler.ViewPublicEmergencyCardHandler).Methods("GET", "OPTIONS")
+}
+
+// setupPlanRoutes configures the caller's plan and the admin endpoint that changes a user's plan
+func (rt *Router) setupPlanRoutes(api *mux.Router) {
+	plan := api.PathPrefix("/users/me/plan").Subrouter()
+	plan.Use(rt.authMiddleware.RequireAuth)
+	plan.HandleFunc("", rt.planHandler.GetMyPlanHandler).Methods("GET", "OPTIONS")
+
+	admin := api.PathPrefix("/admin/users").Subrouter()
+	admin.Use(rt.authMiddleware.RequireAuth)
+	admin.Use(rt.authMiddleware.RequireAdmin)
+	admin.HandleFunc("/{userId:[0-9]+}/plan", rt.planHandler.SetUserPlanHandler).Methods("PUT", "OPTIONS")
+}
+
+// setupAILogRoutes configures the caller's log of AI operations run on their data
+func (rt *Router) setupAILogRoutes(api *mux.Router) {
+	aiLog := api.PathPrefix("/users/me/ai-log").Subrouter()
+	aiLog.Use(rt.authMiddleware.RequireAuth)
+	aiLog.HandleFunc("", rt.aiLogHandler.ListAILogHandler).Methods("GET", "OPTIONS")
+}
+
+// setupBillingRoutes configures premium checkout and cancellation, and the provider's public webhook
+func (rt *Router) setupBillingRoutes(api *mux.Router) {
+	api.HandleFunc("/billing/webhook", rt.billingHandler.WebhookHandler).Methods("POST")
+
+	billing := api.PathPrefix("/billing").Subrouter()
+	billing.Use(rt.authMiddleware.RequireAuth)
+	billing.HandleFunc("/subscription", rt.billingHandler.GetBillingHandler).Methods("GET", "OPTIONS")
+	billing.HandleFunc("/checkout", rt.billingHandler.CheckoutHandler).Methods("POST", "OPTIONS")
+	billing.HandleFunc("/cancel", rt.billingHandler.CancelHandler).Methods("POST", "OPTIONS")
+}
+
+// setupPrescriptionRoutes configures photographed prescriptions and medication corrections
+func (rt *Router) setupPrescriptionRoutes(api *mux.Router) {
+	prescriptions := api.PathPrefix("/prescriptions").Subrouter()
+	prescriptions.Use(rt.authMiddleware.RequireAuth)
+	prescriptions.HandleFunc("", rt.rxHandler.ListPrescriptionsHandler).Methods("GET", "OPTIONS")
+	prescriptions.HandleFunc("", rt.rxHandler.UploadPrescriptionHandler).Methods("POST", "OPTIONS")
+	prescriptions.HandleFunc("/{id:[0-9]+}", rt.rxHandler.GetPrescriptionHandler).Methods("GET", "OPTIONS")
+	prescriptions.HandleFunc("/{id:[0-9]+}", rt.rxHandler.DeletePrescriptionHandler).Methods("DELETE", "OPTIONS")
+	prescriptions.HandleFunc("/{id:[0-9]+}/image", rt.rxHandler.GetPrescriptionImageHandler).Methods("GET", "OPTIONS")
+	prescriptions.HandleFunc("/{id:[0-9]+}/medications/{index:[0-9]+}", rt.rxHandler.CorrectMedicationHandler).Methods("PATCH", "OPTIONS")
+}
+
+// setupTransferRoutes configures report ownership transfer endpoints
+// Decision: Offers hang off the report; responses live under /transfers since the recipient doesn't own the report yet
+func (rt *Router) setupTransferRoutes(api *mux.Router) {
+	reports := api.PathPrefix("/reports").Subrouter()
+	reports.Use(rt.authMiddleware.RequireAuth)
+	reports.HandleFunc("/{id:[0-9]+}/transfer", rt.transferHandler.RequestTransferHandler).Methods("POST", "OPTIONS")
+	reports.HandleFunc("/{id:[0-9]+}/transfers", rt.transferHandler.GetReportTransfersHandler).Methods("GET", "OPTIONS")
+
+	transfers := api.PathPrefix("/transfers").Subrouter()
+	transfers.Use(rt.authMiddleware.RequireAuth)
+	transfers.HandleFunc("", rt.transferHandler.GetIncomingTransfersHandler).Methods("GET", "OPTIONS")
+	transfers.HandleFunc("/{id:[0-9]+}/accept", rt.transferHandler.AcceptTransferHandler).Methods("POST", "OPTIONS")
+	transfers.HandleFunc("/{id:[0-9]+}/decline", rt.transferHandler.DeclineTransferHandler).Methods("POST", "OPTIONS")
+	transfers.HandleFunc("/{id:[0-9]+}/cancel", rt.transferHandler.CancelTransferHandler).Methods("POST", "OPTIONS")
+}
+
+// setupShareRoutes configures share link management and the public viewer endpoint
+// Decision: Viewing needs no account; the link token (and PIN, if set) is the credential
+func (rt *Router) setupShareRoutes(api *mux.Router) {
+	reports := api.PathPrefix("/reports").Subrouter()
+	reports.Use(rt.authMiddleware.RequireAuth)
+	reports.HandleFunc("/{id:[0-9]+}/shares", rt.shareHandler.CreateShareLinkHandler).Methods("POST", "OPTIONS")
+	reports.HandleFunc("/{id:[0-9]+}/shares", rt.shareHandler.ListShareLinksHandler).Methods("GET", "OPTIONS")
+	reports.HandleFunc("/{id:[0-9]+}/shares/{shareId:[0-9]+}", rt.shareHandler.RevokeShareLinkHandler).Methods("DELETE", "OPTIONS")
+
+	api.HandleFunc("/shared/{token:[A-Za-z0-9_-]+}", rt.shareHandler.ViewSharedReportHandler).Methods("GET", "OPTIONS")
+}
+
+// setupWidgetRoutes configures widget tokens and the data endpoint embedded widgets call
+// Decision: The data endpoint sits outside RequireAuth; it accepts only widget tokens, never session tokens
+func (rt *Router) setupWidgetRoutes(api *mux.Router) {
+	widgets := api.PathPrefix("/widgets").Subrouter()
+	widgets.HandleFunc("/report", rt.widgetHandler.GetWidgetDataHandler).Methods("GET", "OPTIONS")
+
+	protected := widgets.PathPrefix("").Subrouter()
+	protected.Use(rt.authMiddleware.RequireAuth)
+	protected.HandleFunc("/token", rt.widgetHandler.CreateWidgetTokenHandler).Methods("POST", "OPTIONS")
+}
+
+// setupAdminRoutes configures operator-only endpoints
+func (rt *Router) setupAdminRoutes(api *mux.Router) {
+	admin := api.PathPrefix("/admin").Subrouter()
+	admin.Use(rt.authMiddleware.RequireAuth)
+	admin.Use(rt.authMiddleware.RequireAdmin) // Decision: Admin check needs the authenticated user
+
+	admin.HandleFunc("/prompts/stats", rt.adminHandler.GetPromptStatsHandler).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/prompts/canary", rt.adminHandler.GetPromptCanaryHandler).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/prompts/canary/rollback", rt.adminHandler.RollbackPromptCanaryHandler).Methods("POST", "OPTIONS")
+	admin.HandleFunc("/prompts/canary/promote", rt.adminHandler.PromotePromptCanaryHandler).Methods("POST", "OPTIONS")
+	admin.HandleFunc("/impersonate/{userId:[0-9]+}", rt.adminHandler.ImpersonateHandler).Methods("POST", "OPTIONS")
+	admin.HandleFunc("/audit", rt.adminHandler.GetAuditLogHandler).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/usage", rt.adminHandler.GetAPIUsageHandler).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/safety", rt.adminHandler.GetSafetyInterventionsHandler).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/crisis", rt.adminHandler.GetCrisisFlagsHandler).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/moderation/chat", rt.adminHandler.ListFlaggedChatHandler).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/moderation/chat/{messageId:[0-9]+}", rt.adminHandler.GetFlaggedChatHandler).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/moderation/chat/{messageId:[0-9]+}/dismiss", rt.adminHandler.DismissFlaggedChatHandler).Methods("POST", "OPTIONS")
+	admin.HandleFunc("/moderation/chat/{messageId:[0-9]+}/redact", rt.adminHandler.RedactFlaggedChatHandler).Methods("POST", "OPTIONS")
+	admin.HandleFunc("/users/{userId:[0-9]+}/ban", rt.adminHandler.BanUserHandler).Methods("POST", "OPTIONS")
+	admin.HandleFunc("/users/{userId:[0-9]+}/ban", rt.adminHandler.UnbanUserHandler).Methods("DELETE", "OPTIONS")
+	admin.HandleFunc("/legal-holds", rt.adminHandler.ListLegalHoldsHandler).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/users/{userId:[0-9]+}/legal-hold", rt.adminHandler.GetLegalHoldHandler).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/users/{userId:[0-9]+}/legal-hold", rt.adminHandler.PlaceLegalHoldHandler).Methods("PUT", "OPTIONS")
+	admin.HandleFunc("/users/{userId:[0-9]+}/legal-hold", rt.adminHandler.ReleaseLegalHoldHandler).Methods("DELETE", "OPTIONS")
+	admin.HandleFunc("/api-keys", rt.adminHandler.CreateScopedAPIKeyHandler).Methods("POST", "OPTIONS")
+
+	// Decision: Runbook for stuck jobs; pause/resume act on every worker through the shared queue state
+	admin.HandleFunc("/jobs", rt.adminHandler.GetQueueHandler).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/jobs/pause", rt.adminHandler.PauseQueueHandler).Methods("POST", "OPTIONS")
+	admin.HandleFunc("/jobs/resume", rt.adminHandler.ResumeQueueHandler).Methods("POST", "OPTIONS")
+	admin.HandleFunc("/jobs/{reportId:[0-9]+}", rt.adminHandler.GetJobHandler).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/jobs/{reportId:[0-9]+}/retry", rt.adminHandler.RetryJobHandler).Methods("POST", "OPTIONS")
+	admin.HandleFunc("/jobs/{reportId:[0-9]+}/cancel", rt.adminHandler.CancelJobHandler).Methods("POST", "OPTIONS")
+
+	// Decision: Analyses the model returned in an unreadable form wait here instead of reaching the patient
+	admin.HandleFunc("/reviews", rt.adminHandler.ListAnalysisReviewsHandler).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/reviews/{reportId:[0-9]+}", rt.adminHandler.GetAnalysisReviewHandler).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/reviews/{reportId:[0-9]+}/reparse", rt.adminHandler.ReparseAnalysisHandler).Methods("POST", "OPTIONS")
+
+	// Decision: When a user reports a wrong result, the exact prompts and responses behind it (needs AI_CALL_LOG_ENABLED)
+	admin.HandleFunc("/reports/{reportId:[0-9]+}/ai-calls", rt.adminHandler.GetAICallsHandler).Methods("GET", "OPTIONS")
+
+	// Decision: After a prompt or model upgrade, bring older analyses up to date at a pace the model quota allows
+	admin.HandleFunc("/reanalysis", rt.adminHandler.ListReanalysisHandler).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/reanalysis", rt.adminHandler.StartReanalysisHandler).Methods("POST", "OPTIONS")
+	admin.HandleFunc("/reanalysis/{runId:[0-9]+}", rt.adminHandler.GetReanalysisHandler).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/reanalysis/{runId:[0-9]+}/cancel", rt.adminHandler.CancelReanalysisHandler).Methods("POST", "OPTIONS")
+	admin.HandleFunc("/reanalysis/{runId:[0-9]+}/reports/{reportId:[0-9]+}", rt.adminHandler.GetReanalysisDiffHandler).Methods("GET", "OPTIONS")
+}
+
+// setupOrganizationRoutes configures organization management and branding endpoints
+// Decision: Site admins create organizations and assign members; organization admins manage their own branding
+func (rt *Router) setupOrganizationRoutes(api *mux.Router) {
+	admin := api.PathPrefix("/admin/organizations").Subrouter()
+	admin.Use(rt.authMiddleware.RequireAuth)
+	admin.Use(rt.authMiddleware.RequireAdmin)
+	admin.HandleFunc("", rt.orgHandler.CreateOrganizationHandler).Methods("POST", "OPTIONS")
+	admin.HandleFunc("/usage", rt.orgHandler.GetUsageHandler).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/{id:[0-9]+}/members", rt.orgHandler.AddOrganizationMemberHandler).Methods("POST", "OPTIONS")
+
+	org := api.PathPrefix("/organization").Subrouter()
+	org.Use(rt.authMiddleware.RequireAuth)
+	org.HandleFunc("/branding", rt.orgHandler.GetBrandingHandler).Methods("GET", "OPTIONS")
+	org.HandleFunc("/branding", rt.orgHandler.UpdateBrandingHandler).Methods("PUT", "OPTIONS")
+	org.HandleFunc("/branding/logo", rt.orgHandler.GetLogoHandler).Methods("GET", "OPTIONS")
+	org.HandleFunc("/branding/logo", rt.orgHandler.UploadLogoHandler).Methods("PUT", "OPTIONS")
+}
+
+// setupNotificationRoutes configures the user's in-app notification endpoints
+func (rt *Router) setupNotificationRoutes(api *mux.Router) {
+	notifications := api.PathPrefix("/notifications").Subrouter()
+	notifications.Use(rt.authMiddleware.RequireAuth)
+
+	notifications.HandleFunc("", rt.notifyHandler.GetNotificationsHandler).Methods("GET", "OPTIONS")
+	notifications.HandleFunc("/{id:[0-9]+}/read", rt.notifyHandler.MarkNotificationReadHandler).Methods("POST", "OPTIONS")
+}
+
+// setupDeviceRoutes configures the mobile apps' push notification registrations
+func (rt *Router) setupDeviceRoutes(api *mux.Router) {
+	devices := api.PathPrefix("/devices").Subrouter()
+	devices.Use(rt.authMiddleware.RequireAuth)
+
+	devices.HandleFunc("", rt.deviceHandler.ListDevicesHandler).Methods("GET", "OPTIONS")
+	devices.HandleFunc("", rt.deviceHandler.RegisterDeviceHandler).Methods("POST", "OPTIONS")
+	devices.HandleFunc("/{id:[0-9]+}", rt.deviceHandler.UnregisterDeviceHandler).Methods("DELETE", "OPTIONS")
+}
+
+// setupGlossaryRoutes configures term definition lookups
+// Decision: Requires auth because a miss costs a model call
+func (rt *Router) setupGlossaryRoutes(api *mux.Router) {
+	glossary := api.PathPrefix("/glossary").Subrouter()
+	glossary.Use(rt.authMiddleware.RequireAuth)
+
+	glossary.HandleFunc("", rt.glossaryHandler.GetTermHandler).Methods("GET", "OPTIONS")
+}
+
+// setupChatRoutes configures chat endpoints
+// Decision: Message-level operations are addressed by message ID; ownership is checked through the report
+func (rt *Router) setupChatRoutes(api *mux.Router) {
+	chat := api.PathPrefix("/chat").Subrouter()
+	chat.Use(rt.authMiddleware.RequireAuth) // All chat routes require auth
+
+	chat.HandleFunc("/{messageId:[0-9]+}", rt.chatHandler.EditMessageHandler).Methods("PUT", "OPTIONS")
+	chat.HandleFunc("/{messageId:[0-9]+}", rt.chatHandler.DeleteMessageHandler).Methods("DELETE", "OPTIONS")
+	chat.HandleFunc("/{messageId:[0-9]+}/regenerate", rt.chatHandler.RegenerateMessageHandler).Methods("POST", "OPTIONS")
+	chat.HandleFunc("/{messageId:[0-9]+}/versions", rt.chatHandler.GetMessageVersionsHandler).Methods("GET", "OPTIONS")
+	chat.HandleFunc("/{messageId:[0-9]+}/flag", rt.chatHandler.FlagMessageHandler).Methods("POST", "OPTIONS")
+
+	reports := api.PathPrefix("/reports").Subrouter()
+	reports.Use(rt.authMiddleware.RequireAuth)
+	reports.HandleFunc("/{id:[0-9]+}/chat", rt.chatHandler.AskHandler).Methods("POST", "OPTIONS")
+	reports.HandleFunc("/{id:[0-9]+}/chat", rt.chatHandler.GetChatHistoryHandler).Methods("GET", "OPTIONS")
+	reports.HandleFunc("/{id:[0-9]+}/chat/export", rt.chatHandler.ExportChatHandler).Methods("GET", "OPTIONS")
+	reports.HandleFunc("/{id:[0-9]+}/chat/voice", rt.chatHandler.AskVoiceHandler).Methods("POST", "OPTIONS")
+}
\ No newline at end of file
@@ -0,0 +1,225 @@
+package selftest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Check outcomes
+const (
+	StatusOK   = "ok"
+	StatusFail = "FAIL"
+	StatusSkip = "skip"
+)
+
+// ErrSkipped marks a check that doesn't apply to this configuration; its message explains why
+type ErrSkipped struct {
+	Reason string
+}
+
+func (e *ErrSkipped) Error() string {
+	return e.Reason
+}
+
+// Check verifies one dependency, returning a short description of what it found
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) (string, error)
+}
+
+// Result is the outcome of one check
+type Result struct {
+	Name   string
+	Status string
+	Detail string
+	Took   time.Duration
+}
+
+// Run executes every check in order, each with its own timeout
+// Decision: Every check runs even after a failure, so one run shows everything a deployment is missing
+func Run(ctx context.Context, checks []Check, timeout time.Duration) []Result {
+	results := make([]Result, 0, len(checks))
+	for _, check := range checks {
+		checkCtx, cancel := context.WithTimeout(ctx, timeout)
+		start := time.Now()
+		detail, err := check.Run(checkCtx)
+		cancel()
+
+		result := Result{Name: check.Name, Status: StatusOK, Detail: detail, Took: time.Since(start)}
+		var skipped *ErrSkipped
+		if errors.As(err, &skipped) {
+			result.Status, result.Detail = StatusSkip, skipped.Reason
+		} else if err != nil {
+			result.Status, result.Detail = StatusFail, err.Error()
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// Failed reports whether any check failed; skipped checks don't count
+func Failed(results []Result) bool {
+	for _, result := range results {
+		if result.Status == StatusFail {
+			return true
+		}
+	}
+	return false
+}
+
+// Print writes the results as an aligned table
+func Print(w io.Writer, results []Result) {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "CHECK\tSTATUS\tTIME\tDETAIL")
+	for _, result := range results {
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\n", result.Name, result.Status, result.Took.Round(time.Millisecond), result.Detail)
+	}
+	table.Flush()
+}
+
+// DatabaseCheck pings the database
+func DatabaseCheck(db *sql.DB) Check {
+	return Check{Name: "database", Run: func(ctx context.Context) (string, error) {
+		if err := db.PingContext(ctx); err != nil {
+			return "", err
+		}
+		return "reachable", nil
+	}}
+}
+
+// SchemaCheck compares the migrations goose applied with the migration files shipped in dir
+// Decision: A database behind the build fails as surely as one ahead of it; either means queries against missing or unknown columns
+func SchemaCheck(db *sql.DB, dir string) Check {
+	return Check{Name: "schema", Run: func(ctx context.Context) (string, error) {
+		shipped, err := migrationVersions(dir)
+		if err != nil {
+			return "", err
+		}
+		applied, err := appliedVersions(ctx, db)
+		if err != nil {
+			return "", fmt.Errorf("no migration history (run make migrate-up): %w", err)
+		}
+
+		var pending []int64
+		for _, version := range shipped {
+			if !applied[version] {
+				pending = append(pending, version)
+			}
+		}
+		var current int64
+		for version := range applied {
+			current = max(current, version)
+		}
+		latest := shipped[len(shipped)-1]
+
+		if len(pending) > 0 {
+			return "", fmt.Errorf("at %d, %d migration(s) pending, first %d", current, len(pending), pending[0])
+		}
+		if current > latest {
+			return "", fmt.Errorf("database at %d is newer than this build's latest migration %d", current, latest)
+		}
+		return fmt.Sprintf("version %d", current), nil
+	}}
+}
+
+// UploadDirCheck creates, writes, and removes a file in the upload directory
+func UploadDirCheck(dir string) Check {
+	return Check{Name: "upload dir", Run: func(ctx context.Context) (string, error) {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return "", err
+		}
+		file, err := os.CreateTemp(dir, ".selftest-*")
+		if err != nil {
+			return "", err
+		}
+		defer os.Remove(file.Name())
+		if _, err := file.WriteString("selftest"); err != nil {
+			file.Close()
+			return "", err
+		}
+		if err := file.Close(); err != nil {
+			return "", err
+		}
+		return dir + " writable", nil
+	}}
+}
+
+// PingCheck calls ping, e.g. to validate an API key without spending tokens
+func PingCheck(name, target string, ping func(ctx context.Context) error) Check {
+	return Check{Name: name, Run: func(ctx context.Context) (string, error) {
+		if err := ping(ctx); err != nil {
+			return "", err
+		}
+		return target + " accepted credentials", nil
+	}}
+}
+
+// Failing is a check that reports an error found while setting up the checks, e.g. a refused connection
+func Failing(name string, err error) Check {
+	return Check{Name: name, Run: func(ctx context.Context) (string, error) {
+		return "", err
+	}}
+}
+
+// Skipped is a check that only reports why it doesn't apply
+func Skipped(name, reason string) Check {
+	return Check{Name: name, Run: func(ctx context.Context) (string, error) {
+		return "", &ErrSkipped{Reason: reason}
+	}}
+}
+
+// migrationVersions lists the versions of the goose migrations in dir, oldest first
+func migrationVersions(dir string) ([]int64, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
+		return nil, err
+	}
+	var versions []int64
+	for _, path := range paths {
+		prefix, _, _ := strings.Cut(filepath.Base(path), "_")
+		if version, err := strconv.ParseInt(prefix, 10, 64); err == nil {
+			versions = append(versions, version)
+		}
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("no migrations found in %s", dir)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	return versions, nil
+}
+
+// appliedVersions reads goose's history; each version's latest row says whether it is applied
+func appliedVersions(ctx context.Context, db *sql.DB) (map[int64]bool, error) {
+	rows, err := db.QueryContext(ctx, `SELECT version_id, is_applied FROM goose_db_version ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]bool)
+	for rows.Next() {
+		var version int64
+		var isApplied bool
+		if err := rows.Scan(&version, &isApplied); err != nil {
+			return nil, err
+		}
+		if isApplied {
+			applied[version] = true
+		} else {
+			delete(applied, version)
+		}
+	}
+	// Goose's own bootstrap row
+	delete(applied, 0)
+	return applied, rows.Err()
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// WriteAnnualReview asks the model for an overview of a user's year of reports
+func (ai *AIService) WriteAnnualReview(ctx context.Context, year int, entries []AnnualReportEntry, trends []MetricTrend, readingLevel string) (*AnnualReviewText, error) {
+	response, err := ai.generateText(ctx, AICallAnnual, buildAnnualReviewPrompt(year, entries, trends, readingLevel))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate annual review: %w", err)
+	}
+
+	var text AnnualReviewText
+	if err := json.Unmarshal([]byte(extractJSONObject(response)), &text); err != nil {
+		return nil, fmt.Errorf("failed to parse annual review: %w", err)
+	}
+	if strings.TrimSpace(text.Overview) == "" {
+		return nil, fmt.Errorf("annual review has no overview")
+	}
+	return &text, nil
+}
+
+// buildAnnualReviewPrompt lays out the year's trends and each report's findings and recommendations
+// Decision: Trends are computed before the prompt so the model describes movements rather than deriving them
+func buildAnnualReviewPrompt(year int, entries []AnnualReportEntry, trends []MetricTrend, readingLevel string) string {
+	var prompt strings.Builder
+
+	fmt.Fprintf(&prompt, "Write a year-in-review of this patient's health for %d from %d medical reports.\n", year, len(entries))
+	prompt.WriteString(`Respond with JSON only, in this shape:
+{"overview": "...", "improvements": ["..."], "deteriorations": ["..."], "unresolved_recommendations": ["..."]}
+- overview: one paragraph on the year as a whole
+- improvements and deteriorations: one item per result that moved, naming the values and dates
+- unresolved_recommendations: advice from earlier reports that later results suggest has not yet been acted on or resolved
+Use empty lists rather than inventing items. Do not diagnose.
+`)
+	prompt.WriteString(summaryGuidance[readingLevelOrDefault(readingLevel)])
+	prompt.WriteString("\n\nRESULT TRENDS (first and last reading of the year):\n")
+	for _, trend := range trends {
+		fmt.Fprintf(&prompt, "- %s: %s %s (%s, %s) -> %s %s (%s, %s), %d readings, %s\n", trend.Name,
+			trend.FirstValue, trend.Unit, trend.FirstStatus, trend.FirstDate.Format("2006-01-02"),
+			trend.LastValue, trend.Unit, trend.LastStatus, trend.LastDate.Format("2006-01-02"),
+			trend.Measurements, trend.Direction)
+	}
+
+	for _, entry := range entries {
+		fmt.Fprintf(&prompt, "\nREPORT %s: %s (overall risk %s)\n", entry.Date.Format("2006-01-02"), entry.Label, entry.Analysis.RiskLevel)
+		for _, finding := range entry.Analysis.KeyFindings {
+			fmt.Fprintf(&prompt, "Finding: %s\n", finding)
+		}
+		for _, recommendation := range entry.Analysis.Recommendations {
+			fmt.Fprintf(&prompt, "Recommendation: %s\n", recommendation)
+		}
+	}
+
+	return prompt.String()
+}
@@ -0,0 +1,337 @@
+package services
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+// What a logged model call was made for
+const (
+	AICallAnalysis    = "analysis"
+	AICallMerge       = "merge"
+	AICallChat        = "chat"
+	AICallSummary     = "summary"
+	AICallTranslation = "translation"
+	AICallGlossary    = "glossary"
+	AICallAnnual      = "annual"
+)
+
+// aiCallReportKey carries the report a model call is made for
+type aiCallReportKey struct{}
+
+// WithAICallReport attributes model calls made with the returned context to a report
+func WithAICallReport(ctx context.Context, reportID int) context.Context {
+	return context.WithValue(ctx, aiCallReportKey{}, reportID)
+}
+
+// aiCallReport returns the report set with WithAICallReport, or 0
+func aiCallReport(ctx context.Context) int {
+	reportID, _ := ctx.Value(aiCallReportKey{}).(int)
+	return reportID
+}
+
+// aiCallUserKey carries the user whose data a model call is made with
+type aiCallUserKey struct{}
+
+// WithAICallUser attributes model calls made with the returned context to a user, for their AI usage log
+func WithAICallUser(ctx context.Context, userID int) context.Context {
+	return context.WithValue(ctx, aiCallUserKey{}, userID)
+}
+
+// aiCallUser returns the user set with WithAICallUser, or 0
+func aiCallUser(ctx context.Context) int {
+	userID, _ := ctx.Value(aiCallUserKey{}).(int)
+	return userID
+}
+
+// AICallParameters are the generation settings a call was made with, stored as JSON
+type AICallParameters struct {
+	Temperature     float32 `json:"temperature"`
+	MaxOutputTokens int32   `json:"max_output_tokens"`
+	TopK            int32   `json:"top_k,omitempty"`
+	TopP            float32 `json:"top_p,omitempty"`
+	SystemPrompt    string  `json:"system_prompt"`
+}
+
+// aiCallSettings describes the model behind an AIService for the call log
+type aiCallSettings struct {
+	provider   string
+	model      string
+	parameters AICallParameters
+}
+
+// newAICallSettings mirrors the settings NewLLMProvider applies for cfg
+func newAICallSettings(cfg config.AIConfig, systemPrompt string) aiCallSettings {
+	settings := aiCallSettings{
+		provider: "gemini",
+		model:    geminiModelName(cfg),
+		parameters: AICallParameters{
+			Temperature:     cfg.Temperature,
+			MaxOutputTokens: cfg.MaxTokens,
+			TopK:            geminiTopK,
+			TopP:            geminiTopP,
+			SystemPrompt:    systemPrompt,
+		},
+	}
+	if strings.EqualFold(cfg.Provider, "ollama") {
+		settings.provider, settings.model = "ollama", cfg.OllamaModel
+		settings.parameters.TopK, settings.parameters.TopP = 0, 0
+	}
+	return settings
+}
+
+// AICallRecorder keeps the exact prompt and raw response of every model call, encrypted, for a limited time
+// Decision: Prompts and responses quote the patient's report, so both are sealed with AES-GCM before they reach
+// the database; provider, model, and parameters stay readable so calls can be filtered without the key
+type AICallRecorder struct {
+	repo      models.AICallRepository
+	aead      cipher.AEAD
+	retention time.Duration
+}
+
+// NewAICallRecorder creates a recorder, or returns nil when the call log is disabled
+func NewAICallRecorder(repo models.AICallRepository, cfg config.AICallLogConfig) (*AICallRecorder, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(cfg.EncryptionKey)
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("AI_CALL_LOG_KEY must be a base64-encoded 32-byte key (generate one with: openssl rand -base64 32)")
+	}
+	if cfg.Retention <= 0 {
+		return nil, fmt.Errorf("AI_CALL_LOG_RETENTION must be positive")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AI call log cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AI call log cipher: %w", err)
+	}
+
+	return &AICallRecorder{repo: repo, aead: aead, retention: cfg.Retention}, nil
+}
+
+// Retention is how long calls are kept
+func (rec *AICallRecorder) Retention() time.Duration {
+	return rec.retention
+}
+
+// Record stores one call; failures are logged and never fail the call itself
+func (rec *AICallRecorder) Record(call *models.AICall, prompt, response string) {
+	sealedPrompt, err := rec.seal(prompt)
+	if err == nil {
+		call.Prompt = sealedPrompt
+		call.Response, err = rec.seal(response)
+	}
+	if err == nil {
+		err = rec.repo.Create(call)
+	}
+	if err != nil {
+		slog.Error("Failed to log AI call", "purpose", call.Purpose, "error", err)
+	}
+}
+
+// Calls returns the calls made for a report with their prompts and responses decrypted
+func (rec *AICallRecorder) Calls(reportID int) ([]*models.AICall, error) {
+	calls, err := rec.repo.ListByReport(reportID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, call := range calls {
+		if call.Prompt, err = rec.open(call.Prompt); err != nil {
+			return nil, fmt.Errorf("failed to decrypt AI call %d: %w", call.ID, err)
+		}
+		if call.Response, err = rec.open(call.Response); err != nil {
+			return nil, fmt.Errorf("failed to decrypt AI call %d: %w", call.ID, err)
+		}
+	}
+	return calls, nil
+}
+
+// UserLog returns a page of the calls made with a user's data, newest first
+// Decision: Read straight from the table without the key; the page carries what was run, with which model,
+// and when, never the prompts or responses that quote the user's reports
+func (rec *AICallRecorder) UserLog(userID, limit, offset int) ([]*models.AICall, error) {
+	return rec.repo.ListByUser(userID, limit, offset)
+}
+
+// Purge deletes calls older than the retention period and returns how many were removed
+func (rec *AICallRecorder) Purge() (int64, error) {
+	return rec.repo.DeleteBefore(time.Now().Add(-rec.retention))
+}
+
+// Run purges expired calls hourly until ctx is cancelled
+func (rec *AICallRecorder) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		if removed, err := rec.Purge(); err != nil {
+			slog.Error("Failed to purge expired AI calls", "error", err)
+		} else if removed > 0 {
+			slog.Info("Purged expired AI calls", "count", removed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// seal encrypts text as base64(nonce || ciphertext); empty text stays empty
+func (rec *AICallRecorder) seal(text string) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+	nonce := make([]byte, rec.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(rec.aead.Seal(nonce, nonce, []byte(text), nil)), nil
+}
+
+// open reverses seal
+func (rec *AICallRecorder) open(sealed string) (string, error) {
+	if sealed == "" {
+		return "", nil
+	}
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < rec.aead.NonceSize() {
+		return "", fmt.Errorf("ciphertext too short")
+	}
+	nonce, ciphertext := data[:rec.aead.NonceSize()], data[rec.aead.NonceSize():]
+	text, err := rec.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(text), nil
+}
+
+// SetCallRecorder logs every model call the service makes; nil disables logging
+func (ai *AIService) SetCallRecorder(rec *AICallRecorder) {
+	ai.calls = rec
+}
+
+// SetUsageMeter counts every model call made with a user's data toward their organization's usage; nil disables it
+func (ai *AIService) SetUsageMeter(usage *OrganizationUsageService) {
+	ai.usage = usage
+}
+
+// generate sends a prompt to the model, logging the call when a recorder is set
+// Decision: The one path to the provider for analysis and chat alike, so no call escapes the log
+func (ai *AIService) generate(ctx context.Context, purpose, prompt string) (string, error) {
+	return ai.generateOn(ctx, ai.provider, ai.callSettings, purpose, prompt)
+}
+
+// generateOn is generate on a given model, such as a prompt canary's
+func (ai *AIService) generateOn(ctx context.Context, provider *rateLimitedProvider, settings aiCallSettings, purpose, prompt string) (string, error) {
+	start := time.Now()
+	ctx, tokens := withTokenUsage(ctx)
+	response, err := provider.Generate(ctx, prompt)
+	// Decision: Failed calls aren't metered; organizations are billed for answers, not for the provider's errors
+	if ai.usage != nil && err == nil && aiCallUser(ctx) != 0 {
+		ai.usage.RecordAICall(aiCallUser(ctx), *tokens)
+	}
+	if ai.calls == nil {
+		return response, err
+	}
+
+	parameters := settings.parameters
+	if n, ok := maxOutputTokens(ctx); ok {
+		parameters.MaxOutputTokens = n
+	}
+	parametersJSON, _ := json.Marshal(parameters)
+
+	call := &models.AICall{
+		ReportID:   aiCallReport(ctx),
+		UserID:     aiCallUser(ctx),
+		Purpose:    purpose,
+		Provider:   settings.provider,
+		Model:      settings.model,
+		Parameters: string(parametersJSON),
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		call.Error = err.Error()
+	}
+	ai.calls.Record(call, prompt, response)
+
+	return response, err
+}
+
+// ProvenanceService shows operators how an analysis was produced when a user reports a wrong result, and
+// users what the AI did with their data
+type ProvenanceService struct {
+	reportRepo models.ReportRepository
+	auditRepo  models.AuditLogRepository
+	recorder   *AICallRecorder // Optional; nil means the call log is disabled
+}
+
+// NewProvenanceService creates a new analysis provenance service
+func NewProvenanceService(reportRepo models.ReportRepository, auditRepo models.AuditLogRepository, recorder *AICallRecorder) *ProvenanceService {
+	return &ProvenanceService{reportRepo: reportRepo, auditRepo: auditRepo, recorder: recorder}
+}
+
+// Calls returns the logged model calls behind a report's analysis, oldest first
+// Decision: Decrypted prompts quote the patient's report, so each view is audited like an analysis review
+func (ps *ProvenanceService) Calls(admin *models.User, reportID int) ([]*models.AICall, error) {
+	if ps.recorder == nil {
+		return nil, errors.ErrAICallLogDisabled
+	}
+
+	report, err := ps.reportRepo.GetByID(reportID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if report == nil {
+		return nil, errors.ErrRecordNotFound
+	}
+
+	calls, err := ps.recorder.Calls(reportID)
+	if err != nil {
+		slog.Error("Failed to load AI calls", "report_id", reportID, "error", err)
+		return nil, errors.ErrDatabaseConnection
+	}
+
+	entry := &models.AuditLog{ActorID: admin.ID, UserID: report.UserID, Action: models.AuditAICallsViewed,
+		Details: fmt.Sprintf("report %d", reportID)}
+	if err := ps.auditRepo.Create(entry); err != nil {
+		slog.Error("Failed to audit admin action", "action", entry.Action, "admin_id", admin.ID, "error", err)
+	}
+	return calls, nil
+}
+
+// UsageLog returns a page of the model calls made with the user's data, newest first, without PHI
+func (ps *ProvenanceService) UsageLog(userID, limit, offset int) ([]*models.AICall, error) {
+	if ps.recorder == nil {
+		return nil, errors.ErrAICallLogDisabled
+	}
+
+	calls, err := ps.recorder.UserLog(userID, limit, offset)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	return calls, nil
+}
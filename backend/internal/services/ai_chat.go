@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+)
+
+// ChatResponder answers follow-up questions about an analyzed report
+// Decision: Implemented by AIService and by DemoAnalyzer for keyless demo deployments
+type ChatResponder interface {
+	// AnswerQuestion answers question given the report, a summary of older turns, and the recent turns
+	// readingLevel is one of the models.ReadingLevel* constants; patient is PatientContext output, possibly empty
+	AnswerQuestion(ctx context.Context, reportSummary, conversationSummary string, history []*models.ChatMessage, question, readingLevel, patient string) (string, error)
+
+	// SummarizeConversation folds turns into previousSummary, which may be empty
+	SummarizeConversation(ctx context.Context, previousSummary string, turns []*models.ChatMessage) (string, error)
+}
+
+// AnswerQuestion asks the model a question about a report, using prior turns as context
+func (ai *AIService) AnswerQuestion(ctx context.Context, reportSummary, conversationSummary string, history []*models.ChatMessage, question, readingLevel, patient string) (string, error) {
+	prompt := ai.buildChatPrompt(reportSummary, conversationSummary, history, question, readingLevel, patient)
+	return ai.generateText(ctx, AICallChat, prompt)
+}
+
+// SummarizeConversation condenses older chat turns so they fit in later prompts
+func (ai *AIService) SummarizeConversation(ctx context.Context, previousSummary string, turns []*models.ChatMessage) (string, error) {
+	var prompt strings.Builder
+
+	prompt.WriteString(`Summarize this conversation between a patient and a medical report assistant.
+Keep every question the patient asked, the key facts and values discussed, and any advice given.
+Write at most 200 words in third person. Output only the summary.
+
+`)
+	if previousSummary != "" {
+		prompt.WriteString("SUMMARY OF EARLIER CONVERSATION:\n")
+		prompt.WriteString(previousSummary)
+		prompt.WriteString("\n\nCONTINUED CONVERSATION:\n")
+	}
+	writeChatTurns(&prompt, turns)
+
+	return ai.generateText(ctx, AICallSummary, prompt.String())
+}
+
+// buildChatPrompt lays out the report analysis, the conversation so far, and the new question
+func (ai *AIService) buildChatPrompt(reportSummary, conversationSummary string, history []*models.ChatMessage, question, readingLevel, patient string) string {
+	var prompt strings.Builder
+
+	// Decision: Identity comes from the persona system instruction; this template frames the task
+	// and adjusts the register to the reader's level
+	prompt.WriteString("Answer the patient's question about their medical report.\n")
+	prompt.WriteString(chatGuidance[readingLevelOrDefault(readingLevel)])
+	prompt.WriteString("\n\nREPORT ANALYSIS:\n")
+	prompt.WriteString(reportSummary)
+	prompt.WriteString("\n\n")
+
+	if patient != "" {
+		prompt.WriteString("PATIENT PROFILE (as entered by the patient; tailor advice to it and avoid their allergies):\n")
+		prompt.WriteString(patient)
+		prompt.WriteString("\n\n")
+	}
+
+	if conversationSummary != "" {
+		prompt.WriteString("EARLIER CONVERSATION (SUMMARIZED):\n")
+		prompt.WriteString(conversationSummary)
+		prompt.WriteString("\n\n")
+	}
+
+	if len(history) > 0 {
+		prompt.WriteString("CONVERSATION SO FAR:\n")
+		writeChatTurns(&prompt, history)
+		prompt.WriteString("\n")
+	}
+
+	fmt.Fprintf(&prompt, "Patient: %s\nAssistant:", question)
+	return prompt.String()
+}
+
+// writeChatTurns renders question/answer pairs as a transcript
+func writeChatTurns(prompt *strings.Builder, turns []*models.ChatMessage) {
+	for _, message := range turns {
+		fmt.Fprintf(prompt, "Patient: %s\nAssistant: %s\n", message.UserMessage, message.AIResponse)
+	}
+}
+
+// generateText sends a prompt to the model and returns the trimmed reply; purpose is one of the AICall* constants
+func (ai *AIService) generateText(ctx context.Context, purpose, prompt string) (string, error) {
+	responseText, err := ai.generate(ctx, purpose, prompt)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(responseText), nil
+}
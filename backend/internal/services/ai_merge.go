@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MergeSource is one analyzed report fed into a combined assessment
+type MergeSource struct {
+	Label      string // Report title or filename
+	ReportDate *time.Time
+	Analysis   *AnalysisResult
+}
+
+// CombinedAnalyzer writes one assessment across several analyzed reports
+// Decision: Implemented by AIService and by DemoAnalyzer for keyless demo deployments
+type CombinedAnalyzer interface {
+	// readingLevel is one of the models.ReadingLevel* constants; plan one of the models.Plan* constants
+	AnalyzeCombined(ctx context.Context, sources []MergeSource, readingLevel, plan string) (*ReportAnalysis, error)
+}
+
+// AnalyzeCombined asks the model for one assessment of several reports from the same checkup
+// Decision: The model sees the stored analyses, not the files again, so merging never re-reads or re-OCRs uploads
+func (ai *AIService) AnalyzeCombined(ctx context.Context, sources []MergeSource, readingLevel, plan string) (*ReportAnalysis, error) {
+	variant := ai.selectPromptVariant()
+	analysis, err := ai.generateAnalysis(ctx, AICallMerge, buildMergedContent(sources), variant, readingLevel, PlanLimits(ai.plans, plan), "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate combined analysis: %w", err)
+	}
+
+	analysis.SchemaVersion = CurrentAnalysisSchemaVersion
+	analysisJSON, err := json.Marshal(analysis)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize analysis: %w", err)
+	}
+
+	return &ReportAnalysis{
+		ResultJSON:    string(analysisJSON),
+		PromptVersion: variant.Version,
+	}, nil
+}
+
+// buildMergedContent lays out each report's results as the report content of the analysis prompt
+func buildMergedContent(sources []MergeSource) string {
+	var content strings.Builder
+
+	fmt.Fprintf(&content, "These are %d reports from the same patient, analyzed separately. "+
+		"Write one combined assessment: relate results across reports where they bear on each other "+
+		"(for example anemia and thyroid function, or glucose and lipids), avoid repeating the same finding, "+
+		"and give a single overall risk level.\n", len(sources))
+
+	for i, source := range sources {
+		fmt.Fprintf(&content, "\nREPORT %d: %s", i+1, source.Label)
+		if source.ReportDate != nil {
+			fmt.Fprintf(&content, " (%s)", source.ReportDate.Format("2006-01-02"))
+		}
+		content.WriteString("\n")
+
+		analysis := source.Analysis
+		if analysis.Summary != "" {
+			fmt.Fprintf(&content, "Summary: %s\n", analysis.Summary)
+		}
+		if len(analysis.HealthMetrics) > 0 {
+			content.WriteString("Results:\n")
+			for _, metric := range analysis.HealthMetrics {
+				fmt.Fprintf(&content, "- %s: %s %s (normal %g-%g, %s)\n", metric.Name, metric.GetValueAsString(),
+					metric.Unit, metric.RangeMin, metric.RangeMax, metric.Status)
+			}
+		}
+		if len(analysis.KeyFindings) > 0 {
+			content.WriteString("Findings:\n")
+			for _, finding := range analysis.KeyFindings {
+				fmt.Fprintf(&content, "- %s\n", finding)
+			}
+		}
+	}
+
+	return content.String()
+}
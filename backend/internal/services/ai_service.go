@@ -3,26 +3,33 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"math/rand/v2"
 	"os"
 	"path/filepath"
 	"strings"
 
-	"github.com/google/generative-ai-go/genai"
-	"github.com/ledongthuc/pdf"
-	"google.golang.org/api/option"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
 )
 
 // HealthMetric represents a single health parameter with scoring
 type HealthMetric struct {
-	Name        string      `json:"name"`
-	Value       interface{} `json:"value"`       // Can be string or number from AI
-	Unit        string      `json:"unit"`
-	Score       float64     `json:"score"`       // 0-100 score for speedometer
-	Status      string      `json:"status"`      // "normal", "warning", "critical"
-	RangeMin    float64     `json:"range_min"`   // Normal range minimum
-	RangeMax    float64     `json:"range_max"`   // Normal range maximum
-	Description string      `json:"description"` // Explanation for user
+	Name          string      `json:"name"`
+	Value         interface{} `json:"value"` // Can be string or number from AI
+	Unit          string      `json:"unit"`
+	Score         float64     `json:"score"`                    // 0-100 score for speedometer
+	Status        string      `json:"status"`                   // "normal", "warning", "critical"
+	RangeMin      float64     `json:"range_min"`                // Normal range minimum
+	RangeMax      float64     `json:"range_max"`                // Normal range maximum
+	Description   string      `json:"description"`              // Explanation for user
+	Conditions    []string    `json:"conditions,omitempty"`     // Tracked-condition keys this metric relates to
+	UnitInferred  bool        `json:"unit_inferred,omitempty"`  // The report gave no unit; Unit, if any, was inferred from the catalog
+	LowConfidence bool        `json:"low_confidence,omitempty"` // The unit is a guess or unknown, so the value shouldn't be drawn on a gauge
+	UnitNote      string      `json:"unit_note,omitempty"`      // Explains the inference to the reader
+	Confidence    float64     `json:"confidence,omitempty"`     // 0-1, how sure the value was read right; higher when the model and the printed text agree
+	ValueNote     string      `json:"value_note,omitempty"`     // Explains a value corrected from the printed text
 }
 
 // GetValueAsString converts the value to string format for display
@@ -40,208 +47,325 @@ func (h *HealthMetric) GetValueAsString() string {
 }
 
 // AnalysisResult contains the complete AI analysis
+// Decision: SchemaVersion lets stored blobs be upgraded on read (see analysis_schema.go)
 type AnalysisResult struct {
+	SchemaVersion   int             `json:"schema_version"`
 	Summary         string          `json:"summary"`
 	SimpleSummary   string          `json:"simple_summary"`
 	HealthMetrics   []HealthMetric  `json:"health_metrics"`
 	KeyFindings     []string        `json:"key_findings"`
 	Recommendations []string        `json:"recommendations"`
 	RiskLevel       string          `json:"risk_level"` // "low", "medium", "high"
+	GlossaryTerms   []GlossaryRef   `json:"glossary_terms"` // Jargon in simple_summary the frontend links to the glossary
+	ConditionFindings map[string][]string `json:"condition_findings,omitempty"` // Key findings grouped by the condition they relate to
+	Completeness    *Completeness   `json:"completeness,omitempty"` // Which expected tests of the detected panels are missing
+	AnalysisMode    string          `json:"analysis_mode,omitempty"`  // AnalysisModeBasic when no model was involved; empty for model analyses
+	ExtractedText   string          `json:"extracted_text,omitempty"` // The report's text, kept with basic analyses so nothing read is lost
 }
 
-// AIService handles AI-powered report analysis using Gemini
-type AIService struct {
-	client     *genai.Client
-	model      *genai.GenerativeModel
-	apiKey     string
-	maxTokens  int32
+// PromptVariant identifies a prompt template file and the version label stored with analyses
+type PromptVariant struct {
+	Version string
+	Path    string
+
+	model *variantModel // Optional; nil runs on the service's model
 }
 
-// NewAIService creates a new AI service instance
-func NewAIService(apiKey string) (*AIService, error) {
-	if apiKey == "" {
-		return nil, fmt.Errorf("Gemini API key is required")
-	}
+// variantModel is a model a prompt variant runs on instead of the service's own
+type variantModel struct {
+	provider *rateLimitedProvider
+	settings aiCallSettings
+}
 
-	ctx := context.Background()
-	client, err := genai.NewClient(ctx, option.WithAPIKey(apiKey))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create Gemini client: %w", err)
-	}
-
-	// Configure the model for medical report analysis
-	model := client.GenerativeModel("gemini-1.5-flash")
-	model.SetTemperature(0.3) // Lower temperature for more consistent medical analysis
-	model.SetTopK(40)
-	model.SetTopP(0.95)
-	model.SetMaxOutputTokens(2048)
-
-	// Set safety settings for medical content
-	model.SafetySettings = []*genai.SafetySetting{
-		{
-			Category:  genai.HarmCategoryHarassment,
-			Threshold: genai.HarmBlockMediumAndAbove,
-		},
-		{
-			Category:  genai.HarmCategoryHateSpeech,
-			Threshold: genai.HarmBlockMediumAndAbove,
-		},
-		{
-			Category:  genai.HarmCategoryDangerousContent,
-			Threshold: genai.HarmBlockMediumAndAbove,
-		},
-		{
-			Category:  genai.HarmCategorySexuallyExplicit,
-			Threshold: genai.HarmBlockMediumAndAbove,
-		},
-	}
-
-	return &AIService{
-		client:    client,
-		model:     model,
-		apiKey:    apiKey,
-		maxTokens: 2048,
-	}, nil
+// promptCanaryKey marks analyses that may be served by the prompt canary
+type promptCanaryKey struct{}
+
+// WithPromptCanary lets the analysis made with the returned context go to the prompt canary
+// Decision: Only new reports opt in; re-analyses and merges replace an analysis the patient already has, so a
+// candidate that turns out worse would overwrite a good one
+func WithPromptCanary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, promptCanaryKey{}, true)
 }
 
-// AnalyzeReport processes a medical report file and returns comprehensive analysis
-func (ai *AIService) AnalyzeReport(filePath, fileType string) (string, error) {
-	fmt.Println("--- AI Service: AnalyzeReport ---")
-	fmt.Println("File path:", filePath)
-	fmt.Println("File type:", fileType)
+// ReportAnalysis is the outcome of analyzing one report
+// Decision: Carry prompt version and parse status alongside the JSON so callers can persist them
+type ReportAnalysis struct {
+	ResultJSON    string // Empty when ParseFailed
+	PromptVersion string
+	ParseFailed   bool
+	RawOutput     string // The model's response as received, kept for manual review when ParseFailed
+	ParseError    string
+	Language      string // ISO 639-1 code of the language the report was printed in, before any translation
+}
 
-	// Extract text content from file
-	content, err := ai.extractTextFromFile(filePath, fileType)
-	if err != nil {
-		return "", fmt.Errorf("failed to extract text from file: %w", err)
-	}
-	fmt.Println("Extracted content length:", len(content))
+// AnalysisParseError means the model answered but its response isn't a usable analysis
+type AnalysisParseError struct {
+	Raw string
+	Err error
+}
+
+func (e *AnalysisParseError) Error() string {
+	return fmt.Sprintf("failed to parse analysis response: %v", e.Err)
+}
+
+func (e *AnalysisParseError) Unwrap() error {
+	return e.Err
+}
+
+// AIService handles AI-powered report analysis using the configured LLM provider
+type AIService struct {
+	provider *rateLimitedProvider
+
+	// Decision: Control prompt always exists; variant B is optional for A/B tests
+	promptA        PromptVariant
+	promptB        *PromptVariant
+	promptBPercent int
+
+	// Canary serves a candidate prompt or model to canaryPercent% of new reports while canaryGate allows it
+	canary        *PromptVariant
+	canaryPercent int
+	canaryGate    func() bool
+
+	extractor *TextExtractor
+	plans     map[string]config.PlanConfig
+
+	calls        *AICallRecorder           // Optional; nil logs no calls
+	usage        *OrganizationUsageService // Optional; nil meters no calls
+	callSettings aiCallSettings
+}
 
-	// Generate comprehensive analysis
-	analysis, err := ai.generateAnalysis(content)
+// NewAIService creates a new AI service instance
+func NewAIService(cfg config.AIConfig) (*AIService, error) {
+	systemPrompt, err := BuildSystemPrompt(cfg.Persona)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate AI analysis: %w", err)
+		return nil, err
 	}
 
-	// Convert to JSON for storage
-	analysisJSON, err := json.Marshal(analysis)
+	provider, err := NewLLMProvider(cfg, systemPrompt)
 	if err != nil {
-		return "", fmt.Errorf("failed to serialize analysis: %w", err)
+		return nil, err
 	}
 
-	return string(analysisJSON), nil
-}
+	limiter := NewLLMRateLimiter(cfg.RequestsPerMinute, cfg.TokensPerMinute)
 
-// extractTextFromFile extracts text content based on file type
-func (ai *AIService) extractTextFromFile(filePath, fileType string) (string, error) {
-	ext := strings.ToLower(filepath.Ext(filePath))
+	ai := &AIService{
+		provider: newRateLimitedProvider(provider, limiter, cfg.RateLimitRetries, cfg.RateLimitBackoff),
+		promptA:  PromptVariant{Version: cfg.PromptVersion, Path: cfg.PromptPath},
 
-	switch ext {
-	case ".txt":
-		return ai.extractFromTXT(filePath)
-	case ".pdf":
-		return ai.extractFromPDF(filePath)
-	case ".docx", ".doc":
-		return ai.extractFromDOCX(filePath)
-	default:
-		return "", fmt.Errorf("unsupported file type: %s", ext)
+		extractor: NewTextExtractor(cfg.Extraction),
+		plans:     cfg.Plans,
+
+		callSettings: newAICallSettings(cfg, systemPrompt),
 	}
-}
 
-// extractFromTXT reads plain text files
-func (ai *AIService) extractFromTXT(filePath string) (string, error) {
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return "", err
+	if cfg.PromptBPath != "" && cfg.PromptBPercent > 0 {
+		ai.promptB = &PromptVariant{Version: cfg.PromptBVersion, Path: cfg.PromptBPath}
+		ai.promptBPercent = min(cfg.PromptBPercent, 100)
+	}
+
+	if cfg.Canary.Version != "" && cfg.Canary.Percent > 0 {
+		if err := ai.setupCanary(cfg, systemPrompt, limiter); err != nil {
+			return nil, err
+		}
 	}
-	return string(content), nil
+
+	return ai, nil
 }
 
-// extractFromPDF extracts text from PDF files using ledongthuc/pdf library
-func (ai *AIService) extractFromPDF(filePath string) (string, error) {
-	f, r, err := pdf.Open(filePath)
-	if err != nil {
-		return "", fmt.Errorf("failed to open PDF: %w", err)
+// setupCanary prepares the canary variant, with its own model when cfg.Canary names one
+// Decision: The canary shares the stable provider's rate limiter, since both spend the same quota
+func (ai *AIService) setupCanary(cfg config.AIConfig, systemPrompt string, limiter *LLMRateLimiter) error {
+	canary := cfg.Canary
+	if canary.Version == ai.promptA.Version || (ai.promptB != nil && canary.Version == ai.promptB.Version) {
+		return fmt.Errorf("AI_CANARY_VERSION %q must differ from the stable prompt versions", canary.Version)
 	}
-	defer f.Close()
 
-	var textContent strings.Builder
-	totalPages := r.NumPage()
+	variant := &PromptVariant{Version: canary.Version, Path: canary.PromptPath}
+	if variant.Path == "" {
+		variant.Path = ai.promptA.Path
+	}
 
-	// Extract text from all pages
-	for pageNum := 1; pageNum <= totalPages; pageNum++ {
-		page := r.Page(pageNum)
-		if page.V.IsNull() {
-			continue
+	if canary.Model != "" {
+		modelCfg := cfg
+		if strings.EqualFold(cfg.Provider, "ollama") {
+			modelCfg.OllamaModel = canary.Model
+		} else {
+			modelCfg.GeminiModel = canary.Model
 		}
-
-		content, err := page.GetPlainText(nil)
+		provider, err := NewLLMProvider(modelCfg, systemPrompt)
 		if err != nil {
-			// Log error but continue with other pages
-			fmt.Printf("Warning: Failed to extract text from page %d: %v\n", pageNum, err)
-			continue
+			return fmt.Errorf("failed to create canary model: %w", err)
+		}
+		variant.model = &variantModel{
+			provider: newRateLimitedProvider(provider, limiter, cfg.RateLimitRetries, cfg.RateLimitBackoff),
+			settings: newAICallSettings(modelCfg, systemPrompt),
 		}
-
-		textContent.WriteString(content)
-		textContent.WriteString("\n")
 	}
 
-	extractedText := textContent.String()
-	if strings.TrimSpace(extractedText) == "" {
-		return "", fmt.Errorf("no text content found in PDF")
+	ai.canary = variant
+	ai.canaryPercent = min(canary.Percent, 100)
+	return nil
+}
+
+// SetCanaryGate sets the check that decides whether the canary still serves, e.g. that it wasn't rolled back
+func (ai *AIService) SetCanaryGate(gate func() bool) {
+	ai.canaryGate = gate
+}
+
+// ProviderName identifies the model backend, e.g. for startup logs
+func (ai *AIService) ProviderName() string {
+	return ai.provider.Name()
+}
+
+// ModelName identifies the model analyses run on, as provider/model
+func (ai *AIService) ModelName() string {
+	return ai.callSettings.provider + "/" + ai.callSettings.model
+}
+
+// LimiterStats reports rate limiter waits, coalesced calls, and 429 retries for /metrics
+func (ai *AIService) LimiterStats() LLMLimiterStats {
+	return ai.provider.Stats()
+}
+
+// selectAnalysisVariant picks the prompt for one report analysis, the canary first when ctx allows it
+func (ai *AIService) selectAnalysisVariant(ctx context.Context) PromptVariant {
+	eligible, _ := ctx.Value(promptCanaryKey{}).(bool)
+	if eligible && ai.canary != nil && rand.IntN(100) < ai.canaryPercent && (ai.canaryGate == nil || ai.canaryGate()) {
+		return *ai.canary
 	}
+	return ai.selectPromptVariant()
+}
 
-	return extractedText, nil
+// selectPromptVariant picks the prompt for one analysis according to the A/B split
+func (ai *AIService) selectPromptVariant() PromptVariant {
+	if ai.promptB != nil && rand.IntN(100) < ai.promptBPercent {
+		return *ai.promptB
+	}
+	return ai.promptA
 }
 
-// extractFromDOCX extracts text from DOCX files (placeholder - requires DOCX library)
-func (ai *AIService) extractFromDOCX(filePath string) (string, error) {
-	// TODO: Implement DOCX text extraction using a library like gingfrederik/docx
-	// For now, return placeholder text
-	return "DOCX text extraction not yet implemented. Please use TXT format for testing.", nil
+// AnalyzeReport processes a medical report file and returns comprehensive analysis
+func (ai *AIService) AnalyzeReport(ctx context.Context, filePath, fileType, readingLevel, plan, patient string) (*ReportAnalysis, error) {
+	return ai.AnalyzeParts(ctx, []string{filePath}, fileType, readingLevel, plan, patient)
 }
 
-// generateAnalysis uses Gemini to analyze medical report content
-func (ai *AIService) generateAnalysis(content string) (*AnalysisResult, error) {
-	ctx := context.Background()
+// AnalyzeParts analyzes the files of a multi-part report, in order, as one report
+// Decision: The parts' text is joined before the single analysis call, so results that span
+// pages (a table continued on page two) are read together instead of as two fragments
+func (ai *AIService) AnalyzeParts(ctx context.Context, filePaths []string, fileType, readingLevel, plan, patient string) (*ReportAnalysis, error) {
+	slog.DebugContext(ctx, "Analyzing report", "parts", len(filePaths), "file_type", fileType)
 
-	// Create comprehensive prompt for medical analysis
-	prompt := ai.buildAnalysisPrompt(content)
-	fmt.Println("--- AI Service: Prompt ---")
-	fmt.Println(prompt)
+	parts := make([]string, len(filePaths))
+	language := ""
+	for i, filePath := range filePaths {
+		text, partLanguage, err := ai.extractPart(ctx, filePath)
+		if err != nil {
+			return nil, err
+		}
+		language = reportLanguageOf(language, partLanguage)
+		parts[i] = text
+		if len(filePaths) > 1 {
+			parts[i] = fmt.Sprintf("--- Part %d of %d ---\n%s", i+1, len(filePaths), text)
+		}
+	}
+	content := strings.Join(parts, "\n\n")
+	slog.DebugContext(ctx, "Extracted report content", "characters", len(content))
+
+	// Generate comprehensive analysis with the canary or A/B-selected prompt
+	variant := ai.selectAnalysisVariant(ctx)
+	analysis, err := ai.generateAnalysis(ctx, AICallAnalysis, content, variant, readingLevel, PlanLimits(ai.plans, plan), patient)
+	// Decision: Unparseable output is quarantined for review rather than stored as a made-up analysis
+	var parseErr *AnalysisParseError
+	if errors.As(err, &parseErr) {
+		return &ReportAnalysis{
+			PromptVersion: variant.Version,
+			ParseFailed:   true,
+			RawOutput:     parseErr.Raw,
+			ParseError:    parseErr.Err.Error(),
+			Language:      language,
+		}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate AI analysis: %w", err)
+	}
 
-	// Generate response from Gemini
-	resp, err := ai.model.GenerateContent(ctx, genai.Text(prompt))
+	// Decision: Checked against the text the model was given, so a translated report is compared in English
+	if check := CrossCheckMetrics(analysis.HealthMetrics, content); check.Disputed > 0 {
+		slog.WarnContext(ctx, "The model read metrics differently from the report text", "disputed", check.Disputed,
+			"metrics", len(analysis.HealthMetrics), "corrected", check.Corrected)
+	}
+
+	// Convert to JSON for storage
+	analysis.SchemaVersion = CurrentAnalysisSchemaVersion
+	analysisJSON, err := json.Marshal(analysis)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate content: %w", err)
+		return nil, fmt.Errorf("failed to serialize analysis: %w", err)
 	}
 
-	if len(resp.Candidates) == 0 {
-		return nil, fmt.Errorf("no response generated")
+	return &ReportAnalysis{
+		ResultJSON:    string(analysisJSON),
+		PromptVersion: variant.Version,
+		Language:      language,
+	}, nil
+}
+
+// extractPart reads one report file, translating reports printed in a regional script, and returns its text
+// with the language it was printed in
+func (ai *AIService) extractPart(ctx context.Context, filePath string) (string, string, error) {
+	// Unreadable scans stop here as *UnreadableReportError
+	extraction, err := ai.extractor.Extract(context.WithoutCancel(ctx), filePath)
+	if err != nil {
+		return "", "", &ExtractionError{Err: err}
 	}
+	content := extraction.Text
 
-	// Extract text from response
-	var responseText string
-	for _, part := range resp.Candidates[0].Content.Parts {
-		if txt, ok := part.(genai.Text); ok {
-			responseText += string(txt)
+	// Reports printed in a regional script are translated first so labels and values reach the analysis in English
+	if extraction.Language != "" && extraction.Language != "en" {
+		content = NormalizeDigits(content)
+		translated, err := ai.translateReport(ctx, content, extraction.Language)
+		if err != nil {
+			// Decision: Fall back to the original text; the model reads these languages, just less reliably than English
+			slog.WarnContext(ctx, "Failed to translate report, analyzing the original text", "language", extraction.Language, "error", err)
+		} else {
+			content = translated
 		}
 	}
-	fmt.Println("--- AI Service: Response ---")
-	fmt.Println(responseText)
+	return content, extraction.Language, nil
+}
+
+// generateAnalysis asks the model to analyze medical report content
+// A response that can't be parsed is returned as an *AnalysisParseError carrying the raw text
+func (ai *AIService) generateAnalysis(ctx context.Context, purpose, content string, variant PromptVariant, readingLevel string, limits config.PlanConfig, patient string) (*AnalysisResult, error) {
+	ctx = WithMaxOutputTokens(ctx, limits.MaxOutputTokens)
+
+	// Create comprehensive prompt for medical analysis
+	prompt := ai.buildAnalysisPrompt(content, variant, readingLevel, limits, patient)
+	// Decision: Only sizes are logged; prompts and responses hold the patient's report and are kept, encrypted,
+	// in the AI call log when that is enabled
+	slog.DebugContext(ctx, "Sending analysis prompt", "purpose", purpose, "prompt_characters", len(prompt))
+
+	provider, settings := ai.provider, ai.callSettings
+	if variant.model != nil {
+		provider, settings = variant.model.provider, variant.model.settings
+	}
+	responseText, err := ai.generateOn(ctx, provider, settings, purpose, prompt)
+	if err != nil {
+		return nil, err
+	}
+	slog.DebugContext(ctx, "Received analysis response", "purpose", purpose, "response_characters", len(responseText))
 
 	// Parse the structured response
-	analysis, err := ai.parseAnalysisResponse(responseText)
+	analysis, err := ParseAnalysisResponse(responseText)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse analysis response: %w", err)
+		return nil, &AnalysisParseError{Raw: responseText, Err: err}
 	}
+	applyPlanLimits(analysis, limits)
 
 	return analysis, nil
 }
 
 // loadPromptTemplate loads the medical analysis prompt template from file
-func (ai *AIService) loadPromptTemplate() (string, error) {
-	promptPath := "prompts/medical_analysis_prompt.txt"
+func (ai *AIService) loadPromptTemplate(promptPath string) (string, error) {
 	promptBytes, err := os.ReadFile(promptPath)
 	if err != nil {
 		// Fallback to embedded prompt if file doesn't exist
@@ -275,13 +399,14 @@ Please provide your analysis in the following JSON structure:
   ],
   "key_findings": ["List of important findings"],
   "recommendations": ["List of actionable recommendations"],
-  "risk_level": "low/medium/high"
+  "risk_level": "low/medium/high",
+  "glossary_terms": ["Medical terms used in simple_summary that a patient may not know, spelled exactly as in simple_summary"]
 }
 
 Guidelines:
 1. Extract all measurable parameters (blood tests, vitals, etc.)
 2. Provide scores based on how close values are to optimal ranges
-3. Use simple language in simple_summary and descriptions
+3. {{READING_LEVEL}}
 4. Be accurate but not alarming in tone
 5. Include lifestyle recommendations when appropriate
 6. If no specific values are found, focus on general health insights
@@ -291,20 +416,74 @@ Respond only with valid JSON.`
 }
 
 // buildAnalysisPrompt creates a comprehensive prompt for medical analysis
-func (ai *AIService) buildAnalysisPrompt(content string) string {
-	promptTemplate, err := ai.loadPromptTemplate()
+func (ai *AIService) buildAnalysisPrompt(content string, variant PromptVariant, readingLevel string, limits config.PlanConfig, patient string) string {
+	promptTemplate, err := ai.loadPromptTemplate(variant.Path)
 	if err != nil {
 		// Use default template if loading fails
 		promptTemplate = ai.getDefaultPromptTemplate()
 	}
 
+	// Decision: Templates without the placeholder still get the guidance, appended after the rules
+	guidance := summaryGuidance[readingLevelOrDefault(readingLevel)]
+	if strings.Contains(promptTemplate, "{{READING_LEVEL}}") {
+		promptTemplate = strings.ReplaceAll(promptTemplate, "{{READING_LEVEL}}", guidance)
+	} else {
+		promptTemplate += "\n\nReading level: " + guidance
+	}
+
+	// Decision: Plan limits follow the rules too, so every template version gets them without a placeholder
+	if length := planGuidance(limits); length != "" {
+		promptTemplate += "\n\nLength: " + length
+	}
+
+	// Decision: The profile follows the rules so it applies to every template version without a placeholder
+	if patient != "" {
+		promptTemplate += "\n\nPatient profile, as entered by the patient: " + patient + "\n" +
+			"Use reference ranges appropriate to this age and sex, relate findings to the known conditions, " +
+			"and never recommend anything the patient is allergic to."
+	}
+
 	// Replace placeholder with actual content
 	prompt := strings.ReplaceAll(promptTemplate, "{{REPORT_CONTENT}}", content)
 	return prompt
 }
 
-// parseAnalysisResponse parses the AI response into structured data
-func (ai *AIService) parseAnalysisResponse(response string) (*AnalysisResult, error) {
+// ParseAnalysisResponse parses the AI response into structured data
+// Decision: A package function so operators can re-parse quarantined output without an AI provider,
+// exported so the fuzz targets in tests/ can feed it malformed output
+func ParseAnalysisResponse(response string) (*AnalysisResult, error) {
+	var blob any
+	if err := json.Unmarshal([]byte(extractJSONObject(response)), &blob); err != nil {
+		return nil, err
+	}
+
+	// Decision: Check the output against AnalysisResultSchema before it can be stored. Slips with one
+	// sensible reading are repaired; anything else fails the parse, so the report goes to review
+	if object, ok := blob.(map[string]any); ok {
+		if repairs := repairAnalysis(object); len(repairs) > 0 {
+			slog.Warn("Repaired analysis output", "repairs", strings.Join(repairs, "; "))
+		}
+	}
+	if problems := analysisResultSchema.validate(blob); len(problems) > 0 {
+		return nil, fmt.Errorf("analysis doesn't match the schema: %s", strings.Join(problems, "; "))
+	}
+	repaired, err := json.Marshal(blob)
+	if err != nil {
+		return nil, err
+	}
+	var analysis AnalysisResult
+	if err := json.Unmarshal(repaired, &analysis); err != nil {
+		return nil, err
+	}
+
+	// Validate and enhance the analysis
+	validateAndEnhanceAnalysis(&analysis)
+
+	return &analysis, nil
+}
+
+// extractJSONObject strips markdown fences and any text the model wrapped around a JSON object
+func extractJSONObject(response string) string {
 	// Clean response (remove markdown formatting if present)
 	response = strings.TrimPrefix(response, "```json")
 	response = strings.TrimSuffix(response, "```")
@@ -317,42 +496,33 @@ func (ai *AIService) parseAnalysisResponse(response string) (*AnalysisResult, er
 	if jsonStart >= 0 && jsonEnd > jsonStart {
 		response = response[jsonStart:jsonEnd+1]
 	}
-
-	var analysis AnalysisResult
-	err := json.Unmarshal([]byte(response), &analysis)
-	if err != nil {
-		// Log the actual response for debugging
-		fmt.Printf("Failed to parse JSON response: %s\nError: %v\n", response, err)
-
-		// If JSON parsing fails, create a fallback analysis with the raw response
-		return &AnalysisResult{
-			Summary:       "AI analysis completed. Raw response formatting required improvement.",
-			SimpleSummary: fmt.Sprintf("Analysis: %s", ai.extractSimpleSummary(response)),
-			HealthMetrics: ai.extractHealthMetrics(response),
-			KeyFindings:   []string{"Report analysis completed", "Response parsing needed enhancement"},
-			Recommendations: []string{"Consult with your healthcare provider for personalized advice"},
-			RiskLevel:     "medium",
-		}, nil
-	}
-
-	// Validate and enhance the analysis
-	ai.validateAndEnhanceAnalysis(&analysis)
-
-	return &analysis, nil
+	return response
 }
 
 // validateAndEnhanceAnalysis ensures the analysis meets quality standards
-func (ai *AIService) validateAndEnhanceAnalysis(analysis *AnalysisResult) {
+func validateAndEnhanceAnalysis(analysis *AnalysisResult) {
 	// Ensure all required fields have content
 	if analysis.Summary == "" {
-		analysis.Summary = "Medical analysis completed."
+		analysis.Summary = defaultAnalysisSummary
 	}
 	if analysis.SimpleSummary == "" {
-		analysis.SimpleSummary = "Your report has been analyzed. Please discuss with your healthcare provider."
+		analysis.SimpleSummary = defaultSimpleSummary
 	}
 	if analysis.RiskLevel == "" {
-		analysis.RiskLevel = "medium"
+		analysis.RiskLevel = defaultRiskLevel
+	}
+
+	// Decision: Keep only terms that really occur in simple_summary, so every reference can be linked
+	candidates := make([]string, len(analysis.GlossaryTerms))
+	for i, ref := range analysis.GlossaryTerms {
+		candidates[i] = ref.Term
 	}
+	analysis.GlossaryTerms = AnnotateGlossaryTerms(analysis.SimpleSummary, candidates)
+
+	// Decision: Condition tags are computed here rather than asked of the model, so every prompt version tags alike
+	TagConditions(analysis)
+	analysis.Completeness = ScoreCompleteness(analysis.HealthMetrics)
+	InferMissingUnits(analysis.HealthMetrics)
 
 	// Validate health metrics scores
 	for i := range analysis.HealthMetrics {
@@ -367,13 +537,7 @@ func (ai *AIService) validateAndEnhanceAnalysis(analysis *AnalysisResult) {
 
 		// Validate status matches score
 		if metric.Status == "" {
-			if metric.Score >= 80 {
-				metric.Status = "normal"
-			} else if metric.Score >= 50 {
-				metric.Status = "warning"
-			} else {
-				metric.Status = "critical"
-			}
+			metric.Status = statusForScore(metric.Score)
 		}
 	}
 
@@ -389,42 +553,29 @@ func (ai *AIService) validateAndEnhanceAnalysis(analysis *AnalysisResult) {
 
 // GetHealthMetrics extracts health metrics from analysis for speedometer display
 func (ai *AIService) GetHealthMetrics(analysisJSON string) ([]HealthMetric, error) {
-	var analysis AnalysisResult
-	err := json.Unmarshal([]byte(analysisJSON), &analysis)
+	// Decision: Upgrade older stored blobs on read so historic reports keep working
+	analysis, err := ParseStoredAnalysis(analysisJSON)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse analysis: %w", err)
+		return nil, err
 	}
 
 	return analysis.HealthMetrics, nil
 }
 
+// Ping checks the model backend is reachable with the configured credentials
+func (ai *AIService) Ping(ctx context.Context) error {
+	return ai.provider.Ping(ctx)
+}
+
 // Close cleanly shuts down the AI service
 func (ai *AIService) Close() error {
-	if ai.client != nil {
-		return ai.client.Close()
+	if ai.canary != nil && ai.canary.model != nil {
+		ai.canary.model.provider.Close()
 	}
-	return nil
-}
-
-// extractSimpleSummary extracts a simple summary from raw AI response
-func (ai *AIService) extractSimpleSummary(response string) string {
-	// Try to extract meaningful content from the response
-	lines := strings.Split(response, "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if len(line) > 20 && !strings.HasPrefix(line, "{") && !strings.HasPrefix(line, "}") {
-			// Return first meaningful line as summary
-			return line
-		}
+	if ai.provider != nil {
+		return ai.provider.Close()
 	}
-	return "Your medical report has been analyzed. Please consult your healthcare provider."
-}
-
-// extractHealthMetrics attempts to extract health metrics from raw response
-func (ai *AIService) extractHealthMetrics(response string) []HealthMetric {
-	// For now, return empty metrics if JSON parsing fails
-	// In the future, we could implement regex parsing to extract numeric values
-	return []HealthMetric{}
+	return nil
 }
 
 // Helper function to determine file content type from extension
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// translateReport renders extracted report text in English before analysis
+// Decision: A separate call rather than an instruction in the analysis prompt, so every prompt variant
+// sees English labels and the A/B comparison isn't skewed by regional reports
+func (ai *AIService) translateReport(ctx context.Context, content, languageCode string) (string, error) {
+	language := LookupReportLanguage(languageCode)
+	if language == nil {
+		return "", fmt.Errorf("unsupported report language %q", languageCode)
+	}
+
+	translated, err := ai.generateText(ctx, AICallTranslation, buildReportTranslationPrompt(content, language.Name))
+	if err != nil {
+		return "", err
+	}
+	translated = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(translated, "```"), "```"))
+	if translated == "" {
+		return "", fmt.Errorf("translation from %s was empty", language.Name)
+	}
+	return translated, nil
+}
+
+// buildReportTranslationPrompt asks for a line-by-line English rendering that leaves every value untouched
+func buildReportTranslationPrompt(content, languageName string) string {
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "The following text was read from a medical lab report printed in %s, possibly mixed with English.\n", languageName)
+	prompt.WriteString(`Translate it into English so it can be analyzed.
+- Keep every number, unit, reference range, and date exactly as written
+- Use the standard English name for each test and label, e.g. "Hemoglobin", "Fasting Blood Glucose", "TSH"
+- Keep one result per line, in the original order
+- Leave text that is already English unchanged
+Respond with the translated report text only, without notes or commentary.
+
+REPORT TEXT:
+`)
+	prompt.WriteString(content)
+	return prompt.String()
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+// ImportedPromptVersion labels analyses produced outside this service, so prompt statistics keep them apart
+const ImportedPromptVersion = "external"
+
+// Limits on an imported analysis
+const (
+	maxImportSource  = 100
+	maxImportMetrics = 200
+)
+
+// AnalysisImportService attaches analyses produced elsewhere, such as a hospital's NLP pipeline, to reports
+// Decision: Imported analyses go through the same enhancement as the model's, so glossary links,
+// condition tags, and panel completeness are computed here and not trusted from the pipeline
+type AnalysisImportService struct {
+	reportRepo models.ReportRepository
+	auditRepo  models.AuditLogRepository
+	events     EventBus // Optional; nil publishes nothing
+}
+
+// NewAnalysisImportService creates a new analysis import service
+func NewAnalysisImportService(reportRepo models.ReportRepository, auditRepo models.AuditLogRepository) *AnalysisImportService {
+	return &AnalysisImportService{
+		reportRepo: reportRepo,
+		auditRepo:  auditRepo,
+	}
+}
+
+// SetEventBus publishes analysis.completed for every import, so owners are notified as for the model's analyses
+func (is *AnalysisImportService) SetEventBus(bus EventBus) {
+	is.events = bus
+}
+
+// Import validates raw as an AnalysisResult and makes it the report's analysis, completing the report
+// source names the system that produced it and is kept in the audit log
+func (is *AnalysisImportService) Import(actor *models.User, reportID int, raw json.RawMessage, source string) (*AnalysisResult, error) {
+	source = strings.TrimSpace(source)
+	if source == "" {
+		return nil, errors.NewValidationError("source is required")
+	}
+	if len([]rune(source)) > maxImportSource {
+		return nil, errors.NewValidationError(fmt.Sprintf("source must be at most %d characters", maxImportSource))
+	}
+
+	analysis, err := ParseExternalAnalysis(raw)
+	if err != nil {
+		return nil, err
+	}
+	analysisJSON, err := json.Marshal(analysis)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+
+	report, err := is.reportRepo.GetByID(reportID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if report == nil {
+		return nil, errors.ErrRecordNotFound
+	}
+
+	// Decision: A report waiting for the model is claimed like a worker would, so no worker analyzes it
+	// afterwards; one being analyzed is left alone, and quarantined output is resolved through the review queue
+	switch report.ProcessingStatus {
+	case "completed":
+	case "pending", "failed":
+		claimed, err := is.reportRepo.ClaimForProcessing(report.ID, report.ProcessingStatus)
+		if err == models.ErrLegalHold {
+			return nil, errors.ErrLegalHold
+		}
+		if err != nil {
+			return nil, errors.ErrDatabaseConnection
+		}
+		if !claimed {
+			return nil, errors.ErrReportBusy
+		}
+	case "processing":
+		return nil, errors.ErrReportBusy
+	default:
+		return nil, errors.NewValidationError(fmt.Sprintf("A %s report can't take an imported analysis", report.ProcessingStatus))
+	}
+
+	// The analysis goes first, so a legal hold refusing it leaves the report's metadata untouched too
+	if err := is.reportRepo.UpdateProcessingStatus(report.ID, "completed", string(analysisJSON)); err == models.ErrLegalHold {
+		return nil, errors.ErrLegalHold
+	} else if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if err := is.reportRepo.SetAnalysisMetadata(report.ID, ImportedPromptVersion, false); err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+
+	entry := &models.AuditLog{ActorID: actor.ID, UserID: report.UserID, Action: models.AuditAnalysisImported,
+		Details: fmt.Sprintf("report %d from %q", report.ID, source)}
+	if err := is.auditRepo.Create(entry); err != nil {
+		slog.Error("Failed to audit analysis import", "report_id", report.ID, "actor_id", actor.ID, "error", err)
+	}
+	publishEvent(is.events, Event{Type: EventAnalysisCompleted, UserID: report.UserID, ReportID: report.ID})
+	return analysis, nil
+}
+
+// ParseExternalAnalysis decodes an analysis produced outside this service, rejecting anything
+// AnalysisResultSchema doesn't allow and listing every problem found
+// Decision: Stricter than parsing the model's output: nothing is repaired, so unknown fields, numbers
+// as strings, and missing statuses are errors, since a pipeline can be fixed and a guessed status could mislead a patient
+func ParseExternalAnalysis(raw json.RawMessage) (*AnalysisResult, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	var blob any
+	if err := decoder.Decode(&blob); err != nil {
+		return nil, errors.NewValidationError(fmt.Sprintf("Invalid analysis: %v", err))
+	}
+	if decoder.More() {
+		return nil, errors.NewValidationError("Invalid analysis: expected a single JSON object")
+	}
+
+	problems := analysisResultSchema.validate(blob)
+	var analysis AnalysisResult
+	// Values of the wrong type are already listed; the checks the schema can't express need the rest to decode
+	if err := json.Unmarshal(raw, &analysis); err == nil {
+		// Decision: Older schema versions are only upgraded for blobs this service stored itself
+		if analysis.SchemaVersion != 0 && analysis.SchemaVersion != CurrentAnalysisSchemaVersion {
+			problems = append(problems, fmt.Sprintf("schema_version must be %d", CurrentAnalysisSchemaVersion))
+		}
+		if len(analysis.HealthMetrics) > maxImportMetrics {
+			problems = append(problems, fmt.Sprintf("health_metrics may have at most %d entries", maxImportMetrics))
+		}
+		for i, metric := range analysis.HealthMetrics {
+			if metric.RangeMin > metric.RangeMax {
+				problems = append(problems, fmt.Sprintf("health_metrics[%d].range_min must not exceed range_max", i))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return nil, errors.NewValidationError("Invalid analysis: " + strings.Join(problems, "; "))
+	}
+
+	validateAndEnhanceAnalysis(&analysis)
+	analysis.SchemaVersion = CurrentAnalysisSchemaVersion
+	return &analysis, nil
+}
@@ -0,0 +1,382 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Defaults for the fields a model sometimes leaves out
+const (
+	defaultAnalysisSummary = "Medical analysis completed."
+	defaultSimpleSummary   = "Your report has been analyzed. Please discuss with your healthcare provider."
+	defaultRiskLevel       = "medium"
+)
+
+// AnalysisResultSchema is the JSON Schema every analysis is checked against before it is stored,
+// whether the model wrote it or it was imported
+// Decision: Only the subset of JSON Schema the validator below understands is used: type, required,
+// properties, additionalProperties, items, enum, minimum, maximum, and minLength
+const AnalysisResultSchema = `{
+	"$schema": "http://json-schema.org/draft-07/schema#",
+	"title": "AnalysisResult",
+	"type": "object",
+	"required": ["summary", "simple_summary", "risk_level"],
+	"additionalProperties": false,
+	"properties": {
+		"schema_version": {"type": "integer", "minimum": 0},
+		"summary": {"type": "string", "minLength": 1},
+		"simple_summary": {"type": "string", "minLength": 1},
+		"risk_level": {"type": "string", "enum": ["low", "medium", "high"]},
+		"health_metrics": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"required": ["name", "value", "status"],
+				"additionalProperties": false,
+				"properties": {
+					"name": {"type": "string", "minLength": 1},
+					"value": {"type": ["number", "string"], "minLength": 1},
+					"unit": {"type": "string"},
+					"score": {"type": "number", "minimum": 0, "maximum": 100},
+					"status": {"type": "string", "enum": ["normal", "warning", "critical"]},
+					"range_min": {"type": "number"},
+					"range_max": {"type": "number"},
+					"description": {"type": "string"},
+					"conditions": {"type": "array", "items": {"type": "string"}},
+					"unit_inferred": {"type": "boolean"},
+					"low_confidence": {"type": "boolean"},
+					"unit_note": {"type": "string"},
+					"confidence": {"type": "number", "minimum": 0, "maximum": 1},
+					"value_note": {"type": "string"}
+				}
+			}
+		},
+		"key_findings": {"type": "array", "items": {"type": "string", "minLength": 1}},
+		"recommendations": {"type": "array", "items": {"type": "string", "minLength": 1}},
+		"glossary_terms": {"type": "array", "items": {"type": ["string", "object"]}},
+		"condition_findings": {"type": "object", "additionalProperties": {"type": "array", "items": {"type": "string"}}},
+		"completeness": {"type": ["object", "null"]},
+		"analysis_mode": {"type": "string", "enum": ["basic"]},
+		"extracted_text": {"type": "string"}
+	}
+}`
+
+// analysisResultSchema is AnalysisResultSchema compiled once at startup
+var analysisResultSchema = mustCompileSchema(AnalysisResultSchema)
+
+// jsonSchema is one node of a compiled JSON Schema
+type jsonSchema struct {
+	Type                 schemaTypes            `json:"type"`
+	Required             []string               `json:"required"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	AdditionalProperties *jsonSchema            `json:"additionalProperties"`
+	Items                *jsonSchema            `json:"items"`
+	Enum                 []any                  `json:"enum"`
+	Minimum              *float64               `json:"minimum"`
+	Maximum              *float64               `json:"maximum"`
+	MinLength            *int                   `json:"minLength"`
+
+	reject bool // Set by the boolean schema false, which no value matches
+}
+
+// UnmarshalJSON also accepts the boolean schemas true and false
+func (s *jsonSchema) UnmarshalJSON(data []byte) error {
+	var allowed bool
+	if err := json.Unmarshal(data, &allowed); err == nil {
+		s.reject = !allowed
+		return nil
+	}
+	type plain jsonSchema
+	return json.Unmarshal(data, (*plain)(s))
+}
+
+// schemaTypes is the type keyword, a single name or a list of them
+type schemaTypes []string
+
+func (t *schemaTypes) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = schemaTypes{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*t = list
+	return nil
+}
+
+// matches reports whether a value decoded by encoding/json has one of the types
+func (t schemaTypes) matches(value any) bool {
+	for _, name := range t {
+		switch v := value.(type) {
+		case nil:
+			if name == "null" {
+				return true
+			}
+		case bool:
+			if name == "boolean" {
+				return true
+			}
+		case float64:
+			if name == "number" || (name == "integer" && v == math.Trunc(v)) {
+				return true
+			}
+		case string:
+			if name == "string" {
+				return true
+			}
+		case []any:
+			if name == "array" {
+				return true
+			}
+		case map[string]any:
+			if name == "object" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// describe names the types the way a problem message reads, e.g. "a number or a string"
+func (t schemaTypes) describe() string {
+	names := make([]string, len(t))
+	for i, name := range t {
+		switch name {
+		case "null":
+			names[i] = "null"
+		case "array", "object", "integer":
+			names[i] = "an " + name
+		default:
+			names[i] = "a " + name
+		}
+	}
+	return strings.Join(names, " or ")
+}
+
+// mustCompileSchema parses a schema document, panicking on a malformed one since schemas are constants
+func mustCompileSchema(document string) *jsonSchema {
+	var schema jsonSchema
+	if err := json.Unmarshal([]byte(document), &schema); err != nil {
+		panic(fmt.Sprintf("invalid JSON Schema: %v", err))
+	}
+	return &schema
+}
+
+// validate checks a value decoded by encoding/json and returns every problem found, each naming its field
+// like health_metrics[0].score; nil means the value is valid
+func (s *jsonSchema) validate(value any) []string {
+	var problems []string
+	s.check("", value, &problems)
+	return problems
+}
+
+func (s *jsonSchema) check(path string, value any, problems *[]string) {
+	name := path
+	if name == "" {
+		name = "analysis"
+	}
+	if s.reject {
+		*problems = append(*problems, name+" is not allowed")
+		return
+	}
+	if len(s.Type) > 0 && !s.Type.matches(value) {
+		*problems = append(*problems, fmt.Sprintf("%s must be %s", name, s.Type.describe()))
+		return
+	}
+	if len(s.Enum) > 0 && !enumContains(s.Enum, value) {
+		options := make([]string, len(s.Enum))
+		for i, option := range s.Enum {
+			options[i] = fmt.Sprint(option)
+		}
+		*problems = append(*problems, fmt.Sprintf("%s must be one of %s", name, strings.Join(options, ", ")))
+		return
+	}
+
+	switch v := value.(type) {
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			*problems = append(*problems, fmt.Sprintf("%s must be at least %g", name, *s.Minimum))
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			*problems = append(*problems, fmt.Sprintf("%s must be at most %g", name, *s.Maximum))
+		}
+	case string:
+		// Decision: Whitespace doesn't count towards minLength, so "  " is as empty as ""
+		if s.MinLength != nil && len([]rune(strings.TrimSpace(v))) < *s.MinLength {
+			if *s.MinLength == 1 {
+				*problems = append(*problems, name+" must not be empty")
+			} else {
+				*problems = append(*problems, fmt.Sprintf("%s must be at least %d characters", name, *s.MinLength))
+			}
+		}
+	case []any:
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.check(fmt.Sprintf("%s[%d]", path, i), item, problems)
+			}
+		}
+	case map[string]any:
+		for _, field := range s.Required {
+			if _, ok := v[field]; !ok {
+				*problems = append(*problems, schemaPath(path, field)+" is required")
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if property, ok := s.Properties[key]; ok {
+				property.check(schemaPath(path, key), v[key], problems)
+			} else if s.AdditionalProperties != nil {
+				s.AdditionalProperties.check(schemaPath(path, key), v[key], problems)
+			}
+		}
+	}
+}
+
+// enumContains compares decoded JSON scalars; enums here only hold strings and numbers
+func enumContains(enum []any, value any) bool {
+	for _, option := range enum {
+		if option == value {
+			return true
+		}
+	}
+	return false
+}
+
+func schemaPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
+
+// repairAnalysis fixes the schema violations models commonly make that have only one sensible reading,
+// and returns a note for each repair so they can be logged
+// Decision: Repairs never invent medical content: numbers written as strings are converted, unknown fields
+// dropped, and missing text given the same defaults as before, but a metric without a name or value,
+// a score like "high", or a field of the wrong shape is left for validation to reject
+func repairAnalysis(blob map[string]any) []string {
+	var repairs []string
+	note := func(format string, args ...any) {
+		repairs = append(repairs, fmt.Sprintf(format, args...))
+	}
+
+	dropUnknownFields("", blob, analysisResultSchema, note)
+
+	for _, text := range []struct{ field, fallback string }{
+		{"summary", defaultAnalysisSummary},
+		{"simple_summary", defaultSimpleSummary},
+		{"risk_level", defaultRiskLevel},
+	} {
+		field := text.field
+		value, isString := blob[field].(string)
+		if _, present := blob[field]; present && !isString {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if field == "risk_level" {
+			value = strings.ToLower(value)
+		}
+		if value == "" {
+			note("defaulted missing %s", field)
+			value = text.fallback
+		}
+		blob[field] = value
+	}
+
+	for _, field := range []string{"key_findings", "recommendations"} {
+		switch v := blob[field].(type) {
+		case string:
+			note("wrapped %s in a list", field)
+			blob[field] = []any{v}
+		case []any:
+			kept := v[:0]
+			for _, item := range v {
+				if text, ok := item.(string); ok && strings.TrimSpace(text) == "" {
+					note("dropped an empty entry from %s", field)
+					continue
+				}
+				kept = append(kept, item)
+			}
+			blob[field] = kept
+		}
+	}
+
+	metrics, _ := blob["health_metrics"].([]any)
+	metricSchema := analysisResultSchema.Properties["health_metrics"].Items
+	for i, m := range metrics {
+		metric, ok := m.(map[string]any)
+		if !ok {
+			continue
+		}
+		path := fmt.Sprintf("health_metrics[%d]", i)
+		dropUnknownFields(path, metric, metricSchema, note)
+
+		for _, field := range []string{"score", "range_min", "range_max"} {
+			if text, ok := metric[field].(string); ok {
+				if number, ok := parseNumericString(text); ok {
+					note("converted %s.%s %q to a number", path, field, text)
+					metric[field] = number
+				}
+			}
+		}
+		if score, ok := metric["score"].(float64); ok && (score < 0 || score > 100) {
+			note("clamped %s.score %g to 0-100", path, score)
+			metric["score"] = math.Max(0, math.Min(100, score))
+		}
+
+		if status, ok := metric["status"].(string); ok {
+			metric["status"] = strings.ToLower(strings.TrimSpace(status))
+		}
+		if status, present := metric["status"]; !present || status == "" {
+			score, _ := metric["score"].(float64)
+			note("inferred %s.status from its score", path)
+			metric["status"] = statusForScore(score)
+		}
+	}
+	return repairs
+}
+
+// dropUnknownFields removes the fields schema doesn't list, and null ones, which encoding/json would
+// otherwise read as zero values
+func dropUnknownFields(path string, object map[string]any, schema *jsonSchema, note func(string, ...any)) {
+	for key, value := range object {
+		if _, known := schema.Properties[key]; !known {
+			note("dropped unknown field %s", schemaPath(path, key))
+			delete(object, key)
+		} else if value == nil && !schema.Properties[key].Type.matches(nil) {
+			note("dropped null %s", schemaPath(path, key))
+			delete(object, key)
+		}
+	}
+}
+
+// parseNumericString reads numbers models write as text, like "85" or "85%"
+func parseNumericString(text string) (float64, bool) {
+	cleaned := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(text), "%"))
+	number, err := strconv.ParseFloat(cleaned, 64)
+	if err != nil || math.IsInf(number, 0) || math.IsNaN(number) {
+		return 0, false
+	}
+	return number, true
+}
+
+// statusForScore is the status a metric's 0-100 score implies
+func statusForScore(score float64) string {
+	if score >= 80 {
+		return "normal"
+	} else if score >= 50 {
+		return "warning"
+	}
+	return "critical"
+}
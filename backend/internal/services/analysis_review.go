@@ -0,0 +1,111 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+// ReviewService lets operators inspect quarantined model output and re-parse it into an analysis
+type ReviewService struct {
+	reportRepo models.ReportRepository
+	reviewRepo models.AnalysisReviewRepository
+	auditRepo  models.AuditLogRepository
+}
+
+// NewReviewService creates a new analysis review service
+func NewReviewService(
+	reportRepo models.ReportRepository,
+	reviewRepo models.AnalysisReviewRepository,
+	auditRepo models.AuditLogRepository,
+) *ReviewService {
+	return &ReviewService{
+		reportRepo: reportRepo,
+		reviewRepo: reviewRepo,
+		auditRepo:  auditRepo,
+	}
+}
+
+// ListOpen returns reports waiting on review, without their raw output
+func (rs *ReviewService) ListOpen(limit, offset int) ([]*models.AnalysisReview, error) {
+	reviews, err := rs.reviewRepo.ListOpen(limit, offset)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	return reviews, nil
+}
+
+// Get returns a report's quarantined output
+// Decision: Raw output describes the patient's report, so each view is audited like an impersonated request
+func (rs *ReviewService) Get(admin *models.User, reportID int) (*models.AnalysisReview, error) {
+	review, err := rs.reviewRepo.GetByReportID(reportID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if review == nil {
+		return nil, errors.ErrRecordNotFound
+	}
+
+	rs.audit(admin, review.UserID, models.AuditAnalysisReviewViewed, fmt.Sprintf("report %d", reportID))
+	return review, nil
+}
+
+// Reparse turns quarantined output into the report's analysis and completes it
+// correctedOutput replaces the stored raw output when an operator fixed it by hand; empty re-parses it as stored
+func (rs *ReviewService) Reparse(admin *models.User, reportID int, correctedOutput string) (*AnalysisResult, error) {
+	review, err := rs.reviewRepo.GetByReportID(reportID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if review == nil {
+		return nil, errors.ErrRecordNotFound
+	}
+	report, err := rs.reportRepo.GetByID(reportID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if report == nil || report.ProcessingStatus != "needs_review" {
+		return nil, errors.NewValidationError("Report is not waiting on review")
+	}
+
+	output := review.RawOutput
+	if correctedOutput != "" {
+		output = correctedOutput
+	}
+	analysis, err := ParseAnalysisResponse(output)
+	if err != nil {
+		return nil, errors.NewValidationError(fmt.Sprintf("Output still can't be parsed: %v", err))
+	}
+
+	analysis.SchemaVersion = CurrentAnalysisSchemaVersion
+	analysisJSON, err := json.Marshal(analysis)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+
+	// Decision: parse_failed stays set so prompt statistics still count the original failure
+	if err := rs.reportRepo.UpdateProcessingStatus(reportID, "completed", string(analysisJSON)); err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if err := rs.reviewRepo.Resolve(reportID, admin.ID); err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+
+	details := fmt.Sprintf("report %d", reportID)
+	if correctedOutput != "" {
+		details += " with corrected output"
+	}
+	rs.audit(admin, review.UserID, models.AuditAnalysisReparsed, details)
+	return analysis, nil
+}
+
+// audit records an operator action; failures are logged rather than undoing the action
+func (rs *ReviewService) audit(admin *models.User, userID int, action, details string) {
+	entry := &models.AuditLog{ActorID: admin.ID, UserID: userID, Action: action, Details: details}
+	if err := rs.auditRepo.Create(entry); err != nil {
+		slog.Error("Failed to audit admin action", "action", action, "admin_id", admin.ID, "error", err)
+	}
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CurrentAnalysisSchemaVersion is the AnalysisResult schema written by this build
+// Decision: Bump this and register an upgrade whenever AnalysisResult changes shape
+const CurrentAnalysisSchemaVersion = 5
+
+// analysisUpgrade migrates a decoded analysis blob from version N to N+1 in place
+type analysisUpgrade func(blob map[string]any) error
+
+// analysisUpgrades maps a schema version to the function upgrading it to the next version
+var analysisUpgrades = map[int]analysisUpgrade{
+	0: upgradeAnalysisV0ToV1,
+	1: upgradeAnalysisV1ToV2,
+	2: upgradeAnalysisV2ToV3,
+	3: upgradeAnalysisV3ToV4,
+	4: upgradeAnalysisV4ToV5,
+}
+
+// UpgradeAnalysisJSON migrates a stored analysis blob to the current schema version
+// Decision: Operate on a generic map so upgrades can read fields the current struct no longer has
+func UpgradeAnalysisJSON(raw string) (upgraded string, changed bool, err error) {
+	var blob map[string]any
+	if err := json.Unmarshal([]byte(raw), &blob); err != nil {
+		return "", false, fmt.Errorf("failed to decode stored analysis: %w", err)
+	}
+
+	version := 0
+	if v, ok := blob["schema_version"].(float64); ok {
+		version = int(v)
+	}
+
+	if version > CurrentAnalysisSchemaVersion {
+		return "", false, fmt.Errorf("analysis schema version %d is newer than supported version %d",
+			version, CurrentAnalysisSchemaVersion)
+	}
+
+	if version == CurrentAnalysisSchemaVersion {
+		return raw, false, nil
+	}
+
+	for ; version < CurrentAnalysisSchemaVersion; version++ {
+		upgrade, ok := analysisUpgrades[version]
+		if !ok {
+			return "", false, fmt.Errorf("no upgrade registered for analysis schema version %d", version)
+		}
+		if err := upgrade(blob); err != nil {
+			return "", false, fmt.Errorf("failed to upgrade analysis from version %d: %w", version, err)
+		}
+		blob["schema_version"] = version + 1
+	}
+
+	upgradedBytes, err := json.Marshal(blob)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to encode upgraded analysis: %w", err)
+	}
+
+	return string(upgradedBytes), true, nil
+}
+
+// ParseStoredAnalysis decodes a stored analysis blob, upgrading it first if needed
+func ParseStoredAnalysis(raw string) (*AnalysisResult, error) {
+	upgraded, _, err := UpgradeAnalysisJSON(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var analysis AnalysisResult
+	if err := json.Unmarshal([]byte(upgraded), &analysis); err != nil {
+		return nil, fmt.Errorf("failed to parse analysis: %w", err)
+	}
+
+	return &analysis, nil
+}
+
+// upgradeAnalysisV0ToV1 normalizes pre-versioning blobs
+// Version 0 blobs came straight from the model and may hold numbers as strings
+// ("score": "85") or single strings where lists are expected
+func upgradeAnalysisV0ToV1(blob map[string]any) error {
+	if metrics, ok := blob["health_metrics"].([]any); ok {
+		for _, m := range metrics {
+			metric, ok := m.(map[string]any)
+			if !ok {
+				continue
+			}
+			for _, field := range []string{"score", "range_min", "range_max"} {
+				metric[field] = coerceNumber(metric[field])
+			}
+		}
+	} else {
+		blob["health_metrics"] = []any{}
+	}
+
+	for _, field := range []string{"key_findings", "recommendations"} {
+		switch v := blob[field].(type) {
+		case []any:
+		case string:
+			blob[field] = []any{v}
+		default:
+			blob[field] = []any{}
+		}
+	}
+
+	return nil
+}
+
+// upgradeAnalysisV1ToV2 adds glossary references
+// Version 1 predates glossary_terms; older summaries get links for the built-in terms they mention
+func upgradeAnalysisV1ToV2(blob map[string]any) error {
+	simpleSummary, _ := blob["simple_summary"].(string)
+	blob["glossary_terms"] = AnnotateGlossaryTerms(simpleSummary, nil)
+	return nil
+}
+
+// upgradeAnalysisV2ToV3 adds condition tags
+// Version 2 predates condition tracking; metrics and key findings are tagged the same way new analyses are
+func upgradeAnalysisV2ToV3(blob map[string]any) error {
+	if metrics, ok := blob["health_metrics"].([]any); ok {
+		for _, m := range metrics {
+			metric, ok := m.(map[string]any)
+			if !ok {
+				continue
+			}
+			name, _ := metric["name"].(string)
+			if keys := ConditionsForText(name); len(keys) > 0 {
+				metric["conditions"] = keys
+			}
+		}
+	}
+
+	findings := make(map[string][]string)
+	if keyFindings, ok := blob["key_findings"].([]any); ok {
+		for _, f := range keyFindings {
+			finding, ok := f.(string)
+			if !ok {
+				continue
+			}
+			for _, key := range ConditionsForText(finding) {
+				findings[key] = append(findings[key], finding)
+			}
+		}
+	}
+	if len(findings) > 0 {
+		blob["condition_findings"] = findings
+	}
+	return nil
+}
+
+// upgradeAnalysisV3ToV4 scores the completeness of the panels the metrics belong to
+func upgradeAnalysisV3ToV4(blob map[string]any) error {
+	var names []string
+	if metrics, ok := blob["health_metrics"].([]any); ok {
+		for _, m := range metrics {
+			if metric, ok := m.(map[string]any); ok {
+				name, _ := metric["name"].(string)
+				names = append(names, name)
+			}
+		}
+	}
+
+	if completeness := scoreCompleteness(names); completeness != nil {
+		blob["completeness"] = completeness
+	}
+	return nil
+}
+
+// upgradeAnalysisV4ToV5 infers the units of metrics reported without one
+func upgradeAnalysisV4ToV5(blob map[string]any) error {
+	metrics, _ := blob["health_metrics"].([]any)
+	for _, m := range metrics {
+		metric, ok := m.(map[string]any)
+		if !ok {
+			continue
+		}
+		if unit, _ := metric["unit"].(string); strings.TrimSpace(unit) != "" {
+			continue
+		}
+		name, _ := metric["name"].(string)
+		if inference := InferUnit(name, metric["value"]); inference != nil {
+			metric["unit"] = inference.Unit
+			metric["unit_inferred"] = true
+			metric["low_confidence"] = inference.LowConfidence
+			metric["unit_note"] = inference.Note
+		}
+	}
+	return nil
+}
+
+// coerceNumber converts numeric strings like "85" or "85%" to float64, defaulting to 0
+func coerceNumber(value any) float64 {
+	switch v := value.(type) {
+	case float64:
+		return v
+	case string:
+		cleaned := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(v), "%"))
+		if f, err := strconv.ParseFloat(cleaned, 64); err == nil {
+			return f
+		}
+	}
+	return 0
+}
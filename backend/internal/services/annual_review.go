@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+// Directions a measurement can move over a year, judged by its status
+const (
+	TrendImproved = "improved"
+	TrendWorsened = "worsened"
+	TrendStable   = "stable"
+)
+
+// statusRank orders metric statuses from healthiest to most concerning
+var statusRank = map[string]int{"normal": 0, "warning": 1, "critical": 2}
+
+// AnnualReportEntry is one report of the year as the review writer sees it
+type AnnualReportEntry struct {
+	Date     time.Time
+	Label    string
+	Analysis *AnalysisResult
+}
+
+// MetricTrend is how one measurement moved between its first and last reading of the year
+type MetricTrend struct {
+	Name         string    `json:"name"`
+	Unit         string    `json:"unit"`
+	Measurements int       `json:"measurements"`
+	FirstValue   string    `json:"first_value"`
+	FirstStatus  string    `json:"first_status"`
+	FirstDate    time.Time `json:"first_date"`
+	LastValue    string    `json:"last_value"`
+	LastStatus   string    `json:"last_status"`
+	LastDate     time.Time `json:"last_date"`
+	Direction    string    `json:"direction"` // improved, worsened, or stable
+}
+
+// AnnualReviewText is the written part of a year in review
+type AnnualReviewText struct {
+	Overview                  string   `json:"overview"`
+	Improvements              []string `json:"improvements"`
+	Deteriorations            []string `json:"deteriorations"`
+	UnresolvedRecommendations []string `json:"unresolved_recommendations"`
+}
+
+// AnnualReview is a user's year in review
+type AnnualReview struct {
+	Year        int           `json:"year"`
+	ReportCount int           `json:"report_count"`
+	Trends      []MetricTrend `json:"trends"`
+	AnnualReviewText
+	ReadingLevel string    `json:"reading_level"`
+	GeneratedAt  time.Time `json:"generated_at"`
+}
+
+// AnnualReviewWriter writes the overview of a year's reports
+// Decision: Implemented by AIService and by DemoAnalyzer for keyless demo deployments
+type AnnualReviewWriter interface {
+	// entries are in date order; trends are precomputed so the writer never does arithmetic on values
+	WriteAnnualReview(ctx context.Context, year int, entries []AnnualReportEntry, trends []MetricTrend, readingLevel string) (*AnnualReviewText, error)
+}
+
+// AnnualReviewService builds and caches year-in-review overviews from stored analyses
+type AnnualReviewService struct {
+	reviewRepo models.AnnualReviewRepository
+	reportRepo models.ReportRepository
+	writer     AnnualReviewWriter
+}
+
+// NewAnnualReviewService creates a new annual review service
+// Decision: writer may be nil when no AI is configured; cached reviews are still served
+func NewAnnualReviewService(reviewRepo models.AnnualReviewRepository, reportRepo models.ReportRepository, writer AnnualReviewWriter) *AnnualReviewService {
+	return &AnnualReviewService{
+		reviewRepo: reviewRepo,
+		reportRepo: reportRepo,
+		writer:     writer,
+	}
+}
+
+// Get returns the review of a user's year, regenerating it only when the year's reports or the reading level changed
+// A report belongs to the year of its report date, or of its upload when no date was entered, in the user's timezone
+func (as *AnnualReviewService) Get(user *models.User, year int, readingLevel string) (*AnnualReview, error) {
+	if !models.IsValidReadingLevel(readingLevel) {
+		return nil, errors.ErrInvalidReadingLevel
+	}
+	loc := user.Location()
+	if year < 1900 || year > time.Now().In(loc).Year() {
+		return nil, errors.NewValidationError("Year must be between 1900 and the current year")
+	}
+
+	entries, fingerprint, err := as.collect(user.ID, year, loc)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, errors.NewValidationError(fmt.Sprintf("No processed reports are dated in %d", year))
+	}
+
+	// Decision: The fingerprint covers what the review is written from, so edits, deletions, and re-analyses invalidate the cache
+	cached, err := as.reviewRepo.Get(user.ID, year)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if cached != nil && cached.Fingerprint == fingerprint && cached.ReadingLevel == readingLevel {
+		var review AnnualReview
+		if err := json.Unmarshal([]byte(cached.Review), &review); err == nil {
+			return &review, nil
+		}
+		slog.Warn("Discarding unreadable cached annual review", "user_id", user.ID, "year", year)
+	}
+
+	if as.writer == nil {
+		return nil, errors.ErrAIUnavailable
+	}
+	trends := ComputeMetricTrends(entries)
+	text, err := as.writer.WriteAnnualReview(WithAICallUser(context.Background(), user.ID), year, entries, trends, readingLevel)
+	if err != nil {
+		slog.Error("Failed to write annual review", "user_id", user.ID, "year", year, "error", err)
+		return nil, errors.ErrAIProcessingFailed
+	}
+
+	review := &AnnualReview{
+		Year:             year,
+		ReportCount:      len(entries),
+		Trends:           trends,
+		AnnualReviewText: *text,
+		ReadingLevel:     readingLevel,
+		GeneratedAt:      time.Now().UTC(),
+	}
+	reviewJSON, err := json.Marshal(review)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	err = as.reviewRepo.Save(&models.AnnualReview{UserID: user.ID, Year: year, Review: string(reviewJSON),
+		Fingerprint: fingerprint, ReadingLevel: readingLevel})
+	if err != nil {
+		// Decision: A failed cache write still returns the review; the next request regenerates it
+		slog.Warn("Failed to cache annual review", "user_id", user.ID, "year", year, "error", err)
+	}
+	return review, nil
+}
+
+// collect returns the year's completed reports in date order and a fingerprint of them
+func (as *AnnualReviewService) collect(userID, year int, loc *time.Location) ([]AnnualReportEntry, string, error) {
+	reports, err := as.reportRepo.ListByFilter(models.ReportFilter{UserID: userID, Status: "completed"})
+	if err != nil {
+		return nil, "", errors.ErrDatabaseConnection
+	}
+
+	var entries []AnnualReportEntry
+	var included []*models.Report
+	for _, report := range reports {
+		date := report.UploadDate.In(loc)
+		if report.ReportDate != nil {
+			date = *report.ReportDate
+		}
+		if date.Year() != year {
+			continue
+		}
+
+		analysis, err := ParseStoredAnalysis(report.SimplifiedSummary)
+		if err != nil {
+			slog.Warn("Skipping report in annual review", "report_id", report.ID, "error", err)
+			continue
+		}
+		label := report.Title
+		if label == "" {
+			label = report.OriginalFilename
+		}
+		entries = append(entries, AnnualReportEntry{Date: date, Label: label, Analysis: analysis})
+		included = append(included, report)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Date.Before(entries[j].Date) })
+
+	hash := sha256.New()
+	for _, report := range included {
+		// Decision: Hashed from content rather than updated_at, which only has second resolution
+		fmt.Fprintf(hash, "%d\x00%s\x00%v\x00%s\x00", report.ID, report.Title, report.ReportDate, report.SimplifiedSummary)
+	}
+	return entries, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// ComputeMetricTrends compares each measurement's first and last reading in entries, which must be in date order
+// Decision: Direction follows the reported status rather than the value, since higher is better for some metrics and worse for others
+func ComputeMetricTrends(entries []AnnualReportEntry) []MetricTrend {
+	byName := make(map[string]*MetricTrend)
+	var order []string
+
+	for _, entry := range entries {
+		for _, metric := range entry.Analysis.HealthMetrics {
+			key := strings.ToLower(strings.TrimSpace(metric.Name))
+			if key == "" {
+				continue
+			}
+			trend, ok := byName[key]
+			if !ok {
+				trend = &MetricTrend{Name: metric.Name, Unit: metric.Unit,
+					FirstValue: metric.GetValueAsString(), FirstStatus: metric.Status, FirstDate: entry.Date}
+				byName[key] = trend
+				order = append(order, key)
+			}
+			trend.Measurements++
+			trend.LastValue, trend.LastStatus, trend.LastDate = metric.GetValueAsString(), metric.Status, entry.Date
+		}
+	}
+
+	trends := make([]MetricTrend, 0, len(order))
+	for _, key := range order {
+		trend := byName[key]
+		first, firstKnown := statusRank[trend.FirstStatus]
+		last, lastKnown := statusRank[trend.LastStatus]
+		switch {
+		case !firstKnown || !lastKnown || first == last:
+			trend.Direction = TrendStable
+		case last < first:
+			trend.Direction = TrendImproved
+		default:
+			trend.Direction = TrendWorsened
+		}
+		trends = append(trends, *trend)
+	}
+	return trends
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+// APIKeyPrefix starts every API key, so the auth middleware can tell keys from JWTs and leaked keys are easy to scan for
+const APIKeyPrefix = "mk_"
+
+// Limits on a user's API keys
+const (
+	maxActiveAPIKeys  = 10
+	maxAPIKeyName     = 100
+	apiKeyShownPrefix = 8 // Characters of the key kept in clear for listings, including APIKeyPrefix
+)
+
+// knownAPIKeyScopes are the scopes keys may be granted
+var knownAPIKeyScopes = map[string]bool{
+	models.APIKeyScopeAnalysisImport: true,
+}
+
+// APIKeyService issues and checks the long-lived keys scripts and the medctl CLI sign in with
+// Decision: Keys don't expire, unlike session tokens, so they can only be created or revoked from a
+// password session and are stored hashed; a leaked key can be revoked without changing the password
+type APIKeyService struct {
+	keyRepo  models.APIKeyRepository
+	userRepo models.UserRepository
+}
+
+// NewAPIKeyService creates a new API key service
+func NewAPIKeyService(keyRepo models.APIKeyRepository, userRepo models.UserRepository) *APIKeyService {
+	return &APIKeyService{
+		keyRepo:  keyRepo,
+		userRepo: userRepo,
+	}
+}
+
+// Create issues a key for the user; the returned key is shown once and can't be recovered later
+// scopes grant the key privileges beyond the user's own data; only admins may pass any
+func (ks *APIKeyService) Create(userID int, name string, scopes []string) (*models.APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", errors.NewValidationError("name is required")
+	}
+	if len([]rune(name)) > maxAPIKeyName {
+		return nil, "", errors.NewValidationError(fmt.Sprintf("name must be at most %d characters", maxAPIKeyName))
+	}
+
+	for _, scope := range scopes {
+		if !knownAPIKeyScopes[scope] {
+			return nil, "", errors.NewValidationError(fmt.Sprintf("unknown scope %q", scope))
+		}
+	}
+
+	active, err := ks.keyRepo.CountActive(userID)
+	if err != nil {
+		return nil, "", errors.ErrDatabaseConnection
+	}
+	if active >= maxActiveAPIKeys {
+		return nil, "", errors.NewValidationError(fmt.Sprintf("At most %d API keys can be active; revoke one first", maxActiveAPIKeys))
+	}
+
+	keyBytes := make([]byte, 32)
+	if _, err := rand.Read(keyBytes); err != nil {
+		return nil, "", errors.ErrDatabaseConnection
+	}
+	secret := APIKeyPrefix + base64.RawURLEncoding.EncodeToString(keyBytes)
+
+	key := &models.APIKey{UserID: userID, Name: name, Prefix: secret[:apiKeyShownPrefix], KeyHash: hashShareToken(secret),
+		Scopes: strings.Join(scopes, ",")}
+	if err := ks.keyRepo.Create(key); err != nil {
+		return nil, "", errors.ErrDatabaseConnection
+	}
+	return key, secret, nil
+}
+
+// List returns the user's keys, newest first
+func (ks *APIKeyService) List(userID int) ([]*models.APIKey, error) {
+	keys, err := ks.keyRepo.ListByUser(userID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	return keys, nil
+}
+
+// Revoke stops one of the user's keys from working
+func (ks *APIKeyService) Revoke(userID, id int) error {
+	revoked, err := ks.keyRepo.Revoke(id, userID)
+	if err != nil {
+		return errors.ErrDatabaseConnection
+	}
+	if !revoked {
+		return errors.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Authenticate returns the owner of a live key along with the key
+func (ks *APIKeyService) Authenticate(secret string) (*models.User, *models.APIKey, error) {
+	key, err := ks.keyRepo.GetByHash(hashShareToken(secret))
+	if err != nil {
+		return nil, nil, errors.ErrDatabaseConnection
+	}
+	if key == nil || key.RevokedAt != nil {
+		return nil, nil, errors.ErrInvalidToken
+	}
+
+	user, err := ks.userRepo.GetByID(key.UserID)
+	if err != nil {
+		return nil, nil, errors.ErrDatabaseConnection
+	}
+	if user == nil {
+		return nil, nil, errors.ErrInvalidToken
+	}
+
+	if err := ks.keyRepo.RecordUse(key.ID); err != nil {
+		// Decision: The stamp is informational; failing to write it doesn't fail the request
+		slog.Warn("Failed to record use of API key", "key_id", key.ID, "error", err)
+	}
+	return user, key, nil
+}
@@ -0,0 +1,322 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+// schedulingSignatureTolerance is how old a signed booking request or callback may be
+const schedulingSignatureTolerance = 5 * time.Minute
+
+// maxBookingNotes bounds the note a user passes on to the clinic
+const maxBookingNotes = 500
+
+// AppointmentRequest is what the scheduling system is told about a follow-up
+// Decision: Only what a receptionist needs to book is sent: the patient's name, email, and time zone, and the
+// recommendation being followed up; never the report, its values, or the analysis
+type AppointmentRequest struct {
+	BookingID      int        `json:"booking_id"` // Ours; lets the scheduling system deduplicate retries
+	PatientName    string     `json:"patient_name"`
+	PatientEmail   string     `json:"patient_email"`
+	Timezone       string     `json:"timezone"`
+	Recommendation string     `json:"recommendation"`
+	Specialty      string     `json:"specialty"` // A key from GET /api/specialties, or empty
+	PreferredTime  *time.Time `json:"preferred_time"`
+	Notes          string     `json:"notes"`
+}
+
+// AppointmentConfirmation is the scheduling system's answer to a booking request
+type AppointmentConfirmation struct {
+	Reference   string     `json:"reference"`
+	Status      string     `json:"status"`      // booked, or pending while the user picks a time or the clinic confirms
+	BookingURL  string     `json:"booking_url"` // Where the user completes or manages the booking
+	ScheduledAt *time.Time `json:"scheduled_at"`
+}
+
+// Scheduler books follow-up appointments in an external scheduling system
+type Scheduler interface {
+	Book(ctx context.Context, request AppointmentRequest) (*AppointmentConfirmation, error)
+	Name() string
+}
+
+// NewScheduler returns the scheduling system selected by SCHEDULING_PROVIDER, or nil when booking is disabled
+func NewScheduler(cfg config.SchedulingConfig) (Scheduler, error) {
+	switch strings.ToLower(cfg.Provider) {
+	case "", "none":
+		return nil, nil
+	case "webhook":
+		if cfg.URL == "" || cfg.Secret == "" {
+			return nil, fmt.Errorf("SCHEDULING_URL and SCHEDULING_SECRET are required when SCHEDULING_PROVIDER is webhook")
+		}
+		timeout := cfg.Timeout
+		if timeout <= 0 {
+			timeout = 15 * time.Second
+		}
+		return &webhookScheduler{
+			endpoint: cfg.URL,
+			secret:   cfg.Secret,
+			client:   &http.Client{Timeout: timeout},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown scheduling provider %q (expected webhook or none)", cfg.Provider)
+	}
+}
+
+// webhookScheduler posts signed booking requests to an adapter in front of the scheduling system
+// Decision: One signed JSON contract instead of a client per vendor; Practo- or Calendly-style systems are reached
+// through a small adapter that answers with their booking reference
+type webhookScheduler struct {
+	endpoint string
+	secret   string
+	client   *http.Client
+}
+
+// Book posts the request with an X-Signature header of "t=<unix>,v1=<hex HMAC-SHA256 of "<t>.<body>">"
+func (ws *webhookScheduler) Book(ctx context.Context, request AppointmentRequest) (*AppointmentConfirmation, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ws.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Signature", "t="+timestamp+",v1="+signHMAC(ws.secret, append([]byte(timestamp+"."), body...)))
+
+	resp, err := ws.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("scheduling request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("scheduling system returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+
+	var confirmation AppointmentConfirmation
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&confirmation); err != nil {
+		return nil, fmt.Errorf("invalid scheduling response: %w", err)
+	}
+	if confirmation.Reference == "" {
+		return nil, fmt.Errorf("scheduling response has no reference")
+	}
+	if confirmation.Status != models.BookingBooked && confirmation.Status != models.BookingPending {
+		return nil, fmt.Errorf("scheduling response has unexpected status %q", confirmation.Status)
+	}
+	return &confirmation, nil
+}
+
+func (ws *webhookScheduler) Name() string {
+	return "webhook"
+}
+
+// BookingInput is what the user chose when asking for a follow-up
+type BookingInput struct {
+	Specialty     string
+	PreferredTime *time.Time
+	Notes         string
+}
+
+// AppointmentCallback is the scheduling system's later word on a booking
+type AppointmentCallback struct {
+	Reference   string     `json:"reference"`
+	Status      string     `json:"status"` // pending, booked, or cancelled
+	ScheduledAt *time.Time `json:"scheduled_at"`
+	BookingURL  string     `json:"booking_url"`
+}
+
+// AppointmentService books follow-ups for report recommendations and keeps their status in step with the scheduling system
+type AppointmentService struct {
+	bookingRepo      models.AppointmentRepository
+	reportRepo       models.ReportRepository
+	notificationRepo models.NotificationRepository
+	scheduler        Scheduler // Optional; nil disables booking
+	secret           string    // Verifies callbacks
+}
+
+// NewAppointmentService creates an appointment service; scheduler may be nil
+func NewAppointmentService(
+	bookingRepo models.AppointmentRepository,
+	reportRepo models.ReportRepository,
+	notificationRepo models.NotificationRepository,
+	scheduler Scheduler,
+	secret string,
+) *AppointmentService {
+	return &AppointmentService{
+		bookingRepo:      bookingRepo,
+		reportRepo:       reportRepo,
+		notificationRepo: notificationRepo,
+		scheduler:        scheduler,
+		secret:           secret,
+	}
+}
+
+// Book asks the scheduling system for a follow-up on the report's recommendation at index
+// Decision: The booking is stored before the call so the request has an id the scheduling system can deduplicate
+// on; a failed call is kept as failed with its error, and the user may simply try again
+func (as *AppointmentService) Book(ctx context.Context, user *models.User, reportID, index int, input BookingInput) (*models.AppointmentBooking, error) {
+	if as.scheduler == nil {
+		return nil, errors.ErrSchedulingUnavailable
+	}
+
+	report, err := getOwnedReport(as.reportRepo, user.ID, reportID)
+	if err != nil {
+		return nil, err
+	}
+	if report.ProcessingStatus != "completed" {
+		return nil, errors.ErrReportNotProcessed
+	}
+	analysis, err := ParseStoredAnalysis(report.SimplifiedSummary)
+	if err != nil {
+		return nil, errors.ErrAIProcessingFailed
+	}
+	if index < 0 || index >= len(analysis.Recommendations) {
+		return nil, errors.ErrRecordNotFound
+	}
+
+	input.Notes = strings.TrimSpace(input.Notes)
+	if len(input.Notes) > maxBookingNotes {
+		return nil, errors.NewValidationError(fmt.Sprintf("notes must be at most %d characters", maxBookingNotes))
+	}
+	if input.Specialty != "" && LookupSpecialty(input.Specialty) == nil {
+		return nil, errors.NewValidationError("Unknown specialty; see GET /api/specialties")
+	}
+	if input.PreferredTime != nil && !input.PreferredTime.After(time.Now()) {
+		return nil, errors.NewValidationError("preferred_time must be in the future")
+	}
+
+	booking := &models.AppointmentBooking{
+		UserID:              user.ID,
+		ReportID:            report.ID,
+		RecommendationIndex: index,
+		Recommendation:      analysis.Recommendations[index],
+		Specialty:           input.Specialty,
+		PreferredTime:       input.PreferredTime,
+		Notes:               input.Notes,
+		Provider:            as.scheduler.Name(),
+		Status:              models.BookingRequested,
+	}
+	created, err := as.bookingRepo.Create(booking)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if !created {
+		return nil, errors.ErrBookingExists
+	}
+
+	confirmation, err := as.scheduler.Book(ctx, AppointmentRequest{
+		BookingID:      booking.ID,
+		PatientName:    user.FullName,
+		PatientEmail:   user.Email,
+		Timezone:       user.Location().String(),
+		Recommendation: booking.Recommendation,
+		Specialty:      booking.Specialty,
+		PreferredTime:  booking.PreferredTime,
+		Notes:          booking.Notes,
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to book follow-up", "booking_id", booking.ID, "report_id", report.ID, "error", err)
+		booking.Status = models.BookingFailed
+		booking.Error = err.Error()
+		if err := as.bookingRepo.Update(booking); err != nil {
+			slog.ErrorContext(ctx, "Failed to record failed booking", "booking_id", booking.ID, "error", err)
+		}
+		return nil, errors.ErrSchedulingFailed
+	}
+
+	booking.Status = confirmation.Status
+	booking.ExternalRef = confirmation.Reference
+	booking.BookingURL = confirmation.BookingURL
+	booking.ScheduledAt = confirmation.ScheduledAt
+	if err := as.bookingRepo.Update(booking); err != nil {
+		// Decision: The appointment exists at the scheduling system; losing the reference here would orphan it
+		slog.ErrorContext(ctx, "Failed to record booking", "booking_id", booking.ID, "reference", confirmation.Reference, "error", err)
+		return nil, errors.ErrDatabaseConnection
+	}
+	return booking, nil
+}
+
+// List returns the follow-ups booked for a report, oldest first
+func (as *AppointmentService) List(userID, reportID int) ([]*models.AppointmentBooking, error) {
+	if _, err := getOwnedReport(as.reportRepo, userID, reportID); err != nil {
+		return nil, err
+	}
+	bookings, err := as.bookingRepo.ListByReport(reportID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	return bookings, nil
+}
+
+// HandleCallback applies a signed status update from the scheduling system and notifies the user of changes
+func (as *AppointmentService) HandleCallback(payload []byte, signature string) error {
+	if as.scheduler == nil {
+		return errors.ErrSchedulingUnavailable
+	}
+	if err := verifyTimestampedSignature(as.secret, payload, signature, time.Now(), schedulingSignatureTolerance); err != nil {
+		return err
+	}
+
+	var callback AppointmentCallback
+	if err := json.Unmarshal(payload, &callback); err != nil {
+		return errors.NewValidationError("Invalid callback payload")
+	}
+	switch callback.Status {
+	case models.BookingPending, models.BookingBooked, models.BookingCancelled:
+	default:
+		return errors.NewValidationError("status must be pending, booked, or cancelled")
+	}
+
+	booking, err := as.bookingRepo.GetByReference(as.scheduler.Name(), callback.Reference)
+	if err != nil {
+		return errors.ErrDatabaseConnection
+	}
+	if booking == nil {
+		return errors.ErrRecordNotFound
+	}
+
+	changed := booking.Status != callback.Status ||
+		(callback.ScheduledAt != nil && (booking.ScheduledAt == nil || !booking.ScheduledAt.Equal(*callback.ScheduledAt)))
+	booking.Status = callback.Status
+	if callback.ScheduledAt != nil {
+		booking.ScheduledAt = callback.ScheduledAt
+	}
+	if callback.BookingURL != "" {
+		booking.BookingURL = callback.BookingURL
+	}
+	if err := as.bookingRepo.Update(booking); err != nil {
+		return errors.ErrDatabaseConnection
+	}
+
+	// Decision: Retried callbacks carrying nothing new don't notify the user again
+	if changed && callback.Status != models.BookingPending {
+		message := "Your follow-up appointment was cancelled by the clinic."
+		if callback.Status == models.BookingBooked {
+			message = "Your follow-up appointment is booked."
+			if booking.ScheduledAt != nil {
+				message = "Your follow-up appointment is booked for " + booking.ScheduledAt.UTC().Format("2 Jan 2006 15:04 UTC") + "."
+			}
+		}
+		if err := as.notificationRepo.Create(&models.Notification{UserID: booking.UserID, Kind: models.NotificationAppointment,
+			Message: message}); err != nil {
+			slog.Warn("Failed to notify of booking", "user_id", booking.UserID, "booking_id", booking.ID, "error", err)
+		}
+	}
+	return nil
+}
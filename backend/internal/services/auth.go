@@ -1,7 +1,13 @@
 package services
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"log/slog"
 	"strings"
+	"time"
 
 	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
 	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
@@ -14,8 +20,16 @@ type AuthService struct {
 	userRepo        models.UserRepository
 	passwordService *PasswordService
 	jwtService      *JWTService
+	signupHooks     []func(user *models.User)
+	sessions        *SessionService               // nil leaves sessions untracked and unlimited
+	revoked         models.RevokedTokenRepository // nil keeps signed-out tokens valid until they expire
+	refreshTokens   models.RefreshTokenRepository // nil issues access tokens only, which can't be refreshed
+	refreshTTL      time.Duration
 }
 
+// RefreshTokenPrefix starts every refresh token, so one pasted where an access token belongs is easy to spot
+const RefreshTokenPrefix = "rt_"
+
 // NewAuthService creates a new authentication service
 // Decision: Inject all dependencies to allow for mocking in tests
 func NewAuthService(
@@ -30,6 +44,80 @@ func NewAuthService(
 	}
 }
 
+// AddSignupHook registers a callback run after each successful signup
+// Decision: Hooks must not fail the signup; they log their own errors
+func (as *AuthService) AddSignupHook(hook func(user *models.User)) {
+	as.signupHooks = append(as.signupHooks, hook)
+}
+
+// SetSessionService tracks sign-ins so each user may only have a limited number of sessions at once
+func (as *AuthService) SetSessionService(sessions *SessionService) {
+	as.sessions = sessions
+}
+
+// SetTokenRevocation refuses tokens signed out with Logout for the rest of their lifetime
+func (as *AuthService) SetTokenRevocation(revoked models.RevokedTokenRepository) {
+	as.revoked = revoked
+}
+
+// SetRefreshTokens issues a refresh token with every sign-in, valid for ttl from its last use
+func (as *AuthService) SetRefreshTokens(refreshTokens models.RefreshTokenRepository, ttl time.Duration) {
+	as.refreshTokens = refreshTokens
+	as.refreshTTL = ttl
+}
+
+// issueToken signs the user in, starting a tracked session when sessions are limited
+// Decision: Every sign-in gets a jti, tracked or not, so logout can revoke it along with the tokens renewed from it
+func (as *AuthService) issueToken(user *models.User, userAgent, ipAddress string) (*types.LoginResponse, error) {
+	tokenID, err := newTokenID()
+	if err != nil {
+		return nil, err
+	}
+	token, expiresAt, err := as.jwtService.GenerateSessionToken(user.ID, user.Email, tokenID)
+	if err != nil {
+		return nil, err
+	}
+	response := &types.LoginResponse{Token: token, ExpiresAt: expiresAt, User: ToUserResponse(user)}
+
+	// Decision: A tracked session lasts as long as the sign-in can be refreshed, not just its first access token
+	sessionExpiry := expiresAt
+	if as.refreshTokens != nil {
+		refreshToken, refreshExpiry, err := as.newRefreshToken(user.ID, tokenID)
+		if err != nil {
+			return nil, err
+		}
+		response.RefreshToken, response.RefreshExpiresAt = refreshToken, &refreshExpiry
+		sessionExpiry = refreshExpiry
+	}
+	if as.sessions == nil {
+		return response, nil
+	}
+	if err := as.sessions.Start(user.ID, tokenID, userAgent, ipAddress, sessionExpiry); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// newRefreshToken stores a refresh token for the sign-in tokenID names, returning it and when it expires
+// Decision: Only the hash is stored, like API keys, so a database leak can't be replayed at /refresh
+func (as *AuthService) newRefreshToken(userID int, tokenID string) (string, time.Time, error) {
+	now := time.Now()
+	if err := as.refreshTokens.DeleteExpired(userID, now); err != nil {
+		slog.Warn("Failed to delete expired refresh tokens", "user_id", userID, "error", err)
+	}
+
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", time.Time{}, err
+	}
+	secret := RefreshTokenPrefix + base64.RawURLEncoding.EncodeToString(tokenBytes)
+	stored := &models.RefreshToken{UserID: userID, TokenHash: hashShareToken(secret), SessionID: tokenID, ExpiresAt: now.Add(as.refreshTTL)}
+	if err := as.refreshTokens.Create(stored); err != nil {
+		return "", time.Time{}, errors.ErrDatabaseConnection
+	}
+	return secret, stored.ExpiresAt, nil
+}
+
 // SignUp creates a new user account
 // Decision: Accept signup request struct for validation and type safety
 func (as *AuthService) SignUp(req *types.SignupRequest) (*types.LoginResponse, error) {
@@ -46,6 +134,22 @@ func (as *AuthService) SignUp(req *types.SignupRequest) (*types.LoginResponse, e
 	// Decision: Normalize email to lowercase for consistency
 	email := strings.ToLower(strings.TrimSpace(req.Email))
 
+	timezone := models.DefaultTimezone
+	if req.Timezone != "" {
+		if _, err := models.LoadTimezone(req.Timezone); err != nil {
+			return nil, errors.NewValidationError("Unknown timezone; use an IANA name such as Asia/Kolkata")
+		}
+		timezone = req.Timezone
+	}
+
+	readingLevel := models.ReadingLevelStandard
+	if req.ReadingLevel != "" {
+		if !models.IsValidReadingLevel(req.ReadingLevel) {
+			return nil, errors.ErrInvalidReadingLevel
+		}
+		readingLevel = req.ReadingLevel
+	}
+
 	// Decision: Check if user already exists before processing
 	existingUser, err := as.userRepo.GetByEmail(email)
 	if err != nil {
@@ -69,6 +173,8 @@ func (as *AuthService) SignUp(req *types.SignupRequest) (*types.LoginResponse, e
 		FullName:      strings.TrimSpace(req.FullName),
 		EmailVerified: false, // Decision: Require email verification in future
 		IsActive:      true,
+		Timezone:      timezone,
+		ReadingLevel:  readingLevel,
 	}
 
 	// Decision: Create user in database
@@ -77,16 +183,14 @@ func (as *AuthService) SignUp(req *types.SignupRequest) (*types.LoginResponse, e
 		return nil, errors.ErrDatabaseConnection
 	}
 
-	// Decision: Generate JWT token immediately after successful signup
-	token, err := as.jwtService.GenerateToken(user.ID, user.Email)
-	if err != nil {
-		return nil, errors.ErrDatabaseConnection
+	for _, hook := range as.signupHooks {
+		hook(user)
 	}
 
-	// Decision: Return user data and token for immediate login
-	response := &types.LoginResponse{
-		Token: token,
-		User:  convertModelUserToTypeUser(user),
+	// Decision: Generate JWT token immediately after successful signup, returned with the user for immediate login
+	response, err := as.issueToken(user, req.UserAgent, req.IPAddress)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
 	}
 
 	return response, nil
@@ -120,30 +224,152 @@ func (as *AuthService) Login(req *types.LoginRequest) (*types.LoginResponse, err
 		return nil, errors.ErrInvalidCredentials
 	}
 
-	// Decision: Generate fresh JWT token on each login
-	token, err := as.jwtService.GenerateToken(user.ID, user.Email)
+	// Decision: Generate fresh JWT token on each login, returned with the user data
+	response, err := as.issueToken(user, req.UserAgent, req.IPAddress)
 	if err != nil {
 		return nil, errors.ErrDatabaseConnection
 	}
 
-	// Decision: Return user data and token
-	response := &types.LoginResponse{
-		Token: token,
-		User:  convertModelUserToTypeUser(user),
-	}
-
 	return response, nil
 }
 
 // GetUserFromToken validates a JWT token and returns user information
 // Decision: Useful for middleware to authenticate requests
 func (as *AuthService) GetUserFromToken(tokenString string) (*models.User, error) {
+	user, _, err := as.Authenticate(tokenString)
+	return user, err
+}
+
+// Authenticate validates a JWT token and returns the user along with the token's claims
+// Decision: Middleware needs the claims to tell impersonated sessions apart
+func (as *AuthService) Authenticate(tokenString string) (*models.User, *JWTClaims, error) {
 	// Decision: Validate token first
-	userID, email, err := as.jwtService.GetUserFromToken(tokenString)
+	claims, err := as.jwtService.ValidateToken(tokenString)
 	if err != nil {
-		return nil, errors.ErrInvalidToken
+		return nil, nil, errors.ErrInvalidToken
+	}
+
+	if as.revoked != nil {
+		revoked, err := as.revoked.IsRevoked(revocationID(tokenString, claims))
+		if err != nil {
+			return nil, nil, errors.ErrDatabaseConnection
+		}
+		if revoked {
+			return nil, nil, errors.ErrSessionEnded
+		}
+	}
+
+	// Decision: Impersonation tokens are short-lived and never count as the user's sessions
+	if as.sessions != nil && !claims.Impersonated() {
+		if _, err := as.sessions.Check(claims.ID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	user, err := as.userForClaims(claims.UserID, claims.Email)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return user, claims, nil
+}
+
+// Logout revokes the token, with every token renewed from the same sign-in, and ends its tracked session
+// Decision: A token that no longer validates can't be used anyway, so there is nothing to sign out
+func (as *AuthService) Logout(tokenString string) error {
+	claims, err := as.jwtService.ValidateToken(tokenString)
+	if err != nil {
+		return nil
+	}
+
+	if as.revoked != nil {
+		expiresAt := time.Now().Add(as.jwtService.expiration)
+		if claims.ExpiresAt != nil {
+			expiresAt = claims.ExpiresAt.Time
+		}
+		revocation := &models.RevokedToken{
+			TokenID:   revocationID(tokenString, claims),
+			UserID:    claims.UserID,
+			Reason:    models.TokenRevokedLogout,
+			ExpiresAt: expiresAt,
+		}
+		if err := as.revoked.Revoke(revocation, time.Now()); err != nil {
+			return errors.ErrDatabaseConnection
+		}
+	}
+
+	if claims.ID == "" || claims.Impersonated() {
+		return nil
+	}
+	if as.refreshTokens != nil {
+		if err := as.refreshTokens.RevokeSession(claims.ID, models.RefreshTokenLogout, time.Now()); err != nil {
+			return errors.ErrDatabaseConnection
+		}
+	}
+	if as.sessions == nil {
+		return nil
+	}
+	return as.sessions.End(claims.ID, models.SessionEndedLogout)
+}
+
+// LogoutRefreshToken signs out the sign-in a refresh token belongs to, for clients whose access token has expired
+// Decision: Unknown or spent refresh tokens are ignored like invalid access tokens, so logout never fails on them
+func (as *AuthService) LogoutRefreshToken(refreshToken string) error {
+	if as.refreshTokens == nil {
+		return nil
+	}
+	stored, err := as.refreshTokens.GetByHash(hashShareToken(refreshToken))
+	if err != nil {
+		return errors.ErrDatabaseConnection
+	}
+	if stored == nil || stored.RevokedAt != nil {
+		return nil
+	}
+	return as.endSignIn(stored, models.RefreshTokenLogout)
+}
+
+// endSignIn stops a sign-in everywhere: its refresh tokens, the access tokens it was issued, and its tracked session
+// reason is RefreshTokenLogout or RefreshTokenReused
+// Decision: Access tokens aren't stored, so the sign-in's jti is listed for as long as any of them could still be valid
+func (as *AuthService) endSignIn(stored *models.RefreshToken, reason string) error {
+	revocationReason, sessionReason := models.TokenRevokedLogout, models.SessionEndedLogout
+	if reason == models.RefreshTokenReused {
+		revocationReason, sessionReason = models.TokenRevokedReused, models.SessionEndedRevoked
+	}
+
+	now := time.Now()
+	if err := as.refreshTokens.RevokeSession(stored.SessionID, reason, now); err != nil {
+		return errors.ErrDatabaseConnection
+	}
+	if as.revoked != nil {
+		revocation := &models.RevokedToken{
+			TokenID:   stored.SessionID,
+			UserID:    stored.UserID,
+			Reason:    revocationReason,
+			ExpiresAt: now.Add(as.jwtService.expiration),
+		}
+		if err := as.revoked.Revoke(revocation, now); err != nil {
+			return errors.ErrDatabaseConnection
+		}
+	}
+	if as.sessions == nil {
+		return nil
+	}
+	return as.sessions.End(stored.SessionID, sessionReason)
+}
+
+// revocationID is the name a token goes by on the revocation list
+// Decision: Tokens issued before every sign-in had a jti are listed by their hash, which revokes only that token
+func revocationID(tokenString string, claims *JWTClaims) string {
+	if claims.ID != "" {
+		return claims.ID
 	}
+	sum := sha256.Sum256([]byte(tokenString))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
 
+// userForClaims loads the token's user and checks it still matches
+func (as *AuthService) userForClaims(userID int, email string) (*models.User, error) {
 	// Decision: Get fresh user data from database (handles user deactivation)
 	user, err := as.userRepo.GetByID(userID)
 	if err != nil {
@@ -163,22 +389,177 @@ func (as *AuthService) GetUserFromToken(tokenString string) (*models.User, error
 	return user, nil
 }
 
-// RefreshToken generates a new token for valid existing token
-// Decision: Extend user sessions without requiring re-authentication
-func (as *AuthService) RefreshToken(tokenString string) (string, error) {
-	// Decision: Validate current token and get user info
-	_, err := as.GetUserFromToken(tokenString)
+// Refresh exchanges a refresh token for a new access token and a new refresh token, spending the one sent
+// Decision: Presenting a spent token means it was copied, so the whole sign-in is ended rather than guessing
+// whether the thief or the owner holds the newer token; both have to sign in again
+func (as *AuthService) Refresh(refreshToken string) (*types.TokenResponse, error) {
+	if as.refreshTokens == nil || !strings.HasPrefix(refreshToken, RefreshTokenPrefix) {
+		return nil, errors.ErrInvalidToken
+	}
+	stored, err := as.refreshTokens.GetByHash(hashShareToken(refreshToken))
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	now := time.Now()
+	if stored == nil || !now.Before(stored.ExpiresAt) {
+		return nil, errors.ErrInvalidToken
+	}
+	if stored.RevokedAt != nil {
+		if stored.RevokedReason == models.RefreshTokenRotated {
+			as.refreshTokenReused(stored)
+		}
+		return nil, errors.ErrSessionEnded
+	}
+
+	if as.revoked != nil {
+		revoked, err := as.revoked.IsRevoked(stored.SessionID)
+		if err != nil {
+			return nil, errors.ErrDatabaseConnection
+		}
+		if revoked {
+			return nil, errors.ErrSessionEnded
+		}
+	}
+	if as.sessions != nil {
+		if _, err := as.sessions.Check(stored.SessionID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Decision: Fresh user data, so a deactivated or banned account can't keep refreshing
+	user, err := as.userRepo.GetByID(stored.UserID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if user == nil || !user.IsActive {
+		return nil, errors.ErrInvalidToken
+	}
+
+	// Decision: Spending the token is a conditional update, so of two requests racing with it only one wins;
+	// the other is treated as reuse
+	rotated, err := as.refreshTokens.Revoke(stored.ID, models.RefreshTokenRotated, now)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if !rotated {
+		as.refreshTokenReused(stored)
+		return nil, errors.ErrSessionEnded
+	}
+
+	// Decision: The new tokens keep the sign-in's jti, so they continue its session and logout still reaches them
+	token, expiresAt, err := as.jwtService.GenerateSessionToken(user.ID, user.Email, stored.SessionID)
+	if err != nil {
+		return nil, errors.ErrInvalidToken
+	}
+	next, nextExpiry, err := as.newRefreshToken(user.ID, stored.SessionID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if as.sessions != nil {
+		if err := as.sessions.Extend(stored.SessionID, nextExpiry); err != nil {
+			return nil, err
+		}
+	}
+
+	return &types.TokenResponse{
+		Token:            token,
+		ExpiresAt:        expiresAt,
+		RefreshToken:     next,
+		RefreshExpiresAt: nextExpiry,
+		Message:          "Token refreshed successfully",
+	}, nil
+}
+
+// refreshTokenReused ends the sign-in a spent refresh token belongs to
+func (as *AuthService) refreshTokenReused(stored *models.RefreshToken) {
+	slog.Warn("Spent refresh token presented again, signing out its sign-in", "token_id", stored.ID, "user_id", stored.UserID)
+	if err := as.endSignIn(stored, models.RefreshTokenReused); err != nil {
+		slog.Error("Failed to sign out after refresh token reuse", "user_id", stored.UserID, "error", err)
+	}
+}
+
+// RenewToken issues a fresh token for an authenticated token's claims, returning when it expires
+// Decision: Used by sliding expiration, which continues the same session like /refresh does
+func (as *AuthService) RenewToken(claims *JWTClaims) (string, time.Time, error) {
+	// Decision: Impersonation must end at its original expiry; support requests a new token instead
+	if claims.Impersonated() {
+		return "", time.Time{}, errors.ErrInvalidToken
+	}
+
+	// Decision: A renewed token keeps its jti, so it continues its session and never signs out another device
+	newToken, expiresAt, err := as.jwtService.GenerateSessionToken(claims.UserID, claims.Email, claims.ID)
 	if err != nil {
-		return "", err
+		return "", time.Time{}, errors.ErrInvalidToken
+	}
+	if as.sessions != nil {
+		if err := as.sessions.Extend(claims.ID, expiresAt); err != nil {
+			return "", time.Time{}, err
+		}
 	}
 
-	// Decision: Generate new token using JWT service
-	newToken, err := as.jwtService.RefreshToken(tokenString)
+	return newToken, expiresAt, nil
+}
+
+// UpdateProfile changes the user's display name, timezone, or reading level
+// A nonzero version applies the change only while the profile is still at that version; otherwise the
+// current profile is returned with ErrVersionConflict
+func (as *AuthService) UpdateProfile(userID int, req *types.UpdateProfileRequest, version int) (*models.User, error) {
+	current, err := as.userRepo.GetByID(userID)
 	if err != nil {
-		return "", errors.ErrInvalidToken
+		return nil, errors.ErrDatabaseConnection
+	}
+	if current == nil {
+		return nil, errors.ErrUserNotFound
+	}
+	if version != 0 && current.Version != version {
+		return current, errors.ErrVersionConflict
+	}
+
+	// Decision: Work on a copy - the cached repository may share the stored pointer
+	user := *current
+	if req.FullName != nil {
+		name := strings.TrimSpace(*req.FullName)
+		if len(name) < 2 {
+			return nil, errors.NewValidationError("Full name must be at least 2 characters")
+		}
+		user.FullName = name
+	}
+	if req.Timezone != nil {
+		if _, err := models.LoadTimezone(*req.Timezone); err != nil {
+			return nil, errors.NewValidationError("Unknown timezone; use an IANA name such as Asia/Kolkata")
+		}
+		user.Timezone = *req.Timezone
+	}
+	if req.ReadingLevel != nil {
+		if !models.IsValidReadingLevel(*req.ReadingLevel) {
+			return nil, errors.ErrInvalidReadingLevel
+		}
+		user.ReadingLevel = *req.ReadingLevel
+	}
+
+	if version == 0 {
+		if err := as.userRepo.Update(&user); err != nil {
+			return nil, errors.ErrDatabaseConnection
+		}
+		return &user, nil
 	}
 
-	return newToken, nil
+	updated, err := as.userRepo.UpdateAtVersion(&user, version)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if !updated {
+		// Changed elsewhere between reading and writing
+		latest, err := as.userRepo.GetByID(userID)
+		if err != nil {
+			return nil, errors.ErrDatabaseConnection
+		}
+		if latest == nil {
+			return nil, errors.ErrUserNotFound
+		}
+		return latest, errors.ErrVersionConflict
+	}
+	return &user, nil
 }
 
 // isValidEmail performs basic email validation
@@ -188,9 +569,10 @@ func isValidEmail(email string) bool {
 	return len(email) > 0 && strings.Contains(email, "@") && strings.Contains(email, ".")
 }
 
-// convertModelUserToTypeUser converts models.User to types.User
+// ToUserResponse converts models.User to types.User with timestamps in the user's zone
 // Decision: Keep models and API types separate for better abstraction
-func convertModelUserToTypeUser(user *models.User) types.User {
+func ToUserResponse(user *models.User) types.User {
+	loc := user.Location()
 	return types.User{
 		ID:            user.ID,
 		Email:         user.Email,
@@ -198,7 +580,11 @@ func convertModelUserToTypeUser(user *models.User) types.User {
 		FullName:      user.FullName,
 		EmailVerified: user.EmailVerified,
 		IsActive:      user.IsActive,
-		CreatedAt:     user.CreatedAt,
-		UpdatedAt:     user.UpdatedAt,
+		CreatedAt:     user.CreatedAt.In(loc),
+		UpdatedAt:     user.UpdatedAt.In(loc),
+		Timezone:      user.Timezone,
+		ReadingLevel:  user.ReadingLevel,
+		Plan:          user.Plan,
+		Version:       user.Version,
 	}
 }
\ No newline at end of file
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+)
+
+// BasicPromptVersion labels analyses produced by the basic extractor instead of a model
+const BasicPromptVersion = "basic"
+
+// AnalysisModeBasic marks an analysis read from the report text without a model
+// Decision: An optional field rather than a schema version bump, since every older blob came from a model
+const AnalysisModeBasic = "basic"
+
+// BasicAnalysisNotice is the honest status shown on analyses made without the model
+const BasicAnalysisNotice = "AI unavailable, basic extraction only"
+
+// BasicAnalyzer reads test results from a report's text with ExtractLabResults, without a model
+// Decision: Stands in for the model when it is missing or down, so the patient still gets their values and
+// the extracted text; it interprets nothing beyond the ranges and flags the lab printed
+type BasicAnalyzer struct {
+	extractor *TextExtractor
+}
+
+// NewBasicAnalyzer creates a basic analyzer extracting text the same way AIService does
+func NewBasicAnalyzer(cfg config.ExtractionConfig) *BasicAnalyzer {
+	return &BasicAnalyzer{extractor: NewTextExtractor(cfg)}
+}
+
+// AnalyzeReport extracts the report's text and the results it recognizes
+func (b *BasicAnalyzer) AnalyzeReport(ctx context.Context, filePath, fileType, readingLevel, plan, patient string) (*ReportAnalysis, error) {
+	return b.AnalyzeParts(ctx, []string{filePath}, fileType, readingLevel, plan, patient)
+}
+
+// AnalyzeParts extracts the text of every part of a multi-part report and the results it recognizes
+func (b *BasicAnalyzer) AnalyzeParts(ctx context.Context, filePaths []string, fileType, readingLevel, plan, patient string) (*ReportAnalysis, error) {
+	parts := make([]string, len(filePaths))
+	language := ""
+	for i, filePath := range filePaths {
+		extraction, err := b.extractor.Extract(ctx, filePath)
+		if err != nil {
+			return nil, &ExtractionError{Err: err}
+		}
+		language = reportLanguageOf(language, extraction.Language)
+		parts[i] = NormalizeDigits(extraction.Text)
+	}
+
+	analysis := BasicAnalysis(strings.Join(parts, "\n\n"))
+	analysis.SchemaVersion = CurrentAnalysisSchemaVersion
+	analysisJSON, err := json.Marshal(analysis)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize analysis: %w", err)
+	}
+	return &ReportAnalysis{ResultJSON: string(analysisJSON), PromptVersion: BasicPromptVersion, Language: language}, nil
+}
+
+// BasicAnalysis builds an analysis of report text from the results ExtractBasicMetrics finds
+func BasicAnalysis(text string) *AnalysisResult {
+	metrics := ExtractBasicMetrics(text)
+
+	var findings []string
+	for _, metric := range metrics {
+		if metric.Status != "normal" {
+			findings = append(findings, fmt.Sprintf("%s is outside the normal range printed on the report", metric.Name))
+		}
+	}
+	// Decision: Risk follows the lab's own ranges and flags; nothing is judged that the report doesn't say
+	riskLevel := "low"
+	if len(findings) > 0 {
+		riskLevel = "medium"
+	}
+
+	analysis := &AnalysisResult{
+		Summary: fmt.Sprintf("%s: %d test results were read from the report without interpretation.",
+			BasicAnalysisNotice, len(metrics)),
+		SimpleSummary: "We couldn't run the full analysis of your report right now, so these are only the values " +
+			"we could read from it. Compare each one with the normal range printed on your report.",
+		HealthMetrics: metrics,
+		KeyFindings:   findings,
+		Recommendations: []string{
+			"Discuss these results with your doctor",
+			"Compare each value with the reference range printed on your report",
+		},
+		RiskLevel:     riskLevel,
+		AnalysisMode:  AnalysisModeBasic,
+		ExtractedText: text,
+	}
+	// Decision: Only values with a printed range are drawn on a gauge, whatever unit inference concluded
+	lowConfidence := make([]bool, len(metrics))
+	for i, metric := range metrics {
+		lowConfidence[i] = metric.LowConfidence
+	}
+	validateAndEnhanceAnalysis(analysis)
+	for i := range analysis.HealthMetrics {
+		analysis.HealthMetrics[i].LowConfidence = analysis.HealthMetrics[i].LowConfidence || lowConfidence[i]
+	}
+	return analysis
+}
+
+// ExtractBasicMetrics turns the results ExtractLabResults reads from report text into metrics
+// Results are judged only by the reference range and flag printed with them
+func ExtractBasicMetrics(text string) []HealthMetric {
+	results := ExtractLabResults(text)
+	metrics := make([]HealthMetric, len(results))
+	for i, result := range results {
+		metric := HealthMetric{Name: result.Name, Confidence: confidenceRuleOnly}
+		applyLabResult(&metric, result)
+		if metric.Status == "" {
+			metric.Status = "normal"
+		}
+		switch {
+		case result.OutOfRange():
+			metric.Description = "Read from the report without AI; outside the normal range printed on the report."
+		case result.HasRange():
+			metric.Description = "Read from the report without AI; within the normal range printed on the report."
+		default:
+			metric.Description = "Read from the report without AI; not flagged by the lab."
+		}
+		metrics[i] = metric
+	}
+	return metrics
+}
@@ -0,0 +1,253 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+// BillingService sells the premium plan and keeps each user's plan in step with their subscription
+// Decision: The provider's webhooks are the source of truth; checkout and cancel only ask the provider
+// for a change, and the plan moves when the provider confirms it
+type BillingService struct {
+	provider  PaymentProvider // Nil when payments are disabled
+	subRepo   models.SubscriptionRepository
+	userRepo  models.UserRepository
+	auditRepo models.AuditLogRepository
+}
+
+// NewBillingService creates a new billing service; a nil provider disables checkout and webhooks
+func NewBillingService(provider PaymentProvider, subRepo models.SubscriptionRepository, userRepo models.UserRepository, auditRepo models.AuditLogRepository) *BillingService {
+	return &BillingService{
+		provider:  provider,
+		subRepo:   subRepo,
+		userRepo:  userRepo,
+		auditRepo: auditRepo,
+	}
+}
+
+// Enabled reports whether a payment provider is configured
+func (bs *BillingService) Enabled() bool {
+	return bs.provider != nil
+}
+
+// Subscription returns the user's subscription, or nil if they never started one
+func (bs *BillingService) Subscription(user *models.User) (*models.Subscription, error) {
+	subscription, err := bs.subRepo.GetByUserID(user.ID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	return subscription, nil
+}
+
+// Checkout starts a premium subscription and returns the provider's payment page
+func (bs *BillingService) Checkout(ctx context.Context, user *models.User) (*Checkout, error) {
+	if bs.provider == nil {
+		return nil, errors.ErrPaymentsUnavailable
+	}
+
+	existing, err := bs.subRepo.GetByUserID(user.ID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	// Decision: A subscription set to cancel still counts until it lapses, so a second checkout can't double-bill
+	if existing.Entitled() {
+		return nil, errors.ErrAlreadySubscribed
+	}
+
+	req := CheckoutRequest{UserID: user.ID, Email: user.Email}
+	if existing != nil && existing.Provider == bs.provider.Name() {
+		req.CustomerID = existing.CustomerID
+	}
+	checkout, err := bs.provider.CreateCheckout(ctx, req)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to start checkout", "user_id", user.ID, "error", err)
+		return nil, errors.ErrPaymentProviderFailed
+	}
+
+	if checkout.SubscriptionID != "" {
+		pending := &models.Subscription{
+			UserID:         user.ID,
+			Provider:       bs.provider.Name(),
+			CustomerID:     req.CustomerID,
+			SubscriptionID: checkout.SubscriptionID,
+			Status:         models.SubscriptionIncomplete,
+		}
+		if err := bs.subRepo.Upsert(pending); err != nil {
+			return nil, errors.ErrDatabaseConnection
+		}
+	}
+	return checkout, nil
+}
+
+// Cancel downgrades the user at the end of the period they've paid for
+func (bs *BillingService) Cancel(ctx context.Context, user *models.User) (*models.Subscription, error) {
+	if bs.provider == nil {
+		return nil, errors.ErrPaymentsUnavailable
+	}
+
+	subscription, err := bs.subRepo.GetByUserID(user.ID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if !subscription.Entitled() || subscription.SubscriptionID == "" || subscription.Provider != bs.provider.Name() {
+		return nil, errors.ErrNoSubscription
+	}
+	if subscription.CancelAtPeriodEnd {
+		return subscription, nil
+	}
+
+	if err := bs.provider.CancelSubscription(ctx, subscription.SubscriptionID); err != nil {
+		slog.ErrorContext(ctx, "Failed to cancel subscription", "subscription_id", subscription.SubscriptionID, "user_id", user.ID, "error", err)
+		return nil, errors.ErrPaymentProviderFailed
+	}
+
+	// Decision: Record the pending cancellation now so the UI reflects it before the provider's webhook arrives
+	subscription.CancelAtPeriodEnd = true
+	if err := bs.subRepo.Upsert(subscription); err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	return subscription, nil
+}
+
+// HandleWebhook verifies a provider event and applies it to the user's subscription and plan
+// Decision: Events already applied, stale events, and events for unknown users are acknowledged without
+// effect, so the provider stops retrying them
+func (bs *BillingService) HandleWebhook(payload []byte, header http.Header) error {
+	if bs.provider == nil {
+		return errors.ErrPaymentsUnavailable
+	}
+	providerName := bs.provider.Name()
+
+	event, err := bs.provider.ParseWebhook(payload, header)
+	if err != nil {
+		return err
+	}
+
+	seen, err := bs.subRepo.HasEvent(providerName, event.ID)
+	if err != nil {
+		return errors.ErrDatabaseConnection
+	}
+	if seen {
+		return nil
+	}
+
+	userID, err := bs.applyEvent(event)
+	if err != nil {
+		return err
+	}
+
+	// Decision: Recorded only once applied, so an event that failed midway is retried by the provider;
+	// applying one twice is harmless
+	if err := bs.subRepo.RecordEvent(providerName, event.ID, event.Type, userID); err != nil {
+		return errors.ErrDatabaseConnection
+	}
+	return nil
+}
+
+// applyEvent updates the stored subscription and syncs the plan, returning the user the event belonged to
+func (bs *BillingService) applyEvent(event *PaymentEvent) (int, error) {
+	if event.Status == "" {
+		return event.UserID, nil
+	}
+	providerName := bs.provider.Name()
+
+	userID := event.UserID
+	if userID == 0 {
+		known, err := bs.subRepo.GetBySubscriptionID(providerName, event.SubscriptionID)
+		if err != nil {
+			return 0, errors.ErrDatabaseConnection
+		}
+		if known == nil {
+			slog.Warn("Ignoring payment event for an unknown subscription", "provider", providerName, "type", event.Type, "subscription_id", event.SubscriptionID)
+			return 0, nil
+		}
+		userID = known.UserID
+	}
+
+	user, err := bs.userRepo.GetByID(userID)
+	if err != nil {
+		return 0, errors.ErrDatabaseConnection
+	}
+	if user == nil {
+		slog.Warn("Ignoring payment event for a missing user", "provider", providerName, "type", event.Type, "user_id", userID)
+		return 0, nil
+	}
+
+	stored, err := bs.subRepo.GetByUserID(userID)
+	if err != nil {
+		return 0, errors.ErrDatabaseConnection
+	}
+
+	subscription := &models.Subscription{UserID: userID, Provider: providerName}
+	if stored != nil && stored.Provider == providerName && (stored.SubscriptionID == event.SubscriptionID || stored.SubscriptionID == "") {
+		// Decision: Providers don't guarantee delivery order; an event older than the one applied last is dropped
+		if stored.EventAt != nil && event.OccurredAt.Before(*stored.EventAt) {
+			return userID, nil
+		}
+		subscription = stored
+	} else if stored != nil && stored.Entitled() && !isEntitledStatus(event.Status) {
+		// A late event for a subscription the user has since replaced mustn't take premium away
+		return userID, nil
+	}
+
+	subscription.Status = event.Status
+	subscription.SubscriptionID = event.SubscriptionID
+	if event.CustomerID != "" {
+		subscription.CustomerID = event.CustomerID
+	}
+	if event.CurrentPeriodEnd != nil {
+		subscription.CurrentPeriodEnd = event.CurrentPeriodEnd
+	}
+	if event.CancelAtPeriodEnd != nil {
+		subscription.CancelAtPeriodEnd = *event.CancelAtPeriodEnd
+	}
+	if subscription.Status == models.SubscriptionCanceled {
+		subscription.CancelAtPeriodEnd = false
+	}
+	occurredAt := event.OccurredAt
+	subscription.EventAt = &occurredAt
+
+	if err := bs.subRepo.Upsert(subscription); err != nil {
+		return 0, errors.ErrDatabaseConnection
+	}
+	return userID, bs.syncPlan(user, subscription)
+}
+
+// syncPlan moves the user to the plan their subscription entitles them to
+// Decision: A lapsed subscription returns the user to free even if an administrator had granted premium;
+// administrators re-grant it if needed
+func (bs *BillingService) syncPlan(user *models.User, subscription *models.Subscription) error {
+	plan := models.PlanFree
+	if subscription.Entitled() {
+		plan = models.PlanPremium
+	}
+	if user.Plan == plan {
+		return nil
+	}
+
+	// Decision: Work on a copy - the cached repository may share the stored pointer
+	updated := *user
+	previous := updated.Plan
+	updated.Plan = plan
+	if err := bs.userRepo.Update(&updated); err != nil {
+		return errors.ErrDatabaseConnection
+	}
+
+	entry := &models.AuditLog{ActorID: user.ID, UserID: user.ID, Action: models.AuditPlanChanged,
+		Details: fmt.Sprintf("%s -> %s (%s subscription %s %s)", previous, plan, subscription.Provider,
+			subscription.SubscriptionID, subscription.Status)}
+	if err := bs.auditRepo.Create(entry); err != nil {
+		slog.Error("Failed to audit plan change", "user_id", user.ID, "error", err)
+	}
+	return nil
+}
+
+// isEntitledStatus reports whether a subscription in status grants premium
+func isEntitledStatus(status string) bool {
+	return (&models.Subscription{Status: status}).Entitled()
+}
@@ -0,0 +1,307 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	_ "image/png" // Registers the PNG decoder for logo uploads
+	"slices"
+	"strings"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+// Export sections an organization can choose and order
+const (
+	ExportSectionReportDetails   = "report_details"
+	ExportSectionSummary         = "summary"
+	ExportSectionClinicalSummary = "clinical_summary"
+	ExportSectionKeyFindings     = "key_findings"
+	ExportSectionRecommendations = "recommendations"
+	ExportSectionConversation    = "conversation"
+)
+
+// ExportSections lists every section name an organization may pick
+var ExportSections = []string{
+	ExportSectionReportDetails,
+	ExportSectionSummary,
+	ExportSectionClinicalSummary,
+	ExportSectionKeyFindings,
+	ExportSectionRecommendations,
+	ExportSectionConversation,
+}
+
+// defaultExportSections matches what exports printed before organizations could choose
+var defaultExportSections = []string{ExportSectionReportDetails, ExportSectionConversation}
+
+const (
+	maxLogoBytes     = 512 * 1024
+	maxLogoDimension = 2000
+	maxFooterLength  = 300
+	maxContactLength = 300
+	maxOrgNameLength = 120
+	logoJPEGQuality  = 90
+)
+
+// ExportBranding is the organization styling applied to a member's exports
+type ExportBranding struct {
+	OrganizationName string
+	ContactInfo      string
+	FooterText       string
+	Logo             []byte // JPEG; nil when the organization has no logo
+	LogoWidth        int
+	LogoHeight       int
+	Sections         []string // Never empty; defaults apply when the organization chose none
+}
+
+// BrandingUpdate is an organization admin's change to their branding
+type BrandingUpdate struct {
+	Name        string
+	FooterText  string
+	ContactInfo string
+	Sections    []string
+}
+
+// BrandingService manages organizations and the branding their members' exports carry
+// Decision: Branding belongs to the organization rather than each user, so a clinic sets it once
+// and every clinician's exports stay consistent
+type BrandingService struct {
+	orgRepo  models.OrganizationRepository
+	userRepo models.UserRepository
+}
+
+// NewBrandingService creates a new branding service
+func NewBrandingService(orgRepo models.OrganizationRepository, userRepo models.UserRepository) *BrandingService {
+	return &BrandingService{
+		orgRepo:  orgRepo,
+		userRepo: userRepo,
+	}
+}
+
+// ForUser returns the branding for the user's exports, or nil if they don't belong to an organization
+func (bs *BrandingService) ForUser(userID int) (*ExportBranding, error) {
+	org, _, err := bs.orgRepo.GetForUser(userID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if org == nil {
+		return nil, nil
+	}
+
+	sections := org.Sections
+	if len(sections) == 0 {
+		sections = defaultExportSections
+	}
+	return &ExportBranding{
+		OrganizationName: org.Name,
+		ContactInfo:      org.ContactInfo,
+		FooterText:       org.FooterText,
+		Logo:             org.Logo,
+		LogoWidth:        org.LogoWidth,
+		LogoHeight:       org.LogoHeight,
+		Sections:         sections,
+	}, nil
+}
+
+// GetOrganization returns the user's organization and their role in it
+func (bs *BrandingService) GetOrganization(userID int) (*models.Organization, string, error) {
+	org, role, err := bs.orgRepo.GetForUser(userID)
+	if err != nil {
+		return nil, "", errors.ErrDatabaseConnection
+	}
+	if org == nil {
+		return nil, "", errors.ErrNotOrganizationMember
+	}
+	return org, role, nil
+}
+
+// UpdateBranding changes the name, footer, contact details, and sections of an organization the user administers
+func (bs *BrandingService) UpdateBranding(userID int, update BrandingUpdate) (*models.Organization, error) {
+	org, err := bs.getAdministeredOrganization(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(update.Name)
+	if name == "" {
+		name = org.Name
+	}
+	if err := validateOrganizationName(name); err != nil {
+		return nil, err
+	}
+	footer := strings.TrimSpace(update.FooterText)
+	contact := strings.TrimSpace(update.ContactInfo)
+	if len(footer) > maxFooterLength || len(contact) > maxContactLength {
+		return nil, errors.NewValidationError(fmt.Sprintf("Footer and contact details can be at most %d characters", maxFooterLength))
+	}
+	sections, err := normalizeExportSections(update.Sections)
+	if err != nil {
+		return nil, err
+	}
+
+	org.Name, org.FooterText, org.ContactInfo, org.Sections = name, footer, contact, sections
+	if err := bs.orgRepo.UpdateBranding(org); err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	return org, nil
+}
+
+// SetLogo stores a PNG or JPEG logo for an organization the user administers; empty data removes it
+// Decision: Logos are re-encoded as JPEG on a white background because the PDF writer only embeds
+// JPEGs, and converting once on upload keeps every export cheap
+func (bs *BrandingService) SetLogo(userID int, data []byte) error {
+	org, err := bs.getAdministeredOrganization(userID)
+	if err != nil {
+		return err
+	}
+
+	if len(data) == 0 {
+		if err := bs.orgRepo.SetLogo(org.ID, nil, 0, 0); err != nil {
+			return errors.ErrDatabaseConnection
+		}
+		return nil
+	}
+	if len(data) > maxLogoBytes {
+		return errors.NewValidationError(fmt.Sprintf("Logo must be at most %d KB", maxLogoBytes/1024))
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || (format != "png" && format != "jpeg") {
+		return errors.NewValidationError("Logo must be a PNG or JPEG image")
+	}
+	if config.Width > maxLogoDimension || config.Height > maxLogoDimension {
+		return errors.NewValidationError(fmt.Sprintf("Logo can be at most %dx%d pixels", maxLogoDimension, maxLogoDimension))
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return errors.NewValidationError("Logo must be a PNG or JPEG image")
+	}
+	bounds := src.Bounds()
+	flat := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(flat, flat.Bounds(), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), src, bounds.Min, draw.Over)
+
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, flat, &jpeg.Options{Quality: logoJPEGQuality}); err != nil {
+		return errors.NewValidationError("Logo could not be converted")
+	}
+
+	if err := bs.orgRepo.SetLogo(org.ID, encoded.Bytes(), bounds.Dx(), bounds.Dy()); err != nil {
+		return errors.ErrDatabaseConnection
+	}
+	return nil
+}
+
+// CreateOrganization adds an organization with default branding; only site admins call this
+func (bs *BrandingService) CreateOrganization(name string) (*models.Organization, error) {
+	name = strings.TrimSpace(name)
+	if err := validateOrganizationName(name); err != nil {
+		return nil, err
+	}
+
+	org := &models.Organization{Name: name}
+	if err := bs.orgRepo.Create(org); err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	return org, nil
+}
+
+// AddMember puts the user with the given email in an organization; only site admins call this
+// A patient's doctor is assigned by doctorEmail, who must already be a doctor in the organization; empty leaves it as it is
+func (bs *BrandingService) AddMember(orgID int, email, role, doctorEmail string) error {
+	if role == "" {
+		role = models.OrgRoleMember
+	}
+	if role != models.OrgRoleMember && role != models.OrgRoleAdmin && !models.IsClinicianRole(role) {
+		return errors.NewValidationError("Role must be member, admin, doctor, or care_team")
+	}
+	if doctorEmail != "" && role != models.OrgRoleMember {
+		return errors.NewValidationError("Only patients (members) are assigned a doctor")
+	}
+
+	org, err := bs.orgRepo.GetByID(orgID)
+	if err != nil {
+		return errors.ErrDatabaseConnection
+	}
+	if org == nil {
+		return errors.ErrRecordNotFound
+	}
+
+	user, err := bs.userRepo.GetByEmail(strings.ToLower(strings.TrimSpace(email)))
+	if err != nil {
+		return errors.ErrDatabaseConnection
+	}
+	if user == nil {
+		return errors.ErrUserNotFound
+	}
+
+	var doctorID *int
+	if doctorEmail != "" {
+		doctor, err := bs.userRepo.GetByEmail(strings.ToLower(strings.TrimSpace(doctorEmail)))
+		if err != nil {
+			return errors.ErrDatabaseConnection
+		}
+		if doctor == nil {
+			return errors.ErrUserNotFound
+		}
+		membership, err := bs.orgRepo.GetMembership(doctor.ID)
+		if err != nil {
+			return errors.ErrDatabaseConnection
+		}
+		if membership == nil || membership.OrganizationID != orgID || membership.Role != models.OrgRoleDoctor {
+			return errors.NewValidationError("The assigned doctor must be a doctor in the same organization")
+		}
+		doctorID = &doctor.ID
+	}
+
+	if err := bs.orgRepo.AddMember(orgID, user.ID, role); err != nil {
+		return errors.ErrDatabaseConnection
+	}
+	if doctorID != nil {
+		if err := bs.orgRepo.AssignDoctor(user.ID, doctorID); err != nil {
+			return errors.ErrDatabaseConnection
+		}
+	}
+	return nil
+}
+
+// getAdministeredOrganization returns the user's organization if they are one of its admins
+func (bs *BrandingService) getAdministeredOrganization(userID int) (*models.Organization, error) {
+	org, role, err := bs.GetOrganization(userID)
+	if err != nil {
+		return nil, err
+	}
+	if role != models.OrgRoleAdmin {
+		return nil, errors.ErrOrganizationAdminRequired
+	}
+	return org, nil
+}
+
+// validateOrganizationName checks an organization name is present and of reasonable length
+func validateOrganizationName(name string) error {
+	if name == "" || len(name) > maxOrgNameLength {
+		return errors.NewValidationError(fmt.Sprintf("Organization name must be 1 to %d characters", maxOrgNameLength))
+	}
+	return nil
+}
+
+// normalizeExportSections validates section names and drops duplicates, keeping the first position
+func normalizeExportSections(sections []string) ([]string, error) {
+	var normalized []string
+	for _, section := range sections {
+		section = strings.ToLower(strings.TrimSpace(section))
+		if !slices.Contains(ExportSections, section) {
+			return nil, errors.NewValidationError(fmt.Sprintf("Unknown export section %q, use one of %s",
+				section, strings.Join(ExportSections, ", ")))
+		}
+		if !slices.Contains(normalized, section) {
+			normalized = append(normalized, section)
+		}
+	}
+	return normalized, nil
+}
@@ -0,0 +1,455 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+// Calculator names accepted by GET /api/calculators/{name}
+const (
+	CalculatorASCVD      = "ascvd"
+	CalculatorFramingham = "framingham"
+	CalculatorEGFR       = "egfr"
+	CalculatorBMI        = "bmi"
+)
+
+// CalculatorInputs are the values a calculator may need; nil means unknown
+// Decision: Demographics and vitals come from the request, lab values from reports unless the request overrides them
+type CalculatorInputs struct {
+	Age              *float64 // years
+	Sex              string   // "male" or "female"
+	Race             string   // "black" selects the African American pooled cohort equations; anything else the white ones
+	HeightCm         *float64
+	WeightKg         *float64
+	SystolicBP       *float64 // mmHg
+	TotalCholesterol *float64 // mg/dL
+	HDL              *float64 // mg/dL
+	Creatinine       *float64 // serum, mg/dL
+	Smoker           *bool
+	Diabetic         *bool
+	BPTreated        *bool
+
+	// Sources says where each lab value came from, keyed by input name
+	Sources map[string]string
+}
+
+// CalculatorResult is the outcome of one calculator; Value is nil when inputs are missing or out of range
+type CalculatorResult struct {
+	Name     string            `json:"name"`
+	Title    string            `json:"title"`
+	Value    *float64          `json:"value"`
+	Unit     string            `json:"unit"`
+	Category string            `json:"category,omitempty"`
+	Missing  []string          `json:"missing,omitempty"` // Input names still needed
+	Note     string            `json:"note,omitempty"`    // Why no value was computed, when not just missing inputs
+	Sources  map[string]string `json:"sources,omitempty"` // Lab value sources, e.g. "report 12"
+}
+
+// calculator computes one score; missing lists absent inputs, err an input outside the validated range
+type calculator struct {
+	name    string
+	title   string
+	unit    string
+	compute func(in CalculatorInputs) (value float64, category string, missing []string, err error)
+}
+
+// calculators in the order the dashboard shows them
+var calculators = []calculator{
+	{CalculatorASCVD, "10-year ASCVD risk (Pooled Cohort Equations)", "%", computeASCVD},
+	{CalculatorFramingham, "10-year cardiovascular risk (Framingham)", "%", computeFramingham},
+	{CalculatorEGFR, "Estimated GFR (CKD-EPI 2021)", "mL/min/1.73m²", computeEGFR},
+	{CalculatorBMI, "Body mass index", "kg/m²", computeBMI},
+}
+
+// RunCalculator runs the named calculator, reporting missing inputs in the result rather than as an error
+func RunCalculator(name string, in CalculatorInputs) (*CalculatorResult, error) {
+	for _, calc := range calculators {
+		if calc.name == name {
+			return runCalculator(calc, in), nil
+		}
+	}
+	return nil, errors.ErrRecordNotFound
+}
+
+// RunAllCalculators runs every calculator against in, for the metrics dashboard
+func RunAllCalculators(in CalculatorInputs) []*CalculatorResult {
+	results := make([]*CalculatorResult, len(calculators))
+	for i, calc := range calculators {
+		results[i] = runCalculator(calc, in)
+	}
+	return results
+}
+
+func runCalculator(calc calculator, in CalculatorInputs) *CalculatorResult {
+	result := &CalculatorResult{Name: calc.name, Title: calc.title, Unit: calc.unit, Sources: in.Sources}
+	value, category, missing, err := calc.compute(in)
+	switch {
+	case len(missing) > 0:
+		result.Missing = missing
+	case err != nil:
+		result.Note = err.Error()
+	default:
+		rounded := math.Round(value*10) / 10
+		result.Value = &rounded
+		result.Category = category
+	}
+	return result
+}
+
+// CalculatorService runs calculators against the lab values in a user's reports
+type CalculatorService struct {
+	reportRepo  models.ReportRepository
+	profileRepo models.HealthProfileRepository // Optional; nil leaves demographics to the request
+}
+
+// NewCalculatorService creates a new calculator service
+func NewCalculatorService(reportRepo models.ReportRepository, profileRepo models.HealthProfileRepository) *CalculatorService {
+	return &CalculatorService{reportRepo: reportRepo, profileRepo: profileRepo}
+}
+
+// Calculate runs the named calculator, filling inputs the request left out from the user's health profile
+// and lab values from their newest reports
+func (cs *CalculatorService) Calculate(userID int, name string, in CalculatorInputs) (*CalculatorResult, error) {
+	if cs.profileRepo != nil {
+		profile, err := cs.profileRepo.GetByUserID(userID)
+		if err != nil {
+			return nil, errors.ErrDatabaseConnection
+		}
+		FillInputsFromProfile(&in, profile, time.Now())
+	}
+
+	reports, err := cs.reportRepo.ListByFilter(models.ReportFilter{UserID: userID, Status: "completed"})
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+
+	// Decision: ListByFilter returns oldest first; walking it backwards lets the newest report carrying a value win
+	for i := len(reports) - 1; i >= 0; i-- {
+		report := reports[i]
+		analysis, err := ParseStoredAnalysis(report.SimplifiedSummary)
+		if err != nil {
+			continue
+		}
+		FillInputsFromMetrics(&in, analysis.HealthMetrics, fmt.Sprintf("report %d", report.ID))
+	}
+
+	return RunCalculator(name, in)
+}
+
+// FillInputsFromProfile sets demographics still unset in in from the user's health profile
+func FillInputsFromProfile(in *CalculatorInputs, profile *models.HealthProfile, now time.Time) {
+	if profile == nil {
+		return
+	}
+	if in.Age == nil {
+		if age := profile.Age(now); age != nil {
+			years := float64(*age)
+			in.Age = &years
+		}
+	}
+	// Decision: "other" stays unset; the formulas only have male and female coefficients
+	if in.Sex == "" && validSex(profile.Sex) {
+		in.Sex = profile.Sex
+	}
+	if in.HeightCm == nil {
+		in.HeightCm = profile.HeightCm
+	}
+	if in.WeightKg == nil {
+		in.WeightKg = profile.WeightKg
+	}
+}
+
+// FillInputsFromMetrics sets lab values and vitals still nil in in from metrics, converting units to those the formulas expect
+func FillInputsFromMetrics(in *CalculatorInputs, metrics []HealthMetric, source string) {
+	for i := range metrics {
+		metric := &metrics[i]
+		value, err := strconv.ParseFloat(strings.TrimSpace(metric.GetValueAsString()), 64)
+		if err != nil || value <= 0 {
+			continue
+		}
+		name := strings.ToLower(metric.Name)
+		unit := strings.ToLower(strings.ReplaceAll(metric.Unit, " ", ""))
+
+		switch {
+		case isHDLName(name):
+			setInput(in, &in.HDL, "hdl", cholesterolMgDL(value, unit), source)
+		case isRatioName(name):
+			// A ratio like "Total cholesterol/HDL ratio" is no input to any formula
+		case name == "cholesterol" || strings.Contains(name, "total cholesterol") || strings.Contains(name, "cholesterol, total"):
+			setInput(in, &in.TotalCholesterol, "total_cholesterol", cholesterolMgDL(value, unit), source)
+		case strings.Contains(name, "creatinine") && !strings.Contains(name, "clearance") &&
+			!strings.Contains(name, "ratio") && !strings.Contains(name, "urine"):
+			if strings.Contains(unit, "mol") {
+				value /= 88.4 // µmol/L to mg/dL
+			}
+			setInput(in, &in.Creatinine, "creatinine", value, source)
+		case strings.Contains(name, "systolic"):
+			setInput(in, &in.SystolicBP, "systolic_bp", value, source)
+		case name == "height":
+			if unit == "m" {
+				value *= 100
+			}
+			setInput(in, &in.HeightCm, "height_cm", value, source)
+		case name == "weight" || name == "body weight":
+			if unit == "lb" || unit == "lbs" {
+				value *= 0.4536
+			}
+			setInput(in, &in.WeightKg, "weight_kg", value, source)
+		}
+	}
+}
+
+// isHDLName reports whether a lowercased metric name is HDL cholesterol itself
+// Decision: Non-HDL cholesterol and cholesterol/HDL ratios mention HDL too, and either taken as HDL would
+// skew ASCVD and Framingham without any error
+func isHDLName(name string) bool {
+	if strings.Contains(name, "non-hdl") || strings.Contains(name, "non hdl") || isRatioName(name) {
+		return false
+	}
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool { return !unicode.IsLetter(r) }) {
+		if word == "hdl" {
+			return true
+		}
+	}
+	return false
+}
+
+// isRatioName reports whether a lowercased metric name is a ratio of two results
+func isRatioName(name string) bool {
+	return strings.Contains(name, "ratio") || strings.Contains(name, "/")
+}
+
+func setInput(in *CalculatorInputs, field **float64, name string, value float64, source string) {
+	if *field != nil {
+		return
+	}
+	*field = &value
+	if in.Sources == nil {
+		in.Sources = make(map[string]string)
+	}
+	in.Sources[name] = source
+}
+
+// cholesterolMgDL converts a cholesterol value reported in mmol/L to mg/dL
+func cholesterolMgDL(value float64, unit string) float64 {
+	if strings.Contains(unit, "mmol") {
+		return value * 38.67
+	}
+	return value
+}
+
+// inputCheck pairs an input name with whether it was provided
+type inputCheck struct {
+	name string
+	set  bool
+}
+
+// missingInputs names the inputs among checks that are unset
+func missingInputs(checks ...inputCheck) []string {
+	var missing []string
+	for _, check := range checks {
+		if !check.set {
+			missing = append(missing, check.name)
+		}
+	}
+	return missing
+}
+
+func validSex(sex string) bool { return sex == "male" || sex == "female" }
+
+// cardiovascularMissing lists the inputs both cardiovascular risk models need
+func cardiovascularMissing(in CalculatorInputs) []string {
+	return missingInputs(
+		inputCheck{"age", in.Age != nil},
+		inputCheck{"sex", validSex(in.Sex)},
+		inputCheck{"total_cholesterol", in.TotalCholesterol != nil},
+		inputCheck{"hdl", in.HDL != nil},
+		inputCheck{"systolic_bp", in.SystolicBP != nil},
+		inputCheck{"smoker", in.Smoker != nil},
+		inputCheck{"diabetic", in.Diabetic != nil},
+		inputCheck{"bp_treated", in.BPTreated != nil},
+	)
+}
+
+// cardiovascularCategory buckets a 10-year risk percentage as in the 2019 ACC/AHA guideline
+func cardiovascularCategory(risk float64) string {
+	switch {
+	case risk < 5:
+		return "low"
+	case risk < 7.5:
+		return "borderline"
+	case risk < 20:
+		return "intermediate"
+	default:
+		return "high"
+	}
+}
+
+// pooledCohortCoefficients are one row of the 2013 ACC/AHA Pooled Cohort Equations
+type pooledCohortCoefficients struct {
+	lnAge, lnAgeSq, lnTC, lnAgeTC, lnHDL, lnAgeHDL                   float64
+	lnTreatedSBP, lnAgeTreatedSBP, lnUntreatedSBP, lnAgeUntreatedSBP float64
+	smoker, lnAgeSmoker, diabetes                                    float64
+	baselineSurvival, meanSum                                        float64
+}
+
+var pooledCohort = map[string]pooledCohortCoefficients{
+	"white female": {lnAge: -29.799, lnAgeSq: 4.884, lnTC: 13.540, lnAgeTC: -3.114, lnHDL: -13.578, lnAgeHDL: 3.149,
+		lnTreatedSBP: 2.019, lnUntreatedSBP: 1.957, smoker: 7.574, lnAgeSmoker: -1.665, diabetes: 0.661,
+		baselineSurvival: 0.9665, meanSum: -29.18},
+	"black female": {lnAge: 17.114, lnTC: 0.940, lnHDL: -18.920, lnAgeHDL: 4.475,
+		lnTreatedSBP: 29.291, lnAgeTreatedSBP: -6.432, lnUntreatedSBP: 27.820, lnAgeUntreatedSBP: -6.087,
+		smoker: 0.691, diabetes: 0.874, baselineSurvival: 0.9533, meanSum: 86.61},
+	"white male": {lnAge: 12.344, lnTC: 11.853, lnAgeTC: -2.664, lnHDL: -7.990, lnAgeHDL: 1.769,
+		lnTreatedSBP: 1.797, lnUntreatedSBP: 1.764, smoker: 7.837, lnAgeSmoker: -1.795, diabetes: 0.658,
+		baselineSurvival: 0.9144, meanSum: 61.18},
+	"black male": {lnAge: 2.469, lnTC: 0.302, lnHDL: -0.307, lnTreatedSBP: 1.916, lnUntreatedSBP: 1.809,
+		smoker: 0.549, diabetes: 0.645, baselineSurvival: 0.8954, meanSum: 19.54},
+}
+
+// computeASCVD is the 10-year risk of a first hard ASCVD event, validated for ages 40-79
+func computeASCVD(in CalculatorInputs) (float64, string, []string, error) {
+	if missing := cardiovascularMissing(in); len(missing) > 0 {
+		return 0, "", missing, nil
+	}
+	if *in.Age < 40 || *in.Age > 79 {
+		return 0, "", nil, fmt.Errorf("the pooled cohort equations are validated for ages 40 to 79")
+	}
+
+	race := "white"
+	if in.Race == "black" {
+		race = "black"
+	}
+	c := pooledCohort[race+" "+in.Sex]
+
+	lnAge, lnTC, lnHDL, lnSBP := math.Log(*in.Age), math.Log(*in.TotalCholesterol), math.Log(*in.HDL), math.Log(*in.SystolicBP)
+	sum := c.lnAge*lnAge + c.lnAgeSq*lnAge*lnAge + c.lnTC*lnTC + c.lnAgeTC*lnAge*lnTC + c.lnHDL*lnHDL + c.lnAgeHDL*lnAge*lnHDL
+	if *in.BPTreated {
+		sum += c.lnTreatedSBP*lnSBP + c.lnAgeTreatedSBP*lnAge*lnSBP
+	} else {
+		sum += c.lnUntreatedSBP*lnSBP + c.lnAgeUntreatedSBP*lnAge*lnSBP
+	}
+	if *in.Smoker {
+		sum += c.smoker + c.lnAgeSmoker*lnAge
+	}
+	if *in.Diabetic {
+		sum += c.diabetes
+	}
+
+	risk := (1 - math.Pow(c.baselineSurvival, math.Exp(sum-c.meanSum))) * 100
+	return risk, cardiovascularCategory(risk), nil, nil
+}
+
+// framinghamCoefficients are one row of the 2008 Framingham general cardiovascular risk model
+type framinghamCoefficients struct {
+	lnAge, lnTC, lnHDL, lnTreatedSBP, lnUntreatedSBP, smoker, diabetes float64
+	baselineSurvival, meanSum                                          float64
+}
+
+var framingham = map[string]framinghamCoefficients{
+	"female": {lnAge: 2.32888, lnTC: 1.20904, lnHDL: -0.70833, lnTreatedSBP: 2.82263, lnUntreatedSBP: 2.76157,
+		smoker: 0.52873, diabetes: 0.69154, baselineSurvival: 0.95012, meanSum: 26.1931},
+	"male": {lnAge: 3.06117, lnTC: 1.12370, lnHDL: -0.93263, lnTreatedSBP: 1.99881, lnUntreatedSBP: 1.93303,
+		smoker: 0.65451, diabetes: 0.57367, baselineSurvival: 0.88936, meanSum: 23.9802},
+}
+
+// computeFramingham is the 10-year risk of any cardiovascular event, validated for ages 30-74
+func computeFramingham(in CalculatorInputs) (float64, string, []string, error) {
+	if missing := cardiovascularMissing(in); len(missing) > 0 {
+		return 0, "", missing, nil
+	}
+	if *in.Age < 30 || *in.Age > 74 {
+		return 0, "", nil, fmt.Errorf("the Framingham model is validated for ages 30 to 74")
+	}
+
+	c := framingham[in.Sex]
+	sum := c.lnAge*math.Log(*in.Age) + c.lnTC*math.Log(*in.TotalCholesterol) + c.lnHDL*math.Log(*in.HDL)
+	if *in.BPTreated {
+		sum += c.lnTreatedSBP * math.Log(*in.SystolicBP)
+	} else {
+		sum += c.lnUntreatedSBP * math.Log(*in.SystolicBP)
+	}
+	if *in.Smoker {
+		sum += c.smoker
+	}
+	if *in.Diabetic {
+		sum += c.diabetes
+	}
+
+	risk := (1 - math.Pow(c.baselineSurvival, math.Exp(sum-c.meanSum))) * 100
+	return risk, cardiovascularCategory(risk), nil, nil
+}
+
+// computeEGFR is the race-free 2021 CKD-EPI creatinine equation, staged by KDIGO GFR category
+func computeEGFR(in CalculatorInputs) (float64, string, []string, error) {
+	missing := missingInputs(
+		inputCheck{"age", in.Age != nil},
+		inputCheck{"sex", validSex(in.Sex)},
+		inputCheck{"creatinine", in.Creatinine != nil},
+	)
+	if len(missing) > 0 {
+		return 0, "", missing, nil
+	}
+	if *in.Age < 18 {
+		return 0, "", nil, fmt.Errorf("CKD-EPI is validated for adults only")
+	}
+
+	kappa, alpha, factor := 0.9, -0.302, 1.0
+	if in.Sex == "female" {
+		kappa, alpha, factor = 0.7, -0.241, 1.012
+	}
+	ratio := *in.Creatinine / kappa
+	egfr := 142 * math.Pow(math.Min(ratio, 1), alpha) * math.Pow(math.Max(ratio, 1), -1.200) *
+		math.Pow(0.9938, *in.Age) * factor
+
+	var category string
+	switch {
+	case egfr >= 90:
+		category = "G1"
+	case egfr >= 60:
+		category = "G2"
+	case egfr >= 45:
+		category = "G3a"
+	case egfr >= 30:
+		category = "G3b"
+	case egfr >= 15:
+		category = "G4"
+	default:
+		category = "G5"
+	}
+	return egfr, category, nil, nil
+}
+
+// computeBMI is weight over height squared, with WHO adult categories
+func computeBMI(in CalculatorInputs) (float64, string, []string, error) {
+	missing := missingInputs(
+		inputCheck{"height_cm", in.HeightCm != nil},
+		inputCheck{"weight_kg", in.WeightKg != nil},
+	)
+	if len(missing) > 0 {
+		return 0, "", missing, nil
+	}
+
+	meters := *in.HeightCm / 100
+	bmi := *in.WeightKg / (meters * meters)
+
+	var category string
+	switch {
+	case bmi < 18.5:
+		category = "underweight"
+	case bmi < 25:
+		category = "normal"
+	case bmi < 30:
+		category = "overweight"
+	default:
+		category = "obese"
+	}
+	return bmi, category, nil, nil
+}
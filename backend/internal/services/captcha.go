@@ -0,0 +1,273 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+// Siteverify endpoints; both providers accept the same form and return the same shape
+const (
+	hCaptchaVerifyURL  = "https://api.hcaptcha.com/siteverify"
+	reCaptchaVerifyURL = "https://www.google.com/recaptcha/api/siteverify"
+)
+
+// CaptchaVerifier checks a token produced by a CAPTCHA widget in the browser
+type CaptchaVerifier interface {
+	Verify(token, remoteIP string) error
+}
+
+// NewCaptchaVerifier returns the verifier for the configured provider, or nil when CAPTCHA is disabled
+func NewCaptchaVerifier(cfg config.CaptchaConfig) (CaptchaVerifier, error) {
+	verifyURL := cfg.VerifyURL
+
+	switch strings.ToLower(cfg.Provider) {
+	case "", "none":
+		return nil, nil
+	case "hcaptcha":
+		if verifyURL == "" {
+			verifyURL = hCaptchaVerifyURL
+		}
+	case "recaptcha":
+		if verifyURL == "" {
+			verifyURL = reCaptchaVerifyURL
+		}
+	default:
+		return nil, fmt.Errorf("unknown CAPTCHA provider %q (expected hcaptcha, recaptcha or none)", cfg.Provider)
+	}
+
+	if cfg.SecretKey == "" {
+		return nil, fmt.Errorf("CAPTCHA_SECRET_KEY is required when CAPTCHA_PROVIDER is %s", cfg.Provider)
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+
+	return &siteVerifier{
+		secret:    cfg.SecretKey,
+		verifyURL: verifyURL,
+		client:    &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// siteVerifier validates tokens against an hCaptcha or reCAPTCHA siteverify endpoint
+type siteVerifier struct {
+	secret    string
+	verifyURL string
+	client    *http.Client
+}
+
+type siteVerifyResponse struct {
+	Success    bool     `json:"success"`
+	ErrorCodes []string `json:"error-codes"`
+}
+
+// Verify posts the token to the provider and fails unless it reports success
+func (sv *siteVerifier) Verify(token, remoteIP string) error {
+	form := url.Values{"secret": {sv.secret}, "response": {token}}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+
+	resp, err := sv.client.PostForm(sv.verifyURL, form)
+	if err != nil {
+		return fmt.Errorf("CAPTCHA verification request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("CAPTCHA provider returned status %d", resp.StatusCode)
+	}
+
+	var result siteVerifyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("invalid CAPTCHA provider response: %w", err)
+	}
+	if !result.Success {
+		return errors.ErrCaptchaFailed
+	}
+
+	return nil
+}
+
+// CaptchaGuard decides when a CAPTCHA is required and verifies it
+// Decision: Signups always need one; logins only after repeated failures for the same
+// email or client IP, so regular users never see a challenge
+type CaptchaGuard struct {
+	verifier  CaptchaVerifier
+	siteKey   string
+	provider  string
+	threshold int
+	failures  *loginFailureTracker
+}
+
+// NewCaptchaGuard creates a guard; a nil verifier disables every check
+func NewCaptchaGuard(verifier CaptchaVerifier, cfg config.CaptchaConfig) *CaptchaGuard {
+	threshold := cfg.LoginFailureThreshold
+	if threshold <= 0 {
+		threshold = 3
+	}
+	window := cfg.FailureWindow
+	if window <= 0 {
+		window = 15 * time.Minute
+	}
+
+	return &CaptchaGuard{
+		verifier:  verifier,
+		siteKey:   cfg.SiteKey,
+		provider:  strings.ToLower(cfg.Provider),
+		threshold: threshold,
+		failures:  newLoginFailureTracker(window),
+	}
+}
+
+// Enabled reports whether a CAPTCHA provider is configured
+func (cg *CaptchaGuard) Enabled() bool {
+	return cg != nil && cg.verifier != nil
+}
+
+// CheckSignup verifies the CAPTCHA token sent with a signup
+func (cg *CaptchaGuard) CheckSignup(token, remoteIP string) error {
+	if !cg.Enabled() {
+		return nil
+	}
+	return cg.verify(token, remoteIP)
+}
+
+// CheckLogin verifies the CAPTCHA token once the email or IP has failed too often
+func (cg *CaptchaGuard) CheckLogin(email, token, remoteIP string) error {
+	if !cg.Enabled() || !cg.LoginChallenged(email, remoteIP) {
+		return nil
+	}
+	return cg.verify(token, remoteIP)
+}
+
+// LoginChallenged reports whether the next login for email or IP must carry a CAPTCHA token
+func (cg *CaptchaGuard) LoginChallenged(email, remoteIP string) bool {
+	if !cg.Enabled() {
+		return false
+	}
+	return cg.failures.count(emailKey(email)) >= cg.threshold ||
+		cg.failures.count(ipKey(remoteIP)) >= cg.threshold
+}
+
+// LoginFailed records a failed login attempt
+func (cg *CaptchaGuard) LoginFailed(email, remoteIP string) {
+	if !cg.Enabled() {
+		return
+	}
+	cg.failures.record(emailKey(email))
+	cg.failures.record(ipKey(remoteIP))
+}
+
+// LoginSucceeded clears the failure count for the account
+// Decision: Keep the IP count so one valid account can't launder a credential-stuffing run
+func (cg *CaptchaGuard) LoginSucceeded(email string) {
+	if !cg.Enabled() {
+		return
+	}
+	cg.failures.reset(emailKey(email))
+}
+
+// Settings describes the widget the frontend should render
+func (cg *CaptchaGuard) Settings() (provider, siteKey string, threshold int) {
+	if !cg.Enabled() {
+		return "none", "", 0
+	}
+	return cg.provider, cg.siteKey, cg.threshold
+}
+
+// verify rejects missing tokens and maps provider outages to a retryable error
+func (cg *CaptchaGuard) verify(token, remoteIP string) error {
+	if strings.TrimSpace(token) == "" {
+		return errors.ErrCaptchaRequired
+	}
+
+	err := cg.verifier.Verify(token, remoteIP)
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*errors.AppError); ok {
+		return err
+	}
+	// Decision: Fail closed; an unreachable provider must not disable bot protection
+	return errors.ErrCaptchaUnavailable
+}
+
+func emailKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// loginFailureTracker counts failures per key within a sliding window
+// Decision: In-memory like the user cache; a restart only forgets recent failures
+type loginFailureTracker struct {
+	mu       sync.Mutex
+	window   time.Duration
+	attempts map[string][]time.Time
+}
+
+func newLoginFailureTracker(window time.Duration) *loginFailureTracker {
+	return &loginFailureTracker{
+		window:   window,
+		attempts: make(map[string][]time.Time),
+	}
+}
+
+func (lt *loginFailureTracker) record(key string) {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+
+	now := time.Now()
+	lt.attempts[key] = append(lt.recent(key, now), now)
+
+	// Decision: Sweep expired keys occasionally so spraying many emails can't grow the map forever
+	if len(lt.attempts) > 10000 {
+		for k := range lt.attempts {
+			if len(lt.recent(k, now)) == 0 {
+				delete(lt.attempts, k)
+			}
+		}
+	}
+}
+
+func (lt *loginFailureTracker) count(key string) int {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+	return len(lt.recent(key, time.Now()))
+}
+
+func (lt *loginFailureTracker) reset(key string) {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+	delete(lt.attempts, key)
+}
+
+// recent returns the attempts for key still inside the window; callers hold mu
+func (lt *loginFailureTracker) recent(key string, now time.Time) []time.Time {
+	attempts := lt.attempts[key]
+	cutoff := now.Add(-lt.window)
+	i := 0
+	for i < len(attempts) && attempts[i].Before(cutoff) {
+		i++
+	}
+	if i == len(attempts) {
+		delete(lt.attempts, key)
+		return nil
+	}
+	attempts = attempts[i:]
+	lt.attempts[key] = attempts
+	return attempts
+}
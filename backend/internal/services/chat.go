@@ -0,0 +1,445 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+// maxQuestionLength bounds a single chat question
+const maxQuestionLength = 2000
+
+// ChatService handles report Q&A business logic
+type ChatService struct {
+	chatRepo    models.ChatMessageRepository
+	summaryRepo models.ChatSummaryRepository
+	reportRepo  models.ReportRepository
+	profileRepo models.HealthProfileRepository // Optional; nil answers without the patient's profile
+	responder   ChatResponder
+	transcriber Transcriber
+	events      EventBus // Optional; nil publishes nothing
+	safety      *SafetyFilter
+	safetyLog   models.SafetyInterventionRepository // Optional; nil logs interventions to the server log only
+	crisis      *CrisisService
+
+	historyTokens int
+	recentTurns   int
+	disclaimer    string
+	plans         map[string]config.PlanConfig
+}
+
+// NewChatService creates a new chat service
+// Decision: responder may be nil when no AI is configured; AI-backed operations then return 503.
+// transcriber may be nil too, which only disables voice questions
+func NewChatService(chatRepo models.ChatMessageRepository, summaryRepo models.ChatSummaryRepository, reportRepo models.ReportRepository, profileRepo models.HealthProfileRepository, responder ChatResponder, transcriber Transcriber, cfg config.AIConfig) *ChatService {
+	// Decision: An invalid level screens at standard rather than not at all; main refuses to start with one anyway
+	safety, err := NewSafetyFilter(cfg.ChatSafety)
+	if err != nil {
+		slog.Warn("Invalid chat safety level, screening chat at the standard level", "error", err)
+		safety, _ = NewSafetyFilter(config.ChatSafetyConfig{EmergencyNumber: cfg.ChatSafety.EmergencyNumber})
+	}
+	crisis, err := NewCrisisService(nil, nil, cfg.ChatSafety)
+	if err != nil {
+		slog.Warn("Invalid crisis contacts, giving generic ones", "error", err)
+		crisis, _ = NewCrisisService(nil, nil, config.ChatSafetyConfig{EmergencyNumber: cfg.ChatSafety.EmergencyNumber})
+	}
+
+	return &ChatService{
+		chatRepo:      chatRepo,
+		summaryRepo:   summaryRepo,
+		reportRepo:    reportRepo,
+		profileRepo:   profileRepo,
+		responder:     responder,
+		transcriber:   transcriber,
+		safety:        safety,
+		crisis:        crisis,
+		historyTokens: cfg.ChatHistoryTokens,
+		recentTurns:   cfg.ChatRecentTurns,
+		disclaimer:    cfg.Persona.Disclaimer,
+		plans:         cfg.Plans,
+	}
+}
+
+// SetEventBus publishes chat.created on bus for every new question answered
+func (cs *ChatService) SetEventBus(bus EventBus) {
+	cs.events = bus
+}
+
+// SetSafetyLog records every answer the safety filter changes in repo
+func (cs *ChatService) SetSafetyLog(repo models.SafetyInterventionRepository) {
+	cs.safetyLog = repo
+}
+
+// SetCrisisService replaces the default crisis service, which gives everyone the same contacts and flags
+// to the server log only
+func (cs *ChatService) SetCrisisService(crisis *CrisisService) {
+	cs.crisis = crisis
+}
+
+// VoiceQuestion is a recorded question uploaded to the chat
+type VoiceQuestion struct {
+	Audio     []byte
+	AudioType string // One of the types DetectAudioType returns
+	Language  string // Optional BCP 47 hint for the transcriber
+}
+
+// AskByVoice transcribes a recorded question and answers it like a typed one
+// Decision: The recording is discarded after transcription; the stored question is the
+// transcription, so history, edits, and exports treat it like any other message
+func (cs *ChatService) AskByVoice(ctx context.Context, userID, reportID int, voice VoiceQuestion, readingLevel, plan string) (*models.ChatMessage, error) {
+	if !models.IsValidReadingLevel(readingLevel) {
+		return nil, errors.ErrInvalidReadingLevel
+	}
+	if cs.transcriber == nil {
+		return nil, errors.ErrTranscriptionUnavailable
+	}
+
+	report, err := getOwnedReport(cs.reportRepo, userID, reportID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Decision: Readiness and the allowance are checked after transcription, which costs a transcription for
+	// questions that are then refused, so that a crisis is answered even on an unanalyzed report, without AI,
+	// or past the daily limit
+	transcription, err := cs.transcriber.Transcribe(ctx, voice.Audio, voice.AudioType, voice.Language)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to transcribe voice question", "report_id", report.ID, "transcriber", cs.transcriber.Name(), "error", err)
+		return nil, errors.ErrAIProcessingFailed
+	}
+	transcription = strings.Join(strings.Fields(transcription), " ")
+	if transcription == "" {
+		return nil, errors.ErrTranscriptionEmpty
+	}
+	if len(transcription) > maxQuestionLength {
+		return nil, errors.NewValidationError("Recording is too long; ask one question at a time")
+	}
+
+	if match := DetectCrisis(transcription); match != nil {
+		return cs.answerCrisis(report, transcription, models.ChatInputVoice, match)
+	}
+	if err := cs.checkAnswerable(report); err != nil {
+		return nil, err
+	}
+	if err := cs.checkChatAllowance(userID, plan); err != nil {
+		return nil, err
+	}
+	return cs.ask(report, transcription, readingLevel, models.ChatInputVoice)
+}
+
+// Ask answers a typed question about one of the user's reports, with the report's analysis and earlier turns as context
+func (cs *ChatService) Ask(userID, reportID int, question, readingLevel, plan string) (*models.ChatMessage, error) {
+	question = strings.TrimSpace(question)
+	if question == "" || len(question) > maxQuestionLength {
+		return nil, errors.NewValidationError("Question must be between 1 and 2000 characters")
+	}
+	if !models.IsValidReadingLevel(readingLevel) {
+		return nil, errors.ErrInvalidReadingLevel
+	}
+
+	report, err := getOwnedReport(cs.reportRepo, userID, reportID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Decision: Crisis questions are answered before the readiness and allowance checks, like voice questions
+	if match := DetectCrisis(question); match != nil {
+		return cs.answerCrisis(report, question, models.ChatInputText, match)
+	}
+	if err := cs.checkAnswerable(report); err != nil {
+		return nil, err
+	}
+	if err := cs.checkChatAllowance(userID, plan); err != nil {
+		return nil, err
+	}
+	return cs.ask(report, question, readingLevel, models.ChatInputText)
+}
+
+// GetHistory returns a page of the conversation about one of the user's reports, oldest first
+func (cs *ChatService) GetHistory(userID, reportID, limit, offset int) ([]*models.ChatMessage, error) {
+	if _, err := getOwnedReport(cs.reportRepo, userID, reportID); err != nil {
+		return nil, err
+	}
+
+	messages, err := cs.chatRepo.GetByReportID(reportID, limit, offset)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	return messages, nil
+}
+
+// DeleteMessage removes a question and its answer from the conversation
+// Decision: Soft delete, so the question still counts towards the chat allowance and the safety and crisis
+// records that point at it stay intact; deleted turns are no longer context for later answers
+func (cs *ChatService) DeleteMessage(userID, messageID int) error {
+	message, report, err := cs.getOwnedMessage(userID, messageID)
+	if err != nil {
+		return err
+	}
+
+	if err := cs.chatRepo.SoftDelete(message.ID); err == models.ErrLegalHold {
+		return errors.ErrLegalHold
+	} else if err != nil {
+		return errors.ErrDatabaseConnection
+	}
+	cs.dropSummaryCovering(report.ID, message.ID)
+	return nil
+}
+
+// answerCrisis stores a crisis question with the emergency contacts as its answer and flags it
+func (cs *ChatService) answerCrisis(report *models.Report, question, inputMode string, match *CrisisMatch) (*models.ChatMessage, error) {
+	contacts := cs.crisis.ContactsFor(report.UserID)
+	message := &models.ChatMessage{
+		ReportID:    report.ID,
+		UserMessage: question,
+		AIResponse:  cs.crisis.Reply(match, contacts),
+		InputMode:   inputMode,
+	}
+	if err := cs.chatRepo.Create(message); err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	cs.crisis.Flag(report, message, match, contacts)
+	publishEvent(cs.events, Event{Type: EventChatCreated, UserID: report.UserID, ReportID: report.ID, MessageID: message.ID})
+	return message, nil
+}
+
+// ask answers a new question after the report's existing turns and stores the pair
+func (cs *ChatService) ask(report *models.Report, question, readingLevel, inputMode string) (*models.ChatMessage, error) {
+	history, err := cs.chatRepo.GetChatHistory(report.ID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+
+	ctx := WithAICallUser(context.Background(), report.UserID)
+	conversationSummary, recent, err := cs.compactHistory(ctx, report.ID, history)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+
+	answer, err := cs.responder.AnswerQuestion(ctx, report.SimplifiedSummary, conversationSummary, recent, question, readingLevel, cs.patientContext(report.UserID))
+	if err != nil {
+		return nil, errors.ErrAIProcessingFailed
+	}
+	screened := cs.safety.Screen(answer)
+
+	message := &models.ChatMessage{
+		ReportID:    report.ID,
+		UserMessage: question,
+		AIResponse:  screened.Answer,
+		InputMode:   inputMode,
+	}
+	if err := cs.chatRepo.Create(message); err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	cs.logIntervention(report, message.ID, answer, screened)
+	publishEvent(cs.events, Event{Type: EventChatCreated, UserID: report.UserID, ReportID: report.ID, MessageID: message.ID})
+	return message, nil
+}
+
+// logIntervention records an answer the safety filter changed; failures are logged and don't fail the chat
+func (cs *ChatService) logIntervention(report *models.Report, messageID int, original string, screened SafetyResult) {
+	if !screened.Intervened() {
+		return
+	}
+	slog.Info("Chat safety filter removed content", "level", cs.safety.Level(), "categories", strings.Join(screened.Categories, ","),
+		"message_id", messageID, "report_id", report.ID)
+	if cs.safetyLog == nil {
+		return
+	}
+
+	intervention := &models.SafetyIntervention{
+		UserID:         report.UserID,
+		ReportID:       report.ID,
+		MessageID:      messageID,
+		Level:          cs.safety.Level(),
+		Categories:     screened.Categories,
+		Removed:        screened.Removed,
+		OriginalAnswer: original,
+	}
+	if err := cs.safetyLog.Create(intervention); err != nil {
+		slog.Error("Failed to record chat safety intervention", "message_id", messageID, "error", err)
+	}
+}
+
+// patientContext describes the report owner's profile for the chat prompt; lookup failures answer without it
+func (cs *ChatService) patientContext(userID int) string {
+	if cs.profileRepo == nil {
+		return ""
+	}
+	profile, err := cs.profileRepo.GetByUserID(userID)
+	if err != nil {
+		slog.Warn("Failed to load profile for chat", "user_id", userID, "error", err)
+		return ""
+	}
+	return PatientContext(profile, time.Now())
+}
+
+// checkChatAllowance fails when the user has asked as many questions as their plan allows in the last 24 hours
+// Decision: Only new questions count; edits and regenerations revise an existing turn
+func (cs *ChatService) checkChatAllowance(userID int, plan string) error {
+	limit := PlanLimits(cs.plans, plan).ChatMessagesPerDay
+	if limit <= 0 {
+		return nil
+	}
+	used, err := cs.chatRepo.CountByUserSince(userID, time.Now().Add(-chatWindow))
+	if err != nil {
+		return errors.ErrDatabaseConnection
+	}
+	if used >= limit {
+		return errors.ErrChatLimitReached
+	}
+	return nil
+}
+
+// checkAnswerable fails when the report can't be discussed yet
+func (cs *ChatService) checkAnswerable(report *models.Report) error {
+	if cs.responder == nil {
+		return errors.ErrAIUnavailable
+	}
+	if report.ProcessingStatus != "completed" {
+		return errors.ErrReportNotProcessed
+	}
+	return nil
+}
+
+// EditMessage replaces a question and re-asks the AI, keeping the old pair as a version
+func (cs *ChatService) EditMessage(userID, messageID int, question, readingLevel string) (*models.ChatMessage, error) {
+	question = strings.TrimSpace(question)
+	if question == "" || len(question) > maxQuestionLength {
+		return nil, errors.NewValidationError("Question must be between 1 and 2000 characters")
+	}
+	if !models.IsValidReadingLevel(readingLevel) {
+		return nil, errors.ErrInvalidReadingLevel
+	}
+
+	message, report, err := cs.getOwnedMessage(userID, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	return cs.revise(message, report, question, readingLevel)
+}
+
+// RegenerateMessage re-asks the AI the same question, keeping the old answer as a version
+func (cs *ChatService) RegenerateMessage(userID, messageID int, readingLevel string) (*models.ChatMessage, error) {
+	if !models.IsValidReadingLevel(readingLevel) {
+		return nil, errors.ErrInvalidReadingLevel
+	}
+
+	message, report, err := cs.getOwnedMessage(userID, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	return cs.revise(message, report, message.UserMessage, readingLevel)
+}
+
+// GetVersions returns a message along with its superseded versions
+func (cs *ChatService) GetVersions(userID, messageID int) (*models.ChatMessage, []*models.ChatMessageVersion, error) {
+	message, _, err := cs.getOwnedMessage(userID, messageID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	versions, err := cs.chatRepo.GetVersions(messageID)
+	if err != nil {
+		return nil, nil, errors.ErrDatabaseConnection
+	}
+	return message, versions, nil
+}
+
+// revise answers question in the context of the turns before message and stores the result
+func (cs *ChatService) revise(message *models.ChatMessage, report *models.Report, question, readingLevel string) (*models.ChatMessage, error) {
+	if match := DetectCrisis(question); match != nil {
+		contacts := cs.crisis.ContactsFor(report.UserID)
+		message.UserMessage = question
+		message.AIResponse = cs.crisis.Reply(match, contacts)
+		if err := cs.storeRevision(message, report); err != nil {
+			return nil, err
+		}
+		cs.crisis.Flag(report, message, match, contacts)
+		return message, nil
+	}
+
+	if err := cs.checkAnswerable(report); err != nil {
+		return nil, err
+	}
+
+	history, err := cs.chatRepo.GetChatHistory(report.ID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+
+	// Decision: Only earlier turns are context - later answers were based on the old question
+	var earlier []*models.ChatMessage
+	for _, turn := range history {
+		if turn.ID < message.ID {
+			earlier = append(earlier, turn)
+		}
+	}
+
+	ctx := WithAICallUser(context.Background(), report.UserID)
+	conversationSummary, recent, err := cs.compactHistory(ctx, report.ID, earlier)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+
+	answer, err := cs.responder.AnswerQuestion(ctx, report.SimplifiedSummary, conversationSummary, recent, question, readingLevel, cs.patientContext(report.UserID))
+	if err != nil {
+		return nil, errors.ErrAIProcessingFailed
+	}
+	screened := cs.safety.Screen(answer)
+
+	message.UserMessage = question
+	message.AIResponse = screened.Answer
+	if err := cs.storeRevision(message, report); err != nil {
+		return nil, err
+	}
+	cs.logIntervention(report, message.ID, answer, screened)
+
+	return message, nil
+}
+
+// storeRevision saves a revised message, archiving its previous version
+func (cs *ChatService) storeRevision(message *models.ChatMessage, report *models.Report) error {
+	if err := cs.chatRepo.Revise(message); err != nil {
+		return errors.ErrDatabaseConnection
+	}
+
+	// Decision: A stored summary that covered this turn now describes the old question; rebuild it lazily
+	cs.dropSummaryCovering(report.ID, message.ID)
+	return nil
+}
+
+// dropSummaryCovering deletes the report's conversation summary if it covers the message, so it is rebuilt
+// from the turns as they are now the next time it is needed
+func (cs *ChatService) dropSummaryCovering(reportID, messageID int) {
+	if stored, err := cs.summaryRepo.GetByReportID(reportID); err == nil && stored != nil && stored.ThroughMessageID >= messageID {
+		if err := cs.summaryRepo.Delete(reportID); err != nil {
+			slog.Warn("Failed to invalidate chat summary", "report_id", reportID, "error", err)
+		}
+	}
+}
+
+// getOwnedMessage loads a message and its report, checking the caller owns the report
+func (cs *ChatService) getOwnedMessage(userID, messageID int) (*models.ChatMessage, *models.Report, error) {
+	message, err := cs.chatRepo.GetByID(messageID)
+	if err != nil {
+		return nil, nil, errors.ErrDatabaseConnection
+	}
+	if message == nil {
+		return nil, nil, errors.ErrRecordNotFound
+	}
+
+	report, err := getOwnedReport(cs.reportRepo, userID, message.ReportID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return message, report, nil
+}
@@ -0,0 +1,219 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/pdfgen"
+)
+
+// defaultDisclaimer is printed on exported conversations when the deployment doesn't configure one
+const defaultDisclaimer = "Answers in this conversation were generated by an AI assistant to help explain a medical report. " +
+	"They are not a diagnosis or medical advice."
+
+// ChatTranscript is a report's conversation prepared for export
+type ChatTranscript struct {
+	Report     *models.Report
+	Messages   []*models.ChatMessage
+	Disclaimer string
+	ExportedAt time.Time
+	Location   *time.Location  // Zone timestamps are printed in
+	Analysis   *AnalysisResult // Nil when the report has no readable analysis
+	Branding   *ExportBranding // Nil prints the default layout without organization branding
+}
+
+// GetTranscript returns the full conversation about a report owned by the caller, timestamped in their zone
+func (cs *ChatService) GetTranscript(user *models.User, reportID int) (*ChatTranscript, error) {
+	report, err := getOwnedReport(cs.reportRepo, user.ID, reportID)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := cs.chatRepo.GetChatHistory(reportID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+
+	disclaimer := cs.disclaimer
+	if disclaimer == "" {
+		disclaimer = defaultDisclaimer
+	}
+
+	transcript := &ChatTranscript{
+		Report:     report,
+		Messages:   messages,
+		Disclaimer: disclaimer,
+		ExportedAt: time.Now().In(user.Location()),
+		Location:   user.Location(),
+	}
+	if report.SimplifiedSummary != "" {
+		// Decision: An unreadable analysis only drops the analysis sections; the conversation still exports
+		transcript.Analysis, _ = ParseStoredAnalysis(report.SimplifiedSummary)
+	}
+	return transcript, nil
+}
+
+// sections returns the export sections to print, in order
+func (t *ChatTranscript) sections() []string {
+	if t.Branding != nil && len(t.Branding.Sections) > 0 {
+		return t.Branding.Sections
+	}
+	return defaultExportSections
+}
+
+// RenderTranscriptMarkdown formats a transcript as a markdown document
+func RenderTranscriptMarkdown(transcript *ChatTranscript) []byte {
+	var b strings.Builder
+
+	if branding := transcript.Branding; branding != nil {
+		fmt.Fprintf(&b, "**%s**\n", branding.OrganizationName)
+		if branding.ContactInfo != "" {
+			fmt.Fprintf(&b, "%s\n", branding.ContactInfo)
+		}
+		b.WriteString("\n")
+	}
+
+	fmt.Fprintf(&b, "# Conversation about %s\n\n", transcript.Report.OriginalFilename)
+	fmt.Fprintf(&b, "> %s\n", transcript.Disclaimer)
+
+	for _, section := range transcript.sections() {
+		switch section {
+		case ExportSectionReportDetails:
+			fmt.Fprintf(&b, "\n- **Report uploaded:** %s\n", transcript.localTime(transcript.Report.UploadDate).Format("2006-01-02"))
+			fmt.Fprintf(&b, "- **Exported:** %s\n", transcript.ExportedAt.Format("2006-01-02 15:04 MST"))
+		case ExportSectionConversation:
+			if len(transcript.Messages) == 0 {
+				b.WriteString("\n_No questions have been asked about this report._\n")
+			}
+			for i, message := range transcript.Messages {
+				fmt.Fprintf(&b, "\n## Question %d\n\n", i+1)
+				fmt.Fprintf(&b, "_%s%s_\n\n", transcript.localTime(message.CreatedAt).Format("2006-01-02 15:04 MST"), editedNote(message))
+				fmt.Fprintf(&b, "**Patient:** %s\n\n", message.UserMessage)
+				fmt.Fprintf(&b, "**Assistant:** %s\n", message.AIResponse)
+			}
+		default:
+			heading, paragraphs := transcript.analysisSection(section)
+			if heading == "" {
+				continue
+			}
+			fmt.Fprintf(&b, "\n## %s\n\n", heading)
+			for _, paragraph := range paragraphs {
+				fmt.Fprintf(&b, "%s\n", paragraph)
+			}
+		}
+	}
+
+	if transcript.Branding != nil && transcript.Branding.FooterText != "" {
+		fmt.Fprintf(&b, "\n---\n\n_%s_\n", transcript.Branding.FooterText)
+	}
+
+	return []byte(b.String())
+}
+
+// RenderTranscriptPDF formats a transcript as a printable PDF
+func RenderTranscriptPDF(transcript *ChatTranscript) []byte {
+	doc := pdfgen.New()
+
+	if branding := transcript.Branding; branding != nil {
+		doc.Image(branding.Logo, branding.LogoWidth, branding.LogoHeight, 180, 60)
+		doc.Label(branding.OrganizationName)
+		if branding.ContactInfo != "" {
+			doc.Paragraph(branding.ContactInfo)
+		}
+		if branding.FooterText != "" {
+			doc.SetFooter(branding.FooterText)
+		}
+		doc.Spacer()
+	}
+
+	doc.Heading("Conversation about " + transcript.Report.OriginalFilename)
+	doc.Paragraph(transcript.Disclaimer)
+
+	for _, section := range transcript.sections() {
+		switch section {
+		case ExportSectionReportDetails:
+			doc.Spacer()
+			doc.Paragraph("Report uploaded: " + transcript.localTime(transcript.Report.UploadDate).Format("2006-01-02"))
+			doc.Paragraph("Exported: " + transcript.ExportedAt.Format("2006-01-02 15:04 MST"))
+		case ExportSectionConversation:
+			if len(transcript.Messages) == 0 {
+				doc.Spacer()
+				doc.Paragraph("No questions have been asked about this report.")
+			}
+			for i, message := range transcript.Messages {
+				doc.Heading(fmt.Sprintf("Question %d", i+1))
+				doc.Paragraph(transcript.localTime(message.CreatedAt).Format("2006-01-02 15:04 MST") + editedNote(message))
+				doc.Label("Patient:")
+				doc.Paragraph(message.UserMessage)
+				doc.Label("Assistant:")
+				doc.Paragraph(message.AIResponse)
+			}
+		default:
+			heading, paragraphs := transcript.analysisSection(section)
+			if heading == "" {
+				continue
+			}
+			doc.Heading(heading)
+			for _, paragraph := range paragraphs {
+				doc.Paragraph(paragraph)
+			}
+		}
+	}
+
+	return doc.Bytes()
+}
+
+// analysisSection returns the heading and paragraphs of an analysis-backed section, or an empty
+// heading when the report has nothing to print there
+func (t *ChatTranscript) analysisSection(section string) (string, []string) {
+	if t.Analysis == nil {
+		return "", nil
+	}
+	switch section {
+	case ExportSectionSummary:
+		if t.Analysis.SimpleSummary != "" {
+			return "Summary", []string{t.Analysis.SimpleSummary}
+		}
+	case ExportSectionClinicalSummary:
+		if t.Analysis.Summary != "" {
+			return "Clinical summary", []string{t.Analysis.Summary}
+		}
+	case ExportSectionKeyFindings:
+		if len(t.Analysis.KeyFindings) > 0 {
+			return "Key findings", bulleted(t.Analysis.KeyFindings)
+		}
+	case ExportSectionRecommendations:
+		if len(t.Analysis.Recommendations) > 0 {
+			return "Recommendations", bulleted(t.Analysis.Recommendations)
+		}
+	}
+	return "", nil
+}
+
+// bulleted prefixes each item with a dash
+func bulleted(items []string) []string {
+	lines := make([]string, len(items))
+	for i, item := range items {
+		lines[i] = "- " + item
+	}
+	return lines
+}
+
+// localTime converts t to the transcript's zone, defaulting to UTC
+func (t *ChatTranscript) localTime(ts time.Time) time.Time {
+	if t.Location == nil {
+		return ts.UTC()
+	}
+	return ts.In(t.Location)
+}
+
+// editedNote flags answers that were edited or regenerated so readers know they differ from the original
+func editedNote(message *models.ChatMessage) string {
+	if message.Version > 1 {
+		return fmt.Sprintf(" (revised, version %d)", message.Version)
+	}
+	return ""
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+)
+
+// estimateTokens approximates the model token count of text
+// Decision: ~4 characters per token is close enough for budgeting and avoids a tokenizer round trip
+func estimateTokens(text string) int {
+	return len(text)/4 + 1
+}
+
+// estimateTurnTokens approximates the prompt size of chat turns
+func estimateTurnTokens(turns []*models.ChatMessage) int {
+	total := 0
+	for _, turn := range turns {
+		total += estimateTokens(turn.UserMessage) + estimateTokens(turn.AIResponse)
+	}
+	return total
+}
+
+// compactHistory splits chat turns into a summary of older turns and the recent turns to send verbatim
+// Decision: The summary is stored per report and extended only with turns it hasn't seen, so each
+// question costs at most one small summarization call once a conversation outgrows the budget
+func (cs *ChatService) compactHistory(ctx context.Context, reportID int, turns []*models.ChatMessage) (string, []*models.ChatMessage, error) {
+	if cs.historyTokens <= 0 || estimateTurnTokens(turns) <= cs.historyTokens {
+		return "", turns, nil
+	}
+
+	// Keep the configured number of recent turns, fewer if they alone exceed the budget
+	keep := min(max(cs.recentTurns, 1), len(turns))
+	for keep > 1 && estimateTurnTokens(turns[len(turns)-keep:]) > cs.historyTokens {
+		keep--
+	}
+	older, recent := turns[:len(turns)-keep], turns[len(turns)-keep:]
+	if len(older) == 0 {
+		return "", recent, nil
+	}
+	throughID := older[len(older)-1].ID
+
+	stored, err := cs.summaryRepo.GetByReportID(reportID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	switch {
+	case stored != nil && stored.ThroughMessageID == throughID:
+		return stored.Summary, recent, nil
+
+	case stored != nil && stored.ThroughMessageID > throughID:
+		// Revising an early turn: the stored summary covers later turns, so summarize this prefix without saving it
+		summary, err := cs.responder.SummarizeConversation(ctx, "", older)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to summarize chat history", "report_id", reportID, "error", err)
+			return "", recent, nil
+		}
+		return summary, recent, nil
+	}
+
+	previous, unsummarized := "", older
+	if stored != nil {
+		previous = stored.Summary
+		unsummarized = nil
+		for _, turn := range older {
+			if turn.ID > stored.ThroughMessageID {
+				unsummarized = append(unsummarized, turn)
+			}
+		}
+	}
+
+	// Decision: If summarization fails, answer from the recent turns rather than failing the question
+	summary, err := cs.responder.SummarizeConversation(ctx, previous, unsummarized)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to summarize chat history", "report_id", reportID, "error", err)
+		return previous, recent, nil
+	}
+
+	if err := cs.summaryRepo.Save(&models.ChatSummary{ReportID: reportID, Summary: summary, ThroughMessageID: throughID}); err != nil {
+		return "", nil, err
+	}
+
+	return summary, recent, nil
+}
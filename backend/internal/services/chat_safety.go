@@ -0,0 +1,226 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+)
+
+// Levels of chat answer screening
+const (
+	SafetyOff      = "off"
+	SafetyStandard = "standard" // Removes instructions and definite claims
+	SafetyStrict   = "strict"   // Also removes hedged diagnoses, any dose amounts, and unescalated emergency symptoms
+)
+
+// Categories of content the safety filter takes out of an answer
+const (
+	SafetyDosage    = "dosage"
+	SafetyDiagnosis = "diagnosis"
+	SafetyEmergency = "emergency"
+)
+
+// IsValidSafetyCategory reports whether category is one the filter records
+func IsValidSafetyCategory(category string) bool {
+	return category == SafetyDosage || category == SafetyDiagnosis || category == SafetyEmergency
+}
+
+// Phrase lists shared by the rules below
+const (
+	medicineWords  = `(?:medicines?|medications?|meds|tablets?|pills?|capsules?|doses?|dosage|insulin|metformin|statins?|atorvastatin|aspirin|ibuprofen|paracetamol|acetaminophen|levothyroxine|thyroxine|antibiotics?|supplements?|iron|vitamin\s+[a-z0-9]+)`
+	doseAmount     = `\d+(?:\.\d+)?\s?(?:mg|mcg|µg|ml|iu|units?|tablets?|pills?|capsules?|drops?)(?:[^/\w]|$)` // Not mg/dL and other lab units
+	conditionWords = `(?:diabetes|prediabetes|cancer|an(?:a)?emia|hypothyroidism|hyperthyroidism|hypertension|fatty\s+liver|leuk(?:a)?emia|lymphoma|tuberculosis|dengue|malaria|typhoid|hepatitis|cirrhosis|pcos|an?\s+infection|[a-z]+\s+(?:disease|disorder|syndrome|failure|deficiency|infection))`
+	conditionAdjs  = `(?:diabetic|prediabetic|an(?:a)?emic|hypertensive|hypothyroid|hyperthyroid)`
+	emergencyWords = `(?:chest\s+pain|chest\s+tightness|shortness\s+of\s+breath|trouble\s+breathing|difficulty\s+breathing|can'?t\s+breathe|cannot\s+breathe|faint(?:ed|ing)|passed\s+out|unconscious|seizures?|stroke|slurred\s+speech|sudden\s+weakness|severe\s+bleeding|(?:coughing|vomiting)\s+(?:up\s+)?blood|suicidal|overdose|anaphyla(?:xis|ctic))`
+	negationWords  = `\b(?:if|whether|not|don'?t|doesn'?t|isn'?t|no|without)\b`
+)
+
+// safetyRule flags a sentence that matches pattern, also matches also (when set), and doesn't match unless (when set)
+type safetyRule struct {
+	category string
+	strict   bool // Only applied at the strict level
+	pattern  *regexp.Regexp
+	also     *regexp.Regexp
+	unless   *regexp.Regexp
+}
+
+func (r safetyRule) matches(sentence string) bool {
+	return r.pattern.MatchString(sentence) &&
+		(r.also == nil || r.also.MatchString(sentence)) &&
+		(r.unless == nil || !r.unless.MatchString(sentence))
+}
+
+// safetyRules are checked sentence by sentence, case-insensitively
+// Decision: Phrase rules rather than a second model call, so screening is instant, free, and predictable;
+// they catch the common phrasings, and the prompt's own instructions remain the first line of defence
+var safetyRules = []safetyRule{
+	// Dosing: telling the user to take, change, or stop a medicine
+	{category: SafetyDosage, pattern: regexp.MustCompile(`(?i)\b(?:take|taking|start|starting|increase|decrease|reduce|lower|raise|double|halve|skip|stop|stopping|switch)\b[^.!?]{0,40}` + doseAmount),
+		unless: regexp.MustCompile(`(?i)\b(?:as\s+prescribed|your\s+doctor|your\s+pharmacist)\b`)},
+	{category: SafetyDosage, pattern: regexp.MustCompile(`(?i)\b(?:increase|decrease|reduce|lower|raise|double|halve|adjust|change|stop|skip|discontinue)\s+(?:your|the)\s+(?:[a-z]+\s+){0,2}` + medicineWords + `\b`),
+		unless: regexp.MustCompile(`(?i)\b(?:doctor|pharmacist|prescrib\w*)\b`)},
+	{category: SafetyDosage, pattern: regexp.MustCompile(`(?i)\b(?:you\s+should|you\s+can|you\s+could|you\s+may\s+want\s+to|i\s+recommend|i\s+suggest|try)\s+(?:to\s+)?(?:start|starting|take|taking|stop|stopping|begin)\b[^.!?]{0,30}\b` + medicineWords + `\b`),
+		unless: regexp.MustCompile(`(?i)\b(?:as\s+prescribed|doctor|pharmacist)\b`)},
+	{category: SafetyDosage, strict: true, pattern: regexp.MustCompile(`(?i)` + doseAmount)},
+	{category: SafetyDosage, strict: true, pattern: regexp.MustCompile(`(?i)\b` + medicineWords + `\b[^.!?]{0,40}\b(?:(?:once|twice|three\s+times|\d+\s+times)\s+(?:a|per)\s+day|every\s+\d+\s+hours|daily|at\s+bedtime)\b`)},
+
+	// Diagnosis: stating the user has a condition
+	{category: SafetyDiagnosis, pattern: regexp.MustCompile(`(?i)\byou\s*(?:definitely\s+|clearly\s+|certainly\s+|now\s+)?(?:'ve\s+got|have\s+got|have\s+been\s+diagnosed\s+with|have|are\s+suffering\s+from|suffer\s+from|are\s+diagnosed\s+with)\s+(?:a\s+|an\s+)?` + conditionWords + `\b`),
+		unless: regexp.MustCompile(`(?i)` + negationWords)},
+	{category: SafetyDiagnosis, pattern: regexp.MustCompile(`(?i)\b(?:this|these|that|the|your)\s+(?:results?|report|values?|levels?|numbers?|findings?)\s+(?:confirms?|proves?|means?|shows?)\s+(?:that\s+)?you\s+(?:have|are)\b`),
+		unless: regexp.MustCompile(`(?i)` + negationWords)},
+	{category: SafetyDiagnosis, pattern: regexp.MustCompile(`(?i)\byou\s+are\s+(?:definitely\s+|clearly\s+|now\s+)?` + conditionAdjs + `\b`),
+		unless: regexp.MustCompile(`(?i)` + negationWords)},
+	{category: SafetyDiagnosis, strict: true, pattern: regexp.MustCompile(`(?i)\byou\s+(?:probably|likely|most\s+likely|may|might|could|possibly)\s+(?:have|be\s+suffering\s+from|be\s+` + conditionAdjs + `)\b`)},
+	{category: SafetyDiagnosis, strict: true, pattern: regexp.MustCompile(`(?i)\b(?:suggests?|indicates?|points?\s+to|consistent\s+with)\s+(?:that\s+)?(?:you\s+have\s+)?` + conditionWords + `\b`)},
+
+	// Emergencies: advice that delays care or manages emergency symptoms at home
+	{category: SafetyEmergency, pattern: regexp.MustCompile(`(?i)\b(?:no\s+need|don'?t\s+need|do\s+not\s+need|needn'?t|not\s+necessary|unnecessary)\s+(?:for\s+you\s+)?to\s+(?:go\s+to|visit|call|see|rush\s+to)\s+(?:the\s+|a\s+|an\s+)?(?:hospital|er|emergency|doctor|ambulance|clinic|\d{3})\b`)},
+	{category: SafetyEmergency, pattern: regexp.MustCompile(`(?i)\b(?:wait\s+(?:it\s+out|and\s+see|until\s+(?:the\s+)?(?:morning|tomorrow))|sleep\s+it\s+off)\b`)},
+	{category: SafetyEmergency, pattern: regexp.MustCompile(`(?i)\b` + emergencyWords + `\b`),
+		also: regexp.MustCompile(`(?i)\b(?:at\s+home|home\s+remed\w*|rest|lie\s+down|drink\s+(?:some\s+)?water|take\s+an?\s+(?:aspirin|painkiller|antacid)|(?:should|will)\s+pass|nothing\s+to\s+worry|not\s+serious)\b`)},
+	{category: SafetyEmergency, strict: true, pattern: regexp.MustCompile(`(?i)\b` + emergencyWords + `\b`),
+		unless: regexp.MustCompile(`(?i)\b(?:emergency|ambulance|hospital|call|immediately|right\s+away|urgent\w*)\b`)},
+}
+
+// SafetyResult is a screened answer and what was taken out of it
+type SafetyResult struct {
+	Answer     string
+	Categories []string // In the order first found; empty when the answer passed unchanged
+	Removed    []string // Sentences taken out, as written by the model
+}
+
+// Intervened reports whether the filter changed the answer
+func (r SafetyResult) Intervened() bool {
+	return len(r.Categories) > 0
+}
+
+// SafetyFilter screens chat answers after generation, replacing unsafe sentences with escalation guidance
+type SafetyFilter struct {
+	level           string
+	emergencyNumber string
+}
+
+// NewSafetyFilter creates a filter at the configured level; an empty level means standard
+func NewSafetyFilter(cfg config.ChatSafetyConfig) (*SafetyFilter, error) {
+	level := strings.ToLower(cfg.Level)
+	switch level {
+	case "":
+		level = SafetyStandard
+	case SafetyOff, SafetyStandard, SafetyStrict:
+	default:
+		return nil, fmt.Errorf("unknown chat safety level %q (expected off, standard or strict)", cfg.Level)
+	}
+
+	number := cfg.EmergencyNumber
+	if number == "" {
+		number = "112"
+	}
+	return &SafetyFilter{level: level, emergencyNumber: number}, nil
+}
+
+// Level returns the filter's strictness
+func (sf *SafetyFilter) Level() string {
+	return sf.level
+}
+
+// Screen removes sentences giving dosing instructions or diagnoses and appends guidance to ask a clinician
+// Decision: Anything touching an emergency replaces the whole answer - the rest of it was written without
+// the urgency the situation needs
+func (sf *SafetyFilter) Screen(answer string) SafetyResult {
+	result := SafetyResult{Answer: answer}
+	if sf.level == SafetyOff {
+		return result
+	}
+
+	var kept strings.Builder
+	found := map[string]bool{}
+	for _, sentence := range splitSentences(answer) {
+		category := sf.classify(sentence)
+		if category == "" {
+			kept.WriteString(sentence)
+			continue
+		}
+		if !found[category] {
+			found[category] = true
+			result.Categories = append(result.Categories, category)
+		}
+		result.Removed = append(result.Removed, strings.TrimSpace(sentence))
+	}
+
+	if !result.Intervened() {
+		return result
+	}
+	if found[SafetyEmergency] {
+		result.Answer = sf.guidance(SafetyEmergency)
+		return result
+	}
+
+	var parts []string
+	if text := strings.TrimSpace(kept.String()); text != "" {
+		parts = append(parts, text)
+	}
+	for _, category := range result.Categories {
+		parts = append(parts, sf.guidance(category))
+	}
+	result.Answer = strings.Join(parts, "\n\n")
+	return result
+}
+
+// classify returns the category of the first rule the sentence breaks, or ""
+func (sf *SafetyFilter) classify(sentence string) string {
+	for _, rule := range safetyRules {
+		if rule.strict && sf.level != SafetyStrict {
+			continue
+		}
+		if rule.matches(sentence) {
+			return rule.category
+		}
+	}
+	return ""
+}
+
+// guidance is the escalation text that stands in for removed content
+func (sf *SafetyFilter) guidance(category string) string {
+	switch category {
+	case SafetyDosage:
+		return "I can't advise on starting, stopping, or changing a medicine or its dose. Please ask your doctor or pharmacist, who can check it against your full history."
+	case SafetyDiagnosis:
+		return "I can't diagnose a condition from a report. Your doctor can tell you what these results mean for you and whether you need further tests."
+	default:
+		return fmt.Sprintf("If you have symptoms like chest pain, trouble breathing, fainting, sudden weakness or slurred speech, or severe bleeding, "+
+			"call %s or go to the nearest emergency department now. Please don't wait to see whether it passes.", sf.emergencyNumber)
+	}
+}
+
+// splitSentences cuts text after sentence-ending punctuation and at line breaks, keeping the separators
+// so kept sentences join back into the original layout
+func splitSentences(text string) []string {
+	var sentences []string
+	start := 0
+	for i := 0; i < len(text); i++ {
+		end := -1
+		switch text[i] {
+		case '\n':
+			end = i + 1
+		case '.', '!', '?':
+			if i+1 == len(text) || text[i+1] == ' ' || text[i+1] == '\t' || text[i+1] == '\n' {
+				end = i + 1
+				for end < len(text) && (text[end] == ' ' || text[end] == '\t') {
+					end++
+				}
+				i = end - 1
+			}
+		}
+		if end > 0 {
+			sentences = append(sentences, text[start:end])
+			start = end
+		}
+	}
+	if start < len(text) {
+		sentences = append(sentences, text[start:])
+	}
+	return sentences
+}
@@ -0,0 +1,394 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/pdfgen"
+)
+
+// maxClaimFieldLength bounds the insurer, policy number, and claim reference printed on the cover sheet
+const maxClaimFieldLength = 100
+
+// claimDisclaimer is printed on the cover sheet and the summary
+const claimDisclaimer = "The summary and itemized findings were generated by software from the attached original report " +
+	"to help explain it. They are not a diagnosis. The original report is the authoritative record."
+
+// ClaimDetails are what the claimant wants printed on the cover sheet; all optional
+type ClaimDetails struct {
+	Insurer        string
+	PolicyNumber   string
+	ClaimReference string
+}
+
+// claimEntry is one file of a claim package
+type claimEntry struct {
+	name string
+	data []byte // Nil for an original file, which is copied from storage
+	path string
+	size int64
+}
+
+// ClaimPackageService bundles reports for insurance reimbursement claims
+type ClaimPackageService struct {
+	reportRepo  models.ReportRepository
+	partRepo    models.ReportPartRepository
+	claimRepo   models.ClaimPackageRepository
+	fileStorage *FileStorage
+}
+
+// NewClaimPackageService creates a new claim package service
+func NewClaimPackageService(
+	reportRepo models.ReportRepository,
+	partRepo models.ReportPartRepository,
+	claimRepo models.ClaimPackageRepository,
+	fileStorage *FileStorage,
+) *ClaimPackageService {
+	return &ClaimPackageService{
+		reportRepo:  reportRepo,
+		partRepo:    partRepo,
+		claimRepo:   claimRepo,
+		fileStorage: fileStorage,
+	}
+}
+
+// Generate builds a ZIP of a cover sheet, summary PDF, itemized findings CSV, and the report's original files,
+// stores it, and replaces any earlier package for the report
+// Decision: A ZIP of separate files rather than one merged PDF, because insurers' portals ask for the lab's
+// original document unaltered; our PDFs go alongside it, never around it
+func (cs *ClaimPackageService) Generate(user *models.User, reportID int, details ClaimDetails) (*models.ClaimPackage, error) {
+	details.Insurer = strings.TrimSpace(details.Insurer)
+	details.PolicyNumber = strings.TrimSpace(details.PolicyNumber)
+	details.ClaimReference = strings.TrimSpace(details.ClaimReference)
+	for _, field := range []struct{ value, label string }{
+		{details.Insurer, "insurer"},
+		{details.PolicyNumber, "policy_number"},
+		{details.ClaimReference, "claim_reference"},
+	} {
+		if len([]rune(field.value)) > maxClaimFieldLength {
+			return nil, errors.NewValidationError(fmt.Sprintf("%s must be at most %d characters", field.label, maxClaimFieldLength))
+		}
+	}
+
+	report, err := getOwnedReport(cs.reportRepo, user.ID, reportID)
+	if err != nil {
+		return nil, err
+	}
+	if report.ProcessingStatus != "completed" {
+		return nil, errors.ErrReportNotProcessed
+	}
+	analysis, err := ParseStoredAnalysis(report.SimplifiedSummary)
+	if err != nil {
+		return nil, errors.ErrAIProcessingFailed
+	}
+	parts, err := cs.partRepo.ListByReport(report.ID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+
+	generatedAt := time.Now().In(user.Location())
+	entries := claimOriginals(report, parts)
+	entries = append([]claimEntry{
+		{name: "2-summary.pdf", data: renderClaimSummaryPDF(report, analysis, generatedAt)},
+		{name: "3-itemized-findings.csv", data: renderFindingsCSV(analysis.HealthMetrics)},
+	}, entries...)
+	cover := renderClaimCoverPDF(user, report, details, entries, generatedAt)
+	entries = append([]claimEntry{{name: "1-cover-sheet.pdf", data: cover}}, entries...)
+
+	filePath, err := cs.fileStorage.NewFilePath(user.ID, "claim.zip")
+	if err != nil {
+		return nil, errors.ErrClaimPackageFailed
+	}
+	size, checksum, err := cs.writeZip(filePath, entries, generatedAt)
+	if err != nil {
+		slog.Error("Failed to write claim package", "report_id", report.ID, "error", err)
+		cs.removeFile(filePath)
+		return nil, errors.ErrClaimPackageFailed
+	}
+
+	pkg := &models.ClaimPackage{
+		UserID:         user.ID,
+		ReportID:       report.ID,
+		FilePath:       filePath,
+		FileSize:       size,
+		Checksum:       checksum,
+		Insurer:        details.Insurer,
+		PolicyNumber:   details.PolicyNumber,
+		ClaimReference: details.ClaimReference,
+	}
+	if err := cs.claimRepo.Save(pkg); err != nil {
+		cs.removeFile(filePath)
+		return nil, errors.ErrDatabaseConnection
+	}
+	return pkg, nil
+}
+
+// Get returns the stored package of one of the user's reports
+func (cs *ClaimPackageService) Get(userID, reportID int) (*models.ClaimPackage, error) {
+	if _, err := getOwnedReport(cs.reportRepo, userID, reportID); err != nil {
+		return nil, err
+	}
+	pkg, err := cs.claimRepo.GetByReport(reportID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	// Decision: A package made before the report was transferred names the previous owner; it is not handed over
+	if pkg == nil || pkg.UserID != userID {
+		return nil, errors.ErrRecordNotFound
+	}
+	return pkg, nil
+}
+
+// Open opens the stored package of one of the user's reports; the caller closes it
+func (cs *ClaimPackageService) Open(userID, reportID int) (*models.ClaimPackage, *os.File, error) {
+	pkg, err := cs.Get(userID, reportID)
+	if err != nil {
+		return nil, nil, err
+	}
+	file, err := cs.fileStorage.Open(pkg.FilePath)
+	if err != nil {
+		slog.Error("Failed to open claim package", "package_id", pkg.ID, "error", err)
+		return nil, nil, errors.ErrRecordNotFound
+	}
+	return pkg, file, nil
+}
+
+// writeZip writes the entries to path and returns the archive's size and hex SHA-256
+func (cs *ClaimPackageService) writeZip(path string, entries []claimEntry, modified time.Time) (int64, string, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0640)
+	if err != nil {
+		return 0, "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	archive := zip.NewWriter(io.MultiWriter(file, hash))
+	for _, entry := range entries {
+		w, err := archive.CreateHeader(&zip.FileHeader{Name: entry.name, Method: zip.Deflate, Modified: modified})
+		if err != nil {
+			return 0, "", err
+		}
+		if entry.data != nil {
+			if _, err := w.Write(entry.data); err != nil {
+				return 0, "", err
+			}
+			continue
+		}
+		if err := cs.copyStored(w, entry.path); err != nil {
+			return 0, "", fmt.Errorf("failed to add %s: %w", entry.name, err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return 0, "", err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, "", err
+	}
+	if err := file.Sync(); err != nil {
+		return 0, "", err
+	}
+	return info.Size(), hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// copyStored copies a stored upload into w
+func (cs *ClaimPackageService) copyStored(w io.Writer, path string) error {
+	src, err := cs.fileStorage.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	_, err = io.Copy(w, src)
+	return err
+}
+
+// removeFile deletes a package that won't be stored, logging rather than failing
+func (cs *ClaimPackageService) removeFile(path string) {
+	if err := cs.fileStorage.Remove(path); err != nil && !os.IsNotExist(err) {
+		slog.Warn("Failed to remove claim package", "error", err)
+	}
+}
+
+// claimOriginals lists the report's uploaded files in page order
+// Decision: Entries are named by position and extension only; uploaded names can identify patients or
+// carry characters insurers' portals reject, so they are printed on the cover sheet instead
+func claimOriginals(report *models.Report, parts []*models.ReportPart) []claimEntry {
+	if len(parts) == 0 {
+		return []claimEntry{{name: "4-original" + claimExtension(report.OriginalFilename), path: report.FilePath, size: report.FileSize}}
+	}
+	entries := []claimEntry{{name: "4-original-part-1" + claimExtension(report.OriginalFilename), path: report.FilePath, size: report.FileSize}}
+	for _, part := range parts {
+		entries = append(entries, claimEntry{
+			name: fmt.Sprintf("4-original-part-%d%s", part.PartNumber, claimExtension(part.OriginalFilename)),
+			path: part.FilePath,
+			size: part.FileSize,
+		})
+	}
+	return entries
+}
+
+// claimExtension returns the lowercase extension of an uploaded name, or nothing if it isn't plain ASCII
+func claimExtension(name string) string {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, r := range ext[min(1, len(ext)):] {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			return ""
+		}
+	}
+	return ext
+}
+
+// renderClaimCoverPDF prints who is claiming, for which report, and what the package contains
+func renderClaimCoverPDF(user *models.User, report *models.Report, details ClaimDetails, entries []claimEntry, generatedAt time.Time) []byte {
+	doc := pdfgen.New()
+	doc.Heading("Medical report claim package")
+
+	doc.Spacer()
+	doc.Label("Claimant")
+	doc.Paragraph(user.FullName)
+	doc.Paragraph(user.Email)
+	for _, field := range []struct{ label, value string }{
+		{"Insurer", details.Insurer},
+		{"Policy number", details.PolicyNumber},
+		{"Claim reference", details.ClaimReference},
+	} {
+		if field.value != "" {
+			doc.Paragraph(field.label + ": " + field.value)
+		}
+	}
+
+	doc.Spacer()
+	doc.Label("Report")
+	if report.Title != "" {
+		doc.Paragraph("Title: " + report.Title)
+	}
+	doc.Paragraph("Original file: " + report.OriginalFilename)
+	if report.ReportDate != nil {
+		doc.Paragraph("Test date: " + report.ReportDate.Format("2006-01-02"))
+	}
+	doc.Paragraph("Uploaded: " + report.UploadDate.In(generatedAt.Location()).Format("2006-01-02"))
+
+	doc.Heading("Contents")
+	for _, entry := range entries {
+		size := entry.size
+		if entry.data != nil {
+			size = int64(len(entry.data))
+		}
+		doc.Paragraph(fmt.Sprintf("- %s (%s)", entry.name, formatFileSize(size)))
+	}
+
+	doc.Spacer()
+	doc.Paragraph("Prepared " + generatedAt.Format("2006-01-02 15:04 MST"))
+	doc.Paragraph(claimDisclaimer)
+	return doc.Bytes()
+}
+
+// renderClaimSummaryPDF prints the analysis with every metric itemized
+func renderClaimSummaryPDF(report *models.Report, analysis *AnalysisResult, generatedAt time.Time) []byte {
+	doc := pdfgen.New()
+	doc.Heading("Report summary: " + reportDisplayName(report))
+	doc.Paragraph(claimDisclaimer)
+
+	if analysis.Summary != "" {
+		doc.Heading("Clinical summary")
+		doc.Paragraph(analysis.Summary)
+	}
+	if analysis.SimpleSummary != "" {
+		doc.Heading("Summary")
+		doc.Paragraph(analysis.SimpleSummary)
+	}
+
+	doc.Heading("Itemized findings")
+	if len(analysis.HealthMetrics) == 0 {
+		doc.Paragraph("No measured values were found in the report.")
+	}
+	for _, metric := range analysis.HealthMetrics {
+		line := fmt.Sprintf("- %s: %s", metric.Name, strings.TrimSpace(metric.GetValueAsString()+" "+metric.Unit))
+		if metric.RangeMin != 0 || metric.RangeMax != 0 {
+			line += fmt.Sprintf(" (reference %s-%s)", formatRangeBound(metric.RangeMin), formatRangeBound(metric.RangeMax))
+		}
+		if metric.Status != "" {
+			line += ", " + metric.Status
+		}
+		doc.Paragraph(line)
+	}
+	if len(analysis.KeyFindings) > 0 {
+		doc.Heading("Key findings")
+		for _, line := range bulleted(analysis.KeyFindings) {
+			doc.Paragraph(line)
+		}
+	}
+	if len(analysis.Recommendations) > 0 {
+		doc.Heading("Recommendations")
+		for _, line := range bulleted(analysis.Recommendations) {
+			doc.Paragraph(line)
+		}
+	}
+
+	doc.SetFooter("Prepared " + generatedAt.Format("2006-01-02 15:04 MST"))
+	return doc.Bytes()
+}
+
+// renderFindingsCSV lists every metric as one row, for insurers that key claims into their own systems
+func renderFindingsCSV(metrics []HealthMetric) []byte {
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	w.Write([]string{"test", "value", "unit", "reference_min", "reference_max", "status"})
+	for _, metric := range metrics {
+		low, high := "", ""
+		if metric.RangeMin != 0 || metric.RangeMax != 0 {
+			low, high = formatRangeBound(metric.RangeMin), formatRangeBound(metric.RangeMax)
+		}
+		w.Write([]string{csvSafe(metric.Name), csvSafe(metric.GetValueAsString()), csvSafe(metric.Unit), low, high, metric.Status})
+	}
+	w.Flush()
+	return b.Bytes()
+}
+
+// csvSafe stops spreadsheet software from running model-written text as a formula
+// Decision: Negative numbers stay as they are; only text that starts like a formula is quoted
+func csvSafe(value string) string {
+	if value == "" {
+		return value
+	}
+	switch value[0] {
+	case '=', '+', '@', '\t', '\r':
+		return "'" + value
+	case '-':
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return "'" + value
+		}
+	}
+	return value
+}
+
+// formatRangeBound prints a reference range bound without trailing zeros
+func formatRangeBound(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// formatFileSize prints a size in bytes, KB, or MB
+func formatFileSize(size int64) string {
+	switch {
+	case size >= 1024*1024:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1024*1024))
+	case size >= 1024:
+		return fmt.Sprintf("%.1f KB", float64(size)/1024)
+	default:
+		return fmt.Sprintf("%d bytes", size)
+	}
+}
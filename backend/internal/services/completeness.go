@@ -0,0 +1,195 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Analyte is one test a lab panel is expected to contain
+type Analyte struct {
+	Name     string   // As written mid-sentence in hints
+	Keywords []string // Metric names that count as this analyte; same syntax as condition keywords
+	Exclude  []string // Metric names matching these never count, e.g. "glycated hemoglobin" for hemoglobin
+}
+
+// Panel is a group of tests usually ordered together
+type Panel struct {
+	Key      string
+	Name     string
+	Detect   int // Analytes that must be found before the report counts as containing the panel
+	Analytes []Analyte
+}
+
+// panelCatalog lists the panels completeness is judged against, in display order
+// Decision: A fixed catalog like the condition keywords, so every prompt version and stored analysis scores alike
+// without a model call; larger panels need two analytes to be detected so one stray value doesn't demand a full panel
+var panelCatalog = []Panel{
+	{Key: "cbc", Name: "Complete blood count", Detect: 2, Analytes: []Analyte{
+		{Name: "hemoglobin", Keywords: []string{"hemoglobin", "haemoglobin", "hb", "hgb"},
+			Exclude: []string{"glycated", "glycosylated", "a1c", "corpuscular", "mch*"}},
+		{Name: "hematocrit", Keywords: []string{"hematocrit", "haematocrit", "hct", "pcv", "packed cell volume"}},
+		{Name: "RBC count", Keywords: []string{"rbc", "red blood cell*", "erythrocyte*"},
+			Exclude: []string{"sedimentation", "esr", "distribution"}},
+		{Name: "WBC count", Keywords: []string{"wbc", "white blood cell*", "leukocyte*", "leucocyte*", "tlc"}},
+		{Name: "platelet count", Keywords: []string{"platelet*", "plt", "thrombocyte*"},
+			Exclude: []string{"mean platelet volume", "mpv", "distribution"}},
+		{Name: "MCV", Keywords: []string{"mcv", "mean corpuscular volume", "mean cell volume"}},
+		{Name: "MCH", Keywords: []string{"mch", "mean corpuscular hemoglobin", "mean corpuscular haemoglobin"},
+			Exclude: []string{"concentration"}},
+		{Name: "MCHC", Keywords: []string{"mchc", "mean corpuscular hemoglobin concentration",
+			"mean corpuscular haemoglobin concentration"}},
+	}},
+	{Key: "lipid", Name: "Lipid panel", Detect: 2, Analytes: []Analyte{
+		{Name: "total cholesterol", Keywords: []string{"cholesterol"},
+			Exclude: []string{"hdl", "ldl", "vldl", "ratio"}},
+		{Name: "LDL cholesterol", Keywords: []string{"ldl", "low density lipoprotein"}, Exclude: []string{"ratio"}},
+		{Name: "HDL cholesterol", Keywords: []string{"hdl", "high density lipoprotein"},
+			Exclude: []string{"non hdl", "non-hdl", "ratio"}},
+		{Name: "triglycerides", Keywords: []string{"triglyceride*", "tg"}, Exclude: []string{"ratio"}},
+	}},
+	{Key: "glycemic", Name: "Blood sugar tests", Detect: 1, Analytes: []Analyte{
+		{Name: "fasting glucose", Keywords: []string{"fasting glucose", "fasting blood glucose", "fasting plasma glucose",
+			"fasting blood sugar", "fbs", "fpg", "glucose"},
+			Exclude: []string{"urine", "post*", "pp", "ppbs", "random", "rbs", "tolerance"}},
+		{Name: "HbA1c", Keywords: []string{"hba1c", "a1c", "glycated", "glycosylated"}},
+	}},
+	{Key: "thyroid", Name: "Thyroid panel", Detect: 1, Analytes: []Analyte{
+		{Name: "TSH", Keywords: []string{"tsh", "thyroid stimulating hormone", "thyrotropin"}},
+		{Name: "free T4", Keywords: []string{"t4", "ft4", "thyroxine"}},
+		{Name: "free T3", Keywords: []string{"t3", "ft3", "triiodothyronine"}, Exclude: []string{"reverse"}},
+	}},
+	{Key: "kidney", Name: "Kidney function tests", Detect: 1, Analytes: []Analyte{
+		{Name: "creatinine", Keywords: []string{"creatinine"}, Exclude: []string{"ratio", "clearance", "urine"}},
+		{Name: "urea", Keywords: []string{"urea", "bun"}, Exclude: []string{"ratio", "urine"}},
+		{Name: "eGFR", Keywords: []string{"egfr", "gfr", "glomerular filtration"}},
+	}},
+	{Key: "liver", Name: "Liver function tests", Detect: 2, Analytes: []Analyte{
+		{Name: "ALT", Keywords: []string{"alt", "sgpt", "alanine aminotransferase", "alanine transaminase"}},
+		{Name: "AST", Keywords: []string{"ast", "sgot", "aspartate aminotransferase", "aspartate transaminase"},
+			Exclude: []string{"ratio"}},
+		{Name: "ALP", Keywords: []string{"alp", "alkaline phosphatase"}},
+		{Name: "bilirubin", Keywords: []string{"bilirubin"}},
+		{Name: "albumin", Keywords: []string{"albumin"}, Exclude: []string{"globulin", "urine", "creatinine", "ratio"}},
+	}},
+}
+
+// analytePattern is the compiled form of an analyte's keywords and exclusions
+type analytePattern struct {
+	match   *regexp.Regexp
+	exclude *regexp.Regexp // Nil when nothing is excluded
+}
+
+// panelPatterns holds one compiled matcher per analyte, keyed by panel and indexed like its analytes
+var panelPatterns = compilePanelPatterns()
+
+func compilePanelPatterns() map[string][]analytePattern {
+	patterns := make(map[string][]analytePattern, len(panelCatalog))
+	for _, panel := range panelCatalog {
+		compiled := make([]analytePattern, len(panel.Analytes))
+		for i, analyte := range panel.Analytes {
+			compiled[i].match = compileKeywords(analyte.Keywords)
+			if len(analyte.Exclude) > 0 {
+				compiled[i].exclude = compileKeywords(analyte.Exclude)
+			}
+		}
+		patterns[panel.Key] = compiled
+	}
+	return patterns
+}
+
+// PanelCompleteness is how much of one detected panel a report contains
+type PanelCompleteness struct {
+	Key     string   `json:"key"`
+	Name    string   `json:"name"`
+	Score   int      `json:"score"` // Percentage of the panel's analytes found
+	Found   []string `json:"found"`
+	Missing []string `json:"missing"`
+}
+
+// Completeness scores a report against the panels it appears to contain
+type Completeness struct {
+	Score  int                 `json:"score"` // Percentage of the detected panels' analytes found
+	Panels []PanelCompleteness `json:"panels"`
+	Hints  []string            `json:"hints"` // One per incomplete panel, e.g. "Fasting glucose present but HbA1c missing"
+}
+
+// ScoreCompleteness detects the panels among metrics and reports which of their analytes are missing
+// Returns nil when no panel was detected, since there is nothing to compare the report against
+func ScoreCompleteness(metrics []HealthMetric) *Completeness {
+	names := make([]string, len(metrics))
+	for i := range metrics {
+		names[i] = metrics[i].Name
+	}
+	return scoreCompleteness(names)
+}
+
+// scoreCompleteness scores metric names against the panel catalog
+func scoreCompleteness(names []string) *Completeness {
+	var completeness Completeness
+	expected, found := 0, 0
+	for _, panel := range panelCatalog {
+		result := PanelCompleteness{Key: panel.Key, Name: panel.Name, Found: []string{}, Missing: []string{}}
+		for i, analyte := range panel.Analytes {
+			if analyteFound(panelPatterns[panel.Key][i], names) {
+				result.Found = append(result.Found, analyte.Name)
+			} else {
+				result.Missing = append(result.Missing, analyte.Name)
+			}
+		}
+		if len(result.Found) < panel.Detect {
+			continue
+		}
+
+		result.Score = len(result.Found) * 100 / len(panel.Analytes)
+		expected += len(panel.Analytes)
+		found += len(result.Found)
+		completeness.Panels = append(completeness.Panels, result)
+		if len(result.Missing) > 0 {
+			completeness.Hints = append(completeness.Hints, completenessHint(panel, result))
+		}
+	}
+
+	if len(completeness.Panels) == 0 {
+		return nil
+	}
+	if completeness.Hints == nil {
+		completeness.Hints = []string{}
+	}
+	completeness.Score = found * 100 / expected
+	return &completeness
+}
+
+// analyteFound reports whether any metric name counts as the analyte
+func analyteFound(pattern analytePattern, names []string) bool {
+	for _, name := range names {
+		if pattern.match.MatchString(name) && (pattern.exclude == nil || !pattern.exclude.MatchString(name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// completenessHint names what was found and what is missing; a single analyte is named itself, more by the panel
+func completenessHint(panel Panel, result PanelCompleteness) string {
+	subject := panel.Name
+	if len(result.Found) == 1 {
+		subject = result.Found[0]
+	}
+	return upperFirst(subject) + " present but " + joinWithAnd(result.Missing) + " missing"
+}
+
+// joinWithAnd joins items as "a, b and c"
+func joinWithAnd(items []string) string {
+	if len(items) <= 1 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " and " + items[len(items)-1]
+}
+
+// upperFirst capitalizes the first letter of s
+func upperFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[size:]
+}
@@ -0,0 +1,289 @@
+package services
+
+import (
+	"log/slog"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+// Condition is a chronic condition users can track, with the lab terms that relate to it
+type Condition struct {
+	Key      string
+	Name     string
+	Keywords []string // Whole-word, case-insensitive; a trailing * also matches longer words
+}
+
+// conditionCatalog lists the trackable conditions in display order
+// Decision: A fixed catalog instead of free text, so metrics can be tagged without a model call
+var conditionCatalog = []Condition{
+	{Key: "diabetes", Name: "Diabetes", Keywords: []string{"glucose", "hba1c", "a1c", "glycated", "glycosylated",
+		"blood sugar", "insulin", "diabet*", "fructosamine", "microalbumin"}},
+	{Key: "hypertension", Name: "Hypertension", Keywords: []string{"blood pressure", "systolic", "diastolic",
+		"hypertens*", "aldosterone", "renin"}},
+	{Key: "thyroid", Name: "Thyroid disorder", Keywords: []string{"tsh", "thyroid*", "thyroxine", "triiodothyronine",
+		"t3", "t4", "ft3", "ft4", "tpo", "thyroglobulin"}},
+}
+
+// conditionPatterns holds one compiled matcher per catalog entry, keyed by condition
+var conditionPatterns = compileConditionPatterns()
+
+func compileConditionPatterns() map[string]*regexp.Regexp {
+	patterns := make(map[string]*regexp.Regexp, len(conditionCatalog))
+	for _, condition := range conditionCatalog {
+		patterns[condition.Key] = compileKeywords(condition.Keywords)
+	}
+	return patterns
+}
+
+// compileKeywords builds a whole-word, case-insensitive matcher for keywords; a trailing * also matches longer words
+func compileKeywords(keywords []string) *regexp.Regexp {
+	alternatives := make([]string, len(keywords))
+	for i, keyword := range keywords {
+		prefix := strings.HasSuffix(keyword, "*")
+		alternative := strings.ReplaceAll(regexp.QuoteMeta(strings.TrimSuffix(keyword, "*")), " ", `\s+`)
+		if prefix {
+			alternative += `\w*`
+		}
+		alternatives[i] = alternative
+	}
+	return regexp.MustCompile(`(?i)\b(?:` + strings.Join(alternatives, "|") + `)\b`)
+}
+
+// ListConditions returns the catalog of trackable conditions
+func ListConditions() []Condition {
+	return append([]Condition{}, conditionCatalog...)
+}
+
+// LookupCondition returns the catalog entry for key, or nil if there is none
+func LookupCondition(key string) *Condition {
+	for i := range conditionCatalog {
+		if conditionCatalog[i].Key == key {
+			return &conditionCatalog[i]
+		}
+	}
+	return nil
+}
+
+// ConditionsForText returns the keys of the conditions text mentions, in catalog order
+func ConditionsForText(text string) []string {
+	var keys []string
+	for _, condition := range conditionCatalog {
+		if conditionPatterns[condition.Key].MatchString(text) {
+			keys = append(keys, condition.Key)
+		}
+	}
+	return keys
+}
+
+// TagConditions tags an analysis's metrics and key findings with the conditions they relate to
+// Decision: Metrics are tagged by name only; descriptions mention too many neighbouring topics to be reliable
+func TagConditions(analysis *AnalysisResult) {
+	for i := range analysis.HealthMetrics {
+		analysis.HealthMetrics[i].Conditions = ConditionsForText(analysis.HealthMetrics[i].Name)
+	}
+
+	analysis.ConditionFindings = nil
+	for _, finding := range analysis.KeyFindings {
+		for _, key := range ConditionsForText(finding) {
+			if analysis.ConditionFindings == nil {
+				analysis.ConditionFindings = make(map[string][]string)
+			}
+			analysis.ConditionFindings[key] = append(analysis.ConditionFindings[key], finding)
+		}
+	}
+}
+
+// ConditionReading is one measurement of a condition-related metric
+type ConditionReading struct {
+	ReportID int
+	Date     time.Time
+	Value    string
+	Unit     string
+	Status   string
+}
+
+// ConditionMetric is every reading of one metric across the user's reports, oldest first
+type ConditionMetric struct {
+	Name         string
+	LatestStatus string
+	Readings     []ConditionReading
+}
+
+// ConditionFinding is a key finding of a report that relates to the condition
+type ConditionFinding struct {
+	ReportID int
+	Date     time.Time
+	Text     string
+}
+
+// ConditionOverview gathers everything the user's reports say about one condition
+type ConditionOverview struct {
+	Condition   Condition
+	Since       time.Time // When the user started tracking it
+	ReportCount int       // Reports with at least one related metric or finding
+	Metrics     []ConditionMetric
+	Findings    []ConditionFinding
+}
+
+// TrackedCondition is a catalog entry and whether the user tracks it
+type TrackedCondition struct {
+	Condition
+	Tracked bool
+	Since   *time.Time
+}
+
+// ConditionService manages the conditions users track and builds condition-centric views of their reports
+type ConditionService struct {
+	conditionRepo models.UserConditionRepository
+	reportRepo    models.ReportRepository
+}
+
+// NewConditionService creates a new condition service
+func NewConditionService(conditionRepo models.UserConditionRepository, reportRepo models.ReportRepository) *ConditionService {
+	return &ConditionService{
+		conditionRepo: conditionRepo,
+		reportRepo:    reportRepo,
+	}
+}
+
+// List returns the whole catalog, marking the conditions the user tracks
+func (cs *ConditionService) List(userID int) ([]TrackedCondition, error) {
+	tracked, err := cs.tracked(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	conditions := make([]TrackedCondition, len(conditionCatalog))
+	for i, condition := range conditionCatalog {
+		conditions[i] = TrackedCondition{Condition: condition}
+		if entry, ok := tracked[condition.Key]; ok {
+			conditions[i].Tracked = true
+			conditions[i].Since = &entry.CreatedAt
+		}
+	}
+	return conditions, nil
+}
+
+// Track starts tracking a catalog condition; tracking it again is a no-op
+func (cs *ConditionService) Track(userID int, key string) (*TrackedCondition, error) {
+	condition := LookupCondition(key)
+	if condition == nil {
+		return nil, errors.ErrRecordNotFound
+	}
+
+	entry := &models.UserCondition{UserID: userID, ConditionKey: key}
+	if err := cs.conditionRepo.Add(entry); err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	return &TrackedCondition{Condition: *condition, Tracked: true, Since: &entry.CreatedAt}, nil
+}
+
+// Untrack stops tracking a condition; the reports and their tags are left alone
+func (cs *ConditionService) Untrack(userID int, key string) error {
+	if LookupCondition(key) == nil {
+		return errors.ErrRecordNotFound
+	}
+	if err := cs.conditionRepo.Remove(userID, key); err != nil {
+		return errors.ErrDatabaseConnection
+	}
+	return nil
+}
+
+// Overview collects the metrics and findings tagged with a tracked condition across the user's completed reports
+// A report is dated by its report date, or by its upload in the user's timezone when no date was entered
+func (cs *ConditionService) Overview(user *models.User, key string) (*ConditionOverview, error) {
+	condition := LookupCondition(key)
+	if condition == nil {
+		return nil, errors.ErrRecordNotFound
+	}
+	tracked, err := cs.tracked(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	// Decision: Overviews are only for conditions the user declared, so nobody is shown a diagnosis they never gave
+	entry, ok := tracked[key]
+	if !ok {
+		return nil, errors.NewValidationError("Track this condition before viewing its overview")
+	}
+
+	reports, err := cs.reportRepo.ListByFilter(models.ReportFilter{UserID: user.ID, Status: "completed"})
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+
+	type datedAnalysis struct {
+		reportID int
+		date     time.Time
+		analysis *AnalysisResult
+	}
+	loc := user.Location()
+	var dated []datedAnalysis
+	for _, report := range reports {
+		analysis, err := ParseStoredAnalysis(report.SimplifiedSummary)
+		if err != nil {
+			slog.Warn("Skipping report in condition overview", "report_id", report.ID, "error", err)
+			continue
+		}
+		date := report.UploadDate.In(loc)
+		if report.ReportDate != nil {
+			date = *report.ReportDate
+		}
+		dated = append(dated, datedAnalysis{reportID: report.ID, date: date, analysis: analysis})
+	}
+	sort.SliceStable(dated, func(i, j int) bool { return dated[i].date.Before(dated[j].date) })
+
+	overview := &ConditionOverview{Condition: *condition, Since: entry.CreatedAt}
+	byName := make(map[string]*ConditionMetric)
+	var order []string
+	for _, d := range dated {
+		related := false
+		for _, metric := range d.analysis.HealthMetrics {
+			if !slices.Contains(metric.Conditions, key) {
+				continue
+			}
+			related = true
+			name := strings.ToLower(strings.TrimSpace(metric.Name))
+			series, ok := byName[name]
+			if !ok {
+				series = &ConditionMetric{Name: metric.Name}
+				byName[name] = series
+				order = append(order, name)
+			}
+			series.Readings = append(series.Readings, ConditionReading{ReportID: d.reportID, Date: d.date,
+				Value: metric.GetValueAsString(), Unit: metric.Unit, Status: metric.Status})
+			series.LatestStatus = metric.Status
+		}
+		for _, finding := range d.analysis.ConditionFindings[key] {
+			related = true
+			overview.Findings = append(overview.Findings, ConditionFinding{ReportID: d.reportID, Date: d.date, Text: finding})
+		}
+		if related {
+			overview.ReportCount++
+		}
+	}
+
+	overview.Metrics = make([]ConditionMetric, 0, len(order))
+	for _, name := range order {
+		overview.Metrics = append(overview.Metrics, *byName[name])
+	}
+	return overview, nil
+}
+
+// tracked returns the user's tracked conditions keyed by condition
+func (cs *ConditionService) tracked(userID int) (map[string]*models.UserCondition, error) {
+	entries, err := cs.conditionRepo.ListByUser(userID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	tracked := make(map[string]*models.UserCondition, len(entries))
+	for _, entry := range entries {
+		tracked[entry.ConditionKey] = entry
+	}
+	return tracked, nil
+}
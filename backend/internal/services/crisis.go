@@ -0,0 +1,182 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+)
+
+// Kinds of crisis a question can signal
+const (
+	CrisisSelfHarm  = "self_harm"
+	CrisisEmergency = "emergency"
+)
+
+// IsValidCrisisCategory reports whether category is one the detector flags
+func IsValidCrisisCategory(category string) bool {
+	return category == CrisisSelfHarm || category == CrisisEmergency
+}
+
+// crisisRule flags text matching pattern, unless it also matches unless (when set)
+type crisisRule struct {
+	category string
+	pattern  *regexp.Regexp
+	unless   *regexp.Regexp
+}
+
+// crisisRules are checked in order against the whole question, case-insensitively
+// Decision: Self-harm phrases are specific enough to flag on their own; symptoms only count when the asker
+// describes them happening, so "does this raise my stroke risk?" still gets a normal answer
+var crisisRules = []crisisRule{
+	{category: CrisisSelfHarm, pattern: regexp.MustCompile(`(?i)\b(?:kill(?:ing)?\s+myself|end(?:ing)?\s+(?:my|it)\s+(?:life|all)|take\s+my\s+(?:own\s+)?life|want\s+to\s+die|wanna\s+die|better\s+off\s+dead|no\s+reason\s+to\s+live|suicidal|commit(?:ting)?\s+suicide|(?:thinking|thoughts?)\s+(?:of|about)\s+suicide|(?:hurt(?:ing)?|harm(?:ing)?|cut(?:ting)?)\s+myself|self[\s-]?harm(?:ing)?|overdos(?:e|ing)\s+on\s+purpose|khudkushi|a+tmahatya|marna\s+chaht[aie])\b`)},
+	{category: CrisisEmergency, pattern: regexp.MustCompile(`(?i)\b(?:i|i'm|im|i've|i\s+am|my\s+\w+|he|she|they)\b[^.!?]{0,30}\b(?:chest\s+pain|chest\s+(?:is\s+)?(?:tight|hurting)|can'?t\s+breathe|cannot\s+breathe|(?:trouble|difficulty)\s+breathing|not\s+breathing|short\s+of\s+breath|faint(?:ed|ing)|passed\s+out|unconscious|(?:having|had)\s+a\s+(?:seizure|stroke|heart\s+attack)|slurred\s+speech|face\s+(?:is\s+)?drooping|(?:coughing|vomiting)\s+(?:up\s+)?blood|bleeding\s+(?:heavily|a\s+lot|won'?t\s+stop)|took\s+too\s+many\s+(?:pills|tablets)|overdosed)\b`),
+		unless: regexp.MustCompile(`(?i)\b(?:risk|chance|prevent\w*|history\s+of|years?\s+ago|last\s+(?:year|month))\b`)},
+}
+
+// CrisisMatch is what the detector found in a question
+type CrisisMatch struct {
+	Category string
+	Keyword  string // The phrase that matched, as written
+}
+
+// DetectCrisis returns the first crisis signal in text, or nil
+func DetectCrisis(text string) *CrisisMatch {
+	text = strings.NewReplacer("’", "'", "‘", "'").Replace(text)
+	for _, rule := range crisisRules {
+		keyword := rule.pattern.FindString(text)
+		if keyword == "" || (rule.unless != nil && rule.unless.MatchString(text)) {
+			continue
+		}
+		return &CrisisMatch{Category: rule.category, Keyword: strings.TrimSpace(keyword)}
+	}
+	return nil
+}
+
+// CrisisContacts are the numbers a crisis reply gives for one country
+type CrisisContacts struct {
+	Country   string // ISO 3166 code; empty for the generic fallback
+	Emergency string
+	Helpline  string // Who to call about self-harm, phrased to follow "call"
+}
+
+// crisisContacts lists the countries with local numbers; everyone else gets the generic fallback
+var crisisContacts = map[string]CrisisContacts{
+	"IN": {Country: "IN", Emergency: "112", Helpline: "Tele-MANAS on 14416"},
+	"US": {Country: "US", Emergency: "911", Helpline: "the 988 Suicide & Crisis Lifeline on 988"},
+	"CA": {Country: "CA", Emergency: "911", Helpline: "the 988 Suicide Crisis Helpline on 988"},
+	"GB": {Country: "GB", Emergency: "999", Helpline: "Samaritans on 116 123"},
+	"AU": {Country: "AU", Emergency: "000", Helpline: "Lifeline on 13 11 14"},
+}
+
+// timezoneCountries maps the IANA zones of the countries above to their codes
+var timezoneCountries = map[string]string{
+	"Asia/Kolkata":  "IN",
+	"Asia/Calcutta": "IN",
+
+	"America/New_York":    "US",
+	"America/Chicago":     "US",
+	"America/Denver":      "US",
+	"America/Phoenix":     "US",
+	"America/Los_Angeles": "US",
+	"America/Anchorage":   "US",
+	"Pacific/Honolulu":    "US",
+	"America/Detroit":     "US",
+
+	"America/Toronto":   "CA",
+	"America/Vancouver": "CA",
+	"America/Edmonton":  "CA",
+	"America/Winnipeg":  "CA",
+	"America/Halifax":   "CA",
+	"America/St_Johns":  "CA",
+	"America/Regina":    "CA",
+
+	"Europe/London": "GB",
+
+	"Australia/Sydney":    "AU",
+	"Australia/Melbourne": "AU",
+	"Australia/Brisbane":  "AU",
+	"Australia/Perth":     "AU",
+	"Australia/Adelaide":  "AU",
+	"Australia/Hobart":    "AU",
+	"Australia/Darwin":    "AU",
+}
+
+// CrisisService answers crisis questions with emergency contacts instead of the AI and flags them for follow-up
+// Decision: Detection is keyword-based and runs before any model call, so it works the same whatever the
+// model would have said, and even when no AI is configured
+type CrisisService struct {
+	flagRepo models.CrisisFlagRepository // Optional; nil logs flags to the server log only
+	userRepo models.UserRepository       // Optional; nil gives everyone the default contacts
+	fallback CrisisContacts
+}
+
+// NewCrisisService creates a crisis service; users whose time zone maps to no listed country get
+// cfg.DefaultCountry's contacts, or cfg.EmergencyNumber when that is empty
+func NewCrisisService(flagRepo models.CrisisFlagRepository, userRepo models.UserRepository, cfg config.ChatSafetyConfig) (*CrisisService, error) {
+	number := cfg.EmergencyNumber
+	if number == "" {
+		number = "112"
+	}
+	fallback := CrisisContacts{Emergency: number, Helpline: "a crisis line near you (findahelpline.com lists them)"}
+
+	if country := strings.ToUpper(cfg.DefaultCountry); country != "" {
+		contacts, ok := crisisContacts[country]
+		if !ok {
+			return nil, fmt.Errorf("no crisis contacts for country %q (expected one of IN, US, CA, GB, AU, or empty)", cfg.DefaultCountry)
+		}
+		fallback = contacts
+	}
+	return &CrisisService{flagRepo: flagRepo, userRepo: userRepo, fallback: fallback}, nil
+}
+
+// ContactsFor returns the crisis contacts for the user's country, judged by their time zone
+func (cs *CrisisService) ContactsFor(userID int) CrisisContacts {
+	if cs.userRepo == nil {
+		return cs.fallback
+	}
+	user, err := cs.userRepo.GetByID(userID)
+	if err != nil || user == nil {
+		return cs.fallback
+	}
+	if contacts, ok := crisisContacts[timezoneCountries[user.Timezone]]; ok {
+		return contacts
+	}
+	return cs.fallback
+}
+
+// Reply is the answer given in place of the AI's for a crisis question
+func (cs *CrisisService) Reply(match *CrisisMatch, contacts CrisisContacts) string {
+	if match.Category == CrisisSelfHarm {
+		return fmt.Sprintf("It sounds like you're going through something really painful, and you don't have to face it alone. "+
+			"Please talk to someone now: call %s, or %s if you might act on these thoughts or are in danger. "+
+			"If you can, let someone you trust know how you're feeling.", contacts.Helpline, contacts.Emergency)
+	}
+	return fmt.Sprintf("What you describe may be a medical emergency. Please call %s or go to the nearest emergency department now, "+
+		"and don't wait for a reply here. I can go through your report with you once you're safe.", contacts.Emergency)
+}
+
+// Flag records a crisis question for follow-up; failures are logged and don't fail the chat
+func (cs *CrisisService) Flag(report *models.Report, message *models.ChatMessage, match *CrisisMatch, contacts CrisisContacts) {
+	slog.Warn("Crisis keywords in chat message", "category", match.Category, "message_id", message.ID, "report_id", report.ID)
+	if cs.flagRepo == nil {
+		return
+	}
+
+	flag := &models.CrisisFlag{
+		UserID:    report.UserID,
+		ReportID:  report.ID,
+		MessageID: message.ID,
+		Category:  match.Category,
+		Keyword:   match.Keyword,
+		Question:  message.UserMessage,
+		InputMode: message.InputMode,
+		Country:   contacts.Country,
+	}
+	if err := cs.flagRepo.Create(flag); err != nil {
+		slog.Error("Failed to flag crisis message", "message_id", message.ID, "error", err)
+	}
+}
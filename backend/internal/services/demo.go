@@ -0,0 +1,273 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+)
+
+// DemoPromptVersion labels analyses produced by the demo analyzer instead of Gemini
+const DemoPromptVersion = "demo"
+
+// DemoSample is a sample report with a pre-baked analysis
+type DemoSample struct {
+	Filename string
+	FileType string
+	FileSize int64
+	Analysis AnalysisResult
+	Chat     []DemoChatExchange
+}
+
+// DemoChatExchange is one sample question and answer about a report
+type DemoChatExchange struct {
+	Question string
+	Answer   string
+}
+
+// DemoSamples are the synthetic reports given to every demo account
+// Decision: Entirely fictional values - demo deployments must never contain real medical data
+var DemoSamples = []DemoSample{
+	{
+		Filename: "complete_blood_count.pdf",
+		FileType: "pdf",
+		FileSize: 48213,
+		Analysis: AnalysisResult{
+			Summary:       "Complete blood count within normal limits apart from mildly low hemoglobin.",
+			SimpleSummary: "Your blood counts look mostly healthy. Hemoglobin is a little low, which can make you feel tired.",
+			HealthMetrics: []HealthMetric{
+				{Name: "Hemoglobin", Value: 11.8, Unit: "g/dL", Score: 68, Status: "warning", RangeMin: 12, RangeMax: 15.5,
+					Description: "Carries oxygen in your blood. Slightly below the normal range."},
+				{Name: "White Blood Cells", Value: 7.2, Unit: "x10^3/uL", Score: 92, Status: "normal", RangeMin: 4.5, RangeMax: 11,
+					Description: "Fights infection. Your level is normal."},
+				{Name: "Platelets", Value: 260, Unit: "x10^3/uL", Score: 95, Status: "normal", RangeMin: 150, RangeMax: 400,
+					Description: "Helps your blood clot. Your level is normal."},
+			},
+			KeyFindings:     []string{"Mildly low hemoglobin", "Normal white cell and platelet counts"},
+			Recommendations: []string{"Include iron-rich foods such as leafy greens and lentils", "Recheck hemoglobin in 3 months"},
+			RiskLevel:       "low",
+		},
+		Chat: []DemoChatExchange{
+			{Question: "Should I be worried about my hemoglobin?",
+				Answer: "It is only slightly below the normal range. This is common and often improves with diet, but mention it to your doctor at your next visit."},
+			{Question: "What foods are high in iron?",
+				Answer: "Spinach, lentils, chickpeas, red meat, and fortified cereals are good sources. Vitamin C helps your body absorb iron."},
+		},
+	},
+	{
+		Filename: "lipid_panel.pdf",
+		FileType: "pdf",
+		FileSize: 39877,
+		Analysis: AnalysisResult{
+			Summary:       "Elevated LDL cholesterol with normal HDL and triglycerides.",
+			SimpleSummary: "Your 'bad' cholesterol is higher than ideal. The rest of your cholesterol results are fine.",
+			HealthMetrics: []HealthMetric{
+				{Name: "LDL Cholesterol", Value: 148, Unit: "mg/dL", Score: 55, Status: "warning", RangeMin: 0, RangeMax: 100,
+					Description: "Can build up in arteries. Yours is above the recommended level."},
+				{Name: "HDL Cholesterol", Value: 52, Unit: "mg/dL", Score: 85, Status: "normal", RangeMin: 40, RangeMax: 90,
+					Description: "Protects your heart. Your level is good."},
+				{Name: "Triglycerides", Value: 130, Unit: "mg/dL", Score: 88, Status: "normal", RangeMin: 0, RangeMax: 150,
+					Description: "A type of fat in your blood. Your level is normal."},
+			},
+			KeyFindings:     []string{"LDL above recommended range"},
+			Recommendations: []string{"Reduce saturated fat intake", "Aim for 30 minutes of activity most days", "Discuss results with your doctor"},
+			RiskLevel:       "medium",
+		},
+		Chat: []DemoChatExchange{
+			{Question: "What does high LDL mean for me?",
+				Answer: "LDL is the cholesterol that can build up in your arteries over time. Yours is moderately high, so lifestyle changes are a good first step. Your doctor can advise whether medication is needed."},
+		},
+	},
+	{
+		Filename: "thyroid_profile.txt",
+		FileType: "txt",
+		FileSize: 2104,
+		Analysis: AnalysisResult{
+			Summary:       "Thyroid function tests within reference ranges.",
+			SimpleSummary: "Your thyroid is working normally.",
+			HealthMetrics: []HealthMetric{
+				{Name: "TSH", Value: 2.1, Unit: "mIU/L", Score: 94, Status: "normal", RangeMin: 0.4, RangeMax: 4,
+					Description: "Controls your thyroid. Your level is normal."},
+				{Name: "Free T4", Value: 1.2, Unit: "ng/dL", Score: 93, Status: "normal", RangeMin: 0.8, RangeMax: 1.8,
+					Description: "Main thyroid hormone. Your level is normal."},
+			},
+			KeyFindings:     []string{"Normal thyroid function"},
+			Recommendations: []string{"No action needed; continue routine check-ups"},
+			RiskLevel:       "low",
+		},
+	},
+}
+
+// DemoAnalyzer stands in for Gemini when DEMO_MODE is enabled
+// Decision: Return a canned analysis matched on filename so demos work without API keys
+type DemoAnalyzer struct{}
+
+// NewDemoAnalyzer creates a mock analyzer
+func NewDemoAnalyzer() *DemoAnalyzer {
+	return &DemoAnalyzer{}
+}
+
+// ModelName identifies canned demo analyses in processing history
+func (da *DemoAnalyzer) ModelName() string {
+	return "demo"
+}
+
+// AnalyzeReport returns a pre-baked analysis without reading the file or calling an API
+// Decision: Demo analyses are canned, so every reading level and plan gets the same text
+func (da *DemoAnalyzer) AnalyzeReport(ctx context.Context, filePath, fileType, readingLevel, plan, patient string) (*ReportAnalysis, error) {
+	sample := DemoSamples[0]
+	name := strings.ToLower(filepath.Base(filePath))
+	for _, candidate := range DemoSamples {
+		if strings.Contains(name, strings.TrimSuffix(candidate.Filename, filepath.Ext(candidate.Filename))) {
+			sample = candidate
+			break
+		}
+	}
+
+	resultJSON, err := marshalDemoAnalysis(sample.Analysis)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReportAnalysis{ResultJSON: resultJSON, PromptVersion: DemoPromptVersion}, nil
+}
+
+// AnalyzeParts returns the canned analysis matching the first part
+func (da *DemoAnalyzer) AnalyzeParts(ctx context.Context, filePaths []string, fileType, readingLevel, plan, patient string) (*ReportAnalysis, error) {
+	return da.AnalyzeReport(ctx, filePaths[0], fileType, readingLevel, plan, patient)
+}
+
+// AnswerQuestion returns a canned reply so chat works in demo deployments
+func (da *DemoAnalyzer) AnswerQuestion(ctx context.Context, reportSummary, conversationSummary string, history []*models.ChatMessage, question, readingLevel, patient string) (string, error) {
+	return fmt.Sprintf("This is a demo answer to %q. In the live app, the assistant explains your report "+
+		"in plain language using your results and earlier questions (%d so far).", question, len(history)), nil
+}
+
+// SummarizeConversation lists the questions asked so demo summaries stay deterministic
+func (da *DemoAnalyzer) SummarizeConversation(ctx context.Context, previousSummary string, turns []*models.ChatMessage) (string, error) {
+	questions := make([]string, len(turns))
+	for i, turn := range turns {
+		questions[i] = turn.UserMessage
+	}
+
+	summary := "The patient asked: " + strings.Join(questions, "; ")
+	if previousSummary != "" {
+		summary = previousSummary + " " + summary
+	}
+	return summary, nil
+}
+
+// AnalyzeCombined concatenates the sources' results so merged analyses work in demo deployments
+func (da *DemoAnalyzer) AnalyzeCombined(ctx context.Context, sources []MergeSource, readingLevel, plan string) (*ReportAnalysis, error) {
+	combined := AnalysisResult{RiskLevel: "low"}
+	labels := make([]string, len(sources))
+	for i, source := range sources {
+		labels[i] = source.Label
+		combined.HealthMetrics = append(combined.HealthMetrics, source.Analysis.HealthMetrics...)
+		combined.KeyFindings = append(combined.KeyFindings, source.Analysis.KeyFindings...)
+		combined.Recommendations = append(combined.Recommendations, source.Analysis.Recommendations...)
+		if riskRank[source.Analysis.RiskLevel] > riskRank[combined.RiskLevel] {
+			combined.RiskLevel = source.Analysis.RiskLevel
+		}
+	}
+	combined.Summary = "Combined assessment of " + strings.Join(labels, ", ") + "."
+	combined.SimpleSummary = fmt.Sprintf("This is a demo overview of your %d reports taken together.", len(sources))
+
+	resultJSON, err := marshalDemoAnalysis(combined)
+	if err != nil {
+		return nil, err
+	}
+	return &ReportAnalysis{ResultJSON: resultJSON, PromptVersion: DemoPromptVersion}, nil
+}
+
+// WriteAnnualReview lists the trends and the latest report's advice so annual reviews work in demo deployments
+func (da *DemoAnalyzer) WriteAnnualReview(ctx context.Context, year int, entries []AnnualReportEntry, trends []MetricTrend, readingLevel string) (*AnnualReviewText, error) {
+	text := &AnnualReviewText{
+		Overview:                  fmt.Sprintf("This is a demo overview of your %d reports from %d.", len(entries), year),
+		Improvements:              []string{},
+		Deteriorations:            []string{},
+		UnresolvedRecommendations: []string{},
+	}
+	for _, trend := range trends {
+		change := fmt.Sprintf("%s went from %s to %s %s", trend.Name, trend.FirstValue, trend.LastValue, trend.Unit)
+		switch trend.Direction {
+		case TrendImproved:
+			text.Improvements = append(text.Improvements, change)
+		case TrendWorsened:
+			text.Deteriorations = append(text.Deteriorations, change)
+		}
+	}
+	if len(entries) > 0 {
+		text.UnresolvedRecommendations = append(text.UnresolvedRecommendations, entries[len(entries)-1].Analysis.Recommendations...)
+	}
+	return text, nil
+}
+
+// riskRank orders risk levels so the demo combined analysis keeps the highest
+var riskRank = map[string]int{"low": 1, "medium": 2, "high": 3}
+
+// DemoService provisions sample data for demo accounts
+type DemoService struct {
+	reportRepo models.ReportRepository
+}
+
+// NewDemoService creates a new demo service
+func NewDemoService(reportRepo models.ReportRepository) *DemoService {
+	return &DemoService{reportRepo: reportRepo}
+}
+
+// ProvisionSampleReports gives a user one completed report per demo sample
+// Decision: Reports point at a virtual demo/ path; no file is ever written to disk.
+// Returned reports are in DemoSamples order so callers can attach sample chats.
+func (ds *DemoService) ProvisionSampleReports(userID int) ([]*models.Report, error) {
+	var reports []*models.Report
+	for _, sample := range DemoSamples {
+		report := &models.Report{
+			UserID:           userID,
+			OriginalFilename: sample.Filename,
+			FilePath:         filepath.Join("demo", sample.Filename),
+			FileType:         sample.FileType,
+			FileSize:         sample.FileSize,
+		}
+		if err := ds.reportRepo.Create(report); err != nil {
+			return nil, fmt.Errorf("failed to create sample report %s: %w", sample.Filename, err)
+		}
+
+		resultJSON, err := marshalDemoAnalysis(sample.Analysis)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := ds.reportRepo.SetAnalysisMetadata(report.ID, DemoPromptVersion, false); err != nil {
+			return nil, fmt.Errorf("failed to record sample analysis metadata: %w", err)
+		}
+		if err := ds.reportRepo.UpdateProcessingStatus(report.ID, "completed", resultJSON); err != nil {
+			return nil, fmt.Errorf("failed to complete sample report %s: %w", sample.Filename, err)
+		}
+
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
+
+// marshalDemoAnalysis stamps the current schema version and encodes the analysis for storage
+// Decision: The metrics are cloned before they are tagged or given inferred units, since the copied struct
+// still shares them with DemoSamples, which concurrent signups and analyses read
+func marshalDemoAnalysis(analysis AnalysisResult) (string, error) {
+	analysis.HealthMetrics = slices.Clone(analysis.HealthMetrics)
+	analysis.SchemaVersion = CurrentAnalysisSchemaVersion
+	analysis.GlossaryTerms = AnnotateGlossaryTerms(analysis.SimpleSummary, nil)
+	TagConditions(&analysis)
+	analysis.Completeness = ScoreCompleteness(analysis.HealthMetrics)
+	InferMissingUnits(analysis.HealthMetrics)
+	resultJSON, err := json.Marshal(analysis)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode sample analysis: %w", err)
+	}
+	return string(resultJSON), nil
+}
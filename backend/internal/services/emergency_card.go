@@ -0,0 +1,283 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/pdfgen"
+)
+
+// emergencyCardDisclaimer is printed on every card
+const emergencyCardDisclaimer = "Entered by the patient and taken from their uploaded reports. Not verified by a clinician."
+
+// CriticalValue is a metric whose most recent reading was critical
+type CriticalValue struct {
+	Name     string
+	Value    string
+	Unit     string
+	ReportID int
+	Date     time.Time
+}
+
+// EmergencyCard is a compact summary for first responders
+type EmergencyCard struct {
+	FullName       string
+	Age            *int
+	Sex            string
+	BloodGroup     string
+	Conditions     []string
+	Allergies      []string
+	Medications    []string
+	CriticalValues []CriticalValue
+	GeneratedAt    time.Time // In the owner's timezone
+}
+
+// EmergencyCardService builds emergency cards and manages their public links
+type EmergencyCardService struct {
+	userRepo      models.UserRepository
+	profileRepo   models.HealthProfileRepository
+	conditionRepo models.UserConditionRepository
+	reportRepo    models.ReportRepository
+	linkRepo      models.EmergencyCardLinkRepository
+}
+
+// NewEmergencyCardService creates a new emergency card service
+func NewEmergencyCardService(
+	userRepo models.UserRepository,
+	profileRepo models.HealthProfileRepository,
+	conditionRepo models.UserConditionRepository,
+	reportRepo models.ReportRepository,
+	linkRepo models.EmergencyCardLinkRepository,
+) *EmergencyCardService {
+	return &EmergencyCardService{
+		userRepo:      userRepo,
+		profileRepo:   profileRepo,
+		conditionRepo: conditionRepo,
+		reportRepo:    reportRepo,
+		linkRepo:      linkRepo,
+	}
+}
+
+// Card builds the user's emergency card from their health profile, tracked conditions, and reports
+func (es *EmergencyCardService) Card(user *models.User) (*EmergencyCard, error) {
+	loc := user.Location()
+	card := &EmergencyCard{FullName: user.FullName, GeneratedAt: time.Now().In(loc),
+		Conditions: []string{}, Allergies: []string{}, Medications: []string{}, CriticalValues: []CriticalValue{}}
+
+	profile, err := es.profileRepo.GetByUserID(user.ID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if profile != nil {
+		card.Age = profile.Age(card.GeneratedAt)
+		card.Sex = profile.Sex
+		card.BloodGroup = profile.BloodGroup
+		card.Conditions = append(card.Conditions, profile.Conditions...)
+		card.Allergies = append(card.Allergies, profile.Allergies...)
+		card.Medications = append(card.Medications, profile.Medications...)
+	}
+
+	// Decision: Tracked conditions are listed by name unless the profile already spells them out
+	tracked, err := es.conditionRepo.ListByUser(user.ID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	for _, entry := range tracked {
+		condition := LookupCondition(entry.ConditionKey)
+		if condition == nil || mentionsCondition(card.Conditions, condition) {
+			continue
+		}
+		card.Conditions = append(card.Conditions, condition.Name)
+	}
+
+	if card.CriticalValues, err = es.criticalValues(user.ID, loc); err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+// criticalValues returns the metrics whose latest reading across completed reports was critical
+// A report is dated by its report date, or by its upload in the user's timezone when no date was entered
+func (es *EmergencyCardService) criticalValues(userID int, loc *time.Location) ([]CriticalValue, error) {
+	reports, err := es.reportRepo.ListByFilter(models.ReportFilter{UserID: userID, Status: "completed"})
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+
+	latest := make(map[string]CriticalValue)
+	statuses := make(map[string]string)
+	var order []string
+	for _, report := range reports {
+		analysis, err := ParseStoredAnalysis(report.SimplifiedSummary)
+		if err != nil {
+			slog.Warn("Skipping report in emergency card", "report_id", report.ID, "error", err)
+			continue
+		}
+		date := report.UploadDate.In(loc)
+		if report.ReportDate != nil {
+			date = *report.ReportDate
+		}
+		for _, metric := range analysis.HealthMetrics {
+			key := strings.ToLower(strings.TrimSpace(metric.Name))
+			if key == "" {
+				continue
+			}
+			previous, seen := latest[key]
+			if seen && previous.Date.After(date) {
+				continue
+			}
+			if !seen {
+				order = append(order, key)
+			}
+			latest[key] = CriticalValue{Name: metric.Name, Value: metric.GetValueAsString(), Unit: metric.Unit,
+				ReportID: report.ID, Date: date}
+			statuses[key] = metric.Status
+		}
+	}
+
+	values := []CriticalValue{}
+	for _, key := range order {
+		if statuses[key] == "critical" {
+			values = append(values, latest[key])
+		}
+	}
+	sort.SliceStable(values, func(i, j int) bool { return values[i].Date.After(values[j].Date) })
+	return values, nil
+}
+
+// mentionsCondition reports whether any free-text entry already names the catalog condition
+func mentionsCondition(entries []string, condition *Condition) bool {
+	for _, entry := range entries {
+		for _, key := range ConditionsForText(entry) {
+			if key == condition.Key {
+				return true
+			}
+		}
+		if strings.EqualFold(strings.TrimSpace(entry), condition.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// PublicLink returns the user's public card link, or nil when they haven't consented to one
+func (es *EmergencyCardService) PublicLink(userID int) (*models.EmergencyCardLink, error) {
+	link, err := es.linkRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	return link, nil
+}
+
+// EnablePublicLink records the user's consent and issues a new link token, replacing any earlier one
+// The returned token is shown once and can't be recovered later
+func (es *EmergencyCardService) EnablePublicLink(userID int) (*models.EmergencyCardLink, string, error) {
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return nil, "", errors.ErrDatabaseConnection
+	}
+	token := base64.RawURLEncoding.EncodeToString(tokenBytes)
+
+	link := &models.EmergencyCardLink{UserID: userID, TokenHash: hashShareToken(token)}
+	if err := es.linkRepo.Replace(link); err != nil {
+		return nil, "", errors.ErrDatabaseConnection
+	}
+	return link, token, nil
+}
+
+// DisablePublicLink withdraws consent; the link stops working at once
+func (es *EmergencyCardService) DisablePublicLink(userID int) error {
+	if err := es.linkRepo.Delete(userID); err != nil {
+		return errors.ErrDatabaseConnection
+	}
+	return nil
+}
+
+// OpenPublicLink returns the card behind a public link token
+// Decision: Unknown tokens and inactive accounts look the same, so a scan can't probe for users
+func (es *EmergencyCardService) OpenPublicLink(token string) (*EmergencyCard, error) {
+	link, err := es.linkRepo.GetByTokenHash(hashShareToken(token))
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if link == nil {
+		return nil, errors.ErrRecordNotFound
+	}
+
+	user, err := es.userRepo.GetByID(link.UserID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if user == nil || !user.IsActive {
+		return nil, errors.ErrRecordNotFound
+	}
+
+	card, err := es.Card(user)
+	if err != nil {
+		return nil, err
+	}
+	if err := es.linkRepo.RecordView(user.ID); err != nil {
+		slog.Error("Failed to record emergency card view", "user_id", user.ID, "error", err)
+	}
+	return card, nil
+}
+
+// RenderEmergencyCardPDF formats a card as a printable PDF
+func RenderEmergencyCardPDF(card *EmergencyCard) []byte {
+	doc := pdfgen.New()
+	doc.Heading("Emergency medical card")
+	doc.Label(card.FullName)
+
+	var details []string
+	if card.Age != nil {
+		details = append(details, fmt.Sprintf("Age %d", *card.Age))
+	}
+	if card.Sex != "" {
+		details = append(details, "Sex: "+card.Sex)
+	}
+	if card.BloodGroup != "" {
+		details = append(details, "Blood group: "+card.BloodGroup)
+	}
+	if len(details) > 0 {
+		doc.Paragraph(strings.Join(details, "    "))
+	}
+
+	for _, section := range []struct {
+		heading string
+		items   []string
+	}{
+		{"Allergies", card.Allergies},
+		{"Conditions", card.Conditions},
+		{"Medications", card.Medications},
+	} {
+		doc.Heading(section.heading)
+		if len(section.items) == 0 {
+			doc.Paragraph("None recorded")
+			continue
+		}
+		for _, line := range bulleted(section.items) {
+			doc.Paragraph(line)
+		}
+	}
+
+	doc.Heading("Latest critical values")
+	if len(card.CriticalValues) == 0 {
+		doc.Paragraph("None in processed reports")
+	}
+	for _, value := range card.CriticalValues {
+		reading := strings.TrimSpace(value.Value + " " + value.Unit)
+		doc.Paragraph(fmt.Sprintf("- %s: %s (%s)", value.Name, reading, value.Date.Format("2006-01-02")))
+	}
+
+	doc.Spacer()
+	doc.Paragraph("Generated " + card.GeneratedAt.Format("2006-01-02 15:04 MST"))
+	doc.Paragraph(emergencyCardDisclaimer)
+	return doc.Bytes()
+}
@@ -0,0 +1,195 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+)
+
+// Event types published on the event bus
+const (
+	EventReportUploaded      = "report.uploaded"       // A report file was stored and is waiting for analysis
+	EventAnalysisCompleted   = "analysis.completed"    // A report's analysis was stored and the report is completed
+	EventReportStatusChanged = "report.status_changed" // Processing started, failed, or was put off for a retry or review
+	EventChatCreated         = "chat.created"          // A new question and its answer were stored
+)
+
+// defaultEventBuffer is how many events may wait for delivery before Publish blocks
+const defaultEventBuffer = 256
+
+// Event is something that happened to a user's data
+// Decision: Events carry IDs, not records, so subscribers read current state and payloads stay serializable
+type Event struct {
+	Type       string    `json:"type"`
+	UserID     int       `json:"user_id"`
+	ReportID   int       `json:"report_id,omitempty"`
+	MessageID  int       `json:"message_id,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// EventHandler reacts to one event; a returned error is logged and does not stop other subscribers
+type EventHandler func(event Event) error
+
+// EventBus delivers published events to the handlers subscribed to their type
+// Decision: Publishers never learn who is listening, so notifications, auditing, and later
+// integrations plug in at startup without touching the code that produces the events
+type EventBus interface {
+	Subscribe(eventType string, handler EventHandler)
+	Publish(event Event)
+	Close()
+}
+
+// NewEventBus creates the event bus QUEUE_BACKEND selects; group names the process's role on a shared bus
+func NewEventBus(cfg config.QueueConfig, group string) (EventBus, error) {
+	switch strings.ToLower(cfg.Backend) {
+	case "", "local":
+		return NewLocalEventBus(0), nil
+	case "nats":
+		return NewNATSEventBus(cfg, group)
+	case "kafka":
+		// Decision: Kafka's binary protocol needs a client library this build doesn't include; NATS covers the same deployments
+		return nil, fmt.Errorf("QUEUE_BACKEND=kafka is not supported by this build; use nats or local")
+	default:
+		return nil, fmt.Errorf("unknown QUEUE_BACKEND %q (expected local or nats)", cfg.Backend)
+	}
+}
+
+// LocalEventBus is an in-process EventBus
+// Decision: Delivery happens on one background goroutine, in publish order, so a slow subscriber
+// never delays the request that published and subscribers never race each other
+type LocalEventBus struct {
+	handlersMu sync.RWMutex
+	handlers   map[string][]EventHandler
+
+	// Decision: Guards closed separately from handlers so a Publish blocked on a full queue can't stall delivery
+	mu     sync.RWMutex
+	queue  chan Event
+	closed bool
+	done   chan struct{}
+}
+
+// NewLocalEventBus creates an in-process event bus and starts delivering; buffer 0 uses the default
+func NewLocalEventBus(buffer int) *LocalEventBus {
+	if buffer <= 0 {
+		buffer = defaultEventBuffer
+	}
+	bus := &LocalEventBus{
+		handlers: make(map[string][]EventHandler),
+		queue:    make(chan Event, buffer),
+		done:     make(chan struct{}),
+	}
+	go bus.deliver()
+	return bus
+}
+
+// Subscribe registers handler for every later event of eventType
+func (b *LocalEventBus) Subscribe(eventType string, handler EventHandler) {
+	b.handlersMu.Lock()
+	defer b.handlersMu.Unlock()
+	b.handlers[eventType] = append(b.handlers[eventType], handler)
+}
+
+// Publish queues an event for delivery, stamping it with the current time if unset
+// Decision: A full queue blocks the publisher rather than dropping events; events after Close are dropped
+func (b *LocalEventBus) Publish(event Event) {
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		slog.Warn("Dropping event, event bus closed", "type", event.Type, "user_id", event.UserID)
+		return
+	}
+	b.queue <- event
+}
+
+// Close stops accepting events and waits until every queued event has been delivered
+func (b *LocalEventBus) Close() {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.queue)
+	}
+	b.mu.Unlock()
+	<-b.done
+}
+
+// deliver hands each queued event to its subscribers until the bus is closed
+func (b *LocalEventBus) deliver() {
+	defer close(b.done)
+	for event := range b.queue {
+		b.handlersMu.RLock()
+		handlers := b.handlers[event.Type]
+		b.handlersMu.RUnlock()
+		for _, handler := range handlers {
+			callEventHandler(handler, event)
+		}
+	}
+}
+
+// callEventHandler runs one subscriber, keeping its failure or panic away from the others
+func callEventHandler(handler EventHandler, event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("Event subscriber panicked", "type", event.Type, "user_id", event.UserID, "panic", r)
+		}
+	}()
+	if err := handler(event); err != nil {
+		slog.Error("Event subscriber failed", "type", event.Type, "user_id", event.UserID, "error", err)
+	}
+}
+
+// publishEvent publishes on bus when one is configured
+func publishEvent(bus EventBus, event Event) {
+	if bus != nil {
+		bus.Publish(event)
+	}
+}
+
+// SubscribeNotifications tells users in-app when their report's analysis is ready
+// Decision: Uploads are analyzed in the background, possibly by cmd/worker, so the
+// patient learns the result is ready without keeping the report page open
+func SubscribeNotifications(bus EventBus, notificationRepo models.NotificationRepository, reportRepo models.ReportRepository) {
+	bus.Subscribe(EventAnalysisCompleted, func(event Event) error {
+		report, err := reportRepo.GetByID(event.ReportID)
+		if err != nil {
+			return err
+		}
+		// Deleted or handed to someone else before the event was delivered
+		if report == nil || report.UserID != event.UserID {
+			return nil
+		}
+		return notificationRepo.Create(&models.Notification{
+			UserID:  event.UserID,
+			Kind:    models.NotificationAnalysisReady,
+			Message: fmt.Sprintf("The analysis of %q is ready.", reportDisplayName(report)),
+		})
+	})
+}
+
+// SubscribeAuditLog records report and chat activity in the audit log
+// Decision: The user is both actor and subject; impersonated requests are already audited by the auth middleware
+func SubscribeAuditLog(bus EventBus, auditRepo models.AuditLogRepository) {
+	record := func(event Event) error {
+		details := fmt.Sprintf("report %d", event.ReportID)
+		if event.MessageID != 0 {
+			details += fmt.Sprintf(", message %d", event.MessageID)
+		}
+		return auditRepo.Create(&models.AuditLog{
+			ActorID: event.UserID,
+			UserID:  event.UserID,
+			Action:  event.Type,
+			Details: details,
+		})
+	}
+	for _, eventType := range []string{EventReportUploaded, EventAnalysisCompleted, EventChatCreated} {
+		bus.Subscribe(eventType, record)
+	}
+}
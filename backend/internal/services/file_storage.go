@@ -0,0 +1,169 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// How the uploaded name of a report is kept; files on disk are always named by UUID
+const (
+	FilenamePolicyPreserve      = "preserve"       // Keep the name as uploaded, in any script
+	FilenamePolicyExtensionOnly = "extension_only" // Keep only the extension, for deployments where names identify patients
+)
+
+// maxOriginalFilenameBytes caps stored names at what common filesystems accept for a download
+const maxOriginalFilenameBytes = 255
+
+// ErrUnsafeFilePath is returned when a stored path resolves outside the upload directory
+var ErrUnsafeFilePath = fmt.Errorf("file path is outside the upload directory")
+
+// FileStorage lays out uploads as {baseDir}/{userHash}/{uuid}.ext and guards every path it touches
+// Decision: Keyed user hashes and random names make files unguessable even if the
+// upload directory is ever exposed; the guard stops tampered DB rows reaching other files
+type FileStorage struct {
+	baseDir        string
+	secret         []byte
+	filenamePolicy string
+}
+
+// NewFileStorage creates file storage rooted at baseDir
+func NewFileStorage(baseDir, secret string) *FileStorage {
+	return &FileStorage{
+		baseDir:        baseDir,
+		secret:         []byte(secret),
+		filenamePolicy: FilenamePolicyPreserve,
+	}
+}
+
+// SetFilenamePolicy chooses how OriginalFilename keeps uploaded names; empty keeps the default, preserve
+func (fs *FileStorage) SetFilenamePolicy(policy string) error {
+	switch policy {
+	case "":
+		fs.filenamePolicy = FilenamePolicyPreserve
+	case FilenamePolicyPreserve, FilenamePolicyExtensionOnly:
+		fs.filenamePolicy = policy
+	default:
+		return fmt.Errorf("UPLOAD_FILENAME_POLICY must be %s or %s, got %q", FilenamePolicyPreserve, FilenamePolicyExtensionOnly, policy)
+	}
+	return nil
+}
+
+// OriginalFilename returns the name to store and show for an upload under the filename policy
+// Decision: Names are stored for display and downloads only, so every script survives; only what
+// could hide the real extension or break a header is removed. Zero-width joiners stay, since
+// Malayalam, Kannada, and other Indic scripts need them to spell words correctly
+func (fs *FileStorage) OriginalFilename(name string) string {
+	name = strings.ToValidUTF8(name, "")
+	// Some clients send the full client-side path, with either separator
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || unicode.Is(unicode.Bidi_Control, r) {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+
+	ext := filepath.Ext(name)
+	stem := strings.TrimSpace(strings.TrimSuffix(name, ext))
+	if len(ext) >= maxOriginalFilenameBytes {
+		stem, ext = name, ""
+	}
+	if stem == "" || fs.filenamePolicy == FilenamePolicyExtensionOnly {
+		stem, ext = "report", strings.ToLower(ext)
+	}
+	// Cut whole characters, never bytes, so a long Devanagari name stays valid UTF-8
+	for len(stem)+len(ext) > maxOriginalFilenameBytes {
+		_, size := utf8.DecodeLastRuneInString(stem)
+		stem = stem[:len(stem)-size]
+	}
+	return stem + ext
+}
+
+// UserDir returns the directory holding a user's uploads
+func (fs *FileStorage) UserDir(userID int) string {
+	mac := hmac.New(sha256.New, fs.secret)
+	mac.Write([]byte(strconv.Itoa(userID)))
+	return filepath.Join(fs.baseDir, hex.EncodeToString(mac.Sum(nil))[:32])
+}
+
+// NewFilePath creates the user's directory and returns a fresh path for an upload
+// Decision: Only the extension of the original name is kept - user input never reaches the filesystem
+func (fs *FileStorage) NewFilePath(userID int, originalFilename string) (string, error) {
+	dir := fs.UserDir(userID)
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return "", fmt.Errorf("failed to create upload directory: %w", err)
+	}
+
+	ext := strings.ToLower(filepath.Ext(originalFilename))
+	return filepath.Join(dir, uuid.NewString()+ext), nil
+}
+
+// Resolve returns the cleaned absolute form of a stored path, rejecting traversal outside baseDir
+func (fs *FileStorage) Resolve(path string) (string, error) {
+	base, err := filepath.Abs(fs.baseDir)
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	rel, err := filepath.Rel(base, abs)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", ErrUnsafeFilePath
+	}
+
+	return abs, nil
+}
+
+// IsUserPath reports whether path already follows the per-user layout for userID
+func (fs *FileStorage) IsUserPath(userID int, path string) bool {
+	resolved, err := fs.Resolve(path)
+	if err != nil {
+		return false
+	}
+	userDir, err := filepath.Abs(fs.UserDir(userID))
+	if err != nil {
+		return false
+	}
+	return filepath.Dir(resolved) == userDir
+}
+
+// FileID returns an opaque, stable identifier for a stored path that is safe to show clients
+func (fs *FileStorage) FileID(path string) string {
+	mac := hmac.New(sha256.New, fs.secret)
+	mac.Write([]byte("file:" + path))
+	return hex.EncodeToString(mac.Sum(nil))[:24]
+}
+
+// Open opens a stored file for reading after checking it lives under baseDir
+func (fs *FileStorage) Open(path string) (*os.File, error) {
+	resolved, err := fs.Resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(resolved)
+}
+
+// Remove deletes a stored file after checking it lives under baseDir
+func (fs *FileStorage) Remove(path string) error {
+	resolved, err := fs.Resolve(path)
+	if err != nil {
+		return err
+	}
+	return os.Remove(resolved)
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+// FileTypeSpec describes how to recognize one uploadable file type
+type FileTypeSpec struct {
+	Extension string
+	MIMETypes []string
+	Magic     [][]byte // Any matching prefix is accepted; empty means no signature check
+	Text      bool     // Plain text has no signature, so reject binary content instead
+	Sniffed   []string // Acceptable http.DetectContentType results for this type
+}
+
+// executableSignatures catch binaries and scripts renamed to an allowed extension
+var executableSignatures = [][]byte{
+	[]byte("MZ"),             // Windows PE
+	[]byte("\x7fELF"),        // Linux ELF
+	{0xFE, 0xED, 0xFA, 0xCE}, // Mach-O 32-bit
+	{0xFE, 0xED, 0xFA, 0xCF}, // Mach-O 64-bit
+	{0xCF, 0xFA, 0xED, 0xFE}, // Mach-O 64-bit little-endian
+	{0xCA, 0xFE, 0xBA, 0xBE}, // Mach-O universal / Java class
+	[]byte("#!"),             // Shell script
+}
+
+// knownFileTypes lists every extension a deployment may enable via ALLOWED_FILE_EXTENSIONS
+// Decision: Enabling a type only lets it be uploaded; text extraction must support it too
+var knownFileTypes = map[string]FileTypeSpec{
+	".pdf": {Extension: ".pdf", MIMETypes: []string{"application/pdf"},
+		Magic: [][]byte{[]byte("%PDF-")}, Sniffed: []string{"application/pdf"}},
+	".txt": {Extension: ".txt", MIMETypes: []string{"text/plain"}, Text: true,
+		Sniffed: []string{"text/plain"}},
+	".docx": {Extension: ".docx", MIMETypes: []string{"application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+		Magic: [][]byte{[]byte("PK\x03\x04")}, Sniffed: []string{"application/zip"}},
+	".doc": {Extension: ".doc", MIMETypes: []string{"application/msword"},
+		Magic: [][]byte{{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}}, Sniffed: []string{"application/octet-stream"}},
+	".png": {Extension: ".png", MIMETypes: []string{"image/png"},
+		Magic: [][]byte{{0x89, 'P', 'N', 'G', '\r', '\n', 0x1A, '\n'}}, Sniffed: []string{"image/png"}},
+	".jpg": {Extension: ".jpg", MIMETypes: []string{"image/jpeg"},
+		Magic: [][]byte{{0xFF, 0xD8, 0xFF}}, Sniffed: []string{"image/jpeg"}},
+	".jpeg": {Extension: ".jpeg", MIMETypes: []string{"image/jpeg"},
+		Magic: [][]byte{{0xFF, 0xD8, 0xFF}}, Sniffed: []string{"image/jpeg"}},
+}
+
+// FileValidator checks uploads against the configured extensions, MIME types, and signatures
+type FileValidator struct {
+	specs        map[string]FileTypeSpec
+	allowedTypes map[string]bool
+	maxFileSize  int64
+}
+
+// NewFileValidator builds a validator from upload configuration
+// Decision: Unknown extensions fail at startup rather than silently accepting unchecked files
+func NewFileValidator(cfg config.UploadConfig) (*FileValidator, error) {
+	fv := &FileValidator{
+		specs:        make(map[string]FileTypeSpec),
+		allowedTypes: make(map[string]bool),
+		maxFileSize:  cfg.MaxFileSize,
+	}
+
+	for _, ext := range cfg.AllowedExtensions {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		spec, ok := knownFileTypes[ext]
+		if !ok {
+			return nil, fmt.Errorf("unsupported upload extension %q", ext)
+		}
+		fv.specs[ext] = spec
+	}
+
+	if len(fv.specs) == 0 {
+		return nil, fmt.Errorf("no upload extensions configured")
+	}
+
+	// Decision: An empty MIME list means "whatever the allowed extensions use"
+	if len(cfg.AllowedTypes) == 0 {
+		for _, spec := range fv.specs {
+			for _, mimeType := range spec.MIMETypes {
+				fv.allowedTypes[mimeType] = true
+			}
+		}
+	} else {
+		for _, mimeType := range cfg.AllowedTypes {
+			fv.allowedTypes[strings.ToLower(strings.TrimSpace(mimeType))] = true
+		}
+	}
+
+	return fv, nil
+}
+
+// Validate checks the file size, extension, declared Content-Type, and leading bytes
+func (fv *FileValidator) Validate(filename, contentType string, size int64, header []byte) error {
+	if fv.maxFileSize > 0 && size > fv.maxFileSize {
+		return errors.NewValidationError(fmt.Sprintf("File size exceeds maximum limit of %dMB", fv.maxFileSize/(1024*1024)))
+	}
+
+	ext := strings.ToLower(filepath.Ext(filename))
+	// Decision: Refused with the extractor's explanation even where enabled, since no .doc upload could ever be analyzed
+	if ext == ".doc" {
+		return errors.NewValidationError(unreadableDocReason)
+	}
+	spec, ok := fv.specs[ext]
+	if !ok {
+		return errors.NewValidationError("File type not supported. Allowed types: " + strings.Join(fv.AllowedExtensions(), ", "))
+	}
+
+	// Decision: Compare the bare media type so "text/plain; charset=utf-8" still matches
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || !fv.allowedTypes[strings.ToLower(mediaType)] {
+		return errors.NewValidationError("Invalid file content type")
+	}
+
+	// Decision: Client-supplied extension and Content-Type are both spoofable,
+	// so they must agree with each other and with the sniffed content
+	if !containsFold(spec.MIMETypes, mediaType) {
+		return errors.ErrFileTypeMismatch
+	}
+	if isExecutable(header) || !matchesSignature(spec, header) || !matchesSniffedType(spec, header) {
+		return errors.ErrFileTypeMismatch
+	}
+
+	return nil
+}
+
+// AllowedExtensions returns the enabled extensions in a stable order for error messages
+func (fv *FileValidator) AllowedExtensions() []string {
+	var extensions []string
+	for ext := range fv.specs {
+		extensions = append(extensions, ext)
+	}
+	sort.Strings(extensions)
+	return extensions
+}
+
+// matchesSniffedType checks http.DetectContentType's verdict on the first 512 bytes
+func matchesSniffedType(spec FileTypeSpec, header []byte) bool {
+	if len(spec.Sniffed) == 0 || len(header) == 0 {
+		return true
+	}
+	sniffed, _, err := mime.ParseMediaType(http.DetectContentType(header))
+	if err != nil {
+		return false
+	}
+	return containsFold(spec.Sniffed, sniffed)
+}
+
+// isExecutable reports whether header starts with a known binary or script signature
+func isExecutable(header []byte) bool {
+	for _, signature := range executableSignatures {
+		if bytes.HasPrefix(header, signature) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsFold reports whether values contains target, ignoring case
+func containsFold(values []string, target string) bool {
+	for _, value := range values {
+		if strings.EqualFold(value, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesSignature reports whether header starts like the given file type
+func matchesSignature(spec FileTypeSpec, header []byte) bool {
+	if spec.Text {
+		return !bytes.ContainsRune(header, 0)
+	}
+	if len(spec.Magic) == 0 {
+		return true
+	}
+	for _, magic := range spec.Magic {
+		if bytes.HasPrefix(header, magic) {
+			return true
+		}
+	}
+	return false
+}
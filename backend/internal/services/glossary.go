@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+// maxGlossaryTermLength bounds a looked-up term; real lab terms are far shorter
+const maxGlossaryTermLength = 64
+
+// maxGlossaryDefinitionLength caps what is stored from the model
+const maxGlossaryDefinitionLength = 600
+
+// glossaryTermPattern accepts letters and digits with the punctuation lab names use, e.g. "HbA1c" or "T3 (free)"
+var glossaryTermPattern = regexp.MustCompile(`^[\p{L}\p{N}][\p{L}\p{N} .,'()/+-]*$`)
+
+// builtinGlossary defines common lab terms without a model call; keys are GlossaryKey-normalized
+// Decision: Also used to annotate summaries, so analyses link the usual jargon even before anyone looks it up
+var builtinGlossary = map[string]string{
+	"hdl":               "High-density lipoprotein, often called \"good\" cholesterol. It carries cholesterol away from your arteries, so higher levels are usually better.",
+	"ldl":               "Low-density lipoprotein, often called \"bad\" cholesterol. Too much can build up in the walls of your arteries.",
+	"triglycerides":     "A type of fat in your blood that your body uses for energy. High levels can raise the risk of heart disease.",
+	"cholesterol":       "A waxy substance your body needs to build cells. Too much in the blood can narrow the arteries.",
+	"hemoglobin":        "The protein in red blood cells that carries oxygen around your body. Low levels can make you feel tired.",
+	"hba1c":             "A blood test showing your average blood sugar over the past two to three months. It is used to check for diabetes.",
+	"tsh":               "Thyroid-stimulating hormone. It tells your thyroid how much hormone to make, so it shows whether your thyroid is under- or overactive.",
+	"free t4":           "The main hormone made by your thyroid that is available for your body to use. It helps control your energy and metabolism.",
+	"platelets":         "Tiny blood cells that help your blood clot and stop bleeding.",
+	"white blood cells": "Cells that help your body fight infections.",
+	"creatinine":        "A waste product filtered out by your kidneys. Its level in the blood shows how well your kidneys are working.",
+	"egfr":              "Estimated glomerular filtration rate. A number calculated from creatinine that shows how well your kidneys filter blood.",
+	"mcv":               "Mean corpuscular volume, the average size of your red blood cells. It helps find the cause of anemia.",
+	"bilirubin":         "A yellow substance made when old red blood cells break down. High levels can point to liver problems.",
+}
+
+// GlossaryRef links a jargon term in a summary to its glossary entry
+type GlossaryRef struct {
+	Term string `json:"term"` // Exactly as written in the summary, so the frontend can find it
+	Key  string `json:"key"`  // Value for GET /api/glossary?term=
+}
+
+// UnmarshalJSON also accepts a bare string, which is how the analysis prompt asks the model to list terms
+func (g *GlossaryRef) UnmarshalJSON(data []byte) error {
+	var term string
+	if err := json.Unmarshal(data, &term); err == nil {
+		*g = GlossaryRef{Term: term, Key: models.GlossaryKey(term)}
+		return nil
+	}
+
+	type plain GlossaryRef
+	return json.Unmarshal(data, (*plain)(g))
+}
+
+// AnnotateGlossaryTerms returns references for candidates and built-in terms that occur in text, in order of appearance
+func AnnotateGlossaryTerms(text string, candidates []string) []GlossaryRef {
+	terms := append([]string{}, candidates...)
+	for key := range builtinGlossary {
+		terms = append(terms, key)
+	}
+
+	type match struct {
+		ref GlossaryRef
+		pos int
+	}
+	seen := make(map[string]bool)
+	var matches []match
+	for _, term := range terms {
+		key := models.GlossaryKey(term)
+		if key == "" || seen[key] || !glossaryTermPattern.MatchString(key) {
+			continue
+		}
+		// Decision: Only whole-word matches, so "alt" never links inside "salt"
+		pattern := regexp.MustCompile(`(?i)\b` + strings.ReplaceAll(regexp.QuoteMeta(key), " ", `\s+`) + `\b`)
+		loc := pattern.FindStringIndex(text)
+		if loc == nil {
+			continue
+		}
+		seen[key] = true
+		matches = append(matches, match{ref: GlossaryRef{Term: text[loc[0]:loc[1]], Key: key}, pos: loc[0]})
+	}
+
+	sort.Slice(matches, func(i, j int) bool { return matches[i].pos < matches[j].pos })
+	refs := make([]GlossaryRef, len(matches))
+	for i, m := range matches {
+		refs[i] = m.ref
+	}
+	return refs
+}
+
+// GlossaryDefiner writes a plain-language definition of a medical term
+// Decision: Implemented by AIService and by DemoAnalyzer, like ChatResponder
+type GlossaryDefiner interface {
+	// DefineTerm returns errors.ErrGlossaryTermUnknown when term is not a medical term
+	DefineTerm(term string) (string, error)
+}
+
+// GlossaryService looks up definitions, generating and storing them on first miss
+type GlossaryService struct {
+	repo    models.GlossaryRepository
+	definer GlossaryDefiner
+}
+
+// NewGlossaryService creates a glossary service; a nil definer limits lookups to stored and built-in terms
+func NewGlossaryService(repo models.GlossaryRepository, definer GlossaryDefiner) *GlossaryService {
+	return &GlossaryService{
+		repo:    repo,
+		definer: definer,
+	}
+}
+
+// Lookup returns the definition of term from the store, the built-in list, or the model, in that order
+func (gs *GlossaryService) Lookup(term string) (*models.GlossaryTerm, error) {
+	term = strings.Join(strings.Fields(term), " ")
+	if term == "" || utf8.RuneCountInString(term) > maxGlossaryTermLength || !glossaryTermPattern.MatchString(term) {
+		return nil, errors.NewValidationError(fmt.Sprintf("Term must be 1-%d letters, digits, or simple punctuation", maxGlossaryTermLength))
+	}
+	key := models.GlossaryKey(term)
+
+	stored, err := gs.repo.GetByKey(key)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if stored != nil {
+		return stored, nil
+	}
+
+	entry := &models.GlossaryTerm{Key: key, Term: term}
+	if definition, ok := builtinGlossary[key]; ok {
+		entry.Definition = definition
+		entry.Source = models.GlossarySourceBuiltin
+	} else {
+		if gs.definer == nil {
+			return nil, errors.ErrAIUnavailable
+		}
+		definition, err := gs.definer.DefineTerm(term)
+		if err != nil {
+			if appErr, ok := err.(*errors.AppError); ok {
+				return nil, appErr
+			}
+			slog.Warn("Failed to define glossary term", "error", err)
+			return nil, errors.ErrAIProcessingFailed
+		}
+		entry.Definition = definition
+		entry.Source = models.GlossarySourceAI
+	}
+
+	if err := gs.repo.Create(entry); err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	return entry, nil
+}
+
+// DefineTerm asks the model for a short patient-friendly definition
+func (ai *AIService) DefineTerm(term string) (string, error) {
+	prompt := fmt.Sprintf(`Define the medical term %q for a patient reading their lab report.
+Use plain language in at most two sentences. Do not give advice.
+If it is not a medical or laboratory term, reply with exactly UNKNOWN.`, term)
+
+	definition, err := ai.generateText(context.Background(), AICallGlossary, prompt)
+	if err != nil {
+		return "", err
+	}
+	return cleanGlossaryDefinition(definition)
+}
+
+// DefineTerm gives a placeholder definition so glossary links work in demo deployments
+func (da *DemoAnalyzer) DefineTerm(term string) (string, error) {
+	return fmt.Sprintf("In the live app, the assistant explains %q here in plain language.", term), nil
+}
+
+// cleanGlossaryDefinition rejects refusals and trims overlong model output
+func cleanGlossaryDefinition(definition string) (string, error) {
+	definition = strings.TrimSpace(definition)
+	if definition == "" || strings.EqualFold(strings.Trim(definition, ". "), "UNKNOWN") {
+		return "", errors.ErrGlossaryTermUnknown
+	}
+	if len(definition) > maxGlossaryDefinitionLength {
+		definition = strings.ToValidUTF8(definition[:maxGlossaryDefinitionLength], "") + "..."
+	}
+	return definition, nil
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+// Limits on the free-text lists in a profile
+const (
+	maxProfileListItems  = 20
+	maxProfileItemLength = 100
+)
+
+// HealthProfileService manages the optional health details that personalize analyses and chat
+type HealthProfileService struct {
+	profileRepo models.HealthProfileRepository
+}
+
+// NewHealthProfileService creates a new profile service
+func NewHealthProfileService(profileRepo models.HealthProfileRepository) *HealthProfileService {
+	return &HealthProfileService{profileRepo: profileRepo}
+}
+
+// Get returns the user's profile; a user who never saved one gets an empty profile
+func (ps *HealthProfileService) Get(userID int) (*models.HealthProfile, error) {
+	profile, err := ps.profileRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if profile == nil {
+		profile = &models.HealthProfile{UserID: userID, Conditions: []string{}, Allergies: []string{}, Medications: []string{}}
+	}
+	return profile, nil
+}
+
+// Update validates and replaces the user's profile; unset fields are cleared
+func (ps *HealthProfileService) Update(userID int, profile *models.HealthProfile) (*models.HealthProfile, error) {
+	profile.UserID = userID
+
+	if profile.DateOfBirth != nil {
+		dob := *profile.DateOfBirth
+		if dob.After(time.Now()) || dob.Year() < 1900 {
+			return nil, errors.NewValidationError("Date of birth must be between 1900 and today")
+		}
+	}
+	switch profile.Sex {
+	case "", models.SexMale, models.SexFemale, models.SexOther:
+	default:
+		return nil, errors.NewValidationError("Sex must be male, female, or other")
+	}
+	if profile.BloodGroup != "" && !slices.Contains(models.BloodGroups, profile.BloodGroup) {
+		return nil, errors.NewValidationError("Blood group must be one of " + strings.Join(models.BloodGroups, ", "))
+	}
+	if profile.HeightCm != nil && (*profile.HeightCm < 30 || *profile.HeightCm > 275) {
+		return nil, errors.NewValidationError("Height must be between 30 and 275 cm")
+	}
+	if profile.WeightKg != nil && (*profile.WeightKg < 1 || *profile.WeightKg > 650) {
+		return nil, errors.NewValidationError("Weight must be between 1 and 650 kg")
+	}
+
+	var err error
+	if profile.Conditions, err = cleanProfileList("conditions", profile.Conditions); err != nil {
+		return nil, err
+	}
+	if profile.Allergies, err = cleanProfileList("allergies", profile.Allergies); err != nil {
+		return nil, err
+	}
+	if profile.Medications, err = cleanProfileList("medications", profile.Medications); err != nil {
+		return nil, err
+	}
+
+	if err := ps.profileRepo.Upsert(profile); err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	return profile, nil
+}
+
+// Delete clears the user's profile
+func (ps *HealthProfileService) Delete(userID int) error {
+	if err := ps.profileRepo.Delete(userID); err == models.ErrLegalHold {
+		return errors.ErrLegalHold
+	} else if err != nil {
+		return errors.ErrDatabaseConnection
+	}
+	return nil
+}
+
+// cleanProfileList trims entries, drops blanks and case-insensitive duplicates, and enforces the limits
+func cleanProfileList(field string, items []string) ([]string, error) {
+	cleaned := make([]string, 0, len(items))
+	seen := make(map[string]bool, len(items))
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		key := strings.ToLower(item)
+		if item == "" || seen[key] {
+			continue
+		}
+		if len(item) > maxProfileItemLength {
+			return nil, errors.NewValidationError(fmt.Sprintf("Each of %s must be at most %d characters", field, maxProfileItemLength))
+		}
+		seen[key] = true
+		cleaned = append(cleaned, item)
+	}
+	if len(cleaned) > maxProfileListItems {
+		return nil, errors.NewValidationError(fmt.Sprintf("List at most %d %s", maxProfileListItems, field))
+	}
+	return cleaned, nil
+}
+
+// PatientContext describes a profile for prompts, or returns "" when there is nothing to say
+// Decision: Only what the user entered is included; the name and email never reach the model
+func PatientContext(profile *models.HealthProfile, now time.Time) string {
+	if profile.IsEmpty() {
+		return ""
+	}
+
+	var parts []string
+	if age := profile.Age(now); age != nil {
+		parts = append(parts, fmt.Sprintf("age %d", *age))
+	}
+	if profile.Sex != "" {
+		parts = append(parts, "sex "+profile.Sex)
+	}
+	if profile.HeightCm != nil {
+		parts = append(parts, fmt.Sprintf("height %g cm", *profile.HeightCm))
+	}
+	if profile.WeightKg != nil {
+		parts = append(parts, fmt.Sprintf("weight %g kg", *profile.WeightKg))
+	}
+	if len(profile.Conditions) > 0 {
+		parts = append(parts, "known conditions: "+strings.Join(profile.Conditions, ", "))
+	}
+	if len(profile.Allergies) > 0 {
+		parts = append(parts, "allergies: "+strings.Join(profile.Allergies, ", "))
+	}
+	if len(profile.Medications) > 0 {
+		parts = append(parts, "current medications: "+strings.Join(profile.Medications, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
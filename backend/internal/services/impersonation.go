@@ -0,0 +1,111 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// ImpersonationService lets support staff act as a user to reproduce problems
+// Decision: Every grant is audited and the user is told, so access is never silent
+type ImpersonationService struct {
+	userRepo         models.UserRepository
+	auditRepo        models.AuditLogRepository
+	notificationRepo models.NotificationRepository
+	jwtService       *JWTService
+	ttl              time.Duration
+	adminEmails      map[string]bool
+}
+
+// NewImpersonationService creates a new impersonation service
+func NewImpersonationService(
+	userRepo models.UserRepository,
+	auditRepo models.AuditLogRepository,
+	notificationRepo models.NotificationRepository,
+	jwtService *JWTService,
+	ttl time.Duration,
+	adminEmails []string,
+) *ImpersonationService {
+	if ttl <= 0 {
+		ttl = 15 * time.Minute
+	}
+
+	admins := make(map[string]bool, len(adminEmails))
+	for _, email := range adminEmails {
+		admins[strings.ToLower(strings.TrimSpace(email))] = true
+	}
+
+	return &ImpersonationService{
+		userRepo:         userRepo,
+		auditRepo:        auditRepo,
+		notificationRepo: notificationRepo,
+		jwtService:       jwtService,
+		ttl:              ttl,
+		adminEmails:      admins,
+	}
+}
+
+// Start issues a token that acts as targetID on behalf of admin
+func (is *ImpersonationService) Start(admin *models.User, targetID int, reason string) (*types.ImpersonationResponse, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.NewValidationError("A reason is required to impersonate a user")
+	}
+	if len(reason) > 500 {
+		return nil, errors.NewValidationError("Reason must be at most 500 characters")
+	}
+	if targetID == admin.ID {
+		return nil, errors.NewValidationError("You cannot impersonate yourself")
+	}
+
+	target, err := is.userRepo.GetByID(targetID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if target == nil || !target.IsActive {
+		return nil, errors.ErrUserNotFound
+	}
+	// Decision: Admin accounts can't be impersonated so support can't borrow another operator's privileges
+	if is.adminEmails[strings.ToLower(target.Email)] {
+		return nil, errors.ErrAccessDenied
+	}
+
+	token, expiresAt, err := is.jwtService.GenerateImpersonationToken(target.ID, target.Email, admin.ID, is.ttl)
+	if err != nil {
+		return nil, errors.ErrInvalidToken
+	}
+
+	// Decision: Refuse to hand out the token if the grant can't be recorded
+	entry := &models.AuditLog{
+		ActorID:      admin.ID,
+		UserID:       target.ID,
+		Action:       models.AuditImpersonationStarted,
+		Impersonated: true,
+		Details:      reason,
+	}
+	if err := is.auditRepo.Create(entry); err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+
+	notification := &models.Notification{
+		UserID: target.ID,
+		Kind:   models.NotificationImpersonation,
+		Message: fmt.Sprintf("A support team member accessed your account on %s to help resolve an issue. "+
+			"Access ends by %s.", time.Now().In(target.Location()).Format("Jan 2, 2006 15:04 MST"),
+			expiresAt.In(target.Location()).Format("15:04 MST")),
+	}
+	if err := is.notificationRepo.Create(notification); err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+
+	return &types.ImpersonationResponse{
+		Token:          token,
+		ExpiresAt:      expiresAt.In(admin.Location()),
+		User:           ToUserResponse(target),
+		ImpersonatorID: admin.ID,
+	}, nil
+}
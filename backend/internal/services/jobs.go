@@ -0,0 +1,313 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+// QueueOverview is the processing queue as operators see it during an incident
+type QueueOverview struct {
+	State         *models.QueueState
+	Counts        map[string]int
+	FailureCauses map[string]int // Failed reports per error code
+	Drained       bool           // Paused with nothing left processing, so maintenance can start
+	Jobs          []*QueueJob
+}
+
+// QueueJob is a queued report and whether it looks stuck
+type QueueJob struct {
+	*models.QueueJob
+	Stuck bool
+}
+
+// JobDetail is one report's processing history
+type JobDetail struct {
+	Report    *models.Report
+	Attempts  []*models.ProcessingAttempt
+	LastError string
+	Stuck     bool
+}
+
+// ReportHistory is the processing history a report's owner sees
+type ReportHistory struct {
+	Report      *models.Report
+	Transitions []*models.ReportStatusEvent
+	Attempts    []*models.ProcessingAttempt
+}
+
+// ReportStatus is how far a report has got through processing, for its owner to poll
+type ReportStatus struct {
+	Report        *models.Report
+	QueuePosition int        // 1 when the report is next; 0 unless it is pending and due
+	RetryAt       *time.Time // When a pending report waiting out a retry becomes due
+	QueuePaused   bool       // Pending reports wait until an operator resumes the queue
+	Attempts      int
+	StartedAt     *time.Time // When the latest attempt started
+}
+
+// JobService backs the operator runbook: inspecting, retrying, and cancelling jobs, and pausing the queue
+// Decision: Jobs are reports, as in the worker, so every action is a status change on the report
+// plus an audit entry naming the operator
+type JobService struct {
+	reportRepo models.ReportRepository
+	jobRepo    models.JobRepository
+	auditRepo  models.AuditLogRepository
+	stuckAfter time.Duration
+}
+
+// NewJobService creates a new job service; reports processing longer than stuckAfter count as stuck
+func NewJobService(
+	reportRepo models.ReportRepository,
+	jobRepo models.JobRepository,
+	auditRepo models.AuditLogRepository,
+	stuckAfter time.Duration,
+) *JobService {
+	if stuckAfter <= 0 {
+		stuckAfter = 15 * time.Minute
+	}
+	return &JobService{
+		reportRepo: reportRepo,
+		jobRepo:    jobRepo,
+		auditRepo:  auditRepo,
+		stuckAfter: stuckAfter,
+	}
+}
+
+// Overview lists pending, processing, and failed reports along with the queue state
+func (js *JobService) Overview(statuses []string, limit int) (*QueueOverview, error) {
+	if len(statuses) == 0 {
+		statuses = []string{"pending", "processing", "failed"}
+	}
+	for _, status := range statuses {
+		if status != "pending" && status != "processing" && status != "failed" {
+			return nil, errors.NewValidationError("Status must be pending, processing, or failed")
+		}
+	}
+
+	state, err := js.jobRepo.GetQueueState()
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	counts, err := js.jobRepo.CountByStatus()
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	causes, err := js.jobRepo.CountFailuresByCode()
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	jobs, err := js.jobRepo.ListQueue(statuses, limit)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+
+	overview := &QueueOverview{
+		State:         state,
+		Counts:        counts,
+		FailureCauses: causes,
+		Drained:       state.Paused && counts["processing"] == 0,
+		Jobs:          make([]*QueueJob, len(jobs)),
+	}
+	for i, job := range jobs {
+		overview.Jobs[i] = &QueueJob{QueueJob: job, Stuck: js.isStuck(job.Status, job.UpdatedAt)}
+	}
+	return overview, nil
+}
+
+// Detail returns a report's attempts and the last error it hit
+func (js *JobService) Detail(reportID int) (*JobDetail, error) {
+	report, err := js.getReport(reportID)
+	if err != nil {
+		return nil, err
+	}
+
+	attempts, err := js.jobRepo.ListAttempts(reportID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+
+	detail := &JobDetail{
+		Report:   report,
+		Attempts: attempts,
+		Stuck:    js.isStuck(report.ProcessingStatus, report.UpdatedAt),
+	}
+	for i := len(attempts) - 1; i >= 0; i-- {
+		if attempts[i].Error != "" {
+			detail.LastError = attempts[i].Error
+			break
+		}
+	}
+	// Decision: Reports that failed before attempts were recorded, or were cancelled while waiting, have only the report's reason
+	if detail.LastError == "" && report.ProcessingStatus == "failed" {
+		detail.LastError = report.ErrorDetail
+	}
+	return detail, nil
+}
+
+// History returns the statuses a user's report went through and the attempts to analyze it
+// Decision: Attempt errors are the pipeline's internal messages and stay operator-only;
+// owners get the failure reasons recorded on their status transitions instead
+func (js *JobService) History(user *models.User, reportID int) (*ReportHistory, error) {
+	report, err := js.getReport(reportID)
+	if err != nil {
+		return nil, err
+	}
+	if report.UserID != user.ID {
+		return nil, errors.ErrAccessDenied
+	}
+
+	transitions, err := js.reportRepo.ListStatusEvents(reportID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	attempts, err := js.jobRepo.ListAttempts(reportID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	for _, attempt := range attempts {
+		attempt.Error = ""
+	}
+
+	return &ReportHistory{Report: report, Transitions: transitions, Attempts: attempts}, nil
+}
+
+// Status returns where the user's report is in processing
+// Decision: Cheap enough to poll every few seconds: the report, its queue position, and its attempts, no transitions
+func (js *JobService) Status(user *models.User, reportID int) (*ReportStatus, error) {
+	report, err := js.getReport(reportID)
+	if err != nil {
+		return nil, err
+	}
+	if report.UserID != user.ID {
+		return nil, errors.ErrAccessDenied
+	}
+
+	status := &ReportStatus{Report: report}
+	if report.ProcessingStatus == "pending" {
+		position, err := js.jobRepo.GetQueuePosition(reportID)
+		if err != nil {
+			return nil, errors.ErrDatabaseConnection
+		}
+		if position != nil && position.RetryAt != nil && position.RetryAt.After(time.Now()) {
+			status.RetryAt = position.RetryAt
+		} else if position != nil {
+			status.QueuePosition = position.Ahead + 1
+		}
+
+		state, err := js.jobRepo.GetQueueState()
+		if err != nil {
+			return nil, errors.ErrDatabaseConnection
+		}
+		status.QueuePaused = state.Paused
+	}
+
+	attempts, err := js.jobRepo.ListAttempts(reportID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	status.Attempts = len(attempts)
+	if len(attempts) > 0 {
+		status.StartedAt = &attempts[len(attempts)-1].StartedAt
+	}
+
+	return status, nil
+}
+
+// Retry puts a failed or stuck report back in the queue, for the next worker poll to pick up
+// An operator's retry is one more attempt; it isn't retried automatically if it times out again
+func (js *JobService) Retry(admin *models.User, reportID int) error {
+	report, err := js.getReport(reportID)
+	if err != nil {
+		return err
+	}
+	if report.ProcessingStatus != "failed" && !js.isStuck(report.ProcessingStatus, report.UpdatedAt) {
+		return errors.NewValidationError(fmt.Sprintf("Only failed or stuck jobs can be retried; this one is %s", report.ProcessingStatus))
+	}
+
+	if err := js.jobRepo.AbandonRunningAttempts(reportID, "Retried by an administrator"); err != nil {
+		return errors.ErrDatabaseConnection
+	}
+	if err := js.reportRepo.UpdateProcessingStatus(reportID, "pending", ""); err != nil {
+		return errors.ErrDatabaseConnection
+	}
+	js.audit(admin, report.UserID, models.AuditJobRetried, fmt.Sprintf("report %d", reportID))
+	return nil
+}
+
+// Cancel takes a pending or stuck report out of the queue, marking it failed with the operator's reason
+// Decision: A report already being analyzed can't be interrupted, so only jobs no process is working on can be cancelled
+func (js *JobService) Cancel(admin *models.User, reportID int, reason string) error {
+	report, err := js.getReport(reportID)
+	if err != nil {
+		return err
+	}
+	if report.ProcessingStatus != "pending" && !js.isStuck(report.ProcessingStatus, report.UpdatedAt) {
+		return errors.NewValidationError(fmt.Sprintf("Only pending or stuck jobs can be cancelled; this one is %s", report.ProcessingStatus))
+	}
+
+	message := "Processing cancelled by an administrator"
+	if reason = strings.TrimSpace(reason); reason != "" {
+		message += ": " + reason
+	}
+	if err := js.jobRepo.AbandonRunningAttempts(reportID, message); err != nil {
+		return errors.ErrDatabaseConnection
+	}
+	if err := js.reportRepo.MarkFailed(reportID, models.ProcessingErrorCancelled, message); err != nil {
+		return errors.ErrDatabaseConnection
+	}
+	js.audit(admin, report.UserID, models.AuditJobCancelled, fmt.Sprintf("report %d: %s", reportID, message))
+	return nil
+}
+
+// Pause stops workers and inline processing from starting new jobs; jobs already running finish
+func (js *JobService) Pause(admin *models.User, reason string) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return errors.NewValidationError("A reason is required to pause the queue")
+	}
+
+	if err := js.jobRepo.SetQueueState(true, reason, admin.ID); err != nil {
+		return errors.ErrDatabaseConnection
+	}
+	js.audit(admin, admin.ID, models.AuditQueuePaused, reason)
+	return nil
+}
+
+// Resume lets processing start again; workers pick up reports that arrived while paused on their next poll
+func (js *JobService) Resume(admin *models.User) error {
+	if err := js.jobRepo.SetQueueState(false, "", 0); err != nil {
+		return errors.ErrDatabaseConnection
+	}
+	js.audit(admin, admin.ID, models.AuditQueueResumed, "")
+	return nil
+}
+
+// isStuck reports whether a processing job has gone too long without finishing
+func (js *JobService) isStuck(status string, updatedAt time.Time) bool {
+	return status == "processing" && time.Since(updatedAt) > js.stuckAfter
+}
+
+// getReport loads a report by ID
+func (js *JobService) getReport(reportID int) (*models.Report, error) {
+	report, err := js.reportRepo.GetByID(reportID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if report == nil {
+		return nil, errors.ErrRecordNotFound
+	}
+	return report, nil
+}
+
+// audit records an operator action; failures are logged rather than undoing the action
+func (js *JobService) audit(admin *models.User, userID int, action, details string) {
+	entry := &models.AuditLog{ActorID: admin.ID, UserID: userID, Action: action, Details: details}
+	if err := js.auditRepo.Create(entry); err != nil {
+		slog.Error("Failed to audit admin action", "action", action, "admin_id", admin.ID, "error", err)
+	}
+}
@@ -1,7 +1,10 @@
 package services
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -12,13 +15,24 @@ import (
 type JWTClaims struct {
 	UserID int    `json:"user_id"`
 	Email  string `json:"email"`
+
+	// ImpersonatorID is the admin acting as this user; zero for the user's own sessions
+	ImpersonatorID int `json:"impersonator_id,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// Impersonated reports whether the token was issued to an admin acting as the user
+func (c *JWTClaims) Impersonated() bool {
+	return c.ImpersonatorID != 0
+}
+
 // JWTService handles JWT token operations
 type JWTService struct {
-	secret     []byte        // Secret key for signing tokens
-	expiration time.Duration // Token expiration time
+	mu            sync.RWMutex
+	secret        []byte        // Secret key for signing tokens
+	previous      []byte        // Secret before the last rotation; nil when never rotated
+	previousUntil time.Time     // When the last token signed with previous expires
+	expiration    time.Duration // Token expiration time
 }
 
 // NewJWTService creates a new JWT service
@@ -51,7 +65,7 @@ func (js *JWTService) GenerateToken(userID int, email string) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
 	// Decision: Sign the token with our secret key
-	tokenString, err := token.SignedString(js.secret)
+	tokenString, err := token.SignedString(js.signingKey())
 	if err != nil {
 		return "", err
 	}
@@ -59,6 +73,100 @@ func (js *JWTService) GenerateToken(userID int, email string) (string, error) {
 	return tokenString, nil
 }
 
+// newTokenID returns a random jti, which names a sign-in's tokens in its session and on the revocation list
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// GenerateSessionToken creates a token naming its sign-in in the jti claim, returning when it expires
+func (js *JWTService) GenerateSessionToken(userID int, email, sessionID string) (string, time.Time, error) {
+	now := time.Now()
+	expirationTime := now.Add(js.expiration)
+
+	claims := &JWTClaims{
+		UserID: userID,
+		Email:  email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expirationTime),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Issuer:    "medical-report-backend",
+			ID:        sessionID,
+		},
+	}
+
+	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(js.signingKey())
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return tokenString, expirationTime, nil
+}
+
+// GenerateImpersonationToken creates a short-lived token that lets an admin act as a user
+// Decision: The admin's ID travels in the token so every request can be attributed without server state
+func (js *JWTService) GenerateImpersonationToken(userID int, email string, impersonatorID int, ttl time.Duration) (string, time.Time, error) {
+	tokenID, err := newTokenID()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	now := time.Now()
+	expirationTime := now.Add(ttl)
+
+	claims := &JWTClaims{
+		UserID:         userID,
+		Email:          email,
+		ImpersonatorID: impersonatorID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expirationTime),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Issuer:    "medical-report-backend",
+			Subject:   "impersonation",
+			ID:        tokenID,
+		},
+	}
+
+	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(js.signingKey())
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return tokenString, expirationTime, nil
+}
+
+// Rotate signs new tokens with secret
+// Decision: Tokens signed with the replaced secret stay valid until the last of them would have expired, so a
+// rotation doesn't sign everyone out; a second rotation within that time ends the grace for the oldest secret
+func (js *JWTService) Rotate(secret string) {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	if secret == "" || secret == string(js.secret) {
+		return
+	}
+	js.previous, js.previousUntil = js.secret, time.Now().Add(js.expiration)
+	js.secret = []byte(secret)
+}
+
+// signingKey returns the current secret
+func (js *JWTService) signingKey() []byte {
+	js.mu.RLock()
+	defer js.mu.RUnlock()
+	return js.secret
+}
+
+// previousKey returns the secret before the last rotation while tokens it signed may still be valid, or nil
+func (js *JWTService) previousKey() []byte {
+	js.mu.RLock()
+	defer js.mu.RUnlock()
+	if js.previous == nil || time.Now().After(js.previousUntil) {
+		return nil
+	}
+	return js.previous
+}
+
 // ValidateToken parses and validates a JWT token
 // Decision: Return claims if valid, error if invalid/expired
 func (js *JWTService) ValidateToken(tokenString string) (*JWTClaims, error) {
@@ -68,8 +176,18 @@ func (js *JWTService) ValidateToken(tokenString string) (*JWTClaims, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("unexpected signing method")
 		}
-		return js.secret, nil
+		return js.signingKey(), nil
 	})
+	if errors.Is(err, jwt.ErrTokenSignatureInvalid) {
+		if previous := js.previousKey(); previous != nil {
+			token, err = jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
+				if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+					return nil, errors.New("unexpected signing method")
+				}
+				return previous, nil
+			})
+		}
+	}
 
 	if err != nil {
 		return nil, err
@@ -92,6 +210,11 @@ func (js *JWTService) RefreshToken(tokenString string) (string, error) {
 		return "", err
 	}
 
+	// Decision: Impersonation must end at its original expiry; support requests a new token instead
+	if claims.Impersonated() {
+		return "", errors.New("impersonation tokens cannot be refreshed")
+	}
+
 	// Decision: Generate new token with same user information
 	return js.GenerateToken(claims.UserID, claims.Email)
 }
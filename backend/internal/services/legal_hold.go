@@ -0,0 +1,96 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+const maxLegalHoldReference = 100 // Longest case or request number a hold is filed under
+
+// LegalHoldService lets admins keep a user's reports and related data from being deleted or altered for a medico-legal request
+// Decision: The service only records holds; the repositories refuse to delete or overwrite held data, so
+// placing a hold protects the data from every path at once, including the AI call log's purge
+type LegalHoldService struct {
+	holdRepo  models.LegalHoldRepository
+	auditRepo models.AuditLogRepository
+}
+
+// NewLegalHoldService creates a new legal hold service
+func NewLegalHoldService(holdRepo models.LegalHoldRepository, auditRepo models.AuditLogRepository) *LegalHoldService {
+	return &LegalHoldService{holdRepo: holdRepo, auditRepo: auditRepo}
+}
+
+// Place puts the user's data on hold; placing it again updates the reason and reference
+// Decision: Deactivated accounts can be held too, since their reports are kept after the account is closed
+func (ls *LegalHoldService) Place(admin *models.User, userID int, reason, reference string) (*models.LegalHold, error) {
+	reason, err := checkModerationText(reason, "reason", true)
+	if err != nil {
+		return nil, err
+	}
+	reference = strings.TrimSpace(reference)
+	if len([]rune(reference)) > maxLegalHoldReference {
+		return nil, errors.NewValidationError(fmt.Sprintf("The reference must be at most %d characters", maxLegalHoldReference))
+	}
+
+	hold := &models.LegalHold{UserID: userID, Reason: reason, Reference: reference, PlacedBy: admin.ID}
+	if err := ls.holdRepo.Place(hold); err == sql.ErrNoRows {
+		return nil, errors.ErrUserNotFound
+	} else if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+
+	details := reason
+	if reference != "" {
+		details = fmt.Sprintf("%s (reference %s)", reason, reference)
+	}
+	ls.audit(admin, userID, models.AuditLegalHoldPlaced, details)
+	return hold, nil
+}
+
+// Release lifts the user's hold, after which their data can be deleted again
+func (ls *LegalHoldService) Release(admin *models.User, userID int) error {
+	released, err := ls.holdRepo.Release(userID)
+	if err != nil {
+		return errors.ErrDatabaseConnection
+	}
+	if !released {
+		return errors.ErrRecordNotFound
+	}
+
+	ls.audit(admin, userID, models.AuditLegalHoldReleased, "")
+	return nil
+}
+
+// Get returns the user's hold
+func (ls *LegalHoldService) Get(userID int) (*models.LegalHold, error) {
+	hold, err := ls.holdRepo.Get(userID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if hold == nil {
+		return nil, errors.ErrRecordNotFound
+	}
+	return hold, nil
+}
+
+// List returns every hold in place
+func (ls *LegalHoldService) List() ([]*models.LegalHold, error) {
+	holds, err := ls.holdRepo.List()
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	return holds, nil
+}
+
+// audit records a hold being placed or released; failures are logged rather than undoing the change
+func (ls *LegalHoldService) audit(admin *models.User, userID int, action, details string) {
+	entry := &models.AuditLog{ActorID: admin.ID, UserID: userID, Action: action, Details: details}
+	if err := ls.auditRepo.Create(entry); err != nil {
+		slog.Error("Failed to audit admin action", "action", action, "admin_id", admin.ID, "error", err)
+	}
+}
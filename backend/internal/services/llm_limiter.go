@@ -0,0 +1,277 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RateLimitError reports that the model provider rejected a call for exceeding its quota
+type RateLimitError struct {
+	RetryAfter time.Duration // Provider's hint; zero when it gave none
+	Err        error
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("model provider rate limit exceeded: %v", e.Err)
+}
+
+func (e *RateLimitError) Unwrap() error {
+	return e.Err
+}
+
+// LLMRateLimiter paces model calls to stay under requests-per-minute and tokens-per-minute quotas
+// Decision: One limiter per process is shared by analysis and chat, since the provider counts
+// both against the same quota; run several processes with proportionally smaller limits
+type LLMRateLimiter struct {
+	mu          sync.Mutex
+	rpm         float64 // 0 disables the request limit
+	tpm         float64 // 0 disables the token limit
+	requests    float64 // Remaining request budget; negative while callers are queued
+	tokens      float64 // Remaining token budget; negative while callers are queued
+	last        time.Time
+	pausedUntil time.Time
+
+	waits    atomic.Int64
+	waitedMs atomic.Int64
+}
+
+// LLMLimiterStats summarizes how often callers were held back
+type LLMLimiterStats struct {
+	RequestsPerMinute int   `json:"requests_per_minute"`
+	TokensPerMinute   int   `json:"tokens_per_minute"`
+	Waits             int64 `json:"waits"`
+	WaitedMs          int64 `json:"waited_ms"`
+	Coalesced         int64 `json:"coalesced"`
+	RateLimitRetries  int64 `json:"rate_limit_retries"`
+}
+
+// NewLLMRateLimiter creates a limiter that starts with a full minute of budget
+func NewLLMRateLimiter(requestsPerMinute, tokensPerMinute int) *LLMRateLimiter {
+	return &LLMRateLimiter{
+		rpm:      float64(max(requestsPerMinute, 0)),
+		tpm:      float64(max(tokensPerMinute, 0)),
+		requests: float64(max(requestsPerMinute, 0)),
+		tokens:   float64(max(tokensPerMinute, 0)),
+		last:     time.Now(),
+	}
+}
+
+// Wait reserves one request and tokens, blocking until the reservation fits the quota
+// Decision: Reserve first and sleep off the deficit, so waiting callers are served in arrival order
+func (l *LLMRateLimiter) Wait(ctx context.Context, tokens int) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.refill(now)
+
+	var delay time.Duration
+	if l.rpm > 0 {
+		l.requests--
+		delay = max(delay, deficitDelay(l.requests, l.rpm))
+	}
+	if l.tpm > 0 {
+		l.tokens -= float64(tokens)
+		delay = max(delay, deficitDelay(l.tokens, l.tpm))
+	}
+	delay = max(delay, l.pausedUntil.Sub(now))
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	l.waits.Add(1)
+	l.waitedMs.Add(delay.Milliseconds())
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.refund(tokens)
+		return ctx.Err()
+	}
+}
+
+// Charge deducts tokens used after the fact, such as the model's output
+func (l *LLMRateLimiter) Charge(tokens int) {
+	if l.tpm == 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill(time.Now())
+	l.tokens -= float64(tokens)
+}
+
+// Pause holds every caller for d, e.g. after the provider answered 429
+func (l *LLMRateLimiter) Pause(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := time.Now().Add(d); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+}
+
+// Stats returns the configured limits and how much waiting they caused
+func (l *LLMRateLimiter) Stats() LLMLimiterStats {
+	return LLMLimiterStats{
+		RequestsPerMinute: int(l.rpm),
+		TokensPerMinute:   int(l.tpm),
+		Waits:             l.waits.Load(),
+		WaitedMs:          l.waitedMs.Load(),
+	}
+}
+
+// refill adds the budget earned since the last update, capped at one minute's worth; callers hold mu
+func (l *LLMRateLimiter) refill(now time.Time) {
+	minutes := now.Sub(l.last).Minutes()
+	l.last = now
+	if minutes <= 0 {
+		return
+	}
+	l.requests = min(l.requests+minutes*l.rpm, l.rpm)
+	l.tokens = min(l.tokens+minutes*l.tpm, l.tpm)
+}
+
+// refund returns a reservation abandoned before the call was made
+func (l *LLMRateLimiter) refund(tokens int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.rpm > 0 {
+		l.requests++
+	}
+	if l.tpm > 0 {
+		l.tokens += float64(tokens)
+	}
+}
+
+// deficitDelay is how long a negative budget takes to refill back to zero
+func deficitDelay(budget, perMinute float64) time.Duration {
+	if budget >= 0 {
+		return 0
+	}
+	return time.Duration(-budget / perMinute * float64(time.Minute))
+}
+
+// rateLimitedProvider paces calls through a shared limiter, retries 429s, and coalesces duplicates
+type rateLimitedProvider struct {
+	provider    LLMProvider
+	limiter     *LLMRateLimiter
+	maxRetries  int
+	backoffBase time.Duration
+
+	mu        sync.Mutex
+	inflight  map[[sha256.Size]byte]*inflightCall
+	coalesced atomic.Int64
+	retries   atomic.Int64
+}
+
+// inflightCall is a model call other callers with the same prompt can wait on
+type inflightCall struct {
+	done chan struct{}
+	text string
+	err  error
+}
+
+func newRateLimitedProvider(provider LLMProvider, limiter *LLMRateLimiter, maxRetries int, backoffBase time.Duration) *rateLimitedProvider {
+	if backoffBase <= 0 {
+		backoffBase = 2 * time.Second
+	}
+	return &rateLimitedProvider{
+		provider:    provider,
+		limiter:     limiter,
+		maxRetries:  max(maxRetries, 0),
+		backoffBase: backoffBase,
+		inflight:    make(map[[sha256.Size]byte]*inflightCall),
+	}
+}
+
+// Generate shares the result of an identical prompt already in flight, otherwise calls the provider
+// Decision: Duplicate uploads and double-clicked questions arrive together; one call answers all of them
+func (rp *rateLimitedProvider) Generate(ctx context.Context, prompt string) (string, error) {
+	key := sha256.Sum256([]byte(prompt))
+
+	rp.mu.Lock()
+	if call, ok := rp.inflight[key]; ok {
+		rp.mu.Unlock()
+		rp.coalesced.Add(1)
+		select {
+		case <-call.done:
+			return call.text, call.err
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	call := &inflightCall{done: make(chan struct{})}
+	rp.inflight[key] = call
+	rp.mu.Unlock()
+
+	call.text, call.err = rp.generate(ctx, prompt)
+
+	rp.mu.Lock()
+	delete(rp.inflight, key)
+	rp.mu.Unlock()
+	close(call.done)
+
+	return call.text, call.err
+}
+
+// generate waits for quota and retries rate-limit rejections with exponential backoff
+func (rp *rateLimitedProvider) generate(ctx context.Context, prompt string) (string, error) {
+	promptTokens := estimateTokens(prompt)
+
+	for attempt := 0; ; attempt++ {
+		if err := rp.limiter.Wait(ctx, promptTokens); err != nil {
+			return "", err
+		}
+
+		text, err := rp.provider.Generate(ctx, prompt)
+		if err == nil {
+			rp.limiter.Charge(estimateTokens(text))
+			return text, nil
+		}
+
+		var rateErr *RateLimitError
+		if !errors.As(err, &rateErr) || attempt >= rp.maxRetries {
+			return "", err
+		}
+
+		backoff := rateErr.RetryAfter
+		if backoff <= 0 {
+			backoff = rp.backoffBase << attempt
+		}
+		rp.retries.Add(1)
+		slog.WarnContext(ctx, "AI provider rate limited, pausing all calls", "provider", rp.provider.Name(), "pause", backoff,
+			"retry", attempt+1, "max_retries", rp.maxRetries)
+		// Decision: Pause the shared limiter so chat and analysis both back off, not just this caller
+		rp.limiter.Pause(backoff)
+	}
+}
+
+// Ping bypasses the limiter; it spends no tokens
+func (rp *rateLimitedProvider) Ping(ctx context.Context) error {
+	return rp.provider.Ping(ctx)
+}
+
+func (rp *rateLimitedProvider) Name() string {
+	return rp.provider.Name()
+}
+
+func (rp *rateLimitedProvider) Close() error {
+	return rp.provider.Close()
+}
+
+// Stats reports limiter waits along with coalesced calls and 429 retries
+func (rp *rateLimitedProvider) Stats() LLMLimiterStats {
+	stats := rp.limiter.Stats()
+	stats.Coalesced = rp.coalesced.Load()
+	stats.RateLimitRetries = rp.retries.Load()
+	return stats
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+)
+
+// ollamaProvider calls a self-hosted model through the OpenAI-compatible chat completions API
+// Decision: Ollama and the llama.cpp server both expose /v1/chat/completions, so one client covers
+// either and medical text never leaves the deployment's network
+type ollamaProvider struct {
+	baseURL      string
+	model        string
+	systemPrompt string
+	temperature  float32
+	maxTokens    int32
+	client       *http.Client
+}
+
+type chatCompletionMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatCompletionRequest struct {
+	Model       string                  `json:"model"`
+	Messages    []chatCompletionMessage `json:"messages"`
+	Temperature float32                 `json:"temperature"`
+	MaxTokens   int32                   `json:"max_tokens,omitempty"`
+	Stream      bool                    `json:"stream"`
+}
+
+type chatCompletionResponse struct {
+	Choices []struct {
+		Message chatCompletionMessage `json:"message"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+	Usage *struct {
+		PromptTokens     int64 `json:"prompt_tokens"`
+		CompletionTokens int64 `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+func newOllamaProvider(cfg config.AIConfig, systemPrompt string) (*ollamaProvider, error) {
+	if cfg.OllamaURL == "" {
+		return nil, fmt.Errorf("OLLAMA_URL is required when AI_PROVIDER is ollama")
+	}
+	if cfg.OllamaModel == "" {
+		return nil, fmt.Errorf("OLLAMA_MODEL is required when AI_PROVIDER is ollama")
+	}
+
+	// Decision: Local models on CPU can take minutes per report; the timeout is generous by default
+	timeout := cfg.OllamaTimeout
+	if timeout <= 0 {
+		timeout = 5 * time.Minute
+	}
+
+	return &ollamaProvider{
+		baseURL:      strings.TrimRight(cfg.OllamaURL, "/"),
+		model:        cfg.OllamaModel,
+		systemPrompt: systemPrompt,
+		temperature:  cfg.Temperature,
+		maxTokens:    cfg.MaxTokens,
+		client:       &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// Generate sends the persona and prompt as a two-message chat and returns the reply
+func (op *ollamaProvider) Generate(ctx context.Context, prompt string) (string, error) {
+	maxTokens := op.maxTokens
+	if n, ok := maxOutputTokens(ctx); ok {
+		maxTokens = n
+	}
+
+	body, err := json.Marshal(chatCompletionRequest{
+		Model: op.model,
+		Messages: []chatCompletionMessage{
+			{Role: "system", Content: op.systemPrompt},
+			{Role: "user", Content: prompt},
+		},
+		Temperature: op.temperature,
+		MaxTokens:   maxTokens,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, op.baseURL+"/v1/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := op.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach %s: %w", op.baseURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return "", &RateLimitError{RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")), Err: fmt.Errorf("model server returned status 429")}
+	}
+
+	var result chatCompletionResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 10<<20)).Decode(&result); err != nil {
+		return "", fmt.Errorf("invalid response from model server (status %d): %w", resp.StatusCode, err)
+	}
+	if result.Error != nil {
+		return "", fmt.Errorf("model server error: %s", result.Error.Message)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("model server returned status %d", resp.StatusCode)
+	}
+	if result.Usage != nil {
+		addTokenUsage(ctx, result.Usage.PromptTokens, result.Usage.CompletionTokens)
+	}
+	if len(result.Choices) == 0 {
+		return "", fmt.Errorf("no response generated")
+	}
+
+	return result.Choices[0].Message.Content, nil
+}
+
+// Ping lists the server's models, which every OpenAI-compatible server answers without loading one
+func (op *ollamaProvider) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, op.baseURL+"/v1/models", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := op.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach %s: %w", op.baseURL, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("model server returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (op *ollamaProvider) Name() string {
+	return "ollama:" + op.model
+}
+
+// Close is a no-op; the HTTP client holds no resources that need releasing
+func (op *ollamaProvider) Close() error {
+	return nil
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds; HTTP dates fall back to backoff
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/generative-ai-go/genai"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// LLMProvider generates text from a prompt for analysis and chat
+// Decision: AIService owns prompts and parsing; providers only move text to and from a model,
+// so switching between Gemini and an on-prem model changes no behavior above this interface
+type LLMProvider interface {
+	Generate(ctx context.Context, prompt string) (string, error)
+	// Ping checks the backend is reachable and accepts the credentials, without generating anything
+	Ping(ctx context.Context) error
+	Name() string
+	Close() error
+}
+
+// maxOutputTokensKey carries a per-call cap on the model's answer
+type maxOutputTokensKey struct{}
+
+// WithMaxOutputTokens caps the answer to calls made with the returned context; n <= 0 keeps the configured cap
+// Decision: Carried on the context rather than added to Generate, so the limiter and every provider pass it through untouched
+func WithMaxOutputTokens(ctx context.Context, n int32) context.Context {
+	if n <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, maxOutputTokensKey{}, n)
+}
+
+// maxOutputTokens returns the per-call cap set with WithMaxOutputTokens, if any
+func maxOutputTokens(ctx context.Context) (int32, bool) {
+	n, ok := ctx.Value(maxOutputTokensKey{}).(int32)
+	return n, ok
+}
+
+// TokenUsage counts the tokens a provider reported for the calls made with one context
+type TokenUsage struct {
+	InputTokens  int64
+	OutputTokens int64
+}
+
+// tokenUsageKey carries the TokenUsage providers add to
+type tokenUsageKey struct{}
+
+// withTokenUsage returns a context whose calls add the tokens their provider reports to the returned TokenUsage
+// Decision: Carried on the context like WithMaxOutputTokens, so the limiter's retries are all counted and a
+// caller sharing another's in-flight call counts nothing
+func withTokenUsage(ctx context.Context) (context.Context, *TokenUsage) {
+	usage := &TokenUsage{}
+	return context.WithValue(ctx, tokenUsageKey{}, usage), usage
+}
+
+// addTokenUsage adds a call's reported tokens to the context's TokenUsage, if it has one
+// Decision: Only the caller's goroutine writes to it, since the limiter calls the provider synchronously
+func addTokenUsage(ctx context.Context, inputTokens, outputTokens int64) {
+	if usage, ok := ctx.Value(tokenUsageKey{}).(*TokenUsage); ok {
+		usage.InputTokens += inputTokens
+		usage.OutputTokens += outputTokens
+	}
+}
+
+// NewLLMProvider creates the provider selected by cfg.Provider with the persona as system prompt
+func NewLLMProvider(cfg config.AIConfig, systemPrompt string) (LLMProvider, error) {
+	switch strings.ToLower(cfg.Provider) {
+	case "", "gemini":
+		return newGeminiProvider(cfg, systemPrompt)
+	case "ollama":
+		return newOllamaProvider(cfg, systemPrompt)
+	default:
+		return nil, fmt.Errorf("unknown AI provider %q (expected gemini or ollama)", cfg.Provider)
+	}
+}
+
+// Gemini default model and sampling settings, shared with the AI call log
+const (
+	geminiModel = "gemini-1.5-flash"
+	geminiTopK  = 40
+	geminiTopP  = 0.95
+)
+
+// geminiModelName is the configured Gemini model, or geminiModel when none is set
+func geminiModelName(cfg config.AIConfig) string {
+	if cfg.GeminiModel != "" {
+		return cfg.GeminiModel
+	}
+	return geminiModel
+}
+
+// geminiProvider calls Google's Gemini API
+type geminiProvider struct {
+	client *genai.Client
+	model  *genai.GenerativeModel
+}
+
+func newGeminiProvider(cfg config.AIConfig, systemPrompt string) (*geminiProvider, error) {
+	apiKey := cfg.GeminiAPIKey
+	if apiKey == "" {
+		return nil, fmt.Errorf("Gemini API key is required")
+	}
+
+	ctx := context.Background()
+	client, err := genai.NewClient(ctx, option.WithAPIKey(apiKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Gemini client: %w", err)
+	}
+
+	// Configure the model for medical report analysis
+	model := client.GenerativeModel(geminiModelName(cfg))
+	model.SetTemperature(cfg.Temperature) // Lower temperature for more consistent medical analysis
+	model.SetTopK(geminiTopK)
+	model.SetTopP(geminiTopP)
+	model.SetMaxOutputTokens(cfg.MaxTokens)
+
+	// Decision: Persona applies to every request on the model - analysis and chat alike
+	model.SystemInstruction = &genai.Content{Parts: []genai.Part{genai.Text(systemPrompt)}}
+
+	// Set safety settings for medical content
+	model.SafetySettings = []*genai.SafetySetting{
+		{
+			Category:  genai.HarmCategoryHarassment,
+			Threshold: genai.HarmBlockMediumAndAbove,
+		},
+		{
+			Category:  genai.HarmCategoryHateSpeech,
+			Threshold: genai.HarmBlockMediumAndAbove,
+		},
+		{
+			Category:  genai.HarmCategoryDangerousContent,
+			Threshold: genai.HarmBlockMediumAndAbove,
+		},
+		{
+			Category:  genai.HarmCategorySexuallyExplicit,
+			Threshold: genai.HarmBlockMediumAndAbove,
+		},
+	}
+
+	return &geminiProvider{client: client, model: model}, nil
+}
+
+// Generate sends a prompt to Gemini and concatenates the text parts of the first candidate
+func (gp *geminiProvider) Generate(ctx context.Context, prompt string) (string, error) {
+	model := gp.model
+	if n, ok := maxOutputTokens(ctx); ok {
+		// Decision: A shallow copy per call, since the shared model serves concurrent requests
+		capped := *gp.model
+		capped.SetMaxOutputTokens(n)
+		model = &capped
+	}
+
+	resp, err := model.GenerateContent(ctx, genai.Text(prompt))
+	if err != nil {
+		if isGeminiQuotaError(err) {
+			return "", &RateLimitError{Err: err}
+		}
+		return "", fmt.Errorf("failed to generate content: %w", err)
+	}
+	if resp.UsageMetadata != nil {
+		addTokenUsage(ctx, int64(resp.UsageMetadata.PromptTokenCount), int64(resp.UsageMetadata.CandidatesTokenCount))
+	}
+
+	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil {
+		return "", fmt.Errorf("no response generated")
+	}
+
+	var responseText strings.Builder
+	for _, part := range resp.Candidates[0].Content.Parts {
+		if txt, ok := part.(genai.Text); ok {
+			responseText.WriteString(string(txt))
+		}
+	}
+
+	return responseText.String(), nil
+}
+
+// isGeminiQuotaError reports a 429 from either the gRPC or the REST transport
+func isGeminiQuotaError(err error) bool {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusTooManyRequests {
+		return true
+	}
+	return status.Code(err) == codes.ResourceExhausted
+}
+
+// Ping fetches the model's metadata, which fails on an invalid key but costs no tokens
+func (gp *geminiProvider) Ping(ctx context.Context) error {
+	_, err := gp.model.Info(ctx)
+	return err
+}
+
+func (gp *geminiProvider) Name() string {
+	return "gemini"
+}
+
+func (gp *geminiProvider) Close() error {
+	return gp.client.Close()
+}
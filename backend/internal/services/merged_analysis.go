@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+// Bounds on how many reports one merged analysis covers
+const (
+	minMergeReports = 2
+	maxMergeReports = 10
+)
+
+// MergedAnalysisService combines several completed reports into one stored assessment
+type MergedAnalysisService struct {
+	mergedRepo models.MergedAnalysisRepository
+	reportRepo models.ReportRepository
+	analyzer   CombinedAnalyzer
+}
+
+// NewMergedAnalysisService creates a new merged analysis service
+// Decision: analyzer may be nil when no AI is configured; merging then returns 503 while stored analyses stay readable
+func NewMergedAnalysisService(mergedRepo models.MergedAnalysisRepository, reportRepo models.ReportRepository, analyzer CombinedAnalyzer) *MergedAnalysisService {
+	return &MergedAnalysisService{
+		mergedRepo: mergedRepo,
+		reportRepo: reportRepo,
+		analyzer:   analyzer,
+	}
+}
+
+// Merge writes and stores one assessment across the given reports, in the order given
+func (ms *MergedAnalysisService) Merge(userID int, reportIDs []int, title, readingLevel, plan string) (*models.MergedAnalysis, error) {
+	if !models.IsValidReadingLevel(readingLevel) {
+		return nil, errors.ErrInvalidReadingLevel
+	}
+	if len(reportIDs) < minMergeReports || len(reportIDs) > maxMergeReports {
+		return nil, errors.NewValidationError(fmt.Sprintf("Choose between %d and %d reports to merge", minMergeReports, maxMergeReports))
+	}
+	title = strings.TrimSpace(title)
+	if len(title) > 200 {
+		return nil, errors.NewValidationError("Title must be at most 200 characters")
+	}
+
+	sources := make([]MergeSource, 0, len(reportIDs))
+	links := make([]*models.MergedSource, 0, len(reportIDs))
+	seen := make(map[int]bool, len(reportIDs))
+	for _, reportID := range reportIDs {
+		if seen[reportID] {
+			return nil, errors.NewValidationError(fmt.Sprintf("Report %d is listed more than once", reportID))
+		}
+		seen[reportID] = true
+
+		report, err := ms.reportRepo.GetByID(reportID)
+		if err != nil {
+			return nil, errors.ErrDatabaseConnection
+		}
+		// Decision: Someone else's report looks the same as a missing one
+		if report == nil || report.UserID != userID {
+			return nil, errors.ErrRecordNotFound
+		}
+		if report.ProcessingStatus != "completed" {
+			return nil, errors.NewValidationError(fmt.Sprintf("Report %d has not been processed yet", reportID))
+		}
+
+		analysis, err := ParseStoredAnalysis(report.SimplifiedSummary)
+		if err != nil {
+			slog.Warn("Failed to parse stored analysis for merge", "report_id", reportID, "error", err)
+			return nil, errors.NewValidationError(fmt.Sprintf("Report %d has no usable analysis", reportID))
+		}
+
+		label := report.Title
+		if label == "" {
+			label = report.OriginalFilename
+		}
+		id := report.ID
+		sources = append(sources, MergeSource{Label: label, ReportDate: report.ReportDate, Analysis: analysis})
+		links = append(links, &models.MergedSource{ReportID: &id, Label: label, ReportDate: report.ReportDate})
+	}
+
+	if ms.analyzer == nil {
+		return nil, errors.ErrAIUnavailable
+	}
+	result, err := ms.analyzer.AnalyzeCombined(WithAICallUser(context.Background(), userID), sources, readingLevel, plan)
+	if err != nil {
+		slog.Error("Failed to merge reports", "report_ids", reportIDs, "error", err)
+		return nil, errors.ErrAIProcessingFailed
+	}
+
+	if title == "" {
+		title = fmt.Sprintf("Combined analysis of %d reports", len(sources))
+	}
+	merged := &models.MergedAnalysis{
+		UserID:        userID,
+		Title:         title,
+		Analysis:      result.ResultJSON,
+		PromptVersion: result.PromptVersion,
+		ReadingLevel:  readingLevel,
+		Sources:       links,
+	}
+	if err := ms.mergedRepo.Create(merged); err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	return merged, nil
+}
+
+// Get returns one of the user's merged analyses
+func (ms *MergedAnalysisService) Get(userID, id int) (*models.MergedAnalysis, error) {
+	merged, err := ms.mergedRepo.GetByID(id)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if merged == nil || merged.UserID != userID {
+		return nil, errors.ErrRecordNotFound
+	}
+	return merged, nil
+}
+
+// List returns the user's merged analyses, newest first
+func (ms *MergedAnalysisService) List(userID, limit, offset int) ([]*models.MergedAnalysis, error) {
+	merged, err := ms.mergedRepo.ListByUser(userID, limit, offset)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	return merged, nil
+}
+
+// Delete removes one of the user's merged analyses, leaving the source reports alone
+func (ms *MergedAnalysisService) Delete(userID, id int) error {
+	if _, err := ms.Get(userID, id); err != nil {
+		return err
+	}
+	if err := ms.mergedRepo.Delete(id); err == models.ErrLegalHold {
+		return errors.ErrLegalHold
+	} else if err != nil {
+		return errors.ErrDatabaseConnection
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+const maxModerationText = 500 // Longest flag reason, moderator note, or ban reason
+
+// ModerationService lets users flag chat exchanges and moderators review them, remove content, and ban accounts
+// Decision: Every moderator action is written to the audit log with the user it affected, like other operator actions
+type ModerationService struct {
+	moderationRepo models.ModerationRepository
+	chatRepo       models.ChatMessageRepository
+	reportRepo     models.ReportRepository
+	summaryRepo    models.ChatSummaryRepository
+	userRepo       models.UserRepository
+	auditRepo      models.AuditLogRepository
+	adminEmails    map[string]bool
+}
+
+// NewModerationService creates a new moderation service; adminEmails are the accounts that can't be banned
+func NewModerationService(
+	moderationRepo models.ModerationRepository,
+	chatRepo models.ChatMessageRepository,
+	reportRepo models.ReportRepository,
+	summaryRepo models.ChatSummaryRepository,
+	userRepo models.UserRepository,
+	auditRepo models.AuditLogRepository,
+	adminEmails []string,
+) *ModerationService {
+	admins := make(map[string]bool, len(adminEmails))
+	for _, email := range adminEmails {
+		admins[strings.ToLower(strings.TrimSpace(email))] = true
+	}
+
+	return &ModerationService{
+		moderationRepo: moderationRepo,
+		chatRepo:       chatRepo,
+		reportRepo:     reportRepo,
+		summaryRepo:    summaryRepo,
+		userRepo:       userRepo,
+		auditRepo:      auditRepo,
+		adminEmails:    admins,
+	}
+}
+
+// IsValidModerationSource reports whether source is one of the models.ModerationSource* values
+func IsValidModerationSource(source string) bool {
+	switch source {
+	case models.ModerationSourceSafety, models.ModerationSourceCrisis, models.ModerationSourceUser:
+		return true
+	}
+	return false
+}
+
+// IsValidModerationStatus reports whether status is one of the models.ModerationStatus* values
+func IsValidModerationStatus(status string) bool {
+	return status == models.ModerationStatusOpen || status == models.ModerationStatusReviewed
+}
+
+// checkModerationText trims text and checks its length; what names the field in the error
+func checkModerationText(text, what string, required bool) (string, error) {
+	text = strings.TrimSpace(text)
+	if required && text == "" {
+		return "", errors.NewValidationError(fmt.Sprintf("A %s is required", what))
+	}
+	if len([]rune(text)) > maxModerationText {
+		return "", errors.NewValidationError(fmt.Sprintf("The %s must be at most %d characters", what, maxModerationText))
+	}
+	return text, nil
+}
+
+// Flag asks the moderators to look at one of the user's chat exchanges; flagging it again updates the reason
+// and reopens it if it was reviewed
+func (ms *ModerationService) Flag(userID, messageID int, reason string) error {
+	reason, err := checkModerationText(reason, "reason", true)
+	if err != nil {
+		return err
+	}
+
+	message, err := ms.chatRepo.GetByID(messageID)
+	if err != nil {
+		return errors.ErrDatabaseConnection
+	}
+	if message == nil {
+		return errors.ErrRecordNotFound
+	}
+	report, err := ms.reportRepo.GetByID(message.ReportID)
+	if err != nil {
+		return errors.ErrDatabaseConnection
+	}
+	if report == nil {
+		return errors.ErrRecordNotFound
+	}
+	if report.UserID != userID {
+		return errors.ErrAccessDenied
+	}
+
+	if err := ms.moderationRepo.Flag(&models.MessageFlag{MessageID: messageID, UserID: userID, Reason: reason}); err != nil {
+		return errors.ErrDatabaseConnection
+	}
+	return nil
+}
+
+// List returns a page of flagged exchanges, newest first
+func (ms *ModerationService) List(filter models.ModerationFilter) ([]*models.FlaggedMessage, error) {
+	messages, err := ms.moderationRepo.List(filter)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	return messages, nil
+}
+
+// Get returns a flagged exchange with the flags users raised on it
+func (ms *ModerationService) Get(messageID int) (*models.FlaggedMessage, []*models.MessageFlag, error) {
+	message, err := ms.getFlagged(messageID)
+	if err != nil {
+		return nil, nil, err
+	}
+	flags, err := ms.moderationRepo.ListFlags(messageID)
+	if err != nil {
+		return nil, nil, errors.ErrDatabaseConnection
+	}
+	return message, flags, nil
+}
+
+// Dismiss closes a flagged exchange without changing it
+func (ms *ModerationService) Dismiss(admin *models.User, messageID int, note string) (*models.FlaggedMessage, error) {
+	note, err := checkModerationText(note, "note", false)
+	if err != nil {
+		return nil, err
+	}
+	message, err := ms.getFlagged(messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ms.moderationRepo.Review(messageID, admin.ID, models.ModerationDismissed, note); err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	ms.audit(admin, message.UserID, models.AuditChatDismissed, fmt.Sprintf("message %d", messageID))
+	return ms.getFlagged(messageID)
+}
+
+// Redact removes a flagged exchange's question, answer, or both, and closes it
+// Decision: The exchange is kept with its content replaced, so the conversation still reads in order and the
+// moderators can see what they did; the conversation summary is dropped since it may repeat what was removed
+func (ms *ModerationService) Redact(admin *models.User, messageID int, question, answer bool, note string) (*models.FlaggedMessage, error) {
+	if !question && !answer {
+		return nil, errors.NewValidationError("Choose the question, the answer, or both to remove")
+	}
+	note, err := checkModerationText(note, "note", false)
+	if err != nil {
+		return nil, err
+	}
+	message, err := ms.getFlagged(messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ms.moderationRepo.Redact(messageID, question, answer); err == models.ErrLegalHold {
+		return nil, errors.ErrLegalHold
+	} else if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if err := ms.summaryRepo.Delete(message.ReportID); err != nil {
+		slog.Error("Failed to drop the conversation summary after redaction", "report_id", message.ReportID, "error", err)
+	}
+	if err := ms.moderationRepo.Review(messageID, admin.ID, models.ModerationRedacted, note); err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+
+	var parts []string
+	if question {
+		parts = append(parts, "question")
+	}
+	if answer {
+		parts = append(parts, "answer")
+	}
+	ms.audit(admin, message.UserID, models.AuditChatRedacted, fmt.Sprintf("message %d: %s", messageID, strings.Join(parts, " and ")))
+	return ms.getFlagged(messageID)
+}
+
+// Ban deactivates an abusive account; it is signed out on its next request and can't sign in again until unbanned
+func (ms *ModerationService) Ban(admin *models.User, userID int, reason string) (*models.UserBan, error) {
+	reason, err := checkModerationText(reason, "reason", true)
+	if err != nil {
+		return nil, err
+	}
+	if userID == admin.ID {
+		return nil, errors.NewValidationError("You cannot ban yourself")
+	}
+
+	user, err := ms.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if user == nil || !user.IsActive {
+		return nil, errors.ErrUserNotFound
+	}
+	// Decision: Admin accounts can't be banned, so one operator can't lock the others out
+	if ms.adminEmails[strings.ToLower(user.Email)] {
+		return nil, errors.ErrAccessDenied
+	}
+
+	ban := &models.UserBan{UserID: userID, Reason: reason, BannedBy: admin.ID}
+	if err := ms.moderationRepo.Ban(ban); err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if err := ms.userRepo.Delete(userID); err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+
+	ms.audit(admin, userID, models.AuditUserBanned, reason)
+	return ban, nil
+}
+
+// Unban reactivates an account a moderator banned
+// Decision: Only active accounts can be banned, so reactivating on unban never revives one its owner deleted
+func (ms *ModerationService) Unban(admin *models.User, userID int) error {
+	unbanned, err := ms.moderationRepo.Unban(userID)
+	if err != nil {
+		return errors.ErrDatabaseConnection
+	}
+	if !unbanned {
+		return errors.ErrRecordNotFound
+	}
+	if err := ms.userRepo.Reactivate(userID); err != nil {
+		return errors.ErrDatabaseConnection
+	}
+
+	ms.audit(admin, userID, models.AuditUserUnbanned, "")
+	return nil
+}
+
+// getFlagged returns an exchange in the moderation queue
+func (ms *ModerationService) getFlagged(messageID int) (*models.FlaggedMessage, error) {
+	message, err := ms.moderationRepo.Get(messageID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if message == nil {
+		return nil, errors.ErrRecordNotFound
+	}
+	return message, nil
+}
+
+// audit records a moderator action; failures are logged rather than undoing the action
+func (ms *ModerationService) audit(admin *models.User, userID int, action, details string) {
+	entry := &models.AuditLog{ActorID: admin.ID, UserID: userID, Action: action, Details: details}
+	if err := ms.auditRepo.Create(entry); err != nil {
+		slog.Error("Failed to audit admin action", "action", action, "admin_id", admin.ID, "error", err)
+	}
+}
@@ -0,0 +1,364 @@
+package services
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+)
+
+// NATS connection timing
+const (
+	natsDialTimeout   = 5 * time.Second
+	natsWriteTimeout  = 5 * time.Second
+	natsMaxBackoff    = 30 * time.Second
+	natsFirstBackoff  = 250 * time.Millisecond
+	natsMaxPayloadLen = 1 << 20
+)
+
+// NATSEventBus is an EventBus shared by every process connected to the same NATS server
+// Decision: Each event goes to one member of the bus's queue group, so running more servers or
+// workers spreads the work instead of repeating it; received events are handed to a local bus
+// so handlers run in order and off the connection's read loop
+type NATSEventBus struct {
+	conn   *natsConn
+	prefix string
+	group  string
+	local  *LocalEventBus
+
+	mu         sync.Mutex
+	subscribed map[string]bool
+}
+
+// NewNATSEventBus connects to cfg.NATSURL; group names the role (server, worker) whose members share deliveries
+func NewNATSEventBus(cfg config.QueueConfig, group string) (*NATSEventBus, error) {
+	prefix := cfg.SubjectPrefix
+	if prefix == "" {
+		prefix = "medreport"
+	}
+	conn, err := dialNATS(cfg.NATSURL, prefix+"-"+group)
+	if err != nil {
+		return nil, err
+	}
+	return &NATSEventBus{
+		conn:       conn,
+		prefix:     prefix,
+		group:      prefix + "." + group,
+		local:      NewLocalEventBus(0),
+		subscribed: make(map[string]bool),
+	}, nil
+}
+
+// Subscribe registers handler for every later event of eventType received by this process
+func (b *NATSEventBus) Subscribe(eventType string, handler EventHandler) {
+	b.local.Subscribe(eventType, handler)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subscribed[eventType] {
+		return
+	}
+	b.subscribed[eventType] = true
+	b.conn.subscribe(b.subject(eventType), b.group, func(data []byte) {
+		var event Event
+		if err := json.Unmarshal(data, &event); err != nil {
+			slog.Warn("Dropping unreadable event from NATS", "type", eventType, "error", err)
+			return
+		}
+		b.local.Publish(event)
+	})
+}
+
+// Publish sends an event to NATS, stamping it with the current time if unset
+// Decision: A failed publish is logged, not retried; a lost report.uploaded is still found by the worker's poll
+func (b *NATSEventBus) Publish(event Event) {
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		slog.Error("Failed to encode event", "type", event.Type, "error", err)
+		return
+	}
+	if err := b.conn.publish(b.subject(event.Type), data); err != nil {
+		slog.Error("Failed to publish event", "type", event.Type, "user_id", event.UserID, "error", err)
+	}
+}
+
+// Close disconnects from NATS and waits until every received event has been delivered
+func (b *NATSEventBus) Close() {
+	b.conn.close()
+	b.local.Close()
+}
+
+// subject is the NATS subject events of eventType are published on
+func (b *NATSEventBus) subject(eventType string) string {
+	return b.prefix + ".events." + eventType
+}
+
+// natsSubscription is a subject this connection listens on, kept to resubscribe after reconnecting
+type natsSubscription struct {
+	subject string
+	queue   string
+	handler func(data []byte)
+}
+
+// natsConn is a minimal client for the NATS core protocol: publish, queue subscribe, and reconnect
+// Decision: Only the text protocol's PUB, SUB, MSG, and PING are needed, which keeps a client library out of the build
+type natsConn struct {
+	addr  string
+	name  string
+	user  string
+	pass  string
+	token string
+
+	mu     sync.Mutex
+	conn   net.Conn
+	writer *bufio.Writer
+	subs   map[int]*natsSubscription
+	sid    int
+	closed bool
+}
+
+// dialNATS connects to a nats://[user:pass@]host[:port] URL
+func dialNATS(rawURL, name string) (*natsConn, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid NATS_URL %q", rawURL)
+	}
+	if parsed.Scheme != "nats" {
+		return nil, fmt.Errorf("NATS_URL must use nats:// (TLS is not supported), got %q", parsed.Scheme)
+	}
+
+	nc := &natsConn{addr: parsed.Host, name: name, subs: make(map[int]*natsSubscription)}
+	if parsed.Port() == "" {
+		nc.addr = net.JoinHostPort(parsed.Hostname(), "4222")
+	}
+	if parsed.User != nil {
+		if pass, ok := parsed.User.Password(); ok {
+			nc.user, nc.pass = parsed.User.Username(), pass
+		} else {
+			nc.token = parsed.User.Username()
+		}
+	}
+
+	conn, reader, err := nc.handshake()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS at %s: %w", nc.addr, err)
+	}
+	nc.install(conn)
+	go nc.readLoop(conn, reader)
+	return nc, nil
+}
+
+// handshake opens a connection and waits for the server to accept CONNECT
+func (nc *natsConn) handshake() (net.Conn, *bufio.Reader, error) {
+	conn, err := net.DialTimeout("tcp", nc.addr, natsDialTimeout)
+	if err != nil {
+		return nil, nil, err
+	}
+	conn.SetDeadline(time.Now().Add(natsDialTimeout))
+	reader := bufio.NewReader(conn)
+
+	line, err := readNATSLine(reader)
+	if err != nil || !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return nil, nil, fmt.Errorf("expected INFO from server, got %q (%v)", line, err)
+	}
+
+	options, _ := json.Marshal(map[string]any{
+		"verbose": false, "pedantic": false, "lang": "go", "version": "1", "protocol": 1,
+		"name": nc.name, "user": nc.user, "pass": nc.pass, "auth_token": nc.token,
+	})
+	if _, err := fmt.Fprintf(conn, "CONNECT %s\r\nPING\r\n", options); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	for {
+		line, err := readNATSLine(reader)
+		if err != nil {
+			conn.Close()
+			return nil, nil, err
+		}
+		if line == "PONG" {
+			break
+		}
+		if strings.HasPrefix(line, "-ERR") {
+			conn.Close()
+			return nil, nil, fmt.Errorf("server refused connection: %s", line)
+		}
+	}
+	conn.SetDeadline(time.Time{})
+	return conn, reader, nil
+}
+
+// install makes conn the live connection and subscribes it to every known subject
+func (nc *natsConn) install(conn net.Conn) {
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+	nc.conn = conn
+	nc.writer = bufio.NewWriter(conn)
+	for sid, sub := range nc.subs {
+		nc.writeSub(sid, sub)
+	}
+	nc.flush()
+}
+
+// readLoop dispatches messages from conn, reconnecting with backoff whenever it is lost
+func (nc *natsConn) readLoop(conn net.Conn, reader *bufio.Reader) {
+	for {
+		err := nc.read(reader)
+
+		nc.mu.Lock()
+		closed := nc.closed
+		nc.conn, nc.writer = nil, nil
+		nc.mu.Unlock()
+		conn.Close()
+		if closed {
+			return
+		}
+		slog.Error("Lost connection to NATS", "addr", nc.addr, "error", err)
+
+		if conn, reader = nc.reconnect(); conn == nil {
+			return
+		}
+		slog.Info("Reconnected to NATS", "addr", nc.addr)
+	}
+}
+
+// reconnect retries the handshake until it succeeds or the connection is closed
+func (nc *natsConn) reconnect() (net.Conn, *bufio.Reader) {
+	backoff := natsFirstBackoff
+	for {
+		time.Sleep(backoff)
+		nc.mu.Lock()
+		closed := nc.closed
+		nc.mu.Unlock()
+		if closed {
+			return nil, nil
+		}
+
+		conn, reader, err := nc.handshake()
+		if err == nil {
+			nc.mu.Lock()
+			if nc.closed {
+				nc.mu.Unlock()
+				conn.Close()
+				return nil, nil
+			}
+			nc.mu.Unlock()
+			nc.install(conn)
+			return conn, reader
+		}
+		backoff = min(backoff*2, natsMaxBackoff)
+	}
+}
+
+// read handles protocol lines until the connection fails
+func (nc *natsConn) read(reader *bufio.Reader) error {
+	for {
+		line, err := readNATSLine(reader)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case strings.HasPrefix(line, "MSG "):
+			// MSG <subject> <sid> [reply-to] <#bytes>
+			fields := strings.Fields(line)
+			if len(fields) < 4 {
+				return fmt.Errorf("malformed message header %q", line)
+			}
+			sid, _ := strconv.Atoi(fields[2])
+			size, err := strconv.Atoi(fields[len(fields)-1])
+			if err != nil || size < 0 || size > natsMaxPayloadLen {
+				return fmt.Errorf("malformed message size in %q", line)
+			}
+			payload := make([]byte, size+2)
+			if _, err := io.ReadFull(reader, payload); err != nil {
+				return err
+			}
+
+			nc.mu.Lock()
+			sub := nc.subs[sid]
+			nc.mu.Unlock()
+			if sub != nil {
+				sub.handler(payload[:size])
+			}
+		case line == "PING":
+			nc.mu.Lock()
+			if nc.writer != nil {
+				nc.writer.WriteString("PONG\r\n")
+				nc.flush()
+			}
+			nc.mu.Unlock()
+		case strings.HasPrefix(line, "-ERR"):
+			slog.Error("NATS error", "error", line)
+		}
+	}
+}
+
+// subscribe listens on subject as a member of queue, now and after every reconnect
+func (nc *natsConn) subscribe(subject, queue string, handler func(data []byte)) {
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+	nc.sid++
+	sub := &natsSubscription{subject: subject, queue: queue, handler: handler}
+	nc.subs[nc.sid] = sub
+	if nc.writer != nil {
+		nc.writeSub(nc.sid, sub)
+		nc.flush()
+	}
+}
+
+// publish sends data on subject, failing while disconnected
+func (nc *natsConn) publish(subject string, data []byte) error {
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+	if nc.writer == nil {
+		return fmt.Errorf("not connected to NATS")
+	}
+	fmt.Fprintf(nc.writer, "PUB %s %d\r\n", subject, len(data))
+	nc.writer.Write(data)
+	nc.writer.WriteString("\r\n")
+	return nc.flush()
+}
+
+// close disconnects for good
+func (nc *natsConn) close() {
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+	nc.closed = true
+	if nc.conn != nil {
+		nc.conn.Close()
+	}
+}
+
+// writeSub buffers a SUB line; callers hold mu
+func (nc *natsConn) writeSub(sid int, sub *natsSubscription) {
+	fmt.Fprintf(nc.writer, "SUB %s %s %d\r\n", sub.subject, sub.queue, sid)
+}
+
+// flush sends buffered lines; callers hold mu
+func (nc *natsConn) flush() error {
+	nc.conn.SetWriteDeadline(time.Now().Add(natsWriteTimeout))
+	return nc.writer.Flush()
+}
+
+// readNATSLine reads one CRLF-terminated protocol line
+func readNATSLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+// OrganizationUsageService meters what clinic tenants use each month: reports processed, model tokens, and storage
+// Decision: Counted into monthly totals as it happens rather than derived from reports and the AI call log
+// later, since reports get deleted and the call log is optional and purged, but an invoice must not change
+type OrganizationUsageService struct {
+	repo models.OrganizationUsageRepository
+	now  func() time.Time
+}
+
+// NewOrganizationUsageService creates a new organization usage service
+func NewOrganizationUsageService(repo models.OrganizationUsageRepository) *OrganizationUsageService {
+	return &OrganizationUsageService{repo: repo, now: time.Now}
+}
+
+// CurrentMonth returns the calendar month, YYYY-MM in UTC, usage happening now counts toward
+func (us *OrganizationUsageService) CurrentMonth() string {
+	return us.now().UTC().Format(models.OrganizationUsageMonthLayout)
+}
+
+// RecordReportProcessed counts a finished analysis of a user's report
+// Decision: Metering never fails the work it counts; a lost increment is logged instead
+func (us *OrganizationUsageService) RecordReportProcessed(userID int) {
+	if err := us.repo.Add(userID, us.CurrentMonth(), models.OrganizationUsageDelta{ReportsProcessed: 1}); err != nil {
+		slog.Error("Failed to count processed report", "user_id", userID, "error", err)
+	}
+}
+
+// RecordAICall counts a model call made with a user's data and the tokens the provider reported for it
+func (us *OrganizationUsageService) RecordAICall(userID int, usage TokenUsage) {
+	delta := models.OrganizationUsageDelta{AICalls: 1, AIInputTokens: usage.InputTokens, AIOutputTokens: usage.OutputTokens}
+	if err := us.repo.Add(userID, us.CurrentMonth(), delta); err != nil {
+		slog.Error("Failed to count AI usage", "user_id", userID, "error", err)
+	}
+}
+
+// RecordStorage measures the storage every organization holds now toward this month's peak
+func (us *OrganizationUsageService) RecordStorage() error {
+	return us.repo.RecordStorage(us.CurrentMonth())
+}
+
+// Run measures storage hourly until ctx is cancelled
+// Decision: Billing on the month's peak needs regular measurements, since uploads can be deleted between them
+func (us *OrganizationUsageService) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		if err := us.RecordStorage(); err != nil {
+			slog.Error("Failed to measure organization storage", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// MonthlyUsage returns a month's usage per organization, YYYY-MM, defaulting to the current month;
+// organizationID narrows it to one organization
+func (us *OrganizationUsageService) MonthlyUsage(month string, organizationID int) ([]*models.OrganizationUsage, error) {
+	current := us.CurrentMonth()
+	if month == "" {
+		month = current
+	}
+	if _, err := time.Parse(models.OrganizationUsageMonthLayout, month); err != nil {
+		return nil, errors.NewValidationError("month must be YYYY-MM")
+	}
+	if month > current {
+		return nil, errors.NewValidationError("month can't be in the future")
+	}
+
+	// Decision: The running month's storage is measured again on request, so it is current to the minute;
+	// closed months keep their peak
+	if month == current {
+		if err := us.RecordStorage(); err != nil {
+			return nil, err
+		}
+	}
+	return us.repo.ListMonth(month, organizationID)
+}
+
+// usageCSVHeader names the columns of WriteOrganizationUsageCSV, one row per organization
+var usageCSVHeader = []string{"month", "organization_id", "organization_name", "reports_processed", "ai_calls",
+	"ai_input_tokens", "ai_output_tokens", "storage_bytes"}
+
+// WriteOrganizationUsageCSV writes monthly usage as CSV for invoicing
+func WriteOrganizationUsageCSV(w io.Writer, usage []*models.OrganizationUsage) error {
+	writer := csv.NewWriter(w)
+	writer.Write(usageCSVHeader)
+	for _, u := range usage {
+		writer.Write([]string{
+			u.Month,
+			strconv.Itoa(u.OrganizationID),
+			csvSafe(u.OrganizationName),
+			strconv.Itoa(u.ReportsProcessed),
+			strconv.Itoa(u.AICalls),
+			strconv.FormatInt(u.AIInputTokens, 10),
+			strconv.FormatInt(u.AIOutputTokens, 10),
+			strconv.FormatInt(u.StorageBytes, 10),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
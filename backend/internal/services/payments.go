@@ -0,0 +1,475 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+// Provider API base URLs
+const (
+	stripeAPIURL   = "https://api.stripe.com"
+	razorpayAPIURL = "https://api.razorpay.com"
+)
+
+// stripeSignatureTolerance is how old a signed Stripe webhook may be, limiting replays of a captured request
+const stripeSignatureTolerance = 5 * time.Minute
+
+// razorpayTotalCount is how many billing cycles a Razorpay subscription runs; Razorpay requires a finite count
+const razorpayTotalCount = 120
+
+// CheckoutRequest describes who is buying the premium plan
+type CheckoutRequest struct {
+	UserID     int
+	Email      string
+	CustomerID string // Provider customer from an earlier subscription; empty for first-time buyers
+}
+
+// Checkout is a hosted payment page the user is sent to
+type Checkout struct {
+	URL            string
+	SubscriptionID string // Set when the provider creates the subscription before payment
+}
+
+// PaymentEvent is a webhook event reduced to what entitlements need
+type PaymentEvent struct {
+	ID                string
+	Type              string
+	UserID            int // From the metadata attached at checkout; 0 when the event doesn't carry it
+	CustomerID        string
+	SubscriptionID    string
+	Status            string // One of the models.Subscription* statuses; empty for events that don't change one
+	CurrentPeriodEnd  *time.Time
+	CancelAtPeriodEnd *bool // Nil when the provider doesn't report it on this event
+	OccurredAt        time.Time
+}
+
+// PaymentProvider sells the premium plan as a recurring subscription
+type PaymentProvider interface {
+	Name() string
+	CreateCheckout(ctx context.Context, req CheckoutRequest) (*Checkout, error)
+	CancelSubscription(ctx context.Context, subscriptionID string) error
+	ParseWebhook(payload []byte, header http.Header) (*PaymentEvent, error)
+}
+
+// NewPaymentProvider returns the provider selected by PAYMENT_PROVIDER, or nil when payments are disabled
+func NewPaymentProvider(cfg config.PaymentConfig) (PaymentProvider, error) {
+	provider := strings.ToLower(cfg.Provider)
+	switch provider {
+	case "", "none":
+		return nil, nil
+	case "stripe", "razorpay":
+	default:
+		return nil, fmt.Errorf("unknown payment provider %q (expected stripe, razorpay or none)", cfg.Provider)
+	}
+
+	if cfg.SecretKey == "" || cfg.WebhookSecret == "" || cfg.PremiumPriceID == "" {
+		return nil, fmt.Errorf("PAYMENT_SECRET_KEY, PAYMENT_WEBHOOK_SECRET and PAYMENT_PREMIUM_PRICE_ID are required when PAYMENT_PROVIDER is %s", provider)
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 15 * time.Second
+	}
+	client := &http.Client{Timeout: timeout}
+	apiURL := strings.TrimSuffix(cfg.APIURL, "/")
+
+	if provider == "stripe" {
+		if apiURL == "" {
+			apiURL = stripeAPIURL
+		}
+		return &stripeProvider{
+			secretKey:     cfg.SecretKey,
+			webhookSecret: cfg.WebhookSecret,
+			priceID:       cfg.PremiumPriceID,
+			successURL:    cfg.SuccessURL,
+			cancelURL:     cfg.CancelURL,
+			apiURL:        apiURL,
+			client:        client,
+		}, nil
+	}
+
+	if cfg.KeyID == "" {
+		return nil, fmt.Errorf("PAYMENT_KEY_ID is required when PAYMENT_PROVIDER is razorpay")
+	}
+	if apiURL == "" {
+		apiURL = razorpayAPIURL
+	}
+	return &razorpayProvider{
+		keyID:         cfg.KeyID,
+		keySecret:     cfg.SecretKey,
+		webhookSecret: cfg.WebhookSecret,
+		planID:        cfg.PremiumPriceID,
+		apiURL:        apiURL,
+		client:        client,
+	}, nil
+}
+
+// paymentRequest sends one API call and decodes a 2xx JSON response into out
+func paymentRequest(client *http.Client, req *http.Request, out any) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("payment provider request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("payment provider returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("invalid payment provider response: %w", err)
+	}
+	return nil
+}
+
+// signHMAC returns the hex HMAC-SHA256 of message, the signature both providers use for webhooks
+func signHMAC(secret string, message []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(message)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// userIDFromMetadata reads the user id attached at checkout; 0 when missing or malformed
+func userIDFromMetadata(value string) int {
+	id, err := strconv.Atoi(value)
+	if err != nil || id <= 0 {
+		return 0
+	}
+	return id
+}
+
+// unixTime converts a provider timestamp, keeping 0 as nil
+func unixTime(seconds int64) *time.Time {
+	if seconds <= 0 {
+		return nil
+	}
+	t := time.Unix(seconds, 0).UTC()
+	return &t
+}
+
+// stripeProvider uses Stripe Checkout and Billing through the REST API
+type stripeProvider struct {
+	secretKey     string
+	webhookSecret string
+	priceID       string
+	successURL    string
+	cancelURL     string
+	apiURL        string
+	client        *http.Client
+}
+
+func (sp *stripeProvider) Name() string {
+	return "stripe"
+}
+
+// post sends a form-encoded request, the only body format the Stripe API accepts
+func (sp *stripeProvider) post(ctx context.Context, path string, form url.Values, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sp.apiURL+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+sp.secretKey)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return paymentRequest(sp.client, req, out)
+}
+
+// CreateCheckout opens a Checkout session in subscription mode
+// Decision: The user id goes in both client_reference_id and the subscription's metadata, so every
+// later subscription event can be matched to the user without a lookup
+func (sp *stripeProvider) CreateCheckout(ctx context.Context, req CheckoutRequest) (*Checkout, error) {
+	userID := strconv.Itoa(req.UserID)
+	form := url.Values{
+		"mode":                                 {"subscription"},
+		"line_items[0][price]":                 {sp.priceID},
+		"line_items[0][quantity]":              {"1"},
+		"success_url":                          {sp.successURL},
+		"cancel_url":                           {sp.cancelURL},
+		"client_reference_id":                  {userID},
+		"subscription_data[metadata][user_id]": {userID},
+	}
+	if req.CustomerID != "" {
+		form.Set("customer", req.CustomerID)
+	} else if req.Email != "" {
+		form.Set("customer_email", req.Email)
+	}
+
+	var session struct {
+		URL string `json:"url"`
+	}
+	if err := sp.post(ctx, "/v1/checkout/sessions", form, &session); err != nil {
+		return nil, err
+	}
+	if session.URL == "" {
+		return nil, fmt.Errorf("payment provider returned no checkout URL")
+	}
+	return &Checkout{URL: session.URL}, nil
+}
+
+// CancelSubscription stops renewal; the subscription stays active until the paid period ends
+func (sp *stripeProvider) CancelSubscription(ctx context.Context, subscriptionID string) error {
+	return sp.post(ctx, "/v1/subscriptions/"+url.PathEscape(subscriptionID), url.Values{"cancel_at_period_end": {"true"}}, nil)
+}
+
+type stripeEvent struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Created int64  `json:"created"`
+	Data    struct {
+		Object json.RawMessage `json:"object"`
+	} `json:"data"`
+}
+
+type stripeCheckoutSession struct {
+	ClientReferenceID string `json:"client_reference_id"`
+	Customer          string `json:"customer"`
+	Subscription      string `json:"subscription"`
+	Mode              string `json:"mode"`
+	PaymentStatus     string `json:"payment_status"`
+}
+
+type stripeSubscription struct {
+	ID                string            `json:"id"`
+	Customer          string            `json:"customer"`
+	Status            string            `json:"status"`
+	CurrentPeriodEnd  int64             `json:"current_period_end"`
+	CancelAtPeriodEnd bool              `json:"cancel_at_period_end"`
+	Metadata          map[string]string `json:"metadata"`
+}
+
+// ParseWebhook verifies the Stripe-Signature header and reads subscription changes
+func (sp *stripeProvider) ParseWebhook(payload []byte, header http.Header) (*PaymentEvent, error) {
+	if err := sp.verifySignature(payload, header.Get("Stripe-Signature"), time.Now()); err != nil {
+		return nil, err
+	}
+
+	var event stripeEvent
+	if err := json.Unmarshal(payload, &event); err != nil || event.ID == "" {
+		return nil, errors.NewValidationError("Invalid webhook payload")
+	}
+	parsed := &PaymentEvent{ID: event.ID, Type: event.Type, OccurredAt: time.Unix(event.Created, 0).UTC()}
+
+	switch event.Type {
+	case "checkout.session.completed":
+		var session stripeCheckoutSession
+		if err := json.Unmarshal(event.Data.Object, &session); err != nil {
+			return nil, errors.NewValidationError("Invalid webhook payload")
+		}
+		if session.Mode != "subscription" {
+			return parsed, nil
+		}
+		parsed.UserID = userIDFromMetadata(session.ClientReferenceID)
+		parsed.CustomerID = session.Customer
+		parsed.SubscriptionID = session.Subscription
+		// Decision: A paid session activates premium right away; the subscription events that follow
+		// carry the period end
+		if session.PaymentStatus == "paid" || session.PaymentStatus == "no_payment_required" {
+			parsed.Status = models.SubscriptionActive
+		}
+	case "customer.subscription.created", "customer.subscription.updated", "customer.subscription.deleted",
+		"customer.subscription.paused", "customer.subscription.resumed":
+		var subscription stripeSubscription
+		if err := json.Unmarshal(event.Data.Object, &subscription); err != nil {
+			return nil, errors.NewValidationError("Invalid webhook payload")
+		}
+		parsed.UserID = userIDFromMetadata(subscription.Metadata["user_id"])
+		parsed.CustomerID = subscription.Customer
+		parsed.SubscriptionID = subscription.ID
+		parsed.Status = stripeStatus(subscription.Status)
+		parsed.CurrentPeriodEnd = unixTime(subscription.CurrentPeriodEnd)
+		parsed.CancelAtPeriodEnd = &subscription.CancelAtPeriodEnd
+	}
+	return parsed, nil
+}
+
+// verifySignature checks "t=<unix>,v1=<hex>" against an HMAC of "<t>.<payload>"
+func (sp *stripeProvider) verifySignature(payload []byte, header string, now time.Time) error {
+	return verifyTimestampedSignature(sp.webhookSecret, payload, header, now, stripeSignatureTolerance)
+}
+
+// verifyTimestampedSignature checks a "t=<unix>,v1=<hex>" header against an HMAC of "<t>.<payload>" signed
+// within tolerance of now; several v1 values are allowed while a secret is rotated
+func verifyTimestampedSignature(secret string, payload []byte, header string, now time.Time, tolerance time.Duration) error {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	signedAt, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return errors.ErrInvalidWebhookSignature
+	}
+	if age := now.Sub(time.Unix(signedAt, 0)); age > tolerance || age < -tolerance {
+		return errors.ErrInvalidWebhookSignature
+	}
+
+	expected := signHMAC(secret, append([]byte(timestamp+"."), payload...))
+	for _, signature := range signatures {
+		if hmac.Equal([]byte(signature), []byte(expected)) {
+			return nil
+		}
+	}
+	return errors.ErrInvalidWebhookSignature
+}
+
+// stripeStatus maps a Stripe subscription status onto ours
+func stripeStatus(status string) string {
+	switch status {
+	case "active", "trialing":
+		return models.SubscriptionActive
+	case "past_due":
+		return models.SubscriptionPastDue
+	case "paused":
+		return models.SubscriptionPaused
+	case "incomplete":
+		return models.SubscriptionIncomplete
+	default: // canceled, unpaid, incomplete_expired
+		return models.SubscriptionCanceled
+	}
+}
+
+// razorpayProvider uses Razorpay Subscriptions through the REST API
+type razorpayProvider struct {
+	keyID         string
+	keySecret     string
+	webhookSecret string
+	planID        string
+	apiURL        string
+	client        *http.Client
+}
+
+func (rp *razorpayProvider) Name() string {
+	return "razorpay"
+}
+
+// post sends a JSON request with the key pair as basic auth
+func (rp *razorpayProvider) post(ctx context.Context, path string, payload any, out any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rp.apiURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(rp.keyID, rp.keySecret)
+	req.Header.Set("Content-Type", "application/json")
+	return paymentRequest(rp.client, req, out)
+}
+
+// CreateCheckout creates the subscription up front and returns its hosted payment link
+// Decision: Razorpay creates the subscription before payment, so its id is known immediately; the
+// user id rides in notes, which every subscription webhook echoes back
+func (rp *razorpayProvider) CreateCheckout(ctx context.Context, req CheckoutRequest) (*Checkout, error) {
+	payload := map[string]any{
+		"plan_id":         rp.planID,
+		"total_count":     razorpayTotalCount,
+		"customer_notify": 1,
+		"notes":           map[string]string{"user_id": strconv.Itoa(req.UserID)},
+	}
+
+	var subscription struct {
+		ID       string `json:"id"`
+		ShortURL string `json:"short_url"`
+	}
+	if err := rp.post(ctx, "/v1/subscriptions", payload, &subscription); err != nil {
+		return nil, err
+	}
+	if subscription.ShortURL == "" {
+		return nil, fmt.Errorf("payment provider returned no checkout URL")
+	}
+	return &Checkout{URL: subscription.ShortURL, SubscriptionID: subscription.ID}, nil
+}
+
+// CancelSubscription stops renewal at the end of the current billing cycle
+func (rp *razorpayProvider) CancelSubscription(ctx context.Context, subscriptionID string) error {
+	return rp.post(ctx, "/v1/subscriptions/"+url.PathEscape(subscriptionID)+"/cancel",
+		map[string]int{"cancel_at_cycle_end": 1}, nil)
+}
+
+type razorpayEvent struct {
+	Event     string `json:"event"`
+	CreatedAt int64  `json:"created_at"`
+	Payload   struct {
+		Subscription *struct {
+			Entity struct {
+				ID         string            `json:"id"`
+				CustomerID string            `json:"customer_id"`
+				Status     string            `json:"status"`
+				CurrentEnd int64             `json:"current_end"`
+				Notes      map[string]string `json:"notes"`
+			} `json:"entity"`
+		} `json:"subscription"`
+	} `json:"payload"`
+}
+
+// ParseWebhook verifies the X-Razorpay-Signature header and reads subscription changes
+func (rp *razorpayProvider) ParseWebhook(payload []byte, header http.Header) (*PaymentEvent, error) {
+	if !hmac.Equal([]byte(header.Get("X-Razorpay-Signature")), []byte(signHMAC(rp.webhookSecret, payload))) {
+		return nil, errors.ErrInvalidWebhookSignature
+	}
+
+	var event razorpayEvent
+	if err := json.Unmarshal(payload, &event); err != nil || event.Event == "" {
+		return nil, errors.NewValidationError("Invalid webhook payload")
+	}
+
+	// Decision: Razorpay sends the event id in a header; a hash of the body stands in for senders that omit it
+	eventID := header.Get("X-Razorpay-Event-Id")
+	if eventID == "" {
+		sum := sha256.Sum256(payload)
+		eventID = hex.EncodeToString(sum[:])
+	}
+	parsed := &PaymentEvent{ID: eventID, Type: event.Event, OccurredAt: time.Unix(event.CreatedAt, 0).UTC()}
+
+	if !strings.HasPrefix(event.Event, "subscription.") || event.Payload.Subscription == nil {
+		return parsed, nil
+	}
+	subscription := event.Payload.Subscription.Entity
+	parsed.UserID = userIDFromMetadata(subscription.Notes["user_id"])
+	parsed.CustomerID = subscription.CustomerID
+	parsed.SubscriptionID = subscription.ID
+	parsed.Status = razorpayStatus(subscription.Status)
+	parsed.CurrentPeriodEnd = unixTime(subscription.CurrentEnd)
+	return parsed, nil
+}
+
+// razorpayStatus maps a Razorpay subscription status onto ours
+func razorpayStatus(status string) string {
+	switch status {
+	case "active":
+		return models.SubscriptionActive
+	case "pending":
+		return models.SubscriptionPastDue
+	case "halted", "paused":
+		return models.SubscriptionPaused
+	case "created", "authenticated":
+		return models.SubscriptionIncomplete
+	default: // cancelled, completed, expired
+		return models.SubscriptionCanceled
+	}
+}
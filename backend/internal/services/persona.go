@@ -0,0 +1,48 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+)
+
+// BuildSystemPrompt renders the deployment persona as a system instruction for the model
+// Decision: The persona is a system prompt layered over the task templates, so hospitals can
+// rebrand the assistant without forking the analysis prompt and its JSON contract
+func BuildSystemPrompt(persona config.PersonaConfig) (string, error) {
+	var prompt strings.Builder
+
+	name := persona.Name
+	if name == "" {
+		name = "a medical report assistant"
+	}
+	if persona.Organization != "" {
+		fmt.Fprintf(&prompt, "You are %s, the patient assistant of %s.\n", name, persona.Organization)
+		fmt.Fprintf(&prompt, "Speak on behalf of %s and refer patients to their %s care team for clinical decisions.\n", persona.Organization, persona.Organization)
+	} else {
+		fmt.Fprintf(&prompt, "You are %s, helping patients understand their own medical reports.\n", name)
+	}
+
+	if persona.Tone != "" {
+		fmt.Fprintf(&prompt, "Tone: %s.\n", strings.TrimSuffix(persona.Tone, "."))
+	}
+
+	prompt.WriteString("Never diagnose, prescribe, or contradict a treating physician.\n")
+	if persona.Disclaimer != "" {
+		fmt.Fprintf(&prompt, "When a patient asks for a diagnosis or treatment decision, remind them: %q\n", persona.Disclaimer)
+	}
+
+	if persona.PromptPath != "" {
+		extra, err := os.ReadFile(persona.PromptPath)
+		if err != nil {
+			return "", fmt.Errorf("failed to read persona prompt: %w", err)
+		}
+		prompt.WriteString("\n")
+		prompt.WriteString(strings.TrimSpace(string(extra)))
+		prompt.WriteString("\n")
+	}
+
+	return prompt.String(), nil
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+// chatWindow is the rolling period a plan's chat allowance covers
+// Decision: Rolling rather than per calendar day, so the limit needs no timezone and can't be doubled around midnight
+const chatWindow = 24 * time.Hour
+
+// Features a plan can include through PlanConfig.Features
+const (
+	FeatureCrossReport = "cross_report" // Merged analyses across several reports
+	FeaturePDFExport   = "pdf_export"   // Chat transcripts as PDF
+)
+
+// PlanLimits returns the limits of plan, falling back to the free plan for unknown or empty names
+func PlanLimits(plans map[string]config.PlanConfig, plan string) config.PlanConfig {
+	if limits, ok := plans[plan]; ok {
+		return limits
+	}
+	return plans[models.PlanFree]
+}
+
+// planGuidance tells the analysis prompt how long and how deep an analysis may be; empty when unlimited
+func planGuidance(limits config.PlanConfig) string {
+	guidance := ""
+	if limits.SummaryWords > 0 {
+		guidance += fmt.Sprintf("Keep simple_summary under %d words. ", limits.SummaryWords)
+	}
+	if limits.MaxFindings > 0 {
+		guidance += fmt.Sprintf("List at most %d key_findings and at most %d recommendations, most important first.",
+			limits.MaxFindings, limits.MaxFindings)
+	}
+	return guidance
+}
+
+// applyPlanLimits trims an analysis to the plan's depth
+// Decision: The prompt asks for the limit and this enforces it, since models don't reliably count
+func applyPlanLimits(analysis *AnalysisResult, limits config.PlanConfig) {
+	if limits.MaxFindings <= 0 {
+		return
+	}
+	if len(analysis.KeyFindings) > limits.MaxFindings {
+		analysis.KeyFindings = analysis.KeyFindings[:limits.MaxFindings]
+	}
+	if len(analysis.Recommendations) > limits.MaxFindings {
+		analysis.Recommendations = analysis.Recommendations[:limits.MaxFindings]
+	}
+}
+
+// PlanStatus is a user's plan, its limits, and how much of the chat allowance is used
+type PlanStatus struct {
+	Plan           string
+	Limits         config.PlanConfig
+	ChatUsed       int       // Questions asked in the current window
+	ChatWindowFrom time.Time // Start of the window ChatUsed counts
+}
+
+// PlanService reports plan usage and lets administrators change a user's plan
+type PlanService struct {
+	userRepo  models.UserRepository
+	chatRepo  models.ChatMessageRepository
+	auditRepo models.AuditLogRepository
+	plans     map[string]config.PlanConfig
+}
+
+// NewPlanService creates a new plan service
+func NewPlanService(userRepo models.UserRepository, chatRepo models.ChatMessageRepository, auditRepo models.AuditLogRepository, plans map[string]config.PlanConfig) *PlanService {
+	return &PlanService{
+		userRepo:  userRepo,
+		chatRepo:  chatRepo,
+		auditRepo: auditRepo,
+		plans:     plans,
+	}
+}
+
+// Status describes the user's plan and current chat usage
+func (ps *PlanService) Status(user *models.User) (*PlanStatus, error) {
+	from := time.Now().Add(-chatWindow)
+	used, err := ps.chatRepo.CountByUserSince(user.ID, from)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+
+	plan := user.Plan
+	if !models.IsValidPlan(plan) {
+		plan = models.PlanFree
+	}
+	return &PlanStatus{
+		Plan:           plan,
+		Limits:         PlanLimits(ps.plans, plan),
+		ChatUsed:       used,
+		ChatWindowFrom: from,
+	}, nil
+}
+
+// Require fails with ErrPremiumRequired unless the user's plan includes feature
+// Decision: A feature no plan lists is open to everyone, so emptying PLAN_PREMIUM_FEATURES ungates it
+func (ps *PlanService) Require(user *models.User, feature string) error {
+	if slices.Contains(PlanLimits(ps.plans, user.Plan).Features, feature) {
+		return nil
+	}
+	for _, limits := range ps.plans {
+		if slices.Contains(limits.Features, feature) {
+			return errors.ErrPremiumRequired
+		}
+	}
+	return nil
+}
+
+// SetPlan moves a user to another plan; reports uploaded before keep the depth they were analyzed at
+func (ps *PlanService) SetPlan(admin *models.User, userID int, plan string) (*models.User, error) {
+	if !models.IsValidPlan(plan) {
+		return nil, errors.ErrInvalidPlan
+	}
+
+	current, err := ps.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if current == nil {
+		return nil, errors.ErrUserNotFound
+	}
+
+	// Decision: Work on a copy - the cached repository may share the stored pointer
+	user := *current
+	previous := user.Plan
+	user.Plan = plan
+	if err := ps.userRepo.Update(&user); err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+
+	entry := &models.AuditLog{ActorID: admin.ID, UserID: userID, Action: models.AuditPlanChanged,
+		Details: fmt.Sprintf("%s -> %s", previous, plan)}
+	if err := ps.auditRepo.Create(entry); err != nil {
+		slog.Error("Failed to audit plan change", "admin_id", admin.ID, "error", err)
+	}
+	return &user, nil
+}
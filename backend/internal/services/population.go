@@ -0,0 +1,340 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+// AgeBands are the age groups population statistics are computed for, youngest first
+// Decision: Minors are left out entirely; only adults can consent for themselves
+var AgeBands = []struct {
+	Name     string
+	From, To int // Inclusive; To 0 means no upper bound
+}{
+	{"18-29", 18, 29},
+	{"30-39", 30, 39},
+	{"40-49", 40, 49},
+	{"50-59", 50, 59},
+	{"60-69", 60, 69},
+	{"70+", 70, 0},
+}
+
+// AgeBand returns the band an age falls in, or "" for minors
+func AgeBand(age int) string {
+	for _, band := range AgeBands {
+		if age >= band.From && (band.To == 0 || age <= band.To) {
+			return band.Name
+		}
+	}
+	return ""
+}
+
+// PopulationConsent is whether a user shares their values with the population statistics
+type PopulationConsent struct {
+	OptedIn     bool
+	ConsentedAt *time.Time
+}
+
+// PopulationComparison places a user's latest values within their age band
+type PopulationComparison struct {
+	AgeBand   string
+	MinCohort int
+	Metrics   []MetricComparison
+}
+
+// MetricComparison is one of the user's values against the distribution of their peers
+type MetricComparison struct {
+	Stat     *models.PopulationStat
+	Unit     string // As the user's report wrote it
+	Value    float64
+	ReportID int
+	Position string // below_p10, p10_p25, p25_p50, p50_p75, p75_p90, or above_p90
+}
+
+// metricValue is a numeric lab value taken from a report
+type metricValue struct {
+	name     string
+	unit     string
+	value    float64
+	reportID int
+}
+
+// PopulationService aggregates the lab values of users who opted in into anonymized distributions per age band
+// Decision: Only aggregates are stored, each from at least minCohort users, and each user counts once per
+// metric with their latest value, so no stored number can be traced to one person or one frequent uploader
+type PopulationService struct {
+	repo        models.PopulationRepository
+	reportRepo  models.ReportRepository
+	profileRepo models.HealthProfileRepository
+	minCohort   int
+	interval    time.Duration
+}
+
+// minPopulationCohort is the lowest k-anonymity floor the service accepts
+const minPopulationCohort = 5
+
+// NewPopulationService creates a population insight service; cohorts smaller than cfg.MinCohort are never published
+func NewPopulationService(
+	repo models.PopulationRepository,
+	reportRepo models.ReportRepository,
+	profileRepo models.HealthProfileRepository,
+	cfg config.InsightsConfig,
+) (*PopulationService, error) {
+	if cfg.MinCohort < minPopulationCohort {
+		return nil, fmt.Errorf("POPULATION_MIN_COHORT must be at least %d", minPopulationCohort)
+	}
+	if cfg.RefreshInterval <= 0 {
+		return nil, fmt.Errorf("POPULATION_REFRESH_INTERVAL must be positive")
+	}
+	return &PopulationService{
+		repo:        repo,
+		reportRepo:  reportRepo,
+		profileRepo: profileRepo,
+		minCohort:   cfg.MinCohort,
+		interval:    cfg.RefreshInterval,
+	}, nil
+}
+
+// Consent returns the user's current choice
+func (ps *PopulationService) Consent(userID int) (*PopulationConsent, error) {
+	consentedAt, err := ps.repo.GetConsent(userID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	return &PopulationConsent{OptedIn: consentedAt != nil, ConsentedAt: consentedAt}, nil
+}
+
+// SetConsent opts the user in or out
+// Decision: Withdrawing takes effect at the next refresh, when the statistics are rebuilt without the user
+func (ps *PopulationService) SetConsent(userID int, optedIn bool) (*PopulationConsent, error) {
+	if err := ps.repo.SetConsent(userID, optedIn); err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	return ps.Consent(userID)
+}
+
+// Compare places the user's latest values within their age band's distributions
+// Decision: Only users who share their own values may see their peers', and only metrics whose
+// cohort cleared the floor are compared
+func (ps *PopulationService) Compare(userID int) (*PopulationComparison, error) {
+	consent, err := ps.Consent(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !consent.OptedIn {
+		return nil, errors.ErrPopulationConsentRequired
+	}
+
+	band, err := ps.ageBand(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := ps.repo.ListStats(band)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	values, err := ps.latestValues(userID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+
+	comparison := &PopulationComparison{AgeBand: band, MinCohort: ps.minCohort, Metrics: []MetricComparison{}}
+	for _, stat := range stats {
+		value, ok := values[populationKey(stat.MetricKey, stat.Unit)]
+		if !ok {
+			continue
+		}
+		comparison.Metrics = append(comparison.Metrics, MetricComparison{
+			Stat:     stat,
+			Unit:     value.unit,
+			Value:    value.value,
+			ReportID: value.reportID,
+			Position: percentilePosition(stat, value.value),
+		})
+	}
+	return comparison, nil
+}
+
+// Refresh rebuilds every distribution from the users currently opted in and returns how many were published
+func (ps *PopulationService) Refresh() (int, error) {
+	userIDs, err := ps.repo.ListConsentedUsers()
+	if err != nil {
+		return 0, err
+	}
+
+	type cohort struct {
+		name   string
+		values []float64
+	}
+	cohorts := map[string]*cohort{}
+	now := time.Now()
+	for _, userID := range userIDs {
+		profile, err := ps.profileRepo.GetByUserID(userID)
+		if err != nil {
+			return 0, err
+		}
+		age := profile.Age(now)
+		if age == nil || AgeBand(*age) == "" {
+			continue
+		}
+		band := AgeBand(*age)
+
+		values, err := ps.latestValues(userID)
+		if err != nil {
+			return 0, err
+		}
+		for key, value := range values {
+			c := cohorts[band+"\x00"+key]
+			if c == nil {
+				c = &cohort{name: value.name}
+				cohorts[band+"\x00"+key] = c
+			}
+			c.values = append(c.values, value.value)
+		}
+	}
+
+	var stats []*models.PopulationStat
+	for key, c := range cohorts {
+		if len(c.values) < ps.minCohort {
+			continue
+		}
+		parts := strings.SplitN(key, "\x00", 3)
+		sort.Float64s(c.values)
+		stats = append(stats, &models.PopulationStat{
+			AgeBand:      parts[0],
+			MetricKey:    parts[1],
+			Unit:         parts[2],
+			DisplayName:  c.name,
+			Contributors: len(c.values),
+			P10:          percentile(c.values, 0.10),
+			P25:          percentile(c.values, 0.25),
+			P50:          percentile(c.values, 0.50),
+			P75:          percentile(c.values, 0.75),
+			P90:          percentile(c.values, 0.90),
+		})
+	}
+
+	if err := ps.repo.ReplaceStats(stats); err != nil {
+		return 0, err
+	}
+	return len(stats), nil
+}
+
+// Run refreshes the statistics at the configured interval until ctx is cancelled
+func (ps *PopulationService) Run(ctx context.Context) {
+	ticker := time.NewTicker(ps.interval)
+	defer ticker.Stop()
+
+	for {
+		if published, err := ps.Refresh(); err != nil {
+			slog.Error("Failed to refresh population statistics", "error", err)
+		} else {
+			slog.Info("Published population metric distributions", "count", published)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ageBand returns the user's age band from their health profile
+func (ps *PopulationService) ageBand(userID int) (string, error) {
+	profile, err := ps.profileRepo.GetByUserID(userID)
+	if err != nil {
+		return "", errors.ErrDatabaseConnection
+	}
+	age := profile.Age(time.Now())
+	if age == nil {
+		return "", errors.NewValidationError("Add your date of birth to your health profile to compare with your age group")
+	}
+	band := AgeBand(*age)
+	if band == "" {
+		return "", errors.NewValidationError("Population insights are only available to adults")
+	}
+	return band, nil
+}
+
+// latestValues returns the user's newest numeric value of each metric, keyed by metric and unit
+func (ps *PopulationService) latestValues(userID int) (map[string]metricValue, error) {
+	reports, err := ps.reportRepo.ListByFilter(models.ReportFilter{UserID: userID, Status: "completed"})
+	if err != nil {
+		return nil, err
+	}
+
+	// Decision: ListByFilter returns oldest first; walking it backwards lets the newest report carrying a value win
+	values := map[string]metricValue{}
+	for i := len(reports) - 1; i >= 0; i-- {
+		analysis, err := ParseStoredAnalysis(reports[i].SimplifiedSummary)
+		if err != nil {
+			continue
+		}
+		for j := range analysis.HealthMetrics {
+			metric := &analysis.HealthMetrics[j]
+			value, err := strconv.ParseFloat(strings.TrimSpace(metric.GetValueAsString()), 64)
+			if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+				continue
+			}
+			key := populationKey(populationMetricKey(metric.Name), populationUnit(metric.Unit))
+			if _, seen := values[key]; !seen {
+				values[key] = metricValue{name: strings.TrimSpace(metric.Name), unit: metric.Unit, value: value, reportID: reports[i].ID}
+			}
+		}
+	}
+	return values, nil
+}
+
+// populationKey joins a metric key and unit into a map key
+func populationKey(metricKey, unit string) string {
+	return metricKey + "\x00" + unit
+}
+
+// populationMetricKey normalizes a metric name so "HbA1c" and " hba1c " share a cohort
+func populationMetricKey(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// populationUnit normalizes a unit so "mg/dL" and "mg / dl" share a cohort
+func populationUnit(unit string) string {
+	return strings.ToLower(strings.Join(strings.Fields(unit), ""))
+}
+
+// percentile interpolates the p-th quantile of sorted values, rounded to two decimals
+func percentile(sorted []float64, p float64) float64 {
+	rank := p * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := min(lower+1, len(sorted)-1)
+	value := sorted[lower] + (rank-float64(lower))*(sorted[upper]-sorted[lower])
+	return math.Round(value*100) / 100
+}
+
+// percentilePosition names the part of the distribution value falls in
+func percentilePosition(stat *models.PopulationStat, value float64) string {
+	switch {
+	case value < stat.P10:
+		return "below_p10"
+	case value < stat.P25:
+		return "p10_p25"
+	case value < stat.P50:
+		return "p25_p50"
+	case value < stat.P75:
+		return "p50_p75"
+	case value <= stat.P90:
+		return "p75_p90"
+	default:
+		return "above_p90"
+	}
+}
@@ -0,0 +1,375 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/generative-ai-go/genai"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+	"google.golang.org/api/option"
+)
+
+// Bounds on what one prescription stores
+const (
+	maxPrescriptionMedications = 30
+	maxPrescriptionFieldLength = 200
+	maxPrescriptionNotesLength = 1000
+)
+
+// prescriptionImageExtensions maps accepted photo types to the extension the stored file gets
+var prescriptionImageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+// DetectPrescriptionImageType returns the type of a prescription photo, or false if it is not a supported format
+// Decision: Sniff rather than trust the part's Content-Type, as for voice recordings
+func DetectPrescriptionImageType(data []byte) (string, bool) {
+	sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+	_, ok := prescriptionImageExtensions[sniffed]
+	return sniffed, ok
+}
+
+// PrescriptionReading is what a reader made of one prescription photo
+type PrescriptionReading struct {
+	Prescriber   string                           `json:"prescriber"`
+	PrescribedOn string                           `json:"prescribed_on"`
+	Notes        string                           `json:"notes"`
+	Medications  []*models.PrescriptionMedication `json:"medications"`
+}
+
+// PrescriptionReader transcribes a photographed, often handwritten, prescription into medication lines
+type PrescriptionReader interface {
+	ReadPrescription(ctx context.Context, image []byte, imageType string) (*PrescriptionReading, error)
+	Name() string
+}
+
+// NewPrescriptionReader returns the provider selected by PRESCRIPTION_PROVIDER, or nil when prescription reading is disabled
+func NewPrescriptionReader(cfg config.PrescriptionConfig) (PrescriptionReader, error) {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 60 * time.Second
+	}
+
+	switch strings.ToLower(cfg.Provider) {
+	case "", "none":
+		return nil, nil
+	case "gemini":
+		if cfg.APIKey == "" {
+			return nil, fmt.Errorf("PRESCRIPTION_API_KEY or GEMINI_API_KEY is required when PRESCRIPTION_PROVIDER is gemini")
+		}
+		client, err := genai.NewClient(context.Background(), option.WithAPIKey(cfg.APIKey))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Gemini client: %w", err)
+		}
+		modelName := cfg.Model
+		if modelName == "" {
+			modelName = "gemini-1.5-flash"
+		}
+		model := client.GenerativeModel(modelName)
+		model.SetTemperature(0) // Read, don't guess
+		model.ResponseMIMEType = "application/json"
+		return &geminiPrescriptionReader{model: model, timeout: timeout}, nil
+	default:
+		return nil, fmt.Errorf("unknown prescription provider %q (expected gemini or none)", cfg.Provider)
+	}
+}
+
+// prescriptionInstruction asks for the prescription as JSON with a confidence per line
+// Decision: The model scores each line itself; a misread drug name is the costly mistake, so it is told to score low rather than guess
+const prescriptionInstruction = `This is a photo of a medical prescription, often handwritten. Transcribe it as JSON:
+{"prescriber": "doctor or clinic name, or empty",
+ "prescribed_on": "date as written, or empty",
+ "notes": "advice or instructions that are not about one medication, or empty",
+ "medications": [{"name": "", "dosage": "", "frequency": "", "duration": "", "instructions": "", "confidence": 0.0}]}
+Rules:
+- One entry per medication, in the order written. Expand common abbreviations in frequency and instructions
+  (OD = once daily, BD = twice daily, TDS = three times daily, SOS = when needed, AC = before food, PC = after food).
+- Copy drug names and strengths exactly as written; do not substitute a similar-looking drug.
+- confidence is 0 to 1: how sure you are that the whole line is read correctly. Use below 0.5 when any part is a guess.
+- Leave a field empty rather than invent it. If the image is not a prescription, return an empty medications list.
+Output only the JSON object.`
+
+// geminiPrescriptionReader sends the photo to a multimodal Gemini model
+type geminiPrescriptionReader struct {
+	model   *genai.GenerativeModel
+	timeout time.Duration
+}
+
+// ReadPrescription asks the model for the prescription's medication lines
+func (gr *geminiPrescriptionReader) ReadPrescription(ctx context.Context, image []byte, imageType string) (*PrescriptionReading, error) {
+	ctx, cancel := context.WithTimeout(ctx, gr.timeout)
+	defer cancel()
+
+	resp, err := gr.model.GenerateContent(ctx, genai.Blob{MIMEType: imageType, Data: image}, genai.Text(prescriptionInstruction))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prescription: %w", err)
+	}
+	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil {
+		return nil, fmt.Errorf("no response from model")
+	}
+
+	var text strings.Builder
+	for _, part := range resp.Candidates[0].Content.Parts {
+		if txt, ok := part.(genai.Text); ok {
+			text.WriteString(string(txt))
+		}
+	}
+
+	var reading PrescriptionReading
+	if err := json.Unmarshal([]byte(extractJSONObject(text.String())), &reading); err != nil {
+		return nil, fmt.Errorf("invalid prescription response: %w", err)
+	}
+	return &reading, nil
+}
+
+func (gr *geminiPrescriptionReader) Name() string {
+	return "gemini"
+}
+
+// ReadPrescription returns a canned prescription with one doubtful line so the correction flow can be tried
+func (da *DemoAnalyzer) ReadPrescription(ctx context.Context, image []byte, imageType string) (*PrescriptionReading, error) {
+	return &PrescriptionReading{
+		Prescriber:   "Dr. A. Rao, City Clinic",
+		PrescribedOn: "12/03/2024",
+		Notes:        "Review after two weeks with fasting sugar report",
+		Medications: []*models.PrescriptionMedication{
+			{Name: "Metformin 500 mg", Dosage: "1 tablet", Frequency: "twice daily", Duration: "30 days",
+				Instructions: "after food", Confidence: 0.93},
+			{Name: "Atorvastatin 10 mg", Dosage: "1 tablet", Frequency: "once daily at night", Duration: "30 days",
+				Confidence: 0.88},
+			{Name: "Pantoprazole 40 mg", Dosage: "1 tablet", Frequency: "once daily", Duration: "5 days",
+				Instructions: "before food", Confidence: 0.46},
+		},
+	}, nil
+}
+
+// Name identifies the demo reader on stored prescriptions
+func (da *DemoAnalyzer) Name() string {
+	return "demo"
+}
+
+// MedicationCorrection is a user's edit to one medication line; nil fields are left as read
+type MedicationCorrection struct {
+	Name         *string
+	Dosage       *string
+	Frequency    *string
+	Duration     *string
+	Instructions *string
+}
+
+// PrescriptionService reads prescription photos and keeps the medications for the user to check
+type PrescriptionService struct {
+	prescriptionRepo models.PrescriptionRepository
+	reader           PrescriptionReader
+	fileStorage      *FileStorage
+	lowConfidence    float64
+}
+
+// NewPrescriptionService creates a new prescription service
+// Decision: reader may be nil when no vision model is configured; uploads then return 503 while stored prescriptions stay readable
+func NewPrescriptionService(prescriptionRepo models.PrescriptionRepository, reader PrescriptionReader, fileStorage *FileStorage, lowConfidence float64) *PrescriptionService {
+	return &PrescriptionService{
+		prescriptionRepo: prescriptionRepo,
+		reader:           reader,
+		fileStorage:      fileStorage,
+		lowConfidence:    lowConfidence,
+	}
+}
+
+// Upload reads a prescription photo, then stores the photo and the medications read from it
+func (ps *PrescriptionService) Upload(ctx context.Context, userID int, image []byte, imageType string) (*models.Prescription, error) {
+	if ps.reader == nil {
+		return nil, errors.ErrPrescriptionReaderUnavailable
+	}
+	ext, ok := prescriptionImageExtensions[imageType]
+	if !ok {
+		return nil, errors.NewValidationError("Unsupported image format, use JPEG, PNG, or WebP")
+	}
+
+	// Decision: Read before writing the file so a failed read leaves nothing behind
+	reading, err := ps.reader.ReadPrescription(ctx, image, imageType)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to read prescription", "user_id", userID, "reader", ps.reader.Name(), "error", err)
+		return nil, errors.ErrAIProcessingFailed
+	}
+	medications := ps.cleanMedications(reading.Medications)
+	if len(medications) == 0 {
+		return nil, errors.ErrPrescriptionUnreadable
+	}
+
+	filePath, err := ps.fileStorage.NewFilePath(userID, "prescription"+ext)
+	if err != nil {
+		return nil, errors.ErrFileUploadFailed
+	}
+	if err := os.WriteFile(filePath, image, 0640); err != nil {
+		slog.ErrorContext(ctx, "Failed to save prescription photo", "user_id", userID, "error", err)
+		return nil, errors.ErrFileUploadFailed
+	}
+
+	prescription := &models.Prescription{
+		UserID:       userID,
+		FilePath:     filePath,
+		ImageType:    imageType,
+		Prescriber:   truncateField(reading.Prescriber),
+		PrescribedOn: truncateField(reading.PrescribedOn),
+		Notes:        capRunes(strings.TrimSpace(reading.Notes), maxPrescriptionNotesLength),
+		Medications:  medications,
+		Reader:       ps.reader.Name(),
+	}
+	if err := ps.prescriptionRepo.Create(prescription); err != nil {
+		ps.removePhoto(prescription)
+		return nil, errors.ErrDatabaseConnection
+	}
+	return prescription, nil
+}
+
+// cleanMedications trims what the reader returned, drops lines without a name, and flags doubtful ones
+func (ps *PrescriptionService) cleanMedications(read []*models.PrescriptionMedication) []*models.PrescriptionMedication {
+	medications := make([]*models.PrescriptionMedication, 0, len(read))
+	for _, line := range read {
+		if line == nil || strings.TrimSpace(line.Name) == "" {
+			continue
+		}
+		confidence := min(max(line.Confidence, 0), 1)
+		medications = append(medications, &models.PrescriptionMedication{
+			Name:          truncateField(line.Name),
+			Dosage:        truncateField(line.Dosage),
+			Frequency:     truncateField(line.Frequency),
+			Duration:      truncateField(line.Duration),
+			Instructions:  truncateField(line.Instructions),
+			Confidence:    confidence,
+			LowConfidence: confidence < ps.lowConfidence,
+		})
+		if len(medications) == maxPrescriptionMedications {
+			break
+		}
+	}
+	return medications
+}
+
+// Get returns one of the user's prescriptions
+func (ps *PrescriptionService) Get(userID, id int) (*models.Prescription, error) {
+	prescription, err := ps.prescriptionRepo.GetByID(id)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	// Decision: Someone else's prescription looks the same as a missing one
+	if prescription == nil || prescription.UserID != userID {
+		return nil, errors.ErrRecordNotFound
+	}
+	return prescription, nil
+}
+
+// List returns the user's prescriptions, newest first
+func (ps *PrescriptionService) List(userID, limit, offset int) ([]*models.Prescription, error) {
+	prescriptions, err := ps.prescriptionRepo.ListByUser(userID, limit, offset)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	return prescriptions, nil
+}
+
+// OpenPhoto opens the photo of one of the user's prescriptions; the caller closes it
+func (ps *PrescriptionService) OpenPhoto(userID, id int) (*models.Prescription, *os.File, error) {
+	prescription, err := ps.Get(userID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	file, err := ps.fileStorage.Open(prescription.FilePath)
+	if err != nil {
+		slog.Error("Failed to open prescription photo", "prescription_id", id, "error", err)
+		return nil, nil, errors.ErrRecordNotFound
+	}
+	return prescription, file, nil
+}
+
+// CorrectMedication applies the user's edit to one medication line, by its position in the list
+// Decision: Any correction, even an empty one confirming the line as read, clears the low-confidence flag
+func (ps *PrescriptionService) CorrectMedication(userID, id, index int, correction MedicationCorrection) (*models.Prescription, error) {
+	prescription, err := ps.Get(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if index < 0 || index >= len(prescription.Medications) {
+		return nil, errors.ErrRecordNotFound
+	}
+
+	line := prescription.Medications[index]
+	fields := []struct {
+		value *string
+		into  *string
+		label string
+	}{
+		{correction.Name, &line.Name, "Name"},
+		{correction.Dosage, &line.Dosage, "Dosage"},
+		{correction.Frequency, &line.Frequency, "Frequency"},
+		{correction.Duration, &line.Duration, "Duration"},
+		{correction.Instructions, &line.Instructions, "Instructions"},
+	}
+	for _, field := range fields {
+		if field.value == nil {
+			continue
+		}
+		value := strings.TrimSpace(*field.value)
+		if len([]rune(value)) > maxPrescriptionFieldLength {
+			return nil, errors.NewValidationError(fmt.Sprintf("%s must be at most %d characters", field.label, maxPrescriptionFieldLength))
+		}
+		*field.into = value
+	}
+	if line.Name == "" {
+		return nil, errors.NewValidationError("Name is required")
+	}
+	line.Corrected = true
+	line.LowConfidence = false
+
+	if err := ps.prescriptionRepo.UpdateMedications(prescription); err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	return prescription, nil
+}
+
+// Delete removes one of the user's prescriptions and its photo
+func (ps *PrescriptionService) Delete(userID, id int) error {
+	prescription, err := ps.Get(userID, id)
+	if err != nil {
+		return err
+	}
+	if err := ps.prescriptionRepo.Delete(id); err == models.ErrLegalHold {
+		return errors.ErrLegalHold
+	} else if err != nil {
+		return errors.ErrDatabaseConnection
+	}
+	ps.removePhoto(prescription)
+	return nil
+}
+
+// removePhoto deletes a prescription's stored photo, logging rather than failing since the record is what matters
+func (ps *PrescriptionService) removePhoto(prescription *models.Prescription) {
+	if err := ps.fileStorage.Remove(prescription.FilePath); err != nil && !os.IsNotExist(err) {
+		slog.Warn("Failed to remove prescription photo", "prescription_id", prescription.ID, "error", err)
+	}
+}
+
+// truncateField trims a read field and caps its length
+func truncateField(value string) string {
+	return capRunes(strings.TrimSpace(value), maxPrescriptionFieldLength)
+}
+
+// capRunes cuts value to at most limit characters without splitting one
+func capRunes(value string, limit int) string {
+	if runes := []rune(value); len(runes) > limit {
+		return string(runes[:limit])
+	}
+	return value
+}
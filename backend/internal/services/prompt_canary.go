@@ -0,0 +1,298 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+const canarySampleLimit = 1000 // Newest analyses read per side of a comparison
+
+// Verdicts of a canary comparison
+const (
+	CanaryVerdictCollecting = "collecting" // One side has fewer than the minimum analyses
+	CanaryVerdictHealthy    = "healthy"
+	CanaryVerdictWorse      = "worse" // An active canary with this verdict is rolled back
+)
+
+// CanaryArm summarizes the analyses one side of a canary comparison produced
+type CanaryArm struct {
+	Versions           []string
+	Analyses           int
+	ParseFailures      int
+	ParseFailureRate   float64
+	MedianSummaryWords int // Of the analyses that parsed
+	P90SummaryWords    int
+}
+
+// CanaryComparison is the canary's standing against the stable prompt since it started
+type CanaryComparison struct {
+	Canary  *models.PromptCanary
+	Arm     CanaryArm
+	Stable  CanaryArm
+	Verdict string
+	Reason  string // Why the verdict is worse, or what is still being collected
+}
+
+// PromptCanaryService watches a canary prompt or model and rolls it back when its analyses are worse than the stable ones
+// Decision: The canary's state lives in the database, so a rollback decided by the server stops the canary in
+// every worker on its next report without a restart
+type PromptCanaryService struct {
+	repo           models.PromptCanaryRepository
+	reportRepo     models.ReportRepository
+	auditRepo      models.AuditLogRepository
+	cfg            config.PromptCanaryConfig
+	promptPath     string
+	stableVersions []string
+}
+
+// NewPromptCanaryService creates the service for the configured canary, or returns nil when none is deployed
+func NewPromptCanaryService(
+	repo models.PromptCanaryRepository,
+	reportRepo models.ReportRepository,
+	auditRepo models.AuditLogRepository,
+	cfg config.AIConfig,
+) *PromptCanaryService {
+	if cfg.Canary.Version == "" || cfg.Canary.Percent <= 0 {
+		return nil
+	}
+
+	stable := []string{cfg.PromptVersion}
+	if cfg.PromptBPath != "" && cfg.PromptBPercent > 0 {
+		stable = append(stable, cfg.PromptBVersion)
+	}
+	promptPath := cfg.Canary.PromptPath
+	if promptPath == "" {
+		promptPath = cfg.PromptPath
+	}
+
+	return &PromptCanaryService{
+		repo:           repo,
+		reportRepo:     reportRepo,
+		auditRepo:      auditRepo,
+		cfg:            cfg.Canary,
+		promptPath:     promptPath,
+		stableVersions: stable,
+	}
+}
+
+// Start records the canary's deployment, keeping the outcome of an earlier deployment of the same version
+func (pcs *PromptCanaryService) Start() (*models.PromptCanary, error) {
+	canary := &models.PromptCanary{
+		Version:    pcs.cfg.Version,
+		PromptPath: pcs.promptPath,
+		Model:      pcs.cfg.Model,
+		Percent:    min(pcs.cfg.Percent, 100),
+	}
+	if err := pcs.repo.Register(canary); err != nil {
+		return nil, fmt.Errorf("failed to register prompt canary %s: %w", pcs.cfg.Version, err)
+	}
+	return canary, nil
+}
+
+// Live reports whether the canary may serve a report; it is the AIService's canary gate
+// Decision: Any doubt, such as an unreachable database, sends the report to the stable prompt
+func (pcs *PromptCanaryService) Live() bool {
+	canary, err := pcs.repo.GetByVersion(pcs.cfg.Version)
+	if err != nil || canary == nil {
+		return false
+	}
+	return canary.Status != models.PromptCanaryRolledBack
+}
+
+// Status compares the canary with the stable prompt without acting on the result
+func (pcs *PromptCanaryService) Status() (*CanaryComparison, error) {
+	canary, err := pcs.get()
+	if err != nil {
+		return nil, err
+	}
+	return pcs.compare(canary)
+}
+
+// Evaluate compares the canary with the stable prompt and rolls it back if it is worse
+func (pcs *PromptCanaryService) Evaluate() (*CanaryComparison, error) {
+	comparison, err := pcs.Status()
+	if err != nil {
+		return nil, err
+	}
+	if comparison.Verdict != CanaryVerdictWorse || comparison.Canary.Status != models.PromptCanaryActive {
+		return comparison, nil
+	}
+
+	ended, err := pcs.repo.End(comparison.Canary.ID, models.PromptCanaryRolledBack, comparison.Reason, 0)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if ended {
+		slog.Warn("Rolled back prompt canary", "version", comparison.Canary.Version, "reason", comparison.Reason)
+	}
+	comparison.Canary, err = pcs.get()
+	if err != nil {
+		return nil, err
+	}
+	return comparison, nil
+}
+
+// Rollback stops the canary by hand
+func (pcs *PromptCanaryService) Rollback(admin *models.User, reason string) (*models.PromptCanary, error) {
+	return pcs.end(admin, models.PromptCanaryRolledBack, models.AuditCanaryRolledBack, reason)
+}
+
+// Promote marks the canary as ready to become the stable prompt; it keeps serving its share and is no longer
+// rolled back automatically
+// Decision: Making it the stable prompt is a configuration change (AI_PROMPT_VERSION, AI_PROMPT_PATH, and the
+// model) so every process switches together on deploy, rather than some switching while others keep the old prompt
+func (pcs *PromptCanaryService) Promote(admin *models.User, reason string) (*models.PromptCanary, error) {
+	return pcs.end(admin, models.PromptCanaryPromoted, models.AuditCanaryPromoted, reason)
+}
+
+// Run evaluates the canary every check interval until ctx is cancelled
+func (pcs *PromptCanaryService) Run(ctx context.Context) {
+	interval := pcs.cfg.CheckInterval
+	if interval <= 0 {
+		interval = 10 * time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := pcs.Evaluate(); err != nil {
+			slog.Error("Failed to evaluate prompt canary", "version", pcs.cfg.Version, "error", err)
+		}
+	}
+}
+
+// end moves an active canary to status on an admin's behalf
+func (pcs *PromptCanaryService) end(admin *models.User, status, action, reason string) (*models.PromptCanary, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.NewValidationError("A reason is required")
+	}
+	canary, err := pcs.get()
+	if err != nil {
+		return nil, err
+	}
+
+	ended, err := pcs.repo.End(canary.ID, status, reason, admin.ID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if !ended {
+		return nil, errors.ErrPromptCanaryEnded
+	}
+
+	entry := &models.AuditLog{ActorID: admin.ID, UserID: admin.ID, Action: action, Details: fmt.Sprintf("%s: %s", canary.Version, reason)}
+	if err := pcs.auditRepo.Create(entry); err != nil {
+		slog.Error("Failed to audit admin action", "action", action, "admin_id", admin.ID, "error", err)
+	}
+	return pcs.get()
+}
+
+// get returns the configured canary's record
+func (pcs *PromptCanaryService) get() (*models.PromptCanary, error) {
+	canary, err := pcs.repo.GetByVersion(pcs.cfg.Version)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if canary == nil {
+		return nil, errors.ErrPromptCanaryDisabled
+	}
+	return canary, nil
+}
+
+// compare reads both sides' analyses of reports uploaded since the canary started and judges the canary
+// Decision: Only reports uploaded in the same period are compared, so a change in what patients upload
+// affects both sides alike
+func (pcs *PromptCanaryService) compare(canary *models.PromptCanary) (*CanaryComparison, error) {
+	canarySamples, err := pcs.reportRepo.ListAnalysisSamples([]string{canary.Version}, canary.StartedAt, canarySampleLimit)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	stableSamples, err := pcs.reportRepo.ListAnalysisSamples(pcs.stableVersions, canary.StartedAt, canarySampleLimit)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+
+	comparison := &CanaryComparison{
+		Canary: canary,
+		Arm:    summarizeCanaryArm([]string{canary.Version}, canarySamples),
+		Stable: summarizeCanaryArm(pcs.stableVersions, stableSamples),
+	}
+	comparison.Verdict, comparison.Reason = judgeCanary(comparison.Arm, comparison.Stable, pcs.cfg)
+	return comparison, nil
+}
+
+// judgeCanary decides whether the canary's parse failures or summary lengths are worse than the stable side's
+// Decision: Summary length counts as worse in either direction; longer summaries defeat the simplification
+// and much shorter ones usually drop findings
+func judgeCanary(arm, stable CanaryArm, cfg config.PromptCanaryConfig) (string, string) {
+	minSamples := max(cfg.MinSamples, 1)
+	if arm.Analyses < minSamples || stable.Analyses < minSamples {
+		return CanaryVerdictCollecting, fmt.Sprintf("%d canary and %d stable analyses of %d needed", arm.Analyses, stable.Analyses, minSamples)
+	}
+
+	if arm.ParseFailureRate-stable.ParseFailureRate > cfg.MaxParseFailureIncrease {
+		return CanaryVerdictWorse, fmt.Sprintf("parse failure rate %.1f%% against %.1f%% stable",
+			arm.ParseFailureRate*100, stable.ParseFailureRate*100)
+	}
+
+	for _, length := range []struct {
+		name           string
+		canary, stable int
+	}{
+		{"median", arm.MedianSummaryWords, stable.MedianSummaryWords},
+		{"90th percentile", arm.P90SummaryWords, stable.P90SummaryWords},
+	} {
+		if length.stable == 0 || length.canary == 0 {
+			continue
+		}
+		change := float64(length.canary-length.stable) / float64(length.stable)
+		if math.Abs(change) > cfg.MaxLengthChange {
+			return CanaryVerdictWorse, fmt.Sprintf("%s summary length %d words against %d stable (%+.0f%%)",
+				length.name, length.canary, length.stable, change*100)
+		}
+	}
+
+	return CanaryVerdictHealthy, ""
+}
+
+// summarizeCanaryArm counts parse failures and measures the summaries of one side's analyses
+func summarizeCanaryArm(versions []string, samples []*models.AnalysisSample) CanaryArm {
+	arm := CanaryArm{Versions: versions, Analyses: len(samples)}
+	var words []int
+	for _, sample := range samples {
+		if sample.ParseFailed {
+			arm.ParseFailures++
+			continue
+		}
+		analysis, err := ParseStoredAnalysis(sample.SimplifiedSummary)
+		if err != nil {
+			continue
+		}
+		words = append(words, len(strings.Fields(analysis.SimpleSummary)))
+	}
+
+	if arm.Analyses > 0 {
+		arm.ParseFailureRate = float64(arm.ParseFailures) / float64(arm.Analyses)
+	}
+	if len(words) > 0 {
+		slices.Sort(words)
+		arm.MedianSummaryWords = words[len(words)/2]
+		arm.P90SummaryWords = words[min(len(words)*9/10, len(words)-1)]
+	}
+	return arm
+}
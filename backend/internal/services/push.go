@@ -0,0 +1,560 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+// Limits on device registrations
+const (
+	maxPushTokenLength = 4096
+	maxPushDeviceName  = 100
+	defaultMaxDevices  = 10
+)
+
+// Push kinds, sent to the app as the "kind" data field so it can open the right screen
+const (
+	PushKindAnalysisReady  = "analysis_ready"
+	PushKindCriticalResult = "critical_result"
+)
+
+// ErrPushTokenInvalid is returned by a PushSender when the push service says the token will never work again,
+// because the app was uninstalled or the token was replaced; the device registration is then deleted
+var ErrPushTokenInvalid = fmt.Errorf("push token is no longer valid")
+
+// PushMessage is one notification shown on a device
+type PushMessage struct {
+	Title  string
+	Body   string
+	Data   map[string]string // Handed to the app with the notification, e.g. kind and report_id
+	Urgent bool              // Delivered at once even when the device is saving power
+}
+
+// PushSender delivers notifications through one platform's push service
+type PushSender interface {
+	Send(ctx context.Context, token string, msg PushMessage) error
+	Name() string
+}
+
+// NewPushSenders returns a sender for each platform with credentials in cfg; none means push is disabled
+func NewPushSenders(cfg config.PushConfig) (map[string]PushSender, error) {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	client := &http.Client{Timeout: timeout}
+
+	senders := make(map[string]PushSender)
+	if cfg.FCMCredentialsFile != "" {
+		sender, err := newFCMSender(cfg.FCMCredentialsFile, client)
+		if err != nil {
+			return nil, err
+		}
+		senders[models.PushPlatformFCM] = sender
+	}
+	if cfg.APNsKeyFile != "" {
+		if cfg.APNsKeyID == "" || cfg.APNsTeamID == "" || cfg.APNsTopic == "" {
+			return nil, fmt.Errorf("PUSH_APNS_KEY_ID, PUSH_APNS_TEAM_ID, and PUSH_APNS_TOPIC are required with PUSH_APNS_KEY_FILE")
+		}
+		sender, err := newAPNsSender(cfg, client)
+		if err != nil {
+			return nil, err
+		}
+		senders[models.PushPlatformAPNs] = sender
+	}
+	return senders, nil
+}
+
+// fcmSender sends through the Firebase Cloud Messaging HTTP v1 API
+type fcmSender struct {
+	endpoint    string
+	tokenURI    string
+	clientEmail string
+	keyID       string
+	key         *rsa.PrivateKey
+	client      *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// fcmScope is the OAuth scope sending messages needs
+const fcmScope = "https://www.googleapis.com/auth/firebase.messaging"
+
+// newFCMSender reads a Firebase service account file
+func newFCMSender(credentialsFile string, client *http.Client) (*fcmSender, error) {
+	raw, err := os.ReadFile(credentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PUSH_FCM_CREDENTIALS_FILE: %w", err)
+	}
+	var account struct {
+		ProjectID    string `json:"project_id"`
+		PrivateKeyID string `json:"private_key_id"`
+		PrivateKey   string `json:"private_key"`
+		ClientEmail  string `json:"client_email"`
+		TokenURI     string `json:"token_uri"`
+	}
+	if err := json.Unmarshal(raw, &account); err != nil {
+		return nil, fmt.Errorf("invalid FCM service account file: %w", err)
+	}
+	if account.ProjectID == "" || account.PrivateKey == "" || account.ClientEmail == "" {
+		return nil, fmt.Errorf("FCM service account file needs project_id, private_key, and client_email")
+	}
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(account.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid FCM service account private key: %w", err)
+	}
+	if account.TokenURI == "" {
+		account.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+
+	return &fcmSender{
+		endpoint:    fmt.Sprintf("https://fcm.googleapis.com/v1/projects/%s/messages:send", url.PathEscape(account.ProjectID)),
+		tokenURI:    account.TokenURI,
+		clientEmail: account.ClientEmail,
+		keyID:       account.PrivateKeyID,
+		key:         key,
+		client:      client,
+	}, nil
+}
+
+// Send posts one message to the device
+func (fs *fcmSender) Send(ctx context.Context, token string, msg PushMessage) error {
+	accessToken, err := fs.token(ctx)
+	if err != nil {
+		return err
+	}
+
+	priority := "NORMAL"
+	if msg.Urgent {
+		priority = "HIGH"
+	}
+	body, err := json.Marshal(map[string]any{
+		"message": map[string]any{
+			"token":        token,
+			"notification": map[string]string{"title": msg.Title, "body": msg.Body},
+			"data":         msg.Data,
+			"android":      map[string]string{"priority": priority},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fs.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := fs.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("FCM request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	var failure struct {
+		Error struct {
+			Message string `json:"message"`
+			Details []struct {
+				ErrorCode string `json:"errorCode"`
+			} `json:"details"`
+		} `json:"error"`
+	}
+	json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&failure)
+	for _, detail := range failure.Error.Details {
+		if detail.ErrorCode == "UNREGISTERED" {
+			return ErrPushTokenInvalid
+		}
+	}
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return ErrPushTokenInvalid
+	case http.StatusUnauthorized:
+		fs.mu.Lock()
+		fs.accessToken = ""
+		fs.mu.Unlock()
+	}
+	return fmt.Errorf("FCM returned status %d: %s", resp.StatusCode, failure.Error.Message)
+}
+
+// token returns a cached OAuth access token, exchanging a signed assertion for a new one when it is about to expire
+// Decision: The service account's JWT-bearer grant is a single signed POST, so it is done here
+// rather than pulling in Google's client libraries
+func (fs *fcmSender) token(ctx context.Context) (string, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if fs.accessToken != "" && time.Now().Before(fs.expiresAt) {
+		return fs.accessToken, nil
+	}
+
+	now := time.Now()
+	assertion := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   fs.clientEmail,
+		"scope": fcmScope,
+		"aud":   fs.tokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if fs.keyID != "" {
+		assertion.Header["kid"] = fs.keyID
+	}
+	signed, err := assertion.SignedString(fs.key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign FCM token request: %w", err)
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+	form.Set("assertion", signed)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fs.tokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := fs.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("FCM token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("FCM token request returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+
+	var granted struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&granted); err != nil || granted.AccessToken == "" {
+		return "", fmt.Errorf("invalid FCM token response")
+	}
+	fs.accessToken = granted.AccessToken
+	// Renew a minute early so a token never expires mid-request
+	fs.expiresAt = now.Add(time.Duration(granted.ExpiresIn)*time.Second - time.Minute)
+	return fs.accessToken, nil
+}
+
+func (fs *fcmSender) Name() string {
+	return models.PushPlatformFCM
+}
+
+// apnsSender sends through Apple's HTTP/2 provider API with token-based authentication
+type apnsSender struct {
+	endpoint string
+	keyID    string
+	teamID   string
+	topic    string
+	key      *ecdsa.PrivateKey
+	client   *http.Client
+
+	mu       sync.Mutex
+	jwt      string
+	issuedAt time.Time
+}
+
+// apnsTokenLifetime is how long a provider token is reused; Apple rejects tokens older than an hour
+// and throttles providers that sign new ones more often than every 20 minutes
+const apnsTokenLifetime = 50 * time.Minute
+
+// newAPNsSender reads the .p8 signing key
+func newAPNsSender(cfg config.PushConfig, client *http.Client) (*apnsSender, error) {
+	raw, err := os.ReadFile(cfg.APNsKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PUSH_APNS_KEY_FILE: %w", err)
+	}
+	key, err := jwt.ParseECPrivateKeyFromPEM(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid APNs signing key: %w", err)
+	}
+
+	endpoint := "https://api.push.apple.com"
+	if cfg.APNsSandbox {
+		endpoint = "https://api.sandbox.push.apple.com"
+	}
+	return &apnsSender{
+		endpoint: endpoint,
+		keyID:    cfg.APNsKeyID,
+		teamID:   cfg.APNsTeamID,
+		topic:    cfg.APNsTopic,
+		key:      key,
+		client:   client,
+	}, nil
+}
+
+// Send posts one alert to the device; data fields travel beside the aps dictionary
+func (as *apnsSender) Send(ctx context.Context, token string, msg PushMessage) error {
+	providerToken, err := as.providerToken()
+	if err != nil {
+		return err
+	}
+
+	payload := map[string]any{
+		"aps": map[string]any{
+			"alert": map[string]string{"title": msg.Title, "body": msg.Body},
+			"sound": "default",
+		},
+	}
+	for key, value := range msg.Data {
+		if key != "aps" {
+			payload[key] = value
+		}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, as.endpoint+"/3/device/"+url.PathEscape(token), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	priority := 5
+	if msg.Urgent {
+		priority = 10
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "bearer "+providerToken)
+	req.Header.Set("apns-topic", as.topic)
+	req.Header.Set("apns-push-type", "alert")
+	req.Header.Set("apns-priority", strconv.Itoa(priority))
+
+	resp, err := as.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("APNs request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	var failure struct {
+		Reason string `json:"reason"`
+	}
+	json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&failure)
+	switch {
+	case resp.StatusCode == http.StatusGone,
+		failure.Reason == "BadDeviceToken", failure.Reason == "DeviceTokenNotForTopic", failure.Reason == "Unregistered":
+		return ErrPushTokenInvalid
+	case failure.Reason == "ExpiredProviderToken", failure.Reason == "InvalidProviderToken":
+		as.mu.Lock()
+		as.jwt = ""
+		as.mu.Unlock()
+	}
+	return fmt.Errorf("APNs returned status %d: %s", resp.StatusCode, failure.Reason)
+}
+
+// providerToken returns the signed token APNs authenticates the provider with, renewing it before Apple stops accepting it
+func (as *apnsSender) providerToken() (string, error) {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	if as.jwt != "" && time.Since(as.issuedAt) < apnsTokenLifetime {
+		return as.jwt, nil
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{"iss": as.teamID, "iat": now.Unix()})
+	token.Header["kid"] = as.keyID
+	signed, err := token.SignedString(as.key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign APNs provider token: %w", err)
+	}
+	as.jwt = signed
+	as.issuedAt = now
+	return signed, nil
+}
+
+func (as *apnsSender) Name() string {
+	return models.PushPlatformAPNs
+}
+
+// PushService keeps track of users' devices and delivers push notifications to them
+// Decision: Devices are registered even for platforms without credentials, so the apps don't need to know
+// the server's configuration and existing installs start receiving pushes once credentials are added
+type PushService struct {
+	deviceRepo models.PushDeviceRepository
+	senders    map[string]PushSender
+	maxDevices int
+}
+
+// NewPushService creates a new push service; senders may be empty and maxDevices 0 uses the default
+func NewPushService(deviceRepo models.PushDeviceRepository, senders map[string]PushSender, maxDevices int) *PushService {
+	if maxDevices <= 0 {
+		maxDevices = defaultMaxDevices
+	}
+	return &PushService{
+		deviceRepo: deviceRepo,
+		senders:    senders,
+		maxDevices: maxDevices,
+	}
+}
+
+// Platforms returns the platforms pushes can be sent to, sorted
+func (ps *PushService) Platforms() []string {
+	platforms := make([]string, 0, len(ps.senders))
+	for platform := range ps.senders {
+		platforms = append(platforms, platform)
+	}
+	sort.Strings(platforms)
+	return platforms
+}
+
+// Enabled reports whether pushes can be sent to the platform
+func (ps *PushService) Enabled(platform string) bool {
+	_, ok := ps.senders[platform]
+	return ok
+}
+
+// Register records the user's device token, or refreshes it when the app registers again
+// Decision: The apps register on every launch; beyond the per-user limit the least recently
+// seen device is forgotten, since it is most likely a reinstall whose old token is dead
+func (ps *PushService) Register(userID int, platform, token, name string) (*models.PushDevice, error) {
+	platform = strings.ToLower(strings.TrimSpace(platform))
+	if platform != models.PushPlatformFCM && platform != models.PushPlatformAPNs {
+		return nil, errors.NewValidationError("platform must be fcm or apns")
+	}
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil, errors.NewValidationError("token is required")
+	}
+	if len(token) > maxPushTokenLength || strings.IndexFunc(token, func(r rune) bool { return r > unicode.MaxASCII || !unicode.IsPrint(r) || r == ' ' }) >= 0 {
+		return nil, errors.NewValidationError("token is not a valid device token")
+	}
+	name = strings.TrimSpace(name)
+	if len([]rune(name)) > maxPushDeviceName {
+		return nil, errors.NewValidationError(fmt.Sprintf("name must be at most %d characters", maxPushDeviceName))
+	}
+
+	device := &models.PushDevice{UserID: userID, Platform: platform, Token: token, Name: name}
+	if err := ps.deviceRepo.Upsert(device); err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if err := ps.deviceRepo.KeepRecent(userID, ps.maxDevices); err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	return device, nil
+}
+
+// List returns the user's devices, most recently seen first
+func (ps *PushService) List(userID int) ([]*models.PushDevice, error) {
+	devices, err := ps.deviceRepo.ListByUser(userID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	return devices, nil
+}
+
+// Unregister stops pushes to one of the user's devices
+func (ps *PushService) Unregister(userID, id int) error {
+	deleted, err := ps.deviceRepo.Delete(id, userID)
+	if err != nil {
+		return errors.ErrDatabaseConnection
+	}
+	if !deleted {
+		return errors.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Notify sends msg to every device the user registered, returning how many accepted it
+// Decision: One device failing doesn't stop the others; tokens the push service rejects
+// for good are deleted so later notifications don't retry them
+func (ps *PushService) Notify(ctx context.Context, userID int, msg PushMessage) (int, error) {
+	if len(ps.senders) == 0 {
+		return 0, nil
+	}
+	devices, err := ps.deviceRepo.ListByUser(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for _, device := range devices {
+		sender, ok := ps.senders[device.Platform]
+		if !ok {
+			continue
+		}
+		err := sender.Send(ctx, device.Token, msg)
+		switch {
+		case err == nil:
+			delivered++
+		case err == ErrPushTokenInvalid:
+			slog.InfoContext(ctx, "Forgetting push device with an invalid token", "platform", device.Platform, "device_id", device.ID, "user_id", userID)
+			if err := ps.deviceRepo.DeleteByToken(device.Platform, device.Token); err != nil {
+				slog.ErrorContext(ctx, "Failed to delete push device", "device_id", device.ID, "error", err)
+			}
+		default:
+			slog.WarnContext(ctx, "Failed to push to device", "platform", device.Platform, "device_id", device.ID, "user_id", userID, "error", err)
+		}
+	}
+	return delivered, nil
+}
+
+// SubscribePush sends analysis-ready pushes, and an urgent alert instead when a result is critical
+// Decision: Lock screens are visible to others, so pushes never name the report, a test, or a value;
+// the app fetches the details with the report_id in the data once the user opens it
+func SubscribePush(bus EventBus, push *PushService, reportRepo models.ReportRepository) {
+	bus.Subscribe(EventAnalysisCompleted, func(event Event) error {
+		report, err := reportRepo.GetByID(event.ReportID)
+		if err != nil {
+			return err
+		}
+		// Deleted or handed to someone else before the event was delivered
+		if report == nil || report.UserID != event.UserID {
+			return nil
+		}
+
+		msg := PushMessage{
+			Title: "Your report is ready",
+			Body:  "Open the app to see what your results mean.",
+			Data:  map[string]string{"kind": PushKindAnalysisReady, "report_id": strconv.Itoa(report.ID)},
+		}
+		if analysis, err := ParseStoredAnalysis(report.SimplifiedSummary); err == nil && hasCriticalMetric(analysis) {
+			msg = PushMessage{
+				Title:  "A result needs your attention",
+				Body:   "Your latest report has a result well outside the normal range. Open the app to review it.",
+				Data:   map[string]string{"kind": PushKindCriticalResult, "report_id": strconv.Itoa(report.ID)},
+				Urgent: true,
+			}
+		}
+
+		_, err = push.Notify(context.Background(), event.UserID, msg)
+		return err
+	})
+}
+
+// hasCriticalMetric reports whether any of the analysis's metrics is critical
+func hasCriticalMetric(analysis *AnalysisResult) bool {
+	for _, metric := range analysis.HealthMetrics {
+		if metric.Status == "critical" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,32 @@
+package services
+
+import "github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+
+// summaryGuidance tells the analysis prompt how to write simple_summary and metric descriptions
+var summaryGuidance = map[string]string{
+	models.ReadingLevelChild: "Write simple_summary and every description for a 10-year-old: short sentences, " +
+		"everyday words, no medical terms or abbreviations, and a calm, friendly tone.",
+	models.ReadingLevelStandard: "Write simple_summary and every description for an adult patient without medical " +
+		"training: plain language, and briefly explain any medical term you must use.",
+	models.ReadingLevelClinical: "Write simple_summary and every description for a clinician: use standard medical " +
+		"terminology and abbreviations, cite values against reference ranges, and skip lay explanations.",
+}
+
+// chatGuidance sets the tone of chat answers for each reading level
+var chatGuidance = map[string]string{
+	models.ReadingLevelChild: "Answer for a 10-year-old: a few short sentences in everyday words, no medical terms.\n" +
+		"Suggest asking a parent or doctor when a question needs clinical judgement.",
+	models.ReadingLevelStandard: "Use plain language a non-expert can follow. Keep answers short and specific to the report.\n" +
+		"Suggest consulting a doctor when a question needs clinical judgement.",
+	models.ReadingLevelClinical: "The reader is a clinician. Answer concisely in clinical terminology, referring to values\n" +
+		"and reference ranges directly. Note where findings warrant further workup.",
+}
+
+// readingLevelOrDefault maps empty or unknown levels to standard
+// Decision: Callers validate user input; this only guards rows written before the column existed
+func readingLevelOrDefault(level string) string {
+	if models.IsValidReadingLevel(level) {
+		return level
+	}
+	return models.ReadingLevelStandard
+}
@@ -0,0 +1,441 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+// Limits on how many reports one re-analysis run takes
+const (
+	defaultReanalysisLimit = 100
+	maxReanalysisLimit     = 1000
+)
+
+// ReanalysisRequest selects the reports an operator wants brought up to the current prompt and model
+type ReanalysisRequest struct {
+	Reason    string
+	UserID    int
+	Since     string // YYYY-MM-DD
+	Until     string // YYYY-MM-DD
+	ReportIDs []int
+	Limit     int
+}
+
+// ReanalysisRunDetail is a run with its reports
+type ReanalysisRunDetail struct {
+	Run   *models.ReanalysisRun
+	Items []*models.ReanalysisItem
+}
+
+// ReanalysisDiff compares a report's analysis before and after re-analysis
+type ReanalysisDiff struct {
+	Item            *models.ReanalysisItem
+	RiskLevel       *ValueChange
+	SimpleSummary   *ValueChange
+	Metrics         []MetricChange
+	KeyFindings     ListChange
+	Recommendations ListChange
+}
+
+// ValueChange is a field whose value differs between the analyses
+type ValueChange struct {
+	Old string
+	New string
+}
+
+// MetricChange is a health metric added, removed, or reported differently
+type MetricChange struct {
+	Name      string
+	Change    string // added, removed, or changed
+	OldValue  string
+	NewValue  string
+	OldStatus string
+	NewStatus string
+}
+
+// ListChange is the entries of a list only one analysis has
+type ListChange struct {
+	Added   []string
+	Removed []string
+}
+
+// ReanalysisService lets operators bring historical reports up to a new prompt template or model
+// Decision: A run only queues items; ReanalysisRunner works through them on processes that analyze reports,
+// so an API server without inline processing can still start one
+type ReanalysisService struct {
+	repo          models.ReanalysisRepository
+	reportRepo    models.ReportRepository
+	auditRepo     models.AuditLogRepository
+	promptVersion string // The prompt version reports are brought up to
+	model         string // The model reports are brought up to; empty ignores the model
+}
+
+// NewReanalysisService creates a re-analysis service targeting the given prompt version and model
+func NewReanalysisService(
+	repo models.ReanalysisRepository,
+	reportRepo models.ReportRepository,
+	auditRepo models.AuditLogRepository,
+	promptVersion, model string,
+) *ReanalysisService {
+	return &ReanalysisService{
+		repo:          repo,
+		reportRepo:    reportRepo,
+		auditRepo:     auditRepo,
+		promptVersion: promptVersion,
+		model:         model,
+	}
+}
+
+// Target returns the prompt version and model runs bring reports up to
+func (rs *ReanalysisService) Target() (promptVersion, model string) {
+	return rs.promptVersion, rs.model
+}
+
+// Preview returns the reports a run with req would re-analyze
+func (rs *ReanalysisService) Preview(req ReanalysisRequest) ([]int, error) {
+	filter, err := rs.filter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	reportIDs, err := rs.repo.SelectStale(filter)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	return reportIDs, nil
+}
+
+// Start queues a run over the reports req selects
+// Decision: One run at a time, so two runs never race to replace the same report's analysis
+func (rs *ReanalysisService) Start(admin *models.User, req ReanalysisRequest) (*models.ReanalysisRun, error) {
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		return nil, errors.NewValidationError("A reason is required to start a re-analysis")
+	}
+
+	running, err := rs.repo.HasRunning()
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if running {
+		return nil, errors.NewValidationError("A re-analysis is already running; wait for it to finish or cancel it")
+	}
+
+	reportIDs, err := rs.Preview(req)
+	if err != nil {
+		return nil, err
+	}
+	if len(reportIDs) == 0 {
+		return nil, errors.NewValidationError("No reports need re-analysis with the current prompt and model")
+	}
+
+	run := &models.ReanalysisRun{
+		CreatedBy:     admin.ID,
+		Reason:        req.Reason,
+		PromptVersion: rs.promptVersion,
+		Model:         rs.model,
+	}
+	if err := rs.repo.CreateRun(run, reportIDs); err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+
+	rs.audit(admin, admin.ID, models.AuditReanalysisStarted,
+		fmt.Sprintf("run %d: %d reports to %s %s: %s", run.ID, run.Counts[models.ReanalysisItemPending], run.PromptVersion, run.Model, run.Reason))
+	return run, nil
+}
+
+// Cancel stops a run; reports already re-analyzed keep their new analysis
+func (rs *ReanalysisService) Cancel(admin *models.User, runID int) error {
+	run, err := rs.getRun(runID)
+	if err != nil {
+		return err
+	}
+
+	cancelled, err := rs.repo.Cancel(runID)
+	if err != nil {
+		return errors.ErrDatabaseConnection
+	}
+	if !cancelled {
+		return errors.NewValidationError(fmt.Sprintf("Only running re-analyses can be cancelled; this one is %s", run.Status))
+	}
+
+	rs.audit(admin, admin.ID, models.AuditReanalysisCancelled, fmt.Sprintf("run %d", runID))
+	return nil
+}
+
+// Runs lists the most recent runs
+func (rs *ReanalysisService) Runs(limit int) ([]*models.ReanalysisRun, error) {
+	runs, err := rs.repo.ListRuns(limit)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	return runs, nil
+}
+
+// Run returns a run with the state of each of its reports
+func (rs *ReanalysisService) Run(runID int) (*ReanalysisRunDetail, error) {
+	run, err := rs.getRun(runID)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := rs.repo.ListItems(runID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	return &ReanalysisRunDetail{Run: run, Items: items}, nil
+}
+
+// Diff compares a report's analysis before and after a run
+// Decision: Both analyses describe the patient's results, so each view is audited like an analysis review
+func (rs *ReanalysisService) Diff(admin *models.User, runID, reportID int) (*ReanalysisDiff, error) {
+	item, err := rs.repo.GetItem(runID, reportID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if item == nil {
+		return nil, errors.ErrRecordNotFound
+	}
+
+	diff := &ReanalysisDiff{Item: item}
+	if item.Status == models.ReanalysisItemSucceeded {
+		oldAnalysis, err := ParseStoredAnalysis(item.OldAnalysis)
+		if err != nil {
+			oldAnalysis = &AnalysisResult{}
+		}
+		newAnalysis, err := ParseStoredAnalysis(item.NewAnalysis)
+		if err != nil {
+			slog.Error("Failed to parse re-analysis", "report_id", reportID, "run_id", runID, "error", err)
+			return nil, errors.ErrAIProcessingFailed
+		}
+		diffAnalyses(diff, oldAnalysis, newAnalysis)
+	}
+
+	userID := 0
+	if report, err := rs.reportRepo.GetByID(reportID); err == nil && report != nil {
+		userID = report.UserID
+	}
+	rs.audit(admin, userID, models.AuditReanalysisDiffViewed, fmt.Sprintf("run %d, report %d", runID, reportID))
+	return diff, nil
+}
+
+// diffAnalyses fills diff with what changed from before to after
+func diffAnalyses(diff *ReanalysisDiff, before, after *AnalysisResult) {
+	if before.RiskLevel != after.RiskLevel {
+		diff.RiskLevel = &ValueChange{Old: before.RiskLevel, New: after.RiskLevel}
+	}
+	if before.SimpleSummary != after.SimpleSummary {
+		diff.SimpleSummary = &ValueChange{Old: before.SimpleSummary, New: after.SimpleSummary}
+	}
+	diff.KeyFindings = diffLists(before.KeyFindings, after.KeyFindings)
+	diff.Recommendations = diffLists(before.Recommendations, after.Recommendations)
+
+	// Decision: Metrics are matched by name, ignoring case, since the model may reorder them
+	oldMetrics := make(map[string]HealthMetric, len(before.HealthMetrics))
+	for _, metric := range before.HealthMetrics {
+		oldMetrics[strings.ToLower(strings.TrimSpace(metric.Name))] = metric
+	}
+	seen := make(map[string]bool, len(after.HealthMetrics))
+	for _, metric := range after.HealthMetrics {
+		key := strings.ToLower(strings.TrimSpace(metric.Name))
+		seen[key] = true
+		previous, ok := oldMetrics[key]
+		if !ok {
+			diff.Metrics = append(diff.Metrics, MetricChange{Name: metric.Name, Change: "added",
+				NewValue: metric.GetValueAsString(), NewStatus: metric.Status})
+			continue
+		}
+		if previous.GetValueAsString() != metric.GetValueAsString() || previous.Status != metric.Status {
+			diff.Metrics = append(diff.Metrics, MetricChange{Name: metric.Name, Change: "changed",
+				OldValue: previous.GetValueAsString(), NewValue: metric.GetValueAsString(),
+				OldStatus: previous.Status, NewStatus: metric.Status})
+		}
+	}
+	for _, metric := range before.HealthMetrics {
+		if !seen[strings.ToLower(strings.TrimSpace(metric.Name))] {
+			diff.Metrics = append(diff.Metrics, MetricChange{Name: metric.Name, Change: "removed",
+				OldValue: metric.GetValueAsString(), OldStatus: metric.Status})
+		}
+	}
+}
+
+// diffLists returns the entries only after has as added and those only before has as removed
+func diffLists(before, after []string) ListChange {
+	var change ListChange
+	for _, entry := range after {
+		if !slices.Contains(before, entry) {
+			change.Added = append(change.Added, entry)
+		}
+	}
+	for _, entry := range before {
+		if !slices.Contains(after, entry) {
+			change.Removed = append(change.Removed, entry)
+		}
+	}
+	return change
+}
+
+// filter turns a request into the repository's selection
+func (rs *ReanalysisService) filter(req ReanalysisRequest) (models.ReanalysisFilter, error) {
+	for _, day := range []string{req.Since, req.Until} {
+		if _, err := time.Parse("2006-01-02", day); day != "" && err != nil {
+			return models.ReanalysisFilter{}, errors.NewValidationError("Dates must be formatted YYYY-MM-DD")
+		}
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultReanalysisLimit
+	}
+	if limit > maxReanalysisLimit {
+		return models.ReanalysisFilter{}, errors.NewValidationError(fmt.Sprintf("A run may take at most %d reports", maxReanalysisLimit))
+	}
+
+	return models.ReanalysisFilter{
+		PromptVersion: rs.promptVersion,
+		Model:         rs.model,
+		UserID:        req.UserID,
+		Since:         req.Since,
+		Until:         req.Until,
+		ReportIDs:     req.ReportIDs,
+		Limit:         limit,
+	}, nil
+}
+
+// getRun loads a run by ID
+func (rs *ReanalysisService) getRun(runID int) (*models.ReanalysisRun, error) {
+	run, err := rs.repo.GetRun(runID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if run == nil {
+		return nil, errors.ErrRecordNotFound
+	}
+	return run, nil
+}
+
+// audit records an operator action; failures are logged rather than undoing the action
+func (rs *ReanalysisService) audit(admin *models.User, userID int, action, details string) {
+	entry := &models.AuditLog{ActorID: admin.ID, UserID: userID, Action: action, Details: details}
+	if err := rs.auditRepo.Create(entry); err != nil {
+		slog.Error("Failed to audit admin action", "action", action, "admin_id", admin.ID, "error", err)
+	}
+}
+
+// ReanalysisRunner works through queued re-analysis items at a fixed pace
+// Decision: Re-analysis shares the model quota with new uploads, so it runs well below the limiter's
+// ceiling and stops while the queue is paused; the pace is per process, like the AI rate limiter
+type ReanalysisRunner struct {
+	repo       models.ReanalysisRepository
+	reportRepo models.ReportRepository
+	processor  *ReportProcessor
+	interval   time.Duration
+}
+
+// NewReanalysisRunner creates a runner re-analyzing at most perMinute reports a minute
+func NewReanalysisRunner(repo models.ReanalysisRepository, reportRepo models.ReportRepository, processor *ReportProcessor, perMinute int) *ReanalysisRunner {
+	if perMinute <= 0 {
+		perMinute = 6
+	}
+	return &ReanalysisRunner{
+		repo:       repo,
+		reportRepo: reportRepo,
+		processor:  processor,
+		interval:   time.Minute / time.Duration(perMinute),
+	}
+}
+
+// Run re-analyzes queued reports until ctx is cancelled
+func (rr *ReanalysisRunner) Run(ctx context.Context) {
+	// Decision: Items claimed by a process that died go back in the queue; one that was mid-analysis on
+	// another live process is at worst analyzed twice, and Apply keeps whichever result lands first
+	if reset, err := rr.repo.ResetRunning(); err != nil {
+		slog.Error("Failed to requeue interrupted re-analyses", "error", err)
+	} else if reset > 0 {
+		slog.Warn("Requeued interrupted re-analyses", "count", reset)
+	}
+
+	wait := rr.interval
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		wait = rr.Step()
+	}
+}
+
+// Step re-analyzes the next queued report, if any, and returns how long to wait before the next one
+func (rr *ReanalysisRunner) Step() time.Duration {
+	paused, err := rr.processor.Paused()
+	if err != nil {
+		slog.Error("Failed to read queue state for re-analysis", "error", err)
+		return rr.interval
+	}
+	if paused {
+		return rr.interval
+	}
+
+	item, err := rr.repo.ClaimNext()
+	if err != nil {
+		slog.Error("Failed to claim re-analysis", "error", err)
+		return rr.interval
+	}
+	if item == nil {
+		return rr.interval
+	}
+
+	report, err := rr.reportRepo.GetByID(item.ReportID)
+	if err != nil {
+		slog.Error("Failed to load report for re-analysis", "report_id", item.ReportID, "error", err)
+		rr.release(item)
+		return rr.interval
+	}
+	if report == nil {
+		rr.fail(item, "The report was deleted")
+		return rr.interval
+	}
+
+	analysis, err := rr.processor.Reanalyze(report)
+	// Decision: Quota errors put the report back and slow down instead of failing it
+	if err != nil && ClassifyProcessingError(err) == models.ProcessingErrorQuotaExceeded {
+		slog.Warn("Re-analysis hit the model's rate limit, backing off", "report_id", report.ID)
+		rr.release(item)
+		return 4 * rr.interval
+	}
+	if err != nil {
+		rr.fail(item, fmt.Sprintf("Re-analysis failed (%s): %v", ClassifyProcessingError(err), err))
+		return rr.interval
+	}
+
+	applied, err := rr.repo.Apply(item, analysis.ResultJSON, analysis.PromptVersion)
+	if err != nil {
+		slog.Error("Failed to store re-analysis", "report_id", report.ID, "error", err)
+		rr.release(item)
+	} else if !applied {
+		slog.Info("Report changed or was put under a legal hold during re-analysis, kept its current analysis", "report_id", report.ID)
+	}
+	return rr.interval
+}
+
+// fail records an item's failure; the report keeps its old analysis
+func (rr *ReanalysisRunner) fail(item *models.ReanalysisItem, reason string) {
+	if err := rr.repo.Fail(item, reason); err != nil {
+		slog.Error("Failed to record re-analysis failure", "report_id", item.ReportID, "error", err)
+	}
+}
+
+// release returns an item to the queue for a later try
+func (rr *ReanalysisRunner) release(item *models.ReanalysisItem) {
+	if err := rr.repo.Release(item.ID); err != nil {
+		slog.Error("Failed to requeue re-analysis", "report_id", item.ReportID, "error", err)
+	}
+}
@@ -0,0 +1,352 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+// referralDoctorsPerSpecialty is how many nearby doctors each referral suggestion lists
+const referralDoctorsPerSpecialty = 3
+
+// Specialty is a kind of specialist, with the lab terms that fall in their area
+type Specialty struct {
+	Key      string
+	Name     string
+	Area     string   // What the specialist looks after, in plain words
+	Keywords []string // Metric names in this area; same syntax as condition keywords
+	Exclude  []string // Metric names matching these never count, e.g. "glycated hemoglobin" for hematology
+}
+
+// generalPhysician is suggested for out-of-range results no specialty in the catalog covers
+var generalPhysician = Specialty{Key: "general_physician", Name: "General physician", Area: "Overall health and first opinion"}
+
+// specialtyCatalog lists the specialties referrals can suggest, in display order
+// Decision: A fixed keyword catalog like the condition tags, so suggestions need no model call and read the same
+// for every prompt version; only out-of-range metrics count, since findings also mention normal results
+var specialtyCatalog = []Specialty{
+	{Key: "nephrology", Name: "Nephrologist", Area: "Kidneys",
+		Keywords: []string{"creatinine", "egfr", "gfr", "glomerular*", "urea", "bun", "microalbumin", "cystatin*",
+			"proteinuria", "urine protein", "kidney", "renal"}},
+	{Key: "cardiology", Name: "Cardiologist", Area: "Heart and blood vessels",
+		Keywords: []string{"cholesterol", "ldl", "hdl", "vldl", "triglyceride*", "lipoprotein*", "apolipoprotein*",
+			"troponin*", "bnp", "nt-probnp", "ck-mb", "homocysteine", "hs-crp", "hscrp", "blood pressure", "systolic",
+			"diastolic", "heart rate", "cardiac"}},
+	{Key: "endocrinology", Name: "Endocrinologist", Area: "Diabetes, thyroid, and hormones",
+		Keywords: []string{"glucose", "blood sugar", "fbs", "ppbs", "rbs", "hba1c", "a1c", "glycated", "glycosylated",
+			"insulin", "c-peptide", "tsh", "t3", "t4", "ft3", "ft4", "thyroid*", "thyroxine", "cortisol", "prolactin"}},
+	{Key: "gastroenterology", Name: "Gastroenterologist", Area: "Liver and digestive system",
+		Keywords: []string{"alt", "ast", "sgpt", "sgot", "alp", "alkaline phosphatase", "bilirubin", "ggt", "gamma gt",
+			"liver", "hepat*", "amylase", "lipase"}},
+	{Key: "hematology", Name: "Hematologist", Area: "Blood cells and clotting",
+		Keywords: []string{"hemoglobin", "haemoglobin", "hb", "hgb", "hematocrit", "haematocrit", "pcv", "rbc",
+			"red blood cell*", "wbc", "white blood cell*", "leukocyte*", "leucocyte*", "platelet*", "mcv", "mch", "mchc",
+			"rdw", "neutrophil*", "lymphocyte*", "eosinophil*", "ferritin", "inr", "prothrombin"},
+		Exclude: []string{"glycated", "glycosylated", "a1c"}},
+}
+
+// specialtyPatterns holds one compiled matcher per catalog entry, keyed by specialty
+var specialtyPatterns = compileSpecialtyPatterns()
+
+func compileSpecialtyPatterns() map[string]analytePattern {
+	patterns := make(map[string]analytePattern, len(specialtyCatalog))
+	for _, specialty := range specialtyCatalog {
+		pattern := analytePattern{match: compileKeywords(specialty.Keywords)}
+		if len(specialty.Exclude) > 0 {
+			pattern.exclude = compileKeywords(specialty.Exclude)
+		}
+		patterns[specialty.Key] = pattern
+	}
+	return patterns
+}
+
+// ListSpecialties returns the catalog of specialties, with the general physician last
+func ListSpecialties() []Specialty {
+	return append(append([]Specialty{}, specialtyCatalog...), generalPhysician)
+}
+
+// LookupSpecialty returns the catalog entry for key, or nil if there is none
+func LookupSpecialty(key string) *Specialty {
+	for _, specialty := range ListSpecialties() {
+		if specialty.Key == key {
+			return &specialty
+		}
+	}
+	return nil
+}
+
+// ReferralReason is an out-of-range metric that led to a suggestion
+type ReferralReason struct {
+	Metric string
+	Value  string
+	Unit   string
+	Status string
+}
+
+// ReferralSuggestion is a specialist worth consulting about some of a report's results
+type ReferralSuggestion struct {
+	Specialty Specialty
+	Urgency   string // soon when any reason is critical, otherwise routine
+	Reasons   []ReferralReason
+	Doctors   []Doctor // Nearby doctors; nil when the directory wasn't asked
+}
+
+// SuggestReferrals groups an analysis's out-of-range metrics by the specialty whose area they fall in
+// Suggestions with critical results come first, then catalog order; metrics no specialty covers go to a general physician
+func SuggestReferrals(analysis *AnalysisResult) []ReferralSuggestion {
+	var suggestions []ReferralSuggestion
+	general := ReferralSuggestion{Specialty: generalPhysician}
+	add := func(target *ReferralSuggestion, metric *HealthMetric) {
+		target.Reasons = append(target.Reasons, ReferralReason{
+			Metric: metric.Name,
+			Value:  metric.GetValueAsString(),
+			Unit:   metric.Unit,
+			Status: metric.Status,
+		})
+		if metric.Status == "critical" {
+			target.Urgency = "soon"
+		}
+	}
+
+	bySpecialty := make(map[string]*ReferralSuggestion)
+	for i := range analysis.HealthMetrics {
+		metric := &analysis.HealthMetrics[i]
+		if metric.Status != "warning" && metric.Status != "critical" {
+			continue
+		}
+		matched := false
+		for _, specialty := range specialtyCatalog {
+			pattern := specialtyPatterns[specialty.Key]
+			if !pattern.match.MatchString(metric.Name) || (pattern.exclude != nil && pattern.exclude.MatchString(metric.Name)) {
+				continue
+			}
+			matched = true
+			if bySpecialty[specialty.Key] == nil {
+				bySpecialty[specialty.Key] = &ReferralSuggestion{Specialty: specialty}
+			}
+			add(bySpecialty[specialty.Key], metric)
+		}
+		if !matched {
+			add(&general, metric)
+		}
+	}
+
+	for _, urgency := range []string{"soon", ""} {
+		for _, specialty := range specialtyCatalog {
+			if suggestion := bySpecialty[specialty.Key]; suggestion != nil && suggestion.Urgency == urgency {
+				suggestions = append(suggestions, *suggestion)
+			}
+		}
+		if len(general.Reasons) > 0 && general.Urgency == urgency {
+			suggestions = append(suggestions, general)
+		}
+	}
+	for i := range suggestions {
+		if suggestions[i].Urgency == "" {
+			suggestions[i].Urgency = "routine"
+		}
+	}
+	return suggestions
+}
+
+// DoctorLocation is where to look for doctors: coordinates, a city, or both
+type DoctorLocation struct {
+	Latitude  *float64
+	Longitude *float64
+	City      string
+}
+
+// Doctor is one entry of the provider directory
+type Doctor struct {
+	Name       string   `json:"name"`
+	Specialty  string   `json:"specialty"`
+	Address    string   `json:"address"`
+	Phone      string   `json:"phone"`
+	URL        string   `json:"url"`
+	DistanceKm *float64 `json:"distance_km"` // Null when the directory searched by city
+}
+
+// DoctorDirectory finds doctors of a specialty near a location
+type DoctorDirectory interface {
+	Search(ctx context.Context, specialty string, near DoctorLocation, limit int) ([]Doctor, error)
+	Name() string
+}
+
+// NewDoctorDirectory returns the directory selected by DOCTOR_DIRECTORY_PROVIDER, or nil when doctor search is disabled
+func NewDoctorDirectory(cfg config.DirectoryConfig) (DoctorDirectory, error) {
+	switch strings.ToLower(cfg.Provider) {
+	case "", "none":
+		return nil, nil
+	case "http":
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("DOCTOR_DIRECTORY_URL is required when DOCTOR_DIRECTORY_PROVIDER is http")
+		}
+		timeout := cfg.Timeout
+		if timeout <= 0 {
+			timeout = 10 * time.Second
+		}
+		return &httpDoctorDirectory{
+			endpoint: cfg.URL,
+			apiKey:   cfg.APIKey,
+			client:   &http.Client{Timeout: timeout},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown doctor directory provider %q (expected http or none)", cfg.Provider)
+	}
+}
+
+// httpDoctorDirectory queries a directory API with GET ?specialty=&lat=&lng=&city=&limit=
+// Decision: One small JSON contract rather than a client per vendor; deployments put an adapter in front of theirs
+type httpDoctorDirectory struct {
+	endpoint string
+	apiKey   string
+	client   *http.Client
+}
+
+// Search asks the directory for up to limit doctors
+func (h *httpDoctorDirectory) Search(ctx context.Context, specialty string, near DoctorLocation, limit int) ([]Doctor, error) {
+	query := url.Values{}
+	query.Set("specialty", specialty)
+	query.Set("limit", strconv.Itoa(limit))
+	if near.Latitude != nil && near.Longitude != nil {
+		query.Set("lat", strconv.FormatFloat(*near.Latitude, 'f', -1, 64))
+		query.Set("lng", strconv.FormatFloat(*near.Longitude, 'f', -1, 64))
+	}
+	if near.City != "" {
+		query.Set("city", near.City)
+	}
+
+	separator := "?"
+	if strings.Contains(h.endpoint, "?") {
+		separator = "&"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.endpoint+separator+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if h.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+h.apiKey)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("doctor directory request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("doctor directory returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+
+	var result struct {
+		Doctors []Doctor `json:"doctors"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&result); err != nil {
+		return nil, fmt.Errorf("invalid doctor directory response: %w", err)
+	}
+	if len(result.Doctors) > limit {
+		result.Doctors = result.Doctors[:limit]
+	}
+	return result.Doctors, nil
+}
+
+func (h *httpDoctorDirectory) Name() string {
+	return "http"
+}
+
+// Referrals are the specialists suggested for one report
+type Referrals struct {
+	ReportID         int
+	Suggestions      []ReferralSuggestion
+	DirectoryEnabled bool
+}
+
+// ReferralService suggests specialists from a report's results and, when a directory is configured, doctors nearby
+type ReferralService struct {
+	reportRepo models.ReportRepository
+	directory  DoctorDirectory // Optional; nil disables doctor search
+}
+
+// NewReferralService creates a referral service; directory may be nil
+func NewReferralService(reportRepo models.ReportRepository, directory DoctorDirectory) *ReferralService {
+	return &ReferralService{reportRepo: reportRepo, directory: directory}
+}
+
+// DirectoryEnabled reports whether doctors can be searched
+func (rs *ReferralService) DirectoryEnabled() bool {
+	return rs.directory != nil
+}
+
+// Suggest lists the specialists to consult about a completed report, with nearby doctors when near is given
+// Decision: A failing directory doesn't hide the suggestions; they are returned without doctors and the failure logged
+func (rs *ReferralService) Suggest(ctx context.Context, userID, reportID int, near *DoctorLocation) (*Referrals, error) {
+	report, err := rs.reportRepo.GetByID(reportID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if report == nil {
+		return nil, errors.ErrRecordNotFound
+	}
+	if report.UserID != userID {
+		return nil, errors.ErrAccessDenied
+	}
+	if report.ProcessingStatus != "completed" {
+		return nil, errors.ErrReportNotProcessed
+	}
+
+	analysis, err := ParseStoredAnalysis(report.SimplifiedSummary)
+	if err != nil {
+		return nil, errors.ErrAIProcessingFailed
+	}
+
+	referrals := &Referrals{ReportID: report.ID, Suggestions: SuggestReferrals(analysis), DirectoryEnabled: rs.directory != nil}
+	if rs.directory == nil || near == nil {
+		return referrals, nil
+	}
+	for i := range referrals.Suggestions {
+		suggestion := &referrals.Suggestions[i]
+		doctors, err := rs.directory.Search(ctx, suggestion.Specialty.Key, *near, referralDoctorsPerSpecialty)
+		if err != nil {
+			slog.WarnContext(ctx, "Doctor directory search failed", "specialty", suggestion.Specialty.Key, "error", err)
+			continue
+		}
+		suggestion.Doctors = doctors
+		if suggestion.Doctors == nil {
+			suggestion.Doctors = []Doctor{}
+		}
+	}
+	return referrals, nil
+}
+
+// FindDoctors searches the directory for doctors of one specialty
+func (rs *ReferralService) FindDoctors(ctx context.Context, specialty string, near DoctorLocation, limit int) ([]Doctor, error) {
+	if LookupSpecialty(specialty) == nil {
+		return nil, errors.NewValidationError("Unknown specialty; see GET /api/specialties")
+	}
+	if rs.directory == nil {
+		return nil, errors.ErrDoctorDirectoryUnavailable
+	}
+
+	doctors, err := rs.directory.Search(ctx, specialty, near, limit)
+	if err != nil {
+		slog.WarnContext(ctx, "Doctor directory search failed", "specialty", specialty, "error", err)
+		return nil, errors.ErrDoctorDirectoryFailed
+	}
+	if doctors == nil {
+		doctors = []Doctor{}
+	}
+	return doctors, nil
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+// ReportAccessService decides who besides its owner may read a report, from the visibility the owner chose
+// Decision: Visibility only ever widens reading to clinicians of the owner's own organization; editing, deleting,
+// sharing, and chatting stay with the owner whatever it is set to
+type ReportAccessService struct {
+	orgRepo    models.OrganizationRepository
+	reportRepo models.ReportRepository
+}
+
+// NewReportAccessService creates a new report access service
+func NewReportAccessService(orgRepo models.OrganizationRepository, reportRepo models.ReportRepository) *ReportAccessService {
+	return &ReportAccessService{orgRepo: orgRepo, reportRepo: reportRepo}
+}
+
+// getOwnedReport loads a report and checks the caller owns it, for the services that only ever act for the owner
+func getOwnedReport(reportRepo models.ReportRepository, userID, reportID int) (*models.Report, error) {
+	report, err := reportRepo.GetByID(reportID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if report == nil {
+		return nil, errors.ErrRecordNotFound
+	}
+	if report.UserID != userID {
+		return nil, errors.ErrAccessDenied
+	}
+	return report, nil
+}
+
+// CanView reports whether the viewer may read the report
+func (ras *ReportAccessService) CanView(viewerID int, report *models.Report) (bool, error) {
+	if report.UserID == viewerID {
+		return true, nil
+	}
+	if report.Visibility != models.ReportVisibilityDoctor && report.Visibility != models.ReportVisibilityCareTeam {
+		return false, nil
+	}
+
+	viewer, err := ras.orgRepo.GetMembership(viewerID)
+	if err != nil {
+		return false, errors.ErrDatabaseConnection
+	}
+	if viewer == nil || !models.IsClinicianRole(viewer.Role) {
+		return false, nil
+	}
+	// Decision: Memberships are read on every check rather than when visibility is set, so a clinician who
+	// leaves the organization, or a patient who moves to another, stops sharing at once
+	owner, err := ras.orgRepo.GetMembership(report.UserID)
+	if err != nil {
+		return false, errors.ErrDatabaseConnection
+	}
+	if owner == nil || owner.OrganizationID != viewer.OrganizationID {
+		return false, nil
+	}
+
+	if report.Visibility == models.ReportVisibilityCareTeam {
+		return true, nil
+	}
+	return viewer.Role == models.OrgRoleDoctor && owner.AssignedDoctorID != nil && *owner.AssignedDoctorID == viewerID, nil
+}
+
+// SetVisibility changes who in the owner's organization may read one of their reports
+func (ras *ReportAccessService) SetVisibility(userID, reportID int, visibility string) (*models.Report, error) {
+	if !models.IsValidReportVisibility(visibility) {
+		return nil, errors.NewValidationError("Visibility must be private, doctor, or care_team")
+	}
+
+	report, err := getOwnedReport(ras.reportRepo, userID, reportID)
+	if err != nil {
+		return nil, err
+	}
+
+	if visibility != models.ReportVisibilityPrivate {
+		owner, err := ras.orgRepo.GetMembership(userID)
+		if err != nil {
+			return nil, errors.ErrDatabaseConnection
+		}
+		if owner == nil {
+			return nil, errors.ErrNotOrganizationMember
+		}
+	}
+
+	if err := ras.reportRepo.SetVisibility(reportID, visibility); err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	report.Visibility = visibility
+	return report, nil
+}
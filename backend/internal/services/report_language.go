@@ -0,0 +1,104 @@
+package services
+
+import (
+	"strings"
+	"unicode"
+)
+
+// ReportLanguage is a language reports are printed in that extraction and analysis know how to handle
+type ReportLanguage struct {
+	Code      string // ISO 639-1, stored and logged
+	Name      string // English name, used in prompts
+	Script    string // Name tesseract's script detection reports
+	Tesseract string // Tesseract language pack
+	table     *unicode.RangeTable
+}
+
+// reportLanguages lists the supported languages; English must stay first as the default
+// Decision: Identified by script rather than by vocabulary, since lab reports are mostly numbers and
+// abbreviations that say little about the language around them
+var reportLanguages = []ReportLanguage{
+	{Code: "en", Name: "English", Script: "Latin", Tesseract: "eng", table: unicode.Latin},
+	{Code: "hi", Name: "Hindi", Script: "Devanagari", Tesseract: "hin", table: unicode.Devanagari},
+	{Code: "kn", Name: "Kannada", Script: "Kannada", Tesseract: "kan", table: unicode.Kannada},
+	{Code: "ta", Name: "Tamil", Script: "Tamil", Tesseract: "tam", table: unicode.Tamil},
+}
+
+// minRegionalScriptShare is the share of letters in a regional script above which a report counts as written in it
+// Regional reports still print test names, units, and abbreviations in Latin letters, so a majority is not required
+const minRegionalScriptShare = 0.2
+
+// LookupReportLanguage returns the supported language with the given ISO code, or nil
+func LookupReportLanguage(code string) *ReportLanguage {
+	for i := range reportLanguages {
+		if reportLanguages[i].Code == code {
+			return &reportLanguages[i]
+		}
+	}
+	return nil
+}
+
+// reportLanguageForScript returns the language written in a tesseract script name, or nil
+func reportLanguageForScript(script string) *ReportLanguage {
+	for i := range reportLanguages {
+		if reportLanguages[i].Script == script {
+			return &reportLanguages[i]
+		}
+	}
+	return nil
+}
+
+// DetectReportLanguage returns the code of the regional script with the most letters in text,
+// or "en" when none reaches minRegionalScriptShare
+func DetectReportLanguage(text string) string {
+	counts := make([]int, len(reportLanguages))
+	letters := 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) && !unicode.IsMark(r) {
+			continue
+		}
+		letters++
+		for i, language := range reportLanguages {
+			if unicode.Is(language.table, r) {
+				counts[i]++
+				break
+			}
+		}
+	}
+
+	best := 1
+	for i := 2; i < len(reportLanguages); i++ {
+		if counts[i] > counts[best] {
+			best = i
+		}
+	}
+	if counts[best] == 0 || float64(counts[best]) < minRegionalScriptShare*float64(letters) {
+		return reportLanguages[0].Code
+	}
+	return reportLanguages[best].Code
+}
+
+// nativeDigitZeros are the zero digits of the supported regional scripts; each script's digits follow its zero
+var nativeDigitZeros = []rune{'०', '೦', '௦'} // Devanagari, Kannada, Tamil
+
+// NormalizeDigits rewrites Devanagari, Kannada, and Tamil digits as 0-9 so values parse the same in every script
+func NormalizeDigits(text string) string {
+	return strings.Map(func(r rune) rune {
+		for _, zero := range nativeDigitZeros {
+			if r >= zero && r <= zero+9 {
+				return '0' + (r - zero)
+			}
+		}
+		return r
+	}, text)
+}
+
+// reportLanguageOf folds the language of one more part into the language of a multi-part report
+// Decision: A report counts as regional when any part is, since English pages (a lab's cover sheet) are
+// common in regional reports while the reverse says nothing about the results
+func reportLanguageOf(language, partLanguage string) string {
+	if language == "" || language == reportLanguages[0].Code {
+		return partLanguage
+	}
+	return language
+}
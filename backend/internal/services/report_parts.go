@@ -0,0 +1,138 @@
+package services
+
+import (
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+// ReportPartService joins reports uploaded page by page into one report analyzed as a whole
+type ReportPartService struct {
+	reportRepo models.ReportRepository
+	partRepo   models.ReportPartRepository
+	window     time.Duration // How close together sibling uploads must be to be offered as one; 0 disables offers
+}
+
+// NewReportPartService creates a new multi-part report service
+func NewReportPartService(reportRepo models.ReportRepository, partRepo models.ReportPartRepository, window time.Duration) *ReportPartService {
+	return &ReportPartService{reportRepo: reportRepo, partRepo: partRepo, window: window}
+}
+
+// Candidate returns an earlier upload that report looks like another page of, or nil
+// Decision: Only offered, never merged automatically: "cbc1.pdf" and "cbc2.pdf" may well be two
+// checkups, and only the patient knows; a wrong merge would blend two dates into one analysis
+func (ps *ReportPartService) Candidate(report *models.Report) *models.Report {
+	if ps.window <= 0 {
+		return nil
+	}
+
+	recent, err := ps.reportRepo.GetByUserID(report.UserID, 5, 0)
+	if err != nil {
+		slog.Warn("Failed to look for other parts of report", "report_id", report.ID, "error", err)
+		return nil
+	}
+	for _, other := range recent {
+		if other.ID == report.ID || report.UploadDate.Sub(other.UploadDate) > ps.window {
+			continue
+		}
+		if siblingFilenames(other.OriginalFilename, report.OriginalFilename) {
+			return other
+		}
+	}
+	return nil
+}
+
+// siblingFilenames reports whether two names differ only in page numbering, like page1.pdf and page2.pdf
+func siblingFilenames(a, b string) bool {
+	if a == b {
+		// The same name twice is a re-upload, not a second page
+		return false
+	}
+	return pageStem(a) == pageStem(b)
+}
+
+// pageStem lowercases a filename and drops its extension, digits, separators, and page words
+func pageStem(name string) string {
+	name = strings.ToLower(strings.TrimSuffix(name, filepath.Ext(name)))
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsMark(r)
+	})
+
+	var stem []string
+	for _, word := range words {
+		switch word {
+		case "page", "pg", "p", "part", "pt", "of", "scan", "img", "image":
+			continue
+		}
+		stem = append(stem, word)
+	}
+	return strings.Join(stem, " ")
+}
+
+// Merge appends source's pages to target and queues target to be analyzed again as one report
+// Decision: Source is consumed: its row, analysis, and chat are deleted, since they described a fragment
+func (ps *ReportPartService) Merge(user *models.User, targetID, sourceID int) (*models.Report, error) {
+	if targetID == sourceID {
+		return nil, errors.NewValidationError("A report can't be merged into itself")
+	}
+	target, err := ps.ownedReport(user, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := ps.ownedReport(user, sourceID); err != nil {
+		return nil, err
+	}
+	if target.ProcessingStatus == "processing" {
+		return nil, errors.ErrReportBusy
+	}
+
+	merged, err := ps.partRepo.Merge(targetID, sourceID)
+	if err == models.ErrLegalHold {
+		return nil, errors.ErrLegalHold
+	}
+	if err != nil {
+		slog.Error("Failed to merge report parts", "source_id", sourceID, "target_id", targetID, "error", err)
+		return nil, errors.ErrDatabaseConnection
+	}
+	if !merged {
+		return nil, errors.ErrReportBusy
+	}
+
+	report, err := ps.reportRepo.GetByID(targetID)
+	if err != nil || report == nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	return report, nil
+}
+
+// Parts returns a report's further pages in order
+func (ps *ReportPartService) Parts(user *models.User, reportID int) ([]*models.ReportPart, error) {
+	if _, err := ps.ownedReport(user, reportID); err != nil {
+		return nil, err
+	}
+	parts, err := ps.partRepo.ListByReport(reportID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	return parts, nil
+}
+
+// ownedReport loads a report, refusing other users' reports
+func (ps *ReportPartService) ownedReport(user *models.User, reportID int) (*models.Report, error) {
+	report, err := ps.reportRepo.GetByID(reportID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if report == nil {
+		return nil, errors.ErrRecordNotFound
+	}
+	if report.UserID != user.ID {
+		return nil, errors.ErrAccessDenied
+	}
+	return report, nil
+}
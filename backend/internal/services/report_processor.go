@@ -0,0 +1,553 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/logging"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+)
+
+// ReportAnalyzer produces an analysis for a stored report file
+// Decision: Implemented by AIService and by DemoAnalyzer for keyless demo deployments
+type ReportAnalyzer interface {
+	// readingLevel is one of the models.ReadingLevel* constants; plan one of the models.Plan* constants;
+	// patient is PatientContext output, possibly empty; ctx carries the report for the AI call log
+	AnalyzeReport(ctx context.Context, filePath, fileType, readingLevel, plan, patient string) (*ReportAnalysis, error)
+}
+
+// partsAnalyzer is implemented by analyzers that can read a multi-part report as one
+type partsAnalyzer interface {
+	AnalyzeParts(ctx context.Context, filePaths []string, fileType, readingLevel, plan, patient string) (*ReportAnalysis, error)
+}
+
+// modelNamer is implemented by analyzers that can say which model ran an attempt
+type modelNamer interface {
+	ModelName() string
+}
+
+// ErrQueuePaused is returned when an operator paused processing; the report stays pending
+var ErrQueuePaused = errors.New("processing queue is paused")
+
+// ErrReportClaimed is returned when another process already took the report; it is not a failure
+var ErrReportClaimed = errors.New("report already claimed by another process")
+
+// RetryScheduledError is returned when an analysis failed for a passing reason and the report went back in the queue
+type RetryScheduledError struct {
+	After time.Duration // How long until a worker may pick it up again
+	Err   error
+}
+
+func (e *RetryScheduledError) Error() string {
+	return fmt.Sprintf("retrying in %s: %v", e.After, e.Err)
+}
+
+func (e *RetryScheduledError) Unwrap() error {
+	return e.Err
+}
+
+// RetryPolicy decides whether a report whose analysis timed out or hit the provider's quota is analyzed again
+type RetryPolicy struct {
+	MaxAttempts int           // Analyses a report gets in all since it last succeeded; 0 retries nothing
+	Backoff     time.Duration // Wait before the second analysis, doubled for each one after
+	MaxBackoff  time.Duration // Longest wait; 0 for no limit
+}
+
+// delay returns the wait after a report's attempts-th failed analysis
+func (p RetryPolicy) delay(attempts int) time.Duration {
+	delay := p.Backoff
+	for i := 1; i < attempts && (p.MaxBackoff <= 0 || delay < p.MaxBackoff); i++ {
+		delay *= 2
+	}
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	return delay
+}
+
+// ReportProcessor runs the AI analysis pipeline for a single report
+// Decision: Shared by the HTTP server, the queue worker, and the reprocess command
+// so every entry point updates report status the same way
+type ReportProcessor struct {
+	reportRepo  models.ReportRepository
+	jobRepo     models.JobRepository            // Optional; nil skips attempt history and the pause check
+	reviewRepo  models.AnalysisReviewRepository // Optional; nil fails unparseable analyses instead of quarantining them
+	profileRepo models.HealthProfileRepository  // Optional; nil analyzes without the patient's profile
+	analyzer    ReportAnalyzer
+	fileStorage *FileStorage
+	events      EventBus                    // Optional; nil publishes nothing
+	partRepo    models.ReportPartRepository // Optional; nil analyzes only each report's first part
+	fallback    ReportAnalyzer              // Optional; nil fails reports the analyzer can't analyze
+	retry       RetryPolicy                 // Zero fails every analysis on its first error
+	usage       *OrganizationUsageService   // Optional; nil counts no processed reports
+}
+
+// NewReportProcessor creates a new report processor
+func NewReportProcessor(
+	reportRepo models.ReportRepository,
+	jobRepo models.JobRepository,
+	reviewRepo models.AnalysisReviewRepository,
+	profileRepo models.HealthProfileRepository,
+	aiService *AIService,
+	fileStorage *FileStorage,
+) *ReportProcessor {
+	// Decision: Keep a nil *AIService out of the interface so the availability check below works
+	if aiService == nil {
+		return NewReportProcessorWithAnalyzer(reportRepo, jobRepo, reviewRepo, profileRepo, nil, fileStorage)
+	}
+	return NewReportProcessorWithAnalyzer(reportRepo, jobRepo, reviewRepo, profileRepo, aiService, fileStorage)
+}
+
+// NewReportProcessorWithAnalyzer creates a report processor backed by any analyzer
+func NewReportProcessorWithAnalyzer(
+	reportRepo models.ReportRepository,
+	jobRepo models.JobRepository,
+	reviewRepo models.AnalysisReviewRepository,
+	profileRepo models.HealthProfileRepository,
+	analyzer ReportAnalyzer,
+	fileStorage *FileStorage,
+) *ReportProcessor {
+	return &ReportProcessor{
+		reportRepo:  reportRepo,
+		jobRepo:     jobRepo,
+		reviewRepo:  reviewRepo,
+		profileRepo: profileRepo,
+		analyzer:    analyzer,
+		fileStorage: fileStorage,
+	}
+}
+
+// SetEventBus publishes analysis.completed on bus for every report this processor completes, and
+// report.status_changed when it starts, fails, or puts off one
+// Decision: Set after construction so cmd/reprocess can re-analyze in bulk without notifying every owner
+func (rp *ReportProcessor) SetEventBus(bus EventBus) {
+	rp.events = bus
+}
+
+// SetPartRepository analyzes the further parts of multi-part reports along with the first
+func (rp *ReportProcessor) SetPartRepository(repo models.ReportPartRepository) {
+	rp.partRepo = repo
+}
+
+// SetFallbackAnalyzer completes reports with analyzer when there is no model or it keeps failing,
+// typically a BasicAnalyzer, instead of failing them
+func (rp *ReportProcessor) SetFallbackAnalyzer(analyzer ReportAnalyzer) {
+	rp.fallback = analyzer
+}
+
+// SetUsageMeter counts each completed report toward its owner's organization's usage
+func (rp *ReportProcessor) SetUsageMeter(usage *OrganizationUsageService) {
+	rp.usage = usage
+}
+
+// SetRetryPolicy retries analyses that time out or hit the provider's quota; it needs the job repository,
+// which counts each report's attempts
+func (rp *ReportProcessor) SetRetryPolicy(policy RetryPolicy) {
+	rp.retry = policy
+}
+
+// Paused reports whether an operator paused the queue
+func (rp *ReportProcessor) Paused() (bool, error) {
+	if rp.jobRepo == nil {
+		return false, nil
+	}
+	state, err := rp.jobRepo.GetQueueState()
+	if err != nil {
+		return false, err
+	}
+	return state.Paused, nil
+}
+
+// ProcessReport analyzes a report and stores the result or failure reason, recording the attempt
+func (rp *ReportProcessor) ProcessReport(report *models.Report) error {
+	// Decision: Checked per report, not per batch, so a pause also stops inline processing of new uploads
+	paused, err := rp.Paused()
+	if err != nil {
+		return fmt.Errorf("failed to read queue state: %w", err)
+	}
+	if paused {
+		return ErrQueuePaused
+	}
+
+	// Decision: Claim the report from the status it was loaded with, so when several servers or workers
+	// picked it up only one analyzes it; a report already processing belongs to whoever claimed it
+	if report.ProcessingStatus == "processing" {
+		return ErrReportClaimed
+	}
+	claimed, err := rp.reportRepo.ClaimForProcessing(report.ID, report.ProcessingStatus)
+	if err != nil {
+		return fmt.Errorf("failed to mark report %d as processing: %w", report.ID, err)
+	}
+	if !claimed {
+		return ErrReportClaimed
+	}
+	report.ProcessingStatus = "processing"
+	publishEvent(rp.events, Event{Type: EventReportStatusChanged, UserID: report.UserID, ReportID: report.ID})
+
+	attemptID := rp.startAttempt(report.ID)
+	err = rp.analyze(report)
+	rp.finishAttempt(attemptID, err)
+	if err == nil {
+		publishEvent(rp.events, Event{Type: EventAnalysisCompleted, UserID: report.UserID, ReportID: report.ID})
+	} else {
+		publishEvent(rp.events, Event{Type: EventReportStatusChanged, UserID: report.UserID, ReportID: report.ID})
+	}
+	return err
+}
+
+// analyze runs the pipeline on a report already marked as processing
+func (rp *ReportProcessor) analyze(report *models.Report) error {
+	// Check if AI service is available
+	if rp.analyzer == nil && rp.fallback == nil {
+		rp.fail(report.ID, models.ProcessingErrorInternal, "AI service not available - missing API key")
+		return fmt.Errorf("AI service not available")
+	}
+
+	// Decision: Never hand the analyzer a path outside the upload directory
+	filePath, err := rp.fileStorage.Resolve(report.FilePath)
+	if err != nil {
+		rp.fail(report.ID, models.ProcessingErrorInternal, "Processing failed: invalid file location")
+		return fmt.Errorf("report %d: %w", report.ID, err)
+	}
+
+	filePaths, err := rp.partPaths(report.ID)
+	if err != nil {
+		rp.fail(report.ID, models.ProcessingErrorInternal, "Processing failed: the report's other pages could not be found")
+		return fmt.Errorf("report %d: %w", report.ID, err)
+	}
+
+	if rp.analyzer == nil {
+		return rp.giveUp(report, filePath, filePaths, models.ProcessingErrorInternal,
+			"AI service not available - missing API key", fmt.Errorf("AI service not available"))
+	}
+
+	// Extract text from file and get AI analysis; only new reports, never re-analyses, may go to a prompt canary
+	analysis, err := rp.runAnalyzer(WithPromptCanary(context.Background()), rp.analyzer, report, filePath, filePaths)
+	// Decision: Unreadable files fail with the extractor's explanation, which tells the patient what to upload instead
+	var unreadable *UnreadableReportError
+	if errors.As(err, &unreadable) {
+		rp.fail(report.ID, models.ProcessingErrorUnreadableDocument, unreadable.Reason)
+		return err
+	}
+	if err != nil {
+		return rp.failOrRetry(report, filePath, filePaths, err)
+	}
+	return rp.store(report, analysis)
+}
+
+// store records a finished analysis on the report, or holds it for review when it couldn't be parsed
+func (rp *ReportProcessor) store(report *models.Report, analysis *ReportAnalysis) error {
+	// Decision: Record prompt version before completion so A/B stats never miss a finished report
+	if err := rp.reportRepo.SetAnalysisMetadata(report.ID, analysis.PromptVersion, analysis.ParseFailed); err != nil {
+		return fmt.Errorf("failed to record analysis metadata for report %d: %w", report.ID, err)
+	}
+	if analysis.Language != "" {
+		if err := rp.reportRepo.SetLanguage(report.ID, analysis.Language); err != nil {
+			return fmt.Errorf("failed to record the language of report %d: %w", report.ID, err)
+		}
+	}
+
+	if analysis.ParseFailed {
+		return rp.quarantine(report, analysis)
+	}
+
+	// Update status to completed with summary
+	if err := rp.reportRepo.UpdateProcessingStatus(report.ID, "completed", analysis.ResultJSON); err != nil {
+		return err
+	}
+	if rp.usage != nil {
+		rp.usage.RecordReportProcessed(report.UserID)
+	}
+	return nil
+}
+
+// Reanalyze analyzes a completed report again and returns the new analysis without storing it
+// Decision: The report keeps its status and current analysis throughout, so the patient never sees it
+// leave "completed"; the caller decides whether to replace the analysis. Unparseable output is an error,
+// since there is already a readable analysis to keep
+func (rp *ReportProcessor) Reanalyze(report *models.Report) (*ReportAnalysis, error) {
+	if rp.analyzer == nil {
+		return nil, fmt.Errorf("AI service not available")
+	}
+
+	filePath, err := rp.fileStorage.Resolve(report.FilePath)
+	if err != nil {
+		return nil, fmt.Errorf("report %d: %w", report.ID, err)
+	}
+	filePaths, err := rp.partPaths(report.ID)
+	if err != nil {
+		return nil, fmt.Errorf("report %d: %w", report.ID, err)
+	}
+
+	attemptID := rp.startAttempt(report.ID)
+	analysis, err := rp.runAnalyzer(context.Background(), rp.analyzer, report, filePath, filePaths)
+	if err == nil && analysis.ParseFailed {
+		err = &AnalysisParseError{Raw: analysis.RawOutput, Err: errors.New(analysis.ParseError)}
+	}
+	rp.finishAttempt(attemptID, err)
+	if err != nil {
+		return nil, err
+	}
+	return analysis, nil
+}
+
+// runAnalyzer hands a report's files to analyzer, all parts together when it can read them
+func (rp *ReportProcessor) runAnalyzer(ctx context.Context, analyzer ReportAnalyzer, report *models.Report, filePath string, partPaths []string) (*ReportAnalysis, error) {
+	ctx = WithAICallUser(WithAICallReport(ctx, report.ID), report.UserID)
+	ctx = logging.With(ctx, "report_id", report.ID)
+	patient := rp.patientContext(report.UserID)
+	if parts, ok := analyzer.(partsAnalyzer); ok && len(partPaths) > 0 {
+		return parts.AnalyzeParts(ctx, append([]string{filePath}, partPaths...), report.FileType, report.ReadingLevel, report.Plan, patient)
+	}
+	return analyzer.AnalyzeReport(ctx, filePath, report.FileType, report.ReadingLevel, report.Plan, patient)
+}
+
+// startAttempt records the start of an analysis with the model running it; 0 when it is not recorded
+func (rp *ReportProcessor) startAttempt(reportID int) int {
+	if rp.jobRepo == nil {
+		return 0
+	}
+	attemptID, err := rp.jobRepo.StartAttempt(reportID, rp.ModelName())
+	if err != nil {
+		slog.Error("Failed to record processing attempt", "report_id", reportID, "error", err)
+	}
+	return attemptID
+}
+
+// finishAttempt records how an attempt ended
+func (rp *ReportProcessor) finishAttempt(attemptID int, err error) {
+	if attemptID == 0 {
+		return
+	}
+	status, errMsg := models.AttemptSucceeded, ""
+	if err != nil {
+		status, errMsg = models.AttemptFailed, err.Error()
+	}
+	if finishErr := rp.jobRepo.FinishAttempt(attemptID, status, errMsg); finishErr != nil {
+		slog.Error("Failed to finish processing attempt", "attempt_id", attemptID, "error", finishErr)
+	}
+}
+
+// ModelName identifies the model analyses run on; empty when the analyzer doesn't say
+func (rp *ReportProcessor) ModelName() string {
+	if namer, ok := rp.analyzer.(modelNamer); ok {
+		return namer.ModelName()
+	}
+	return ""
+}
+
+// partPaths resolves the files of a report's further parts, in page order
+func (rp *ReportProcessor) partPaths(reportID int) ([]string, error) {
+	if rp.partRepo == nil {
+		return nil, nil
+	}
+	parts, err := rp.partRepo.ListByReport(reportID)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, len(parts))
+	for i, part := range parts {
+		if paths[i], err = rp.fileStorage.Resolve(part.FilePath); err != nil {
+			return nil, err
+		}
+	}
+	return paths, nil
+}
+
+// failOrRetry records why an analysis failed, putting the report back in the queue if the cause may pass
+// Decision: Only timeouts and quota errors are retried; any other failure would happen again
+func (rp *ReportProcessor) failOrRetry(report *models.Report, filePath string, partPaths []string, err error) error {
+	errorCode := ClassifyProcessingError(err)
+	errorDetail := fmt.Sprintf("Processing failed: %v", err)
+	if errorCode != models.ProcessingErrorAITimeout && errorCode != models.ProcessingErrorQuotaExceeded {
+		return rp.giveUp(report, filePath, partPaths, errorCode, errorDetail, err)
+	}
+
+	// Decision: Without the job repository attempts can't be counted, so nothing is retried
+	if rp.jobRepo == nil || rp.retry.MaxAttempts == 0 {
+		return rp.giveUp(report, filePath, partPaths, errorCode, errorDetail, err)
+	}
+	attempts, countErr := rp.attempts(report.ID)
+	if countErr != nil {
+		slog.Error("Failed to count processing attempts", "report_id", report.ID, "error", countErr)
+	}
+	if countErr != nil || attempts >= rp.retry.MaxAttempts {
+		return rp.giveUp(report, filePath, partPaths, errorCode, errorDetail, err)
+	}
+	delay := rp.retry.delay(attempts)
+	// Decision: Never come back sooner than the provider asked
+	var rateErr *RateLimitError
+	if errors.As(err, &rateErr) && rateErr.RetryAfter > delay {
+		delay = rateErr.RetryAfter
+	}
+
+	if retryErr := rp.reportRepo.ScheduleRetry(report.ID, errorCode, errorDetail, time.Now().Add(delay)); retryErr != nil {
+		slog.Error("Failed to schedule a retry", "report_id", report.ID, "error", retryErr)
+		return rp.giveUp(report, filePath, partPaths, errorCode, errorDetail, err)
+	}
+	return &RetryScheduledError{After: delay, Err: err}
+}
+
+// giveUp ends an analysis that won't be retried: with the fallback's basic analysis when the model was
+// at fault, otherwise by failing the report with errorCode
+// Decision: Files the extractor or model couldn't make sense of still fail, since the fallback reads the
+// same text; only a missing, slow, or broken model is worked around
+func (rp *ReportProcessor) giveUp(report *models.Report, filePath string, partPaths []string, errorCode, errorDetail string, err error) error {
+	if rp.fallback == nil || !modelFailure(errorCode) {
+		rp.fail(report.ID, errorCode, errorDetail)
+		return err
+	}
+
+	slog.Warn("Analysis failed, storing a basic extraction instead", "report_id", report.ID, "error_code", errorCode, "error", err)
+	analysis, fallbackErr := rp.runAnalyzer(context.Background(), rp.fallback, report, filePath, partPaths)
+	var unreadable *UnreadableReportError
+	if errors.As(fallbackErr, &unreadable) {
+		rp.fail(report.ID, models.ProcessingErrorUnreadableDocument, unreadable.Reason)
+		return fallbackErr
+	}
+	if fallbackErr != nil {
+		slog.Error("Basic extraction failed", "report_id", report.ID, "error", fallbackErr)
+		rp.fail(report.ID, errorCode, errorDetail)
+		return err
+	}
+	return rp.store(report, analysis)
+}
+
+// modelFailure reports whether an analysis failed because of the model rather than the report
+func modelFailure(errorCode string) bool {
+	switch errorCode {
+	case models.ProcessingErrorAITimeout, models.ProcessingErrorQuotaExceeded, models.ProcessingErrorInternal:
+		return true
+	}
+	return false
+}
+
+// attempts counts the report's analyses since its last successful one, the running one included
+func (rp *ReportProcessor) attempts(reportID int) (int, error) {
+	attempts, err := rp.jobRepo.ListAttempts(reportID)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for i := len(attempts) - 1; i >= 0 && attempts[i].Status != models.AttemptSucceeded; i-- {
+		count++
+	}
+	return count, nil
+}
+
+// RecoverStale puts reports processing since before the given time back in the queue, assuming the
+// process analyzing them stopped; reports that have had all their attempts are failed instead
+// Returns how many reports it recovered
+// Decision: Each abandoned analysis counts as an attempt, so a report that brings its worker down
+// every time fails once it has had its attempts instead of taking down every worker in turn
+func (rp *ReportProcessor) RecoverStale(before time.Time, limit int) (int, error) {
+	reports, err := rp.reportRepo.ListStaleProcessing(before, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	recovered := 0
+	for _, report := range reports {
+		attempts := 0
+		// Decision: Close the dead attempt before requeueing, so the next worker's attempt isn't closed with it
+		if rp.jobRepo != nil {
+			if err := rp.jobRepo.AbandonRunningAttempts(report.ID, "The process analyzing the report stopped before finishing"); err != nil {
+				return recovered, err
+			}
+			if attempts, err = rp.attempts(report.ID); err != nil {
+				return recovered, err
+			}
+		}
+
+		var ok bool
+		if rp.retry.MaxAttempts > 0 && attempts >= rp.retry.MaxAttempts {
+			ok, err = rp.reportRepo.FailStale(report.ID, before, models.ProcessingErrorInternal,
+				"Processing failed: the process analyzing the report stopped before finishing")
+		} else {
+			ok, err = rp.reportRepo.RequeueStale(report.ID, before)
+		}
+		if err != nil {
+			return recovered, err
+		}
+		if ok {
+			recovered++
+		}
+	}
+	return recovered, nil
+}
+
+// fail records why a report failed
+// Decision: A failed write is only logged; the caller is already returning the analysis error
+func (rp *ReportProcessor) fail(reportID int, errorCode, errorDetail string) {
+	if err := rp.reportRepo.MarkFailed(reportID, errorCode, errorDetail); err != nil {
+		slog.Error("Failed to mark report failed", "report_id", reportID, "error_code", errorCode, "error", err)
+	}
+}
+
+// ClassifyProcessingError maps an analysis failure to one of the models.ProcessingError* codes
+// Decision: Quota and timeout checks come before extraction, since OCR calls the model too
+func ClassifyProcessingError(err error) string {
+	var unreadable *UnreadableReportError
+	var rateErr *RateLimitError
+	var netErr net.Error
+	var extractErr *ExtractionError
+	var parseErr *AnalysisParseError
+
+	switch {
+	case errors.As(err, &unreadable):
+		return models.ProcessingErrorUnreadableDocument
+	case errors.As(err, &rateErr):
+		return models.ProcessingErrorQuotaExceeded
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return models.ProcessingErrorAITimeout
+	case errors.As(err, &extractErr):
+		return models.ProcessingErrorExtractionFailed
+	case errors.As(err, &parseErr):
+		return models.ProcessingErrorParseFailed
+	default:
+		return models.ProcessingErrorInternal
+	}
+}
+
+// quarantine holds unparseable model output for an operator instead of storing a made-up analysis
+// The returned error records the parse failure on the processing attempt
+func (rp *ReportProcessor) quarantine(report *models.Report, analysis *ReportAnalysis) error {
+	if rp.reviewRepo == nil {
+		rp.fail(report.ID, models.ProcessingErrorParseFailed, "Processing failed: the analysis could not be read")
+		return fmt.Errorf("report %d: unparseable analysis: %s", report.ID, analysis.ParseError)
+	}
+
+	review := &models.AnalysisReview{
+		ReportID:      report.ID,
+		RawOutput:     analysis.RawOutput,
+		ParseError:    analysis.ParseError,
+		PromptVersion: analysis.PromptVersion,
+	}
+	if err := rp.reviewRepo.Quarantine(review); err != nil {
+		return fmt.Errorf("failed to quarantine analysis of report %d: %w", report.ID, err)
+	}
+	if err := rp.reportRepo.UpdateProcessingStatus(report.ID, "needs_review", ""); err != nil {
+		return fmt.Errorf("failed to mark report %d for review: %w", report.ID, err)
+	}
+	return fmt.Errorf("report %d held for review: unparseable analysis: %s", report.ID, analysis.ParseError)
+}
+
+// patientContext describes the report owner's profile for the analysis prompt
+// Decision: A profile lookup failure analyzes without it rather than failing the report
+func (rp *ReportProcessor) patientContext(userID int) string {
+	if rp.profileRepo == nil {
+		return ""
+	}
+	profile, err := rp.profileRepo.GetByUserID(userID)
+	if err != nil {
+		slog.Warn("Failed to load profile for analysis", "user_id", userID, "error", err)
+		return ""
+	}
+	return PatientContext(profile, time.Now())
+}
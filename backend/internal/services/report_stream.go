@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+// defaultReportStreamPoll is how often a stream reads the report's history when no event wakes it
+const defaultReportStreamPoll = 2 * time.Second
+
+// ReportStreamService follows a report's processing status for its owner as it changes
+// Decision: The report's status history is the source of truth, so a change made by any server or worker
+// reaches every stream; events on this process's bus only wake a stream before its next poll
+type ReportStreamService struct {
+	reportRepo   models.ReportRepository
+	pollInterval time.Duration
+
+	mu       sync.Mutex
+	watchers map[int]map[chan struct{}]struct{} // Wake channels of open streams, by report
+}
+
+// NewReportStreamService creates a report stream service; pollInterval 0 uses the default
+func NewReportStreamService(reportRepo models.ReportRepository, pollInterval time.Duration) *ReportStreamService {
+	if pollInterval <= 0 {
+		pollInterval = defaultReportStreamPoll
+	}
+	return &ReportStreamService{
+		reportRepo:   reportRepo,
+		pollInterval: pollInterval,
+		watchers:     make(map[int]map[chan struct{}]struct{}),
+	}
+}
+
+// Subscribe wakes a report's streams whenever bus carries an event about the report
+func (rs *ReportStreamService) Subscribe(bus EventBus) {
+	wake := func(event Event) error {
+		rs.wake(event.ReportID)
+		return nil
+	}
+	for _, eventType := range []string{EventReportUploaded, EventReportStatusChanged, EventAnalysisCompleted} {
+		bus.Subscribe(eventType, wake)
+	}
+}
+
+// IsFinalReportStatus reports whether a report in status will change no further on its own
+func IsFinalReportStatus(status string) bool {
+	return status == "completed" || status == "failed"
+}
+
+// Watch checks the user may follow the report, then sends each status it enters after the history entry
+// afterID, starting with those already recorded; the channel closes after a final status or when ctx ends.
+// It returns a nil channel when the report is final and nothing after afterID is left to send
+func (rs *ReportStreamService) Watch(ctx context.Context, user *models.User, reportID, afterID int) (<-chan *models.ReportStatusEvent, error) {
+	report, err := rs.reportRepo.GetByID(reportID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if report == nil {
+		return nil, errors.ErrRecordNotFound
+	}
+	if report.UserID != user.ID {
+		return nil, errors.ErrAccessDenied
+	}
+	// Decision: A client reconnecting after the final status gets nothing, rather than a stream that never ends
+	if IsFinalReportStatus(report.ProcessingStatus) {
+		history, err := rs.reportRepo.ListStatusEvents(reportID)
+		if err != nil {
+			return nil, errors.ErrDatabaseConnection
+		}
+		if len(history) == 0 || history[len(history)-1].ID <= afterID {
+			return nil, nil
+		}
+	}
+
+	wake := make(chan struct{}, 1)
+	rs.mu.Lock()
+	if rs.watchers[reportID] == nil {
+		rs.watchers[reportID] = make(map[chan struct{}]struct{})
+	}
+	rs.watchers[reportID][wake] = struct{}{}
+	rs.mu.Unlock()
+
+	events := make(chan *models.ReportStatusEvent)
+	go func() {
+		defer close(events)
+		defer rs.unwatch(reportID, wake)
+
+		ticker := time.NewTicker(rs.pollInterval)
+		defer ticker.Stop()
+		for {
+			history, err := rs.reportRepo.ListStatusEvents(reportID)
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to read status history", "report_id", reportID, "error", err)
+				return
+			}
+			for i, event := range history {
+				if event.ID <= afterID {
+					continue
+				}
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+				afterID = event.ID
+				// An operator may have retried a report that failed earlier in its history
+				if i == len(history)-1 && IsFinalReportStatus(event.Status) {
+					return
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-wake:
+			case <-ticker.C:
+			}
+		}
+	}()
+	return events, nil
+}
+
+// wake prompts the report's open streams to read its history now
+func (rs *ReportStreamService) wake(reportID int) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	for wake := range rs.watchers[reportID] {
+		select {
+		case wake <- struct{}{}:
+		default: // Already due to read
+		}
+	}
+}
+
+// unwatch forgets a closed stream
+func (rs *ReportStreamService) unwatch(reportID int, wake chan struct{}) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	delete(rs.watchers[reportID], wake)
+	if len(rs.watchers[reportID]) == 0 {
+		delete(rs.watchers, reportID)
+	}
+}
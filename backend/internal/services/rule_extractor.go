@@ -0,0 +1,289 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Confidence in a metric's value, by who read it
+const (
+	confidenceAgreed    = 0.95 // The model and the rule extractor read the same value
+	confidenceModelOnly = 0.7  // Only the model found the result
+	confidenceRuleOnly  = 0.6  // Only the rule extractor found the result
+	confidenceDisputed  = 0.5  // The two read different values; the printed one was kept
+)
+
+// maxLabelWords bounds the label before a value, so sentences that mention a test aren't read as results
+const maxLabelWords = 5
+
+// valueTolerance is the relative difference under which two readings of a value agree, so 7.8 and 7.80 do
+const valueTolerance = 0.005
+
+// labValue matches a value field, optionally with its unit attached ("13.5g/dL") or a comparison ("<0.5")
+var labValue = regexp.MustCompile(`^[<>]?=?(\d+(?:\.\d+)?)([A-Za-zµ%][^\s]*)?$`)
+
+// Reference range shapes labs print after the value: "12.0 - 15.0", "[< 5.0]", "> 1.0", "up to 40"
+var (
+	rangeBetween = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*(?:-|–|to)\s*(\d+(?:\.\d+)?)`)
+	rangeBelow   = regexp.MustCompile(`(?i)(?:<|≤|up\s*to)\s*=?\s*(\d+(?:\.\d+)?)`)
+	rangeAbove   = regexp.MustCompile(`(?:>|≥)\s*=?\s*(\d+(?:\.\d+)?)`)
+)
+
+// labSeparators turns column separators that strings.Fields doesn't split on into spaces
+var labSeparators = strings.NewReplacer("|", " ")
+
+// LabResult is one test result the rule extractor read from a line of report text
+type LabResult struct {
+	Name     string // As printed
+	Value    float64
+	Unit     string   // As printed; empty when the line gives none
+	RangeMin *float64 // Nil when the reference range has no lower bound or none is printed
+	RangeMax *float64 // Nil when the reference range has no upper bound or none is printed
+	Flagged  bool     // The lab printed a high or low flag
+}
+
+// HasRange reports whether the line printed a reference range
+func (r LabResult) HasRange() bool {
+	return r.RangeMin != nil || r.RangeMax != nil
+}
+
+// OutOfRange reports whether the value is outside the printed range, or the lab flagged it
+func (r LabResult) OutOfRange() bool {
+	return r.Flagged || (r.RangeMin != nil && r.Value < *r.RangeMin) || (r.RangeMax != nil && r.Value > *r.RangeMax)
+}
+
+// ExtractLabResults reads the results of a report printed one per line as name, value, unit, and reference range,
+// in fixed-width columns, piped tables, or sentences like "urea 58 mg/dL"
+// Decision: Deterministic on purpose; the same text always gives the same results, so it can stand in for the
+// model and check it. A label must name a catalog analyte, or the line must print a reference range, since either
+// shows the number is a result rather than a date, an age, or a lab number. Only the first result per analyte is kept
+func ExtractLabResults(text string) []LabResult {
+	results := []LabResult{}
+	seen := make(map[string]bool)
+	for _, result := range readLabResults(text) {
+		if key := labResultKey(result.Name); !seen[key] {
+			seen[key] = true
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// readLabResults reads every result line of text, repeated analytes included
+func readLabResults(text string) []LabResult {
+	var results []LabResult
+	for _, line := range strings.Split(text, "\n") {
+		if result, ok := parseLabLine(strings.Fields(labSeparators.Replace(line))); ok {
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// parseLabLine reads one line's fields as label, value, and the unit, flag, and range after it
+func parseLabLine(fields []string) (LabResult, bool) {
+	for i := 1; i < len(fields) && i <= maxLabelWords; i++ {
+		match := labValue.FindStringSubmatch(fields[i])
+		if match == nil {
+			continue
+		}
+		label := strings.Trim(strings.Join(fields[:i], " "), " :-=")
+		value, err := strconv.ParseFloat(match[1], 64)
+		if label == "" || err != nil {
+			return LabResult{}, false
+		}
+		analyte := lookupAnalyteUnits(label)
+
+		// Labs print the flag before or after the unit, and the reference range last
+		result := LabResult{Name: label, Value: value, Unit: match[2]}
+		var rest []string
+		for _, field := range fields[i+1:] {
+			if isLabFlag(field) {
+				result.Flagged = true
+			} else if result.Unit == "" && len(rest) == 0 && isLabUnit(field, analyte) {
+				result.Unit = strings.TrimRight(field, ",;")
+			} else {
+				rest = append(rest, field)
+			}
+		}
+		result.RangeMin, result.RangeMax = parseRange(strings.Join(rest, " "))
+
+		if analyte == nil && !result.HasRange() {
+			return LabResult{}, false
+		}
+		return result, true
+	}
+	return LabResult{}, false
+}
+
+// parseRange reads the reference range at the start of the text after a value and its unit
+func parseRange(text string) (*float64, *float64) {
+	text = strings.TrimLeft(text, "([ ")
+	if match := rangeBetween.FindStringSubmatchIndex(text); match != nil && match[0] == 0 {
+		low, _ := strconv.ParseFloat(text[match[2]:match[3]], 64)
+		high, _ := strconv.ParseFloat(text[match[4]:match[5]], 64)
+		if low <= high {
+			return &low, &high
+		}
+	}
+	if match := rangeBelow.FindStringSubmatchIndex(text); match != nil && match[0] == 0 {
+		high, _ := strconv.ParseFloat(text[match[2]:match[3]], 64)
+		return nil, &high
+	}
+	if match := rangeAbove.FindStringSubmatchIndex(text); match != nil && match[0] == 0 {
+		low, _ := strconv.ParseFloat(text[match[2]:match[3]], 64)
+		return &low, nil
+	}
+	return nil, nil
+}
+
+// isLabUnit reports whether a field is a unit the analyte is reported in, or looks like a unit
+func isLabUnit(field string, analyte *AnalyteUnits) bool {
+	if analyte != nil {
+		for _, unit := range analyte.Units {
+			if strings.EqualFold(field, unit.Unit) {
+				return true
+			}
+		}
+	}
+	switch strings.ToLower(field) {
+	case "fl", "pg", "u", "iu":
+		return true
+	}
+	// Decision: A unit needs a letter or a percent sign, so dates like 12/03/2026 aren't taken for one
+	return strings.ContainsAny(field, "/%") && (strings.IndexFunc(field, unicode.IsLetter) >= 0 || strings.Contains(field, "%"))
+}
+
+// isLabFlag reports whether a field is the lab's high or low flag
+func isLabFlag(field string) bool {
+	switch strings.ToUpper(strings.Trim(field, "()[]*")) {
+	case "H", "L", "HIGH", "LOW", "ABNORMAL", "HH", "LL":
+		return true
+	}
+	return false
+}
+
+// labResultKey identifies the analyte a result or metric name refers to, so "Haemoglobin" and "Hb" match
+func labResultKey(name string) string {
+	if analyte := lookupAnalyteUnits(name); analyte != nil {
+		return analyte.Name
+	}
+	return strings.Join(strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !('a' <= r && r <= 'z' || '0' <= r && r <= '9')
+	}), " ")
+}
+
+// CrossCheck counts how the model's metrics compared with the rule extractor's reading of the same text
+type CrossCheck struct {
+	Agreed    int // Both read the same value
+	Disputed  int // The model's value isn't printed for the analyte
+	Corrected int // Of the disputed, those given the one value the report prints instead
+	ModelOnly int // The rule extractor didn't find the result
+}
+
+// CrossCheckMetrics compares the model's metrics with the results the rule extractor reads from text, setting
+// each metric's confidence and correcting values the model misread
+// Decision: A metric agrees when any printed reading of its analyte matches, so serial values (admission and
+// day 3) don't count as misreads. When none does and the analyte is printed once, that value wins, since it was
+// copied from the line while the model may have transposed it; the metric is re-judged against the printed range,
+// or kept off the gauge when there is none. Results the model left out aren't added, since plan limits may have cut them
+func CrossCheckMetrics(metrics []HealthMetric, text string) CrossCheck {
+	printed := make(map[string][]LabResult)
+	for _, result := range readLabResults(text) {
+		key := labResultKey(result.Name)
+		printed[key] = append(printed[key], result)
+	}
+
+	var check CrossCheck
+	for i := range metrics {
+		metric := &metrics[i]
+		readings := printed[labResultKey(metric.Name)]
+		modelValue, numeric := metricNumber(metric.Value)
+		switch {
+		case len(readings) == 0 || !numeric:
+			metric.Confidence = confidenceModelOnly
+			check.ModelOnly++
+		case anyReadingAgrees(readings, modelValue):
+			metric.Confidence = confidenceAgreed
+			check.Agreed++
+		case len(readings) == 1:
+			metric.Confidence = confidenceDisputed
+			metric.ValueNote = fmt.Sprintf("The report prints %g%s; the analysis had read %s",
+				readings[0].Value, unitSuffix(readings[0].Unit), metric.GetValueAsString())
+			applyLabResult(metric, readings[0])
+			check.Disputed++
+			check.Corrected++
+		default:
+			metric.Confidence = confidenceDisputed
+			metric.ValueNote = fmt.Sprintf("The report prints %s several times, never as %s; check the original report",
+				metric.Name, metric.GetValueAsString())
+			check.Disputed++
+		}
+	}
+	return check
+}
+
+// anyReadingAgrees reports whether one of the printed readings is value
+func anyReadingAgrees(readings []LabResult, value float64) bool {
+	for _, reading := range readings {
+		if valuesAgree(value, reading.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyLabResult replaces a metric's value, unit, range, and status with the printed result's
+func applyLabResult(metric *HealthMetric, result LabResult) {
+	metric.Value = result.Value
+	if result.Unit != "" {
+		metric.Unit, metric.UnitInferred, metric.UnitNote = result.Unit, false, ""
+	}
+	metric.RangeMin, metric.RangeMax = 0, 0
+	if result.RangeMin != nil {
+		metric.RangeMin = *result.RangeMin
+	}
+	if result.RangeMax != nil {
+		metric.RangeMax = *result.RangeMax
+	}
+	metric.LowConfidence = !result.HasRange()
+	if result.HasRange() || result.Flagged {
+		metric.Status, metric.Score = labResultStatus(result)
+	}
+}
+
+// labResultStatus judges a printed result by its range and flag alone
+func labResultStatus(result LabResult) (string, float64) {
+	if result.OutOfRange() {
+		return "warning", 60
+	}
+	return "normal", 85
+}
+
+// metricNumber reads a metric value the model gave as a number or numeric text
+func metricNumber(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case string:
+		return parseNumericString(v)
+	}
+	return 0, false
+}
+
+// valuesAgree reports whether two readings of a value are the same number
+func valuesAgree(a, b float64) bool {
+	return math.Abs(a-b) <= valueTolerance*math.Max(math.Abs(a), math.Abs(b))
+}
+
+// unitSuffix formats a unit to follow a value, or nothing when there is none
+func unitSuffix(unit string) string {
+	if unit == "" {
+		return ""
+	}
+	return " " + unit
+}
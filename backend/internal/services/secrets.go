@@ -0,0 +1,438 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+)
+
+// gcpMetadataTokenURL issues access tokens to the service account of the GCE or Cloud Run instance
+const gcpMetadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+// SecretSource reads the application's secrets from a secrets manager
+type SecretSource interface {
+	Name() string
+	// Fetch returns the secrets keyed by the environment variable names they replace
+	Fetch(ctx context.Context) (map[string]string, error)
+}
+
+// NewSecretSource returns the source selected by SECRETS_PROVIDER, or nil when secrets come from the environment
+// Decision: Each provider is reached over its HTTP API rather than a vendor SDK, like the payment providers
+func NewSecretSource(cfg config.SecretsConfig) (SecretSource, error) {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	client := &http.Client{Timeout: timeout}
+	baseURL := strings.TrimSuffix(cfg.URL, "/")
+
+	switch strings.ToLower(cfg.Provider) {
+	case "", "env", "none":
+		return nil, nil
+	case "vault":
+		if baseURL == "" || cfg.VaultToken == "" || cfg.Path == "" {
+			return nil, fmt.Errorf("VAULT_ADDR, VAULT_TOKEN and SECRETS_PATH are required when SECRETS_PROVIDER is vault")
+		}
+		return &vaultSecretSource{addr: baseURL, token: cfg.VaultToken, path: strings.Trim(cfg.Path, "/"), client: client}, nil
+	case "aws":
+		if cfg.AWSRegion == "" || cfg.AWSAccessKeyID == "" || cfg.AWSSecretKey == "" || cfg.Path == "" {
+			return nil, fmt.Errorf("AWS_REGION, AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and SECRETS_PATH are required when SECRETS_PROVIDER is aws")
+		}
+		if baseURL == "" {
+			baseURL = fmt.Sprintf("https://secretsmanager.%s.amazonaws.com", cfg.AWSRegion)
+		}
+		return &awsSecretSource{
+			endpoint:     baseURL,
+			region:       cfg.AWSRegion,
+			accessKeyID:  cfg.AWSAccessKeyID,
+			secretKey:    cfg.AWSSecretKey,
+			sessionToken: cfg.AWSSessionToken,
+			secretID:     cfg.Path,
+			client:       client,
+		}, nil
+	case "gcp":
+		if cfg.GCPProject == "" || cfg.Path == "" {
+			return nil, fmt.Errorf("SECRETS_GCP_PROJECT and SECRETS_PATH are required when SECRETS_PROVIDER is gcp")
+		}
+		if baseURL == "" {
+			baseURL = "https://secretmanager.googleapis.com"
+		}
+		return &gcpSecretSource{
+			endpoint:    baseURL,
+			project:     cfg.GCPProject,
+			secret:      cfg.Path,
+			accessToken: cfg.GCPAccessToken,
+			tokenURL:    gcpMetadataTokenURL,
+			client:      client,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown secrets provider %q (expected env, vault, aws or gcp)", cfg.Provider)
+	}
+}
+
+// parseSecretBundle reads a JSON object of secret names to string values
+func parseSecretBundle(data []byte) (map[string]string, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("secret is not a JSON object: %w", err)
+	}
+	secrets := make(map[string]string, len(raw))
+	for name, value := range raw {
+		var text string
+		if err := json.Unmarshal(value, &text); err != nil {
+			return nil, fmt.Errorf("secret %s is not a string", name)
+		}
+		secrets[name] = text
+	}
+	return secrets, nil
+}
+
+// readSecretResponse returns the body of a successful provider response
+func readSecretResponse(resp *http.Response, provider string) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %d: %s", provider, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+// vaultSecretSource reads a HashiCorp Vault KV secret
+type vaultSecretSource struct {
+	addr   string
+	token  string
+	path   string // e.g. secret/data/medreport for KV v2
+	client *http.Client
+}
+
+func (vs *vaultSecretSource) Name() string { return "vault" }
+
+func (vs *vaultSecretSource) Fetch(ctx context.Context) (map[string]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, vs.addr+"/v1/"+vs.path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", vs.token)
+
+	resp, err := vs.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := readSecretResponse(resp, "vault")
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse vault response: %w", err)
+	}
+	// Decision: KV v2 nests the values under data.data next to their metadata; KV v1 returns them as data
+	var v2 struct {
+		Data     json.RawMessage `json:"data"`
+		Metadata json.RawMessage `json:"metadata"`
+	}
+	if err := json.Unmarshal(response.Data, &v2); err == nil && v2.Data != nil && v2.Metadata != nil {
+		return parseSecretBundle(v2.Data)
+	}
+	return parseSecretBundle(response.Data)
+}
+
+// awsSecretSource reads an AWS Secrets Manager secret, signing with Signature Version 4
+type awsSecretSource struct {
+	endpoint     string
+	region       string
+	accessKeyID  string
+	secretKey    string
+	sessionToken string
+	secretID     string
+	client       *http.Client
+}
+
+func (as *awsSecretSource) Name() string { return "aws" }
+
+func (as *awsSecretSource) Fetch(ctx context.Context) (map[string]string, error) {
+	payload, _ := json.Marshal(map[string]string{"SecretId": as.secretID})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, as.endpoint+"/", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	as.sign(req, payload, time.Now().UTC())
+
+	resp, err := as.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := readSecretResponse(resp, "aws secrets manager")
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse secrets manager response: %w", err)
+	}
+	if response.SecretString == "" {
+		return nil, fmt.Errorf("secret %s has no SecretString; binary secrets aren't supported", as.secretID)
+	}
+	return parseSecretBundle([]byte(response.SecretString))
+}
+
+// sign adds the Signature Version 4 headers for a Secrets Manager request
+func (as *awsSecretSource) sign(req *http.Request, payload []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	if as.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", as.sessionToken)
+	}
+
+	headers := []string{"content-type", "host", "x-amz-date", "x-amz-target"}
+	if as.sessionToken != "" {
+		headers = []string{"content-type", "host", "x-amz-date", "x-amz-security-token", "x-amz-target"}
+	}
+	var canonicalHeaders strings.Builder
+	for _, name := range headers {
+		value := req.Header.Get(name)
+		if name == "host" {
+			value = req.URL.Host
+		}
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", name, strings.TrimSpace(value))
+	}
+	signedHeaders := strings.Join(headers, ";")
+
+	payloadHash := sha256.Sum256(payload)
+	canonicalRequest := strings.Join([]string{req.Method, "/", "", canonicalHeaders.String(), signedHeaders,
+		hex.EncodeToString(payloadHash[:])}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	scope := fmt.Sprintf("%s/%s/secretsmanager/aws4_request", day, as.region)
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, hex.EncodeToString(requestHash[:])}, "\n")
+
+	key := []byte("AWS4" + as.secretKey)
+	for _, part := range []string{day, as.region, "secretsmanager", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		as.accessKeyID, scope, signedHeaders, signature))
+}
+
+// hmacSHA256 returns HMAC-SHA256(key, data)
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// gcpSecretSource reads the latest version of a Google Cloud Secret Manager secret
+type gcpSecretSource struct {
+	endpoint    string
+	project     string
+	secret      string
+	accessToken string // Empty asks tokenURL for the instance's service account token
+	tokenURL    string
+	client      *http.Client
+}
+
+func (gs *gcpSecretSource) Name() string { return "gcp" }
+
+func (gs *gcpSecretSource) Fetch(ctx context.Context) (map[string]string, error) {
+	token, err := gs.token(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get a GCP access token: %w", err)
+	}
+
+	secretURL := fmt.Sprintf("%s/v1/projects/%s/secrets/%s/versions/latest:access", gs.endpoint,
+		url.PathEscape(gs.project), url.PathEscape(gs.secret))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, secretURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := gs.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := readSecretResponse(resp, "gcp secret manager")
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse secret manager response: %w", err)
+	}
+	data, err := base64.StdEncoding.DecodeString(response.Payload.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode secret payload: %w", err)
+	}
+	return parseSecretBundle(data)
+}
+
+// token returns the configured access token, or asks the metadata server for one
+// Decision: Metadata tokens outlive the refresh interval, but one is requested per fetch so none is ever
+// used past its expiry
+func (gs *gcpSecretSource) token(ctx context.Context) (string, error) {
+	if gs.accessToken != "" {
+		return gs.accessToken, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, gs.tokenURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := gs.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := readSecretResponse(resp, "gcp metadata server")
+	if err != nil {
+		return "", err
+	}
+
+	var response struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil || response.AccessToken == "" {
+		return "", fmt.Errorf("metadata server returned no access token")
+	}
+	return response.AccessToken, nil
+}
+
+// SecretStore caches the secrets a SecretSource returns and re-reads them to pick up rotations
+// Decision: Lookups only read the cache, so an unreachable secrets manager never slows a request; a failed
+// refresh keeps the last values read
+type SecretStore struct {
+	source   SecretSource
+	interval time.Duration
+
+	mu       sync.RWMutex
+	values   map[string]string
+	watchers map[string][]func(value string)
+}
+
+// NewSecretStore creates a store that re-reads source every interval once Run is started
+func NewSecretStore(source SecretSource, interval time.Duration) *SecretStore {
+	return &SecretStore{source: source, interval: interval, watchers: make(map[string][]func(string))}
+}
+
+// LoadSecrets reads the secrets provider configured in cfg.Secrets and applies its values to cfg
+// Returns nil when secrets come from the environment
+func LoadSecrets(cfg *config.Config) (*SecretStore, error) {
+	source, err := NewSecretSource(cfg.Secrets)
+	if err != nil || source == nil {
+		return nil, err
+	}
+
+	store := NewSecretStore(source, cfg.Secrets.RefreshInterval)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Secrets.Timeout+time.Second)
+	defer cancel()
+	if err := store.Refresh(ctx); err != nil {
+		return nil, fmt.Errorf("failed to read secrets from %s: %w", source.Name(), err)
+	}
+
+	applied := cfg.ApplySecrets(store.Lookup)
+	slog.Info("Loaded secrets", "count", len(applied), "source", source.Name(), "names", strings.Join(applied, ","))
+	return store, nil
+}
+
+// Lookup returns a cached secret
+func (ss *SecretStore) Lookup(name string) (string, bool) {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+	value, ok := ss.values[name]
+	return value, ok
+}
+
+// OnRotate calls fn with the new value whenever a refresh finds the secret changed
+// Decision: Only consumers that can switch keys while running register; the rest read their secret at startup
+// and pick up a rotation on restart
+func (ss *SecretStore) OnRotate(name string, fn func(value string)) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.watchers[name] = append(ss.watchers[name], fn)
+}
+
+// Refresh re-reads the secrets and notifies the watchers of those that changed
+func (ss *SecretStore) Refresh(ctx context.Context) error {
+	values, err := ss.source.Fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	ss.mu.Lock()
+	previous := ss.values
+	ss.values = values
+	var notify []func()
+	if previous != nil {
+		for name, fns := range ss.watchers {
+			value, ok := values[name]
+			if !ok || value == "" || value == previous[name] {
+				continue
+			}
+			slog.Info("Secret was rotated", "name", name, "source", ss.source.Name())
+			for _, fn := range fns {
+				notify = append(notify, func() { fn(value) })
+			}
+		}
+	}
+	ss.mu.Unlock()
+
+	for _, fn := range notify {
+		fn()
+	}
+	return nil
+}
+
+// Run refreshes the secrets every interval until ctx is cancelled; a zero interval returns at once
+func (ss *SecretStore) Run(ctx context.Context) {
+	if ss.interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(ss.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := ss.Refresh(ctx); err != nil {
+			slog.Error("Failed to refresh secrets, keeping the cached values", "source", ss.source.Name(), "error", err)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+const (
+	sessionTouchInterval = time.Minute // How stale last_seen_at may get before a request updates it
+	maxSessionUserAgent  = 255
+)
+
+// SessionService limits how many sessions a user may have signed in at once
+// Decision: Sessions are rows rather than token state so the oldest can be signed out when a new one
+// starts; every session token names its row in jti and is refused once the row is revoked or gone
+type SessionService struct {
+	repo        models.SessionRepository
+	maxSessions int
+}
+
+// NewSessionService creates a session service allowing maxSessions active sessions per user
+func NewSessionService(repo models.SessionRepository, maxSessions int) *SessionService {
+	return &SessionService{repo: repo, maxSessions: maxSessions}
+}
+
+// Limit is the number of sessions a user may have active at once
+func (ss *SessionService) Limit() int {
+	return ss.maxSessions
+}
+
+// Start records a sign-in whose tokens carry tokenID and expire at expiresAt, then signs out the
+// user's oldest sessions beyond the limit
+func (ss *SessionService) Start(userID int, tokenID, userAgent, ipAddress string, expiresAt time.Time) error {
+	now := time.Now()
+	if err := ss.repo.DeleteExpired(userID, now); err != nil {
+		slog.Warn("Failed to delete expired sessions", "user_id", userID, "error", err)
+	}
+
+	if runes := []rune(userAgent); len(runes) > maxSessionUserAgent {
+		userAgent = string(runes[:maxSessionUserAgent])
+	}
+	session := &models.Session{UserID: userID, TokenID: tokenID, UserAgent: userAgent, IPAddress: ipAddress, ExpiresAt: expiresAt}
+	if err := ss.repo.Create(session); err != nil {
+		return errors.ErrDatabaseConnection
+	}
+
+	sessions, err := ss.repo.ListByUser(userID, now)
+	if err != nil {
+		return errors.ErrDatabaseConnection
+	}
+	active := 0
+	for _, existing := range sessions {
+		if !existing.Active(now) {
+			continue
+		}
+		// Decision: Newest first, so the sessions past the limit are the oldest sign-ins
+		if active++; active > ss.maxSessions {
+			if _, err := ss.repo.Revoke(existing.ID, userID, models.SessionEndedLimit, now); err != nil {
+				return errors.ErrDatabaseConnection
+			}
+		}
+	}
+	return nil
+}
+
+// Check returns the session tokenID names if it's still active, recording the request on it
+func (ss *SessionService) Check(tokenID string) (*models.Session, error) {
+	// Decision: Tokens issued before the limit was configured carry no session and are refused,
+	// otherwise they would sit outside the limit until they expire
+	if tokenID == "" {
+		return nil, errors.ErrInvalidToken
+	}
+	session, err := ss.repo.GetByTokenID(tokenID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	now := time.Now()
+	if session == nil || !now.Before(session.ExpiresAt) {
+		return nil, errors.ErrInvalidToken
+	}
+	if session.RevokedAt != nil {
+		if session.RevokedReason == models.SessionEndedLimit {
+			return nil, errors.ErrSessionLimitReached
+		}
+		return nil, errors.ErrSessionEnded
+	}
+
+	if now.Sub(session.LastSeenAt) >= sessionTouchInterval {
+		if err := ss.repo.Touch(session.ID, now, session.ExpiresAt); err != nil {
+			slog.Warn("Failed to record use of session", "session_id", session.ID, "error", err)
+		}
+	}
+	return session, nil
+}
+
+// Extend moves the session's expiry to that of a refreshed token, or of its new refresh token
+// Decision: Never earlier, so a short-lived access token renewed under sliding expiration doesn't cut short a
+// session that lasts as long as its refresh token
+func (ss *SessionService) Extend(tokenID string, expiresAt time.Time) error {
+	session, err := ss.repo.GetByTokenID(tokenID)
+	if err != nil {
+		return errors.ErrDatabaseConnection
+	}
+	if session == nil {
+		return errors.ErrInvalidToken
+	}
+	if expiresAt.Before(session.ExpiresAt) {
+		expiresAt = session.ExpiresAt
+	}
+	if err := ss.repo.Touch(session.ID, time.Now(), expiresAt); err != nil {
+		return errors.ErrDatabaseConnection
+	}
+	return nil
+}
+
+// End signs out the session tokenID names, e.g. on logout; unknown sessions are ignored
+func (ss *SessionService) End(tokenID, reason string) error {
+	session, err := ss.repo.GetByTokenID(tokenID)
+	if err != nil {
+		return errors.ErrDatabaseConnection
+	}
+	if session == nil {
+		return nil
+	}
+	if _, err := ss.repo.Revoke(session.ID, session.UserID, reason, time.Now()); err != nil {
+		return errors.ErrDatabaseConnection
+	}
+	return nil
+}
+
+// List returns the user's active sessions, newest first
+func (ss *SessionService) List(userID int) ([]*models.Session, error) {
+	now := time.Now()
+	sessions, err := ss.repo.ListByUser(userID, now)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	active := make([]*models.Session, 0, len(sessions))
+	for _, session := range sessions {
+		if session.Active(now) {
+			active = append(active, session)
+		}
+	}
+	return active, nil
+}
+
+// Revoke signs out one of the user's sessions
+func (ss *SessionService) Revoke(userID, sessionID int, reason string) error {
+	revoked, err := ss.repo.Revoke(sessionID, userID, reason, time.Now())
+	if err != nil {
+		return errors.ErrDatabaseConnection
+	}
+	if !revoked {
+		return errors.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+// sharePINPattern accepts the 4-6 digit PINs owners pass on by phone or in person
+var sharePINPattern = regexp.MustCompile(`^[0-9]{4,6}$`)
+
+// ShareService creates read-only report links and checks them when opened
+// Decision: A link alone is a bearer secret that leaks through forwarded emails and chat
+// previews; the optional PIN travels by another channel, so both are needed to read the report
+type ShareService struct {
+	shareRepo        models.ShareLinkRepository
+	reportRepo       models.ReportRepository
+	notificationRepo models.NotificationRepository
+	passwordService  *PasswordService
+
+	defaultTTL  time.Duration
+	maxTTL      time.Duration
+	maxAttempts int
+}
+
+// NewShareService creates a new share service
+func NewShareService(
+	shareRepo models.ShareLinkRepository,
+	reportRepo models.ReportRepository,
+	notificationRepo models.NotificationRepository,
+	passwordService *PasswordService,
+	cfg config.ShareConfig,
+) *ShareService {
+	defaultTTL := cfg.DefaultTTL
+	if defaultTTL <= 0 {
+		defaultTTL = 7 * 24 * time.Hour
+	}
+	maxTTL := max(cfg.MaxTTL, defaultTTL)
+	maxAttempts := cfg.MaxPINAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+
+	return &ShareService{
+		shareRepo:        shareRepo,
+		reportRepo:       reportRepo,
+		notificationRepo: notificationRepo,
+		passwordService:  passwordService,
+		defaultTTL:       defaultTTL,
+		maxTTL:           maxTTL,
+		maxAttempts:      maxAttempts,
+	}
+}
+
+// CreateLink makes a link to a processed report; pin may be empty, and ttl 0 uses the default lifetime
+// The returned token is shown once and can't be recovered later
+func (ss *ShareService) CreateLink(userID, reportID int, pin string, ttl time.Duration) (*models.ShareLink, string, error) {
+	report, err := getOwnedReport(ss.reportRepo, userID, reportID)
+	if err != nil {
+		return nil, "", err
+	}
+	if report.ProcessingStatus != "completed" {
+		return nil, "", errors.ErrReportNotProcessed
+	}
+
+	if ttl == 0 {
+		ttl = ss.defaultTTL
+	}
+	if ttl < 0 || ttl > ss.maxTTL {
+		return nil, "", errors.NewValidationError(fmt.Sprintf("Links can last at most %d hours", int(ss.maxTTL.Hours())))
+	}
+
+	link := &models.ShareLink{
+		ReportID:  reportID,
+		CreatedBy: userID,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if pin != "" {
+		if !sharePINPattern.MatchString(pin) {
+			return nil, "", errors.NewValidationError("PIN must be 4 to 6 digits")
+		}
+		if link.PINHash, err = ss.passwordService.HashPassword(pin); err != nil {
+			return nil, "", errors.ErrDatabaseConnection
+		}
+	}
+
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return nil, "", errors.ErrDatabaseConnection
+	}
+	token := base64.RawURLEncoding.EncodeToString(tokenBytes)
+	link.TokenHash = hashShareToken(token)
+
+	if err := ss.shareRepo.Create(link); err != nil {
+		return nil, "", errors.ErrDatabaseConnection
+	}
+	return link, token, nil
+}
+
+// ListLinks returns every link made for a report the user owns
+func (ss *ShareService) ListLinks(userID, reportID int) ([]*models.ShareLink, error) {
+	if _, err := getOwnedReport(ss.reportRepo, userID, reportID); err != nil {
+		return nil, err
+	}
+
+	links, err := ss.shareRepo.ListByReport(reportID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	return links, nil
+}
+
+// RevokeLink disables a link to a report the user owns
+func (ss *ShareService) RevokeLink(userID, reportID, linkID int) error {
+	if _, err := getOwnedReport(ss.reportRepo, userID, reportID); err != nil {
+		return err
+	}
+
+	link, err := ss.shareRepo.GetByID(linkID)
+	if err != nil {
+		return errors.ErrDatabaseConnection
+	}
+	if link == nil || link.ReportID != reportID {
+		return errors.ErrRecordNotFound
+	}
+
+	if err := ss.shareRepo.Revoke(linkID); err != nil {
+		return errors.ErrDatabaseConnection
+	}
+	return nil
+}
+
+// OpenLink returns the shared report once the token and, if required, the PIN check out
+func (ss *ShareService) OpenLink(token, pin string) (*models.Report, *models.ShareLink, error) {
+	link, err := ss.shareRepo.GetByTokenHash(hashShareToken(token))
+	if err != nil {
+		return nil, nil, errors.ErrDatabaseConnection
+	}
+	// Decision: Unknown, expired, and revoked links look the same so tokens can't be probed
+	if link == nil || link.RevokedAt != nil || time.Now().After(link.ExpiresAt) {
+		return nil, nil, errors.ErrShareLinkUnavailable
+	}
+	if link.LockedAt != nil {
+		return nil, nil, errors.ErrShareLinkLocked
+	}
+
+	report, err := ss.reportRepo.GetByID(link.ReportID)
+	if err != nil {
+		return nil, nil, errors.ErrDatabaseConnection
+	}
+	// Decision: Links die with a transfer; the new owner never agreed to the old owner's shares
+	if report == nil || report.UserID != link.CreatedBy {
+		return nil, nil, errors.ErrShareLinkUnavailable
+	}
+
+	if link.RequiresPIN() {
+		if pin == "" {
+			return nil, nil, errors.ErrSharePINRequired
+		}
+		if err := ss.checkPIN(link, report, pin); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := ss.shareRepo.RecordView(link.ID); err != nil {
+		slog.Warn("Failed to record view of share link", "link_id", link.ID, "error", err)
+	}
+	return report, link, nil
+}
+
+// checkPIN compares pin with the link's, locking the link once the attempt limit is reached
+func (ss *ShareService) checkPIN(link *models.ShareLink, report *models.Report, pin string) error {
+	attempts, err := ss.shareRepo.RecordPINAttempt(link.ID)
+	if err != nil {
+		return errors.ErrDatabaseConnection
+	}
+	if attempts > ss.maxAttempts {
+		return errors.ErrShareLinkLocked
+	}
+
+	if ss.passwordService.CheckPassword(pin, link.PINHash) {
+		return nil
+	}
+	if attempts < ss.maxAttempts {
+		return errors.ErrSharePINInvalid
+	}
+
+	if err := ss.shareRepo.Lock(link.ID); err != nil {
+		return errors.ErrDatabaseConnection
+	}
+	// Decision: Tell the owner, since repeated wrong PINs suggest the link reached someone it shouldn't have
+	notification := &models.Notification{
+		UserID: link.CreatedBy,
+		Kind:   models.NotificationShareLinkLocked,
+		Message: fmt.Sprintf("A share link for %q was locked after %d incorrect PIN attempts. "+
+			"Create a new link if you still want to share this report.", reportDisplayName(report), ss.maxAttempts),
+	}
+	if err := ss.notificationRepo.Create(notification); err != nil {
+		slog.Error("Failed to notify of locked share link", "user_id", link.CreatedBy, "link_id", link.ID, "error", err)
+	}
+	return errors.ErrShareLinkLocked
+}
+
+// hashShareToken is the stored form of a link token
+func hashShareToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// reportDisplayName is the user's title for a report, or its filename
+func reportDisplayName(report *models.Report) string {
+	if report.Title != "" {
+		return report.Title
+	}
+	return report.OriginalFilename
+}
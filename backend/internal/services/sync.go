@@ -0,0 +1,121 @@
+package services
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+// SyncChanges is everything that changed in a user's account since a checkpoint
+type SyncChanges struct {
+	Checkpoint      time.Time // Pass as since on the next sync
+	FullSync        bool
+	Reports         []*models.Report
+	Analyses        map[int]*AnalysisResult // By report ID, for the completed reports among Reports
+	ChatMessages    []*models.ChatMessage
+	DeletedReports  []int // Their chat messages are gone too
+	DeletedMessages []int
+}
+
+// syncRecord identifies a record across kinds
+type syncRecord struct {
+	kind string
+	id   int
+}
+
+// SyncService tells offline-first clients what changed since they last synced
+// Decision: Changes are found from updated_at and tombstones rather than a change log, so every
+// existing write path is covered without having to record each one
+type SyncService struct {
+	reportRepo    models.ReportRepository
+	chatRepo      models.ChatMessageRepository
+	tombstoneRepo models.TombstoneRepository
+}
+
+// NewSyncService creates a new sync service
+func NewSyncService(
+	reportRepo models.ReportRepository,
+	chatRepo models.ChatMessageRepository,
+	tombstoneRepo models.TombstoneRepository,
+) *SyncService {
+	return &SyncService{
+		reportRepo:    reportRepo,
+		chatRepo:      chatRepo,
+		tombstoneRepo: tombstoneRepo,
+	}
+}
+
+// Changes returns what changed in the user's account at or after since; nil since returns everything
+// Decision: The checkpoint is taken before reading and compared inclusively at whole seconds, the precision
+// of the stored timestamps, so a write landing during the sync is sent again next time rather than missed.
+// Clients must therefore apply changes idempotently
+func (ss *SyncService) Changes(userID int, since *time.Time) (*SyncChanges, error) {
+	changes := &SyncChanges{
+		Checkpoint: time.Now().UTC().Truncate(time.Second),
+		FullSync:   since == nil,
+		Analyses:   make(map[int]*AnalysisResult),
+	}
+	var from time.Time
+	if since != nil {
+		from = *since
+	}
+
+	reports, err := ss.reportRepo.ListChangedSince(userID, from)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	messages, err := ss.chatRepo.ListChangedSince(userID, from)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	changes.Reports = reports
+	changes.ChatMessages = messages
+
+	for _, report := range reports {
+		if report.ProcessingStatus != "completed" {
+			continue
+		}
+		analysis, err := ParseStoredAnalysis(report.SimplifiedSummary)
+		if err != nil {
+			slog.Warn("Sync skipped an unreadable analysis", "report_id", report.ID, "error", err)
+			continue
+		}
+		changes.Analyses[report.ID] = analysis
+	}
+
+	// A full sync replaces everything the client holds, so there is nothing to delete
+	if since == nil {
+		return changes, nil
+	}
+	tombstones, err := ss.tombstoneRepo.ListSince(userID, from)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+
+	// Decision: A record that left and came back (a report transferred away and returned) is sent as
+	// changed, not deleted, so applying the deletions after the changes can't lose it
+	present := make(map[syncRecord]bool, len(reports)+len(messages))
+	for _, report := range reports {
+		present[syncRecord{models.TombstoneReport, report.ID}] = true
+	}
+	for _, message := range messages {
+		present[syncRecord{models.TombstoneChatMessage, message.ID}] = true
+	}
+	seen := make(map[syncRecord]bool, len(tombstones))
+	for _, tombstone := range tombstones {
+		key := syncRecord{tombstone.Kind, tombstone.RecordID}
+		if present[key] || seen[key] {
+			continue
+		}
+		seen[key] = true
+		switch tombstone.Kind {
+		case models.TombstoneReport:
+			changes.DeletedReports = append(changes.DeletedReports, tombstone.RecordID)
+		case models.TombstoneChatMessage:
+			changes.DeletedMessages = append(changes.DeletedMessages, tombstone.RecordID)
+		}
+	}
+	return changes, nil
+}
@@ -0,0 +1,558 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ledongthuc/pdf"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+)
+
+// Extraction is the text read from a report file and how trustworthy it looks
+type Extraction struct {
+	Text     string
+	Pages    int    // 0 for formats without pages, like plain text and DOCX
+	Method   string // Name of the Extractor that produced the text
+	Language string // ISO 639-1 code of the script the text is mostly written in; see DetectReportLanguage
+	Quality  ExtractionQuality
+}
+
+// ExtractionQuality scores extracted text so scans and broken encodings are caught before analysis
+type ExtractionQuality struct {
+	Characters     int     // Non-whitespace characters
+	CharsPerPage   float64 // Equal to Characters for formats without pages
+	GibberishRatio float64 // Share of words made mostly of symbols, control, or replacement characters
+	Score          float64 // 0-1; text density times the share of readable words
+}
+
+// Extractor reads the text of one kind of report file
+type Extractor interface {
+	Extract(ctx context.Context, filePath string) (*Extraction, error)
+	Name() string
+}
+
+// UnreadableReportError means no extractor produced usable text
+// Reason is written for the patient and stored as the report's failure message
+type UnreadableReportError struct {
+	Reason  string
+	Quality ExtractionQuality
+}
+
+func (e *UnreadableReportError) Error() string {
+	return fmt.Sprintf("report is unreadable (quality %.2f, %.0f characters per page, %.0f%% gibberish)",
+		e.Quality.Score, e.Quality.CharsPerPage, e.Quality.GibberishRatio*100)
+}
+
+// ExtractionError means the report file couldn't be opened or parsed at all
+type ExtractionError struct {
+	Err error
+}
+
+func (e *ExtractionError) Error() string {
+	return "failed to extract text from file: " + e.Err.Error()
+}
+
+func (e *ExtractionError) Unwrap() error {
+	return e.Err
+}
+
+// User-facing explanations for unreadable reports
+const (
+	unreadableScanReason = "We couldn't read enough text from this report. It looks like a scan or photo; " +
+		"please upload a text-based PDF or a sharper, well-lit image of each page."
+	unreadableTextReason = "We couldn't read the text in this report. The file may be damaged or use an unsupported encoding; " +
+		"please export it again as a PDF or upload a photo of the printed report."
+	unreadableDocReason = "Older Word documents (.doc) can't be read. Please save the report as .docx or PDF and upload it again."
+)
+
+// TextExtractor picks an Extractor for each file type, scores its output, and falls back to OCR for scans
+// Decision: Quality is judged here rather than by the model, so an empty scan never costs an LLM call
+type TextExtractor struct {
+	extractors        map[string]Extractor // By lowercase file extension
+	ocr               Extractor            // Nil when OCR_COMMAND is unset
+	minCharsPerPage   float64
+	maxGibberishRatio float64
+}
+
+// NewTextExtractor creates the extraction pipeline; zero thresholds take the defaults
+func NewTextExtractor(cfg config.ExtractionConfig) *TextExtractor {
+	te := &TextExtractor{
+		extractors: map[string]Extractor{
+			".txt":  plainTextExtractor{},
+			".pdf":  pdfTextExtractor{},
+			".docx": docxTextExtractor{},
+		},
+		minCharsPerPage:   cfg.MinCharsPerPage,
+		maxGibberishRatio: cfg.MaxGibberishRatio,
+	}
+	if te.minCharsPerPage <= 0 {
+		te.minCharsPerPage = 200
+	}
+	if te.maxGibberishRatio <= 0 {
+		te.maxGibberishRatio = 0.3
+	}
+	if cfg.OCRCommand != "" {
+		te.ocr = newTesseractExtractor(cfg)
+	}
+	return te
+}
+
+// Extract returns the best text available for a report file
+// Returns *UnreadableReportError when the file has no usable text even after OCR
+func (te *TextExtractor) Extract(ctx context.Context, filePath string) (*Extraction, error) {
+	ext := strings.ToLower(filepath.Ext(filePath))
+
+	if ext == ".doc" {
+		return nil, &UnreadableReportError{Reason: unreadableDocReason}
+	}
+	if isImageExtension(ext) {
+		if te.ocr == nil {
+			return nil, &UnreadableReportError{Reason: "Photos of reports can't be read on this server yet. " +
+				"Please upload the report as a PDF or text file."}
+		}
+		return te.accept(te.run(ctx, te.ocr, filePath))
+	}
+
+	extractor, ok := te.extractors[ext]
+	if !ok {
+		return nil, fmt.Errorf("unsupported file type: %s", ext)
+	}
+
+	extraction, err := te.run(ctx, extractor, filePath)
+	if err == nil && te.acceptable(extraction) {
+		return extraction, nil
+	}
+
+	// Decision: Only PDFs get a second try; text and DOCX files have no image layer for OCR to read
+	if ext == ".pdf" && te.ocr != nil {
+		ocrExtraction, ocrErr := te.run(ctx, te.ocr, filePath)
+		if ocrErr != nil {
+			slog.WarnContext(ctx, "OCR fallback failed", "error", ocrErr)
+		} else if err != nil || ocrExtraction.Quality.Score > extraction.Quality.Score {
+			extraction, err = ocrExtraction, nil
+		}
+	}
+	return te.accept(extraction, err)
+}
+
+// run extracts with one extractor and scores the result
+func (te *TextExtractor) run(ctx context.Context, extractor Extractor, filePath string) (*Extraction, error) {
+	extraction, err := extractor.Extract(ctx, filePath)
+	if err != nil {
+		return nil, fmt.Errorf("%s extraction failed: %w", extractor.Name(), err)
+	}
+	extraction.Method = extractor.Name()
+	extraction.Quality = ScoreExtraction(extraction.Text, extraction.Pages)
+	extraction.Language = DetectReportLanguage(extraction.Text)
+	slog.DebugContext(ctx, "Extracted report text", "characters", extraction.Quality.Characters, "method", extraction.Method,
+		"quality", extraction.Quality.Score, "language", extraction.Language)
+	return extraction, nil
+}
+
+// accept turns a low-quality extraction into an UnreadableReportError with the matching explanation
+func (te *TextExtractor) accept(extraction *Extraction, err error) (*Extraction, error) {
+	if err != nil {
+		return nil, err
+	}
+	if te.acceptable(extraction) {
+		return extraction, nil
+	}
+
+	reason := unreadableTextReason
+	if extraction.Quality.GibberishRatio <= te.maxGibberishRatio {
+		reason = unreadableScanReason
+	}
+	return nil, &UnreadableReportError{Reason: reason, Quality: extraction.Quality}
+}
+
+// acceptable reports whether text is worth sending to the model
+// Decision: The density check applies only to paged formats; a short lab slip typed into a .txt is still a report
+func (te *TextExtractor) acceptable(extraction *Extraction) bool {
+	quality := extraction.Quality
+	if quality.Characters == 0 || quality.GibberishRatio > te.maxGibberishRatio {
+		return false
+	}
+	return extraction.Pages == 0 || quality.CharsPerPage >= te.minCharsPerPage
+}
+
+// ScoreExtraction measures text density and the share of unreadable words
+func ScoreExtraction(text string, pages int) ExtractionQuality {
+	quality := ExtractionQuality{}
+	for _, r := range text {
+		if !unicode.IsSpace(r) {
+			quality.Characters++
+		}
+	}
+
+	quality.CharsPerPage = float64(quality.Characters)
+	if pages > 0 {
+		quality.CharsPerPage /= float64(pages)
+	}
+
+	words := strings.Fields(text)
+	if len(words) > 0 {
+		gibberish := 0
+		for _, word := range words {
+			if isGibberishWord(word) {
+				gibberish++
+			}
+		}
+		quality.GibberishRatio = float64(gibberish) / float64(len(words))
+	}
+
+	// Decision: Density saturates at 500 characters per page, about a third of a typical lab report page
+	density := min(quality.CharsPerPage/500, 1)
+	quality.Score = density * (1 - quality.GibberishRatio)
+	return quality
+}
+
+// isGibberishWord flags words a text layer or OCR engine garbled
+// Abbreviations like "mg/dL" and values like "13.5" are readable; "�§¤" and long unbroken runs are not
+func isGibberishWord(word string) bool {
+	if utf8.RuneCountInString(word) > 40 {
+		return true
+	}
+
+	readable, total := 0, 0
+	for _, r := range word {
+		total++
+		switch {
+		case r == utf8.RuneError, unicode.Is(unicode.Co, r), unicode.IsControl(r):
+			return true
+		// Decision: Combining marks count as readable; Kannada, Devanagari, and Tamil write most vowels with them
+		case unicode.IsLetter(r), unicode.IsDigit(r), unicode.IsMark(r):
+			readable++
+		}
+	}
+	// Decision: Lone punctuation like "-" or ":" is common in lab tables and isn't counted against the text
+	if total == 1 {
+		return false
+	}
+	return readable*2 < total
+}
+
+func isImageExtension(ext string) bool {
+	return ext == ".png" || ext == ".jpg" || ext == ".jpeg"
+}
+
+// plainTextExtractor reads .txt files
+type plainTextExtractor struct{}
+
+func (plainTextExtractor) Name() string { return "text" }
+
+func (plainTextExtractor) Extract(ctx context.Context, filePath string) (*Extraction, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return &Extraction{Text: string(content)}, nil
+}
+
+// pdfTextExtractor reads the text layer of PDFs using ledongthuc/pdf
+type pdfTextExtractor struct{}
+
+func (pdfTextExtractor) Name() string { return "pdf" }
+
+func (pdfTextExtractor) Extract(ctx context.Context, filePath string) (*Extraction, error) {
+	f, r, err := pdf.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PDF: %w", err)
+	}
+	defer f.Close()
+
+	var textContent strings.Builder
+	totalPages := r.NumPage()
+
+	// Extract text from all pages
+	for pageNum := 1; pageNum <= totalPages; pageNum++ {
+		page := r.Page(pageNum)
+		if page.V.IsNull() {
+			continue
+		}
+
+		content, err := page.GetPlainText(nil)
+		if err != nil {
+			// Log error but continue with other pages
+			slog.WarnContext(ctx, "Failed to extract text from PDF page", "page", pageNum, "error", err)
+			continue
+		}
+
+		textContent.WriteString(content)
+		textContent.WriteString("\n")
+	}
+
+	// Decision: An empty text layer isn't an error here; it is scored as low quality so OCR can take over
+	return &Extraction{Text: textContent.String(), Pages: totalPages}, nil
+}
+
+// maxDOCXPartSize bounds how much XML is decompressed from one part of a .docx, so a small upload
+// can't expand into gigabytes
+const maxDOCXPartSize = 50 << 20
+
+// docxTextExtractor reads paragraphs from word/document.xml inside a .docx archive, with its headers and footers
+// Decision: Labs often print the patient's details and the lab's name in the page header, so headers come
+// before the body and footers after it; a header repeated for first or even pages is read once
+type docxTextExtractor struct{}
+
+func (docxTextExtractor) Name() string { return "docx" }
+
+func (docxTextExtractor) Extract(ctx context.Context, filePath string) (*Extraction, error) {
+	archive, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open DOCX: %w", err)
+	}
+	defer archive.Close()
+
+	var document *zip.File
+	var headers, footers []*zip.File
+	for _, f := range archive.File {
+		switch {
+		case f.Name == "word/document.xml":
+			document = f
+		case isDOCXPart(f.Name, "header"):
+			headers = append(headers, f)
+		case isDOCXPart(f.Name, "footer"):
+			footers = append(footers, f)
+		}
+	}
+	if document == nil {
+		return nil, errors.New("DOCX has no word/document.xml")
+	}
+	// Decision: Sorted by name so header1 comes before header2 whatever order the archive lists them in
+	byName := func(a, b *zip.File) int { return strings.Compare(a.Name, b.Name) }
+	slices.SortFunc(headers, byName)
+	slices.SortFunc(footers, byName)
+
+	var text strings.Builder
+	seen := map[string]bool{}
+	for _, part := range slices.Concat(headers, []*zip.File{document}, footers) {
+		partText, err := docxPartText(part)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read DOCX %s: %w", part.Name, err)
+		}
+		if part != document && (strings.TrimSpace(partText) == "" || seen[partText]) {
+			continue
+		}
+		seen[partText] = true
+		text.WriteString(partText)
+	}
+	return &Extraction{Text: text.String()}, nil
+}
+
+// isDOCXPart reports whether name is a word/header<n>.xml or word/footer<n>.xml part
+func isDOCXPart(name, kind string) bool {
+	rest, ok := strings.CutPrefix(name, "word/"+kind)
+	return ok && strings.HasSuffix(rest, ".xml") && !strings.Contains(rest, "/")
+}
+
+// docxPartText reads the plain text of one part of a .docx
+func docxPartText(part *zip.File) (string, error) {
+	if part.UncompressedSize64 > maxDOCXPartSize {
+		return "", fmt.Errorf("part is larger than %d MB", maxDOCXPartSize>>20)
+	}
+	rc, err := part.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	// Decision: The declared size can lie, so the reader is capped as well
+	limited := &io.LimitedReader{R: rc, N: maxDOCXPartSize + 1}
+	text, err := docxPlainText(limited)
+	if err != nil {
+		return "", err
+	}
+	if limited.N <= 0 {
+		return "", fmt.Errorf("part is larger than %d MB", maxDOCXPartSize>>20)
+	}
+	return text, nil
+}
+
+// docxPlainText collects w:t runs, breaking lines at paragraphs and table rows and tabbing between cells
+func docxPlainText(r io.Reader) (string, error) {
+	decoder := xml.NewDecoder(r)
+	var text strings.Builder
+	inText := false
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return text.String(), nil
+		}
+		if err != nil {
+			return "", err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				text.WriteString("\t")
+			case "noBreakHyphen":
+				text.WriteString("-")
+			case "br", "cr":
+				text.WriteString("\n")
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p", "tr":
+				text.WriteString("\n")
+			case "tc":
+				text.WriteString("\t")
+			}
+		case xml.CharData:
+			if inText {
+				text.Write(t)
+			}
+		}
+	}
+}
+
+// tesseractExtractor runs the tesseract CLI on images, rasterizing PDFs page by page first
+// Decision: Shell out rather than link libtesseract so the server still builds without cgo OCR libraries
+type tesseractExtractor struct {
+	command      string
+	languages    string
+	detectScript bool   // Run script detection first and add the matching regional language pack
+	rasterizer   string // pdftoppm from poppler-utils; empty disables OCR of PDFs
+	timeout      time.Duration
+}
+
+func newTesseractExtractor(cfg config.ExtractionConfig) *tesseractExtractor {
+	timeout := cfg.OCRTimeout
+	if timeout <= 0 {
+		timeout = 2 * time.Minute
+	}
+	languages := cfg.OCRLanguages
+	if languages == "" {
+		languages = "eng"
+	}
+	return &tesseractExtractor{
+		command:      cfg.OCRCommand,
+		languages:    languages,
+		detectScript: cfg.OCRDetectScript,
+		rasterizer:   cfg.PDFRasterizer,
+		timeout:      timeout,
+	}
+}
+
+func (te *tesseractExtractor) Name() string { return "ocr" }
+
+func (te *tesseractExtractor) Extract(ctx context.Context, filePath string) (*Extraction, error) {
+	ctx, cancel := context.WithTimeout(ctx, te.timeout)
+	defer cancel()
+
+	if !strings.EqualFold(filepath.Ext(filePath), ".pdf") {
+		text, err := te.recognize(ctx, filePath)
+		if err != nil {
+			return nil, err
+		}
+		return &Extraction{Text: text, Pages: 1}, nil
+	}
+
+	if te.rasterizer == "" {
+		return nil, errors.New("no PDF rasterizer configured")
+	}
+	pages, err := te.rasterize(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(filepath.Dir(pages[0]))
+
+	var text strings.Builder
+	for _, page := range pages {
+		pageText, err := te.recognize(ctx, page)
+		if err != nil {
+			return nil, err
+		}
+		text.WriteString(pageText)
+		text.WriteString("\n")
+	}
+	return &Extraction{Text: text.String(), Pages: len(pages)}, nil
+}
+
+// recognize runs tesseract on one image and returns its text
+func (te *tesseractExtractor) recognize(ctx context.Context, imagePath string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, te.command, imagePath, "stdout", "-l", te.languagesFor(ctx, imagePath))
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("tesseract: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// languagesFor returns the language packs to recognize an image with
+// Decision: Recognizing with every regional pack at once is slow and confuses similar glyphs, so tesseract's
+// orientation and script detection (--psm 0) picks the one pack to add to the configured languages
+func (te *tesseractExtractor) languagesFor(ctx context.Context, imagePath string) string {
+	if !te.detectScript {
+		return te.languages
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, te.command, imagePath, "stdout", "--psm", "0")
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		// Pages with too little text for detection fail here; the configured languages still apply
+		slog.DebugContext(ctx, "OCR script detection failed", "error", strings.TrimSpace(stderr.String()))
+		return te.languages
+	}
+
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		script, ok := strings.CutPrefix(strings.TrimSpace(line), "Script:")
+		if !ok {
+			continue
+		}
+		language := reportLanguageForScript(strings.TrimSpace(script))
+		if language == nil || slices.Contains(strings.Split(te.languages, "+"), language.Tesseract) {
+			return te.languages
+		}
+		return te.languages + "+" + language.Tesseract
+	}
+	return te.languages
+}
+
+// rasterize renders each PDF page to a PNG in a temporary directory, returned in page order
+func (te *tesseractExtractor) rasterize(ctx context.Context, pdfPath string) ([]string, error) {
+	dir, err := os.MkdirTemp("", "ocr-")
+	if err != nil {
+		return nil, err
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, te.rasterizer, "-r", "300", "-png", pdfPath, filepath.Join(dir, "page"))
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("pdftoppm: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	pages, err := filepath.Glob(filepath.Join(dir, "page-*.png"))
+	if err != nil || len(pages) == 0 {
+		os.RemoveAll(dir)
+		return nil, errors.New("PDF rendered no pages")
+	}
+	// pdftoppm pads page numbers to the same width, so lexical order is page order
+	sort.Strings(pages)
+	return pages, nil
+}
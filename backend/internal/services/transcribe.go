@@ -0,0 +1,218 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/generative-ai-go/genai"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"golang.org/x/text/language"
+	"google.golang.org/api/option"
+)
+
+// whisperURL is OpenAI's transcription endpoint; self-hosted Whisper servers expose the same API
+const whisperURL = "https://api.openai.com/v1/audio/transcriptions"
+
+// audioExtensions maps accepted recording types to the file extension Whisper uses to pick a decoder
+var audioExtensions = map[string]string{
+	"audio/webm": "webm", // Chrome and Firefox MediaRecorder
+	"audio/ogg":  "ogg",
+	"audio/mp4":  "m4a", // Safari MediaRecorder
+	"audio/mpeg": "mp3",
+	"audio/wav":  "wav",
+}
+
+// sniffedAudioTypes maps http.DetectContentType results to the audio type they stand for
+// Decision: Sniff rather than trust the part's Content-Type; browsers label webm audio as video/webm
+var sniffedAudioTypes = map[string]string{
+	"video/webm":      "audio/webm",
+	"audio/webm":      "audio/webm",
+	"application/ogg": "audio/ogg",
+	"audio/ogg":       "audio/ogg",
+	"video/mp4":       "audio/mp4",
+	"audio/mp4":       "audio/mp4",
+	"audio/mpeg":      "audio/mpeg",
+	"audio/wave":      "audio/wav",
+}
+
+// DetectAudioType returns the audio type of a recording, or false if it is not a supported format
+// Sniffing can't recognize MP3 without an ID3 tag, so the declared type is trusted only for MP3
+func DetectAudioType(data []byte, declared string) (string, bool) {
+	sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+	if audioType, ok := sniffedAudioTypes[sniffed]; ok {
+		return audioType, true
+	}
+
+	declared, _, _ = mime.ParseMediaType(declared)
+	if sniffed == "application/octet-stream" && (declared == "audio/mpeg" || declared == "audio/mp3") {
+		return "audio/mpeg", true
+	}
+	return "", false
+}
+
+// Transcriber turns a spoken question into text
+type Transcriber interface {
+	// Transcribe converts audio of the given type; languageTag is an optional BCP 47 hint
+	Transcribe(ctx context.Context, audio []byte, audioType, languageTag string) (string, error)
+	Name() string
+}
+
+// NewTranscriber returns the provider selected by TRANSCRIBE_PROVIDER, or nil when voice questions are disabled
+func NewTranscriber(cfg config.TranscriptionConfig) (Transcriber, error) {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 60 * time.Second
+	}
+
+	switch strings.ToLower(cfg.Provider) {
+	case "", "none":
+		return nil, nil
+	case "whisper":
+		endpoint := cfg.URL
+		if endpoint == "" {
+			endpoint = whisperURL
+			// Decision: Only the hosted API requires a key; self-hosted servers usually run without one
+			if cfg.APIKey == "" {
+				return nil, fmt.Errorf("TRANSCRIBE_API_KEY is required when TRANSCRIBE_PROVIDER is whisper without TRANSCRIBE_URL")
+			}
+		}
+		model := cfg.Model
+		if model == "" {
+			model = "whisper-1"
+		}
+		return &whisperTranscriber{
+			apiKey:   cfg.APIKey,
+			endpoint: endpoint,
+			model:    model,
+			client:   &http.Client{Timeout: timeout},
+		}, nil
+	case "gemini":
+		if cfg.APIKey == "" {
+			return nil, fmt.Errorf("TRANSCRIBE_API_KEY or GEMINI_API_KEY is required when TRANSCRIBE_PROVIDER is gemini")
+		}
+		client, err := genai.NewClient(context.Background(), option.WithAPIKey(cfg.APIKey))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Gemini client: %w", err)
+		}
+		modelName := cfg.Model
+		if modelName == "" {
+			modelName = "gemini-1.5-flash"
+		}
+		model := client.GenerativeModel(modelName)
+		model.SetTemperature(0) // Transcribe, don't paraphrase
+		return &geminiTranscriber{model: model, timeout: timeout}, nil
+	default:
+		return nil, fmt.Errorf("unknown transcription provider %q (expected whisper, gemini or none)", cfg.Provider)
+	}
+}
+
+// whisperTranscriber calls an OpenAI-compatible /v1/audio/transcriptions endpoint
+type whisperTranscriber struct {
+	apiKey   string
+	endpoint string
+	model    string
+	client   *http.Client
+}
+
+// Transcribe uploads the recording as multipart form data and returns the recognized text
+func (wt *whisperTranscriber) Transcribe(ctx context.Context, audio []byte, audioType, languageTag string) (string, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+
+	part, err := form.CreateFormFile("file", "question."+audioExtensions[audioType])
+	if err != nil {
+		return "", err
+	}
+	if _, err := part.Write(audio); err != nil {
+		return "", err
+	}
+	form.WriteField("model", wt.model)
+	form.WriteField("response_format", "json")
+	// Whisper takes an ISO 639-1 code, so "hi-IN" is sent as "hi"
+	if tag, err := language.Parse(languageTag); err == nil {
+		if base, confidence := tag.Base(); confidence != language.No {
+			form.WriteField("language", base.String())
+		}
+	}
+	if err := form.Close(); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wt.endpoint, &body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	if wt.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+wt.apiKey)
+	}
+
+	resp, err := wt.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("transcription request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("transcription provider returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+
+	var result struct {
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("invalid transcription response: %w", err)
+	}
+	return strings.TrimSpace(result.Text), nil
+}
+
+func (wt *whisperTranscriber) Name() string {
+	return "whisper"
+}
+
+// geminiTranscriber sends the recording to a multimodal Gemini model with a transcription instruction
+type geminiTranscriber struct {
+	model   *genai.GenerativeModel
+	timeout time.Duration
+}
+
+// Transcribe asks the model for a verbatim transcript of the recording
+func (gt *geminiTranscriber) Transcribe(ctx context.Context, audio []byte, audioType, languageTag string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, gt.timeout)
+	defer cancel()
+
+	instruction := "Transcribe this recording of a patient asking about their medical report. " +
+		"Output only the words spoken, without commentary. If nothing is said, output nothing."
+	if languageTag != "" {
+		instruction += " The speaker's language is " + languageTag + "."
+	}
+
+	resp, err := gt.model.GenerateContent(ctx, genai.Blob{MIMEType: audioType, Data: audio}, genai.Text(instruction))
+	if err != nil {
+		return "", fmt.Errorf("failed to transcribe audio: %w", err)
+	}
+	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil {
+		return "", nil
+	}
+
+	var text strings.Builder
+	for _, part := range resp.Candidates[0].Content.Parts {
+		if txt, ok := part.(genai.Text); ok {
+			text.WriteString(string(txt))
+		}
+	}
+	return strings.TrimSpace(text.String()), nil
+}
+
+func (gt *geminiTranscriber) Name() string {
+	return "gemini"
+}
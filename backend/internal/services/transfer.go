@@ -0,0 +1,148 @@
+package services
+
+import (
+	"strings"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+// TransferService moves reports between users with the recipient's consent
+// Decision: There is no family/linked-profile model yet, so any registered user
+// can be a recipient; requiring acceptance keeps reports from being pushed on strangers
+type TransferService struct {
+	transferRepo models.ReportTransferRepository
+	reportRepo   models.ReportRepository
+	userRepo     models.UserRepository
+}
+
+// NewTransferService creates a new transfer service
+func NewTransferService(
+	transferRepo models.ReportTransferRepository,
+	reportRepo models.ReportRepository,
+	userRepo models.UserRepository,
+) *TransferService {
+	return &TransferService{
+		transferRepo: transferRepo,
+		reportRepo:   reportRepo,
+		userRepo:     userRepo,
+	}
+}
+
+// RequestTransfer offers a report the owner holds to the user with recipientEmail
+func (ts *TransferService) RequestTransfer(ownerID, reportID int, recipientEmail string) (*models.ReportTransfer, error) {
+	if _, err := getOwnedReport(ts.reportRepo, ownerID, reportID); err != nil {
+		return nil, err
+	}
+
+	if !isValidEmail(recipientEmail) {
+		return nil, errors.ErrInvalidInput
+	}
+
+	recipient, err := ts.userRepo.GetByEmail(strings.ToLower(strings.TrimSpace(recipientEmail)))
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if recipient == nil || !recipient.IsActive {
+		return nil, errors.ErrUserNotFound
+	}
+	if recipient.ID == ownerID {
+		return nil, errors.NewValidationError("You already own this report")
+	}
+
+	pending, err := ts.transferRepo.GetPendingForReport(reportID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if pending != nil {
+		return nil, errors.ErrTransferPending
+	}
+
+	transfer := &models.ReportTransfer{
+		ReportID:   reportID,
+		FromUserID: ownerID,
+		ToUserID:   recipient.ID,
+	}
+	if err := ts.transferRepo.Create(transfer); err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+
+	return transfer, nil
+}
+
+// ListIncoming returns transfers waiting for the user to accept or decline
+func (ts *TransferService) ListIncoming(userID int) ([]*models.ReportTransfer, error) {
+	transfers, err := ts.transferRepo.ListPendingForRecipient(userID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	return transfers, nil
+}
+
+// History returns every transfer of a report the user currently owns
+func (ts *TransferService) History(userID, reportID int) ([]*models.ReportTransfer, error) {
+	if _, err := getOwnedReport(ts.reportRepo, userID, reportID); err != nil {
+		return nil, err
+	}
+
+	transfers, err := ts.transferRepo.ListByReport(reportID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	return transfers, nil
+}
+
+// Accept moves the report to the recipient
+func (ts *TransferService) Accept(userID, transferID int) (*models.ReportTransfer, error) {
+	transfer, err := ts.getPendingTransfer(transferID, func(t *models.ReportTransfer) bool { return t.ToUserID == userID })
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ts.transferRepo.Accept(transferID); err == models.ErrLegalHold {
+		return nil, errors.ErrLegalHold
+	} else if err != nil {
+		// Decision: A failed conditional update means the transfer or ownership changed underneath us
+		return nil, errors.ErrTransferClosed
+	}
+
+	return ts.transferRepo.GetByID(transfer.ID)
+}
+
+// Decline rejects a transfer offered to the user
+func (ts *TransferService) Decline(userID, transferID int) (*models.ReportTransfer, error) {
+	return ts.resolve(transferID, models.TransferDeclined, func(t *models.ReportTransfer) bool { return t.ToUserID == userID })
+}
+
+// Cancel withdraws a transfer the user offered
+func (ts *TransferService) Cancel(userID, transferID int) (*models.ReportTransfer, error) {
+	return ts.resolve(transferID, models.TransferCancelled, func(t *models.ReportTransfer) bool { return t.FromUserID == userID })
+}
+
+func (ts *TransferService) resolve(transferID int, status string, allowed func(*models.ReportTransfer) bool) (*models.ReportTransfer, error) {
+	if _, err := ts.getPendingTransfer(transferID, allowed); err != nil {
+		return nil, err
+	}
+
+	if err := ts.transferRepo.Resolve(transferID, status); err != nil {
+		return nil, errors.ErrTransferClosed
+	}
+
+	return ts.transferRepo.GetByID(transferID)
+}
+
+// getPendingTransfer loads a transfer and checks the caller may act on it
+// Decision: Unrelated users get 404 rather than 403 so transfer IDs can't be probed
+func (ts *TransferService) getPendingTransfer(transferID int, allowed func(*models.ReportTransfer) bool) (*models.ReportTransfer, error) {
+	transfer, err := ts.transferRepo.GetByID(transferID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if transfer == nil || !allowed(transfer) {
+		return nil, errors.ErrRecordNotFound
+	}
+	if transfer.Status != models.TransferPending {
+		return nil, errors.ErrTransferClosed
+	}
+	return transfer, nil
+}
@@ -0,0 +1,389 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+// googleTranslateURL is the Cloud Translation v2 endpoint
+const googleTranslateURL = "https://translation.googleapis.com/language/translate/v2"
+
+// googleTranslateBatch is how many segments go in one request; the API accepts up to 128
+const googleTranslateBatch = 100
+
+// TranslationLanguage is a language summaries can be translated into
+type TranslationLanguage struct {
+	Code string // ISO 639-1, as passed in ?lang=
+	Name string // English name, used in prompts
+}
+
+// translationLanguages lists the supported targets; analyses are always written in English
+var translationLanguages = []TranslationLanguage{
+	{Code: "hi", Name: "Hindi"},
+	{Code: "bn", Name: "Bengali"},
+	{Code: "gu", Name: "Gujarati"},
+	{Code: "kn", Name: "Kannada"},
+	{Code: "ml", Name: "Malayalam"},
+	{Code: "mr", Name: "Marathi"},
+	{Code: "pa", Name: "Punjabi"},
+	{Code: "ta", Name: "Tamil"},
+	{Code: "te", Name: "Telugu"},
+	{Code: "ur", Name: "Urdu"},
+	{Code: "ar", Name: "Arabic"},
+	{Code: "es", Name: "Spanish"},
+	{Code: "fr", Name: "French"},
+}
+
+// LookupTranslationLanguage returns the target for a code or tag such as "hi-IN", or nil
+func LookupTranslationLanguage(code string) *TranslationLanguage {
+	base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(code)), "-")
+	for i := range translationLanguages {
+		if translationLanguages[i].Code == base {
+			return &translationLanguages[i]
+		}
+	}
+	return nil
+}
+
+// Translator translates English text; the result has one entry per input, in order
+type Translator interface {
+	Translate(ctx context.Context, texts []string, language TranslationLanguage) ([]string, error)
+	Name() string
+}
+
+// TextTranslator is the model-backed translation used by the llm provider
+// Decision: Implemented by AIService and by DemoAnalyzer for keyless demo deployments
+type TextTranslator interface {
+	TranslateTexts(texts []string, languageName string) ([]string, error)
+}
+
+// NewTranslator returns the translator selected by TRANSLATE_PROVIDER, or nil when translation is disabled
+// llm backs the llm provider and may be nil otherwise
+func NewTranslator(cfg config.TranslationConfig, llm TextTranslator) (Translator, error) {
+	switch strings.ToLower(cfg.Provider) {
+	case "", "none":
+		return nil, nil
+	case "google":
+		if cfg.APIKey == "" {
+			return nil, fmt.Errorf("TRANSLATE_API_KEY is required when TRANSLATE_PROVIDER is google")
+		}
+		endpoint := cfg.URL
+		if endpoint == "" {
+			endpoint = googleTranslateURL
+		}
+		timeout := cfg.Timeout
+		if timeout <= 0 {
+			timeout = 30 * time.Second
+		}
+		return &googleTranslator{
+			apiKey:   cfg.APIKey,
+			endpoint: endpoint,
+			client:   &http.Client{Timeout: timeout},
+		}, nil
+	case "llm":
+		if llm == nil {
+			return nil, fmt.Errorf("TRANSLATE_PROVIDER llm needs a working AI provider")
+		}
+		return &llmTranslator{llm: llm}, nil
+	default:
+		return nil, fmt.Errorf("unknown translation provider %q (expected google, llm, or none)", cfg.Provider)
+	}
+}
+
+// googleTranslator calls the Cloud Translation v2 REST API with an API key
+type googleTranslator struct {
+	apiKey   string
+	endpoint string
+	client   *http.Client
+}
+
+type googleTranslateRequest struct {
+	Q      []string `json:"q"`
+	Source string   `json:"source"`
+	Target string   `json:"target"`
+	Format string   `json:"format"`
+}
+
+// Translate sends the texts in batches of googleTranslateBatch
+func (g *googleTranslator) Translate(ctx context.Context, texts []string, language TranslationLanguage) ([]string, error) {
+	translated := make([]string, 0, len(texts))
+	for start := 0; start < len(texts); start += googleTranslateBatch {
+		batch, err := g.translateBatch(ctx, texts[start:min(start+googleTranslateBatch, len(texts))], language.Code)
+		if err != nil {
+			return nil, err
+		}
+		translated = append(translated, batch...)
+	}
+	return translated, nil
+}
+
+func (g *googleTranslator) translateBatch(ctx context.Context, texts []string, target string) ([]string, error) {
+	// Decision: Plain text format, so the API neither expects HTML nor escapes quotes and ampersands
+	body, err := json.Marshal(googleTranslateRequest{Q: texts, Source: "en", Target: target, Format: "text"})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.endpoint+"?key="+url.QueryEscape(g.apiKey), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("translation request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("translation provider returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+
+	var result struct {
+		Data struct {
+			Translations []struct {
+				TranslatedText string `json:"translatedText"`
+			} `json:"translations"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("invalid translation response: %w", err)
+	}
+	if len(result.Data.Translations) != len(texts) {
+		return nil, fmt.Errorf("translation response had %d texts, expected %d", len(result.Data.Translations), len(texts))
+	}
+
+	translated := make([]string, len(texts))
+	for i, t := range result.Data.Translations {
+		translated[i] = t.TranslatedText
+	}
+	return translated, nil
+}
+
+func (g *googleTranslator) Name() string {
+	return "google"
+}
+
+// llmTranslator translates with the configured AI provider
+type llmTranslator struct {
+	llm TextTranslator
+}
+
+func (l *llmTranslator) Translate(ctx context.Context, texts []string, language TranslationLanguage) ([]string, error) {
+	return l.llm.TranslateTexts(texts, language.Name)
+}
+
+func (l *llmTranslator) Name() string {
+	return "llm"
+}
+
+// TranslateTexts asks the model to translate a JSON array of texts and return one in the same shape
+func (ai *AIService) TranslateTexts(texts []string, languageName string) ([]string, error) {
+	input, err := json.Marshal(texts)
+	if err != nil {
+		return nil, err
+	}
+
+	prompt := fmt.Sprintf(`Translate each string in this JSON array from English into %s for a patient reading their lab report.
+- Keep every number, unit, reference range, and test name abbreviation (such as LDL, HbA1c, TSH) exactly as written
+- Use everyday words a patient would understand
+- Return only a JSON array with exactly %d strings, in the same order
+
+%s`, languageName, len(texts), input)
+
+	response, err := ai.generateText(context.Background(), AICallTranslation, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	var translated []string
+	start, end := strings.Index(response, "["), strings.LastIndex(response, "]")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("translation response was not a JSON array")
+	}
+	if err := json.Unmarshal([]byte(response[start:end+1]), &translated); err != nil {
+		return nil, fmt.Errorf("failed to parse translation response: %w", err)
+	}
+	if len(translated) != len(texts) {
+		return nil, fmt.Errorf("translation response had %d texts, expected %d", len(translated), len(texts))
+	}
+	return translated, nil
+}
+
+// TranslateTexts tags each text with the language so translation works in demo deployments
+func (da *DemoAnalyzer) TranslateTexts(texts []string, languageName string) ([]string, error) {
+	translated := make([]string, len(texts))
+	for i, text := range texts {
+		translated[i] = fmt.Sprintf("[%s] %s", languageName, text)
+	}
+	return translated, nil
+}
+
+// TranslationService translates stored analysis text on request, caching each translated piece
+// Decision: Analyses are generated once in English and translated when read, so adding a language never
+// re-runs an analysis; pieces are cached by a hash of their English text, so a re-analyzed report only
+// translates what changed and identical findings across reports are translated once
+type TranslationService struct {
+	translator Translator // Optional; nil makes every request for another language fail with 503
+	cache      models.TranslationCacheRepository
+}
+
+// NewTranslationService creates a translation service
+func NewTranslationService(translator Translator, cache models.TranslationCacheRepository) *TranslationService {
+	return &TranslationService{translator: translator, cache: cache}
+}
+
+// ResolveLanguage validates a ?lang= value and returns the code responses should report
+// Empty and English need no translation and return "en"
+func (ts *TranslationService) ResolveLanguage(code string) (string, error) {
+	if code == "" || strings.EqualFold(code, "en") || strings.HasPrefix(strings.ToLower(code), "en-") {
+		return "en", nil
+	}
+	language := LookupTranslationLanguage(code)
+	if language == nil {
+		codes := make([]string, len(translationLanguages))
+		for i, l := range translationLanguages {
+			codes[i] = l.Code
+		}
+		return "", errors.NewValidationError("Unsupported language; use en or one of " + strings.Join(codes, ", "))
+	}
+	if ts == nil || ts.translator == nil {
+		return "", errors.ErrTranslationUnavailable
+	}
+	return language.Code, nil
+}
+
+// TranslateAnalysis translates the analysis's prose in place; names, values, units, and ranges stay as analyzed
+// Decision: Glossary references point into the English summary, so they are dropped from translations
+func (ts *TranslationService) TranslateAnalysis(ctx context.Context, analysis *AnalysisResult, code string) error {
+	fields := []*string{&analysis.Summary, &analysis.SimpleSummary}
+	for i := range analysis.KeyFindings {
+		fields = append(fields, &analysis.KeyFindings[i])
+	}
+	for i := range analysis.Recommendations {
+		fields = append(fields, &analysis.Recommendations[i])
+	}
+	for _, findings := range analysis.ConditionFindings {
+		for i := range findings {
+			fields = append(fields, &findings[i])
+		}
+	}
+	for i := range analysis.HealthMetrics {
+		fields = append(fields, &analysis.HealthMetrics[i].Description, &analysis.HealthMetrics[i].UnitNote)
+	}
+	if analysis.Completeness != nil {
+		for i := range analysis.Completeness.Hints {
+			fields = append(fields, &analysis.Completeness.Hints[i])
+		}
+	}
+
+	if err := ts.translateFields(ctx, fields, code); err != nil {
+		return err
+	}
+	analysis.GlossaryTerms = nil
+	return nil
+}
+
+// TranslateMetrics translates the metrics' descriptions and unit notes in place
+func (ts *TranslationService) TranslateMetrics(ctx context.Context, metrics []HealthMetric, code string) error {
+	fields := make([]*string, 0, 2*len(metrics))
+	for i := range metrics {
+		fields = append(fields, &metrics[i].Description, &metrics[i].UnitNote)
+	}
+	return ts.translateFields(ctx, fields, code)
+}
+
+// TranslateCompleteness translates the completeness hints in place; panel and test names stay as listed
+func (ts *TranslationService) TranslateCompleteness(ctx context.Context, completeness *Completeness, code string) error {
+	fields := make([]*string, len(completeness.Hints))
+	for i := range completeness.Hints {
+		fields[i] = &completeness.Hints[i]
+	}
+	return ts.translateFields(ctx, fields, code)
+}
+
+// translateFields replaces each non-empty field with its translation, from the cache where possible
+func (ts *TranslationService) translateFields(ctx context.Context, fields []*string, code string) error {
+	if code == "en" {
+		return nil
+	}
+	language := LookupTranslationLanguage(code)
+	if language == nil {
+		return errors.NewValidationError("Unsupported language")
+	}
+	if ts.translator == nil {
+		return errors.ErrTranslationUnavailable
+	}
+
+	hashes := make([]string, len(fields))
+	var unique []string
+	for i, field := range fields {
+		if strings.TrimSpace(*field) == "" {
+			continue
+		}
+		sum := sha256.Sum256([]byte(*field))
+		hashes[i] = hex.EncodeToString(sum[:])
+		if !slices.Contains(unique, hashes[i]) {
+			unique = append(unique, hashes[i])
+		}
+	}
+
+	translations, err := ts.cache.Lookup(language.Code, unique)
+	if err != nil {
+		return errors.ErrDatabaseConnection
+	}
+
+	var missingHashes, missingTexts []string
+	for i, field := range fields {
+		if hashes[i] == "" || slices.Contains(missingHashes, hashes[i]) {
+			continue
+		}
+		if _, ok := translations[hashes[i]]; !ok {
+			missingHashes = append(missingHashes, hashes[i])
+			missingTexts = append(missingTexts, *field)
+		}
+	}
+
+	if len(missingTexts) > 0 {
+		translated, err := ts.translator.Translate(ctx, missingTexts, *language)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to translate texts", "count", len(missingTexts), "language", language.Code, "error", err)
+			return errors.ErrTranslationFailed
+		}
+
+		entries := make([]*models.CachedTranslation, len(translated))
+		for i, text := range translated {
+			translations[missingHashes[i]] = text
+			entries[i] = &models.CachedTranslation{SourceHash: missingHashes[i], Language: language.Code,
+				Provider: ts.translator.Name(), TranslatedText: text}
+		}
+		// Decision: A cache write failure still returns the translation; the next request pays for it again
+		if err := ts.cache.Store(entries); err != nil {
+			slog.WarnContext(ctx, "Failed to cache translations", "count", len(entries), "error", err)
+		}
+	}
+
+	for i, field := range fields {
+		if hashes[i] != "" {
+			*field = translations[hashes[i]]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+	"golang.org/x/text/language"
+)
+
+// googleTTSURL is the Cloud Text-to-Speech synthesize endpoint
+const googleTTSURL = "https://texttospeech.googleapis.com/v1/text:synthesize"
+
+// maxSpeechBytes keeps requests under the provider's 5000-byte input limit
+const maxSpeechBytes = 4800
+
+// DefaultSpeechLanguage is used when neither the request nor the browser names a language
+const DefaultSpeechLanguage = "en-US"
+
+// TTSProvider turns text into MP3 audio
+type TTSProvider interface {
+	Synthesize(ctx context.Context, text, languageTag string) ([]byte, error)
+	Name() string
+}
+
+// NewTTSProvider returns the provider selected by TTS_PROVIDER, or nil when text-to-speech is disabled
+func NewTTSProvider(cfg config.TTSConfig) (TTSProvider, error) {
+	switch strings.ToLower(cfg.Provider) {
+	case "", "none":
+		return nil, nil
+	case "google":
+		if cfg.APIKey == "" {
+			return nil, fmt.Errorf("TTS_API_KEY is required when TTS_PROVIDER is google")
+		}
+		endpoint := cfg.URL
+		if endpoint == "" {
+			endpoint = googleTTSURL
+		}
+		timeout := cfg.Timeout
+		if timeout <= 0 {
+			timeout = 30 * time.Second
+		}
+		return &googleTTS{
+			apiKey:   cfg.APIKey,
+			endpoint: endpoint,
+			client:   &http.Client{Timeout: timeout},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown TTS provider %q (expected google or none)", cfg.Provider)
+	}
+}
+
+// googleTTS calls the Cloud Text-to-Speech REST API with an API key
+type googleTTS struct {
+	apiKey   string
+	endpoint string
+	client   *http.Client
+}
+
+type googleTTSRequest struct {
+	Input struct {
+		Text string `json:"text"`
+	} `json:"input"`
+	Voice struct {
+		LanguageCode string `json:"languageCode"`
+		SSMLGender   string `json:"ssmlGender"`
+	} `json:"voice"`
+	AudioConfig struct {
+		AudioEncoding string `json:"audioEncoding"`
+	} `json:"audioConfig"`
+}
+
+// Synthesize requests MP3 audio in the default voice for the language
+func (g *googleTTS) Synthesize(ctx context.Context, text, languageTag string) ([]byte, error) {
+	var payload googleTTSRequest
+	payload.Input.Text = text
+	payload.Voice.LanguageCode = languageTag
+	payload.Voice.SSMLGender = "NEUTRAL"
+	payload.AudioConfig.AudioEncoding = "MP3"
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.endpoint+"?key="+url.QueryEscape(g.apiKey), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("TTS request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("TTS provider returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+
+	var result struct {
+		AudioContent string `json:"audioContent"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("invalid TTS response: %w", err)
+	}
+
+	audio, err := base64.StdEncoding.DecodeString(result.AudioContent)
+	if err != nil || len(audio) == 0 {
+		return nil, fmt.Errorf("TTS response contained no audio")
+	}
+	return audio, nil
+}
+
+func (g *googleTTS) Name() string {
+	return "google"
+}
+
+// SummaryAudioService produces spoken versions of report summaries
+// Decision: Files are named by a hash of provider, language and text, so a re-analyzed
+// report gets new audio and identical summaries share one file
+type SummaryAudioService struct {
+	provider TTSProvider
+	cacheDir string
+}
+
+// NewSummaryAudioService creates the service; a nil provider makes every request fail with 503
+func NewSummaryAudioService(provider TTSProvider, cacheDir string) *SummaryAudioService {
+	return &SummaryAudioService{
+		provider: provider,
+		cacheDir: cacheDir,
+	}
+}
+
+// SummaryAudio returns the path of an MP3 reading the report's simple summary, generating it on first request
+func (sa *SummaryAudioService) SummaryAudio(ctx context.Context, report *models.Report, languageTag string) (string, error) {
+	if sa.provider == nil {
+		return "", errors.ErrTTSUnavailable
+	}
+	if report.ProcessingStatus != "completed" {
+		return "", errors.ErrReportNotProcessed
+	}
+
+	analysis, err := ParseStoredAnalysis(report.SimplifiedSummary)
+	if err != nil || strings.TrimSpace(analysis.SimpleSummary) == "" {
+		return "", errors.ErrReportNotProcessed
+	}
+	text := truncateSpeech(analysis.SimpleSummary)
+
+	hash := sha256.Sum256([]byte(sa.provider.Name() + "\x00" + languageTag + "\x00" + text))
+	path := filepath.Join(sa.cacheDir, hex.EncodeToString(hash[:])+".mp3")
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+
+	audio, err := sa.provider.Synthesize(ctx, text, languageTag)
+	if err != nil {
+		return "", fmt.Errorf("failed to synthesize summary audio for report %d: %w", report.ID, err)
+	}
+
+	// Decision: Write to a temp file and rename, so a concurrent request never serves half a file;
+	// two first requests may both synthesize, and the later rename harmlessly replaces the earlier file
+	if err := os.MkdirAll(sa.cacheDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create TTS cache directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(sa.cacheDir, "tts-*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("failed to cache summary audio: %w", err)
+	}
+	if _, err := tmp.Write(audio); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to cache summary audio: %w", err)
+	}
+	tmp.Close()
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to cache summary audio: %w", err)
+	}
+
+	return path, nil
+}
+
+// SpeechLanguage picks the voice language from an explicit tag, then the Accept-Language header
+func SpeechLanguage(requested, acceptLanguage string) (string, error) {
+	if requested != "" {
+		tag, err := language.Parse(requested)
+		if err != nil {
+			return "", errors.NewValidationError("Invalid language; use a tag such as en-US or hi-IN")
+		}
+		return tag.String(), nil
+	}
+
+	if tags, _, err := language.ParseAcceptLanguage(acceptLanguage); err == nil && len(tags) > 0 && tags[0] != language.Und {
+		return tags[0].String(), nil
+	}
+	return DefaultSpeechLanguage, nil
+}
+
+// truncateSpeech cuts text to the provider limit at the last sentence end that fits
+func truncateSpeech(text string) string {
+	text = strings.TrimSpace(text)
+	if len(text) <= maxSpeechBytes {
+		return text
+	}
+
+	cut := strings.ToValidUTF8(text[:maxSpeechBytes], "")
+	if end := strings.LastIndexAny(cut, ".!?"); end > 0 {
+		return cut[:end+1]
+	}
+	return cut
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// UnitRange is a unit an analyte is reported in, with the values plausible in that unit
+type UnitRange struct {
+	Unit     string
+	Min, Max float64 // Spans values seen in practice, critical ones included, not the normal range
+}
+
+// AnalyteUnits lists the units labs report one analyte in
+type AnalyteUnits struct {
+	Name     string
+	Keywords []string    // Metric names that count as this analyte; same syntax as condition keywords
+	Exclude  []string    // Metric names matching these never count
+	Units    []UnitRange // Most common first; the first plausible one is chosen when several fit
+}
+
+// unitCatalog is the reference catalog units are inferred from when the model leaves them out
+// Decision: Plausible ranges are deliberately wide, so a correct unit is never ruled out; where two units' ranges
+// overlap, a value in the overlap gets the more common unit flagged as low confidence rather than a silent guess
+var unitCatalog = []AnalyteUnits{
+	{Name: "hemoglobin", Keywords: []string{"hemoglobin", "haemoglobin", "hb", "hgb"},
+		Exclude: []string{"glycated", "glycosylated", "a1c", "corpuscular", "mch*"},
+		Units:   []UnitRange{{"g/dL", 2, 25}, {"g/L", 20, 250}}},
+	{Name: "hematocrit", Keywords: []string{"hematocrit", "haematocrit", "hct", "pcv", "packed cell volume"},
+		Units: []UnitRange{{"%", 5, 80}, {"L/L", 0.05, 0.8}}},
+	{Name: "RBC count", Keywords: []string{"rbc", "red blood cell*", "erythrocyte*"},
+		Exclude: []string{"sedimentation", "esr", "distribution"},
+		Units:   []UnitRange{{"million/µL", 0.5, 10}}},
+	{Name: "WBC count", Keywords: []string{"wbc", "white blood cell*", "leukocyte*", "leucocyte*", "tlc"},
+		Units: []UnitRange{{"×10³/µL", 0.1, 500}, {"cells/µL", 100, 500000}}},
+	{Name: "platelet count", Keywords: []string{"platelet*", "plt", "thrombocyte*"},
+		Exclude: []string{"mean platelet volume", "mpv", "distribution"},
+		Units:   []UnitRange{{"×10³/µL", 1, 2000}, {"lakh/µL", 0.01, 20}, {"cells/µL", 1000, 2000000}}},
+	{Name: "MCV", Keywords: []string{"mcv", "mean corpuscular volume", "mean cell volume"},
+		Units: []UnitRange{{"fL", 40, 150}}},
+	{Name: "MCHC", Keywords: []string{"mchc", "mean corpuscular hemoglobin concentration",
+		"mean corpuscular haemoglobin concentration"},
+		Units: []UnitRange{{"g/dL", 15, 45}, {"g/L", 150, 450}}},
+	{Name: "MCH", Keywords: []string{"mch", "mean corpuscular hemoglobin", "mean corpuscular haemoglobin"},
+		Exclude: []string{"concentration"},
+		Units:   []UnitRange{{"pg", 10, 50}}},
+	{Name: "total cholesterol", Keywords: []string{"cholesterol"}, Exclude: []string{"hdl", "ldl", "vldl", "ratio"},
+		Units: []UnitRange{{"mg/dL", 40, 1000}, {"mmol/L", 1, 26}}},
+	{Name: "LDL cholesterol", Keywords: []string{"ldl", "low density lipoprotein"}, Exclude: []string{"ratio"},
+		Units: []UnitRange{{"mg/dL", 10, 700}, {"mmol/L", 0.2, 18}}},
+	{Name: "HDL cholesterol", Keywords: []string{"hdl", "high density lipoprotein"},
+		Exclude: []string{"non hdl", "non-hdl", "ratio"},
+		Units:   []UnitRange{{"mg/dL", 5, 200}, {"mmol/L", 0.1, 5}}},
+	{Name: "triglycerides", Keywords: []string{"triglyceride*", "tg"}, Exclude: []string{"ratio"},
+		Units: []UnitRange{{"mg/dL", 15, 5000}, {"mmol/L", 0.1, 60}}},
+	{Name: "glucose", Keywords: []string{"glucose", "blood sugar", "fbs", "fpg", "ppbs", "rbs"},
+		Exclude: []string{"urine", "tolerance"},
+		Units:   []UnitRange{{"mg/dL", 15, 1500}, {"mmol/L", 0.8, 80}}},
+	{Name: "HbA1c", Keywords: []string{"hba1c", "a1c", "glycated", "glycosylated"},
+		Units: []UnitRange{{"%", 3, 20}, {"mmol/mol", 9, 200}}},
+	{Name: "TSH", Keywords: []string{"tsh", "thyroid stimulating hormone", "thyrotropin"},
+		Units: []UnitRange{{"mIU/L", 0.001, 500}}},
+	{Name: "free T4", Keywords: []string{"t4", "ft4", "thyroxine"},
+		Units: []UnitRange{{"ng/dL", 0.1, 10}, {"pmol/L", 1, 130}}},
+	{Name: "free T3", Keywords: []string{"t3", "ft3", "triiodothyronine"}, Exclude: []string{"reverse"},
+		Units: []UnitRange{{"pg/mL", 0.5, 30}, {"pmol/L", 0.8, 45}}},
+	{Name: "creatinine", Keywords: []string{"creatinine"}, Exclude: []string{"ratio", "clearance", "urine"},
+		Units: []UnitRange{{"mg/dL", 0.1, 25}, {"µmol/L", 9, 2200}}},
+	{Name: "urea", Keywords: []string{"urea", "bun"}, Exclude: []string{"ratio", "urine"},
+		Units: []UnitRange{{"mg/dL", 2, 400}, {"mmol/L", 0.5, 140}}},
+	{Name: "eGFR", Keywords: []string{"egfr", "gfr", "glomerular filtration"},
+		Units: []UnitRange{{"mL/min/1.73m²", 1, 200}}},
+	{Name: "ALT", Keywords: []string{"alt", "sgpt", "alanine aminotransferase", "alanine transaminase"},
+		Units: []UnitRange{{"U/L", 1, 10000}}},
+	{Name: "AST", Keywords: []string{"ast", "sgot", "aspartate aminotransferase", "aspartate transaminase"},
+		Exclude: []string{"ratio"},
+		Units:   []UnitRange{{"U/L", 1, 10000}}},
+	{Name: "ALP", Keywords: []string{"alp", "alkaline phosphatase"},
+		Units: []UnitRange{{"U/L", 5, 5000}}},
+	{Name: "bilirubin", Keywords: []string{"bilirubin"},
+		Units: []UnitRange{{"mg/dL", 0.05, 50}, {"µmol/L", 1, 850}}},
+	{Name: "albumin", Keywords: []string{"albumin"}, Exclude: []string{"globulin", "urine", "creatinine", "ratio"},
+		Units: []UnitRange{{"g/dL", 0.5, 7}, {"g/L", 5, 70}}},
+	{Name: "sodium", Keywords: []string{"sodium", "na"}, Units: []UnitRange{{"mmol/L", 90, 200}}},
+	{Name: "potassium", Keywords: []string{"potassium", "k"}, Exclude: []string{"vitamin"},
+		Units: []UnitRange{{"mmol/L", 1, 12}}},
+	{Name: "calcium", Keywords: []string{"calcium"}, Exclude: []string{"ionized", "ionised", "urine"},
+		Units: []UnitRange{{"mg/dL", 3, 20}, {"mmol/L", 0.7, 5}}},
+	{Name: "uric acid", Keywords: []string{"uric acid", "urate"},
+		Units: []UnitRange{{"mg/dL", 0.5, 25}, {"µmol/L", 30, 1500}}},
+	{Name: "vitamin D", Keywords: []string{"vitamin d", "vit d", "25 oh*", "25-hydroxy*", "calcidiol"},
+		Units: []UnitRange{{"ng/mL", 1, 250}, {"nmol/L", 2.5, 625}}},
+	{Name: "vitamin B12", Keywords: []string{"vitamin b12", "vit b12", "b12", "cobalamin"},
+		Units: []UnitRange{{"pg/mL", 30, 5000}, {"pmol/L", 20, 3700}}},
+	{Name: "ferritin", Keywords: []string{"ferritin"}, Units: []UnitRange{{"ng/mL", 1, 100000}}},
+}
+
+// unitPatterns holds one compiled matcher per catalog entry, indexed like unitCatalog
+var unitPatterns = compileUnitPatterns()
+
+func compileUnitPatterns() []analytePattern {
+	patterns := make([]analytePattern, len(unitCatalog))
+	for i, analyte := range unitCatalog {
+		patterns[i].match = compileKeywords(analyte.Keywords)
+		if len(analyte.Exclude) > 0 {
+			patterns[i].exclude = compileKeywords(analyte.Exclude)
+		}
+	}
+	return patterns
+}
+
+// lookupAnalyteUnits returns the catalog entry a metric name refers to, or nil
+// Decision: The first matching entry wins, so more specific entries (MCHC) are listed before broader ones (MCH)
+func lookupAnalyteUnits(name string) *AnalyteUnits {
+	for i, pattern := range unitPatterns {
+		if pattern.match.MatchString(name) && (pattern.exclude == nil || !pattern.exclude.MatchString(name)) {
+			return &unitCatalog[i]
+		}
+	}
+	return nil
+}
+
+// UnitInference is what InferUnit concluded about a metric reported without a unit
+type UnitInference struct {
+	Unit          string // Empty when no catalog unit fits the value
+	LowConfidence bool   // Several units fit, or none did
+	Note          string // Explains the inference to the reader
+}
+
+// unitNumber matches a value that is a plain number, so values like "Positive" or "1:80" aren't given units
+var unitNumber = regexp.MustCompile(`^[+-]?(\d+\.?\d*|\.\d+)$`)
+
+// InferUnit infers the unit of a metric the model reported without one from the analyte and the value's magnitude
+// Returns nil when the metric isn't in the catalog or its value isn't a number, since nothing can be said about it
+func InferUnit(name string, value any) *UnitInference {
+	var number float64
+	switch v := value.(type) {
+	case float64:
+		number = v
+	case string:
+		text := strings.TrimSpace(v)
+		if !unitNumber.MatchString(text) {
+			return nil
+		}
+		number, _ = strconv.ParseFloat(text, 64)
+	default:
+		return nil
+	}
+	analyte := lookupAnalyteUnits(name)
+	if analyte == nil || math.IsNaN(number) || math.IsInf(number, 0) {
+		return nil
+	}
+
+	var fits []string
+	for _, unit := range analyte.Units {
+		if number >= unit.Min && number <= unit.Max {
+			fits = append(fits, unit.Unit)
+		}
+	}
+	switch len(fits) {
+	case 0:
+		units := make([]string, len(analyte.Units))
+		for i, unit := range analyte.Units {
+			units[i] = unit.Unit
+		}
+		return &UnitInference{LowConfidence: true,
+			Note: fmt.Sprintf("The report doesn't give a unit, and %g is unusual for %s in %s; check the original report",
+				number, analyte.Name, strings.Join(units, " or "))}
+	case 1:
+		return &UnitInference{Unit: fits[0],
+			Note: fmt.Sprintf("The report doesn't give a unit; %s is assumed from the test and value", fits[0])}
+	default:
+		return &UnitInference{Unit: fits[0], LowConfidence: true,
+			Note: fmt.Sprintf("The report doesn't give a unit; %s is most likely, but it could be %s. Check the original report",
+				fits[0], strings.Join(fits[1:], " or "))}
+	}
+}
+
+// InferMissingUnits fills in the units of metrics reported without one and flags those it isn't sure of
+// Decision: Units written in the report are never changed, even when the value looks implausible in them
+func InferMissingUnits(metrics []HealthMetric) {
+	for i := range metrics {
+		metric := &metrics[i]
+		if strings.TrimSpace(metric.Unit) != "" {
+			continue
+		}
+		inference := InferUnit(metric.Name, metric.Value)
+		if inference == nil {
+			continue
+		}
+		metric.Unit = inference.Unit
+		metric.UnitInferred = true
+		metric.LowConfidence = inference.LowConfidence
+		metric.UnitNote = inference.Note
+	}
+}
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+// What a widget token may show
+const (
+	WidgetScopeMetrics = "metrics" // The report's metrics, for the speedometer
+	WidgetScopeTrends  = "trends"  // Earlier readings of those metrics from the user's other reports
+)
+
+// widgetTokenSubject marks widget tokens apart from session and impersonation tokens
+const widgetTokenSubject = "widget"
+
+// WidgetClaims are the contents of a widget token
+type WidgetClaims struct {
+	UserID   int      `json:"user_id"`
+	ReportID int      `json:"report_id"`
+	Scopes   []string `json:"scopes"`
+	jwt.RegisteredClaims
+}
+
+// WidgetReading is one earlier value of a metric, without the report it came from
+type WidgetReading struct {
+	Date   time.Time
+	Value  string
+	Status string
+}
+
+// WidgetTrend is every reading of one of the report's metrics up to the report's date, oldest first
+type WidgetTrend struct {
+	Name     string
+	Unit     string
+	Readings []WidgetReading
+}
+
+// WidgetView is what an embedded widget may show of a report
+type WidgetView struct {
+	ReportID int
+	Label    string
+	Date     time.Time
+	Scopes   []string
+	Metrics  []HealthMetric
+	Trends   []WidgetTrend // Only with the trends scope
+}
+
+// WidgetService issues and opens short-lived tokens that expose one report's metrics to an embedding site
+// Decision: Tokens are stateless JWTs so a widget read needs no lookup; the short lifetime stands in for revocation
+type WidgetService struct {
+	secret     []byte
+	reportRepo models.ReportRepository
+	userRepo   models.UserRepository
+	defaultTTL time.Duration
+	maxTTL     time.Duration
+}
+
+// NewWidgetService creates a new widget service
+// Decision: Widget tokens are signed with a key derived from the JWT secret, so a leaked widget token
+// can never pass as a session token even if its claims were rewritten to look like one
+func NewWidgetService(jwtSecret string, reportRepo models.ReportRepository, userRepo models.UserRepository, cfg config.WidgetConfig) *WidgetService {
+	mac := hmac.New(sha256.New, []byte(jwtSecret))
+	mac.Write([]byte("widget-token"))
+
+	defaultTTL, maxTTL := cfg.DefaultTTL, cfg.MaxTTL
+	if maxTTL <= 0 {
+		maxTTL = time.Hour
+	}
+	if defaultTTL <= 0 || defaultTTL > maxTTL {
+		defaultTTL = min(15*time.Minute, maxTTL)
+	}
+	return &WidgetService{
+		secret:     mac.Sum(nil),
+		reportRepo: reportRepo,
+		userRepo:   userRepo,
+		defaultTTL: defaultTTL,
+		maxTTL:     maxTTL,
+	}
+}
+
+// IssueToken creates a widget token for a completed report the user owns, returning it with its claims; ttl 0 uses the default
+func (ws *WidgetService) IssueToken(userID, reportID int, scopes []string, ttl time.Duration) (string, *WidgetClaims, error) {
+	if ttl == 0 {
+		ttl = ws.defaultTTL
+	}
+	if ttl < time.Minute || ttl > ws.maxTTL {
+		return "", nil, errors.NewValidationError(fmt.Sprintf("Widget tokens last between 1 and %d minutes", int(ws.maxTTL.Minutes())))
+	}
+
+	if len(scopes) == 0 {
+		scopes = []string{WidgetScopeMetrics}
+	}
+	scopes = slices.Clone(scopes)
+	for _, scope := range scopes {
+		if scope != WidgetScopeMetrics && scope != WidgetScopeTrends {
+			return "", nil, errors.NewValidationError(fmt.Sprintf("Unknown widget scope %q (expected metrics or trends)", scope))
+		}
+	}
+	slices.Sort(scopes)
+	scopes = slices.Compact(scopes)
+
+	report, err := ws.reportRepo.GetByID(reportID)
+	if err != nil {
+		return "", nil, errors.ErrDatabaseConnection
+	}
+	if report == nil || report.UserID != userID {
+		return "", nil, errors.ErrRecordNotFound
+	}
+	if report.ProcessingStatus != "completed" {
+		return "", nil, errors.ErrReportNotProcessed
+	}
+
+	now := time.Now()
+	claims := &WidgetClaims{
+		UserID:   userID,
+		ReportID: reportID,
+		Scopes:   scopes,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Issuer:    "medical-report-backend",
+			Subject:   widgetTokenSubject,
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(ws.secret)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, claims, nil
+}
+
+// Open returns what the token's widget may show
+// Decision: A token stops working as soon as the report is deleted, transferred, or its owner deactivated
+func (ws *WidgetService) Open(token string) (*WidgetView, error) {
+	claims := &WidgetClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (any, error) {
+		return ws.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithSubject(widgetTokenSubject))
+	if err != nil {
+		return nil, errors.ErrWidgetTokenInvalid
+	}
+
+	user, err := ws.userRepo.GetByID(claims.UserID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	report, err := ws.reportRepo.GetByID(claims.ReportID)
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+	if user == nil || !user.IsActive || report == nil || report.UserID != user.ID || report.ProcessingStatus != "completed" {
+		return nil, errors.ErrWidgetTokenInvalid
+	}
+
+	analysis, err := ParseStoredAnalysis(report.SimplifiedSummary)
+	if err != nil {
+		slog.Warn("Failed to parse stored analysis for widget", "report_id", report.ID, "error", err)
+		return nil, errors.ErrDatabaseConnection
+	}
+
+	loc := user.Location()
+	view := &WidgetView{
+		ReportID: report.ID,
+		Label:    reportDisplayName(report),
+		Date:     widgetReportDate(report, loc),
+		Scopes:   claims.Scopes,
+		Metrics:  analysis.HealthMetrics,
+	}
+	if slices.Contains(claims.Scopes, WidgetScopeTrends) {
+		if view.Trends, err = ws.trends(user.ID, view, loc); err != nil {
+			return nil, err
+		}
+	}
+	return view, nil
+}
+
+// trends collects earlier readings of the view's metrics from the user's completed reports
+// Decision: Readings dated after the embedded report are left out, so embedding an old report reveals nothing newer
+func (ws *WidgetService) trends(userID int, view *WidgetView, loc *time.Location) ([]WidgetTrend, error) {
+	reports, err := ws.reportRepo.ListByFilter(models.ReportFilter{UserID: userID, Status: "completed"})
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection
+	}
+
+	byName := make(map[string]*WidgetTrend, len(view.Metrics))
+	trends := make([]*WidgetTrend, 0, len(view.Metrics))
+	for _, metric := range view.Metrics {
+		key := strings.ToLower(strings.TrimSpace(metric.Name))
+		if key == "" || byName[key] != nil {
+			continue
+		}
+		byName[key] = &WidgetTrend{Name: metric.Name, Unit: metric.Unit}
+		trends = append(trends, byName[key])
+	}
+
+	for _, report := range reports {
+		date := widgetReportDate(report, loc)
+		if date.After(view.Date) {
+			continue
+		}
+		analysis, err := ParseStoredAnalysis(report.SimplifiedSummary)
+		if err != nil {
+			slog.Warn("Skipping report in widget trend", "report_id", report.ID, "error", err)
+			continue
+		}
+		for _, metric := range analysis.HealthMetrics {
+			if trend := byName[strings.ToLower(strings.TrimSpace(metric.Name))]; trend != nil {
+				trend.Readings = append(trend.Readings, WidgetReading{Date: date, Value: metric.GetValueAsString(), Status: metric.Status})
+			}
+		}
+	}
+
+	result := make([]WidgetTrend, len(trends))
+	for i, trend := range trends {
+		sort.SliceStable(trend.Readings, func(a, b int) bool { return trend.Readings[a].Date.Before(trend.Readings[b].Date) })
+		result[i] = *trend
+	}
+	return result, nil
+}
+
+// widgetReportDate is a report's date, or its upload day when none was entered
+func widgetReportDate(report *models.Report, loc *time.Location) time.Time {
+	if report.ReportDate != nil {
+		return *report.ReportDate
+	}
+	return report.UploadDate.In(loc)
+}
@@ -0,0 +1,87 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+)
+
+// janitorMaxAttempts bounds retries for files that cannot be removed
+const janitorMaxAttempts = 5
+
+// Janitor removes files queued by report deletions
+// Decision: Deleting files outside the request path keeps bulk operations transactional and fast
+type Janitor struct {
+	cleanupRepo models.FileCleanupRepository
+	fileStorage *services.FileStorage
+	interval    time.Duration
+	batchSize   int
+}
+
+// NewJanitor creates a new file janitor
+func NewJanitor(cleanupRepo models.FileCleanupRepository, fileStorage *services.FileStorage, interval time.Duration) *Janitor {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	return &Janitor{
+		cleanupRepo: cleanupRepo,
+		fileStorage: fileStorage,
+		interval:    interval,
+		batchSize:   100,
+	}
+}
+
+// Run sweeps the cleanup queue until ctx is cancelled
+func (j *Janitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		j.Sweep()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep removes one batch of queued files and returns how many entries were completed
+func (j *Janitor) Sweep() int {
+	entries, err := j.cleanupRepo.ListPending(j.batchSize, janitorMaxAttempts)
+	if err != nil {
+		slog.Error("Janitor failed to list queued files", "error", err)
+		return 0
+	}
+
+	var completed int
+	for _, entry := range entries {
+		err := j.fileStorage.Remove(entry.FilePath)
+
+		// Decision: Already-missing files are done; paths outside uploads are never touched but dropped
+		switch {
+		case err == nil, errors.Is(err, os.ErrNotExist):
+		case errors.Is(err, services.ErrUnsafeFilePath):
+			slog.Warn("Janitor refused to remove a file outside the upload directory", "entry_id", entry.ID)
+		default:
+			slog.Error("Janitor failed to remove file", "entry_id", entry.ID, "error", err)
+			j.cleanupRepo.RecordFailure(entry.ID)
+			continue
+		}
+
+		if err := j.cleanupRepo.Complete(entry.ID); err != nil {
+			slog.Error("Janitor failed to complete entry", "entry_id", entry.ID, "error", err)
+			continue
+		}
+		completed++
+	}
+
+	return completed
+}
@@ -0,0 +1,184 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+)
+
+// Worker polls the reports table for pending reports and processes them
+// Decision: The reports table is the job queue, so any number of worker
+// processes can run next to the API servers without extra infrastructure
+type Worker struct {
+	reportRepo   models.ReportRepository
+	processor    *services.ReportProcessor
+	pollInterval time.Duration
+	batchSize    int
+	concurrency  int
+	recoverAfter time.Duration // A report processing this long is assumed abandoned by a stopped process
+
+	slots    chan struct{}  // Reports in flight across polling and consumed uploads
+	consumed sync.WaitGroup // Uploads received from the event bus still being processed
+}
+
+// NewWorker creates a new queue worker; reports processing longer than recoverAfter are put back in the queue
+func NewWorker(
+	reportRepo models.ReportRepository,
+	processor *services.ReportProcessor,
+	pollInterval time.Duration,
+	batchSize int,
+	concurrency int,
+	recoverAfter time.Duration,
+) *Worker {
+	if pollInterval <= 0 {
+		pollInterval = 5 * time.Second
+	}
+	if batchSize <= 0 {
+		batchSize = 10
+	}
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	if recoverAfter <= 0 {
+		recoverAfter = 15 * time.Minute
+	}
+
+	return &Worker{
+		reportRepo:   reportRepo,
+		processor:    processor,
+		pollInterval: pollInterval,
+		batchSize:    batchSize,
+		concurrency:  concurrency,
+		recoverAfter: recoverAfter,
+		slots:        make(chan struct{}, concurrency),
+	}
+}
+
+// Consume processes reports as their report.uploaded events arrive on bus, until ctx is cancelled
+// Decision: With a shared bus each upload reaches one worker right away; polling keeps running
+// to pick up uploads published while no worker was connected or while the queue was paused
+func (w *Worker) Consume(ctx context.Context, bus services.EventBus) {
+	bus.Subscribe(services.EventReportUploaded, func(event services.Event) error {
+		if ctx.Err() != nil {
+			return nil
+		}
+		// Decision: Process outside the bus's delivery goroutine so a busy worker doesn't hold up other events
+		w.consumed.Add(1)
+		go func() {
+			defer w.consumed.Done()
+			select {
+			case <-ctx.Done():
+				return
+			case w.slots <- struct{}{}:
+			}
+			defer func() { <-w.slots }()
+
+			report, err := w.reportRepo.GetByID(event.ReportID)
+			if err != nil {
+				slog.Error("Worker failed to load report", "report_id", event.ReportID, "error", err)
+				return
+			}
+			if report == nil || report.ProcessingStatus != "pending" {
+				return
+			}
+			w.process(report)
+		}()
+		return nil
+	})
+}
+
+// Run processes pending reports until ctx is cancelled
+func (w *Worker) Run(ctx context.Context) {
+	slog.Info("Worker started", "poll_interval", w.pollInterval, "batch_size", w.batchSize, "concurrency", w.concurrency)
+
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Decision: Drain immediately on startup instead of waiting one interval
+		w.recoverStale()
+		w.processBatch(ctx)
+
+		select {
+		case <-ctx.Done():
+			w.consumed.Wait()
+			slog.Info("Worker stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// recoverStale puts reports whose process stopped mid-analysis back in the queue
+// Decision: Every worker checks on every poll, since the process that crashed may never come back;
+// the conditional requeue keeps workers that check at once from recovering a report twice
+func (w *Worker) recoverStale() {
+	recovered, err := w.processor.RecoverStale(time.Now().Add(-w.recoverAfter), w.batchSize)
+	if err != nil {
+		slog.Error("Worker failed to recover abandoned reports", "error", err)
+		return
+	}
+	if recovered > 0 {
+		slog.Warn("Worker recovered reports left processing by a stopped process", "count", recovered)
+	}
+}
+
+// processBatch handles one batch of pending reports
+// Decision: Small reports spend most of their time waiting on the model, so several are kept
+// in flight at once; the AI service's shared rate limiter keeps them under the provider quota
+func (w *Worker) processBatch(ctx context.Context) {
+	// Decision: Leave pending reports alone while an operator has paused or is draining the queue
+	if paused, err := w.processor.Paused(); err != nil {
+		slog.Error("Worker failed to read queue state", "error", err)
+		return
+	} else if paused {
+		return
+	}
+
+	reports, err := w.reportRepo.GetPendingReports(w.batchSize)
+	if err != nil {
+		slog.Error("Worker failed to fetch pending reports", "error", err)
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, report := range reports {
+		select {
+		case <-ctx.Done():
+		case w.slots <- struct{}{}:
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(report *models.Report) {
+			defer wg.Done()
+			defer func() { <-w.slots }()
+			w.process(report)
+		}(report)
+	}
+
+	// Decision: Finish reports already started so none is left stuck in "processing"
+	wg.Wait()
+}
+
+// process analyzes one report, logging the outcome
+func (w *Worker) process(report *models.Report) {
+	var retry *services.RetryScheduledError
+	if err := w.processor.ProcessReport(report); errors.Is(err, services.ErrQueuePaused) || errors.Is(err, services.ErrReportClaimed) {
+		return
+	} else if errors.As(err, &retry) {
+		slog.Warn("Worker will retry report", "report_id", report.ID, "retry_in", retry.After, "error", retry.Err)
+		return
+	} else if err != nil {
+		slog.Error("Worker failed to process report", "report_id", report.ID, "error", err)
+		return
+	}
+	slog.Info("Worker processed report", "report_id", report.ID)
+}
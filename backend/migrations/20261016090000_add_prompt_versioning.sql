@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE reports ADD COLUMN prompt_version TEXT;
+ALTER TABLE reports ADD COLUMN parse_failed BOOLEAN DEFAULT FALSE;
+ALTER TABLE reports ADD COLUMN feedback_rating INTEGER CHECK (feedback_rating BETWEEN 1 AND 5);
+
+-- Create index on prompt_version for per-variant comparisons
+CREATE INDEX IF NOT EXISTS idx_reports_prompt_version ON reports(prompt_version);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_reports_prompt_version;
+ALTER TABLE reports DROP COLUMN feedback_rating;
+ALTER TABLE reports DROP COLUMN parse_failed;
+ALTER TABLE reports DROP COLUMN prompt_version;
+-- +goose StatementEnd
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS report_transfers (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    report_id INTEGER NOT NULL,
+    from_user_id INTEGER NOT NULL,
+    to_user_id INTEGER NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'declined', 'cancelled')),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    responded_at DATETIME,
+    FOREIGN KEY (report_id) REFERENCES reports(id) ON DELETE CASCADE,
+    FOREIGN KEY (from_user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (to_user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Rows are never deleted on completion so the table doubles as the ownership audit trail
+CREATE INDEX IF NOT EXISTS idx_report_transfers_report_id ON report_transfers(report_id);
+
+-- Create index for a recipient's pending inbox
+CREATE INDEX IF NOT EXISTS idx_report_transfers_recipient ON report_transfers(to_user_id, status);
+
+-- At most one open transfer per report
+CREATE UNIQUE INDEX IF NOT EXISTS idx_report_transfers_pending ON report_transfers(report_id) WHERE status = 'pending';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_report_transfers_pending;
+DROP INDEX IF EXISTS idx_report_transfers_recipient;
+DROP INDEX IF EXISTS idx_report_transfers_report_id;
+DROP TABLE IF EXISTS report_transfers;
+-- +goose StatementEnd
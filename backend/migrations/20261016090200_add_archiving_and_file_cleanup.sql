@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE reports ADD COLUMN archived_at DATETIME;
+
+-- Create index so active-report listings skip archived rows cheaply
+CREATE INDEX IF NOT EXISTS idx_reports_user_archived ON reports(user_id, archived_at);
+
+-- Files of deleted reports, removed from disk by the janitor after the delete commits
+CREATE TABLE IF NOT EXISTS file_cleanup_queue (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    file_path TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS file_cleanup_queue;
+DROP INDEX IF EXISTS idx_reports_user_archived;
+ALTER TABLE reports DROP COLUMN archived_at;
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE chat_messages ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE chat_messages ADD COLUMN updated_at DATETIME;
+
+-- Previous question/answer pairs, kept when a message is edited or regenerated
+CREATE TABLE IF NOT EXISTS chat_message_versions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    message_id INTEGER NOT NULL,
+    version INTEGER NOT NULL,
+    user_message TEXT NOT NULL,
+    ai_response TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (message_id) REFERENCES chat_messages(id) ON DELETE CASCADE,
+    UNIQUE (message_id, version)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS chat_message_versions;
+ALTER TABLE chat_messages DROP COLUMN updated_at;
+ALTER TABLE chat_messages DROP COLUMN version;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Rolling summary of a report's older chat turns, used in place of them in AI prompts
+CREATE TABLE IF NOT EXISTS chat_summaries (
+    report_id INTEGER PRIMARY KEY,
+    summary TEXT NOT NULL,
+    through_message_id INTEGER NOT NULL,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (report_id) REFERENCES reports(id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS chat_summaries;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- User-editable details; the original filename and analysis stay immutable
+ALTER TABLE reports ADD COLUMN title TEXT;
+ALTER TABLE reports ADD COLUMN report_date DATE;
+ALTER TABLE reports ADD COLUMN notes TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE reports DROP COLUMN notes;
+ALTER TABLE reports DROP COLUMN report_date;
+ALTER TABLE reports DROP COLUMN title;
+-- +goose StatementEnd
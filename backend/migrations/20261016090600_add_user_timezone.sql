@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- IANA zone name (e.g. Asia/Kolkata) used to render timestamps and compute the user's calendar day
+ALTER TABLE users ADD COLUMN timezone TEXT NOT NULL DEFAULT 'UTC';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN timezone;
+-- +goose StatementEnd
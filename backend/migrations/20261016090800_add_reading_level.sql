@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- How plainly analyses and chat answers are written: child, standard, or clinical
+ALTER TABLE users ADD COLUMN reading_level TEXT NOT NULL DEFAULT 'standard';
+
+-- Level the report was analyzed at; set from the upload request or the owner's preference
+ALTER TABLE reports ADD COLUMN reading_level TEXT NOT NULL DEFAULT 'standard';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE reports DROP COLUMN reading_level;
+ALTER TABLE users DROP COLUMN reading_level;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Plain-language definitions of medical terms; built-in entries are stored on first lookup, others are AI-generated once
+CREATE TABLE IF NOT EXISTS glossary_terms (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    term_key TEXT NOT NULL UNIQUE,
+    term TEXT NOT NULL,
+    definition TEXT NOT NULL,
+    source TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS glossary_terms;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- How the question was asked: text, or voice (user_message then holds the transcription)
+ALTER TABLE chat_messages ADD COLUMN input_mode TEXT NOT NULL DEFAULT 'text';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE chat_messages DROP COLUMN input_mode;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Read-only links to a report's summary for people without an account, e.g. a doctor
+CREATE TABLE IF NOT EXISTS report_share_links (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    report_id INTEGER NOT NULL,
+    created_by INTEGER NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE, -- SHA-256 of the token in the URL; the token itself is never stored
+    pin_hash TEXT,                   -- bcrypt of the optional PIN shared out-of-band
+    failed_attempts INTEGER NOT NULL DEFAULT 0,
+    locked_at DATETIME,              -- Set once too many wrong PINs were tried; the link stays unusable
+    expires_at DATETIME NOT NULL,
+    revoked_at DATETIME,
+    last_viewed_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (report_id) REFERENCES reports(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_report_share_links_report_id ON report_share_links(report_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_report_share_links_report_id;
+DROP TABLE IF EXISTS report_share_links;
+-- +goose StatementEnd
@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Clinics and hospitals whose patients use the app; branding applies to their members' exports
+CREATE TABLE IF NOT EXISTS organizations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    footer_text TEXT NOT NULL DEFAULT '',
+    contact_info TEXT NOT NULL DEFAULT '',
+    logo BLOB,                               -- JPEG, re-encoded on upload so exports can embed it directly
+    logo_width INTEGER NOT NULL DEFAULT 0,
+    logo_height INTEGER NOT NULL DEFAULT 0,
+    sections TEXT NOT NULL DEFAULT '',       -- Comma-separated export sections in print order; empty uses the default
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- A user belongs to at most one organization
+CREATE TABLE IF NOT EXISTS organization_members (
+    user_id INTEGER PRIMARY KEY,
+    organization_id INTEGER NOT NULL,
+    role TEXT NOT NULL DEFAULT 'member' CHECK (role IN ('member', 'admin')),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_organization_members_org ON organization_members(organization_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_organization_members_org;
+DROP TABLE IF EXISTS organization_members;
+DROP TABLE IF EXISTS organizations;
+-- +goose StatementEnd
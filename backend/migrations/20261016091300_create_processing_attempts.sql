@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+-- One row per run of the analysis pipeline on a report, so operators can see why a job keeps failing
+CREATE TABLE IF NOT EXISTS processing_attempts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    report_id INTEGER NOT NULL,
+    status TEXT NOT NULL DEFAULT 'running' CHECK (status IN ('running', 'succeeded', 'failed', 'abandoned')),
+    error TEXT,
+    started_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    finished_at DATETIME,
+    FOREIGN KEY (report_id) REFERENCES reports(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_processing_attempts_report ON processing_attempts(report_id, started_at);
+
+-- Single row shared by the API servers and every cmd/worker process
+CREATE TABLE IF NOT EXISTS job_queue_state (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    paused BOOLEAN NOT NULL DEFAULT FALSE,
+    reason TEXT NOT NULL DEFAULT '',
+    paused_by INTEGER,
+    paused_at DATETIME
+);
+
+INSERT OR IGNORE INTO job_queue_state (id) VALUES (1);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS job_queue_state;
+DROP INDEX IF EXISTS idx_processing_attempts_report;
+DROP TABLE IF EXISTS processing_attempts;
+-- +goose StatementEnd
@@ -0,0 +1,75 @@
+-- +goose NO TRANSACTION
+-- +goose Up
+-- SQLite can't change a CHECK constraint in place, so reports is rebuilt to allow 'needs_review'
+-- (https://www.sqlite.org/lang_altertable.html#otheralter). Foreign keys are off while the table is swapped.
+PRAGMA foreign_keys = OFF;
+
+-- +goose StatementBegin
+BEGIN;
+
+CREATE TABLE reports_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    original_filename TEXT NOT NULL,
+    file_path TEXT NOT NULL,
+    file_type TEXT NOT NULL,
+    file_size INTEGER NOT NULL,
+    simplified_summary TEXT,
+    processing_status TEXT DEFAULT 'pending' CHECK (processing_status IN ('pending', 'processing', 'completed', 'failed', 'needs_review')),
+    upload_date DATETIME DEFAULT CURRENT_TIMESTAMP,
+    processed_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    prompt_version TEXT,
+    parse_failed BOOLEAN DEFAULT FALSE,
+    feedback_rating INTEGER CHECK (feedback_rating BETWEEN 1 AND 5),
+    archived_at DATETIME,
+    title TEXT,
+    report_date DATE,
+    notes TEXT,
+    reading_level TEXT NOT NULL DEFAULT 'standard',
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+INSERT INTO reports_new (id, user_id, original_filename, file_path, file_type, file_size, simplified_summary,
+    processing_status, upload_date, processed_at, created_at, updated_at, prompt_version, parse_failed,
+    feedback_rating, archived_at, title, report_date, notes, reading_level)
+SELECT id, user_id, original_filename, file_path, file_type, file_size, simplified_summary,
+    processing_status, upload_date, processed_at, created_at, updated_at, prompt_version, parse_failed,
+    feedback_rating, archived_at, title, report_date, notes, reading_level
+FROM reports;
+
+DROP TABLE reports;
+ALTER TABLE reports_new RENAME TO reports;
+
+CREATE INDEX IF NOT EXISTS idx_reports_user_id ON reports(user_id);
+CREATE INDEX IF NOT EXISTS idx_reports_upload_date ON reports(upload_date);
+CREATE INDEX IF NOT EXISTS idx_reports_status ON reports(processing_status);
+CREATE INDEX IF NOT EXISTS idx_reports_user_date ON reports(user_id, upload_date DESC);
+CREATE INDEX IF NOT EXISTS idx_reports_prompt_version ON reports(prompt_version);
+CREATE INDEX IF NOT EXISTS idx_reports_user_archived ON reports(user_id, archived_at);
+
+-- Model output that couldn't be parsed into an analysis, held for an operator to fix and re-parse
+CREATE TABLE IF NOT EXISTS analysis_reviews (
+    report_id INTEGER PRIMARY KEY,
+    raw_output TEXT NOT NULL,
+    parse_error TEXT NOT NULL,
+    prompt_version TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    resolved_at DATETIME,
+    resolved_by INTEGER,
+    FOREIGN KEY (report_id) REFERENCES reports(id) ON DELETE CASCADE
+);
+
+COMMIT;
+-- +goose StatementEnd
+
+PRAGMA foreign_keys = ON;
+
+-- +goose Down
+-- Reports awaiting review go back to failed; the old constraint doesn't know needs_review
+-- +goose StatementBegin
+UPDATE reports SET processing_status = 'failed', simplified_summary = 'Processing failed: analysis could not be parsed'
+WHERE processing_status = 'needs_review';
+DROP TABLE IF EXISTS analysis_reviews;
+-- +goose StatementEnd
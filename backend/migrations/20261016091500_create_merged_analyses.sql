@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Combined assessments of several reports, e.g. the CBC, lipid, and thyroid panels of one checkup
+CREATE TABLE IF NOT EXISTS merged_analyses (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    title TEXT NOT NULL,
+    analysis TEXT NOT NULL,  -- AnalysisResult JSON, same shape as reports.simplified_summary
+    prompt_version TEXT,
+    reading_level TEXT NOT NULL DEFAULT 'standard',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Source reports in the order they were given; the label survives deletion of the report itself
+CREATE TABLE IF NOT EXISTS merged_analysis_sources (
+    merged_analysis_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    report_id INTEGER,       -- NULL once the source report is deleted
+    label TEXT NOT NULL,     -- Report title or filename at merge time
+    report_date DATETIME,
+    PRIMARY KEY (merged_analysis_id, position),
+    FOREIGN KEY (merged_analysis_id) REFERENCES merged_analyses(id) ON DELETE CASCADE,
+    FOREIGN KEY (report_id) REFERENCES reports(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_merged_analyses_user_id ON merged_analyses(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_merged_analysis_sources_report_id ON merged_analysis_sources(report_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_merged_analysis_sources_report_id;
+DROP INDEX IF EXISTS idx_merged_analyses_user_id;
+DROP TABLE IF EXISTS merged_analysis_sources;
+DROP TABLE IF EXISTS merged_analyses;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Generated year-in-review overviews, one per user and year
+CREATE TABLE IF NOT EXISTS annual_reviews (
+    user_id INTEGER NOT NULL,
+    year INTEGER NOT NULL,
+    review TEXT NOT NULL,        -- AnnualReview JSON
+    fingerprint TEXT NOT NULL,   -- Hash of the reports it was written from; a mismatch means regenerate
+    reading_level TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, year),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS annual_reviews;
+-- +goose StatementEnd
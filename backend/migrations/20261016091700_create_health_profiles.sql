@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Optional health details that personalize analyses and chat answers
+CREATE TABLE IF NOT EXISTS health_profiles (
+    user_id INTEGER PRIMARY KEY,
+    date_of_birth DATE,
+    sex TEXT CHECK (sex IS NULL OR sex IN ('male', 'female', 'other')),
+    height_cm REAL,
+    weight_kg REAL,
+    conditions TEXT NOT NULL DEFAULT '[]', -- JSON array of free-text conditions
+    allergies TEXT NOT NULL DEFAULT '[]',  -- JSON array of free-text allergies
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS health_profiles;
+-- +goose StatementEnd
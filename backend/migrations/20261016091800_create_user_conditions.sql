@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Chronic conditions a user tracks; keys come from the condition catalog in code
+CREATE TABLE IF NOT EXISTS user_conditions (
+    user_id INTEGER NOT NULL,
+    condition_key TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, condition_key),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS user_conditions;
+-- +goose StatementEnd
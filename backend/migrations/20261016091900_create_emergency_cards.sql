@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Emergency card details that don't belong to any report
+ALTER TABLE health_profiles ADD COLUMN medications TEXT NOT NULL DEFAULT '[]'; -- JSON array of strings
+ALTER TABLE health_profiles ADD COLUMN blood_group TEXT CHECK (blood_group IS NULL OR blood_group IN ('A+', 'A-', 'B+', 'B-', 'AB+', 'AB-', 'O+', 'O-'));
+
+-- Public emergency card links; a row exists only while the user consents to one
+CREATE TABLE IF NOT EXISTS emergency_card_links (
+    user_id INTEGER PRIMARY KEY,
+    token_hash TEXT NOT NULL UNIQUE, -- SHA-256 of the token; the token itself is shown once
+    last_viewed_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS emergency_card_links;
+ALTER TABLE health_profiles DROP COLUMN blood_group;
+ALTER TABLE health_profiles DROP COLUMN medications;
+-- +goose StatementEnd
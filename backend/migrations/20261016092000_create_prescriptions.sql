@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Photographed prescriptions and the medications read from them
+CREATE TABLE IF NOT EXISTS prescriptions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    file_path TEXT NOT NULL,
+    image_type TEXT NOT NULL,
+    prescriber TEXT NOT NULL DEFAULT '',
+    prescribed_on TEXT NOT NULL DEFAULT '',  -- As written on the slip; handwritten dates are too varied to parse
+    medications TEXT NOT NULL DEFAULT '[]',  -- PrescriptionMedication JSON array, in the order written
+    notes TEXT NOT NULL DEFAULT '',
+    reader TEXT NOT NULL,                    -- Provider that read the image
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_prescriptions_user_id ON prescriptions(user_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS prescriptions;
+-- +goose StatementEnd
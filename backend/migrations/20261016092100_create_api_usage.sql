@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Daily call counts per route and caller, kept to see who still calls deprecated routes
+CREATE TABLE IF NOT EXISTS api_usage (
+    method TEXT NOT NULL,
+    route TEXT NOT NULL,                     -- Route template, e.g. /api/reports/{id:[0-9]+}
+    user_id INTEGER NOT NULL DEFAULT 0,      -- 0 for unauthenticated callers; no foreign key so counts outlive accounts
+    day TEXT NOT NULL,                       -- UTC date, YYYY-MM-DD
+    calls INTEGER NOT NULL DEFAULT 0,
+    last_called_at DATETIME NOT NULL,
+    PRIMARY KEY (method, route, user_id, day)
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_usage_day ON api_usage(day);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS api_usage;
+-- +goose StatementEnd
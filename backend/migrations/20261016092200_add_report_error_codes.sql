@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Why a report failed, as a fixed code the frontend maps to guidance plus the specifics
+ALTER TABLE reports ADD COLUMN error_code TEXT NOT NULL DEFAULT '';
+ALTER TABLE reports ADD COLUMN error_detail TEXT NOT NULL DEFAULT '';
+
+-- Failed reports kept their reason in the summary column; classify what the old messages allow
+UPDATE reports
+SET error_code = CASE
+        WHEN simplified_summary LIKE 'Processing cancelled%' THEN 'cancelled'
+        WHEN simplified_summary LIKE '%analysis could not be read%' THEN 'parse_failed'
+        WHEN simplified_summary LIKE 'We couldn''t read%' THEN 'unreadable_document'
+        WHEN simplified_summary LIKE '%failed to extract text%' THEN 'extraction_failed'
+        WHEN simplified_summary LIKE '%rate limit exceeded%' THEN 'quota_exceeded'
+        ELSE 'internal'
+    END,
+    error_detail = COALESCE(simplified_summary, ''),
+    simplified_summary = ''
+WHERE processing_status = 'failed';
+
+CREATE INDEX IF NOT EXISTS idx_reports_error_code ON reports(error_code) WHERE error_code != '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+UPDATE reports SET simplified_summary = error_detail WHERE processing_status = 'failed';
+DROP INDEX IF EXISTS idx_reports_error_code;
+ALTER TABLE reports DROP COLUMN error_detail;
+ALTER TABLE reports DROP COLUMN error_code;
+-- +goose StatementEnd
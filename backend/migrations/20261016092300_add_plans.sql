@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The plan a user is on sets analysis depth, summary length, and chat allowance
+ALTER TABLE users ADD COLUMN plan TEXT NOT NULL DEFAULT 'free';
+-- The plan in force when the report was uploaded, so reprocessing keeps the depth it was analyzed at
+ALTER TABLE reports ADD COLUMN plan TEXT NOT NULL DEFAULT 'free';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE reports DROP COLUMN plan;
+ALTER TABLE users DROP COLUMN plan;
+-- +goose StatementEnd
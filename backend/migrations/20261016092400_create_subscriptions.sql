@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A user's premium subscription with the payment provider; one row per user, replaced as the provider reports changes
+CREATE TABLE IF NOT EXISTS subscriptions (
+    user_id INTEGER PRIMARY KEY,
+    provider TEXT NOT NULL,                          -- stripe or razorpay
+    customer_id TEXT NOT NULL DEFAULT '',
+    subscription_id TEXT NOT NULL DEFAULT '',        -- Empty until the provider creates the subscription
+    status TEXT NOT NULL,                            -- incomplete, active, past_due, paused, or canceled
+    current_period_end DATETIME,                     -- Nullable; paid-through date when the provider reports one
+    cancel_at_period_end BOOLEAN NOT NULL DEFAULT FALSE,
+    event_at DATETIME,                               -- Time of the newest provider event applied, to ignore stale ones
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_subscriptions_provider_id ON subscriptions(provider, subscription_id)
+    WHERE subscription_id != '';
+
+-- Webhook events already applied, so provider retries are acknowledged without being applied twice
+CREATE TABLE IF NOT EXISTS payment_events (
+    provider TEXT NOT NULL,
+    event_id TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    user_id INTEGER NOT NULL DEFAULT 0,              -- 0 when the event couldn't be matched to a user
+    received_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (provider, event_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS payment_events;
+DROP TABLE IF EXISTS subscriptions;
+-- +goose StatementEnd
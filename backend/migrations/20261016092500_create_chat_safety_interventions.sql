@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Chat answers the safety filter changed, kept so the rules can be reviewed for misses and false positives
+CREATE TABLE IF NOT EXISTS chat_safety_interventions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    report_id INTEGER NOT NULL,
+    message_id INTEGER NOT NULL,
+    level TEXT NOT NULL,                    -- Filter strictness at the time: standard or strict
+    categories TEXT NOT NULL,               -- Comma-separated: dosage, diagnosis, emergency
+    removed TEXT NOT NULL DEFAULT '[]',     -- JSON array of the sentences taken out
+    original_answer TEXT NOT NULL,          -- The model's answer before screening
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_chat_safety_interventions_created ON chat_safety_interventions(created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS chat_safety_interventions;
+-- +goose StatementEnd
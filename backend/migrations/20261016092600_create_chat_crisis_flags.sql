@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Chat questions that matched crisis keywords and were answered with emergency contacts instead of the AI
+CREATE TABLE IF NOT EXISTS chat_crisis_flags (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    report_id INTEGER NOT NULL,
+    message_id INTEGER NOT NULL,
+    category TEXT NOT NULL,                 -- self_harm or emergency
+    keyword TEXT NOT NULL,                  -- The phrase that matched
+    question TEXT NOT NULL,                 -- As asked; a later edit doesn't change it
+    input_mode TEXT NOT NULL DEFAULT 'text',
+    country TEXT NOT NULL DEFAULT '',       -- Whose contacts were given; empty for the generic fallback
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_chat_crisis_flags_created ON chat_crisis_flags(created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS chat_crisis_flags;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Every model call's exact prompt and raw response, for investigating results users report as wrong
+CREATE TABLE IF NOT EXISTS ai_calls (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    report_id INTEGER NOT NULL DEFAULT 0,   -- The analyzed report; 0 for calls not tied to one analysis
+    purpose TEXT NOT NULL,                  -- analysis, merge, chat, summary, translation, glossary or annual
+    provider TEXT NOT NULL,
+    model TEXT NOT NULL,
+    parameters TEXT NOT NULL DEFAULT '{}',  -- JSON: temperature, token cap, sampling, system prompt hash
+    prompt TEXT NOT NULL,                   -- AES-GCM encrypted, base64
+    response TEXT NOT NULL DEFAULT '',      -- AES-GCM encrypted, base64; empty when the call failed
+    error TEXT NOT NULL DEFAULT '',
+    duration_ms INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_ai_calls_report ON ai_calls(report_id);
+CREATE INDEX IF NOT EXISTS idx_ai_calls_created ON ai_calls(created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS ai_calls;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Translated summary text, keyed by a hash of the English source so edited or re-analyzed text is translated afresh
+CREATE TABLE IF NOT EXISTS translation_cache (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    source_hash TEXT NOT NULL,              -- SHA-256 of the English text, hex
+    language TEXT NOT NULL,                 -- Target ISO 639-1 code
+    provider TEXT NOT NULL,                 -- Who translated it; switching providers keeps earlier translations
+    translated_text TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (source_hash, language)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS translation_cache;
+-- +goose StatementEnd
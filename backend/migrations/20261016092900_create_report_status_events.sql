@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+-- One row per processing status a report entered, so owners can see what happened to their upload
+CREATE TABLE IF NOT EXISTS report_status_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    report_id INTEGER NOT NULL,
+    status TEXT NOT NULL,
+    error_code TEXT NOT NULL DEFAULT '',
+    error_detail TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (report_id) REFERENCES reports(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_report_status_events_report ON report_status_events(report_id, id);
+
+-- The model that ran each attempt, e.g. gemini/gemini-1.5-flash; empty for attempts recorded before this column
+ALTER TABLE processing_attempts ADD COLUMN model TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE processing_attempts DROP COLUMN model;
+DROP INDEX IF EXISTS idx_report_status_events_report;
+DROP TABLE IF EXISTS report_status_events;
+-- +goose StatementEnd
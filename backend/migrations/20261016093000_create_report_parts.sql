@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Further pages of a multi-part report; the report row itself holds part 1
+CREATE TABLE IF NOT EXISTS report_parts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    report_id INTEGER NOT NULL,
+    part_number INTEGER NOT NULL,
+    original_filename TEXT NOT NULL,
+    file_path TEXT NOT NULL,
+    file_type TEXT NOT NULL,
+    file_size INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (report_id) REFERENCES reports(id) ON DELETE CASCADE,
+    UNIQUE (report_id, part_number)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS report_parts;
+-- +goose StatementEnd
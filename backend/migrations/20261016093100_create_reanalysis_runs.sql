@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+-- An operator-started re-analysis of historical reports after a prompt or model upgrade
+CREATE TABLE IF NOT EXISTS reanalysis_runs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_by INTEGER NOT NULL,
+    reason TEXT NOT NULL,
+    prompt_version TEXT NOT NULL, -- The prompt and model reports were brought up to
+    model TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'running' CHECK (status IN ('running', 'completed', 'cancelled')),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    finished_at DATETIME,
+    FOREIGN KEY (created_by) REFERENCES users(id)
+);
+
+-- One report in a run, with the analysis before and after so operators can review what changed
+CREATE TABLE IF NOT EXISTS reanalysis_items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    run_id INTEGER NOT NULL,
+    report_id INTEGER NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'succeeded', 'failed', 'skipped')),
+    old_analysis TEXT NOT NULL DEFAULT '',
+    old_prompt_version TEXT NOT NULL DEFAULT '',
+    new_analysis TEXT NOT NULL DEFAULT '',
+    new_prompt_version TEXT NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT '',
+    finished_at DATETIME,
+    FOREIGN KEY (run_id) REFERENCES reanalysis_runs(id) ON DELETE CASCADE,
+    FOREIGN KEY (report_id) REFERENCES reports(id) ON DELETE CASCADE,
+    UNIQUE (run_id, report_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_reanalysis_items_status ON reanalysis_items(status, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_reanalysis_items_status;
+DROP TABLE IF EXISTS reanalysis_items;
+DROP TABLE IF EXISTS reanalysis_runs;
+-- +goose StatementEnd
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Users who agreed to add their latest lab values to the anonymized population statistics
+CREATE TABLE IF NOT EXISTS population_consents (
+    user_id INTEGER PRIMARY KEY,
+    consented_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Distribution of one metric in one age band; only cohorts at or above the k-anonymity floor are stored
+CREATE TABLE IF NOT EXISTS population_metric_stats (
+    metric_key TEXT NOT NULL, -- Lower-cased metric name
+    unit TEXT NOT NULL,       -- Lower-cased unit without spaces; values in other units form their own cohort
+    age_band TEXT NOT NULL,
+    display_name TEXT NOT NULL,
+    contributors INTEGER NOT NULL,
+    p10 REAL NOT NULL,
+    p25 REAL NOT NULL,
+    p50 REAL NOT NULL,
+    p75 REAL NOT NULL,
+    p90 REAL NOT NULL,
+    computed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (metric_key, unit, age_band)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS population_metric_stats;
+DROP TABLE IF EXISTS population_consents;
+-- +goose StatementEnd
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Follow-up appointments requested from a report's recommendation through the external scheduling system
+CREATE TABLE IF NOT EXISTS appointment_bookings (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    report_id INTEGER NOT NULL,
+    recommendation_index INTEGER NOT NULL,  -- Position in the analysis's recommendations when booked
+    recommendation TEXT NOT NULL,           -- The recommendation as it read then; re-analysis may change the list
+    specialty TEXT NOT NULL DEFAULT '',
+    preferred_time DATETIME,
+    notes TEXT NOT NULL DEFAULT '',
+    provider TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'requested', -- requested, pending, booked, cancelled, or failed
+    external_ref TEXT,                        -- The scheduling system's booking reference
+    booking_url TEXT NOT NULL DEFAULT '',
+    scheduled_at DATETIME,
+    error TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (report_id) REFERENCES reports(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_appointment_bookings_report ON appointment_bookings(report_id, recommendation_index);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_appointment_bookings_ref ON appointment_bookings(provider, external_ref);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS appointment_bookings;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Insurer-ready bundles of a report: cover sheet, summary, itemized findings, and the original files
+CREATE TABLE IF NOT EXISTS claim_packages (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    report_id INTEGER NOT NULL UNIQUE,      -- One package per report; generating again replaces it
+    file_path TEXT NOT NULL,
+    file_size INTEGER NOT NULL,
+    checksum TEXT NOT NULL,                 -- Hex SHA-256 of the ZIP, printed for the claimant to quote
+    insurer TEXT NOT NULL DEFAULT '',
+    policy_number TEXT NOT NULL DEFAULT '',
+    claim_reference TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (report_id) REFERENCES reports(id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS claim_packages;
+-- +goose StatementEnd
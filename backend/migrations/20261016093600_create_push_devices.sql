@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Phones and browsers that receive push notifications; a token belongs to whoever registered it last
+CREATE TABLE IF NOT EXISTS push_devices (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    platform TEXT NOT NULL CHECK (platform IN ('fcm', 'apns')),
+    token TEXT NOT NULL,
+    name TEXT NOT NULL DEFAULT '',       -- Shown in the device list, e.g. "Pixel 8"
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE (platform, token)
+);
+
+CREATE INDEX IF NOT EXISTS idx_push_devices_user ON push_devices(user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS push_devices;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Comma-separated privileges an admin granted the key beyond its owner's own data, e.g. analysis:import; empty for ordinary keys
+ALTER TABLE api_keys ADD COLUMN scopes TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE api_keys DROP COLUMN scopes;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Counts edits to the user's profile, so two devices editing it at once can't silently overwrite each other
+ALTER TABLE users ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+-- Counts edits to the report's title, date, and notes, for the same reason
+ALTER TABLE reports ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE reports DROP COLUMN version;
+ALTER TABLE users DROP COLUMN version;
+-- +goose StatementEnd
@@ -0,0 +1,68 @@
+-- +goose NO TRANSACTION
+-- +goose Up
+-- SQLite can't change a CHECK constraint in place, so organization_members is rebuilt to allow clinician roles
+-- (https://www.sqlite.org/lang_altertable.html#otheralter). Foreign keys are off while the table is swapped.
+PRAGMA foreign_keys = OFF;
+
+-- +goose StatementBegin
+BEGIN;
+
+CREATE TABLE organization_members_new (
+    user_id INTEGER PRIMARY KEY,
+    organization_id INTEGER NOT NULL,
+    role TEXT NOT NULL DEFAULT 'member' CHECK (role IN ('member', 'admin', 'doctor', 'care_team')),
+    assigned_doctor_id INTEGER,              -- The doctor in the same organization looking after this patient
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE,
+    FOREIGN KEY (assigned_doctor_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+INSERT INTO organization_members_new (user_id, organization_id, role, created_at)
+SELECT user_id, organization_id, role, created_at FROM organization_members;
+
+DROP TABLE organization_members;
+ALTER TABLE organization_members_new RENAME TO organization_members;
+
+CREATE INDEX IF NOT EXISTS idx_organization_members_org ON organization_members(organization_id);
+
+-- Who in the owner's organization may read a report besides the owner
+ALTER TABLE reports ADD COLUMN visibility TEXT NOT NULL DEFAULT 'private'
+    CHECK (visibility IN ('private', 'doctor', 'care_team'));
+
+COMMIT;
+-- +goose StatementEnd
+
+PRAGMA foreign_keys = ON;
+
+-- +goose Down
+-- Clinicians become plain members; the old constraint doesn't know their roles
+PRAGMA foreign_keys = OFF;
+
+-- +goose StatementBegin
+BEGIN;
+
+ALTER TABLE reports DROP COLUMN visibility;
+
+CREATE TABLE organization_members_old (
+    user_id INTEGER PRIMARY KEY,
+    organization_id INTEGER NOT NULL,
+    role TEXT NOT NULL DEFAULT 'member' CHECK (role IN ('member', 'admin')),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE
+);
+
+INSERT INTO organization_members_old (user_id, organization_id, role, created_at)
+SELECT user_id, organization_id, CASE WHEN role = 'admin' THEN 'admin' ELSE 'member' END, created_at
+FROM organization_members;
+
+DROP TABLE organization_members;
+ALTER TABLE organization_members_old RENAME TO organization_members;
+
+CREATE INDEX IF NOT EXISTS idx_organization_members_org ON organization_members(organization_id);
+
+COMMIT;
+-- +goose StatementEnd
+
+PRAGMA foreign_keys = ON;
@@ -0,0 +1,46 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Exchanges a user flagged as harmful, wrong, or offensive
+CREATE TABLE IF NOT EXISTS chat_message_flags (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    message_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    reason TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (message_id, user_id),
+    FOREIGN KEY (message_id) REFERENCES chat_messages(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- A moderator's decision on a flagged exchange; flags raised after it reopen the exchange
+CREATE TABLE IF NOT EXISTS chat_moderation_reviews (
+    message_id INTEGER PRIMARY KEY,
+    action TEXT NOT NULL CHECK (action IN ('dismissed', 'redacted')),
+    note TEXT NOT NULL DEFAULT '',
+    reviewed_by INTEGER NOT NULL,
+    reviewed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (message_id) REFERENCES chat_messages(id) ON DELETE CASCADE
+);
+
+-- Accounts deactivated by a moderator, and why
+CREATE TABLE IF NOT EXISTS user_bans (
+    user_id INTEGER PRIMARY KEY,
+    reason TEXT NOT NULL,
+    banned_by INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_chat_message_flags_message ON chat_message_flags(message_id);
+CREATE INDEX IF NOT EXISTS idx_chat_safety_interventions_message ON chat_safety_interventions(message_id);
+CREATE INDEX IF NOT EXISTS idx_chat_crisis_flags_message ON chat_crisis_flags(message_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_chat_crisis_flags_message;
+DROP INDEX IF EXISTS idx_chat_safety_interventions_message;
+DROP TABLE IF EXISTS user_bans;
+DROP TABLE IF EXISTS chat_moderation_reviews;
+DROP TABLE IF EXISTS chat_message_flags;
+-- +goose StatementEnd
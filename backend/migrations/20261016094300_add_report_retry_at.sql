@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- When a report whose analysis failed for a passing reason, such as a timeout or the provider's quota,
+-- may be picked up again; NULL for reports that can be processed right away
+ALTER TABLE reports ADD COLUMN retry_at DATETIME;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE reports DROP COLUMN retry_at;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The user whose data a model call was made with, so users can see what the AI did for them; 0 for calls
+-- made for no one in particular (glossary definitions, shared translations) and for calls logged before this
+ALTER TABLE ai_calls ADD COLUMN user_id INTEGER NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_ai_calls_user ON ai_calls(user_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_ai_calls_user;
+ALTER TABLE ai_calls DROP COLUMN user_id;
+-- +goose StatementEnd
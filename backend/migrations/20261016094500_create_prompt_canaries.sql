@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Candidate prompts and models served to a share of new reports, and how each canary ended
+CREATE TABLE IF NOT EXISTS prompt_canaries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    version TEXT NOT NULL UNIQUE, -- The prompt version label the canary's analyses are stored with
+    prompt_path TEXT NOT NULL,
+    model TEXT NOT NULL DEFAULT '', -- Empty when the canary runs on the stable model
+    percent INTEGER NOT NULL,
+    status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'rolled_back', 'promoted')),
+    reason TEXT NOT NULL DEFAULT '', -- Why it was rolled back or promoted
+    started_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    ended_at DATETIME,
+    ended_by INTEGER NOT NULL DEFAULT 0 -- The admin who ended it; 0 for an automatic rollback
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS prompt_canaries;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Tokens signed out before they expired; each is refused until its expiry, after which its row is pruned
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    token_id TEXT PRIMARY KEY,  -- The token's jti, or "sha256:" and the token's hash when it has none
+    user_id INTEGER NOT NULL,
+    reason TEXT NOT NULL DEFAULT '', -- logout
+    expires_at DATETIME NOT NULL,    -- When the token would have expired anyway
+    revoked_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires ON revoked_tokens(expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS revoked_tokens;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Long-lived tokens exchanged at /api/auth/refresh for a new access token; each is used once and replaced
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,        -- SHA-256 of the token; the token itself is only sent to the client
+    session_id TEXT NOT NULL,               -- The jti of the sign-in's access tokens, shared by every rotation
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    expires_at DATETIME NOT NULL,
+    revoked_at DATETIME,
+    revoked_reason TEXT NOT NULL DEFAULT '', -- rotated, logout, or reused
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_session ON refresh_tokens(session_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user ON refresh_tokens(user_id, expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS refresh_tokens;
+-- +goose StatementEnd
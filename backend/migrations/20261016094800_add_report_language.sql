@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- ISO 639-1 code of the language the report was printed in, as detected from its text before analysis;
+-- empty for reports not yet analyzed, or analyzed before languages were recorded
+ALTER TABLE reports ADD COLUMN language TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE reports DROP COLUMN language;
+-- +goose StatementEnd
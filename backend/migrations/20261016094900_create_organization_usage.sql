@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+-- What each organization used in a calendar month (UTC), added to as it happens so invoices don't depend on
+-- who is a member by the time they are drawn up
+CREATE TABLE IF NOT EXISTS organization_usage (
+    organization_id INTEGER NOT NULL,
+    month TEXT NOT NULL, -- YYYY-MM
+    reports_processed INTEGER NOT NULL DEFAULT 0,
+    ai_calls INTEGER NOT NULL DEFAULT 0,
+    ai_input_tokens INTEGER NOT NULL DEFAULT 0,
+    ai_output_tokens INTEGER NOT NULL DEFAULT 0,
+    storage_bytes INTEGER NOT NULL DEFAULT 0, -- Most bytes of report files the members held at any measurement
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (organization_id, month),
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS organization_usage;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Users whose reports and related data are kept for a medico-legal request; nothing of theirs is deleted or
+-- overwritten while a hold stands. No cascade, so a user row can't be removed out from under its hold either
+CREATE TABLE IF NOT EXISTS legal_holds (
+    user_id INTEGER PRIMARY KEY,
+    reason TEXT NOT NULL,
+    reference TEXT NOT NULL DEFAULT '', -- Case or request number the hold answers
+    placed_by INTEGER NOT NULL,
+    placed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS legal_holds;
+-- +goose StatementEnd
@@ -0,0 +1,153 @@
+// Package migrations embeds the schema's goose migrations and applies them at startup
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// Migration is one goose migration file; only its Up section is applied
+type Migration struct {
+	Version       int64
+	Name          string
+	Up            string
+	NoTransaction bool // The file manages its own transaction, e.g. to turn foreign keys off around a table rebuild
+}
+
+// Load reads the embedded migrations, oldest first
+func Load() ([]Migration, error) {
+	names, err := fs.Glob(files, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []Migration
+	for _, name := range names {
+		prefix, _, _ := strings.Cut(name, "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s has no version prefix", name)
+		}
+		content, err := files.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+
+		up, _, _ := strings.Cut(string(content), "-- +goose Down")
+		migrations = append(migrations, Migration{
+			Version:       version,
+			Name:          name,
+			Up:            up,
+			NoTransaction: strings.Contains(up, "-- +goose NO TRANSACTION"),
+		})
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// historyTable is goose's own version table for SQLite
+// Decision: History is kept exactly as the goose CLI keeps it, so databases migrated with make migrate-up
+// and ones migrated at startup are interchangeable, and migrate-status and -selftest read either
+const historyTable = `
+	CREATE TABLE IF NOT EXISTS goose_db_version (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		version_id INTEGER NOT NULL,
+		is_applied INTEGER NOT NULL,
+		tstamp TIMESTAMP DEFAULT (datetime('now'))
+	)`
+
+// Apply runs the embedded migrations the database hasn't applied yet, oldest first, and returns how many ran
+// Decision: Everything runs on one connection, since a migration's PRAGMA only affects the connection it runs on
+func Apply(ctx context.Context, db *sql.DB) (int, error) {
+	migrations, err := Load()
+	if err != nil {
+		return 0, err
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, historyTable); err != nil {
+		return 0, fmt.Errorf("failed to create migration history: %w", err)
+	}
+	if _, err := conn.ExecContext(ctx, `
+		INSERT INTO goose_db_version (version_id, is_applied)
+		SELECT 0, 1 WHERE NOT EXISTS (SELECT 1 FROM goose_db_version)`); err != nil {
+		return 0, fmt.Errorf("failed to create migration history: %w", err)
+	}
+
+	count := 0
+	for _, migration := range migrations {
+		ran, err := apply(ctx, conn, migration)
+		if err != nil {
+			return count, fmt.Errorf("migration %s failed: %w", migration.Name, err)
+		}
+		if ran {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// apply runs one migration unless it is already applied
+// Decision: The check and the migration share an immediate transaction, so when several processes start
+// together one applies it and the others wait, then skip it
+func apply(ctx context.Context, conn *sql.Conn, migration Migration) (bool, error) {
+	if migration.NoTransaction {
+		applied, err := isApplied(ctx, conn, migration.Version)
+		if err != nil || applied {
+			return false, err
+		}
+		if _, err := conn.ExecContext(ctx, migration.Up); err != nil {
+			return false, err
+		}
+		return true, record(ctx, conn, migration.Version)
+	}
+
+	if _, err := conn.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+		return false, err
+	}
+	applied, err := isApplied(ctx, conn, migration.Version)
+	if err == nil && !applied {
+		if _, err = conn.ExecContext(ctx, migration.Up); err == nil {
+			err = record(ctx, conn, migration.Version)
+		}
+	}
+	if err != nil || applied {
+		conn.ExecContext(ctx, "ROLLBACK")
+		return false, err
+	}
+	if _, err := conn.ExecContext(ctx, "COMMIT"); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// isApplied reads the version's latest history row, as goose does
+func isApplied(ctx context.Context, conn *sql.Conn, version int64) (bool, error) {
+	var applied bool
+	err := conn.QueryRowContext(ctx, `
+		SELECT is_applied FROM goose_db_version WHERE version_id = ? ORDER BY id DESC LIMIT 1`, version).Scan(&applied)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return applied, err
+}
+
+// record adds the version to the history
+func record(ctx context.Context, conn *sql.Conn, version int64) error {
+	_, err := conn.ExecContext(ctx, `INSERT INTO goose_db_version (version_id, is_applied) VALUES (?, 1)`, version)
+	return err
+}
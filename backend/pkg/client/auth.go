@@ -0,0 +1,123 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// Signup registers an account and, on success, uses its session token for later calls
+func (c *Client) Signup(ctx context.Context, req types.SignupRequest) (*types.LoginResponse, error) {
+	var response types.LoginResponse
+	if err := c.Do(ctx, http.MethodPost, "/api/auth/signup", req, &response); err != nil {
+		return nil, err
+	}
+	c.token, c.refreshToken = response.Token, response.RefreshToken
+	return &response, nil
+}
+
+// Login signs in and, on success, uses the session token for later calls
+func (c *Client) Login(ctx context.Context, email, password string) (*types.LoginResponse, error) {
+	var response types.LoginResponse
+	req := types.LoginRequest{Email: email, Password: password}
+	if err := c.Do(ctx, http.MethodPost, "/api/auth/login", req, &response); err != nil {
+		return nil, err
+	}
+	c.token, c.refreshToken = response.Token, response.RefreshToken
+	return &response, nil
+}
+
+// Logout ends the session and forgets its tokens
+func (c *Client) Logout(ctx context.Context) error {
+	var req any
+	if c.refreshToken != "" {
+		req = types.LogoutRequest{RefreshToken: c.refreshToken}
+	}
+	if err := c.Do(ctx, http.MethodPost, "/api/auth/logout", req, nil); err != nil {
+		return err
+	}
+	c.token, c.refreshToken = "", ""
+	return nil
+}
+
+// Refresh exchanges the refresh token for a new session token and refresh token, and uses both from then on
+func (c *Client) Refresh(ctx context.Context) (*types.TokenResponse, error) {
+	if c.refreshToken == "" {
+		return nil, fmt.Errorf("no refresh token; sign in first")
+	}
+	var response types.TokenResponse
+	req := types.RefreshTokenRequest{RefreshToken: c.refreshToken}
+	if err := c.Do(ctx, http.MethodPost, "/api/auth/refresh", req, &response); err != nil {
+		return nil, err
+	}
+	c.token, c.refreshToken = response.Token, response.RefreshToken
+	return &response, nil
+}
+
+// Me returns the account the credential belongs to
+func (c *Client) Me(ctx context.Context) (*types.User, error) {
+	var user types.User
+	if err := c.Do(ctx, http.MethodGet, "/api/auth/me", nil, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UpdateMe changes the account's name, timezone, or reading level; nil fields are left as they are
+func (c *Client) UpdateMe(ctx context.Context, req types.UpdateProfileRequest) (*types.User, error) {
+	var user types.User
+	if err := c.Do(ctx, http.MethodPatch, "/api/auth/me", req, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// CreateAPIKey issues an API key; the returned Key is shown only this once
+// Keys can only be managed with a session token, not with another API key
+func (c *Client) CreateAPIKey(ctx context.Context, name string) (*types.CreateAPIKeyResponse, error) {
+	var response types.CreateAPIKeyResponse
+	if err := c.Do(ctx, http.MethodPost, "/api/auth/api-keys", types.CreateAPIKeyRequest{Name: name}, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// CreateScopedAPIKey issues an admin a key with scopes, e.g. analysis:import for a hospital pipeline
+func (c *Client) CreateScopedAPIKey(ctx context.Context, name string, scopes []string) (*types.CreateAPIKeyResponse, error) {
+	var response types.CreateAPIKeyResponse
+	req := types.CreateAPIKeyRequest{Name: name, Scopes: scopes}
+	if err := c.Do(ctx, http.MethodPost, "/api/admin/api-keys", req, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ListAPIKeys returns the account's keys, newest first, including revoked ones
+func (c *Client) ListAPIKeys(ctx context.Context) ([]types.APIKey, error) {
+	var response types.APIKeysResponse
+	if err := c.Do(ctx, http.MethodGet, "/api/auth/api-keys", nil, &response); err != nil {
+		return nil, err
+	}
+	return response.Keys, nil
+}
+
+// RevokeAPIKey revokes one of the account's keys
+func (c *Client) RevokeAPIKey(ctx context.Context, id int) error {
+	return c.Do(ctx, http.MethodDelete, fmt.Sprintf("/api/auth/api-keys/%d", id), nil, nil)
+}
+
+// GetAILog returns a page of the AI operations run on the account's data, newest first
+func (c *Client) GetAILog(ctx context.Context, limit, offset int) ([]types.AIUsageEntry, error) {
+	query := url.Values{}
+	query.Set("limit", strconv.Itoa(limit))
+	query.Set("offset", strconv.Itoa(offset))
+	var entries []types.AIUsageEntry
+	if err := c.Do(ctx, http.MethodGet, "/api/users/me/ai-log?"+query.Encode(), nil, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
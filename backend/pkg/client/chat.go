@@ -0,0 +1,113 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// VoiceOptions are the optional fields of a recorded question
+type VoiceOptions struct {
+	ContentType  string // Detected from the file name, then the content, when empty
+	Language     string // Language spoken, e.g. hi; detected when empty
+	ReadingLevel string // child, standard, or clinical; the account's setting when empty
+}
+
+// AskByVoice asks a recorded question about a report and returns the transcription with its answer
+func (c *Client) AskByVoice(ctx context.Context, reportID int, filename string, audio io.Reader, opts VoiceOptions) (*types.ChatMessage, error) {
+	fields := map[string]string{"language": opts.Language, "reading_level": opts.ReadingLevel}
+	var message types.ChatMessage
+	path := fmt.Sprintf("/api/reports/%d/chat/voice", reportID)
+	if err := c.upload(ctx, path, "audio", filename, opts.ContentType, audio, fields, &message); err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// Ask asks a typed question about a report and returns it with its answer; readingLevel may be empty
+func (c *Client) Ask(ctx context.Context, reportID int, question, readingLevel string) (*types.ChatMessage, error) {
+	var message types.ChatMessage
+	req := types.ChatRequest{Message: question, ReadingLevel: readingLevel}
+	if err := c.Do(ctx, http.MethodPost, fmt.Sprintf("/api/reports/%d/chat", reportID), req, &message); err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// GetChatHistory returns a page of a report's conversation, oldest first
+func (c *Client) GetChatHistory(ctx context.Context, reportID, limit, offset int) ([]types.ChatMessage, error) {
+	query := url.Values{}
+	query.Set("limit", strconv.Itoa(limit))
+	query.Set("offset", strconv.Itoa(offset))
+	var messages []types.ChatMessage
+	if err := c.Do(ctx, http.MethodGet, fmt.Sprintf("/api/reports/%d/chat?%s", reportID, query.Encode()), nil, &messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// DeleteMessage removes a question and its answer from a report's conversation
+func (c *Client) DeleteMessage(ctx context.Context, messageID int) error {
+	return c.Do(ctx, http.MethodDelete, fmt.Sprintf("/api/chat/%d", messageID), nil, nil)
+}
+
+// FlagMessage asks the moderators to review a question and its answer
+func (c *Client) FlagMessage(ctx context.Context, messageID int, reason string) error {
+	return c.Do(ctx, http.MethodPost, fmt.Sprintf("/api/chat/%d/flag", messageID), types.FlagMessageRequest{Reason: reason}, nil)
+}
+
+// EditMessage replaces a question and returns the regenerated answer; readingLevel may be empty
+func (c *Client) EditMessage(ctx context.Context, messageID int, question, readingLevel string) (*types.ChatMessage, error) {
+	var message types.ChatMessage
+	req := types.ChatEditRequest{Message: question, ReadingLevel: readingLevel}
+	if err := c.Do(ctx, http.MethodPut, fmt.Sprintf("/api/chat/%d", messageID), req, &message); err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// RegenerateMessage answers a question again; readingLevel may be empty
+func (c *Client) RegenerateMessage(ctx context.Context, messageID int, readingLevel string) (*types.ChatMessage, error) {
+	path := fmt.Sprintf("/api/chat/%d/regenerate", messageID)
+	if readingLevel != "" {
+		path += "?reading_level=" + url.QueryEscape(readingLevel)
+	}
+	var message types.ChatMessage
+	if err := c.Do(ctx, http.MethodPost, path, nil, &message); err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// GetMessageVersions returns a message with its earlier questions and answers
+func (c *Client) GetMessageVersions(ctx context.Context, messageID int) (*types.ChatVersionsResponse, error) {
+	var response types.ChatVersionsResponse
+	if err := c.Do(ctx, http.MethodGet, fmt.Sprintf("/api/chat/%d/versions", messageID), nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GetChatTranscript returns a report's whole conversation
+func (c *Client) GetChatTranscript(ctx context.Context, reportID int) (*types.ChatTranscript, error) {
+	body, err := c.ExportChat(ctx, reportID, "json")
+	if err != nil {
+		return nil, err
+	}
+	var transcript types.ChatTranscript
+	if err := json.Unmarshal(body, &transcript); err != nil {
+		return nil, fmt.Errorf("unexpected chat export: %w", err)
+	}
+	return &transcript, nil
+}
+
+// ExportChat returns a report's conversation as a json, markdown, or pdf document
+func (c *Client) ExportChat(ctx context.Context, reportID int, format string) ([]byte, error) {
+	return c.download(ctx, fmt.Sprintf("/api/reports/%d/chat/export?format=%s", reportID, url.QueryEscape(format)))
+}
@@ -0,0 +1,260 @@
+// Package client is a Go SDK for the medical report API.
+//
+//	c := client.New("https://reports.example.com", os.Getenv("MEDCTL_API_KEY"))
+//	reports, err := c.ListReports(ctx, 20, 0)
+//
+// Every method returns *Error when the API answers with an error envelope, so callers can check Status or Type.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// DefaultTimeout bounds each request made by a client from New
+const DefaultTimeout = 2 * time.Minute
+
+// Client calls the API with one credential, a session token or an API key; both are sent as a bearer token
+type Client struct {
+	baseURL      string
+	token        string
+	refreshToken string // From Signup or Login; exchanged by Refresh for new tokens
+	userAgent    string
+	httpClient   *http.Client
+}
+
+// New creates a client for the API at baseURL, e.g. http://localhost:8080; token may be empty until Login
+func New(baseURL, token string) *Client {
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		token:      token,
+		userAgent:  "medreport-go-client",
+		httpClient: &http.Client{Timeout: DefaultTimeout},
+	}
+}
+
+// SetToken replaces the credential sent with each request
+func (c *Client) SetToken(token string) {
+	c.token = token
+}
+
+// Token returns the credential the client sends
+func (c *Client) Token() string {
+	return c.token
+}
+
+// SetRefreshToken sets the refresh token Refresh exchanges, e.g. one saved from an earlier run
+func (c *Client) SetRefreshToken(refreshToken string) {
+	c.refreshToken = refreshToken
+}
+
+// RefreshToken returns the refresh token the client holds; it changes with every Refresh, so save it again after one
+func (c *Client) RefreshToken() string {
+	return c.refreshToken
+}
+
+// BaseURL returns the API address the client calls
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// SetHTTPClient replaces the HTTP client, e.g. to change the timeout or transport
+func (c *Client) SetHTTPClient(httpClient *http.Client) {
+	c.httpClient = httpClient
+}
+
+// SetUserAgent names the integration in the User-Agent header
+func (c *Client) SetUserAgent(userAgent string) {
+	c.userAgent = userAgent
+}
+
+// Error is a failed response, carrying the error envelope's type and message when there was one
+type Error struct {
+	Status  int
+	Type    string // e.g. AUTH_ERROR or VALIDATION_ERROR; empty when the body wasn't an envelope
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Type != "" {
+		return fmt.Sprintf("API error %d %s: %s", e.Status, e.Type, e.Message)
+	}
+	return fmt.Sprintf("API error %d: %s", e.Status, e.Message)
+}
+
+// IsStatus reports whether err is an API error with the given HTTP status
+func IsStatus(err error, status int) bool {
+	apiErr, ok := err.(*Error)
+	return ok && apiErr.Status == status
+}
+
+// Do sends a JSON request to any endpoint and decodes the envelope's data into out
+// body and out may be nil; it is the escape hatch for endpoints without a typed method
+func (c *Client) Do(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	contentType := ""
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader, contentType = bytes.NewReader(payload), "application/json"
+	}
+	_, err := c.send(ctx, method, path, reader, contentType, out)
+	return err
+}
+
+// send makes a request and decodes the envelope, returning its meta
+func (c *Client) send(ctx context.Context, method, path string, body io.Reader, contentType string, out any) (*types.Meta, error) {
+	resp, err := c.request(ctx, method, path, body, contentType)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		return nil, decodeError(resp.StatusCode, raw)
+	}
+
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+		Meta *types.Meta     `json:"meta"`
+	}
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return nil, fmt.Errorf("unexpected response from %s: %w", path, err)
+	}
+	if out == nil {
+		return envelope.Meta, nil
+	}
+	if target, ok := out.(*json.RawMessage); ok {
+		*target = envelope.Data
+		return envelope.Meta, nil
+	}
+	if err := json.Unmarshal(envelope.Data, out); err != nil {
+		return nil, fmt.Errorf("unexpected response from %s: %w", path, err)
+	}
+	return envelope.Meta, nil
+}
+
+// download fetches a response that isn't enveloped, such as a file or an export
+func (c *Client) download(ctx context.Context, path string) ([]byte, error) {
+	resp, err := c.request(ctx, http.MethodGet, path, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		return nil, decodeError(resp.StatusCode, body)
+	}
+	return body, nil
+}
+
+// upload posts a multipart form with one file and the non-empty fields
+func (c *Client) upload(ctx context.Context, path, field, filename, contentType string, file io.Reader, fields map[string]string, out any) error {
+	// Decision: The server checks the declared type against the extension and content, so declare the real one
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(filename))
+	}
+	if contentType == "" {
+		head := make([]byte, 512)
+		n, err := io.ReadFull(file, head)
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			return err
+		}
+		head = head[:n]
+		contentType = http.DetectContentType(head)
+		file = io.MultiReader(bytes.NewReader(head), file)
+	}
+	partHeader := textproto.MIMEHeader{}
+	partHeader.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{"name": field, "filename": filepath.Base(filename)}))
+	partHeader.Set("Content-Type", contentType)
+
+	// Decision: Stream the form through a pipe so large scans aren't held in memory twice
+	reader, writer := io.Pipe()
+	form := multipart.NewWriter(writer)
+	go func() {
+		for name, value := range fields {
+			if value == "" {
+				continue
+			}
+			if err := form.WriteField(name, value); err != nil {
+				writer.CloseWithError(err)
+				return
+			}
+		}
+		part, err := form.CreatePart(partHeader)
+		if err == nil {
+			_, err = io.Copy(part, file)
+		}
+		if err == nil {
+			err = form.Close()
+		}
+		writer.CloseWithError(err)
+	}()
+
+	_, err := c.send(ctx, http.MethodPost, path, reader, form.FormDataContentType(), out)
+	reader.Close()
+	return err
+}
+
+func (c *Client) request(ctx context.Context, method, path string, body io.Reader, contentType string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request to %s failed: %w", c.baseURL, err)
+	}
+	// Decision: Under sliding expiration the server renews session tokens near expiry; keep using the newest
+	if renewed := resp.Header.Get(types.RefreshedTokenHeader); renewed != "" {
+		c.token = renewed
+	}
+	return resp, nil
+}
+
+// decodeError turns a failed response into an *Error, falling back to the body text when it isn't an envelope
+func decodeError(status int, body []byte) error {
+	var envelope types.Envelope
+	if err := json.Unmarshal(body, &envelope); err == nil && envelope.Error != nil {
+		return &Error{Status: status, Type: envelope.Error.Type, Message: envelope.Error.Message}
+	}
+	message := strings.TrimSpace(string(body))
+	if len(message) > 200 {
+		message = message[:200]
+	}
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	return &Error{Status: status, Message: message}
+}
@@ -0,0 +1,195 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// UploadOptions are the optional fields of an upload
+type UploadOptions struct {
+	ContentType  string // Detected from the file name, then the content, when empty
+	ReadingLevel string // child, standard, or clinical; the account's setting when empty
+	PartOf       int    // Adds the file as another page of this report instead of creating one
+}
+
+// UploadReport uploads a report file read from file and queues it for analysis
+func (c *Client) UploadReport(ctx context.Context, filename string, file io.Reader, opts UploadOptions) (*types.UploadResponse, error) {
+	fields := map[string]string{"reading_level": opts.ReadingLevel}
+	if opts.PartOf > 0 {
+		fields["part_of"] = strconv.Itoa(opts.PartOf)
+	}
+	var response types.UploadResponse
+	if err := c.upload(ctx, "/api/reports", "file", filename, opts.ContentType, file, fields, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ListReports returns a page of the account's reports, newest first; limit is capped at 100 by the server
+func (c *Client) ListReports(ctx context.Context, limit, offset int) (*types.ReportListResponse, error) {
+	query := url.Values{}
+	query.Set("limit", strconv.Itoa(limit))
+	query.Set("offset", strconv.Itoa(offset))
+	var response types.ReportListResponse
+	if err := c.Do(ctx, http.MethodGet, "/api/reports?"+query.Encode(), nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GetReport returns one report
+func (c *Client) GetReport(ctx context.Context, reportID int) (*types.Report, error) {
+	var report types.Report
+	if err := c.Do(ctx, http.MethodGet, fmt.Sprintf("/api/reports/%d", reportID), nil, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// UpdateReport changes a report's title, date, or notes; nil fields are left as they are and "" clears one
+func (c *Client) UpdateReport(ctx context.Context, reportID int, req types.UpdateReportRequest) (*types.Report, error) {
+	var report types.Report
+	if err := c.Do(ctx, http.MethodPatch, fmt.Sprintf("/api/reports/%d", reportID), req, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// SetReportVisibility sets who in the account's organization may read a report: private, doctor, or care_team
+func (c *Client) SetReportVisibility(ctx context.Context, reportID int, visibility string) (*types.Report, error) {
+	var report types.Report
+	req := types.UpdateVisibilityRequest{Visibility: visibility}
+	if err := c.Do(ctx, http.MethodPatch, fmt.Sprintf("/api/reports/%d/visibility", reportID), req, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// DeleteReport deletes a report and its file
+func (c *Client) DeleteReport(ctx context.Context, reportID int) error {
+	return c.Do(ctx, http.MethodDelete, fmt.Sprintf("/api/reports/%d", reportID), nil, nil)
+}
+
+// DownloadReportFile returns the uploaded file as it was sent
+func (c *Client) DownloadReportFile(ctx context.Context, reportID int) ([]byte, error) {
+	return c.download(ctx, fmt.Sprintf("/api/reports/%d/file", reportID))
+}
+
+// GetReportSummary returns an analyzed report's summary; lang picks a translation and may be empty
+func (c *Client) GetReportSummary(ctx context.Context, reportID int, lang string) (*types.ReportSummaryResponse, error) {
+	path := fmt.Sprintf("/api/reports/%d/summary", reportID)
+	if lang != "" {
+		path += "?lang=" + url.QueryEscape(lang)
+	}
+	var response types.ReportSummaryResponse
+	if err := c.Do(ctx, http.MethodGet, path, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GetProcessingHistory returns a report's processing status and every status it went through
+func (c *Client) GetProcessingHistory(ctx context.Context, reportID int) (*types.ReportProcessingHistoryResponse, error) {
+	var response types.ReportProcessingHistoryResponse
+	if err := c.Do(ctx, http.MethodGet, fmt.Sprintf("/api/reports/%d/history", reportID), nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GetReportStatus returns how far a report has got through processing, including its place in the queue
+func (c *Client) GetReportStatus(ctx context.Context, reportID int) (*types.ReportStatusResponse, error) {
+	var response types.ReportStatusResponse
+	if err := c.Do(ctx, http.MethodGet, fmt.Sprintf("/api/reports/%d/status", reportID), nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// WaitForReport polls a report until its analysis completes or fails, or ctx ends
+// The wait between polls starts at interval and doubles up to ten times it
+func (c *Client) WaitForReport(ctx context.Context, reportID int, interval time.Duration) (*types.ReportProcessingHistoryResponse, error) {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	delay := interval
+	for {
+		history, err := c.GetProcessingHistory(ctx, reportID)
+		if err != nil {
+			return nil, err
+		}
+		if history.Status == "completed" || history.Status == "failed" {
+			return history, nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return history, ctx.Err()
+		case <-timer.C:
+		}
+		if delay < 10*interval {
+			delay *= 2
+		}
+	}
+}
+
+// ImportAnalysis makes analysis, an AnalysisResult object produced elsewhere, the report's analysis and
+// returns it as stored; needs an admin session or an API key with the analysis:import scope
+func (c *Client) ImportAnalysis(ctx context.Context, reportID int, source string, analysis any) (json.RawMessage, error) {
+	raw, err := json.Marshal(analysis)
+	if err != nil {
+		return nil, err
+	}
+	var stored json.RawMessage
+	req := types.ImportAnalysisRequest{Source: source, Analysis: raw}
+	if err := c.Do(ctx, http.MethodPost, fmt.Sprintf("/api/reports/%d/analysis", reportID), req, &stored); err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
+// HealthMetric is one analyzed lab value
+type HealthMetric struct {
+	Name        string   `json:"name"`
+	Value       any      `json:"value"` // A number or, for results like "Positive", a string
+	Unit        string   `json:"unit"`
+	Score       float64  `json:"score"`  // 0-100
+	Status      string   `json:"status"` // normal, warning, or critical
+	RangeMin    float64  `json:"range_min"`
+	RangeMax    float64  `json:"range_max"`
+	Description string   `json:"description"`
+	Conditions  []string `json:"conditions,omitempty"`
+}
+
+// HealthMetrics is a report's metrics with the calculators and panel completeness they feed
+type HealthMetrics struct {
+	ReportID     int             `json:"report_id"`
+	Metrics      []HealthMetric  `json:"metrics"`
+	Calculators  json.RawMessage `json:"calculators"`
+	Completeness json.RawMessage `json:"completeness"` // null when no panel was recognized
+	Status       string          `json:"status"`
+	Language     string          `json:"language"`
+}
+
+// GetHealthMetrics returns an analyzed report's metrics; lang picks a translation of the descriptions and may be empty
+func (c *Client) GetHealthMetrics(ctx context.Context, reportID int, lang string) (*HealthMetrics, error) {
+	path := fmt.Sprintf("/api/reports/%d/metrics", reportID)
+	if lang != "" {
+		path += "?lang=" + url.QueryEscape(lang)
+	}
+	var response HealthMetrics
+	if err := c.Do(ctx, http.MethodGet, path, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
@@ -46,6 +46,119 @@ var (
 		Message: "Authorization token missing",
 		Type:    "AUTH_ERROR",
 	}
+
+	ErrAccessDenied = &AppError{
+		Code:    http.StatusForbidden,
+		Message: "Access denied",
+		Type:    "AUTH_ERROR",
+	}
+
+	ErrSessionRequired = &AppError{
+		Code:    http.StatusForbidden,
+		Message: "Sign in with your password to manage API keys",
+		Type:    "AUTH_ERROR",
+	}
+
+	ErrSessionsRequirePassword = &AppError{
+		Code:    http.StatusForbidden,
+		Message: "Sign in with your password to manage sessions",
+		Type:    "AUTH_ERROR",
+	}
+
+	ErrScopeRequiresAdmin = &AppError{
+		Code:    http.StatusForbidden,
+		Message: "Only administrators can grant API key scopes, through POST /api/admin/api-keys",
+		Type:    "AUTH_ERROR",
+	}
+
+	ErrSessionEnded = &AppError{
+		Code:    http.StatusUnauthorized,
+		Message: "This session was signed out",
+		Type:    "SESSION_ENDED",
+	}
+
+	ErrSessionLimitReached = &AppError{
+		Code:    http.StatusUnauthorized,
+		Message: "Signed out because this account signed in on too many other devices",
+		Type:    "SESSION_ENDED",
+	}
+)
+
+// CAPTCHA errors
+// Decision: 428 tells the client to render the widget and retry; a rejected token is 403
+var (
+	ErrCaptchaRequired = &AppError{
+		Code:    http.StatusPreconditionRequired,
+		Message: "CAPTCHA verification required",
+		Type:    "CAPTCHA_ERROR",
+	}
+
+	ErrCaptchaFailed = &AppError{
+		Code:    http.StatusForbidden,
+		Message: "CAPTCHA verification failed, please try again",
+		Type:    "CAPTCHA_ERROR",
+	}
+
+	ErrCaptchaUnavailable = &AppError{
+		Code:    http.StatusServiceUnavailable,
+		Message: "CAPTCHA verification is temporarily unavailable",
+		Type:    "CAPTCHA_ERROR",
+	}
+)
+
+// Report transfer errors
+var (
+	ErrTransferPending = &AppError{
+		Code:    http.StatusConflict,
+		Message: "This report already has a pending transfer",
+		Type:    "TRANSFER_ERROR",
+	}
+
+	ErrTransferClosed = &AppError{
+		Code:    http.StatusConflict,
+		Message: "This transfer is no longer pending",
+		Type:    "TRANSFER_ERROR",
+	}
+
+	ErrShareLinkUnavailable = &AppError{
+		Code:    http.StatusNotFound,
+		Message: "This share link is invalid, expired, or has been revoked",
+		Type:    "SHARE_ERROR",
+	}
+
+	ErrSharePINRequired = &AppError{
+		Code:    http.StatusUnauthorized,
+		Message: "This share link requires a PIN",
+		Type:    "SHARE_ERROR",
+	}
+
+	ErrSharePINInvalid = &AppError{
+		Code:    http.StatusUnauthorized,
+		Message: "Incorrect PIN",
+		Type:    "SHARE_ERROR",
+	}
+
+	ErrWidgetTokenInvalid = &AppError{
+		Code:    http.StatusUnauthorized,
+		Message: "This widget token is invalid or expired; ask the site for a fresh one",
+		Type:    "SHARE_ERROR",
+	}
+
+	ErrShareLinkLocked = &AppError{
+		Code:    http.StatusLocked,
+		Message: "This share link was locked after too many incorrect PINs; ask the owner for a new link",
+		Type:    "SHARE_ERROR",
+	}
+)
+
+// Legal hold errors
+// Decision: 409 rather than 403, since the caller may delete the data once the hold is released
+var (
+	ErrLegalHold = &AppError{
+		Code:    http.StatusConflict,
+		Message: "This data is under a legal hold and can't be deleted or changed",
+		Type:    "LEGAL_HOLD_ERROR",
+	}
 )
 
 // File upload errors
@@ -62,6 +175,12 @@ var (
 		Type:    "UPLOAD_ERROR",
 	}
 
+	ErrFileTypeMismatch = &AppError{
+		Code:    http.StatusUnsupportedMediaType,
+		Message: "File content does not match its extension or declared content type",
+		Type:    "UPLOAD_ERROR",
+	}
+
 	ErrFileUploadFailed = &AppError{
 		Code:    http.StatusInternalServerError,
 		Message: "Failed to upload file",
@@ -69,6 +188,78 @@ var (
 	}
 )
 
+// Claim package errors
+var (
+	ErrClaimPackageFailed = &AppError{
+		Code:    http.StatusInternalServerError,
+		Message: "Failed to generate the claim package",
+		Type:    "CLAIM_PACKAGE_ERROR",
+	}
+)
+
+// Organization errors
+var (
+	ErrNotOrganizationMember = &AppError{
+		Code:    http.StatusNotFound,
+		Message: "You don't belong to an organization",
+		Type:    "ORGANIZATION_ERROR",
+	}
+
+	ErrOrganizationAdminRequired = &AppError{
+		Code:    http.StatusForbidden,
+		Message: "Only organization admins can change branding",
+		Type:    "ORGANIZATION_ERROR",
+	}
+)
+
+// Plan errors
+var (
+	ErrChatLimitReached = &AppError{
+		Code:    http.StatusTooManyRequests,
+		Message: "You've reached your plan's chat limit for today; try again later or upgrade",
+		Type:    "PLAN_LIMIT",
+	}
+
+	ErrPremiumRequired = &AppError{
+		Code:    http.StatusPaymentRequired,
+		Message: "This feature is part of the premium plan",
+		Type:    "PLAN_LIMIT",
+	}
+)
+
+// Payment errors
+var (
+	ErrPaymentsUnavailable = &AppError{
+		Code:    http.StatusServiceUnavailable,
+		Message: "Payments are not configured",
+		Type:    "PAYMENT_ERROR",
+	}
+
+	ErrPaymentProviderFailed = &AppError{
+		Code:    http.StatusBadGateway,
+		Message: "The payment provider could not be reached, please try again",
+		Type:    "PAYMENT_ERROR",
+	}
+
+	ErrInvalidWebhookSignature = &AppError{
+		Code:    http.StatusBadRequest,
+		Message: "Invalid webhook signature",
+		Type:    "PAYMENT_ERROR",
+	}
+
+	ErrAlreadySubscribed = &AppError{
+		Code:    http.StatusConflict,
+		Message: "You already have a premium subscription",
+		Type:    "PAYMENT_ERROR",
+	}
+
+	ErrNoSubscription = &AppError{
+		Code:    http.StatusNotFound,
+		Message: "You don't have an active subscription",
+		Type:    "PAYMENT_ERROR",
+	}
+)
+
 // Database errors
 var (
 	ErrDatabaseConnection = &AppError{
@@ -77,11 +268,23 @@ var (
 		Type:    "DATABASE_ERROR",
 	}
 
+	ErrDatabaseUnavailable = &AppError{
+		Code:    http.StatusServiceUnavailable,
+		Message: "Database temporarily unavailable, please retry shortly",
+		Type:    "DATABASE_ERROR",
+	}
+
 	ErrRecordNotFound = &AppError{
 		Code:    http.StatusNotFound,
 		Message: "Record not found",
 		Type:    "DATABASE_ERROR",
 	}
+
+	ErrVersionConflict = &AppError{
+		Code:    http.StatusConflict,
+		Message: "This was changed on another device since you loaded it; reload it and apply your edit again",
+		Type:    "VERSION_CONFLICT",
+	}
 )
 
 // Validation errors
@@ -97,6 +300,18 @@ var (
 		Message: "Missing required field",
 		Type:    "VALIDATION_ERROR",
 	}
+
+	ErrInvalidReadingLevel = &AppError{
+		Code:    http.StatusBadRequest,
+		Message: "Reading level must be child, standard, or clinical",
+		Type:    "VALIDATION_ERROR",
+	}
+
+	ErrInvalidPlan = &AppError{
+		Code:    http.StatusBadRequest,
+		Message: "Plan must be free or premium",
+		Type:    "VALIDATION_ERROR",
+	}
 )
 
 // NewValidationError creates a new validation error with custom message
@@ -116,9 +331,132 @@ var (
 		Type:    "AI_ERROR",
 	}
 
+	ErrReportBusy = &AppError{
+		Code:    http.StatusConflict,
+		Message: "The report is being analyzed; try again when it finishes",
+		Type:    "REPORT_BUSY",
+	}
+
 	ErrReportNotProcessed = &AppError{
 		Code:    http.StatusBadRequest,
 		Message: "Report has not been processed yet",
 		Type:    "AI_ERROR",
 	}
+
+	ErrAIUnavailable = &AppError{
+		Code:    http.StatusServiceUnavailable,
+		Message: "AI service not available",
+		Type:    "AI_ERROR",
+	}
+
+	ErrTTSUnavailable = &AppError{
+		Code:    http.StatusServiceUnavailable,
+		Message: "Text-to-speech is not configured",
+		Type:    "AI_ERROR",
+	}
+
+	ErrTranscriptionUnavailable = &AppError{
+		Code:    http.StatusServiceUnavailable,
+		Message: "Voice questions are not configured",
+		Type:    "AI_ERROR",
+	}
+
+	ErrTranscriptionEmpty = &AppError{
+		Code:    http.StatusUnprocessableEntity,
+		Message: "No speech was recognized in the recording",
+		Type:    "AI_ERROR",
+	}
+
+	ErrPrescriptionReaderUnavailable = &AppError{
+		Code:    http.StatusServiceUnavailable,
+		Message: "Prescription reading is not configured",
+		Type:    "AI_ERROR",
+	}
+
+	ErrPrescriptionUnreadable = &AppError{
+		Code:    http.StatusUnprocessableEntity,
+		Message: "No medications could be read from the photo; retake it in good light",
+		Type:    "AI_ERROR",
+	}
+
+	ErrGlossaryTermUnknown = &AppError{
+		Code:    http.StatusNotFound,
+		Message: "No definition found; this does not look like a medical term",
+		Type:    "AI_ERROR",
+	}
+
+	ErrTranslationUnavailable = &AppError{
+		Code:    http.StatusServiceUnavailable,
+		Message: "Translation is not configured",
+		Type:    "AI_ERROR",
+	}
+
+	ErrTranslationFailed = &AppError{
+		Code:    http.StatusBadGateway,
+		Message: "Translation failed; try again or request the summary in English",
+		Type:    "AI_ERROR",
+	}
+
+	ErrAICallLogDisabled = &AppError{
+		Code:    http.StatusServiceUnavailable,
+		Message: "AI call logging is not enabled",
+		Type:    "AI_ERROR",
+	}
+
+	ErrPromptCanaryDisabled = &AppError{
+		Code:    http.StatusServiceUnavailable,
+		Message: "No prompt canary is deployed",
+		Type:    "AI_ERROR",
+	}
+
+	ErrPromptCanaryEnded = &AppError{
+		Code:    http.StatusConflict,
+		Message: "The prompt canary was already rolled back or promoted",
+		Type:    "AI_ERROR",
+	}
+)
+
+// Appointment booking errors
+var (
+	ErrSchedulingUnavailable = &AppError{
+		Code:    http.StatusServiceUnavailable,
+		Message: "Appointment booking is not configured",
+		Type:    "SCHEDULING_ERROR",
+	}
+
+	ErrSchedulingFailed = &AppError{
+		Code:    http.StatusBadGateway,
+		Message: "The scheduling system could not book the appointment; try again later",
+		Type:    "SCHEDULING_ERROR",
+	}
+
+	ErrBookingExists = &AppError{
+		Code:    http.StatusConflict,
+		Message: "A follow-up is already requested or booked for this recommendation",
+		Type:    "SCHEDULING_ERROR",
+	}
+)
+
+// Doctor directory errors
+var (
+	ErrDoctorDirectoryUnavailable = &AppError{
+		Code:    http.StatusServiceUnavailable,
+		Message: "Doctor search is not configured",
+		Type:    "DIRECTORY_ERROR",
+	}
+
+	ErrDoctorDirectoryFailed = &AppError{
+		Code:    http.StatusBadGateway,
+		Message: "The doctor directory could not be reached; try again later",
+		Type:    "DIRECTORY_ERROR",
+	}
+)
+
+// Population insight errors
+var (
+	ErrPopulationConsentRequired = &AppError{
+		Code:    http.StatusForbidden,
+		Message: "Opt in to population insights to compare your results with your peers",
+		Type:    "CONSENT_REQUIRED",
+	}
 )
\ No newline at end of file
@@ -0,0 +1,278 @@
+// Package pdfgen writes simple PDF documents (headings, wrapped paragraphs, and JPEG images)
+// Decision: Exports only need plain text on Letter pages, which the base-14 Helvetica fonts
+// cover without embedding, so a small writer avoids pulling in a full PDF library; JPEGs
+// embed as-is through the DCTDecode filter, so logos need no image encoder either
+package pdfgen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	pageWidth   = 612 // US Letter, in points
+	pageHeight  = 792
+	margin      = 54
+	bodySize    = 10
+	headingSize = 13
+	footerSize  = 8
+	lineFactor  = 1.4
+
+	// Decision: Helvetica averages ~0.5em per character; wrapping by count keeps the writer metric-free
+	avgCharWidth = 0.5
+)
+
+// line is a single positioned run of text on a page
+type line struct {
+	text string
+	bold bool
+	size float64
+	y    float64
+}
+
+// placement positions an embedded image on a page
+type placement struct {
+	image         int // Index into Document.images
+	x, y          float64
+	width, height float64
+}
+
+// jpegImage is an embedded image and its size in pixels
+type jpegImage struct {
+	data          []byte
+	width, height int
+}
+
+// Document accumulates lines and lays them out onto pages
+type Document struct {
+	pages      [][]line
+	placements [][]placement // Per page, parallel to pages
+	images     []jpegImage
+	footer     []string
+	y          float64
+}
+
+// New creates an empty document
+func New() *Document {
+	d := &Document{}
+	d.newPage()
+	return d
+}
+
+// Heading adds a bold line, wrapped if needed
+func (d *Document) Heading(text string) {
+	d.Spacer()
+	d.addWrapped(text, true, headingSize)
+}
+
+// Paragraph adds body text; newlines in text start new lines
+func (d *Document) Paragraph(text string) {
+	for _, para := range strings.Split(text, "\n") {
+		d.addWrapped(para, false, bodySize)
+	}
+}
+
+// Label adds a bold body-sized line
+func (d *Document) Label(text string) {
+	d.addWrapped(text, true, bodySize)
+}
+
+// Spacer adds a blank line
+func (d *Document) Spacer() {
+	d.advance(bodySize)
+}
+
+// Image adds a JPEG of the given pixel size, scaled down to fit within maxWidth x maxHeight points
+func (d *Document) Image(jpeg []byte, width, height int, maxWidth, maxHeight float64) {
+	if len(jpeg) == 0 || width <= 0 || height <= 0 {
+		return
+	}
+	scale := min(maxWidth/float64(width), maxHeight/float64(height), 1)
+	w, h := float64(width)*scale, float64(height)*scale
+
+	d.y -= h
+	if d.y < margin {
+		d.newPage()
+		d.y -= h
+	}
+
+	d.images = append(d.images, jpegImage{data: jpeg, width: width, height: height})
+	current := len(d.pages) - 1
+	d.placements[current] = append(d.placements[current], placement{
+		image: len(d.images) - 1, x: margin, y: d.y, width: w, height: h,
+	})
+}
+
+// SetFooter prints text in small type at the bottom of every page; at most three lines fit in the margin
+func (d *Document) SetFooter(text string) {
+	maxChars := int(float64(pageWidth-2*margin) / (footerSize * avgCharWidth))
+	d.footer = nil
+	for _, para := range strings.Split(text, "\n") {
+		d.footer = append(d.footer, wrap(para, maxChars)...)
+	}
+	if len(d.footer) > 3 {
+		d.footer = d.footer[:3]
+	}
+}
+
+// Bytes renders the document as a PDF file
+func (d *Document) Bytes() []byte {
+	var buf bytes.Buffer
+	var offsets []int
+
+	startObject := func() int {
+		offsets = append(offsets, buf.Len())
+		id := len(offsets)
+		fmt.Fprintf(&buf, "%d 0 obj\n", id)
+		return id
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+
+	// Objects 1-4 are fixed: catalog, page tree, regular and bold fonts; images follow, then pages
+	firstImage := 5
+	firstPage := firstImage + len(d.images)
+	pageCount := len(d.pages)
+	kids := make([]string, pageCount)
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+i*2)
+	}
+
+	var xObjects strings.Builder
+	for i := range d.images {
+		fmt.Fprintf(&xObjects, " /Im%d %d 0 R", i+1, firstImage+i)
+	}
+
+	startObject()
+	buf.WriteString("<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+	startObject()
+	fmt.Fprintf(&buf, "<< /Type /Pages /Kids [%s] /Count %d >>\nendobj\n", strings.Join(kids, " "), pageCount)
+	startObject()
+	buf.WriteString("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>\nendobj\n")
+	startObject()
+	buf.WriteString("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>\nendobj\n")
+
+	for _, img := range d.images {
+		startObject()
+		fmt.Fprintf(&buf, "<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB "+
+			"/BitsPerComponent 8 /Filter /DCTDecode /Length %d >>\nstream\n", img.width, img.height, len(img.data))
+		buf.Write(img.data)
+		buf.WriteString("\nendstream\nendobj\n")
+	}
+
+	for i, page := range d.pages {
+		pageID := startObject()
+		fmt.Fprintf(&buf, "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> /XObject <<%s >> >> /Contents %d 0 R >>\nendobj\n",
+			pageWidth, pageHeight, xObjects.String(), pageID+1)
+
+		var content bytes.Buffer
+		for _, p := range d.placements[i] {
+			fmt.Fprintf(&content, "q %.2f 0 0 %.2f %.2f %.2f cm /Im%d Do Q\n", p.width, p.height, p.x, p.y, p.image+1)
+		}
+		for _, l := range page {
+			font := "F1"
+			if l.bold {
+				font = "F2"
+			}
+			fmt.Fprintf(&content, "BT /%s %.1f Tf %d %.2f Td (%s) Tj ET\n", font, l.size, margin, l.y, escape(l.text))
+		}
+		for j, text := range d.footer {
+			y := margin - 18 - float64(j)*footerSize*lineFactor
+			fmt.Fprintf(&content, "BT /F1 %d Tf %d %.2f Td (%s) Tj ET\n", footerSize, margin, y, escape(text))
+		}
+
+		startObject()
+		fmt.Fprintf(&buf, "<< /Length %d >>\nstream\n", content.Len())
+		buf.Write(content.Bytes())
+		buf.WriteString("endstream\nendobj\n")
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return buf.Bytes()
+}
+
+// addWrapped breaks text into lines that fit the page width
+func (d *Document) addWrapped(text string, bold bool, size float64) {
+	maxChars := int(float64(pageWidth-2*margin) / (size * avgCharWidth))
+	for _, wrapped := range wrap(text, maxChars) {
+		d.advance(size)
+		current := len(d.pages) - 1
+		d.pages[current] = append(d.pages[current], line{text: wrapped, bold: bold, size: size, y: d.y})
+	}
+}
+
+// advance moves the cursor down one line, starting a new page when it runs out
+func (d *Document) advance(size float64) {
+	d.y -= size * lineFactor
+	if d.y < margin {
+		d.newPage()
+		d.y -= size * lineFactor
+	}
+}
+
+func (d *Document) newPage() {
+	d.pages = append(d.pages, nil)
+	d.placements = append(d.placements, nil)
+	d.y = pageHeight - margin
+}
+
+// wrap splits text at word boundaries into chunks of at most width characters
+func wrap(text string, width int) []string {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return []string{""}
+	}
+
+	var lines []string
+	current := ""
+	for _, word := range words {
+		// Decision: Hard-break words longer than a line (URLs, long values)
+		for len([]rune(word)) > width {
+			if current != "" {
+				lines = append(lines, current)
+				current = ""
+			}
+			runes := []rune(word)
+			lines = append(lines, string(runes[:width]))
+			word = string(runes[width:])
+		}
+
+		switch {
+		case current == "":
+			current = word
+		case len([]rune(current))+1+len([]rune(word)) <= width:
+			current += " " + word
+		default:
+			lines = append(lines, current)
+			current = word
+		}
+	}
+	return append(lines, current)
+}
+
+// escape encodes text as a PDF literal string in WinAnsi (Latin-1 subset)
+func escape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 32 && r < 127:
+			b.WriteRune(r)
+		case r >= 160 && r <= 255:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,336 @@
+package types
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type PromptVariantStats struct {
+	PromptVersion    string   `json:"prompt_version"`
+	Total            int      `json:"total"`
+	ParseFailures    int      `json:"parse_failures"`
+	ParseFailureRate float64  `json:"parse_failure_rate"`
+	FeedbackCount    int      `json:"feedback_count"`
+	AverageRating    *float64 `json:"average_rating"`
+}
+
+type PromptStatsResponse struct {
+	Variants []PromptVariantStats `json:"variants"`
+}
+
+type PromptCanaryArm struct {
+	Versions           []string `json:"versions"`
+	Analyses           int      `json:"analyses"`
+	ParseFailures      int      `json:"parse_failures"`
+	ParseFailureRate   float64  `json:"parse_failure_rate"`
+	MedianSummaryWords int      `json:"median_summary_words"`
+	P90SummaryWords    int      `json:"p90_summary_words"`
+}
+
+type PromptCanaryResponse struct {
+	ID         int             `json:"id"`
+	Version    string          `json:"version"`
+	PromptPath string          `json:"prompt_path"`
+	Model      string          `json:"model,omitempty"` // Empty when it runs on the stable model
+	Percent    int             `json:"percent"`
+	Status     string          `json:"status"` // active, rolled_back, or promoted
+	Reason     string          `json:"reason,omitempty"`
+	StartedAt  time.Time       `json:"started_at"`
+	EndedAt    *time.Time      `json:"ended_at"`
+	EndedBy    int             `json:"ended_by,omitempty"` // 0 for an automatic rollback
+	Canary     PromptCanaryArm `json:"canary"`
+	Stable     PromptCanaryArm `json:"stable"`
+	Verdict    string          `json:"verdict"` // collecting, healthy, or worse
+	Finding    string          `json:"finding,omitempty"`
+}
+
+type EndPromptCanaryRequest struct {
+	Reason string `json:"reason"`
+}
+
+type ImpersonateRequest struct {
+	Reason string `json:"reason"` // Support ticket or description; stored in the audit log
+}
+
+type ImpersonationResponse struct {
+	Token          string    `json:"token"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	User           User      `json:"user"`
+	ImpersonatorID int       `json:"impersonator_id"`
+}
+
+type AuditLogEntry struct {
+	ID           int       `json:"id"`
+	ActorID      int       `json:"actor_id"`
+	UserID       int       `json:"user_id"`
+	Action       string    `json:"action"`
+	Method       string    `json:"method,omitempty"`
+	Path         string    `json:"path,omitempty"`
+	StatusCode   int       `json:"status_code,omitempty"`
+	Impersonated bool      `json:"impersonated"`
+	Details      string    `json:"details,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type APIUsageEntry struct {
+	Method       string     `json:"method"`
+	Route        string     `json:"route"`
+	UserID       int        `json:"user_id"` // 0 for unauthenticated callers
+	Email        string     `json:"email,omitempty"`
+	Calls        int        `json:"calls"`
+	Days         int        `json:"days"` // Days with at least one call
+	LastCalledAt time.Time  `json:"last_called_at"`
+	Deprecated   bool       `json:"deprecated"`
+	Sunset       *time.Time `json:"sunset,omitempty"`
+	Successor    string     `json:"successor,omitempty"`
+}
+
+type SafetyInterventionEntry struct {
+	ID             int       `json:"id"`
+	UserID         int       `json:"user_id"`
+	ReportID       int       `json:"report_id"`
+	MessageID      int       `json:"message_id"`
+	Level          string    `json:"level"`
+	Categories     []string  `json:"categories"` // dosage, diagnosis, emergency
+	Removed        []string  `json:"removed"`    // Sentences taken out of the answer
+	OriginalAnswer string    `json:"original_answer"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type CrisisFlagEntry struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	ReportID  int       `json:"report_id"`
+	MessageID int       `json:"message_id"`
+	Category  string    `json:"category"` // self_harm or emergency
+	Keyword   string    `json:"keyword"`  // The phrase that matched
+	Question  string    `json:"question"`
+	InputMode string    `json:"input_mode"`
+	Country   string    `json:"country"` // Whose contacts were given; empty for the generic fallback
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type AICallEntry struct {
+	ID         int             `json:"id"`
+	ReportID   int             `json:"report_id"`
+	Purpose    string          `json:"purpose"` // analysis, merge, chat, summary, translation, glossary or annual
+	Provider   string          `json:"provider"`
+	Model      string          `json:"model"`
+	Parameters json.RawMessage `json:"parameters"`
+	Prompt     string          `json:"prompt"`
+	Response   string          `json:"response"`
+	Error      string          `json:"error,omitempty"`
+	DurationMs int64           `json:"duration_ms"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+type QueueState struct {
+	Paused   bool       `json:"paused"`
+	Reason   string     `json:"reason,omitempty"`
+	PausedBy *int       `json:"paused_by,omitempty"`
+	PausedAt *time.Time `json:"paused_at,omitempty"`
+	Drained  bool       `json:"drained"` // Paused and nothing still processing; safe to start maintenance
+}
+
+type QueueJob struct {
+	ReportID         int       `json:"report_id"`
+	UserID           int       `json:"user_id"`
+	OriginalFilename string    `json:"original_filename"`
+	Status           string    `json:"status"`
+	Stuck            bool      `json:"stuck"`
+	Attempts         int       `json:"attempts"`
+	ErrorCode        string    `json:"error_code,omitempty"` // Why a failed job failed
+	LastError        string    `json:"last_error,omitempty"`
+	UploadDate       time.Time `json:"upload_date"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+type QueueOverviewResponse struct {
+	Queue         QueueState     `json:"queue"`
+	Counts        map[string]int `json:"counts"`         // Reports per processing status
+	FailureCauses map[string]int `json:"failure_causes"` // Failed reports per error code
+	Jobs          []QueueJob     `json:"jobs"`
+}
+
+type ProcessingAttempt struct {
+	ID         int        `json:"id"`
+	Status     string     `json:"status"`
+	Model      string     `json:"model,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+}
+
+type JobDetailResponse struct {
+	ReportID         int                 `json:"report_id"`
+	UserID           int                 `json:"user_id"`
+	OriginalFilename string              `json:"original_filename"`
+	Status           string              `json:"status"`
+	Stuck            bool                `json:"stuck"`
+	ErrorCode        string              `json:"error_code,omitempty"`
+	LastError        string              `json:"last_error,omitempty"`
+	Attempts         []ProcessingAttempt `json:"attempts"`
+}
+
+type QueueActionRequest struct {
+	Reason string `json:"reason"` // Required to pause; optional when cancelling a job
+}
+
+type AnalysisReview struct {
+	ReportID         int        `json:"report_id"`
+	UserID           int        `json:"user_id"`
+	OriginalFilename string     `json:"original_filename"`
+	PromptVersion    string     `json:"prompt_version"`
+	ParseError       string     `json:"parse_error"`
+	RawOutput        string     `json:"raw_output,omitempty"` // Only in single-review responses
+	CreatedAt        time.Time  `json:"created_at"`
+	ResolvedAt       *time.Time `json:"resolved_at"`
+}
+
+type ReparseAnalysisRequest struct {
+	RawOutput string `json:"raw_output"` // Hand-corrected model output; empty re-parses the stored output
+}
+
+type StartReanalysisRequest struct {
+	Reason    string `json:"reason"`
+	UserID    int    `json:"user_id,omitempty"` // Only this user's reports
+	Since     string `json:"since,omitempty"`   // First upload day, YYYY-MM-DD
+	Until     string `json:"until,omitempty"`   // Last upload day, YYYY-MM-DD
+	ReportIDs []int  `json:"report_ids,omitempty"`
+	Limit     int    `json:"limit,omitempty"`   // Default 100, at most 1000
+	DryRun    bool   `json:"dry_run,omitempty"` // List the reports without starting a run
+}
+
+type ReanalysisPreviewResponse struct {
+	PromptVersion string `json:"prompt_version"`
+	Model         string `json:"model"`
+	ReportIDs     []int  `json:"report_ids"`
+}
+
+type ReanalysisRun struct {
+	ID            int            `json:"id"`
+	CreatedBy     int            `json:"created_by"`
+	Reason        string         `json:"reason"`
+	PromptVersion string         `json:"prompt_version"`
+	Model         string         `json:"model"`
+	Status        string         `json:"status"` // running, completed, or cancelled
+	Counts        map[string]int `json:"counts"` // Reports per status: pending, running, succeeded, failed, skipped
+	CreatedAt     time.Time      `json:"created_at"`
+	FinishedAt    *time.Time     `json:"finished_at"`
+}
+
+type ReanalysisRunsResponse struct {
+	Runs []ReanalysisRun `json:"runs"`
+}
+
+type ReanalysisItem struct {
+	ReportID         int        `json:"report_id"`
+	Status           string     `json:"status"`
+	OldPromptVersion string     `json:"old_prompt_version"`
+	NewPromptVersion string     `json:"new_prompt_version,omitempty"`
+	Error            string     `json:"error,omitempty"`
+	FinishedAt       *time.Time `json:"finished_at"`
+}
+
+type ReanalysisRunResponse struct {
+	Run     ReanalysisRun    `json:"run"`
+	Reports []ReanalysisItem `json:"reports"`
+}
+
+type ValueChange struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+type MetricChange struct {
+	Name      string `json:"name"`
+	Change    string `json:"change"` // added, removed, or changed
+	OldValue  string `json:"old_value,omitempty"`
+	NewValue  string `json:"new_value,omitempty"`
+	OldStatus string `json:"old_status,omitempty"`
+	NewStatus string `json:"new_status,omitempty"`
+}
+
+type ListChange struct {
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+}
+
+type ReanalysisDiffResponse struct {
+	ReanalysisItem
+	RiskLevel       *ValueChange    `json:"risk_level,omitempty"`     // Only when it changed
+	SimpleSummary   *ValueChange    `json:"simple_summary,omitempty"` // Only when it changed
+	Metrics         []MetricChange  `json:"metrics"`
+	KeyFindings     ListChange      `json:"key_findings"`
+	Recommendations ListChange      `json:"recommendations"`
+	OldAnalysis     json.RawMessage `json:"old_analysis,omitempty"`
+	NewAnalysis     json.RawMessage `json:"new_analysis,omitempty"`
+}
+
+type FlaggedChatEntry struct {
+	MessageID   int        `json:"message_id"`
+	ReportID    int        `json:"report_id"`
+	UserID      int        `json:"user_id"`
+	UserMessage string     `json:"user_message"`
+	AIResponse  string     `json:"ai_response"`
+	InputMode   string     `json:"input_mode"`
+	IsDeleted   bool       `json:"is_deleted"` // Deleted by the user; still moderated
+	Sources     []string   `json:"sources"`    // safety, crisis and/or user
+	FlagCount   int        `json:"flag_count"`
+	Open        bool       `json:"open"`
+	Action      string     `json:"action,omitempty"` // dismissed or redacted, once reviewed
+	Note        string     `json:"note,omitempty"`
+	ReviewedBy  *int       `json:"reviewed_by,omitempty"`
+	ReviewedAt  *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type ChatFlagEntry struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type FlaggedChatDetail struct {
+	FlaggedChatEntry
+	Flags []ChatFlagEntry `json:"flags"`
+}
+
+type DismissChatRequest struct {
+	Note string `json:"note"` // Optional
+}
+
+type RedactChatRequest struct {
+	Question bool   `json:"question"` // Remove the user's question
+	Answer   bool   `json:"answer"`   // Remove the assistant's answer
+	Note     string `json:"note"`     // Optional
+}
+
+type BanUserRequest struct {
+	Reason string `json:"reason"`
+}
+
+type UserBanResponse struct {
+	UserID    int       `json:"user_id"`
+	Reason    string    `json:"reason"`
+	BannedBy  int       `json:"banned_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type PlaceLegalHoldRequest struct {
+	Reason    string `json:"reason"`
+	Reference string `json:"reference"` // Optional case or request number
+}
+
+type LegalHoldResponse struct {
+	UserID    int       `json:"user_id"`
+	Reason    string    `json:"reason"`
+	Reference string    `json:"reference,omitempty"`
+	PlacedBy  int       `json:"placed_by"`
+	PlacedAt  time.Time `json:"placed_at"`
+}
+
+type LegalHoldListResponse struct {
+	Holds []LegalHoldResponse `json:"holds"`
+}
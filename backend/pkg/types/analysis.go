@@ -0,0 +1,63 @@
+package types
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type MergeAnalysisRequest struct {
+	ReportIDs    []int  `json:"report_ids" validate:"required,min=2,max=10"`
+	Title        string `json:"title,omitempty" validate:"omitempty,max=200"` // Defaults to "Combined analysis of N reports"
+	ReadingLevel string `json:"reading_level,omitempty"`                      // Overrides the user's preference
+}
+
+type MergedAnalysisSource struct {
+	ReportID   *int    `json:"report_id"` // Null once the source report was deleted
+	Label      string  `json:"label"`
+	ReportDate *string `json:"report_date"` // YYYY-MM-DD, when set on the report
+}
+
+type MergedAnalysis struct {
+	ID            int                    `json:"id"`
+	Title         string                 `json:"title"`
+	ReadingLevel  string                 `json:"reading_level"`
+	PromptVersion string                 `json:"prompt_version"`
+	CreatedAt     time.Time              `json:"created_at"`
+	Sources       []MergedAnalysisSource `json:"sources"`
+	Analysis      json.RawMessage        `json:"analysis"` // Same shape as a report's analysis
+}
+
+type MergedAnalysisListResponse struct {
+	Analyses []MergedAnalysis `json:"analyses"`
+}
+
+type MetricTrend struct {
+	Name         string `json:"name"`
+	Unit         string `json:"unit"`
+	Measurements int    `json:"measurements"`
+	FirstValue   string `json:"first_value"`
+	FirstStatus  string `json:"first_status"`
+	FirstDate    string `json:"first_date"` // YYYY-MM-DD
+	LastValue    string `json:"last_value"`
+	LastStatus   string `json:"last_status"`
+	LastDate     string `json:"last_date"` // YYYY-MM-DD
+	Direction    string `json:"direction"` // improved, worsened, or stable, judged by status
+}
+
+type AnnualReview struct {
+	Year                      int           `json:"year"`
+	ReportCount               int           `json:"report_count"`
+	Overview                  string        `json:"overview"`
+	Improvements              []string      `json:"improvements"`
+	Deteriorations            []string      `json:"deteriorations"`
+	UnresolvedRecommendations []string      `json:"unresolved_recommendations"`
+	Trends                    []MetricTrend `json:"trends"`
+	ReadingLevel              string        `json:"reading_level"`
+	GeneratedAt               time.Time     `json:"generated_at"`
+}
+
+// ImportAnalysisRequest attaches an analysis produced outside this service to a report
+type ImportAnalysisRequest struct {
+	Source   string          `json:"source"`   // The system that produced it, e.g. "city-hospital-nlp v3"; kept in the audit log
+	Analysis json.RawMessage `json:"analysis"` // An AnalysisResult object
+}
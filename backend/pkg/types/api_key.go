@@ -0,0 +1,29 @@
+package types
+
+import "time"
+
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name"`             // What the key is for, e.g. "lab sync script"
+	Scopes []string `json:"scopes,omitempty"` // Admin-only privileges, e.g. analysis:import
+}
+
+// APIKey describes a key without its secret
+type APIKey struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"` // First characters of the key
+	Scopes     []string   `json:"scopes"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CreateAPIKeyResponse carries the key itself, returned only when it is created
+type CreateAPIKeyResponse struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+type APIKeysResponse struct {
+	Keys []APIKey `json:"keys"`
+}
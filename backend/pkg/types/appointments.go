@@ -0,0 +1,32 @@
+package types
+
+import "time"
+
+// BookFollowUpRequest asks for a follow-up appointment for one of a report's recommendations
+type BookFollowUpRequest struct {
+	Specialty     string     `json:"specialty"`      // Optional; a key from GET /api/specialties
+	PreferredTime *time.Time `json:"preferred_time"` // Optional; RFC 3339
+	Notes         string     `json:"notes"`          // Optional; passed on to the clinic
+}
+
+// AppointmentBooking is a follow-up booked through the scheduling system
+type AppointmentBooking struct {
+	ID                  int        `json:"id"`
+	ReportID            int        `json:"report_id"`
+	RecommendationIndex int        `json:"recommendation_index"`
+	Recommendation      string     `json:"recommendation"` // As it read when the booking was made
+	Specialty           string     `json:"specialty,omitempty"`
+	PreferredTime       *time.Time `json:"preferred_time"`
+	Status              string     `json:"status"` // requested, pending, booked, cancelled, or failed
+	Reference           string     `json:"reference,omitempty"`
+	BookingURL          string     `json:"booking_url,omitempty"`
+	ScheduledAt         *time.Time `json:"scheduled_at"`
+	Error               string     `json:"error,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+type AppointmentBookingsResponse struct {
+	ReportID int                  `json:"report_id"`
+	Bookings []AppointmentBooking `json:"bookings"`
+}
@@ -0,0 +1,24 @@
+package types
+
+import "time"
+
+// ClaimPackageRequest holds the optional details printed on a claim package's cover sheet
+type ClaimPackageRequest struct {
+	Insurer        string `json:"insurer"`
+	PolicyNumber   string `json:"policy_number"`
+	ClaimReference string `json:"claim_reference"`
+}
+
+// ClaimPackage describes a report's stored claim package
+type ClaimPackage struct {
+	ID             int       `json:"id"`
+	ReportID       int       `json:"report_id"`
+	Filename       string    `json:"filename"`
+	FileSize       int64     `json:"file_size"`
+	Checksum       string    `json:"checksum"` // Hex SHA-256 of the ZIP
+	Insurer        string    `json:"insurer,omitempty"`
+	PolicyNumber   string    `json:"policy_number,omitempty"`
+	ClaimReference string    `json:"claim_reference,omitempty"`
+	DownloadURL    string    `json:"download_url"`
+	CreatedAt      time.Time `json:"created_at"`
+}
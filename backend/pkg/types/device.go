@@ -0,0 +1,24 @@
+package types
+
+import "time"
+
+type RegisterDeviceRequest struct {
+	Platform string `json:"platform"` // fcm (Android, web) or apns (iOS)
+	Token    string `json:"token"`    // The registration token the platform's SDK gave the app
+	Name     string `json:"name"`     // Optional, e.g. "Pixel 8"
+}
+
+// Device describes a registered device without its token
+type Device struct {
+	ID          int       `json:"id"`
+	Platform    string    `json:"platform"`
+	Name        string    `json:"name"`
+	PushEnabled bool      `json:"push_enabled"` // False while the server has no credentials for the platform
+	CreatedAt   time.Time `json:"created_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+}
+
+type DevicesResponse struct {
+	Devices   []Device `json:"devices"`
+	Platforms []string `json:"platforms"` // Platforms the server can push to
+}
@@ -0,0 +1,84 @@
+package types
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Envelope is the body of every JSON API response
+// Decision: Exactly one of Data or Error is set, so clients check one field instead of sniffing shapes
+type Envelope struct {
+	Data  any       `json:"data"`
+	Error *APIError `json:"error"`
+	Meta  *Meta     `json:"meta,omitempty"`
+}
+
+// APIError describes why a request failed
+type APIError struct {
+	Status         int    `json:"status"`
+	Type           string `json:"type"`
+	Message        string `json:"message"`
+	CurrentVersion int    `json:"current_version,omitempty"` // Set on VERSION_CONFLICT; the version the edit lost to
+}
+
+// Meta carries response-level details that aren't part of the resource itself
+type Meta struct {
+	Pagination *Pagination `json:"pagination,omitempty"`
+}
+
+// Pagination describes the page of a list response
+type Pagination struct {
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+	Count  int `json:"count"` // Items in this page
+}
+
+// DataEnvelope wraps a successful response; meta may be nil
+func DataEnvelope(data any, meta *Meta) Envelope {
+	return Envelope{Data: data, Meta: meta}
+}
+
+// ErrorEnvelope wraps a failed response
+// Decision: An empty errType falls back to the status name (e.g. NOT_FOUND) so type is always set
+func ErrorEnvelope(status int, errType, message string) Envelope {
+	if errType == "" {
+		errType = strings.ToUpper(strings.ReplaceAll(http.StatusText(status), " ", "_"))
+	}
+	return Envelope{Error: &APIError{Status: status, Type: errType, Message: message}}
+}
+
+type MessageResponse struct {
+	Message string `json:"message"`
+}
+
+type TokenResponse struct {
+	Token            string    `json:"token"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"` // Replaces the one sent, which is now spent
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	Message          string    `json:"message"`
+}
+
+// Headers on authenticated responses that let clients renew session tokens before they expire
+const (
+	TokenExpiresInHeader        = "X-Token-Expires-In"        // Seconds until the session token expires
+	TokenRefreshSuggestedHeader = "X-Token-Refresh-Suggested" // "true" once the token is close to expiry
+	RefreshedTokenHeader        = "X-Refreshed-Token"         // A renewed token, sent under sliding expiration
+)
+
+type HealthMetricsResponse struct {
+	ReportID     int    `json:"report_id"`
+	Metrics      any    `json:"metrics"`
+	Calculators  any    `json:"calculators"`  // Risk calculators fed by these metrics; see GET /api/calculators/{name}
+	Completeness any    `json:"completeness"` // Detected panels, missing tests, and hints; null when no panel was recognized
+	Status       string `json:"status"`
+	Language     string `json:"language"` // Language of the metric descriptions
+}
+
+type HealthResponse struct {
+	Status   string `json:"status"`
+	Service  string `json:"service"`
+	Version  string `json:"version"`
+	Database any    `json:"database,omitempty"`
+}
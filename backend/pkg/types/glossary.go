@@ -0,0 +1,8 @@
+package types
+
+type GlossaryEntry struct {
+	Term       string `json:"term"`
+	Key        string `json:"key"`
+	Definition string `json:"definition"`
+	Source     string `json:"source"` // builtin or ai
+}
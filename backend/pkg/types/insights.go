@@ -0,0 +1,37 @@
+package types
+
+import "time"
+
+// PopulationConsentRequest opts the caller in to or out of the population statistics
+type PopulationConsentRequest struct {
+	OptedIn *bool `json:"opted_in" validate:"required"`
+}
+
+// PopulationConsentResponse is the caller's current choice
+type PopulationConsentResponse struct {
+	OptedIn     bool       `json:"opted_in"`
+	ConsentedAt *time.Time `json:"consented_at"` // Null when not opted in
+}
+
+// PopulationInsightsResponse compares the caller's latest values with peers in their age band
+type PopulationInsightsResponse struct {
+	AgeBand   string             `json:"age_band"`   // 18-29, 30-39, 40-49, 50-59, 60-69, or 70+
+	MinCohort int                `json:"min_cohort"` // Fewest users a distribution is published from
+	Metrics   []PopulationMetric `json:"metrics"`    // Only metrics whose cohort reached min_cohort
+}
+
+// PopulationMetric is one of the caller's values against the distribution of their peers
+type PopulationMetric struct {
+	Name         string    `json:"name"`
+	Unit         string    `json:"unit"`
+	Value        float64   `json:"value"`     // The caller's latest value
+	ReportID     int       `json:"report_id"` // The report it came from
+	Position     string    `json:"position"`  // below_p10, p10_p25, p25_p50, p50_p75, p75_p90, or above_p90
+	Contributors int       `json:"contributors"`
+	P10          float64   `json:"p10"`
+	P25          float64   `json:"p25"`
+	P50          float64   `json:"p50"`
+	P75          float64   `json:"p75"`
+	P90          float64   `json:"p90"`
+	ComputedAt   time.Time `json:"computed_at"`
+}
@@ -0,0 +1,11 @@
+package types
+
+import "time"
+
+type Notification struct {
+	ID        int        `json:"id"`
+	Kind      string     `json:"kind"`
+	Message   string     `json:"message"`
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,48 @@
+package types
+
+import "time"
+
+type CreateOrganizationRequest struct {
+	Name string `json:"name"`
+}
+
+type AddOrganizationMemberRequest struct {
+	Email       string `json:"email"`
+	Role        string `json:"role"`                   // member (default), admin, doctor, or care_team
+	DoctorEmail string `json:"doctor_email,omitempty"` // A member's assigned doctor, already a doctor in the organization
+}
+
+type UpdateBrandingRequest struct {
+	Name        string   `json:"name"` // Empty keeps the current name
+	FooterText  string   `json:"footer_text"`
+	ContactInfo string   `json:"contact_info"`
+	Sections    []string `json:"sections"` // Export sections in print order; empty restores the default
+}
+
+type OrganizationBranding struct {
+	ID                int       `json:"id"`
+	Name              string    `json:"name"`
+	Role              string    `json:"role,omitempty"` // The caller's role; omitted in admin responses
+	FooterText        string    `json:"footer_text"`
+	ContactInfo       string    `json:"contact_info"`
+	HasLogo           bool      `json:"has_logo"`
+	Sections          []string  `json:"sections"`
+	AvailableSections []string  `json:"available_sections"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+type OrganizationUsage struct {
+	OrganizationID   int        `json:"organization_id"`
+	OrganizationName string     `json:"organization_name"`
+	ReportsProcessed int        `json:"reports_processed"` // Analyses completed for members' reports
+	AICalls          int        `json:"ai_calls"`          // Successful model calls made with members' data
+	AIInputTokens    int64      `json:"ai_input_tokens"`   // As reported by the model provider
+	AIOutputTokens   int64      `json:"ai_output_tokens"`
+	StorageBytes     int64      `json:"storage_bytes"` // Most bytes of report files the members held at once, measured hourly
+	UpdatedAt        *time.Time `json:"updated_at"`    // Last change to the month's usage; null when there was none
+}
+
+type OrganizationUsageResponse struct {
+	Month         string              `json:"month"` // YYYY-MM, UTC
+	Organizations []OrganizationUsage `json:"organizations"`
+}
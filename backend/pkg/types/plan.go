@@ -0,0 +1,53 @@
+package types
+
+import "time"
+
+// PlanResponse describes the caller's plan, its limits, and how much of the chat allowance is used
+type PlanResponse struct {
+	Plan   string     `json:"plan"` // free or premium
+	Limits PlanLimits `json:"limits"`
+	Usage  PlanUsage  `json:"usage"`
+}
+
+// PlanLimits are what one plan allows; 0 means no limit
+type PlanLimits struct {
+	MaxOutputTokens    int32    `json:"max_output_tokens"` // 0 uses the deployment's default
+	SummaryWords       int      `json:"summary_words"`
+	MaxFindings        int      `json:"max_findings"` // Applies to key findings and to recommendations
+	ChatMessagesPerDay int      `json:"chat_messages_per_day"`
+	Features           []string `json:"features"` // Premium features included, e.g. cross_report and pdf_export
+}
+
+// PlanUsage counts chat questions over the rolling 24 hours the allowance covers
+type PlanUsage struct {
+	ChatMessages  int       `json:"chat_messages"`
+	ChatRemaining *int      `json:"chat_remaining"` // Null when chat is unlimited
+	WindowStart   time.Time `json:"window_start"`
+}
+
+// UpdatePlanRequest moves a user to another plan
+type UpdatePlanRequest struct {
+	Plan string `json:"plan"`
+}
+
+// CheckoutResponse is the provider's payment page for upgrading to premium
+type CheckoutResponse struct {
+	Provider    string `json:"provider"` // stripe or razorpay
+	CheckoutURL string `json:"checkout_url"`
+}
+
+// Subscription is the caller's premium subscription as last reported by the payment provider
+type Subscription struct {
+	Provider          string     `json:"provider"`
+	Status            string     `json:"status"` // incomplete, active, past_due, paused, or canceled
+	CurrentPeriodEnd  *time.Time `json:"current_period_end"`
+	CancelAtPeriodEnd bool       `json:"cancel_at_period_end"` // Downgrades to free when the period ends
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// BillingResponse describes the caller's plan and subscription
+type BillingResponse struct {
+	Plan            string        `json:"plan"`
+	PaymentsEnabled bool          `json:"payments_enabled"`
+	Subscription    *Subscription `json:"subscription"` // Null for users who never subscribed
+}
@@ -0,0 +1,40 @@
+package types
+
+import "time"
+
+type PrescriptionMedication struct {
+	Name          string  `json:"name"`
+	Dosage        string  `json:"dosage"`
+	Frequency     string  `json:"frequency"`
+	Duration      string  `json:"duration"`
+	Instructions  string  `json:"instructions"`
+	Confidence    float64 `json:"confidence"`     // How sure the reader was of the line, 0-1
+	LowConfidence bool    `json:"low_confidence"` // Check this line against the photo
+	Corrected     bool    `json:"corrected"`
+}
+
+type Prescription struct {
+	ID           int                      `json:"id"`
+	Prescriber   string                   `json:"prescriber"`
+	PrescribedOn string                   `json:"prescribed_on"` // As written on the prescription
+	Notes        string                   `json:"notes"`
+	Medications  []PrescriptionMedication `json:"medications"`
+	NeedsReview  bool                     `json:"needs_review"` // Some line is still low confidence
+	Reader       string                   `json:"reader"`
+	ImageURL     string                   `json:"image_url"`
+	CreatedAt    time.Time                `json:"created_at"`
+	UpdatedAt    time.Time                `json:"updated_at"`
+}
+
+type PrescriptionListResponse struct {
+	Prescriptions []Prescription `json:"prescriptions"`
+}
+
+// Omitted fields keep their value; an empty body confirms the line as read
+type MedicationCorrectionRequest struct {
+	Name         *string `json:"name,omitempty" validate:"omitempty,max=200"`
+	Dosage       *string `json:"dosage,omitempty" validate:"omitempty,max=200"`
+	Frequency    *string `json:"frequency,omitempty" validate:"omitempty,max=200"`
+	Duration     *string `json:"duration,omitempty" validate:"omitempty,max=200"`
+	Instructions *string `json:"instructions,omitempty" validate:"omitempty,max=200"`
+}
@@ -0,0 +1,51 @@
+package types
+
+// Specialty is a kind of specialist referrals can suggest
+type Specialty struct {
+	Key  string `json:"key"`
+	Name string `json:"name"`
+	Area string `json:"area"` // What the specialist looks after
+}
+
+type SpecialtiesResponse struct {
+	Specialties      []Specialty `json:"specialties"`
+	DirectoryEnabled bool        `json:"directory_enabled"` // Whether GET /api/doctors and nearby doctors are available
+}
+
+// ReferralReason is an out-of-range result behind a suggestion
+type ReferralReason struct {
+	Metric string `json:"metric"`
+	Value  string `json:"value"`
+	Unit   string `json:"unit"`
+	Status string `json:"status"` // warning or critical
+}
+
+// Doctor is one entry of the provider directory
+type Doctor struct {
+	Name       string   `json:"name"`
+	Specialty  string   `json:"specialty"`
+	Address    string   `json:"address"`
+	Phone      string   `json:"phone"`
+	URL        string   `json:"url"`
+	DistanceKm *float64 `json:"distance_km"`
+}
+
+// ReferralSuggestion is a specialist worth consulting about some of a report's results
+type ReferralSuggestion struct {
+	Specialty Specialty        `json:"specialty"`
+	Urgency   string           `json:"urgency"` // soon or routine
+	Reasons   []ReferralReason `json:"reasons"`
+	Doctors   []Doctor         `json:"doctors"` // Null unless a location was given and the directory answered
+}
+
+type ReferralsResponse struct {
+	ReportID         int                  `json:"report_id"`
+	Suggestions      []ReferralSuggestion `json:"suggestions"`
+	DirectoryEnabled bool                 `json:"directory_enabled"`
+	Note             string               `json:"note"`
+}
+
+type DoctorsResponse struct {
+	Specialty string   `json:"specialty"`
+	Doctors   []Doctor `json:"doctors"`
+}
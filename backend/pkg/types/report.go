@@ -6,11 +6,35 @@ type Report struct {
 	ID                int       `json:"id" db:"id"`
 	UserID           int       `json:"user_id" db:"user_id"`
 	OriginalFilename string    `json:"original_filename" db:"original_filename"`
-	FilePath         string    `json:"file_path" db:"file_path"`
+	FileID           string    `json:"file_id"`
+	DownloadURL      string    `json:"download_url"`
+	FilePath         string    `json:"file_path,omitempty"` // Deprecated: only set when EXPOSE_FILE_PATHS=true (v1 compatibility)
 	FileType         string    `json:"file_type" db:"file_type"`
 	SimplifiedSummary string   `json:"simplified_summary" db:"simplified_summary"`
 	UploadDate       time.Time `json:"upload_date" db:"upload_date"`
 	ProcessedAt      *time.Time `json:"processed_at" db:"processed_at"`
+	ArchivedAt       *time.Time `json:"archived_at,omitempty"`
+	Title            string     `json:"title"`       // Display title; falls back to the original filename
+	ReportDate       *string    `json:"report_date"` // YYYY-MM-DD, when set by the user
+	Notes            string     `json:"notes"`
+	ReadingLevel     string     `json:"reading_level"` // child, standard, or clinical
+	ErrorCode        string     `json:"error_code,omitempty"`   // Why processing failed: extraction_failed, ai_timeout, parse_failed, quota_exceeded, unreadable_document, cancelled, or internal
+	ErrorDetail      string     `json:"error_detail,omitempty"` // The failure in words, shown with the code's guidance
+	Version          int        `json:"version"`                // Send as If-Match (or use the ETag) to guard edits
+	Visibility       string     `json:"visibility"`             // private, doctor, or care_team
+	Language         string     `json:"language,omitempty"`     // ISO 639-1 code of the language the report was printed in (en, hi, kn, ta); set once analyzed
+}
+
+// UpdateReportRequest is a partial update; omitted fields are left unchanged and "" clears a field
+type UpdateReportRequest struct {
+	Title      *string `json:"title" validate:"omitempty,max=200"`
+	ReportDate *string `json:"report_date" validate:"omitempty,datetime=2006-01-02"`
+	Notes      *string `json:"notes" validate:"omitempty,max=5000"`
+}
+
+// UpdateVisibilityRequest sets who in the owner's organization may read a report
+type UpdateVisibilityRequest struct {
+	Visibility string `json:"visibility" validate:"required,oneof=private doctor care_team"`
 }
 
 type UploadRequest struct {
@@ -20,27 +44,56 @@ type UploadRequest struct {
 }
 
 type UploadResponse struct {
-	Message  string `json:"message"`
-	Success  bool   `json:"success"`
-	ReportID int    `json:"report_id,omitempty"`
+	Message        string          `json:"message"`
+	Success        bool            `json:"success"`
+	ReportID       int             `json:"report_id,omitempty"`
+	MergeCandidate *MergeCandidate `json:"merge_candidate,omitempty"` // An earlier upload this looks like another page of
+}
+
+type MergeCandidate struct {
+	ReportID         int    `json:"report_id"`
+	OriginalFilename string `json:"original_filename"`
+	MergeURL         string `json:"merge_url"`
+}
+
+type MergePartsRequest struct {
+	ReportID int `json:"report_id" validate:"required"`
+}
+
+type ReportPart struct {
+	PartNumber       int       `json:"part_number"`
+	OriginalFilename string    `json:"original_filename"`
+	FileType         string    `json:"file_type"`
+	FileSize         int64     `json:"file_size"`
+	AddedAt          time.Time `json:"added_at"`
+}
+
+type ReportPartsResponse struct {
+	Report Report       `json:"report"`
+	Parts  []ReportPart `json:"parts"`
 }
 
 type ReportSummaryResponse struct {
-	Report  Report `json:"report"`
-	Summary string `json:"summary"`
+	Report   Report `json:"report"`
+	Summary  string `json:"summary"`
+	Language string `json:"language"` // Language of the summary's prose; names, values, and units stay as analyzed
 }
 
 type ChatMessage struct {
-	ID         int       `json:"id" db:"id"`
-	ReportID   int       `json:"report_id" db:"report_id"`
-	UserMessage string   `json:"user_message" db:"user_message"`
-	AIResponse string    `json:"ai_response" db:"ai_response"`
-	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	ID          int        `json:"id" db:"id"`
+	ReportID    int        `json:"report_id" db:"report_id"`
+	UserMessage string     `json:"user_message" db:"user_message"`
+	AIResponse  string     `json:"ai_response" db:"ai_response"`
+	Version     int        `json:"version" db:"version"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+	InputMode   string     `json:"input_mode" db:"input_mode"` // text or voice; voice questions hold the transcription
 }
 
+// ChatRequest is a typed question about the report in the URL
 type ChatRequest struct {
-	ReportID int    `json:"report_id" validate:"required"`
-	Message  string `json:"message" validate:"required,min=1"`
+	Message      string `json:"message" validate:"required,min=1"`
+	ReadingLevel string `json:"reading_level,omitempty"` // Overrides the user's preference for this answer
 }
 
 type ChatResponse struct {
@@ -49,7 +102,97 @@ type ChatResponse struct {
 	ChatData  *ChatMessage  `json:"chat_data,omitempty"`
 }
 
+type ChatEditRequest struct {
+	Message      string `json:"message" validate:"required,min=1"`
+	ReadingLevel string `json:"reading_level,omitempty"` // Overrides the user's preference for this answer
+}
+
+type FlagMessageRequest struct {
+	Reason string `json:"reason"` // Why the exchange needs a moderator; at most 500 characters
+}
+
+type ChatMessageVersion struct {
+	Version     int       `json:"version"`
+	UserMessage string    `json:"user_message"`
+	AIResponse  string    `json:"ai_response"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type ChatVersionsResponse struct {
+	Current  ChatMessage          `json:"current"`
+	Versions []ChatMessageVersion `json:"versions"`
+}
+
+type ChatTranscript struct {
+	ReportID       int           `json:"report_id"`
+	ReportFilename string        `json:"report_filename"`
+	UploadDate     time.Time     `json:"upload_date"`
+	ExportedAt     time.Time     `json:"exported_at"`
+	Disclaimer     string        `json:"disclaimer"`
+	Messages       []ChatMessage `json:"messages"`
+}
+
 type ReportListResponse struct {
 	Reports []Report `json:"reports"`
 	Total   int      `json:"total"`
-}
\ No newline at end of file
+}
+
+type BulkReportRequest struct {
+	ReportIDs []int `json:"report_ids" validate:"required,min=1,max=100"`
+}
+
+type BulkResult struct {
+	ReportID int    `json:"report_id"`
+	Status   string `json:"status"`
+}
+
+type BulkReportResponse struct {
+	Results   []BulkResult `json:"results"`
+	Succeeded int          `json:"succeeded"`
+	Failed    int          `json:"failed"`
+}
+
+type FeedbackRequest struct {
+	Rating int `json:"rating" validate:"required,min=1,max=5"`
+}
+type StatusTransition struct {
+	Status      string    `json:"status"`
+	ErrorCode   string    `json:"error_code,omitempty"`
+	ErrorDetail string    `json:"error_detail,omitempty"`
+	At          time.Time `json:"at"`
+}
+
+type ReportProcessingHistoryResponse struct {
+	ReportID     int                 `json:"report_id"`
+	Status       string              `json:"status"`
+	ErrorCode    string              `json:"error_code,omitempty"`
+	ErrorDetail  string              `json:"error_detail,omitempty"`
+	AttemptCount int                 `json:"attempt_count"`
+	Transitions  []StatusTransition  `json:"transitions"`
+	Attempts     []ProcessingAttempt `json:"attempts"`
+}
+
+// ReportStatusEvent is the data of one status event on a report's event stream
+type ReportStatusEvent struct {
+	ReportID    int       `json:"report_id"`
+	Status      string    `json:"status"` // pending, processing, completed, or failed
+	ErrorCode   string    `json:"error_code,omitempty"`
+	ErrorDetail string    `json:"error_detail,omitempty"`
+	At          time.Time `json:"at"`
+}
+
+// ReportStatusResponse is how far a report has got through processing, small enough to poll
+type ReportStatusResponse struct {
+	ReportID         int        `json:"report_id"`
+	ProcessingStatus string     `json:"processing_status"` // pending, processing, completed, or failed
+	ErrorCode        string     `json:"error_code,omitempty"`
+	ErrorDetail      string     `json:"error_detail,omitempty"` // Why it failed, in words
+	QueuePosition    *int       `json:"queue_position"`         // 1 when next to be analyzed; null unless pending and due
+	RetryAt          *time.Time `json:"retry_at,omitempty"`     // When a pending report waiting out a retry becomes due
+	QueuePaused      bool       `json:"queue_paused"`           // Pending reports wait until the queue is resumed
+	AttemptCount     int        `json:"attempt_count"`
+	UploadedAt       time.Time  `json:"uploaded_at"`
+	StartedAt        *time.Time `json:"started_at"` // When the latest attempt started; null before the first
+	ProcessedAt      *time.Time `json:"processed_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,19 @@
+package types
+
+import "time"
+
+// Session describes one device the user is signed in on
+type Session struct {
+	ID         int       `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"` // The session the request was made from
+}
+
+type SessionsResponse struct {
+	Sessions []Session `json:"sessions"`
+	Limit    int       `json:"limit"` // Sessions allowed at once; 0 while sessions are untracked
+}
@@ -0,0 +1,88 @@
+package types
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type ShareLinkRequest struct {
+	PIN            string `json:"pin"`              // Optional 4-6 digits, passed to the viewer separately
+	ExpiresInHours int    `json:"expires_in_hours"` // 0 uses the server default
+}
+
+type ShareLink struct {
+	ID             int        `json:"id"`
+	ReportID       int        `json:"report_id"`
+	PINRequired    bool       `json:"pin_required"`
+	FailedAttempts int        `json:"failed_attempts"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	RevokedAt      *time.Time `json:"revoked_at"`
+	LockedAt       *time.Time `json:"locked_at"`
+	LastViewedAt   *time.Time `json:"last_viewed_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// CreatedShareLink carries the token, which is only ever returned once
+type CreatedShareLink struct {
+	ShareLink
+	Token string `json:"token"`
+	Path  string `json:"path"` // API path viewers open; the frontend wraps it in its own URL
+}
+
+type ShareLinkListResponse struct {
+	Links []ShareLink `json:"links"`
+}
+
+// SharedReport is what a share link reveals: the analysis, not the file, notes, or chat
+type SharedReport struct {
+	Title            string          `json:"title"`
+	OriginalFilename string          `json:"original_filename"`
+	ReportDate       *time.Time      `json:"report_date"`
+	UploadDate       time.Time       `json:"upload_date"`
+	Analysis         json.RawMessage `json:"analysis"`
+	ExpiresAt        time.Time       `json:"expires_at"`
+}
+
+type WidgetTokenRequest struct {
+	ReportID         int      `json:"report_id" validate:"required"`
+	Scopes           []string `json:"scopes,omitempty"`             // metrics (default) and/or trends
+	ExpiresInMinutes int      `json:"expires_in_minutes,omitempty"` // 0 uses the server default
+}
+
+type WidgetToken struct {
+	Token     string    `json:"token"`
+	ReportID  int       `json:"report_id"`
+	Scopes    []string  `json:"scopes"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Path      string    `json:"path"` // API path the widget fetches, with the token as a Bearer header or ?token=
+}
+
+type WidgetMetric struct {
+	Name     string  `json:"name"`
+	Value    string  `json:"value"`
+	Unit     string  `json:"unit"`
+	Score    float64 `json:"score"` // 0-100 speedometer position
+	Status   string  `json:"status"`
+	RangeMin float64 `json:"range_min"`
+	RangeMax float64 `json:"range_max"`
+}
+
+type WidgetReading struct {
+	Date   string `json:"date"` // YYYY-MM-DD
+	Value  string `json:"value"`
+	Status string `json:"status"`
+}
+
+type WidgetTrend struct {
+	Name     string          `json:"name"`
+	Unit     string          `json:"unit"`
+	Readings []WidgetReading `json:"readings"` // Oldest first, up to the report's date
+}
+
+// Deliberately omits descriptions, findings, and condition tags; a widget shows numbers only
+type WidgetData struct {
+	Label   string         `json:"label"`
+	Date    string         `json:"date"` // YYYY-MM-DD
+	Metrics []WidgetMetric `json:"metrics"`
+	Trends  []WidgetTrend  `json:"trends,omitempty"` // Only with the trends scope
+}
@@ -0,0 +1,26 @@
+package types
+
+import "time"
+
+// SyncReport is a report as a syncing client stores it
+type SyncReport struct {
+	Report
+	ProcessingStatus string    `json:"processing_status"`
+	UpdatedAt        time.Time `json:"updated_at"`
+	Analysis         any       `json:"analysis,omitempty"` // The parsed analysis of completed reports; simplified_summary is left empty
+}
+
+// SyncDeleted lists the IDs of records the client should drop
+type SyncDeleted struct {
+	Reports      []int `json:"reports"` // Their chat messages are gone too
+	ChatMessages []int `json:"chat_messages"`
+}
+
+// SyncResponse is everything that changed in the user's account since the checkpoint the client sent
+type SyncResponse struct {
+	Checkpoint   time.Time     `json:"checkpoint"` // Send as since on the next sync
+	FullSync     bool          `json:"full_sync"`  // No since was sent; replace everything held locally
+	Reports      []SyncReport  `json:"reports"`
+	ChatMessages []ChatMessage `json:"chat_messages"`
+	Deleted      SyncDeleted   `json:"deleted"`
+}
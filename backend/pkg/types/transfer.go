@@ -0,0 +1,21 @@
+package types
+
+import "time"
+
+type TransferRequest struct {
+	RecipientEmail string `json:"recipient_email" validate:"required,email"`
+}
+
+type ReportTransfer struct {
+	ID          int        `json:"id"`
+	ReportID    int        `json:"report_id"`
+	FromUserID  int        `json:"from_user_id"`
+	ToUserID    int        `json:"to_user_id"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	RespondedAt *time.Time `json:"responded_at"`
+}
+
+type TransferListResponse struct {
+	Transfers []ReportTransfer `json:"transfers"`
+}
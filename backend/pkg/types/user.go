@@ -11,25 +11,176 @@ type User struct {
 	IsActive      bool      `json:"is_active" db:"is_active"`
 	CreatedAt     time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+	Timezone      string    `json:"timezone" db:"timezone"`
+	ReadingLevel  string    `json:"reading_level" db:"reading_level"` // child, standard, or clinical
+	Plan          string    `json:"plan" db:"plan"`                   // free or premium
+	Version       int       `json:"version"`                          // Send as If-Match (or use the ETag) to guard edits
 }
 
 type LoginRequest struct {
-	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=6"`
+	Email        string `json:"email" validate:"required,email"`
+	Password     string `json:"password" validate:"required,min=6"`
+	CaptchaToken string `json:"captcha_token,omitempty"` // Required after repeated failed logins when CAPTCHA is enabled
+
+	// Set by the handler from the request and shown in the session list
+	UserAgent string `json:"-"`
+	IPAddress string `json:"-"`
 }
 
 type SignupRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required,min=6"`
 	FullName string `json:"full_name" validate:"required,min=2"`
+	Timezone string `json:"timezone,omitempty"` // IANA zone name, e.g. "Asia/Kolkata"; defaults to UTC
+
+	ReadingLevel string `json:"reading_level,omitempty"` // child, standard, or clinical; defaults to standard
+
+	CaptchaToken string `json:"captcha_token,omitempty"` // Widget response token when CAPTCHA is enabled
+
+	// Set by the handler from the request and shown in the session list
+	UserAgent string `json:"-"`
+	IPAddress string `json:"-"`
+}
+
+// CaptchaSettings tells the frontend which CAPTCHA widget to render and when
+type CaptchaSettings struct {
+	Provider              string `json:"provider"` // hcaptcha, recaptcha, or none
+	SiteKey               string `json:"site_key,omitempty"`
+	RequiredForSignup     bool   `json:"required_for_signup"`
+	LoginFailureThreshold int    `json:"login_failure_threshold,omitempty"`
+}
+
+type UpdateProfileRequest struct {
+	FullName *string `json:"full_name,omitempty" validate:"omitempty,min=2"`
+	Timezone     *string `json:"timezone,omitempty"`
+	ReadingLevel *string `json:"reading_level,omitempty"`
 }
 
 type LoginResponse struct {
-	Token string `json:"token"`
-	User  User   `json:"user"`
+	Token            string     `json:"token"`                        // Short-lived access token, sent as the bearer token
+	ExpiresAt        time.Time  `json:"expires_at"`                   // When the access token stops working
+	RefreshToken     string     `json:"refresh_token,omitempty"`      // Exchanged at /api/auth/refresh for new tokens; used once
+	RefreshExpiresAt *time.Time `json:"refresh_expires_at,omitempty"` // When the refresh token stops working if unused
+	User             User       `json:"user"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token,omitempty"` // Also signs out a sign-in whose access token has expired
 }
 
 type AuthResponse struct {
 	Message string `json:"message"`
 	Success bool   `json:"success"`
-}
\ No newline at end of file
+}
+
+type HealthProfileRequest struct {
+	DateOfBirth *string  `json:"date_of_birth"` // YYYY-MM-DD; null clears it
+	Sex         string   `json:"sex" validate:"omitempty,oneof=male female other"`
+	HeightCm    *float64 `json:"height_cm"`
+	WeightKg    *float64 `json:"weight_kg"`
+	Conditions  []string `json:"conditions" validate:"max=20"` // Free text, e.g. "type 2 diabetes"
+	Allergies   []string `json:"allergies" validate:"max=20"`
+	Medications []string `json:"medications" validate:"max=20"` // Free text, e.g. "metformin 500 mg twice daily"
+	BloodGroup  string   `json:"blood_group"`                   // A+, A-, B+, B-, AB+, AB-, O+, or O-
+}
+
+type HealthProfile struct {
+	DateOfBirth *string   `json:"date_of_birth"` // YYYY-MM-DD
+	Age         *int      `json:"age"`           // Derived from date_of_birth in the user's timezone
+	Sex         string    `json:"sex"`
+	HeightCm    *float64  `json:"height_cm"`
+	WeightKg    *float64  `json:"weight_kg"`
+	Conditions  []string  `json:"conditions"`
+	Allergies   []string  `json:"allergies"`
+	Medications []string  `json:"medications"`
+	BloodGroup  string    `json:"blood_group"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type TrackedCondition struct {
+	Key     string     `json:"key"`
+	Name    string     `json:"name"`
+	Tracked bool       `json:"tracked"`
+	Since   *time.Time `json:"since"` // When tracking started; null if not tracked
+}
+
+type ConditionListResponse struct {
+	Conditions []TrackedCondition `json:"conditions"`
+}
+
+type ConditionReading struct {
+	ReportID int    `json:"report_id"`
+	Date     string `json:"date"` // YYYY-MM-DD
+	Value    string `json:"value"`
+	Unit     string `json:"unit"`
+	Status   string `json:"status"`
+}
+
+type ConditionMetric struct {
+	Name         string             `json:"name"`
+	LatestStatus string             `json:"latest_status"`
+	Readings     []ConditionReading `json:"readings"` // Oldest first
+}
+
+type ConditionFinding struct {
+	ReportID int    `json:"report_id"`
+	Date     string `json:"date"` // YYYY-MM-DD
+	Text     string `json:"text"`
+}
+
+type ConditionOverview struct {
+	Key         string             `json:"key"`
+	Name        string             `json:"name"`
+	Since       time.Time          `json:"since"`
+	ReportCount int                `json:"report_count"`
+	Metrics     []ConditionMetric  `json:"metrics"`
+	Findings    []ConditionFinding `json:"findings"` // Oldest first
+}
+
+type EmergencyCriticalValue struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Unit     string `json:"unit"`
+	ReportID *int   `json:"report_id,omitempty"` // Omitted on the public card
+	Date     string `json:"date"`                // YYYY-MM-DD
+}
+
+type EmergencyCardSharing struct {
+	Enabled      bool       `json:"enabled"`
+	CreatedAt    *time.Time `json:"created_at,omitempty"`
+	LastViewedAt *time.Time `json:"last_viewed_at,omitempty"`
+	Token        string     `json:"token,omitempty"` // Only in the response that enabled sharing
+	Path         string     `json:"path,omitempty"`  // Public URL path to encode in the QR code; only with token
+}
+
+type EmergencyCard struct {
+	FullName       string                   `json:"full_name"`
+	Age            *int                     `json:"age"`
+	Sex            string                   `json:"sex"`
+	BloodGroup     string                   `json:"blood_group"`
+	Conditions     []string                 `json:"conditions"`
+	Allergies      []string                 `json:"allergies"`
+	Medications    []string                 `json:"medications"`
+	CriticalValues []EmergencyCriticalValue `json:"critical_values"` // Newest first
+	GeneratedAt    time.Time                `json:"generated_at"`
+	Sharing        *EmergencyCardSharing    `json:"sharing,omitempty"` // Only for the owner
+}
+
+type AIUsageEntry struct {
+	ID         int       `json:"id"`
+	ReportID   *int      `json:"report_id"` // The report analyzed; null for chat, merges, and annual reviews
+	Purpose    string    `json:"purpose"`   // analysis, merge, chat, summary, translation, or annual
+	Provider   string    `json:"provider"`
+	Model      string    `json:"model"`
+	Succeeded  bool      `json:"succeeded"`
+	DurationMs int64     `json:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type EmergencyCardSharingRequest struct {
+	Enabled bool `json:"enabled"` // true consents to a public link and issues a new token
+}
@@ -0,0 +1,204 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/handlers"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// testCallLog enables the AI call log with a fixed key
+var testCallLog = config.AICallLogConfig{
+	Enabled:       true,
+	EncryptionKey: "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=",
+	Retention:     30 * 24 * time.Hour,
+}
+
+// TestAICallLog tests that model calls are stored encrypted, attributed to their report, and purged when expired
+func TestAICallLog(t *testing.T) {
+	modelServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reply := `{"summary":"Elevated LDL","simple_summary":"Cholesterol is a bit high","health_metrics":[],"risk_level":"medium"}`
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{"message": map[string]string{"role": "assistant", "content": reply}}},
+		})
+	}))
+	defer modelServer.Close()
+
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{
+			Driver: "sqlite3",
+			DSN:    ":memory:",
+		},
+	}
+
+	db, err := database.Setup(cfg)
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	owner := &models.User{Email: "owner@example.com", PasswordHash: "hash", FullName: "Owner", IsActive: true}
+	if err := models.NewUserRepository(db.GetDB()).Create(owner); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	reportRepo := models.NewReportRepository(db.GetDB())
+	reports, err := services.NewDemoService(reportRepo).ProvisionSampleReports(owner.ID)
+	if err != nil {
+		t.Fatalf("Failed to provision reports: %v", err)
+	}
+
+	recorder, err := services.NewAICallRecorder(models.NewAICallRepository(db.GetDB()), testCallLog)
+	if err != nil {
+		t.Fatalf("Failed to create AI call recorder: %v", err)
+	}
+	aiService, err := services.NewAIService(config.AIConfig{
+		Provider:    "ollama",
+		OllamaURL:   modelServer.URL,
+		OllamaModel: "llama3.1",
+		Temperature: 0.3,
+		MaxTokens:   2048,
+		PromptPath:  "does-not-exist.txt",
+		Persona:     config.PersonaConfig{Name: "Ava"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create AI service: %v", err)
+	}
+	defer aiService.Close()
+	aiService.SetCallRecorder(recorder)
+
+	reportPath := filepath.Join(t.TempDir(), "lipids.txt")
+	if err := os.WriteFile(reportPath, []byte("LDL 160 mg/dL"), 0644); err != nil {
+		t.Fatalf("Failed to write report: %v", err)
+	}
+	ctx := services.WithAICallReport(context.Background(), reports[0].ID)
+	if _, err := aiService.AnalyzeReport(ctx, reportPath, "text/plain", models.ReadingLevelStandard, models.PlanFree, ""); err != nil {
+		t.Fatalf("Analysis failed: %v", err)
+	}
+	if _, err := aiService.AnswerQuestion(services.WithAICallUser(context.Background(), owner.ID), "Elevated LDL", "", nil, "Is that bad?", models.ReadingLevelStandard, ""); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	// Decision: Nothing the patient's report said may be readable in the table without the key
+	var storedPrompt, storedResponse string
+	if err := db.GetDB().QueryRow(`SELECT prompt, response FROM ai_calls WHERE purpose = 'analysis'`).Scan(&storedPrompt, &storedResponse); err != nil {
+		t.Fatalf("Failed to read stored call: %v", err)
+	}
+	if strings.Contains(storedPrompt, "LDL 160") || strings.Contains(storedResponse, "Elevated LDL") {
+		t.Error("Expected the prompt and response encrypted at rest")
+	}
+
+	provenance := services.NewProvenanceService(reportRepo, models.NewAuditLogRepository(db.GetDB()), recorder)
+	admin := &models.User{ID: 99, Email: "admin@example.com"}
+	calls, err := provenance.Calls(admin, reports[0].ID)
+	if err != nil {
+		t.Fatalf("Failed to load provenance: %v", err)
+	}
+	if len(calls) != 1 {
+		t.Fatalf("Expected only the analysis call attributed to the report, got %d", len(calls))
+	}
+	call := calls[0]
+	if call.Purpose != services.AICallAnalysis || call.Provider != "ollama" || call.Model != "llama3.1" {
+		t.Errorf("Unexpected call %+v", call)
+	}
+	if !strings.Contains(call.Prompt, "LDL 160") || !strings.Contains(call.Response, "Elevated LDL") {
+		t.Errorf("Expected the exact prompt and response decrypted, got %q / %q", call.Prompt, call.Response)
+	}
+	var parameters services.AICallParameters
+	if err := json.Unmarshal([]byte(call.Parameters), &parameters); err != nil || parameters.Temperature != 0.3 ||
+		parameters.MaxOutputTokens == 0 || !strings.Contains(parameters.SystemPrompt, "Ava") {
+		t.Errorf("Unexpected parameters %s (%v)", call.Parameters, err)
+	}
+
+	entries, err := models.NewAuditLogRepository(db.GetDB()).List(models.AuditLogFilter{ActorID: admin.ID})
+	if err != nil || len(entries) != 1 || entries[0].Action != models.AuditAICallsViewed || entries[0].UserID != owner.ID {
+		t.Errorf("Expected the provenance view audited, got %+v (%v)", entries, err)
+	}
+
+	// Users see what was run on their data, but none of what was said; the analysis call predates calls
+	// carrying their user and is found through its report
+	logHandler := handlers.NewAILogHandler(provenance)
+	listLog := func(user *models.User) []types.AIUsageEntry {
+		req := httptest.NewRequest("GET", "/api/users/me/ai-log", nil)
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserKey, user))
+		recorder := httptest.NewRecorder()
+		logHandler.ListAILogHandler(recorder, req)
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("Expected 200 from the AI log, got %d", recorder.Code)
+		}
+		if strings.Contains(recorder.Body.String(), "LDL") {
+			t.Errorf("Expected no report content in the AI log, got %s", recorder.Body.String())
+		}
+		var page []types.AIUsageEntry
+		decodeEnvelope(recorder.Body, &page)
+		return page
+	}
+	if _, err := db.GetDB().Exec(`UPDATE ai_calls SET user_id = 0 WHERE purpose = 'analysis'`); err != nil {
+		t.Fatalf("Failed to clear call user: %v", err)
+	}
+	usage := listLog(owner)
+	if len(usage) != 2 || usage[0].Purpose != services.AICallChat || usage[0].ReportID != nil ||
+		usage[1].Purpose != services.AICallAnalysis || usage[1].ReportID == nil || *usage[1].ReportID != reports[0].ID {
+		t.Fatalf("Expected the chat and analysis calls newest first, got %+v", usage)
+	}
+	if !usage[1].Succeeded || usage[1].Model != "llama3.1" {
+		t.Errorf("Unexpected analysis entry %+v", usage[1])
+	}
+	if usage := listLog(&models.User{ID: owner.ID + 100, Email: "stranger@example.com"}); len(usage) != 0 {
+		t.Errorf("Expected no calls for another user, got %+v", usage)
+	}
+
+	// Calls past the retention period are purged
+	if _, err := db.GetDB().Exec(`UPDATE ai_calls SET created_at = datetime('now', '-31 days') WHERE purpose = 'chat'`); err != nil {
+		t.Fatalf("Failed to age call: %v", err)
+	}
+	removed, err := recorder.Purge()
+	if err != nil || removed != 1 {
+		t.Errorf("Expected 1 expired call purged, got %d (%v)", removed, err)
+	}
+	if calls, _ := recorder.Calls(reports[0].ID); len(calls) != 1 {
+		t.Errorf("Expected the recent analysis call kept, got %d", len(calls))
+	}
+}
+
+// TestAICallLogConfig tests that the call log is off by default and needs a valid key
+func TestAICallLogConfig(t *testing.T) {
+	if recorder, err := services.NewAICallRecorder(nil, config.AICallLogConfig{}); recorder != nil || err != nil {
+		t.Errorf("Expected a disabled log to return nil, got %v (%v)", recorder, err)
+	}
+	if _, err := services.NewAICallRecorder(nil, config.AICallLogConfig{Enabled: true, EncryptionKey: "c2hvcnQ=", Retention: time.Hour}); err == nil {
+		t.Error("Expected a short key to be rejected")
+	}
+	if _, err := services.NewAICallRecorder(nil, config.AICallLogConfig{Enabled: true, EncryptionKey: testCallLog.EncryptionKey}); err == nil {
+		t.Error("Expected a missing retention to be rejected")
+	}
+}
+
+// TestAdminAICalls tests access to the admin provenance endpoint
+func TestAdminAICalls(t *testing.T) {
+	server := setupTestServer(t)
+	defer server.Close()
+
+	token := signupAndGetToken(t, server.URL, "patient@example.com")
+	if status := doJSONRequest(t, "GET", server.URL+"/api/admin/reports/1/ai-calls", token, nil, nil); status != http.StatusForbidden {
+		t.Errorf("Expected non-admins to be refused, got %d", status)
+	}
+
+	adminToken := signupAndGetToken(t, server.URL, "admin@example.com")
+	if status := doJSONRequest(t, "GET", server.URL+"/api/admin/reports/999/ai-calls", adminToken, nil, nil); status != http.StatusNotFound {
+		t.Errorf("Expected an unknown report to be not found, got %d", status)
+	}
+}
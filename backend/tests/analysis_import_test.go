@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/client"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// TestAnalysisImport tests that only admins and their scoped keys can attach external analyses, and that they are validated
+func TestAnalysisImport(t *testing.T) {
+	server := setupTestServer(t)
+	defer server.Close()
+	ctx := context.Background()
+
+	owner := client.New(server.URL, signupAndGetToken(t, server.URL, "import-owner@example.com"))
+	admin := client.New(server.URL, signupAndGetToken(t, server.URL, "admin@example.com"))
+	uploaded, err := owner.UploadReport(ctx, "cbc.txt", strings.NewReader("Potassium: 6.9 mmol/L"), client.UploadOptions{})
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	analysis := map[string]any{
+		"summary":        "Potassium is markedly raised.",
+		"simple_summary": "Your potassium level is much higher than normal.",
+		"risk_level":     "high",
+		"health_metrics": []map[string]any{
+			{"name": "Potassium", "value": 6.9, "unit": "mmol/L", "score": 10, "status": "critical", "range_min": 3.5, "range_max": 5.1},
+		},
+		"key_findings":    []string{"Hyperkalemia"},
+		"recommendations": []string{"Repeat the test today"},
+	}
+
+	if _, err := owner.ImportAnalysis(ctx, uploaded.ReportID, "owner script", analysis); !client.IsStatus(err, http.StatusForbidden) {
+		t.Errorf("Expected 403 for a regular user, got %v", err)
+	}
+
+	// Only admins grant scopes, through the admin endpoint
+	if err := owner.Do(ctx, http.MethodPost, "/api/auth/api-keys",
+		types.CreateAPIKeyRequest{Name: "pipeline", Scopes: []string{"analysis:import"}}, nil); !client.IsStatus(err, http.StatusForbidden) {
+		t.Errorf("Expected 403 granting a scope without admin rights, got %v", err)
+	}
+	if _, err := admin.CreateScopedAPIKey(ctx, "pipeline", []string{"reports:delete"}); !client.IsStatus(err, http.StatusBadRequest) {
+		t.Errorf("Expected 400 for an unknown scope, got %v", err)
+	}
+	scoped, err := admin.CreateScopedAPIKey(ctx, "hospital pipeline", []string{"analysis:import"})
+	if err != nil || len(scoped.Scopes) != 1 || scoped.Scopes[0] != "analysis:import" {
+		t.Fatalf("Expected a scoped key, got %+v %v", scoped, err)
+	}
+	plain, err := admin.CreateAPIKey(ctx, "admin script")
+	if err != nil {
+		t.Fatalf("CreateAPIKey failed: %v", err)
+	}
+	if _, err := client.New(server.URL, plain.Key).ImportAnalysis(ctx, uploaded.ReportID, "script", analysis); !client.IsStatus(err, http.StatusForbidden) {
+		t.Errorf("Expected 403 for an admin's unscoped key, got %v", err)
+	}
+
+	pipeline := client.New(server.URL, scoped.Key)
+	if _, err := pipeline.GetReport(ctx, uploaded.ReportID); !client.IsStatus(err, http.StatusForbidden) {
+		t.Errorf("Expected the scope not to open other report routes, got %v", err)
+	}
+
+	invalid := map[string]any{
+		"summary": "x", "simple_summary": "y", "risk_level": "severe", "confidence": 0.9,
+	}
+	_, err = pipeline.ImportAnalysis(ctx, uploaded.ReportID, "city-hospital-nlp v3", invalid)
+	if !client.IsStatus(err, http.StatusBadRequest) || !strings.Contains(err.Error(), "confidence") {
+		t.Errorf("Expected 400 naming the unknown field, got %v", err)
+	}
+	invalid = map[string]any{
+		"summary": "x", "simple_summary": "y", "risk_level": "severe",
+		"health_metrics": []map[string]any{{"name": "Potassium", "value": 6.9, "score": 140}},
+	}
+	_, err = pipeline.ImportAnalysis(ctx, uploaded.ReportID, "city-hospital-nlp v3", invalid)
+	if !client.IsStatus(err, http.StatusBadRequest) || !strings.Contains(err.Error(), "risk_level") ||
+		!strings.Contains(err.Error(), "health_metrics[0].status") || !strings.Contains(err.Error(), "health_metrics[0].score") {
+		t.Errorf("Expected 400 listing every problem, got %v", err)
+	}
+	if _, err := pipeline.ImportAnalysis(ctx, uploaded.ReportID, "", analysis); !client.IsStatus(err, http.StatusBadRequest) {
+		t.Errorf("Expected 400 without a source, got %v", err)
+	}
+	if _, err := pipeline.ImportAnalysis(ctx, 99999, "city-hospital-nlp v3", analysis); !client.IsStatus(err, http.StatusNotFound) {
+		t.Errorf("Expected 404 for a missing report, got %v", err)
+	}
+
+	stored, err := pipeline.ImportAnalysis(ctx, uploaded.ReportID, "city-hospital-nlp v3", analysis)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	var result struct {
+		SchemaVersion int `json:"schema_version"`
+	}
+	if err := json.Unmarshal(stored, &result); err != nil || result.SchemaVersion == 0 {
+		t.Errorf("Expected the stored analysis with its schema version, got %s %v", stored, err)
+	}
+
+	// The owner sees the imported analysis as a completed report
+	history, err := owner.GetProcessingHistory(ctx, uploaded.ReportID)
+	if err != nil || history.Status != "completed" {
+		t.Fatalf("Expected the report completed, got %+v %v", history, err)
+	}
+	metrics, err := owner.GetHealthMetrics(ctx, uploaded.ReportID, "")
+	if err != nil || len(metrics.Metrics) != 1 || metrics.Metrics[0].Status != "critical" {
+		t.Fatalf("Expected the imported metric, got %+v %v", metrics, err)
+	}
+
+	// A completed report can take a corrected analysis
+	analysis["risk_level"] = "medium"
+	if _, err := admin.ImportAnalysis(ctx, uploaded.ReportID, "manual correction", analysis); err != nil {
+		t.Errorf("Expected an admin session to re-import, got %v", err)
+	}
+
+	me, err := owner.Me(ctx)
+	if err != nil {
+		t.Fatalf("Me failed: %v", err)
+	}
+	var entries []types.AuditLogEntry
+	doJSONRequest(t, "GET", fmt.Sprintf("%s/api/admin/audit?user_id=%d", server.URL, me.ID), admin.Token(), nil, &entries)
+	imports := 0
+	for _, entry := range entries {
+		if entry.Action == "analysis.imported" {
+			imports++
+		}
+	}
+	if imports != 2 {
+		t.Errorf("Expected both imports audited, got %+v", entries)
+	}
+}
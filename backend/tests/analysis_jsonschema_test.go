@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+)
+
+// TestAnalysisSchemaValidation tests that model output is repaired when the fix is unambiguous and flagged otherwise
+func TestAnalysisSchemaValidation(t *testing.T) {
+	if !json.Valid([]byte(services.AnalysisResultSchema)) {
+		t.Fatal("Expected the published schema to be valid JSON")
+	}
+
+	replies := map[string]string{
+		// Numbers as strings, a capitalized enum, a bare string list, and fields the schema doesn't know
+		"repairable": `{"summary":"Lipid panel","simple_summary":"Cholesterol is a bit high","risk_level":"Medium",
+			"health_metrics":[{"name":"LDL","value":160,"unit":"mg/dL","score":"62%","range_min":"0","range_max":"100","status":" Warning ","certainty":0.8}],
+			"key_findings":"LDL above range","recommendations":["Reduce saturated fat",""],"notes":"model chatter"}`,
+		// A metric without a value, a score in words, and findings of the wrong shape
+		"broken": `{"summary":"Lipid panel","health_metrics":[{"name":"LDL","score":"high"}],"key_findings":{"LDL":"high"}}`,
+	}
+	modelServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		reply := ""
+		for keyword, output := range replies {
+			if strings.Contains(req.Messages[len(req.Messages)-1].Content, keyword) {
+				reply = output
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{"message": map[string]string{"role": "assistant", "content": reply}}},
+		})
+	}))
+	defer modelServer.Close()
+
+	aiService, err := services.NewAIService(config.AIConfig{
+		Provider:    "ollama",
+		OllamaURL:   modelServer.URL,
+		OllamaModel: "llama3.1",
+		PromptPath:  "does-not-exist.txt",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create AI service: %v", err)
+	}
+	defer aiService.Close()
+
+	analyze := func(content string) *services.ReportAnalysis {
+		path := filepath.Join(t.TempDir(), "report.txt")
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write report: %v", err)
+		}
+		analysis, err := aiService.AnalyzeReport(context.Background(), path, "text/plain", "standard", models.PlanFree, "")
+		if err != nil {
+			t.Fatalf("Analysis failed: %v", err)
+		}
+		return analysis
+	}
+
+	repaired := analyze("repairable LDL 160 mg/dL")
+	if repaired.ParseFailed {
+		t.Fatalf("Expected minor violations to be repaired, got %s", repaired.ParseError)
+	}
+	result, err := services.ParseStoredAnalysis(repaired.ResultJSON)
+	if err != nil {
+		t.Fatalf("Failed to read stored analysis: %v", err)
+	}
+	metric := result.HealthMetrics[0]
+	if metric.Score != 62 || metric.RangeMax != 100 || metric.Status != "warning" || result.RiskLevel != "medium" {
+		t.Errorf("Expected coerced numbers and normalized enums, got %+v %q", metric, result.RiskLevel)
+	}
+	if len(result.KeyFindings) != 1 || len(result.Recommendations) != 1 {
+		t.Errorf("Expected the findings wrapped and the empty recommendation dropped, got %q %q", result.KeyFindings, result.Recommendations)
+	}
+	if strings.Contains(repaired.ResultJSON, "certainty") || strings.Contains(repaired.ResultJSON, "model chatter") {
+		t.Errorf("Expected unknown fields dropped, got %s", repaired.ResultJSON)
+	}
+
+	broken := analyze("broken LDL")
+	if !broken.ParseFailed || broken.ResultJSON != "" || broken.RawOutput == "" {
+		t.Fatalf("Expected structurally invalid output to be flagged for review, got %+v", broken)
+	}
+	for _, problem := range []string{"health_metrics[0].value is required", "health_metrics[0].score must be a number", "key_findings must be an array"} {
+		if !strings.Contains(broken.ParseError, problem) {
+			t.Errorf("Expected %q in the parse error, got %s", problem, broken.ParseError)
+		}
+	}
+
+	// Imported analyses get no repairs
+	_, err = services.ParseExternalAnalysis(json.RawMessage(`{"summary":"Lipid panel","simple_summary":"High","risk_level":"medium",
+		"health_metrics":[{"name":"LDL","value":160,"score":"62","status":"warning"}]}`))
+	if err == nil || !strings.Contains(err.Error(), "health_metrics[0].score must be a number") {
+		t.Errorf("Expected a string score to be refused on import, got %v", err)
+	}
+}
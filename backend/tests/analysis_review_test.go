@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+)
+
+// garbledAnalyzer returns output the parser can't read, like a model that answered in prose
+type garbledAnalyzer struct{}
+
+func (garbledAnalyzer) AnalyzeReport(ctx context.Context, filePath, fileType, readingLevel, plan, patient string) (*services.ReportAnalysis, error) {
+	return &services.ReportAnalysis{
+		PromptVersion: "v-test",
+		ParseFailed:   true,
+		RawOutput:     `Here is the analysis: {"summary": "Normal blood count", "key_findings": [`,
+		ParseError:    "unexpected end of JSON input",
+	}, nil
+}
+
+// TestAnalysisReviewQuarantine tests that unparseable output is held for review and can be re-parsed by an admin
+func TestAnalysisReviewQuarantine(t *testing.T) {
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	admin := &models.User{Email: "ops@example.com", PasswordHash: "hash", FullName: "Ops", IsActive: true}
+	if err := models.NewUserRepository(db.GetDB()).Create(admin); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	uploadDir := t.TempDir()
+	reportRepo := models.NewReportRepository(db.GetDB())
+	report := &models.Report{UserID: admin.ID, OriginalFilename: "cbc.txt", FilePath: filepath.Join(uploadDir, "cbc.txt"),
+		FileType: "text/plain", FileSize: 10, ProcessingStatus: "pending", ReadingLevel: models.ReadingLevelStandard}
+	if err := reportRepo.Create(report); err != nil {
+		t.Fatalf("Failed to create report: %v", err)
+	}
+
+	jobRepo := models.NewJobRepository(db.GetDB())
+	reviewRepo := models.NewAnalysisReviewRepository(db.GetDB())
+	auditRepo := models.NewAuditLogRepository(db.GetDB())
+	processor := services.NewReportProcessorWithAnalyzer(reportRepo, jobRepo, reviewRepo, nil, garbledAnalyzer{}, services.NewFileStorage(uploadDir, "secret"))
+	reviews := services.NewReviewService(reportRepo, reviewRepo, auditRepo)
+
+	if err := processor.ProcessReport(report); err == nil {
+		t.Error("Expected the quarantined attempt to be reported as failed")
+	}
+	stored, _ := reportRepo.GetByID(report.ID)
+	if stored.ProcessingStatus != "needs_review" || stored.SimplifiedSummary != "" {
+		t.Errorf("Expected report held for review with no summary, got %s %q", stored.ProcessingStatus, stored.SimplifiedSummary)
+	}
+
+	open, err := reviews.ListOpen(20, 0)
+	if err != nil || len(open) != 1 || open[0].RawOutput != "" {
+		t.Fatalf("Expected one open review without raw output, got %+v (%v)", open, err)
+	}
+	review, err := reviews.Get(admin, report.ID)
+	if err != nil || review.RawOutput == "" || review.PromptVersion != "v-test" {
+		t.Fatalf("Expected the raw output to be kept, got %+v (%v)", review, err)
+	}
+
+	// The stored output is still truncated, so re-parsing it as-is fails
+	if _, err := reviews.Reparse(admin, report.ID, ""); err == nil {
+		t.Error("Expected re-parsing the stored output to fail")
+	}
+	analysis, err := reviews.Reparse(admin, report.ID, `{"summary": "Normal blood count", "key_findings": ["Hemoglobin normal"]}`)
+	if err != nil {
+		t.Fatalf("Failed to re-parse corrected output: %v", err)
+	}
+	if analysis.Summary != "Normal blood count" {
+		t.Errorf("Expected corrected summary, got %q", analysis.Summary)
+	}
+	if stored, _ := reportRepo.GetByID(report.ID); stored.ProcessingStatus != "completed" {
+		t.Errorf("Expected report completed after re-parse, got %s", stored.ProcessingStatus)
+	}
+	if open, _ := reviews.ListOpen(20, 0); len(open) != 0 {
+		t.Errorf("Expected the review to be resolved, got %d open", len(open))
+	}
+	if _, err := reviews.Reparse(admin, report.ID, ""); err == nil {
+		t.Error("Expected a completed report to refuse another re-parse")
+	}
+
+	entries, err := auditRepo.List(models.AuditLogFilter{ActorID: admin.ID})
+	if err != nil || len(entries) != 2 {
+		t.Errorf("Expected the view and re-parse audited, got %d entries (%v)", len(entries), err)
+	}
+
+	// HTTP endpoints are admin-only
+	server := setupTestServer(t)
+	defer server.Close()
+	token := signupAndGetToken(t, server.URL, "not-ops@example.com")
+	if status := doJSONRequest(t, "GET", server.URL+"/api/admin/reviews", token, nil, nil); status != http.StatusForbidden {
+		t.Errorf("Expected non-admins to be refused, got %d", status)
+	}
+	adminToken := signupAndGetToken(t, server.URL, "admin@example.com")
+	if status := doJSONRequest(t, "GET", server.URL+"/api/admin/reviews", adminToken, nil, nil); status != http.StatusOK {
+		t.Errorf("Expected admin to list reviews, got %d", status)
+	}
+	if status := doJSONRequest(t, "GET", server.URL+"/api/admin/reviews/999", adminToken, nil, nil); status != http.StatusNotFound {
+		t.Errorf("Expected unknown review to be not found, got %d", status)
+	}
+}
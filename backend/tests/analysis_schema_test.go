@@ -0,0 +1,55 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+)
+
+// TestParseStoredAnalysisUpgrade tests that pre-versioning blobs are migrated on read
+func TestParseStoredAnalysisUpgrade(t *testing.T) {
+	// Decision: Version 0 blob with string scores and a bare string recommendation
+	legacy := `{
+		"summary": "Lipid panel",
+		"simple_summary": "Cholesterol slightly high",
+		"health_metrics": [
+			{"name": "LDL", "value": 145, "unit": "mg/dL", "score": "62", "range_min": "0", "range_max": "100"}
+		],
+		"key_findings": ["LDL above range"],
+		"recommendations": "Reduce saturated fat",
+		"risk_level": "medium"
+	}`
+
+	analysis, err := services.ParseStoredAnalysis(legacy)
+	if err != nil {
+		t.Fatalf("Legacy analysis should parse after upgrade: %v", err)
+	}
+
+	if analysis.SchemaVersion != services.CurrentAnalysisSchemaVersion {
+		t.Fatalf("Expected schema version %d, got %d", services.CurrentAnalysisSchemaVersion, analysis.SchemaVersion)
+	}
+
+	if len(analysis.HealthMetrics) != 1 || analysis.HealthMetrics[0].Score != 62 {
+		t.Fatalf("Expected one metric with score 62, got %+v", analysis.HealthMetrics)
+	}
+
+	if analysis.HealthMetrics[0].RangeMax != 100 {
+		t.Fatalf("Expected range_max 100, got %v", analysis.HealthMetrics[0].RangeMax)
+	}
+
+	if len(analysis.Recommendations) != 1 {
+		t.Fatalf("Expected string recommendation to become a list, got %v", analysis.Recommendations)
+	}
+
+	// Current-version blobs pass through unchanged
+	current := `{"schema_version": 5, "summary": "ok", "health_metrics": [], "glossary_terms": []}`
+	_, changed, err := services.UpgradeAnalysisJSON(current)
+	if err != nil {
+		t.Fatalf("Current analysis should not fail: %v", err)
+	}
+	if changed {
+		t.Fatal("Current-version analysis should not be rewritten")
+	}
+
+	t.Log("Stored analysis upgrade test passed")
+}
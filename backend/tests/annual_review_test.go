@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// countingReviewWriter records how often a review is written so caching can be observed
+type countingReviewWriter struct {
+	services.DemoAnalyzer
+	calls   int
+	entries int
+}
+
+func (w *countingReviewWriter) WriteAnnualReview(ctx context.Context, year int, entries []services.AnnualReportEntry, trends []services.MetricTrend, readingLevel string) (*services.AnnualReviewText, error) {
+	w.calls++
+	w.entries = len(entries)
+	return w.DemoAnalyzer.WriteAnnualReview(ctx, year, entries, trends, readingLevel)
+}
+
+// TestAnnualReview tests that a year's review covers its reports and is cached until they change
+func TestAnnualReview(t *testing.T) {
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	userRepo := models.NewUserRepository(db.GetDB())
+	user := &models.User{Email: "annual@example.com", PasswordHash: "hash", FullName: "Annual", IsActive: true}
+	if err := userRepo.Create(user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	reportRepo := models.NewReportRepository(db.GetDB())
+	reports, err := services.NewDemoService(reportRepo).ProvisionSampleReports(user.ID)
+	if err != nil {
+		t.Fatalf("Failed to provision reports: %v", err)
+	}
+	for i, date := range []time.Time{
+		time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC),
+	} {
+		if err := reportRepo.UpdateDetails(reports[i].ID, models.ReportDetails{ReportDate: &date}); err != nil {
+			t.Fatalf("Failed to date report: %v", err)
+		}
+	}
+
+	writer := &countingReviewWriter{}
+	annual := services.NewAnnualReviewService(models.NewAnnualReviewRepository(db.GetDB()), reportRepo, writer)
+
+	review, err := annual.Get(user, 2024, models.ReadingLevelStandard)
+	if err != nil {
+		t.Fatalf("Failed to get annual review: %v", err)
+	}
+	if review.ReportCount != 2 || writer.entries != 2 || len(review.Trends) != 6 {
+		t.Errorf("Expected the two 2024 reports, got %d reports and %d trends", review.ReportCount, len(review.Trends))
+	}
+	if review.Trends[0].FirstDate.Month() != time.February {
+		t.Errorf("Expected trends in report date order, got %+v", review.Trends[0])
+	}
+
+	// Unchanged reports are served from the cache; a new reading level or an edited report regenerates
+	if _, err := annual.Get(user, 2024, models.ReadingLevelStandard); err != nil || writer.calls != 1 {
+		t.Errorf("Expected the cached review, got %d writes (%v)", writer.calls, err)
+	}
+	if _, err := annual.Get(user, 2024, models.ReadingLevelClinical); err != nil || writer.calls != 2 {
+		t.Errorf("Expected a new reading level to regenerate, got %d writes (%v)", writer.calls, err)
+	}
+	moved := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	if err := reportRepo.UpdateDetails(reports[2].ID, models.ReportDetails{ReportDate: &moved}); err != nil {
+		t.Fatalf("Failed to date report: %v", err)
+	}
+	review, err = annual.Get(user, 2024, models.ReadingLevelClinical)
+	if err != nil || writer.calls != 3 || review.ReportCount != 3 {
+		t.Errorf("Expected a report moved into the year to regenerate, got %d writes %+v (%v)", writer.calls, review, err)
+	}
+
+	for _, year := range []int{2022, 1800, time.Now().Year() + 1} {
+		if _, err := annual.Get(user, year, models.ReadingLevelStandard); err == nil {
+			t.Errorf("Expected year %d to be refused", year)
+		}
+	}
+
+	// Directions follow status, not value
+	trends := services.ComputeMetricTrends([]services.AnnualReportEntry{
+		{Analysis: &services.AnalysisResult{HealthMetrics: []services.HealthMetric{
+			{Name: "LDL", Value: 160.0, Status: "critical"}, {Name: "HDL", Value: 50.0, Status: "normal"}}}},
+		{Analysis: &services.AnalysisResult{HealthMetrics: []services.HealthMetric{
+			{Name: "ldl", Value: 120.0, Status: "warning"}, {Name: "HDL", Value: 35.0, Status: "warning"}}}},
+	})
+	if len(trends) != 2 || trends[0].Direction != services.TrendImproved || trends[0].Measurements != 2 ||
+		trends[1].Direction != services.TrendWorsened {
+		t.Errorf("Unexpected trends: %+v", trends)
+	}
+
+	// HTTP: a year without processed reports is refused
+	server := setupTestServer(t)
+	defer server.Close()
+	token := signupAndGetToken(t, server.URL, "annual-http@example.com")
+	var response types.AnnualReview
+	if status := doJSONRequest(t, "GET", server.URL+"/api/analyses/annual?year=2024", token, nil, &response); status != http.StatusBadRequest {
+		t.Errorf("Expected an empty year to be refused, got %d", status)
+	}
+	if status := doJSONRequest(t, "GET", server.URL+"/api/analyses/annual?year=abc", token, nil, nil); status != http.StatusBadRequest {
+		t.Errorf("Expected an invalid year to be refused, got %d", status)
+	}
+}
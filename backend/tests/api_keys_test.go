@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// TestAPIKeys tests creating, using, and revoking API keys, and what a key may not do
+func TestAPIKeys(t *testing.T) {
+	server := setupTestServer(t)
+	defer server.Close()
+
+	token := signupAndGetToken(t, server.URL, "scripts@example.com")
+
+	if status := doJSONRequest(t, "POST", server.URL+"/api/auth/api-keys", token, types.CreateAPIKeyRequest{Name: " "}, nil); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a key without a name, got %d", status)
+	}
+
+	var created types.CreateAPIKeyResponse
+	if status := doJSONRequest(t, "POST", server.URL+"/api/auth/api-keys", token, types.CreateAPIKeyRequest{Name: "lab sync"}, &created); status != http.StatusCreated {
+		t.Fatalf("Expected 201 creating a key, got %d", status)
+	}
+	if !strings.HasPrefix(created.Key, "mk_") || !strings.HasPrefix(created.Key, created.Prefix) || created.Prefix == created.Key {
+		t.Fatalf("Expected an mk_ key with a shorter shown prefix, got %q / %q", created.Key, created.Prefix)
+	}
+
+	// The key works wherever a session token does
+	var me types.User
+	if status := doJSONRequest(t, "GET", server.URL+"/api/auth/me", created.Key, nil, &me); status != http.StatusOK || me.Email != "scripts@example.com" {
+		t.Fatalf("Expected the key to authenticate as its owner, got %d %q", status, me.Email)
+	}
+	if status := doJSONRequest(t, "GET", server.URL+"/api/reports", created.Key, nil, nil); status != http.StatusOK {
+		t.Errorf("Expected the key to list reports, got %d", status)
+	}
+	if status := doJSONRequest(t, "GET", server.URL+"/api/auth/me", created.Key+"x", nil, nil); status != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for an unknown key, got %d", status)
+	}
+
+	// Keys can't manage keys, so a leaked key can't mint more
+	if status := doJSONRequest(t, "POST", server.URL+"/api/auth/api-keys", created.Key, types.CreateAPIKeyRequest{Name: "more"}, nil); status != http.StatusForbidden {
+		t.Errorf("Expected 403 creating a key with a key, got %d", status)
+	}
+
+	var keys types.APIKeysResponse
+	if status := doJSONRequest(t, "GET", server.URL+"/api/auth/api-keys", token, nil, &keys); status != http.StatusOK {
+		t.Fatalf("Expected 200 listing keys, got %d", status)
+	}
+	if len(keys.Keys) != 1 || keys.Keys[0].ID != created.ID || keys.Keys[0].LastUsedAt == nil {
+		t.Fatalf("Expected the used key listed, got %+v", keys.Keys)
+	}
+
+	// Other users can't see or revoke the key
+	otherToken := signupAndGetToken(t, server.URL, "other-scripts@example.com")
+	revokeURL := fmt.Sprintf("%s/api/auth/api-keys/%d", server.URL, created.ID)
+	if status := doJSONRequest(t, "DELETE", revokeURL, otherToken, nil, nil); status != http.StatusNotFound {
+		t.Errorf("Expected 404 revoking another user's key, got %d", status)
+	}
+
+	if status := doJSONRequest(t, "DELETE", revokeURL, token, nil, nil); status != http.StatusOK {
+		t.Fatalf("Expected 200 revoking the key, got %d", status)
+	}
+	if status := doJSONRequest(t, "GET", server.URL+"/api/auth/me", created.Key, nil, nil); status != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for a revoked key, got %d", status)
+	}
+	if status := doJSONRequest(t, "DELETE", revokeURL, token, nil, nil); status != http.StatusNotFound {
+		t.Errorf("Expected 404 revoking a key twice, got %d", status)
+	}
+}
+
+// TestAPIKeyAdminRoutes tests that an admin's key can't reach admin routes
+func TestAPIKeyAdminRoutes(t *testing.T) {
+	server := setupTestServer(t)
+	defer server.Close()
+
+	adminToken := signupAndGetToken(t, server.URL, "admin@example.com")
+	var created types.CreateAPIKeyResponse
+	if status := doJSONRequest(t, "POST", server.URL+"/api/auth/api-keys", adminToken, types.CreateAPIKeyRequest{Name: "cron"}, &created); status != http.StatusCreated {
+		t.Fatalf("Expected 201 creating a key, got %d", status)
+	}
+
+	if status := doJSONRequest(t, "GET", server.URL+"/api/admin/prompts/stats", adminToken, nil, nil); status != http.StatusOK {
+		t.Fatalf("Expected the admin session to reach admin routes, got %d", status)
+	}
+	if status := doJSONRequest(t, "GET", server.URL+"/api/admin/prompts/stats", created.Key, nil, nil); status != http.StatusForbidden {
+		t.Errorf("Expected 403 for an admin route with a key, got %d", status)
+	}
+}
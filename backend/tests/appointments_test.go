@@ -0,0 +1,188 @@
+package tests
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+const appointmentAnalysis = `{"schema_version": 4, "simple_summary": "Labs", "risk_level": "medium",
+	"recommendations": ["See a nephrologist about your creatinine", "Repeat the lipid panel in three months"]}`
+
+// schedulingSignature signs a payload the way the scheduling webhook contract requires
+func schedulingSignature(secret string, payload []byte, at time.Time) string {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	return "t=" + timestamp + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// TestAppointmentBooking tests booking a follow-up, recording the reference, and applying signed callbacks
+func TestAppointmentBooking(t *testing.T) {
+	const secret = "scheduling-secret"
+
+	// A scheduling adapter that checks the signature and fails for lipid follow-ups
+	var received []services.AppointmentRequest
+	schedulingServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		header := r.Header.Get("X-Signature")
+		timestamp, _, _ := strings.Cut(strings.TrimPrefix(header, "t="), ",")
+		unix, _ := strconv.ParseInt(timestamp, 10, 64)
+		if header != schedulingSignature(secret, body, time.Unix(unix, 0)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var request services.AppointmentRequest
+		json.Unmarshal(body, &request)
+		received = append(received, request)
+		if strings.Contains(request.Recommendation, "lipid") {
+			http.Error(w, "no slots", http.StatusConflict)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"reference": fmt.Sprintf("APT-%d", request.BookingID), "status": "pending",
+			"booking_url": "https://clinic.example.com/book/" + strconv.Itoa(request.BookingID)})
+	}))
+	defer schedulingServer.Close()
+
+	if _, err := services.NewScheduler(config.SchedulingConfig{Provider: "webhook", URL: schedulingServer.URL}); err == nil {
+		t.Error("Expected a webhook scheduler without a secret to be refused")
+	}
+	if _, err := services.NewScheduler(config.SchedulingConfig{Provider: "practo"}); err == nil {
+		t.Error("Expected an unknown scheduling provider to be refused")
+	}
+	scheduler, err := services.NewScheduler(config.SchedulingConfig{Provider: "webhook", URL: schedulingServer.URL, Secret: secret})
+	if err != nil {
+		t.Fatalf("Failed to create scheduler: %v", err)
+	}
+
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	owner := &models.User{Email: "owner@example.com", PasswordHash: "hash", FullName: "Owner", IsActive: true}
+	if err := models.NewUserRepository(db.GetDB()).Create(owner); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	reportRepo := models.NewReportRepository(db.GetDB())
+	report := &models.Report{UserID: owner.ID, OriginalFilename: "labs.txt", FilePath: "labs.txt", FileType: "text/plain",
+		FileSize: 10, ProcessingStatus: "pending", ReadingLevel: models.ReadingLevelStandard}
+	if err := reportRepo.Create(report); err != nil {
+		t.Fatalf("Failed to create report: %v", err)
+	}
+	if err := reportRepo.UpdateProcessingStatus(report.ID, "completed", appointmentAnalysis); err != nil {
+		t.Fatalf("Failed to store analysis: %v", err)
+	}
+
+	notificationRepo := models.NewNotificationRepository(db.GetDB())
+	appointments := services.NewAppointmentService(models.NewAppointmentRepository(db.GetDB()), reportRepo, notificationRepo, scheduler, secret)
+	ctx := context.Background()
+
+	if _, err := appointments.Book(ctx, owner, report.ID, 2, services.BookingInput{}); err != errors.ErrRecordNotFound {
+		t.Errorf("Expected an unknown recommendation to be not found, got %v", err)
+	}
+	if _, err := appointments.Book(ctx, owner, report.ID, 0, services.BookingInput{Specialty: "astrology"}); err == nil {
+		t.Error("Expected an unknown specialty to be refused")
+	}
+	past := time.Now().Add(-time.Hour)
+	if _, err := appointments.Book(ctx, owner, report.ID, 0, services.BookingInput{PreferredTime: &past}); err == nil {
+		t.Error("Expected a preferred time in the past to be refused")
+	}
+	if len(received) != 0 {
+		t.Fatalf("Expected invalid bookings not to reach the scheduling system, got %+v", received)
+	}
+
+	booking, err := appointments.Book(ctx, owner, report.ID, 0, services.BookingInput{Specialty: "nephrology", Notes: " mornings "})
+	if err != nil {
+		t.Fatalf("Failed to book follow-up: %v", err)
+	}
+	if booking.Status != models.BookingPending || booking.ExternalRef != fmt.Sprintf("APT-%d", booking.ID) || booking.BookingURL == "" {
+		t.Errorf("Expected a pending booking with a reference, got %+v", booking)
+	}
+	if len(received) != 1 || received[0].PatientEmail != owner.Email || received[0].Specialty != "nephrology" || received[0].Notes != "mornings" {
+		t.Errorf("Unexpected scheduling request %+v", received)
+	}
+	if _, err := appointments.Book(ctx, owner, report.ID, 0, services.BookingInput{}); err != errors.ErrBookingExists {
+		t.Errorf("Expected a second booking for the same recommendation to conflict, got %v", err)
+	}
+	other := &models.User{ID: owner.ID + 1, Email: "other@example.com"}
+	if _, err := appointments.Book(ctx, other, report.ID, 1, services.BookingInput{}); err != errors.ErrAccessDenied {
+		t.Errorf("Expected another user's report to be refused, got %v", err)
+	}
+
+	// A refusal from the scheduling system is kept as a failed booking, which doesn't block a retry
+	if _, err := appointments.Book(ctx, owner, report.ID, 1, services.BookingInput{}); err != errors.ErrSchedulingFailed {
+		t.Errorf("Expected the scheduling failure to be reported, got %v", err)
+	}
+	bookings, err := appointments.List(owner.ID, report.ID)
+	if err != nil || len(bookings) != 2 || bookings[1].Status != models.BookingFailed || !strings.Contains(bookings[1].Error, "409") {
+		t.Fatalf("Expected the failed booking to be recorded, got %+v (%v)", bookings, err)
+	}
+
+	// Signed callbacks move the booking on and notify the user once per change
+	scheduled := time.Now().Add(48 * time.Hour).UTC().Truncate(time.Second)
+	payload, _ := json.Marshal(map[string]any{"reference": booking.ExternalRef, "status": "booked", "scheduled_at": scheduled})
+	if err := appointments.HandleCallback(payload, schedulingSignature("wrong", payload, time.Now())); err != errors.ErrInvalidWebhookSignature {
+		t.Errorf("Expected a wrongly signed callback to be rejected, got %v", err)
+	}
+	if err := appointments.HandleCallback(payload, schedulingSignature(secret, payload, time.Now().Add(-time.Hour))); err != errors.ErrInvalidWebhookSignature {
+		t.Errorf("Expected a stale callback to be rejected, got %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := appointments.HandleCallback(payload, schedulingSignature(secret, payload, time.Now())); err != nil {
+			t.Fatalf("Failed to apply callback: %v", err)
+		}
+	}
+	bookings, _ = appointments.List(owner.ID, report.ID)
+	if bookings[0].Status != models.BookingBooked || bookings[0].ScheduledAt == nil || !bookings[0].ScheduledAt.Equal(scheduled) {
+		t.Errorf("Expected the booking to be scheduled, got %+v", bookings[0])
+	}
+	notifications, err := notificationRepo.ListByUser(owner.ID, false, 10, 0)
+	if err != nil || len(notifications) != 1 || notifications[0].Kind != models.NotificationAppointment {
+		t.Errorf("Expected one appointment notification, got %+v (%v)", notifications, err)
+	}
+
+	unknown, _ := json.Marshal(map[string]any{"reference": "APT-404", "status": "cancelled"})
+	if err := appointments.HandleCallback(unknown, schedulingSignature(secret, unknown, time.Now())); err != errors.ErrRecordNotFound {
+		t.Errorf("Expected an unknown reference to be not found, got %v", err)
+	}
+}
+
+// TestAppointmentEndpoints tests the booking endpoints when no scheduling system is configured
+func TestAppointmentEndpoints(t *testing.T) {
+	server := setupTestServer(t)
+	defer server.Close()
+
+	token := signupAndGetToken(t, server.URL, "patient@example.com")
+	if status := doJSONRequest(t, "POST", server.URL+"/api/reports/1/recommendations/0/bookings", token, map[string]any{}, nil); status != http.StatusServiceUnavailable {
+		t.Errorf("Expected booking to be unavailable without a scheduling system, got %d", status)
+	}
+	if status := doJSONRequest(t, "POST", server.URL+"/api/reports/1/recommendations/0/bookings", "", map[string]any{}, nil); status != http.StatusUnauthorized {
+		t.Errorf("Expected booking to require authentication, got %d", status)
+	}
+	if status := doJSONRequest(t, "GET", server.URL+"/api/reports/999/bookings", token, nil, nil); status != http.StatusNotFound {
+		t.Errorf("Expected an unknown report to be not found, got %d", status)
+	}
+	if status := doJSONRequest(t, "POST", server.URL+"/api/scheduling/webhook", "", map[string]any{"reference": "x"}, nil); status != http.StatusServiceUnavailable {
+		t.Errorf("Expected callbacks to be unavailable without a scheduling system, got %d", status)
+	}
+}
@@ -52,6 +52,10 @@ func createTestTables(t *testing.T, db *database.DB) {
 			full_name TEXT NOT NULL,
 			email_verified BOOLEAN DEFAULT FALSE,
 			is_active BOOLEAN DEFAULT TRUE,
+			timezone TEXT NOT NULL DEFAULT 'UTC',
+			reading_level TEXT NOT NULL DEFAULT 'standard',
+			plan TEXT NOT NULL DEFAULT 'free',
+			version INTEGER NOT NULL DEFAULT 1,
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 		)`
@@ -315,20 +319,58 @@ func TestAuthServiceTokenValidation(t *testing.T) {
 		t.Fatal("Should fail to validate invalid token")
 	}
 
-	// Test token refresh
-	newToken, err := authService.RefreshToken(loginResponse.Token)
-	if err != nil {
-		t.Fatalf("Should refresh valid token: %v", err)
+	// Decision: Without a refresh token store, sign-ins get an access token only and nothing can be refreshed
+	if loginResponse.RefreshToken != "" {
+		t.Fatal("Should not issue a refresh token without a store for them")
+	}
+	if _, err := authService.Refresh("rt_unknown"); err == nil {
+		t.Fatal("Should fail to refresh without refresh tokens")
+	}
+
+	t.Log("Auth service token validation test passed")
+}
+
+// TestCachedUserRepository tests cache hits and invalidation on deactivation
+func TestCachedUserRepository(t *testing.T) {
+	_, db := setupAuthTest(t)
+	defer db.Close()
+
+	cachedRepo := models.NewCachedUserRepository(models.NewUserRepository(db.GetDB()), time.Minute)
+
+	user := &models.User{
+		Email:        "cached@example.com",
+		PasswordHash: "hashed_password_123",
+		FullName:     "Cached User",
+		IsActive:     true,
+	}
+	if err := cachedRepo.Create(user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	// First lookup misses, second hits
+	for i := 0; i < 2; i++ {
+		if _, err := cachedRepo.GetByID(user.ID); err != nil {
+			t.Fatalf("Failed to get user: %v", err)
+		}
 	}
 
-	// Decision: Focus on functionality - refreshed token should be valid
-	// (May be identical if refreshed immediately, which is acceptable)
+	stats := cachedRepo.Stats()
+	if stats.Hits != 1 || stats.Misses != 1 {
+		t.Fatalf("Expected 1 hit and 1 miss, got %d hits and %d misses", stats.Hits, stats.Misses)
+	}
 
-	// Validate refreshed token works
-	_, err = authService.GetUserFromToken(newToken)
+	// Decision: Deactivation must not be masked by a stale cache entry
+	if err := cachedRepo.Delete(user.ID); err != nil {
+		t.Fatalf("Failed to deactivate user: %v", err)
+	}
+
+	deactivated, err := cachedRepo.GetByID(user.ID)
 	if err != nil {
-		t.Fatalf("Refreshed token should be valid: %v", err)
+		t.Fatalf("Failed to get user after deactivation: %v", err)
+	}
+	if deactivated != nil {
+		t.Fatal("Deactivated user should not be returned from cache")
 	}
 
-	t.Log("Auth service token validation test passed")
-}
\ No newline at end of file
+	t.Log("Cached user repository test passed")
+}
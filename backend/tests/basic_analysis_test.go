@@ -0,0 +1,141 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/tests/fixtures"
+)
+
+// TestBasicMetricExtraction tests that basic metrics are judged only by the range and flag printed with them
+func TestBasicMetricExtraction(t *testing.T) {
+	for _, report := range fixtures.Reports {
+		if report.Name != "cbc_columns.txt" {
+			continue
+		}
+		text, err := report.Bytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		metrics := services.ExtractBasicMetrics(string(text))
+
+		byName := make(map[string]services.HealthMetric)
+		for _, metric := range metrics {
+			byName[metric.Name] = metric
+		}
+		if hb := byName["Haemoglobin"]; hb.Value != 11.2 || hb.Unit != "g/dL" || hb.Status != "warning" || hb.RangeMin != 12 {
+			t.Errorf("Expected haemoglobin 11.2 g/dL below its printed range, got %+v", hb)
+		}
+		if mcv := byName["MCV"]; mcv.Value != 84.0 || mcv.Unit != "fL" || mcv.Status != "normal" || mcv.LowConfidence {
+			t.Errorf("Expected MCV 84 fL within its printed range, got %+v", mcv)
+		}
+		if len(metrics) != 12 {
+			t.Errorf("Expected the 12 results of the CBC, got %d: %+v", len(metrics), metrics)
+		}
+	}
+
+	// Without a printed range only the lab's flag is judged, and the value stays off the gauge
+	flagged := services.ExtractBasicMetrics("Serum Creatinine  1.9  mg/dL  H")
+	if len(flagged) != 1 || flagged[0].Status != "warning" || !flagged[0].LowConfidence {
+		t.Errorf("Expected a flagged creatinine without a gauge, got %+v", flagged)
+	}
+}
+
+// TestBasicFallback tests that reports are completed with a basic extraction when there is no model or it keeps
+// failing, and that reports the extractor can't read still fail
+func TestBasicFallback(t *testing.T) {
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	owner := &models.User{Email: "basic@example.com", PasswordHash: "hash", FullName: "Basic", IsActive: true}
+	if err := models.NewUserRepository(db.GetDB()).Create(owner); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	uploadDir := t.TempDir()
+	reportRepo := models.NewReportRepository(db.GetDB())
+	newReport := func(name, content string) *models.Report {
+		path := filepath.Join(uploadDir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write report: %v", err)
+		}
+		report := &models.Report{UserID: owner.ID, OriginalFilename: name, FilePath: path,
+			FileType: "text/plain", FileSize: int64(len(content)), ProcessingStatus: "pending", ReadingLevel: models.ReadingLevelStandard}
+		if err := reportRepo.Create(report); err != nil {
+			t.Fatalf("Failed to create report: %v", err)
+		}
+		return report
+	}
+	storage := services.NewFileStorage(uploadDir, "secret")
+	jobRepo := models.NewJobRepository(db.GetDB())
+	basic := services.NewBasicAnalyzer(config.ExtractionConfig{})
+	const labText = "Fasting Blood Sugar  142  mg/dL  H  70 - 100\nHbA1c  7.1  %  H  4.0 - 5.6\n"
+
+	processors := map[string]*services.ReportProcessor{
+		"no model":   services.NewReportProcessorWithAnalyzer(reportRepo, jobRepo, nil, nil, nil, storage),
+		"model down": services.NewReportProcessorWithAnalyzer(reportRepo, jobRepo, nil, nil, failingAnalyzer{}, storage),
+		"quota": services.NewReportProcessorWithAnalyzer(reportRepo, jobRepo, nil, nil,
+			erroringAnalyzer{err: &services.RateLimitError{Err: fmt.Errorf("quota exhausted")}}, storage),
+	}
+	for name, processor := range processors {
+		processor.SetFallbackAnalyzer(basic)
+		report := newReport(strings.ReplaceAll(name, " ", "_")+".txt", labText)
+		if err := processor.ProcessReport(report); err != nil {
+			t.Fatalf("%s: expected the basic extraction stored, got %v", name, err)
+		}
+
+		stored, _ := reportRepo.GetByID(report.ID)
+		if stored.ProcessingStatus != "completed" || stored.PromptVersion != services.BasicPromptVersion || stored.ErrorCode != "" {
+			t.Errorf("%s: expected a completed basic analysis, got %+v", name, stored)
+			continue
+		}
+		if stored.Language != "en" {
+			t.Errorf("%s: expected the report's language recorded, got %q", name, stored.Language)
+		}
+		analysis, err := services.ParseStoredAnalysis(stored.SimplifiedSummary)
+		if err != nil {
+			t.Fatalf("%s: failed to parse the stored analysis: %v", name, err)
+		}
+		if analysis.AnalysisMode != services.AnalysisModeBasic || !strings.Contains(analysis.Summary, services.BasicAnalysisNotice) ||
+			analysis.ExtractedText != labText {
+			t.Errorf("%s: expected an honest basic analysis keeping the text, got %+v", name, analysis)
+		}
+		if len(analysis.HealthMetrics) != 2 || analysis.HealthMetrics[0].Status != "warning" || analysis.HealthMetrics[0].RangeMax != 100 {
+			t.Errorf("%s: expected both results judged by their printed ranges, got %+v", name, analysis.HealthMetrics)
+		}
+	}
+
+	// A file no extractor can read fails the same way with or without the model
+	processor := services.NewReportProcessorWithAnalyzer(reportRepo, jobRepo, nil, nil, nil, storage)
+	processor.SetFallbackAnalyzer(basic)
+	empty := newReport("empty.txt", "")
+	if err := processor.ProcessReport(empty); err == nil {
+		t.Error("Expected an empty report to fail")
+	}
+	if stored, _ := reportRepo.GetByID(empty.ID); stored.ProcessingStatus != "failed" || stored.ErrorCode != models.ProcessingErrorUnreadableDocument {
+		t.Errorf("Expected an unreadable failure, got %+v", stored)
+	}
+
+	// Without a fallback a report still fails when the model does
+	strict := services.NewReportProcessorWithAnalyzer(reportRepo, jobRepo, nil, nil, failingAnalyzer{}, storage)
+	failed := newReport("strict.txt", labText)
+	strict.ProcessReport(failed)
+	if stored, _ := reportRepo.GetByID(failed.ID); stored.ProcessingStatus != "failed" {
+		t.Errorf("Expected the report failed without a fallback, got %q", stored.ProcessingStatus)
+	}
+
+	if _, err := basic.AnalyzeReport(context.Background(), filepath.Join(uploadDir, "missing.txt"), "text/plain", "", "", ""); err == nil {
+		t.Error("Expected a missing file to fail extraction")
+	}
+}
@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+)
+
+// Run with: go test ./tests -run '^$' -bench . -benchmem
+
+const (
+	benchmarkUsers   = 500
+	benchmarkReports = 200
+)
+
+// benchmarkDB creates a database with benchmarkUsers users, the first owning benchmarkReports reports
+func benchmarkDB(tb testing.TB) (*database.DB, []*models.User) {
+	tb.Helper()
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		tb.Fatalf("Failed to setup test database: %v", err)
+	}
+	tb.Cleanup(func() { db.Close() })
+	createAllTestTables(tb, db)
+
+	userRepo := models.NewUserRepository(db.GetDB())
+	reportRepo := models.NewReportRepository(db.GetDB())
+	var users []*models.User
+	for i := range benchmarkUsers {
+		user := &models.User{Email: fmt.Sprintf("patient%d@example.com", i), PasswordHash: "hash", FullName: "Patient", IsActive: true}
+		if err := userRepo.Create(user); err != nil {
+			tb.Fatalf("Failed to create user: %v", err)
+		}
+		users = append(users, user)
+	}
+	for range benchmarkReports {
+		report := &models.Report{UserID: users[0].ID, OriginalFilename: "cbc.pdf", FilePath: "cbc.pdf", FileType: "pdf", FileSize: 1}
+		if err := reportRepo.Create(report); err != nil {
+			tb.Fatalf("Failed to create report: %v", err)
+		}
+	}
+	return db, users
+}
+
+// BenchmarkJWTValidate measures the check every authenticated request makes
+func BenchmarkJWTValidate(b *testing.B) {
+	jwtService := services.NewJWTService("benchmark-secret", time.Hour)
+	token, err := jwtService.GenerateToken(1, "patient@example.com")
+	if err != nil {
+		b.Fatalf("Failed to generate token: %v", err)
+	}
+	for b.Loop() {
+		if _, err := jwtService.ValidateToken(token); err != nil {
+			b.Fatalf("Failed to validate token: %v", err)
+		}
+	}
+}
+
+// BenchmarkUserGetByEmail measures the lookup behind login
+func BenchmarkUserGetByEmail(b *testing.B) {
+	db, users := benchmarkDB(b)
+	userRepo := models.NewUserRepository(db.GetDB())
+	email := users[len(users)/2].Email
+	for b.Loop() {
+		if _, err := userRepo.GetByEmail(email); err != nil {
+			b.Fatalf("Failed to get user: %v", err)
+		}
+	}
+}
+
+// BenchmarkReportCreate measures the insert behind an upload
+func BenchmarkReportCreate(b *testing.B) {
+	db, users := benchmarkDB(b)
+	reportRepo := models.NewReportRepository(db.GetDB())
+	for b.Loop() {
+		report := &models.Report{UserID: users[1].ID, OriginalFilename: "cbc.pdf", FilePath: "cbc.pdf", FileType: "pdf", FileSize: 1}
+		if err := reportRepo.Create(report); err != nil {
+			b.Fatalf("Failed to create report: %v", err)
+		}
+	}
+}
+
+// BenchmarkReportListByUser measures the first page of a patient's report list
+func BenchmarkReportListByUser(b *testing.B) {
+	db, users := benchmarkDB(b)
+	reportRepo := models.NewReportRepository(db.GetDB())
+	for b.Loop() {
+		if reports, err := reportRepo.GetByUserID(users[0].ID, 20, 0); err != nil || len(reports) != 20 {
+			b.Fatalf("Failed to list reports: %d (%v)", len(reports), err)
+		}
+	}
+}
+
+// TestPerformanceBudgets tests that the benchmarks above stay within their per-operation budgets
+// Decision: The budgets are several times what a laptop measures, so they only catch regressions like a lost
+// index or a query per row, not noise from a busy CI machine
+func TestPerformanceBudgets(t *testing.T) {
+	requireLoadTests(t)
+
+	for _, budget := range []struct {
+		name      string
+		benchmark func(*testing.B)
+		perOp     time.Duration
+	}{
+		{"JWTValidate", BenchmarkJWTValidate, 200 * time.Microsecond},
+		{"UserGetByEmail", BenchmarkUserGetByEmail, time.Millisecond},
+		{"ReportCreate", BenchmarkReportCreate, 5 * time.Millisecond},
+		{"ReportListByUser", BenchmarkReportListByUser, 5 * time.Millisecond},
+	} {
+		result := testing.Benchmark(budget.benchmark)
+		if result.N == 0 {
+			t.Errorf("%s failed to run", budget.name)
+			continue
+		}
+		perOp := time.Duration(result.NsPerOp())
+		t.Logf("%s: %s/op", budget.name, perOp)
+		if perOp > budget.perOp {
+			t.Errorf("%s took %s/op, over its %s budget", budget.name, perOp, budget.perOp)
+		}
+	}
+}
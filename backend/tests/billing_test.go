@@ -0,0 +1,310 @@
+package tests
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+const testWebhookSecret = "whsec_test"
+
+// hmacHex signs message the way both payment providers sign webhooks
+func hmacHex(secret string, message []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(message)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// TestNewPaymentProvider tests provider selection and required settings
+func TestNewPaymentProvider(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.PaymentConfig
+		want    string
+		wantErr bool
+	}{
+		{"disabled", config.PaymentConfig{Provider: "none"}, "", false},
+		{"stripe", config.PaymentConfig{Provider: "Stripe", SecretKey: "sk", WebhookSecret: "wh", PremiumPriceID: "price"}, "stripe", false},
+		{"stripe without price", config.PaymentConfig{Provider: "stripe", SecretKey: "sk", WebhookSecret: "wh"}, "", true},
+		{"razorpay without key id", config.PaymentConfig{Provider: "razorpay", SecretKey: "sk", WebhookSecret: "wh", PremiumPriceID: "plan"}, "", true},
+		{"unknown", config.PaymentConfig{Provider: "paypal"}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := services.NewPaymentProvider(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.want == "" && provider != nil {
+				t.Errorf("Expected no provider, got %s", provider.Name())
+			}
+			if tt.want != "" && (provider == nil || provider.Name() != tt.want) {
+				t.Errorf("Expected the %s provider, got %v", tt.want, provider)
+			}
+		})
+	}
+}
+
+// TestStripeSubscriptionFlow tests checkout, webhook entitlement sync, premium gating, and cancellation
+func TestStripeSubscriptionFlow(t *testing.T) {
+	var checkoutForm, cancelForm map[string]string
+	stripe := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer sk_test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		r.ParseForm()
+		form := map[string]string{}
+		for key := range r.PostForm {
+			form[key] = r.PostForm.Get(key)
+		}
+		switch r.URL.Path {
+		case "/v1/checkout/sessions":
+			checkoutForm = form
+			json.NewEncoder(w).Encode(map[string]string{"id": "cs_1", "url": "https://checkout.stripe.test/cs_1"})
+		case "/v1/subscriptions/sub_1":
+			cancelForm = form
+			json.NewEncoder(w).Encode(map[string]string{"id": "sub_1"})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer stripe.Close()
+
+	provider, err := services.NewPaymentProvider(config.PaymentConfig{Provider: "stripe", SecretKey: "sk_test",
+		WebhookSecret: testWebhookSecret, PremiumPriceID: "price_premium", APIURL: stripe.URL})
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+	plans := map[string]config.PlanConfig{
+		models.PlanFree:    testPlans[models.PlanFree],
+		models.PlanPremium: {MaxOutputTokens: 4096, SummaryWords: 200, Features: []string{services.FeatureCrossReport, services.FeaturePDFExport}},
+	}
+	server := setupTestServerWith(t, plans, provider)
+	defer server.Close()
+
+	token := signupAndGetToken(t, server.URL, "subscriber@example.com")
+	var me types.User
+	doJSONRequest(t, "GET", server.URL+"/api/auth/me", token, nil, &me)
+
+	planOf := func() string {
+		var plan types.PlanResponse
+		doJSONRequest(t, "GET", server.URL+"/api/users/me/plan", token, nil, &plan)
+		return plan.Plan
+	}
+	gated := func() (int, int) {
+		merge := doJSONRequest(t, "POST", server.URL+"/api/analyses/merge", token, types.MergeAnalysisRequest{}, nil)
+		export := doJSONRequest(t, "GET", server.URL+"/api/reports/1/chat/export?format=pdf", token, nil, nil)
+		return merge, export
+	}
+	webhook := func(id, eventType string, created time.Time, object map[string]any, signature string) int {
+		payload, _ := json.Marshal(map[string]any{"id": id, "type": eventType, "created": created.Unix(),
+			"data": map[string]any{"object": object}})
+		if signature == "" {
+			signedAt := fmt.Sprint(time.Now().Unix())
+			signature = "t=" + signedAt + ",v1=" + hmacHex(testWebhookSecret, []byte(signedAt+"."+string(payload)))
+		}
+		req, _ := http.NewRequest("POST", server.URL+"/api/billing/webhook", strings.NewReader(string(payload)))
+		req.Header.Set("Stripe-Signature", signature)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to deliver webhook: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if merge, export := gated(); merge != http.StatusPaymentRequired || export != http.StatusPaymentRequired {
+		t.Errorf("Expected premium features to need premium, got %d for merge and %d for PDF export", merge, export)
+	}
+	if status := doJSONRequest(t, "GET", server.URL+"/api/reports/1/chat/export?format=markdown", token, nil, nil); status == http.StatusPaymentRequired {
+		t.Error("Expected markdown export to stay free")
+	}
+
+	var checkout types.CheckoutResponse
+	if status := doJSONRequest(t, "POST", server.URL+"/api/billing/checkout", token, nil, &checkout); status != http.StatusCreated {
+		t.Fatalf("Expected 201 starting checkout, got %d", status)
+	}
+	if checkout.Provider != "stripe" || checkout.CheckoutURL != "https://checkout.stripe.test/cs_1" {
+		t.Errorf("Expected the Stripe checkout page, got %+v", checkout)
+	}
+	if checkoutForm["client_reference_id"] != fmt.Sprint(me.ID) || checkoutForm["subscription_data[metadata][user_id]"] != fmt.Sprint(me.ID) ||
+		checkoutForm["line_items[0][price]"] != "price_premium" || checkoutForm["customer_email"] != "subscriber@example.com" {
+		t.Errorf("Expected the checkout to carry the user and price, got %v", checkoutForm)
+	}
+
+	paidAt := time.Now().Add(-time.Minute)
+	session := map[string]any{"client_reference_id": fmt.Sprint(me.ID), "customer": "cus_1", "subscription": "sub_1",
+		"mode": "subscription", "payment_status": "paid"}
+	if status := webhook("evt_1", "checkout.session.completed", paidAt, session, "t=1,v1=forged"); status != http.StatusBadRequest {
+		t.Errorf("Expected a forged signature to be rejected, got %d", status)
+	}
+	if planOf() != models.PlanFree {
+		t.Fatal("Expected a forged webhook to leave the plan alone")
+	}
+	for range 2 {
+		if status := webhook("evt_1", "checkout.session.completed", paidAt, session, ""); status != http.StatusOK {
+			t.Fatalf("Expected the webhook to be accepted, got %d", status)
+		}
+	}
+	if planOf() != models.PlanPremium {
+		t.Fatal("Expected a paid checkout to upgrade the user")
+	}
+	if merge, export := gated(); merge == http.StatusPaymentRequired || export == http.StatusPaymentRequired {
+		t.Errorf("Expected premium to unlock merge and PDF export, got %d and %d", merge, export)
+	}
+	if status := doJSONRequest(t, "POST", server.URL+"/api/billing/checkout", token, nil, nil); status != http.StatusConflict {
+		t.Errorf("Expected a second checkout to be refused, got %d", status)
+	}
+
+	var subscription types.Subscription
+	if status := doJSONRequest(t, "POST", server.URL+"/api/billing/cancel", token, nil, &subscription); status != http.StatusOK {
+		t.Fatalf("Expected 200 cancelling, got %d", status)
+	}
+	if !subscription.CancelAtPeriodEnd || cancelForm["cancel_at_period_end"] != "true" || planOf() != models.PlanPremium {
+		t.Errorf("Expected the subscription to lapse at period end and stay premium until then, got %+v", subscription)
+	}
+
+	// Events without the user's metadata are matched through the stored subscription
+	ended := map[string]any{"id": "sub_1", "customer": "cus_1", "status": "canceled", "current_period_end": paidAt.Unix()}
+	if status := webhook("evt_3", "customer.subscription.deleted", paidAt.Add(time.Minute), ended, ""); status != http.StatusOK {
+		t.Fatalf("Expected the webhook to be accepted, got %d", status)
+	}
+	if planOf() != models.PlanFree {
+		t.Fatal("Expected a deleted subscription to downgrade the user")
+	}
+
+	// A renewal event delivered after the cancellation is older and must not restore premium
+	renewed := map[string]any{"id": "sub_1", "customer": "cus_1", "status": "active", "current_period_end": paidAt.Add(720 * time.Hour).Unix()}
+	webhook("evt_2", "customer.subscription.updated", paidAt.Add(30*time.Second), renewed, "")
+	if planOf() != models.PlanFree {
+		t.Error("Expected a stale event to be ignored")
+	}
+
+	var billing types.BillingResponse
+	doJSONRequest(t, "GET", server.URL+"/api/billing/subscription", token, nil, &billing)
+	if !billing.PaymentsEnabled || billing.Subscription == nil || billing.Subscription.Status != models.SubscriptionCanceled ||
+		billing.Subscription.CancelAtPeriodEnd {
+		t.Errorf("Expected a canceled subscription, got %+v", billing)
+	}
+
+	// Resubscribing reuses the Stripe customer
+	if status := doJSONRequest(t, "POST", server.URL+"/api/billing/checkout", token, nil, nil); status != http.StatusCreated ||
+		checkoutForm["customer"] != "cus_1" {
+		t.Errorf("Expected a new checkout for the existing customer, got %d %v", status, checkoutForm)
+	}
+}
+
+// TestRazorpayWebhooks tests Razorpay checkout, signature checks, replayed events, and past-due grace
+func TestRazorpayWebhooks(t *testing.T) {
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	var created struct {
+		PlanID string            `json:"plan_id"`
+		Notes  map[string]string `json:"notes"`
+	}
+	razorpay := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if key, secret, ok := r.BasicAuth(); !ok || key != "rzp_key" || secret != "rzp_secret" || r.URL.Path != "/v1/subscriptions" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&created)
+		json.NewEncoder(w).Encode(map[string]string{"id": "sub_R1", "short_url": "https://rzp.io/i/sub_R1", "status": "created"})
+	}))
+	defer razorpay.Close()
+
+	provider, err := services.NewPaymentProvider(config.PaymentConfig{Provider: "razorpay", KeyID: "rzp_key", SecretKey: "rzp_secret",
+		WebhookSecret: testWebhookSecret, PremiumPriceID: "plan_premium", APIURL: razorpay.URL})
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+
+	userRepo := models.NewUserRepository(db.GetDB())
+	subRepo := models.NewSubscriptionRepository(db.GetDB())
+	billing := services.NewBillingService(provider, subRepo, userRepo, models.NewAuditLogRepository(db.GetDB()))
+	user := &models.User{Email: "rupee@example.com", PasswordHash: "hash", FullName: "Rupee", IsActive: true}
+	if err := userRepo.Create(user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	checkout, err := billing.Checkout(context.Background(), user)
+	if err != nil {
+		t.Fatalf("Failed to start checkout: %v", err)
+	}
+	if checkout.URL != "https://rzp.io/i/sub_R1" || created.PlanID != "plan_premium" || created.Notes["user_id"] != fmt.Sprint(user.ID) {
+		t.Errorf("Expected a subscription for the user on the premium plan, got %+v and %+v", checkout, created)
+	}
+	if pending, _ := subRepo.GetByUserID(user.ID); pending == nil || pending.Status != models.SubscriptionIncomplete {
+		t.Errorf("Expected the unpaid subscription to be stored as incomplete, got %+v", pending)
+	}
+
+	deliver := func(eventID, event, status string, at time.Time) error {
+		payload, _ := json.Marshal(map[string]any{"entity": "event", "event": event, "created_at": at.Unix(),
+			"payload": map[string]any{"subscription": map[string]any{"entity": map[string]any{
+				"id": "sub_R1", "customer_id": "cust_1", "status": status, "current_end": at.Add(720 * time.Hour).Unix(),
+				"notes": map[string]string{"user_id": fmt.Sprint(user.ID)},
+			}}}})
+		header := http.Header{}
+		header.Set("X-Razorpay-Signature", hmacHex(testWebhookSecret, payload))
+		header.Set("X-Razorpay-Event-Id", eventID)
+		return billing.HandleWebhook(payload, header)
+	}
+	planOf := func() string {
+		current, _ := userRepo.GetByID(user.ID)
+		return current.Plan
+	}
+
+	if err := billing.HandleWebhook([]byte(`{"event":"subscription.activated"}`), http.Header{"X-Razorpay-Signature": {"bad"}}); err != errors.ErrInvalidWebhookSignature {
+		t.Errorf("Expected an unsigned webhook to be rejected, got %v", err)
+	}
+
+	start := time.Now().Add(-time.Hour)
+	if err := deliver("evt_a", "subscription.activated", "active", start); err != nil {
+		t.Fatalf("Failed to apply activation: %v", err)
+	}
+	if planOf() != models.PlanPremium {
+		t.Fatal("Expected activation to upgrade the user")
+	}
+
+	// A replayed event is acknowledged without being applied again
+	downgraded, _ := userRepo.GetByID(user.ID)
+	demoted := *downgraded
+	demoted.Plan = models.PlanFree
+	userRepo.Update(&demoted)
+	if err := deliver("evt_a", "subscription.activated", "active", start); err != nil || planOf() != models.PlanFree {
+		t.Errorf("Expected the replay to be ignored, got %v with plan %s", err, planOf())
+	}
+
+	// Failed renewals keep premium while Razorpay retries, and take it away once it gives up
+	if err := deliver("evt_b", "subscription.pending", "pending", start.Add(time.Minute)); err != nil || planOf() != models.PlanPremium {
+		t.Errorf("Expected premium during the retry window, got %v with plan %s", err, planOf())
+	}
+	if err := deliver("evt_c", "subscription.halted", "halted", start.Add(2*time.Minute)); err != nil || planOf() != models.PlanFree {
+		t.Errorf("Expected a halted subscription to downgrade, got %v with plan %s", err, planOf())
+	}
+	stored, _ := subRepo.GetByUserID(user.ID)
+	if stored == nil || stored.Status != models.SubscriptionPaused || stored.CustomerID != "cust_1" || stored.CurrentPeriodEnd == nil {
+		t.Errorf("Expected the stored subscription to follow the events, got %+v", stored)
+	}
+}
@@ -0,0 +1,155 @@
+package tests
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ledongthuc/pdf"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+// TestOrganizationBranding tests organization branding rules and how exports apply it
+func TestOrganizationBranding(t *testing.T) {
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	userRepo := models.NewUserRepository(db.GetDB())
+	clinician := &models.User{Email: "clinic-admin@example.com", PasswordHash: "hash", FullName: "Admin", IsActive: true}
+	patient := &models.User{Email: "clinic-patient@example.com", PasswordHash: "hash", FullName: "Patient", IsActive: true}
+	for _, user := range []*models.User{clinician, patient} {
+		if err := userRepo.Create(user); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+
+	branding := services.NewBrandingService(models.NewOrganizationRepository(db.GetDB()), userRepo)
+	if b, err := branding.ForUser(patient.ID); err != nil || b != nil {
+		t.Fatalf("Expected no branding outside an organization, got %+v (%v)", b, err)
+	}
+
+	org, err := branding.CreateOrganization("Lakeside Clinic")
+	if err != nil {
+		t.Fatalf("Failed to create organization: %v", err)
+	}
+	if err := branding.AddMember(org.ID, clinician.Email, models.OrgRoleAdmin, ""); err != nil {
+		t.Fatalf("Failed to add admin: %v", err)
+	}
+	if err := branding.AddMember(org.ID, patient.Email, "", ""); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+	if err := branding.AddMember(org.ID, "nobody@example.com", "", ""); err != errors.ErrUserNotFound {
+		t.Errorf("Expected unknown email to be rejected, got %v", err)
+	}
+
+	update := services.BrandingUpdate{
+		FooterText:  "Lakeside Clinic, 12 Shore Road",
+		ContactInfo: "Call 555-0100",
+		Sections:    []string{"summary", "key_findings", "summary", "conversation"},
+	}
+	if _, err := branding.UpdateBranding(patient.ID, update); err != errors.ErrOrganizationAdminRequired {
+		t.Errorf("Expected members to be refused, got %v", err)
+	}
+	if _, err := branding.UpdateBranding(clinician.ID, services.BrandingUpdate{Sections: []string{"billing"}}); err == nil {
+		t.Error("Expected unknown section to be rejected")
+	}
+	updated, err := branding.UpdateBranding(clinician.ID, update)
+	if err != nil {
+		t.Fatalf("Failed to update branding: %v", err)
+	}
+	if strings.Join(updated.Sections, ",") != "summary,key_findings,conversation" || updated.Name != "Lakeside Clinic" {
+		t.Errorf("Expected duplicates dropped and name kept, got %+v", updated)
+	}
+
+	if err := branding.SetLogo(clinician.ID, []byte("not an image")); err == nil {
+		t.Error("Expected a non-image logo to be rejected")
+	}
+	if err := branding.SetLogo(clinician.ID, testLogoPNG(t)); err != nil {
+		t.Fatalf("Failed to set logo: %v", err)
+	}
+
+	exportBranding, err := branding.ForUser(patient.ID)
+	if err != nil || exportBranding == nil {
+		t.Fatalf("Expected member branding, got %v", err)
+	}
+	if !bytes.HasPrefix(exportBranding.Logo, []byte{0xFF, 0xD8}) || exportBranding.LogoWidth != 40 {
+		t.Errorf("Expected logo stored as a 40px wide JPEG, got %d bytes, width %d", len(exportBranding.Logo), exportBranding.LogoWidth)
+	}
+
+	// Exports print the organization's sections in its order
+	reportRepo := models.NewReportRepository(db.GetDB())
+	reports, err := services.NewDemoService(reportRepo).ProvisionSampleReports(patient.ID)
+	if err != nil {
+		t.Fatalf("Failed to provision reports: %v", err)
+	}
+	chatService := services.NewChatService(models.NewChatMessageRepository(db.GetDB()),
+		models.NewChatSummaryRepository(db.GetDB()), reportRepo, nil, services.NewDemoAnalyzer(), nil, config.AIConfig{})
+	transcript, err := chatService.GetTranscript(patient, reports[0].ID)
+	if err != nil {
+		t.Fatalf("Failed to get transcript: %v", err)
+	}
+	transcript.Branding = exportBranding
+
+	markdown := string(services.RenderTranscriptMarkdown(transcript))
+	summaryAt := strings.Index(markdown, "## Summary")
+	findingsAt := strings.Index(markdown, "## Key findings")
+	if summaryAt < 0 || findingsAt < summaryAt || strings.Contains(markdown, "Report uploaded") {
+		t.Errorf("Expected summary then key findings and no report details:\n%s", markdown)
+	}
+	for _, expected := range []string{"**Lakeside Clinic**", "Call 555-0100", "_Lakeside Clinic, 12 Shore Road_"} {
+		if !strings.Contains(markdown, expected) {
+			t.Errorf("Markdown export missing %q", expected)
+		}
+	}
+
+	data := services.RenderTranscriptPDF(transcript)
+	reader, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Branded PDF is not readable: %v", err)
+	}
+	text, err := reader.Page(1).GetPlainText(nil)
+	if err != nil || !strings.Contains(text, "Lakeside Clinic") || !strings.Contains(text, "12 Shore Road") {
+		t.Errorf("Expected organization name and footer in PDF, got %q (%v)", text, err)
+	}
+
+	// HTTP endpoints
+	server := setupTestServer(t)
+	defer server.Close()
+	token := signupAndGetToken(t, server.URL, "unaffiliated@example.com")
+
+	if status := doJSONRequest(t, "GET", server.URL+"/api/organization/branding", token, nil, nil); status != http.StatusNotFound {
+		t.Errorf("Expected 404 for a user without an organization, got %d", status)
+	}
+	if status := doJSONRequest(t, "POST", server.URL+"/api/admin/organizations", token, map[string]string{"name": "Rogue"}, nil); status != http.StatusForbidden {
+		t.Errorf("Expected non-admins to be refused creating organizations, got %d", status)
+	}
+}
+
+// testLogoPNG returns a small half-transparent PNG
+func testLogoPNG(t *testing.T) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, 40, 20))
+	for x := 0; x < 20; x++ {
+		for y := 0; y < 20; y++ {
+			img.Set(x, y, color.NRGBA{R: 0, G: 90, B: 160, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("Failed to encode logo: %v", err)
+	}
+	return buf.Bytes()
+}
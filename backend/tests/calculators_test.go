@@ -0,0 +1,163 @@
+package tests
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"testing"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+)
+
+func floatPtr(v float64) *float64 { return &v }
+func boolPtr(v bool) *bool        { return &v }
+
+// TestRiskCalculators checks each calculator against published worked examples
+func TestRiskCalculators(t *testing.T) {
+	// ACC/AHA 2013 guideline example: 55 years, TC 213, HDL 50, SBP 120 untreated, non-smoker, no diabetes
+	guidelinePatient := func(sex, race string) services.CalculatorInputs {
+		return services.CalculatorInputs{Age: floatPtr(55), Sex: sex, Race: race, TotalCholesterol: floatPtr(213),
+			HDL: floatPtr(50), SystolicBP: floatPtr(120), Smoker: boolPtr(false), Diabetic: boolPtr(false), BPTreated: boolPtr(false)}
+	}
+
+	tests := []struct {
+		name       string
+		calculator string
+		inputs     services.CalculatorInputs
+		want       float64
+		category   string
+	}{
+		{"ASCVD white female", services.CalculatorASCVD, guidelinePatient("female", ""), 2.1, "low"},
+		{"ASCVD black female", services.CalculatorASCVD, guidelinePatient("female", "black"), 3.0, "low"},
+		{"ASCVD white male", services.CalculatorASCVD, guidelinePatient("male", ""), 5.3, "borderline"},
+		{"ASCVD black male", services.CalculatorASCVD, guidelinePatient("male", "black"), 6.1, "borderline"},
+		{"Framingham female", services.CalculatorFramingham, services.CalculatorInputs{Age: floatPtr(61), Sex: "female",
+			TotalCholesterol: floatPtr(180), HDL: floatPtr(47), SystolicBP: floatPtr(124), Smoker: boolPtr(true),
+			Diabetic: boolPtr(false), BPTreated: boolPtr(false)}, 10.5, "intermediate"},
+		{"eGFR female", services.CalculatorEGFR, services.CalculatorInputs{Age: floatPtr(50), Sex: "female", Creatinine: floatPtr(1.0)}, 68.6, "G2"},
+		{"eGFR male", services.CalculatorEGFR, services.CalculatorInputs{Age: floatPtr(50), Sex: "male", Creatinine: floatPtr(1.0)}, 91.7, "G1"},
+		{"BMI", services.CalculatorBMI, services.CalculatorInputs{HeightCm: floatPtr(170), WeightKg: floatPtr(70)}, 24.2, "normal"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := services.RunCalculator(tt.calculator, tt.inputs)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			// Published examples use rounded coefficients, so allow a tenth of a point either way
+			if result.Value == nil || math.Abs(*result.Value-tt.want) > 0.11 || result.Category != tt.category {
+				t.Errorf("Expected %.1f (%s), got %v (%s)", tt.want, tt.category, derefFloat(result.Value), result.Category)
+			}
+		})
+	}
+
+	// Missing inputs are listed; ages outside a model's validation are explained rather than extrapolated
+	result, _ := services.RunCalculator(services.CalculatorEGFR, services.CalculatorInputs{Age: floatPtr(50)})
+	if result.Value != nil || len(result.Missing) != 2 {
+		t.Errorf("Expected sex and creatinine to be missing, got %+v", result)
+	}
+	young := guidelinePatient("female", "")
+	young.Age = floatPtr(30)
+	if result, _ := services.RunCalculator(services.CalculatorASCVD, young); result.Value != nil || result.Note == "" {
+		t.Errorf("Expected age 30 to be outside the pooled cohort equations, got %+v", result)
+	}
+	if _, err := services.RunCalculator("unknown", services.CalculatorInputs{}); err == nil {
+		t.Error("Expected an unknown calculator to be refused")
+	}
+
+	// Lab values are read from metrics with unit conversion
+	var inputs services.CalculatorInputs
+	services.FillInputsFromMetrics(&inputs, []services.HealthMetric{
+		{Name: "Serum Creatinine", Value: 88.4, Unit: "µmol/L"},
+		{Name: "HDL Cholesterol", Value: "1.3", Unit: "mmol/L"},
+		{Name: "Creatinine Clearance", Value: 90.0, Unit: "mL/min"},
+	}, "report 7")
+	if inputs.Creatinine == nil || *inputs.Creatinine != 1.0 || inputs.HDL == nil || *inputs.HDL < 50 || *inputs.HDL > 51 {
+		t.Errorf("Expected converted creatinine and HDL, got %+v", inputs)
+	}
+	if inputs.Sources["creatinine"] != "report 7" {
+		t.Errorf("Expected the source to be recorded, got %+v", inputs.Sources)
+	}
+
+	// Non-HDL cholesterol and cholesterol/HDL ratios are neither HDL nor total cholesterol
+	for _, metrics := range [][]services.HealthMetric{
+		{{Name: "Non-HDL Cholesterol", Value: 191.0, Unit: "mg/dL"}},
+		{{Name: "Total cholesterol/HDL ratio", Value: 5.66}},
+		{{Name: "Cholesterol/HDL Ratio", Value: 4.1}},
+	} {
+		var lipids services.CalculatorInputs
+		services.FillInputsFromMetrics(&lipids, metrics, "report 8")
+		if lipids.HDL != nil || lipids.TotalCholesterol != nil {
+			t.Errorf("Expected %q left out of the lipid inputs, got %+v", metrics[0].Name, lipids)
+		}
+	}
+	// Listed first, they don't keep the report's HDL row from being used
+	var lipids services.CalculatorInputs
+	services.FillInputsFromMetrics(&lipids, []services.HealthMetric{
+		{Name: "Non-HDL cholesterol", Value: 5.1},
+		{Name: "Total cholesterol/HDL ratio", Value: 5.6},
+		{Name: "Total cholesterol", Value: 200.0, Unit: "mg/dL"},
+		{Name: "HDL-C", Value: 48.0, Unit: "mg/dL"},
+	}, "report 9")
+	if lipids.HDL == nil || *lipids.HDL != 48 || lipids.TotalCholesterol == nil || *lipids.TotalCholesterol != 200 {
+		t.Errorf("Expected HDL 48 and total cholesterol 200, got %+v", lipids)
+	}
+}
+
+// TestCalculatorService tests that calculators pick up lab values from the user's newest reports
+func TestCalculatorService(t *testing.T) {
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	user := &models.User{Email: "calculators@example.com", PasswordHash: "hash", FullName: "Calc", IsActive: true}
+	if err := models.NewUserRepository(db.GetDB()).Create(user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	reportRepo := models.NewReportRepository(db.GetDB())
+	reports, err := services.NewDemoService(reportRepo).ProvisionSampleReports(user.ID)
+	if err != nil {
+		t.Fatalf("Failed to provision reports: %v", err)
+	}
+
+	// HDL comes from the demo lipid panel; the rest from the request
+	inputs := services.CalculatorInputs{Age: floatPtr(55), Sex: "female", TotalCholesterol: floatPtr(213),
+		SystolicBP: floatPtr(120), Smoker: boolPtr(false), Diabetic: boolPtr(false), BPTreated: boolPtr(false)}
+	result, err := services.NewCalculatorService(reportRepo, nil).Calculate(user.ID, services.CalculatorASCVD, inputs)
+	if err != nil {
+		t.Fatalf("Failed to calculate: %v", err)
+	}
+	if result.Value == nil || result.Sources["hdl"] != fmt.Sprintf("report %d", reports[1].ID) {
+		t.Errorf("Expected a risk using the lipid panel's HDL, got %+v", result)
+	}
+
+	// HTTP: unknown calculators and malformed inputs are refused
+	server := setupTestServer(t)
+	defer server.Close()
+	token := signupAndGetToken(t, server.URL, "calculators-http@example.com")
+	if status := doJSONRequest(t, "GET", server.URL+"/api/calculators/unknown", token, nil, nil); status != http.StatusNotFound {
+		t.Errorf("Expected an unknown calculator to be not found, got %d", status)
+	}
+	if status := doJSONRequest(t, "GET", server.URL+"/api/calculators/bmi?weight_kg=abc", token, nil, nil); status != http.StatusBadRequest {
+		t.Errorf("Expected an invalid input to be refused, got %d", status)
+	}
+	var bmi services.CalculatorResult
+	status := doJSONRequest(t, "GET", server.URL+"/api/calculators/bmi?height_cm=170&weight_kg=70", token, nil, &bmi)
+	if status != http.StatusOK || bmi.Value == nil || *bmi.Value != 24.2 {
+		t.Errorf("Expected BMI from the query alone, got %d %+v", status, bmi)
+	}
+}
+
+func derefFloat(v *float64) any {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
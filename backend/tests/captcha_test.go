@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/handlers"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// TestCaptchaProtection tests CAPTCHA on signup and after repeated failed logins
+func TestCaptchaProtection(t *testing.T) {
+	// Decision: Stand in for the provider's siteverify endpoint; only "valid-token" passes
+	var verifyCalls int
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		verifyCalls++
+		if r.FormValue("secret") != "test-secret" {
+			t.Errorf("Expected secret to be forwarded, got %q", r.FormValue("secret"))
+		}
+		json.NewEncoder(w).Encode(map[string]any{"success": r.FormValue("response") == "valid-token"})
+	}))
+	defer provider.Close()
+
+	cfg := config.CaptchaConfig{
+		Provider:              "hcaptcha",
+		SiteKey:               "site-key",
+		SecretKey:             "test-secret",
+		VerifyURL:             provider.URL,
+		LoginFailureThreshold: 2,
+	}
+	verifier, err := services.NewCaptchaVerifier(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create verifier: %v", err)
+	}
+
+	authService, db := setupAuthTest(t)
+	defer db.Close()
+	handler := handlers.NewAuthHandler(authService, services.NewCaptchaGuard(verifier, cfg))
+
+	post := func(h http.HandlerFunc, body any) int {
+		payload, _ := json.Marshal(body)
+		rec := httptest.NewRecorder()
+		h(rec, httptest.NewRequest("POST", "/", bytes.NewReader(payload)))
+		return rec.Code
+	}
+
+	signup := types.SignupRequest{Email: "human@example.com", Password: "password123", FullName: "Human"}
+	if status := post(handler.SignupHandler, signup); status != http.StatusPreconditionRequired {
+		t.Errorf("Expected 428 for signup without token, got %d", status)
+	}
+	signup.CaptchaToken = "bot-token"
+	if status := post(handler.SignupHandler, signup); status != http.StatusForbidden {
+		t.Errorf("Expected 403 for rejected token, got %d", status)
+	}
+	signup.CaptchaToken = "valid-token"
+	if status := post(handler.SignupHandler, signup); status != http.StatusCreated {
+		t.Fatalf("Expected signup with valid token to succeed, got %d", status)
+	}
+
+	// Logins are unchallenged until the failure threshold is reached
+	calls := verifyCalls
+	wrong := types.LoginRequest{Email: "human@example.com", Password: "wrong-password"}
+	for i := 0; i < 2; i++ {
+		if status := post(handler.LoginHandler, wrong); status != http.StatusUnauthorized {
+			t.Fatalf("Attempt %d: expected 401, got %d", i+1, status)
+		}
+	}
+	if verifyCalls != calls {
+		t.Errorf("Expected no CAPTCHA checks below the threshold")
+	}
+
+	right := types.LoginRequest{Email: "human@example.com", Password: "password123"}
+	if status := post(handler.LoginHandler, right); status != http.StatusPreconditionRequired {
+		t.Errorf("Expected 428 once challenged, got %d", status)
+	}
+	right.CaptchaToken = "valid-token"
+	if status := post(handler.LoginHandler, right); status != http.StatusOK {
+		t.Errorf("Expected login with valid token to succeed, got %d", status)
+	}
+
+	// A successful login clears the account's count, but the IP stays challenged
+	if status := post(handler.LoginHandler, types.LoginRequest{Email: "other@example.com", Password: "x"}); status != http.StatusPreconditionRequired {
+		t.Errorf("Expected the same client IP to stay challenged, got %d", status)
+	}
+
+	// Fail closed when the provider is unreachable
+	provider.Close()
+	if status := post(handler.SignupHandler, types.SignupRequest{
+		Email: "later@example.com", Password: "password123", FullName: "Later", CaptchaToken: "valid-token",
+	}); status != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 when the provider is down, got %d", status)
+	}
+
+	// Disabled configuration needs no tokens
+	if v, err := services.NewCaptchaVerifier(config.CaptchaConfig{Provider: "none"}); err != nil || v != nil {
+		t.Errorf("Expected no verifier for provider none, got %v %v", v, err)
+	}
+	if _, err := services.NewCaptchaVerifier(config.CaptchaConfig{Provider: "recaptcha"}); err == nil {
+		t.Error("Expected error for missing secret key")
+	}
+	open := handlers.NewAuthHandler(authService, nil)
+	if status := post(open.SignupHandler, types.SignupRequest{Email: "open@example.com", Password: "password123", FullName: "Open"}); status != http.StatusCreated {
+		t.Errorf("Expected signup without CAPTCHA when disabled, got %d", status)
+	}
+}
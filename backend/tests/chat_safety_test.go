@@ -0,0 +1,172 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// TestChatSafetyScreen tests which answers each strictness level changes
+func TestChatSafetyScreen(t *testing.T) {
+	tests := []struct {
+		name       string
+		level      string
+		answer     string
+		categories []string
+	}{
+		{"lab values pass", services.SafetyStandard, "Your fasting glucose is 126 mg/dL, above the 70-99 mg/dL range.", nil},
+		{"dose instruction", services.SafetyStandard, "Your sugar is high. You should take 500 mg of metformin twice a day.", []string{services.SafetyDosage}},
+		{"deferring to the doctor passes", services.SafetyStandard, "Keep taking your medicines as prescribed by your doctor.", nil},
+		{"stopping a medicine", services.SafetyStandard, "Your TSH is low, so stop your levothyroxine.", []string{services.SafetyDosage}},
+		{"definite diagnosis", services.SafetyStandard, "These results confirm that you have diabetes.", []string{services.SafetyDiagnosis}},
+		{"negated diagnosis passes", services.SafetyStandard, "This doesn't mean you have diabetes.", nil},
+		{"hedged diagnosis passes at standard", services.SafetyStandard, "You might have anemia.", nil},
+		{"hedged diagnosis at strict", services.SafetyStrict, "You might have anemia.", []string{services.SafetyDiagnosis}},
+		{"dose amount at strict", services.SafetyStrict, "Most adults are prescribed 75 mg of aspirin.", []string{services.SafetyDosage}},
+		{"emergency managed at home", services.SafetyStandard, "Chest pain like this usually passes if you rest.", []string{services.SafetyEmergency}},
+		{"emergency escalated passes", services.SafetyStrict, "If you have chest pain, call an ambulance immediately.", nil},
+		{"off changes nothing", services.SafetyOff, "You should take 500 mg of metformin. You have diabetes.", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := services.NewSafetyFilter(config.ChatSafetyConfig{Level: tt.level, EmergencyNumber: "112"})
+			if err != nil {
+				t.Fatalf("Failed to create filter: %v", err)
+			}
+			result := filter.Screen(tt.answer)
+			if strings.Join(result.Categories, ",") != strings.Join(tt.categories, ",") {
+				t.Fatalf("Expected categories %v, got %v (answer %q)", tt.categories, result.Categories, result.Answer)
+			}
+			if !result.Intervened() && result.Answer != tt.answer {
+				t.Errorf("Expected an unflagged answer unchanged, got %q", result.Answer)
+			}
+			for _, removed := range result.Removed {
+				if strings.Contains(result.Answer, removed) {
+					t.Errorf("Expected %q removed from the answer, got %q", removed, result.Answer)
+				}
+			}
+		})
+	}
+
+	// Decision: An emergency replaces the whole answer, including its otherwise safe sentences
+	filter, _ := services.NewSafetyFilter(config.ChatSafetyConfig{Level: services.SafetyStandard, EmergencyNumber: "911"})
+	result := filter.Screen("Your potassium is normal. Fainting after standing is nothing to worry about.")
+	if strings.Contains(result.Answer, "potassium") || !strings.Contains(result.Answer, "call 911") {
+		t.Errorf("Expected the answer replaced with emergency guidance, got %q", result.Answer)
+	}
+
+	// Kept sentences stay ahead of the guidance
+	result = filter.Screen("Your HbA1c is 7.2%. You have diabetes.")
+	if !strings.HasPrefix(result.Answer, "Your HbA1c is 7.2%.") || !strings.Contains(result.Answer, "can't diagnose") {
+		t.Errorf("Expected the safe sentence kept and diagnosis guidance added, got %q", result.Answer)
+	}
+
+	if _, err := services.NewSafetyFilter(config.ChatSafetyConfig{Level: "lenient"}); err == nil {
+		t.Error("Expected an unknown level to be rejected")
+	}
+}
+
+// unsafeResponder answers every question with dosing advice
+type unsafeResponder struct {
+	services.DemoAnalyzer
+}
+
+func (*unsafeResponder) AnswerQuestion(ctx context.Context, reportSummary, conversationSummary string, history []*models.ChatMessage, question, readingLevel, patient string) (string, error) {
+	return "Your glucose is 180 mg/dL. Increase your metformin to 1000 mg daily.", nil
+}
+
+// TestChatSafetyInterventionsLogged tests that screened answers are stored in place of the original and logged
+func TestChatSafetyInterventionsLogged(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{
+			Driver: "sqlite3",
+			DSN:    ":memory:",
+		},
+	}
+
+	db, err := database.Setup(cfg)
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	owner := &models.User{Email: "owner@example.com", PasswordHash: "hash", FullName: "Owner", IsActive: true}
+	if err := models.NewUserRepository(db.GetDB()).Create(owner); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	reportRepo := models.NewReportRepository(db.GetDB())
+	reports, err := services.NewDemoService(reportRepo).ProvisionSampleReports(owner.ID)
+	if err != nil {
+		t.Fatalf("Failed to provision reports: %v", err)
+	}
+
+	chatRepo := models.NewChatMessageRepository(db.GetDB())
+	message := &models.ChatMessage{ReportID: reports[0].ID, UserMessage: "What should I do about my sugar?", AIResponse: "Ask your doctor."}
+	if err := chatRepo.Create(message); err != nil {
+		t.Fatalf("Failed to create chat message: %v", err)
+	}
+
+	safetyRepo := models.NewSafetyInterventionRepository(db.GetDB())
+	chatService := services.NewChatService(chatRepo, models.NewChatSummaryRepository(db.GetDB()), reportRepo, nil,
+		&unsafeResponder{}, nil, config.AIConfig{})
+	chatService.SetSafetyLog(safetyRepo)
+
+	regenerated, err := chatService.RegenerateMessage(owner.ID, message.ID, models.ReadingLevelStandard)
+	if err != nil {
+		t.Fatalf("Failed to regenerate: %v", err)
+	}
+	if strings.Contains(regenerated.AIResponse, "1000 mg") || !strings.Contains(regenerated.AIResponse, "180 mg/dL") {
+		t.Errorf("Expected the dosing sentence removed and the rest kept, got %q", regenerated.AIResponse)
+	}
+
+	interventions, err := safetyRepo.List(models.SafetyInterventionFilter{Category: services.SafetyDosage})
+	if err != nil {
+		t.Fatalf("Failed to list interventions: %v", err)
+	}
+	if len(interventions) != 1 {
+		t.Fatalf("Expected 1 dosage intervention, got %d", len(interventions))
+	}
+	logged := interventions[0]
+	if logged.MessageID != message.ID || logged.UserID != owner.ID || logged.Level != services.SafetyStandard {
+		t.Errorf("Unexpected intervention %+v", logged)
+	}
+	if !strings.Contains(logged.OriginalAnswer, "1000 mg") || len(logged.Removed) != 1 {
+		t.Errorf("Expected the original answer and removed sentence kept for review, got %+v", logged)
+	}
+
+	if others, _ := safetyRepo.List(models.SafetyInterventionFilter{Category: services.SafetyEmergency}); len(others) != 0 {
+		t.Errorf("Expected no emergency interventions, got %d", len(others))
+	}
+}
+
+// TestAdminSafetyInterventions tests the admin listing of safety interventions
+func TestAdminSafetyInterventions(t *testing.T) {
+	server := setupTestServer(t)
+	defer server.Close()
+
+	token := signupAndGetToken(t, server.URL, "patient@example.com")
+	if status := doJSONRequest(t, "GET", server.URL+"/api/admin/safety", token, nil, nil); status != http.StatusForbidden {
+		t.Errorf("Expected non-admins to be refused, got %d", status)
+	}
+
+	adminToken := signupAndGetToken(t, server.URL, "admin@example.com")
+	var entries []types.SafetyInterventionEntry
+	if status := doJSONRequest(t, "GET", server.URL+"/api/admin/safety?category=dosage", adminToken, nil, &entries); status != http.StatusOK {
+		t.Fatalf("Expected admin to list interventions, got %d", status)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Expected no interventions yet, got %d", len(entries))
+	}
+	if status := doJSONRequest(t, "GET", server.URL+"/api/admin/safety?category=billing", adminToken, nil, nil); status != http.StatusBadRequest {
+		t.Errorf("Expected an unknown category to be rejected, got %d", status)
+	}
+}
@@ -0,0 +1,379 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/ledongthuc/pdf"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/handlers"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// TestChatMessageRevisions tests editing and regenerating answers while keeping earlier versions
+func TestChatMessageRevisions(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{
+			Driver: "sqlite3",
+			DSN:    ":memory:",
+		},
+	}
+
+	db, err := database.Setup(cfg)
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	userRepo := models.NewUserRepository(db.GetDB())
+	owner := &models.User{Email: "owner@example.com", PasswordHash: "hash", FullName: "Owner", IsActive: true}
+	other := &models.User{Email: "other@example.com", PasswordHash: "hash", FullName: "Other", IsActive: true}
+	for _, user := range []*models.User{owner, other} {
+		if err := userRepo.Create(user); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+
+	reportRepo := models.NewReportRepository(db.GetDB())
+	reports, err := services.NewDemoService(reportRepo).ProvisionSampleReports(owner.ID)
+	if err != nil {
+		t.Fatalf("Failed to provision reports: %v", err)
+	}
+
+	chatRepo := models.NewChatMessageRepository(db.GetDB())
+	message := &models.ChatMessage{ReportID: reports[0].ID, UserMessage: "Is my hemoglobin ok?", AIResponse: "Yes."}
+	if err := chatRepo.Create(message); err != nil {
+		t.Fatalf("Failed to create chat message: %v", err)
+	}
+
+	summaryRepo := models.NewChatSummaryRepository(db.GetDB())
+	chatService := services.NewChatService(chatRepo, summaryRepo, reportRepo, nil, services.NewDemoAnalyzer(), nil, config.AIConfig{})
+
+	// Decision: Regenerating keeps the question and archives the first answer
+	regenerated, err := chatService.RegenerateMessage(owner.ID, message.ID, models.ReadingLevelStandard)
+	if err != nil {
+		t.Fatalf("Failed to regenerate message: %v", err)
+	}
+	if regenerated.Version != 2 || regenerated.UserMessage != "Is my hemoglobin ok?" || regenerated.UpdatedAt == nil {
+		t.Fatalf("Unexpected regenerated message: %+v", regenerated)
+	}
+
+	edited, err := chatService.EditMessage(owner.ID, message.ID, "What does MCV mean?", models.ReadingLevelStandard)
+	if err != nil {
+		t.Fatalf("Failed to edit message: %v", err)
+	}
+	if edited.Version != 3 || edited.UserMessage != "What does MCV mean?" {
+		t.Fatalf("Unexpected edited message: %+v", edited)
+	}
+
+	current, versions, err := chatService.GetVersions(owner.ID, message.ID)
+	if err != nil {
+		t.Fatalf("Failed to get versions: %v", err)
+	}
+	if current.Version != 3 || len(versions) != 2 {
+		t.Fatalf("Expected current version 3 with 2 earlier versions, got %d and %d", current.Version, len(versions))
+	}
+	if versions[0].Version != 1 || versions[0].AIResponse != "Yes." {
+		t.Fatalf("Original answer not preserved: %+v", versions[0])
+	}
+
+	// Other users can neither see nor change the conversation
+	if _, err := chatService.EditMessage(other.ID, message.ID, "hi", models.ReadingLevelStandard); err != errors.ErrAccessDenied {
+		t.Fatalf("Expected access denied for non-owner, got %v", err)
+	}
+	if _, err := chatService.EditMessage(owner.ID, message.ID, "  ", models.ReadingLevelStandard); err == nil {
+		t.Fatal("Expected validation error for empty question")
+	}
+	if _, err := chatService.RegenerateMessage(owner.ID, 9999, models.ReadingLevelStandard); err != errors.ErrRecordNotFound {
+		t.Fatalf("Expected not found for missing message, got %v", err)
+	}
+
+	// Without an AI backend, revisions fail cleanly
+	noAI := services.NewChatService(chatRepo, summaryRepo, reportRepo, nil, nil, nil, config.AIConfig{})
+	if _, err := noAI.RegenerateMessage(owner.ID, message.ID, models.ReadingLevelStandard); err != errors.ErrAIUnavailable {
+		t.Fatalf("Expected AI unavailable, got %v", err)
+	}
+}
+
+// recordingResponder captures the context the chat service sends with each question
+type recordingResponder struct {
+	services.DemoAnalyzer
+	conversationSummary string
+	history             []*models.ChatMessage
+	patient             string
+	summarizeCalls      int
+}
+
+func (rr *recordingResponder) AnswerQuestion(ctx context.Context, reportSummary, conversationSummary string, history []*models.ChatMessage, question, readingLevel, patient string) (string, error) {
+	rr.conversationSummary = conversationSummary
+	rr.history = history
+	rr.patient = patient
+	return "answer", nil
+}
+
+func (rr *recordingResponder) SummarizeConversation(ctx context.Context, previousSummary string, turns []*models.ChatMessage) (string, error) {
+	rr.summarizeCalls++
+	return rr.DemoAnalyzer.SummarizeConversation(ctx, previousSummary, turns)
+}
+
+// TestChatHistorySummarization tests that long histories are summarized and the summary is reused
+func TestChatHistorySummarization(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{
+			Driver: "sqlite3",
+			DSN:    ":memory:",
+		},
+	}
+
+	db, err := database.Setup(cfg)
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	user := &models.User{Email: "long@example.com", PasswordHash: "hash", FullName: "Long Chat", IsActive: true}
+	if err := models.NewUserRepository(db.GetDB()).Create(user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	reportRepo := models.NewReportRepository(db.GetDB())
+	reports, err := services.NewDemoService(reportRepo).ProvisionSampleReports(user.ID)
+	if err != nil {
+		t.Fatalf("Failed to provision reports: %v", err)
+	}
+
+	// Ten turns of ~100 tokens each against a 300 token budget
+	chatRepo := models.NewChatMessageRepository(db.GetDB())
+	var messages []*models.ChatMessage
+	for i := 0; i < 10; i++ {
+		message := &models.ChatMessage{
+			ReportID:    reports[0].ID,
+			UserMessage: fmt.Sprintf("question %d", i),
+			AIResponse:  strings.Repeat("a", 400),
+		}
+		if err := chatRepo.Create(message); err != nil {
+			t.Fatalf("Failed to create chat message: %v", err)
+		}
+		messages = append(messages, message)
+	}
+
+	responder := &recordingResponder{}
+	summaryRepo := models.NewChatSummaryRepository(db.GetDB())
+	chatService := services.NewChatService(chatRepo, summaryRepo, reportRepo, nil, responder, nil,
+		config.AIConfig{ChatHistoryTokens: 300, ChatRecentTurns: 2})
+
+	last := messages[len(messages)-1]
+	if _, err := chatService.RegenerateMessage(user.ID, last.ID, models.ReadingLevelStandard); err != nil {
+		t.Fatalf("Failed to regenerate message: %v", err)
+	}
+	if len(responder.history) != 2 || !strings.Contains(responder.conversationSummary, "question 0") {
+		t.Fatalf("Expected 2 recent turns plus a summary, got %d turns and %q", len(responder.history), responder.conversationSummary)
+	}
+
+	stored, err := summaryRepo.GetByReportID(reports[0].ID)
+	if err != nil || stored == nil || stored.ThroughMessageID != messages[6].ID {
+		t.Fatalf("Expected summary stored through message %d, got %+v (%v)", messages[6].ID, stored, err)
+	}
+
+	// The same context reuses the stored summary without another summarization call
+	if _, err := chatService.RegenerateMessage(user.ID, last.ID, models.ReadingLevelStandard); err != nil {
+		t.Fatalf("Failed to regenerate message: %v", err)
+	}
+	if responder.summarizeCalls != 1 {
+		t.Fatalf("Expected stored summary to be reused, got %d summarize calls", responder.summarizeCalls)
+	}
+
+	// Editing a summarized turn invalidates the stored summary
+	if _, err := chatService.EditMessage(user.ID, messages[3].ID, "new question", models.ReadingLevelStandard); err != nil {
+		t.Fatalf("Failed to edit message: %v", err)
+	}
+	if stored, _ := summaryRepo.GetByReportID(reports[0].ID); stored != nil {
+		t.Fatalf("Expected summary to be invalidated, got %+v", stored)
+	}
+}
+
+// TestChatExport tests transcript rendering and the export endpoint
+func TestChatExport(t *testing.T) {
+	report := &models.Report{ID: 7, OriginalFilename: "blood_test.pdf", UploadDate: time.Now()}
+	transcript := &services.ChatTranscript{
+		Report: report,
+		Messages: []*models.ChatMessage{
+			{ID: 1, ReportID: 7, UserMessage: "What is (LDL)?", AIResponse: strings.Repeat("Cholesterol carried in blood. ", 40), Version: 1},
+			{ID: 2, ReportID: 7, UserMessage: "Should I worry?", AIResponse: "Ask your doctor.", Version: 2},
+		},
+		Disclaimer: "Not medical advice.",
+		ExportedAt: time.Now(),
+	}
+
+	markdown := string(services.RenderTranscriptMarkdown(transcript))
+	for _, expected := range []string{"# Conversation about blood_test.pdf", "**Patient:** What is (LDL)?", "version 2", "> Not medical advice."} {
+		if !strings.Contains(markdown, expected) {
+			t.Fatalf("Markdown transcript missing %q:\n%s", expected, markdown)
+		}
+	}
+
+	// Decision: Read the PDF back with the same library used for report extraction
+	data := services.RenderTranscriptPDF(transcript)
+	reader, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Generated PDF is not readable: %v", err)
+	}
+	text, err := reader.Page(1).GetPlainText(nil)
+	if err != nil || !strings.Contains(text, "What is (LDL)?") {
+		t.Fatalf("PDF text missing question, got %q (%v)", text, err)
+	}
+
+	// Endpoint enforces ownership and format
+	server := setupTestServer(t)
+	defer server.Close()
+
+	token := signupAndGetToken(t, server.URL, "exporter@example.com")
+	reportID := uploadTestReport(t, server.URL, token, "lab.txt", "Hemoglobin 13.5 g/dL")
+	exportURL := fmt.Sprintf("%s/api/reports/%d/chat/export", server.URL, reportID)
+
+	req, _ := http.NewRequest("GET", exportURL+"?format=pdf", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to call export endpoint: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "application/pdf" {
+		t.Fatalf("Expected PDF export, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	// The JSON export is a standalone file, not an API envelope
+	req, _ = http.NewRequest("GET", exportURL, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to call export endpoint: %v", err)
+	}
+	defer resp.Body.Close()
+	var exported types.ChatTranscript
+	if err := json.NewDecoder(resp.Body).Decode(&exported); err != nil || exported.ReportID != reportID {
+		t.Fatalf("Expected JSON export of report %d, got %+v (%v)", reportID, exported, err)
+	}
+	if status := doJSONRequest(t, "GET", exportURL+"?format=xml", token, nil, nil); status != http.StatusBadRequest {
+		t.Fatalf("Expected 400 for unsupported format, got %d", status)
+	}
+
+	otherToken := signupAndGetToken(t, server.URL, "stranger@example.com")
+	if status := doJSONRequest(t, "GET", exportURL, otherToken, nil, nil); status != http.StatusForbidden {
+		t.Fatalf("Expected 403 for other user, got %d", status)
+	}
+}
+
+// TestChatEndpoints tests asking about a report, reading the conversation back, and deleting a message
+func TestChatEndpoints(t *testing.T) {
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	userRepo := models.NewUserRepository(db.GetDB())
+	owner := &models.User{Email: "asker@example.com", PasswordHash: "hash", FullName: "Owner", IsActive: true}
+	other := &models.User{Email: "nosy@example.com", PasswordHash: "hash", FullName: "Other", IsActive: true}
+	for _, user := range []*models.User{owner, other} {
+		if err := userRepo.Create(user); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+
+	reportRepo := models.NewReportRepository(db.GetDB())
+	reports, err := services.NewDemoService(reportRepo).ProvisionSampleReports(owner.ID)
+	if err != nil {
+		t.Fatalf("Failed to provision reports: %v", err)
+	}
+	reportID := strconv.Itoa(reports[0].ID)
+
+	chatRepo := models.NewChatMessageRepository(db.GetDB())
+	responder := &recordingResponder{}
+	chatService := services.NewChatService(chatRepo, models.NewChatSummaryRepository(db.GetDB()), reportRepo, nil, responder, nil, config.AIConfig{})
+	chatHandler := handlers.NewChatHandler(chatService, nil, nil, 0)
+
+	call := func(handler http.HandlerFunc, user *models.User, method string, vars map[string]string, body any, out any) int {
+		payload, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, "/api/reports/"+reportID+"/chat?limit=10", bytes.NewReader(payload))
+		req = mux.SetURLVars(req.WithContext(context.WithValue(req.Context(), middleware.UserKey, user)), vars)
+		recorder := httptest.NewRecorder()
+		handler(recorder, req)
+		if out != nil {
+			decodeEnvelope(recorder.Body, out)
+		}
+		return recorder.Code
+	}
+	report := map[string]string{"id": reportID}
+
+	var first, second types.ChatMessage
+	if status := call(chatHandler.AskHandler, owner, "POST", report, types.ChatRequest{Message: "Is my hemoglobin ok?"}, &first); status != http.StatusCreated {
+		t.Fatalf("Expected 201 for a question, got %d", status)
+	}
+	if first.UserMessage != "Is my hemoglobin ok?" || first.AIResponse != "answer" || first.ReportID != reports[0].ID {
+		t.Fatalf("Unexpected answer: %+v", first)
+	}
+	if status := call(chatHandler.AskHandler, owner, "POST", report, types.ChatRequest{Message: "And my platelets?"}, &second); status != http.StatusCreated {
+		t.Fatalf("Expected 201 for a follow-up, got %d", status)
+	}
+	// Decision: The follow-up is answered with the earlier turn as context
+	if len(responder.history) != 1 || responder.history[0].ID != first.ID {
+		t.Fatalf("Expected the first turn in the follow-up's history, got %d turns", len(responder.history))
+	}
+
+	var history []types.ChatMessage
+	if status := call(chatHandler.GetChatHistoryHandler, owner, "GET", report, nil, &history); status != http.StatusOK {
+		t.Fatalf("Expected 200 for history, got %d", status)
+	}
+	if len(history) != 2 || history[0].ID != first.ID || history[1].ID != second.ID {
+		t.Fatalf("Expected both turns oldest first, got %+v", history)
+	}
+
+	// Other users can neither ask about the report nor read or delete its conversation
+	if status := call(chatHandler.AskHandler, other, "POST", report, types.ChatRequest{Message: "hi"}, nil); status != http.StatusForbidden {
+		t.Fatalf("Expected 403 asking about another user's report, got %d", status)
+	}
+	if status := call(chatHandler.GetChatHistoryHandler, other, "GET", report, nil, nil); status != http.StatusForbidden {
+		t.Fatalf("Expected 403 reading another user's conversation, got %d", status)
+	}
+	message := map[string]string{"messageId": strconv.Itoa(first.ID)}
+	if status := call(chatHandler.DeleteMessageHandler, other, "DELETE", message, nil, nil); status != http.StatusForbidden {
+		t.Fatalf("Expected 403 deleting another user's message, got %d", status)
+	}
+
+	if status := call(chatHandler.AskHandler, owner, "POST", report, types.ChatRequest{Message: "   "}, nil); status != http.StatusBadRequest {
+		t.Fatalf("Expected 400 for an empty question, got %d", status)
+	}
+	if status := call(chatHandler.AskHandler, owner, "POST", map[string]string{"id": "9999"}, types.ChatRequest{Message: "hi"}, nil); status != http.StatusNotFound {
+		t.Fatalf("Expected 404 for a missing report, got %d", status)
+	}
+
+	if status := call(chatHandler.DeleteMessageHandler, owner, "DELETE", message, nil, nil); status != http.StatusOK {
+		t.Fatalf("Expected 200 deleting a message, got %d", status)
+	}
+	history = nil
+	call(chatHandler.GetChatHistoryHandler, owner, "GET", report, nil, &history)
+	if len(history) != 1 || history[0].ID != second.ID {
+		t.Fatalf("Expected only the follow-up after deleting the first turn, got %+v", history)
+	}
+	if status := call(chatHandler.DeleteMessageHandler, owner, "DELETE", message, nil, nil); status != http.StatusNotFound {
+		t.Fatalf("Expected 404 deleting a message twice, got %d", status)
+	}
+}
@@ -0,0 +1,182 @@
+package tests
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+const claimAnalysis = `{"schema_version": 4, "summary": "Renal impairment.", "simple_summary": "Your kidneys need attention.",
+	"health_metrics": [
+		{"name": "Serum Creatinine", "value": 3.2, "unit": "mg/dL", "status": "critical", "range_min": 0.6, "range_max": 1.2},
+		{"name": "=HYPERLINK(\"x\")", "value": "-2.5", "unit": "", "status": "normal"}],
+	"recommendations": ["See a nephrologist"]}`
+
+// readClaimZip returns the entries of a stored claim package by name
+func readClaimZip(t *testing.T, path string) map[string]string {
+	t.Helper()
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("Failed to open claim package: %v", err)
+	}
+	defer archive.Close()
+
+	entries := make(map[string]string)
+	for _, file := range archive.File {
+		r, err := file.Open()
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", file.Name, err)
+		}
+		data, _ := io.ReadAll(r)
+		r.Close()
+		entries[file.Name] = string(data)
+	}
+	return entries
+}
+
+// TestClaimPackage tests bundling a report, replacing the bundle, and dropping it when the report changes
+func TestClaimPackage(t *testing.T) {
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	owner := &models.User{Email: "owner@example.com", PasswordHash: "hash", FullName: "Asha Rao", IsActive: true}
+	if err := models.NewUserRepository(db.GetDB()).Create(owner); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	storage := services.NewFileStorage(t.TempDir(), "test-secret")
+	reportRepo := models.NewReportRepository(db.GetDB())
+	createReport := func(name, content string) *models.Report {
+		path, err := storage.NewFilePath(owner.ID, name)
+		if err != nil {
+			t.Fatalf("Failed to create file path: %v", err)
+		}
+		os.WriteFile(path, []byte(content), 0640)
+		report := &models.Report{UserID: owner.ID, OriginalFilename: name, FilePath: path, FileType: "text/plain",
+			FileSize: int64(len(content)), ProcessingStatus: "pending", ReadingLevel: models.ReadingLevelStandard}
+		if err := reportRepo.Create(report); err != nil {
+			t.Fatalf("Failed to create report: %v", err)
+		}
+		return report
+	}
+	report := createReport("labs page 1.TXT", "page one")
+	second := createReport("labs page 2.txt", "page two")
+
+	partRepo := models.NewReportPartRepository(db.GetDB())
+	claims := services.NewClaimPackageService(reportRepo, partRepo, models.NewClaimPackageRepository(db.GetDB()), storage)
+	if _, err := claims.Generate(owner, report.ID, services.ClaimDetails{}); err != errors.ErrReportNotProcessed {
+		t.Errorf("Expected an unprocessed report to be refused, got %v", err)
+	}
+	if err := reportRepo.UpdateProcessingStatus(report.ID, "completed", claimAnalysis); err != nil {
+		t.Fatalf("Failed to store analysis: %v", err)
+	}
+	if _, err := claims.Generate(owner, report.ID, services.ClaimDetails{PolicyNumber: strings.Repeat("9", 101)}); err == nil {
+		t.Error("Expected an overlong policy number to be refused")
+	}
+	if _, err := claims.Generate(&models.User{ID: owner.ID + 1}, report.ID, services.ClaimDetails{}); err != errors.ErrAccessDenied {
+		t.Errorf("Expected another user's report to be refused, got %v", err)
+	}
+
+	pkg, err := claims.Generate(owner, report.ID, services.ClaimDetails{Insurer: " Star Health ", PolicyNumber: "P-123"})
+	if err != nil {
+		t.Fatalf("Failed to generate claim package: %v", err)
+	}
+	if pkg.Insurer != "Star Health" || pkg.FileSize == 0 {
+		t.Errorf("Unexpected package %+v", pkg)
+	}
+	data, _ := os.ReadFile(pkg.FilePath)
+	if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != pkg.Checksum {
+		t.Errorf("Expected the checksum of the stored ZIP, got %s", pkg.Checksum)
+	}
+	entries := readClaimZip(t, pkg.FilePath)
+	if len(entries) != 4 || entries["4-original.txt"] != "page one" {
+		t.Fatalf("Unexpected package entries %v", entries)
+	}
+	for _, name := range []string{"1-cover-sheet.pdf", "2-summary.pdf"} {
+		if !strings.HasPrefix(entries[name], "%PDF-") {
+			t.Errorf("Expected %s to be a PDF", name)
+		}
+	}
+	if !strings.Contains(entries["1-cover-sheet.pdf"], "Policy number: P-123") || !strings.Contains(entries["1-cover-sheet.pdf"], "Asha Rao") {
+		t.Error("Expected the cover sheet to name the claimant and policy")
+	}
+	wantCSV := "test,value,unit,reference_min,reference_max,status\n" +
+		"Serum Creatinine,3.2,mg/dL,0.6,1.2,critical\n" +
+		"\"'=HYPERLINK(\"\"x\"\")\",-2.5,,,,normal\n"
+	if entries["3-itemized-findings.csv"] != wantCSV {
+		t.Errorf("Unexpected findings CSV:\n%s", entries["3-itemized-findings.csv"])
+	}
+
+	// Generating again replaces the package and queues the old file
+	replaced, err := claims.Generate(owner, report.ID, services.ClaimDetails{})
+	if err != nil || replaced.FilePath == pkg.FilePath {
+		t.Fatalf("Expected a new package, got %+v (%v)", replaced, err)
+	}
+	var queued int
+	db.GetDB().QueryRow(`SELECT COUNT(*) FROM file_cleanup_queue WHERE file_path = ?`, pkg.FilePath).Scan(&queued)
+	if queued != 1 {
+		t.Errorf("Expected the replaced package to be queued for removal, got %d", queued)
+	}
+
+	// Merging pages drops the package; the next one includes every page
+	if merged, err := partRepo.Merge(report.ID, second.ID); err != nil || !merged {
+		t.Fatalf("Failed to merge reports: %v", err)
+	}
+	if _, err := claims.Get(owner.ID, report.ID); err != errors.ErrRecordNotFound {
+		t.Errorf("Expected the merged report's package to be dropped, got %v", err)
+	}
+	if err := reportRepo.UpdateProcessingStatus(report.ID, "completed", claimAnalysis); err != nil {
+		t.Fatalf("Failed to store analysis: %v", err)
+	}
+	pkg, err = claims.Generate(owner, report.ID, services.ClaimDetails{})
+	if err != nil {
+		t.Fatalf("Failed to generate claim package: %v", err)
+	}
+	entries = readClaimZip(t, pkg.FilePath)
+	if entries["4-original-part-1.txt"] != "page one" || entries["4-original-part-2.txt"] != "page two" {
+		t.Errorf("Expected both pages in the package, got %v", entries)
+	}
+
+	if err := reportRepo.Delete(report.ID); err != nil {
+		t.Fatalf("Failed to delete report: %v", err)
+	}
+	db.GetDB().QueryRow(`SELECT COUNT(*) FROM file_cleanup_queue WHERE file_path = ?`, pkg.FilePath).Scan(&queued)
+	if queued != 1 {
+		t.Errorf("Expected the deleted report's package to be queued for removal, got %d", queued)
+	}
+}
+
+// TestClaimPackageEndpoints tests the claim package endpoints before a report is processed
+func TestClaimPackageEndpoints(t *testing.T) {
+	server := setupTestServer(t)
+	defer server.Close()
+
+	token := signupAndGetToken(t, server.URL, "patient@example.com")
+	reportID := uploadTestReport(t, server.URL, token, "labs.txt", "Hemoglobin 13.5 g/dL")
+	url := fmt.Sprintf("%s/api/reports/%d/claim-package", server.URL, reportID)
+
+	if status := doJSONRequest(t, "POST", url, token, map[string]string{"insurer": "Star Health"}, nil); status != http.StatusBadRequest {
+		t.Errorf("Expected an unprocessed report to be refused, got %d", status)
+	}
+	if status := doJSONRequest(t, "GET", url, token, nil, nil); status != http.StatusNotFound {
+		t.Errorf("Expected no package before one is generated, got %d", status)
+	}
+	if status := doJSONRequest(t, "GET", url+"/file", "", nil, nil); status != http.StatusUnauthorized {
+		t.Errorf("Expected the download to require authentication, got %d", status)
+	}
+}
@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/client"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// TestClientSDK tests the typed SDK methods against the real router
+func TestClientSDK(t *testing.T) {
+	server := setupTestServer(t)
+	defer server.Close()
+	ctx := context.Background()
+
+	api := client.New(server.URL+"/", "")
+	if _, err := api.Me(ctx); !client.IsStatus(err, http.StatusUnauthorized) {
+		t.Fatalf("Expected 401 before signing in, got %v", err)
+	}
+
+	signup, err := api.Signup(ctx, types.SignupRequest{Email: "sdk@example.com", Password: "password123", FullName: "SDK User"})
+	if err != nil {
+		t.Fatalf("Signup failed: %v", err)
+	}
+	if api.Token() != signup.Token || signup.Token == "" {
+		t.Fatal("Expected Signup to keep the session token")
+	}
+
+	if _, err := client.New(server.URL, "").Login(ctx, "sdk@example.com", "wrong-password"); !client.IsStatus(err, http.StatusUnauthorized) {
+		t.Errorf("Expected 401 for a wrong password, got %v", err)
+	} else if apiErr := err.(*client.Error); apiErr.Type == "" || apiErr.Message == "" {
+		t.Errorf("Expected the error envelope's type and message, got %+v", apiErr)
+	}
+
+	name := "Dr. SDK"
+	me, err := api.UpdateMe(ctx, types.UpdateProfileRequest{FullName: &name})
+	if err != nil || me.FullName != name {
+		t.Fatalf("Expected UpdateMe to rename the account, got %+v %v", me, err)
+	}
+
+	// Without a declared type the SDK works it out from the name and content
+	content := "Hemoglobin: 13.5 g/dL"
+	uploaded, err := api.UploadReport(ctx, "cbc.txt", strings.NewReader(content), client.UploadOptions{})
+	if err != nil || uploaded.ReportID == 0 {
+		t.Fatalf("Upload failed: %+v %v", uploaded, err)
+	}
+
+	list, err := api.ListReports(ctx, 10, 0)
+	if err != nil || list.Total != 1 || len(list.Reports) != 1 || list.Reports[0].ID != uploaded.ReportID {
+		t.Fatalf("Expected the upload listed, got %+v %v", list, err)
+	}
+
+	title := "Annual CBC"
+	report, err := api.UpdateReport(ctx, uploaded.ReportID, types.UpdateReportRequest{Title: &title})
+	if err != nil || report.Title != title {
+		t.Fatalf("Expected UpdateReport to set the title, got %+v %v", report, err)
+	}
+	if report, err = api.GetReport(ctx, uploaded.ReportID); err != nil || report.Title != title {
+		t.Fatalf("Expected GetReport to return the title, got %+v %v", report, err)
+	}
+
+	file, err := api.DownloadReportFile(ctx, uploaded.ReportID)
+	if err != nil || string(file) != content {
+		t.Fatalf("Expected the original file, got %q %v", file, err)
+	}
+
+	// The test server queues reports without processing them, so waiting ends with the context
+	waitCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	history, err := api.WaitForReport(waitCtx, uploaded.ReportID, 10*time.Millisecond)
+	if err != context.DeadlineExceeded || history == nil || history.Status != "pending" {
+		t.Fatalf("Expected the wait to time out on a pending report, got %+v %v", history, err)
+	}
+	if _, err := api.GetReportSummary(ctx, uploaded.ReportID, ""); !client.IsStatus(err, http.StatusBadRequest) {
+		t.Errorf("Expected 400 for the summary of an unanalyzed report, got %v", err)
+	}
+
+	transcript, err := api.GetChatTranscript(ctx, uploaded.ReportID)
+	if err != nil || transcript.ReportID != uploaded.ReportID || len(transcript.Messages) != 0 {
+		t.Fatalf("Expected an empty transcript, got %+v %v", transcript, err)
+	}
+
+	// API keys made through the SDK work as its credential
+	key, err := api.CreateAPIKey(ctx, "sdk test")
+	if err != nil {
+		t.Fatalf("CreateAPIKey failed: %v", err)
+	}
+	viaKey := client.New(server.URL, key.Key)
+	if me, err := viaKey.Me(ctx); err != nil || me.Email != "sdk@example.com" {
+		t.Fatalf("Expected the key to sign in as its owner, got %+v %v", me, err)
+	}
+	if err := api.RevokeAPIKey(ctx, key.ID); err != nil {
+		t.Fatalf("RevokeAPIKey failed: %v", err)
+	}
+	if _, err := viaKey.Me(ctx); !client.IsStatus(err, http.StatusUnauthorized) {
+		t.Errorf("Expected 401 for a revoked key, got %v", err)
+	}
+
+	other := client.New(server.URL, signupAndGetToken(t, server.URL, "sdk-other@example.com"))
+	if _, err := other.GetReport(ctx, uploaded.ReportID); !client.IsStatus(err, http.StatusForbidden) {
+		t.Errorf("Expected 403 for another user's report, got %v", err)
+	}
+
+	if err := api.DeleteReport(ctx, uploaded.ReportID); err != nil {
+		t.Fatalf("DeleteReport failed: %v", err)
+	}
+	if _, err := api.GetReport(ctx, uploaded.ReportID); !client.IsStatus(err, http.StatusNotFound) {
+		t.Errorf("Expected 404 after deleting, got %v", err)
+	}
+}
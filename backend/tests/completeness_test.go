@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+)
+
+// metricsNamed builds metrics carrying only the given names
+func metricsNamed(names ...string) []services.HealthMetric {
+	metrics := make([]services.HealthMetric, len(names))
+	for i, name := range names {
+		metrics[i] = services.HealthMetric{Name: name}
+	}
+	return metrics
+}
+
+// TestReportCompleteness tests detecting panels from metric names and hinting at their missing tests
+func TestReportCompleteness(t *testing.T) {
+	completeness := services.ScoreCompleteness(metricsNamed("Fasting Blood Sugar", "Urine Glucose"))
+	if completeness == nil || len(completeness.Panels) != 1 || completeness.Score != 50 {
+		t.Fatalf("Expected the blood sugar tests half complete, got %+v", completeness)
+	}
+	if fmt.Sprint(completeness.Hints) != "[Fasting glucose present but HbA1c missing]" {
+		t.Errorf("Unexpected hints %v", completeness.Hints)
+	}
+
+	// Glycated hemoglobin is HbA1c, not the hemoglobin of a blood count, and ratios don't count as lipids
+	completeness = services.ScoreCompleteness(metricsNamed("HbA1c (Glycated Hemoglobin)", "Total Cholesterol",
+		"LDL Cholesterol", "HDL Cholesterol", "Triglycerides", "Cholesterol/HDL Ratio", "Hemoglobin"))
+	if completeness == nil || len(completeness.Panels) != 2 {
+		t.Fatalf("Expected the lipid panel and blood sugar tests, got %+v", completeness)
+	}
+	if completeness.Panels[0].Key != "lipid" || completeness.Panels[0].Score != 100 || completeness.Score != 83 {
+		t.Errorf("Expected a complete lipid panel and 5 of 6 tests overall, got %+v", completeness)
+	}
+	if fmt.Sprint(completeness.Hints) != "[HbA1c present but fasting glucose missing]" {
+		t.Errorf("Unexpected hints %v", completeness.Hints)
+	}
+
+	// A larger panel needs two of its tests before the rest are asked for
+	if completeness := services.ScoreCompleteness(metricsNamed("Vitamin D", "Platelet Count")); completeness != nil {
+		t.Errorf("Expected no panel detected, got %+v", completeness)
+	}
+	completeness = services.ScoreCompleteness(metricsNamed("Hemoglobin", "Platelet Count", "MCHC", "Total WBC Count",
+		"RBC Count", "PCV", "MCV"))
+	if completeness == nil || fmt.Sprint(completeness.Hints) != "[Complete blood count present but MCH missing]" {
+		t.Errorf("Expected only MCH missing from the blood count, got %+v", completeness)
+	}
+
+	// Version 3 blobs are scored on read
+	analysis, err := services.ParseStoredAnalysis(`{"schema_version": 3, "health_metrics": [{"name": "TSH", "value": 2.1}]}`)
+	if err != nil {
+		t.Fatalf("Failed to parse version 3 analysis: %v", err)
+	}
+	if analysis.Completeness == nil || fmt.Sprint(analysis.Completeness.Hints) != "[TSH present but free T4 and free T3 missing]" {
+		t.Errorf("Expected the thyroid panel scored, got %+v", analysis.Completeness)
+	}
+}
@@ -0,0 +1,124 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/handlers"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// versionedResponse is the part of an edit response the concurrency checks read
+type versionedResponse struct {
+	Data struct {
+		Version  int    `json:"version"`
+		Title    string `json:"title"`
+		FullName string `json:"full_name"`
+	} `json:"data"`
+	Error *types.APIError `json:"error"`
+}
+
+// TestOptimisticConcurrency tests that edits sent with a stale If-Match get 409 and the current version instead of overwriting
+func TestOptimisticConcurrency(t *testing.T) {
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	user := &models.User{Email: "twodevices@example.com", PasswordHash: "hash", FullName: "Two Devices", IsActive: true}
+	if err := models.NewUserRepository(db.GetDB()).Create(user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	reportRepo := models.NewReportRepository(db.GetDB())
+	report := &models.Report{UserID: user.ID, OriginalFilename: "cbc.pdf", FilePath: "cbc.pdf", FileType: "pdf", FileSize: 1}
+	if err := reportRepo.Create(report); err != nil {
+		t.Fatalf("Failed to create report: %v", err)
+	}
+
+	reportHandler := handlers.NewReportHandler(reportRepo, nil, nil, nil, services.NewFileStorage(t.TempDir(), "test-secret"), 20971520, false)
+	call := func(handler http.HandlerFunc, method, ifMatch string, body any) (*httptest.ResponseRecorder, versionedResponse) {
+		payload, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, "/api/reports/"+strconv.Itoa(report.ID), bytes.NewReader(payload))
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		req = mux.SetURLVars(req.WithContext(context.WithValue(req.Context(), middleware.UserKey, user)),
+			map[string]string{"id": strconv.Itoa(report.ID)})
+		recorder := httptest.NewRecorder()
+		handler(recorder, req)
+		var response versionedResponse
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		return recorder, response
+	}
+
+	recorder, fetched := call(reportHandler.GetReportHandler, "GET", "", nil)
+	etag := recorder.Header().Get("ETag")
+	if fetched.Data.Version != 1 || etag != `"1"` {
+		t.Fatalf("Expected version 1 with a matching ETag, got %d and %q", fetched.Data.Version, etag)
+	}
+
+	// The first device's edit applies; the second, made against the same version, conflicts
+	title := func(value string) types.UpdateReportRequest { return types.UpdateReportRequest{Title: &value} }
+	recorder, edited := call(reportHandler.UpdateReportHandler, "PATCH", etag, title("Phone edit"))
+	if recorder.Code != http.StatusOK || edited.Data.Version != 2 || recorder.Header().Get("ETag") != `"2"` {
+		t.Fatalf("Expected the edit to apply as version 2, got %d %+v", recorder.Code, edited)
+	}
+	recorder, conflict := call(reportHandler.UpdateReportHandler, "PATCH", etag, title("Laptop edit"))
+	if recorder.Code != http.StatusConflict || conflict.Error == nil || conflict.Error.Type != "VERSION_CONFLICT" ||
+		conflict.Error.CurrentVersion != 2 || recorder.Header().Get("ETag") != `"2"` {
+		t.Fatalf("Expected 409 with the current version, got %d %s", recorder.Code, recorder.Body.String())
+	}
+	if stored, _ := reportRepo.GetByID(report.ID); stored.Title != "Phone edit" {
+		t.Errorf("Expected the conflicting edit to be refused, got title %q", stored.Title)
+	}
+
+	// Retrying against the current version, or without If-Match, applies
+	if recorder, _ := call(reportHandler.UpdateReportHandler, "PATCH", `W/"2"`, title("Laptop edit")); recorder.Code != http.StatusOK {
+		t.Errorf("Expected the retried edit to apply, got %d", recorder.Code)
+	}
+	if recorder, edited := call(reportHandler.UpdateReportHandler, "PATCH", "", title("Tablet edit")); recorder.Code != http.StatusOK || edited.Data.Version != 4 {
+		t.Errorf("Expected an unconditional edit to apply as version 4, got %d %+v", recorder.Code, edited)
+	}
+	if recorder, _ := call(reportHandler.UpdateReportHandler, "PATCH", "yesterday", title("Bad")); recorder.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a malformed If-Match, got %d", recorder.Code)
+	}
+
+	// The profile behaves the same through the full stack
+	server := setupTestServer(t)
+	defer server.Close()
+	token := signupAndGetToken(t, server.URL, "profile-versions@example.com")
+	patchMe := func(ifMatch, name string) (*http.Response, versionedResponse) {
+		payload, _ := json.Marshal(types.UpdateProfileRequest{FullName: &name})
+		req, _ := http.NewRequest("PATCH", server.URL+"/api/auth/me", bytes.NewReader(payload))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", ifMatch)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("PATCH /api/auth/me failed: %v", err)
+		}
+		defer resp.Body.Close()
+		var response versionedResponse
+		json.NewDecoder(resp.Body).Decode(&response)
+		return resp, response
+	}
+	if resp, updated := patchMe(`"1"`, "Renamed Once"); resp.StatusCode != http.StatusOK || updated.Data.Version != 2 || resp.Header.Get("ETag") != `"2"` {
+		t.Fatalf("Expected the profile edit to apply as version 2, got %d %+v", resp.StatusCode, updated)
+	}
+	if resp, conflict := patchMe(`"1"`, "Renamed Twice"); resp.StatusCode != http.StatusConflict || conflict.Error == nil || conflict.Error.CurrentVersion != 2 {
+		t.Errorf("Expected 409 for a stale profile edit, got %d %+v", resp.StatusCode, conflict.Error)
+	}
+}
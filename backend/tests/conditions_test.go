@@ -0,0 +1,138 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// TestConditionTagging tests matching metric names and findings to the condition catalog
+func TestConditionTagging(t *testing.T) {
+	for text, want := range map[string]string{
+		"HbA1c":                         "[diabetes]",
+		"Fasting Blood  Sugar":          "[diabetes]",
+		"Signs of diabetic nephropathy": "[diabetes]",
+		"Systolic Blood Pressure":       "[hypertension]",
+		"Free T4":                       "[thyroid]",
+		"TSH raised; glucose also high": "[diabetes thyroid]",
+		"Hemoglobin":                    "[]",
+		"Salt intake":                   "[]",
+		"Potassium":                     "[]",
+	} {
+		if got := fmt.Sprint(services.ConditionsForText(text)); got != want {
+			t.Errorf("ConditionsForText(%q) = %s, want %s", text, got, want)
+		}
+	}
+
+	// Version 2 blobs are tagged on read
+	analysis, err := services.ParseStoredAnalysis(`{"schema_version": 2, "health_metrics": [{"name": "HbA1c", "value": 6.1},
+		{"name": "Hemoglobin", "value": 13}], "key_findings": ["HbA1c in the prediabetic range", "Normal blood count"]}`)
+	if err != nil {
+		t.Fatalf("Failed to parse version 2 analysis: %v", err)
+	}
+	if fmt.Sprint(analysis.HealthMetrics[0].Conditions) != "[diabetes]" || analysis.HealthMetrics[1].Conditions != nil {
+		t.Errorf("Expected only HbA1c to be tagged, got %+v", analysis.HealthMetrics)
+	}
+	if fmt.Sprint(analysis.ConditionFindings) != "map[diabetes:[HbA1c in the prediabetic range]]" {
+		t.Errorf("Expected the HbA1c finding under diabetes, got %v", analysis.ConditionFindings)
+	}
+}
+
+// TestConditionOverview tests tracking a condition and collecting its metrics across reports
+func TestConditionOverview(t *testing.T) {
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	user := &models.User{Email: "conditions@example.com", PasswordHash: "hash", FullName: "Conditions", IsActive: true}
+	if err := models.NewUserRepository(db.GetDB()).Create(user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	reportRepo := models.NewReportRepository(db.GetDB())
+	reports, err := services.NewDemoService(reportRepo).ProvisionSampleReports(user.ID)
+	if err != nil {
+		t.Fatalf("Failed to provision reports: %v", err)
+	}
+
+	// An earlier thyroid panel, stored before condition tags existed
+	earlier := &models.Report{UserID: user.ID, OriginalFilename: "old_thyroid.txt", FilePath: "old_thyroid.txt", FileType: "txt", FileSize: 10}
+	if err := reportRepo.Create(earlier); err != nil {
+		t.Fatalf("Failed to create report: %v", err)
+	}
+	reportRepo.UpdateProcessingStatus(earlier.ID, "completed", `{"schema_version": 2, "health_metrics":
+		[{"name": "TSH", "value": 5.6, "unit": "mIU/L", "status": "warning"}], "key_findings": ["TSH mildly raised"]}`)
+	reportDate := time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)
+	reportRepo.UpdateDetails(earlier.ID, models.ReportDetails{ReportDate: &reportDate})
+
+	conditions := services.NewConditionService(models.NewUserConditionRepository(db.GetDB()), reportRepo)
+	if _, err := conditions.Overview(user, "thyroid"); err == nil {
+		t.Error("Expected an untracked condition's overview to be refused")
+	}
+	if _, err := conditions.Track(user.ID, "gout"); err == nil {
+		t.Error("Expected an unknown condition to be refused")
+	}
+	if _, err := conditions.Track(user.ID, "thyroid"); err != nil {
+		t.Fatalf("Failed to track condition: %v", err)
+	}
+	if _, err := conditions.Track(user.ID, "thyroid"); err != nil {
+		t.Errorf("Expected tracking twice to succeed: %v", err)
+	}
+
+	overview, err := conditions.Overview(user, "thyroid")
+	if err != nil {
+		t.Fatalf("Failed to build overview: %v", err)
+	}
+	if overview.ReportCount != 2 || len(overview.Metrics) != 2 {
+		t.Fatalf("Expected TSH and Free T4 from two reports, got %+v", overview)
+	}
+	tsh := overview.Metrics[0]
+	if tsh.Name != "TSH" || len(tsh.Readings) != 2 || tsh.Readings[0].ReportID != earlier.ID ||
+		tsh.Readings[1].ReportID != reports[2].ID || tsh.LatestStatus != "normal" {
+		t.Errorf("Expected TSH readings oldest first ending normal, got %+v", tsh)
+	}
+	if len(overview.Findings) != 2 || overview.Findings[0].Text != "TSH mildly raised" {
+		t.Errorf("Expected both thyroid findings oldest first, got %+v", overview.Findings)
+	}
+
+	list, err := conditions.List(user.ID)
+	if err != nil || len(list) != 3 || list[0].Tracked || !list[2].Tracked || list[2].Since == nil {
+		t.Errorf("Expected the catalog with only thyroid tracked, got %+v (%v)", list, err)
+	}
+
+	// HTTP: track, view, and untrack over the API
+	server := setupTestServer(t)
+	defer server.Close()
+	token := signupAndGetToken(t, server.URL, "conditions-http@example.com")
+
+	var tracked types.TrackedCondition
+	if status := doJSONRequest(t, "PUT", server.URL+"/api/conditions/diabetes", token, nil, &tracked); status != http.StatusOK || !tracked.Tracked {
+		t.Errorf("Expected diabetes to be tracked, got %d %+v", status, tracked)
+	}
+	var empty types.ConditionOverview
+	if status := doJSONRequest(t, "GET", server.URL+"/api/conditions/diabetes/overview", token, nil, &empty); status != http.StatusOK ||
+		empty.ReportCount != 0 || !strings.EqualFold(empty.Name, "diabetes") {
+		t.Errorf("Expected an empty diabetes overview, got %d %+v", status, empty)
+	}
+	if status := doJSONRequest(t, "GET", server.URL+"/api/conditions/gout/overview", token, nil, nil); status != http.StatusNotFound {
+		t.Errorf("Expected unknown condition to be not found, got %d", status)
+	}
+	if status := doJSONRequest(t, "DELETE", server.URL+"/api/conditions/diabetes", token, nil, nil); status != http.StatusOK {
+		t.Errorf("Expected untracking to succeed, got %d", status)
+	}
+	var listed types.ConditionListResponse
+	if status := doJSONRequest(t, "GET", server.URL+"/api/conditions", token, nil, &listed); status != http.StatusOK ||
+		len(listed.Conditions) != 3 || listed.Conditions[0].Tracked {
+		t.Errorf("Expected no tracked conditions, got %d %+v", status, listed)
+	}
+}
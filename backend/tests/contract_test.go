@@ -0,0 +1,617 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// Contract tests pin the JSON the frontend exchanges with each endpoint. Every call below sends a pkg/types
+// request through the real router, decodes the response strictly into its pkg/types value, and compares the
+// shape of both (field names and JSON kinds, not values) with a golden file in testdata/contracts. A renamed
+// field, a changed type, or a handler answering with a struct other than the one the SDK decodes fails here.
+// When a change is deliberate and the frontend is ready for it, rewrite the golden files and commit them:
+//
+//	go test ./tests -run TestAPIContracts -update-contracts
+
+var updateContracts = flag.Bool("update-contracts", false, "rewrite the API contract golden files from the handlers")
+
+// contractDir holds one golden file per contract
+const contractDir = "testdata/contracts"
+
+// contractExemptions are the request and response types no contract covers, with the reason
+var contractExemptions = map[string]string{
+	"UploadRequest":       "reports are uploaded as multipart forms, which no handler decodes into it",
+	"ChatResponse":        "no handler returns it; answers are ChatMessage",
+	"BookFollowUpRequest": "booking needs a scheduling system; TestAppointmentBooking runs it against a fake one",
+	"DoctorsResponse":     "doctor search needs a directory; TestReferralSuggestions runs it against a fake one",
+}
+
+// contractRun sends contract calls to one server and records what they cover
+type contractRun struct {
+	t       *testing.T
+	baseURL string
+	covered map[string]bool // pkg/types names sent or decoded
+	checked map[string]bool // Golden files compared or written
+}
+
+// call sends body as JSON and checks the exchange against the golden file for name
+func (c *contractRun) call(name, method, path, token string, body any, status int, out any) {
+	c.t.Helper()
+	var payload []byte
+	if body != nil {
+		payload, _ = json.Marshal(body)
+		c.cover(body)
+	}
+	req, _ := http.NewRequest(method, c.baseURL+path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	c.send(name, req, payload, status, out)
+}
+
+// upload posts data as the multipart file field and checks the response against the golden file for name
+func (c *contractRun) upload(name, path, token, field, filename, contentType string, data []byte, status int, out any) {
+	c.t.Helper()
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	partHeader := textproto.MIMEHeader{}
+	partHeader.Set("Content-Disposition", fmt.Sprintf(`form-data; name=%q; filename=%q`, field, filename))
+	partHeader.Set("Content-Type", contentType)
+	part, _ := writer.CreatePart(partHeader)
+	part.Write(data)
+	writer.Close()
+
+	req, _ := http.NewRequest("POST", c.baseURL+path, body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	c.send(name, req, nil, status, out)
+}
+
+// send makes the request, decodes the response data strictly into out, and compares the exchange with the golden file
+func (c *contractRun) send(name string, req *http.Request, payload []byte, status int, out any) {
+	c.t.Helper()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		c.t.Fatalf("%s: %s %s failed: %v", name, req.Method, req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != status {
+		c.t.Fatalf("%s: expected status %d from %s %s, got %d: %s", name, status, req.Method, req.URL.Path, resp.StatusCode, raw)
+	}
+
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		c.t.Fatalf("%s: response isn't an envelope: %v", name, err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(envelope.Data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(out); err != nil {
+		c.t.Errorf("%s: response doesn't decode into %T: %v\n%s", name, out, err, envelope.Data)
+		return
+	}
+	c.cover(out)
+
+	// Decision: The decoded value is encoded again, so a field the type has but the handler never sends shows up
+	// as well as one the handler sends that the type lacks
+	sent := jsonShape(envelope.Data)
+	again, _ := json.Marshal(out)
+	if diff := shapeDiff("data", sent, jsonShape(again)); len(diff) > 0 {
+		c.t.Errorf("%s: %T doesn't match what the handler sends:\n%s", name, out, strings.Join(diff, "\n"))
+	}
+
+	contract := map[string]any{"status": status, "response": sent}
+	if payload != nil {
+		contract["request"] = jsonShape(payload)
+	}
+	c.compare(name, contract)
+}
+
+// compare checks contract against its golden file, or rewrites the file with -update-contracts
+func (c *contractRun) compare(name string, contract map[string]any) {
+	c.t.Helper()
+	if c.checked[name] {
+		c.t.Fatalf("Contract %s is checked twice", name)
+	}
+	c.checked[name] = true
+
+	encoded, _ := json.MarshalIndent(contract, "", "  ")
+	encoded = append(encoded, '\n')
+	path := filepath.Join(contractDir, name+".json")
+	if *updateContracts {
+		if err := os.MkdirAll(contractDir, 0755); err != nil {
+			c.t.Fatal(err)
+		}
+		if err := os.WriteFile(path, encoded, 0644); err != nil {
+			c.t.Fatal(err)
+		}
+		return
+	}
+
+	golden, err := os.ReadFile(path)
+	if err != nil {
+		c.t.Errorf("%s: no golden file; run go test ./tests -run TestAPIContracts -update-contracts", name)
+		return
+	}
+	var want map[string]any
+	if err := json.Unmarshal(golden, &want); err != nil {
+		c.t.Errorf("%s: golden file is not JSON: %v", name, err)
+		return
+	}
+	var got map[string]any
+	json.Unmarshal(encoded, &got)
+	if diff := shapeDiff(name, want, got); len(diff) > 0 {
+		c.t.Errorf("Contract %s changed; if the frontend is ready for it, rerun with -update-contracts and commit %s:\n%s",
+			name, path, strings.Join(diff, "\n"))
+	}
+}
+
+// cover records value's type, and the pkg/types types it holds, as covered
+func (c *contractRun) cover(value any) {
+	var walk func(reflect.Type)
+	walk = func(typ reflect.Type) {
+		for typ.Kind() == reflect.Pointer || typ.Kind() == reflect.Slice || typ.Kind() == reflect.Map {
+			typ = typ.Elem()
+		}
+		if typ.PkgPath() != reflect.TypeFor[types.User]().PkgPath() || c.covered[typ.Name()] {
+			return
+		}
+		c.covered[typ.Name()] = true
+		if typ.Kind() == reflect.Struct {
+			for i := range typ.NumField() {
+				walk(typ.Field(i).Type)
+			}
+		}
+	}
+	walk(reflect.TypeOf(value))
+}
+
+// jsonShape reduces a JSON document to its field names and value kinds; a list becomes one element
+// standing for all of its elements
+func jsonShape(raw []byte) any {
+	var value any
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return "invalid"
+	}
+	return shapeOf(value)
+}
+
+func shapeOf(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		shape := make(map[string]any, len(v))
+		for key, field := range v {
+			shape[key] = shapeOf(field)
+		}
+		return shape
+	case []any:
+		if len(v) == 0 {
+			return []any{}
+		}
+		element := shapeOf(v[0])
+		for _, next := range v[1:] {
+			element = mergeShapes(element, shapeOf(next))
+		}
+		return []any{element}
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	}
+	return "null"
+}
+
+// mergeShapes combines the shapes of two list elements: objects keep every field either has, and differing
+// kinds are joined, e.g. "null|string"
+// Decision: A null beside an object or list is an omitted pointer, so the object or list stands for both
+func mergeShapes(a, b any) any {
+	aObject, aIsObject := a.(map[string]any)
+	bObject, bIsObject := b.(map[string]any)
+	if aIsObject && bIsObject {
+		merged := make(map[string]any, len(aObject))
+		for key, field := range aObject {
+			merged[key] = field
+		}
+		for key, field := range bObject {
+			if existing, ok := merged[key]; ok {
+				field = mergeShapes(existing, field)
+			}
+			merged[key] = field
+		}
+		return merged
+	}
+	aList, aIsList := a.([]any)
+	bList, bIsList := b.([]any)
+	if aIsList && bIsList {
+		if len(aList) == 0 {
+			return bList
+		}
+		if len(bList) == 0 {
+			return aList
+		}
+		return []any{mergeShapes(aList[0], bList[0])}
+	}
+	aKind, aIsKind := a.(string)
+	bKind, bIsKind := b.(string)
+	switch {
+	case aIsKind && bIsKind:
+		kinds := append(strings.Split(aKind, "|"), strings.Split(bKind, "|")...)
+		sort.Strings(kinds)
+		return strings.Join(slices.Compact(kinds), "|")
+	case aKind == "null":
+		return b
+	case bKind == "null":
+		return a
+	}
+	return "mixed"
+}
+
+// shapeDiff lists where got differs from want, one line per field
+func shapeDiff(path string, want, got any) []string {
+	wantObject, wantIsObject := want.(map[string]any)
+	gotObject, gotIsObject := got.(map[string]any)
+	if wantIsObject && gotIsObject {
+		keys := make([]string, 0, len(wantObject)+len(gotObject))
+		for key := range wantObject {
+			keys = append(keys, key)
+		}
+		for key := range gotObject {
+			if _, ok := wantObject[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		var diff []string
+		for _, key := range keys {
+			wantField, inWant := wantObject[key]
+			gotField, inGot := gotObject[key]
+			switch {
+			case !inGot:
+				diff = append(diff, fmt.Sprintf("  %s.%s: missing (was %s)", path, key, describeShape(wantField)))
+			case !inWant:
+				diff = append(diff, fmt.Sprintf("  %s.%s: unexpected %s", path, key, describeShape(gotField)))
+			default:
+				diff = append(diff, shapeDiff(path+"."+key, wantField, gotField)...)
+			}
+		}
+		return diff
+	}
+	wantList, wantIsList := want.([]any)
+	gotList, gotIsList := got.([]any)
+	if wantIsList && gotIsList && len(wantList) == 1 && len(gotList) == 1 {
+		return shapeDiff(path+"[]", wantList[0], gotList[0])
+	}
+	if reflect.DeepEqual(want, got) {
+		return nil
+	}
+	return []string{fmt.Sprintf("  %s: %s, was %s", path, describeShape(got), describeShape(want))}
+}
+
+// describeShape names a shape in a diff line
+func describeShape(shape any) string {
+	switch s := shape.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		if len(s) == 0 {
+			return "empty list"
+		}
+		return "list of " + describeShape(s[0])
+	case string:
+		return s
+	}
+	return fmt.Sprint(shape)
+}
+
+// contractTypes lists the exported request and response types pkg/types declares
+func contractTypes(t *testing.T) []string {
+	files, err := filepath.Glob("../pkg/types/*.go")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("Failed to find pkg/types: %v", err)
+	}
+	var names []string
+	fset := token.NewFileSet()
+	for _, file := range files {
+		parsed, err := parser.ParseFile(fset, file, nil, parser.SkipObjectResolution)
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v", file, err)
+		}
+		for _, decl := range parsed.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				name := spec.(*ast.TypeSpec).Name.Name
+				if ast.IsExported(name) && (strings.HasSuffix(name, "Request") || strings.HasSuffix(name, "Response")) {
+					names = append(names, name)
+				}
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ref returns a pointer to v, for the optional fields of requests
+func ref[T any](v T) *T {
+	return &v
+}
+
+// TestAPIContracts tests every request and response type the frontend uses against the golden files, and that
+// each one pkg/types declares is covered or exempt
+func TestAPIContracts(t *testing.T) {
+	// Checkout reaches a payment provider, so a fake Stripe answers it
+	stripe := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"id": "cs_1", "url": "https://checkout.stripe.test/cs_1"})
+	}))
+	defer stripe.Close()
+	payments, err := services.NewPaymentProvider(config.PaymentConfig{Provider: "stripe", SecretKey: "sk_test",
+		WebhookSecret: testWebhookSecret, PremiumPriceID: "price_premium", APIURL: stripe.URL})
+	if err != nil {
+		t.Fatalf("Failed to create payment provider: %v", err)
+	}
+	plans := map[string]config.PlanConfig{
+		models.PlanFree:    testPlans[models.PlanFree],
+		models.PlanPremium: {MaxOutputTokens: 4096, SummaryWords: 200, Features: []string{services.FeatureCrossReport}},
+	}
+
+	// A second connection stands in for a worker, to put a report through analysis
+	dsn := filepath.Join(t.TempDir(), "contracts.db") + "?_busy_timeout=5000&_journal_mode=WAL"
+	server := newTestServer(t, testServerOptions{dsn: dsn, plans: plans, payments: payments, demoReports: true, promptCanary: true})
+	defer server.Close()
+	worker, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: dsn}})
+	if err != nil {
+		t.Fatalf("Failed to open the database: %v", err)
+	}
+	defer worker.Close()
+
+	c := &contractRun{t: t, baseURL: server.URL, covered: map[string]bool{}, checked: map[string]bool{}}
+	adminToken := signupAndGetToken(t, server.URL, "admin@example.com")
+	otherToken := signupAndGetToken(t, server.URL, "doctor@example.com")
+
+	// Health and auth
+	c.call("health", "GET", "/health", "", nil, http.StatusOK, &types.HealthResponse{})
+	var signedUp types.LoginResponse
+	c.call("auth_signup", "POST", "/api/auth/signup", "", types.SignupRequest{Email: "patient@example.com", Password: "password123",
+		FullName: "Asha Rao", Timezone: "Asia/Kolkata", ReadingLevel: models.ReadingLevelStandard}, http.StatusCreated, &signedUp)
+	patient := signedUp.User
+	var login types.LoginResponse
+	c.call("auth_login", "POST", "/api/auth/login", "", types.LoginRequest{Email: "patient@example.com", Password: "password123"},
+		http.StatusOK, &login)
+	token := login.Token
+	c.call("auth_me", "GET", "/api/auth/me", token, nil, http.StatusOK, &types.User{})
+	c.call("auth_update_me", "PATCH", "/api/auth/me", token, types.UpdateProfileRequest{FullName: ref("Asha R."),
+		Timezone: ref("Asia/Kolkata"), ReadingLevel: ref(models.ReadingLevelChild)}, http.StatusOK, &types.User{})
+	var refreshed types.TokenResponse
+	c.call("auth_refresh", "POST", "/api/auth/refresh", "", types.RefreshTokenRequest{RefreshToken: login.RefreshToken},
+		http.StatusOK, &refreshed)
+	c.call("auth_sessions", "GET", "/api/auth/sessions", token, nil, http.StatusOK, &types.SessionsResponse{})
+	c.call("api_keys_create", "POST", "/api/auth/api-keys", token, types.CreateAPIKeyRequest{Name: "lab sync script"},
+		http.StatusCreated, &types.CreateAPIKeyResponse{})
+	c.call("api_keys_list", "GET", "/api/auth/api-keys", token, nil, http.StatusOK, &types.APIKeysResponse{})
+
+	// Reports: the demo reports are analyzed; uploads wait for a worker
+	var list types.ReportListResponse
+	c.call("reports_list", "GET", "/api/reports", token, nil, http.StatusOK, &list)
+	if len(list.Reports) < 3 {
+		t.Fatalf("Expected the demo reports, got %+v", list)
+	}
+	analyzed, archived := list.Reports[0].ID, list.Reports[1].ID
+	var uploaded types.UploadResponse
+	c.upload("reports_upload", "/api/reports", token, "file", "cbc-page-1.txt", "text/plain",
+		[]byte("Hemoglobin 13.5 g/dL\nWBC 7.2 x10^3/uL"), http.StatusCreated, &uploaded)
+	secondPage := uploadTestReport(t, server.URL, token, "cbc-page-2.txt", "Platelets 250 x10^3/uL")
+	quarantined := uploadTestReport(t, server.URL, token, "thyroid.txt", "TSH 2.1 mIU/L")
+	report := fmt.Sprintf("/api/reports/%d", analyzed)
+
+	c.call("report_get", "GET", report, token, nil, http.StatusOK, &types.Report{})
+	c.call("report_update", "PATCH", report, token, types.UpdateReportRequest{Title: ref("Annual checkup"),
+		ReportDate: ref("2026-09-30"), Notes: ref("Fasting sample")}, http.StatusOK, &types.Report{})
+	c.call("report_summary", "GET", report+"/summary", token, nil, http.StatusOK, &types.ReportSummaryResponse{})
+	c.call("report_metrics", "GET", report+"/metrics", token, nil, http.StatusOK, &types.HealthMetricsResponse{})
+	c.call("report_feedback", "POST", report+"/feedback", token, types.FeedbackRequest{Rating: 4}, http.StatusOK, &types.MessageResponse{})
+	c.call("report_history", "GET", report+"/history", token, nil, http.StatusOK, &types.ReportProcessingHistoryResponse{})
+	c.call("report_status", "GET", report+"/status", token, nil, http.StatusOK, &types.ReportStatusResponse{})
+	c.call("report_parts_merge", "POST", fmt.Sprintf("/api/reports/%d/parts", uploaded.ReportID), token,
+		types.MergePartsRequest{ReportID: secondPage}, http.StatusOK, &types.ReportPartsResponse{})
+	c.call("report_parts", "GET", fmt.Sprintf("/api/reports/%d/parts", uploaded.ReportID), token, nil, http.StatusOK, &types.ReportPartsResponse{})
+	c.call("reports_bulk_archive", "POST", "/api/reports/bulk-archive", token, types.BulkReportRequest{ReportIDs: []int{archived}},
+		http.StatusOK, &types.BulkReportResponse{})
+	c.call("sync", "GET", "/api/sync", token, nil, http.StatusOK, &types.SyncResponse{})
+
+	// Plans and billing; the admin then grants premium for the features below
+	c.call("plan", "GET", "/api/users/me/plan", token, nil, http.StatusOK, &types.PlanResponse{})
+	c.call("billing_subscription", "GET", "/api/billing/subscription", token, nil, http.StatusOK, &types.BillingResponse{})
+	c.call("billing_checkout", "POST", "/api/billing/checkout", token, nil, http.StatusCreated, &types.CheckoutResponse{})
+	c.call("admin_set_plan", "PUT", fmt.Sprintf("/api/admin/users/%d/plan", patient.ID), adminToken,
+		types.UpdatePlanRequest{Plan: models.PlanPremium}, http.StatusOK, &types.User{})
+
+	// Chat
+	var message types.ChatMessage
+	c.call("chat_ask", "POST", report+"/chat", token, types.ChatRequest{Message: "What does my hemoglobin mean?",
+		ReadingLevel: models.ReadingLevelStandard}, http.StatusCreated, &message)
+	chat := fmt.Sprintf("/api/chat/%d", message.ID)
+	c.call("chat_history", "GET", report+"/chat", token, nil, http.StatusOK, &[]types.ChatMessage{})
+	c.call("chat_edit", "PUT", chat, token, types.ChatEditRequest{Message: "Is my hemoglobin normal?",
+		ReadingLevel: models.ReadingLevelChild}, http.StatusOK, &types.ChatMessage{})
+	c.call("chat_versions", "GET", chat+"/versions", token, nil, http.StatusOK, &types.ChatVersionsResponse{})
+	c.call("chat_flag", "POST", chat+"/flag", token, types.FlagMessageRequest{Reason: "The answer mentions a dose"},
+		http.StatusOK, &types.MessageResponse{})
+
+	// Health record
+	c.call("health_profile_update", "PUT", "/api/health-profile", token, types.HealthProfileRequest{DateOfBirth: ref("1988-04-12"),
+		Sex: "female", HeightCm: ref(162.0), WeightKg: ref(58.5), Conditions: []string{"hypothyroidism"}, Allergies: []string{"penicillin"},
+		Medications: []string{"levothyroxine 50 mcg daily"}, BloodGroup: "B+"}, http.StatusOK, &types.HealthProfile{})
+	c.call("condition_track", "PUT", "/api/conditions/diabetes", token, nil, http.StatusOK, &types.TrackedCondition{})
+	c.call("conditions", "GET", "/api/conditions", token, nil, http.StatusOK, &types.ConditionListResponse{})
+	c.call("emergency_card_sharing", "PUT", "/api/users/me/emergency-card/sharing", token,
+		types.EmergencyCardSharingRequest{Enabled: true}, http.StatusOK, &types.EmergencyCardSharing{})
+	c.call("emergency_card", "GET", "/api/users/me/emergency-card", token, nil, http.StatusOK, &types.EmergencyCard{})
+
+	// Analyses, insights, and referrals
+	var merged types.MergedAnalysis
+	c.call("analyses_merge", "POST", "/api/analyses/merge", token, types.MergeAnalysisRequest{ReportIDs: []int{analyzed, list.Reports[2].ID},
+		Title: "Checkups compared", ReadingLevel: models.ReadingLevelStandard}, http.StatusCreated, &merged)
+	c.call("analyses_list", "GET", "/api/analyses", token, nil, http.StatusOK, &types.MergedAnalysisListResponse{})
+	c.call("insights_consent_update", "PUT", "/api/insights/population/consent", token,
+		types.PopulationConsentRequest{OptedIn: ref(true)}, http.StatusOK, &types.PopulationConsentResponse{})
+	c.call("insights_consent", "GET", "/api/insights/population/consent", token, nil, http.StatusOK, &types.PopulationConsentResponse{})
+	c.call("insights_population", "GET", "/api/insights/population", token, nil, http.StatusOK, &types.PopulationInsightsResponse{})
+	c.call("specialties", "GET", "/api/specialties", token, nil, http.StatusOK, &types.SpecialtiesResponse{})
+	c.call("report_referrals", "GET", report+"/referrals", token, nil, http.StatusOK, &types.ReferralsResponse{})
+	c.call("report_bookings", "GET", report+"/bookings", token, nil, http.StatusOK, &types.AppointmentBookingsResponse{})
+
+	// Prescriptions
+	var prescription types.Prescription
+	c.upload("prescription_upload", "/api/prescriptions", token, "image", "prescription.png", "image/png", testPNG,
+		http.StatusCreated, &prescription)
+	c.call("prescriptions", "GET", "/api/prescriptions", token, nil, http.StatusOK, &types.PrescriptionListResponse{})
+	c.call("prescription_correct", "PATCH", fmt.Sprintf("/api/prescriptions/%d/medications/0", prescription.ID), token,
+		types.MedicationCorrectionRequest{Name: ref("Metformin"), Dosage: ref("500 mg"), Frequency: ref("Twice daily"),
+			Duration: ref("30 days"), Instructions: ref("After meals")}, http.StatusOK, &types.Prescription{})
+
+	// Sharing, transfers, widgets, devices, and notifications
+	c.call("share_create", "POST", report+"/shares", token, types.ShareLinkRequest{PIN: "4821", ExpiresInHours: 24},
+		http.StatusCreated, &types.CreatedShareLink{})
+	c.call("shares", "GET", report+"/shares", token, nil, http.StatusOK, &types.ShareLinkListResponse{})
+	c.call("transfer_request", "POST", fmt.Sprintf("/api/reports/%d/transfer", archived), token,
+		types.TransferRequest{RecipientEmail: "doctor@example.com"}, http.StatusCreated, &types.ReportTransfer{})
+	c.call("transfers_incoming", "GET", "/api/transfers", otherToken, nil, http.StatusOK, &types.TransferListResponse{})
+	c.call("widget_token", "POST", "/api/widgets/token", token, types.WidgetTokenRequest{ReportID: analyzed,
+		Scopes: []string{"metrics", "trends"}, ExpiresInMinutes: 30}, http.StatusCreated, &types.WidgetToken{})
+	c.call("device_register", "POST", "/api/devices", token, types.RegisterDeviceRequest{Platform: "fcm", Token: "fcm-token-1",
+		Name: "Pixel 8"}, http.StatusCreated, &types.Device{})
+	c.call("devices", "GET", "/api/devices", token, nil, http.StatusOK, &types.DevicesResponse{})
+	c.call("notifications", "GET", "/api/notifications", otherToken, nil, http.StatusOK, &[]types.Notification{})
+
+	// Organizations
+	var org types.OrganizationBranding
+	c.call("admin_organization_create", "POST", "/api/admin/organizations", adminToken,
+		types.CreateOrganizationRequest{Name: "City Clinic"}, http.StatusCreated, &org)
+	members := fmt.Sprintf("/api/admin/organizations/%d/members", org.ID)
+	c.call("admin_organization_member", "POST", members, adminToken, types.AddOrganizationMemberRequest{Email: "admin@example.com",
+		Role: models.OrgRoleAdmin}, http.StatusOK, &types.MessageResponse{})
+	doJSONRequest(t, "POST", server.URL+members, adminToken, types.AddOrganizationMemberRequest{Email: "doctor@example.com", Role: "doctor"}, nil)
+	doJSONRequest(t, "POST", server.URL+members, adminToken, types.AddOrganizationMemberRequest{Email: "patient@example.com",
+		DoctorEmail: "doctor@example.com"}, nil)
+	c.call("organization_branding_update", "PUT", "/api/organization/branding", adminToken, types.UpdateBrandingRequest{Name: "City Clinic",
+		FooterText: "Not a diagnosis", ContactInfo: "+91 80 1234 5678", Sections: []string{"summary", "key_findings"}},
+		http.StatusOK, &types.OrganizationBranding{})
+	c.call("organization_branding", "GET", "/api/organization/branding", token, nil, http.StatusOK, &types.OrganizationBranding{})
+	c.call("admin_organization_usage", "GET", "/api/admin/organizations/usage?month=2026-01", adminToken, nil,
+		http.StatusOK, &types.OrganizationUsageResponse{})
+	c.call("report_visibility", "PATCH", report+"/visibility", token, types.UpdateVisibilityRequest{Visibility: "doctor"},
+		http.StatusOK, &types.Report{})
+
+	// A worker quarantines one upload's unreadable analysis for an admin to re-parse
+	workerReports := models.NewReportRepository(worker.GetDB())
+	processor := services.NewReportProcessorWithAnalyzer(workerReports, models.NewJobRepository(worker.GetDB()),
+		models.NewAnalysisReviewRepository(worker.GetDB()), nil, garbledAnalyzer{}, services.NewFileStorage("/tmp/test_uploads", "test-secret"))
+	held, err := workerReports.GetByID(quarantined)
+	if err != nil || held == nil {
+		t.Fatalf("Failed to load upload %d: %v", quarantined, err)
+	}
+	processor.ProcessReport(held)
+
+	// Admin
+	c.call("admin_prompt_stats", "GET", "/api/admin/prompts/stats", adminToken, nil, http.StatusOK, &types.PromptStatsResponse{})
+	c.call("admin_prompt_canary", "GET", "/api/admin/prompts/canary", adminToken, nil, http.StatusOK, &types.PromptCanaryResponse{})
+	c.call("admin_prompt_canary_rollback", "POST", "/api/admin/prompts/canary/rollback", adminToken,
+		types.EndPromptCanaryRequest{Reason: "Summaries got longer"}, http.StatusOK, &types.PromptCanaryResponse{})
+	c.call("admin_impersonate", "POST", fmt.Sprintf("/api/admin/impersonate/%d", patient.ID), adminToken,
+		types.ImpersonateRequest{Reason: "Ticket 1234"}, http.StatusCreated, &types.ImpersonationResponse{})
+	c.call("admin_api_keys_create", "POST", "/api/admin/api-keys", adminToken, types.CreateAPIKeyRequest{Name: "hospital importer",
+		Scopes: []string{models.APIKeyScopeAnalysisImport}}, http.StatusCreated, &types.CreateAPIKeyResponse{})
+	c.call("admin_jobs", "GET", "/api/admin/jobs", adminToken, nil, http.StatusOK, &types.QueueOverviewResponse{})
+	c.call("admin_job", "GET", fmt.Sprintf("/api/admin/jobs/%d", uploaded.ReportID), adminToken, nil, http.StatusOK, &types.JobDetailResponse{})
+	c.call("admin_jobs_pause", "POST", "/api/admin/jobs/pause", adminToken, types.QueueActionRequest{Reason: "Model upgrade"},
+		http.StatusOK, &types.MessageResponse{})
+	c.call("admin_jobs_resume", "POST", "/api/admin/jobs/resume", adminToken, nil, http.StatusOK, &types.MessageResponse{})
+	c.call("admin_reviews", "GET", "/api/admin/reviews", adminToken, nil, http.StatusOK, &[]types.AnalysisReview{})
+	c.call("admin_review_reparse", "POST", fmt.Sprintf("/api/admin/reviews/%d/reparse", quarantined), adminToken,
+		types.ReparseAnalysisRequest{RawOutput: `{"summary": "Normal thyroid", "simple_summary": "Your thyroid looks fine", "risk_level": "low"}`},
+		http.StatusOK, &services.AnalysisResult{})
+	c.call("report_claim_package", "POST", fmt.Sprintf("/api/reports/%d/claim-package", quarantined), token,
+		types.ClaimPackageRequest{Insurer: "Star Health", PolicyNumber: "P-1234", ClaimReference: "CLM-42"},
+		http.StatusCreated, &types.ClaimPackage{})
+	c.call("admin_analysis_import", "POST", fmt.Sprintf("/api/reports/%d/analysis", uploaded.ReportID), adminToken,
+		types.ImportAnalysisRequest{Source: "city-hospital-nlp v3", Analysis: json.RawMessage(
+			`{"summary": "CBC normal", "simple_summary": "Your blood counts look healthy", "risk_level": "low"}`)},
+		http.StatusOK, &services.AnalysisResult{})
+
+	c.call("admin_reanalysis_preview", "POST", "/api/admin/reanalysis", adminToken, types.StartReanalysisRequest{Reason: "Prompt rollout",
+		UserID: patient.ID, Since: "2020-01-01", Until: "2099-12-31", ReportIDs: []int{quarantined}, Limit: 10, DryRun: true},
+		http.StatusOK, &types.ReanalysisPreviewResponse{})
+	var run types.ReanalysisRun
+	c.call("admin_reanalysis_start", "POST", "/api/admin/reanalysis", adminToken, types.StartReanalysisRequest{Reason: "Prompt rollout",
+		ReportIDs: []int{quarantined}}, http.StatusAccepted, &run)
+	c.call("admin_reanalysis_runs", "GET", "/api/admin/reanalysis", adminToken, nil, http.StatusOK, &types.ReanalysisRunsResponse{})
+	c.call("admin_reanalysis_run", "GET", fmt.Sprintf("/api/admin/reanalysis/%d", run.ID), adminToken, nil,
+		http.StatusOK, &types.ReanalysisRunResponse{})
+	c.call("admin_reanalysis_diff", "GET", fmt.Sprintf("/api/admin/reanalysis/%d/reports/%d", run.ID, quarantined), adminToken, nil,
+		http.StatusOK, &types.ReanalysisDiffResponse{})
+
+	c.call("admin_moderation_dismiss", "POST", fmt.Sprintf("/api/admin/moderation/chat/%d/dismiss", message.ID), adminToken,
+		types.DismissChatRequest{Note: "Dose was from the report"}, http.StatusOK, &types.FlaggedChatEntry{})
+	doJSONRequest(t, "POST", server.URL+chat+"/flag", token, types.FlagMessageRequest{Reason: "Still worried"}, nil)
+	c.call("admin_moderation_redact", "POST", fmt.Sprintf("/api/admin/moderation/chat/%d/redact", message.ID), adminToken,
+		types.RedactChatRequest{Question: true, Answer: true, Note: "Personal details"}, http.StatusOK, &types.FlaggedChatEntry{})
+	c.call("auth_logout", "POST", "/api/auth/logout", token, types.LogoutRequest{RefreshToken: refreshed.RefreshToken},
+		http.StatusOK, &types.AuthResponse{})
+	c.call("admin_ban", "POST", fmt.Sprintf("/api/admin/users/%d/ban", patient.ID), adminToken, types.BanUserRequest{Reason: "Abuse"},
+		http.StatusCreated, &types.UserBanResponse{})
+	c.call("admin_legal_hold_place", "PUT", fmt.Sprintf("/api/admin/users/%d/legal-hold", patient.ID), adminToken,
+		types.PlaceLegalHoldRequest{Reason: "Court order", Reference: "OS 123/2026"}, http.StatusOK, &types.LegalHoldResponse{})
+	c.call("admin_legal_holds", "GET", "/api/admin/legal-holds", adminToken, nil, http.StatusOK, &types.LegalHoldListResponse{})
+
+	// Every request and response type is covered or exempt, and every golden file still has its contract
+	for _, name := range contractTypes(t) {
+		reason, exempt := contractExemptions[name]
+		switch {
+		case c.covered[name] && exempt:
+			t.Errorf("%s is covered by a contract; remove its exemption (%s)", name, reason)
+		case !c.covered[name] && !exempt:
+			t.Errorf("%s has no contract; add a call to TestAPIContracts or an exemption saying why it can't have one", name)
+		}
+	}
+	goldens, _ := filepath.Glob(filepath.Join(contractDir, "*.json"))
+	for _, golden := range goldens {
+		if name := strings.TrimSuffix(filepath.Base(golden), ".json"); !c.checked[name] {
+			if *updateContracts {
+				os.Remove(golden)
+			} else {
+				t.Errorf("Golden file %s has no contract; rerun with -update-contracts to remove it", golden)
+			}
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// TestDetectCrisis tests which questions count as a crisis
+func TestDetectCrisis(t *testing.T) {
+	tests := []struct {
+		question string
+		category string
+	}{
+		{"I don't want to live anymore, I want to die", services.CrisisSelfHarm},
+		{"I’ve been thinking about suicide since the diagnosis", services.CrisisSelfHarm},
+		{"Sometimes I hurt myself when the results are bad", services.CrisisSelfHarm},
+		{"I have chest pain right now and my arm is numb", services.CrisisEmergency},
+		{"My father passed out after his dialysis", services.CrisisEmergency},
+		{"I can't breathe properly", services.CrisisEmergency},
+		{"Does high LDL raise my risk of chest pain?", ""},
+		{"What causes chest pain?", ""},
+		{"Is my hemoglobin ok?", ""},
+		{"Can this medicine cause suicidal thoughts?", services.CrisisSelfHarm},
+	}
+
+	for _, tt := range tests {
+		match := services.DetectCrisis(tt.question)
+		got := ""
+		if match != nil {
+			got = match.Category
+		}
+		if got != tt.category {
+			t.Errorf("DetectCrisis(%q) = %q, expected %q", tt.question, got, tt.category)
+		}
+	}
+}
+
+// TestCrisisChatAnswer tests that crisis questions get local contacts without the AI and are flagged
+func TestCrisisChatAnswer(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{
+			Driver: "sqlite3",
+			DSN:    ":memory:",
+		},
+	}
+
+	db, err := database.Setup(cfg)
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	userRepo := models.NewUserRepository(db.GetDB())
+	owner := &models.User{Email: "owner@example.com", PasswordHash: "hash", FullName: "Owner", IsActive: true, Timezone: "America/Chicago"}
+	if err := userRepo.Create(owner); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	reportRepo := models.NewReportRepository(db.GetDB())
+	reports, err := services.NewDemoService(reportRepo).ProvisionSampleReports(owner.ID)
+	if err != nil {
+		t.Fatalf("Failed to provision reports: %v", err)
+	}
+
+	crisisRepo := models.NewCrisisFlagRepository(db.GetDB())
+	crisisService, err := services.NewCrisisService(crisisRepo, userRepo, config.ChatSafetyConfig{DefaultCountry: "IN"})
+	if err != nil {
+		t.Fatalf("Failed to create crisis service: %v", err)
+	}
+
+	// Decision: No AI is configured and the free allowance is used up - neither may stand in the way
+	chatRepo := models.NewChatMessageRepository(db.GetDB())
+	chatService := services.NewChatService(chatRepo, models.NewChatSummaryRepository(db.GetDB()), reportRepo, nil, nil,
+		fixedTranscriber{text: "I want to kill myself"}, config.AIConfig{Plans: testPlans})
+	chatService.SetCrisisService(crisisService)
+	for i := 0; i < 3; i++ {
+		if err := chatRepo.Create(&models.ChatMessage{ReportID: reports[0].ID, UserMessage: "q", AIResponse: "a"}); err != nil {
+			t.Fatalf("Failed to create chat message: %v", err)
+		}
+	}
+
+	message, err := chatService.AskByVoice(context.Background(), owner.ID, reports[0].ID,
+		services.VoiceQuestion{Audio: webmHeader, AudioType: "audio/webm"}, models.ReadingLevelStandard, models.PlanFree)
+	if err != nil {
+		t.Fatalf("Failed to answer crisis question: %v", err)
+	}
+	if !strings.Contains(message.AIResponse, "988") || !strings.Contains(message.AIResponse, "911") {
+		t.Errorf("Expected US crisis contacts for a Chicago user, got %q", message.AIResponse)
+	}
+
+	flags, err := crisisRepo.List(models.CrisisFlagFilter{UserID: owner.ID})
+	if err != nil {
+		t.Fatalf("Failed to list crisis flags: %v", err)
+	}
+	if len(flags) != 1 {
+		t.Fatalf("Expected 1 crisis flag, got %d", len(flags))
+	}
+	if flags[0].Category != services.CrisisSelfHarm || flags[0].MessageID != message.ID || flags[0].Country != "US" ||
+		flags[0].InputMode != models.ChatInputVoice || flags[0].Keyword != "kill myself" {
+		t.Errorf("Unexpected crisis flag %+v", flags[0])
+	}
+
+	// Editing a question into an emergency answers it the same way; users in unlisted zones get the default country
+	owner.Timezone = "UTC"
+	if err := userRepo.Update(owner); err != nil {
+		t.Fatalf("Failed to update user: %v", err)
+	}
+	edited, err := chatService.EditMessage(owner.ID, message.ID, "My mother has chest pain and is sweating", models.ReadingLevelStandard)
+	if err != nil {
+		t.Fatalf("Failed to edit into an emergency: %v", err)
+	}
+	if !strings.Contains(edited.AIResponse, "call 112") {
+		t.Errorf("Expected the default country's emergency number, got %q", edited.AIResponse)
+	}
+	if flags, _ := crisisRepo.List(models.CrisisFlagFilter{Category: services.CrisisEmergency}); len(flags) != 1 || flags[0].Country != "IN" {
+		t.Errorf("Expected the edit flagged as an emergency with IN contacts, got %+v", flags)
+	}
+
+	// Ordinary questions still need the AI
+	if _, err := chatService.EditMessage(owner.ID, message.ID, "Is my hemoglobin ok?", models.ReadingLevelStandard); err == nil {
+		t.Error("Expected an ordinary question to need the AI")
+	}
+
+	if _, err := services.NewCrisisService(nil, nil, config.ChatSafetyConfig{DefaultCountry: "ZZ"}); err == nil {
+		t.Error("Expected an unknown default country to be rejected")
+	}
+}
+
+// TestAdminCrisisFlags tests the admin listing of crisis flags
+func TestAdminCrisisFlags(t *testing.T) {
+	server := setupTestServer(t)
+	defer server.Close()
+
+	token := signupAndGetToken(t, server.URL, "patient@example.com")
+	if status := doJSONRequest(t, "GET", server.URL+"/api/admin/crisis", token, nil, nil); status != http.StatusForbidden {
+		t.Errorf("Expected non-admins to be refused, got %d", status)
+	}
+
+	adminToken := signupAndGetToken(t, server.URL, "admin@example.com")
+	var entries []types.CrisisFlagEntry
+	if status := doJSONRequest(t, "GET", server.URL+"/api/admin/crisis?category=self_harm", adminToken, nil, &entries); status != http.StatusOK {
+		t.Fatalf("Expected admin to list crisis flags, got %d", status)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Expected no crisis flags yet, got %d", len(entries))
+	}
+	if status := doJSONRequest(t, "GET", server.URL+"/api/admin/crisis?category=dosage", adminToken, nil, nil); status != http.StatusBadRequest {
+		t.Errorf("Expected an unknown category to be rejected, got %d", status)
+	}
+}
@@ -1,7 +1,9 @@
 package tests
 
 import (
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
 	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
@@ -63,6 +65,10 @@ func TestUserModel(t *testing.T) {
 			full_name TEXT NOT NULL,
 			email_verified BOOLEAN DEFAULT FALSE,
 			is_active BOOLEAN DEFAULT TRUE,
+			timezone TEXT NOT NULL DEFAULT 'UTC',
+			reading_level TEXT NOT NULL DEFAULT 'standard',
+			plan TEXT NOT NULL DEFAULT 'free',
+			version INTEGER NOT NULL DEFAULT 1,
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 		)`
@@ -123,4 +129,121 @@ func TestUserModel(t *testing.T) {
 	}
 
 	t.Log("User model test passed")
-}
\ No newline at end of file
+}
+
+// TestDatabaseHealthMonitor tests health checks before and after the connection is lost
+func TestDatabaseHealthMonitor(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{
+			Driver: "sqlite3",
+			DSN:    ":memory:",
+		},
+	}
+
+	db, err := database.Setup(cfg)
+	if err != nil {
+		t.Fatalf("Failed to setup database: %v", err)
+	}
+
+	monitor := database.NewHealthMonitor(db, time.Minute, time.Second)
+
+	if err := monitor.Check(); err != nil {
+		t.Fatalf("Health check should pass on open database: %v", err)
+	}
+
+	if !monitor.IsHealthy() {
+		t.Fatal("Monitor should report healthy after successful check")
+	}
+
+	// Decision: Closing the pool simulates a dropped connection
+	db.Close()
+
+	if err := monitor.Check(); err == nil {
+		t.Fatal("Health check should fail on closed database")
+	}
+
+	status := monitor.Status()
+	if status.Healthy {
+		t.Fatal("Monitor should report unhealthy after failed check")
+	}
+
+	if status.Pool.ConsecutiveFailures != 1 {
+		t.Fatalf("Expected 1 consecutive failure, got %d", status.Pool.ConsecutiveFailures)
+	}
+
+	t.Log("Database health monitor test passed")
+}
+
+// TestReportReadReplica tests that listings and metrics read the replica while everything else reads the primary
+// Decision: Separate SQLite files stand in for a primary and a replica that hasn't caught up
+func TestReportReadReplica(t *testing.T) {
+	dir := t.TempDir()
+	replicaCfg := &config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: filepath.Join(dir, "replica.db"), AutoMigrate: true}}
+	replica, err := database.Setup(replicaCfg)
+	if err != nil {
+		t.Fatalf("Failed to setup replica: %v", err)
+	}
+	defer replica.Close()
+
+	primaryCfg := &config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: filepath.Join(dir, "primary.db"),
+		ReadDSN: replicaCfg.Database.DSN, AutoMigrate: true}}
+	db, err := database.Setup(primaryCfg)
+	if err != nil {
+		t.Fatalf("Failed to setup primary: %v", err)
+	}
+	defer db.Close()
+	if db.GetReadDB() == db.GetDB() {
+		t.Fatal("Expected a separate read connection when DB_READ_DSN is set")
+	}
+
+	for _, conn := range []*database.DB{db, replica} {
+		user := &models.User{Email: "replica@example.com", PasswordHash: "hash", FullName: "Replica", IsActive: true}
+		if err := models.NewUserRepository(conn.GetDB()).Create(user); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+
+	repo := models.NewReportRepositoryWithReplica(db.GetDB(), db.GetReadDB())
+	report := &models.Report{UserID: 1, OriginalFilename: "cbc.txt", FilePath: "/tmp/cbc.txt", FileType: "text/plain", FileSize: 10}
+	if err := repo.Create(report); err != nil {
+		t.Fatalf("Failed to create report: %v", err)
+	}
+
+	var onPrimary, onReplica int
+	db.GetDB().QueryRow(`SELECT COUNT(*) FROM reports`).Scan(&onPrimary)
+	replica.GetDB().QueryRow(`SELECT COUNT(*) FROM reports`).Scan(&onReplica)
+	if onPrimary != 1 || onReplica != 0 {
+		t.Fatalf("Expected the report written to the primary only, got %d on primary and %d on replica", onPrimary, onReplica)
+	}
+
+	// Reads that follow a write see it at once: ownership checks, read-backs, status history, the queue, and sync
+	if got, err := repo.GetByID(report.ID); err != nil || got == nil {
+		t.Errorf("Expected GetByID to read the primary, got %v", err)
+	}
+	if events, err := repo.ListStatusEvents(report.ID); err != nil || len(events) != 1 {
+		t.Errorf("Expected status history read from the primary, got %d events (%v)", len(events), err)
+	}
+	if pending, err := repo.GetPendingReports(10); err != nil || len(pending) != 1 {
+		t.Errorf("Expected the queue listed from the primary, got %d reports (%v)", len(pending), err)
+	}
+	if changed, err := repo.ListChangedSince(1, time.Time{}); err != nil || len(changed) != 1 {
+		t.Errorf("Expected sync to read the primary, got %d reports (%v)", len(changed), err)
+	}
+
+	// Listings and metrics tolerate lag, so they read the replica, which hasn't caught up yet
+	if listed, err := repo.GetByUserID(1, 10, 0); err != nil || len(listed) != 0 {
+		t.Errorf("Expected the listing read from the replica, got %d reports (%v)", len(listed), err)
+	}
+	if filtered, err := repo.ListByFilter(models.ReportFilter{UserID: 1}); err != nil || len(filtered) != 0 {
+		t.Errorf("Expected the filtered listing read from the replica, got %d reports (%v)", len(filtered), err)
+	}
+
+	// Once replicated, the listings show it
+	if err := models.NewReportRepository(replica.GetDB()).Create(&models.Report{UserID: 1, OriginalFilename: "cbc.txt",
+		FilePath: "/tmp/cbc.txt", FileType: "text/plain", FileSize: 10}); err != nil {
+		t.Fatalf("Failed to replicate report: %v", err)
+	}
+	if listed, err := repo.GetByUserID(1, 10, 0); err != nil || len(listed) != 1 {
+		t.Errorf("Expected the replicated report listed, got %d reports (%v)", len(listed), err)
+	}
+}
@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+)
+
+// TestDemoMode tests sample report provisioning, the mock analyzer, and blocked deletes
+func TestDemoMode(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{
+			Driver: "sqlite3",
+			DSN:    ":memory:",
+		},
+	}
+
+	db, err := database.Setup(cfg)
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	userRepo := models.NewUserRepository(db.GetDB())
+	user := &models.User{Email: "demo@example.com", PasswordHash: "hash", FullName: "Demo User", IsActive: true}
+	if err := userRepo.Create(user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	// Decision: Every sample must land as a completed report with readable metrics
+	reportRepo := models.NewReportRepository(db.GetDB())
+	if _, err := services.NewDemoService(reportRepo).ProvisionSampleReports(user.ID); err != nil {
+		t.Fatalf("Failed to provision demo reports: %v", err)
+	}
+
+	reports, err := reportRepo.GetByUserID(user.ID, 10, 0)
+	if err != nil {
+		t.Fatalf("Failed to list reports: %v", err)
+	}
+	if len(reports) != len(services.DemoSamples) {
+		t.Fatalf("Expected %d demo reports, got %d", len(services.DemoSamples), len(reports))
+	}
+	for _, report := range reports {
+		if report.ProcessingStatus != "completed" || report.PromptVersion != services.DemoPromptVersion {
+			t.Fatalf("Demo report %d not completed with demo prompt version: %+v", report.ID, report)
+		}
+		analysis, err := services.ParseStoredAnalysis(report.SimplifiedSummary)
+		if err != nil || len(analysis.HealthMetrics) == 0 {
+			t.Fatalf("Demo report %d has no usable metrics: %v", report.ID, err)
+		}
+	}
+
+	// Mock analyzer matches uploads to samples by filename
+	result, err := services.NewDemoAnalyzer().AnalyzeReport(context.Background(), "uploads/123_lipid_panel.pdf", "pdf", "standard", models.PlanFree, "")
+	if err != nil {
+		t.Fatalf("Demo analyzer failed: %v", err)
+	}
+	analysis, err := services.ParseStoredAnalysis(result.ResultJSON)
+	if err != nil || analysis.RiskLevel != "medium" {
+		t.Fatalf("Expected lipid panel sample analysis, got %+v (%v)", analysis, err)
+	}
+
+	// Destructive requests are rejected before reaching handlers
+	handler := middleware.DisableDestructiveActions(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	for method, expected := range map[string]int{http.MethodDelete: http.StatusForbidden, http.MethodGet: http.StatusOK} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, "/api/reports/1", nil))
+		if rec.Code != expected {
+			t.Fatalf("%s: expected status %d, got %d", method, expected, rec.Code)
+		}
+	}
+
+	t.Log("Demo mode test passed")
+}
+
+// TestDemoConcurrentProvisioning tests that simultaneous demo signups and analyses don't share sample data; run with -race
+func TestDemoConcurrentProvisioning(t *testing.T) {
+	// Decision: A file database, since concurrent calls use several connections; a shared in-memory cache would
+	// refuse concurrent writers with "table is locked" instead of queueing them
+	dsn := filepath.Join(t.TempDir(), "demo.db") + "?_busy_timeout=5000&_journal_mode=WAL&_txlock=immediate"
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: dsn}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	userRepo := models.NewUserRepository(db.GetDB())
+	demo := services.NewDemoService(models.NewReportRepository(db.GetDB()))
+	analyzer := services.NewDemoAnalyzer()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 4; i++ {
+		user := &models.User{Email: fmt.Sprintf("demo%d@example.com", i), PasswordHash: "hash", FullName: "Demo User", IsActive: true}
+		if err := userRepo.Create(user); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, err := demo.ProvisionSampleReports(user.ID)
+			errs <- err
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := analyzer.AnalyzeReport(context.Background(), "lipid_panel.pdf", "pdf", "standard", "free", "")
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Concurrent demo call failed: %v", err)
+		}
+	}
+
+	// Tags and inferred units belong to each stored analysis, never to the shared samples
+	for _, sample := range services.DemoSamples {
+		for _, metric := range sample.Analysis.HealthMetrics {
+			if metric.Conditions != nil {
+				t.Errorf("Expected %s in %s left untagged, got %v", metric.Name, sample.Filename, metric.Conditions)
+			}
+			if metric.UnitInferred || metric.UnitNote != "" {
+				t.Errorf("Expected %s in %s left without an inferred unit, got %+v", metric.Name, sample.Filename, metric)
+			}
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// TestEmergencyCard tests building the card and opening it through a consented public link
+func TestEmergencyCard(t *testing.T) {
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	userRepo := models.NewUserRepository(db.GetDB())
+	user := &models.User{Email: "card@example.com", PasswordHash: "hash", FullName: "Card Holder", IsActive: true}
+	if err := userRepo.Create(user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	reportRepo := models.NewReportRepository(db.GetDB())
+	if _, err := services.NewDemoService(reportRepo).ProvisionSampleReports(user.ID); err != nil {
+		t.Fatalf("Failed to provision reports: %v", err)
+	}
+
+	// Glucose was critical and has since recovered; potassium is critical in the latest reading
+	for i, analysis := range []string{
+		`{"schema_version": 3, "health_metrics": [{"name": "Glucose", "value": 310, "unit": "mg/dL", "status": "critical"}]}`,
+		`{"schema_version": 3, "health_metrics": [{"name": "Glucose", "value": 98, "unit": "mg/dL", "status": "normal"},
+			{"name": "Potassium", "value": 6.4, "unit": "mmol/L", "status": "critical"}]}`,
+	} {
+		report := &models.Report{UserID: user.ID, OriginalFilename: fmt.Sprintf("bmp%d.txt", i), FilePath: "bmp.txt", FileType: "txt", FileSize: 10}
+		if err := reportRepo.Create(report); err != nil {
+			t.Fatalf("Failed to create report: %v", err)
+		}
+		reportRepo.UpdateProcessingStatus(report.ID, "completed", analysis)
+		reportDate := time.Date(2021+i, 1, 1, 0, 0, 0, 0, time.UTC)
+		reportRepo.UpdateDetails(report.ID, models.ReportDetails{ReportDate: &reportDate})
+	}
+
+	profileRepo := models.NewHealthProfileRepository(db.GetDB())
+	_, err = services.NewHealthProfileService(profileRepo).Update(user.ID, &models.HealthProfile{BloodGroup: "O-",
+		Conditions: []string{"Type 2 diabetes"}, Allergies: []string{"Penicillin"}, Medications: []string{"Metformin 500 mg"}})
+	if err != nil {
+		t.Fatalf("Failed to save profile: %v", err)
+	}
+	if _, err := services.NewHealthProfileService(profileRepo).Update(user.ID, &models.HealthProfile{BloodGroup: "Z+"}); err == nil {
+		t.Error("Expected an unknown blood group to be refused")
+	}
+	conditionRepo := models.NewUserConditionRepository(db.GetDB())
+	conditions := services.NewConditionService(conditionRepo, reportRepo)
+	conditions.Track(user.ID, "diabetes")
+	conditions.Track(user.ID, "thyroid")
+
+	cards := services.NewEmergencyCardService(userRepo, profileRepo, conditionRepo, reportRepo, models.NewEmergencyCardLinkRepository(db.GetDB()))
+	card, err := cards.Card(user)
+	if err != nil {
+		t.Fatalf("Failed to build card: %v", err)
+	}
+	if card.BloodGroup != "O-" || fmt.Sprint(card.Medications) != "[Metformin 500 mg]" || fmt.Sprint(card.Allergies) != "[Penicillin]" {
+		t.Errorf("Expected profile details on the card, got %+v", card)
+	}
+	if fmt.Sprint(card.Conditions) != "[Type 2 diabetes Thyroid disorder]" {
+		t.Errorf("Expected tracked conditions without duplicating the profile, got %v", card.Conditions)
+	}
+	if len(card.CriticalValues) != 1 || card.CriticalValues[0].Name != "Potassium" || card.CriticalValues[0].Value != "6.4" {
+		t.Errorf("Expected only the currently critical potassium, got %+v", card.CriticalValues)
+	}
+	if pdf := services.RenderEmergencyCardPDF(card); !bytes.HasPrefix(pdf, []byte("%PDF")) || !bytes.Contains(pdf, []byte("Penicillin")) {
+		t.Error("Expected a PDF listing the allergies")
+	}
+
+	// Public links work only while consented, and re-enabling invalidates the previous token
+	if link, _ := cards.PublicLink(user.ID); link != nil {
+		t.Error("Expected no public link before consent")
+	}
+	_, first, err := cards.EnablePublicLink(user.ID)
+	if err != nil {
+		t.Fatalf("Failed to enable public link: %v", err)
+	}
+	if opened, err := cards.OpenPublicLink(first); err != nil || opened.FullName != "Card Holder" {
+		t.Errorf("Expected the public link to open the card, got %+v (%v)", opened, err)
+	}
+	if link, _ := cards.PublicLink(user.ID); link == nil || link.LastViewedAt == nil {
+		t.Errorf("Expected the view to be recorded, got %+v", link)
+	}
+	_, second, _ := cards.EnablePublicLink(user.ID)
+	if _, err := cards.OpenPublicLink(first); err == nil {
+		t.Error("Expected the replaced token to stop working")
+	}
+	cards.DisablePublicLink(user.ID)
+	if _, err := cards.OpenPublicLink(second); err == nil {
+		t.Error("Expected the link to stop working once consent is withdrawn")
+	}
+
+	// HTTP: enable sharing, open the public card without a token, then withdraw consent
+	server := setupTestServer(t)
+	defer server.Close()
+	token := signupAndGetToken(t, server.URL, "card-http@example.com")
+
+	if status := doJSONRequest(t, "PUT", server.URL+"/api/health-profile", token, types.HealthProfileRequest{BloodGroup: "AB"}, nil); status != http.StatusBadRequest {
+		t.Errorf("Expected invalid blood group to be refused, got %d", status)
+	}
+	var own types.EmergencyCard
+	if status := doJSONRequest(t, "GET", server.URL+"/api/users/me/emergency-card", token, nil, &own); status != http.StatusOK ||
+		own.Sharing == nil || own.Sharing.Enabled {
+		t.Errorf("Expected a card with sharing off, got %d %+v", status, own)
+	}
+	var sharing types.EmergencyCardSharing
+	status := doJSONRequest(t, "PUT", server.URL+"/api/users/me/emergency-card/sharing", token, types.EmergencyCardSharingRequest{Enabled: true}, &sharing)
+	if status != http.StatusOK || !sharing.Enabled || sharing.Path == "" {
+		t.Fatalf("Expected sharing to be enabled with a path, got %d %+v", status, sharing)
+	}
+	var public types.EmergencyCard
+	if status := doJSONRequest(t, "GET", server.URL+sharing.Path, "", nil, &public); status != http.StatusOK || public.Sharing != nil {
+		t.Errorf("Expected the public card without sharing details, got %d %+v", status, public)
+	}
+	resp, err := http.Get(server.URL + sharing.Path + "?format=pdf")
+	if err != nil {
+		t.Fatalf("Failed to fetch PDF: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "application/pdf" {
+		t.Errorf("Expected a PDF, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	doJSONRequest(t, "PUT", server.URL+"/api/users/me/emergency-card/sharing", token, types.EmergencyCardSharingRequest{Enabled: false}, nil)
+	if status := doJSONRequest(t, "GET", server.URL+sharing.Path, "", nil, nil); status != http.StatusNotFound {
+		t.Errorf("Expected the withdrawn link to be not found, got %d", status)
+	}
+}
@@ -0,0 +1,124 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+)
+
+// fixedTranscriber hears the same question in every recording
+type fixedTranscriber struct {
+	text string
+}
+
+func (f fixedTranscriber) Transcribe(ctx context.Context, audio []byte, audioType, language string) (string, error) {
+	return f.text, nil
+}
+
+func (f fixedTranscriber) Name() string {
+	return "fixed"
+}
+
+// TestEventBus tests in-order delivery, subscriber isolation, and the notification and audit subscribers
+func TestEventBus(t *testing.T) {
+	bus := services.NewLocalEventBus(1)
+	var delivered []int
+	bus.Subscribe(services.EventReportUploaded, func(event services.Event) error {
+		return fmt.Errorf("subscriber down")
+	})
+	bus.Subscribe(services.EventReportUploaded, func(event services.Event) error {
+		if event.ReportID == 2 {
+			panic("bad subscriber")
+		}
+		return nil
+	})
+	bus.Subscribe(services.EventReportUploaded, func(event services.Event) error {
+		if event.OccurredAt.IsZero() {
+			t.Errorf("Expected event %d to be stamped", event.ReportID)
+		}
+		delivered = append(delivered, event.ReportID)
+		return nil
+	})
+	for id := 1; id <= 3; id++ {
+		bus.Publish(services.Event{Type: services.EventReportUploaded, UserID: 1, ReportID: id})
+	}
+	bus.Publish(services.Event{Type: services.EventChatCreated, UserID: 1, ReportID: 9})
+	bus.Close()
+	bus.Publish(services.Event{Type: services.EventReportUploaded, UserID: 1, ReportID: 4})
+
+	if fmt.Sprint(delivered) != "[1 2 3]" {
+		t.Errorf("Expected events 1-3 in order despite failing subscribers, got %v", delivered)
+	}
+
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	owner := &models.User{Email: "events@example.com", PasswordHash: "hash", FullName: "Owner", IsActive: true}
+	if err := models.NewUserRepository(db.GetDB()).Create(owner); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	uploadDir := t.TempDir()
+	filePath := filepath.Join(uploadDir, "cbc.txt")
+	os.WriteFile(filePath, []byte("Hemoglobin 13.5 g/dL"), 0o600)
+	reportRepo := models.NewReportRepository(db.GetDB())
+	report := &models.Report{UserID: owner.ID, OriginalFilename: "cbc.txt", FilePath: filePath, FileType: "text/plain",
+		FileSize: 20, ProcessingStatus: "pending", ReadingLevel: models.ReadingLevelStandard}
+	if err := reportRepo.Create(report); err != nil {
+		t.Fatalf("Failed to create report: %v", err)
+	}
+
+	notificationRepo := models.NewNotificationRepository(db.GetDB())
+	auditRepo := models.NewAuditLogRepository(db.GetDB())
+	bus = services.NewLocalEventBus(0)
+	services.SubscribeNotifications(bus, notificationRepo, reportRepo)
+	services.SubscribeAuditLog(bus, auditRepo)
+
+	processor := services.NewReportProcessorWithAnalyzer(reportRepo, nil, nil, nil, services.NewDemoAnalyzer(), services.NewFileStorage(uploadDir, "secret"))
+	processor.SetEventBus(bus)
+	if err := processor.ProcessReport(report); err != nil {
+		t.Fatalf("Failed to process report: %v", err)
+	}
+
+	chatService := services.NewChatService(models.NewChatMessageRepository(db.GetDB()), models.NewChatSummaryRepository(db.GetDB()),
+		reportRepo, nil, services.NewDemoAnalyzer(), fixedTranscriber{text: "Is my hemoglobin normal?"}, config.AIConfig{})
+	chatService.SetEventBus(bus)
+	message, err := chatService.AskByVoice(context.Background(), owner.ID, report.ID,
+		services.VoiceQuestion{Audio: webmHeader, AudioType: "audio/webm"}, models.ReadingLevelStandard, models.PlanFree)
+	if err != nil {
+		t.Fatalf("Failed to ask question: %v", err)
+	}
+	bus.Close()
+
+	notifications, err := notificationRepo.ListByUser(owner.ID, false, 20, 0)
+	if err != nil || len(notifications) != 1 || notifications[0].Kind != models.NotificationAnalysisReady {
+		t.Errorf("Expected one analysis-ready notification, got %+v (%v)", notifications, err)
+	}
+
+	entries, err := auditRepo.List(models.AuditLogFilter{UserID: owner.ID})
+	if err != nil {
+		t.Fatalf("Failed to list audit log: %v", err)
+	}
+	actions := map[string]string{}
+	for _, entry := range entries {
+		if entry.ActorID != owner.ID {
+			t.Errorf("Expected the owner as actor, got %+v", entry)
+		}
+		actions[entry.Action] = entry.Details
+	}
+	if len(actions) != 2 || actions[services.EventAnalysisCompleted] != fmt.Sprintf("report %d", report.ID) ||
+		actions[services.EventChatCreated] != fmt.Sprintf("report %d, message %d", report.ID, message.ID) {
+		t.Errorf("Expected the analysis and the question to be audited, got %v", actions)
+	}
+}
@@ -0,0 +1,147 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/worker"
+)
+
+// TestFileStorage tests per-user upload layout and the path traversal guard
+func TestFileStorage(t *testing.T) {
+	baseDir := t.TempDir()
+	storage := services.NewFileStorage(baseDir, "test-secret")
+
+	path, err := storage.NewFilePath(1, "Blood Test (final).PDF")
+	if err != nil {
+		t.Fatalf("Failed to create file path: %v", err)
+	}
+
+	// Decision: Original names never reach disk - only the extension survives
+	if strings.Contains(path, "Blood") || filepath.Ext(path) != ".pdf" {
+		t.Errorf("Expected random .pdf filename, got %s", path)
+	}
+	if filepath.Dir(path) != storage.UserDir(1) {
+		t.Errorf("Expected file under user directory %s, got %s", storage.UserDir(1), path)
+	}
+	if storage.UserDir(1) == storage.UserDir(2) {
+		t.Error("Users must not share an upload directory")
+	}
+	if len(filepath.Base(storage.UserDir(1))) != 32 {
+		t.Error("User directory name should be a hash, not the user ID")
+	}
+
+	if !storage.IsUserPath(1, path) || storage.IsUserPath(2, path) {
+		t.Error("IsUserPath should only match the owning user's directory")
+	}
+
+	// Traversal attempts are rejected before touching the filesystem
+	for _, unsafe := range []string{
+		filepath.Join(baseDir, "..", "etc", "passwd"),
+		"/etc/passwd",
+		baseDir,
+	} {
+		if _, err := storage.Resolve(unsafe); err != services.ErrUnsafeFilePath {
+			t.Errorf("Expected %q to be rejected, got %v", unsafe, err)
+		}
+	}
+
+	if err := os.WriteFile(path, []byte("data"), 0600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := storage.Remove(path); err != nil {
+		t.Errorf("Failed to remove stored file: %v", err)
+	}
+}
+
+// TestFileJanitor tests that queued files are removed and the queue drained
+func TestFileJanitor(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{
+			Driver: "sqlite3",
+			DSN:    ":memory:",
+		},
+	}
+
+	db, err := database.Setup(cfg)
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	baseDir := t.TempDir()
+	storage := services.NewFileStorage(baseDir, "test-secret")
+	cleanupRepo := models.NewFileCleanupRepository(db.GetDB())
+
+	path, _ := storage.NewFilePath(1, "old.pdf")
+	if err := os.WriteFile(path, []byte("%PDF-"), 0600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	// Decision: Missing files and paths outside the upload dir still complete
+	for _, p := range []string{path, filepath.Join(baseDir, "already-gone.pdf"), "/etc/passwd"} {
+		if err := cleanupRepo.Enqueue(p); err != nil {
+			t.Fatalf("Failed to enqueue: %v", err)
+		}
+	}
+
+	janitor := worker.NewJanitor(cleanupRepo, storage, time.Minute)
+	if completed := janitor.Sweep(); completed != 3 {
+		t.Fatalf("Expected 3 completed entries, got %d", completed)
+	}
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Expected queued file to be removed")
+	}
+	if _, err := os.Stat("/etc/passwd"); err != nil {
+		t.Error("Janitor must never touch files outside the upload directory")
+	}
+
+	remaining, _ := cleanupRepo.ListPending(10, 5)
+	if len(remaining) != 0 {
+		t.Errorf("Expected empty queue, got %d entries", len(remaining))
+	}
+}
+
+// TestOriginalFilename tests that uploaded names survive in any script and follow the filename policy
+func TestOriginalFilename(t *testing.T) {
+	storage := services.NewFileStorage(t.TempDir(), "test-secret")
+
+	cases := map[string]string{
+		"रक्त जांच रिपोर्ट.pdf":         "रक्त जांच रिपोर्ट.pdf",
+		"ರಕ್ತ ಪರೀಕ್ಷೆ.pdf":              "ರಕ್ತ ಪರೀಕ್ಷೆ.pdf",
+		"കര്‍ണ്ണന്‍.pdf":                "കര്‍ണ്ണന്‍.pdf", // Zero-width joiners are part of the spelling
+		`C:\Users\asha\Desktop\cbc.pdf`: "cbc.pdf",
+		"invoice\u202Efdp.exe":          "invoicefdp.exe",
+		"  \x00.pdf":                    "report.pdf",
+	}
+	for uploaded, want := range cases {
+		if got := storage.OriginalFilename(uploaded); got != want {
+			t.Errorf("OriginalFilename(%q) = %q, want %q", uploaded, got, want)
+		}
+	}
+
+	long := storage.OriginalFilename(strings.Repeat("रक्त", 40) + ".pdf")
+	if len(long) > 255 || !utf8.ValidString(long) || !strings.HasSuffix(long, ".pdf") {
+		t.Errorf("Expected a long name cut to 255 bytes on a character boundary, got %d bytes %q", len(long), long)
+	}
+
+	if err := storage.SetFilenamePolicy(services.FilenamePolicyExtensionOnly); err != nil {
+		t.Fatalf("Failed to set filename policy: %v", err)
+	}
+	if got := storage.OriginalFilename("Asha Rao HIV test.PDF"); got != "report.pdf" {
+		t.Errorf("Expected only the extension kept, got %q", got)
+	}
+	if err := storage.SetFilenamePolicy("ascii"); err == nil {
+		t.Error("Expected an unknown filename policy to be rejected")
+	}
+}
@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+// TestFileValidator tests config-driven extension, MIME, signature, and spoofing checks
+func TestFileValidator(t *testing.T) {
+	validator, err := services.NewFileValidator(config.UploadConfig{
+		MaxFileSize:       1024 * 1024,
+		AllowedExtensions: []string{".pdf", "txt"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+
+	pdfHeader := []byte("%PDF-1.7\n")
+
+	tests := []struct {
+		name        string
+		filename    string
+		contentType string
+		size        int64
+		header      []byte
+		wantErr     bool
+	}{
+		{"valid pdf", "report.pdf", "application/pdf", 100, pdfHeader, false},
+		{"text with charset", "notes.TXT", "text/plain; charset=utf-8", 10, []byte("Hemoglobin 13"), false},
+		{"extension not enabled", "scan.docx", "application/vnd.openxmlformats-officedocument.wordprocessingml.document", 100, []byte("PK\x03\x04"), true},
+		{"wrong content type", "report.pdf", "image/png", 100, pdfHeader, true},
+		{"bad signature", "report.pdf", "application/pdf", 100, []byte("MZ\x90\x00"), true},
+		{"binary text file", "notes.txt", "text/plain", 10, []byte{'a', 0, 'b'}, true},
+		{"too large", "report.pdf", "application/pdf", 2 * 1024 * 1024, pdfHeader, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.Validate(tt.filename, tt.contentType, tt.size, tt.header)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Decision: Spoofed uploads get the specific mismatch error, not a generic validation error
+	spoofs := []struct {
+		name        string
+		filename    string
+		contentType string
+		header      []byte
+	}{
+		{"executable renamed to pdf", "report.pdf", "application/pdf", []byte("MZ\x90\x00\x03\x00")},
+		{"elf renamed to txt", "notes.txt", "text/plain", []byte("\x7fELF\x02\x01\x01")},
+		{"script renamed to txt", "notes.txt", "text/plain", []byte("#!/bin/sh\nrm -rf /\n")},
+		{"pdf claimed as text", "report.pdf", "text/plain", pdfHeader},
+		{"html renamed to pdf", "report.pdf", "application/pdf", []byte("<html><body>%PDF-</body></html>")},
+	}
+	for _, tt := range spoofs {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.Validate(tt.filename, tt.contentType, 100, tt.header)
+			if err != errors.ErrFileTypeMismatch {
+				t.Errorf("Expected ErrFileTypeMismatch, got %v", err)
+			}
+		})
+	}
+
+	// Decision: Misconfigured deployments must fail fast
+	if _, err := services.NewFileValidator(config.UploadConfig{AllowedExtensions: []string{".exe"}}); err == nil {
+		t.Error("Expected unknown extension to be rejected")
+	}
+
+	// Legacy Word files are refused with advice, even by a deployment that still enables them
+	docValidator, err := services.NewFileValidator(config.UploadConfig{AllowedExtensions: []string{".docx", ".doc"}})
+	if err != nil {
+		t.Fatalf("Failed to create doc validator: %v", err)
+	}
+	err = docValidator.Validate("old.doc", "application/msword", 100, []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1})
+	if appErr, ok := err.(*errors.AppError); !ok || !strings.Contains(appErr.Message, ".docx or PDF") {
+		t.Errorf("Expected .doc refused with advice, got %v", err)
+	}
+
+	// Images can be enabled purely through config
+	imageValidator, err := services.NewFileValidator(config.UploadConfig{AllowedExtensions: []string{".png"}})
+	if err != nil {
+		t.Fatalf("Failed to create image validator: %v", err)
+	}
+	if err := imageValidator.Validate("xray.png", "image/png", 100, []byte("\x89PNG\r\n\x1a\n")); err != nil {
+		t.Errorf("Expected png to be accepted: %v", err)
+	}
+}
@@ -0,0 +1,192 @@
+// Package fixtures holds synthetic lab reports in the layouts real labs print, for extraction and analysis tests
+//
+//	for _, report := range fixtures.Reports {
+//		path, err := report.Save(t.TempDir())
+//		...
+//	}
+//
+// Every patient, doctor, and lab named in them is made up. Each report comes with the values a reader must find
+// in its text and a recorded model reply, so a change anywhere from extraction to parsing shows up as a failing
+// fixture rather than a worse analysis in production. A new layout that broke the pipeline belongs here.
+package fixtures
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/pdfgen"
+)
+
+//go:embed reports
+var files embed.FS
+
+// Result is one value the report prints
+type Result struct {
+	Analyte string // As printed
+	Value   string // As printed
+	Unit    string // The unit the stored analysis should carry, as the model gave it or inferred; empty for neither
+}
+
+// Report is one synthetic lab report
+type Report struct {
+	Name    string   // File name, whose extension picks the extractor
+	Layout  string   // What about the layout is worth covering
+	Pages   int      // Pages the extractor should find; 0 for plain text
+	Panels  []string // Completeness panel keys its analysis should detect, in catalog order
+	Results []Result // In the order the model reply lists them
+}
+
+// Reports is every fixture
+var Reports = []Report{
+	{
+		Name:   "cbc_columns.txt",
+		Layout: "Fixed-width columns with flags between value and unit, Indian units (lakhs/cu.mm)",
+		Panels: []string{"cbc"},
+		Results: []Result{
+			{"Haemoglobin", "11.2", "g/dL"},
+			{"Total Leucocyte Count (TLC)", "7800", "cells/µL"},
+			{"RBC Count", "4.12", "million/µL"},
+			{"Packed Cell Volume (PCV)", "34.6", "%"},
+			{"MCV", "84.0", "fL"},
+			{"MCH", "27.2", "pg"},
+			{"MCHC", "32.4", "g/dL"},
+			{"Platelet Count", "2.45", "lakhs/cu.mm"},
+		},
+	},
+	{
+		Name:   "cbc_narrative.pdf",
+		Layout: "Patient portal printout: each analyte a bold heading with its value on the next line",
+		Pages:  1,
+		Panels: []string{"cbc"},
+		Results: []Result{
+			{"Hemoglobin", "14.1", "g/dL"},
+			{"Hematocrit", "42.3", "%"},
+			{"WBC", "11.8", "x10E3/uL"},
+			{"RBC", "4.71", "x10E6/uL"},
+			{"MCV", "89.8", "fL"},
+			{"MCH", "29.9", "pg"},
+			{"MCHC", "33.3", "g/dL"},
+			{"Platelets", "262", "x10E3/uL"},
+			{"Neutrophils (Absolute)", "8.9", "x10E3/uL"},
+		},
+	},
+	{
+		Name:   "lipid_profile.pdf",
+		Layout: "Table under a section heading with derived values and a ratio",
+		Pages:  1,
+		Panels: []string{"lipid"},
+		Results: []Result{
+			{"Cholesterol, Total", "232", "mg/dL"},
+			{"Triglycerides", "188", "mg/dL"},
+			{"HDL Cholesterol", "41", "mg/dL"},
+			{"LDL Cholesterol (Calculated)", "153.4", "mg/dL"},
+			{"VLDL Cholesterol", "37.6", "mg/dL"},
+			{"Non-HDL Cholesterol", "191", "mg/dL"},
+			{"Total Cholesterol / HDL Ratio", "5.66", "ratio"},
+		},
+	},
+	{
+		Name:   "lipid_mmol.txt",
+		Layout: "UK report in mmol/L with bracketed ranges; the model reply leaves every unit out",
+		Panels: []string{"lipid"},
+		Results: []Result{
+			{"Total cholesterol", "6.2", "mmol/L"},
+			{"HDL cholesterol", "1.1", "mmol/L"},
+			{"LDL cholesterol", "4.3", "mmol/L"},
+			{"Triglycerides", "1.8", "mmol/L"},
+			{"Non-HDL cholesterol", "5.1", ""},
+			{"Total cholesterol/HDL ratio", "5.6", ""},
+		},
+	},
+	{
+		Name:   "thyroid_pipes.txt",
+		Layout: "Pipe-delimited export from a lab system, with µ in a unit",
+		Panels: []string{"thyroid"},
+		Results: []Result{
+			{"TSH (Ultrasensitive)", "6.84", "µIU/mL"},
+			{"Free T4 (FT4)", "0.92", "ng/dL"},
+			{"Free T3 (FT3)", "2.9", "pg/mL"},
+		},
+	},
+	{
+		Name:   "kft_inline.txt",
+		Layout: "Values inside discharge summary sentences, measured twice",
+		Panels: []string{"kidney"},
+		Results: []Result{
+			{"Blood urea", "58", "mg/dL"},
+			{"serum creatinine", "1.9", "mg/dL"},
+			{"eGFR", "38", "mL/min/1.73m2"},
+			{"sodium", "133", "mmol/L"},
+			{"potassium", "5.4", "mmol/L"},
+		},
+	},
+	{
+		Name:   "lft_kft.pdf",
+		Layout: "Two panels over two pages with long notes and a footer on each page",
+		Pages:  2,
+		Panels: []string{"kidney", "liver"},
+		Results: []Result{
+			{"Bilirubin - Total", "1.4", "mg/dL"},
+			{"SGOT / AST", "48", "U/L"},
+			{"SGPT / ALT", "67", "U/L"},
+			{"Alkaline Phosphatase (ALP)", "112", "U/L"},
+			{"Albumin", "4.1", "g/dL"},
+			{"Blood Urea", "32", "mg/dL"},
+			{"Serum Creatinine", "1.1", "mg/dL"},
+			{"eGFR (CKD-EPI 2021)", "83", "mL/min/1.73m2"},
+		},
+	},
+}
+
+// Bytes returns the report file
+// Decision: PDFs are rendered from a text source with pkg/pdfgen when read, so a fixture's layout is reviewed as a
+// text diff; in the source "# " starts a heading, "## " a bold line, "> " the page footer, and a blank line a gap
+func (r Report) Bytes() ([]byte, error) {
+	if path.Ext(r.Name) != ".pdf" {
+		return files.ReadFile("reports/" + r.Name)
+	}
+
+	source, err := files.ReadFile("reports/" + r.Name + ".txt")
+	if err != nil {
+		return nil, err
+	}
+	doc := pdfgen.New()
+	for _, line := range strings.Split(strings.TrimRight(string(source), "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "# "):
+			doc.Heading(strings.TrimPrefix(line, "# "))
+		case strings.HasPrefix(line, "## "):
+			doc.Label(strings.TrimPrefix(line, "## "))
+		case strings.HasPrefix(line, "> "):
+			doc.SetFooter(strings.TrimPrefix(line, "> "))
+		case line == "":
+			doc.Spacer()
+		default:
+			doc.Paragraph(line)
+		}
+	}
+	return doc.Bytes(), nil
+}
+
+// ModelReply returns the reply a model gave when asked to analyze the report, quirks and all
+func (r Report) ModelReply() (string, error) {
+	reply, err := files.ReadFile("reports/" + strings.TrimSuffix(r.Name, path.Ext(r.Name)) + ".model.txt")
+	return string(reply), err
+}
+
+// Save writes the report file into dir and returns its path
+func (r Report) Save(dir string) (string, error) {
+	data, err := r.Bytes()
+	if err != nil {
+		return "", fmt.Errorf("failed to read fixture %s: %w", r.Name, err)
+	}
+	filePath := filepath.Join(dir, r.Name)
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write fixture %s: %w", r.Name, err)
+	}
+	return filePath, nil
+}
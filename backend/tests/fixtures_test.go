@@ -0,0 +1,153 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/tests/fixtures"
+)
+
+// TestFixtureExtraction tests that every fixture's text comes out readable, in English, and with each printed
+// value still on its analyte's line or the next
+func TestFixtureExtraction(t *testing.T) {
+	extractor := services.NewTextExtractor(config.ExtractionConfig{})
+	for _, report := range fixtures.Reports {
+		t.Run(report.Name, func(t *testing.T) {
+			path, err := report.Save(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			extraction, err := extractor.Extract(context.Background(), path)
+			if err != nil {
+				t.Fatalf("Failed to extract %s (%s): %v", report.Name, report.Layout, err)
+			}
+			if extraction.Pages != report.Pages || extraction.Language != "en" {
+				t.Errorf("Expected %d pages in English, got %d in %q", report.Pages, extraction.Pages, extraction.Language)
+			}
+
+			lines := strings.Split(extraction.Text, "\n")
+			for _, result := range report.Results {
+				if !valueFollowsAnalyte(lines, result) {
+					t.Errorf("Expected %s %s on its line or the next in:\n%s", result.Analyte, result.Value, extraction.Text)
+				}
+			}
+		})
+	}
+}
+
+// valueFollowsAnalyte reports whether a line holding the analyte has its value after it, on the line or the next
+func valueFollowsAnalyte(lines []string, result fixtures.Result) bool {
+	for i, line := range lines {
+		at := strings.Index(line, result.Analyte)
+		if at < 0 {
+			continue
+		}
+		rest := line[at+len(result.Analyte):]
+		if i+1 < len(lines) {
+			rest += "\n" + lines[i+1]
+		}
+		if strings.Contains(rest, result.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+// TestFixtureAnalysis tests that every fixture's text reaches the model and its recorded reply is stored with the
+// values, units, and panels the report holds
+func TestFixtureAnalysis(t *testing.T) {
+	var prompt, reply string
+	modelServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if len(req.Messages) > 0 {
+			prompt = req.Messages[len(req.Messages)-1].Content
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{"message": map[string]string{"role": "assistant", "content": reply}}},
+		})
+	}))
+	defer modelServer.Close()
+
+	aiService, err := services.NewAIService(config.AIConfig{
+		Provider:    "ollama",
+		OllamaURL:   modelServer.URL + "/",
+		OllamaModel: "llama3.1",
+		PromptPath:  "does-not-exist.txt",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create AI service: %v", err)
+	}
+	defer aiService.Close()
+
+	for _, report := range fixtures.Reports {
+		t.Run(report.Name, func(t *testing.T) {
+			path, err := report.Save(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reply, err = report.ModelReply(); err != nil {
+				t.Fatalf("Fixture has no model reply: %v", err)
+			}
+
+			analysis, err := aiService.AnalyzeReport(context.Background(), path, "", "standard", models.PlanFree, "")
+			if err != nil || analysis.ParseFailed {
+				t.Fatalf("Failed to analyze %s: %v %+v", report.Name, err, analysis)
+			}
+			for _, result := range report.Results {
+				if !strings.Contains(prompt, result.Analyte) || !strings.Contains(prompt, result.Value) {
+					t.Errorf("Expected %s %s in the prompt", result.Analyte, result.Value)
+				}
+			}
+
+			stored, err := services.ParseStoredAnalysis(analysis.ResultJSON)
+			if err != nil {
+				t.Fatalf("Failed to read stored analysis: %v", err)
+			}
+			if len(stored.HealthMetrics) != len(report.Results) {
+				t.Fatalf("Expected %d metrics, got %d", len(report.Results), len(stored.HealthMetrics))
+			}
+			for i, result := range report.Results {
+				metric := stored.HealthMetrics[i]
+				want, _ := strconv.ParseFloat(result.Value, 64)
+				if got, ok := metricNumber(metric.Value); !ok || got != want || metric.Unit != result.Unit {
+					t.Errorf("Expected %s %s %q, got %s %v %q", result.Analyte, result.Value, result.Unit, metric.Name, metric.Value, metric.Unit)
+				}
+			}
+
+			var panels []string
+			if stored.Completeness != nil {
+				for _, panel := range stored.Completeness.Panels {
+					panels = append(panels, panel.Key)
+				}
+			}
+			if strings.Join(panels, ",") != strings.Join(report.Panels, ",") {
+				t.Errorf("Expected panels %v detected, got %v", report.Panels, panels)
+			}
+		})
+	}
+}
+
+// metricNumber reads a metric value the model may have given as a number or a string
+func metricNumber(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case string:
+		n, err := strconv.ParseFloat(v, 64)
+		return n, err == nil
+	}
+	return 0, false
+}
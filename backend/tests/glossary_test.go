@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// countingDefiner records which terms needed a model call
+type countingDefiner struct {
+	calls []string
+}
+
+func (cd *countingDefiner) DefineTerm(term string) (string, error) {
+	cd.calls = append(cd.calls, term)
+	if term == "Banana" {
+		return "", errors.ErrGlossaryTermUnknown
+	}
+	return "A test definition of " + term + ".", nil
+}
+
+// TestGlossary tests definition lookup, storage on first miss, and summary annotation
+func TestGlossary(t *testing.T) {
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	definer := &countingDefiner{}
+	glossary := services.NewGlossaryService(models.NewGlossaryRepository(db.GetDB()), definer)
+
+	// Built-in terms never reach the model
+	hdl, err := glossary.Lookup(" hdl ")
+	if err != nil || hdl.Source != models.GlossarySourceBuiltin || hdl.Key != "hdl" {
+		t.Fatalf("Expected built-in HDL definition, got %+v: %v", hdl, err)
+	}
+
+	// Unknown terms are generated once, then served from the store under any spelling
+	for _, spelling := range []string{"Ferritin", "FERRITIN", "ferritin"} {
+		entry, err := glossary.Lookup(spelling)
+		if err != nil || entry.Source != models.GlossarySourceAI || entry.Term != "Ferritin" {
+			t.Fatalf("Unexpected lookup of %q: %+v %v", spelling, entry, err)
+		}
+	}
+	if len(definer.calls) != 1 {
+		t.Errorf("Expected one model call for ferritin, got %q", definer.calls)
+	}
+
+	if _, err := glossary.Lookup("Banana"); err != errors.ErrGlossaryTermUnknown {
+		t.Errorf("Expected unknown term error, got %v", err)
+	}
+	for _, invalid := range []string{"", "<script>", "HDL; DROP TABLE users"} {
+		if _, err := glossary.Lookup(invalid); err == nil {
+			t.Errorf("Expected validation error for %q", invalid)
+		}
+	}
+
+	if _, err := services.NewGlossaryService(models.NewGlossaryRepository(db.GetDB()), nil).Lookup("Lipase"); err != errors.ErrAIUnavailable {
+		t.Errorf("Expected AI unavailable without a definer, got %v", err)
+	}
+
+	// Annotations keep the summary's spelling and only link whole words
+	refs := services.AnnotateGlossaryTerms("Your LDL and HbA1c are high; watch the salt.", []string{"HbA1c", "Not mentioned"})
+	if len(refs) != 2 || refs[0] != (services.GlossaryRef{Term: "LDL", Key: "ldl"}) || refs[1].Key != "hba1c" {
+		t.Errorf("Unexpected annotations %+v", refs)
+	}
+
+	// Older analyses gain references when upgraded
+	analysis, err := services.ParseStoredAnalysis(`{"schema_version": 1, "simple_summary": "Your triglycerides are fine."}`)
+	if err != nil || len(analysis.GlossaryTerms) != 1 || analysis.GlossaryTerms[0].Term != "triglycerides" {
+		t.Errorf("Expected upgraded analysis with a triglycerides reference, got %+v: %v", analysis, err)
+	}
+
+	// HTTP endpoint
+	server := setupTestServer(t)
+	defer server.Close()
+	token := signupAndGetToken(t, server.URL, "glossary@example.com")
+
+	var entry types.GlossaryEntry
+	if status := doJSONRequest(t, "GET", server.URL+"/api/glossary?term=TSH", token, nil, &entry); status != http.StatusOK || entry.Key != "tsh" || entry.Definition == "" {
+		t.Errorf("Expected TSH definition, got %d %+v", status, entry)
+	}
+	if status := doJSONRequest(t, "GET", server.URL+"/api/glossary?term="+url.QueryEscape("Vitamin D"), token, nil, &entry); status != http.StatusOK || entry.Source != models.GlossarySourceAI {
+		t.Errorf("Expected generated definition, got %d %+v", status, entry)
+	}
+	if status := doJSONRequest(t, "GET", server.URL+"/api/glossary", token, nil, nil); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 without a term, got %d", status)
+	}
+	if status := doJSONRequest(t, "GET", server.URL+"/api/glossary?term=HDL", "", nil, nil); status != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without auth, got %d", status)
+	}
+}
@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// TestHealthProfile tests saving a health profile and passing it to chat and calculators
+func TestHealthProfile(t *testing.T) {
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	user := &models.User{Email: "profile@example.com", PasswordHash: "hash", FullName: "Profile", IsActive: true}
+	if err := models.NewUserRepository(db.GetDB()).Create(user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	reportRepo := models.NewReportRepository(db.GetDB())
+	reports, err := services.NewDemoService(reportRepo).ProvisionSampleReports(user.ID)
+	if err != nil {
+		t.Fatalf("Failed to provision reports: %v", err)
+	}
+
+	profileRepo := models.NewHealthProfileRepository(db.GetDB())
+	profiles := services.NewHealthProfileService(profileRepo)
+	dob := time.Date(1970, 3, 15, 0, 0, 0, 0, time.UTC)
+	height := 165.0
+	saved, err := profiles.Update(user.ID, &models.HealthProfile{DateOfBirth: &dob, Sex: models.SexFemale, HeightCm: &height,
+		Conditions: []string{" Hypertension ", "hypertension", ""}, Allergies: []string{"Penicillin"}})
+	if err != nil {
+		t.Fatalf("Failed to save profile: %v", err)
+	}
+	if len(saved.Conditions) != 1 || saved.Conditions[0] != "Hypertension" {
+		t.Errorf("Expected conditions trimmed and deduplicated, got %q", saved.Conditions)
+	}
+
+	context := services.PatientContext(saved, time.Date(2024, 3, 14, 0, 0, 0, 0, time.UTC))
+	if context != "age 53; sex female; height 165 cm; known conditions: Hypertension; allergies: Penicillin" {
+		t.Errorf("Unexpected patient context %q", context)
+	}
+	if services.PatientContext(nil, time.Now()) != "" {
+		t.Error("Expected no context without a profile")
+	}
+
+	for name, profile := range map[string]*models.HealthProfile{
+		"unknown sex":   {Sex: "unknown"},
+		"future birth":  {DateOfBirth: func() *time.Time { d := time.Now().AddDate(1, 0, 0); return &d }()},
+		"absurd height": {HeightCm: func() *float64 { h := 900.0; return &h }()},
+		"long allergy":  {Allergies: []string{strings.Repeat("a", 101)}},
+	} {
+		if _, err := profiles.Update(user.ID, profile); err == nil {
+			t.Errorf("Expected %s to be refused", name)
+		}
+	}
+
+	// Chat answers see the profile
+	chatRepo := models.NewChatMessageRepository(db.GetDB())
+	message := &models.ChatMessage{ReportID: reports[0].ID, UserMessage: "Is this bad?", AIResponse: "old"}
+	if err := chatRepo.Create(message); err != nil {
+		t.Fatalf("Failed to create chat message: %v", err)
+	}
+	responder := &recordingResponder{}
+	chatService := services.NewChatService(chatRepo, models.NewChatSummaryRepository(db.GetDB()), reportRepo, profileRepo,
+		responder, nil, config.AIConfig{})
+	if _, err := chatService.RegenerateMessage(user.ID, message.ID, models.ReadingLevelStandard); err != nil {
+		t.Fatalf("Failed to regenerate message: %v", err)
+	}
+	if !strings.Contains(responder.patient, "allergies: Penicillin") {
+		t.Errorf("Expected the profile in the chat context, got %q", responder.patient)
+	}
+
+	// Calculators take age, sex, and height from the profile
+	weight := 70.0
+	result, err := services.NewCalculatorService(reportRepo, profileRepo).Calculate(user.ID, services.CalculatorBMI,
+		services.CalculatorInputs{WeightKg: &weight})
+	if err != nil || result.Value == nil || *result.Value != 25.7 {
+		t.Errorf("Expected BMI from the profile's height, got %+v (%v)", result, err)
+	}
+
+	// HTTP
+	server := setupTestServer(t)
+	defer server.Close()
+	token := signupAndGetToken(t, server.URL, "profile-http@example.com")
+
+	var profile types.HealthProfile
+	if status := doJSONRequest(t, "GET", server.URL+"/api/health-profile", token, nil, &profile); status != http.StatusOK || profile.Age != nil {
+		t.Errorf("Expected an empty profile, got %d %+v", status, profile)
+	}
+	birth := "1980-01-01"
+	status := doJSONRequest(t, "PUT", server.URL+"/api/health-profile", token,
+		types.HealthProfileRequest{DateOfBirth: &birth, Sex: "male", Conditions: []string{"type 2 diabetes"}}, &profile)
+	if status != http.StatusOK || profile.Age == nil || profile.Sex != "male" || *profile.DateOfBirth != birth {
+		t.Errorf("Expected the saved profile, got %d %+v", status, profile)
+	}
+	badDate := "01/01/1980"
+	if status := doJSONRequest(t, "PUT", server.URL+"/api/health-profile", token, types.HealthProfileRequest{DateOfBirth: &badDate}, nil); status != http.StatusBadRequest {
+		t.Errorf("Expected a malformed date to be refused, got %d", status)
+	}
+	if status := doJSONRequest(t, "DELETE", server.URL+"/api/health-profile", token, nil, nil); status != http.StatusOK {
+		t.Errorf("Expected the profile to be cleared, got %d", status)
+	}
+	profile = types.HealthProfile{}
+	if doJSONRequest(t, "GET", server.URL+"/api/health-profile", token, nil, &profile); profile.Sex != "" || len(profile.Conditions) != 0 {
+		t.Errorf("Expected an empty profile after clearing, got %+v", profile)
+	}
+}
@@ -2,9 +2,15 @@ package tests
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/textproto"
+	"strings"
 	"testing"
 	"time"
 
@@ -15,16 +21,41 @@ import (
 	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
 	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/router"
 	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/client"
 	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
 )
 
 // setupTestServer creates a test HTTP server with all dependencies
 func setupTestServer(t *testing.T) *httptest.Server {
+	return setupTestServerWith(t, testPlans, nil)
+}
+
+// setupTestServerWith creates a test HTTP server with the given plans and payment provider; payments may be nil
+func setupTestServerWith(t *testing.T, plans map[string]config.PlanConfig, payments services.PaymentProvider) *httptest.Server {
+	return newTestServer(t, testServerOptions{plans: plans, payments: payments})
+}
+
+// testServerOptions varies the stack newTestServer builds
+type testServerOptions struct {
+	dsn          string // Defaults to an in-memory database
+	plans        map[string]config.PlanConfig
+	payments     services.PaymentProvider // May be nil
+	demoReports  bool                     // Give each new account the demo sample reports, already analyzed
+	promptCanary bool                     // Deploy a canary prompt for the admin endpoints to compare and end
+}
+
+// newTestServer creates a test HTTP server with all dependencies
+func newTestServer(t *testing.T, opts testServerOptions) *httptest.Server {
 	// Decision: Use in-memory database for isolated integration tests
+	dsn := opts.dsn
+	if dsn == "" {
+		dsn = ":memory:"
+	}
+	plans, payments := opts.plans, opts.payments
 	cfg := &config.Config{
 		Database: config.DatabaseConfig{
 			Driver: "sqlite3",
-			DSN:    ":memory:",
+			DSN:    dsn,
 		},
 		JWT: config.JWTConfig{
 			Secret:     "test-secret-key-for-integration-tests",
@@ -44,27 +75,153 @@ func setupTestServer(t *testing.T) *httptest.Server {
 	// Decision: Initialize all application layers
 	userRepo := models.NewUserRepository(db.GetDB())
 	reportRepo := models.NewReportRepository(db.GetDB())
+	profileRepo := models.NewHealthProfileRepository(db.GetDB())
 	passwordService := services.NewPasswordServiceWithCost(4) // Faster for tests
 	jwtService := services.NewJWTService(cfg.JWT.Secret, cfg.JWT.Expiration)
 	authService := services.NewAuthService(userRepo, passwordService, jwtService)
+	authService.SetTokenRevocation(models.NewRevokedTokenRepository(db.GetDB()))
+	authService.SetRefreshTokens(models.NewRefreshTokenRepository(db.GetDB()), 30*24*time.Hour)
+	if opts.demoReports {
+		demoService := services.NewDemoService(reportRepo)
+		authService.AddSignupHook(func(user *models.User) {
+			if _, err := demoService.ProvisionSampleReports(user.ID); err != nil {
+				t.Errorf("Failed to provision demo reports for user %d: %v", user.ID, err)
+			}
+		})
+	}
 
 	// Initialize AI service (can be nil for auth tests)
 	var aiService *services.AIService
 
-	authHandler := handlers.NewAuthHandler(authService)
-	reportHandler := handlers.NewReportHandler(reportRepo, authService, aiService, "/tmp/test_uploads", 20971520)
-	authMiddleware := middleware.NewAuthMiddleware(authService)
+	apiKeyService := services.NewAPIKeyService(models.NewAPIKeyRepository(db.GetDB()), userRepo)
+	authHandler := handlers.NewAuthHandler(authService, nil)
+	authHandler.SetAPIKeyService(apiKeyService)
+	fileValidator, err := services.NewFileValidator(config.UploadConfig{
+		MaxFileSize:       20971520,
+		AllowedExtensions: []string{".pdf", ".txt", ".docx"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create file validator: %v", err)
+	}
+	reportHandler := handlers.NewReportHandler(reportRepo, authService, aiService, fileValidator, services.NewFileStorage("/tmp/test_uploads", "test-secret"), 20971520, false)
+	auditRepo := models.NewAuditLogRepository(db.GetDB())
+	notificationRepo := models.NewNotificationRepository(db.GetDB())
+	safetyRepo := models.NewSafetyInterventionRepository(db.GetDB())
+	crisisRepo := models.NewCrisisFlagRepository(db.GetDB())
+	jobService := services.NewJobService(reportRepo, models.NewJobRepository(db.GetDB()), auditRepo, time.Minute)
+	reportHandler.SetJobService(jobService)
+	reportHandler.SetPartService(services.NewReportPartService(reportRepo, models.NewReportPartRepository(db.GetDB()), 10*time.Minute))
+	reportHandler.SetClaimPackageService(services.NewClaimPackageService(reportRepo, models.NewReportPartRepository(db.GetDB()),
+		models.NewClaimPackageRepository(db.GetDB()), services.NewFileStorage("/tmp/test_uploads", "test-secret")))
+	reportHandler.SetAnalysisImportService(services.NewAnalysisImportService(reportRepo, auditRepo))
+	reportHandler.SetSyncService(services.NewSyncService(reportRepo, models.NewChatMessageRepository(db.GetDB()),
+		models.NewTombstoneRepository(db.GetDB())))
+	// Decision: No bus reaches the stream in tests, so it polls often enough to keep them quick
+	reportHandler.SetReportStream(services.NewReportStreamService(reportRepo, 50*time.Millisecond))
+	adminHandler := handlers.NewAdminHandler(reportRepo, auditRepo, models.NewAPIUsageRepository(db.GetDB()), safetyRepo, crisisRepo, services.NewImpersonationService(
+		userRepo, auditRepo, notificationRepo, jwtService, 15*time.Minute, []string{"admin@example.com"}),
+		jobService,
+		services.NewReviewService(reportRepo, models.NewAnalysisReviewRepository(db.GetDB()), auditRepo))
+	callRecorder, err := services.NewAICallRecorder(models.NewAICallRepository(db.GetDB()), testCallLog)
+	if err != nil {
+		t.Fatalf("Failed to create AI call recorder: %v", err)
+	}
+	provenanceService := services.NewProvenanceService(reportRepo, auditRepo, callRecorder)
+	adminHandler.SetProvenanceService(provenanceService)
+	adminHandler.SetAPIKeyService(apiKeyService)
+	if opts.promptCanary {
+		canaries := services.NewPromptCanaryService(models.NewPromptCanaryRepository(db.GetDB()), reportRepo, auditRepo, config.AIConfig{
+			PromptVersion: "v1",
+			Canary:        config.PromptCanaryConfig{Version: "v2", Percent: 10, MinSamples: 3},
+		})
+		if _, err := canaries.Start(); err != nil {
+			t.Fatalf("Failed to start prompt canary: %v", err)
+		}
+		adminHandler.SetPromptCanaryService(canaries)
+	}
+	adminHandler.SetReanalysisService(services.NewReanalysisService(models.NewReanalysisRepository(db.GetDB()), reportRepo, auditRepo,
+		services.DemoPromptVersion, "demo"))
+	transferHandler := handlers.NewTransferHandler(services.NewTransferService(
+		models.NewReportTransferRepository(db.GetDB()), reportRepo, userRepo))
+	brandingService := services.NewBrandingService(models.NewOrganizationRepository(db.GetDB()), userRepo)
+	reportHandler.SetReportAccess(services.NewReportAccessService(models.NewOrganizationRepository(db.GetDB()), reportRepo))
+	planService := services.NewPlanService(userRepo, models.NewChatMessageRepository(db.GetDB()), auditRepo, plans)
+	chatService := services.NewChatService(models.NewChatMessageRepository(db.GetDB()),
+		models.NewChatSummaryRepository(db.GetDB()), reportRepo, profileRepo, services.NewDemoAnalyzer(), nil, config.AIConfig{})
+	chatService.SetSafetyLog(safetyRepo)
+	crisisService, err := services.NewCrisisService(crisisRepo, userRepo, config.ChatSafetyConfig{DefaultCountry: "IN"})
+	if err != nil {
+		t.Fatalf("Failed to create crisis service: %v", err)
+	}
+	chatService.SetCrisisService(crisisService)
+	chatHandler := handlers.NewChatHandler(chatService, brandingService, planService, 0)
+	moderationService := services.NewModerationService(models.NewModerationRepository(db.GetDB()), models.NewChatMessageRepository(db.GetDB()),
+		reportRepo, models.NewChatSummaryRepository(db.GetDB()), userRepo, auditRepo, []string{"admin@example.com"})
+	chatHandler.SetModerationService(moderationService)
+	adminHandler.SetModerationService(moderationService)
+	adminHandler.SetLegalHoldService(services.NewLegalHoldService(models.NewLegalHoldRepository(db.GetDB()), auditRepo))
+	authMiddleware := middleware.NewAuthMiddleware(authService, []string{"admin@example.com"}, auditRepo)
+	authMiddleware.SetAPIKeyService(apiKeyService)
+
+	populationService, err := services.NewPopulationService(models.NewPopulationRepository(db.GetDB()), reportRepo, profileRepo,
+		config.InsightsConfig{MinCohort: 5, RefreshInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("Failed to create population service: %v", err)
+	}
 
 	// Decision: Create router with all endpoints
-	rt := router.NewRouter(authHandler, reportHandler, authMiddleware)
+	rt := router.NewRouter(authHandler, reportHandler, adminHandler, transferHandler, chatHandler,
+		handlers.NewNotificationHandler(notificationRepo),
+		handlers.NewGlossaryHandler(services.NewGlossaryService(models.NewGlossaryRepository(db.GetDB()), services.NewDemoAnalyzer())),
+		handlers.NewSummaryAudioHandler(reportRepo, services.NewSummaryAudioService(nil, t.TempDir())),
+		handlers.NewShareHandler(services.NewShareService(models.NewShareLinkRepository(db.GetDB()),
+			reportRepo, notificationRepo, passwordService, config.ShareConfig{})),
+		handlers.NewOrganizationHandler(brandingService,
+			services.NewOrganizationUsageService(models.NewOrganizationUsageRepository(db.GetDB()))),
+		handlers.NewAnalysisHandler(services.NewMergedAnalysisService(models.NewMergedAnalysisRepository(db.GetDB()),
+			reportRepo, services.NewDemoAnalyzer()),
+			services.NewAnnualReviewService(models.NewAnnualReviewRepository(db.GetDB()), reportRepo, services.NewDemoAnalyzer()),
+			planService),
+		handlers.NewCalculatorHandler(services.NewCalculatorService(reportRepo, profileRepo)),
+		handlers.NewHealthProfileHandler(services.NewHealthProfileService(profileRepo)),
+		handlers.NewConditionHandler(services.NewConditionService(models.NewUserConditionRepository(db.GetDB()), reportRepo)),
+		handlers.NewEmergencyCardHandler(services.NewEmergencyCardService(userRepo, profileRepo,
+			models.NewUserConditionRepository(db.GetDB()), reportRepo, models.NewEmergencyCardLinkRepository(db.GetDB()))),
+		handlers.NewPrescriptionHandler(services.NewPrescriptionService(models.NewPrescriptionRepository(db.GetDB()),
+			services.NewDemoAnalyzer(), services.NewFileStorage(t.TempDir(), "test-secret"), 0.7), 0),
+		handlers.NewWidgetHandler(services.NewWidgetService(cfg.JWT.Secret, reportRepo, userRepo, config.WidgetConfig{})),
+		handlers.NewPlanHandler(planService),
+		handlers.NewBillingHandler(services.NewBillingService(payments, models.NewSubscriptionRepository(db.GetDB()), userRepo, auditRepo), payments),
+		handlers.NewInsightsHandler(populationService),
+		handlers.NewReferralHandler(services.NewReferralService(reportRepo, nil)),
+		handlers.NewAppointmentHandler(services.NewAppointmentService(models.NewAppointmentRepository(db.GetDB()), reportRepo,
+			models.NewNotificationRepository(db.GetDB()), nil, "")),
+		handlers.NewDeviceHandler(services.NewPushService(models.NewPushDeviceRepository(db.GetDB()), nil, 0)),
+		handlers.NewAILogHandler(provenanceService),
+		authMiddleware, nil, nil, nil)
 	httpRouter := rt.SetupRoutes()
 
 	// Decision: Return test server for HTTP requests
 	return httptest.NewServer(httpRouter)
 }
 
+// decodeEnvelope decodes a standard API response and unmarshals its data into out
+func decodeEnvelope(body io.Reader, out any) error {
+	var envelope struct {
+		Data  json.RawMessage `json:"data"`
+		Error *types.APIError `json:"error"`
+	}
+	if err := json.NewDecoder(body).Decode(&envelope); err != nil {
+		return err
+	}
+	if envelope.Error != nil {
+		return fmt.Errorf("API error %d %s: %s", envelope.Error.Status, envelope.Error.Type, envelope.Error.Message)
+	}
+	return json.Unmarshal(envelope.Data, out)
+}
+
 // createAllTestTables creates all necessary tables for integration testing
-func createAllTestTables(t *testing.T, db *database.DB) {
+func createAllTestTables(t testing.TB, db *database.DB) {
 	createUserTable := `
 		CREATE TABLE users (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -73,6 +230,10 @@ func createAllTestTables(t *testing.T, db *database.DB) {
 			full_name TEXT NOT NULL,
 			email_verified BOOLEAN DEFAULT FALSE,
 			is_active BOOLEAN DEFAULT TRUE,
+			timezone TEXT NOT NULL DEFAULT 'UTC',
+			reading_level TEXT NOT NULL DEFAULT 'standard',
+			plan TEXT NOT NULL DEFAULT 'free',
+			version INTEGER NOT NULL DEFAULT 1,
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 		)`
@@ -94,6 +255,23 @@ func createAllTestTables(t *testing.T, db *database.DB) {
 			simplified_summary TEXT,
 			upload_date DATETIME DEFAULT CURRENT_TIMESTAMP,
 			processed_at DATETIME,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			prompt_version TEXT,
+			parse_failed BOOLEAN DEFAULT FALSE,
+			feedback_rating INTEGER,
+			archived_at DATETIME,
+			title TEXT,
+			report_date DATE,
+			notes TEXT,
+			reading_level TEXT NOT NULL DEFAULT 'standard',
+			error_code TEXT NOT NULL DEFAULT '',
+			error_detail TEXT NOT NULL DEFAULT '',
+			plan TEXT NOT NULL DEFAULT 'free',
+			version INTEGER NOT NULL DEFAULT 1,
+			visibility TEXT NOT NULL DEFAULT 'private' CHECK (visibility IN ('private', 'doctor', 'care_team')),
+			retry_at DATETIME,
+			language TEXT NOT NULL DEFAULT '',
 			FOREIGN KEY (user_id) REFERENCES users(id)
 		)`
 
@@ -101,6 +279,572 @@ func createAllTestTables(t *testing.T, db *database.DB) {
 	if err != nil {
 		t.Fatalf("Failed to create reports table: %v", err)
 	}
+
+	createTransferTable := `
+		CREATE TABLE report_transfers (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			report_id INTEGER NOT NULL,
+			from_user_id INTEGER NOT NULL,
+			to_user_id INTEGER NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			responded_at DATETIME,
+			FOREIGN KEY (report_id) REFERENCES reports(id) ON DELETE CASCADE
+		)`
+
+	_, err = db.Exec(createTransferTable)
+	if err != nil {
+		t.Fatalf("Failed to create report_transfers table: %v", err)
+	}
+
+	createCleanupTable := `
+		CREATE TABLE file_cleanup_queue (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			file_path TEXT NOT NULL,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`
+
+	_, err = db.Exec(createCleanupTable)
+	if err != nil {
+		t.Fatalf("Failed to create file_cleanup_queue table: %v", err)
+	}
+
+	createChatTables := `
+		CREATE TABLE chat_messages (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			report_id INTEGER NOT NULL,
+			user_message TEXT NOT NULL,
+			ai_response TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			is_deleted BOOLEAN DEFAULT FALSE,
+			version INTEGER NOT NULL DEFAULT 1,
+			updated_at DATETIME,
+			input_mode TEXT NOT NULL DEFAULT 'text',
+			FOREIGN KEY (report_id) REFERENCES reports(id) ON DELETE CASCADE
+		);
+		CREATE TABLE chat_message_versions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			message_id INTEGER NOT NULL,
+			version INTEGER NOT NULL,
+			user_message TEXT NOT NULL,
+			ai_response TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (message_id) REFERENCES chat_messages(id) ON DELETE CASCADE,
+			UNIQUE (message_id, version)
+		);
+		CREATE TABLE chat_summaries (
+			report_id INTEGER PRIMARY KEY,
+			summary TEXT NOT NULL,
+			through_message_id INTEGER NOT NULL,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (report_id) REFERENCES reports(id) ON DELETE CASCADE
+		)`
+
+	_, err = db.Exec(createChatTables)
+	if err != nil {
+		t.Fatalf("Failed to create chat tables: %v", err)
+	}
+
+	createAuditTables := `
+		CREATE TABLE audit_logs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			actor_id INTEGER NOT NULL,
+			user_id INTEGER NOT NULL,
+			action TEXT NOT NULL,
+			method TEXT,
+			path TEXT,
+			status_code INTEGER,
+			impersonated BOOLEAN NOT NULL DEFAULT FALSE,
+			details TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE TABLE notifications (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			kind TEXT NOT NULL,
+			message TEXT NOT NULL,
+			read_at DATETIME,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		);
+		CREATE TABLE glossary_terms (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			term_key TEXT NOT NULL UNIQUE,
+			term TEXT NOT NULL,
+			definition TEXT NOT NULL,
+			source TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE TABLE report_share_links (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			report_id INTEGER NOT NULL,
+			created_by INTEGER NOT NULL,
+			token_hash TEXT NOT NULL UNIQUE,
+			pin_hash TEXT,
+			failed_attempts INTEGER NOT NULL DEFAULT 0,
+			locked_at DATETIME,
+			expires_at DATETIME NOT NULL,
+			revoked_at DATETIME,
+			last_viewed_at DATETIME,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (report_id) REFERENCES reports(id) ON DELETE CASCADE
+		);
+
+		CREATE TABLE processing_attempts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			report_id INTEGER NOT NULL,
+			status TEXT NOT NULL DEFAULT 'running' CHECK (status IN ('running', 'succeeded', 'failed', 'abandoned')),
+			model TEXT NOT NULL DEFAULT '',
+			error TEXT,
+			started_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			finished_at DATETIME,
+			FOREIGN KEY (report_id) REFERENCES reports(id) ON DELETE CASCADE
+		);
+
+		CREATE TABLE job_queue_state (
+			id INTEGER PRIMARY KEY CHECK (id = 1),
+			paused BOOLEAN NOT NULL DEFAULT FALSE,
+			reason TEXT NOT NULL DEFAULT '',
+			paused_by INTEGER,
+			paused_at DATETIME
+		);
+		INSERT INTO job_queue_state (id) VALUES (1);
+
+		CREATE TABLE analysis_reviews (
+			report_id INTEGER PRIMARY KEY,
+			raw_output TEXT NOT NULL,
+			parse_error TEXT NOT NULL,
+			prompt_version TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			resolved_at DATETIME,
+			resolved_by INTEGER,
+			FOREIGN KEY (report_id) REFERENCES reports(id) ON DELETE CASCADE
+		);
+
+		CREATE TABLE merged_analyses (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			title TEXT NOT NULL,
+			analysis TEXT NOT NULL,
+			prompt_version TEXT,
+			reading_level TEXT NOT NULL DEFAULT 'standard',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		);
+
+		CREATE TABLE merged_analysis_sources (
+			merged_analysis_id INTEGER NOT NULL,
+			position INTEGER NOT NULL,
+			report_id INTEGER,
+			label TEXT NOT NULL,
+			report_date DATETIME,
+			PRIMARY KEY (merged_analysis_id, position),
+			FOREIGN KEY (merged_analysis_id) REFERENCES merged_analyses(id) ON DELETE CASCADE,
+			FOREIGN KEY (report_id) REFERENCES reports(id) ON DELETE SET NULL
+		);
+
+		CREATE TABLE annual_reviews (
+			user_id INTEGER NOT NULL,
+			year INTEGER NOT NULL,
+			review TEXT NOT NULL,
+			fingerprint TEXT NOT NULL,
+			reading_level TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_id, year),
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		);
+
+		CREATE TABLE health_profiles (
+			user_id INTEGER PRIMARY KEY,
+			date_of_birth DATE,
+			sex TEXT CHECK (sex IS NULL OR sex IN ('male', 'female', 'other')),
+			height_cm REAL,
+			weight_kg REAL,
+			conditions TEXT NOT NULL DEFAULT '[]',
+			allergies TEXT NOT NULL DEFAULT '[]',
+			medications TEXT NOT NULL DEFAULT '[]',
+			blood_group TEXT,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		);
+
+		CREATE TABLE emergency_card_links (
+			user_id INTEGER PRIMARY KEY,
+			token_hash TEXT NOT NULL UNIQUE,
+			last_viewed_at DATETIME,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		);
+
+		CREATE TABLE prescriptions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			file_path TEXT NOT NULL,
+			image_type TEXT NOT NULL,
+			prescriber TEXT NOT NULL DEFAULT '',
+			prescribed_on TEXT NOT NULL DEFAULT '',
+			medications TEXT NOT NULL DEFAULT '[]',
+			notes TEXT NOT NULL DEFAULT '',
+			reader TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		);
+
+		CREATE TABLE user_conditions (
+			user_id INTEGER NOT NULL,
+			condition_key TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_id, condition_key),
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		);
+
+		CREATE TABLE organizations (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			footer_text TEXT NOT NULL DEFAULT '',
+			contact_info TEXT NOT NULL DEFAULT '',
+			logo BLOB,
+			logo_width INTEGER NOT NULL DEFAULT 0,
+			logo_height INTEGER NOT NULL DEFAULT 0,
+			sections TEXT NOT NULL DEFAULT '',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE organization_members (
+			user_id INTEGER PRIMARY KEY,
+			organization_id INTEGER NOT NULL,
+			role TEXT NOT NULL DEFAULT 'member' CHECK (role IN ('member', 'admin', 'doctor', 'care_team')),
+			assigned_doctor_id INTEGER,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE
+		);
+
+		CREATE TABLE organization_usage (
+			organization_id INTEGER NOT NULL,
+			month TEXT NOT NULL,
+			reports_processed INTEGER NOT NULL DEFAULT 0,
+			ai_calls INTEGER NOT NULL DEFAULT 0,
+			ai_input_tokens INTEGER NOT NULL DEFAULT 0,
+			ai_output_tokens INTEGER NOT NULL DEFAULT 0,
+			storage_bytes INTEGER NOT NULL DEFAULT 0,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (organization_id, month),
+			FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE
+		);
+
+		CREATE TABLE api_usage (
+			method TEXT NOT NULL,
+			route TEXT NOT NULL,
+			user_id INTEGER NOT NULL DEFAULT 0,
+			day TEXT NOT NULL,
+			calls INTEGER NOT NULL DEFAULT 0,
+			last_called_at DATETIME NOT NULL,
+			PRIMARY KEY (method, route, user_id, day)
+		);
+
+		CREATE TABLE subscriptions (
+			user_id INTEGER PRIMARY KEY,
+			provider TEXT NOT NULL,
+			customer_id TEXT NOT NULL DEFAULT '',
+			subscription_id TEXT NOT NULL DEFAULT '',
+			status TEXT NOT NULL,
+			current_period_end DATETIME,
+			cancel_at_period_end BOOLEAN NOT NULL DEFAULT FALSE,
+			event_at DATETIME,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE UNIQUE INDEX idx_subscriptions_provider_id ON subscriptions(provider, subscription_id)
+			WHERE subscription_id != '';
+
+		CREATE TABLE payment_events (
+			provider TEXT NOT NULL,
+			event_id TEXT NOT NULL,
+			event_type TEXT NOT NULL,
+			user_id INTEGER NOT NULL DEFAULT 0,
+			received_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (provider, event_id)
+		);
+
+		CREATE TABLE chat_safety_interventions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			report_id INTEGER NOT NULL,
+			message_id INTEGER NOT NULL,
+			level TEXT NOT NULL,
+			categories TEXT NOT NULL,
+			removed TEXT NOT NULL DEFAULT '[]',
+			original_answer TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE chat_crisis_flags (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			report_id INTEGER NOT NULL,
+			message_id INTEGER NOT NULL,
+			category TEXT NOT NULL,
+			keyword TEXT NOT NULL,
+			question TEXT NOT NULL,
+			input_mode TEXT NOT NULL DEFAULT 'text',
+			country TEXT NOT NULL DEFAULT '',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE TABLE ai_calls (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			report_id INTEGER NOT NULL DEFAULT 0,
+			user_id INTEGER NOT NULL DEFAULT 0,
+			purpose TEXT NOT NULL,
+			provider TEXT NOT NULL,
+			model TEXT NOT NULL,
+			parameters TEXT NOT NULL DEFAULT '{}',
+			prompt TEXT NOT NULL,
+			response TEXT NOT NULL DEFAULT '',
+			error TEXT NOT NULL DEFAULT '',
+			duration_ms INTEGER NOT NULL DEFAULT 0,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE TABLE translation_cache (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			source_hash TEXT NOT NULL,
+			language TEXT NOT NULL,
+			provider TEXT NOT NULL,
+			translated_text TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (source_hash, language)
+		);
+
+		CREATE TABLE report_status_events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			report_id INTEGER NOT NULL,
+			status TEXT NOT NULL,
+			error_code TEXT NOT NULL DEFAULT '',
+			error_detail TEXT NOT NULL DEFAULT '',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (report_id) REFERENCES reports(id) ON DELETE CASCADE
+		);
+
+		CREATE TABLE report_parts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			report_id INTEGER NOT NULL,
+			part_number INTEGER NOT NULL,
+			original_filename TEXT NOT NULL,
+			file_path TEXT NOT NULL,
+			file_type TEXT NOT NULL,
+			file_size INTEGER NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (report_id) REFERENCES reports(id) ON DELETE CASCADE,
+			UNIQUE (report_id, part_number)
+		);
+		CREATE TABLE IF NOT EXISTS reanalysis_runs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			created_by INTEGER NOT NULL,
+			reason TEXT NOT NULL,
+			prompt_version TEXT NOT NULL,
+			model TEXT NOT NULL DEFAULT '',
+			status TEXT NOT NULL DEFAULT 'running',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			finished_at DATETIME,
+			FOREIGN KEY (created_by) REFERENCES users(id)
+		);
+		CREATE TABLE IF NOT EXISTS reanalysis_items (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			run_id INTEGER NOT NULL,
+			report_id INTEGER NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending',
+			old_analysis TEXT NOT NULL DEFAULT '',
+			old_prompt_version TEXT NOT NULL DEFAULT '',
+			new_analysis TEXT NOT NULL DEFAULT '',
+			new_prompt_version TEXT NOT NULL DEFAULT '',
+			error TEXT NOT NULL DEFAULT '',
+			finished_at DATETIME,
+			FOREIGN KEY (run_id) REFERENCES reanalysis_runs(id) ON DELETE CASCADE,
+			FOREIGN KEY (report_id) REFERENCES reports(id) ON DELETE CASCADE,
+			UNIQUE (run_id, report_id)
+		);
+		CREATE TABLE IF NOT EXISTS population_consents (
+			user_id INTEGER PRIMARY KEY,
+			consented_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		);
+		CREATE TABLE IF NOT EXISTS population_metric_stats (
+			metric_key TEXT NOT NULL,
+			unit TEXT NOT NULL,
+			age_band TEXT NOT NULL,
+			display_name TEXT NOT NULL,
+			contributors INTEGER NOT NULL,
+			p10 REAL NOT NULL,
+			p25 REAL NOT NULL,
+			p50 REAL NOT NULL,
+			p75 REAL NOT NULL,
+			p90 REAL NOT NULL,
+			computed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (metric_key, unit, age_band)
+		);
+
+		CREATE TABLE IF NOT EXISTS appointment_bookings (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			report_id INTEGER NOT NULL,
+			recommendation_index INTEGER NOT NULL,
+			recommendation TEXT NOT NULL,
+			specialty TEXT NOT NULL DEFAULT '',
+			preferred_time DATETIME,
+			notes TEXT NOT NULL DEFAULT '',
+			provider TEXT NOT NULL,
+			status TEXT NOT NULL DEFAULT 'requested',
+			external_ref TEXT,
+			booking_url TEXT NOT NULL DEFAULT '',
+			scheduled_at DATETIME,
+			error TEXT NOT NULL DEFAULT '',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+			FOREIGN KEY (report_id) REFERENCES reports(id) ON DELETE CASCADE
+		);
+
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_appointment_bookings_ref ON appointment_bookings(provider, external_ref);
+
+		CREATE TABLE IF NOT EXISTS claim_packages (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			report_id INTEGER NOT NULL UNIQUE,
+			file_path TEXT NOT NULL,
+			file_size INTEGER NOT NULL,
+			checksum TEXT NOT NULL,
+			insurer TEXT NOT NULL DEFAULT '',
+			policy_number TEXT NOT NULL DEFAULT '',
+			claim_reference TEXT NOT NULL DEFAULT '',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+			FOREIGN KEY (report_id) REFERENCES reports(id) ON DELETE CASCADE
+		);
+
+		CREATE TABLE IF NOT EXISTS api_keys (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			name TEXT NOT NULL,
+			prefix TEXT NOT NULL,
+			key_hash TEXT NOT NULL UNIQUE,
+			scopes TEXT NOT NULL DEFAULT '',
+			last_used_at DATETIME,
+			revoked_at DATETIME,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		);
+		CREATE TABLE IF NOT EXISTS push_devices (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			platform TEXT NOT NULL CHECK (platform IN ('fcm', 'apns')),
+			token TEXT NOT NULL,
+			name TEXT NOT NULL DEFAULT '',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			last_seen_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+			UNIQUE (platform, token)
+		);
+
+		CREATE TABLE IF NOT EXISTS sessions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			token_id TEXT NOT NULL UNIQUE,
+			user_agent TEXT NOT NULL DEFAULT '',
+			ip_address TEXT NOT NULL DEFAULT '',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			last_seen_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			expires_at DATETIME NOT NULL,
+			revoked_at DATETIME,
+			revoked_reason TEXT NOT NULL DEFAULT '',
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		);
+
+		CREATE TABLE IF NOT EXISTS revoked_tokens (
+			token_id TEXT PRIMARY KEY,
+			user_id INTEGER NOT NULL,
+			reason TEXT NOT NULL DEFAULT '',
+			expires_at DATETIME NOT NULL,
+			revoked_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		);
+
+		CREATE TABLE IF NOT EXISTS refresh_tokens (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			token_hash TEXT NOT NULL UNIQUE,
+			session_id TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			expires_at DATETIME NOT NULL,
+			revoked_at DATETIME,
+			revoked_reason TEXT NOT NULL DEFAULT '',
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		);
+
+		CREATE TABLE IF NOT EXISTS sync_tombstones (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			kind TEXT NOT NULL,
+			record_id INTEGER NOT NULL,
+			deleted_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		);
+
+		CREATE TABLE IF NOT EXISTS chat_message_flags (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			message_id INTEGER NOT NULL,
+			user_id INTEGER NOT NULL,
+			reason TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (message_id, user_id),
+			FOREIGN KEY (message_id) REFERENCES chat_messages(id) ON DELETE CASCADE
+		);
+
+		CREATE TABLE IF NOT EXISTS chat_moderation_reviews (
+			message_id INTEGER PRIMARY KEY,
+			action TEXT NOT NULL CHECK (action IN ('dismissed', 'redacted')),
+			note TEXT NOT NULL DEFAULT '',
+			reviewed_by INTEGER NOT NULL,
+			reviewed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (message_id) REFERENCES chat_messages(id) ON DELETE CASCADE
+		);
+
+		CREATE TABLE IF NOT EXISTS user_bans (
+			user_id INTEGER PRIMARY KEY,
+			reason TEXT NOT NULL,
+			banned_by INTEGER NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		);
+
+		CREATE TABLE IF NOT EXISTS legal_holds (
+			user_id INTEGER PRIMARY KEY,
+			reason TEXT NOT NULL,
+			reference TEXT NOT NULL DEFAULT '',
+			placed_by INTEGER NOT NULL,
+			placed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id)
+		);
+
+		CREATE TABLE IF NOT EXISTS prompt_canaries (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			version TEXT NOT NULL UNIQUE,
+			prompt_path TEXT NOT NULL,
+			model TEXT NOT NULL DEFAULT '',
+			percent INTEGER NOT NULL,
+			status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'rolled_back', 'promoted')),
+			reason TEXT NOT NULL DEFAULT '',
+			started_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			ended_at DATETIME,
+			ended_by INTEGER NOT NULL DEFAULT 0
+		)`
+
+	_, err = db.Exec(createAuditTables)
+	if err != nil {
+		t.Fatalf("Failed to create audit tables: %v", err)
+	}
 }
 
 // TestHealthEndpoint tests the health check endpoint
@@ -126,7 +870,7 @@ func TestHealthEndpoint(t *testing.T) {
 
 	// Decision: Parse and validate response body
 	var healthResponse map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&healthResponse); err != nil {
+	if err := decodeEnvelope(resp.Body, &healthResponse); err != nil {
 		t.Fatalf("Failed to parse health response: %v", err)
 	}
 
@@ -163,7 +907,7 @@ func TestSignupEndpoint(t *testing.T) {
 
 	// Decision: Parse response and verify token and user data
 	var signupResponse types.LoginResponse
-	if err := json.NewDecoder(resp.Body).Decode(&signupResponse); err != nil {
+	if err := decodeEnvelope(resp.Body, &signupResponse); err != nil {
 		t.Fatalf("Failed to parse signup response: %v", err)
 	}
 
@@ -225,7 +969,7 @@ func TestLoginEndpoint(t *testing.T) {
 	}
 
 	var loginResponse types.LoginResponse
-	if err := json.NewDecoder(resp.Body).Decode(&loginResponse); err != nil {
+	if err := decodeEnvelope(resp.Body, &loginResponse); err != nil {
 		t.Fatalf("Failed to parse login response: %v", err)
 	}
 
@@ -273,7 +1017,7 @@ func TestProtectedEndpoint(t *testing.T) {
 	defer resp.Body.Close()
 
 	var signupResponse types.LoginResponse
-	json.NewDecoder(resp.Body).Decode(&signupResponse)
+	decodeEnvelope(resp.Body, &signupResponse)
 	token := signupResponse.Token
 
 	// Decision: Test /me endpoint with valid token
@@ -292,7 +1036,7 @@ func TestProtectedEndpoint(t *testing.T) {
 	}
 
 	var userResponse types.User
-	if err := json.NewDecoder(resp2.Body).Decode(&userResponse); err != nil {
+	if err := decodeEnvelope(resp2.Body, &userResponse); err != nil {
 		t.Fatalf("Failed to parse /me response: %v", err)
 	}
 
@@ -348,4 +1092,595 @@ func TestCORSHeaders(t *testing.T) {
 	}
 
 	t.Log("CORS headers test passed")
-}
\ No newline at end of file
+}
+
+// signupAndGetToken registers a user through the SDK and returns their JWT
+func signupAndGetToken(t *testing.T, serverURL, email string) string {
+	response, err := client.New(serverURL, "").Signup(context.Background(), types.SignupRequest{
+		Email:    email,
+		Password: "password123",
+		FullName: "Test User",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	return response.Token
+}
+
+// TestAdminEndpointAccess tests that admin routes require a configured admin
+func TestAdminEndpointAccess(t *testing.T) {
+	server := setupTestServer(t)
+	defer server.Close()
+
+	client := &http.Client{}
+
+	// Decision: Regular users are authenticated but not authorized
+	userToken := signupAndGetToken(t, server.URL, "regular@example.com")
+	req, _ := http.NewRequest("GET", server.URL+"/api/admin/prompts/stats", nil)
+	req.Header.Set("Authorization", "Bearer "+userToken)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to call admin endpoint: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("Expected status 403 for non-admin, got %d", resp.StatusCode)
+	}
+
+	adminToken := signupAndGetToken(t, server.URL, "admin@example.com")
+	req2, _ := http.NewRequest("GET", server.URL+"/api/admin/prompts/stats", nil)
+	req2.Header.Set("Authorization", "Bearer "+adminToken)
+
+	resp2, err := client.Do(req2)
+	if err != nil {
+		t.Fatalf("Failed to call admin endpoint as admin: %v", err)
+	}
+	defer resp2.Body.Close()
+
+	if resp2.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 for admin, got %d", resp2.StatusCode)
+	}
+
+	var statsResponse types.PromptStatsResponse
+	if err := decodeEnvelope(resp2.Body, &statsResponse); err != nil {
+		t.Fatalf("Failed to parse prompt stats response: %v", err)
+	}
+
+	t.Log("Admin endpoint access test passed")
+}
+
+// uploadTestReport uploads a plain text report through the SDK and returns its ID
+func uploadTestReport(t *testing.T, serverURL, token, filename, content string) int {
+	opts := client.UploadOptions{ContentType: "text/plain"}
+	response, err := client.New(serverURL, token).UploadReport(context.Background(), filename, strings.NewReader(content), opts)
+	if err != nil {
+		t.Fatalf("Failed to upload report: %v", err)
+	}
+	return response.ReportID
+}
+
+// TestReportFileDownload tests that responses hide server paths and files download via the API
+func TestReportFileDownload(t *testing.T) {
+	server := setupTestServer(t)
+	defer server.Close()
+
+	token := signupAndGetToken(t, server.URL, "download@example.com")
+	content := "Hemoglobin: 13.5 g/dL"
+	reportID := uploadTestReport(t, server.URL, token, "blood_test.txt", content)
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("%s/api/reports/%d", server.URL, reportID), nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to get report: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// Decision: Decode into a map so a leaked file_path key is caught even if empty
+	var raw map[string]any
+	if err := decodeEnvelope(resp.Body, &raw); err != nil {
+		t.Fatalf("Failed to parse report response: %v", err)
+	}
+	if _, leaked := raw["file_path"]; leaked {
+		t.Fatal("Report response must not include file_path")
+	}
+	downloadURL, _ := raw["download_url"].(string)
+	if raw["file_id"] == "" || downloadURL == "" {
+		t.Fatalf("Expected file_id and download_url, got %v", raw)
+	}
+
+	req, _ = http.NewRequest("GET", server.URL+downloadURL, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp2, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to download report: %v", err)
+	}
+	defer resp2.Body.Close()
+
+	downloaded, _ := io.ReadAll(resp2.Body)
+	if resp2.StatusCode != http.StatusOK || string(downloaded) != content {
+		t.Fatalf("Expected original content, got %d %q", resp2.StatusCode, downloaded)
+	}
+
+	// Other users cannot download the file
+	otherToken := signupAndGetToken(t, server.URL, "other@example.com")
+	req, _ = http.NewRequest("GET", server.URL+downloadURL, nil)
+	req.Header.Set("Authorization", "Bearer "+otherToken)
+	resp3, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to call download endpoint: %v", err)
+	}
+	defer resp3.Body.Close()
+
+	if resp3.StatusCode != http.StatusForbidden {
+		t.Fatalf("Expected status 403 for other user, got %d", resp3.StatusCode)
+	}
+
+	t.Log("Report file download test passed")
+}
+
+// doJSONRequest sends an authenticated JSON request and decodes the response into out (if non-nil)
+func doJSONRequest(t *testing.T, method, url, token string, body any, out any) int {
+	var reader io.Reader
+	if body != nil {
+		payload, _ := json.Marshal(body)
+		reader = bytes.NewBuffer(payload)
+	}
+
+	req, _ := http.NewRequest(method, url, reader)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, url, err)
+	}
+	defer resp.Body.Close()
+
+	if out != nil {
+		decodeEnvelope(resp.Body, out)
+	}
+
+	return resp.StatusCode
+}
+
+// TestReportTransfer tests offering, accepting, and auditing a report ownership transfer
+func TestReportTransfer(t *testing.T) {
+	server := setupTestServer(t)
+	defer server.Close()
+
+	ownerToken := signupAndGetToken(t, server.URL, "parent@example.com")
+	recipientToken := signupAndGetToken(t, server.URL, "child@example.com")
+	reportID := uploadTestReport(t, server.URL, ownerToken, "checkup.txt", "Blood pressure 120/80")
+	reportURL := fmt.Sprintf("%s/api/reports/%d", server.URL, reportID)
+
+	var transfer types.ReportTransfer
+	status := doJSONRequest(t, "POST", reportURL+"/transfer", ownerToken,
+		types.TransferRequest{RecipientEmail: "child@example.com"}, &transfer)
+	if status != http.StatusCreated || transfer.Status != "pending" {
+		t.Fatalf("Expected pending transfer, got %d %+v", status, transfer)
+	}
+
+	// Decision: Only one open offer per report
+	status = doJSONRequest(t, "POST", reportURL+"/transfer", ownerToken,
+		types.TransferRequest{RecipientEmail: "child@example.com"}, nil)
+	if status != http.StatusConflict {
+		t.Fatalf("Expected 409 for duplicate transfer, got %d", status)
+	}
+
+	// Recipient doesn't own the report until accepting
+	if status := doJSONRequest(t, "GET", reportURL, recipientToken, nil, nil); status != http.StatusForbidden {
+		t.Fatalf("Expected 403 before acceptance, got %d", status)
+	}
+
+	var inbox types.TransferListResponse
+	doJSONRequest(t, "GET", server.URL+"/api/transfers", recipientToken, nil, &inbox)
+	if len(inbox.Transfers) != 1 || inbox.Transfers[0].ID != transfer.ID {
+		t.Fatalf("Expected transfer in recipient inbox, got %+v", inbox)
+	}
+
+	// The sender cannot accept on the recipient's behalf
+	acceptURL := fmt.Sprintf("%s/api/transfers/%d/accept", server.URL, transfer.ID)
+	if status := doJSONRequest(t, "POST", acceptURL, ownerToken, nil, nil); status != http.StatusNotFound {
+		t.Fatalf("Expected 404 when sender accepts, got %d", status)
+	}
+
+	var accepted types.ReportTransfer
+	if status := doJSONRequest(t, "POST", acceptURL, recipientToken, nil, &accepted); status != http.StatusOK || accepted.Status != "accepted" {
+		t.Fatalf("Expected accepted transfer, got %d %+v", status, accepted)
+	}
+
+	// Ownership checks follow the new owner
+	if status := doJSONRequest(t, "GET", reportURL, recipientToken, nil, nil); status != http.StatusOK {
+		t.Fatalf("Expected new owner to read report, got %d", status)
+	}
+	if status := doJSONRequest(t, "GET", reportURL, ownerToken, nil, nil); status != http.StatusForbidden {
+		t.Fatalf("Expected previous owner to lose access, got %d", status)
+	}
+
+	var history types.TransferListResponse
+	doJSONRequest(t, "GET", reportURL+"/transfers", recipientToken, nil, &history)
+	if len(history.Transfers) != 1 || history.Transfers[0].RespondedAt == nil {
+		t.Fatalf("Expected audited transfer in history, got %+v", history)
+	}
+
+	t.Log("Report transfer test passed")
+}
+
+// TestBulkReportOperations tests bulk archive and bulk delete with per-item results
+func TestBulkReportOperations(t *testing.T) {
+	server := setupTestServer(t)
+	defer server.Close()
+
+	token := signupAndGetToken(t, server.URL, "bulk@example.com")
+	otherToken := signupAndGetToken(t, server.URL, "bulk-other@example.com")
+
+	first := uploadTestReport(t, server.URL, token, "one.txt", "report one")
+	second := uploadTestReport(t, server.URL, token, "two.txt", "report two")
+	third := uploadTestReport(t, server.URL, token, "three.txt", "report three")
+	foreign := uploadTestReport(t, server.URL, otherToken, "theirs.txt", "not yours")
+
+	var archived types.BulkReportResponse
+	status := doJSONRequest(t, "POST", server.URL+"/api/reports/bulk-archive", token,
+		types.BulkReportRequest{ReportIDs: []int{first, 99999, first}}, &archived)
+	if status != http.StatusOK || archived.Succeeded != 1 || archived.Failed != 1 || len(archived.Results) != 2 {
+		t.Fatalf("Unexpected bulk archive result: %d %+v", status, archived)
+	}
+
+	// Decision: Archived reports drop out of the default listing
+	var list types.ReportListResponse
+	doJSONRequest(t, "GET", server.URL+"/api/reports", token, nil, &list)
+	if list.Total != 2 {
+		t.Fatalf("Expected 2 active reports after archiving, got %d", list.Total)
+	}
+
+	var deleted types.BulkReportResponse
+	doJSONRequest(t, "POST", server.URL+"/api/reports/bulk-delete", token,
+		types.BulkReportRequest{ReportIDs: []int{second, third, foreign}}, &deleted)
+	if deleted.Succeeded != 2 || deleted.Failed != 1 {
+		t.Fatalf("Unexpected bulk delete result: %+v", deleted)
+	}
+	if deleted.Results[2].Status != "forbidden" {
+		t.Fatalf("Expected foreign report to be forbidden, got %s", deleted.Results[2].Status)
+	}
+
+	// The other user's report must be untouched
+	if status := doJSONRequest(t, "GET", fmt.Sprintf("%s/api/reports/%d", server.URL, foreign), otherToken, nil, nil); status != http.StatusOK {
+		t.Fatalf("Expected foreign report to survive, got %d", status)
+	}
+
+	if status := doJSONRequest(t, "POST", server.URL+"/api/reports/bulk-delete", token,
+		types.BulkReportRequest{}, nil); status != http.StatusBadRequest {
+		t.Fatalf("Expected 400 for empty bulk request, got %d", status)
+	}
+
+	t.Log("Bulk report operations test passed")
+}
+
+// TestResponseEnvelope tests that successes and errors share the {data, error, meta} shape
+func TestResponseEnvelope(t *testing.T) {
+	server := setupTestServer(t)
+	defer server.Close()
+
+	token := signupAndGetToken(t, server.URL, "envelope@example.com")
+
+	get := func(path, token string) (int, types.Envelope) {
+		req, _ := http.NewRequest("GET", server.URL+path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		defer resp.Body.Close()
+
+		var envelope types.Envelope
+		if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+			t.Fatalf("GET %s returned a non-envelope body: %v", path, err)
+		}
+		return resp.StatusCode, envelope
+	}
+
+	// Lists carry pagination in meta
+	status, envelope := get("/api/reports?limit=5&offset=0", token)
+	if status != http.StatusOK || envelope.Error != nil || envelope.Data == nil {
+		t.Fatalf("Expected data envelope, got %d %+v", status, envelope)
+	}
+	if envelope.Meta == nil || envelope.Meta.Pagination == nil || envelope.Meta.Pagination.Limit != 5 {
+		t.Fatalf("Expected pagination meta with limit 5, got %+v", envelope.Meta)
+	}
+
+	// Handler, service, and middleware errors all use the error member
+	for path, expected := range map[string]struct {
+		token   string
+		status  int
+		errType string
+	}{
+		"/api/reports/9999":       {token, http.StatusNotFound, "NOT_FOUND"},
+		"/api/chat/9999/versions": {token, http.StatusNotFound, "DATABASE_ERROR"},
+		"/api/reports":            {"", http.StatusUnauthorized, "UNAUTHORIZED"},
+	} {
+		status, envelope := get(path, expected.token)
+		if status != expected.status || envelope.Data != nil || envelope.Error == nil {
+			t.Fatalf("%s: expected %d error envelope, got %d %+v", path, expected.status, status, envelope)
+		}
+		if envelope.Error.Status != expected.status || envelope.Error.Type != expected.errType {
+			t.Fatalf("%s: expected error %d %s, got %+v", path, expected.status, expected.errType, envelope.Error)
+		}
+	}
+}
+
+// TestUpdateReportDetails tests PATCH /api/reports/{id}
+func TestUpdateReportDetails(t *testing.T) {
+	server := setupTestServer(t)
+	defer server.Close()
+
+	token := signupAndGetToken(t, server.URL, "patcher@example.com")
+	reportID := uploadTestReport(t, server.URL, token, "cbc.txt", "Hemoglobin 13.5 g/dL")
+	reportURL := fmt.Sprintf("%s/api/reports/%d", server.URL, reportID)
+
+	var updated types.Report
+	status := doJSONRequest(t, "PATCH", reportURL, token, map[string]any{
+		"title":       "  Annual checkup  ",
+		"report_date": "2025-03-14",
+		"notes":       "Fasted 12 hours",
+	}, &updated)
+	if status != http.StatusOK || updated.Title != "Annual checkup" || updated.ReportDate == nil || *updated.ReportDate != "2025-03-14" {
+		t.Fatalf("Unexpected update result: %d %+v", status, updated)
+	}
+
+	// Omitted fields are kept; empty strings clear
+	status = doJSONRequest(t, "PATCH", reportURL, token, map[string]any{"title": ""}, &updated)
+	if status != http.StatusOK || updated.Title != "cbc.txt" || updated.Notes != "Fasted 12 hours" || updated.ReportDate == nil {
+		t.Fatalf("Expected title reset to filename with other fields kept, got %d %+v", status, updated)
+	}
+
+	var fetched types.Report
+	if status := doJSONRequest(t, "GET", reportURL, token, nil, &fetched); status != http.StatusOK || fetched.Notes != "Fasted 12 hours" {
+		t.Fatalf("Expected stored notes on GET, got %d %+v", status, fetched)
+	}
+
+	for name, body := range map[string]map[string]any{
+		"future date":     {"report_date": time.Now().AddDate(0, 1, 0).Format("2006-01-02")},
+		"bad date":        {"report_date": "14/03/2025"},
+		"long title":      {"title": strings.Repeat("x", 201)},
+		"immutable field": {"original_filename": "renamed.pdf"},
+	} {
+		if status := doJSONRequest(t, "PATCH", reportURL, token, body, nil); status != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", name, status)
+		}
+	}
+
+	otherToken := signupAndGetToken(t, server.URL, "notowner@example.com")
+	if status := doJSONRequest(t, "PATCH", reportURL, otherToken, map[string]any{"title": "mine"}, nil); status != http.StatusForbidden {
+		t.Fatalf("Expected 403 for other user, got %d", status)
+	}
+}
+
+// TestUserTimezone tests storing a timezone preference and rendering timestamps in it
+func TestUserTimezone(t *testing.T) {
+	server := setupTestServer(t)
+	defer server.Close()
+
+	var signup types.LoginResponse
+	status := doJSONRequest(t, "POST", server.URL+"/api/auth/signup", "", types.SignupRequest{
+		Email:    "kolkata@example.com",
+		Password: "password123",
+		FullName: "Zone User",
+		Timezone: "Asia/Kolkata",
+	}, &signup)
+	if status != http.StatusCreated || signup.User.Timezone != "Asia/Kolkata" {
+		t.Fatalf("Expected signup with timezone, got %d %+v", status, signup.User)
+	}
+	if _, offset := signup.User.CreatedAt.Zone(); offset != 5*3600+30*60 {
+		t.Errorf("Expected created_at with +05:30 offset, got %s", signup.User.CreatedAt.Format(time.RFC3339))
+	}
+
+	reportID := uploadTestReport(t, server.URL, signup.Token, "lipid.txt", "LDL 120 mg/dL")
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/reports/%d", server.URL, reportID), nil)
+	if err != nil {
+		t.Fatalf("Failed to build request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+signup.Token)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to fetch report: %v", err)
+	}
+	raw, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if !strings.Contains(string(raw), "+05:30") {
+		t.Errorf("Expected report timestamps with +05:30 offset, got %s", raw)
+	}
+
+	var me types.User
+	status = doJSONRequest(t, "PATCH", server.URL+"/api/auth/me", signup.Token, map[string]any{"timezone": "America/New_York"}, &me)
+	if status != http.StatusOK || me.Timezone != "America/New_York" || me.FullName != "Zone User" {
+		t.Fatalf("Expected timezone update, got %d %+v", status, me)
+	}
+	if status := doJSONRequest(t, "GET", server.URL+"/api/auth/me", signup.Token, nil, &me); status != http.StatusOK || me.Timezone != "America/New_York" {
+		t.Fatalf("Expected stored timezone on GET, got %d %+v", status, me)
+	}
+
+	for _, zone := range []string{"Mars/Olympus_Mons", "Local", ""} {
+		if status := doJSONRequest(t, "PATCH", server.URL+"/api/auth/me", signup.Token, map[string]any{"timezone": zone}, nil); status != http.StatusBadRequest {
+			t.Errorf("Expected 400 for timezone %q, got %d", zone, status)
+		}
+	}
+
+	// Users without a preference keep UTC
+	var plain types.LoginResponse
+	doJSONRequest(t, "POST", server.URL+"/api/auth/signup", "", types.SignupRequest{
+		Email: "utc@example.com", Password: "password123", FullName: "UTC User",
+	}, &plain)
+	if plain.User.Timezone != models.DefaultTimezone {
+		t.Errorf("Expected default timezone %s, got %q", models.DefaultTimezone, plain.User.Timezone)
+	}
+
+	// Day boundaries for quotas follow the user's zone, not the server's
+	user := &models.User{Timezone: "Asia/Kolkata"}
+	start := user.StartOfDay(time.Date(2026, 3, 1, 20, 0, 0, 0, time.UTC))
+	if want := time.Date(2026, 3, 1, 18, 30, 0, 0, time.UTC); !start.Equal(want) {
+		t.Errorf("Expected start of day %s, got %s", want, start.UTC())
+	}
+}
+
+// TestReadingLevel tests the stored reading level preference and per-upload overrides
+func TestReadingLevel(t *testing.T) {
+	server := setupTestServer(t)
+	defer server.Close()
+
+	var signup types.LoginResponse
+	status := doJSONRequest(t, "POST", server.URL+"/api/auth/signup", "", types.SignupRequest{
+		Email: "clinician@example.com", Password: "password123", FullName: "Dr Rao", ReadingLevel: models.ReadingLevelClinical,
+	}, &signup)
+	if status != http.StatusCreated || signup.User.ReadingLevel != models.ReadingLevelClinical {
+		t.Fatalf("Expected signup with clinical reading level, got %d %+v", status, signup.User)
+	}
+
+	// Uploads default to the owner's preference
+	var report types.Report
+	reportID := uploadTestReport(t, server.URL, signup.Token, "cbc.txt", "Hb 13.2 g/dL")
+	doJSONRequest(t, "GET", fmt.Sprintf("%s/api/reports/%d", server.URL, reportID), signup.Token, nil, &report)
+	if report.ReadingLevel != models.ReadingLevelClinical {
+		t.Errorf("Expected report analyzed at clinical level, got %q", report.ReadingLevel)
+	}
+
+	// A form field overrides the preference for one upload
+	upload := func(level string) (int, int) {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		writer.WriteField("reading_level", level)
+		partHeader := textproto.MIMEHeader{}
+		partHeader.Set("Content-Disposition", `form-data; name="file"; filename="kids.txt"`)
+		partHeader.Set("Content-Type", "text/plain")
+		part, _ := writer.CreatePart(partHeader)
+		part.Write([]byte("Hb 11.9 g/dL"))
+		writer.Close()
+
+		req, _ := http.NewRequest("POST", server.URL+"/api/reports", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		req.Header.Set("Authorization", "Bearer "+signup.Token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to upload report: %v", err)
+		}
+		defer resp.Body.Close()
+
+		var uploaded types.UploadResponse
+		decodeEnvelope(resp.Body, &uploaded)
+		return resp.StatusCode, uploaded.ReportID
+	}
+
+	status, childID := upload(models.ReadingLevelChild)
+	if status != http.StatusCreated {
+		t.Fatalf("Expected upload with reading level override, got %d", status)
+	}
+	doJSONRequest(t, "GET", fmt.Sprintf("%s/api/reports/%d", server.URL, childID), signup.Token, nil, &report)
+	if report.ReadingLevel != models.ReadingLevelChild {
+		t.Errorf("Expected overridden child level, got %q", report.ReadingLevel)
+	}
+	if status, _ := upload("expert"); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 for unknown reading level on upload, got %d", status)
+	}
+
+	var me types.User
+	status = doJSONRequest(t, "PATCH", server.URL+"/api/auth/me", signup.Token, map[string]any{"reading_level": "standard"}, &me)
+	if status != http.StatusOK || me.ReadingLevel != models.ReadingLevelStandard {
+		t.Fatalf("Expected reading level update, got %d %+v", status, me)
+	}
+	if status := doJSONRequest(t, "PATCH", server.URL+"/api/auth/me", signup.Token, map[string]any{"reading_level": "expert"}, nil); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 for unknown reading level, got %d", status)
+	}
+
+	// Users without a preference get the standard level
+	var plain types.LoginResponse
+	doJSONRequest(t, "POST", server.URL+"/api/auth/signup", "", types.SignupRequest{
+		Email: "patient-level@example.com", Password: "password123", FullName: "Plain User",
+	}, &plain)
+	if plain.User.ReadingLevel != models.ReadingLevelStandard {
+		t.Errorf("Expected default standard level, got %q", plain.User.ReadingLevel)
+	}
+}
+
+// TestAdminImpersonation tests issuing, using, and auditing a support impersonation token
+func TestAdminImpersonation(t *testing.T) {
+	server := setupTestServer(t)
+	defer server.Close()
+
+	adminToken := signupAndGetToken(t, server.URL, "admin@example.com")
+	userToken := signupAndGetToken(t, server.URL, "patient@example.com")
+	reportID := uploadTestReport(t, server.URL, userToken, "thyroid.txt", "TSH 2.1 mIU/L")
+
+	var patient types.User
+	doJSONRequest(t, "GET", server.URL+"/api/auth/me", userToken, nil, &patient)
+	impersonateURL := fmt.Sprintf("%s/api/admin/impersonate/%d", server.URL, patient.ID)
+
+	if status := doJSONRequest(t, "POST", impersonateURL, userToken, map[string]string{"reason": "x"}, nil); status != http.StatusForbidden {
+		t.Fatalf("Expected 403 for non-admin, got %d", status)
+	}
+	if status := doJSONRequest(t, "POST", impersonateURL, adminToken, map[string]string{}, nil); status != http.StatusBadRequest {
+		t.Fatalf("Expected 400 without a reason, got %d", status)
+	}
+
+	var grant types.ImpersonationResponse
+	status := doJSONRequest(t, "POST", impersonateURL, adminToken, map[string]string{"reason": "Ticket 42: report stuck"}, &grant)
+	if status != http.StatusCreated || grant.Token == "" || grant.User.ID != patient.ID {
+		t.Fatalf("Expected impersonation token, got %d %+v", status, grant)
+	}
+	if time.Until(grant.ExpiresAt) > 16*time.Minute {
+		t.Errorf("Expected a short-lived token, expires at %s", grant.ExpiresAt)
+	}
+
+	// The token acts as the user and every response is flagged
+	req, _ := http.NewRequest("GET", fmt.Sprintf("%s/api/reports/%d", server.URL, reportID), nil)
+	req.Header.Set("Authorization", "Bearer "+grant.Token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Impersonated request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get(middleware.ImpersonationHeader) == "" {
+		t.Fatalf("Expected flagged 200 for impersonated read, got %d %q", resp.StatusCode, resp.Header.Get(middleware.ImpersonationHeader))
+	}
+
+	// It can't be refreshed, reach admin routes, or dismiss the user's notice
+	if status := doJSONRequest(t, "POST", server.URL+"/api/auth/refresh", grant.Token, nil, nil); status == http.StatusOK {
+		t.Error("Expected impersonation token refresh to fail")
+	}
+	var notices []types.Notification
+	doJSONRequest(t, "GET", server.URL+"/api/notifications", userToken, nil, &notices)
+	if len(notices) != 1 || notices[0].ReadAt != nil {
+		t.Fatalf("Expected one unread notification for the user, got %+v", notices)
+	}
+	readURL := fmt.Sprintf("%s/api/notifications/%d/read", server.URL, notices[0].ID)
+	if status := doJSONRequest(t, "POST", readURL, grant.Token, nil, nil); status != http.StatusForbidden {
+		t.Errorf("Expected 403 dismissing while impersonating, got %d", status)
+	}
+	if status := doJSONRequest(t, "POST", readURL, userToken, nil, nil); status != http.StatusOK {
+		t.Errorf("Expected the user to dismiss the notification, got %d", status)
+	}
+
+	var entries []types.AuditLogEntry
+	doJSONRequest(t, "GET", fmt.Sprintf("%s/api/admin/audit?user_id=%d", server.URL, patient.ID), adminToken, nil, &entries)
+	var started, requests int
+	for _, e := range entries {
+		if !e.Impersonated || e.UserID != patient.ID {
+			t.Errorf("Unexpected audit entry %+v", e)
+		}
+		switch e.Action {
+		case models.AuditImpersonationStarted:
+			started++
+		case models.AuditImpersonatedRequest:
+			requests++
+		}
+	}
+	// Decision: /refresh takes a refresh token rather than the bearer token, so the refresh attempt isn't one of them
+	if started != 1 || requests < 2 {
+		t.Errorf("Expected the grant and each impersonated request audited, got %+v", entries)
+	}
+}
@@ -0,0 +1,377 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// failingAnalyzer fails every report, like a model that keeps timing out
+type failingAnalyzer struct{}
+
+func (failingAnalyzer) AnalyzeReport(ctx context.Context, filePath, fileType, readingLevel, plan, patient string) (*services.ReportAnalysis, error) {
+	return nil, fmt.Errorf("model timed out")
+}
+
+// TestJobRunbook tests attempt history, retry, cancel, and pausing the processing queue
+func TestJobRunbook(t *testing.T) {
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	admin := &models.User{Email: "ops@example.com", PasswordHash: "hash", FullName: "Ops", IsActive: true}
+	if err := models.NewUserRepository(db.GetDB()).Create(admin); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	uploadDir := t.TempDir()
+	reportRepo := models.NewReportRepository(db.GetDB())
+	newReport := func(name string) *models.Report {
+		report := &models.Report{UserID: admin.ID, OriginalFilename: name, FilePath: filepath.Join(uploadDir, name),
+			FileType: "text/plain", FileSize: 10, ProcessingStatus: "pending", ReadingLevel: models.ReadingLevelStandard}
+		if err := reportRepo.Create(report); err != nil {
+			t.Fatalf("Failed to create report: %v", err)
+		}
+		return report
+	}
+
+	jobRepo := models.NewJobRepository(db.GetDB())
+	auditRepo := models.NewAuditLogRepository(db.GetDB())
+	processor := services.NewReportProcessorWithAnalyzer(reportRepo, jobRepo, nil, nil, failingAnalyzer{}, services.NewFileStorage(uploadDir, "secret"))
+	jobs := services.NewJobService(reportRepo, jobRepo, auditRepo, time.Minute)
+
+	failing := newReport("failing.txt")
+	for i := 0; i < 2; i++ {
+		// Reports are claimed from the status they were loaded with, so load the failed one afresh
+		failing, _ = reportRepo.GetByID(failing.ID)
+		if err := processor.ProcessReport(failing); err == nil {
+			t.Fatal("Expected the analyzer failure to be returned")
+		}
+	}
+
+	detail, err := jobs.Detail(failing.ID)
+	if err != nil {
+		t.Fatalf("Failed to get job detail: %v", err)
+	}
+	if len(detail.Attempts) != 2 || detail.Attempts[1].Status != models.AttemptFailed || detail.LastError != "model timed out" {
+		t.Errorf("Expected two failed attempts with the last error, got %+v", detail)
+	}
+
+	// Retry only applies to failed or stuck jobs
+	waiting := newReport("waiting.txt")
+	if err := jobs.Retry(admin, waiting.ID); err == nil {
+		t.Error("Expected retrying a pending job to be rejected")
+	}
+	if err := jobs.Retry(admin, failing.ID); err != nil {
+		t.Fatalf("Failed to retry job: %v", err)
+	}
+	if report, _ := reportRepo.GetByID(failing.ID); report.ProcessingStatus != "pending" {
+		t.Errorf("Expected retried job to be pending, got %s", report.ProcessingStatus)
+	}
+
+	if err := jobs.Cancel(admin, waiting.ID, "duplicate upload"); err != nil {
+		t.Fatalf("Failed to cancel job: %v", err)
+	}
+	if detail, _ := jobs.Detail(waiting.ID); detail.Report.ProcessingStatus != "failed" || detail.LastError == "" {
+		t.Errorf("Expected cancelled job to fail with the reason, got %+v", detail)
+	}
+
+	// A paused queue leaves reports pending, including ones processed inline
+	if err := jobs.Pause(admin, ""); err == nil {
+		t.Error("Expected pausing without a reason to be rejected")
+	}
+	if err := jobs.Pause(admin, "database maintenance"); err != nil {
+		t.Fatalf("Failed to pause queue: %v", err)
+	}
+	if err := processor.ProcessReport(failing); err != services.ErrQueuePaused {
+		t.Errorf("Expected paused queue to refuse work, got %v", err)
+	}
+	overview, err := jobs.Overview(nil, 20)
+	if err != nil {
+		t.Fatalf("Failed to get queue overview: %v", err)
+	}
+	if !overview.State.Paused || !overview.Drained || overview.Counts["pending"] != 1 || len(overview.Jobs) != 2 {
+		t.Errorf("Expected a paused, drained queue with one pending job, got %+v", overview)
+	}
+	if err := jobs.Resume(admin); err != nil {
+		t.Fatalf("Failed to resume queue: %v", err)
+	}
+
+	entries, err := auditRepo.List(models.AuditLogFilter{ActorID: admin.ID})
+	if err != nil || len(entries) != 4 {
+		t.Errorf("Expected retry, cancel, pause, and resume audited, got %d entries (%v)", len(entries), err)
+	}
+
+	// HTTP endpoints are admin-only
+	server := setupTestServer(t)
+	defer server.Close()
+	token := signupAndGetToken(t, server.URL, "not-ops@example.com")
+	if status := doJSONRequest(t, "GET", server.URL+"/api/admin/jobs", token, nil, nil); status != http.StatusForbidden {
+		t.Errorf("Expected non-admins to be refused, got %d", status)
+	}
+	adminToken := signupAndGetToken(t, server.URL, "admin@example.com")
+	if status := doJSONRequest(t, "GET", server.URL+"/api/admin/jobs?status=archived", adminToken, nil, nil); status != http.StatusBadRequest {
+		t.Errorf("Expected unknown status filter to be rejected, got %d", status)
+	}
+	if status := doJSONRequest(t, "POST", server.URL+"/api/admin/jobs/pause", adminToken, map[string]string{"reason": "upgrade"}, nil); status != http.StatusOK {
+		t.Errorf("Expected admin to pause the queue, got %d", status)
+	}
+}
+
+// TestProcessingHistory tests that owners see each status their report entered and the model behind each attempt
+func TestProcessingHistory(t *testing.T) {
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	userRepo := models.NewUserRepository(db.GetDB())
+	owner := &models.User{Email: "owner@example.com", PasswordHash: "hash", FullName: "Owner", IsActive: true}
+	if err := userRepo.Create(owner); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	uploadDir := t.TempDir()
+	reportRepo := models.NewReportRepository(db.GetDB())
+	report := &models.Report{UserID: owner.ID, OriginalFilename: "cbc.txt", FilePath: filepath.Join(uploadDir, "cbc.txt"),
+		FileType: "text/plain", FileSize: 10, ProcessingStatus: "pending", ReadingLevel: models.ReadingLevelStandard}
+	if err := reportRepo.Create(report); err != nil {
+		t.Fatalf("Failed to create report: %v", err)
+	}
+
+	jobRepo := models.NewJobRepository(db.GetDB())
+	storage := services.NewFileStorage(uploadDir, "secret")
+	jobs := services.NewJobService(reportRepo, jobRepo, models.NewAuditLogRepository(db.GetDB()), time.Minute)
+
+	failing := services.NewReportProcessorWithAnalyzer(reportRepo, jobRepo, nil, nil, failingAnalyzer{}, storage)
+	if err := failing.ProcessReport(report); err == nil {
+		t.Fatal("Expected the analyzer failure to be returned")
+	}
+	if err := jobs.Retry(owner, report.ID); err != nil {
+		t.Fatalf("Failed to retry job: %v", err)
+	}
+	report, _ = reportRepo.GetByID(report.ID)
+	demo := services.NewReportProcessorWithAnalyzer(reportRepo, jobRepo, nil, nil, services.NewDemoAnalyzer(), storage)
+	if err := demo.ProcessReport(report); err != nil {
+		t.Fatalf("Failed to process report: %v", err)
+	}
+
+	history, err := jobs.History(owner, report.ID)
+	if err != nil {
+		t.Fatalf("Failed to load history: %v", err)
+	}
+	var statuses []string
+	for _, event := range history.Transitions {
+		statuses = append(statuses, event.Status)
+	}
+	if got := strings.Join(statuses, ","); got != "pending,processing,failed,pending,processing,completed" {
+		t.Errorf("Unexpected transitions %s", got)
+	}
+	if failed := history.Transitions[2]; failed.ErrorCode != models.ProcessingErrorInternal || failed.ErrorDetail == "" {
+		t.Errorf("Expected the failure reason on the failed transition, got %+v", failed)
+	}
+	if len(history.Attempts) != 2 || history.Attempts[1].Model != "demo" || history.Attempts[1].Status != models.AttemptSucceeded {
+		t.Errorf("Expected two attempts, the last by the demo model, got %+v", history.Attempts)
+	}
+	if history.Attempts[0].Error != "" {
+		t.Errorf("Expected internal attempt errors withheld from the owner, got %q", history.Attempts[0].Error)
+	}
+
+	if _, err := jobs.History(&models.User{ID: owner.ID + 1}, report.ID); err == nil {
+		t.Error("Expected another user's history request to be refused")
+	}
+
+	// The per-report route sits beside the deprecated report list at /api/reports/history
+	server := setupTestServer(t)
+	defer server.Close()
+	token := signupAndGetToken(t, server.URL, "history@example.com")
+	if status := doJSONRequest(t, "GET", server.URL+"/api/reports/999/history", token, nil, nil); status != http.StatusNotFound {
+		t.Errorf("Expected an unknown report to be not found, got %d", status)
+	}
+	if status := doJSONRequest(t, "GET", server.URL+"/api/reports/history", token, nil, nil); status != http.StatusOK {
+		t.Errorf("Expected the report list route unchanged, got %d", status)
+	}
+}
+
+// TestReportStatus tests that a report's status gives its queue position, retry time, and failure while it is processed
+func TestReportStatus(t *testing.T) {
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	owner := &models.User{Email: "owner@example.com", PasswordHash: "hash", FullName: "Owner", IsActive: true}
+	if err := models.NewUserRepository(db.GetDB()).Create(owner); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	reportRepo := models.NewReportRepository(db.GetDB())
+	var reports []*models.Report
+	for _, name := range []string{"first.txt", "second.txt", "third.txt"} {
+		report := &models.Report{UserID: owner.ID, OriginalFilename: name, FilePath: name, FileType: "text/plain", FileSize: 10}
+		if err := reportRepo.Create(report); err != nil {
+			t.Fatalf("Failed to create report: %v", err)
+		}
+		reports = append(reports, report)
+	}
+	jobRepo := models.NewJobRepository(db.GetDB())
+	jobs := services.NewJobService(reportRepo, jobRepo, models.NewAuditLogRepository(db.GetDB()), time.Minute)
+	status := func(report *models.Report) *services.ReportStatus {
+		t.Helper()
+		status, err := jobs.Status(owner, report.ID)
+		if err != nil {
+			t.Fatalf("Failed to get status: %v", err)
+		}
+		return status
+	}
+
+	// Uploads within the same second keep their upload order
+	if got := status(reports[2]); got.QueuePosition != 3 || got.StartedAt != nil || got.QueuePaused {
+		t.Errorf("Expected the third upload third in the queue, got %+v", got)
+	}
+
+	// The first waits out a retry, so the others move up
+	reportRepo.ClaimForProcessing(reports[0].ID, "pending")
+	jobRepo.StartAttempt(reports[0].ID, "demo")
+	retryAt := time.Now().Add(time.Hour)
+	if err := reportRepo.ScheduleRetry(reports[0].ID, models.ProcessingErrorAITimeout, "model timed out", retryAt); err != nil {
+		t.Fatalf("Failed to schedule retry: %v", err)
+	}
+	if got := status(reports[0]); got.QueuePosition != 0 || got.RetryAt == nil || got.RetryAt.Sub(retryAt).Abs() > time.Second ||
+		got.Attempts != 1 || got.StartedAt == nil {
+		t.Errorf("Expected the retried report waiting with its attempt, got %+v", got)
+	}
+	if got := status(reports[2]); got.QueuePosition != 2 {
+		t.Errorf("Expected the third upload second in the queue, got %d", got.QueuePosition)
+	}
+
+	jobRepo.SetQueueState(true, "maintenance", owner.ID)
+	if got := status(reports[1]); got.QueuePosition != 1 || !got.QueuePaused {
+		t.Errorf("Expected the second upload next in a paused queue, got %+v", got)
+	}
+
+	reportRepo.ClaimForProcessing(reports[1].ID, "pending")
+	reportRepo.MarkFailed(reports[1].ID, models.ProcessingErrorUnreadableDocument, "No text found in the file")
+	if got := status(reports[1]); got.Report.ProcessingStatus != "failed" || got.Report.ErrorDetail != "No text found in the file" ||
+		got.QueuePosition != 0 || got.QueuePaused {
+		t.Errorf("Expected the failure reported without a queue position, got %+v", got)
+	}
+
+	if _, err := jobs.Status(&models.User{ID: owner.ID + 1}, reports[2].ID); err == nil {
+		t.Error("Expected another user's status request to be refused")
+	}
+
+	// Over HTTP a pending report has a position and nothing started yet
+	server := setupTestServer(t)
+	defer server.Close()
+	token := signupAndGetToken(t, server.URL, "status@example.com")
+	uploadTestReport(t, server.URL, token, "cbc.txt", "Hemoglobin 13.5 g/dL")
+	reportID := uploadTestReport(t, server.URL, token, "lipids.txt", "LDL 160 mg/dL")
+	var response types.ReportStatusResponse
+	if code := doJSONRequest(t, "GET", fmt.Sprintf("%s/api/reports/%d/status", server.URL, reportID), token, nil, &response); code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	if response.ProcessingStatus != "pending" || response.QueuePosition == nil || *response.QueuePosition != 2 ||
+		response.StartedAt != nil || response.UploadedAt.IsZero() {
+		t.Errorf("Unexpected status %+v", response)
+	}
+	other := signupAndGetToken(t, server.URL, "other@example.com")
+	if code := doJSONRequest(t, "GET", fmt.Sprintf("%s/api/reports/%d/status", server.URL, reportID), other, nil, nil); code != http.StatusForbidden {
+		t.Errorf("Expected 403 for another user's report, got %d", code)
+	}
+}
+
+// erroringAnalyzer fails every report with err
+type erroringAnalyzer struct {
+	err error
+}
+
+func (e erroringAnalyzer) AnalyzeReport(ctx context.Context, filePath, fileType, readingLevel, plan, patient string) (*services.ReportAnalysis, error) {
+	return nil, e.err
+}
+
+// TestProcessingErrorCodes tests that failures are classified into error codes instead of the summary column
+func TestProcessingErrorCodes(t *testing.T) {
+	cases := map[string]error{
+		models.ProcessingErrorUnreadableDocument: &services.ExtractionError{Err: &services.UnreadableReportError{Reason: "blurry"}},
+		models.ProcessingErrorExtractionFailed:   &services.ExtractionError{Err: fmt.Errorf("pdf: malformed xref table")},
+		models.ProcessingErrorQuotaExceeded:      fmt.Errorf("failed to generate AI analysis: %w", &services.RateLimitError{Err: fmt.Errorf("429")}),
+		models.ProcessingErrorAITimeout:          fmt.Errorf("failed to generate AI analysis: %w", context.DeadlineExceeded),
+		models.ProcessingErrorInternal:           fmt.Errorf("model returned nothing"),
+	}
+	for code, err := range cases {
+		if got := services.ClassifyProcessingError(err); got != code {
+			t.Errorf("Expected %v to be classified %s, got %s", err, code, got)
+		}
+	}
+
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	admin := &models.User{Email: "ops@example.com", PasswordHash: "hash", FullName: "Ops", IsActive: true}
+	if err := models.NewUserRepository(db.GetDB()).Create(admin); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	uploadDir := t.TempDir()
+	reportRepo := models.NewReportRepository(db.GetDB())
+	newReport := func(name string) *models.Report {
+		report := &models.Report{UserID: admin.ID, OriginalFilename: name, FilePath: filepath.Join(uploadDir, name),
+			FileType: "text/plain", FileSize: 10, ProcessingStatus: "pending", ReadingLevel: models.ReadingLevelStandard}
+		if err := reportRepo.Create(report); err != nil {
+			t.Fatalf("Failed to create report: %v", err)
+		}
+		return report
+	}
+	jobRepo := models.NewJobRepository(db.GetDB())
+	jobs := services.NewJobService(reportRepo, jobRepo, models.NewAuditLogRepository(db.GetDB()), time.Minute)
+
+	limited := newReport("limited.txt")
+	processor := services.NewReportProcessorWithAnalyzer(reportRepo, jobRepo, nil, nil,
+		erroringAnalyzer{err: &services.RateLimitError{Err: fmt.Errorf("quota exhausted")}}, services.NewFileStorage(uploadDir, "secret"))
+	processor.ProcessReport(limited)
+	stored, _ := reportRepo.GetByID(limited.ID)
+	if stored.ProcessingStatus != "failed" || stored.ErrorCode != models.ProcessingErrorQuotaExceeded ||
+		!strings.Contains(stored.ErrorDetail, "quota exhausted") || stored.SimplifiedSummary != "" {
+		t.Errorf("Expected a quota failure kept out of the summary, got %+v", stored)
+	}
+
+	cancelled := newReport("cancelled.txt")
+	if err := jobs.Cancel(admin, cancelled.ID, "duplicate upload"); err != nil {
+		t.Fatalf("Failed to cancel job: %v", err)
+	}
+	overview, err := jobs.Overview(nil, 20)
+	if err != nil {
+		t.Fatalf("Failed to get queue overview: %v", err)
+	}
+	if overview.FailureCauses[models.ProcessingErrorQuotaExceeded] != 1 || overview.FailureCauses[models.ProcessingErrorCancelled] != 1 {
+		t.Errorf("Expected failures counted by cause, got %v", overview.FailureCauses)
+	}
+
+	// A retry clears the failure
+	if err := jobs.Retry(admin, limited.ID); err != nil {
+		t.Fatalf("Failed to retry job: %v", err)
+	}
+	if stored, _ := reportRepo.GetByID(limited.ID); stored.ErrorCode != "" || stored.ErrorDetail != "" {
+		t.Errorf("Expected a retried report to have no error, got %q %q", stored.ErrorCode, stored.ErrorDetail)
+	}
+}
@@ -0,0 +1,226 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// TestLegalHold tests that an admin's hold keeps a user's reports from being deleted, altered, or given away until released
+func TestLegalHold(t *testing.T) {
+	server := setupTestServer(t)
+	defer server.Close()
+
+	adminToken := signupAndGetToken(t, server.URL, "admin@example.com")
+	token := signupAndGetToken(t, server.URL, "held@example.com")
+	recipientToken := signupAndGetToken(t, server.URL, "held-recipient@example.com")
+	var patient types.User
+	doJSONRequest(t, "GET", server.URL+"/api/auth/me", token, nil, &patient)
+
+	first := uploadTestReport(t, server.URL, token, "first.txt", "Haemoglobin 13.5")
+	second := uploadTestReport(t, server.URL, token, "second.txt", "Platelets 250000")
+	holdURL := fmt.Sprintf("%s/api/admin/users/%d/legal-hold", server.URL, patient.ID)
+
+	if status := doJSONRequest(t, "PUT", holdURL, token, types.PlaceLegalHoldRequest{Reason: "Mine"}, nil); status != http.StatusForbidden {
+		t.Fatalf("Expected 403 for a non-admin placing a hold, got %d", status)
+	}
+	if status := doJSONRequest(t, "PUT", holdURL, adminToken, types.PlaceLegalHoldRequest{}, nil); status != http.StatusBadRequest {
+		t.Fatalf("Expected 400 without a reason, got %d", status)
+	}
+	if status := doJSONRequest(t, "PUT", fmt.Sprintf("%s/api/admin/users/99999/legal-hold", server.URL), adminToken,
+		types.PlaceLegalHoldRequest{Reason: "Court order"}, nil); status != http.StatusNotFound {
+		t.Fatalf("Expected 404 holding an unknown user, got %d", status)
+	}
+
+	var hold types.LegalHoldResponse
+	status := doJSONRequest(t, "PUT", holdURL, adminToken, types.PlaceLegalHoldRequest{Reason: "Court order", Reference: " OS 123/2026 "}, &hold)
+	if status != http.StatusOK || hold.UserID != patient.ID || hold.Reference != "OS 123/2026" {
+		t.Fatalf("Expected the hold placed, got %d %+v", status, hold)
+	}
+	var holds types.LegalHoldListResponse
+	doJSONRequest(t, "GET", server.URL+"/api/admin/legal-holds", adminToken, nil, &holds)
+	if len(holds.Holds) != 1 || holds.Holds[0].Reason != "Court order" {
+		t.Fatalf("Expected the hold listed, got %+v", holds)
+	}
+
+	// Neither single nor bulk deletion gets past the hold, and the report can't leave the account
+	reportURL := fmt.Sprintf("%s/api/reports/%d", server.URL, first)
+	if status := doJSONRequest(t, "DELETE", reportURL, token, nil, nil); status != http.StatusConflict {
+		t.Fatalf("Expected 409 deleting a held report, got %d", status)
+	}
+	var bulk types.BulkReportResponse
+	doJSONRequest(t, "POST", server.URL+"/api/reports/bulk-delete", token, types.BulkReportRequest{ReportIDs: []int{second}}, &bulk)
+	if bulk.Failed != 1 || bulk.Results[0].Status != models.BulkStatusLegalHold {
+		t.Fatalf("Expected the held report refused in bulk, got %+v", bulk)
+	}
+	var transfer types.ReportTransfer
+	doJSONRequest(t, "POST", reportURL+"/transfer", token, types.TransferRequest{RecipientEmail: "held-recipient@example.com"}, &transfer)
+	acceptURL := fmt.Sprintf("%s/api/transfers/%d/accept", server.URL, transfer.ID)
+	if status := doJSONRequest(t, "POST", acceptURL, recipientToken, nil, nil); status != http.StatusConflict {
+		t.Fatalf("Expected 409 accepting a held report, got %d", status)
+	}
+	if status := doJSONRequest(t, "GET", reportURL, token, nil, nil); status != http.StatusOK {
+		t.Fatalf("Expected the held report to stay with its owner, got %d", status)
+	}
+
+	// Nor can it be edited, or absorb another report as a page
+	title := "Renamed"
+	if status := doJSONRequest(t, "PATCH", reportURL, token, types.UpdateReportRequest{Title: &title}, nil); status != http.StatusConflict {
+		t.Fatalf("Expected 409 editing a held report, got %d", status)
+	}
+	if status := doJSONRequest(t, "POST", reportURL+"/parts", token, types.MergePartsRequest{ReportID: second}, nil); status != http.StatusConflict {
+		t.Fatalf("Expected 409 merging held reports, got %d", status)
+	}
+	if status := doJSONRequest(t, "GET", fmt.Sprintf("%s/api/reports/%d", server.URL, second), token, nil, nil); status != http.StatusOK {
+		t.Fatalf("Expected the refused merge to keep its source, got %d", status)
+	}
+
+	// Released, the data can be deleted again, and both changes are audited
+	if status := doJSONRequest(t, "DELETE", holdURL, adminToken, nil, nil); status != http.StatusOK {
+		t.Fatalf("Expected the hold released, got %d", status)
+	}
+	if status := doJSONRequest(t, "DELETE", holdURL, adminToken, nil, nil); status != http.StatusNotFound {
+		t.Fatalf("Expected 404 releasing a hold twice, got %d", status)
+	}
+	if status := doJSONRequest(t, "PATCH", reportURL, token, types.UpdateReportRequest{Title: &title}, nil); status != http.StatusOK {
+		t.Fatalf("Expected the report edited after release, got %d", status)
+	}
+	if status := doJSONRequest(t, "DELETE", reportURL, token, nil, nil); status != http.StatusOK {
+		t.Fatalf("Expected the report deleted after release, got %d", status)
+	}
+
+	var entries []types.AuditLogEntry
+	doJSONRequest(t, "GET", fmt.Sprintf("%s/api/admin/audit?user_id=%d", server.URL, patient.ID), adminToken, nil, &entries)
+	actions := make(map[string]string)
+	for _, entry := range entries {
+		actions[entry.Action] = entry.Details
+	}
+	if actions[models.AuditLegalHoldPlaced] != "Court order (reference OS 123/2026)" {
+		t.Errorf("Expected the hold audited with its reference, got %+v", entries)
+	}
+	if _, ok := actions[models.AuditLegalHoldReleased]; !ok {
+		t.Errorf("Expected the release audited, got %+v", entries)
+	}
+}
+
+// TestLegalHoldRepositories tests that repositories refuse to destroy or alter held data and purges pass it over
+func TestLegalHoldRepositories(t *testing.T) {
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	userRepo := models.NewUserRepository(db.GetDB())
+	owner := &models.User{Email: "owner@example.com", PasswordHash: "hash", FullName: "Owner", IsActive: true}
+	other := &models.User{Email: "other@example.com", PasswordHash: "hash", FullName: "Other", IsActive: true}
+	admin := &models.User{Email: "admin@example.com", PasswordHash: "hash", FullName: "Admin", IsActive: true}
+	for _, user := range []*models.User{owner, other, admin} {
+		if err := userRepo.Create(user); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+	reports, err := services.NewDemoService(models.NewReportRepository(db.GetDB())).ProvisionSampleReports(owner.ID)
+	if err != nil {
+		t.Fatalf("Failed to provision reports: %v", err)
+	}
+
+	chatRepo := models.NewChatMessageRepository(db.GetDB())
+	message := &models.ChatMessage{ReportID: reports[0].ID, UserMessage: "Is this normal?", AIResponse: "Mostly."}
+	if err := chatRepo.Create(message); err != nil {
+		t.Fatalf("Failed to create message: %v", err)
+	}
+	callRepo := models.NewAICallRepository(db.GetDB())
+	for _, call := range []*models.AICall{
+		{UserID: owner.ID, Purpose: "chat"},
+		{ReportID: reports[0].ID, Purpose: "analysis"}, // Logged before the call knew its user
+		{UserID: other.ID, Purpose: "chat"},
+	} {
+		call.Provider, call.Model = "ollama", "llama3.1"
+		if err := callRepo.Create(call); err != nil {
+			t.Fatalf("Failed to create call: %v", err)
+		}
+	}
+	if _, err := db.GetDB().Exec(`UPDATE ai_calls SET created_at = datetime('now', '-31 days')`); err != nil {
+		t.Fatalf("Failed to age calls: %v", err)
+	}
+
+	// Deactivated accounts can be held, since their data outlives them
+	if err := userRepo.Delete(owner.ID); err != nil {
+		t.Fatalf("Failed to deactivate owner: %v", err)
+	}
+	holds := services.NewLegalHoldService(models.NewLegalHoldRepository(db.GetDB()), models.NewAuditLogRepository(db.GetDB()))
+	if _, err := holds.Place(admin, owner.ID+100, "Court order", ""); err != errors.ErrUserNotFound {
+		t.Fatalf("Expected an unknown user refused, got %v", err)
+	}
+	if _, err := holds.Place(admin, owner.ID, "Court order", ""); err != nil {
+		t.Fatalf("Failed to place hold: %v", err)
+	}
+
+	if err := chatRepo.HardDelete(message.ID); err != models.ErrLegalHold {
+		t.Errorf("Expected deleting a held message refused, got %v", err)
+	}
+	if err := chatRepo.SoftDelete(message.ID); err != models.ErrLegalHold {
+		t.Errorf("Expected hiding a held message refused, got %v", err)
+	}
+
+	// Merging would delete one report and clear the other's analysis, so neither may change
+	reportRepo := models.NewReportRepository(db.GetDB())
+	target, source := reports[0], reports[1]
+	if merged, err := models.NewReportPartRepository(db.GetDB()).Merge(target.ID, source.ID); err != models.ErrLegalHold || merged {
+		t.Errorf("Expected merging held reports refused, got %v (%v)", merged, err)
+	}
+	if kept, _ := reportRepo.GetByID(source.ID); kept == nil {
+		t.Error("Expected the refused merge to keep its source")
+	}
+	if kept, _ := reportRepo.GetByID(target.ID); kept == nil || kept.SimplifiedSummary == "" || kept.ProcessingStatus != "completed" {
+		t.Errorf("Expected the refused merge to keep its target's analysis, got %+v", kept)
+	}
+
+	// A stored analysis can't be cleared for reprocessing, replaced, or re-run, nor the report's details edited
+	if claimed, err := reportRepo.ClaimForProcessing(target.ID, "completed"); err != models.ErrLegalHold || claimed {
+		t.Errorf("Expected reprocessing a held report refused, got %v (%v)", claimed, err)
+	}
+	if err := reportRepo.UpdateProcessingStatus(target.ID, "completed", `{}`); err != models.ErrLegalHold {
+		t.Errorf("Expected replacing a held analysis refused, got %v", err)
+	}
+	if err := reportRepo.UpdateSummary(target.ID, `{}`); err != models.ErrLegalHold {
+		t.Errorf("Expected rewriting a held analysis refused, got %v", err)
+	}
+	if err := reportRepo.UpdateDetails(target.ID, models.ReportDetails{Title: "Renamed"}); err != models.ErrLegalHold {
+		t.Errorf("Expected editing a held report refused, got %v", err)
+	}
+	stale, err := models.NewReanalysisRepository(db.GetDB()).SelectStale(models.ReanalysisFilter{PromptVersion: "next", Limit: 10})
+	if err != nil || len(stale) != 0 {
+		t.Errorf("Expected held reports left out of re-analysis, got %v (%v)", stale, err)
+	}
+	if err := models.NewHealthProfileRepository(db.GetDB()).Delete(owner.ID); err != models.ErrLegalHold {
+		t.Errorf("Expected deleting a held profile refused, got %v", err)
+	}
+	removed, err := callRepo.DeleteBefore(time.Now().Add(-30 * 24 * time.Hour))
+	if err != nil || removed != 1 {
+		t.Errorf("Expected only the other user's call purged, got %d (%v)", removed, err)
+	}
+
+	if err := holds.Release(admin, owner.ID); err != nil {
+		t.Fatalf("Failed to release hold: %v", err)
+	}
+	if err := chatRepo.HardDelete(message.ID); err != nil {
+		t.Errorf("Expected the message deleted after release, got %v", err)
+	}
+	if merged, err := models.NewReportPartRepository(db.GetDB()).Merge(target.ID, source.ID); err != nil || !merged {
+		t.Errorf("Expected the reports merged after release, got %v (%v)", merged, err)
+	}
+	if removed, _ := callRepo.DeleteBefore(time.Now().Add(-30 * 24 * time.Hour)); removed != 2 {
+		t.Errorf("Expected the held calls purged after release, got %d", removed)
+	}
+}
@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+)
+
+// TestLLMRateLimiter tests quota pacing, 429 retries, and coalescing of identical calls
+func TestLLMRateLimiter(t *testing.T) {
+	t.Run("TokenBudget", func(t *testing.T) {
+		limiter := services.NewLLMRateLimiter(0, 6000)
+
+		start := time.Now()
+		if err := limiter.Wait(context.Background(), 6000); err != nil {
+			t.Fatalf("Wait failed: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+			t.Errorf("Expected the first minute's budget to be available immediately, waited %s", elapsed)
+		}
+
+		// 50 tokens at 6000/min refill in 500ms
+		start = time.Now()
+		if err := limiter.Wait(context.Background(), 50); err != nil {
+			t.Fatalf("Wait failed: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+			t.Errorf("Expected to wait for the token budget, waited %s", elapsed)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		if err := limiter.Wait(ctx, 6000); err == nil {
+			t.Error("Expected a cancelled wait to fail")
+		}
+		if stats := limiter.Stats(); stats.Waits != 2 || stats.TokensPerMinute != 6000 {
+			t.Errorf("Unexpected stats %+v", stats)
+		}
+	})
+
+	var calls atomic.Int32
+	var throttle atomic.Bool
+	modelServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if throttle.CompareAndSwap(true, false) {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{"message": map[string]string{"role": "assistant", "content": "All normal."}}},
+		})
+	}))
+	defer modelServer.Close()
+
+	aiService, err := services.NewAIService(config.AIConfig{
+		Provider:         "ollama",
+		OllamaURL:        modelServer.URL,
+		OllamaModel:      "llama3.1",
+		PromptPath:       "does-not-exist.txt",
+		RateLimitRetries: 2,
+		RateLimitBackoff: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create AI service: %v", err)
+	}
+	defer aiService.Close()
+
+	t.Run("RetriesRateLimited", func(t *testing.T) {
+		calls.Store(0)
+		throttle.Store(true)
+
+		answer, err := aiService.AnswerQuestion(context.Background(), "", "", nil, "Is my report fine?", "standard", "")
+		if err != nil || answer != "All normal." {
+			t.Fatalf("Expected the retry to succeed, got %q: %v", answer, err)
+		}
+		if calls.Load() != 2 {
+			t.Errorf("Expected one rejected and one successful call, got %d", calls.Load())
+		}
+		if stats := aiService.LimiterStats(); stats.RateLimitRetries != 1 {
+			t.Errorf("Expected one recorded retry, got %+v", stats)
+		}
+	})
+
+	t.Run("CoalescesIdenticalPrompts", func(t *testing.T) {
+		calls.Store(0)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if answer, err := aiService.AnswerQuestion(context.Background(), "", "", nil, "What does TSH mean?", "standard", ""); err != nil || answer != "All normal." {
+					t.Errorf("Unexpected answer %q: %v", answer, err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if calls.Load() != 1 {
+			t.Errorf("Expected identical concurrent questions to share one call, got %d", calls.Load())
+		}
+		if stats := aiService.LimiterStats(); stats.Coalesced != 4 {
+			t.Errorf("Expected four coalesced calls, got %+v", stats)
+		}
+	})
+}
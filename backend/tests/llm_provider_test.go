@@ -0,0 +1,235 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+)
+
+// TestOllamaProvider tests analysis and chat against a self-hosted OpenAI-compatible model server
+func TestOllamaProvider(t *testing.T) {
+	var prompts []string
+	modelServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			http.NotFound(w, r)
+			return
+		}
+
+		var req struct {
+			Model    string `json:"model"`
+			Messages []struct {
+				Role    string `json:"role"`
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Messages) != 2 {
+			t.Errorf("Unexpected request: %v %+v", err, req)
+			return
+		}
+		if req.Model != "llama3.1" || req.Messages[0].Role != "system" || !strings.Contains(req.Messages[0].Content, "Ava") {
+			t.Errorf("Expected model and persona system message, got %+v", req)
+		}
+		prompts = append(prompts, req.Messages[1].Content)
+
+		reply := "Your LDL is slightly high."
+		if strings.Contains(req.Messages[1].Content, "LDL 160") {
+			reply = "```json\n" + `{"summary":"Elevated LDL","simple_summary":"Cholesterol is a bit high","health_metrics":[{"name":"LDL","value":160,"unit":"mg/dL","score":55}],"risk_level":"medium"}` + "\n```"
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{"message": map[string]string{"role": "assistant", "content": reply}}},
+		})
+	}))
+	defer modelServer.Close()
+
+	aiService, err := services.NewAIService(config.AIConfig{
+		Provider:    "ollama",
+		OllamaURL:   modelServer.URL + "/",
+		OllamaModel: "llama3.1",
+		PromptPath:  "does-not-exist.txt", // Falls back to the built-in template
+		Persona:     config.PersonaConfig{Name: "Ava"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create Ollama-backed AI service: %v", err)
+	}
+	defer aiService.Close()
+
+	if name := aiService.ProviderName(); name != "ollama:llama3.1" {
+		t.Errorf("Unexpected provider name %q", name)
+	}
+
+	reportPath := filepath.Join(t.TempDir(), "lipids.txt")
+	if err := os.WriteFile(reportPath, []byte("LDL 160 mg/dL"), 0644); err != nil {
+		t.Fatalf("Failed to write report: %v", err)
+	}
+	analysis, err := aiService.AnalyzeReport(context.Background(), reportPath, "text/plain", "standard", models.PlanFree, "")
+	if err != nil {
+		t.Fatalf("Analysis failed: %v", err)
+	}
+	if analysis.ParseFailed || !strings.Contains(analysis.ResultJSON, `"Elevated LDL"`) {
+		t.Errorf("Expected parsed analysis, got %+v", analysis)
+	}
+
+	answer, err := aiService.AnswerQuestion(context.Background(), "Elevated LDL", "", nil, "Is my cholesterol ok?", "standard", "")
+	if err != nil || answer != "Your LDL is slightly high." {
+		t.Errorf("Unexpected chat answer %q: %v", answer, err)
+	}
+	if len(prompts) != 2 || !strings.Contains(prompts[1], "Is my cholesterol ok?") {
+		t.Errorf("Expected the chat prompt to reach the model server, got %q", prompts)
+	}
+
+	// Server errors surface instead of producing an empty analysis
+	modelServer.Close()
+	if _, err := aiService.AnswerQuestion(context.Background(), "", "", nil, "Still there?", "standard", ""); err == nil {
+		t.Error("Expected an error when the model server is down")
+	}
+
+	if _, err := services.NewAIService(config.AIConfig{Provider: "openai"}); err == nil {
+		t.Error("Expected an error for an unknown provider")
+	}
+}
+
+// TestReadingLevelPrompts tests that the reading level changes the analysis and chat instructions
+func TestReadingLevelPrompts(t *testing.T) {
+	var lastPrompt string
+	modelServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		lastPrompt = req.Messages[len(req.Messages)-1].Content
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{"message": map[string]string{"role": "assistant", "content": `{"summary":"ok","simple_summary":"ok"}`}}},
+		})
+	}))
+	defer modelServer.Close()
+
+	aiService, err := services.NewAIService(config.AIConfig{
+		Provider:    "ollama",
+		OllamaURL:   modelServer.URL,
+		OllamaModel: "llama3.1",
+		PromptPath:  "../prompts/medical_analysis_prompt.txt",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create AI service: %v", err)
+	}
+	defer aiService.Close()
+
+	reportPath := filepath.Join(t.TempDir(), "cbc.txt")
+	os.WriteFile(reportPath, []byte("Hb 13.2 g/dL"), 0644)
+
+	if _, err := aiService.AnalyzeReport(context.Background(), reportPath, "text/plain", models.ReadingLevelClinical, models.PlanFree, ""); err != nil {
+		t.Fatalf("Analysis failed: %v", err)
+	}
+	if !strings.Contains(lastPrompt, "for a clinician") || strings.Contains(lastPrompt, "{{READING_LEVEL}}") {
+		t.Errorf("Expected clinical guidance in the analysis prompt, got %q", lastPrompt)
+	}
+	if strings.Contains(lastPrompt, "Patient profile") {
+		t.Errorf("Expected no profile section without a profile, got %q", lastPrompt)
+	}
+
+	// The health profile is added to both prompts when the user saved one
+	if _, err := aiService.AnalyzeReport(context.Background(), reportPath, "text/plain", models.ReadingLevelStandard, models.PlanFree, "age 54; sex female"); err != nil {
+		t.Fatalf("Analysis failed: %v", err)
+	}
+	if !strings.Contains(lastPrompt, "Patient profile, as entered by the patient: age 54; sex female") {
+		t.Errorf("Expected the profile in the analysis prompt, got %q", lastPrompt)
+	}
+	if _, err := aiService.AnswerQuestion(context.Background(), "ok", "", nil, "Is this bad?", models.ReadingLevelStandard, "allergies: penicillin"); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if !strings.Contains(lastPrompt, "PATIENT PROFILE") || !strings.Contains(lastPrompt, "allergies: penicillin") {
+		t.Errorf("Expected the profile in the chat prompt, got %q", lastPrompt)
+	}
+
+	if _, err := aiService.AnswerQuestion(context.Background(), "ok", "", nil, "Is this bad?", models.ReadingLevelChild, ""); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if !strings.Contains(lastPrompt, "10-year-old") {
+		t.Errorf("Expected child guidance in the chat prompt, got %q", lastPrompt)
+	}
+
+	// Unknown levels, e.g. from rows older than the column, fall back to standard
+	if _, err := aiService.AnswerQuestion(context.Background(), "ok", "", nil, "And now?", "", ""); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if !strings.Contains(lastPrompt, "non-expert") {
+		t.Errorf("Expected standard guidance by default, got %q", lastPrompt)
+	}
+}
+
+// TestRegionalReportTranslation tests that a report in a regional script is translated before analysis
+func TestRegionalReportTranslation(t *testing.T) {
+	var prompts []string
+	modelServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		prompt := req.Messages[len(req.Messages)-1].Content
+		prompts = append(prompts, prompt)
+
+		reply := `{"summary":"Normal hemoglobin","simple_summary":"ok"}`
+		if strings.Contains(prompt, "Translate it into English") {
+			reply = "Hemoglobin 13.5 g/dL (normal range 13.0-17.0)"
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{"message": map[string]string{"role": "assistant", "content": reply}}},
+		})
+	}))
+	defer modelServer.Close()
+
+	aiService, err := services.NewAIService(config.AIConfig{
+		Provider:    "ollama",
+		OllamaURL:   modelServer.URL,
+		OllamaModel: "llama3.1",
+		PromptPath:  "../prompts/medical_analysis_prompt.txt",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create AI service: %v", err)
+	}
+	defer aiService.Close()
+
+	reportPath := filepath.Join(t.TempDir(), "cbc.txt")
+	os.WriteFile(reportPath, []byte("ಹಿಮೋಗ್ಲೋಬಿನ್ ೧೩.೫ g/dL ಸಾಮಾನ್ಯ ವ್ಯಾಪ್ತಿ ೧೩.೦-೧೭.೦"), 0644)
+
+	analysis, err := aiService.AnalyzeReport(context.Background(), reportPath, "text/plain", models.ReadingLevelStandard, models.PlanFree, "")
+	if err != nil {
+		t.Fatalf("Analysis failed: %v", err)
+	}
+	if analysis.Language != "kn" {
+		t.Errorf("Expected the report recorded as printed in Kannada, got %q", analysis.Language)
+	}
+	if len(prompts) != 2 {
+		t.Fatalf("Expected a translation call and an analysis call, got %d prompts", len(prompts))
+	}
+	if !strings.Contains(prompts[0], "printed in Kannada") || !strings.Contains(prompts[0], "13.5 g/dL") {
+		t.Errorf("Expected a Kannada translation prompt with normalized digits, got %q", prompts[0])
+	}
+	if !strings.Contains(prompts[1], "Hemoglobin 13.5 g/dL") || strings.Contains(prompts[1], "ಹಿಮೋಗ್ಲೋಬಿನ್") {
+		t.Errorf("Expected the analysis to see only the translation, got %q", prompts[1])
+	}
+
+	// An English cover page doesn't make a regional report English
+	coverPath := filepath.Join(t.TempDir(), "cover.txt")
+	os.WriteFile(coverPath, []byte("City Diagnostics, Complete Blood Count"), 0644)
+	analysis, err = aiService.AnalyzeParts(context.Background(), []string{coverPath, reportPath}, "text/plain", models.ReadingLevelStandard, models.PlanFree, "")
+	if err != nil {
+		t.Fatalf("Analysis failed: %v", err)
+	}
+	if analysis.Language != "kn" {
+		t.Errorf("Expected the two-part report recorded as printed in Kannada, got %q", analysis.Language)
+	}
+}
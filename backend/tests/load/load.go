@@ -0,0 +1,256 @@
+// Package load runs concurrent patient journeys against a running API and checks endpoint latencies against budgets
+//
+//	report, err := load.Run(ctx, load.Options{BaseURL: "http://localhost:8080", Users: 20, Iterations: 5})
+//	violations := report.Check(load.DefaultBudgets, 0.01)
+//
+// Each journey signs up a new account, logs in, uploads a small text report, lists the account's reports,
+// and reads the metrics of the first analyzed one when there is one.
+package load
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/client"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// Endpoints a journey calls, in order
+const (
+	EndpointSignup  = "POST /api/auth/signup"
+	EndpointLogin   = "POST /api/auth/login"
+	EndpointUpload  = "POST /api/reports"
+	EndpointList    = "GET /api/reports"
+	EndpointMetrics = "GET /api/reports/{id}/metrics"
+)
+
+var endpoints = []string{EndpointSignup, EndpointLogin, EndpointUpload, EndpointList, EndpointMetrics}
+
+// DefaultBudgets are the 95th percentile latencies the core endpoints must stay within
+// Decision: Signup and login hash a password at the production bcrypt cost, so they get the loosest budgets
+var DefaultBudgets = map[string]time.Duration{
+	EndpointSignup:  1500 * time.Millisecond,
+	EndpointLogin:   1500 * time.Millisecond,
+	EndpointUpload:  time.Second,
+	EndpointList:    300 * time.Millisecond,
+	EndpointMetrics: 300 * time.Millisecond,
+}
+
+// sampleReport is the report each journey uploads; small enough that the upload measures the API, not the network
+const sampleReport = "COMPLETE BLOOD COUNT\nHemoglobin 13.5 g/dL (13.0-17.0)\nWBC 7.2 x10^3/uL (4.0-11.0)\nPlatelets 250 x10^3/uL (150-400)\n"
+
+// Options configures a run
+type Options struct {
+	BaseURL    string
+	Users      int           // Virtual users running journeys at once; defaults to 10
+	Iterations int           // Journeys each virtual user runs; defaults to 1
+	Password   string        // Password of the accounts the journeys create; defaults to a fixed one
+	Timeout    time.Duration // Bounds each request; defaults to client.DefaultTimeout
+}
+
+// Result is one endpoint's latencies over a run
+type Result struct {
+	Endpoint string
+	Requests int
+	Errors   int
+	P50      time.Duration
+	P95      time.Duration
+	P99      time.Duration
+	Max      time.Duration
+}
+
+// ErrorRate is the share of the endpoint's requests that failed
+func (r Result) ErrorRate() float64 {
+	if r.Requests == 0 {
+		return 0
+	}
+	return float64(r.Errors) / float64(r.Requests)
+}
+
+// Report is the outcome of a run
+type Report struct {
+	Journeys  int
+	Duration  time.Duration
+	Results   []Result // In journey order
+	LastError error    // The last request error seen, to explain error counts
+}
+
+// Result returns the endpoint's result, or a zero Result when it was never called
+func (r *Report) Result(endpoint string) Result {
+	for _, result := range r.Results {
+		if result.Endpoint == endpoint {
+			return result
+		}
+	}
+	return Result{Endpoint: endpoint}
+}
+
+// Check returns one message per endpoint over its p95 budget or maxErrorRate; none means the run passed
+// Decision: An endpoint no journey reached, such as metrics on a server that analyzes nothing, is not a violation
+func (r *Report) Check(budgets map[string]time.Duration, maxErrorRate float64) []string {
+	var violations []string
+	for _, result := range r.Results {
+		if result.Requests == 0 {
+			continue
+		}
+		if rate := result.ErrorRate(); rate > maxErrorRate {
+			violations = append(violations, fmt.Sprintf("%s: %.1f%% of requests failed, budget %.1f%%",
+				result.Endpoint, rate*100, maxErrorRate*100))
+		}
+		if budget, ok := budgets[result.Endpoint]; ok && result.P95 > budget {
+			violations = append(violations, fmt.Sprintf("%s: p95 %s over its %s budget",
+				result.Endpoint, result.P95.Round(time.Millisecond), budget))
+		}
+	}
+	return violations
+}
+
+// String formats the report as a table
+func (r *Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d journeys in %s\n", r.Journeys, r.Duration.Round(time.Millisecond))
+	fmt.Fprintf(&b, "%-32s %8s %7s %9s %9s %9s %9s\n", "ENDPOINT", "REQUESTS", "ERRORS", "P50", "P95", "P99", "MAX")
+	for _, result := range r.Results {
+		fmt.Fprintf(&b, "%-32s %8d %7d %9s %9s %9s %9s\n", result.Endpoint, result.Requests, result.Errors,
+			result.P50.Round(time.Millisecond), result.P95.Round(time.Millisecond),
+			result.P99.Round(time.Millisecond), result.Max.Round(time.Millisecond))
+	}
+	if r.LastError != nil {
+		fmt.Fprintf(&b, "last error: %v\n", r.LastError)
+	}
+	return b.String()
+}
+
+// recorder collects latencies from every virtual user
+type recorder struct {
+	mu        sync.Mutex
+	latencies map[string][]time.Duration
+	errors    map[string]int
+	lastError error
+}
+
+// time calls fn and records its latency under endpoint
+func (rec *recorder) time(endpoint string, fn func() error) error {
+	start := time.Now()
+	err := fn()
+	elapsed := time.Since(start)
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.latencies[endpoint] = append(rec.latencies[endpoint], elapsed)
+	if err != nil {
+		rec.errors[endpoint]++
+		rec.lastError = fmt.Errorf("%s: %w", endpoint, err)
+	}
+	return err
+}
+
+// Run starts opts.Users virtual users, each running opts.Iterations journeys back to back, and waits for them
+func Run(ctx context.Context, opts Options) (*Report, error) {
+	if opts.BaseURL == "" {
+		return nil, fmt.Errorf("a base URL is required")
+	}
+	if opts.Users <= 0 {
+		opts.Users = 10
+	}
+	if opts.Iterations <= 0 {
+		opts.Iterations = 1
+	}
+	if opts.Password == "" {
+		opts.Password = "load-test-password"
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = client.DefaultTimeout
+	}
+
+	rec := &recorder{latencies: map[string][]time.Duration{}, errors: map[string]int{}}
+	// Decision: Accounts are named after the run's start so repeated runs against one server don't collide
+	runID := time.Now().UnixNano()
+	// Decision: Virtual users share one transport that keeps a connection per user, as browsers would, so the
+	// run measures requests rather than TCP handshakes
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = opts.Users
+	httpClient := &http.Client{Timeout: opts.Timeout, Transport: transport}
+	defer transport.CloseIdleConnections()
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	for user := range opts.Users {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for iteration := range opts.Iterations {
+				if ctx.Err() != nil {
+					return
+				}
+				email := fmt.Sprintf("load-%d-%d-%d@example.com", runID, user, iteration)
+				journey(ctx, rec, httpClient, opts, email)
+			}
+		}()
+	}
+	wg.Wait()
+
+	report := &Report{
+		Journeys:  opts.Users * opts.Iterations,
+		Duration:  time.Since(start),
+		LastError: rec.lastError,
+	}
+	for _, endpoint := range endpoints {
+		report.Results = append(report.Results, summarize(endpoint, rec.latencies[endpoint], rec.errors[endpoint]))
+	}
+	return report, ctx.Err()
+}
+
+// journey runs one patient's visit, stopping at the first failed step since later steps depend on it
+func journey(ctx context.Context, rec *recorder, httpClient *http.Client, opts Options, email string) {
+	c := client.New(opts.BaseURL, "")
+	c.SetUserAgent("medreport-load-test")
+	c.SetHTTPClient(httpClient)
+
+	signup := types.SignupRequest{Email: email, Password: opts.Password, FullName: "Load Test"}
+	if rec.time(EndpointSignup, func() error { _, err := c.Signup(ctx, signup); return err }) != nil {
+		return
+	}
+	if rec.time(EndpointLogin, func() error { _, err := c.Login(ctx, email, opts.Password); return err }) != nil {
+		return
+	}
+	if rec.time(EndpointUpload, func() error {
+		_, err := c.UploadReport(ctx, "cbc.txt", strings.NewReader(sampleReport), client.UploadOptions{ContentType: "text/plain"})
+		return err
+	}) != nil {
+		return
+	}
+
+	var reports *types.ReportListResponse
+	if rec.time(EndpointList, func() (err error) { reports, err = c.ListReports(ctx, 20, 0); return err }) != nil {
+		return
+	}
+	for _, report := range reports.Reports {
+		if report.ProcessedAt != nil && report.ErrorCode == "" {
+			rec.time(EndpointMetrics, func() error { _, err := c.GetHealthMetrics(ctx, report.ID, ""); return err })
+			return
+		}
+	}
+}
+
+// summarize computes an endpoint's percentiles from its latencies
+func summarize(endpoint string, latencies []time.Duration, errors int) Result {
+	result := Result{Endpoint: endpoint, Requests: len(latencies), Errors: errors}
+	if len(latencies) == 0 {
+		return result
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	percentile := func(p float64) time.Duration {
+		return latencies[min(int(float64(len(latencies))*p), len(latencies)-1)]
+	}
+	result.P50 = percentile(0.50)
+	result.P95 = percentile(0.95)
+	result.P99 = percentile(0.99)
+	result.Max = latencies[len(latencies)-1]
+	return result
+}
@@ -0,0 +1,196 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/logging"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/tests/fixtures"
+)
+
+// captureLogs makes a JSON logger writing to the returned buffer the default until the test ends
+func captureLogs(t *testing.T, level string) *bytes.Buffer {
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, config.LogConfig{Level: level, Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	previous := slog.Default()
+	slog.SetDefault(logger)
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &buf
+}
+
+// logRecords decodes the JSON records written to buf
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Failed to decode log record %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// TestLogRedaction tests that records carry their context's attributes and never the content or identifiers of a patient
+func TestLogRedaction(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, config.LogConfig{Level: "info", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	ctx := logging.With(logging.WithRequestID(context.Background(), "req-1"), "report_id", 42)
+	logger.DebugContext(ctx, "Below the level")
+	logger.With("summary", "Mild anaemia").InfoContext(ctx, "Analysis for asha@example.com stored",
+		"prompt", "Patient Asha Rao, haemoglobin 9.1", "error", errors.New("call +91 98765 43210 failed"),
+		slog.Group("reply", "content", "You have anaemia"), "characters", 120)
+
+	records := logRecords(t, &buf)
+	if len(records) != 1 {
+		t.Fatalf("Expected the debug record dropped, got %d records: %s", len(records), buf.String())
+	}
+	record := records[0]
+	if record["request_id"] != "req-1" || record["report_id"] != float64(42) || record["characters"] != float64(120) {
+		t.Errorf("Expected the context's attributes and plain numbers kept, got %v", record)
+	}
+	if record["prompt"] != "[REDACTED 33 chars]" || record["summary"] != "[REDACTED 12 chars]" {
+		t.Errorf("Expected content redacted to its length, got %v", record)
+	}
+	for _, leaked := range []string{"asha@example.com", "Asha Rao", "98765", "anaemia"} {
+		if strings.Contains(buf.String(), leaked) {
+			t.Errorf("Expected %q redacted, got %s", leaked, buf.String())
+		}
+	}
+	if record["msg"] != "Analysis for [email] stored" || record["error"] != "call [phone] failed" {
+		t.Errorf("Expected identifiers scrubbed from the message and error, got %v", record)
+	}
+	if logging.RequestID(ctx) != "req-1" || logging.RequestID(context.Background()) != "" {
+		t.Errorf("Expected the request ID read back from the context")
+	}
+
+	for _, cfg := range []config.LogConfig{{Level: "verbose", Format: "json"}, {Level: "info", Format: "xml"}} {
+		if _, err := logging.New(&buf, cfg); err == nil {
+			t.Errorf("Expected %+v refused", cfg)
+		}
+	}
+}
+
+// TestRequestLogging tests that requests are tagged with an ID, echoed to the client, and logged by route and user
+func TestRequestLogging(t *testing.T) {
+	server := setupTestServer(t)
+	defer server.Close()
+	token := signupAndGetToken(t, server.URL, "request-log@example.com")
+	logs := captureLogs(t, "info")
+
+	get := func(path, requestID string) *http.Response {
+		req, _ := http.NewRequest("GET", server.URL+path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		if requestID != "" {
+			req.Header.Set(middleware.RequestIDHeader, requestID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to call %s: %v", path, err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	if got := get("/api/auth/me", "trace-abc.1").Header.Get(middleware.RequestIDHeader); got != "trace-abc.1" {
+		t.Errorf("Expected the caller's request ID echoed, got %q", got)
+	}
+	generated := get("/api/reports/999999", "bad id\twith spaces").Header.Get(middleware.RequestIDHeader)
+	if len(generated) != 16 || strings.Contains(generated, " ") {
+		t.Errorf("Expected an unusable request ID replaced by a generated one, got %q", generated)
+	}
+	if got := get("/api/no-such-route", "").Header.Get(middleware.RequestIDHeader); got == "" {
+		t.Errorf("Expected unknown routes tagged too")
+	}
+	get("/health", "")
+
+	byID := make(map[string]map[string]any)
+	for _, record := range logRecords(t, logs) {
+		if record["msg"] == "Request handled" {
+			byID[record["request_id"].(string)] = record
+		}
+	}
+	if me := byID["trace-abc.1"]; me == nil || me["route"] != "/api/auth/me" || me["status"] != float64(200) || me["user_id"] == nil {
+		t.Errorf("Expected the request logged with its route, status, and user, got %v", me)
+	}
+	if report := byID[generated]; report == nil || report["route"] != "/api/reports/{id:[0-9]+}" || report["status"] != float64(404) {
+		t.Errorf("Expected the route template logged rather than the path, got %v", report)
+	}
+	if len(byID) != 3 {
+		t.Errorf("Expected health checks kept out of info logs, got %d requests logged", len(byID))
+	}
+}
+
+// TestAnalysisLogsNoReportContent tests that analyzing a report logs its progress but none of its text or the model's reply
+func TestAnalysisLogsNoReportContent(t *testing.T) {
+	report := fixtures.Reports[0]
+	reply, err := report.ModelReply()
+	if err != nil {
+		t.Fatal(err)
+	}
+	modelServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{"message": map[string]string{"role": "assistant", "content": reply}}},
+		})
+	}))
+	defer modelServer.Close()
+	aiService, err := services.NewAIService(config.AIConfig{Provider: "ollama", OllamaURL: modelServer.URL, OllamaModel: "llama3.1"})
+	if err != nil {
+		t.Fatalf("Failed to create AI service: %v", err)
+	}
+	defer aiService.Close()
+
+	path, err := report.Save(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	logs := captureLogs(t, "debug")
+	ctx := logging.With(context.Background(), "report_id", 7)
+	if _, err := aiService.AnalyzeReport(ctx, path, "", "standard", models.PlanFree, ""); err != nil {
+		t.Fatalf("Failed to analyze: %v", err)
+	}
+
+	var sent bool
+	for _, record := range logRecords(t, logs) {
+		if record["msg"] == "Sending analysis prompt" && record["report_id"] == float64(7) {
+			sent = true
+		}
+	}
+	if !sent {
+		t.Errorf("Expected the prompt's size logged with the report it belongs to, got %s", logs.String())
+	}
+
+	text, _ := os.ReadFile(path)
+	analysis, err := services.ParseAnalysisResponse(reply)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := append(strings.Split(string(text), "\n"), analysis.Summary, analysis.SimpleSummary)
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); len(line) > 20 && strings.Contains(logs.String(), line) {
+			t.Errorf("Expected report content kept out of the logs, found %q", line)
+		}
+	}
+}
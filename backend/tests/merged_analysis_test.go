@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// TestMergedAnalysis tests combining a checkup's reports and linking the result back to them
+func TestMergedAnalysis(t *testing.T) {
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	userRepo := models.NewUserRepository(db.GetDB())
+	owner := &models.User{Email: "owner@example.com", PasswordHash: "hash", FullName: "Owner", IsActive: true}
+	other := &models.User{Email: "other@example.com", PasswordHash: "hash", FullName: "Other", IsActive: true}
+	for _, user := range []*models.User{owner, other} {
+		if err := userRepo.Create(user); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+
+	reportRepo := models.NewReportRepository(db.GetDB())
+	reports, err := services.NewDemoService(reportRepo).ProvisionSampleReports(owner.ID)
+	if err != nil {
+		t.Fatalf("Failed to provision reports: %v", err)
+	}
+	otherReports, _ := services.NewDemoService(reportRepo).ProvisionSampleReports(other.ID)
+
+	merger := services.NewMergedAnalysisService(models.NewMergedAnalysisRepository(db.GetDB()), reportRepo, services.NewDemoAnalyzer())
+	cbc, lipids, thyroid := reports[0].ID, reports[1].ID, reports[2].ID
+
+	// Invalid selections are refused before any model call
+	for name, ids := range map[string][]int{
+		"single report":    {cbc},
+		"duplicate":        {cbc, cbc},
+		"another's report": {cbc, otherReports[0].ID},
+	} {
+		if _, err := merger.Merge(owner.ID, ids, "", models.ReadingLevelStandard, models.PlanFree); err == nil {
+			t.Errorf("Expected %s to be refused", name)
+		}
+	}
+
+	merged, err := merger.Merge(owner.ID, []int{cbc, lipids, thyroid}, "", models.ReadingLevelStandard, models.PlanFree)
+	if err != nil {
+		t.Fatalf("Failed to merge reports: %v", err)
+	}
+	if len(merged.Sources) != 3 || *merged.Sources[1].ReportID != lipids || merged.Sources[1].Label != "lipid_panel.pdf" {
+		t.Errorf("Expected sources in the order given, got %+v", merged.Sources)
+	}
+	analysis, err := services.ParseStoredAnalysis(merged.Analysis)
+	if err != nil || analysis.RiskLevel != "medium" || len(analysis.HealthMetrics) != 8 {
+		t.Errorf("Expected a combined analysis with every source's metrics, got %+v (%v)", analysis, err)
+	}
+
+	// Deleting a source keeps the merged analysis but drops the link
+	if err := reportRepo.Delete(thyroid); err != nil {
+		t.Fatalf("Failed to delete report: %v", err)
+	}
+	stored, err := merger.Get(owner.ID, merged.ID)
+	if err != nil || stored.Sources[2].ReportID != nil || stored.Sources[2].Label != "thyroid_profile.txt" {
+		t.Errorf("Expected the deleted source to keep its label without a link, got %+v (%v)", stored, err)
+	}
+	if _, err := merger.Get(other.ID, merged.ID); err == nil {
+		t.Error("Expected another user's merged analysis to be hidden")
+	}
+
+	// HTTP: only completed reports can be merged
+	server := setupTestServer(t)
+	defer server.Close()
+	token := signupAndGetToken(t, server.URL, "merge@example.com")
+	first := uploadTestReport(t, server.URL, token, "cbc.txt", "Hemoglobin 13.5 g/dL")
+	second := uploadTestReport(t, server.URL, token, "lipids.txt", "LDL 120 mg/dL")
+
+	status := doJSONRequest(t, "POST", server.URL+"/api/analyses/merge", token, types.MergeAnalysisRequest{ReportIDs: []int{first, second}}, nil)
+	if status != http.StatusBadRequest {
+		t.Errorf("Expected pending reports to be refused, got %d", status)
+	}
+	if status := doJSONRequest(t, "GET", server.URL+"/api/analyses/999", token, nil, nil); status != http.StatusNotFound {
+		t.Errorf("Expected unknown analysis to be not found, got %d", status)
+	}
+	var list types.MergedAnalysisListResponse
+	if status := doJSONRequest(t, "GET", server.URL+"/api/analyses", token, nil, &list); status != http.StatusOK || len(list.Analyses) != 0 {
+		t.Errorf("Expected an empty list, got %d %+v", status, list)
+	}
+}
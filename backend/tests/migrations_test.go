@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/selftest"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/migrations"
+)
+
+// TestMigrations tests that Setup creates the schema on a fresh database, records it as goose does, and applies nothing twice
+func TestMigrations(t *testing.T) {
+	cfg := &config.Config{Database: config.DatabaseConfig{
+		Driver:      "sqlite3",
+		DSN:         filepath.Join(t.TempDir(), "fresh.db"),
+		AutoMigrate: true,
+	}}
+	db, err := database.Setup(cfg)
+	if err != nil {
+		t.Fatalf("Failed to setup database: %v", err)
+	}
+	defer db.Close()
+
+	// The repositories work against the migrated schema without any test tables
+	user := &models.User{Email: "fresh@example.com", PasswordHash: "hash", FullName: "Fresh", IsActive: true}
+	if err := models.NewUserRepository(db.GetDB()).Create(user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	report := &models.Report{UserID: user.ID, OriginalFilename: "cbc.pdf", FilePath: "cbc.pdf", FileType: "pdf", FileSize: 1}
+	if err := models.NewReportRepository(db.GetDB()).Create(report); err != nil {
+		t.Fatalf("Failed to create report: %v", err)
+	}
+	message := &models.ChatMessage{ReportID: report.ID, UserMessage: "What is MCV?", AIResponse: "Red cell size"}
+	if err := models.NewChatMessageRepository(db.GetDB()).Create(message); err != nil {
+		t.Fatalf("Failed to create chat message: %v", err)
+	}
+
+	all, err := migrations.Load()
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	var recorded int
+	db.GetDB().QueryRow(`SELECT COUNT(*) FROM goose_db_version WHERE version_id != 0 AND is_applied`).Scan(&recorded)
+	if recorded != len(all) {
+		t.Errorf("Expected %d migrations recorded, got %d", len(all), recorded)
+	}
+	check := selftest.SchemaCheck(db.GetDB(), "../migrations")
+	if detail, err := check.Run(context.Background()); err != nil {
+		t.Errorf("Expected the schema check to pass after migrating, got %v", err)
+	} else if detail == "" {
+		t.Error("Expected the schema check to report the version")
+	}
+
+	if applied, err := migrations.Apply(context.Background(), db.GetDB()); err != nil || applied != 0 {
+		t.Errorf("Expected nothing left to apply, got %d (%v)", applied, err)
+	}
+
+	// Decision: A version goose rolled back is applied again
+	var canaries migrations.Migration
+	for _, migration := range all {
+		if strings.HasSuffix(migration.Name, "_create_prompt_canaries.sql") {
+			canaries = migration
+		}
+	}
+	if _, err := db.GetDB().Exec(`DROP TABLE prompt_canaries`); err != nil {
+		t.Fatalf("Failed to undo the prompt canary migration: %v", err)
+	}
+	if _, err := db.GetDB().Exec(`INSERT INTO goose_db_version (version_id, is_applied) VALUES (?, 0)`, canaries.Version); err != nil {
+		t.Fatalf("Failed to record the rollback: %v", err)
+	}
+	if applied, err := migrations.Apply(context.Background(), db.GetDB()); err != nil || applied != 1 {
+		t.Errorf("Expected the rolled back migration applied again, got %d (%v)", applied, err)
+	}
+	if _, err := models.NewPromptCanaryRepository(db.GetDB()).GetByVersion("v2"); err != nil {
+		t.Errorf("Expected prompt_canaries recreated: %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/handlers"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// TestChatModeration tests flagging exchanges, the moderation queue, redaction, and account bans
+func TestChatModeration(t *testing.T) {
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	userRepo := models.NewUserRepository(db.GetDB())
+	owner := &models.User{Email: "owner@example.com", PasswordHash: "hash", FullName: "Owner", IsActive: true}
+	other := &models.User{Email: "other@example.com", PasswordHash: "hash", FullName: "Other", IsActive: true}
+	admin := &models.User{Email: "admin@example.com", PasswordHash: "hash", FullName: "Admin", IsActive: true}
+	for _, user := range []*models.User{owner, other, admin} {
+		if err := userRepo.Create(user); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+
+	reportRepo := models.NewReportRepository(db.GetDB())
+	reports, err := services.NewDemoService(reportRepo).ProvisionSampleReports(owner.ID)
+	if err != nil {
+		t.Fatalf("Failed to provision reports: %v", err)
+	}
+	chatRepo := models.NewChatMessageRepository(db.GetDB())
+	abusive := &models.ChatMessage{ReportID: reports[0].ID, UserMessage: "Something abusive", AIResponse: "Let's stay on your report."}
+	filtered := &models.ChatMessage{ReportID: reports[0].ID, UserMessage: "How much iron should I take?", AIResponse: "Ask your doctor."}
+	for _, message := range []*models.ChatMessage{abusive, filtered} {
+		if err := chatRepo.Create(message); err != nil {
+			t.Fatalf("Failed to create chat message: %v", err)
+		}
+	}
+	safetyRepo := models.NewSafetyInterventionRepository(db.GetDB())
+	if err := safetyRepo.Create(&models.SafetyIntervention{UserID: owner.ID, ReportID: reports[0].ID, MessageID: filtered.ID,
+		Level: "softened", Categories: []string{"dosage"}, OriginalAnswer: "Take 200mg of iron daily."}); err != nil {
+		t.Fatalf("Failed to record safety intervention: %v", err)
+	}
+
+	auditRepo := models.NewAuditLogRepository(db.GetDB())
+	moderation := services.NewModerationService(models.NewModerationRepository(db.GetDB()), chatRepo, reportRepo,
+		models.NewChatSummaryRepository(db.GetDB()), userRepo, auditRepo, []string{admin.Email})
+	chatHandler := handlers.NewChatHandler(nil, nil, nil, 0)
+	chatHandler.SetModerationService(moderation)
+	adminHandler := handlers.NewAdminHandler(reportRepo, auditRepo, nil, safetyRepo, nil, nil, nil, nil)
+	adminHandler.SetModerationService(moderation)
+
+	call := func(handler http.HandlerFunc, user *models.User, target string, vars map[string]string, body any, out any) int {
+		payload, _ := json.Marshal(body)
+		req := httptest.NewRequest("POST", target, bytes.NewReader(payload))
+		req = mux.SetURLVars(req.WithContext(context.WithValue(req.Context(), middleware.UserKey, user)), vars)
+		recorder := httptest.NewRecorder()
+		handler(recorder, req)
+		if out != nil {
+			decodeEnvelope(recorder.Body, out)
+		}
+		return recorder.Code
+	}
+	messageVars := func(message *models.ChatMessage) map[string]string {
+		return map[string]string{"messageId": strconv.Itoa(message.ID)}
+	}
+	list := func(query string) []types.FlaggedChatEntry {
+		t.Helper()
+		var entries []types.FlaggedChatEntry
+		if status := call(adminHandler.ListFlaggedChatHandler, admin, "/api/admin/moderation/chat?"+query, nil, nil, &entries); status != http.StatusOK {
+			t.Fatalf("Expected 200 listing %q, got %d", query, status)
+		}
+		return entries
+	}
+
+	// Only the exchange's owner flags it, and flagging twice keeps one flag
+	flag := types.FlagMessageRequest{Reason: "Abusive language"}
+	if status := call(chatHandler.FlagMessageHandler, other, "/", messageVars(abusive), flag, nil); status != http.StatusForbidden {
+		t.Errorf("Expected 403 flagging another user's message, got %d", status)
+	}
+	if status := call(chatHandler.FlagMessageHandler, owner, "/", messageVars(abusive), types.FlagMessageRequest{}, nil); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 flagging without a reason, got %d", status)
+	}
+	for range 2 {
+		if status := call(chatHandler.FlagMessageHandler, owner, "/", messageVars(abusive), flag, nil); status != http.StatusOK {
+			t.Fatalf("Expected 200 flagging a message, got %d", status)
+		}
+	}
+
+	// The queue holds both the user's flag and the safety filter's hit, and filters by source and status
+	if entries := list(""); len(entries) != 2 {
+		t.Fatalf("Expected 2 flagged exchanges, got %+v", entries)
+	}
+	if entries := list("source=user"); len(entries) != 1 || entries[0].MessageID != abusive.ID || entries[0].FlagCount != 1 {
+		t.Errorf("Expected the user-flagged exchange once, got %+v", entries)
+	}
+	if entries := list("source=safety&user_id=" + strconv.Itoa(owner.ID)); len(entries) != 1 || entries[0].MessageID != filtered.ID {
+		t.Errorf("Expected the filtered exchange, got %+v", entries)
+	}
+	if status := call(adminHandler.ListFlaggedChatHandler, admin, "/api/admin/moderation/chat?source=email", nil, nil, nil); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown source, got %d", status)
+	}
+
+	// Dismissing closes the exchange until a user flags it again
+	var dismissed types.FlaggedChatEntry
+	if status := call(adminHandler.DismissFlaggedChatHandler, admin, "/", messageVars(abusive), types.DismissChatRequest{Note: "Fine"}, &dismissed); status != http.StatusOK {
+		t.Fatalf("Expected 200 dismissing, got %d", status)
+	}
+	if dismissed.Open || dismissed.Action != models.ModerationDismissed {
+		t.Errorf("Expected a dismissed exchange, got %+v", dismissed)
+	}
+	if entries := list("status=open"); len(entries) != 1 || entries[0].MessageID != filtered.ID {
+		t.Errorf("Expected only the filtered exchange open, got %+v", entries)
+	}
+	if err := moderation.Flag(owner.ID, abusive.ID, "Still abusive"); err != nil {
+		t.Fatalf("Failed to flag again: %v", err)
+	}
+	if entries := list("status=open&source=user"); len(entries) != 1 {
+		t.Errorf("Expected a new flag to reopen the exchange, got %+v", entries)
+	}
+
+	// Redacting replaces the content everywhere it is stored
+	var redacted types.FlaggedChatEntry
+	if status := call(adminHandler.RedactFlaggedChatHandler, admin, "/", messageVars(filtered), types.RedactChatRequest{Answer: true}, &redacted); status != http.StatusOK {
+		t.Fatalf("Expected 200 redacting, got %d", status)
+	}
+	if redacted.Open || redacted.AIResponse != models.RedactedText || redacted.UserMessage != filtered.UserMessage {
+		t.Errorf("Expected only the answer removed, got %+v", redacted)
+	}
+	interventions, err := safetyRepo.List(models.SafetyInterventionFilter{Limit: 10})
+	if err != nil || len(interventions) != 1 || interventions[0].OriginalAnswer != models.RedactedText {
+		t.Errorf("Expected the filtered answer redacted from the safety log, got %+v (%v)", interventions, err)
+	}
+	if status := call(adminHandler.RedactFlaggedChatHandler, admin, "/", messageVars(abusive), types.RedactChatRequest{}, nil); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 redacting nothing, got %d", status)
+	}
+
+	// Banning deactivates the account, admins can't be banned, and unbanning restores it
+	userVars := func(user *models.User) map[string]string { return map[string]string{"userId": strconv.Itoa(user.ID)} }
+	if status := call(adminHandler.BanUserHandler, admin, "/", userVars(owner), types.BanUserRequest{Reason: "Abuse"}, nil); status != http.StatusCreated {
+		t.Fatalf("Expected 201 banning, got %d", status)
+	}
+	if banned, _ := userRepo.GetByID(owner.ID); banned != nil {
+		t.Errorf("Expected the banned account to be inactive, got %+v", banned)
+	}
+	if status := call(adminHandler.BanUserHandler, admin, "/", userVars(admin), types.BanUserRequest{Reason: "Oops"}, nil); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 banning yourself, got %d", status)
+	}
+	if _, err := moderation.Ban(other, admin.ID, "Retaliation"); err != errors.ErrAccessDenied {
+		t.Errorf("Expected admins to be unbannable, got %v", err)
+	}
+	if status := call(adminHandler.UnbanUserHandler, admin, "/", userVars(owner), nil, nil); status != http.StatusOK {
+		t.Fatalf("Expected 200 unbanning, got %d", status)
+	}
+	if restored, _ := userRepo.GetByID(owner.ID); restored == nil || !restored.IsActive {
+		t.Errorf("Expected the unbanned account to be active, got %+v", restored)
+	}
+	if status := call(adminHandler.UnbanUserHandler, admin, "/", userVars(other), nil, nil); status != http.StatusNotFound {
+		t.Errorf("Expected 404 unbanning an account that isn't banned, got %d", status)
+	}
+}
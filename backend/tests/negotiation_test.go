@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/handlers"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+)
+
+// TestContentNegotiation tests report lists and metrics as XML and NDJSON, chosen by the Accept header
+func TestContentNegotiation(t *testing.T) {
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	user := &models.User{Email: "negotiation@example.com", PasswordHash: "hash", FullName: "Negotiation", IsActive: true}
+	if err := models.NewUserRepository(db.GetDB()).Create(user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	reportRepo := models.NewReportRepository(db.GetDB())
+	samples, err := services.NewDemoService(reportRepo).ProvisionSampleReports(user.ID)
+	if err != nil {
+		t.Fatalf("Failed to provision reports: %v", err)
+	}
+	// Enough reports that an export spans more than one page
+	for i := 0; i < 105; i++ {
+		report := &models.Report{UserID: user.ID, OriginalFilename: fmt.Sprintf("r%d.txt", i), FilePath: "r.txt", FileType: "txt", FileSize: 1}
+		if err := reportRepo.Create(report); err != nil {
+			t.Fatalf("Failed to create report: %v", err)
+		}
+	}
+	total := len(samples) + 105
+
+	reportHandler := handlers.NewReportHandler(reportRepo, nil, nil, nil, services.NewFileStorage(t.TempDir(), "test-secret"), 20971520, false)
+	call := func(handler http.HandlerFunc, path, accept string, vars map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Accept", accept)
+		req = mux.SetURLVars(req.WithContext(context.WithValue(req.Context(), middleware.UserKey, user)), vars)
+		recorder := httptest.NewRecorder()
+		handler(recorder, req)
+		return recorder
+	}
+	ndjsonLines := func(recorder *httptest.ResponseRecorder) []map[string]any {
+		var lines []map[string]any
+		scanner := bufio.NewScanner(recorder.Body)
+		for scanner.Scan() {
+			var line map[string]any
+			if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+				t.Fatalf("Expected a JSON document per line, got %q", scanner.Text())
+			}
+			lines = append(lines, line)
+		}
+		return lines
+	}
+
+	recorder := call(reportHandler.GetReportsHandler, "/api/reports?limit=3", "application/xml", nil)
+	var list struct {
+		XMLName xml.Name `xml:"response"`
+		Reports []struct {
+			ID               int    `xml:"id"`
+			OriginalFilename string `xml:"original_filename"`
+		} `xml:"data>reports>item"`
+		Limit int `xml:"meta>pagination>limit"`
+	}
+	if err := xml.Unmarshal(recorder.Body.Bytes(), &list); err != nil || recorder.Header().Get("Content-Type") != "application/xml" {
+		t.Fatalf("Expected an XML report list, got %q (%v)", recorder.Body.String(), err)
+	}
+	if len(list.Reports) != 3 || list.Reports[0].ID == 0 || list.Reports[0].OriginalFilename == "" || list.Limit != 3 {
+		t.Errorf("Expected three reports with their fields and the pagination meta, got %+v", list)
+	}
+
+	// Without a limit NDJSON exports everything; with one it is a page like any other format
+	recorder = call(reportHandler.GetReportsHandler, "/api/reports", "application/x-ndjson", nil)
+	if lines := ndjsonLines(recorder); len(lines) != total || lines[0]["id"] == nil {
+		t.Errorf("Expected all %d reports, one per line, got %d", total, len(lines))
+	}
+	recorder = call(reportHandler.GetReportHistoryHandler, "/api/reports/history?limit=2", "application/json;q=0.5, application/x-ndjson", nil)
+	if lines := ndjsonLines(recorder); len(lines) != 2 {
+		t.Errorf("Expected a page of two reports, got %d", len(lines))
+	}
+
+	if recorder := call(reportHandler.GetReportsHandler, "/api/reports", "text/html, */*;q=0.8", nil); recorder.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("Expected JSON for a browser's Accept header, got %q", recorder.Header().Get("Content-Type"))
+	}
+	if recorder := call(reportHandler.GetReportsHandler, "/api/reports", "text/csv", nil); recorder.Code != http.StatusNotAcceptable {
+		t.Errorf("Expected an unsupported format to be refused, got %d", recorder.Code)
+	}
+
+	vars := map[string]string{"id": fmt.Sprint(samples[0].ID)}
+	recorder = call(reportHandler.GetHealthMetricsHandler, "/api/reports/1/metrics", "application/x-ndjson", vars)
+	lines := ndjsonLines(recorder)
+	if recorder.Code != http.StatusOK || len(lines) == 0 || lines[0]["name"] == nil || lines[0]["value"] == nil {
+		t.Errorf("Expected one metric per line, got %d %v", recorder.Code, lines)
+	}
+	recorder = call(reportHandler.GetHealthMetricsHandler, "/api/reports/1/metrics", "text/xml", vars)
+	var metrics struct {
+		Names []string `xml:"data>metrics>item>name"`
+	}
+	if err := xml.Unmarshal(recorder.Body.Bytes(), &metrics); err != nil || len(metrics.Names) != len(lines) {
+		t.Errorf("Expected the same metrics as XML, got %q (%v)", recorder.Body.String(), err)
+	}
+}
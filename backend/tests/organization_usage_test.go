@@ -0,0 +1,197 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// TestOrganizationUsage tests that processed reports, model tokens, and peak storage are counted toward the
+// organization of the user they were for, and only theirs
+func TestOrganizationUsage(t *testing.T) {
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	userRepo := models.NewUserRepository(db.GetDB())
+	orgRepo := models.NewOrganizationRepository(db.GetDB())
+	reportRepo := models.NewReportRepository(db.GetDB())
+	usage := services.NewOrganizationUsageService(models.NewOrganizationUsageRepository(db.GetDB()))
+
+	clinic := &models.Organization{Name: "Lakeside Clinic"}
+	other := &models.Organization{Name: "Hillview Hospital"}
+	for _, org := range []*models.Organization{clinic, other} {
+		if err := orgRepo.Create(org); err != nil {
+			t.Fatalf("Failed to create organization: %v", err)
+		}
+	}
+	patient := &models.User{Email: "usage-patient@example.com", PasswordHash: "hash", FullName: "Patient", IsActive: true}
+	outsider := &models.User{Email: "usage-outsider@example.com", PasswordHash: "hash", FullName: "Outsider", IsActive: true}
+	for _, user := range []*models.User{patient, outsider} {
+		if err := userRepo.Create(user); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+	if err := orgRepo.AddMember(clinic.ID, patient.ID, models.OrgRoleMember); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+
+	// Completed analyses count for the owner's organization; users outside one count nowhere
+	uploadDir := t.TempDir()
+	processor := services.NewReportProcessorWithAnalyzer(reportRepo, models.NewJobRepository(db.GetDB()), nil, nil,
+		services.NewDemoAnalyzer(), services.NewFileStorage(uploadDir, "secret"))
+	processor.SetUsageMeter(usage)
+	var reports []*models.Report
+	for _, owner := range []*models.User{patient, patient, outsider} {
+		path := filepath.Join(uploadDir, "report.txt")
+		os.WriteFile(path, []byte("Hemoglobin 13.5 g/dL"), 0644)
+		report := &models.Report{UserID: owner.ID, OriginalFilename: "report.txt", FilePath: path, FileType: "text/plain",
+			FileSize: 1000, ProcessingStatus: "pending", ReadingLevel: models.ReadingLevelStandard}
+		if err := reportRepo.Create(report); err != nil {
+			t.Fatalf("Failed to create report: %v", err)
+		}
+		if err := processor.ProcessReport(report); err != nil {
+			t.Fatalf("Failed to process report: %v", err)
+		}
+		reports = append(reports, report)
+	}
+
+	// Model calls count with the tokens the provider reported, when made with a member's data
+	modelServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{"message": map[string]string{"role": "assistant", "content": "Your results look fine."}}},
+			"usage":   map[string]int{"prompt_tokens": 120, "completion_tokens": 30},
+		})
+	}))
+	defer modelServer.Close()
+	aiService, err := services.NewAIService(config.AIConfig{Provider: "ollama", OllamaURL: modelServer.URL, OllamaModel: "llama3.1"})
+	if err != nil {
+		t.Fatalf("Failed to create AI service: %v", err)
+	}
+	defer aiService.Close()
+	aiService.SetUsageMeter(usage)
+	chats := services.NewChatService(models.NewChatMessageRepository(db.GetDB()), models.NewChatSummaryRepository(db.GetDB()),
+		reportRepo, nil, aiService, nil, config.AIConfig{})
+	for _, question := range []string{"Is this normal?", "Should I worry?"} {
+		if _, err := chats.Ask(patient.ID, reports[0].ID, question, models.ReadingLevelStandard, models.PlanFree); err != nil {
+			t.Fatalf("Failed to ask: %v", err)
+		}
+	}
+	if _, err := chats.Ask(outsider.ID, reports[2].ID, "Is this normal?", models.ReadingLevelStandard, models.PlanFree); err != nil {
+		t.Fatalf("Failed to ask: %v", err)
+	}
+
+	// A part of a multi-part report is stored too
+	if _, err := db.GetDB().Exec(`INSERT INTO report_parts (report_id, part_number, original_filename, file_path, file_type, file_size)
+		VALUES (?, 2, 'page2.txt', 'page2.txt', 'text/plain', 500)`, reports[1].ID); err != nil {
+		t.Fatalf("Failed to add report part: %v", err)
+	}
+
+	month := usage.CurrentMonth()
+	monthly, err := usage.MonthlyUsage(month, 0)
+	if err != nil {
+		t.Fatalf("Failed to read usage: %v", err)
+	}
+	if len(monthly) != 2 || monthly[0].OrganizationName != "Hillview Hospital" || monthly[1].OrganizationID != clinic.ID {
+		t.Fatalf("Expected both organizations by name, got %+v", monthly)
+	}
+	if idle := monthly[0]; idle.ReportsProcessed != 0 || idle.AICalls != 0 || idle.StorageBytes != 0 {
+		t.Errorf("Expected nothing counted for the organization without activity, got %+v", idle)
+	}
+	got := monthly[1]
+	if got.ReportsProcessed != 2 || got.AICalls != 2 || got.AIInputTokens != 240 || got.AIOutputTokens != 60 || got.StorageBytes != 2500 {
+		t.Errorf("Expected 2 reports, 2 calls of 120+30 tokens, and 2500 bytes, got %+v", got)
+	}
+
+	// Storage is billed at its peak, so deleting a report doesn't lower the month's figure
+	if err := reportRepo.Delete(reports[0].ID); err != nil {
+		t.Fatalf("Failed to delete report: %v", err)
+	}
+	if monthly, _ := usage.MonthlyUsage(month, clinic.ID); len(monthly) != 1 || monthly[0].StorageBytes != 2500 {
+		t.Errorf("Expected the month's peak storage kept, got %+v", monthly)
+	}
+
+	// Earlier months have nothing recorded, later ones can't be asked for yet
+	if past, err := usage.MonthlyUsage("2020-01", clinic.ID); err != nil || len(past) != 1 || past[0].ReportsProcessed != 0 ||
+		past[0].UpdatedAt != nil {
+		t.Errorf("Expected an empty past month, got %+v (%v)", past, err)
+	}
+	for _, bad := range []string{"2999-01", "2026-1", "January"} {
+		if _, err := usage.MonthlyUsage(bad, 0); err == nil {
+			t.Errorf("Expected month %q refused", bad)
+		}
+	}
+
+	// Organization names can't smuggle formulas into the spreadsheet
+	var csvBody bytes.Buffer
+	if err := services.WriteOrganizationUsageCSV(&csvBody, []*models.OrganizationUsage{{OrganizationID: 7, OrganizationName: "=HYPERLINK(\"x\")",
+		Month: "2026-10", ReportsProcessed: 3, AIInputTokens: 1200}}); err != nil {
+		t.Fatalf("Failed to write CSV: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(csvBody.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "month,organization_id,organization_name") ||
+		lines[1] != `2026-10,7,"'=HYPERLINK(""x"")",3,0,1200,0,0` {
+		t.Errorf("Unexpected CSV %q", csvBody.String())
+	}
+}
+
+// TestOrganizationUsageEndpoint tests the admin usage report as JSON and as a CSV download
+func TestOrganizationUsageEndpoint(t *testing.T) {
+	server := setupTestServer(t)
+	defer server.Close()
+
+	adminToken := signupAndGetToken(t, server.URL, "admin@example.com")
+	var org types.OrganizationBranding
+	if status := doJSONRequest(t, "POST", server.URL+"/api/admin/organizations", adminToken,
+		types.CreateOrganizationRequest{Name: "City Clinic"}, &org); status != http.StatusCreated {
+		t.Fatalf("Failed to create organization: %d", status)
+	}
+
+	var usage types.OrganizationUsageResponse
+	if status := doJSONRequest(t, "GET", server.URL+"/api/admin/organizations/usage", adminToken, nil, &usage); status != http.StatusOK {
+		t.Fatalf("Expected the current month's usage, got %d", status)
+	}
+	if len(usage.Month) != 7 || len(usage.Organizations) != 1 || usage.Organizations[0].OrganizationName != "City Clinic" {
+		t.Errorf("Expected the organization in this month's usage, got %+v", usage)
+	}
+
+	req, _ := http.NewRequest("GET", server.URL+"/api/admin/organizations/usage?month=2026-01&format=csv", nil)
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to download usage: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/csv") ||
+		!strings.Contains(resp.Header.Get("Content-Disposition"), "organization-usage-2026-01.csv") {
+		t.Fatalf("Expected a CSV attachment, got %d %v", resp.StatusCode, resp.Header)
+	}
+	if !strings.Contains(string(body), "2026-01,"+strconv.Itoa(org.ID)+",City Clinic,0,0,0,0,0") {
+		t.Errorf("Expected a zero row for the organization, got %q", body)
+	}
+
+	if status := doJSONRequest(t, "GET", server.URL+"/api/admin/organizations/usage?format=xml", adminToken, nil, nil); status != http.StatusBadRequest {
+		t.Errorf("Expected an unknown format refused, got %d", status)
+	}
+	userToken := signupAndGetToken(t, server.URL, "usage-user@example.com")
+	if status := doJSONRequest(t, "GET", server.URL+"/api/admin/organizations/usage", userToken, nil, nil); status != http.StatusForbidden {
+		t.Errorf("Expected non-admins refused, got %d", status)
+	}
+}
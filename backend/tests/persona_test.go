@@ -0,0 +1,48 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+)
+
+// TestBuildSystemPrompt tests consumer and hospital-branded personas
+func TestBuildSystemPrompt(t *testing.T) {
+	consumer, err := services.BuildSystemPrompt(config.PersonaConfig{Name: "MedSimple Assistant", Tone: "friendly"})
+	if err != nil {
+		t.Fatalf("Failed to build consumer persona: %v", err)
+	}
+	if !strings.Contains(consumer, "You are MedSimple Assistant") || strings.Contains(consumer, "care team") {
+		t.Fatalf("Unexpected consumer persona:\n%s", consumer)
+	}
+
+	extraPath := filepath.Join(t.TempDir(), "persona.txt")
+	if err := os.WriteFile(extraPath, []byte("Mention the 24h nurse line for urgent questions.\n"), 0644); err != nil {
+		t.Fatalf("Failed to write persona prompt: %v", err)
+	}
+
+	hospital, err := services.BuildSystemPrompt(config.PersonaConfig{
+		Name:         "Ava",
+		Organization: "St. Mary's Hospital",
+		Tone:         "concise and clinical",
+		Disclaimer:   "Consult your physician.",
+		PromptPath:   extraPath,
+	})
+	if err != nil {
+		t.Fatalf("Failed to build hospital persona: %v", err)
+	}
+	for _, expected := range []string{"Ava, the patient assistant of St. Mary's Hospital", "concise and clinical", "Consult your physician.", "24h nurse line"} {
+		if !strings.Contains(hospital, expected) {
+			t.Fatalf("Hospital persona missing %q:\n%s", expected, hospital)
+		}
+	}
+
+	// A configured but missing instructions file is a startup error, not a silent fallback
+	if _, err := services.BuildSystemPrompt(config.PersonaConfig{PromptPath: filepath.Join(t.TempDir(), "missing.txt")}); err == nil {
+		t.Fatal("Expected error for missing persona prompt file")
+	}
+}
@@ -0,0 +1,176 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// testPlans keeps limits small enough to reach in a test
+var testPlans = map[string]config.PlanConfig{
+	models.PlanFree:    {MaxOutputTokens: 1024, SummaryWords: 80, MaxFindings: 3, ChatMessagesPerDay: 2},
+	models.PlanPremium: {MaxOutputTokens: 4096, SummaryWords: 200},
+}
+
+// TestPlanLimitsInAnalysis tests that the plan sets the output cap, the length guidance, and the findings kept
+func TestPlanLimitsInAnalysis(t *testing.T) {
+	var lastPrompt string
+	var lastMaxTokens int32
+	modelServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+			MaxTokens int32 `json:"max_tokens"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		lastPrompt = req.Messages[len(req.Messages)-1].Content
+		lastMaxTokens = req.MaxTokens
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{"message": map[string]string{"role": "assistant", "content": `{"summary":"ok","simple_summary":"ok",` +
+				`"key_findings":["a","b","c","d","e"],"recommendations":["1","2","3","4","5"]}`}}},
+		})
+	}))
+	defer modelServer.Close()
+
+	aiService, err := services.NewAIService(config.AIConfig{
+		Provider:    "ollama",
+		OllamaURL:   modelServer.URL,
+		OllamaModel: "llama3.1",
+		MaxTokens:   2048,
+		PromptPath:  "../prompts/medical_analysis_prompt.txt",
+		Plans:       testPlans,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create AI service: %v", err)
+	}
+	defer aiService.Close()
+
+	reportPath := filepath.Join(t.TempDir(), "cbc.txt")
+	os.WriteFile(reportPath, []byte("Hb 13.2 g/dL"), 0644)
+
+	analyze := func(plan string) *services.AnalysisResult {
+		analysis, err := aiService.AnalyzeReport(context.Background(), reportPath, "text/plain", models.ReadingLevelStandard, plan, "")
+		if err != nil {
+			t.Fatalf("Analysis on the %q plan failed: %v", plan, err)
+		}
+		var result services.AnalysisResult
+		json.Unmarshal([]byte(analysis.ResultJSON), &result)
+		return &result
+	}
+
+	// Reports from before plans existed are analyzed as free
+	for _, plan := range []string{models.PlanFree, ""} {
+		result := analyze(plan)
+		if lastMaxTokens != 1024 {
+			t.Errorf("Expected the free plan to cap output at 1024 tokens, got %d", lastMaxTokens)
+		}
+		if !strings.Contains(lastPrompt, "under 80 words") || !strings.Contains(lastPrompt, "at most 3 key_findings") {
+			t.Errorf("Expected free plan length guidance in the prompt, got %q", lastPrompt)
+		}
+		if len(result.KeyFindings) != 3 || len(result.Recommendations) != 3 {
+			t.Errorf("Expected findings and recommendations trimmed to 3, got %v and %v", result.KeyFindings, result.Recommendations)
+		}
+	}
+
+	result := analyze(models.PlanPremium)
+	if lastMaxTokens != 4096 || !strings.Contains(lastPrompt, "under 200 words") || strings.Contains(lastPrompt, "key_findings and at most") {
+		t.Errorf("Expected premium limits, got %d tokens and prompt %q", lastMaxTokens, lastPrompt)
+	}
+	if len(result.KeyFindings) != 5 {
+		t.Errorf("Expected premium analyses to keep every finding, got %v", result.KeyFindings)
+	}
+}
+
+// TestChatAllowance tests that free users are limited to their plan's questions per day
+func TestChatAllowance(t *testing.T) {
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	owner := &models.User{Email: "chatty@example.com", PasswordHash: "hash", FullName: "Chatty", IsActive: true}
+	if err := models.NewUserRepository(db.GetDB()).Create(owner); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	reportRepo := models.NewReportRepository(db.GetDB())
+	reports, err := services.NewDemoService(reportRepo).ProvisionSampleReports(owner.ID)
+	if err != nil {
+		t.Fatalf("Failed to provision reports: %v", err)
+	}
+
+	chatService := services.NewChatService(models.NewChatMessageRepository(db.GetDB()), models.NewChatSummaryRepository(db.GetDB()),
+		reportRepo, nil, services.NewDemoAnalyzer(), fixedTranscriber{text: "Is this normal?"}, config.AIConfig{Plans: testPlans})
+	ask := func(reportID int, plan string) error {
+		_, err := chatService.AskByVoice(context.Background(), owner.ID, reportID,
+			services.VoiceQuestion{Audio: webmHeader, AudioType: "audio/webm"}, models.ReadingLevelStandard, plan)
+		return err
+	}
+
+	// The allowance spans all of the user's reports
+	if err := ask(reports[0].ID, models.PlanFree); err != nil {
+		t.Fatalf("Failed to ask first question: %v", err)
+	}
+	if err := ask(reports[1].ID, models.PlanFree); err != nil {
+		t.Fatalf("Failed to ask second question: %v", err)
+	}
+	if err := ask(reports[0].ID, models.PlanFree); err != errors.ErrChatLimitReached {
+		t.Errorf("Expected the third question to exceed the free allowance, got %v", err)
+	}
+	if err := ask(reports[0].ID, models.PlanPremium); err != nil {
+		t.Errorf("Expected premium chat to be unlimited, got %v", err)
+	}
+}
+
+// TestPlanEndpoints tests reading one's plan and an admin upgrading a user
+func TestPlanEndpoints(t *testing.T) {
+	server := setupTestServer(t)
+	defer server.Close()
+	userToken := signupAndGetToken(t, server.URL, "planner@example.com")
+	adminToken := signupAndGetToken(t, server.URL, "admin@example.com")
+
+	var plan types.PlanResponse
+	if status := doJSONRequest(t, "GET", server.URL+"/api/users/me/plan", userToken, nil, &plan); status != http.StatusOK {
+		t.Fatalf("Expected 200 reading the plan, got %d", status)
+	}
+	if plan.Plan != models.PlanFree || plan.Limits.SummaryWords != 80 || plan.Limits.ChatMessagesPerDay != 2 ||
+		plan.Usage.ChatRemaining == nil || *plan.Usage.ChatRemaining != 2 {
+		t.Errorf("Expected a new account on the free plan with its allowance unused, got %+v", plan)
+	}
+
+	var me types.User
+	doJSONRequest(t, "GET", server.URL+"/api/auth/me", userToken, nil, &me)
+	planURL := fmt.Sprintf("%s/api/admin/users/%d/plan", server.URL, me.ID)
+
+	if status := doJSONRequest(t, "PUT", planURL, userToken, types.UpdatePlanRequest{Plan: models.PlanPremium}, nil); status != http.StatusForbidden {
+		t.Errorf("Expected users not to change their own plan, got %d", status)
+	}
+	if status := doJSONRequest(t, "PUT", planURL, adminToken, types.UpdatePlanRequest{Plan: "enterprise"}, nil); status != http.StatusBadRequest {
+		t.Errorf("Expected an unknown plan to be rejected, got %d", status)
+	}
+	var updated types.User
+	if status := doJSONRequest(t, "PUT", planURL, adminToken, types.UpdatePlanRequest{Plan: models.PlanPremium}, &updated); status != http.StatusOK ||
+		updated.Plan != models.PlanPremium {
+		t.Fatalf("Expected the admin to upgrade the user, got %d %+v", status, updated)
+	}
+
+	doJSONRequest(t, "GET", server.URL+"/api/users/me/plan", userToken, nil, &plan)
+	if plan.Plan != models.PlanPremium || plan.Limits.SummaryWords != 200 || plan.Usage.ChatRemaining != nil {
+		t.Errorf("Expected premium limits with unlimited chat, got %+v", plan)
+	}
+}
@@ -0,0 +1,132 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+)
+
+// TestPopulationInsights tests that only consenting adults are aggregated, small cohorts are withheld, and values are placed in their band
+func TestPopulationInsights(t *testing.T) {
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	userRepo := models.NewUserRepository(db.GetDB())
+	reportRepo := models.NewReportRepository(db.GetDB())
+	profileRepo := models.NewHealthProfileRepository(db.GetDB())
+	populationRepo := models.NewPopulationRepository(db.GetDB())
+
+	if _, err := services.NewPopulationService(populationRepo, reportRepo, profileRepo,
+		config.InsightsConfig{MinCohort: 2, RefreshInterval: time.Hour}); err == nil {
+		t.Error("Expected a floor below 5 to be refused")
+	}
+	population, err := services.NewPopulationService(populationRepo, reportRepo, profileRepo,
+		config.InsightsConfig{MinCohort: 5, RefreshInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("Failed to create population service: %v", err)
+	}
+
+	// Six adults in their thirties report glucose 90..140; only the first one also reports a rare marker
+	dob := time.Now().AddDate(-35, 0, 0)
+	var users []*models.User
+	for i := range 6 {
+		user := &models.User{Email: fmt.Sprintf("peer%d@example.com", i), PasswordHash: "hash", FullName: "Peer", IsActive: true}
+		if err := userRepo.Create(user); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		if err := profileRepo.Upsert(&models.HealthProfile{UserID: user.ID, DateOfBirth: &dob}); err != nil {
+			t.Fatalf("Failed to store profile: %v", err)
+		}
+		metrics := fmt.Sprintf(`{"name":"Glucose","value":"%d","unit":"mg/dL","status":"normal"}`, 90+10*i)
+		if i == 0 {
+			metrics += `,{"name":"Rare Marker","value":"3","status":"normal"}`
+		}
+		report := &models.Report{UserID: user.ID, OriginalFilename: "labs.txt", FilePath: fmt.Sprintf("labs-%d.txt", i),
+			FileType: "text/plain", FileSize: 10, ProcessingStatus: "pending", ReadingLevel: models.ReadingLevelStandard}
+		if err := reportRepo.Create(report); err != nil {
+			t.Fatalf("Failed to create report: %v", err)
+		}
+		if err := reportRepo.UpdateProcessingStatus(report.ID, "completed",
+			`{"simple_summary":"Labs","risk_level":"low","health_metrics":[`+metrics+`]}`); err != nil {
+			t.Fatalf("Failed to store analysis: %v", err)
+		}
+		users = append(users, user)
+	}
+
+	if _, err := population.Compare(users[0].ID); err == nil {
+		t.Error("Expected a comparison without consent to be refused")
+	}
+	for _, user := range users {
+		if _, err := population.SetConsent(user.ID, true); err != nil {
+			t.Fatalf("Failed to consent: %v", err)
+		}
+	}
+
+	if published, err := population.Refresh(); err != nil || published != 1 {
+		t.Fatalf("Expected only the glucose cohort published, got %d (%v)", published, err)
+	}
+	comparison, err := population.Compare(users[5].ID)
+	if err != nil {
+		t.Fatalf("Failed to compare: %v", err)
+	}
+	if comparison.AgeBand != "30-39" || len(comparison.Metrics) != 1 {
+		t.Fatalf("Expected glucose compared within 30-39, got %+v", comparison)
+	}
+	glucose := comparison.Metrics[0]
+	if glucose.Stat.Contributors != 6 || glucose.Stat.P50 != 115 || glucose.Stat.P10 != 95 || glucose.Value != 140 || glucose.Position != "above_p90" {
+		t.Errorf("Unexpected glucose comparison %+v with %+v", glucose, glucose.Stat)
+	}
+
+	// Withdrawing consent drops the cohort below the floor at the next refresh
+	for _, user := range users[1:3] {
+		if _, err := population.SetConsent(user.ID, false); err != nil {
+			t.Fatalf("Failed to withdraw consent: %v", err)
+		}
+	}
+	if published, _ := population.Refresh(); published != 0 {
+		t.Errorf("Expected no cohort left above the floor, got %d", published)
+	}
+
+	// A user without a date of birth cannot be placed in an age band
+	stranger := &models.User{Email: "stranger@example.com", PasswordHash: "hash", FullName: "Stranger", IsActive: true}
+	if err := userRepo.Create(stranger); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	population.SetConsent(stranger.ID, true)
+	if _, err := population.Compare(stranger.ID); err == nil {
+		t.Error("Expected a comparison without a date of birth to be refused")
+	}
+}
+
+// TestPopulationInsightsEndpoints tests consent handling of the population insight endpoints
+func TestPopulationInsightsEndpoints(t *testing.T) {
+	server := setupTestServer(t)
+	defer server.Close()
+
+	token := signupAndGetToken(t, server.URL, "patient@example.com")
+	if status := doJSONRequest(t, "GET", server.URL+"/api/insights/population", token, nil, nil); status != http.StatusForbidden {
+		t.Errorf("Expected insights without consent to be refused, got %d", status)
+	}
+	if status := doJSONRequest(t, "PUT", server.URL+"/api/insights/population/consent", token, map[string]any{}, nil); status != http.StatusBadRequest {
+		t.Errorf("Expected consent without opted_in to be rejected, got %d", status)
+	}
+
+	var consent map[string]any
+	if status := doJSONRequest(t, "PUT", server.URL+"/api/insights/population/consent", token,
+		map[string]any{"opted_in": true}, &consent); status != http.StatusOK || consent["opted_in"] != true {
+		t.Errorf("Expected consent recorded, got %d %v", status, consent)
+	}
+	if status := doJSONRequest(t, "GET", server.URL+"/api/insights/population", token, nil, nil); status != http.StatusBadRequest {
+		t.Errorf("Expected insights without a date of birth to be rejected, got %d", status)
+	}
+}
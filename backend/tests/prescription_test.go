@@ -0,0 +1,224 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// testPNG is the 8-byte PNG signature, enough for content sniffing
+var testPNG = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+// fakePrescriptionReader returns a fixed reading or error
+type fakePrescriptionReader struct {
+	reading *services.PrescriptionReading
+	err     error
+}
+
+func (f *fakePrescriptionReader) ReadPrescription(ctx context.Context, image []byte, imageType string) (*services.PrescriptionReading, error) {
+	return f.reading, f.err
+}
+
+func (f *fakePrescriptionReader) Name() string {
+	return "fake"
+}
+
+// TestPrescriptionReading tests flagging doubtful medication lines and correcting them
+func TestPrescriptionReading(t *testing.T) {
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	userRepo := models.NewUserRepository(db.GetDB())
+	owner := &models.User{Email: "owner@example.com", PasswordHash: "hash", FullName: "Owner", IsActive: true}
+	other := &models.User{Email: "other@example.com", PasswordHash: "hash", FullName: "Other", IsActive: true}
+	for _, user := range []*models.User{owner, other} {
+		if err := userRepo.Create(user); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+
+	reader := &fakePrescriptionReader{reading: &services.PrescriptionReading{
+		Prescriber: "  Dr. Iyer ",
+		Medications: []*models.PrescriptionMedication{
+			{Name: "Amlodipine 5 mg", Frequency: "once daily", Confidence: 0.95},
+			{Name: " ", Confidence: 0.9}, // Nothing legible; dropped
+			{Name: "Telmisartn 40", Frequency: "OD", Confidence: 0.4},
+			{Name: "Aspirin 75 mg", Confidence: 7}, // Out of range; clamped
+		},
+	}}
+	storage := services.NewFileStorage(t.TempDir(), "test-secret")
+	prescriptions := services.NewPrescriptionService(models.NewPrescriptionRepository(db.GetDB()), reader, storage, 0.7)
+
+	prescription, err := prescriptions.Upload(context.Background(), owner.ID, testPNG, "image/png")
+	if err != nil {
+		t.Fatalf("Failed to upload prescription: %v", err)
+	}
+	if prescription.Prescriber != "Dr. Iyer" || len(prescription.Medications) != 3 {
+		t.Fatalf("Expected trimmed prescriber and three medications, got %+v", prescription)
+	}
+	for i, want := range []bool{false, true, false} {
+		if prescription.Medications[i].LowConfidence != want {
+			t.Errorf("Expected medication %d low confidence %v, got %+v", i, want, prescription.Medications[i])
+		}
+	}
+	if prescription.Medications[2].Confidence != 1 {
+		t.Errorf("Expected confidence clamped to 1, got %v", prescription.Medications[2].Confidence)
+	}
+
+	// The photo is stored for checking doubtful lines against
+	_, photo, err := prescriptions.OpenPhoto(owner.ID, prescription.ID)
+	if err != nil {
+		t.Fatalf("Failed to open photo: %v", err)
+	}
+	stored, _ := io.ReadAll(photo)
+	photo.Close()
+	if !bytes.Equal(stored, testPNG) {
+		t.Error("Expected the stored photo to match the upload")
+	}
+
+	// Correcting a line keeps untouched fields and clears its flag
+	name := "Telmisartan 40 mg"
+	corrected, err := prescriptions.CorrectMedication(owner.ID, prescription.ID, 1, services.MedicationCorrection{Name: &name})
+	if err != nil {
+		t.Fatalf("Failed to correct medication: %v", err)
+	}
+	line := corrected.Medications[1]
+	if line.Name != name || line.Frequency != "OD" || line.LowConfidence || !line.Corrected || line.Confidence != 0.4 {
+		t.Errorf("Expected corrected name with other fields kept, got %+v", line)
+	}
+	reloaded, _ := prescriptions.Get(owner.ID, prescription.ID)
+	if reloaded.Medications[1].Name != name {
+		t.Errorf("Expected the correction to be stored, got %+v", reloaded.Medications[1])
+	}
+
+	empty := ""
+	for name, attempt := range map[string]func() error{
+		"empty name": func() error {
+			_, err := prescriptions.CorrectMedication(owner.ID, prescription.ID, 0, services.MedicationCorrection{Name: &empty})
+			return err
+		},
+		"index out of range": func() error {
+			_, err := prescriptions.CorrectMedication(owner.ID, prescription.ID, 3, services.MedicationCorrection{})
+			return err
+		},
+		"another's": func() error {
+			_, err := prescriptions.CorrectMedication(other.ID, prescription.ID, 0, services.MedicationCorrection{})
+			return err
+		},
+	} {
+		if attempt() == nil {
+			t.Errorf("Expected %s correction to be refused", name)
+		}
+	}
+
+	// A failed read or a reading with no medications stores nothing
+	reader.err = fmt.Errorf("model unavailable")
+	if _, err := prescriptions.Upload(context.Background(), owner.ID, testPNG, "image/png"); err == nil {
+		t.Error("Expected a failed read to be reported")
+	}
+	reader.reading, reader.err = &services.PrescriptionReading{Notes: "Not a prescription"}, nil
+	if _, err := prescriptions.Upload(context.Background(), owner.ID, testPNG, "image/png"); err == nil {
+		t.Error("Expected an unreadable photo to be refused")
+	}
+	if list, _ := prescriptions.List(owner.ID, 20, 0); len(list) != 1 {
+		t.Errorf("Expected one stored prescription, got %d", len(list))
+	}
+
+	// Deleting removes the photo too
+	if err := prescriptions.Delete(owner.ID, prescription.ID); err != nil {
+		t.Fatalf("Failed to delete prescription: %v", err)
+	}
+	if _, err := os.Stat(prescription.FilePath); !os.IsNotExist(err) {
+		t.Errorf("Expected the photo to be removed, got %v", err)
+	}
+
+	// Without a reader, uploads are unavailable and nothing is stored
+	disabled := services.NewPrescriptionService(models.NewPrescriptionRepository(db.GetDB()), nil, storage, 0.7)
+	if _, err := disabled.Upload(context.Background(), owner.ID, testPNG, "image/png"); err == nil {
+		t.Error("Expected upload without a reader to fail")
+	}
+
+	// HTTP: the demo reader flags one line, which an empty PATCH confirms
+	server := setupTestServer(t)
+	defer server.Close()
+	token := signupAndGetToken(t, server.URL, "rx@example.com")
+
+	if status := uploadPrescription(t, server.URL, token, []byte("plain text, not a photo"), nil); status != http.StatusUnsupportedMediaType {
+		t.Errorf("Expected a non-image upload to be refused, got %d", status)
+	}
+	var uploaded types.Prescription
+	if status := uploadPrescription(t, server.URL, token, testPNG, &uploaded); status != http.StatusCreated {
+		t.Fatalf("Expected upload to succeed, got %d", status)
+	}
+	if !uploaded.NeedsReview || len(uploaded.Medications) != 3 || !uploaded.Medications[2].LowConfidence {
+		t.Errorf("Expected the demo prescription to need review, got %+v", uploaded)
+	}
+
+	var confirmed types.Prescription
+	url := fmt.Sprintf("%s/api/prescriptions/%d/medications/2", server.URL, uploaded.ID)
+	if status := doJSONRequest(t, "PATCH", url, token, nil, &confirmed); status != http.StatusOK {
+		t.Fatalf("Expected confirmation to succeed, got %d", status)
+	}
+	if confirmed.NeedsReview || !confirmed.Medications[2].Corrected {
+		t.Errorf("Expected no lines left to review, got %+v", confirmed)
+	}
+
+	req, _ := http.NewRequest("GET", server.URL+uploaded.ImageURL, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to fetch photo: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "image/png" {
+		t.Errorf("Expected the photo back as PNG, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	otherToken := signupAndGetToken(t, server.URL, "rx-other@example.com")
+	if status := doJSONRequest(t, "GET", fmt.Sprintf("%s/api/prescriptions/%d", server.URL, uploaded.ID), otherToken, nil, nil); status != http.StatusNotFound {
+		t.Errorf("Expected another user's prescription to be hidden, got %d", status)
+	}
+}
+
+// uploadPrescription posts a photo in the image field and decodes the response into out when given
+func uploadPrescription(t *testing.T, serverURL, token string, image []byte, out any) int {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("image", "prescription.png")
+	if err != nil {
+		t.Fatalf("Failed to create form file: %v", err)
+	}
+	part.Write(image)
+	writer.Close()
+
+	req, _ := http.NewRequest("POST", serverURL+"/api/prescriptions", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to upload prescription: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if out != nil && resp.StatusCode < 300 {
+		if err := decodeEnvelope(resp.Body, out); err != nil {
+			t.Fatalf("Failed to parse prescription response: %v", err)
+		}
+	}
+	return resp.StatusCode
+}
@@ -0,0 +1,221 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/handlers"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// TestPromptCanaryRouting tests that only new reports go to the canary, on its own model, while its gate allows it
+func TestPromptCanaryRouting(t *testing.T) {
+	var mu sync.Mutex
+	var served []string
+	modelServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		served = append(served, req.Model)
+		mu.Unlock()
+		reply := `{"summary":"Elevated LDL","simple_summary":"Cholesterol is a bit high","health_metrics":[],"risk_level":"medium"}`
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{"message": map[string]string{"role": "assistant", "content": reply}}},
+		})
+	}))
+	defer modelServer.Close()
+
+	aiConfig := config.AIConfig{
+		Provider:      "ollama",
+		OllamaURL:     modelServer.URL,
+		OllamaModel:   "llama3.1",
+		MaxTokens:     2048,
+		PromptPath:    "does-not-exist.txt",
+		PromptVersion: "v1",
+		Canary:        config.PromptCanaryConfig{Version: "v1", Model: "llama3.2", Percent: 100},
+	}
+	if _, err := services.NewAIService(aiConfig); err == nil {
+		t.Fatal("Expected a canary sharing the stable version to be refused")
+	}
+	aiConfig.Canary.Version = "v2-canary"
+	aiService, err := services.NewAIService(aiConfig)
+	if err != nil {
+		t.Fatalf("Failed to create AI service: %v", err)
+	}
+	defer aiService.Close()
+
+	reportPath := filepath.Join(t.TempDir(), "lipids.txt")
+	if err := os.WriteFile(reportPath, []byte("LDL 160 mg/dL"), 0644); err != nil {
+		t.Fatalf("Failed to write report: %v", err)
+	}
+	analyze := func(ctx context.Context) string {
+		t.Helper()
+		analysis, err := aiService.AnalyzeReport(ctx, reportPath, "text/plain", models.ReadingLevelStandard, models.PlanFree, "")
+		if err != nil {
+			t.Fatalf("Analysis failed: %v", err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		return analysis.PromptVersion + "/" + served[len(served)-1]
+	}
+
+	live := true
+	aiService.SetCanaryGate(func() bool { return live })
+	if got := analyze(services.WithPromptCanary(context.Background())); got != "v2-canary/llama3.2" {
+		t.Errorf("Expected a new report analyzed by the canary on its model, got %s", got)
+	}
+	if got := analyze(context.Background()); got != "v1/llama3.1" {
+		t.Errorf("Expected a re-analysis kept on the stable prompt, got %s", got)
+	}
+	live = false
+	if got := analyze(services.WithPromptCanary(context.Background())); got != "v1/llama3.1" {
+		t.Errorf("Expected a rolled back canary to serve nothing, got %s", got)
+	}
+}
+
+// TestPromptCanaryRollback tests that a canary with more parse failures or drifting summaries is rolled back
+func TestPromptCanaryRollback(t *testing.T) {
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	owner := &models.User{Email: "owner@example.com", PasswordHash: "hash", FullName: "Owner", IsActive: true}
+	admin := &models.User{Email: "admin@example.com", PasswordHash: "hash", FullName: "Admin", IsActive: true}
+	for _, user := range []*models.User{owner, admin} {
+		if err := models.NewUserRepository(db.GetDB()).Create(user); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+
+	reportRepo := models.NewReportRepository(db.GetDB())
+	analyzed := func(version string, words int, parseFailed bool) {
+		t.Helper()
+		report := &models.Report{UserID: owner.ID, OriginalFilename: "cbc.pdf", FilePath: "cbc.pdf", FileType: "pdf", FileSize: 1}
+		if err := reportRepo.Create(report); err != nil {
+			t.Fatalf("Failed to create report: %v", err)
+		}
+		if !parseFailed {
+			summary, _ := json.Marshal(map[string]any{"simple_summary": strings.TrimSpace(strings.Repeat("word ", words)), "risk_level": "low"})
+			if err := reportRepo.UpdateProcessingStatus(report.ID, "completed", string(summary)); err != nil {
+				t.Fatalf("Failed to store analysis: %v", err)
+			}
+		}
+		if err := reportRepo.SetAnalysisMetadata(report.ID, version, parseFailed); err != nil {
+			t.Fatalf("Failed to store prompt version: %v", err)
+		}
+	}
+
+	canaryRepo := models.NewPromptCanaryRepository(db.GetDB())
+	auditRepo := models.NewAuditLogRepository(db.GetDB())
+	aiConfig := config.AIConfig{
+		PromptPath:    "prompts/medical_analysis_prompt.txt",
+		PromptVersion: "v1",
+		Canary: config.PromptCanaryConfig{Version: "v2", Percent: 10, MinSamples: 3,
+			MaxParseFailureIncrease: 0.1, MaxLengthChange: 0.5},
+	}
+	canaries := services.NewPromptCanaryService(canaryRepo, reportRepo, auditRepo, aiConfig)
+	if _, err := canaries.Start(); err != nil {
+		t.Fatalf("Failed to start canary: %v", err)
+	}
+	if !canaries.Live() {
+		t.Fatal("Expected a new canary to be live")
+	}
+
+	// Too few canary analyses to judge it yet
+	for range 4 {
+		analyzed("v1", 40, false)
+	}
+	analyzed("v2", 40, false)
+	analyzed("v2", 40, false)
+	comparison, err := canaries.Evaluate()
+	if err != nil || comparison.Verdict != services.CanaryVerdictCollecting {
+		t.Fatalf("Expected the canary still collecting, got %+v (%v)", comparison, err)
+	}
+
+	analyzed("v2", 0, true)
+	analyzed("v2", 0, true)
+	comparison, err = canaries.Evaluate()
+	if err != nil {
+		t.Fatalf("Failed to evaluate canary: %v", err)
+	}
+	if comparison.Verdict != services.CanaryVerdictWorse || comparison.Canary.Status != models.PromptCanaryRolledBack ||
+		!strings.Contains(comparison.Canary.Reason, "parse failure rate 50.0%") {
+		t.Errorf("Expected the canary rolled back for its parse failures, got %+v", comparison.Canary)
+	}
+	if canaries.Live() {
+		t.Error("Expected a rolled back canary to stop serving")
+	}
+	// Decision: Restarting with the same version doesn't bring a rejected canary back
+	if canary, _ := canaries.Start(); canary.Status != models.PromptCanaryRolledBack {
+		t.Errorf("Expected the rollback kept across restarts, got %s", canary.Status)
+	}
+
+	// A second candidate writes summaries three times as long
+	aiConfig.Canary.Version = "v3"
+	canaries = services.NewPromptCanaryService(canaryRepo, reportRepo, auditRepo, aiConfig)
+	if _, err := canaries.Start(); err != nil {
+		t.Fatalf("Failed to start canary: %v", err)
+	}
+	for range 3 {
+		analyzed("v1", 40, false)
+		analyzed("v3", 120, false)
+	}
+	comparison, _ = canaries.Status()
+	if comparison.Verdict != services.CanaryVerdictWorse || !strings.Contains(comparison.Reason, "median summary length 120 words against 40") ||
+		comparison.Canary.Status != models.PromptCanaryActive {
+		t.Errorf("Expected status to report the longer summaries without acting, got %+v", comparison)
+	}
+
+	// An admin who wants the longer summaries promotes it, which stops automatic rollback
+	adminHandler := handlers.NewAdminHandler(reportRepo, auditRepo, nil, nil, nil, nil, nil, nil)
+	adminHandler.SetPromptCanaryService(canaries)
+	call := func(handler http.HandlerFunc, body any) (int, types.PromptCanaryResponse) {
+		payload, _ := json.Marshal(body)
+		req := httptest.NewRequest("POST", "/api/admin/prompts/canary", bytes.NewReader(payload))
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserKey, admin))
+		recorder := httptest.NewRecorder()
+		handler(recorder, req)
+		var response types.PromptCanaryResponse
+		decodeEnvelope(recorder.Body, &response)
+		return recorder.Code, response
+	}
+	if status, _ := call(adminHandler.PromotePromptCanaryHandler, types.EndPromptCanaryRequest{}); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a promotion without a reason, got %d", status)
+	}
+	status, promoted := call(adminHandler.PromotePromptCanaryHandler, types.EndPromptCanaryRequest{Reason: "Longer summaries tested well with patients"})
+	if status != http.StatusOK || promoted.Status != models.PromptCanaryPromoted || promoted.EndedBy != admin.ID || promoted.Canary.Analyses != 3 {
+		t.Errorf("Expected the canary promoted, got %d %+v", status, promoted)
+	}
+	if comparison, _ := canaries.Evaluate(); comparison.Canary.Status != models.PromptCanaryPromoted || !canaries.Live() {
+		t.Errorf("Expected a promoted canary left serving, got %s", comparison.Canary.Status)
+	}
+	if status, _ := call(adminHandler.RollbackPromptCanaryHandler, types.EndPromptCanaryRequest{Reason: "Too late"}); status != http.StatusConflict {
+		t.Errorf("Expected 409 rolling back a canary that already ended, got %d", status)
+	}
+	if entries, _ := auditRepo.List(models.AuditLogFilter{ActorID: admin.ID}); len(entries) != 1 || entries[0].Action != models.AuditCanaryPromoted {
+		t.Errorf("Expected the promotion audited, got %+v", entries)
+	}
+
+	adminHandler.SetPromptCanaryService(nil)
+	if status, _ := call(adminHandler.GetPromptCanaryHandler, nil); status != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without a canary, got %d", status)
+	}
+}
@@ -0,0 +1,181 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// TestDeviceRegistration tests registering, listing, moving, and removing push devices over HTTP
+func TestDeviceRegistration(t *testing.T) {
+	server := setupTestServer(t)
+	defer server.Close()
+
+	token := signupAndGetToken(t, server.URL, "phone@example.com")
+	otherToken := signupAndGetToken(t, server.URL, "phone-other@example.com")
+
+	if status := doJSONRequest(t, "POST", server.URL+"/api/devices", token,
+		types.RegisterDeviceRequest{Platform: "gcm", Token: "abc"}, nil); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown platform, got %d", status)
+	}
+	if status := doJSONRequest(t, "POST", server.URL+"/api/devices", token,
+		types.RegisterDeviceRequest{Platform: "fcm", Token: "has spaces"}, nil); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a malformed token, got %d", status)
+	}
+
+	var device types.Device
+	if status := doJSONRequest(t, "POST", server.URL+"/api/devices", token,
+		types.RegisterDeviceRequest{Platform: "FCM", Token: "fcm-token-1", Name: "Pixel 8"}, &device); status != http.StatusCreated {
+		t.Fatalf("Expected 201 registering a device, got %d", status)
+	}
+	if device.Platform != "fcm" || device.Name != "Pixel 8" || device.PushEnabled {
+		t.Errorf("Expected an fcm device without push credentials, got %+v", device)
+	}
+
+	// Registering again on every launch keeps one device
+	var again types.Device
+	doJSONRequest(t, "POST", server.URL+"/api/devices", token,
+		types.RegisterDeviceRequest{Platform: "fcm", Token: "fcm-token-1", Name: "Pixel 8"}, &again)
+	var devices types.DevicesResponse
+	if status := doJSONRequest(t, "GET", server.URL+"/api/devices", token, nil, &devices); status != http.StatusOK {
+		t.Fatalf("Expected 200 listing devices, got %d", status)
+	}
+	if again.ID != device.ID || len(devices.Devices) != 1 || len(devices.Platforms) != 0 {
+		t.Errorf("Expected re-registering to keep the one device, got %+v %+v", again, devices)
+	}
+
+	// Someone else signing in on the same phone takes the token over
+	var moved types.Device
+	doJSONRequest(t, "POST", server.URL+"/api/devices", otherToken,
+		types.RegisterDeviceRequest{Platform: "fcm", Token: "fcm-token-1"}, &moved)
+	doJSONRequest(t, "GET", server.URL+"/api/devices", token, nil, &devices)
+	if len(devices.Devices) != 0 {
+		t.Errorf("Expected the token to leave the first user, got %+v", devices.Devices)
+	}
+
+	if status := doJSONRequest(t, "DELETE", fmt.Sprintf("%s/api/devices/%d", server.URL, moved.ID), token, nil, nil); status != http.StatusNotFound {
+		t.Errorf("Expected 404 removing another user's device, got %d", status)
+	}
+	if status := doJSONRequest(t, "DELETE", fmt.Sprintf("%s/api/devices/%d", server.URL, moved.ID), otherToken, nil, nil); status != http.StatusOK {
+		t.Errorf("Expected 200 removing own device, got %d", status)
+	}
+	doJSONRequest(t, "GET", server.URL+"/api/devices", otherToken, nil, &devices)
+	if len(devices.Devices) != 0 {
+		t.Errorf("Expected no devices after removing, got %+v", devices.Devices)
+	}
+}
+
+// fakePushSender records pushes and rejects the tokens in dead as unregistered
+type fakePushSender struct {
+	name string
+	dead map[string]bool
+
+	mu   sync.Mutex
+	sent map[string][]services.PushMessage
+}
+
+func (f *fakePushSender) Send(ctx context.Context, token string, msg services.PushMessage) error {
+	if f.dead[token] {
+		return services.ErrPushTokenInvalid
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.sent == nil {
+		f.sent = make(map[string][]services.PushMessage)
+	}
+	f.sent[token] = append(f.sent[token], msg)
+	return nil
+}
+
+func (f *fakePushSender) Name() string {
+	return f.name
+}
+
+// TestPushFanOut tests that completed analyses reach every device, critical results as urgent alerts, and dead tokens are pruned
+func TestPushFanOut(t *testing.T) {
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	owner := &models.User{Email: "push@example.com", PasswordHash: "hash", FullName: "Owner", IsActive: true}
+	if err := models.NewUserRepository(db.GetDB()).Create(owner); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	reportRepo := models.NewReportRepository(db.GetDB())
+	normal := &models.Report{UserID: owner.ID, OriginalFilename: "cbc.txt", FilePath: "/tmp/cbc.txt", FileType: "text/plain",
+		FileSize: 20, ProcessingStatus: "pending", ReadingLevel: models.ReadingLevelStandard}
+	critical := &models.Report{UserID: owner.ID, OriginalFilename: "bmp.txt", FilePath: "/tmp/bmp.txt", FileType: "text/plain",
+		FileSize: 20, ProcessingStatus: "pending", ReadingLevel: models.ReadingLevelStandard}
+	for _, report := range []*models.Report{normal, critical} {
+		if err := reportRepo.Create(report); err != nil {
+			t.Fatalf("Failed to create report: %v", err)
+		}
+	}
+	reportRepo.UpdateProcessingStatus(normal.ID, "completed",
+		`{"schema_version":1,"summary":"ok","health_metrics":[{"name":"Hemoglobin","value":13.5,"unit":"g/dL","score":90,"status":"normal"}]}`)
+	reportRepo.UpdateProcessingStatus(critical.ID, "completed",
+		`{"schema_version":1,"summary":"high","health_metrics":[{"name":"Potassium","value":6.9,"unit":"mmol/L","score":5,"status":"critical"}]}`)
+
+	fcm := &fakePushSender{name: "fcm", dead: map[string]bool{"fcm-dead": true}}
+	apns := &fakePushSender{name: "apns"}
+	deviceRepo := models.NewPushDeviceRepository(db.GetDB())
+	push := services.NewPushService(deviceRepo, map[string]services.PushSender{"fcm": fcm, "apns": apns}, 3)
+	for _, device := range []struct{ platform, token string }{
+		{"fcm", "fcm-dead"}, {"fcm", "fcm-live"}, {"apns", "0a1b2c"},
+	} {
+		if _, err := push.Register(owner.ID, device.platform, device.token, ""); err != nil {
+			t.Fatalf("Failed to register %s: %v", device.token, err)
+		}
+	}
+
+	bus := services.NewLocalEventBus(0)
+	services.SubscribePush(bus, push, reportRepo)
+	bus.Publish(services.Event{Type: services.EventAnalysisCompleted, UserID: owner.ID, ReportID: normal.ID})
+	bus.Publish(services.Event{Type: services.EventAnalysisCompleted, UserID: owner.ID, ReportID: critical.ID})
+	// Another user's ID on the event is ignored
+	bus.Publish(services.Event{Type: services.EventAnalysisCompleted, UserID: owner.ID + 1, ReportID: normal.ID})
+	bus.Close()
+
+	for _, got := range [][]services.PushMessage{fcm.sent["fcm-live"], apns.sent["0a1b2c"]} {
+		if len(got) != 2 {
+			t.Fatalf("Expected two pushes per live device, got %+v", got)
+		}
+		if got[0].Urgent || got[0].Data["kind"] != services.PushKindAnalysisReady || got[0].Data["report_id"] != fmt.Sprint(normal.ID) {
+			t.Errorf("Expected a normal analysis-ready push first, got %+v", got[0])
+		}
+		if !got[1].Urgent || got[1].Data["kind"] != services.PushKindCriticalResult || got[1].Data["report_id"] != fmt.Sprint(critical.ID) {
+			t.Errorf("Expected an urgent critical-result push second, got %+v", got[1])
+		}
+	}
+
+	devices, err := push.List(owner.ID)
+	if err != nil {
+		t.Fatalf("Failed to list devices: %v", err)
+	}
+	if len(devices) != 2 {
+		t.Fatalf("Expected the dead token to be pruned, got %d devices", len(devices))
+	}
+	for _, device := range devices {
+		if device.Token == "fcm-dead" {
+			t.Error("Expected the dead token to be deleted")
+		}
+	}
+
+	// Past the limit the least recently seen device is forgotten
+	push.Register(owner.ID, "fcm", "fcm-new-1", "")
+	push.Register(owner.ID, "fcm", "fcm-new-2", "")
+	if devices, _ = push.List(owner.ID); len(devices) != 3 {
+		t.Errorf("Expected at most 3 devices, got %d", len(devices))
+	}
+}
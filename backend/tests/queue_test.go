@@ -0,0 +1,431 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/worker"
+)
+
+// fakeNATS is just enough of a NATS server to route PUB to queue-group SUBs
+type fakeNATS struct {
+	listener net.Listener
+
+	mu    sync.Mutex
+	conns []net.Conn
+	subs  map[string]map[string][]fakeNATSSub // subject -> queue group -> members
+	next  int
+}
+
+type fakeNATSSub struct {
+	conn net.Conn
+	sid  string
+}
+
+func startFakeNATS(t *testing.T) *fakeNATS {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	server := &fakeNATS{listener: listener, subs: map[string]map[string][]fakeNATSSub{}}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			server.mu.Lock()
+			server.conns = append(server.conns, conn)
+			server.mu.Unlock()
+			go server.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { listener.Close(); server.dropAll() })
+	return server
+}
+
+func (s *fakeNATS) url() string {
+	return "nats://" + s.listener.Addr().String()
+}
+
+// dropAll closes every client connection and forgets their subscriptions
+func (s *fakeNATS) dropAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+	s.subs = map[string]map[string][]fakeNATSSub{}
+}
+
+func (s *fakeNATS) serve(conn net.Conn) {
+	fmt.Fprint(conn, "INFO {\"server_id\":\"fake\"}\r\n")
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "PING":
+			fmt.Fprint(conn, "PONG\r\n")
+		case "SUB":
+			s.mu.Lock()
+			if s.subs[fields[1]] == nil {
+				s.subs[fields[1]] = map[string][]fakeNATSSub{}
+			}
+			s.subs[fields[1]][fields[2]] = append(s.subs[fields[1]][fields[2]], fakeNATSSub{conn: conn, sid: fields[3]})
+			s.mu.Unlock()
+		case "PUB":
+			size, _ := strconv.Atoi(fields[2])
+			payload := make([]byte, size+2)
+			if _, err := io.ReadFull(reader, payload); err != nil {
+				return
+			}
+			s.mu.Lock()
+			for _, members := range s.subs[fields[1]] {
+				member := members[s.next%len(members)]
+				fmt.Fprintf(member.conn, "MSG %s %s %d\r\n%s", fields[1], member.sid, size, payload)
+			}
+			s.next++
+			s.mu.Unlock()
+		}
+	}
+}
+
+// waitFor polls cond for up to five seconds
+func waitFor(t *testing.T, what string, cond func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestNATSEventBus tests that each role's queue group receives an event once, across reconnects
+func TestNATSEventBus(t *testing.T) {
+	for _, backend := range []string{"kafka", "rabbitmq"} {
+		if _, err := services.NewEventBus(config.QueueConfig{Backend: backend}, "server"); err == nil {
+			t.Errorf("Expected QUEUE_BACKEND=%s to be refused", backend)
+		}
+	}
+	if _, err := services.NewEventBus(config.QueueConfig{Backend: "nats", NATSURL: "tls://127.0.0.1:4222"}, "server"); err == nil {
+		t.Error("Expected a tls:// NATS URL to be refused")
+	}
+
+	nats := startFakeNATS(t)
+	cfg := config.QueueConfig{Backend: "nats", NATSURL: nats.url(), SubjectPrefix: "test"}
+
+	var serverCount, workerCount atomic.Int32
+	var buses []services.EventBus
+	for _, role := range []string{"server", "server", "worker"} {
+		bus, err := services.NewEventBus(cfg, role)
+		if err != nil {
+			t.Fatalf("Failed to connect %s bus: %v", role, err)
+		}
+		defer bus.Close()
+		counter := &serverCount
+		if role == "worker" {
+			counter = &workerCount
+		}
+		bus.Subscribe(services.EventReportUploaded, func(event services.Event) error {
+			if event.ReportID != 7 || event.OccurredAt.IsZero() {
+				t.Errorf("Unexpected event %+v", event)
+			}
+			counter.Add(1)
+			return nil
+		})
+		buses = append(buses, bus)
+	}
+	waitFor(t, "subscriptions", func() bool {
+		nats.mu.Lock()
+		defer nats.mu.Unlock()
+		groups := nats.subs["test.events.report.uploaded"]
+		return len(groups["test.server"]) == 2 && len(groups["test.worker"]) == 1
+	})
+
+	buses[0].Publish(services.Event{Type: services.EventReportUploaded, UserID: 1, ReportID: 7})
+	waitFor(t, "delivery", func() bool { return serverCount.Load() == 1 && workerCount.Load() == 1 })
+
+	// Every client resubscribes after the server drops it
+	nats.dropAll()
+	waitFor(t, "resubscription", func() bool {
+		nats.mu.Lock()
+		defer nats.mu.Unlock()
+		groups := nats.subs["test.events.report.uploaded"]
+		return len(groups["test.server"]) == 2 && len(groups["test.worker"]) == 1
+	})
+	waitFor(t, "redelivery", func() bool {
+		buses[2].Publish(services.Event{Type: services.EventReportUploaded, UserID: 1, ReportID: 7})
+		time.Sleep(50 * time.Millisecond)
+		return serverCount.Load() >= 2 && workerCount.Load() >= 2
+	})
+	time.Sleep(100 * time.Millisecond)
+	if serverCount.Load() != workerCount.Load() {
+		t.Errorf("Expected each group to receive every event once, got %d server and %d worker deliveries", serverCount.Load(), workerCount.Load())
+	}
+}
+
+// countingAnalyzer counts analyses, taking long enough for concurrent claims to overlap
+type countingAnalyzer struct {
+	calls atomic.Int32
+}
+
+func (c *countingAnalyzer) AnalyzeReport(ctx context.Context, filePath, fileType, readingLevel, plan, patient string) (*services.ReportAnalysis, error) {
+	c.calls.Add(1)
+	time.Sleep(20 * time.Millisecond)
+	return services.NewDemoAnalyzer().AnalyzeReport(ctx, filePath, fileType, readingLevel, plan, patient)
+}
+
+// TestReportClaiming tests that a pending report seen by several workers is analyzed exactly once
+func TestReportClaiming(t *testing.T) {
+	// Decision: A file database, since the workers below open connections of their own and each connection
+	// to :memory: is a separate, empty database
+	dsn := filepath.Join(t.TempDir(), "claims.db") + "?_busy_timeout=5000&_journal_mode=WAL&_txlock=immediate"
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: dsn}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	owner := &models.User{Email: "claims@example.com", PasswordHash: "hash", FullName: "Owner", IsActive: true}
+	if err := models.NewUserRepository(db.GetDB()).Create(owner); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	uploadDir := t.TempDir()
+	filePath := filepath.Join(uploadDir, "cbc.txt")
+	os.WriteFile(filePath, []byte("Hemoglobin 13.5 g/dL"), 0o600)
+	reportRepo := models.NewReportRepository(db.GetDB())
+	report := &models.Report{UserID: owner.ID, OriginalFilename: "cbc.txt", FilePath: filePath, FileType: "text/plain",
+		FileSize: 20, ProcessingStatus: "pending", ReadingLevel: models.ReadingLevelStandard}
+	if err := reportRepo.Create(report); err != nil {
+		t.Fatalf("Failed to create report: %v", err)
+	}
+
+	analyzer := &countingAnalyzer{}
+	processor := services.NewReportProcessorWithAnalyzer(reportRepo, nil, nil, nil, analyzer, services.NewFileStorage(uploadDir, "secret"))
+
+	// Every worker loaded the report while it was still pending
+	var wg sync.WaitGroup
+	var claimed atomic.Int32
+	for i := 0; i < 5; i++ {
+		seen, _ := reportRepo.GetByID(report.ID)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := processor.ProcessReport(seen)
+			if err == nil {
+				claimed.Add(1)
+			} else if err != services.ErrReportClaimed {
+				t.Errorf("Expected a lost claim to be reported as such, got %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if analyzer.calls.Load() != 1 || claimed.Load() != 1 {
+		t.Errorf("Expected exactly one analysis, got %d analyses and %d successful claims", analyzer.calls.Load(), claimed.Load())
+	}
+	if stored, _ := reportRepo.GetByID(report.ID); stored.ProcessingStatus != "completed" {
+		t.Errorf("Expected the report completed, got %s", stored.ProcessingStatus)
+	}
+
+	// A stale copy can't restart a report that has moved on
+	stale := *report
+	if err := processor.ProcessReport(&stale); err != services.ErrReportClaimed {
+		t.Errorf("Expected a stale pending copy to lose its claim, got %v", err)
+	}
+}
+
+// TestWorkerConsumesUploads tests that a worker processes reports as their upload events arrive
+func TestWorkerConsumesUploads(t *testing.T) {
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	owner := &models.User{Email: "queue@example.com", PasswordHash: "hash", FullName: "Owner", IsActive: true}
+	if err := models.NewUserRepository(db.GetDB()).Create(owner); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	uploadDir := t.TempDir()
+	filePath := filepath.Join(uploadDir, "cbc.txt")
+	os.WriteFile(filePath, []byte("Hemoglobin 13.5 g/dL"), 0o600)
+	reportRepo := models.NewReportRepository(db.GetDB())
+
+	processor := services.NewReportProcessorWithAnalyzer(reportRepo, nil, nil, nil, services.NewDemoAnalyzer(), services.NewFileStorage(uploadDir, "secret"))
+	// Decision: An hour-long poll interval leaves the event as the only way the report gets processed
+	w := worker.NewWorker(reportRepo, processor, time.Hour, 10, 2, time.Hour)
+	bus := services.NewLocalEventBus(0)
+	defer bus.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	w.Consume(ctx, bus)
+	go func() { w.Run(ctx); close(done) }()
+
+	// Let the startup poll pass so the report can only arrive through the event
+	time.Sleep(50 * time.Millisecond)
+	report := &models.Report{UserID: owner.ID, OriginalFilename: "cbc.txt", FilePath: filePath, FileType: "text/plain",
+		FileSize: 20, ProcessingStatus: "pending", ReadingLevel: models.ReadingLevelStandard}
+	if err := reportRepo.Create(report); err != nil {
+		t.Fatalf("Failed to create report: %v", err)
+	}
+	bus.Publish(services.Event{Type: services.EventReportUploaded, UserID: owner.ID, ReportID: report.ID})
+
+	waitFor(t, "processing", func() bool {
+		stored, _ := reportRepo.GetByID(report.ID)
+		return stored != nil && stored.ProcessingStatus == "completed"
+	})
+	cancel()
+	<-done
+}
+
+// flakyAnalyzer times out a number of times before answering
+type flakyAnalyzer struct {
+	failures atomic.Int32
+}
+
+func (f *flakyAnalyzer) AnalyzeReport(ctx context.Context, filePath, fileType, readingLevel, plan, patient string) (*services.ReportAnalysis, error) {
+	if f.failures.Add(-1) >= 0 {
+		return nil, fmt.Errorf("calling the model: %w", context.DeadlineExceeded)
+	}
+	return services.NewDemoAnalyzer().AnalyzeReport(ctx, filePath, fileType, readingLevel, plan, patient)
+}
+
+// TestProcessingRetriesAndRecovery tests that passing failures are retried with backoff and that reports
+// left processing by a stopped process go back in the queue
+func TestProcessingRetriesAndRecovery(t *testing.T) {
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	owner := &models.User{Email: "retries@example.com", PasswordHash: "hash", FullName: "Owner", IsActive: true}
+	if err := models.NewUserRepository(db.GetDB()).Create(owner); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	uploadDir := t.TempDir()
+	filePath := filepath.Join(uploadDir, "cbc.txt")
+	os.WriteFile(filePath, []byte("Hemoglobin 13.5 g/dL"), 0o600)
+	reportRepo := models.NewReportRepository(db.GetDB())
+	jobRepo := models.NewJobRepository(db.GetDB())
+	newReport := func() *models.Report {
+		report := &models.Report{UserID: owner.ID, OriginalFilename: "cbc.txt", FilePath: filePath, FileType: "text/plain",
+			FileSize: 20, ProcessingStatus: "pending", ReadingLevel: models.ReadingLevelStandard}
+		if err := reportRepo.Create(report); err != nil {
+			t.Fatalf("Failed to create report: %v", err)
+		}
+		return report
+	}
+	pending := func() map[int]bool {
+		reports, _ := reportRepo.GetPendingReports(10)
+		ids := map[int]bool{}
+		for _, report := range reports {
+			ids[report.ID] = true
+		}
+		return ids
+	}
+
+	analyzer := &flakyAnalyzer{}
+	processor := services.NewReportProcessorWithAnalyzer(reportRepo, jobRepo, nil, nil, analyzer, services.NewFileStorage(uploadDir, "secret"))
+	processor.SetRetryPolicy(services.RetryPolicy{MaxAttempts: 3})
+
+	// A report that times out twice is retried, and completes on its third attempt
+	analyzer.failures.Store(2)
+	report := newReport()
+	for attempt := 1; attempt <= 3; attempt++ {
+		current, _ := reportRepo.GetByID(report.ID)
+		err := processor.ProcessReport(current)
+		var retry *services.RetryScheduledError
+		if attempt < 3 && !errors.As(err, &retry) {
+			t.Fatalf("Expected attempt %d to be retried, got %v", attempt, err)
+		}
+		if attempt == 3 && err != nil {
+			t.Fatalf("Expected the third attempt to succeed, got %v", err)
+		}
+	}
+	if stored, _ := reportRepo.GetByID(report.ID); stored.ProcessingStatus != "completed" {
+		t.Errorf("Expected the report completed, got %s", stored.ProcessingStatus)
+	}
+
+	// One that keeps timing out fails once it has had its attempts
+	analyzer.failures.Store(10)
+	report = newReport()
+	for attempt := 1; attempt <= 3; attempt++ {
+		current, _ := reportRepo.GetByID(report.ID)
+		processor.ProcessReport(current)
+	}
+	if stored, _ := reportRepo.GetByID(report.ID); stored.ProcessingStatus != "failed" || stored.ErrorCode != models.ProcessingErrorAITimeout {
+		t.Errorf("Expected the report failed with ai_timeout, got %s %s", stored.ProcessingStatus, stored.ErrorCode)
+	}
+	if attempts, _ := jobRepo.ListAttempts(report.ID); len(attempts) != 3 {
+		t.Errorf("Expected 3 attempts, got %d", len(attempts))
+	}
+
+	// A report waiting out its backoff isn't handed to workers yet
+	processor.SetRetryPolicy(services.RetryPolicy{MaxAttempts: 3, Backoff: time.Hour})
+	analyzer.failures.Store(1)
+	report = newReport()
+	processor.ProcessReport(report)
+	if stored, _ := reportRepo.GetByID(report.ID); stored.ProcessingStatus != "pending" || pending()[report.ID] {
+		t.Errorf("Expected the report pending but not yet listed, got %s", stored.ProcessingStatus)
+	}
+
+	// A report left processing by a stopped process is requeued, and its attempt closed
+	processor.SetRetryPolicy(services.RetryPolicy{MaxAttempts: 2})
+	abandoned := newReport()
+	if claimed, _ := reportRepo.ClaimForProcessing(abandoned.ID, "pending"); !claimed {
+		t.Fatal("Failed to claim report")
+	}
+	jobRepo.StartAttempt(abandoned.ID, "demo")
+	if recovered, err := processor.RecoverStale(time.Now().Add(-time.Hour), 10); err != nil || recovered != 0 {
+		t.Fatalf("Expected a report processing for moments to be left alone, got %d (%v)", recovered, err)
+	}
+	// Decision: Stored times are kept to the second, so look from a second ahead
+	if recovered, err := processor.RecoverStale(time.Now().Add(time.Second), 10); err != nil || recovered != 1 {
+		t.Fatalf("Expected 1 recovered report, got %d (%v)", recovered, err)
+	}
+	if !pending()[abandoned.ID] {
+		t.Error("Expected the abandoned report back in the queue")
+	}
+	if attempts, _ := jobRepo.ListAttempts(abandoned.ID); len(attempts) != 1 || attempts[0].Status != models.AttemptAbandoned {
+		t.Errorf("Expected the attempt abandoned, got %+v", attempts)
+	}
+
+	// Abandoned again, it has had its attempts and fails
+	reportRepo.ClaimForProcessing(abandoned.ID, "pending")
+	jobRepo.StartAttempt(abandoned.ID, "demo")
+	processor.RecoverStale(time.Now().Add(time.Second), 10)
+	if stored, _ := reportRepo.GetByID(abandoned.ID); stored.ProcessingStatus != "failed" {
+		t.Errorf("Expected a report abandoned twice to fail, got %s", stored.ProcessingStatus)
+	}
+}
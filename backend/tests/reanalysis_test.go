@@ -0,0 +1,167 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+)
+
+// staleAnalysis is an analysis as an earlier prompt wrote it
+const staleAnalysis = `{"schema_version":1,"simple_summary":"Your blood looks fine.","risk_level":"low",
+	"health_metrics":[{"name":"Legacy Index","value":"1","status":"normal"}],
+	"key_findings":["Nothing to report"],"recommendations":[]}`
+
+// TestReanalysis tests that a run re-analyzes only stale reports, throttles on quota errors, and diffs the results
+func TestReanalysis(t *testing.T) {
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	owner := &models.User{Email: "owner@example.com", PasswordHash: "hash", FullName: "Owner", IsActive: true}
+	admin := &models.User{Email: "admin@example.com", PasswordHash: "hash", FullName: "Admin", IsActive: true}
+	for _, user := range []*models.User{owner, admin} {
+		if err := models.NewUserRepository(db.GetDB()).Create(user); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+
+	uploadDir := t.TempDir()
+	reportRepo := models.NewReportRepository(db.GetDB())
+	jobRepo := models.NewJobRepository(db.GetDB())
+	storage := services.NewFileStorage(uploadDir, "secret")
+	demo := services.NewReportProcessorWithAnalyzer(reportRepo, jobRepo, nil, nil, services.NewDemoAnalyzer(), storage)
+
+	// Three reports analyzed by the current prompt and model; two are then made stale
+	var reports []*models.Report
+	for i := range 3 {
+		report := &models.Report{UserID: owner.ID, OriginalFilename: "cbc.txt", FilePath: filepath.Join(uploadDir, fmt.Sprintf("cbc-%d.txt", i)),
+			FileType: "text/plain", FileSize: 10, ProcessingStatus: "pending", ReadingLevel: models.ReadingLevelStandard}
+		if err := reportRepo.Create(report); err != nil {
+			t.Fatalf("Failed to create report: %v", err)
+		}
+		if err := demo.ProcessReport(report); err != nil {
+			t.Fatalf("Failed to process report: %v", err)
+		}
+		reports = append(reports, report)
+	}
+	for _, report := range reports[1:] {
+		if err := reportRepo.UpdateProcessingStatus(report.ID, "completed", staleAnalysis); err != nil {
+			t.Fatalf("Failed to store stale analysis: %v", err)
+		}
+		if err := reportRepo.SetAnalysisMetadata(report.ID, "v0", false); err != nil {
+			t.Fatalf("Failed to store stale prompt version: %v", err)
+		}
+	}
+
+	repo := models.NewReanalysisRepository(db.GetDB())
+	auditRepo := models.NewAuditLogRepository(db.GetDB())
+	reanalysis := services.NewReanalysisService(repo, reportRepo, auditRepo, services.DemoPromptVersion, demo.ModelName())
+
+	stale, err := reanalysis.Preview(services.ReanalysisRequest{})
+	if err != nil || len(stale) != 2 || stale[0] != reports[1].ID {
+		t.Fatalf("Expected the two stale reports selected, got %v (%v)", stale, err)
+	}
+	if _, err := reanalysis.Start(admin, services.ReanalysisRequest{}); err == nil {
+		t.Error("Expected a run without a reason to be refused")
+	}
+	run, err := reanalysis.Start(admin, services.ReanalysisRequest{Reason: "Prompt v1 rollout"})
+	if err != nil {
+		t.Fatalf("Failed to start re-analysis: %v", err)
+	}
+	if run.Counts[models.ReanalysisItemPending] != 2 {
+		t.Errorf("Expected 2 reports queued, got %+v", run.Counts)
+	}
+	if _, err := reanalysis.Start(admin, services.ReanalysisRequest{Reason: "Again"}); err == nil {
+		t.Error("Expected a second run to be refused while one is running")
+	}
+
+	// A quota error puts the report back in the queue and slows the run down
+	throttled := services.NewReportProcessorWithAnalyzer(reportRepo, jobRepo, nil, nil,
+		erroringAnalyzer{err: &services.RateLimitError{Err: fmt.Errorf("429 Too Many Requests")}}, storage)
+	runner := services.NewReanalysisRunner(repo, reportRepo, throttled, 60)
+	if wait := runner.Step(); wait <= time.Second {
+		t.Errorf("Expected a longer wait after a quota error, got %s", wait)
+	}
+	if detail, _ := reanalysis.Run(run.ID); detail.Run.Counts[models.ReanalysisItemPending] != 2 {
+		t.Errorf("Expected the throttled report requeued, got %+v", detail.Run.Counts)
+	}
+
+	// The first report is re-analyzed; the second changes while the model works and keeps its new content
+	runner = services.NewReanalysisRunner(repo, reportRepo, demo, 60)
+	runner.Step()
+	if err := reportRepo.UpdateProcessingStatus(reports[2].ID, "completed", `{"simple_summary":"Edited"}`); err != nil {
+		t.Fatalf("Failed to change report: %v", err)
+	}
+	runner.Step()
+
+	detail, err := reanalysis.Run(run.ID)
+	if err != nil {
+		t.Fatalf("Failed to load run: %v", err)
+	}
+	if detail.Run.Status != models.ReanalysisCompleted || len(detail.Items) != 2 ||
+		detail.Items[0].Status != models.ReanalysisItemSucceeded || detail.Items[1].Status != models.ReanalysisItemSkipped {
+		t.Errorf("Unexpected run %+v with items %+v, %+v", detail.Run, detail.Items[0], detail.Items[1])
+	}
+	updated, _ := reportRepo.GetByID(reports[1].ID)
+	if updated.ProcessingStatus != "completed" || updated.SimplifiedSummary == staleAnalysis {
+		t.Errorf("Expected the stale analysis replaced, got %s %q", updated.ProcessingStatus, updated.SimplifiedSummary)
+	}
+	if kept, _ := reportRepo.GetByID(reports[2].ID); kept.SimplifiedSummary != `{"simple_summary":"Edited"}` {
+		t.Errorf("Expected the changed report left alone, got %q", kept.SimplifiedSummary)
+	}
+
+	diff, err := reanalysis.Diff(admin, run.ID, reports[1].ID)
+	if err != nil {
+		t.Fatalf("Failed to diff: %v", err)
+	}
+	if diff.SimpleSummary == nil || diff.SimpleSummary.Old != "Your blood looks fine." {
+		t.Errorf("Expected the summary change, got %+v", diff.SimpleSummary)
+	}
+	removed := false
+	for _, metric := range diff.Metrics {
+		removed = removed || (metric.Name == "Legacy Index" && metric.Change == "removed")
+	}
+	if !removed || len(diff.KeyFindings.Removed) != 1 {
+		t.Errorf("Expected the old metric and finding removed, got %+v / %+v", diff.Metrics, diff.KeyFindings)
+	}
+
+	entries, err := auditRepo.List(models.AuditLogFilter{ActorID: admin.ID})
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("Expected the start and the diff view audited, got %+v (%v)", entries, err)
+	}
+
+	// Only the report edited mid-run is still stale, since it still carries the old prompt version
+	if stale, _ := reanalysis.Preview(services.ReanalysisRequest{}); len(stale) != 1 || stale[0] != reports[2].ID {
+		t.Errorf("Expected only the edited report still stale, got %v", stale)
+	}
+}
+
+// TestAdminReanalysis tests access to and validation of the admin re-analysis endpoints
+func TestAdminReanalysis(t *testing.T) {
+	server := setupTestServer(t)
+	defer server.Close()
+
+	token := signupAndGetToken(t, server.URL, "patient@example.com")
+	if status := doJSONRequest(t, "GET", server.URL+"/api/admin/reanalysis", token, nil, nil); status != http.StatusForbidden {
+		t.Errorf("Expected non-admins to be refused, got %d", status)
+	}
+
+	adminToken := signupAndGetToken(t, server.URL, "admin@example.com")
+	if status := doJSONRequest(t, "POST", server.URL+"/api/admin/reanalysis", adminToken,
+		map[string]any{"reason": "rollout", "since": "last week"}, nil); status != http.StatusBadRequest {
+		t.Errorf("Expected a malformed date to be rejected, got %d", status)
+	}
+	if status := doJSONRequest(t, "GET", server.URL+"/api/admin/reanalysis/999", adminToken, nil, nil); status != http.StatusNotFound {
+		t.Errorf("Expected an unknown run to be not found, got %d", status)
+	}
+}
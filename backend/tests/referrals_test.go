@@ -0,0 +1,151 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+)
+
+// referralAnalysis has out-of-range kidney, lipid, sugar, and vitamin results next to a normal blood count
+const referralAnalysis = `{"schema_version": 4, "simple_summary": "Labs", "risk_level": "high", "health_metrics": [
+	{"name": "Serum Creatinine", "value": 3.2, "unit": "mg/dL", "status": "critical"},
+	{"name": "LDL Cholesterol", "value": 165, "unit": "mg/dL", "status": "warning"},
+	{"name": "HbA1c (Glycated Hemoglobin)", "value": 6.4, "unit": "%", "status": "warning"},
+	{"name": "Hemoglobin", "value": 14, "unit": "g/dL", "status": "normal"},
+	{"name": "Vitamin D", "value": 12, "unit": "ng/mL", "status": "warning"}]}`
+
+// TestReferralSuggestions tests mapping out-of-range metrics to specialties and looking up doctors nearby
+func TestReferralSuggestions(t *testing.T) {
+	analysis, err := services.ParseStoredAnalysis(referralAnalysis)
+	if err != nil {
+		t.Fatalf("Failed to parse analysis: %v", err)
+	}
+	suggestions := services.SuggestReferrals(analysis)
+	var got []string
+	for _, suggestion := range suggestions {
+		got = append(got, suggestion.Specialty.Key+":"+suggestion.Urgency)
+	}
+	want := "[nephrology:soon cardiology:routine endocrinology:routine general_physician:routine]"
+	if fmt.Sprint(got) != want {
+		t.Errorf("Expected %s, got %v", want, got)
+	}
+
+	// A directory that records what it was asked
+	var asked []string
+	directoryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer directory-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		asked = append(asked, r.URL.Query().Get("specialty")+"@"+r.URL.Query().Get("lat")+","+r.URL.Query().Get("lng"))
+		if r.URL.Query().Get("specialty") == "general_physician" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"doctors": []map[string]any{
+			{"name": "Dr. A", "specialty": r.URL.Query().Get("specialty"), "distance_km": 1.5},
+			{"name": "Dr. B"}, {"name": "Dr. C"}, {"name": "Dr. D"},
+		}})
+	}))
+	defer directoryServer.Close()
+
+	if _, err := services.NewDoctorDirectory(config.DirectoryConfig{Provider: "http"}); err == nil {
+		t.Error("Expected an http directory without a URL to be refused")
+	}
+	directory, err := services.NewDoctorDirectory(config.DirectoryConfig{Provider: "http", URL: directoryServer.URL, APIKey: "directory-key"})
+	if err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	owner := &models.User{Email: "owner@example.com", PasswordHash: "hash", FullName: "Owner", IsActive: true}
+	if err := models.NewUserRepository(db.GetDB()).Create(owner); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	reportRepo := models.NewReportRepository(db.GetDB())
+	report := &models.Report{UserID: owner.ID, OriginalFilename: "labs.txt", FilePath: "labs.txt", FileType: "text/plain",
+		FileSize: 10, ProcessingStatus: "pending", ReadingLevel: models.ReadingLevelStandard}
+	if err := reportRepo.Create(report); err != nil {
+		t.Fatalf("Failed to create report: %v", err)
+	}
+
+	referralService := services.NewReferralService(reportRepo, directory)
+	if _, err := referralService.Suggest(context.Background(), owner.ID, report.ID, nil); err == nil {
+		t.Error("Expected an unprocessed report to be refused")
+	}
+	if err := reportRepo.UpdateProcessingStatus(report.ID, "completed", referralAnalysis); err != nil {
+		t.Fatalf("Failed to store analysis: %v", err)
+	}
+	if _, err := referralService.Suggest(context.Background(), owner.ID+1, report.ID, nil); err == nil {
+		t.Error("Expected another user's report to be refused")
+	}
+
+	// Without a location the directory isn't asked
+	referrals, err := referralService.Suggest(context.Background(), owner.ID, report.ID, nil)
+	if err != nil || len(referrals.Suggestions) != 4 || referrals.Suggestions[0].Doctors != nil || len(asked) != 0 {
+		t.Fatalf("Expected suggestions without doctors, got %+v (%v), asked %v", referrals, err, asked)
+	}
+
+	// A failing search leaves its suggestion without doctors instead of failing the request
+	lat, lng := 12.97, 77.59
+	referrals, err = referralService.Suggest(context.Background(), owner.ID, report.ID, &services.DoctorLocation{Latitude: &lat, Longitude: &lng})
+	if err != nil {
+		t.Fatalf("Failed to suggest referrals: %v", err)
+	}
+	if len(asked) != 4 || asked[0] != "nephrology@12.97,77.59" {
+		t.Errorf("Unexpected directory searches %v", asked)
+	}
+	nephrology := referrals.Suggestions[0]
+	if len(nephrology.Doctors) != 3 || nephrology.Doctors[0].Name != "Dr. A" || *nephrology.Doctors[0].DistanceKm != 1.5 {
+		t.Errorf("Expected three nephrologists, got %+v", nephrology.Doctors)
+	}
+	if referrals.Suggestions[3].Doctors != nil {
+		t.Errorf("Expected no doctors after the failed search, got %+v", referrals.Suggestions[3].Doctors)
+	}
+
+	if _, err := referralService.FindDoctors(context.Background(), "astrology", services.DoctorLocation{City: "Pune"}, 5); err == nil {
+		t.Error("Expected an unknown specialty to be refused")
+	}
+	if _, err := referralService.FindDoctors(context.Background(), "general_physician", services.DoctorLocation{City: "Pune"}, 5); err == nil {
+		t.Error("Expected a failing directory to be reported")
+	}
+}
+
+// TestReferralEndpoints tests validation and the disabled directory on the referral endpoints
+func TestReferralEndpoints(t *testing.T) {
+	server := setupTestServer(t)
+	defer server.Close()
+
+	token := signupAndGetToken(t, server.URL, "patient@example.com")
+	var specialties struct {
+		Specialties      []map[string]any `json:"specialties"`
+		DirectoryEnabled bool             `json:"directory_enabled"`
+	}
+	if status := doJSONRequest(t, "GET", server.URL+"/api/specialties", token, nil, &specialties); status != http.StatusOK ||
+		len(specialties.Specialties) != 6 || specialties.DirectoryEnabled {
+		t.Errorf("Expected six specialties without a directory, got %d %+v", status, specialties)
+	}
+	if status := doJSONRequest(t, "GET", server.URL+"/api/doctors?specialty=cardiology&lat=12.9", token, nil, nil); status != http.StatusBadRequest {
+		t.Errorf("Expected lat without lng to be rejected, got %d", status)
+	}
+	if status := doJSONRequest(t, "GET", server.URL+"/api/doctors?specialty=cardiology&city=Pune", token, nil, nil); status != http.StatusServiceUnavailable {
+		t.Errorf("Expected doctor search to be unavailable without a directory, got %d", status)
+	}
+	if status := doJSONRequest(t, "GET", server.URL+"/api/reports/999/referrals", token, nil, nil); status != http.StatusNotFound {
+		t.Errorf("Expected an unknown report to be not found, got %d", status)
+	}
+}
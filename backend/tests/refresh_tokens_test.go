@@ -0,0 +1,149 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/client"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// TestRefreshTokenRotation tests that each refresh spends the refresh token sent, and that presenting a spent one
+// signs out its whole sign-in while the user's other sign-ins keep working
+func TestRefreshTokenRotation(t *testing.T) {
+	server := setupTestServer(t)
+	defer server.Close()
+	ctx := context.Background()
+
+	session := client.New(server.URL, "")
+	signedUp, err := session.Signup(ctx, types.SignupRequest{Email: "rotate@example.com", Password: "password123", FullName: "Test User"})
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	if signedUp.RefreshToken == "" || signedUp.RefreshExpiresAt == nil || !signedUp.RefreshExpiresAt.After(signedUp.ExpiresAt) {
+		t.Fatalf("Expected a refresh token outliving the access token, got %+v", signedUp)
+	}
+	other, err := client.New(server.URL, "").Login(ctx, "rotate@example.com", "password123")
+	if err != nil {
+		t.Fatalf("Failed to sign in again: %v", err)
+	}
+
+	// Refreshing needs no access token and replaces both tokens
+	first, err := session.Refresh(ctx)
+	if err != nil {
+		t.Fatalf("Failed to refresh: %v", err)
+	}
+	if first.RefreshToken == signedUp.RefreshToken || session.RefreshToken() != first.RefreshToken {
+		t.Fatalf("Expected a new refresh token, got %+v", first)
+	}
+	if _, err := client.New(server.URL, first.Token).Me(ctx); err != nil {
+		t.Fatalf("Expected the refreshed access token to work: %v", err)
+	}
+	second, err := session.Refresh(ctx)
+	if err != nil {
+		t.Fatalf("Failed to refresh again: %v", err)
+	}
+
+	// Replaying a spent token ends the sign-in, including its newest tokens
+	replay := client.New(server.URL, "")
+	replay.SetRefreshToken(signedUp.RefreshToken)
+	if _, err := replay.Refresh(ctx); !isSessionEnded(err) {
+		t.Fatalf("Expected the spent refresh token refused as signed out, got %v", err)
+	}
+	if _, err := session.Refresh(ctx); !isSessionEnded(err) {
+		t.Errorf("Expected the newest refresh token revoked after reuse, got %v", err)
+	}
+	if _, err := client.New(server.URL, second.Token).Me(ctx); !isSessionEnded(err) {
+		t.Errorf("Expected the sign-in's access token revoked after reuse, got %v", err)
+	}
+	if _, err := client.New(server.URL, other.Token).Me(ctx); err != nil {
+		t.Errorf("Expected the user's other sign-in to keep working: %v", err)
+	}
+
+	// Logging out with only the refresh token, as a client whose access token expired would
+	expired := client.New(server.URL, "")
+	expired.SetRefreshToken(other.RefreshToken)
+	if err := expired.Logout(ctx); err != nil {
+		t.Fatalf("Failed to log out with the refresh token: %v", err)
+	}
+	if _, err := client.New(server.URL, other.Token).Me(ctx); !isSessionEnded(err) {
+		t.Errorf("Expected the access token signed out with its refresh token, got %v", err)
+	}
+
+	// Access tokens and unknown tokens can't be exchanged
+	for name, token := range map[string]string{"access token": other.Token, "unknown token": "rt_unknown"} {
+		stranger := client.New(server.URL, "")
+		stranger.SetRefreshToken(token)
+		if _, err := stranger.Refresh(ctx); !client.IsStatus(err, http.StatusUnauthorized) {
+			t.Errorf("Expected the %s refused, got %v", name, err)
+		}
+	}
+	if status := doJSONRequest(t, "POST", server.URL+"/api/auth/refresh", "", map[string]string{}, nil); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 without a refresh token, got %d", status)
+	}
+}
+
+// TestRefreshTokenExpiry tests that refresh tokens stop working once they expire or their account is deactivated
+func TestRefreshTokenExpiry(t *testing.T) {
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	userRepo := models.NewUserRepository(db.GetDB())
+	authService := services.NewAuthService(userRepo, services.NewPasswordServiceWithCost(4), services.NewJWTService("refresh-secret", time.Minute))
+	refreshTokens := models.NewRefreshTokenRepository(db.GetDB())
+	authService.SetRefreshTokens(refreshTokens, time.Hour)
+
+	signedUp, err := authService.SignUp(&types.SignupRequest{Email: "expiry@example.com", Password: "password123", FullName: "Expiry"})
+	if err != nil {
+		t.Fatalf("Failed to sign up: %v", err)
+	}
+
+	// An expired refresh token is refused
+	if _, err := db.GetDB().Exec(`UPDATE refresh_tokens SET expires_at = ?`, time.Now().Add(-time.Minute).UTC()); err != nil {
+		t.Fatalf("Failed to expire the refresh token: %v", err)
+	}
+	if _, err := authService.Refresh(signedUp.RefreshToken); err == nil {
+		t.Error("Expected an expired refresh token refused")
+	}
+
+	// A deactivated account can't refresh, and keeps its refresh token for when it is reactivated
+	login, err := authService.Login(&types.LoginRequest{Email: "expiry@example.com", Password: "password123"})
+	if err != nil {
+		t.Fatalf("Failed to sign in: %v", err)
+	}
+	if _, err := db.GetDB().Exec(`UPDATE users SET is_active = FALSE`); err != nil {
+		t.Fatalf("Failed to deactivate the user: %v", err)
+	}
+	if _, err := authService.Refresh(login.RefreshToken); err == nil {
+		t.Error("Expected a deactivated account's refresh token refused")
+	}
+	if _, err := db.GetDB().Exec(`UPDATE users SET is_active = TRUE`); err != nil {
+		t.Fatalf("Failed to reactivate the user: %v", err)
+	}
+	if _, err := authService.Refresh(login.RefreshToken); err != nil {
+		t.Errorf("Expected the refresh token to work again: %v", err)
+	}
+
+	// Signing in again pruned the expired token
+	var count int
+	db.GetDB().QueryRow(`SELECT COUNT(*) FROM refresh_tokens`).Scan(&count)
+	if count != 2 {
+		t.Errorf("Expected the spent and the live refresh token kept, got %d", count)
+	}
+}
+
+// isSessionEnded reports whether err is a 401 telling the client its sign-in was ended
+func isSessionEnded(err error) bool {
+	apiErr, ok := err.(*client.Error)
+	return ok && apiErr.Status == http.StatusUnauthorized && apiErr.Type == "SESSION_ENDED"
+}
@@ -0,0 +1,190 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// sseEvent is one event read off a report's event stream
+type sseEvent struct {
+	ID   string
+	Data types.ReportStatusEvent
+}
+
+// openReportEvents opens a report's event stream, resuming after lastEventID when it isn't empty
+func openReportEvents(t *testing.T, serverURL, token string, reportID int, lastEventID string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/reports/%d/events", serverURL, reportID), nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to open event stream: %v", err)
+	}
+	return resp
+}
+
+// readSSEEvents reads status events until the stream ends, passing each to onEvent as it arrives
+func readSSEEvents(t *testing.T, resp *http.Response, onEvent func(sseEvent)) []sseEvent {
+	t.Helper()
+	var events []sseEvent
+	var current sseEvent
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "id: "):
+			current.ID = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "data: "):
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &current.Data); err != nil {
+				t.Fatalf("Failed to decode event data %q: %v", line, err)
+			}
+		case line == "" && current.ID != "":
+			events = append(events, current)
+			onEvent(current)
+			current = sseEvent{}
+		}
+	}
+	return events
+}
+
+// TestReportEvents tests that a report's owner is streamed each status it enters, including changes made by
+// another process, and that the stream ends once the report completes
+func TestReportEvents(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "events.db") + "?_busy_timeout=5000&_journal_mode=WAL"
+	server := newTestServer(t, testServerOptions{dsn: dsn})
+	defer server.Close()
+
+	// A second connection stands in for a worker process analyzing the report
+	worker, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: dsn}})
+	if err != nil {
+		t.Fatalf("Failed to open the database: %v", err)
+	}
+	defer worker.Close()
+	reportRepo := models.NewReportRepository(worker.GetDB())
+
+	token := signupAndGetToken(t, server.URL, "events@example.com")
+	reportID := uploadTestReport(t, server.URL, token, "cbc.txt", "Hemoglobin 13.5 g/dL")
+
+	resp := openReportEvents(t, server.URL, token, reportID, "")
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Expected an event stream, got %d %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	// Each status is only entered once the previous one has been streamed
+	events := readSSEEvents(t, resp, func(event sseEvent) {
+		switch event.Data.Status {
+		case "pending":
+			if claimed, err := reportRepo.ClaimForProcessing(reportID, "pending"); err != nil || !claimed {
+				t.Errorf("Failed to claim the report: %v", err)
+			}
+		case "processing":
+			if err := reportRepo.UpdateProcessingStatus(reportID, "completed", "Normal blood count"); err != nil {
+				t.Errorf("Failed to complete the report: %v", err)
+			}
+		}
+	})
+	var statuses []string
+	for _, event := range events {
+		if event.Data.ReportID != reportID || event.Data.At.IsZero() {
+			t.Errorf("Unexpected event %+v", event)
+		}
+		statuses = append(statuses, event.Data.Status)
+	}
+	if got := strings.Join(statuses, ","); got != "pending,processing,completed" {
+		t.Fatalf("Expected pending, processing, then completed, got %s", got)
+	}
+
+	// Reconnecting after the final event has nothing more to send; from earlier, it replays the rest
+	resp = openReportEvents(t, server.URL, token, reportID, events[2].ID)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("Expected 204 after the final event, got %d", resp.StatusCode)
+	}
+	resp = openReportEvents(t, server.URL, token, reportID, events[0].ID)
+	replayed := readSSEEvents(t, resp, func(sseEvent) {})
+	resp.Body.Close()
+	if len(replayed) != 2 || replayed[1].Data.Status != "completed" {
+		t.Errorf("Expected processing and completed replayed, got %+v", replayed)
+	}
+
+	resp = openReportEvents(t, server.URL, signupAndGetToken(t, server.URL, "other@example.com"), reportID, "")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected 403 for another user's report, got %d", resp.StatusCode)
+	}
+	resp = openReportEvents(t, server.URL, token, reportID, "latest")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a malformed Last-Event-ID, got %d", resp.StatusCode)
+	}
+}
+
+// TestReportStreamWake tests that an event on the bus delivers a status change without waiting for the next poll
+func TestReportStreamWake(t *testing.T) {
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	owner := &models.User{Email: "owner@example.com", PasswordHash: "hash", FullName: "Owner", IsActive: true}
+	if err := models.NewUserRepository(db.GetDB()).Create(owner); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	reportRepo := models.NewReportRepository(db.GetDB())
+	report := &models.Report{UserID: owner.ID, OriginalFilename: "cbc.txt", FilePath: "cbc.txt", FileType: "text/plain", FileSize: 10}
+	if err := reportRepo.Create(report); err != nil {
+		t.Fatalf("Failed to create report: %v", err)
+	}
+
+	bus := services.NewLocalEventBus(8)
+	defer bus.Close()
+	stream := services.NewReportStreamService(reportRepo, time.Hour)
+	stream.Subscribe(bus)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	events, err := stream.Watch(ctx, owner, report.ID, 0)
+	if err != nil {
+		t.Fatalf("Failed to watch report: %v", err)
+	}
+	if event := <-events; event == nil || event.Status != "pending" {
+		t.Fatalf("Expected the pending status first, got %+v", event)
+	}
+
+	reportRepo.ClaimForProcessing(report.ID, "pending")
+	bus.Publish(services.Event{Type: services.EventReportStatusChanged, UserID: owner.ID, ReportID: report.ID})
+	select {
+	case event := <-events:
+		if event == nil || event.Status != "processing" {
+			t.Errorf("Expected processing, got %+v", event)
+		}
+	case <-ctx.Done():
+		t.Fatal("Expected the bus event to deliver the change before the next poll")
+	}
+
+	if _, err := stream.Watch(ctx, &models.User{ID: owner.ID + 1}, report.ID, 0); err == nil {
+		t.Error("Expected another user's watch to be refused")
+	}
+}
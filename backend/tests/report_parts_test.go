@@ -0,0 +1,180 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// pagesAnalyzer remembers the files of the last multi-part analysis
+type pagesAnalyzer struct {
+	paths []string
+}
+
+func (p *pagesAnalyzer) AnalyzeReport(ctx context.Context, filePath, fileType, readingLevel, plan, patient string) (*services.ReportAnalysis, error) {
+	return p.AnalyzeParts(ctx, []string{filePath}, fileType, readingLevel, plan, patient)
+}
+
+func (p *pagesAnalyzer) AnalyzeParts(ctx context.Context, filePaths []string, fileType, readingLevel, plan, patient string) (*services.ReportAnalysis, error) {
+	p.paths = filePaths
+	return services.NewDemoAnalyzer().AnalyzeParts(ctx, filePaths, fileType, readingLevel, plan, patient)
+}
+
+// TestMultiPartReports tests offering, merging, analyzing, and deleting reports uploaded page by page
+func TestMultiPartReports(t *testing.T) {
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	owner := &models.User{Email: "pages@example.com", PasswordHash: "hash", FullName: "Pages", IsActive: true}
+	if err := models.NewUserRepository(db.GetDB()).Create(owner); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	uploadDir := t.TempDir()
+	reportRepo := models.NewReportRepository(db.GetDB())
+	upload := func(name string) *models.Report {
+		report := &models.Report{UserID: owner.ID, OriginalFilename: name, FilePath: filepath.Join(uploadDir, name),
+			FileType: "application/pdf", FileSize: 10, ProcessingStatus: "pending", ReadingLevel: models.ReadingLevelStandard}
+		if err := reportRepo.Create(report); err != nil {
+			t.Fatalf("Failed to create report: %v", err)
+		}
+		return report
+	}
+
+	partRepo := models.NewReportPartRepository(db.GetDB())
+	parts := services.NewReportPartService(reportRepo, partRepo, 10*time.Minute)
+
+	first := upload("Lipid Panel page1.pdf")
+	second := upload("lipid_panel_page_2.pdf")
+	if candidate := parts.Candidate(second); candidate == nil || candidate.ID != first.ID {
+		t.Errorf("Expected the first page offered as a merge candidate, got %+v", candidate)
+	}
+	if candidate := parts.Candidate(upload("thyroid.pdf")); candidate != nil {
+		t.Errorf("Expected no candidate for an unrelated report, got %s", candidate.OriginalFilename)
+	}
+	if candidate := services.NewReportPartService(reportRepo, partRepo, 0).Candidate(second); candidate != nil {
+		t.Error("Expected a zero window to disable merge offers")
+	}
+
+	if _, err := parts.Merge(&models.User{ID: owner.ID + 1}, first.ID, second.ID); err != errors.ErrAccessDenied {
+		t.Errorf("Expected another user's merge to be refused, got %v", err)
+	}
+	if _, err := parts.Merge(owner, first.ID, first.ID); err == nil {
+		t.Error("Expected merging a report into itself to be rejected")
+	}
+	merged, err := parts.Merge(owner, first.ID, second.ID)
+	if err != nil {
+		t.Fatalf("Failed to merge reports: %v", err)
+	}
+	if merged.ProcessingStatus != "pending" {
+		t.Errorf("Expected the merged report queued again, got %s", merged.ProcessingStatus)
+	}
+	if gone, _ := reportRepo.GetByID(second.ID); gone != nil {
+		t.Error("Expected the merged page's own report removed")
+	}
+	pages, err := parts.Parts(owner, first.ID)
+	if err != nil || len(pages) != 1 || pages[0].PartNumber != 2 || pages[0].FilePath != second.FilePath {
+		t.Fatalf("Expected page 2 to be the second page's file, got %+v (%v)", pages, err)
+	}
+
+	// The pages are analyzed together, in order
+	analyzer := &pagesAnalyzer{}
+	processor := services.NewReportProcessorWithAnalyzer(reportRepo, nil, nil, nil, analyzer, services.NewFileStorage(uploadDir, "secret"))
+	processor.SetPartRepository(partRepo)
+	if err := processor.ProcessReport(merged); err != nil {
+		t.Fatalf("Failed to process merged report: %v", err)
+	}
+	if len(analyzer.paths) != 2 || filepath.Base(analyzer.paths[1]) != "lipid_panel_page_2.pdf" {
+		t.Errorf("Expected both pages analyzed in order, got %v", analyzer.paths)
+	}
+
+	// A report being analyzed can't take new pages
+	third := upload("lipid panel page 3.pdf")
+	if _, err := db.GetDB().Exec(`UPDATE reports SET processing_status = 'processing' WHERE id = ?`, first.ID); err != nil {
+		t.Fatalf("Failed to mark report processing: %v", err)
+	}
+	if _, err := parts.Merge(owner, first.ID, third.ID); err != errors.ErrReportBusy {
+		t.Errorf("Expected a processing report to refuse pages, got %v", err)
+	}
+
+	// Deleting the report hands every page's file to the janitor
+	if err := reportRepo.Delete(first.ID); err != nil {
+		t.Fatalf("Failed to delete report: %v", err)
+	}
+	var queued int
+	db.GetDB().QueryRow(`SELECT COUNT(*) FROM file_cleanup_queue WHERE file_path = ?`, second.FilePath).Scan(&queued)
+	if queued != 1 {
+		t.Error("Expected the second page's file queued for cleanup")
+	}
+}
+
+// TestMultiPartEndpoints tests merging uploads over HTTP
+func TestMultiPartEndpoints(t *testing.T) {
+	server := setupTestServer(t)
+	defer server.Close()
+
+	token := signupAndGetToken(t, server.URL, "pages-http@example.com")
+	first := uploadTestReport(t, server.URL, token, "cbc-1.txt", "Hemoglobin 13.5 g/dL")
+	second := uploadTestReport(t, server.URL, token, "cbc-2.txt", "Platelets 250 x10^3/uL")
+
+	var merged types.ReportPartsResponse
+	url := fmt.Sprintf("%s/api/reports/%d/parts", server.URL, first)
+	if status := doJSONRequest(t, "POST", url, token, types.MergePartsRequest{ReportID: second}, &merged); status != http.StatusOK {
+		t.Fatalf("Expected the merge to succeed, got %d", status)
+	}
+	if len(merged.Parts) != 2 || merged.Parts[1].OriginalFilename != "cbc-2.txt" {
+		t.Errorf("Expected two pages, got %+v", merged.Parts)
+	}
+	if status := doJSONRequest(t, "GET", fmt.Sprintf("%s/api/reports/%d", server.URL, second), token, nil, nil); status != http.StatusNotFound {
+		t.Errorf("Expected the merged page's report gone, got %d", status)
+	}
+
+	// part_of adds an upload straight onto an earlier report
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	writer.WriteField("part_of", strconv.Itoa(first))
+	partHeader := textproto.MIMEHeader{}
+	partHeader.Set("Content-Disposition", `form-data; name="file"; filename="cbc-3.txt"`)
+	partHeader.Set("Content-Type", "text/plain")
+	part, _ := writer.CreatePart(partHeader)
+	part.Write([]byte("WBC 7.2 x10^3/uL"))
+	writer.Close()
+	req, _ := http.NewRequest("POST", server.URL+"/api/reports", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to upload page: %v", err)
+	}
+	defer resp.Body.Close()
+	var uploaded types.UploadResponse
+	if err := decodeEnvelope(resp.Body, &uploaded); err != nil || resp.StatusCode != http.StatusCreated || uploaded.ReportID != first {
+		t.Errorf("Expected the page added to report %d, got %d %+v (%v)", first, resp.StatusCode, uploaded, err)
+	}
+	if status := doJSONRequest(t, "GET", url, token, nil, &merged); status != http.StatusOK || len(merged.Parts) != 3 {
+		t.Errorf("Expected three pages, got %d %+v", status, merged.Parts)
+	}
+
+	other := signupAndGetToken(t, server.URL, "pages-other@example.com")
+	if status := doJSONRequest(t, "GET", url, other, nil, nil); status != http.StatusForbidden {
+		t.Errorf("Expected another user's parts to be refused, got %d", status)
+	}
+}
@@ -0,0 +1,71 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/router"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// TestRouteFallbacks tests the JSON 404 and the 405 with an Allow header for paths no route takes
+func TestRouteFallbacks(t *testing.T) {
+	server := setupTestServer(t)
+	defer server.Close()
+
+	cases := []struct {
+		method, path string
+		status       int
+		allow        string
+	}{
+		{"DELETE", "/api/auth/login", http.StatusMethodNotAllowed, "POST, OPTIONS"},
+		{"PUT", "/api/reports/1", http.StatusMethodNotAllowed, "GET, PATCH, DELETE, OPTIONS"},
+		{"POST", "/health", http.StatusMethodNotAllowed, "GET, OPTIONS"},
+		{"GET", "/api/no-such-endpoint", http.StatusNotFound, ""},
+		{"GET", "/api/reports/abc", http.StatusNotFound, ""},
+	}
+	for _, c := range cases {
+		req, _ := http.NewRequest(c.method, server.URL+c.path, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s failed: %v", c.method, c.path, err)
+		}
+		var envelope types.Envelope
+		decodeErr := json.NewDecoder(resp.Body).Decode(&envelope)
+		resp.Body.Close()
+
+		if resp.StatusCode != c.status || resp.Header.Get("Allow") != c.allow {
+			t.Errorf("%s %s: expected %d with Allow %q, got %d with %q", c.method, c.path, c.status, c.allow, resp.StatusCode, resp.Header.Get("Allow"))
+		}
+		if decodeErr != nil || envelope.Error == nil || envelope.Error.Status != c.status {
+			t.Errorf("%s %s: expected a JSON error envelope, got %+v (%v)", c.method, c.path, envelope, decodeErr)
+		}
+		if resp.Header.Get("Access-Control-Allow-Origin") == "" {
+			t.Errorf("%s %s: expected CORS headers on the fallback response", c.method, c.path)
+		}
+	}
+
+	// The frontend takes every other GET, but never an API path or another method
+	r := mux.NewRouter()
+	r.HandleFunc("/api/ping", func(w http.ResponseWriter, r *http.Request) {}).Methods("GET")
+	r.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNotFound) })
+	router.ServeFrontend(r, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusTeapot) }))
+	for _, c := range []struct {
+		method, path string
+		status       int
+	}{
+		{"GET", "/reports/42", http.StatusTeapot},
+		{"GET", "/api/unknown", http.StatusNotFound},
+		{"POST", "/api/unknown", http.StatusNotFound},
+		{"POST", "/reports/42", http.StatusMethodNotAllowed},
+	} {
+		recorder := httptest.NewRecorder()
+		r.ServeHTTP(recorder, httptest.NewRequest(c.method, c.path, nil))
+		if recorder.Code != c.status {
+			t.Errorf("%s %s: expected %d, got %d", c.method, c.path, c.status, recorder.Code)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package tests
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/tests/fixtures"
+)
+
+// TestExtractLabResults tests that the rule extractor reads value, unit, range, and flag from the layouts labs print,
+// and leaves dates, ages, and sentences alone
+func TestExtractLabResults(t *testing.T) {
+	text := strings.Join([]string{
+		"Patient Name : MS. SYNTHETIC PATIENT   Age/Sex : 34 Y / F",
+		"Collected    : 12/03/2026 08:15",
+		"Haemoglobin                      11.2   L    g/dL             12.0 - 15.0",
+		"Total cholesterol            6.2      mmol/L    [< 5.0]      H",
+		"HDL cholesterol              1.1      mmol/L    [> 1.0]",
+		"TSH (Ultrasensitive)|6.84|µIU/mL|0.35 - 5.50",
+		"Neutrophils                      62          %                40 - 80",
+		"Investigations: Blood urea 58 mg/dL, serum creatinine 1.9 mg/dL",
+		"Haemoglobin repeated 12.0 g/dL 12.0 - 15.0",
+		"Patient seen on 3 occasions over the year",
+	}, "\n")
+	results := services.ExtractLabResults(text)
+
+	byName := make(map[string]services.LabResult)
+	for _, result := range results {
+		byName[result.Name] = result
+	}
+	if len(results) != 6 {
+		t.Fatalf("Expected 6 results, got %d: %+v", len(results), results)
+	}
+
+	hb := byName["Haemoglobin"]
+	if hb.Value != 11.2 || hb.Unit != "g/dL" || !hb.Flagged || hb.RangeMin == nil || *hb.RangeMin != 12 || *hb.RangeMax != 15 {
+		t.Errorf("Expected haemoglobin 11.2 g/dL flagged, range 12-15, got %+v", hb)
+	}
+	if chol := byName["Total cholesterol"]; chol.RangeMin != nil || chol.RangeMax == nil || *chol.RangeMax != 5 || !chol.OutOfRange() {
+		t.Errorf("Expected cholesterol above an upper limit of 5, got %+v", chol)
+	}
+	if hdl := byName["HDL cholesterol"]; hdl.RangeMin == nil || *hdl.RangeMin != 1 || hdl.RangeMax != nil || hdl.OutOfRange() {
+		t.Errorf("Expected HDL above its lower limit of 1, got %+v", hdl)
+	}
+	if tsh := byName["TSH (Ultrasensitive)"]; tsh.Value != 6.84 || tsh.Unit != "µIU/mL" || !tsh.OutOfRange() {
+		t.Errorf("Expected TSH from the piped table above its range, got %+v", tsh)
+	}
+	if neutrophils, ok := byName["Neutrophils"]; !ok || neutrophils.Unit != "%" || neutrophils.OutOfRange() {
+		t.Errorf("Expected neutrophils read by their printed range, got %+v", neutrophils)
+	}
+	if urea := byName["Investigations: Blood urea"]; urea.Value != 58 || urea.Unit != "mg/dL" || urea.HasRange() {
+		t.Errorf("Expected urea read from the sentence, got %+v", urea)
+	}
+}
+
+// TestCrossCheckMetrics tests that model metrics matching the printed text gain confidence, and misread ones are
+// corrected from it
+func TestCrossCheckMetrics(t *testing.T) {
+	text := "Hemoglobin 11.2 g/dL 12.0 - 15.0\nSerum Creatinine 1.9 mg/dL 0.6 - 1.2\nFerritin 45 ng/mL\n" +
+		"Urea 58 mg/dL (admission)\nUrea 41 mg/dL (repeat)\nTSH 2.1 mIU/L\nTSH repeat 2.4 mIU/L"
+	metrics := []services.HealthMetric{
+		{Name: "Haemoglobin", Value: "11.20", Unit: "g/dL", Score: 60, Status: "warning", RangeMin: 12, RangeMax: 15},
+		{Name: "Creatinine", Value: 1.1, Unit: "mg/dL", Score: 90, Status: "normal", RangeMin: 0.6, RangeMax: 1.2},
+		{Name: "Vitamin D", Value: 18.0, Unit: "ng/mL", Score: 50, Status: "warning"},
+		{Name: "Ferritin", Value: 54.0, Unit: "ng/mL", Score: 80, Status: "normal", RangeMin: 30, RangeMax: 300},
+		{Name: "Blood Urea", Value: 41.0, Unit: "mg/dL", Score: 85, Status: "normal"},
+		{Name: "TSH", Value: 4.2, Unit: "mIU/L", Score: 85, Status: "normal"},
+	}
+	check := services.CrossCheckMetrics(metrics, text)
+	if check.Agreed != 2 || check.Disputed != 3 || check.Corrected != 2 || check.ModelOnly != 1 {
+		t.Errorf("Expected 2 agreed, 3 disputed of which 2 corrected, 1 model-only, got %+v", check)
+	}
+
+	if hb := metrics[0]; hb.Confidence != 0.95 || hb.Value != "11.20" || hb.ValueNote != "" {
+		t.Errorf("Expected agreeing haemoglobin kept with high confidence, got %+v", hb)
+	}
+	creatinine := metrics[1]
+	if creatinine.Value != 1.9 || creatinine.Status != "warning" || creatinine.Confidence != 0.5 ||
+		!strings.Contains(creatinine.ValueNote, "1.9 mg/dL") {
+		t.Errorf("Expected the misread creatinine corrected and re-judged, got %+v", creatinine)
+	}
+	// The printed line has no range to judge the corrected value by
+	if ferritin := metrics[3]; ferritin.Value != 45.0 || !ferritin.LowConfidence || ferritin.RangeMax != 0 {
+		t.Errorf("Expected ferritin corrected and kept off the gauge, got %+v", ferritin)
+	}
+	// A value printed several times is only questioned, since it's unclear which reading was meant
+	if tsh := metrics[5]; tsh.Value != 4.2 || tsh.Confidence != 0.5 || tsh.ValueNote == "" {
+		t.Errorf("Expected the TSH questioned but kept, got %+v", tsh)
+	}
+	if urea := metrics[4]; urea.Confidence != 0.95 {
+		t.Errorf("Expected the repeat urea to agree with its printed reading, got %+v", urea)
+	}
+	if vitD := metrics[2]; vitD.Confidence != 0.7 || vitD.Value != 18.0 {
+		t.Errorf("Expected a result missing from the text kept as the model read it, got %+v", vitD)
+	}
+}
+
+// TestFixtureCrossCheck tests that the recorded model replies agree with the rule extractor on every fixture
+func TestFixtureCrossCheck(t *testing.T) {
+	extractor := services.NewTextExtractor(config.ExtractionConfig{})
+	for _, report := range fixtures.Reports {
+		t.Run(report.Name, func(t *testing.T) {
+			path, err := report.Save(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			extraction, err := extractor.Extract(context.Background(), path)
+			if err != nil {
+				t.Fatalf("Failed to extract %s: %v", report.Name, err)
+			}
+			reply, err := report.ModelReply()
+			if err != nil {
+				t.Fatal(err)
+			}
+			analysis, err := services.ParseAnalysisResponse(reply)
+			if err != nil {
+				t.Fatalf("Failed to parse the recorded reply: %v", err)
+			}
+
+			check := services.CrossCheckMetrics(analysis.HealthMetrics, extraction.Text)
+			if check.Disputed != 0 {
+				for _, metric := range analysis.HealthMetrics {
+					if metric.ValueNote != "" {
+						t.Errorf("%s: %s", metric.Name, metric.ValueNote)
+					}
+				}
+			}
+			if report.Name == "cbc_columns.txt" && check.Agreed != len(analysis.HealthMetrics) {
+				t.Errorf("Expected every column of the CBC confirmed, got %+v", check)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package tests
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+)
+
+// TestSecretSources tests that each secrets manager's response is read into the same bundle
+func TestSecretSources(t *testing.T) {
+	bundle := `{"JWT_SECRET":"from-manager","GEMINI_API_KEY":"gemini-key"}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v1/secret/data/medreport":
+			if r.Header.Get("X-Vault-Token") != "vault-token" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			io.WriteString(w, `{"data":{"data":`+bundle+`,"metadata":{"version":3}}}`)
+		case r.URL.Path == "/" && r.Method == http.MethodPost:
+			// Decision: Signature Version 4 covers the target and date, and scopes the key to region and service
+			auth := r.Header.Get("Authorization")
+			if r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" || r.Header.Get("X-Amz-Date") == "" ||
+				!strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") ||
+				!strings.Contains(auth, "/ap-south-1/secretsmanager/aws4_request") ||
+				!strings.Contains(auth, "SignedHeaders=content-type;host;x-amz-date;x-amz-target") {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"SecretString": bundle})
+		case r.URL.Path == "/v1/projects/medreport-prod/secrets/app-secrets/versions/latest:access":
+			if r.Header.Get("Authorization") != "Bearer gcp-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]any{"payload": map[string]string{"data": base64.StdEncoding.EncodeToString([]byte(bundle))}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	for _, cfg := range []config.SecretsConfig{
+		{Provider: "vault", URL: server.URL, VaultToken: "vault-token", Path: "/secret/data/medreport"},
+		{Provider: "aws", URL: server.URL, AWSRegion: "ap-south-1", AWSAccessKeyID: "AKIDEXAMPLE", AWSSecretKey: "secret", Path: "medreport"},
+		{Provider: "gcp", URL: server.URL, GCPProject: "medreport-prod", GCPAccessToken: "gcp-token", Path: "app-secrets"},
+	} {
+		source, err := services.NewSecretSource(cfg)
+		if err != nil {
+			t.Fatalf("Failed to create %s source: %v", cfg.Provider, err)
+		}
+		secrets, err := source.Fetch(context.Background())
+		if err != nil {
+			t.Fatalf("Failed to fetch from %s: %v", cfg.Provider, err)
+		}
+		if secrets["JWT_SECRET"] != "from-manager" || secrets["GEMINI_API_KEY"] != "gemini-key" {
+			t.Errorf("Unexpected secrets from %s: %v", cfg.Provider, secrets)
+		}
+	}
+
+	if source, err := services.NewSecretSource(config.SecretsConfig{Provider: "env"}); source != nil || err != nil {
+		t.Errorf("Expected no source for env, got %v (%v)", source, err)
+	}
+	if _, err := services.NewSecretSource(config.SecretsConfig{Provider: "vault", URL: server.URL}); err == nil {
+		t.Error("Expected vault without a token to be rejected")
+	}
+	if _, err := services.NewSecretSource(config.SecretsConfig{Provider: "keychain"}); err == nil {
+		t.Error("Expected an unknown provider to be rejected")
+	}
+	source, _ := services.NewSecretSource(config.SecretsConfig{Provider: "vault", URL: server.URL, VaultToken: "wrong", Path: "secret/data/medreport"})
+	if _, err := source.Fetch(context.Background()); err == nil {
+		t.Error("Expected a refused read to fail")
+	}
+}
+
+// TestSecretRotation tests that secrets apply over the environment and a rotated JWT secret keeps older tokens valid
+func TestSecretRotation(t *testing.T) {
+	var mu sync.Mutex
+	jwtSecret := "first-secret"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]any{"data": map[string]string{"JWT_SECRET": jwtSecret, "PAYMENT_SECRET_KEY": "sk_live"}})
+	}))
+	defer server.Close()
+
+	t.Setenv("UPLOAD_DIR_SECRET", "")
+	cfg := &config.Config{
+		JWT:     config.JWTConfig{Secret: "env-secret", Expiration: time.Hour},
+		Upload:  config.UploadConfig{DirSecret: "env-secret"},
+		AI:      config.AIConfig{GeminiAPIKey: "env-gemini"},
+		Secrets: config.SecretsConfig{Provider: "vault", URL: server.URL, VaultToken: "token", Path: "secret/medreport", Timeout: time.Second},
+	}
+	store, err := services.LoadSecrets(cfg)
+	if err != nil {
+		t.Fatalf("Failed to load secrets: %v", err)
+	}
+	if cfg.JWT.Secret != "first-secret" || cfg.Payment.SecretKey != "sk_live" {
+		t.Errorf("Expected the manager's secrets applied, got %q and %q", cfg.JWT.Secret, cfg.Payment.SecretKey)
+	}
+	if cfg.AI.GeminiAPIKey != "env-gemini" {
+		t.Errorf("Expected secrets the manager lacks to keep their environment value, got %q", cfg.AI.GeminiAPIKey)
+	}
+	if cfg.Upload.DirSecret != "first-secret" {
+		t.Errorf("Expected the upload directory secret to follow JWT_SECRET, got %q", cfg.Upload.DirSecret)
+	}
+
+	jwtService := services.NewJWTService(cfg.JWT.Secret, cfg.JWT.Expiration)
+	store.OnRotate("JWT_SECRET", jwtService.Rotate)
+	oldToken, err := jwtService.GenerateToken(1, "patient@example.com")
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	mu.Lock()
+	jwtSecret = "second-secret"
+	mu.Unlock()
+	if err := store.Refresh(context.Background()); err != nil {
+		t.Fatalf("Failed to refresh secrets: %v", err)
+	}
+	if value, _ := store.Lookup("JWT_SECRET"); value != "second-secret" {
+		t.Errorf("Expected the rotated secret cached, got %q", value)
+	}
+
+	newToken, err := jwtService.GenerateToken(1, "patient@example.com")
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	if _, err := services.NewJWTService("second-secret", time.Hour).ValidateToken(newToken); err != nil {
+		t.Errorf("Expected new tokens signed with the rotated secret: %v", err)
+	}
+	if _, err := jwtService.ValidateToken(oldToken); err != nil {
+		t.Errorf("Expected tokens signed before the rotation to stay valid: %v", err)
+	}
+	if _, err := services.NewJWTService("unrelated", time.Hour).ValidateToken(newToken); err == nil {
+		t.Error("Expected a token signed with another secret to be rejected")
+	}
+}
@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/selftest"
+)
+
+// TestSelfTest tests the deployment checks behind --selftest
+func TestSelfTest(t *testing.T) {
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+
+	migrations := t.TempDir()
+	for _, name := range []string{"20260101000000_create_users.sql", "20260102000000_create_reports.sql", "README.md"} {
+		os.WriteFile(filepath.Join(migrations, name), []byte("-- +goose Up"), 0o600)
+	}
+	schema := selftest.SchemaCheck(db.GetDB(), migrations)
+
+	// No goose history at all
+	if _, err := schema.Run(context.Background()); err == nil {
+		t.Error("Expected a database without migration history to fail")
+	}
+
+	db.Exec(`CREATE TABLE goose_db_version (id INTEGER PRIMARY KEY AUTOINCREMENT, version_id INTEGER, is_applied BOOLEAN)`)
+	db.Exec(`INSERT INTO goose_db_version (version_id, is_applied) VALUES (0, 1), (20260101000000, 1), (20260102000000, 1), (20260102000000, 0)`)
+	if _, err := schema.Run(context.Background()); err == nil || !strings.Contains(err.Error(), "1 migration(s) pending") {
+		t.Errorf("Expected the rolled-back migration to be pending, got %v", err)
+	}
+
+	db.Exec(`INSERT INTO goose_db_version (version_id, is_applied) VALUES (20260102000000, 1)`)
+	if detail, err := schema.Run(context.Background()); err != nil || detail != "version 20260102000000" {
+		t.Errorf("Expected the schema to be current, got %q (%v)", detail, err)
+	}
+
+	db.Exec(`INSERT INTO goose_db_version (version_id, is_applied) VALUES (20260103000000, 1)`)
+	if _, err := schema.Run(context.Background()); err == nil {
+		t.Error("Expected a database newer than the build to fail")
+	}
+
+	// A file where the upload directory should be can't be written into
+	blocked := filepath.Join(t.TempDir(), "uploads")
+	os.WriteFile(blocked, nil, 0o600)
+
+	results := selftest.Run(context.Background(), []selftest.Check{
+		selftest.DatabaseCheck(db.GetDB()),
+		selftest.UploadDirCheck(filepath.Join(t.TempDir(), "uploads")),
+		selftest.UploadDirCheck(blocked),
+		selftest.PingCheck("ai", "gemini", func(ctx context.Context) error { return fmt.Errorf("API key not valid") }),
+		selftest.PingCheck("slow", "model", func(ctx context.Context) error { <-ctx.Done(); return ctx.Err() }),
+		selftest.Skipped("smtp", "no SMTP configured"),
+	}, 50*time.Millisecond)
+
+	var statuses []string
+	for _, result := range results {
+		statuses = append(statuses, result.Status)
+	}
+	if strings.Join(statuses, " ") != "ok ok FAIL FAIL FAIL skip" || !selftest.Failed(results) {
+		t.Errorf("Unexpected check outcomes %v", statuses)
+	}
+
+	var table bytes.Buffer
+	selftest.Print(&table, results)
+	if !strings.Contains(table.String(), "API key not valid") || strings.Count(table.String(), "\n") != len(results)+1 {
+		t.Errorf("Expected one table row per check with the failure reason, got:\n%s", table.String())
+	}
+
+	if selftest.Failed(results[:2]) || selftest.Failed(results[5:]) {
+		t.Error("Expected passed and skipped checks not to fail the self-test")
+	}
+}
@@ -0,0 +1,153 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/handlers"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// TestSessionLimit tests that signing in on more devices than allowed signs out the oldest session
+func TestSessionLimit(t *testing.T) {
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	userRepo := models.NewUserRepository(db.GetDB())
+	authService := services.NewAuthService(userRepo, services.NewPasswordServiceWithCost(4), services.NewJWTService("sessions-secret", time.Hour))
+	sessionService := services.NewSessionService(models.NewSessionRepository(db.GetDB()), 2)
+	authService.SetSessionService(sessionService)
+	authService.SetRefreshTokens(models.NewRefreshTokenRepository(db.GetDB()), 24*time.Hour)
+	authHandler := handlers.NewAuthHandler(authService, nil)
+	authHandler.SetSessionService(sessionService)
+	authMiddleware := middleware.NewAuthMiddleware(authService, nil, nil)
+
+	r := mux.NewRouter()
+	auth := r.PathPrefix("/api/auth").Subrouter()
+	auth.HandleFunc("/signup", authHandler.SignupHandler).Methods("POST")
+	auth.HandleFunc("/login", authHandler.LoginHandler).Methods("POST")
+	auth.HandleFunc("/logout", authHandler.LogoutHandler).Methods("POST")
+	auth.HandleFunc("/refresh", authHandler.RefreshHandler).Methods("POST")
+	protected := auth.PathPrefix("").Subrouter()
+	protected.Use(authMiddleware.RequireAuth)
+	protected.HandleFunc("/me", authHandler.MeHandler).Methods("GET")
+	protected.HandleFunc("/sessions", authHandler.ListSessionsHandler).Methods("GET")
+	protected.HandleFunc("/sessions/{id:[0-9]+}", authHandler.RevokeSessionHandler).Methods("DELETE")
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	// call returns the status and, for errors, the error type
+	call := func(method, path, token, userAgent string, body, out any) (int, string) {
+		payload, _ := json.Marshal(body)
+		req, _ := http.NewRequest(method, server.URL+path, bytes.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", userAgent)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s failed: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		var envelope struct {
+			Data  json.RawMessage `json:"data"`
+			Error *types.APIError `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&envelope)
+		if envelope.Error != nil {
+			return resp.StatusCode, envelope.Error.Type
+		}
+		if out != nil {
+			json.Unmarshal(envelope.Data, out)
+		}
+		return resp.StatusCode, ""
+	}
+	credentials := map[string]string{"email": "traveller@example.com", "password": "password123"}
+	refreshTokens := map[string]string{}
+	signIn := func(device string) string {
+		var response types.LoginResponse
+		path := "/api/auth/login"
+		if device == "laptop" {
+			path = "/api/auth/signup"
+			credentials["full_name"] = "Traveller"
+		}
+		if status, errType := call("POST", path, "", device, credentials, &response); response.Token == "" {
+			t.Fatalf("Failed to sign in on %s: %d %s", device, status, errType)
+		}
+		refreshTokens[device] = response.RefreshToken
+		return response.Token
+	}
+
+	laptop := signIn("laptop")
+	phone := signIn("phone")
+	if status, _ := call("GET", "/api/auth/me", laptop, "", nil, nil); status != http.StatusOK {
+		t.Fatalf("Expected two sessions to be allowed, got %d", status)
+	}
+
+	// A third device signs out the oldest
+	tablet := signIn("tablet")
+	if status, errType := call("GET", "/api/auth/me", laptop, "", nil, nil); status != http.StatusUnauthorized || errType != "SESSION_ENDED" {
+		t.Errorf("Expected the laptop to be signed out, got %d %q", status, errType)
+	}
+	for _, token := range []string{phone, tablet} {
+		if status, _ := call("GET", "/api/auth/me", token, "", nil, nil); status != http.StatusOK {
+			t.Errorf("Expected the newer sessions to stay signed in, got %d", status)
+		}
+	}
+
+	// Refreshing continues the session rather than starting another
+	var refreshed types.TokenResponse
+	if status, _ := call("POST", "/api/auth/refresh", "", "", types.RefreshTokenRequest{RefreshToken: refreshTokens["phone"]},
+		&refreshed); status != http.StatusOK || refreshed.Token == "" {
+		t.Fatalf("Failed to refresh: %d", status)
+	}
+	var listed types.SessionsResponse
+	if status, _ := call("GET", "/api/auth/sessions", refreshed.Token, "", nil, &listed); status != http.StatusOK {
+		t.Fatalf("Failed to list sessions: %d", status)
+	}
+	if listed.Limit != 2 || len(listed.Sessions) != 2 || listed.Sessions[0].UserAgent != "tablet" ||
+		listed.Sessions[0].Current || !listed.Sessions[1].Current {
+		t.Fatalf("Expected the tablet and the current phone session, got %+v", listed)
+	}
+
+	// Signing out a device, and another user's sessions being out of reach
+	other := signupAndGetToken(t, server.URL, "other-traveller@example.com")
+	path := fmt.Sprintf("/api/auth/sessions/%d", listed.Sessions[0].ID)
+	if status, _ := call("DELETE", path, other, "", nil, nil); status != http.StatusNotFound {
+		t.Errorf("Expected another user's session to be hidden, got %d", status)
+	}
+	if status, _ := call("DELETE", path, refreshed.Token, "", nil, nil); status != http.StatusOK {
+		t.Fatalf("Failed to revoke the tablet session: %d", status)
+	}
+	if status, errType := call("GET", "/api/auth/me", tablet, "", nil, nil); status != http.StatusUnauthorized || errType != "SESSION_ENDED" {
+		t.Errorf("Expected the tablet to be signed out, got %d %q", status, errType)
+	}
+	if status, errType := call("POST", "/api/auth/refresh", "", "", types.RefreshTokenRequest{RefreshToken: refreshTokens["tablet"]},
+		nil); status != http.StatusUnauthorized || errType != "SESSION_ENDED" {
+		t.Errorf("Expected the signed-out tablet not to refresh, got %d %q", status, errType)
+	}
+
+	// Logging out ends the session for good
+	if status, _ := call("POST", "/api/auth/logout", refreshed.Token, "", nil, nil); status != http.StatusOK {
+		t.Fatalf("Failed to log out: %d", status)
+	}
+	if status, _ := call("GET", "/api/auth/me", refreshed.Token, "", nil, nil); status != http.StatusUnauthorized {
+		t.Errorf("Expected the logged out token to be refused, got %d", status)
+	}
+}
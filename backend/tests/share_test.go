@@ -0,0 +1,141 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+// TestShareLinkPIN tests PIN-protected share links and the wrong-PIN lockout
+func TestShareLinkPIN(t *testing.T) {
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	userRepo := models.NewUserRepository(db.GetDB())
+	owner := &models.User{Email: "sharer@example.com", PasswordHash: "hash", FullName: "Owner", IsActive: true}
+	other := &models.User{Email: "stranger@example.com", PasswordHash: "hash", FullName: "Other", IsActive: true}
+	for _, user := range []*models.User{owner, other} {
+		if err := userRepo.Create(user); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+
+	reportRepo := models.NewReportRepository(db.GetDB())
+	reports, err := services.NewDemoService(reportRepo).ProvisionSampleReports(owner.ID)
+	if err != nil {
+		t.Fatalf("Failed to provision reports: %v", err)
+	}
+	reportID := reports[0].ID
+
+	notificationRepo := models.NewNotificationRepository(db.GetDB())
+	shareRepo := models.NewShareLinkRepository(db.GetDB())
+	shares := services.NewShareService(shareRepo, reportRepo, notificationRepo, services.NewPasswordServiceWithCost(4),
+		config.ShareConfig{DefaultTTL: time.Hour, MaxTTL: 24 * time.Hour, MaxPINAttempts: 3})
+
+	for _, pin := range []string{"123", "1234567", "12a4"} {
+		if _, _, err := shares.CreateLink(owner.ID, reportID, pin, 0); err == nil {
+			t.Errorf("Expected PIN %q to be rejected", pin)
+		}
+	}
+	if _, _, err := shares.CreateLink(owner.ID, reportID, "", 48*time.Hour); err == nil {
+		t.Error("Expected a lifetime over the maximum to be rejected")
+	}
+	if _, _, err := shares.CreateLink(other.ID, reportID, "", 0); err != errors.ErrAccessDenied {
+		t.Errorf("Expected access denied sharing another user's report, got %v", err)
+	}
+
+	// Links without a PIN open with the token alone
+	_, openToken, err := shares.CreateLink(owner.ID, reportID, "", 0)
+	if err != nil {
+		t.Fatalf("Failed to create link: %v", err)
+	}
+	if report, _, err := shares.OpenLink(openToken, ""); err != nil || report.ID != reportID {
+		t.Errorf("Expected open link to show the report, got %v", err)
+	}
+
+	link, token, err := shares.CreateLink(owner.ID, reportID, "4821", 0)
+	if err != nil {
+		t.Fatalf("Failed to create PIN link: %v", err)
+	}
+	if stored, _ := shareRepo.GetByID(link.ID); stored.TokenHash == token || stored.PINHash == "4821" {
+		t.Error("Token and PIN must only be stored hashed")
+	}
+
+	if _, _, err := shares.OpenLink(token, ""); err != errors.ErrSharePINRequired {
+		t.Errorf("Expected PIN required, got %v", err)
+	}
+	if _, _, err := shares.OpenLink(token, "0000"); err != errors.ErrSharePINInvalid {
+		t.Errorf("Expected invalid PIN, got %v", err)
+	}
+	// Decision: A correct PIN resets the count, so an owner's typo doesn't eat into the limit for good
+	if _, _, err := shares.OpenLink(token, "4821"); err != nil {
+		t.Fatalf("Expected correct PIN to open the link, got %v", err)
+	}
+	if stored, _ := shareRepo.GetByID(link.ID); stored.FailedAttempts != 0 || stored.LastViewedAt == nil {
+		t.Errorf("Expected view recorded and attempts reset, got %+v", stored)
+	}
+
+	for i := 1; i <= 3; i++ {
+		_, _, err := shares.OpenLink(token, "1111")
+		if i < 3 && err != errors.ErrSharePINInvalid {
+			t.Errorf("Attempt %d: expected invalid PIN, got %v", i, err)
+		}
+		if i == 3 && err != errors.ErrShareLinkLocked {
+			t.Errorf("Expected the final wrong PIN to lock the link, got %v", err)
+		}
+	}
+	if _, _, err := shares.OpenLink(token, "4821"); err != errors.ErrShareLinkLocked {
+		t.Errorf("Expected a locked link to stay locked even with the right PIN, got %v", err)
+	}
+
+	notifications, err := notificationRepo.ListByUser(owner.ID, true, 10, 0)
+	if err != nil || len(notifications) != 1 || notifications[0].Kind != models.NotificationShareLinkLocked {
+		t.Errorf("Expected the owner to be notified of the lockout, got %+v (%v)", notifications, err)
+	}
+
+	if err := shares.RevokeLink(owner.ID, reportID, mustFindOpenLink(t, shares, owner.ID, reportID)); err != nil {
+		t.Fatalf("Failed to revoke link: %v", err)
+	}
+	if _, _, err := shares.OpenLink(openToken, ""); err != errors.ErrShareLinkUnavailable {
+		t.Errorf("Expected revoked link to be unavailable, got %v", err)
+	}
+
+	// HTTP endpoints
+	server := setupTestServer(t)
+	defer server.Close()
+	authToken := signupAndGetToken(t, server.URL, "share-http@example.com")
+	pendingID := uploadTestReport(t, server.URL, authToken, "blood.txt", "Hemoglobin 13.5 g/dL")
+
+	if status := doJSONRequest(t, "POST", fmt.Sprintf("%s/api/reports/%d/shares", server.URL, pendingID), authToken, map[string]string{"pin": "1234"}, nil); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 sharing an unprocessed report, got %d", status)
+	}
+	if status := doJSONRequest(t, "GET", server.URL+"/api/shared/not-a-real-token", "", nil, nil); status != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown token without auth, got %d", status)
+	}
+}
+
+// mustFindOpenLink returns the ID of the report's link that needs no PIN
+func mustFindOpenLink(t *testing.T, shares *services.ShareService, userID, reportID int) int {
+	links, err := shares.ListLinks(userID, reportID)
+	if err != nil {
+		t.Fatalf("Failed to list links: %v", err)
+	}
+	for _, link := range links {
+		if !link.RequiresPIN() {
+			return link.ID
+		}
+	}
+	t.Fatal("No link without a PIN found")
+	return 0
+}
@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/handlers"
+)
+
+// TestStaticFrontend tests serving a built frontend with cache headers and the index.html fallback
+func TestStaticFrontend(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := handlers.NewStaticHandler(dir); err == nil {
+		t.Error("Expected a directory without index.html to be refused")
+	}
+
+	os.MkdirAll(filepath.Join(dir, "assets"), 0755)
+	os.WriteFile(filepath.Join(dir, "index.html"), []byte("<!doctype html><div id=root></div>"), 0644)
+	os.WriteFile(filepath.Join(dir, "assets", "index-3f2a.js"), []byte("console.log(1)"), 0644)
+	os.WriteFile(filepath.Join(dir, "favicon.ico"), []byte("icon"), 0644)
+
+	static, err := handlers.NewStaticHandler(dir)
+	if err != nil {
+		t.Fatalf("Failed to create static handler: %v", err)
+	}
+
+	tests := []struct {
+		path         string
+		status       int
+		cacheControl string
+		bodyPrefix   string
+	}{
+		{"/", http.StatusOK, "no-cache", "<!doctype html>"},
+		{"/assets/index-3f2a.js", http.StatusOK, "public, max-age=31536000, immutable", "console.log"},
+		{"/favicon.ico", http.StatusOK, "no-cache", "icon"},
+		{"/reports/42", http.StatusOK, "no-cache", "<!doctype html>"},       // Client-side route
+		{"/../../etc/passwd", http.StatusOK, "no-cache", "<!doctype html>"}, // Cleaned to a route, never outside dir
+		{"/assets/index-old.js", http.StatusNotFound, "", ""},
+		{"/api/unknown", http.StatusNotFound, "", `{"data":null`},
+	}
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		static.ServeHTTP(recorder, httptest.NewRequest("GET", tt.path, nil))
+
+		body, _ := io.ReadAll(recorder.Body)
+		if recorder.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.path, tt.status, recorder.Code)
+		}
+		if tt.cacheControl != "" && recorder.Header().Get("Cache-Control") != tt.cacheControl {
+			t.Errorf("%s: expected Cache-Control %q, got %q", tt.path, tt.cacheControl, recorder.Header().Get("Cache-Control"))
+		}
+		if !strings.HasPrefix(string(body), tt.bodyPrefix) {
+			t.Errorf("%s: unexpected body %q", tt.path, body)
+		}
+	}
+}
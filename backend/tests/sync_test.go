@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/handlers"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// TestDeltaSync tests that a sync returns everything without a checkpoint and only changes and deletions with one
+func TestDeltaSync(t *testing.T) {
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	user := &models.User{Email: "offline@example.com", PasswordHash: "hash", FullName: "Offline", IsActive: true}
+	if err := models.NewUserRepository(db.GetDB()).Create(user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	reportRepo := models.NewReportRepository(db.GetDB())
+	chatRepo := models.NewChatMessageRepository(db.GetDB())
+	samples, err := services.NewDemoService(reportRepo).ProvisionSampleReports(user.ID)
+	if err != nil || len(samples) < 2 {
+		t.Fatalf("Failed to provision reports: %v", err)
+	}
+	var messages []*models.ChatMessage
+	for _, report := range samples[:2] {
+		message := &models.ChatMessage{ReportID: report.ID, UserMessage: "Is this normal?", AIResponse: "Mostly."}
+		if err := chatRepo.Create(message); err != nil {
+			t.Fatalf("Failed to create chat message: %v", err)
+		}
+		messages = append(messages, message)
+	}
+
+	reportHandler := handlers.NewReportHandler(reportRepo, nil, nil, nil, services.NewFileStorage(t.TempDir(), "test-secret"), 20971520, false)
+	reportHandler.SetSyncService(services.NewSyncService(reportRepo, chatRepo, models.NewTombstoneRepository(db.GetDB())))
+	sync := func(since string) (int, types.SyncResponse) {
+		path := "/api/sync"
+		if since != "" {
+			path += "?since=" + url.QueryEscape(since)
+		}
+		req := httptest.NewRequest("GET", path, nil)
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserKey, user))
+		recorder := httptest.NewRecorder()
+		reportHandler.SyncHandler(recorder, req)
+		var envelope struct {
+			Data types.SyncResponse `json:"data"`
+		}
+		json.NewDecoder(recorder.Body).Decode(&envelope)
+		return recorder.Code, envelope.Data
+	}
+
+	status, full := sync("")
+	if status != http.StatusOK || !full.FullSync || len(full.Reports) != len(samples) || len(full.ChatMessages) != 2 {
+		t.Fatalf("Expected a full sync of %d reports and 2 messages, got %d %+v", len(samples), status, full)
+	}
+	if full.Reports[0].Analysis == nil || full.Reports[0].SimplifiedSummary != "" || full.Reports[0].ProcessingStatus != "completed" {
+		t.Errorf("Expected completed reports to carry their parsed analysis, got %+v", full.Reports[0])
+	}
+	if full.Checkpoint.IsZero() {
+		t.Error("Expected a checkpoint")
+	}
+
+	// Backdate everything so the next checkpoint separates old records from new changes
+	for _, statement := range []string{
+		`UPDATE reports SET updated_at = '2020-01-01 00:00:00'`,
+		`UPDATE chat_messages SET created_at = '2020-01-01 00:00:00'`,
+	} {
+		if _, err := db.GetDB().Exec(statement); err != nil {
+			t.Fatalf("Failed to backdate records: %v", err)
+		}
+	}
+	checkpoint := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC).Format(time.RFC3339)
+	if status, delta := sync(checkpoint); status != http.StatusOK || delta.FullSync || len(delta.Reports) != 0 ||
+		len(delta.ChatMessages) != 0 || len(delta.Deleted.Reports) != 0 {
+		t.Fatalf("Expected no changes, got %d %+v", status, delta)
+	}
+
+	// Revise one message, rename one report, and delete another
+	messages[1].UserMessage = "Is this still normal?"
+	if err := chatRepo.Revise(messages[1]); err != nil {
+		t.Fatalf("Failed to revise message: %v", err)
+	}
+	if err := reportRepo.UpdateOriginalFilename(samples[1].ID, "renamed.txt"); err != nil {
+		t.Fatalf("Failed to rename report: %v", err)
+	}
+	if err := reportRepo.Delete(samples[0].ID); err != nil {
+		t.Fatalf("Failed to delete report: %v", err)
+	}
+
+	status, delta := sync(checkpoint)
+	if status != http.StatusOK || len(delta.Reports) != 1 || delta.Reports[0].ID != samples[1].ID {
+		t.Fatalf("Expected only the renamed report, got %d %+v", status, delta.Reports)
+	}
+	if len(delta.ChatMessages) != 1 || delta.ChatMessages[0].ID != messages[1].ID || delta.ChatMessages[0].Version != 2 {
+		t.Errorf("Expected only the revised message, got %+v", delta.ChatMessages)
+	}
+	if len(delta.Deleted.Reports) != 1 || delta.Deleted.Reports[0] != samples[0].ID {
+		t.Errorf("Expected the deleted report as a tombstone, got %+v", delta.Deleted)
+	}
+
+	if status, _ := sync("yesterday"); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid since, got %d", status)
+	}
+}
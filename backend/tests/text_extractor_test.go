@@ -0,0 +1,179 @@
+package tests
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/pdfgen"
+)
+
+const labText = "Complete Blood Count. Hemoglobin 13.5 g/dL (13.0-17.0). WBC 7,200 /uL (4,000-11,000). " +
+	"Platelets 250,000 /uL (150,000-450,000). RBC 4.8 million/uL. Hematocrit 41%. MCV 88 fL. " +
+	"All values are within the reference range and no abnormal cells were seen on the smear."
+
+// TestExtractionQualityScore tests that garbled text scores as gibberish while lab abbreviations don't
+func TestExtractionQualityScore(t *testing.T) {
+	readable := services.ScoreExtraction(labText, 1)
+	if readable.GibberishRatio > 0.05 || readable.Characters == 0 {
+		t.Errorf("Expected lab text to be readable, got %+v", readable)
+	}
+
+	garbled := services.ScoreExtraction("�� §¤ ~~^^ \x01\x02 }{|| Hb", 1)
+	if garbled.GibberishRatio < 0.5 {
+		t.Errorf("Expected garbled text to score as gibberish, got %+v", garbled)
+	}
+
+	if sparse := services.ScoreExtraction("Page 1", 3); sparse.CharsPerPage >= 5 || sparse.Score >= readable.Score {
+		t.Errorf("Expected a near-empty scan to score low, got %+v", sparse)
+	}
+}
+
+// TestTextExtractorFallback tests DOCX extraction, unreadable files, and the OCR fallback for scans
+func TestTextExtractorFallback(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	extractor := services.NewTextExtractor(config.ExtractionConfig{})
+
+	docxPath := filepath.Join(dir, "cbc.docx")
+	writeDocx(t, docxPath, "<w:p><w:r><w:t>Hemoglobin</w:t></w:r><w:r><w:tab/><w:t>13.5 g/dL</w:t></w:r></w:p>")
+	extraction, err := extractor.Extract(ctx, docxPath)
+	if err != nil || extraction.Method != "docx" || !strings.Contains(extraction.Text, "Hemoglobin\t13.5 g/dL") {
+		t.Fatalf("Expected DOCX paragraphs, got %+v (%v)", extraction, err)
+	}
+
+	// Headers are read before the body and footers after it, each once
+	lab := "<w:p><w:r><w:t>City Lab | Patient: A. Rao</w:t></w:r></w:p>"
+	writeDocxParts(t, docxPath, "<w:p><w:r><w:t>HbA1c 6.1%</w:t></w:r></w:p>", map[string]string{
+		"word/header2.xml": lab, "word/header1.xml": lab,
+		"word/footer1.xml": "<w:p><w:r><w:t>Reviewed by Dr. K</w:t><w:noBreakHyphen/><w:t>Pathology</w:t></w:r></w:p>",
+	})
+	if extraction, err = extractor.Extract(ctx, docxPath); err != nil {
+		t.Fatalf("Failed to extract DOCX with headers: %v", err)
+	}
+	if want := "City Lab | Patient: A. Rao\nHbA1c 6.1%\nReviewed by Dr. K-Pathology\n"; extraction.Text != want {
+		t.Errorf("Expected %q, got %q", want, extraction.Text)
+	}
+
+	garbledPath := filepath.Join(dir, "garbled.txt")
+	os.WriteFile(garbledPath, []byte("\x00\x01\x02 ��� ¤§¤§ }{}{ Hb"), 0644)
+	var unreadable *services.UnreadableReportError
+	if _, err := extractor.Extract(ctx, garbledPath); !errors.As(err, &unreadable) || !strings.Contains(unreadable.Reason, "encoding") {
+		t.Errorf("Expected garbled text to be unreadable, got %v", err)
+	}
+	if _, err := extractor.Extract(ctx, filepath.Join(dir, "old.doc")); !errors.As(err, &unreadable) {
+		t.Errorf("Expected .doc files to be refused with an explanation, got %v", err)
+	}
+
+	// A PDF with only an image has no text layer, so it's a scan
+	scanPath := filepath.Join(dir, "scan.pdf")
+	scan := pdfgen.New()
+	var img bytes.Buffer
+	jpeg.Encode(&img, image.NewGray(image.Rect(0, 0, 20, 20)), nil)
+	scan.Image(img.Bytes(), 20, 20, 200, 200)
+	os.WriteFile(scanPath, scan.Bytes(), 0644)
+
+	if _, err := extractor.Extract(ctx, scanPath); !errors.As(err, &unreadable) || !strings.Contains(unreadable.Reason, "scan") {
+		t.Errorf("Expected a scan without OCR to be unreadable, got %v", err)
+	}
+	if _, err := extractor.Extract(ctx, filepath.Join(dir, "photo.jpg")); !errors.As(err, &unreadable) {
+		t.Errorf("Expected photos to be refused without OCR, got %v", err)
+	}
+
+	// Stand-ins for pdftoppm and tesseract: one rendered page, recognized as the lab text
+	rasterizer := writeScript(t, dir, "pdftoppm", `touch "$5-1.png"`)
+	tesseract := writeScript(t, dir, "tesseract", `echo "`+labText+`"`)
+	withOCR := services.NewTextExtractor(config.ExtractionConfig{OCRCommand: tesseract, PDFRasterizer: rasterizer})
+
+	extraction, err = withOCR.Extract(ctx, scanPath)
+	if err != nil || extraction.Method != "ocr" || extraction.Pages != 1 || !strings.Contains(extraction.Text, "Hemoglobin") {
+		t.Fatalf("Expected OCR fallback for the scan, got %+v (%v)", extraction, err)
+	}
+	photoPath := filepath.Join(dir, "photo.jpg")
+	os.WriteFile(photoPath, img.Bytes(), 0644)
+	if extraction, err := withOCR.Extract(ctx, photoPath); err != nil || extraction.Method != "ocr" {
+		t.Errorf("Expected photos to go through OCR, got %+v (%v)", extraction, err)
+	}
+}
+
+// TestRegionalReportLanguage tests script detection, native digits, and OCR language selection for regional reports
+func TestRegionalReportLanguage(t *testing.T) {
+	for text, want := range map[string]string{
+		labText: "en",
+		"ಹಿಮೋಗ್ಲೋಬಿನ್ (Hemoglobin) 13.5 g/dL ಸಾಮಾನ್ಯ ವ್ಯಾಪ್ತಿ 13.0-17.0": "kn",
+		"हीमोग्लोबिन (Hemoglobin) 13.5 g/dL सामान्य सीमा 13.0-17.0":      "hi",
+		"ஹீமோகுளோபின் (Hemoglobin) 13.5 g/dL இயல்பான வரம்பு 13.0-17.0":   "ta",
+		labText + " (ಸಾಮಾನ್ಯ)": "en",
+	} {
+		if got := services.DetectReportLanguage(text); got != want {
+			t.Errorf("DetectReportLanguage(%q) = %s, want %s", text, got, want)
+		}
+	}
+
+	// Vowel signs are combining marks and must not make regional text look garbled
+	if quality := services.ScoreExtraction("ಹಿಮೋಗ್ಲೋಬಿನ್ ೧೩.೫ g/dL ಸಾಮಾನ್ಯ", 0); quality.GibberishRatio > 0 {
+		t.Errorf("Expected Kannada text to be readable, got %+v", quality)
+	}
+	if got := services.NormalizeDigits("ಹಿಮೋಗ್ಲೋಬಿನ್ ೧೩.೫, शुगर ९८, ௧௨"); got != "ಹಿಮೋಗ್ಲೋಬಿನ್ 13.5, शुगर 98, 12" {
+		t.Errorf("Expected native digits as 0-9, got %q", got)
+	}
+
+	// Script detection adds the one language pack the page needs
+	dir := t.TempDir()
+	tesseract := writeScript(t, dir, "tesseract", `if [ "$3" = "--psm" ]; then echo "Script: Kannada"; exit 0; fi
+echo "$4" > `+filepath.Join(dir, "languages")+`
+for i in 1 2 3 4 5 6 7 8 9 10 11 12; do echo "ಹಿಮೋಗ್ಲೋಬಿನ್ ೧೩.೫ g/dL ಸಾಮಾನ್ಯ"; done`)
+	photoPath := filepath.Join(dir, "photo.png")
+	os.WriteFile(photoPath, []byte("png"), 0644)
+
+	extractor := services.NewTextExtractor(config.ExtractionConfig{OCRCommand: tesseract, OCRLanguages: "eng", OCRDetectScript: true})
+	extraction, err := extractor.Extract(context.Background(), photoPath)
+	if err != nil || extraction.Language != "kn" {
+		t.Fatalf("Expected a Kannada extraction, got %+v (%v)", extraction, err)
+	}
+	if languages, _ := os.ReadFile(filepath.Join(dir, "languages")); strings.TrimSpace(string(languages)) != "eng+kan" {
+		t.Errorf("Expected recognition with eng+kan, got %q", languages)
+	}
+}
+
+// writeDocx writes a minimal .docx whose body holds the given WordprocessingML
+func writeDocx(t *testing.T, path, body string) {
+	writeDocxParts(t, path, body, nil)
+}
+
+// writeDocxParts writes a minimal .docx with the given body and header or footer parts, by part name
+func writeDocxParts(t *testing.T, path, body string, parts map[string]string) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range parts {
+		f, _ := archive.Create(name)
+		f.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>` +
+			`<w:hdr xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">` + content + `</w:hdr>`))
+	}
+	f, _ := archive.Create("word/document.xml")
+	f.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>` +
+		`<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>` +
+		body + `</w:body></w:document>`))
+	archive.Close()
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to write DOCX: %v", err)
+	}
+}
+
+// writeScript writes an executable shell script standing in for an external tool
+func writeScript(t *testing.T, dir, name, body string) string {
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0755); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	return path
+}
@@ -0,0 +1,90 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// TestTokenRenewalHints tests the expiry headers on authenticated responses and sliding expiration
+func TestTokenRenewalHints(t *testing.T) {
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	userRepo := models.NewUserRepository(db.GetDB())
+	jwtService := services.NewJWTService("renewal-secret", time.Hour)
+	authService := services.NewAuthService(userRepo, services.NewPasswordServiceWithCost(4), jwtService)
+	authMiddleware := middleware.NewAuthMiddleware(authService, nil, nil)
+	user := &models.User{Email: "renewal@example.com", PasswordHash: "hash", FullName: "Renewal", IsActive: true}
+	if err := userRepo.Create(user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	token, _ := jwtService.GenerateToken(user.ID, user.Email)
+	impersonation, _, _ := jwtService.GenerateImpersonationToken(user.ID, user.Email, 99, 10*time.Minute)
+
+	handler := authMiddleware.RequireAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	call := func(token string) http.Header {
+		req := httptest.NewRequest("GET", "/api/auth/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("Expected the token to be accepted, got %d", recorder.Code)
+		}
+		return recorder.Header()
+	}
+	expiresIn := func(header http.Header) int {
+		seconds, err := strconv.Atoi(header.Get(types.TokenExpiresInHeader))
+		if err != nil {
+			t.Fatalf("Expected %s in seconds, got %q", types.TokenExpiresInHeader, header.Get(types.TokenExpiresInHeader))
+		}
+		return seconds
+	}
+
+	// A fresh token is far from the default window, the last fifth of its lifetime
+	header := call(token)
+	if seconds := expiresIn(header); seconds < 3590 || seconds > 3600 || header.Get(types.TokenRefreshSuggestedHeader) != "" {
+		t.Errorf("Expected an hour left and no suggestion, got %v", header)
+	}
+
+	authMiddleware.SetTokenRenewal(2*time.Hour, false)
+	if header := call(token); header.Get(types.TokenRefreshSuggestedHeader) != "true" || header.Get(types.RefreshedTokenHeader) != "" {
+		t.Errorf("Expected a refresh to be suggested inside the window, got %v", header)
+	}
+
+	// Sliding expiration renews the token instead; impersonation tokens are never renewed
+	authMiddleware.SetTokenRenewal(2*time.Hour, true)
+	header = call(token)
+	renewed := header.Get(types.RefreshedTokenHeader)
+	if renewed == "" || header.Get(types.TokenRefreshSuggestedHeader) != "" || expiresIn(header) < 3590 {
+		t.Fatalf("Expected a renewed token, got %v", header)
+	}
+	if claims, err := jwtService.ValidateToken(renewed); err != nil || claims.UserID != user.ID {
+		t.Errorf("Expected the renewed token to be valid for the user, got %v", err)
+	}
+	header = call(impersonation)
+	if header.Get(types.RefreshedTokenHeader) != "" || header.Get(types.TokenRefreshSuggestedHeader) != "" || expiresIn(header) > 600 {
+		t.Errorf("Expected the impersonation token to only report its expiry, got %v", header)
+	}
+
+	cors := middleware.CORS(middleware.DefaultCORSConfig())(handler)
+	recorder := httptest.NewRecorder()
+	cors.ServeHTTP(recorder, httptest.NewRequest("OPTIONS", "/api/auth/me", nil))
+	if exposed := recorder.Header().Get("Access-Control-Expose-Headers"); !strings.Contains(exposed, types.RefreshedTokenHeader) {
+		t.Errorf("Expected the renewal headers exposed to browsers, got %q", exposed)
+	}
+}
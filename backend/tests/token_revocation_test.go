@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/client"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// TestLogoutRevokesToken tests that a signed-out token, and every token renewed from the same sign-in, is refused
+// while other sign-ins of the user keep working
+func TestLogoutRevokesToken(t *testing.T) {
+	server := setupTestServer(t)
+	defer server.Close()
+	ctx := context.Background()
+
+	session := client.New(server.URL, "")
+	if _, err := session.Signup(ctx, types.SignupRequest{Email: "revoke@example.com", Password: "password123", FullName: "Test User"}); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	token := session.Token()
+	login, err := client.New(server.URL, "").Login(ctx, "revoke@example.com", "password123")
+	if err != nil {
+		t.Fatalf("Failed to sign in again: %v", err)
+	}
+	refreshed, err := session.Refresh(ctx)
+	if err != nil {
+		t.Fatalf("Failed to refresh token: %v", err)
+	}
+
+	if err := session.Logout(ctx); err != nil {
+		t.Fatalf("Failed to log out: %v", err)
+	}
+	for name, revoked := range map[string]string{"signed out": refreshed.Token, "renewed from": token} {
+		_, err := client.New(server.URL, revoked).Me(ctx)
+		if apiErr, ok := err.(*client.Error); !ok || apiErr.Status != http.StatusUnauthorized || apiErr.Type != "SESSION_ENDED" {
+			t.Errorf("Expected the %s token refused as signed out, got %v", name, err)
+		}
+	}
+	signedOut := client.New(server.URL, "")
+	signedOut.SetRefreshToken(refreshed.RefreshToken)
+	if _, err := signedOut.Refresh(ctx); !client.IsStatus(err, http.StatusUnauthorized) {
+		t.Errorf("Expected a signed-out refresh token not to be exchanged, got %v", err)
+	}
+	if _, err := client.New(server.URL, login.Token).Me(ctx); err != nil {
+		t.Errorf("Expected the user's other sign-in to keep working: %v", err)
+	}
+
+	// Logging out twice, or with a token that was never valid, is harmless
+	if err := client.New(server.URL, refreshed.Token).Logout(ctx); err != nil {
+		t.Errorf("Expected a second logout to succeed: %v", err)
+	}
+	if err := client.New(server.URL, "not-a-token").Logout(ctx); err != nil {
+		t.Errorf("Expected logout with an invalid token to succeed: %v", err)
+	}
+}
+
+// TestRevocationWithoutTokenID tests that tokens issued before every sign-in had a jti are revoked one by one,
+// and that revocations are pruned once their tokens expire
+func TestRevocationWithoutTokenID(t *testing.T) {
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	user := &models.User{Email: "legacy@example.com", PasswordHash: "hash", FullName: "Legacy", IsActive: true}
+	other := &models.User{Email: "other@example.com", PasswordHash: "hash", FullName: "Other", IsActive: true}
+	for _, u := range []*models.User{user, other} {
+		if err := models.NewUserRepository(db.GetDB()).Create(u); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+	revoked := models.NewRevokedTokenRepository(db.GetDB())
+	jwtService := services.NewJWTService("test-secret", time.Hour)
+	authService := services.NewAuthService(models.NewUserRepository(db.GetDB()), services.NewPasswordServiceWithCost(4), jwtService)
+	authService.SetTokenRevocation(revoked)
+
+	first, _ := jwtService.GenerateToken(user.ID, user.Email)
+	second, _ := jwtService.GenerateToken(other.ID, other.Email)
+	if err := authService.Logout(first); err != nil {
+		t.Fatalf("Failed to log out: %v", err)
+	}
+	if _, _, err := authService.Authenticate(first); err == nil {
+		t.Error("Expected the signed-out token to be refused")
+	}
+	if _, _, err := authService.Authenticate(second); err != nil {
+		t.Errorf("Expected another token to keep working: %v", err)
+	}
+
+	expired := &models.RevokedToken{TokenID: "expired", UserID: user.ID, ExpiresAt: time.Now().Add(-time.Minute)}
+	if err := revoked.Revoke(expired, time.Now().Add(-2*time.Minute)); err != nil {
+		t.Fatalf("Failed to revoke: %v", err)
+	}
+	if err := revoked.Revoke(&models.RevokedToken{TokenID: "current", UserID: user.ID, ExpiresAt: time.Now().Add(time.Hour)}, time.Now()); err != nil {
+		t.Fatalf("Failed to revoke: %v", err)
+	}
+	if isRevoked, _ := revoked.IsRevoked("expired"); isRevoked {
+		t.Error("Expected the expired token's revocation pruned")
+	}
+	if isRevoked, _ := revoked.IsRevoked("current"); !isRevoked {
+		t.Error("Expected the current token's revocation kept")
+	}
+}
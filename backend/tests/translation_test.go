@@ -0,0 +1,170 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+// countingTranslator tags texts with the language code and remembers how many it was sent
+type countingTranslator struct {
+	texts int
+}
+
+func (c *countingTranslator) Translate(ctx context.Context, texts []string, language services.TranslationLanguage) ([]string, error) {
+	c.texts += len(texts)
+	translated := make([]string, len(texts))
+	for i, text := range texts {
+		translated[i] = language.Code + ": " + text
+	}
+	return translated, nil
+}
+
+func (c *countingTranslator) Name() string {
+	return "counting"
+}
+
+// TestTranslateAnalysis tests that stored analyses are translated on request and each piece only once
+func TestTranslateAnalysis(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{
+			Driver: "sqlite3",
+			DSN:    ":memory:",
+		},
+	}
+
+	db, err := database.Setup(cfg)
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	user := &models.User{Email: "translate@example.com", PasswordHash: "hash", FullName: "Translate", IsActive: true}
+	if err := models.NewUserRepository(db.GetDB()).Create(user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	reportRepo := models.NewReportRepository(db.GetDB())
+	reports, err := services.NewDemoService(reportRepo).ProvisionSampleReports(user.ID)
+	if err != nil {
+		t.Fatalf("Failed to provision reports: %v", err)
+	}
+	report, err := reportRepo.GetByID(reports[0].ID)
+	if err != nil {
+		t.Fatalf("Failed to load report: %v", err)
+	}
+
+	translator := &countingTranslator{}
+	translations := services.NewTranslationService(translator, models.NewTranslationCacheRepository(db.GetDB()))
+
+	lang, err := translations.ResolveLanguage("hi-IN")
+	if err != nil || lang != "hi" {
+		t.Fatalf("Expected hi-IN to resolve to hi, got %q (%v)", lang, err)
+	}
+
+	analysis, err := services.ParseStoredAnalysis(report.SimplifiedSummary)
+	if err != nil {
+		t.Fatalf("Failed to parse analysis: %v", err)
+	}
+	original := *analysis
+	original.HealthMetrics = append([]services.HealthMetric(nil), analysis.HealthMetrics...)
+	if err := translations.TranslateAnalysis(context.Background(), analysis, lang); err != nil {
+		t.Fatalf("Failed to translate analysis: %v", err)
+	}
+	if analysis.SimpleSummary != "hi: "+original.SimpleSummary || !strings.HasPrefix(analysis.KeyFindings[0], "hi: ") {
+		t.Errorf("Expected the prose translated, got %q / %q", analysis.SimpleSummary, analysis.KeyFindings[0])
+	}
+	metric := analysis.HealthMetrics[0]
+	if metric.Name != original.HealthMetrics[0].Name || metric.Unit != original.HealthMetrics[0].Unit ||
+		metric.Description != "hi: "+original.HealthMetrics[0].Description {
+		t.Errorf("Expected only the metric description translated, got %+v", metric)
+	}
+	if analysis.GlossaryTerms != nil {
+		t.Errorf("Expected glossary references dropped from the translation, got %+v", analysis.GlossaryTerms)
+	}
+
+	// The same report again is served from the cache, metric descriptions included
+	sent := translator.texts
+	again, _ := services.ParseStoredAnalysis(report.SimplifiedSummary)
+	if err := translations.TranslateAnalysis(context.Background(), again, lang); err != nil {
+		t.Fatalf("Failed to translate analysis again: %v", err)
+	}
+	metrics, _ := services.ParseStoredAnalysis(report.SimplifiedSummary)
+	if err := translations.TranslateMetrics(context.Background(), metrics.HealthMetrics, lang); err != nil {
+		t.Fatalf("Failed to translate metrics: %v", err)
+	}
+	if translator.texts != sent {
+		t.Errorf("Expected cached translations reused, but %d more texts were sent", translator.texts-sent)
+	}
+	if again.SimpleSummary != analysis.SimpleSummary {
+		t.Errorf("Expected the cached translation, got %q", again.SimpleSummary)
+	}
+
+	if _, err := translations.ResolveLanguage("xx"); err == nil {
+		t.Error("Expected an unsupported language to be rejected")
+	}
+	if lang, err := translations.ResolveLanguage(""); err != nil || lang != "en" {
+		t.Errorf("Expected English by default, got %q (%v)", lang, err)
+	}
+	disabled := services.NewTranslationService(nil, nil)
+	if _, err := disabled.ResolveLanguage("ta"); err != errors.ErrTranslationUnavailable {
+		t.Errorf("Expected translation unavailable without a provider, got %v", err)
+	}
+}
+
+// TestGoogleTranslator tests the Cloud Translation request and response mapping
+func TestGoogleTranslator(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Q      []string `json:"q"`
+			Source string   `json:"source"`
+			Target string   `json:"target"`
+			Format string   `json:"format"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || r.URL.Query().Get("key") != "test-key" {
+			t.Errorf("Unexpected request: %v %s", err, r.URL)
+		}
+		if req.Source != "en" || req.Target != "ta" || req.Format != "text" {
+			t.Errorf("Unexpected request %+v", req)
+		}
+
+		translations := make([]map[string]string, len(req.Q))
+		for i, q := range req.Q {
+			translations[i] = map[string]string{"translatedText": "ta " + q}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"translations": translations}})
+	}))
+	defer server.Close()
+
+	translator, err := services.NewTranslator(config.TranslationConfig{Provider: "google", APIKey: "test-key", URL: server.URL}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create translator: %v", err)
+	}
+	translated, err := translator.Translate(context.Background(), []string{"Your LDL is high.", "Eat more fiber."},
+		*services.LookupTranslationLanguage("ta"))
+	if err != nil {
+		t.Fatalf("Failed to translate: %v", err)
+	}
+	if len(translated) != 2 || translated[1] != "ta Eat more fiber." {
+		t.Errorf("Unexpected translation %v", translated)
+	}
+
+	if _, err := services.NewTranslator(config.TranslationConfig{Provider: "google"}, nil); err == nil {
+		t.Error("Expected google without a key to be rejected")
+	}
+	if _, err := services.NewTranslator(config.TranslationConfig{Provider: "llm"}, nil); err == nil {
+		t.Error("Expected llm without an AI provider to be rejected")
+	}
+	if translator, err := services.NewTranslator(config.TranslationConfig{Provider: "none"}, nil); translator != nil || err != nil {
+		t.Errorf("Expected none to disable translation, got %v (%v)", translator, err)
+	}
+}
@@ -0,0 +1,107 @@
+package tests
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+// TestSummaryAudio tests generating, caching, and serving spoken summaries
+func TestSummaryAudio(t *testing.T) {
+	var languages []string
+	ttsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("key") != "tts-key" {
+			http.Error(w, "bad key", http.StatusForbidden)
+			return
+		}
+		var req struct {
+			Input struct {
+				Text string `json:"text"`
+			} `json:"input"`
+			Voice struct {
+				LanguageCode string `json:"languageCode"`
+			} `json:"voice"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		languages = append(languages, req.Voice.LanguageCode)
+		json.NewEncoder(w).Encode(map[string]string{
+			"audioContent": base64.StdEncoding.EncodeToString([]byte("MP3:" + req.Input.Text)),
+		})
+	}))
+	defer ttsServer.Close()
+
+	provider, err := services.NewTTSProvider(config.TTSConfig{Provider: "google", APIKey: "tts-key", URL: ttsServer.URL})
+	if err != nil {
+		t.Fatalf("Failed to create TTS provider: %v", err)
+	}
+	audio := services.NewSummaryAudioService(provider, t.TempDir())
+
+	report := &models.Report{
+		ID:                1,
+		ProcessingStatus:  "completed",
+		SimplifiedSummary: `{"schema_version": 2, "simple_summary": "Your blood sugar is normal."}`,
+	}
+
+	path, err := audio.SummaryAudio(context.Background(), report, "hi-IN")
+	if err != nil {
+		t.Fatalf("Failed to generate summary audio: %v", err)
+	}
+	if content, _ := os.ReadFile(path); string(content) != "MP3:Your blood sugar is normal." {
+		t.Errorf("Unexpected audio content %q", content)
+	}
+
+	// Repeat requests are served from the cache; a new language or summary generates new audio
+	if cached, err := audio.SummaryAudio(context.Background(), report, "hi-IN"); err != nil || cached != path {
+		t.Errorf("Expected cached audio at %s, got %s: %v", path, cached, err)
+	}
+	audio.SummaryAudio(context.Background(), report, "en-US")
+	report.SimplifiedSummary = `{"schema_version": 2, "simple_summary": "Your blood sugar is a little high."}`
+	audio.SummaryAudio(context.Background(), report, "en-US")
+	if fmt.Sprint(languages) != "[hi-IN en-US en-US]" {
+		t.Errorf("Expected three synthesis calls, got %v", languages)
+	}
+
+	report.ProcessingStatus = "pending"
+	if _, err := audio.SummaryAudio(context.Background(), report, "en-US"); err != errors.ErrReportNotProcessed {
+		t.Errorf("Expected not-processed error, got %v", err)
+	}
+	if _, err := services.NewSummaryAudioService(nil, t.TempDir()).SummaryAudio(context.Background(), report, "en-US"); err != errors.ErrTTSUnavailable {
+		t.Errorf("Expected TTS unavailable error, got %v", err)
+	}
+
+	for _, tc := range []struct{ requested, accept, want string }{
+		{"ta-IN", "hi-IN", "ta-IN"},
+		{"", "hi-IN,hi;q=0.9,en;q=0.8", "hi-IN"},
+		{"", "", services.DefaultSpeechLanguage},
+	} {
+		if got, err := services.SpeechLanguage(tc.requested, tc.accept); err != nil || got != tc.want {
+			t.Errorf("SpeechLanguage(%q, %q) = %q, %v; want %q", tc.requested, tc.accept, got, err, tc.want)
+		}
+	}
+	if _, err := services.SpeechLanguage("not a language!", ""); err == nil {
+		t.Error("Expected an error for an invalid language tag")
+	}
+
+	if _, err := services.NewTTSProvider(config.TTSConfig{Provider: "google"}); err == nil {
+		t.Error("Expected an error for google without an API key")
+	}
+
+	// Without a provider the endpoint reports 503
+	server := setupTestServer(t)
+	defer server.Close()
+	token := signupAndGetToken(t, server.URL, "listener@example.com")
+	reportID := uploadTestReport(t, server.URL, token, "glucose.txt", "Glucose 92 mg/dL")
+	if status := doJSONRequest(t, "GET", fmt.Sprintf("%s/api/reports/%d/summary/audio", server.URL, reportID), token, nil, nil); status != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without a TTS provider, got %d", status)
+	}
+}
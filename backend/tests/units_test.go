@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+)
+
+// TestInferUnit tests that units left out by the model are inferred from the analyte and magnitude, and doubtful ones flagged
+func TestInferUnit(t *testing.T) {
+	cases := []struct {
+		name          string
+		value         any
+		unit          string
+		lowConfidence bool
+	}{
+		{"Fasting Blood Glucose", 95.0, "mg/dL", false},
+		{"Fasting Blood Glucose", 5.4, "mmol/L", false},
+		{"Random blood sugar", 40.0, "mg/dL", true}, // Plausible in both units
+		{"Hemoglobin", "13.5", "g/dL", false},
+		{"Haemoglobin (Hb)", 135.0, "g/L", false},
+		{"Platelet Count", 250.0, "×10³/µL", false},
+		{"Serum Creatinine", 88.0, "µmol/L", false},
+		{"Potassium", 90.0, "", true}, // Implausible in every unit
+	}
+	for _, c := range cases {
+		inference := services.InferUnit(c.name, c.value)
+		if inference == nil {
+			t.Errorf("%s %v: expected an inference", c.name, c.value)
+			continue
+		}
+		if inference.Unit != c.unit || inference.LowConfidence != c.lowConfidence || inference.Note == "" {
+			t.Errorf("%s %v: expected %q (low confidence %v), got %+v", c.name, c.value, c.unit, c.lowConfidence, inference)
+		}
+	}
+
+	// Unknown analytes, qualitative results, and excluded names are left alone
+	for _, c := range []struct {
+		name  string
+		value any
+	}{{"Widget index", 5.0}, {"Urine colour", "Pale yellow"}, {"Vitamin K", 1.2}, {"ANA titre", "1:80"}} {
+		if inference := services.InferUnit(c.name, c.value); inference != nil {
+			t.Errorf("%s: expected no inference, got %+v", c.name, inference)
+		}
+	}
+
+	metrics := []services.HealthMetric{
+		{Name: "Glucose", Value: 95.0, Unit: "mmol/L"},
+		{Name: "HbA1c", Value: 6.1},
+	}
+	services.InferMissingUnits(metrics)
+	if metrics[0].Unit != "mmol/L" || metrics[0].UnitInferred {
+		t.Errorf("Expected a written unit to be kept, got %+v", metrics[0])
+	}
+	if metrics[1].Unit != "%" || !metrics[1].UnitInferred || metrics[1].LowConfidence {
+		t.Errorf("Expected HbA1c in percent, got %+v", metrics[1])
+	}
+
+	// Analyses stored before inference get units when read
+	stored := `{"schema_version": 4, "summary": "ok", "health_metrics": [{"name": "Glucose", "value": 5.4, "score": 90, "status": "normal"}]}`
+	analysis, err := services.ParseStoredAnalysis(stored)
+	if err != nil {
+		t.Fatalf("Failed to parse stored analysis: %v", err)
+	}
+	if metric := analysis.HealthMetrics[0]; metric.Unit != "mmol/L" || !metric.UnitInferred || metric.UnitNote == "" {
+		t.Errorf("Expected the upgrade to infer mmol/L, got %+v", metric)
+	}
+}
@@ -0,0 +1,162 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/handlers"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// failingUsageRepo refuses every write until it is told to recover
+type failingUsageRepo struct {
+	models.APIUsageRepository
+	down bool
+}
+
+func (f *failingUsageRepo) Record(entries []*models.APIUsage) error {
+	if f.down {
+		return fmt.Errorf("database is locked")
+	}
+	return f.APIUsageRepository.Record(entries)
+}
+
+// TestDeprecationHeaders tests that deprecated routes announce their sunset and successor while still working
+func TestDeprecationHeaders(t *testing.T) {
+	server := setupTestServer(t)
+	defer server.Close()
+	token := signupAndGetToken(t, server.URL, "deprecated@example.com")
+
+	get := func(path string) *http.Response {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to call %s: %v", path, err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	resp := get("/api/reports/history")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected the deprecated route to keep working, got %d", resp.StatusCode)
+	}
+	if !strings.HasPrefix(resp.Header.Get("Deprecation"), "@") || resp.Header.Get("Sunset") == "" ||
+		resp.Header.Get("Link") != `</api/reports>; rel="successor-version"` {
+		t.Errorf("Expected deprecation headers, got %v", resp.Header)
+	}
+	if sunset, err := http.ParseTime(resp.Header.Get("Sunset")); err != nil || !sunset.Equal(middleware.DeprecatedRoutes[0].Sunset) {
+		t.Errorf("Expected the sunset as an HTTP date, got %q", resp.Header.Get("Sunset"))
+	}
+
+	if resp := get("/api/reports"); resp.Header.Get("Deprecation") != "" || resp.Header.Get("Sunset") != "" {
+		t.Errorf("Expected no deprecation headers on the successor, got %v", resp.Header)
+	}
+}
+
+// TestAPIUsageTracking tests per-route, per-caller counting and the admin report of deprecated route callers
+func TestAPIUsageTracking(t *testing.T) {
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	userRepo := models.NewUserRepository(db.GetDB())
+	jwtService := services.NewJWTService("usage-secret", time.Hour)
+	authMiddleware := middleware.NewAuthMiddleware(services.NewAuthService(userRepo, services.NewPasswordServiceWithCost(4), jwtService), nil, nil)
+	caller := &models.User{Email: "legacy-client@example.com", PasswordHash: "hash", FullName: "Legacy", IsActive: true}
+	if err := userRepo.Create(caller); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	token, _ := jwtService.GenerateToken(caller.ID, caller.Email)
+
+	usageRepo := &failingUsageRepo{APIUsageRepository: models.NewAPIUsageRepository(db.GetDB())}
+	tracker := middleware.NewUsageTracker(usageRepo, time.Hour)
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+
+	r := mux.NewRouter()
+	api := r.PathPrefix("/api").Subrouter()
+	api.Use(tracker.Track)
+	api.HandleFunc("/ping", ok).Methods("GET", "OPTIONS")
+	reports := api.PathPrefix("/reports").Subrouter()
+	reports.Use(authMiddleware.RequireAuth)
+	reports.HandleFunc("/history", ok).Methods("GET", "OPTIONS")
+	reports.HandleFunc("/{id:[0-9]+}", ok).Methods("GET", "OPTIONS")
+
+	call := func(method, path string, auth bool) {
+		req := httptest.NewRequest(method, path, nil)
+		if auth {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	call("GET", "/api/reports/1", true)
+	call("GET", "/api/reports/2", true)
+	call("GET", "/api/reports/history", true)
+	call("OPTIONS", "/api/reports/history", false)
+	call("GET", "/api/ping", false)
+	call("GET", "/api/missing", false)
+
+	// A failed flush keeps its counts for the next one
+	usageRepo.down = true
+	if err := tracker.Flush(); err == nil {
+		t.Fatal("Expected the flush to fail")
+	}
+	usageRepo.down = false
+	call("GET", "/api/reports/history", true)
+	if err := tracker.Flush(); err != nil {
+		t.Fatalf("Failed to flush usage: %v", err)
+	}
+	call("GET", "/api/reports/history", true)
+	if err := tracker.Flush(); err != nil {
+		t.Fatalf("Failed to flush usage: %v", err)
+	}
+
+	summaries, err := usageRepo.Summarize(models.APIUsageFilter{})
+	if err != nil {
+		t.Fatalf("Failed to summarize usage: %v", err)
+	}
+	counts := map[string]int{}
+	for _, s := range summaries {
+		counts[fmt.Sprintf("%s %s %d", s.Method, s.Route, s.UserID)] = s.Calls
+	}
+	expected := map[string]int{
+		fmt.Sprintf("GET /api/reports/history %d", caller.ID):     3,
+		fmt.Sprintf("GET /api/reports/{id:[0-9]+} %d", caller.ID): 2,
+		"GET /api/ping 0": 1,
+	}
+	if fmt.Sprint(counts) != fmt.Sprint(expected) {
+		t.Errorf("Expected counts %v, got %v", expected, counts)
+	}
+
+	adminHandler := handlers.NewAdminHandler(nil, nil, usageRepo, nil, nil, nil, nil, nil)
+	rec := httptest.NewRecorder()
+	adminHandler.GetAPIUsageHandler(rec, httptest.NewRequest("GET", "/api/admin/usage?deprecated=true", nil))
+	var entries []types.APIUsageEntry
+	if err := decodeEnvelope(rec.Body, &entries); err != nil {
+		t.Fatalf("Failed to decode usage report: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Email != caller.Email || entries[0].Calls != 3 || !entries[0].Deprecated ||
+		entries[0].Sunset == nil || entries[0].Successor != "/api/reports" || entries[0].LastCalledAt.IsZero() {
+		t.Errorf("Expected the legacy client as the only caller of deprecated routes, got %+v", entries)
+	}
+
+	rec = httptest.NewRecorder()
+	adminHandler.GetAPIUsageHandler(rec, httptest.NewRequest("GET", "/api/admin/usage?days=0", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected days=0 to be rejected, got %d", rec.Code)
+	}
+}
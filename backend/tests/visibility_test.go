@@ -0,0 +1,153 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/handlers"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/middleware"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// TestReportVisibility tests that clinicians of the patient's organization read only the reports its visibility shares with them
+func TestReportVisibility(t *testing.T) {
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	userRepo := models.NewUserRepository(db.GetDB())
+	newUser := func(email string) *models.User {
+		user := &models.User{Email: email, PasswordHash: "hash", FullName: email, IsActive: true}
+		if err := userRepo.Create(user); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		return user
+	}
+	patient, doctor, otherDoctor, nurse := newUser("patient@clinic.example"), newUser("doctor@clinic.example"),
+		newUser("locum@clinic.example"), newUser("nurse@clinic.example")
+	outsider, admin := newUser("doctor@elsewhere.example"), newUser("admin@clinic.example")
+
+	orgRepo := models.NewOrganizationRepository(db.GetDB())
+	branding := services.NewBrandingService(orgRepo, userRepo)
+	clinic, _ := branding.CreateOrganization("Lakeside Clinic")
+	elsewhere, _ := branding.CreateOrganization("Hillside Hospital")
+	for _, member := range []struct {
+		org         int
+		email, role string
+	}{
+		{clinic.ID, doctor.Email, models.OrgRoleDoctor}, {clinic.ID, otherDoctor.Email, models.OrgRoleDoctor},
+		{clinic.ID, nurse.Email, models.OrgRoleCareTeam}, {clinic.ID, admin.Email, models.OrgRoleAdmin},
+		{elsewhere.ID, outsider.Email, models.OrgRoleDoctor},
+	} {
+		if err := branding.AddMember(member.org, member.email, member.role, ""); err != nil {
+			t.Fatalf("Failed to add %s: %v", member.email, err)
+		}
+	}
+	// Decision: The assigned doctor has to be a doctor of the patient's own organization
+	if err := branding.AddMember(clinic.ID, patient.Email, "", nurse.Email); err == nil {
+		t.Fatal("Expected a care team member to be refused as the assigned doctor")
+	}
+	if err := branding.AddMember(clinic.ID, patient.Email, "", outsider.Email); err == nil {
+		t.Fatal("Expected a doctor of another organization to be refused as the assigned doctor")
+	}
+	if err := branding.AddMember(clinic.ID, patient.Email, "", doctor.Email); err != nil {
+		t.Fatalf("Failed to add patient: %v", err)
+	}
+
+	reportRepo := models.NewReportRepository(db.GetDB())
+	report := &models.Report{UserID: patient.ID, OriginalFilename: "cbc.pdf", FilePath: "cbc.pdf", FileType: "pdf", FileSize: 1}
+	if err := reportRepo.Create(report); err != nil {
+		t.Fatalf("Failed to create report: %v", err)
+	}
+	if report.Visibility != models.ReportVisibilityPrivate {
+		t.Fatalf("Expected new reports to be private, got %q", report.Visibility)
+	}
+
+	reportHandler := handlers.NewReportHandler(reportRepo, nil, nil, nil, services.NewFileStorage(t.TempDir(), "test-secret"), 20971520, false)
+	reportHandler.SetReportAccess(services.NewReportAccessService(orgRepo, reportRepo))
+	call := func(handler http.HandlerFunc, user *models.User, body any) (int, types.Report) {
+		payload, _ := json.Marshal(body)
+		req := httptest.NewRequest("GET", "/api/reports/"+strconv.Itoa(report.ID), bytes.NewReader(payload))
+		req = mux.SetURLVars(req.WithContext(context.WithValue(req.Context(), middleware.UserKey, user)),
+			map[string]string{"id": strconv.Itoa(report.ID)})
+		recorder := httptest.NewRecorder()
+		handler(recorder, req)
+		var response types.Report
+		decodeEnvelope(recorder.Body, &response)
+		return recorder.Code, response
+	}
+	setVisibility := func(visibility string) {
+		t.Helper()
+		status, updated := call(reportHandler.UpdateVisibilityHandler, patient, types.UpdateVisibilityRequest{Visibility: visibility})
+		if status != http.StatusOK || updated.Visibility != visibility {
+			t.Fatalf("Expected visibility %s, got %d %+v", visibility, status, updated)
+		}
+	}
+	expectReaders := func(readers ...*models.User) {
+		t.Helper()
+		allowed := map[int]bool{patient.ID: true}
+		for _, reader := range readers {
+			allowed[reader.ID] = true
+		}
+		for _, user := range []*models.User{patient, doctor, otherDoctor, nurse, outsider, admin} {
+			want := http.StatusForbidden
+			if allowed[user.ID] {
+				want = http.StatusOK
+			}
+			if status, _ := call(reportHandler.GetReportHandler, user, nil); status != want {
+				t.Errorf("Expected %s to get %d, got %d", user.Email, want, status)
+			}
+		}
+	}
+
+	expectReaders()
+	setVisibility(models.ReportVisibilityDoctor)
+	expectReaders(doctor)
+	setVisibility(models.ReportVisibilityCareTeam)
+	expectReaders(doctor, otherDoctor, nurse)
+
+	// Only the owner changes visibility, and only to a known value
+	if status, _ := call(reportHandler.UpdateVisibilityHandler, doctor, types.UpdateVisibilityRequest{Visibility: "private"}); status != http.StatusForbidden {
+		t.Errorf("Expected 403 when a clinician changes visibility, got %d", status)
+	}
+	if status, _ := call(reportHandler.UpdateVisibilityHandler, patient, types.UpdateVisibilityRequest{Visibility: "public"}); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown visibility, got %d", status)
+	}
+
+	// A patient who moves to another organization stops sharing with the old one, and loses their doctor
+	if err := branding.AddMember(elsewhere.ID, patient.Email, "", ""); err != nil {
+		t.Fatalf("Failed to move patient: %v", err)
+	}
+	expectReaders(outsider)
+	if membership, _ := orgRepo.GetMembership(patient.ID); membership.AssignedDoctorID != nil {
+		t.Errorf("Expected the assigned doctor to be cleared after the move, got %d", *membership.AssignedDoctorID)
+	}
+
+	// Patients outside any organization can only keep reports private
+	loner := newUser("loner@example.com")
+	access := services.NewReportAccessService(orgRepo, reportRepo)
+	own := &models.Report{UserID: loner.ID, OriginalFilename: "lipid.pdf", FilePath: "lipid.pdf", FileType: "pdf", FileSize: 1}
+	if err := reportRepo.Create(own); err != nil {
+		t.Fatalf("Failed to create report: %v", err)
+	}
+	if _, err := access.SetVisibility(loner.ID, own.ID, models.ReportVisibilityCareTeam); err != errors.ErrNotOrganizationMember {
+		t.Errorf("Expected sharing outside an organization to be refused, got %v", err)
+	}
+	if _, err := access.SetVisibility(loner.ID, own.ID, models.ReportVisibilityPrivate); err != nil {
+		t.Errorf("Expected private to be allowed outside an organization, got %v", err)
+	}
+}
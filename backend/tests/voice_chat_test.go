@@ -0,0 +1,129 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/errors"
+)
+
+// webmHeader is the EBML magic number every webm recording starts with
+var webmHeader = []byte("\x1A\x45\xDF\xA3\x9F\x42\x86\x81\x01")
+
+// TestVoiceChat tests transcribing a recorded question through a Whisper-compatible API and answering it
+func TestVoiceChat(t *testing.T) {
+	if audioType, ok := services.DetectAudioType(webmHeader, "video/webm"); !ok || audioType != "audio/webm" {
+		t.Errorf("Expected webm recording to be detected as audio/webm, got %q", audioType)
+	}
+	if audioType, ok := services.DetectAudioType([]byte("RIFF\x24\x00\x00\x00WAVEfmt "), ""); !ok || audioType != "audio/wav" {
+		t.Errorf("Expected wav recording to be detected as audio/wav, got %q", audioType)
+	}
+	if _, ok := services.DetectAudioType([]byte("%PDF-1.4"), "audio/webm"); ok {
+		t.Error("Expected a PDF labelled as audio to be rejected")
+	}
+
+	var calls atomic.Int32
+	transcript := "What does my   hemoglobin level mean?"
+	whisper := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			t.Errorf("Expected bearer key, got %q", r.Header.Get("Authorization"))
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			t.Errorf("Expected multipart file field: %v", err)
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		audio, _ := io.ReadAll(file)
+		if header.Filename != "question.webm" || string(audio) != string(webmHeader) {
+			t.Errorf("Unexpected upload %q of %d bytes", header.Filename, len(audio))
+		}
+		if r.FormValue("model") != "whisper-1" || r.FormValue("language") != "hi" {
+			t.Errorf("Unexpected model %q or language %q", r.FormValue("model"), r.FormValue("language"))
+		}
+		json.NewEncoder(w).Encode(map[string]string{"text": transcript})
+	}))
+	defer whisper.Close()
+
+	transcriber, err := services.NewTranscriber(config.TranscriptionConfig{Provider: "whisper", APIKey: "test-key", URL: whisper.URL})
+	if err != nil {
+		t.Fatalf("Failed to create transcriber: %v", err)
+	}
+
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	userRepo := models.NewUserRepository(db.GetDB())
+	owner := &models.User{Email: "voice@example.com", PasswordHash: "hash", FullName: "Owner", IsActive: true}
+	other := &models.User{Email: "eavesdrop@example.com", PasswordHash: "hash", FullName: "Other", IsActive: true}
+	for _, user := range []*models.User{owner, other} {
+		if err := userRepo.Create(user); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+
+	reportRepo := models.NewReportRepository(db.GetDB())
+	reports, err := services.NewDemoService(reportRepo).ProvisionSampleReports(owner.ID)
+	if err != nil {
+		t.Fatalf("Failed to provision reports: %v", err)
+	}
+	reportID := reports[0].ID
+
+	chatRepo := models.NewChatMessageRepository(db.GetDB())
+	summaryRepo := models.NewChatSummaryRepository(db.GetDB())
+	voice := services.VoiceQuestion{Audio: webmHeader, AudioType: "audio/webm", Language: "hi-IN"}
+	ctx := context.Background()
+
+	noSpeech := services.NewChatService(chatRepo, summaryRepo, reportRepo, nil, services.NewDemoAnalyzer(), nil, config.AIConfig{})
+	if _, err := noSpeech.AskByVoice(ctx, owner.ID, reportID, voice, models.ReadingLevelStandard, models.PlanFree); err != errors.ErrTranscriptionUnavailable {
+		t.Errorf("Expected transcription unavailable without a provider, got %v", err)
+	}
+
+	chatService := services.NewChatService(chatRepo, summaryRepo, reportRepo, nil, services.NewDemoAnalyzer(), transcriber, config.AIConfig{})
+
+	// Decision: Someone else's report is rejected before any audio leaves the server
+	if _, err := chatService.AskByVoice(ctx, other.ID, reportID, voice, models.ReadingLevelStandard, models.PlanFree); err != errors.ErrAccessDenied {
+		t.Errorf("Expected access denied for another user's report, got %v", err)
+	}
+	if calls.Load() != 0 {
+		t.Errorf("Expected no transcription call for a rejected question, got %d", calls.Load())
+	}
+
+	message, err := chatService.AskByVoice(ctx, owner.ID, reportID, voice, models.ReadingLevelStandard, models.PlanFree)
+	if err != nil {
+		t.Fatalf("Failed to ask by voice: %v", err)
+	}
+	if message.UserMessage != "What does my hemoglobin level mean?" || message.AIResponse == "" {
+		t.Errorf("Expected normalized transcription and an answer, got %q / %q", message.UserMessage, message.AIResponse)
+	}
+	if message.InputMode != models.ChatInputVoice {
+		t.Errorf("Expected input mode voice, got %q", message.InputMode)
+	}
+
+	history, err := chatRepo.GetChatHistory(reportID)
+	if err != nil || len(history) != 1 {
+		t.Fatalf("Expected one stored message, got %d (%v)", len(history), err)
+	}
+	if history[0].UserMessage != message.UserMessage || history[0].InputMode != models.ChatInputVoice {
+		t.Errorf("Expected stored transcription with voice mode, got %q (%s)", history[0].UserMessage, history[0].InputMode)
+	}
+
+	transcript = "   "
+	if _, err := chatService.AskByVoice(ctx, owner.ID, reportID, voice, models.ReadingLevelStandard, models.PlanFree); err != errors.ErrTranscriptionEmpty {
+		t.Errorf("Expected empty transcription error for silence, got %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/config"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/database"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/models"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/internal/services"
+	"github.com/prateekkhenedcodes/BMSCE-Hackathon/backend/pkg/types"
+)
+
+// TestWidgetTokens tests scoped widget tokens and that they never work as session tokens
+func TestWidgetTokens(t *testing.T) {
+	db, err := database.Setup(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+	createAllTestTables(t, db)
+
+	userRepo := models.NewUserRepository(db.GetDB())
+	owner := &models.User{Email: "owner@example.com", PasswordHash: "hash", FullName: "Owner", IsActive: true}
+	other := &models.User{Email: "other@example.com", PasswordHash: "hash", FullName: "Other", IsActive: true}
+	for _, user := range []*models.User{owner, other} {
+		if err := userRepo.Create(user); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+	reportRepo := models.NewReportRepository(db.GetDB())
+	reports, err := services.NewDemoService(reportRepo).ProvisionSampleReports(owner.ID)
+	if err != nil {
+		t.Fatalf("Failed to provision reports: %v", err)
+	}
+	thyroid := reports[2]
+
+	// An earlier and a later TSH reading; only the earlier one belongs in the thyroid report's trend
+	for _, year := range []int{2020, 2099} {
+		report := &models.Report{UserID: owner.ID, OriginalFilename: "tsh.txt", FilePath: "tsh.txt", FileType: "txt", FileSize: 10}
+		if err := reportRepo.Create(report); err != nil {
+			t.Fatalf("Failed to create report: %v", err)
+		}
+		reportRepo.UpdateProcessingStatus(report.ID, "completed", `{"schema_version": 3, "health_metrics":
+			[{"name": "TSH", "value": 5.6, "unit": "mIU/L", "status": "warning"}]}`)
+		reportDate := time.Date(year, 5, 1, 0, 0, 0, 0, time.UTC)
+		reportRepo.UpdateDetails(report.ID, models.ReportDetails{ReportDate: &reportDate})
+	}
+
+	const secret = "widget-test-secret"
+	widgets := services.NewWidgetService(secret, reportRepo, userRepo, config.WidgetConfig{DefaultTTL: 15 * time.Minute, MaxTTL: time.Hour})
+
+	for name, attempt := range map[string]func() error{
+		"another's report": func() error { _, _, err := widgets.IssueToken(other.ID, thyroid.ID, nil, 0); return err },
+		"unknown scope":    func() error { _, _, err := widgets.IssueToken(owner.ID, thyroid.ID, []string{"chat"}, 0); return err },
+		"too long":         func() error { _, _, err := widgets.IssueToken(owner.ID, thyroid.ID, nil, 2*time.Hour); return err },
+	} {
+		if attempt() == nil {
+			t.Errorf("Expected a token for %s to be refused", name)
+		}
+	}
+
+	token, claims, err := widgets.IssueToken(owner.ID, thyroid.ID, nil, 0)
+	if err != nil {
+		t.Fatalf("Failed to issue widget token: %v", err)
+	}
+	if fmt.Sprint(claims.Scopes) != "[metrics]" || time.Until(claims.ExpiresAt.Time) > 15*time.Minute {
+		t.Errorf("Expected the default scope and lifetime, got %v until %v", claims.Scopes, claims.ExpiresAt)
+	}
+	view, err := widgets.Open(token)
+	if err != nil {
+		t.Fatalf("Failed to open widget: %v", err)
+	}
+	if view.ReportID != thyroid.ID || len(view.Metrics) != 2 || view.Trends != nil {
+		t.Errorf("Expected the thyroid metrics without trends, got %+v", view)
+	}
+
+	// A widget token is not a session token, and a session token is not a widget token
+	if _, err := services.NewJWTService(secret, time.Hour).ValidateToken(token); err == nil {
+		t.Error("Expected a widget token to fail session validation")
+	}
+	session, _ := services.NewJWTService(secret, time.Hour).GenerateToken(owner.ID, owner.Email)
+	if _, err := widgets.Open(session); err == nil {
+		t.Error("Expected a session token to be refused as a widget token")
+	}
+
+	token, _, err = widgets.IssueToken(owner.ID, thyroid.ID, []string{"trends", "metrics", "trends"}, 5*time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to issue trends token: %v", err)
+	}
+	view, err = widgets.Open(token)
+	if err != nil {
+		t.Fatalf("Failed to open widget with trends: %v", err)
+	}
+	if len(view.Trends) != 2 || view.Trends[0].Name != "TSH" || len(view.Trends[0].Readings) != 2 ||
+		view.Trends[0].Readings[0].Value != "5.6" || view.Trends[0].Readings[1].Value != "2.1" {
+		t.Errorf("Expected TSH from 2020 and the report itself, nothing later, got %+v", view.Trends)
+	}
+
+	// Deleting the report ends the token
+	if err := reportRepo.Delete(thyroid.ID); err != nil {
+		t.Fatalf("Failed to delete report: %v", err)
+	}
+	if _, err := widgets.Open(token); err == nil {
+		t.Error("Expected the token of a deleted report to stop working")
+	}
+
+	// HTTP: tokens need a processed report, and the data endpoint takes no session tokens
+	server := setupTestServer(t)
+	defer server.Close()
+	sessionToken := signupAndGetToken(t, server.URL, "widget@example.com")
+	pending := uploadTestReport(t, server.URL, sessionToken, "cbc.txt", "Hemoglobin 13.5 g/dL")
+
+	status := doJSONRequest(t, "POST", server.URL+"/api/widgets/token", sessionToken, types.WidgetTokenRequest{ReportID: pending}, nil)
+	if status != http.StatusBadRequest {
+		t.Errorf("Expected a pending report to be refused, got %d", status)
+	}
+	if status := doJSONRequest(t, "GET", server.URL+"/api/widgets/report", "", nil, nil); status != http.StatusUnauthorized {
+		t.Errorf("Expected a missing widget token to be unauthorized, got %d", status)
+	}
+	if status := doJSONRequest(t, "GET", server.URL+"/api/widgets/report", sessionToken, nil, nil); status != http.StatusUnauthorized {
+		t.Errorf("Expected a session token to be refused by the widget endpoint, got %d", status)
+	}
+}